@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// AccessibilityRepository interface for patient accessibility storage
+type AccessibilityRepository interface {
+	Upsert(a *database.PatientAccessibility) error
+	GetByPatient(hn string) (*database.PatientAccessibility, error)
+	CountByNeed() (map[string]int, error)
+}
+
+// AccessibilityHandler handles patient accessibility accommodation requests
+type AccessibilityHandler struct {
+	repo     AccessibilityRepository
+	patients PatientRepository
+}
+
+// NewAccessibilityHandler creates a new accessibility handler
+func NewAccessibilityHandler(repo AccessibilityRepository, patients PatientRepository) *AccessibilityHandler {
+	return &AccessibilityHandler{repo: repo, patients: patients}
+}
+
+// NeedFrequency is one row of the accommodation frequency report
+type NeedFrequency struct {
+	Need     string  `json:"need"`
+	Patients int     `json:"patients"`
+	Percent  float64 `json:"percent"` // of all registered patients
+}
+
+// GetAccessibility returns a patient's recorded accommodations
+func (h *AccessibilityHandler) GetAccessibility(w http.ResponseWriter, r *http.Request) {
+	record, err := h.repo.GetByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve accessibility record")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
+
+// UpdateAccessibility records a patient's accommodations; an empty needs list clears them
+func (h *AccessibilityHandler) UpdateAccessibility(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var record database.PatientAccessibility
+	if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	known := make(map[string]bool)
+	for _, n := range database.AccessibilityNeeds {
+		known[n] = true
+	}
+	seen := make(map[string]bool)
+	needs := []string{}
+	for _, n := range record.Needs {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if !known[n] {
+			http.Error(w, "Unknown accommodation "+n+"; expected one of "+strings.Join(database.AccessibilityNeeds, ", "), http.StatusBadRequest)
+			return
+		}
+		if !seen[n] {
+			seen[n] = true
+			needs = append(needs, n)
+		}
+	}
+
+	record.PatientHN = hn
+	record.Needs = needs
+	if err := h.repo.Upsert(&record); err != nil {
+		writeError(w, err, "Failed to save accessibility record")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
+
+// GetAlerts returns accommodation alerts for the patients arriving or waiting (?hn=HN000001,HN000002).
+// Patients without recorded needs are omitted.
+func (h *AccessibilityHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts := []database.AccommodationAlert{}
+	for _, hn := range strings.Split(r.URL.Query().Get("hn"), ",") {
+		hn = strings.TrimSpace(hn)
+		if hn == "" {
+			continue
+		}
+		if alert := accommodationFor(h.repo, hn); alert != nil {
+			alerts = append(alerts, *alert)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+// GetReport returns how often each accommodation is needed across registered patients
+func (h *AccessibilityHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.repo.CountByNeed()
+	if err != nil {
+		writeError(w, err, "Failed to count accommodations")
+		return
+	}
+	patients, err := h.patients.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to count patients")
+		return
+	}
+
+	report := []NeedFrequency{}
+	for need, n := range counts {
+		row := NeedFrequency{Need: need, Patients: n}
+		if len(patients) > 0 {
+			row.Percent = float64(n) / float64(len(patients)) * 100
+		}
+		report = append(report, row)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Patients != report[j].Patients {
+			return report[i].Patients > report[j].Patients
+		}
+		return report[i].Need < report[j].Need
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"totalPatients": len(patients),
+		"needs":         report,
+	})
+}
+
+// AccommodationLookup reads patients' recorded accessibility needs
+type AccommodationLookup interface {
+	GetByPatient(hn string) (*database.PatientAccessibility, error)
+}
+
+// accommodationFor is the staff-facing alert for one patient, or nil if they
+// have no needs recorded. It only adds to what staff see, so lookup failures
+// are logged rather than failing the request.
+func accommodationFor(accommodations AccommodationLookup, hn string) *database.AccommodationAlert {
+	record, err := accommodations.GetByPatient(hn)
+	if err != nil {
+		if !apperr.Is(err, apperr.KindNotFound) {
+			log.Printf("Failed to look up the accessibility needs of %s: %v", hn, err)
+		}
+		return nil
+	}
+	return record.Alert()
+}
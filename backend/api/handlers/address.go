@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"clinic/backend/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// AddressRepository interface for patients' address storage
+type AddressRepository interface {
+	Create(a *database.Address) error
+	GetByID(id int) (*database.Address, error)
+	GetByPatient(hn string) ([]database.Address, error)
+	Update(a *database.Address) error
+	Delete(id int) error
+}
+
+// AddressHandler handles the structured addresses on patient records
+type AddressHandler struct {
+	repo     AddressRepository
+	patients PatientRepository
+}
+
+// NewAddressHandler creates a new address handler
+func NewAddressHandler(repo AddressRepository, patients PatientRepository) *AddressHandler {
+	return &AddressHandler{repo: repo, patients: patients}
+}
+
+// CreateAddress adds an address to a patient; their first address, or one
+// sent with primary set, becomes the primary address
+func (h *AddressHandler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var address database.Address
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	address.PatientHN = hn
+	if msg := checkAddress(&address); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Create(&address); err != nil {
+		writeError(w, err, "Failed to create address")
+		return
+	}
+
+	address.Line = address.FormatLine()
+	writeJSON(w, http.StatusCreated, address)
+}
+
+// GetPatientAddresses lists a patient's addresses, the primary one first
+func (h *AddressHandler) GetPatientAddresses(w http.ResponseWriter, r *http.Request) {
+	addresses, err := h.repo.GetByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve addresses")
+		return
+	}
+
+	for i := range addresses {
+		addresses[i].Line = addresses[i].FormatLine()
+	}
+	writeJSON(w, http.StatusOK, addresses)
+}
+
+// GetAddress returns one address
+func (h *AddressHandler) GetAddress(w http.ResponseWriter, r *http.Request) {
+	address, ok := h.loadAddress(w, r)
+	if !ok {
+		return
+	}
+
+	address.Line = address.FormatLine()
+	writeJSON(w, http.StatusOK, address)
+}
+
+// UpdateAddress replaces an address's details. Setting primary demotes the
+// patient's previous primary address; the primary address itself stays
+// primary until another one takes its place.
+func (h *AddressHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.loadAddress(w, r)
+	if !ok {
+		return
+	}
+
+	var address database.Address
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	address.ID = existing.ID
+	address.PatientHN = existing.PatientHN
+	if existing.Primary && !address.Primary {
+		http.Error(w, "a patient's primary address can only change by making another address primary", http.StatusConflict)
+		return
+	}
+	if msg := checkAddress(&address); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Update(&address); err != nil {
+		writeError(w, err, "Failed to update address")
+		return
+	}
+
+	address.Line = address.FormatLine()
+	writeJSON(w, http.StatusOK, address)
+}
+
+// DeleteAddress removes an address; when it was the primary one the
+// patient's oldest remaining address takes over
+func (h *AddressHandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		writeError(w, err, "Failed to delete address")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkAddress trims and validates an address, returning what is wrong with
+// it; optional parts left blank are dropped
+func checkAddress(a *database.Address) string {
+	a.Kind = strings.ToLower(strings.TrimSpace(a.Kind))
+	if a.Kind == "" {
+		a.Kind = "home"
+	}
+	a.HouseNo = strings.TrimSpace(a.HouseNo)
+	a.Tambon = strings.TrimSpace(a.Tambon)
+	a.Amphoe = strings.TrimSpace(a.Amphoe)
+	a.Province = strings.TrimSpace(a.Province)
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+	for _, part := range []**string{&a.Moo, &a.Village, &a.Soi, &a.Road, &a.Notes} {
+		if *part != nil {
+			*part = optionalText(**part)
+		}
+	}
+	switch {
+	case !oneOf(a.Kind, database.AddressKinds):
+		return "kind must be one of " + strings.Join(database.AddressKinds, ", ")
+	case a.HouseNo == "":
+		return "houseNo is required"
+	case a.Tambon == "":
+		return "tambon is required"
+	case a.Amphoe == "":
+		return "amphoe is required"
+	case a.Province == "":
+		return "province is required"
+	case !isPostalCode(a.PostalCode):
+		return "postalCode must be 5 digits, e.g. 10540"
+	}
+	return ""
+}
+
+// isPostalCode reports whether s is a Thai postal code: five digits, the
+// first two naming the province and never 00
+func isPostalCode(s string) bool {
+	if len(s) != 5 || strings.HasPrefix(s, "00") {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *AddressHandler) loadAddress(w http.ResponseWriter, r *http.Request) (*database.Address, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	address, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve address")
+		return nil, false
+	}
+	return address, true
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AdminGate recognises administrators by a shared bearer token until staff
+// accounts exist. With an empty token nobody is an administrator.
+type AdminGate struct {
+	token string
+}
+
+// NewAdminGate creates an admin gate for the given token
+func NewAdminGate(token string) *AdminGate {
+	return &AdminGate{token: token}
+}
+
+// IsAdmin reports whether the request carries the admin token (Authorization: Bearer <token>)
+func (g *AdminGate) IsAdmin(r *http.Request) bool {
+	if g == nil || g.token == "" {
+		return false
+	}
+	presented := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(g.token)) == 1
+}
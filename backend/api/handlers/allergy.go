@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// AllergyRepository interface for patient allergy storage
+type AllergyRepository interface {
+	Create(a *database.Allergy) error
+	GetByID(id int) (*database.Allergy, error)
+	GetByPatient(hn string, activeOnly bool) ([]database.Allergy, error)
+	Update(a *database.Allergy) error
+	Delete(id int) error
+}
+
+// AllergyHandler handles the allergy registry on patient records
+type AllergyHandler struct {
+	repo     AllergyRepository
+	patients PatientRepository
+}
+
+// NewAllergyHandler creates a new allergy handler
+func NewAllergyHandler(repo AllergyRepository, patients PatientRepository) *AllergyHandler {
+	return &AllergyHandler{repo: repo, patients: patients}
+}
+
+// CreateAllergy records something a patient is allergic to; notedDate
+// defaults to today and recordedBy to the signed-in user
+func (h *AllergyHandler) CreateAllergy(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var allergy database.Allergy
+	if err := json.NewDecoder(r.Body).Decode(&allergy); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	allergy.PatientHN = hn
+	allergy.Status = database.AllergyActive
+	if allergy.RecordedBy == "" {
+		allergy.RecordedBy = reqctx.UserName(r.Context())
+	}
+	if allergy.RecordedBy == "" {
+		http.Error(w, "recordedBy is required", http.StatusBadRequest)
+		return
+	}
+	if msg := checkAllergy(&allergy, today(r)); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if ok := h.checkDuplicate(w, &allergy); !ok {
+		return
+	}
+
+	if err := h.repo.Create(&allergy); err != nil {
+		writeError(w, err, "Failed to create allergy")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, allergy)
+}
+
+// GetPatientAllergies lists a patient's allergies, most severe first;
+// inactive ones are included, after the active ones, unless ?active=true
+func (h *AllergyHandler) GetPatientAllergies(w http.ResponseWriter, r *http.Request) {
+	allergies, err := h.repo.GetByPatient(mux.Vars(r)["hn"], r.URL.Query().Get("active") == "true")
+	if err != nil {
+		writeError(w, err, "Failed to retrieve allergies")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, allergies)
+}
+
+// GetAllergy returns one allergy
+func (h *AllergyHandler) GetAllergy(w http.ResponseWriter, r *http.Request) {
+	allergy, ok := h.loadAllergy(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, allergy)
+}
+
+// UpdateAllergy replaces an allergy's details; set status to inactive for an
+// allergy that no longer applies, such as one disproved by testing
+func (h *AllergyHandler) UpdateAllergy(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.loadAllergy(w, r)
+	if !ok {
+		return
+	}
+
+	var allergy database.Allergy
+	if err := json.NewDecoder(r.Body).Decode(&allergy); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	allergy.ID = existing.ID
+	allergy.PatientHN = existing.PatientHN
+	allergy.RecordedBy = existing.RecordedBy
+	if allergy.Status == "" {
+		allergy.Status = existing.Status
+	}
+	if allergy.NotedDate == "" {
+		allergy.NotedDate = existing.NotedDate
+	}
+	if msg := checkAllergy(&allergy, today(r)); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if ok := h.checkDuplicate(w, &allergy); !ok {
+		return
+	}
+
+	if err := h.repo.Update(&allergy); err != nil {
+		writeError(w, err, "Failed to update allergy")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, allergy)
+}
+
+// DeleteAllergy removes an allergy entered by mistake
+func (h *AllergyHandler) DeleteAllergy(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid allergy ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		writeError(w, err, "Failed to delete allergy")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkDuplicate rejects a second active record of the same allergen for a
+// patient, writing the response when it does
+func (h *AllergyHandler) checkDuplicate(w http.ResponseWriter, a *database.Allergy) bool {
+	if a.Status != database.AllergyActive {
+		return true
+	}
+	active, err := h.repo.GetByPatient(a.PatientHN, true)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve allergies")
+		return false
+	}
+	for _, other := range active {
+		if other.ID != a.ID && strings.EqualFold(other.Allergen, a.Allergen) {
+			http.Error(w, a.Allergen+" allergy is already on record", http.StatusConflict)
+			return false
+		}
+	}
+	return true
+}
+
+// checkAllergy trims and validates an allergy noted by day, returning what is wrong with it
+func checkAllergy(a *database.Allergy, day string) string {
+	a.Allergen = strings.TrimSpace(a.Allergen)
+	if a.Allergen == "" {
+		return "allergen is required"
+	}
+	valid := false
+	for _, s := range database.AllergySeverities {
+		valid = valid || a.Severity == s
+	}
+	if !valid {
+		return "severity must be one of " + strings.Join(database.AllergySeverities, ", ")
+	}
+	if a.Status != database.AllergyActive && a.Status != database.AllergyInactive {
+		return "status must be active or inactive"
+	}
+	if a.NotedDate == "" {
+		a.NotedDate = day
+	}
+	if _, err := time.Parse("2006-01-02", a.NotedDate); err != nil {
+		return "Invalid notedDate, expected YYYY-MM-DD"
+	}
+	if a.NotedDate > day {
+		return "notedDate cannot be in the future"
+	}
+	return ""
+}
+
+func (h *AllergyHandler) loadAllergy(w http.ResponseWriter, r *http.Request) (*database.Allergy, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid allergy ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	allergy, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve allergy")
+		return nil, false
+	}
+	return allergy, true
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// AnnouncementRepository interface for announcement storage
+type AnnouncementRepository interface {
+	Create(a *database.Announcement) error
+	GetByID(id int) (*database.Announcement, error)
+	List(f database.AnnouncementFilter) ([]database.Announcement, error)
+	Update(a *database.Announcement) error
+	Delete(id int) error
+	Acknowledge(id int, staff string) (*database.Acknowledgment, error)
+	GetAcknowledgments(id int) ([]database.Acknowledgment, error)
+}
+
+// AnnouncementHandler handles the staff notice board
+type AnnouncementHandler struct {
+	repo AnnouncementRepository
+}
+
+// NewAnnouncementHandler creates a new announcement handler
+func NewAnnouncementHandler(repo AnnouncementRepository) *AnnouncementHandler {
+	return &AnnouncementHandler{repo: repo}
+}
+
+// CreateAnnouncement posts a notice; publishAt defaults to now
+func (h *AnnouncementHandler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	var announcement database.Announcement
+	if err := json.NewDecoder(r.Body).Decode(&announcement); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if announcement.CreatedBy == "" {
+		announcement.CreatedBy = reqctx.UserName(r.Context())
+	}
+	if announcement.PublishAt.IsZero() {
+		announcement.PublishAt = time.Now()
+	}
+	if msg := checkAnnouncement(&announcement); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Create(&announcement); err != nil {
+		writeError(w, err, "Failed to create announcement")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, announcement)
+}
+
+// GetAllAnnouncements lists every announcement, scheduled and expired
+// included, with how many staff have acknowledged each
+func (h *AnnouncementHandler) GetAllAnnouncements(w http.ResponseWriter, r *http.Request) {
+	h.list(w, database.AnnouncementFilter{})
+}
+
+// GetAnnouncementAcknowledgments returns an announcement with who has acknowledged it
+func (h *AnnouncementHandler) GetAnnouncementAcknowledgments(w http.ResponseWriter, r *http.Request) {
+	announcement, ok := h.loadAnnouncement(w, r)
+	if !ok {
+		return
+	}
+
+	acks, err := h.repo.GetAcknowledgments(announcement.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve acknowledgments")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"announcement":    announcement,
+		"acknowledgments": acks,
+	})
+}
+
+// UpdateAnnouncement replaces an announcement's content and window; set
+// expiresAt to take it down
+func (h *AnnouncementHandler) UpdateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.loadAnnouncement(w, r)
+	if !ok {
+		return
+	}
+
+	var announcement database.Announcement
+	if err := json.NewDecoder(r.Body).Decode(&announcement); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	announcement.ID = existing.ID
+	announcement.CreatedBy = existing.CreatedBy
+	if announcement.PublishAt.IsZero() {
+		announcement.PublishAt = existing.PublishAt
+	}
+	if msg := checkAnnouncement(&announcement); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Update(&announcement); err != nil {
+		writeError(w, err, "Failed to update announcement")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, announcement)
+}
+
+// DeleteAnnouncement removes an announcement posted by mistake
+func (h *AnnouncementHandler) DeleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid announcement ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		writeError(w, err, "Failed to delete announcement")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAnnouncements lists the announcements up now, most pressing first,
+// marking which the signed-in user (or ?staff=) has acknowledged
+func (h *AnnouncementHandler) GetAnnouncements(w http.ResponseWriter, r *http.Request) {
+	h.list(w, database.AnnouncementFilter{ActiveAt: time.Now(), Staff: staffName(r)})
+}
+
+// GetBanner lists the announcements up now that the signed-in user (or
+// ?staff=) has not acknowledged yet, most pressing first. The frontend polls
+// it to show the banner, so it is never cached.
+func (h *AnnouncementHandler) GetBanner(w http.ResponseWriter, r *http.Request) {
+	staff := staffName(r)
+	w.Header().Set("Cache-Control", "no-store")
+	h.list(w, database.AnnouncementFilter{ActiveAt: time.Now(), Staff: staff, Unacknowledged: staff != ""})
+}
+
+// AcknowledgeAnnouncement records that the signed-in user (or staffName) has
+// read an announcement that is up
+func (h *AnnouncementHandler) AcknowledgeAnnouncement(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		StaffName string `json:"staffName"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.StaffName == "" {
+		req.StaffName = reqctx.UserName(r.Context())
+	}
+	req.StaffName = strings.TrimSpace(req.StaffName)
+	if req.StaffName == "" {
+		http.Error(w, "staffName is required", http.StatusBadRequest)
+		return
+	}
+
+	announcement, ok := h.loadAnnouncement(w, r)
+	if !ok {
+		return
+	}
+	if !announcement.ActiveAt(time.Now()) {
+		http.Error(w, "Announcement is not up", http.StatusConflict)
+		return
+	}
+
+	ack, err := h.repo.Acknowledge(announcement.ID, req.StaffName)
+	if err != nil {
+		writeError(w, err, "Failed to acknowledge announcement")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ack)
+}
+
+func (h *AnnouncementHandler) list(w http.ResponseWriter, filter database.AnnouncementFilter) {
+	announcements, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve announcements")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, announcements)
+}
+
+func (h *AnnouncementHandler) loadAnnouncement(w http.ResponseWriter, r *http.Request) (*database.Announcement, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid announcement ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	announcement, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve announcement")
+		return nil, false
+	}
+	return announcement, true
+}
+
+// staffName is the signed-in user or, until everyone signs in, ?staff=
+func staffName(r *http.Request) string {
+	if name := reqctx.UserName(r.Context()); name != "" {
+		return name
+	}
+	return strings.TrimSpace(r.URL.Query().Get("staff"))
+}
+
+// checkAnnouncement trims and validates an announcement, returning what is wrong with it
+func checkAnnouncement(a *database.Announcement) string {
+	a.Title = strings.TrimSpace(a.Title)
+	a.Body = strings.TrimSpace(a.Body)
+	if a.Title == "" || a.Body == "" {
+		return "title and body are required"
+	}
+	if a.CreatedBy == "" {
+		return "createdBy is required"
+	}
+	if a.Priority == "" {
+		a.Priority = database.PriorityInfo
+	}
+	valid := false
+	for _, p := range database.AnnouncementPriorities {
+		valid = valid || a.Priority == p
+	}
+	if !valid {
+		return "priority must be one of " + strings.Join(database.AnnouncementPriorities, ", ")
+	}
+	if a.ExpiresAt != nil && !a.ExpiresAt.After(a.PublishAt) {
+		return "expiresAt must be after publishAt"
+	}
+	return ""
+}
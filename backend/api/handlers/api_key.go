@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// API key prefixes; the key itself says whether it is a sandbox key
+const (
+	liveKeyPrefix    = "ck_live_"
+	sandboxKeyPrefix = "ck_test_"
+)
+
+// APIKeyRepository interface for API key storage
+type APIKeyRepository interface {
+	Create(k *database.APIKey) error
+	GetAll() ([]database.APIKey, error)
+	GetByHash(hash string) (*database.APIKey, error)
+	MarkUsed(id int) error
+	Revoke(id int, by string) (*database.APIKey, error)
+}
+
+// APIKeyHandler lets administrators issue and revoke integrations' API keys
+type APIKeyHandler struct {
+	repo APIKeyRepository
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(repo APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo}
+}
+
+// APIKeyRequest is the body of a new API key
+type APIKeyRequest struct {
+	Name    string `json:"name"`
+	Role    string `json:"role"`    // what the integration may do, as a staff role or kiosk
+	Sandbox bool   `json:"sandbox"` // simulate payments and messages instead of making them
+}
+
+// NewAPIKey is a created API key with the key itself, which is not shown again
+type NewAPIKey struct {
+	database.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey issues an API key for an integration. The key goes in the
+// X-API-Key header; sandbox keys start ck_test_ and live keys ck_live_.
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if !oneOf(req.Role, database.APIKeyRoles) {
+		http.Error(w, "role must be one of "+strings.Join(database.APIKeyRoles, ", "), http.StatusBadRequest)
+		return
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		http.Error(w, "Failed to generate API key", http.StatusInternalServerError)
+		return
+	}
+	key := liveKeyPrefix + hex.EncodeToString(secret)
+	if req.Sandbox {
+		key = sandboxKeyPrefix + hex.EncodeToString(secret)
+	}
+
+	created := NewAPIKey{
+		APIKey: database.APIKey{
+			Name:      req.Name,
+			Prefix:    key[:len(liveKeyPrefix)+4],
+			KeyHash:   hashAPIKey(key),
+			Role:      req.Role,
+			Sandbox:   req.Sandbox,
+			CreatedBy: reqctx.UserName(r.Context()),
+		},
+		Key: key,
+	}
+	if err := h.repo.Create(&created.APIKey); err != nil {
+		writeError(w, err, "Failed to create API key")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// GetAPIKeys lists the API keys, revoked ones included, newest first
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.repo.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve API keys")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey stops an API key from working
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := h.repo.Revoke(id, reqctx.UserName(r.Context()))
+	if err != nil {
+		writeError(w, err, "Failed to revoke API key")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revoked)
+}
+
+// hashAPIKey is the hex SHA-256 an API key is stored and looked up by
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyUserID is the reqctx user ID of requests made with an API key
+func apiKeyUserID(k *database.APIKey) string {
+	return fmt.Sprintf("apikey:%d", k.ID)
+}
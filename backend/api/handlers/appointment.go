@@ -0,0 +1,612 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultAppointmentLength is used when a booking gives no end time
+const defaultAppointmentLength = 15 * time.Minute
+
+// appointmentTypePattern keeps types usable as display setting keys, e.g. "follow_up"
+var appointmentTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,29}$`)
+
+// AppointmentRepository interface for appointment storage
+type AppointmentRepository interface {
+	Create(a *database.Appointment) error
+	GetByID(id int) (*database.Appointment, error)
+	List(f database.AppointmentFilter) ([]database.Appointment, error)
+	Reschedule(a *database.Appointment) error
+	UpdateStatus(id int, from, to string, cancel *database.Cancellation) (*database.Appointment, error)
+	SetConfirmation(id int, confirmation string) (*database.Appointment, error)
+}
+
+// AppointmentOverrideRepository interface for the audit trail of bookings
+// forced past the duplicate guard
+type AppointmentOverrideRepository interface {
+	Create(o *database.AppointmentOverride) error
+	List(f database.AppointmentOverrideFilter) ([]database.AppointmentOverride, error)
+}
+
+// PatientLanguageLookup returns a patient's language needs
+type PatientLanguageLookup interface {
+	GetPatientLanguage(hn string) (*database.PatientLanguage, error)
+}
+
+// AppointmentDisplaySource provides the clinic's configured appointment styles
+type AppointmentDisplaySource interface {
+	GetAll() ([]database.AppointmentDisplay, error)
+}
+
+// AppointmentHandler handles appointment booking requests
+type AppointmentHandler struct {
+	repo      AppointmentRepository
+	patients  PatientRepository
+	doctors   DoctorRepository
+	languages PatientLanguageLookup
+	display   AppointmentDisplaySource
+	branches  BranchLookup
+	roster    RosterLookup
+	overrides AppointmentOverrideRepository
+	reasons   CancellationReasonSource
+	services  ServiceLookup
+	history   ServiceHistory
+	line      LINEOutbox
+	emails    EmailOutbox
+
+	blockLapsedLicenses bool // refuse bookings with doctors whose license has expired by the appointment date
+}
+
+// NewAppointmentHandler creates a new appointment handler
+func NewAppointmentHandler(repo AppointmentRepository, patients PatientRepository, doctors DoctorRepository, languages PatientLanguageLookup, display AppointmentDisplaySource, branches BranchLookup, roster RosterLookup, overrides AppointmentOverrideRepository, reasons CancellationReasonSource, services ServiceLookup, history ServiceHistory, line LINEOutbox, emails EmailOutbox, blockLapsedLicenses bool) *AppointmentHandler {
+	return &AppointmentHandler{repo: repo, patients: patients, doctors: doctors, languages: languages, display: display, branches: branches, roster: roster, overrides: overrides, reasons: reasons, services: services, history: history, line: line, emails: emails, blockLapsedLicenses: blockLapsedLicenses}
+}
+
+// RescheduleRequest moves an appointment to a new time, optionally with another doctor
+type RescheduleRequest struct {
+	StartsAt   time.Time  `json:"startsAt"`
+	EndsAt     *time.Time `json:"endsAt,omitempty"` // defaults to the original length
+	DoctorID   *int       `json:"doctorId,omitempty"`
+	DoctorName *string    `json:"doctorName,omitempty"`
+}
+
+// CreateAppointment books an appointment for a patient with a doctor, given
+// by doctorId (preferred) or free-text doctorName. The patient's language
+// record sets interpreterRequired; type defaults to consultation. A booking
+// that duplicates another of the patient's appointments needs ?force=true
+// (see checkDuplicates). A booking for a catalog service (serviceId) must
+// meet the service's eligibility rules. channel records where the booking
+// came from for the booking channel report. Patients who have linked a LINE
+// account get a booking confirmation there, and so do patients with an email
+// address.
+func (h *AppointmentHandler) CreateAppointment(w http.ResponseWriter, r *http.Request) {
+	var appointment database.Appointment
+	if err := json.NewDecoder(r.Body).Decode(&appointment); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	appointment.DoctorName = strings.TrimSpace(appointment.DoctorName)
+	if appointment.PatientHN == "" || (appointment.DoctorID == nil && appointment.DoctorName == "") || appointment.StartsAt.IsZero() {
+		http.Error(w, "patientHn, doctorId (or doctorName) and startsAt are required", http.StatusBadRequest)
+		return
+	}
+	if appointment.EndsAt.IsZero() {
+		appointment.EndsAt = appointment.StartsAt.Add(defaultAppointmentLength)
+	}
+	if !appointment.EndsAt.After(appointment.StartsAt) {
+		http.Error(w, "endsAt must be after startsAt", http.StatusBadRequest)
+		return
+	}
+	appointment.Type = strings.TrimSpace(appointment.Type)
+	if appointment.Type == "" {
+		appointment.Type = database.DefaultAppointmentType
+	}
+	if !appointmentTypePattern.MatchString(appointment.Type) {
+		http.Error(w, "type must be lowercase letters, digits and underscores, e.g. follow_up", http.StatusBadRequest)
+		return
+	}
+	if appointment.Channel != nil {
+		appointment.Channel = optionalText(*appointment.Channel)
+	}
+	if appointment.Channel != nil && !oneOf(*appointment.Channel, database.BookingChannels) {
+		http.Error(w, "channel must be one of "+strings.Join(database.BookingChannels, ", "), http.StatusBadRequest)
+		return
+	}
+
+	id, err := parseHN(appointment.PatientHN)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+	if !h.checkOpen(w, r, &appointment) || !h.resolveDoctor(w, r, &appointment) || !h.checkBookedService(w, r, &appointment) {
+		return
+	}
+	override, ok := h.checkDuplicates(w, r, &appointment)
+	if !ok {
+		return
+	}
+
+	if h.languages != nil {
+		if language, err := h.languages.GetPatientLanguage(appointment.PatientHN); err == nil && language.InterpreterRequired {
+			appointment.InterpreterRequired = true
+		}
+	}
+	appointment.Status = database.AppointmentScheduled
+	appointment.Sandbox = reqctx.Sandbox(r.Context())
+	appointment.RescheduleCount = 0
+	appointment.CancelReason = nil
+	appointment.CancelledAt = nil
+	appointment.Confirmation = database.AppointmentUnconfirmed
+	appointment.ConfirmedAt = nil
+	appointment.RemindersSent = 0
+
+	if err := h.repo.Create(&appointment); err != nil {
+		writeError(w, err, "Failed to create appointment")
+		return
+	}
+	h.recordOverride(override, appointment.ID)
+	if !appointment.Sandbox {
+		queueLINE(h.line, bookingConfirmation(&appointment, reqctx.Location(r.Context())))
+		confirmationEmail(h.emails, patient, &appointment, reqctx.Location(r.Context()))
+	}
+
+	h.style(&appointment)
+	writeJSON(w, http.StatusCreated, appointment)
+}
+
+// GetAppointments lists appointments for a day (?date=, default today) or a
+// range (?from=&to=), filtered by ?doctorId=, ?doctor= (name), ?hn=, ?type=, ?status= and ?channel=
+func (h *AppointmentHandler) GetAppointments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := database.AppointmentFilter{
+		PatientHN:  q.Get("hn"),
+		DoctorName: q.Get("doctor"),
+		Type:       q.Get("type"),
+		Status:     q.Get("status"),
+		Channel:    q.Get("channel"),
+	}
+	if s := q.Get("doctorId"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid doctorId", http.StatusBadRequest)
+			return
+		}
+		filter.DoctorID = id
+	}
+
+	if q.Get("from") != "" || q.Get("to") != "" {
+		from, to, err := dateRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.From, filter.To = from, to
+	} else {
+		day := localNow(r)
+		if s := q.Get("date"); s != "" {
+			d, err := time.ParseInLocation("2006-01-02", s, day.Location())
+			if err != nil {
+				http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			day = d
+		}
+		filter.From = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+		filter.To = filter.From.AddDate(0, 0, 1)
+	}
+
+	appointments, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+
+	h.styleList(appointments)
+	writeJSON(w, http.StatusOK, appointments)
+}
+
+// GetPatientAppointments lists all of a patient's appointments
+func (h *AppointmentHandler) GetPatientAppointments(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	if _, err := parseHN(hn); err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+
+	appointments, err := h.repo.List(database.AppointmentFilter{PatientHN: hn, Status: r.URL.Query().Get("status")})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+
+	h.styleList(appointments)
+	writeJSON(w, http.StatusOK, appointments)
+}
+
+// GetAppointment returns one appointment
+func (h *AppointmentHandler) GetAppointment(w http.ResponseWriter, r *http.Request) {
+	appointment, ok := h.loadAppointment(w, r)
+	if !ok {
+		return
+	}
+
+	h.style(appointment)
+	writeJSON(w, http.StatusOK, appointment)
+}
+
+// GetAppointmentOverrides lists bookings forced past the duplicate guard,
+// newest first, for ?from=&to= (default this month) and optionally ?hn=
+func (h *AppointmentHandler) GetAppointmentOverrides(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	overrides, err := h.overrides.List(database.AppointmentOverrideFilter{From: from, To: to, PatientHN: r.URL.Query().Get("hn")})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment overrides")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, overrides)
+}
+
+// RescheduleAppointment moves a scheduled appointment to a new time, under
+// the same duplicate guard as booking
+func (h *AppointmentHandler) RescheduleAppointment(w http.ResponseWriter, r *http.Request) {
+	appointment, ok := h.loadAppointment(w, r)
+	if !ok {
+		return
+	}
+
+	var req RescheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.StartsAt.IsZero() {
+		http.Error(w, "startsAt is required", http.StatusBadRequest)
+		return
+	}
+	if appointment.Status != database.AppointmentScheduled {
+		http.Error(w, "Only scheduled appointments can be rescheduled", http.StatusConflict)
+		return
+	}
+
+	length := appointment.EndsAt.Sub(appointment.StartsAt)
+	appointment.StartsAt = req.StartsAt
+	appointment.EndsAt = req.StartsAt.Add(length)
+	if req.EndsAt != nil {
+		appointment.EndsAt = *req.EndsAt
+	}
+	if !appointment.EndsAt.After(appointment.StartsAt) {
+		http.Error(w, "endsAt must be after startsAt", http.StatusBadRequest)
+		return
+	}
+	if req.DoctorID != nil {
+		appointment.DoctorID = req.DoctorID
+	} else if req.DoctorName != nil && strings.TrimSpace(*req.DoctorName) != "" {
+		appointment.DoctorID = nil
+		appointment.DoctorName = strings.TrimSpace(*req.DoctorName)
+	}
+	if !h.checkOpen(w, r, appointment) || !h.resolveDoctor(w, r, appointment) || !h.checkBookedService(w, r, appointment) {
+		return
+	}
+	override, ok := h.checkDuplicates(w, r, appointment)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Reschedule(appointment); err != nil {
+		writeError(w, err, "Failed to reschedule appointment")
+		return
+	}
+	h.recordOverride(override, appointment.ID)
+
+	h.style(appointment)
+	writeJSON(w, http.StatusOK, appointment)
+}
+
+// CancelAppointment cancels an appointment. reasonCode must be an active
+// cancellation reason for appointments; reason says more, and is required
+// for reasons that ask for it.
+func (h *AppointmentHandler) CancelAppointment(w http.ResponseWriter, r *http.Request) {
+	cancel, ok := decodeCancellation(w, r, h.reasons, database.CancelForAppointment)
+	if !ok {
+		return
+	}
+
+	h.moveTo(w, r, database.AppointmentCancelled, cancel)
+}
+
+// UpdateAppointmentStatus records check-in, completion or a no-show
+func (h *AppointmentHandler) UpdateAppointmentStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Status {
+	case database.AppointmentCheckedIn, database.AppointmentCompleted, database.AppointmentNoShow:
+	default:
+		http.Error(w, "status must be checked_in, completed or no_show (use /cancel to cancel)", http.StatusBadRequest)
+		return
+	}
+
+	h.moveTo(w, r, req.Status, nil)
+}
+
+// ConfirmAppointment records the patient's answer to a reminder: confirmed or
+// declined stop further reminders, unconfirmed clears the answer
+func (h *AppointmentHandler) ConfirmAppointment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Confirmation string `json:"confirmation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Confirmation {
+	case database.AppointmentConfirmed, database.AppointmentDeclined, database.AppointmentUnconfirmed:
+	default:
+		http.Error(w, "confirmation must be confirmed, declined or unconfirmed", http.StatusBadRequest)
+		return
+	}
+
+	appointment, ok := h.loadAppointment(w, r)
+	if !ok {
+		return
+	}
+	if appointment.Status != database.AppointmentScheduled {
+		http.Error(w, "Only scheduled appointments can be confirmed", http.StatusConflict)
+		return
+	}
+
+	updated, err := h.repo.SetConfirmation(appointment.ID, req.Confirmation)
+	if err != nil {
+		writeError(w, err, "Failed to update appointment confirmation")
+		return
+	}
+
+	h.style(updated)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (h *AppointmentHandler) moveTo(w http.ResponseWriter, r *http.Request, status string, cancel *database.Cancellation) {
+	appointment, ok := h.loadAppointment(w, r)
+	if !ok {
+		return
+	}
+	if !appointment.CanMoveTo(status) {
+		http.Error(w, "Cannot change a "+appointment.Status+" appointment to "+status, http.StatusConflict)
+		return
+	}
+
+	updated, err := h.repo.UpdateStatus(appointment.ID, appointment.Status, status, cancel)
+	if err != nil {
+		writeError(w, err, "Failed to update appointment status")
+		return
+	}
+
+	h.style(updated)
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// resolveDoctor fills in the doctor's name from doctorId and checks that the
+// doctor is active and on their roster for the appointment's time (see
+// rosterConflict). When lapsed licenses are
+// blocked, it also checks the doctor is licensed on that day unless an admin
+// books with ?overrideLicense=true.
+func (h *AppointmentHandler) resolveDoctor(w http.ResponseWriter, r *http.Request, a *database.Appointment) bool {
+	if a.DoctorID == nil {
+		return true
+	}
+
+	doctor, err := h.doctors.GetByID(*a.DoctorID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return false
+	}
+	if !doctor.Active {
+		http.Error(w, "Doctor is no longer active", http.StatusConflict)
+		return false
+	}
+	loc := reqctx.Location(r.Context())
+	msg, err := rosterConflict(h.roster, doctor, a.StartsAt, a.EndsAt, loc)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve roster")
+		return false
+	}
+	if msg != "" {
+		http.Error(w, msg, http.StatusConflict)
+		return false
+	}
+	local := a.StartsAt.In(loc)
+	if h.blockLapsedLicenses && doctor.LicenseLapsedOn(local.Format("2006-01-02")) {
+		override := r.URL.Query().Get("overrideLicense") == "true"
+		if !override || !reqctx.From(r.Context()).HasRole(reqctx.RoleAdmin) {
+			http.Error(w, doctor.FullName+"'s license expired on "+*doctor.LicenseExpiresOn+"; an admin can book with overrideLicense=true", http.StatusConflict)
+			return false
+		}
+		log.Printf("%s booked %s past their license expiry of %s", reqctx.UserName(r.Context()), doctor.FullName, *doctor.LicenseExpiresOn)
+	}
+
+	a.DoctorName = doctor.FullName
+	return true
+}
+
+// checkDuplicates refuses a booking that overlaps another of the patient's
+// active appointments, or repeats the type of one on the same day, unless it
+// is made with ?force=true. A forced booking returns the override to record
+// once it is made; ?reason= says why and ?overriddenBy= who, defaulting to
+// the signed-in user.
+func (h *AppointmentHandler) checkDuplicates(w http.ResponseWriter, r *http.Request, a *database.Appointment) (*database.AppointmentOverride, bool) {
+	loc := reqctx.Location(r.Context())
+	day := midnight(a.StartsAt.In(loc))
+	existing, err := h.repo.List(database.AppointmentFilter{From: day, To: day.AddDate(0, 0, 1), PatientHN: a.PatientHN})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return nil, false
+	}
+
+	conflicts := []int{}
+	found := []string{}
+	for _, other := range existing {
+		if other.ID == a.ID || !other.Active() {
+			continue
+		}
+		what := fmt.Sprintf("appointment %d (%s at %s with %s)", other.ID, other.Type, other.StartsAt.In(loc).Format("15:04"), other.DoctorName)
+		switch {
+		case other.StartsAt.Before(a.EndsAt) && a.StartsAt.Before(other.EndsAt):
+			found = append(found, what+" overlaps")
+		case other.Type == a.Type:
+			found = append(found, what+" is the same type on the same day")
+		default:
+			continue
+		}
+		conflicts = append(conflicts, other.ID)
+	}
+	if len(conflicts) == 0 {
+		return nil, true
+	}
+
+	q := r.URL.Query()
+	detail := a.PatientHN + ": " + strings.Join(found, "; ")
+	if q.Get("force") != "true" {
+		http.Error(w, "Possible duplicate booking for "+detail+"; book with force=true if intended", http.StatusConflict)
+		return nil, false
+	}
+	override := &database.AppointmentOverride{
+		PatientHN:     a.PatientHN,
+		ConflictsWith: conflicts,
+		Detail:        detail,
+		OverriddenBy:  strings.TrimSpace(q.Get("overriddenBy")),
+	}
+	if override.OverriddenBy == "" {
+		override.OverriddenBy = reqctx.UserName(r.Context())
+	}
+	if override.OverriddenBy == "" {
+		http.Error(w, "overriddenBy is required with force=true", http.StatusBadRequest)
+		return nil, false
+	}
+	if reason := strings.TrimSpace(q.Get("reason")); reason != "" {
+		override.Reason = &reason
+	}
+	return override, true
+}
+
+// recordOverride keeps the audit record of a forced booking. A failure is
+// logged rather than failing a booking that has already been made.
+func (h *AppointmentHandler) recordOverride(override *database.AppointmentOverride, appointmentID int) {
+	if override == nil {
+		return
+	}
+	override.AppointmentID = appointmentID
+	if err := h.overrides.Create(override); err != nil {
+		log.Printf("Failed to record duplicate booking override of appointment %d by %s: %v", appointmentID, override.OverriddenBy, err)
+	}
+}
+
+// checkOpen checks that the request's branch is open for the whole
+// appointment. Branches without settings or opening hours take any time.
+func (h *AppointmentHandler) checkOpen(w http.ResponseWriter, r *http.Request, a *database.Appointment) bool {
+	id := reqctx.Branch(r.Context())
+	if id == "" {
+		return true
+	}
+
+	branch, err := h.branches.GetByID(id)
+	if err != nil {
+		if apperr.Is(err, apperr.KindNotFound) {
+			return true
+		}
+		writeError(w, err, "Failed to retrieve branch")
+		return false
+	}
+	if !branch.OpenBetween(a.StartsAt, a.EndsAt) {
+		loc, _ := branch.Location()
+		http.Error(w, branch.Name+" is not open from "+a.StartsAt.In(loc).Format("Mon 15:04")+" to "+a.EndsAt.In(loc).Format("15:04"), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// checkBookedService refuses a booking for a catalog service that is unknown,
+// withdrawn, or one the patient is not eligible for on the appointment's day
+func (h *AppointmentHandler) checkBookedService(w http.ResponseWriter, r *http.Request, a *database.Appointment) bool {
+	if a.ServiceID == nil {
+		return true
+	}
+	service, ok := lookupService(w, h.services, *a.ServiceID)
+	if !ok {
+		return false
+	}
+	patient, ok := findPatient(w, h.patients, a.PatientHN)
+	if !ok {
+		return false
+	}
+	return checkEligible(w, service, patient, a.StartsAt.In(reqctx.Location(r.Context())), h.services, h.history)
+}
+
+func (h *AppointmentHandler) loadAppointment(w http.ResponseWriter, r *http.Request) (*database.Appointment, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid appointment ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	appointment, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment")
+		return nil, false
+	}
+	return appointment, true
+}
+
+// style fills in how calendars draw each appointment. When the display
+// settings cannot be loaded the appointments go out unstyled rather than
+// failing a booking that has already been made.
+func (h *AppointmentHandler) style(appointments ...*database.Appointment) {
+	if h.display == nil || len(appointments) == 0 {
+		return
+	}
+
+	configured, err := h.display.GetAll()
+	if err != nil {
+		log.Printf("Failed to load appointment display settings: %v", err)
+		return
+	}
+	displays := database.NewAppointmentDisplays(configured)
+	for _, a := range appointments {
+		a.Display = displays.StyleOf(a)
+	}
+}
+
+func (h *AppointmentHandler) styleList(appointments []database.Appointment) {
+	pointers := make([]*database.Appointment, len(appointments))
+	for i := range appointments {
+		pointers[i] = &appointments[i]
+	}
+	h.style(pointers...)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"clinic/backend/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// maxDisplayLabel keeps labels short enough for a week-view calendar cell
+const maxDisplayLabel = 12
+
+var displayColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// AppointmentDisplayRepository interface for appointment display settings
+type AppointmentDisplayRepository interface {
+	GetAll() ([]database.AppointmentDisplay, error)
+	Set(d *database.AppointmentDisplay) error
+	Delete(scope, key string) error
+}
+
+// AppointmentDisplayHandler handles the colors, icons and labels calendars use for appointments
+type AppointmentDisplayHandler struct {
+	repo AppointmentDisplayRepository
+}
+
+// NewAppointmentDisplayHandler creates a new appointment display handler
+func NewAppointmentDisplayHandler(repo AppointmentDisplayRepository) *AppointmentDisplayHandler {
+	return &AppointmentDisplayHandler{repo: repo}
+}
+
+// GetAppointmentDisplay lists the effective style of every styled type and
+// status, for calendar legends; built-in defaults have no updatedAt
+func (h *AppointmentDisplayHandler) GetAppointmentDisplay(w http.ResponseWriter, r *http.Request) {
+	configured, err := h.repo.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment display settings")
+		return
+	}
+
+	displays := []database.AppointmentDisplay{}
+	for _, d := range database.NewAppointmentDisplays(configured) {
+		displays = append(displays, d)
+	}
+	sort.Slice(displays, func(i, j int) bool {
+		if displays[i].Scope != displays[j].Scope {
+			return displays[i].Scope > displays[j].Scope // types before statuses
+		}
+		return displays[i].Key < displays[j].Key
+	})
+
+	writeJSON(w, http.StatusOK, displays)
+}
+
+// SetAppointmentDisplay restyles an appointment type or status
+func (h *AppointmentDisplayHandler) SetAppointmentDisplay(w http.ResponseWriter, r *http.Request) {
+	scope, key, ok := displayTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var style database.DisplayStyle
+	if err := json.NewDecoder(r.Body).Decode(&style); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	style.Icon = strings.TrimSpace(style.Icon)
+	style.Label = strings.TrimSpace(style.Label)
+	if !displayColorPattern.MatchString(style.Color) {
+		http.Error(w, "color must be a hex color like #2563EB", http.StatusBadRequest)
+		return
+	}
+	if style.Icon == "" || style.Label == "" {
+		http.Error(w, "icon and label are required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(style.Label) > maxDisplayLabel {
+		http.Error(w, "label must be at most 12 characters", http.StatusBadRequest)
+		return
+	}
+
+	display := database.AppointmentDisplay{Scope: scope, Key: key, DisplayStyle: style}
+	display.Color = strings.ToUpper(display.Color)
+	if err := h.repo.Set(&display); err != nil {
+		writeError(w, err, "Failed to update appointment display")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, display)
+}
+
+// ResetAppointmentDisplay returns a type or status to its default style
+func (h *AppointmentDisplayHandler) ResetAppointmentDisplay(w http.ResponseWriter, r *http.Request) {
+	scope, key, ok := displayTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(scope, key); err != nil {
+		writeError(w, err, "Failed to reset appointment display")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// displayTarget reads the scope and key from the path; statuses are fixed,
+// types are whatever bookings use
+func displayTarget(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	vars := mux.Vars(r)
+	scope, key := vars["scope"], vars["key"]
+	switch scope {
+	case database.DisplayScopeType:
+		if !appointmentTypePattern.MatchString(key) {
+			http.Error(w, "Invalid appointment type", http.StatusBadRequest)
+			return "", "", false
+		}
+	case database.DisplayScopeStatus:
+		switch key {
+		case database.AppointmentScheduled, database.AppointmentCheckedIn, database.AppointmentCompleted,
+			database.AppointmentCancelled, database.AppointmentNoShow:
+		default:
+			http.Error(w, "Unknown appointment status", http.StatusBadRequest)
+			return "", "", false
+		}
+	default:
+		http.Error(w, "scope must be type or status", http.StatusBadRequest)
+		return "", "", false
+	}
+	return scope, key, true
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reminder"
+)
+
+// AppointmentReminderRepository interface for fired appointment reminder storage
+type AppointmentReminderRepository interface {
+	GetByID(id int) (*database.AppointmentReminder, error)
+	List(f database.AppointmentReminderFilter) ([]database.AppointmentReminder, error)
+	UpdateStatus(id int, from, to string) (*database.AppointmentReminder, error)
+	RecordFailure(id int, f *database.NotificationFailure, retryAt func(attempts int) *time.Time) (*database.AppointmentReminder, error)
+	GetFailures(reminderID int) ([]database.NotificationFailure, error)
+	Requeue(id int, phone *string) (*database.AppointmentReminder, error)
+}
+
+// DeliveryMonitor reports how the messaging gateway has been doing
+type DeliveryMonitor interface {
+	Health(now time.Time) (reminder.DeliveryHealth, error)
+}
+
+// AppointmentReminderHandler exposes the reminders the escalation policy has
+// fired, lets a messaging gateway take pending LINE and SMS reminders and
+// report back whether they were sent, and lets staff requeue the ones that
+// ran out of retries
+type AppointmentReminderHandler struct {
+	repo     AppointmentReminderRepository
+	patients PatientRepository
+	retry    reminder.RetryPolicy
+	monitor  DeliveryMonitor
+}
+
+// NewAppointmentReminderHandler creates a new appointment reminder handler;
+// failed deliveries are retried by retry
+func NewAppointmentReminderHandler(repo AppointmentReminderRepository, patients PatientRepository, retry reminder.RetryPolicy, monitor DeliveryMonitor) *AppointmentReminderHandler {
+	return &AppointmentReminderHandler{repo: repo, patients: patients, retry: retry, monitor: monitor}
+}
+
+// GetAppointmentReminders returns the reminders fired for an appointment
+func (h *AppointmentReminderHandler) GetAppointmentReminders(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid appointment ID", http.StatusBadRequest)
+		return
+	}
+
+	reminders, err := h.repo.List(database.AppointmentReminderFilter{AppointmentID: id})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment reminders")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reminders)
+}
+
+// DeliveryLogEntry is one delivery outcome of a reminder: sent, or one
+// failed attempt
+type DeliveryLogEntry struct {
+	ReminderID int       `json:"reminderId"`
+	Step       int       `json:"step"`
+	Channel    string    `json:"channel"`
+	Phone      *string   `json:"phone,omitempty"`
+	Outcome    string    `json:"outcome"`           // sent or failed
+	Attempt    int       `json:"attempt,omitempty"` // which attempt failed, from 1
+	Provider   *string   `json:"provider,omitempty"`
+	ErrorCode  *string   `json:"errorCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// GetAppointmentDeliveryLog returns the sent and failed deliveries of an
+// appointment's LINE and SMS reminders, oldest first; ?outcome=sent|failed
+// narrows it to one outcome
+func (h *AppointmentReminderHandler) GetAppointmentDeliveryLog(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid appointment ID", http.StatusBadRequest)
+		return
+	}
+	outcome := r.URL.Query().Get("outcome")
+	if outcome != "" && outcome != database.NotificationSent && outcome != database.NotificationFailed {
+		http.Error(w, "outcome must be sent or failed", http.StatusBadRequest)
+		return
+	}
+
+	reminders, err := h.repo.List(database.AppointmentReminderFilter{AppointmentID: id})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment reminders")
+		return
+	}
+
+	entries := []DeliveryLogEntry{}
+	for _, m := range reminders {
+		if m.Channel == database.ReminderCall {
+			continue
+		}
+		if m.SentAt != nil && outcome != database.NotificationFailed {
+			entries = append(entries, DeliveryLogEntry{ReminderID: m.ID, Step: m.Step, Channel: m.Channel, Phone: m.Phone,
+				Outcome: database.NotificationSent, At: *m.SentAt})
+		}
+		if outcome == database.NotificationSent {
+			continue
+		}
+		failures, err := h.repo.GetFailures(m.ID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve notification failures")
+			return
+		}
+		for _, f := range failures {
+			entries = append(entries, DeliveryLogEntry{ReminderID: m.ID, Step: m.Step, Channel: m.Channel, Phone: m.Phone,
+				Outcome: database.NotificationFailed, Attempt: f.Attempt, Provider: f.Provider, ErrorCode: f.ErrorCode,
+				Error: f.Error, At: f.FailedAt})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// GetReminders lists reminders by ?channel= and ?status=, e.g. the pending
+// SMS reminders for a gateway to send. Pending reminders waiting out the
+// backoff after a failed attempt are left out until they are due.
+func (h *AppointmentReminderHandler) GetReminders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	reminders, err := h.repo.List(database.AppointmentReminderFilter{
+		Channel: q.Get("channel"),
+		Status:  q.Get("status"),
+		Due:     q.Get("status") == database.NotificationPending,
+	})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment reminders")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reminders)
+}
+
+// ReminderStatusRequest is a messaging gateway's report on a pending reminder
+type ReminderStatusRequest struct {
+	Status    string `json:"status"`              // sent or failed
+	Provider  string `json:"provider,omitempty"`  // who failed to deliver it, e.g. the SMS provider
+	ErrorCode string `json:"errorCode,omitempty"` // the provider's error code
+	Error     string `json:"error,omitempty"`     // the provider's error message
+}
+
+// UpdateReminderStatus records whether a pending reminder was sent or failed.
+// A failed attempt is logged with the provider's error details and the
+// reminder stays pending for a retry after a backoff; once the retry policy
+// runs out it is left failed, in the dead-letter queue.
+func (h *AppointmentReminderHandler) UpdateReminderStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid reminder ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ReminderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Status != database.NotificationSent && req.Status != database.NotificationFailed {
+		http.Error(w, "status must be sent or failed", http.StatusBadRequest)
+		return
+	}
+
+	var updated *database.AppointmentReminder
+	if req.Status == database.NotificationSent {
+		updated, err = h.repo.UpdateStatus(id, database.NotificationPending, req.Status)
+	} else {
+		failure := &database.NotificationFailure{
+			Provider:  optionalText(req.Provider),
+			ErrorCode: optionalText(req.ErrorCode),
+			Error:     strings.TrimSpace(req.Error),
+		}
+		if failure.Error == "" {
+			failure.Error = "delivery failed"
+		}
+		now := time.Now()
+		updated, err = h.repo.RecordFailure(id, failure, func(attempts int) *time.Time { return h.retry.RetryAt(attempts, now) })
+	}
+	if err != nil {
+		writeError(w, err, "Failed to update appointment reminder")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// GetReminderFailures returns a reminder's failed delivery attempts, most recent first
+func (h *AppointmentReminderHandler) GetReminderFailures(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid reminder ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.repo.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve appointment reminder")
+		return
+	}
+
+	failures, err := h.repo.GetFailures(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve notification failures")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, failures)
+}
+
+// GetDeadLetters lists the reminders that could not be delivered, oldest
+// first, by ?channel=: those that ran out of retries and those the patient
+// had no phone number for
+func (h *AppointmentReminderHandler) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	reminders, err := h.repo.List(database.AppointmentReminderFilter{
+		Channel: r.URL.Query().Get("channel"),
+		Status:  database.NotificationFailed,
+	})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment reminders")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reminders)
+}
+
+// RequeueReminder puts a dead-lettered reminder back in the pending queue
+// with a fresh set of retries, to the patient's phone number as it is now
+func (h *AppointmentReminderHandler) RequeueReminder(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid reminder ID", http.StatusBadRequest)
+		return
+	}
+
+	failed, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment reminder")
+		return
+	}
+	if failed.Status != database.NotificationFailed {
+		http.Error(w, "Only failed reminders can be requeued", http.StatusConflict)
+		return
+	}
+	patientID, err := parseHN(failed.PatientHN)
+	if err != nil {
+		http.Error(w, "Reminder has an invalid patient HN", http.StatusInternalServerError)
+		return
+	}
+	patient, err := h.patients.GetByID(patientID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+	if patient.Phone == nil || *patient.Phone == "" {
+		http.Error(w, "Patient still has no phone number; add one before requeueing", http.StatusConflict)
+		return
+	}
+
+	requeued, err := h.repo.Requeue(id, patient.Phone)
+	if err != nil {
+		writeError(w, err, "Failed to requeue appointment reminder")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, requeued)
+}
+
+// GetDeliveryHealth reports the messaging gateway's sent and failed
+// deliveries over the alerting window, and whether the failure rate is
+// high enough to alert on
+func (h *AppointmentReminderHandler) GetDeliveryHealth(w http.ResponseWriter, r *http.Request) {
+	health, err := h.monitor.Health(time.Now())
+	if err != nil {
+		writeError(w, err, "Failed to count notification deliveries")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, health)
+}
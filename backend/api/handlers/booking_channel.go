@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// unrecordedChannel is what the report counts bookings made without a
+// channel, such as those from before channels were recorded, as
+const unrecordedChannel = "unrecorded"
+
+// BookingChannelCount is how the bookings from one channel turned out
+type BookingChannelCount struct {
+	Channel       string  `json:"channel"`
+	Appointments  int     `json:"appointments"` // booked for the period, however they turned out
+	Attended      int     `json:"attended"`     // checked in or completed
+	NoShows       int     `json:"noShows"`
+	Cancelled     int     `json:"cancelled"`
+	Upcoming      int     `json:"upcoming"`      // still scheduled
+	BookedMinutes int     `json:"bookedMinutes"` // schedule time taken, leaving out cancellations
+	Share         float64 `json:"share"`         // percent of all booked minutes
+}
+
+// BookingChannelPeriod is the appointments booked from each channel for one
+// week or month
+type BookingChannelPeriod struct {
+	Start        string         `json:"start"` // first day, YYYY-MM-DD
+	Appointments int            `json:"appointments"`
+	ByChannel    map[string]int `json:"byChannel"`
+}
+
+// BookingChannelReport is where the appointments in a period were booked
+// from; dates are inclusive
+type BookingChannelReport struct {
+	From          string                 `json:"from"`
+	To            string                 `json:"to"`
+	Interval      string                 `json:"interval"` // week or month
+	Appointments  int                    `json:"appointments"`
+	BookedMinutes int                    `json:"bookedMinutes"`
+	Channels      []BookingChannelCount  `json:"channels"` // most booked minutes first
+	Periods       []BookingChannelPeriod `json:"periods"`
+}
+
+// GetBookingChannelReport counts the appointments starting in ?from=&to=
+// (default this month) by the channel they were booked through, with how
+// they turned out and each channel's share of the booked schedule time, in
+// total and per ?interval=week|month (default week). Bookings without a
+// channel are counted as unrecorded.
+func (h *AppointmentHandler) GetBookingChannelReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !to.After(from) {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "week"
+	}
+	if interval != "week" && interval != "month" {
+		http.Error(w, "interval must be week or month", http.StatusBadRequest)
+		return
+	}
+	loc := reqctx.Location(r.Context())
+
+	appointments, err := h.repo.List(database.AppointmentFilter{From: from, To: to})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+
+	report := BookingChannelReport{
+		From:     from.Format("2006-01-02"),
+		To:       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Interval: interval,
+		Channels: []BookingChannelCount{},
+		Periods:  []BookingChannelPeriod{},
+	}
+	periods := map[string]int{}
+	for start := periodStart(from, interval, loc); start.Before(to); start = nextPeriod(start, interval) {
+		key := start.Format("2006-01-02")
+		periods[key] = len(report.Periods)
+		report.Periods = append(report.Periods, BookingChannelPeriod{Start: key, ByChannel: map[string]int{}})
+	}
+
+	counts := map[string]*BookingChannelCount{}
+	for _, a := range appointments {
+		channel := unrecordedChannel
+		if a.Channel != nil {
+			channel = *a.Channel
+		}
+		c := counts[channel]
+		if c == nil {
+			c = &BookingChannelCount{Channel: channel}
+			counts[channel] = c
+		}
+		c.Appointments++
+		report.Appointments++
+		switch a.Status {
+		case database.AppointmentCheckedIn, database.AppointmentCompleted:
+			c.Attended++
+		case database.AppointmentNoShow:
+			c.NoShows++
+		case database.AppointmentCancelled:
+			c.Cancelled++
+		default:
+			c.Upcoming++
+		}
+		if a.Status != database.AppointmentCancelled {
+			minutes := int(a.EndsAt.Sub(a.StartsAt).Minutes())
+			c.BookedMinutes += minutes
+			report.BookedMinutes += minutes
+		}
+
+		period := &report.Periods[periods[periodStart(a.StartsAt, interval, loc).Format("2006-01-02")]]
+		period.ByChannel[channel]++
+		period.Appointments++
+	}
+
+	for _, c := range counts {
+		if report.BookedMinutes > 0 {
+			c.Share = math.Round(float64(c.BookedMinutes)/float64(report.BookedMinutes)*1000) / 10
+		}
+		report.Channels = append(report.Channels, *c)
+	}
+	sort.Slice(report.Channels, func(i, j int) bool {
+		a, b := report.Channels[i], report.Channels[j]
+		if a.BookedMinutes != b.BookedMinutes {
+			return a.BookedMinutes > b.BookedMinutes
+		}
+		if a.Appointments != b.Appointments {
+			return a.Appointments > b.Appointments
+		}
+		return a.Channel < b.Channel
+	})
+
+	writeJSON(w, http.StatusOK, report)
+}
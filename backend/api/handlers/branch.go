@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// clockPattern matches an HH:MM time of day; 24:00 closes at midnight
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$|^24:00$`)
+
+// BranchRepository interface for branch settings storage
+type BranchRepository interface {
+	GetAll() ([]database.Branch, error)
+	GetByID(id string) (*database.Branch, error)
+	Save(b *database.Branch) error
+	Delete(id string) error
+}
+
+// BranchHandler handles clinic branches' timezones, opening hours and walk-in assignment
+type BranchHandler struct {
+	repo BranchRepository
+}
+
+// NewBranchHandler creates a new branch handler
+func NewBranchHandler(repo BranchRepository) *BranchHandler {
+	return &BranchHandler{repo: repo}
+}
+
+// branchView is a branch with its current local time, so clients can show
+// the branch's clock rather than the device's
+type branchView struct {
+	database.Branch
+	LocalTime string `json:"localTime"` // RFC 3339 with the branch's offset
+	OpenNow   bool   `json:"openNow"`
+}
+
+// clinicTime is the timezone a request works in
+type clinicTime struct {
+	Branch    string `json:"branch,omitempty"`
+	Timezone  string `json:"timezone"`
+	LocalTime string `json:"localTime"`
+	Today     string `json:"today"`
+}
+
+// GetBranches lists the configured branches with their local time
+func (h *BranchHandler) GetBranches(w http.ResponseWriter, r *http.Request) {
+	branches, err := h.repo.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve branches")
+		return
+	}
+
+	views := make([]branchView, len(branches))
+	for i := range branches {
+		views[i] = viewBranch(&branches[i])
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// GetBranch returns a branch's settings with its local time
+func (h *BranchHandler) GetBranch(w http.ResponseWriter, r *http.Request) {
+	branch, err := h.repo.GetByID(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve branch")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, viewBranch(branch))
+}
+
+// GetClinicTime returns the timezone, time and date the request works in:
+// its X-Branch-ID branch's when configured, otherwise the clinic's
+func (h *BranchHandler) GetClinicTime(w http.ResponseWriter, r *http.Request) {
+	now := localNow(r)
+	writeJSON(w, http.StatusOK, clinicTime{
+		Branch:    reqctx.Branch(r.Context()),
+		Timezone:  now.Location().String(),
+		LocalTime: now.Format(time.RFC3339),
+		Today:     now.Format("2006-01-02"),
+	})
+}
+
+// SaveBranch creates or replaces a branch's name, timezone, opening hours and
+// how its walk-ins are assigned to doctors
+func (h *BranchHandler) SaveBranch(w http.ResponseWriter, r *http.Request) {
+	var branch database.Branch
+	if err := json.NewDecoder(r.Body).Decode(&branch); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	branch.ID = mux.Vars(r)["id"]
+	if !scopeIDPattern.MatchString(branch.ID) {
+		http.Error(w, "Branch ID must be 1-64 letters, digits, - or _", http.StatusBadRequest)
+		return
+	}
+	if msg := checkBranch(&branch); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Save(&branch); err != nil {
+		writeError(w, err, "Failed to save branch")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, viewBranch(&branch))
+}
+
+// DeleteBranch removes a branch's settings; its requests fall back to the clinic's timezone
+func (h *BranchHandler) DeleteBranch(w http.ResponseWriter, r *http.Request) {
+	if err := h.repo.Delete(mux.Vars(r)["id"]); err != nil {
+		writeError(w, err, "Failed to delete branch")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func viewBranch(b *database.Branch) branchView {
+	view := branchView{Branch: *b}
+	if loc, err := b.Location(); err == nil {
+		now := time.Now().In(loc)
+		view.LocalTime = now.Format(time.RFC3339)
+		minute := now.Truncate(time.Minute)
+		view.OpenNow = b.OpenBetween(minute, minute.Add(time.Minute))
+	}
+	return view
+}
+
+// checkBranch trims and validates a branch's settings, returning what is
+// wrong with them. Hours are normalized to "mon".."sun" and sorted.
+func checkBranch(b *database.Branch) string {
+	b.Name = strings.TrimSpace(b.Name)
+	b.Timezone = strings.TrimSpace(b.Timezone)
+	if b.Name == "" || b.Timezone == "" {
+		return "name and timezone are required"
+	}
+	if _, err := b.Location(); err != nil || b.Timezone == "Local" {
+		return "timezone must be an IANA name such as Asia/Bangkok"
+	}
+
+	b.WalkInAssignment = strings.TrimSpace(b.WalkInAssignment)
+	if b.WalkInAssignment != "" && !oneOf(b.WalkInAssignment, database.WalkInAssignments) {
+		return "walkInAssignment must be one of " + strings.Join(database.WalkInAssignments, ", ")
+	}
+
+	hours, msg := checkHours(b.Hours)
+	if msg != "" {
+		return msg
+	}
+	b.Hours = hours
+	return ""
+}
+
+// checkHours validates weekly spans, returning them normalized to "mon".."sun"
+// and sorted, or what is wrong with them
+func checkHours(hours []database.OpeningHours) ([]database.OpeningHours, string) {
+	byDay := make(map[string][]database.OpeningHours)
+	for _, h := range hours {
+		h.Day = strings.ToLower(strings.TrimSpace(h.Day))
+		code := ""
+		for i, w := range database.Weekdays {
+			if h.Day == w || h.Day == strings.ToLower(time.Weekday(i).String()) {
+				code = w
+			}
+		}
+		if code == "" {
+			return nil, "hours must be on weekdays such as mon, tue, wed"
+		}
+		h.Day = code
+		if !clockPattern.MatchString(h.Opens) || !clockPattern.MatchString(h.Closes) {
+			return nil, "opens and closes must be HH:MM"
+		}
+		if h.Opens >= h.Closes {
+			return nil, "opens must be before closes"
+		}
+		for _, other := range byDay[code] {
+			if h.Opens < other.Closes && other.Opens < h.Closes {
+				return nil, "hours on " + code + " overlap"
+			}
+		}
+		byDay[code] = append(byDay[code], h)
+	}
+
+	sorted := []database.OpeningHours{}
+	for _, day := range database.Weekdays {
+		spans := byDay[day]
+		sort.Slice(spans, func(i, j int) bool { return spans[i].Opens < spans[j].Opens })
+		sorted = append(sorted, spans...)
+	}
+	return sorted, ""
+}
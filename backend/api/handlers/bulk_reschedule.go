@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// Bulk actions on a doctor's day
+const (
+	bulkMove   = "move"
+	bulkCancel = "cancel"
+)
+
+// Outcomes of each appointment in a bulk reschedule
+const (
+	bulkMoved     = "moved"
+	bulkCancelled = "cancelled"
+	bulkSuggested = "suggested" // on a dry run: where it would be moved
+	bulkUnplaced  = "unplaced"  // no other doctor is free for it that day; left for staff
+	bulkFailed    = "failed"
+)
+
+// ChangeNotices stores the SMS notices sent to patients whose appointment
+// was moved or cancelled; they go out through the SMS reminder queue
+type ChangeNotices interface {
+	Create(m *database.AppointmentReminder) error
+	List(f database.AppointmentReminderFilter) ([]database.AppointmentReminder, error)
+}
+
+// BulkRescheduleHandler clears a doctor's day, e.g. when they call in sick
+type BulkRescheduleHandler struct {
+	appointments AppointmentRepository
+	doctors      DoctorRepository
+	roster       RosterRepository
+	reasons      CancellationReasonSource
+	patients     PatientRepository
+	notices      ChangeNotices
+}
+
+// NewBulkRescheduleHandler creates a new bulk reschedule handler
+func NewBulkRescheduleHandler(appointments AppointmentRepository, doctors DoctorRepository, roster RosterRepository, reasons CancellationReasonSource,
+	patients PatientRepository, notices ChangeNotices) *BulkRescheduleHandler {
+	return &BulkRescheduleHandler{appointments: appointments, doctors: doctors, roster: roster, reasons: reasons, patients: patients, notices: notices}
+}
+
+// BulkRescheduleRequest moves or cancels every scheduled appointment a doctor
+// has on a day
+type BulkRescheduleRequest struct {
+	Date       string  `json:"date"`                 // YYYY-MM-DD in the branch timezone
+	Action     string  `json:"action"`               // move or cancel
+	ToDoctorID *int    `json:"toDoctorId,omitempty"` // move: to this doctor; by default each goes to the first free one
+	ReasonCode string  `json:"reasonCode,omitempty"` // cancel: an active cancellation reason for appointments
+	Reason     *string `json:"reason,omitempty"`     // cancel: more about what happened; also the day off's reason
+	DayOff     bool    `json:"dayOff"`               // also record the date as the doctor's day off so no more bookings are taken
+	Notify     bool    `json:"notify"`               // send each patient an SMS about the change
+	DryRun     bool    `json:"dryRun"`               // only suggest where each appointment would go
+}
+
+// BulkRescheduleResult is what happened to one appointment
+type BulkRescheduleResult struct {
+	AppointmentID int        `json:"appointmentId"`
+	PatientHN     string     `json:"patientHn"`
+	StartsAt      time.Time  `json:"startsAt"` // the original time
+	Outcome       string     `json:"outcome"`  // moved, cancelled, suggested, unplaced or failed
+	DoctorID      *int       `json:"doctorId,omitempty"`
+	DoctorName    string     `json:"doctorName,omitempty"` // the doctor it was moved to
+	NewStartsAt   *time.Time `json:"newStartsAt,omitempty"`
+	NewEndsAt     *time.Time `json:"newEndsAt,omitempty"`
+	NoticeID      *int       `json:"noticeId,omitempty"` // the SMS notice queued for the patient
+	Error         string     `json:"error,omitempty"`
+}
+
+// BulkRescheduleReport sums up a bulk reschedule
+type BulkRescheduleReport struct {
+	DoctorID     int                    `json:"doctorId"`
+	DoctorName   string                 `json:"doctorName"`
+	Date         string                 `json:"date"`
+	Action       string                 `json:"action"`
+	DryRun       bool                   `json:"dryRun"`
+	DayOff       *database.DayOff       `json:"dayOff,omitempty"`
+	Moved        int                    `json:"moved"`
+	Cancelled    int                    `json:"cancelled"`
+	Suggested    int                    `json:"suggested"`
+	Unplaced     int                    `json:"unplaced"`
+	Failed       int                    `json:"failed"`
+	Appointments []BulkRescheduleResult `json:"appointments"`
+}
+
+// BulkReschedule moves or cancels all of a doctor's scheduled appointments on
+// a date. Moving keeps each appointment's time with another doctor who is
+// free then, preferring the same specialty, or else takes the free slot
+// nearest to it that day; toDoctorId moves them all to one doctor.
+// Appointments no one can take are left scheduled and reported unplaced.
+// With notify, each patient gets an SMS notice, queued like a reminder; a
+// dry run changes nothing and reports the suggested slots.
+func (h *BulkRescheduleHandler) BulkReschedule(w http.ResponseWriter, r *http.Request) {
+	var req BulkRescheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	loc := reqctx.Location(r.Context())
+	date, err := time.ParseInLocation("2006-01-02", req.Date, loc)
+	if err != nil {
+		http.Error(w, "date is required as YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if !oneOf(req.Action, []string{bulkMove, bulkCancel}) {
+		http.Error(w, "action must be move or cancel", http.StatusBadRequest)
+		return
+	}
+	var cancel *database.Cancellation
+	if req.Action == bulkCancel {
+		var ok bool
+		if cancel, ok = checkCancellation(w, h.reasons, database.CancelForAppointment, cancelRequest{ReasonCode: req.ReasonCode, Reason: req.Reason}); !ok {
+			return
+		}
+	}
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+	doctor, err := h.doctors.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return
+	}
+
+	var candidates []*availability
+	if req.Action == bulkMove {
+		var ok bool
+		if candidates, ok = h.candidates(w, r, doctor, req.ToDoctorID, date); !ok {
+			return
+		}
+	}
+
+	appointments, err := h.appointments.List(database.AppointmentFilter{From: date, To: date.AddDate(0, 0, 1), DoctorID: doctor.ID,
+		Status: database.AppointmentScheduled})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+	sort.Slice(appointments, func(i, j int) bool { return appointments[i].StartsAt.Before(appointments[j].StartsAt) })
+
+	report := BulkRescheduleReport{DoctorID: doctor.ID, DoctorName: doctor.FullName, Date: req.Date, Action: req.Action, DryRun: req.DryRun,
+		Appointments: []BulkRescheduleResult{}}
+	if req.DayOff && !req.DryRun {
+		day := &database.DayOff{DoctorID: doctor.ID, Date: req.Date, CreatedBy: reqctx.UserName(r.Context())}
+		if req.Reason != nil {
+			day.Reason = optionalText(*req.Reason)
+		}
+		if err := h.roster.AddDayOff(day); err != nil && !apperr.Is(err, apperr.KindConflict) {
+			writeError(w, err, "Failed to add day off")
+			return
+		}
+		if day.ID != 0 {
+			report.DayOff = day
+		}
+	}
+
+	for i := range appointments {
+		a := &appointments[i]
+		before := *a
+		result := BulkRescheduleResult{AppointmentID: a.ID, PatientHN: a.PatientHN, StartsAt: a.StartsAt}
+
+		if req.Action == bulkCancel {
+			result.Outcome = bulkCancelled
+			if !req.DryRun {
+				updated, err := h.appointments.UpdateStatus(a.ID, a.Status, database.AppointmentCancelled, cancel)
+				if err != nil {
+					result.Outcome, result.Error = bulkFailed, err.Error()
+				} else {
+					*a = *updated
+				}
+			}
+		} else {
+			result.Outcome = bulkUnplaced
+			to, slot, err := h.place(a, candidates, localNow(r), loc)
+			if err != nil {
+				writeError(w, err, "Failed to retrieve appointments")
+				return
+			}
+			if to != nil {
+				result.Outcome = bulkSuggested
+				a.DoctorID, a.DoctorName = &to.DoctorID, to.DoctorName
+				a.StartsAt, a.EndsAt = slot.StartsAt, slot.EndsAt
+				if !req.DryRun {
+					result.Outcome = bulkMoved
+					if err := h.appointments.Reschedule(a); err != nil {
+						result.Outcome, result.Error = bulkFailed, err.Error()
+					}
+				}
+				if result.Outcome != bulkFailed {
+					to.Free = withoutSpan(to.Free, slot.StartsAt, slot.EndsAt)
+					result.DoctorID, result.DoctorName = &to.DoctorID, to.DoctorName
+					result.NewStartsAt, result.NewEndsAt = &slot.StartsAt, &slot.EndsAt
+				}
+			}
+		}
+
+		if req.Notify && !req.DryRun && (result.Outcome == bulkMoved || result.Outcome == bulkCancelled) {
+			notice, err := h.notify(&before, a, loc)
+			if err != nil {
+				writeError(w, err, "Failed to queue change notice")
+				return
+			}
+			result.NoticeID = &notice.ID
+		}
+
+		switch result.Outcome {
+		case bulkMoved:
+			report.Moved++
+		case bulkCancelled:
+			report.Cancelled++
+		case bulkSuggested:
+			report.Suggested++
+		case bulkUnplaced:
+			report.Unplaced++
+		case bulkFailed:
+			report.Failed++
+		}
+		report.Appointments = append(report.Appointments, result)
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// candidates returns the days of the doctors appointments may be moved to:
+// toDoctorID, or every other active, licensed doctor with the same
+// specialty first
+func (h *BulkRescheduleHandler) candidates(w http.ResponseWriter, r *http.Request, from *database.Doctor, toDoctorID *int, date time.Time) ([]*availability, bool) {
+	var doctors []database.Doctor
+	if toDoctorID != nil {
+		if *toDoctorID == from.ID {
+			http.Error(w, "toDoctorId must be another doctor", http.StatusBadRequest)
+			return nil, false
+		}
+		to, err := h.doctors.GetByID(*toDoctorID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve doctor")
+			return nil, false
+		}
+		if !to.Active {
+			http.Error(w, to.FullName+" is no longer active", http.StatusConflict)
+			return nil, false
+		}
+		doctors = []database.Doctor{*to}
+	} else {
+		all, err := h.doctors.GetAll(database.DoctorFilter{ActiveOnly: true})
+		if err != nil {
+			writeError(w, err, "Failed to retrieve doctors")
+			return nil, false
+		}
+		for _, d := range all {
+			if d.ID != from.ID {
+				doctors = append(doctors, d)
+			}
+		}
+		sort.SliceStable(doctors, func(i, j int) bool {
+			return doctors[i].Specialty == from.Specialty && doctors[j].Specialty != from.Specialty
+		})
+	}
+
+	loc := reqctx.Location(r.Context())
+	days := []*availability{}
+	for i := range doctors {
+		if doctors[i].LicenseLapsedOn(date.Format("2006-01-02")) {
+			continue
+		}
+		day, err := doctorDay(h.roster, h.appointments, &doctors[i], date, loc)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve availability")
+			return nil, false
+		}
+		days = append(days, day)
+	}
+	return days, true
+}
+
+// place finds the candidate and slot for an appointment: the same time with
+// the first doctor free then, or else the free slot starting nearest to it.
+// Slots in the past or overlapping the patient's other appointments are
+// passed over. It returns a nil candidate when there is no room.
+func (h *BulkRescheduleHandler) place(a *database.Appointment, candidates []*availability, now time.Time, loc *time.Location) (*availability, timeSpan, error) {
+	day := midnight(a.StartsAt.In(loc))
+	others, err := h.appointments.List(database.AppointmentFilter{From: day, To: day.AddDate(0, 0, 1), PatientHN: a.PatientHN})
+	if err != nil {
+		return nil, timeSpan{}, err
+	}
+	length := a.EndsAt.Sub(a.StartsAt)
+
+	var best *availability
+	var slot timeSpan
+	var distance time.Duration
+	for _, c := range candidates {
+		free := withoutSpan(c.Free, day, now)
+		for _, o := range others {
+			if o.ID != a.ID && o.Active() {
+				free = withoutSpan(free, o.StartsAt, o.EndsAt)
+			}
+		}
+		for _, f := range free {
+			if f.EndsAt.Sub(f.StartsAt) < length {
+				continue
+			}
+			start := a.StartsAt
+			if start.Before(f.StartsAt) {
+				start = f.StartsAt
+			}
+			if latest := f.EndsAt.Add(-length); start.After(latest) {
+				start = latest
+			}
+			d := start.Sub(a.StartsAt)
+			if d < 0 {
+				d = -d
+			}
+			if best == nil || d < distance {
+				best, distance = c, d
+				slot = timeSpan{StartsAt: start.In(loc), EndsAt: start.Add(length).In(loc)}
+			}
+		}
+	}
+	return best, slot, nil
+}
+
+// notify queues an SMS notice telling the patient their appointment was moved
+// or cancelled. Sandbox appointments get the notice marked sent unsent, as
+// their reminders do, and patients without a phone number a failed one.
+func (h *BulkRescheduleHandler) notify(before, after *database.Appointment, loc *time.Location) (*database.AppointmentReminder, error) {
+	id, err := parseHN(after.PatientHN)
+	if err != nil {
+		return nil, fmt.Errorf("appointment %d has invalid patient HN %q", after.ID, after.PatientHN)
+	}
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := h.notices.List(database.AppointmentReminderFilter{AppointmentID: after.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	starts := before.StartsAt.In(loc)
+	message := fmt.Sprintf("แจ้งยกเลิกนัดหมาย: นัดพบ %s วันที่ %s เวลา %s น. ของท่านถูกยกเลิก กรุณาติดต่อคลินิกเพื่อนัดหมายใหม่ ขออภัยในความไม่สะดวก",
+		before.DoctorName, starts.Format("02/01/2006"), starts.Format("15:04"))
+	if after.Status == database.AppointmentScheduled {
+		moved := after.StartsAt.In(loc)
+		message = fmt.Sprintf("แจ้งเปลี่ยนนัดหมาย: นัดพบ %s วันที่ %s เวลา %s น. ของท่านเปลี่ยนเป็นพบ %s วันที่ %s เวลา %s น. ขออภัยในความไม่สะดวก",
+			before.DoctorName, starts.Format("02/01/2006"), starts.Format("15:04"), after.DoctorName, moved.Format("02/01/2006"), moved.Format("15:04"))
+	}
+
+	notice := &database.AppointmentReminder{
+		AppointmentID: after.ID,
+		PatientHN:     after.PatientHN,
+		Step:          database.NoticeStep(existing),
+		Channel:       database.ReminderSMS,
+		Phone:         patient.Phone,
+		Message:       message,
+		Status:        database.NotificationPending,
+	}
+	switch {
+	case after.Sandbox:
+		sent := time.Now()
+		notice.Status = database.NotificationSent
+		notice.SentAt = &sent
+		notice.Sandbox = true
+	case patient.Phone == nil || *patient.Phone == "":
+		lastError := "patient has no phone number"
+		notice.Status = database.NotificationFailed
+		notice.LastError = &lastError
+	}
+
+	if err := h.notices.Create(notice); err != nil {
+		return nil, err
+	}
+	return notice, nil
+}
+
+// withoutSpan takes [from, to) out of the spans
+func withoutSpan(spans []timeSpan, from, to time.Time) []timeSpan {
+	kept := []timeSpan{}
+	for _, s := range spans {
+		if !s.StartsAt.Before(to) || !s.EndsAt.After(from) {
+			kept = append(kept, s)
+			continue
+		}
+		if s.StartsAt.Before(from) {
+			kept = append(kept, timeSpan{StartsAt: s.StartsAt, EndsAt: from})
+		}
+		if s.EndsAt.After(to) {
+			kept = append(kept, timeSpan{StartsAt: to, EndsAt: s.EndsAt})
+		}
+	}
+	return kept
+}
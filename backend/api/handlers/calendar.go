@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"clinic/backend/internal/calendar"
+	"clinic/backend/internal/database"
+)
+
+// GetCalendar lays out a week (?view=week, the default) or month (?view=month)
+// around ?date= (default today) by doctor and day, with counts and how full
+// each day is. All the appointments are fetched in one query. ?doctorId= narrows
+// it to one doctor, ?type= to one appointment type, and ?summary=true leaves
+// the appointments out, for month overviews.
+func (h *AppointmentHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	view := q.Get("view")
+	if view == "" {
+		view = calendar.ViewWeek
+	}
+	day := localNow(r)
+	if s := q.Get("date"); s != "" {
+		d, err := time.ParseInLocation("2006-01-02", s, day.Location())
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		day = d
+	}
+	from, to, ok := calendar.Range(view, day)
+	if !ok {
+		http.Error(w, "view must be week or month", http.StatusBadRequest)
+		return
+	}
+
+	filter := database.AppointmentFilter{From: from, To: to, Type: q.Get("type")}
+	if s := q.Get("doctorId"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid doctorId", http.StatusBadRequest)
+			return
+		}
+		filter.DoctorID = id
+	}
+
+	appointments, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+
+	var doctors []database.Doctor
+	if filter.DoctorID != 0 {
+		doctor, err := h.doctors.GetByID(filter.DoctorID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve doctor")
+			return
+		}
+		doctors = append(doctors, *doctor)
+	} else {
+		doctors, err = h.doctors.GetAll(database.DoctorFilter{ActiveOnly: true})
+		if err != nil {
+			writeError(w, err, "Failed to retrieve doctors")
+			return
+		}
+	}
+
+	summaryOnly := q.Get("summary") == "true"
+	if !summaryOnly {
+		h.styleList(appointments)
+	}
+	writeJSON(w, http.StatusOK, calendar.Build(view, from, to, appointments, doctors, summaryOnly))
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/campaign"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// maxRegistrationListSize caps uploaded pre-registration lists at 5 MB
+const maxRegistrationListSize = 5 << 20
+
+// CampaignRepository interface for campaign storage
+type CampaignRepository interface {
+	CreateCampaign(c *database.Campaign) error
+	GetCampaign(id int) (*database.Campaign, error)
+	GetCampaigns() ([]database.Campaign, error)
+	UpdateCampaignStatus(id int, status string) error
+	AddRegistrations(regs []database.CampaignRegistration) error
+	GetRegistrations(campaignID int) ([]database.CampaignRegistration, error)
+	UpdateRegistration(reg *database.CampaignRegistration) error
+}
+
+// CampaignSlotRepository is the group-session storage campaign slots live in
+type CampaignSlotRepository interface {
+	CreateSession(s *database.GroupSession) error
+	GetSessionsByCampaign(campaignID int) ([]database.GroupSession, error)
+	UpdateSessionStatus(id int, status string) error
+	Book(b *database.GroupBooking) error
+	GetBookings(sessionID int) ([]database.GroupBooking, error)
+}
+
+// CampaignHandler handles vaccination campaign (capacity surge) requests
+type CampaignHandler struct {
+	repo     CampaignRepository
+	slots    CampaignSlotRepository
+	patients PatientRepository
+}
+
+// NewCampaignHandler creates a new campaign handler
+func NewCampaignHandler(repo CampaignRepository, slots CampaignSlotRepository, patients PatientRepository) *CampaignHandler {
+	return &CampaignHandler{repo: repo, slots: slots, patients: patients}
+}
+
+// CreateCampaign creates a draft campaign; slots open with OpenCampaign
+func (h *CampaignHandler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	var c database.Campaign
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if c.Name == "" || c.SessionType == "" {
+		http.Error(w, "name and sessionType are required", http.StatusBadRequest)
+		return
+	}
+	if c.SlotCapacity < 1 {
+		http.Error(w, "slotCapacity must be at least 1", http.StatusBadRequest)
+		return
+	}
+	if _, err := campaign.Slots(&c, reqctx.Location(r.Context())); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.Status = database.CampaignStatusDraft
+	if err := h.repo.CreateCampaign(&c); err != nil {
+		writeError(w, err, "Failed to create campaign")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// GetCampaigns returns all campaigns
+func (h *CampaignHandler) GetCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := h.repo.GetCampaigns()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve campaigns")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, campaigns)
+}
+
+// GetCampaign returns a campaign with its generated slots
+func (h *CampaignHandler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCampaign(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.slots.GetSessionsByCampaign(c.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve campaign slots")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"campaign": c,
+		"slots":    sessions,
+	})
+}
+
+// OpenCampaign generates the campaign's extra slots and opens them for booking
+func (h *CampaignHandler) OpenCampaign(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCampaign(w, r)
+	if !ok {
+		return
+	}
+	if c.Status != database.CampaignStatusDraft {
+		http.Error(w, "Only draft campaigns can be opened", http.StatusConflict)
+		return
+	}
+
+	slots, err := campaign.Slots(c, reqctx.Location(r.Context()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for i := range slots {
+		if err := h.slots.CreateSession(&slots[i]); err != nil {
+			writeError(w, err, "Failed to create campaign slots")
+			return
+		}
+	}
+
+	if err := h.repo.UpdateCampaignStatus(c.ID, database.CampaignStatusOpen); err != nil {
+		writeError(w, err, "Failed to open campaign")
+		return
+	}
+	c.Status = database.CampaignStatusOpen
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"campaign":     c,
+		"slotsCreated": len(slots),
+	})
+}
+
+// CloseCampaign ends the campaign and cancels its remaining empty future slots
+func (h *CampaignHandler) CloseCampaign(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCampaign(w, r)
+	if !ok {
+		return
+	}
+	if c.Status != database.CampaignStatusOpen {
+		http.Error(w, "Only open campaigns can be closed", http.StatusConflict)
+		return
+	}
+
+	sessions, err := h.slots.GetSessionsByCampaign(c.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve campaign slots")
+		return
+	}
+	now := time.Now()
+	cancelled := 0
+	for _, s := range sessions {
+		if s.Status == database.SessionStatusScheduled && s.BookedCount == 0 && s.StartsAt.After(now) {
+			if err := h.slots.UpdateSessionStatus(s.ID, database.SessionStatusCancelled); err != nil {
+				writeError(w, err, "Failed to cancel empty slots")
+				return
+			}
+			cancelled++
+		}
+	}
+
+	if err := h.repo.UpdateCampaignStatus(c.ID, database.CampaignStatusClosed); err != nil {
+		writeError(w, err, "Failed to close campaign")
+		return
+	}
+	c.Status = database.CampaignStatusClosed
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"campaign":       c,
+		"slotsCancelled": cancelled,
+	})
+}
+
+// ImportRegistrations imports a pre-registration list (CSV with hn/name/phone/preferred date).
+// Accepts a multipart "file" field or a raw text/csv body. Rows without an HN
+// are matched to existing patients by phone number.
+func (h *CampaignHandler) ImportRegistrations(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCampaign(w, r)
+	if !ok {
+		return
+	}
+	if c.Status == database.CampaignStatusClosed {
+		http.Error(w, "Campaign is closed", http.StatusConflict)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRegistrationListSize)
+	var src io.Reader = r.Body
+	if file, _, err := r.FormFile("file"); err == nil {
+		defer file.Close()
+		src = file
+	}
+
+	regs, err := campaign.ParseRegistrationCSV(src, c.ID)
+	if err != nil {
+		http.Error(w, "Invalid registration list: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	patients, err := h.patients.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to load patients")
+		return
+	}
+	byHN := make(map[string]bool)
+	byPhone := make(map[string]string)
+	for _, p := range patients {
+		byHN[p.HN] = true
+		if p.Phone != nil {
+			byPhone[normalizePhone(*p.Phone)] = p.HN
+		}
+	}
+	for i := range regs {
+		reg := &regs[i]
+		if reg.PatientHN == nil && reg.Phone != nil {
+			if hn, found := byPhone[normalizePhone(*reg.Phone)]; found {
+				reg.PatientHN = &hn
+			}
+		}
+		if reg.PatientHN == nil || !byHN[*reg.PatientHN] {
+			reg.PatientHN = nil
+			reg.Status = database.RegistrationStatusUnmatched
+		}
+	}
+
+	if err := h.repo.AddRegistrations(regs); err != nil {
+		writeError(w, err, "Failed to store registrations")
+		return
+	}
+
+	unmatched := 0
+	for _, reg := range regs {
+		if reg.Status == database.RegistrationStatusUnmatched {
+			unmatched++
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"imported":  len(regs),
+		"unmatched": unmatched,
+	})
+}
+
+// GetRegistrations returns a campaign's pre-registrations
+func (h *CampaignHandler) GetRegistrations(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCampaign(w, r)
+	if !ok {
+		return
+	}
+
+	regs, err := h.repo.GetRegistrations(c.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve registrations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, regs)
+}
+
+// AssignRegistrations books every matched, unbooked registration into the
+// earliest slot with room, on the preferred date when one was given
+func (h *CampaignHandler) AssignRegistrations(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCampaign(w, r)
+	if !ok {
+		return
+	}
+	if c.Status != database.CampaignStatusOpen {
+		http.Error(w, "Campaign is not open", http.StatusConflict)
+		return
+	}
+
+	regs, err := h.repo.GetRegistrations(c.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve registrations")
+		return
+	}
+	sessions, err := h.slots.GetSessionsByCampaign(c.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve campaign slots")
+		return
+	}
+
+	now := time.Now()
+	assigned, unassigned := 0, 0
+	for i := range regs {
+		reg := &regs[i]
+		if reg.Status != database.RegistrationStatusRegistered || reg.PatientHN == nil {
+			continue
+		}
+
+		booked := false
+		for j := range sessions {
+			s := &sessions[j]
+			if s.Status != database.SessionStatusScheduled || !s.StartsAt.After(now) || s.BookedCount >= s.Capacity {
+				continue
+			}
+			if reg.PreferredDate != nil && s.StartsAt.Format("2006-01-02") != *reg.PreferredDate {
+				continue
+			}
+
+			booking := database.GroupBooking{SessionID: s.ID, PatientHN: *reg.PatientHN, Status: database.BookingStatusBooked}
+			if err := h.slots.Book(&booking); err != nil {
+				// Filled up concurrently; try the next slot
+				s.BookedCount = s.Capacity
+				continue
+			}
+			s.BookedCount++
+
+			reg.Status = database.RegistrationStatusBooked
+			reg.SessionID = &s.ID
+			reg.BookingID = &booking.ID
+			if err := h.repo.UpdateRegistration(reg); err != nil {
+				writeError(w, err, "Failed to update registration")
+				return
+			}
+			booked = true
+			break
+		}
+
+		if booked {
+			assigned++
+		} else {
+			unassigned++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"assigned":   assigned,
+		"unassigned": unassigned,
+	})
+}
+
+// GetDashboard returns live throughput figures for the campaign
+func (h *CampaignHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.loadCampaign(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.slots.GetSessionsByCampaign(c.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve campaign slots")
+		return
+	}
+	bookings := make(map[int][]database.GroupBooking)
+	for _, s := range sessions {
+		if bookings[s.ID], err = h.slots.GetBookings(s.ID); err != nil {
+			writeError(w, err, "Failed to retrieve bookings")
+			return
+		}
+	}
+	regs, err := h.repo.GetRegistrations(c.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve registrations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, campaign.BuildDashboard(c, sessions, bookings, regs, time.Now()))
+}
+
+func (h *CampaignHandler) loadCampaign(w http.ResponseWriter, r *http.Request) (*database.Campaign, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	c, err := h.repo.GetCampaign(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve campaign")
+		return nil, false
+	}
+
+	return c, true
+}
+
+// normalizePhone strips formatting so "081-234-5678" matches "0812345678"
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// cancelCodePattern keeps reason codes short and stable, e.g. "doctor_unavailable"
+var cancelCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// unspecifiedReason is what the report counts cancellations recorded without
+// a reason code, or with one no longer in the taxonomy, as
+const unspecifiedReason = "unspecified"
+
+// CancellationReasonSource provides the clinic's configured cancellation reasons
+type CancellationReasonSource interface {
+	GetAll() ([]database.CancellationReason, error)
+}
+
+// CancellationReasonRepository interface for the clinic's cancellation reasons
+type CancellationReasonRepository interface {
+	GetAll() ([]database.CancellationReason, error)
+	Set(c *database.CancellationReason) error
+}
+
+// CancelledVisits provides the visits cancelled in a period, for reports
+type CancelledVisits interface {
+	GetCancelledBetween(from, to time.Time) ([]database.Encounter, error)
+}
+
+// CancelledAppointments provides the appointments cancelled in a period, for reports
+type CancelledAppointments interface {
+	List(f database.AppointmentFilter) ([]database.Appointment, error)
+}
+
+// CancellationHandler handles the cancellation reason taxonomy and what
+// appointments and visits were cancelled for
+type CancellationHandler struct {
+	reasons      CancellationReasonRepository
+	appointments CancelledAppointments
+	visits       CancelledVisits
+}
+
+// NewCancellationHandler creates a new cancellation handler
+func NewCancellationHandler(reasons CancellationReasonRepository, appointments CancelledAppointments, visits CancelledVisits) *CancellationHandler {
+	return &CancellationHandler{reasons: reasons, appointments: appointments, visits: visits}
+}
+
+// cancelRequest cancels an appointment or visit
+type cancelRequest struct {
+	ReasonCode string  `json:"reasonCode"`
+	Reason     *string `json:"reason,omitempty"` // more about what happened
+}
+
+// decodeCancellation reads why an appointment or visit (kind) is being
+// cancelled: reasonCode must be an active reason that applies to kind, and
+// reasons that ask for a note need reason too
+func decodeCancellation(w http.ResponseWriter, r *http.Request, reasons CancellationReasonSource, kind string) (*database.Cancellation, bool) {
+	var req cancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	return checkCancellation(w, reasons, kind, req)
+}
+
+// checkCancellation validates a cancellation request as decodeCancellation does
+func checkCancellation(w http.ResponseWriter, reasons CancellationReasonSource, kind string, req cancelRequest) (*database.Cancellation, bool) {
+	cancel := database.Cancellation{ReasonCode: strings.TrimSpace(req.ReasonCode)}
+	if req.Reason != nil {
+		cancel.Note = optionalText(*req.Reason)
+	}
+	if cancel.ReasonCode == "" {
+		http.Error(w, "reasonCode is required (see /api/cancellation-reasons)", http.StatusBadRequest)
+		return nil, false
+	}
+
+	configured, err := reasons.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve cancellation reasons")
+		return nil, false
+	}
+	reason, ok := database.NewCancellationReasons(configured)[cancel.ReasonCode]
+	if !ok || !reason.Active || !reason.AppliesToKind(kind) {
+		http.Error(w, "reasonCode is not an active cancellation reason for "+kind+"s", http.StatusBadRequest)
+		return nil, false
+	}
+	if reason.NoteRequired && cancel.Note == nil {
+		http.Error(w, "reason is required when cancelling for "+reason.Code, http.StatusBadRequest)
+		return nil, false
+	}
+	return &cancel, true
+}
+
+// GetCancellationReasons lists the effective cancellation reasons in the order
+// forms offer them; ?for=appointment|visit keeps those that apply, and
+// ?active=true leaves out retired ones. Built-in defaults have no updatedAt.
+func (h *CancellationHandler) GetCancellationReasons(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("for")
+	if kind != "" && kind != database.CancelForAppointment && kind != database.CancelForVisit {
+		http.Error(w, "for must be appointment or visit", http.StatusBadRequest)
+		return
+	}
+	activeOnly := r.URL.Query().Get("active") == "true"
+
+	configured, err := h.reasons.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve cancellation reasons")
+		return
+	}
+
+	reasons := []database.CancellationReason{}
+	for _, c := range database.NewCancellationReasons(configured).Sorted() {
+		if (kind != "" && !c.AppliesToKind(kind)) || (activeOnly && !c.Active) {
+			continue
+		}
+		reasons = append(reasons, c)
+	}
+
+	writeJSON(w, http.StatusOK, reasons)
+}
+
+// SetCancellationReason adds a cancellation reason or changes an existing one.
+// Reasons cannot be deleted, since cancellations keep their code; set active
+// to false to stop offering one.
+func (h *CancellationHandler) SetCancellationReason(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+	if !cancelCodePattern.MatchString(code) {
+		http.Error(w, "code must be lowercase letters, digits and underscores, e.g. doctor_unavailable", http.StatusBadRequest)
+		return
+	}
+	if code == unspecifiedReason {
+		http.Error(w, "unspecified is reserved for cancellations without a reason", http.StatusBadRequest)
+		return
+	}
+
+	var reason database.CancellationReason
+	if err := json.NewDecoder(r.Body).Decode(&reason); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	reason.Code = code
+	reason.Label = strings.TrimSpace(reason.Label)
+	switch {
+	case reason.Label == "":
+		http.Error(w, "label is required", http.StatusBadRequest)
+		return
+	case !oneOf(reason.AppliesTo, database.CancellationReasonScopes):
+		http.Error(w, "appliesTo must be appointment, visit or both", http.StatusBadRequest)
+		return
+	case !oneOf(reason.Initiator, database.CancellationInitiators):
+		http.Error(w, "initiator must be patient, clinic or other", http.StatusBadRequest)
+		return
+	}
+	reason.UpdatedBy = nil
+	if by := reqctx.UserName(r.Context()); by != "" {
+		reason.UpdatedBy = &by
+	}
+
+	if err := h.reasons.Set(&reason); err != nil {
+		writeError(w, err, "Failed to update cancellation reason")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reason)
+}
+
+// CancellationCount is how often one reason was given
+type CancellationCount struct {
+	Code         string `json:"code"`
+	Label        string `json:"label"`
+	Initiator    string `json:"initiator"`
+	Avoidable    bool   `json:"avoidable"`
+	Appointments int    `json:"appointments"`
+	Visits       int    `json:"visits"`
+	Total        int    `json:"total"`
+}
+
+// CancellationPeriod is one week or month of the cancellation report
+type CancellationPeriod struct {
+	Start        string         `json:"start"` // the period's first day
+	Appointments int            `json:"appointments"`
+	Visits       int            `json:"visits"`
+	Avoidable    int            `json:"avoidable"`
+	ByReason     map[string]int `json:"byReason"` // by reason code
+}
+
+// CancellationReport is what appointments and visits were cancelled for in a
+// period; dates are inclusive
+type CancellationReport struct {
+	From         string               `json:"from"`
+	To           string               `json:"to"`
+	Interval     string               `json:"interval"` // week or month
+	Appointments int                  `json:"appointments"`
+	Visits       int                  `json:"visits"`
+	Avoidable    int                  `json:"avoidable"`
+	Reasons      []CancellationCount  `json:"reasons"` // most given first
+	Periods      []CancellationPeriod `json:"periods"`
+}
+
+// GetCancellationReport counts the appointments and visits cancelled in
+// ?from=&to= (default this month) by reason, in total and per
+// ?interval=week|month (default week; weeks start on Monday). Cancellations
+// without a reason code are counted as unspecified.
+func (h *CancellationHandler) GetCancellationReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !to.After(from) {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "week"
+	}
+	if interval != "week" && interval != "month" {
+		http.Error(w, "interval must be week or month", http.StatusBadRequest)
+		return
+	}
+	loc := reqctx.Location(r.Context())
+
+	configured, err := h.reasons.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve cancellation reasons")
+		return
+	}
+	appointments, err := h.appointments.List(database.AppointmentFilter{
+		Status: database.AppointmentCancelled, CancelledFrom: from, CancelledTo: to,
+	})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+	visits, err := h.visits.GetCancelledBetween(from, to)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visits")
+		return
+	}
+
+	report := CancellationReport{
+		From:     from.Format("2006-01-02"),
+		To:       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Interval: interval,
+		Reasons:  []CancellationCount{},
+		Periods:  []CancellationPeriod{},
+	}
+	periods := map[string]int{}
+	for start := periodStart(from, interval, loc); start.Before(to); start = nextPeriod(start, interval) {
+		key := start.Format("2006-01-02")
+		periods[key] = len(report.Periods)
+		report.Periods = append(report.Periods, CancellationPeriod{Start: key, ByReason: map[string]int{}})
+	}
+
+	reasons := database.NewCancellationReasons(configured)
+	counts := map[string]*CancellationCount{}
+	count := func(code *string, at time.Time, visit bool) {
+		reason, ok := database.CancellationReason{}, false
+		if code != nil {
+			reason, ok = reasons[*code]
+		}
+		if !ok {
+			reason = database.CancellationReason{Code: unspecifiedReason, Label: "ไม่ระบุ", Initiator: "other"}
+		}
+		c := counts[reason.Code]
+		if c == nil {
+			c = &CancellationCount{Code: reason.Code, Label: reason.Label, Initiator: reason.Initiator, Avoidable: reason.Avoidable}
+			counts[reason.Code] = c
+		}
+		period := &report.Periods[periods[periodStart(at, interval, loc).Format("2006-01-02")]]
+		period.ByReason[reason.Code]++
+		if visit {
+			c.Visits++
+			period.Visits++
+			report.Visits++
+		} else {
+			c.Appointments++
+			period.Appointments++
+			report.Appointments++
+		}
+		c.Total++
+		if reason.Avoidable {
+			period.Avoidable++
+			report.Avoidable++
+		}
+	}
+	for _, a := range appointments {
+		count(a.CancelReasonCode, *a.CancelledAt, false)
+	}
+	for _, v := range visits {
+		count(v.CancelReasonCode, *v.EndedAt, true)
+	}
+
+	for _, c := range counts {
+		report.Reasons = append(report.Reasons, *c)
+	}
+	sort.Slice(report.Reasons, func(i, j int) bool {
+		a, b := report.Reasons[i], report.Reasons[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Code < b.Code
+	})
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// periodStart is the first day of the week (from Monday) or month t is in
+func periodStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if interval == "month" {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+func nextPeriod(start time.Time, interval string) time.Time {
+	if interval == "month" {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+func oneOf(v string, values []string) bool {
+	for _, known := range values {
+		if v == known {
+			return true
+		}
+	}
+	return false
+}
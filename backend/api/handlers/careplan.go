@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"clinic/backend/internal/careplan"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// CarePlanRepository interface for care plan goal storage
+type CarePlanRepository interface {
+	CreateGoal(g *database.CarePlanGoal) error
+	GetGoal(id int) (*database.CarePlanGoal, error)
+	GetGoalsByPatient(hn string) ([]database.CarePlanGoal, error)
+	UpdateGoalStatus(id int, status string) error
+	AddCheckpoint(c *database.GoalCheckpoint) error
+	GetCheckpoints(goalID int) ([]database.GoalCheckpoint, error)
+}
+
+// CarePlanHandler handles care plan goal tracking requests
+type CarePlanHandler struct {
+	repo         CarePlanRepository
+	patients     PatientRepository
+	measurements careplan.MeasurementSource
+}
+
+// NewCarePlanHandler creates a new care plan handler.
+// With a nil measurement source, checkpoints are only recorded manually.
+func NewCarePlanHandler(repo CarePlanRepository, patients PatientRepository, measurements careplan.MeasurementSource) *CarePlanHandler {
+	return &CarePlanHandler{repo: repo, patients: patients, measurements: measurements}
+}
+
+// GoalWithProgress is a goal together with its checkpoints and computed progress
+type GoalWithProgress struct {
+	database.CarePlanGoal
+	Checkpoints []database.GoalCheckpoint `json:"checkpoints"`
+	Progress    careplan.Progress         `json:"progress"`
+}
+
+// CreateGoal sets a new goal for a patient
+func (h *CarePlanHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var goal database.CarePlanGoal
+	if err := json.NewDecoder(r.Body).Decode(&goal); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if goal.Metric == "" || goal.CreatedBy == "" {
+		http.Error(w, "metric and createdBy are required", http.StatusBadRequest)
+		return
+	}
+	if goal.Baseline == goal.Target {
+		http.Error(w, "target must differ from baseline", http.StatusBadRequest)
+		return
+	}
+	if goal.StartDate == "" {
+		goal.StartDate = today(r)
+	}
+	start, err := time.Parse("2006-01-02", goal.StartDate)
+	if err != nil {
+		http.Error(w, "startDate must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse("2006-01-02", goal.TargetDate)
+	if err != nil || !end.After(start) {
+		http.Error(w, "targetDate must be a YYYY-MM-DD date after startDate", http.StatusBadRequest)
+		return
+	}
+	if goal.CheckEveryDays < 0 {
+		http.Error(w, "checkEveryDays cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	goal.PatientHN = hn
+	goal.Status = database.GoalStatusActive
+	if err := h.repo.CreateGoal(&goal); err != nil {
+		writeError(w, err, "Failed to create goal")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, goal)
+}
+
+// GetPatientGoals returns a patient's goals with progress, pulling any new
+// vitals/lab measurements in as checkpoints first
+func (h *CarePlanHandler) GetPatientGoals(w http.ResponseWriter, r *http.Request) {
+	goals, err := h.repo.GetGoalsByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve goals")
+		return
+	}
+
+	result := make([]GoalWithProgress, 0, len(goals))
+	for _, g := range goals {
+		gp, err := h.evaluate(&g, reqctx.Location(r.Context()))
+		if err != nil {
+			writeError(w, err, "Failed to compute goal progress")
+			return
+		}
+		result = append(result, *gp)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// GetGoal returns one goal with its checkpoints and progress
+func (h *CarePlanHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	goal, ok := h.loadGoal(w, r)
+	if !ok {
+		return
+	}
+
+	gp, err := h.evaluate(goal, reqctx.Location(r.Context()))
+	if err != nil {
+		writeError(w, err, "Failed to compute goal progress")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, gp)
+}
+
+// AddCheckpoint records a manual measurement against a goal
+func (h *CarePlanHandler) AddCheckpoint(w http.ResponseWriter, r *http.Request) {
+	goal, ok := h.loadGoal(w, r)
+	if !ok {
+		return
+	}
+	if goal.Status != database.GoalStatusActive {
+		http.Error(w, "Goal is no longer active", http.StatusConflict)
+		return
+	}
+
+	var checkpoint database.GoalCheckpoint
+	if err := json.NewDecoder(r.Body).Decode(&checkpoint); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if checkpoint.MeasuredAt.IsZero() {
+		checkpoint.MeasuredAt = time.Now()
+	}
+	checkpoint.GoalID = goal.ID
+	checkpoint.Source = database.CheckpointSourceManual
+
+	if err := h.repo.AddCheckpoint(&checkpoint); err != nil {
+		writeError(w, err, "Failed to record checkpoint")
+		return
+	}
+
+	gp, err := h.evaluate(goal, reqctx.Location(r.Context()))
+	if err != nil {
+		writeError(w, err, "Failed to compute goal progress")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, gp)
+}
+
+// UpdateGoalStatus marks a goal achieved or cancelled (or reactivates it)
+func (h *CarePlanHandler) UpdateGoalStatus(w http.ResponseWriter, r *http.Request) {
+	goal, ok := h.loadGoal(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Status {
+	case database.GoalStatusActive, database.GoalStatusAchieved, database.GoalStatusCancelled:
+	default:
+		http.Error(w, "status must be active, achieved or cancelled", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.UpdateGoalStatus(goal.ID, req.Status); err != nil {
+		writeError(w, err, "Failed to update goal")
+		return
+	}
+	goal.Status = req.Status
+
+	writeJSON(w, http.StatusOK, goal)
+}
+
+// GetTimeline returns the patient's care plan timeline, newest first
+func (h *CarePlanHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	goals, err := h.repo.GetGoalsByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve goals")
+		return
+	}
+
+	checkpoints := make(map[int][]database.GoalCheckpoint)
+	for i := range goals {
+		gp, err := h.evaluate(&goals[i], reqctx.Location(r.Context()))
+		if err != nil {
+			writeError(w, err, "Failed to retrieve checkpoints")
+			return
+		}
+		goals[i] = gp.CarePlanGoal
+		checkpoints[goals[i].ID] = gp.Checkpoints
+	}
+
+	writeJSON(w, http.StatusOK, careplan.Timeline(goals, checkpoints))
+}
+
+// evaluate syncs measurements into an active goal's checkpoints and computes
+// its progress, marking the goal achieved once the target is reached. The
+// start date counts from midnight in loc.
+func (h *CarePlanHandler) evaluate(goal *database.CarePlanGoal, loc *time.Location) (*GoalWithProgress, error) {
+	checkpoints, err := h.repo.GetCheckpoints(goal.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if goal.Status == database.GoalStatusActive && h.measurements != nil {
+		since, _ := time.ParseInLocation("2006-01-02", goal.StartDate, loc)
+		if len(checkpoints) > 0 {
+			since = checkpoints[len(checkpoints)-1].MeasuredAt
+		}
+
+		measured, err := h.measurements.Measurements(goal.PatientHN, goal.Metric, since)
+		if err != nil {
+			return nil, err
+		}
+		added := false
+		for _, m := range measured {
+			if !m.MeasuredAt.After(since) {
+				continue
+			}
+			c := database.GoalCheckpoint{GoalID: goal.ID, Value: m.Value, Source: m.Source, MeasuredAt: m.MeasuredAt}
+			if err := h.repo.AddCheckpoint(&c); err != nil {
+				return nil, err
+			}
+			added = true
+		}
+		if added {
+			if checkpoints, err = h.repo.GetCheckpoints(goal.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	progress := careplan.Evaluate(*goal, checkpoints, time.Now())
+	if goal.Status == database.GoalStatusActive && progress.Achieved {
+		if err := h.repo.UpdateGoalStatus(goal.ID, database.GoalStatusAchieved); err != nil {
+			return nil, err
+		}
+		goal.Status = database.GoalStatusAchieved
+	}
+
+	return &GoalWithProgress{CarePlanGoal: *goal, Checkpoints: checkpoints, Progress: progress}, nil
+}
+
+func (h *CarePlanHandler) loadGoal(w http.ResponseWriter, r *http.Request) (*database.CarePlanGoal, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid goal ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	goal, err := h.repo.GetGoal(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve goal")
+		return nil, false
+	}
+
+	return goal, true
+}
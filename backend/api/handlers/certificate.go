@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/esign"
+
+	"github.com/gorilla/mux"
+)
+
+// CertificateRepository interface for medical certificate storage
+type CertificateRepository interface {
+	NextNumber(year int) (string, error)
+	Create(c *database.MedicalCertificate) error
+	GetByNumber(number string) (*database.MedicalCertificate, error)
+	GetByPatient(hn string) ([]database.MedicalCertificate, error)
+	Revoke(number, reason string) error
+}
+
+// CertificateHandler handles medical certificate issuing and verification requests
+type CertificateHandler struct {
+	repo     CertificateRepository
+	patients PatientRepository
+}
+
+// NewCertificateHandler creates a new certificate handler
+func NewCertificateHandler(repo CertificateRepository, patients PatientRepository) *CertificateHandler {
+	return &CertificateHandler{repo: repo, patients: patients}
+}
+
+// CertificateVerification is the public view of a certificate. It confirms
+// authenticity without exposing the diagnosis or doctor's opinion.
+type CertificateVerification struct {
+	Valid             bool       `json:"valid"`
+	CertificateNumber string     `json:"certificateNumber,omitempty"`
+	Status            string     `json:"status,omitempty"`
+	PatientName       string     `json:"patientName,omitempty"` // masked
+	DoctorName        string     `json:"doctorName,omitempty"`
+	LicenseNumber     string     `json:"licenseNumber,omitempty"`
+	RestFrom          *string    `json:"restFrom,omitempty"`
+	RestTo            *string    `json:"restTo,omitempty"`
+	IssuedAt          *time.Time `json:"issuedAt,omitempty"`
+	Message           string     `json:"message"`
+}
+
+// IssueCertificate issues a new sequentially numbered certificate for a patient
+func (h *CertificateHandler) IssueCertificate(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var cert database.MedicalCertificate
+	if err := json.NewDecoder(r.Body).Decode(&cert); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if cert.DoctorName == "" || cert.LicenseNumber == "" {
+		http.Error(w, "doctorName and licenseNumber are required", http.StatusBadRequest)
+		return
+	}
+	for _, d := range []*string{cert.RestFrom, cert.RestTo} {
+		if d != nil {
+			if _, err := time.Parse("2006-01-02", *d); err != nil {
+				http.Error(w, "Rest dates must be YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	cert.PatientHN = hn
+	cert.IssuedAt = time.Now()
+	cert.Status = database.CertificateValid
+	cert.CertificateNumber, err = h.repo.NextNumber(cert.IssuedAt.Year())
+	if err != nil {
+		writeError(w, err, "Failed to number certificate")
+		return
+	}
+	cert.VerificationCode, err = esign.NewVerificationCode()
+	if err != nil {
+		writeError(w, err, "Failed to issue certificate")
+		return
+	}
+	cert.VerificationCode = cert.VerificationCode[:8]
+	cert.ContentHash = certificateHash(&cert)
+
+	if err := h.repo.Create(&cert); err != nil {
+		writeError(w, err, "Failed to issue certificate")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, cert)
+}
+
+// GetPatientCertificates returns all certificates issued to a patient
+func (h *CertificateHandler) GetPatientCertificates(w http.ResponseWriter, r *http.Request) {
+	certificates, err := h.repo.GetByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve certificates")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, certificates)
+}
+
+// GetCertificate returns a certificate by number (staff view, full content)
+func (h *CertificateHandler) GetCertificate(w http.ResponseWriter, r *http.Request) {
+	cert, err := h.repo.GetByNumber(mux.Vars(r)["number"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve certificate")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cert)
+}
+
+// RevokeCertificate revokes an issued certificate, e.g. when issued in error
+func (h *CertificateHandler) RevokeCertificate(w http.ResponseWriter, r *http.Request) {
+	number := mux.Vars(r)["number"]
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Reason == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Revoke(number, req.Reason); err != nil {
+		writeError(w, err, "Failed to revoke certificate")
+		return
+	}
+
+	cert, err := h.repo.GetByNumber(number)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve certificate")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cert)
+}
+
+// VerifyCertificate is the public endpoint for employers: ?number=&code=
+func (h *CertificateHandler) VerifyCertificate(w http.ResponseWriter, r *http.Request) {
+	number := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("number")))
+	code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+	if number == "" || code == "" {
+		http.Error(w, "number and code are required", http.StatusBadRequest)
+		return
+	}
+
+	notFound := CertificateVerification{Message: "ไม่พบใบรับรองแพทย์ที่ตรงกับเลขที่และรหัสนี้ (certificate not found)"}
+
+	cert, err := h.repo.GetByNumber(number)
+	if err != nil || cert.VerificationCode != code {
+		writeJSON(w, http.StatusNotFound, notFound)
+		return
+	}
+
+	result := CertificateVerification{
+		Valid:             cert.Status == database.CertificateValid && cert.ContentHash == certificateHash(cert),
+		CertificateNumber: cert.CertificateNumber,
+		Status:            cert.Status,
+		DoctorName:        cert.DoctorName,
+		LicenseNumber:     cert.LicenseNumber,
+		RestFrom:          cert.RestFrom,
+		RestTo:            cert.RestTo,
+		IssuedAt:          &cert.IssuedAt,
+	}
+	if id, err := parseHN(cert.PatientHN); err == nil {
+		if patient, err := h.patients.GetByID(id); err == nil {
+			result.PatientName = maskName(patient.FullName)
+		}
+	}
+
+	switch {
+	case result.Valid:
+		result.Message = "ใบรับรองแพทย์ฉบับนี้ออกโดยคลินิกจริง (certificate is authentic)"
+	case cert.Status == database.CertificateRevoked:
+		result.Message = "ใบรับรองแพทย์ฉบับนี้ถูกยกเลิกแล้ว (certificate has been revoked)"
+	default:
+		result.Message = "ข้อมูลใบรับรองแพทย์ไม่ตรงกับที่ออก (certificate record has been altered)"
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// certificateHash fingerprints the issued content so later edits are detectable
+func certificateHash(c *database.MedicalCertificate) string {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	content := strings.Join([]string{
+		c.CertificateNumber, c.PatientHN, c.DoctorName, c.LicenseNumber,
+		c.Diagnosis, c.Recommendation, deref(c.RestFrom), deref(c.RestTo),
+		c.IssuedAt.UTC().Format(time.RFC3339),
+	}, "\x1f")
+
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// maskName keeps the first name and shortens the rest to initials
+// (e.g., "นายสมชาย ใจดี" -> "นายสมชาย ใ.")
+func maskName(fullName string) string {
+	parts := strings.Fields(fullName)
+	for i := 1; i < len(parts); i++ {
+		parts[i] = fmt.Sprintf("%c.", []rune(parts[i])[0])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// maxChatMessageLength caps a chat message, in characters
+const maxChatMessageLength = 4000
+
+// ChatRepository interface for staff chat storage
+type ChatRepository interface {
+	CreateThread(t *database.ChatThread) error
+	GetThread(id int) (*database.ChatThread, error)
+	ListThreads(f database.ChatThreadFilter) ([]database.ChatThread, error)
+	PostMessage(m *database.ChatMessage) error
+	GetMessages(threadID int) ([]database.ChatMessage, error)
+	MarkRead(threadID int, reader string, upTo int) (*database.ChatReceipt, error)
+	GetMentions(staff string, unreadOnly bool) ([]database.ChatMessage, error)
+}
+
+// ChatHandler handles internal staff chat threads attached to patients and visits
+type ChatHandler struct {
+	repo     ChatRepository
+	patients PatientRepository
+	visits   EncounterRepository
+}
+
+// NewChatHandler creates a new chat handler
+func NewChatHandler(repo ChatRepository, patients PatientRepository, visits EncounterRepository) *ChatHandler {
+	return &ChatHandler{repo: repo, patients: patients, visits: visits}
+}
+
+// chatThreadView is a thread with its messages
+type chatThreadView struct {
+	*database.ChatThread
+	Messages []database.ChatMessage `json:"messages"`
+}
+
+// chatMessageRequest is a message as staff post it
+type chatMessageRequest struct {
+	Body     string   `json:"body"`
+	Mentions []string `json:"mentions"` // staff names to notify
+	Author   string   `json:"author"`
+}
+
+// message checks the request and turns it into a message for thread, returning what is wrong with it
+func (req *chatMessageRequest) message(threadID int) (*database.ChatMessage, string) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, "body is required"
+	}
+	if utf8.RuneCountInString(body) > maxChatMessageLength {
+		return nil, "body is too long"
+	}
+	if req.Author == "" {
+		return nil, "author is required"
+	}
+
+	var mentions []string
+	for _, name := range req.Mentions {
+		name = strings.TrimSpace(name)
+		duplicate := false
+		for _, m := range mentions {
+			duplicate = duplicate || strings.EqualFold(m, name)
+		}
+		if name != "" && !duplicate {
+			mentions = append(mentions, name)
+		}
+	}
+	return &database.ChatMessage{ThreadID: threadID, Author: req.Author, Body: body, Mentions: mentions}, ""
+}
+
+// CreateThread starts a thread about a patient, or one of their visits, with
+// an optional first message
+func (h *ChatHandler) CreateThread(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var req struct {
+		Subject   string `json:"subject"`
+		VisitID   *int   `json:"visitId"`
+		CreatedBy string `json:"createdBy"`
+		chatMessageRequest
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Subject = strings.TrimSpace(req.Subject)
+	if req.Subject == "" {
+		http.Error(w, "subject is required", http.StatusBadRequest)
+		return
+	}
+	if req.CreatedBy == "" {
+		req.CreatedBy = reqctx.UserName(r.Context())
+	}
+	if req.CreatedBy == "" {
+		http.Error(w, "createdBy is required", http.StatusBadRequest)
+		return
+	}
+	var first *database.ChatMessage
+	if strings.TrimSpace(req.Body) != "" {
+		req.Author = req.CreatedBy
+		var msg string
+		if first, msg = req.message(0); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.VisitID != nil {
+		visit, err := h.visits.GetByID(*req.VisitID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve visit")
+			return
+		}
+		if visit.PatientHN != hn {
+			http.Error(w, "Visit belongs to another patient", http.StatusBadRequest)
+			return
+		}
+	}
+
+	thread := database.ChatThread{PatientHN: hn, VisitID: req.VisitID, Subject: req.Subject, CreatedBy: req.CreatedBy}
+	if err := h.repo.CreateThread(&thread); err != nil {
+		writeError(w, err, "Failed to create chat thread")
+		return
+	}
+	view := chatThreadView{ChatThread: &thread, Messages: []database.ChatMessage{}}
+	if first != nil {
+		first.ThreadID = thread.ID
+		if err := h.repo.PostMessage(first); err != nil {
+			writeError(w, err, "Failed to post chat message")
+			return
+		}
+		thread.LastMessageAt = first.CreatedAt
+		view.Messages = append(view.Messages, *first)
+	}
+
+	writeJSON(w, http.StatusCreated, view)
+}
+
+// GetPatientThreads lists a patient's threads, most recently active first,
+// with the signed-in user's (or ?reader=) unread count on each
+func (h *ChatHandler) GetPatientThreads(w http.ResponseWriter, r *http.Request) {
+	h.listThreads(w, r, database.ChatThreadFilter{PatientHN: mux.Vars(r)["hn"]})
+}
+
+// GetVisitThreads lists the threads about a visit
+func (h *ChatHandler) GetVisitThreads(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	h.listThreads(w, r, database.ChatThreadFilter{VisitID: visitID})
+}
+
+func (h *ChatHandler) listThreads(w http.ResponseWriter, r *http.Request, filter database.ChatThreadFilter) {
+	filter.Reader = reqctx.UserName(r.Context())
+	if filter.Reader == "" {
+		filter.Reader = r.URL.Query().Get("reader")
+	}
+
+	threads, err := h.repo.ListThreads(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve chat threads")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, threads)
+}
+
+// GetThread returns a thread with its messages, oldest first, each with who has read it
+func (h *ChatHandler) GetThread(w http.ResponseWriter, r *http.Request) {
+	thread, ok := h.loadThread(w, r)
+	if !ok {
+		return
+	}
+
+	messages, err := h.repo.GetMessages(thread.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve chat messages")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, chatThreadView{ChatThread: thread, Messages: messages})
+}
+
+// PostMessage adds a message to a thread; author defaults to the signed-in user
+func (h *ChatHandler) PostMessage(w http.ResponseWriter, r *http.Request) {
+	var req chatMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Author == "" {
+		req.Author = reqctx.UserName(r.Context())
+	}
+
+	thread, ok := h.loadThread(w, r)
+	if !ok {
+		return
+	}
+	message, msg := req.message(thread.ID)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.PostMessage(message); err != nil {
+		writeError(w, err, "Failed to post chat message")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, message)
+}
+
+// MarkRead records that the signed-in user (or reader) has read a thread, up
+// to message upTo or to its latest message
+func (h *ChatHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reader string `json:"reader"`
+		UpTo   int    `json:"upTo"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Reader == "" {
+		req.Reader = reqctx.UserName(r.Context())
+	}
+	if req.Reader == "" {
+		http.Error(w, "reader is required", http.StatusBadRequest)
+		return
+	}
+
+	thread, ok := h.loadThread(w, r)
+	if !ok {
+		return
+	}
+	messages, err := h.repo.GetMessages(thread.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve chat messages")
+		return
+	}
+	latest := 0
+	if len(messages) > 0 {
+		latest = messages[len(messages)-1].ID
+	}
+	if req.UpTo == 0 || req.UpTo > latest {
+		req.UpTo = latest
+	}
+
+	receipt, err := h.repo.MarkRead(thread.ID, req.Reader, req.UpTo)
+	if err != nil {
+		writeError(w, err, "Failed to mark chat thread read")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, receipt)
+}
+
+// GetMentions lists the messages that mention the signed-in user (or
+// ?staff=), newest first; ?unread=true leaves out those already read
+func (h *ChatHandler) GetMentions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	staff := reqctx.UserName(r.Context())
+	if staff == "" {
+		staff = q.Get("staff")
+	}
+	if staff == "" {
+		http.Error(w, "Sign in or give ?staff= to see your mentions", http.StatusBadRequest)
+		return
+	}
+
+	messages, err := h.repo.GetMentions(staff, q.Get("unread") == "true")
+	if err != nil {
+		writeError(w, err, "Failed to retrieve chat mentions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, messages)
+}
+
+func (h *ChatHandler) loadThread(w http.ResponseWriter, r *http.Request) (*database.ChatThread, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid chat thread ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	thread, err := h.repo.GetThread(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve chat thread")
+		return nil, false
+	}
+	return thread, true
+}
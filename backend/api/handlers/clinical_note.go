@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+	"clinic/backend/internal/textdiff"
+)
+
+// ClinicalNoteRepository interface for clinical note storage
+type ClinicalNoteRepository interface {
+	Create(n *database.ClinicalNote) error
+	GetByID(id int) (*database.ClinicalNote, error)
+	GetByVisit(visitID int) ([]database.ClinicalNote, error)
+	GetPendingCosign(supervisor string) ([]database.ClinicalNote, error)
+	Cosign(n *database.ClinicalNote) error
+	HasPendingCosign(visitID int) (bool, error)
+	Amend(noteID int, content, amendedBy, reason string) (*database.ClinicalNote, error)
+	GetVersions(noteID int) ([]database.ClinicalNoteVersion, error)
+}
+
+// ClinicalNoteHandler handles clinical notes and trainee co-signing requests
+type ClinicalNoteHandler struct {
+	repo ClinicalNoteRepository
+}
+
+// NewClinicalNoteHandler creates a new clinical note handler
+func NewClinicalNoteHandler(repo ClinicalNoteRepository) *ClinicalNoteHandler {
+	return &ClinicalNoteHandler{repo: repo}
+}
+
+// CreateNote adds a note to a visit. Doctor notes are final immediately;
+// trainee and assistant notes wait for a supervisor's counter-signature. A
+// signed-in user is the author, and only doctors can write doctor notes.
+func (h *ClinicalNoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	var note database.ClinicalNote
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !signedInAuthor(w, r, &note.AuthorName, &note.AuthorRole) {
+		return
+	}
+	if note.PatientHN == "" || note.AuthorName == "" || note.Content == "" {
+		http.Error(w, "patientHn, authorName and content are required", http.StatusBadRequest)
+		return
+	}
+
+	if msg := checkAuthorRole(note.AuthorRole, note.SupervisorName); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	note.VisitID = visitID
+	note.Status = noteStatusFor(note.AuthorRole)
+	note.CosignedBy = nil
+	note.CosignerLicense = nil
+	note.CosignedAt = nil
+	if err := h.repo.Create(&note); err != nil {
+		writeError(w, err, "Failed to create clinical note")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, note)
+}
+
+// GetVisitNotes returns all notes of a visit
+func (h *ClinicalNoteHandler) GetVisitNotes(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	notes, err := h.repo.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve clinical notes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// GetCosignWorklist returns notes awaiting counter-signature, optionally ?supervisor=
+func (h *ClinicalNoteHandler) GetCosignWorklist(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.repo.GetPendingCosign(r.URL.Query().Get("supervisor"))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve cosign worklist")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// CosignNote finalizes a pending note with the signed-in doctor's counter-signature
+func (h *ClinicalNoteHandler) CosignNote(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	cosignedBy := reqctx.UserName(r.Context())
+	if cosignedBy == "" {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		LicenseNumber string `json:"licenseNumber"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.LicenseNumber == "" {
+		http.Error(w, "licenseNumber is required", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve clinical note")
+		return
+	}
+	if note.Status != database.NoteStatusPendingCosign {
+		http.Error(w, "Clinical note does not need a counter-signature", http.StatusConflict)
+		return
+	}
+	if cosignedBy == note.AuthorName {
+		http.Error(w, "Notes cannot be counter-signed by their author", http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	note.CosignedBy = &cosignedBy
+	note.CosignerLicense = &req.LicenseNumber
+	note.CosignedAt = &now
+	if err := h.repo.Cosign(note); err != nil {
+		writeError(w, err, "Failed to cosign clinical note")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, note)
+}
+
+// GetCosignStatus reports whether a visit can be billed, i.e. has no notes awaiting counter-signature
+func (h *ClinicalNoteHandler) GetCosignStatus(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	pending, err := h.repo.HasPendingCosign(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to check cosign status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"visitId":       visitID,
+		"pendingCosign": pending,
+		"billable":      !pending,
+	})
+}
+
+// NoteVersionInfo identifies one side of a note diff
+type NoteVersionInfo struct {
+	Version    int       `json:"version"`
+	AuthorName string    `json:"authorName"`
+	Reason     *string   `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// NoteDiff is a line-level comparison of two versions of a note
+type NoteDiff struct {
+	NoteID  int              `json:"noteId"`
+	From    NoteVersionInfo  `json:"from"`
+	To      NoteVersionInfo  `json:"to"`
+	Summary textdiff.Summary `json:"summary"`
+	Lines   []textdiff.Line  `json:"lines"`
+}
+
+// AmendNote records a new version of a final note. Earlier versions are kept
+// and the reason is required for the audit trail.
+func (h *ClinicalNoteHandler) AmendNote(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		AmendedBy string `json:"amendedBy"`
+		Content   string `json:"content"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.AmendedBy == "" || req.Content == "" || req.Reason == "" {
+		http.Error(w, "amendedBy, content and reason are required", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve clinical note")
+		return
+	}
+	if note.Status != database.NoteStatusFinal {
+		http.Error(w, "Only final notes can be amended", http.StatusConflict)
+		return
+	}
+	if note.Content == req.Content {
+		http.Error(w, "Amendment does not change the note", http.StatusBadRequest)
+		return
+	}
+
+	amended, err := h.repo.Amend(id, req.Content, req.AmendedBy, req.Reason)
+	if err != nil {
+		writeError(w, err, "Failed to amend clinical note")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, amended)
+}
+
+// GetNoteVersions returns every version of a note, oldest first
+func (h *ClinicalNoteHandler) GetNoteVersions(w http.ResponseWriter, r *http.Request) {
+	_, versions, ok := h.loadVersions(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, versions)
+}
+
+// GetNoteDiff compares two versions of a note (?from=&to=, default the
+// latest amendment against the version before it)
+func (h *ClinicalNoteHandler) GetNoteDiff(w http.ResponseWriter, r *http.Request) {
+	note, versions, ok := h.loadVersions(w, r)
+	if !ok {
+		return
+	}
+
+	to, err := versionParam(r, "to", note.Version)
+	if err != nil {
+		http.Error(w, "Invalid to version", http.StatusBadRequest)
+		return
+	}
+	from, err := versionParam(r, "from", to-1)
+	if err != nil {
+		http.Error(w, "Invalid from version", http.StatusBadRequest)
+		return
+	}
+	if from < 1 || to > len(versions) || from >= to {
+		http.Error(w, "Versions must satisfy 1 <= from < to <= "+strconv.Itoa(len(versions)), http.StatusBadRequest)
+		return
+	}
+
+	older, newer := versions[from-1], versions[to-1]
+	lines := textdiff.Lines(older.Content, newer.Content)
+	writeJSON(w, http.StatusOK, NoteDiff{
+		NoteID:  note.ID,
+		From:    versionInfo(older),
+		To:      versionInfo(newer),
+		Summary: textdiff.Summarize(lines),
+		Lines:   lines,
+	})
+}
+
+// loadVersions returns a note with its full version history; a never-amended
+// note has only its original content as version 1
+func (h *ClinicalNoteHandler) loadVersions(w http.ResponseWriter, r *http.Request) (*database.ClinicalNote, []database.ClinicalNoteVersion, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	note, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve clinical note")
+		return nil, nil, false
+	}
+
+	versions, err := h.repo.GetVersions(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve note versions")
+		return nil, nil, false
+	}
+	if len(versions) == 0 {
+		versions = []database.ClinicalNoteVersion{{
+			NoteID:     note.ID,
+			Version:    1,
+			Content:    note.Content,
+			AuthorName: note.AuthorName,
+			CreatedAt:  note.CreatedAt,
+		}}
+	}
+
+	return note, versions, true
+}
+
+func versionParam(r *http.Request, name string, fallback int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func versionInfo(v database.ClinicalNoteVersion) NoteVersionInfo {
+	return NoteVersionInfo{Version: v.Version, AuthorName: v.AuthorName, Reason: v.Reason, CreatedAt: v.CreatedAt}
+}
+
+// signedInAuthor makes a signed-in user the author of a note or draft: their
+// name replaces the one given, a doctor writes as a doctor and anyone else
+// cannot. Anonymous callers keep what they sent. It reports false after
+// writing the error.
+func signedInAuthor(w http.ResponseWriter, r *http.Request, name, role *string) bool {
+	info := reqctx.From(r.Context())
+	if !info.Authenticated() {
+		return true
+	}
+	*name = info.UserName
+	if info.HasRole(reqctx.RoleDoctor) {
+		*role = database.AuthorRoleDoctor
+		return true
+	}
+	if *role == database.AuthorRoleDoctor {
+		http.Error(w, "Only doctors can write notes that need no counter-signature", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// checkAuthorRole validates a note author's role, returning a message when it is unusable
+func checkAuthorRole(role string, supervisor *string) string {
+	switch role {
+	case database.AuthorRoleDoctor:
+		return ""
+	case database.AuthorRoleTrainee, database.AuthorRoleAssistant:
+		if supervisor == nil || *supervisor == "" {
+			return "supervisorName is required for trainee and assistant notes"
+		}
+		return ""
+	default:
+		return "authorRole must be doctor, trainee or assistant"
+	}
+}
+
+// noteStatusFor returns the initial status of a note: final for doctors, pending co-sign otherwise
+func noteStatusFor(role string) string {
+	if role == database.AuthorRoleDoctor {
+		return database.NoteStatusFinal
+	}
+	return database.NoteStatusPendingCosign
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"clinic/backend/internal/coding"
+	"clinic/backend/internal/database"
+)
+
+// DiagnosisCodeRepository interface for confirmed visit diagnosis code storage
+type DiagnosisCodeRepository interface {
+	Create(d *database.VisitDiagnosis) error
+	GetByVisit(visitID int) ([]database.VisitDiagnosis, error)
+	Delete(visitID, id int) error
+	CountByCode() (map[string]int, error)
+}
+
+// CodingHandler handles ICD-10 coding assistance requests
+type CodingHandler struct {
+	repo  DiagnosisCodeRepository
+	index *coding.Index
+	table *coding.Table
+}
+
+// NewCodingHandler creates a new coding handler over a suggestion index and
+// the ICD-10 table codes are validated against
+func NewCodingHandler(repo DiagnosisCodeRepository, index *coding.Index, table *coding.Table) *CodingHandler {
+	return &CodingHandler{repo: repo, index: index, table: table}
+}
+
+// SearchICD10 looks up ICD-10 codes by code prefix or words of their
+// description or Thai and English terms (?q=&limit=, default 20)
+func (h *CodingHandler) SearchICD10(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := 20
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	writeJSON(w, http.StatusOK, h.table.Search(q, limit))
+}
+
+// SuggestCodes ranks ICD-10 codes for a free-text diagnosis (?text=&limit=, default 5).
+// Suggestions are only candidates; the doctor confirms codes on the visit.
+func (h *CodingHandler) SuggestCodes(w http.ResponseWriter, r *http.Request) {
+	text := strings.TrimSpace(r.URL.Query().Get("text"))
+	if text == "" {
+		http.Error(w, "text is required", http.StatusBadRequest)
+		return
+	}
+	limit := 5
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	popularity, err := h.repo.CountByCode()
+	if err != nil {
+		writeError(w, err, "Failed to rank suggestions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.index.Suggest(text, limit, popularity))
+}
+
+// ConfirmCode records a code the doctor confirmed for a visit. The code must
+// be in the ICD-10 table, which supplies its description.
+func (h *CodingHandler) ConfirmCode(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	var d database.VisitDiagnosis
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	d.Code = coding.NormalizeCode(d.Code)
+	if d.PatientHN == "" || d.ConfirmedBy == "" {
+		http.Error(w, "patientHn and confirmedBy are required", http.StatusBadRequest)
+		return
+	}
+	if !coding.ValidCode(d.Code) {
+		http.Error(w, "code must be an ICD-10 code such as J06.9", http.StatusBadRequest)
+		return
+	}
+	entry, ok := h.table.Lookup(d.Code)
+	if !ok {
+		http.Error(w, "code "+d.Code+" is not in the ICD-10 table", http.StatusBadRequest)
+		return
+	}
+	d.Description = entry.Description
+
+	existing, err := h.repo.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve diagnosis codes")
+		return
+	}
+	for _, e := range existing {
+		if e.Code == d.Code {
+			http.Error(w, "Code is already recorded for this visit", http.StatusConflict)
+			return
+		}
+	}
+
+	d.VisitID = visitID
+	if err := h.repo.Create(&d); err != nil {
+		writeError(w, err, "Failed to record diagnosis code")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, d)
+}
+
+// GetVisitCodes returns the confirmed codes of a visit, primary first
+func (h *CodingHandler) GetVisitCodes(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.repo.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve diagnosis codes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, codes)
+}
+
+// RemoveCode removes a code from a visit
+func (h *CodingHandler) RemoveCode(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid diagnosis code ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(visitID, id); err != nil {
+		writeError(w, err, "Failed to delete diagnosis code")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// ColdChainRepository interface for fridge temperature monitoring storage
+type ColdChainRepository interface {
+	CreateFridge(f *database.Fridge) error
+	GetFridges() ([]database.Fridge, error)
+	GetFridge(id int) (*database.Fridge, error)
+	SetFridgeLots(fridgeID int, lots []database.FridgeLot) error
+	GetFridgeLots(fridgeID int) ([]database.FridgeLot, error)
+	CreateReading(reading *database.TemperatureReading) error
+	GetReadings(fridgeID int, from, to time.Time) ([]database.TemperatureReading, error)
+	GetOpenExcursion(fridgeID int) (*database.TemperatureExcursion, error)
+	SaveExcursion(e *database.TemperatureExcursion) error
+	GetExcursions(openOnly bool) ([]database.TemperatureExcursion, error)
+	CreateLotReviews(reviews []database.LotReview) error
+	GetLotReviews(status string) ([]database.LotReview, error)
+	GetLotReview(id int) (*database.LotReview, error)
+	UpdateLotReview(lr *database.LotReview) error
+}
+
+// ColdChainHandler handles fridge temperature logging and excursion review requests
+type ColdChainHandler struct {
+	repo ColdChainRepository
+}
+
+// NewColdChainHandler creates a new cold-chain handler
+func NewColdChainHandler(repo ColdChainRepository) *ColdChainHandler {
+	return &ColdChainHandler{repo: repo}
+}
+
+// CreateFridge registers a monitored fridge
+func (h *ColdChainHandler) CreateFridge(w http.ResponseWriter, r *http.Request) {
+	fridge := database.Fridge{MinTemp: 2, MaxTemp: 8}
+	if err := json.NewDecoder(r.Body).Decode(&fridge); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if fridge.Name == "" || fridge.MinTemp >= fridge.MaxTemp {
+		http.Error(w, "name is required and minTemp must be below maxTemp", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.CreateFridge(&fridge); err != nil {
+		writeError(w, err, "Failed to create fridge")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, fridge)
+}
+
+// GetFridges returns all monitored fridges
+func (h *ColdChainHandler) GetFridges(w http.ResponseWriter, r *http.Request) {
+	fridges, err := h.repo.GetFridges()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve fridges")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fridges)
+}
+
+// SetFridgeLots replaces the list of lots stored in a fridge
+func (h *ColdChainHandler) SetFridgeLots(w http.ResponseWriter, r *http.Request) {
+	fridge, ok := h.loadFridge(w, r)
+	if !ok {
+		return
+	}
+
+	var lots []database.FridgeLot
+	if err := json.NewDecoder(r.Body).Decode(&lots); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	for i := range lots {
+		if lots[i].ItemID == 0 || lots[i].LotNumber == "" {
+			http.Error(w, "each lot needs itemId and lotNumber", http.StatusBadRequest)
+			return
+		}
+		lots[i].FridgeID = fridge.ID
+	}
+
+	if err := h.repo.SetFridgeLots(fridge.ID, lots); err != nil {
+		writeError(w, err, "Failed to update fridge lots")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lots)
+}
+
+// GetFridgeLots returns the lots stored in a fridge
+func (h *ColdChainHandler) GetFridgeLots(w http.ResponseWriter, r *http.Request) {
+	fridge, ok := h.loadFridge(w, r)
+	if !ok {
+		return
+	}
+
+	lots, err := h.repo.GetFridgeLots(fridge.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve fridge lots")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lots)
+}
+
+// RecordReadings ingests one reading or a JSON array of readings from sensors
+// or manual logs. An out-of-range reading opens an excursion and puts every
+// lot in that fridge on hold for review; the next in-range reading closes it.
+func (h *ColdChainHandler) RecordReadings(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var readings []database.TemperatureReading
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &readings)
+	} else {
+		var reading database.TemperatureReading
+		err = json.Unmarshal(trimmed, &reading)
+		readings = append(readings, reading)
+	}
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	fridges := make(map[int]*database.Fridge)
+	for i := range readings {
+		t := &readings[i]
+		if _, ok := fridges[t.FridgeID]; !ok {
+			fridge, err := h.repo.GetFridge(t.FridgeID)
+			if err != nil {
+				writeError(w, err, "Failed to retrieve fridge")
+				return
+			}
+			fridges[t.FridgeID] = fridge
+		}
+		if t.RecordedAt.IsZero() {
+			t.RecordedAt = now
+		}
+		if t.Source == "" {
+			t.Source = database.ReadingSourceSensor
+		}
+		if t.Source == database.ReadingSourceManual && (t.RecordedBy == nil || *t.RecordedBy == "") {
+			http.Error(w, "recordedBy is required for manual readings", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Excursions are tracked in time order even when sensors upload late batches
+	sort.SliceStable(readings, func(i, j int) bool {
+		return readings[i].RecordedAt.Before(readings[j].RecordedAt)
+	})
+
+	alerts := []database.TemperatureExcursion{}
+	for i := range readings {
+		t := &readings[i]
+		fridge := fridges[t.FridgeID]
+		t.OutOfRange = t.Celsius < fridge.MinTemp || t.Celsius > fridge.MaxTemp
+		if err := h.repo.CreateReading(t); err != nil {
+			writeError(w, err, "Failed to store temperature reading")
+			return
+		}
+
+		opened, err := h.trackExcursion(fridge, t)
+		if err != nil {
+			writeError(w, err, "Failed to update temperature excursion")
+			return
+		}
+		if opened != nil {
+			alerts = append(alerts, *opened)
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"readings": readings,
+		"alerts":   alerts,
+	})
+}
+
+// trackExcursion updates the fridge's excursion state for a reading and
+// returns the excursion if this reading opened a new one
+func (h *ColdChainHandler) trackExcursion(fridge *database.Fridge, t *database.TemperatureReading) (*database.TemperatureExcursion, error) {
+	open, err := h.repo.GetOpenExcursion(fridge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.OutOfRange {
+		if open == nil {
+			return nil, nil
+		}
+		open.EndedAt = &t.RecordedAt
+		return nil, h.repo.SaveExcursion(open)
+	}
+
+	if open != nil {
+		open.ReadingCount++
+		if t.Celsius < open.MinCelsius {
+			open.MinCelsius = t.Celsius
+		}
+		if t.Celsius > open.MaxCelsius {
+			open.MaxCelsius = t.Celsius
+		}
+		return nil, h.repo.SaveExcursion(open)
+	}
+
+	excursion := &database.TemperatureExcursion{
+		FridgeID:     fridge.ID,
+		StartedAt:    t.RecordedAt,
+		MinCelsius:   t.Celsius,
+		MaxCelsius:   t.Celsius,
+		ReadingCount: 1,
+	}
+	if err := h.repo.SaveExcursion(excursion); err != nil {
+		return nil, err
+	}
+
+	lots, err := h.repo.GetFridgeLots(fridge.ID)
+	if err != nil {
+		return nil, err
+	}
+	reviews := make([]database.LotReview, 0, len(lots))
+	for _, lot := range lots {
+		reviews = append(reviews, database.LotReview{
+			ExcursionID: excursion.ID,
+			FridgeID:    fridge.ID,
+			ItemID:      lot.ItemID,
+			ItemName:    lot.ItemName,
+			LotNumber:   lot.LotNumber,
+			Status:      database.LotReviewPending,
+		})
+	}
+	if err := h.repo.CreateLotReviews(reviews); err != nil {
+		return nil, err
+	}
+
+	log.Printf("ALERT: fridge %q out of range at %.1f°C (allowed %.1f-%.1f°C), %d lots held for review",
+		fridge.Name, t.Celsius, fridge.MinTemp, fridge.MaxTemp, len(reviews))
+	return excursion, nil
+}
+
+// GetReadings returns a fridge's temperature log for ?from=&to=
+func (h *ColdChainHandler) GetReadings(w http.ResponseWriter, r *http.Request) {
+	fridge, ok := h.loadFridge(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	readings, err := h.repo.GetReadings(fridge.ID, from, to)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve temperature readings")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, readings)
+}
+
+// GetExcursions returns temperature excursions; ?open=true lists only ongoing alerts
+func (h *ColdChainHandler) GetExcursions(w http.ResponseWriter, r *http.Request) {
+	excursions, err := h.repo.GetExcursions(r.URL.Query().Get("open") == "true")
+	if err != nil {
+		writeError(w, err, "Failed to retrieve excursions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, excursions)
+}
+
+// GetLotReviews returns lot reviews, filtered by ?status=pending|released|discarded
+func (h *ColdChainHandler) GetLotReviews(w http.ResponseWriter, r *http.Request) {
+	reviews, err := h.repo.GetLotReviews(r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve lot reviews")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reviews)
+}
+
+// DecideLotReview releases a held lot for use or marks it discarded
+func (h *ColdChainHandler) DecideLotReview(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid lot review ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Decision   string  `json:"decision"`
+		ReviewedBy string  `json:"reviewedBy"`
+		Notes      *string `json:"notes,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Decision != database.LotReviewReleased && req.Decision != database.LotReviewDiscarded {
+		http.Error(w, "decision must be released or discarded", http.StatusBadRequest)
+		return
+	}
+	if req.ReviewedBy == "" {
+		http.Error(w, "reviewedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.repo.GetLotReview(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve lot review")
+		return
+	}
+	if review.Status != database.LotReviewPending {
+		http.Error(w, "Lot review has already been decided", http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	review.Status = req.Decision
+	review.ReviewedBy = &req.ReviewedBy
+	review.Notes = req.Notes
+	review.ReviewedAt = &now
+	if err := h.repo.UpdateLotReview(review); err != nil {
+		writeError(w, err, "Failed to update lot review")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, review)
+}
+
+func (h *ColdChainHandler) loadFridge(w http.ResponseWriter, r *http.Request) (*database.Fridge, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid fridge ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	fridge, err := h.repo.GetFridge(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve fridge")
+		return nil, false
+	}
+
+	return fridge, true
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultUncompressedTypes are the content types left as they are by default:
+// images, media and archives are compressed already, and event streams must
+// reach the client as they are written. Entries ending in "/" match a whole family.
+var DefaultUncompressedTypes = []string{
+	"image/", "video/", "audio/", "font/",
+	"application/pdf", "application/zip", "application/gzip", "application/octet-stream",
+	"text/event-stream",
+}
+
+// encoder is a pooled compressor for one content coding
+type encoder struct {
+	pool sync.Pool
+}
+
+func (e *encoder) get(w io.Writer) resettableWriter {
+	enc := e.pool.Get().(resettableWriter)
+	enc.Reset(w)
+	return enc
+}
+
+type resettableWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoders are the content codings offered, most preferred first. Brotli has
+// no encoder in the standard library; clients asking for br and gzip get gzip.
+var encoders = []struct {
+	name string
+	*encoder
+}{
+	{"gzip", &encoder{pool: sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}}}},
+	{"deflate", &encoder{pool: sync.Pool{New: func() interface{} {
+		w, _ := zlib.NewWriterLevel(io.Discard, flate.DefaultCompression)
+		return w
+	}}}},
+}
+
+// Compression compresses responses for clients that accept it, so large lists
+// and reports load faster on slow connections. Bodies under minSize bytes and
+// excluded content types are sent as they are.
+type Compression struct {
+	minSize  int
+	excluded []string
+}
+
+// NewCompression creates the compression middleware. excluded lists media
+// types, or families ending in "/", that are never compressed.
+func NewCompression(minSize int, excluded []string) *Compression {
+	c := &Compression{minSize: minSize}
+	for _, t := range excluded {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			c.excluded = append(c.excluded, t)
+		}
+	}
+	return c
+}
+
+// Middleware negotiates Accept-Encoding and compresses the response once it
+// is known to be large enough and of a compressible type
+func (c *Compression) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		coding, enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if enc == nil || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, c: c, coding: coding, encoder: enc}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// excludes reports whether responses of contentType are left uncompressed
+func (c *Compression) excludes(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return true
+	}
+	for _, t := range c.excluded {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding picks the offered coding with the highest q-value in an
+// Accept-Encoding header, gzip on a tie; nil when none is acceptable
+func negotiateEncoding(header string) (string, *encoder) {
+	if header == "" {
+		return "", nil
+	}
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := -1, 0.0
+	for i, e := range encoders {
+		q, ok := weights[e.name]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	if best < 0 {
+		return "", nil
+	}
+	return encoders[best].name, encoders[best].encoder
+}
+
+// compressWriter holds back the first minSize bytes of a response to decide
+// whether to compress it, then sends it compressed or as it is
+type compressWriter struct {
+	http.ResponseWriter
+	c       *Compression
+	coding  string
+	encoder *encoder
+	status  int
+	buf     []byte
+	decided bool
+	enc     resettableWriter // set when the response is being compressed
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < w.c.minSize {
+		return len(p), nil
+	}
+	if err := w.decide(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends what is held back, deciding on compression with what has been
+// written so far
+func (w *compressWriter) Flush() {
+	if !w.decided && w.status != 0 {
+		w.decide()
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header, compressing when the body held back is large
+// enough and compressible, then sends the body held back
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// sniff now, as net/http would, before the bytes it sees are compressed
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if len(w.buf) >= w.c.minSize && len(w.buf) > 0 && w.compressible() {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.coding)
+		w.enc = w.encoder.get(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	if w.status == http.StatusNoContent || w.status == http.StatusNotModified || w.status == http.StatusPartialContent {
+		return false
+	}
+	header := w.Header()
+	return header.Get("Content-Encoding") == "" && !w.c.excludes(header.Get("Content-Type"))
+}
+
+// close sends a response smaller than minSize and finishes a compressed one
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.status == 0 {
+			return
+		}
+		w.decide()
+	}
+	if w.enc != nil {
+		w.enc.Close()
+		w.enc.Reset(io.Discard)
+		w.encoder.pool.Put(w.enc)
+		w.enc = nil
+	}
+}
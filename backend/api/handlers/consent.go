@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+	"clinic/backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// maxSignatureSize caps uploaded consent signature images (2 MB)
+const maxSignatureSize = 2 << 20
+
+var signatureExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// ConsentRepository interface for patient consent storage
+type ConsentRepository interface {
+	Create(c *database.Consent) error
+	GetByID(id int) (*database.Consent, error)
+	GetByPatient(hn string, f database.ConsentFilter) ([]database.Consent, error)
+	SetSignature(c *database.Consent) error
+	Withdraw(c *database.Consent) error
+}
+
+// ConsentVerification answers whether a patient has an active consent of a
+// type, and version when one is required, e.g. before a procedure
+type ConsentVerification struct {
+	PatientHN string            `json:"patientHn"`
+	Type      string            `json:"type"`
+	Version   string            `json:"version,omitempty"`
+	Consented bool              `json:"consented"`
+	Reason    string            `json:"reason,omitempty"` // why not, e.g. "signed version 2025-01; version 2026-01 is required"
+	Consent   *database.Consent `json:"consent,omitempty"`
+}
+
+// ConsentHandler handles the consent forms on patient records
+type ConsentHandler struct {
+	repo     ConsentRepository
+	patients PatientRepository
+	store    DocumentStore
+}
+
+// NewConsentHandler creates a new consent handler. Signature images go to
+// store, the non-public document storage.
+func NewConsentHandler(repo ConsentRepository, patients PatientRepository, store DocumentStore) *ConsentHandler {
+	return &ConsentHandler{repo: repo, patients: patients, store: store}
+}
+
+// CreateConsent records a consent form the patient or their representative
+// signed; signedDate defaults to today, signerRelation to self and
+// recordedBy to the signed-in user
+func (h *ConsentHandler) CreateConsent(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	var consent database.Consent
+	if err := json.NewDecoder(r.Body).Decode(&consent); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	consent.PatientHN = patient.HN
+	consent.Status = database.ConsentActive
+	consent.SignatureKey, consent.SignatureType, consent.HasSignature = nil, nil, false
+	consent.WithdrawnAt, consent.WithdrawalReason = nil, nil
+	if consent.RecordedBy == "" {
+		consent.RecordedBy = reqctx.UserName(r.Context())
+	}
+	if msg := checkConsent(&consent, today(r)); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Create(&consent); err != nil {
+		writeError(w, err, "Failed to create consent")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, consent)
+}
+
+// GetPatientConsents lists a patient's consents, most recently signed first
+// (?type=, ?active=true leaves out withdrawn ones)
+func (h *ConsentHandler) GetPatientConsents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	consents, err := h.repo.GetByPatient(mux.Vars(r)["hn"], database.ConsentFilter{
+		Type:       query.Get("type"),
+		ActiveOnly: query.Get("active") == "true",
+	})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve consents")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, consents)
+}
+
+// GetConsent returns one consent
+func (h *ConsentHandler) GetConsent(w http.ResponseWriter, r *http.Request) {
+	consent, ok := h.loadConsent(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, consent)
+}
+
+// VerifyConsent checks a patient has an active consent of ?type=, signed on
+// form ?version= when given, before care that needs it goes ahead
+func (h *ConsentHandler) VerifyConsent(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	result := ConsentVerification{
+		PatientHN: patient.HN,
+		Type:      strings.ToLower(strings.TrimSpace(query.Get("type"))),
+		Version:   strings.TrimSpace(query.Get("version")),
+	}
+	if !knownConsentType(result.Type) {
+		http.Error(w, "type must be one of "+strings.Join(database.ConsentTypes, ", "), http.StatusBadRequest)
+		return
+	}
+
+	consents, err := h.repo.GetByPatient(patient.HN, database.ConsentFilter{Type: result.Type})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve consents")
+		return
+	}
+
+	var withdrawn, otherVersion *database.Consent
+	for i := range consents {
+		c := &consents[i]
+		switch {
+		case c.Status != database.ConsentActive:
+			if withdrawn == nil {
+				withdrawn = c
+			}
+		case result.Version != "" && c.Version != result.Version:
+			if otherVersion == nil {
+				otherVersion = c
+			}
+		default:
+			result.Consented = true
+			result.Consent = c
+		}
+		if result.Consented {
+			break
+		}
+	}
+
+	switch {
+	case result.Consented:
+	case otherVersion != nil:
+		result.Consent = otherVersion
+		result.Reason = "signed version " + otherVersion.Version + "; version " + result.Version + " is required"
+	case withdrawn != nil:
+		result.Consent = withdrawn
+		result.Reason = "consent was withdrawn on " + withdrawn.WithdrawnAt.Format("2006-01-02")
+	default:
+		result.Reason = "no " + result.Type + " consent on record"
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// WithdrawConsent records that a patient withdrew a consent, with an optional reason
+func (h *ConsentHandler) WithdrawConsent(w http.ResponseWriter, r *http.Request) {
+	consent, ok := h.loadConsent(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	consent.WithdrawalReason = optionalText(req.Reason)
+
+	if err := h.repo.Withdraw(consent); err != nil {
+		writeError(w, err, "Failed to withdraw consent")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, consent)
+}
+
+// UploadConsentSignature attaches the signature image from a multipart "file"
+// (JPEG, PNG or WebP), replacing any earlier one
+func (h *ConsentHandler) UploadConsentSignature(w http.ResponseWriter, r *http.Request) {
+	consent, ok := h.loadConsent(w, r)
+	if !ok {
+		return
+	}
+
+	file, ok := readUpload(w, r, maxSignatureSize, signatureExtensions, "a JPEG, PNG or WebP image")
+	if !ok {
+		return
+	}
+
+	name := make([]byte, 12)
+	if _, err := rand.Read(name); err != nil {
+		writeError(w, err, "Failed to store signature")
+		return
+	}
+	previous := consent.SignatureKey
+	key := "patients/" + consent.PatientHN + "/consents/" + hex.EncodeToString(name) + file.ext
+	if _, err := h.store.Put(r.Context(), key, file.contentType, file.data); err != nil {
+		writeError(w, err, "Failed to store signature")
+		return
+	}
+	consent.SignatureKey, consent.SignatureType = &key, &file.contentType
+	if err := h.repo.SetSignature(consent); err != nil {
+		if err := h.store.Delete(r.Context(), key); err != nil {
+			log.Printf("Failed to remove unrecorded signature %s: %v", key, err)
+		}
+		writeError(w, err, "Failed to update consent")
+		return
+	}
+	if previous != nil {
+		if err := h.store.Delete(r.Context(), *previous); err != nil {
+			log.Printf("Failed to remove replaced signature of consent %d (%s): %v", consent.ID, *previous, err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, consent)
+}
+
+// GetConsentSignature sends a consent's signature image
+func (h *ConsentHandler) GetConsentSignature(w http.ResponseWriter, r *http.Request) {
+	consent, ok := h.loadConsent(w, r)
+	if !ok {
+		return
+	}
+	if consent.SignatureKey == nil {
+		http.Error(w, "Consent has no signature image", http.StatusNotFound)
+		return
+	}
+
+	stored, err := h.store.Open(r.Context(), *consent.SignatureKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Signature image is missing from storage", http.StatusNotFound)
+			return
+		}
+		writeError(w, err, "Failed to open signature")
+		return
+	}
+	defer stored.Close()
+
+	w.Header().Set("Content-Type", *consent.SignatureType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, stored); err != nil {
+		log.Printf("Failed to send signature of consent %d: %v", consent.ID, err)
+	}
+}
+
+func (h *ConsentHandler) loadPatient(w http.ResponseWriter, r *http.Request) (*database.Patient, bool) {
+	id, err := parseHN(mux.Vars(r)["hn"])
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	return patient, true
+}
+
+// loadConsent loads the consent a request names; consents of other patients
+// are reported as not found
+func (h *ConsentHandler) loadConsent(w http.ResponseWriter, r *http.Request) (*database.Consent, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid consent ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	consent, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve consent")
+		return nil, false
+	}
+	if consent.PatientHN != mux.Vars(r)["hn"] {
+		http.Error(w, "Consent not found", http.StatusNotFound)
+		return nil, false
+	}
+	return consent, true
+}
+
+// checkConsent trims and validates a consent signed by day, returning what is wrong with it
+func checkConsent(c *database.Consent, day string) string {
+	c.Type = strings.ToLower(strings.TrimSpace(c.Type))
+	c.Version = strings.TrimSpace(c.Version)
+	c.SignerName = strings.TrimSpace(c.SignerName)
+	c.SignerRelation = strings.ToLower(strings.TrimSpace(c.SignerRelation))
+	if c.Procedure != nil {
+		c.Procedure = optionalText(*c.Procedure)
+	}
+	if c.Witness != nil {
+		c.Witness = optionalText(*c.Witness)
+	}
+	if !knownConsentType(c.Type) {
+		return "type must be one of " + strings.Join(database.ConsentTypes, ", ")
+	}
+	if c.Version == "" {
+		return "version is required"
+	}
+	if c.Type == "procedure" && c.Procedure == nil {
+		return "procedure is required for a procedure consent"
+	}
+	if c.SignerName == "" {
+		return "signerName is required"
+	}
+	if c.SignerRelation == "" {
+		c.SignerRelation = "self"
+	}
+	known := false
+	for _, relation := range database.ConsentSignerRelations {
+		known = known || c.SignerRelation == relation
+	}
+	if !known {
+		return "signerRelation must be one of " + strings.Join(database.ConsentSignerRelations, ", ")
+	}
+	if c.SignedDate == "" {
+		c.SignedDate = day
+	}
+	if _, err := time.Parse("2006-01-02", c.SignedDate); err != nil {
+		return "Invalid signedDate, expected YYYY-MM-DD"
+	}
+	if c.SignedDate > day {
+		return "signedDate cannot be in the future"
+	}
+	if c.RecordedBy == "" {
+		return "recordedBy is required"
+	}
+	return ""
+}
+
+func knownConsentType(t string) bool {
+	for _, known := range database.ConsentTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"clinic/backend/internal/database"
+)
+
+// LeaseLister lists the coordination leases shared by API instances
+type LeaseLister interface {
+	GetLeases() ([]database.Lease, error)
+}
+
+// Leadership reports whether this instance is the elected leader
+type Leadership interface {
+	IsLeader() bool
+	Token() int64
+}
+
+// CoordinationHandler reports multi-instance coordination state to administrators
+type CoordinationHandler struct {
+	leases   LeaseLister
+	leader   Leadership
+	instance string
+}
+
+// NewCoordinationHandler creates a new coordination handler
+func NewCoordinationHandler(leases LeaseLister, leader Leadership, instance string) *CoordinationHandler {
+	return &CoordinationHandler{leases: leases, leader: leader, instance: instance}
+}
+
+// GetCoordination returns this instance's identity and leadership along with every lease
+func (h *CoordinationHandler) GetCoordination(w http.ResponseWriter, r *http.Request) {
+	leases, err := h.leases.GetLeases()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve leases")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"instanceId":  h.instance,
+		"leader":      h.leader.IsLeader(),
+		"leaderToken": h.leader.Token(),
+		"leases":      leases,
+	})
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DemoModeHeader is set on every response while demo mode is on, so clients
+// can show that the names on screen are not real
+const DemoModeHeader = "X-Demo-Mode"
+
+// Placeholder names are drawn from these lists; none is meant to be anyone's.
+// First names follow the patient's gender when it is given.
+var (
+	demoFirstNames = map[string][]string{
+		"ชาย":  {"สมชาย", "ประเสริฐ", "มานะ", "ปิติ", "วีระ", "อำนาจ", "บุญมี", "ธนากร"},
+		"หญิง": {"สมหญิง", "วิไล", "มานี", "ชูใจ", "สุดา", "กาญจนา", "จันทร์เพ็ญ", "พรทิพย์"},
+		"":     {"สมชาย", "สมหญิง", "วิไล", "ประเสริฐ", "มานี", "มานะ", "ชูใจ", "ปิติ"},
+	}
+	demoLastNames = []string{
+		"ใจดี", "รักสงบ", "มีสุข", "ศรีสวัสดิ์", "สุขสบาย", "ทองดี", "แสงทอง", "บุญมา",
+		"วงศ์ใหญ่", "พูนผล", "สายทอง", "ร่มเย็น",
+	}
+	demoNicknames = []string{"ต้น", "แอน", "บี", "ฝน", "เก่ง", "นุ่น", "โอ๊ต", "มิ้นท์"}
+)
+
+// demoPatientNameKeys are JSON fields that always hold a patient's (or their
+// representative's) name. fullName is only a patient's in objects with an hn
+// or a patientHn and in registrations, which may not have matched a patient
+// yet; label is the name of whoever a self-registration link went to; and
+// name is only an emergency contact's in objects with a relationship.
+var demoPatientNameKeys = map[string]bool{"patientName": true, "signerName": true}
+
+// DemoMode replaces patients' names, nicknames, phone numbers, citizen IDs
+// and house numbers in JSON responses with generated placeholders and withholds patient photos,
+// documents and signatures, so the live system can be demonstrated or
+// screenshotted without showing who its patients are. The same real value
+// gets the same placeholder until the server restarts. The API is read-only
+// in demo mode, so placeholders shown in a form are never saved back.
+type DemoMode struct {
+	key []byte // keys the placeholder choice, so placeholders cannot be matched to guessed values
+}
+
+// NewDemoMode creates the demo mode middleware with a key for this run
+func NewDemoMode() (*DemoMode, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate demo mode key: %w", err)
+	}
+	return &DemoMode{key: key}, nil
+}
+
+// Middleware refuses writes and anonymizes what handlers write
+func (d *DemoMode) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(DemoModeHeader, "on")
+		w.Header().Add("Access-Control-Expose-Headers", DemoModeHeader)
+		if isWrite(r.Method) {
+			http.Error(w, "The API is read-only in demo mode", http.StatusForbidden)
+			return
+		}
+
+		dw := &demoWriter{ResponseWriter: w, demo: d}
+		next.ServeHTTP(dw, r)
+		dw.finish()
+	})
+}
+
+// demoWriter holds back JSON bodies to anonymize them once complete, passes
+// errors through and withholds every other successful body
+type demoWriter struct {
+	http.ResponseWriter
+	demo     *DemoMode
+	status   int
+	decided  bool
+	buffered bool // the body is JSON being held back
+	withheld bool
+	buf      bytes.Buffer
+}
+
+func (w *demoWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *demoWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.decide()
+	}
+	switch {
+	case w.buffered:
+		return w.buf.Write(p)
+	case w.withheld:
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide picks what happens to the body from the status and content type,
+// which handlers set before writing it
+func (w *demoWriter) decide() {
+	w.decided = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	switch {
+	case w.status >= http.StatusBadRequest || w.status == http.StatusNoContent || w.status == http.StatusNotModified:
+		w.ResponseWriter.WriteHeader(w.status)
+	case mediaType == "application/json":
+		w.buffered = true
+	default:
+		w.withheld = true
+		for _, h := range []string{"Content-Type", "Content-Length", "Content-Disposition", "Content-Range", "ETag", "Last-Modified"} {
+			w.Header().Del(h)
+		}
+		http.Error(w.ResponseWriter, "Files are withheld in demo mode", http.StatusForbidden)
+	}
+}
+
+// finish sends the anonymized JSON body, or the header of a response that
+// wrote none
+func (w *demoWriter) finish() {
+	if !w.decided {
+		if w.status != 0 {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+		return
+	}
+	if !w.buffered {
+		return
+	}
+
+	body := w.buf.Bytes()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err == nil {
+		var out bytes.Buffer
+		if err := json.NewEncoder(&out).Encode(w.demo.anonymize(v)); err == nil {
+			body = out.Bytes()
+		}
+	}
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *demoWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// anonymize replaces the identifying fields found anywhere in a decoded JSON value
+func (d *DemoMode) anonymize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		_, isPatient := v["hn"]
+		if _, linked := v["patientHn"]; linked {
+			isPatient = true
+		}
+		// Campaign registrations, and self-registration links, carry a name
+		// before there is a patient to link them to
+		_, isRegistration := v["campaignId"]
+		if _, issued := v["issuedBy"]; issued {
+			if _, expires := v["expiresAt"]; expires {
+				isRegistration = true
+			}
+		}
+		_, isContact := v["relationship"]
+		gender, _ := v["gender"].(string)
+		if demoFirstNames[gender] == nil {
+			gender = ""
+		}
+		for key, value := range v {
+			s, isString := value.(string)
+			switch {
+			case key == "photo":
+				delete(v, key)
+			case !isString || s == "":
+				v[key] = d.anonymize(value)
+			case demoPatientNameKeys[key] || (key == "fullName" && (isPatient || isRegistration)) ||
+				(key == "label" && isRegistration) || (key == "name" && isContact):
+				v[key] = d.pick(demoFirstNames[gender], "first", s) + " " + d.pick(demoLastNames, "last", s)
+			case key == "nickname":
+				v[key] = d.pick(demoNicknames, "nickname", s)
+			case key == "phone":
+				v[key] = fmt.Sprintf("000%07d", d.number("phone", s)%10000000)
+			case key == "email":
+				v[key] = fmt.Sprintf("demo%06d@example.com", d.number("email", s)%1000000)
+			case key == "citizenId":
+				v[key] = demoCitizenID(d.number("citizenId", s))
+			case key == "houseNo":
+				v[key] = d.houseNo(s)
+			case key == "line" && isPatient:
+				houseNo, rest, _ := strings.Cut(s, " ")
+				v[key] = d.houseNo(houseNo) + " " + rest
+			}
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = d.anonymize(v[i])
+		}
+		return v
+	}
+	return v
+}
+
+// number derives a stable number from a real value
+func (d *DemoMode) number(field, value string) uint64 {
+	mac := hmac.New(sha256.New, d.key)
+	mac.Write([]byte(field + "\x00" + value))
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
+
+func (d *DemoMode) pick(list []string, field, value string) string {
+	return list[d.number(field, value)%uint64(len(list))]
+}
+
+// houseNo is a placeholder house number, so an address shows the area a
+// patient lives in but not their door
+func (d *DemoMode) houseNo(value string) string {
+	return fmt.Sprint(d.number("houseNo", value)%999 + 1)
+}
+
+// demoCitizenID formats a 13-digit placeholder ID with a valid check digit,
+// as validCitizenID computes it, so clients accept it
+func demoCitizenID(n uint64) string {
+	digits := fmt.Sprintf("0%011d", n%100000000000)
+	sum := 0
+	for i, c := range digits {
+		sum += int(c-'0') * (13 - i)
+	}
+	return digits + fmt.Sprint((11-sum%11)%10)
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// DentalRepository interface for dental chart storage; findings are a
+// history, so there is no update or delete
+type DentalRepository interface {
+	AddFinding(f *database.ToothFinding) error
+	GetFindings(hn string, tooth int) ([]database.ToothFinding, error)
+	CreateTreatment(t *database.DentalTreatment) error
+	GetTreatmentByID(id int) (*database.DentalTreatment, error)
+	GetTreatments(hn string, tooth int) ([]database.DentalTreatment, error)
+	FinishTreatment(id int, status string, performedBy *string, visitID *int) (*database.DentalTreatment, error)
+}
+
+// DentalHandler handles patients' dental charts
+type DentalHandler struct {
+	repo     DentalRepository
+	patients PatientRepository
+	visits   EncounterRepository
+}
+
+// NewDentalHandler creates a new dental handler
+func NewDentalHandler(repo DentalRepository, patients PatientRepository, visits EncounterRepository) *DentalHandler {
+	return &DentalHandler{repo: repo, patients: patients, visits: visits}
+}
+
+// DentalChart is the current condition of a patient's charted teeth and the
+// treatments still planned
+type DentalChart struct {
+	PatientHN     string                     `json:"patientHn"`
+	Teeth         []database.ToothFinding    `json:"teeth"`         // each charted tooth's latest finding, by FDI number
+	TreatmentPlan []database.DentalTreatment `json:"treatmentPlan"` // planned treatments, oldest first
+}
+
+// ToothHistory is everything charted and done on one tooth
+type ToothHistory struct {
+	Tooth      int                        `json:"tooth"`
+	Current    *database.ToothFinding     `json:"current"` // null for a tooth never charted
+	Findings   []database.ToothFinding    `json:"findings"`
+	Treatments []database.DentalTreatment `json:"treatments"`
+}
+
+// toothlessProcedures may be recorded for the whole mouth rather than one tooth
+var toothlessProcedures = map[string]bool{"scaling": true, "fluoride": true, "other": true}
+
+// GetDentalChart returns a patient's dental chart: the latest finding of every
+// charted tooth, in FDI order, and the treatment plan. Teeth never charted are
+// taken to be sound.
+func (h *DentalHandler) GetDentalChart(w http.ResponseWriter, r *http.Request) {
+	hn, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	findings, err := h.repo.GetFindings(hn, 0)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve tooth findings")
+		return
+	}
+	treatments, err := h.repo.GetTreatments(hn, 0)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve dental treatments")
+		return
+	}
+
+	latest := map[int]database.ToothFinding{}
+	for _, f := range findings {
+		latest[f.Tooth] = f
+	}
+	chart := DentalChart{PatientHN: hn, Teeth: []database.ToothFinding{}, TreatmentPlan: []database.DentalTreatment{}}
+	for _, f := range latest {
+		chart.Teeth = append(chart.Teeth, f)
+	}
+	sort.Slice(chart.Teeth, func(i, j int) bool { return chart.Teeth[i].Tooth < chart.Teeth[j].Tooth })
+	for _, t := range treatments {
+		if t.Status == database.DentalTreatmentPlanned {
+			chart.TreatmentPlan = append(chart.TreatmentPlan, t)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, chart)
+}
+
+// GetToothHistory returns the findings and treatments of one tooth, oldest first
+func (h *DentalHandler) GetToothHistory(w http.ResponseWriter, r *http.Request) {
+	hn, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+	tooth, err := strconv.Atoi(mux.Vars(r)["tooth"])
+	if err != nil || !database.ValidTooth(tooth) {
+		http.Error(w, "tooth must be an FDI tooth number, e.g. 36", http.StatusBadRequest)
+		return
+	}
+
+	history := ToothHistory{Tooth: tooth}
+	history.Findings, err = h.repo.GetFindings(hn, tooth)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve tooth findings")
+		return
+	}
+	history.Treatments, err = h.repo.GetTreatments(hn, tooth)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve dental treatments")
+		return
+	}
+	if n := len(history.Findings); n > 0 {
+		history.Current = &history.Findings[n-1]
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+// ChartTooth records the condition a tooth was found in; recordedBy defaults
+// to the signed-in user
+func (h *DentalHandler) ChartTooth(w http.ResponseWriter, r *http.Request) {
+	hn, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	var finding database.ToothFinding
+	if err := json.NewDecoder(r.Body).Decode(&finding); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	finding.PatientHN = hn
+	finding.TreatmentID = nil
+	finding.Status = strings.ToLower(strings.TrimSpace(finding.Status))
+	finding.RecordedBy = strings.TrimSpace(finding.RecordedBy)
+	if finding.RecordedBy == "" {
+		finding.RecordedBy = reqctx.UserName(r.Context())
+	}
+	if finding.Note != nil {
+		finding.Note = optionalText(*finding.Note)
+	}
+	switch {
+	case !database.ValidTooth(finding.Tooth):
+		http.Error(w, "tooth must be an FDI tooth number, e.g. 36", http.StatusBadRequest)
+		return
+	case !oneOf(finding.Status, database.ToothStatuses):
+		http.Error(w, "status must be one of "+strings.Join(database.ToothStatuses, ", "), http.StatusBadRequest)
+		return
+	case finding.RecordedBy == "":
+		http.Error(w, "recordedBy is required", http.StatusBadRequest)
+		return
+	}
+	var msg string
+	if finding.Surfaces, msg = checkSurfaces(finding.Surfaces); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if !h.checkVisit(w, hn, finding.VisitID) {
+		return
+	}
+
+	if err := h.repo.AddFinding(&finding); err != nil {
+		writeError(w, err, "Failed to chart tooth")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, finding)
+}
+
+// CreateDentalTreatment plans a treatment or records one already done
+// (status completed, performedBy defaulting to recordedBy, which defaults to
+// the signed-in user). Completing a filling, extraction, root canal, crown or
+// implant charts the tooth accordingly. Scaling, fluoride and other
+// treatments may leave out the tooth.
+func (h *DentalHandler) CreateDentalTreatment(w http.ResponseWriter, r *http.Request) {
+	hn, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	var t database.DentalTreatment
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	t.PatientHN = hn
+	t.Procedure = strings.ToLower(strings.TrimSpace(t.Procedure))
+	t.RecordedBy = strings.TrimSpace(t.RecordedBy)
+	if t.RecordedBy == "" {
+		t.RecordedBy = reqctx.UserName(r.Context())
+	}
+	t.CompletedAt = nil
+	if t.Status == "" {
+		t.Status = database.DentalTreatmentPlanned
+	}
+	if t.Note != nil {
+		t.Note = optionalText(*t.Note)
+	}
+	if t.PerformedBy != nil {
+		t.PerformedBy = optionalText(*t.PerformedBy)
+	}
+	switch {
+	case !oneOf(t.Procedure, database.DentalProcedures):
+		http.Error(w, "procedure must be one of "+strings.Join(database.DentalProcedures, ", "), http.StatusBadRequest)
+		return
+	case t.Tooth == nil && !toothlessProcedures[t.Procedure]:
+		http.Error(w, "tooth is required for a "+t.Procedure, http.StatusBadRequest)
+		return
+	case t.Tooth != nil && !database.ValidTooth(*t.Tooth):
+		http.Error(w, "tooth must be an FDI tooth number, e.g. 36", http.StatusBadRequest)
+		return
+	case t.Status != database.DentalTreatmentPlanned && t.Status != database.DentalTreatmentCompleted:
+		http.Error(w, "status must be planned or completed", http.StatusBadRequest)
+		return
+	case t.RecordedBy == "":
+		http.Error(w, "recordedBy is required", http.StatusBadRequest)
+		return
+	}
+	var msg string
+	if t.Surfaces, msg = checkSurfaces(t.Surfaces); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if t.Status == database.DentalTreatmentPlanned {
+		t.PerformedBy = nil
+	} else {
+		if t.PerformedBy == nil {
+			t.PerformedBy = &t.RecordedBy
+		}
+		now := time.Now()
+		t.CompletedAt = &now
+	}
+	if !h.checkVisit(w, hn, t.VisitID) || !h.checkToothPresent(w, &t) {
+		return
+	}
+
+	if err := h.repo.CreateTreatment(&t); err != nil {
+		writeError(w, err, "Failed to create dental treatment")
+		return
+	}
+	if t.Status == database.DentalTreatmentCompleted && !h.chartResult(w, &t) {
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, t)
+}
+
+// GetPatientDentalTreatments lists a patient's dental treatments, oldest first;
+// ?status= keeps planned, completed or cancelled ones
+func (h *DentalHandler) GetPatientDentalTreatments(w http.ResponseWriter, r *http.Request) {
+	hn, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+	status := r.URL.Query().Get("status")
+
+	treatments, err := h.repo.GetTreatments(hn, 0)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve dental treatments")
+		return
+	}
+	if status != "" {
+		kept := []database.DentalTreatment{}
+		for _, t := range treatments {
+			if t.Status == status {
+				kept = append(kept, t)
+			}
+		}
+		treatments = kept
+	}
+
+	writeJSON(w, http.StatusOK, treatments)
+}
+
+// UpdateDentalTreatmentStatus completes or cancels a planned treatment.
+// Completing one takes performedBy, defaulting to the signed-in user, and the
+// visitId it was done in, and charts the tooth as for CreateDentalTreatment.
+func (h *DentalHandler) UpdateDentalTreatmentStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid dental treatment ID", http.StatusBadRequest)
+		return
+	}
+	treatment, err := h.repo.GetTreatmentByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve dental treatment")
+		return
+	}
+
+	var req struct {
+		Status      string  `json:"status"`
+		PerformedBy *string `json:"performedBy,omitempty"`
+		VisitID     *int    `json:"visitId,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	var performedBy *string
+	switch req.Status {
+	case database.DentalTreatmentCompleted:
+		if req.PerformedBy != nil {
+			performedBy = optionalText(*req.PerformedBy)
+		}
+		if performedBy == nil {
+			performedBy = optionalText(reqctx.UserName(r.Context()))
+		}
+		if performedBy == nil {
+			http.Error(w, "performedBy is required for a completed treatment", http.StatusBadRequest)
+			return
+		}
+	case database.DentalTreatmentCancelled:
+		req.VisitID = nil
+	default:
+		http.Error(w, "status must be completed or cancelled", http.StatusBadRequest)
+		return
+	}
+	if !h.checkVisit(w, treatment.PatientHN, req.VisitID) {
+		return
+	}
+	if req.Status == database.DentalTreatmentCompleted && !h.checkToothPresent(w, treatment) {
+		return
+	}
+
+	updated, err := h.repo.FinishTreatment(treatment.ID, req.Status, performedBy, req.VisitID)
+	if err != nil {
+		writeError(w, err, "Failed to update dental treatment")
+		return
+	}
+	if updated.Status == database.DentalTreatmentCompleted && !h.chartResult(w, updated) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// checkSurfaces upper-cases surfaces, drops repeats and puts them in charting
+// order, returning what is wrong with them
+func checkSurfaces(surfaces []string) ([]string, string) {
+	given := map[string]bool{}
+	for _, s := range surfaces {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if !oneOf(s, database.ToothSurfaces) {
+			return nil, "surfaces must be among " + strings.Join(database.ToothSurfaces, ", ")
+		}
+		given[s] = true
+	}
+	ordered := []string{}
+	for _, s := range database.ToothSurfaces {
+		if given[s] {
+			ordered = append(ordered, s)
+		}
+	}
+	return ordered, ""
+}
+
+// checkToothPresent refuses a treatment on a tooth charted as missing, other
+// than replacing it, writing the response when it does
+func (h *DentalHandler) checkToothPresent(w http.ResponseWriter, t *database.DentalTreatment) bool {
+	if t.Tooth == nil || t.Procedure == "implant" || t.Procedure == "bridge" {
+		return true
+	}
+	findings, err := h.repo.GetFindings(t.PatientHN, *t.Tooth)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve tooth findings")
+		return false
+	}
+	if n := len(findings); n > 0 && findings[n-1].Status == "missing" {
+		http.Error(w, fmt.Sprintf("Tooth %d is charted as missing", *t.Tooth), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// chartResult charts the condition a completed treatment left its tooth in,
+// writing the error response when that fails
+func (h *DentalHandler) chartResult(w http.ResponseWriter, t *database.DentalTreatment) bool {
+	status, ok := database.ProcedureToothStatus[t.Procedure]
+	if !ok || t.Tooth == nil {
+		return true
+	}
+	finding := database.ToothFinding{
+		PatientHN:   t.PatientHN,
+		Tooth:       *t.Tooth,
+		Status:      status,
+		Surfaces:    t.Surfaces,
+		VisitID:     t.VisitID,
+		TreatmentID: &t.ID,
+		RecordedBy:  *t.PerformedBy,
+	}
+	if status != "filled" {
+		finding.Surfaces = []string{}
+	}
+	if err := h.repo.AddFinding(&finding); err != nil {
+		writeError(w, err, "Failed to chart treated tooth")
+		return false
+	}
+	return true
+}
+
+// checkVisit checks that a visit a finding or treatment names is the
+// patient's, writing the response when it is not
+func (h *DentalHandler) checkVisit(w http.ResponseWriter, hn string, visitID *int) bool {
+	if visitID == nil {
+		return true
+	}
+	visit, err := h.visits.GetByID(*visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return false
+	}
+	if visit.PatientHN != hn {
+		http.Error(w, "Visit belongs to another patient", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (h *DentalHandler) loadPatient(w http.ResponseWriter, r *http.Request) (string, bool) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return "", false
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return "", false
+	}
+	return hn, true
+}
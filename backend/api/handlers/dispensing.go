@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// DispensingRepository interface for the pharmacy's view of prescriptions
+type DispensingRepository interface {
+	GetByID(id int) (*database.Prescription, error)
+	GetPending() ([]database.Prescription, error)
+	GetDispensedBetween(from, to time.Time) ([]database.Prescription, error)
+	MarkDispensed(id int, by string) (*database.Prescription, error)
+	UndoDispensed(id int) error
+}
+
+// DispensingStock is the inventory a prescription is dispensed from
+type DispensingStock interface {
+	GetLevels(f database.StockItemFilter) ([]database.StockLevel, error)
+	DispenseItems(ms []database.StockMovement, exclude map[int][]string) ([]database.StockMovement, error)
+}
+
+// DispensingHandler handles the pharmacy's prescription queue
+type DispensingHandler struct {
+	repo    DispensingRepository
+	stock   DispensingStock
+	recalls LotRecallChecker
+	holds   LotHoldChecker
+}
+
+// NewDispensingHandler creates a new dispensing handler.
+// Nil recall or hold checkers let every lot be dispensed.
+func NewDispensingHandler(repo DispensingRepository, stock DispensingStock, recalls LotRecallChecker, holds LotHoldChecker) *DispensingHandler {
+	return &DispensingHandler{repo: repo, stock: stock, recalls: recalls, holds: holds}
+}
+
+// DispenseLine sets how much of one prescription line is handed over, for
+// lines written without a quantity or supplied short
+type DispenseLine struct {
+	No       int     `json:"no"` // line number on the printed prescription, from 1
+	Quantity float64 `json:"quantity"`
+}
+
+// DispenseRequest is the optional body of a dispense
+type DispenseRequest struct {
+	DispensedBy string         `json:"dispensedBy"` // defaults to the signed-in user
+	Lines       []DispenseLine `json:"lines"`
+}
+
+// DispenseResult is a dispensed prescription with the stock it took
+type DispenseResult struct {
+	Prescription database.Prescription    `json:"prescription"`
+	Movements    []database.StockMovement `json:"movements"`  // one per lot used
+	NotStocked   []string                 `json:"notStocked"` // drugs handed over without stock tracking
+}
+
+// GetDispensing lists prescriptions at the pharmacy: ?status=pending (the
+// default) is the queue, oldest first; ?status=dispensed lists what was
+// handed over (?from=&to=YYYY-MM-DD, default this month), most recent first
+func (h *DispensingHandler) GetDispensing(w http.ResponseWriter, r *http.Request) {
+	var prescriptions []database.Prescription
+	var err error
+	switch r.URL.Query().Get("status") {
+	case "", database.PrescriptionPending:
+		prescriptions, err = h.repo.GetPending()
+	case database.PrescriptionDispensed:
+		from, to, rangeErr := dateRange(r)
+		if rangeErr != nil {
+			http.Error(w, rangeErr.Error(), http.StatusBadRequest)
+			return
+		}
+		prescriptions, err = h.repo.GetDispensedBetween(from, to)
+	default:
+		http.Error(w, "status must be pending or dispensed", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescriptions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prescriptions)
+}
+
+// DispensePrescription hands a pending prescription over and takes its drugs
+// out of stock, earliest expiry first and passing over recalled or held lots.
+// Lines for drugs the clinic does not stock are handed over untracked. The
+// pharmacist and time are recorded on the prescription.
+func (h *DispensingHandler) DispensePrescription(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid prescription ID", http.StatusBadRequest)
+		return
+	}
+
+	var req DispenseRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	req.DispensedBy = strings.TrimSpace(req.DispensedBy)
+	if req.DispensedBy == "" {
+		req.DispensedBy = reqctx.UserName(r.Context())
+	}
+	if req.DispensedBy == "" {
+		http.Error(w, "dispensedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	prescription, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescription")
+		return
+	}
+	if prescription.DispenseStatus != database.PrescriptionPending {
+		http.Error(w, fmt.Sprintf("Prescription %d has already been dispensed", id), http.StatusConflict)
+		return
+	}
+	quantities, msg := dispenseQuantities(prescription, req.Lines)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	levels, err := h.stock.GetLevels(database.StockItemFilter{Kind: database.StockItemDrug})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock levels")
+		return
+	}
+	stocked := map[int]*database.StockLevel{}
+	for i := range levels {
+		if levels[i].DrugID != nil {
+			stocked[*levels[i].DrugID] = &levels[i]
+		}
+	}
+
+	result := DispenseResult{Movements: []database.StockMovement{}, NotStocked: []string{}}
+	movements := []database.StockMovement{}
+	exclude := map[int][]string{}
+	reference := fmt.Sprintf("RX-%06d", prescription.ID)
+	for i, item := range prescription.Items {
+		var level *database.StockLevel
+		if item.DrugID != nil {
+			level = stocked[*item.DrugID]
+		}
+		if level == nil {
+			result.NotStocked = append(result.NotStocked, item.DrugName)
+			continue
+		}
+		if quantities[i] == 0 {
+			http.Error(w, fmt.Sprintf("Line %d (%s) needs a quantity to take from stock", i+1, item.DrugName), http.StatusBadRequest)
+			return
+		}
+		if _, checked := exclude[level.ItemID]; !checked {
+			if exclude[level.ItemID], err = blockedLots(h.recalls, h.holds, level); err != nil {
+				writeError(w, err, "Failed to check lot status")
+				return
+			}
+		}
+		patientHN := prescription.PatientHN
+		movements = append(movements, database.StockMovement{
+			ItemID:     level.ItemID,
+			Type:       database.MovementDispense,
+			Quantity:   -quantities[i],
+			PatientHN:  &patientHN,
+			Reference:  &reference,
+			RecordedBy: req.DispensedBy,
+		})
+	}
+
+	// Marking it dispensed first stops two pharmacists taking the stock twice
+	dispensed, err := h.repo.MarkDispensed(prescription.ID, req.DispensedBy)
+	if err != nil {
+		writeError(w, err, "Failed to mark prescription dispensed")
+		return
+	}
+	if len(movements) > 0 {
+		taken, err := h.stock.DispenseItems(movements, exclude)
+		if err != nil {
+			if undoErr := h.repo.UndoDispensed(prescription.ID); undoErr != nil {
+				log.Printf("Failed to return prescription %d to the pharmacy queue: %v", prescription.ID, undoErr)
+			}
+			writeError(w, err, "Failed to take prescription stock")
+			return
+		}
+		result.Movements = taken
+	}
+
+	result.Prescription = *dispensed
+	writeJSON(w, http.StatusOK, result)
+}
+
+// dispenseQuantities works out how much of each line to hand over: the
+// prescribed quantity unless the request gives another. It returns what is
+// wrong with the request instead when a line is unknown or not positive.
+func dispenseQuantities(p *database.Prescription, lines []DispenseLine) ([]float64, string) {
+	quantities := make([]float64, len(p.Items))
+	for i, item := range p.Items {
+		if item.Quantity != nil {
+			quantities[i] = *item.Quantity
+		}
+	}
+	for _, line := range lines {
+		if line.No < 1 || line.No > len(p.Items) {
+			return nil, fmt.Sprintf("line %d is not on the prescription", line.No)
+		}
+		if line.Quantity <= 0 {
+			return nil, fmt.Sprintf("quantity for line %d must be positive", line.No)
+		}
+		quantities[line.No-1] = line.Quantity
+	}
+	return quantities, ""
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// DoctorRepository interface for doctor storage
+type DoctorRepository interface {
+	Create(d *database.Doctor) error
+	GetByID(id int) (*database.Doctor, error)
+	GetAll(f database.DoctorFilter) ([]database.Doctor, error)
+	Update(d *database.Doctor) error
+	Deactivate(id int) error
+}
+
+// DoctorHandler handles doctor management requests
+type DoctorHandler struct {
+	repo DoctorRepository
+}
+
+// NewDoctorHandler creates a new doctor handler
+func NewDoctorHandler(repo DoctorRepository) *DoctorHandler {
+	return &DoctorHandler{repo: repo}
+}
+
+// GetDoctors lists doctors (?specialty=, ?active=true); ?licenseExpiresWithin=
+// lists only those whose license expires within that many days or has lapsed
+func (h *DoctorHandler) GetDoctors(w http.ResponseWriter, r *http.Request) {
+	filter := database.DoctorFilter{
+		Specialty:  r.URL.Query().Get("specialty"),
+		ActiveOnly: r.URL.Query().Get("active") == "true",
+	}
+	if s := r.URL.Query().Get("licenseExpiresWithin"); s != "" {
+		days, err := strconv.Atoi(s)
+		if err != nil || days < 0 || days > 365 {
+			http.Error(w, "licenseExpiresWithin must be a number of days from 0 to 365", http.StatusBadRequest)
+			return
+		}
+		filter.LicenseExpiresBy = time.Now().AddDate(0, 0, days).Format("2006-01-02")
+	}
+
+	doctors, err := h.repo.GetAll(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve doctors")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, doctors)
+}
+
+// GetDoctor returns one doctor
+func (h *DoctorHandler) GetDoctor(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+
+	doctor, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, doctor)
+}
+
+// CreateDoctor registers a doctor
+func (h *DoctorHandler) CreateDoctor(w http.ResponseWriter, r *http.Request) {
+	var doctor database.Doctor
+	if err := json.NewDecoder(r.Body).Decode(&doctor); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if msg := checkDoctor(&doctor); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	doctor.Active = true
+	if err := h.repo.Create(&doctor); err != nil {
+		writeError(w, err, "Failed to create doctor")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, doctor)
+}
+
+// UpdateDoctor replaces a doctor's details; active can re-enable a deactivated doctor
+func (h *DoctorHandler) UpdateDoctor(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.repo.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return
+	}
+
+	var doctor database.Doctor
+	if err := json.NewDecoder(r.Body).Decode(&doctor); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if msg := checkDoctor(&doctor); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	doctor.ID = id
+	if err := h.repo.Update(&doctor); err != nil {
+		writeError(w, err, "Failed to update doctor")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, doctor)
+}
+
+// DeleteDoctor deactivates a doctor; existing appointments keep their reference
+func (h *DoctorHandler) DeleteDoctor(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Deactivate(id); err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkDoctor validates required fields and normalizes working days to "mon".."sun"
+func checkDoctor(d *database.Doctor) string {
+	d.FullName = strings.TrimSpace(d.FullName)
+	d.Specialty = strings.TrimSpace(d.Specialty)
+	d.LicenseNumber = strings.ToUpper(strings.TrimSpace(d.LicenseNumber))
+	if d.FullName == "" || d.Specialty == "" || d.LicenseNumber == "" {
+		return "fullName, specialty and licenseNumber are required"
+	}
+	if d.LicenseExpiresOn != nil {
+		if _, err := time.Parse("2006-01-02", *d.LicenseExpiresOn); err != nil {
+			return "Invalid licenseExpiresOn, expected YYYY-MM-DD"
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, day := range d.WorkingDays {
+		day = strings.ToLower(strings.TrimSpace(day))
+		code := ""
+		for i, w := range database.Weekdays {
+			if day == w || day == strings.ToLower(time.Weekday(i).String()) {
+				code = w
+			}
+		}
+		if code == "" {
+			return "workingDays must be weekdays such as mon, tue, wed"
+		}
+		seen[code] = true
+	}
+	d.WorkingDays = []string{}
+	for _, w := range database.Weekdays {
+		if seen[w] {
+			d.WorkingDays = append(d.WorkingDays, w)
+		}
+	}
+	return ""
+}
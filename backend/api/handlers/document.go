@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+	"clinic/backend/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// maxDocumentSize caps uploaded patient documents (20 MB)
+const maxDocumentSize = 20 << 20
+
+// documentExtensions are the file types accepted as patient documents, by
+// their sniffed content type: scans come as PDFs or photos
+var documentExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+}
+
+// DocumentRepository interface for patient document storage
+type DocumentRepository interface {
+	Create(d *database.Document) error
+	GetByID(id int) (*database.Document, error)
+	List(f database.DocumentFilter) ([]database.Document, error)
+	Delete(id int) error
+}
+
+// DocumentStore keeps the uploaded files themselves
+type DocumentStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) (string, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DocumentHandler handles the files uploaded to patient records
+type DocumentHandler struct {
+	repo     DocumentRepository
+	patients PatientRepository
+	store    DocumentStore
+	emails   EmailOutbox
+}
+
+// NewDocumentHandler creates a new document handler. Files go to store, which
+// must not be publicly served: they are downloaded through the API only.
+func NewDocumentHandler(repo DocumentRepository, patients PatientRepository, store DocumentStore, emails EmailOutbox) *DocumentHandler {
+	return &DocumentHandler{repo: repo, patients: patients, store: store, emails: emails}
+}
+
+// UploadDocument stores a multipart "file" (PDF, JPEG, PNG or WebP) in a
+// patient's record with its category, title, notes and uploadedBy form fields;
+// the title defaults to the file name. Patients with an email address are
+// emailed when a lab_result is filed.
+func (h *DocumentHandler) UploadDocument(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	file, ok := readUpload(w, r, maxDocumentSize, documentExtensions, "a PDF, JPEG, PNG or WebP file")
+	if !ok {
+		return
+	}
+
+	document := database.Document{
+		PatientHN:   patient.HN,
+		Category:    strings.ToLower(strings.TrimSpace(r.FormValue("category"))),
+		Title:       strings.TrimSpace(r.FormValue("title")),
+		FileName:    filepath.Base(strings.ReplaceAll(file.header.Filename, "\\", "/")),
+		ContentType: file.contentType,
+		Size:        int64(len(file.data)),
+		UploadedBy:  strings.TrimSpace(r.FormValue("uploadedBy")),
+	}
+	if notes := strings.TrimSpace(r.FormValue("notes")); notes != "" {
+		document.Notes = &notes
+	}
+	if document.Title == "" {
+		document.Title = strings.TrimSuffix(document.FileName, filepath.Ext(document.FileName))
+	}
+	if document.UploadedBy == "" {
+		document.UploadedBy = reqctx.UserName(r.Context())
+	}
+	if msg := checkDocument(&document); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	name := make([]byte, 12)
+	if _, err := rand.Read(name); err != nil {
+		writeError(w, err, "Failed to store document")
+		return
+	}
+	document.StorageKey = "patients/" + patient.HN + "/documents/" + hex.EncodeToString(name) + file.ext
+	if _, err := h.store.Put(r.Context(), document.StorageKey, file.contentType, file.data); err != nil {
+		writeError(w, err, "Failed to store document")
+		return
+	}
+	if err := h.repo.Create(&document); err != nil {
+		if err := h.store.Delete(context.Background(), document.StorageKey); err != nil {
+			log.Printf("Failed to remove unrecorded document %s: %v", document.StorageKey, err)
+		}
+		writeError(w, err, "Failed to create document")
+		return
+	}
+	if document.Category == "lab_result" {
+		labResultEmail(h.emails, patient, &document)
+	}
+
+	writeJSON(w, http.StatusCreated, document)
+}
+
+// GetPatientDocuments lists a patient's documents, newest first (?category=)
+func (h *DocumentHandler) GetPatientDocuments(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	documents, err := h.repo.List(database.DocumentFilter{PatientHN: patient.HN, Category: r.URL.Query().Get("category")})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve documents")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, documents)
+}
+
+// GetDocument returns a document's details
+func (h *DocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	document, ok := h.loadDocument(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, document)
+}
+
+// DownloadDocument sends the file as an attachment under its uploaded name
+func (h *DocumentHandler) DownloadDocument(w http.ResponseWriter, r *http.Request) {
+	document, ok := h.loadDocument(w, r)
+	if !ok {
+		return
+	}
+
+	stored, err := h.store.Open(r.Context(), document.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Document file is missing from storage", http.StatusNotFound)
+			return
+		}
+		writeError(w, err, "Failed to open document")
+		return
+	}
+	defer stored.Close()
+
+	w.Header().Set("Content-Type", document.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(document.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, stored); err != nil {
+		log.Printf("Failed to send document %d: %v", document.ID, err)
+	}
+}
+
+// DeleteDocument removes a document from a patient's record along with its file
+func (h *DocumentHandler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	document, ok := h.loadDocument(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(document.ID); err != nil {
+		writeError(w, err, "Failed to delete document")
+		return
+	}
+	if err := h.store.Delete(r.Context(), document.StorageKey); err != nil {
+		log.Printf("Failed to remove file of deleted document %d (%s): %v", document.ID, document.StorageKey, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DocumentHandler) loadPatient(w http.ResponseWriter, r *http.Request) (*database.Patient, bool) {
+	id, err := parseHN(mux.Vars(r)["hn"])
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	return patient, true
+}
+
+// loadDocument loads the document a request names; documents of other
+// patients are reported as not found
+func (h *DocumentHandler) loadDocument(w http.ResponseWriter, r *http.Request) (*database.Document, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	document, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve document")
+		return nil, false
+	}
+	if document.PatientHN != mux.Vars(r)["hn"] {
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return nil, false
+	}
+	return document, true
+}
+
+// checkDocument validates the category and required fields
+func checkDocument(d *database.Document) string {
+	known := false
+	for _, c := range database.DocumentCategories {
+		known = known || d.Category == c
+	}
+	if !known {
+		return "category must be one of " + strings.Join(database.DocumentCategories, ", ")
+	}
+	if d.Title == "" {
+		return "title is required"
+	}
+	if d.UploadedBy == "" {
+		return "uploadedBy is required"
+	}
+	return ""
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/storage"
+)
+
+// Size caps of drug attachments: pack photos (5 MB) and leaflets (10 MB)
+const (
+	maxDrugImageSize   = 5 << 20
+	maxDrugLeafletSize = 10 << 20
+)
+
+var drugImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var drugLeafletExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// DrugRepository interface for drug catalog storage
+type DrugRepository interface {
+	Create(d *database.Drug) error
+	GetByID(id int) (*database.Drug, error)
+	GetAll(f database.DrugFilter) ([]database.Drug, error)
+	Update(d *database.Drug) error
+	Deactivate(id int) error
+}
+
+// DrugLookup resolves the catalog drugs that prescription lines refer to
+type DrugLookup interface {
+	GetByID(id int) (*database.Drug, error)
+}
+
+// DrugHandler handles drug catalog requests
+type DrugHandler struct {
+	repo  DrugRepository
+	files storage.Store
+}
+
+// NewDrugHandler creates a new drug handler; drug images and leaflets are
+// kept in files and linked from the catalog entry
+func NewDrugHandler(repo DrugRepository, files storage.Store) *DrugHandler {
+	return &DrugHandler{repo: repo, files: files}
+}
+
+// GetDrugs lists catalog drugs (?q= part of the generic or brand name, ?active=true)
+func (h *DrugHandler) GetDrugs(w http.ResponseWriter, r *http.Request) {
+	filter := database.DrugFilter{
+		Search:     strings.TrimSpace(r.URL.Query().Get("q")),
+		ActiveOnly: r.URL.Query().Get("active") == "true",
+	}
+
+	drugs, err := h.repo.GetAll(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve drugs")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, drugs)
+}
+
+// GetDrug returns one catalog drug
+func (h *DrugHandler) GetDrug(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid drug ID", http.StatusBadRequest)
+		return
+	}
+
+	drug, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve drug")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, drug)
+}
+
+// CreateDrug adds a drug to the catalog
+func (h *DrugHandler) CreateDrug(w http.ResponseWriter, r *http.Request) {
+	var drug database.Drug
+	if err := json.NewDecoder(r.Body).Decode(&drug); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if msg := checkDrug(&drug); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	drug.Active = true
+	if err := h.repo.Create(&drug); err != nil {
+		writeError(w, err, "Failed to create drug")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, drug)
+}
+
+// UpdateDrug replaces a drug's details; active can return a withdrawn drug to
+// the catalog. The image and leaflet are kept; they have their own endpoints.
+func (h *DrugHandler) UpdateDrug(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid drug ID", http.StatusBadRequest)
+		return
+	}
+	existing, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve drug")
+		return
+	}
+
+	var drug database.Drug
+	if err := json.NewDecoder(r.Body).Decode(&drug); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if msg := checkDrug(&drug); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	drug.ID = id
+	drug.ImageURL = existing.ImageURL
+	drug.LeafletURL = existing.LeafletURL
+	if err := h.repo.Update(&drug); err != nil {
+		writeError(w, err, "Failed to update drug")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, drug)
+}
+
+// DeleteDrug withdraws a drug from the catalog; existing prescriptions keep their reference
+func (h *DrugHandler) DeleteDrug(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid drug ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Deactivate(id); err != nil {
+		writeError(w, err, "Failed to deactivate drug")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadDrugImage sets a drug's pack or tablet photo from a multipart "file" (JPEG, PNG or WebP)
+func (h *DrugHandler) UploadDrugImage(w http.ResponseWriter, r *http.Request) {
+	h.attach(w, r, "image", maxDrugImageSize, drugImageExtensions, "a JPEG, PNG or WebP image")
+}
+
+// UploadDrugLeaflet sets a drug's patient information leaflet from a multipart "file" (PDF, JPEG or PNG)
+func (h *DrugHandler) UploadDrugLeaflet(w http.ResponseWriter, r *http.Request) {
+	h.attach(w, r, "leaflet", maxDrugLeafletSize, drugLeafletExtensions, "a PDF, JPEG or PNG file")
+}
+
+// DeleteDrugImage removes a drug's photo from the catalog entry
+func (h *DrugHandler) DeleteDrugImage(w http.ResponseWriter, r *http.Request) {
+	h.detach(w, r, func(d *database.Drug) { d.ImageURL = nil })
+}
+
+// DeleteDrugLeaflet removes a drug's leaflet from the catalog entry
+func (h *DrugHandler) DeleteDrugLeaflet(w http.ResponseWriter, r *http.Request) {
+	h.detach(w, r, func(d *database.Drug) { d.LeafletURL = nil })
+}
+
+// attach stores an uploaded image or leaflet under a name derived from its
+// content, so uploading the same file again reuses it, and links it from the drug
+func (h *DrugHandler) attach(w http.ResponseWriter, r *http.Request, kind string, maxSize int64, allowed map[string]string, kinds string) {
+	drug, ok := h.loadDrug(w, r)
+	if !ok {
+		return
+	}
+	file, ok := readUpload(w, r, maxSize, allowed, kinds)
+	if !ok {
+		return
+	}
+
+	sum := sha256.Sum256(file.data)
+	key := fmt.Sprintf("drugs/%d/%s-%s%s", drug.ID, kind, hex.EncodeToString(sum[:6]), file.ext)
+	url, err := h.files.Put(r.Context(), key, file.contentType, file.data)
+	if err != nil {
+		writeError(w, err, "Failed to store drug "+kind)
+		return
+	}
+	if kind == "image" {
+		drug.ImageURL = &url
+	} else {
+		drug.LeafletURL = &url
+	}
+
+	if err := h.repo.Update(drug); err != nil {
+		writeError(w, err, "Failed to update drug")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, drug)
+}
+
+// detach clears an attachment link; the stored file stays, as printed
+// prescriptions may still point at it
+func (h *DrugHandler) detach(w http.ResponseWriter, r *http.Request, clear func(d *database.Drug)) {
+	drug, ok := h.loadDrug(w, r)
+	if !ok {
+		return
+	}
+
+	clear(drug)
+	if err := h.repo.Update(drug); err != nil {
+		writeError(w, err, "Failed to update drug")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, drug)
+}
+
+func (h *DrugHandler) loadDrug(w http.ResponseWriter, r *http.Request) (*database.Drug, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid drug ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	drug, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve drug")
+		return nil, false
+	}
+	return drug, true
+}
+
+// checkDrug validates required fields and trims names
+func checkDrug(d *database.Drug) string {
+	d.GenericName = strings.TrimSpace(d.GenericName)
+	d.Strength = strings.TrimSpace(d.Strength)
+	d.Unit = strings.TrimSpace(d.Unit)
+	if d.BrandName != nil {
+		if brand := strings.TrimSpace(*d.BrandName); brand != "" {
+			d.BrandName = &brand
+		} else {
+			d.BrandName = nil
+		}
+	}
+	if d.GenericName == "" || d.Unit == "" {
+		return "genericName and unit are required"
+	}
+	if d.Price < 0 {
+		return "price cannot be negative"
+	}
+	return ""
+}
+
+// resolveDrug names a line after its catalog drug and fills in the drug's
+// default dose and frequency where the line leaves them blank. Lines without
+// a drugId are free text and pass through unchanged.
+func resolveDrug(w http.ResponseWriter, drugs DrugLookup, t *database.DrugTemplate) bool {
+	if t.DrugID == nil || drugs == nil {
+		return true
+	}
+
+	drug, ok := lookupDrug(w, drugs, *t.DrugID)
+	if !ok {
+		return false
+	}
+
+	t.DrugName = drug.DisplayName()
+	if strings.TrimSpace(t.Dose) == "" && drug.DefaultDose != nil {
+		t.Dose = *drug.DefaultDose
+	}
+	if strings.TrimSpace(t.Frequency) == "" && drug.DefaultFrequency != nil {
+		t.Frequency = *drug.DefaultFrequency
+	}
+	return true
+}
+
+// lookupDrug loads a drug that a request refers to; unknown drugs are a bad
+// request and withdrawn ones a conflict
+func lookupDrug(w http.ResponseWriter, drugs DrugLookup, id int) (*database.Drug, bool) {
+	drug, err := drugs.GetByID(id)
+	if err != nil {
+		if apperr.Is(err, apperr.KindNotFound) {
+			http.Error(w, fmt.Sprintf("Drug %d is not in the catalog", id), http.StatusBadRequest)
+			return nil, false
+		}
+		writeError(w, err, "Failed to retrieve drug")
+		return nil, false
+	}
+	if !drug.Active {
+		http.Error(w, drug.DisplayName()+" has been withdrawn from the catalog", http.StatusConflict)
+		return nil, false
+	}
+	return drug, true
+}
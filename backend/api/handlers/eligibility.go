@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+)
+
+// ServiceHistory reports when patients were last given catalog services, for
+// services that need another given first
+type ServiceHistory interface {
+	LastGiven(hn string, serviceID int) (*time.Time, error)
+}
+
+// ServiceEligibility is whether a patient may have a service and, if not, why
+type ServiceEligibility struct {
+	ServiceID int      `json:"serviceId"`
+	PatientHN string   `json:"patientHn"`
+	Eligible  bool     `json:"eligible"`
+	Reasons   []string `json:"reasons"` // one per rule the patient does not meet
+}
+
+// ineligibility lists the service's eligibility rules that the patient does
+// not meet on the given day, in words staff can read back to the patient
+func ineligibility(service *database.Service, patient *database.Patient, on time.Time, services ServiceLookup, history ServiceHistory) ([]string, error) {
+	reasons := []string{}
+
+	age := ageOn(patient, on)
+	switch {
+	case service.MinAge != nil && service.MaxAge != nil && (age < *service.MinAge || age > *service.MaxAge):
+		reasons = append(reasons, fmt.Sprintf("%s is for patients aged %d to %d; the patient is %d", service.Name, *service.MinAge, *service.MaxAge, age))
+	case service.MinAge != nil && service.MaxAge == nil && age < *service.MinAge:
+		reasons = append(reasons, fmt.Sprintf("%s is for patients aged %d and over; the patient is %d", service.Name, *service.MinAge, age))
+	case service.MaxAge != nil && service.MinAge == nil && age > *service.MaxAge:
+		reasons = append(reasons, fmt.Sprintf("%s is for patients aged %d and under; the patient is %d", service.Name, *service.MaxAge, age))
+	}
+
+	if len(service.Genders) > 0 && !oneOf(patient.Gender, service.Genders) {
+		gender := patient.Gender
+		if gender == "" {
+			gender = "not recorded"
+		}
+		reasons = append(reasons, fmt.Sprintf("%s is only for patients of gender %s; the patient's gender is %s",
+			service.Name, strings.Join(service.Genders, " or "), gender))
+	}
+
+	if service.PrerequisiteServiceID != nil {
+		name := fmt.Sprintf("service %d", *service.PrerequisiteServiceID)
+		if prerequisite, err := services.GetByID(*service.PrerequisiteServiceID); err == nil {
+			name = prerequisite.Name
+		} else if !apperr.Is(err, apperr.KindNotFound) {
+			return nil, err
+		}
+		last, err := history.LastGiven(patient.HN, *service.PrerequisiteServiceID)
+		if err != nil {
+			return nil, err
+		}
+		within := service.PrerequisiteWithinDays
+		switch {
+		case last == nil && within == nil:
+			reasons = append(reasons, fmt.Sprintf("%s needs %s first; the patient has never had it", service.Name, name))
+		case last == nil:
+			reasons = append(reasons, fmt.Sprintf("%s needs %s within %d days first; the patient has never had it", service.Name, name, *within))
+		case within != nil && on.Sub(*last) > time.Duration(*within)*24*time.Hour:
+			reasons = append(reasons, fmt.Sprintf("%s needs %s within %d days first; the patient last had it on %s",
+				service.Name, name, *within, last.In(on.Location()).Format("2006-01-02")))
+		}
+	}
+
+	return reasons, nil
+}
+
+// checkEligible refuses a service the patient is not eligible for on the
+// given day, writing the response with every reason when it does
+func checkEligible(w http.ResponseWriter, service *database.Service, patient *database.Patient, on time.Time, services ServiceLookup, history ServiceHistory) bool {
+	reasons, err := ineligibility(service, patient, on, services, history)
+	if err != nil {
+		writeError(w, err, "Failed to check service eligibility")
+		return false
+	}
+	if len(reasons) > 0 {
+		http.Error(w, strings.Join(reasons, "; "), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// findPatient loads the patient an HN names, writing the response when it cannot
+func findPatient(w http.ResponseWriter, patients PatientRepository, hn string) (*database.Patient, bool) {
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return nil, false
+	}
+	patient, err := patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	return patient, true
+}
+
+// ageOn is a patient's age in whole years on a day, from the date of birth
+// when it is recorded and the recorded age otherwise
+func ageOn(p *database.Patient, on time.Time) int {
+	dob, ok := birthDate(p, on.Location())
+	if !ok {
+		return p.Age
+	}
+	age := on.Year() - dob.Year()
+	if on.Month() < dob.Month() || (on.Month() == dob.Month() && on.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// checkEligibilityRules validates a service's eligibility rules, trimming and
+// de-duplicating the genders it is for
+func checkEligibilityRules(s *database.Service) string {
+	genders := []string{}
+	for _, g := range s.Genders {
+		if g = strings.TrimSpace(g); g != "" && !oneOf(g, genders) {
+			genders = append(genders, g)
+		}
+	}
+	s.Genders = genders
+	switch {
+	case s.MinAge != nil && *s.MinAge < 0, s.MaxAge != nil && *s.MaxAge < 0:
+		return "minAge and maxAge cannot be negative"
+	case s.MinAge != nil && s.MaxAge != nil && *s.MinAge > *s.MaxAge:
+		return "minAge cannot be more than maxAge"
+	case s.PrerequisiteWithinDays != nil && s.PrerequisiteServiceID == nil:
+		return "prerequisiteWithinDays needs a prerequisiteServiceId"
+	case s.PrerequisiteWithinDays != nil && *s.PrerequisiteWithinDays < 1:
+		return "prerequisiteWithinDays must be 1 or more"
+	case s.PrerequisiteServiceID != nil && *s.PrerequisiteServiceID == s.ID:
+		return "a service cannot be its own prerequisite"
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/email"
+)
+
+// EmailOutbox queues emails to be sent
+type EmailOutbox interface {
+	Enqueue(m *database.EmailMessage) error
+}
+
+// EmailRepository interface for the email outbox
+type EmailRepository interface {
+	EmailOutbox
+	List(f database.EmailFilter) ([]database.EmailMessage, error)
+	Requeue(id int) (*database.EmailMessage, error)
+}
+
+// EmailHandler shows the email outbox
+type EmailHandler struct {
+	repo EmailRepository
+}
+
+// NewEmailHandler creates a new email handler
+func NewEmailHandler(repo EmailRepository) *EmailHandler {
+	return &EmailHandler{repo: repo}
+}
+
+// GetEmails lists the emails in the outbox, oldest first, filtered by
+// ?patientHn=, ?template= and ?status=pending|sent|failed
+func (h *EmailHandler) GetEmails(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := database.EmailFilter{PatientHN: q.Get("patientHn"), Template: q.Get("template"), Status: q.Get("status")}
+	if filter.Template != "" && !oneOf(filter.Template, database.EmailTemplates) {
+		http.Error(w, "template must be one of "+strings.Join(database.EmailTemplates, ", "), http.StatusBadRequest)
+		return
+	}
+	if filter.Status != "" && !oneOf(filter.Status, []string{database.NotificationPending, database.NotificationSent, database.NotificationFailed}) {
+		http.Error(w, "status must be pending, sent or failed", http.StatusBadRequest)
+		return
+	}
+
+	emails, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve emails")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, emails)
+}
+
+// RequeueEmail puts an email that ran out of retries back in the outbox
+func (h *EmailHandler) RequeueEmail(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid email ID", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.repo.Requeue(id)
+	if err != nil {
+		writeError(w, err, "Failed to requeue email")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, message)
+}
+
+// queueEmail renders a template into an email to the patient and queues it,
+// if they have an email address. Like queueLINE it is best effort, so
+// failures are only logged.
+func queueEmail(outbox EmailOutbox, patient *database.Patient, m *database.EmailMessage, data interface{}) {
+	if patient.Email == nil || *patient.Email == "" {
+		return
+	}
+	subject, body, err := email.Render(m.Template, data)
+	if err != nil {
+		log.Printf("Failed to render %s email to %s: %v", m.Template, patient.HN, err)
+		return
+	}
+	hn := patient.HN
+	m.To, m.Subject, m.Body, m.PatientHN = *patient.Email, subject, body, &hn
+	if err := outbox.Enqueue(m); err != nil {
+		log.Printf("Failed to queue %s email to %s: %v", m.Template, patient.HN, err)
+	}
+}
+
+// confirmationEmail queues the email confirming a new appointment
+func confirmationEmail(outbox EmailOutbox, patient *database.Patient, a *database.Appointment, loc *time.Location) {
+	starts := a.StartsAt.In(loc)
+	id := a.ID
+	queueEmail(outbox, patient, &database.EmailMessage{Template: database.EmailAppointmentConfirmation, AppointmentID: &id},
+		email.AppointmentConfirmation{
+			PatientName: patient.FullName,
+			DoctorName:  a.DoctorName,
+			Date:        starts.Format("02/01/2006"),
+			Time:        starts.Format("15:04"),
+		})
+}
+
+// labResultEmail queues the email telling a patient their lab result is ready
+func labResultEmail(outbox EmailOutbox, patient *database.Patient, d *database.Document) {
+	id := d.ID
+	queueEmail(outbox, patient, &database.EmailMessage{Template: database.EmailLabResultReady, DocumentID: &id},
+		email.LabResultReady{PatientName: patient.FullName, Title: d.Title})
+}
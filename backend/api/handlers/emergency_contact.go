@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"clinic/backend/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// EmergencyContactRepository interface for patients' emergency contact storage
+type EmergencyContactRepository interface {
+	Create(c *database.EmergencyContact) error
+	GetByID(id int) (*database.EmergencyContact, error)
+	GetByPatient(hn string) ([]database.EmergencyContact, error)
+	Update(c *database.EmergencyContact) error
+	Delete(id int) error
+}
+
+// EmergencyContactHandler handles the emergency contacts on patient records
+type EmergencyContactHandler struct {
+	repo     EmergencyContactRepository
+	patients PatientRepository
+}
+
+// NewEmergencyContactHandler creates a new emergency contact handler
+func NewEmergencyContactHandler(repo EmergencyContactRepository, patients PatientRepository) *EmergencyContactHandler {
+	return &EmergencyContactHandler{repo: repo, patients: patients}
+}
+
+// CreateEmergencyContact adds someone to call about a patient in an
+// emergency; without a priority the contact is called after the existing ones
+func (h *EmergencyContactHandler) CreateEmergencyContact(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var contact database.EmergencyContact
+	if err := json.NewDecoder(r.Body).Decode(&contact); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	contact.PatientHN = hn
+	if msg := checkEmergencyContact(&contact); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	existing, ok := h.checkDuplicate(w, &contact)
+	if !ok {
+		return
+	}
+	if contact.Priority == 0 {
+		contact.Priority = len(existing) + 1
+	}
+
+	if err := h.repo.Create(&contact); err != nil {
+		writeError(w, err, "Failed to create emergency contact")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, contact)
+}
+
+// GetPatientEmergencyContacts lists a patient's emergency contacts in the order to call them
+func (h *EmergencyContactHandler) GetPatientEmergencyContacts(w http.ResponseWriter, r *http.Request) {
+	contacts, err := h.repo.GetByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve emergency contacts")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, contacts)
+}
+
+// GetEmergencyContact returns one emergency contact
+func (h *EmergencyContactHandler) GetEmergencyContact(w http.ResponseWriter, r *http.Request) {
+	contact, ok := h.loadContact(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, contact)
+}
+
+// UpdateEmergencyContact replaces a contact's details; without a priority
+// the contact keeps its place
+func (h *EmergencyContactHandler) UpdateEmergencyContact(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.loadContact(w, r)
+	if !ok {
+		return
+	}
+
+	var contact database.EmergencyContact
+	if err := json.NewDecoder(r.Body).Decode(&contact); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	contact.ID = existing.ID
+	contact.PatientHN = existing.PatientHN
+	if contact.Priority == 0 {
+		contact.Priority = existing.Priority
+	}
+	if msg := checkEmergencyContact(&contact); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if _, ok := h.checkDuplicate(w, &contact); !ok {
+		return
+	}
+
+	if err := h.repo.Update(&contact); err != nil {
+		writeError(w, err, "Failed to update emergency contact")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, contact)
+}
+
+// DeleteEmergencyContact removes an emergency contact
+func (h *EmergencyContactHandler) DeleteEmergencyContact(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid emergency contact ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		writeError(w, err, "Failed to delete emergency contact")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkDuplicate rejects a second contact with the same phone number for a
+// patient, writing the response when it does; it returns the patient's
+// contacts otherwise
+func (h *EmergencyContactHandler) checkDuplicate(w http.ResponseWriter, c *database.EmergencyContact) ([]database.EmergencyContact, bool) {
+	contacts, err := h.repo.GetByPatient(c.PatientHN)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve emergency contacts")
+		return nil, false
+	}
+	for _, other := range contacts {
+		if other.ID != c.ID && other.Phone == c.Phone {
+			http.Error(w, other.Name+" already has phone "+c.Phone, http.StatusConflict)
+			return nil, false
+		}
+	}
+	return contacts, true
+}
+
+// checkEmergencyContact trims and validates a contact, returning what is
+// wrong with it; the phone number is kept as digits only
+func checkEmergencyContact(c *database.EmergencyContact) string {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Relationship = strings.ToLower(strings.TrimSpace(c.Relationship))
+	c.Phone = normalizePhone(c.Phone)
+	if c.Notes != nil {
+		c.Notes = optionalText(*c.Notes)
+	}
+	switch {
+	case c.Name == "":
+		return "name is required"
+	case !oneOf(c.Relationship, database.EmergencyContactRelationships):
+		return "relationship must be one of " + strings.Join(database.EmergencyContactRelationships, ", ")
+	case len(c.Phone) < 9 || len(c.Phone) > 10 || c.Phone[0] != '0':
+		return "phone must be a Thai phone number, e.g. 0812345678"
+	case c.Priority < 0:
+		return "priority must be 1 or more"
+	}
+	return ""
+}
+
+func (h *EmergencyContactHandler) loadContact(w http.ResponseWriter, r *http.Request) (*database.EmergencyContact, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid emergency contact ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	contact, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve emergency contact")
+		return nil, false
+	}
+	return contact, true
+}
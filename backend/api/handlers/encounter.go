@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/coding"
+	"clinic/backend/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// EncounterRepository interface for visit storage
+type EncounterRepository interface {
+	Create(e *database.Encounter) error
+	GetByID(id int) (*database.Encounter, error)
+	GetByPatient(hn string) ([]database.Encounter, error)
+	Update(e *database.Encounter) error
+	Close(id int) (*database.Encounter, error)
+	Cancel(id int, c database.Cancellation) (*database.Encounter, error)
+	SaveSOAP(v *database.EncounterSOAPVersion) (*database.Encounter, error)
+	GetSOAPVersions(visitID int) ([]database.EncounterSOAPVersion, error)
+}
+
+// EncounterHandler handles patient visit records
+type EncounterHandler struct {
+	repo         EncounterRepository
+	patients     PatientRepository
+	doctors      DoctorRepository
+	appointments AppointmentRepository
+	intakes      IntakeLookup
+	reasons      CancellationReasonSource
+	codes        *coding.Table
+}
+
+// NewEncounterHandler creates a new encounter handler; diagnosis codes are
+// validated against the ICD-10 table
+func NewEncounterHandler(repo EncounterRepository, patients PatientRepository, doctors DoctorRepository, appointments AppointmentRepository,
+	intakes IntakeLookup, reasons CancellationReasonSource, codes *coding.Table) *EncounterHandler {
+	return &EncounterHandler{repo: repo, patients: patients, doctors: doctors, appointments: appointments, intakes: intakes, reasons: reasons, codes: codes}
+}
+
+// CreateVisit opens a visit for a patient. Given an appointmentId, the
+// appointment is checked in and its doctor attends unless another is named;
+// without a chiefComplaint, the one from the patient's intake form is used.
+func (h *EncounterHandler) CreateVisit(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	var visit database.Encounter
+	if err := json.NewDecoder(r.Body).Decode(&visit); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	visit.ChiefComplaint = strings.TrimSpace(visit.ChiefComplaint)
+	visit.DoctorName = strings.TrimSpace(visit.DoctorName)
+	if visit.ChiefComplaint == "" && visit.AppointmentID != nil && h.intakes != nil {
+		visit.ChiefComplaint = intakeComplaint(h.intakes, *visit.AppointmentID)
+	}
+	if visit.ChiefComplaint == "" {
+		http.Error(w, "chiefComplaint is required", http.StatusBadRequest)
+		return
+	}
+
+	if visit.AppointmentID != nil {
+		appointment, err := h.appointments.GetByID(*visit.AppointmentID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve appointment")
+			return
+		}
+		if appointment.PatientHN != patient.HN {
+			http.Error(w, "Appointment belongs to another patient", http.StatusBadRequest)
+			return
+		}
+		switch appointment.Status {
+		case database.AppointmentScheduled:
+			if _, err := h.appointments.UpdateStatus(appointment.ID, appointment.Status, database.AppointmentCheckedIn, nil); err != nil {
+				writeError(w, err, "Failed to check in appointment")
+				return
+			}
+		case database.AppointmentCheckedIn:
+		default:
+			http.Error(w, "Cannot open a visit for a "+appointment.Status+" appointment", http.StatusConflict)
+			return
+		}
+		if visit.DoctorID == nil && visit.DoctorName == "" {
+			visit.DoctorID = appointment.DoctorID
+			visit.DoctorName = appointment.DoctorName
+		}
+	}
+	if !h.resolveDoctor(w, &visit) || !h.resolveCode(w, &visit) {
+		return
+	}
+
+	visit.PatientHN = patient.HN
+	visit.GroupSessionID = nil
+	visit.Subjective, visit.Objective, visit.Assessment, visit.Plan = "", "", "", ""
+	visit.SOAPVersion = 0
+	visit.Status = database.EncounterOpen
+	visit.EndedAt = nil
+	if visit.StartedAt.IsZero() {
+		visit.StartedAt = time.Now()
+	}
+
+	if err := h.repo.Create(&visit); err != nil {
+		writeError(w, err, "Failed to create visit")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, visit)
+}
+
+// GetPatientVisits lists a patient's visits, most recent first
+func (h *EncounterHandler) GetPatientVisits(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	visits, err := h.repo.GetByPatient(patient.HN)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visits")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, visits)
+}
+
+// GetVisit returns one visit
+func (h *EncounterHandler) GetVisit(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, visit)
+}
+
+// UpdateVisit records the complaint, diagnosis, treatment and attending doctor
+// of an open visit; diagnosisCode must be in the ICD-10 table. The SOAP fields
+// are saved with SaveVisitSOAP.
+func (h *EncounterHandler) UpdateVisit(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+
+	var req database.Encounter
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.ChiefComplaint = strings.TrimSpace(req.ChiefComplaint)
+	req.DoctorName = strings.TrimSpace(req.DoctorName)
+	if req.ChiefComplaint == "" {
+		http.Error(w, "chiefComplaint is required", http.StatusBadRequest)
+		return
+	}
+	if !h.resolveDoctor(w, &req) || !h.resolveCode(w, &req) {
+		return
+	}
+
+	visit.ChiefComplaint = req.ChiefComplaint
+	visit.Diagnosis = req.Diagnosis
+	visit.DiagnosisCode = req.DiagnosisCode
+	visit.Treatment = req.Treatment
+	visit.DoctorID = req.DoctorID
+	visit.DoctorName = req.DoctorName
+	if err := h.repo.Update(visit); err != nil {
+		writeError(w, err, "Failed to update visit")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, visit)
+}
+
+// CloseVisit ends an open visit; a closed visit can no longer be edited
+func (h *EncounterHandler) CloseVisit(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+
+	closed, err := h.repo.Close(visit.ID)
+	if err != nil {
+		writeError(w, err, "Failed to close visit")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, closed)
+}
+
+// CancelVisit ends an open visit the patient was not seen in, e.g. one who left
+// before being called. reasonCode must be an active cancellation reason for
+// visits; reason says more, and is required for reasons that ask for it.
+func (h *EncounterHandler) CancelVisit(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+	cancel, ok := decodeCancellation(w, r, h.reasons, database.CancelForVisit)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.repo.Cancel(visit.ID, *cancel)
+	if err != nil {
+		writeError(w, err, "Failed to cancel visit")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cancelled)
+}
+
+// CreateSessionVisit opens a visit for a patient checking in to a group session
+func (h *EncounterHandler) CreateSessionVisit(hn string, session *database.GroupSession, at time.Time) (int, error) {
+	visit := database.Encounter{
+		PatientHN:      hn,
+		GroupSessionID: &session.ID,
+		DoctorName:     session.Facilitator,
+		ChiefComplaint: session.Title,
+		Status:         database.EncounterOpen,
+		StartedAt:      at,
+	}
+	if err := h.repo.Create(&visit); err != nil {
+		return 0, err
+	}
+	return visit.ID, nil
+}
+
+// CreateTeleconsultVisit opens the visit a video consult for an appointment is
+// recorded as, checking the appointment in, and returns its ID. An open visit
+// the appointment already has is used instead. The chief complaint comes from
+// the patient's intake form or, failing that, the appointment's reason.
+func (h *EncounterHandler) CreateTeleconsultVisit(appointment *database.Appointment, at time.Time) (int, error) {
+	visits, err := h.repo.GetByPatient(appointment.PatientHN)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range visits {
+		if v.AppointmentID != nil && *v.AppointmentID == appointment.ID {
+			if v.Status != database.EncounterOpen {
+				return 0, apperr.Conflict("appointment %d already has a %s visit", appointment.ID, v.Status)
+			}
+			return v.ID, nil
+		}
+	}
+
+	if appointment.Status == database.AppointmentScheduled {
+		if _, err := h.appointments.UpdateStatus(appointment.ID, appointment.Status, database.AppointmentCheckedIn, nil); err != nil {
+			return 0, err
+		}
+	} else if appointment.Status != database.AppointmentCheckedIn {
+		return 0, apperr.Conflict("cannot open a visit for a %s appointment", appointment.Status)
+	}
+
+	visit := database.Encounter{
+		PatientHN:     appointment.PatientHN,
+		AppointmentID: &appointment.ID,
+		DoctorID:      appointment.DoctorID,
+		DoctorName:    appointment.DoctorName,
+		Status:        database.EncounterOpen,
+		StartedAt:     at,
+	}
+	if h.intakes != nil {
+		visit.ChiefComplaint = intakeComplaint(h.intakes, appointment.ID)
+	}
+	if visit.ChiefComplaint == "" && appointment.Reason != nil {
+		visit.ChiefComplaint = strings.TrimSpace(*appointment.Reason)
+	}
+	if visit.ChiefComplaint == "" {
+		visit.ChiefComplaint = "Teleconsultation"
+	}
+	if err := h.repo.Create(&visit); err != nil {
+		return 0, err
+	}
+	return visit.ID, nil
+}
+
+// resolveCode checks a visit's diagnosisCode against the ICD-10 table,
+// filling in the diagnosis from the code's description when none is given
+func (h *EncounterHandler) resolveCode(w http.ResponseWriter, e *database.Encounter) bool {
+	if e.DiagnosisCode == nil || strings.TrimSpace(*e.DiagnosisCode) == "" {
+		e.DiagnosisCode = nil
+		return true
+	}
+
+	entry, ok := h.codes.Lookup(*e.DiagnosisCode)
+	if !ok {
+		http.Error(w, "diagnosisCode "+coding.NormalizeCode(*e.DiagnosisCode)+" is not in the ICD-10 table", http.StatusBadRequest)
+		return false
+	}
+	e.DiagnosisCode = &entry.Code
+	if e.Diagnosis == nil || strings.TrimSpace(*e.Diagnosis) == "" {
+		e.Diagnosis = &entry.Description
+	}
+	return true
+}
+
+// resolveDoctor fills in the attending doctor's name from doctorId
+func (h *EncounterHandler) resolveDoctor(w http.ResponseWriter, e *database.Encounter) bool {
+	if e.DoctorID == nil {
+		return true
+	}
+
+	doctor, err := h.doctors.GetByID(*e.DoctorID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return false
+	}
+	if !doctor.Active {
+		http.Error(w, "Doctor is no longer active", http.StatusConflict)
+		return false
+	}
+
+	e.DoctorName = doctor.FullName
+	return true
+}
+
+func (h *EncounterHandler) loadPatient(w http.ResponseWriter, r *http.Request) (*database.Patient, bool) {
+	id, err := parseHN(mux.Vars(r)["hn"])
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	return patient, true
+}
+
+func (h *EncounterHandler) loadVisit(w http.ResponseWriter, r *http.Request) (*database.Encounter, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	visit, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return nil, false
+	}
+	return visit, true
+}
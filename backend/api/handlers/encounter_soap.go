@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+	"clinic/backend/internal/textdiff"
+)
+
+// soapRequest saves a visit's SOAP fields. SOAPVersion is the version the
+// editor loaded, 0 before the first save.
+type soapRequest struct {
+	Subjective  string `json:"subjective"`
+	Objective   string `json:"objective"`
+	Assessment  string `json:"assessment"`
+	Plan        string `json:"plan"`
+	SOAPVersion int    `json:"soapVersion"`
+	AuthorName  string `json:"authorName"`
+	Reason      string `json:"reason"`
+}
+
+// SOAPSectionDiff is a line-level comparison of one SOAP field
+type SOAPSectionDiff struct {
+	Section string           `json:"section"` // subjective, objective, assessment or plan
+	Summary textdiff.Summary `json:"summary"`
+	Lines   []textdiff.Line  `json:"lines"`
+}
+
+// SOAPDiff compares two versions of a visit's SOAP fields, section by section
+type SOAPDiff struct {
+	VisitID  int               `json:"visitId"`
+	From     NoteVersionInfo   `json:"from"`
+	To       NoteVersionInfo   `json:"to"`
+	Sections []SOAPSectionDiff `json:"sections"`
+}
+
+// SaveVisitSOAP saves the Subjective, Objective, Assessment and Plan of a
+// visit as its next SOAP version. The body carries the soapVersion the editor
+// loaded; if the note was saved elsewhere since, 409 is returned with the
+// visit as stored. Editing the note of a visit that has ended needs a reason.
+// authorName defaults to the signed-in user.
+func (h *EncounterHandler) SaveVisitSOAP(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+
+	var req soapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	v := database.EncounterSOAPVersion{
+		VisitID:    visit.ID,
+		Version:    req.SOAPVersion + 1,
+		Subjective: strings.TrimSpace(req.Subjective),
+		Objective:  strings.TrimSpace(req.Objective),
+		Assessment: strings.TrimSpace(req.Assessment),
+		Plan:       strings.TrimSpace(req.Plan),
+		AuthorName: strings.TrimSpace(req.AuthorName),
+		Reason:     optionalText(req.Reason),
+	}
+	if v.AuthorName == "" {
+		v.AuthorName = reqctx.UserName(r.Context())
+	}
+	switch {
+	case req.SOAPVersion < 0:
+		http.Error(w, "Invalid soapVersion", http.StatusBadRequest)
+		return
+	case v.AuthorName == "":
+		http.Error(w, "authorName is required", http.StatusBadRequest)
+		return
+	case v.Subjective == "" && v.Objective == "" && v.Assessment == "" && v.Plan == "":
+		http.Error(w, "At least one of subjective, objective, assessment and plan is required", http.StatusBadRequest)
+		return
+	case visit.Status != database.EncounterOpen && v.Reason == nil:
+		http.Error(w, "reason is required to edit the note of a "+visit.Status+" visit", http.StatusBadRequest)
+		return
+	case visit.SOAPVersion == req.SOAPVersion && visit.Subjective == v.Subjective && visit.Objective == v.Objective &&
+		visit.Assessment == v.Assessment && visit.Plan == v.Plan:
+		http.Error(w, "Edit does not change the note", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := h.repo.SaveSOAP(&v)
+	if err != nil {
+		if !apperr.Is(err, apperr.KindConflict) {
+			writeError(w, err, "Failed to save SOAP note")
+			return
+		}
+		current, err := h.repo.GetByID(visit.ID)
+		if err != nil {
+			writeError(w, err, "Failed to save SOAP note")
+			return
+		}
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":   "The note was saved elsewhere; reload it before saving",
+			"current": current,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// GetVisitSOAPVersions returns every saved version of a visit's SOAP fields, oldest first
+func (h *EncounterHandler) GetVisitSOAPVersions(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+
+	versions, err := h.repo.GetSOAPVersions(visit.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve SOAP versions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, versions)
+}
+
+// GetVisitSOAPDiff compares two versions of a visit's SOAP fields (?from=&to=,
+// default the latest version against the one before it)
+func (h *EncounterHandler) GetVisitSOAPDiff(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+	versions, err := h.repo.GetSOAPVersions(visit.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve SOAP versions")
+		return
+	}
+
+	to, err := versionParam(r, "to", visit.SOAPVersion)
+	if err != nil {
+		http.Error(w, "Invalid to version", http.StatusBadRequest)
+		return
+	}
+	from, err := versionParam(r, "from", to-1)
+	if err != nil {
+		http.Error(w, "Invalid from version", http.StatusBadRequest)
+		return
+	}
+	if from < 1 || to > len(versions) || from >= to {
+		http.Error(w, "Versions must satisfy 1 <= from < to <= "+strconv.Itoa(len(versions)), http.StatusBadRequest)
+		return
+	}
+
+	older, newer := versions[from-1], versions[to-1]
+	diff := SOAPDiff{
+		VisitID: visit.ID,
+		From:    soapVersionInfo(older),
+		To:      soapVersionInfo(newer),
+	}
+	for _, s := range []struct{ section, old, new string }{
+		{"subjective", older.Subjective, newer.Subjective},
+		{"objective", older.Objective, newer.Objective},
+		{"assessment", older.Assessment, newer.Assessment},
+		{"plan", older.Plan, newer.Plan},
+	} {
+		lines := textdiff.Lines(s.old, s.new)
+		diff.Sections = append(diff.Sections, SOAPSectionDiff{Section: s.section, Summary: textdiff.Summarize(lines), Lines: lines})
+	}
+
+	writeJSON(w, http.StatusOK, diff)
+}
+
+func soapVersionInfo(v database.EncounterSOAPVersion) NoteVersionInfo {
+	return NoteVersionInfo{Version: v.Version, AuthorName: v.AuthorName, Reason: v.Reason, CreatedAt: v.CreatedAt}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"unicode"
+	"unicode/utf8"
+
+	"clinic/backend/internal/apperr"
+)
+
+// errorStatus maps domain error kinds to HTTP status codes
+var errorStatus = map[apperr.Kind]int{
+	apperr.KindNotFound:         http.StatusNotFound,
+	apperr.KindConflict:         http.StatusConflict,
+	apperr.KindValidation:       http.StatusBadRequest,
+	apperr.KindPermissionDenied: http.StatusForbidden,
+}
+
+// writeError answers a failed operation. Domain errors get their kind's status
+// and their own message; any other error is logged and answered 500 with fallback,
+// so storage failures never surface as "not found".
+func writeError(w http.ResponseWriter, err error, fallback string) {
+	status, ok := errorStatus[apperr.KindOf(err)]
+	if !ok {
+		log.Printf("%s: %v", fallback, err)
+		http.Error(w, fallback, http.StatusInternalServerError)
+		return
+	}
+
+	http.Error(w, capitalize(apperr.Message(err)), status)
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/esign"
+
+	"github.com/gorilla/mux"
+)
+
+// maxPrescriptionPDFSize caps uploaded prescription PDFs (5 MB)
+const maxPrescriptionPDFSize = 5 << 20
+
+// SignatureRepository interface for e-signature storage
+type SignatureRepository interface {
+	CreateKey(k *database.SigningKey) error
+	GetActiveKey(doctorID int) (*database.SigningKey, error)
+	GetKey(id int) (*database.SigningKey, error)
+	CreateSignature(s *database.PrescriptionSignature) error
+	GetSignatureByCode(code string) (*database.PrescriptionSignature, error)
+}
+
+// ESignatureHandler handles prescriber signing keys, prescription signing and public verification
+type ESignatureHandler struct {
+	repo          SignatureRepository
+	prescriptions PrescriptionRepository
+	doctors       DoctorRepository
+	signer        *esign.Signer
+	publicBaseURL string
+}
+
+// NewESignatureHandler creates a new e-signature handler. publicBaseURL is
+// the externally reachable address encoded into verification QR codes.
+func NewESignatureHandler(repo SignatureRepository, prescriptions PrescriptionRepository, doctors DoctorRepository, signer *esign.Signer, publicBaseURL string) *ESignatureHandler {
+	return &ESignatureHandler{
+		repo:          repo,
+		prescriptions: prescriptions,
+		doctors:       doctors,
+		signer:        signer,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+	}
+}
+
+// VerificationResult is the public answer to a prescription verification request
+type VerificationResult struct {
+	Valid          bool      `json:"valid"`
+	DocumentMatch  *bool     `json:"documentMatch,omitempty"` // set when a PDF was submitted for comparison
+	PrescriptionID int       `json:"prescriptionId,omitempty"`
+	DoctorName     string    `json:"doctorName,omitempty"`
+	LicenseNumber  string    `json:"licenseNumber,omitempty"`
+	SignedAt       time.Time `json:"signedAt,omitempty"`
+	DocumentHash   string    `json:"documentHash,omitempty"`
+	Message        string    `json:"message"`
+}
+
+// CreateSigningKey generates a new signing key for a doctor, revoking the
+// previous one. The key carries the name and license on the doctor's record.
+func (h *ESignatureHandler) CreateSigningKey(w http.ResponseWriter, r *http.Request) {
+	doctorID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+
+	doctor, err := h.doctors.GetByID(doctorID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return
+	}
+	if !doctor.Active {
+		http.Error(w, "Doctor is no longer active", http.StatusConflict)
+		return
+	}
+	if doctor.LicenseNumber == "" {
+		http.Error(w, "Doctor has no license number on record", http.StatusUnprocessableEntity)
+		return
+	}
+
+	publicKey, sealedKey, err := h.signer.GenerateKey()
+	if err != nil {
+		writeError(w, err, "Failed to generate signing key")
+		return
+	}
+
+	key := database.SigningKey{
+		DoctorID:      doctor.ID,
+		DoctorName:    doctor.FullName,
+		LicenseNumber: doctor.LicenseNumber,
+		PublicKey:     publicKey,
+		SealedKey:     sealedKey,
+	}
+	if err := h.repo.CreateKey(&key); err != nil {
+		writeError(w, err, "Failed to store signing key")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, key)
+}
+
+// GetSigningKey returns the doctor's active public signing key
+func (h *ESignatureHandler) GetSigningKey(w http.ResponseWriter, r *http.Request) {
+	doctorID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.repo.GetActiveKey(doctorID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve signing key")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, key)
+}
+
+// SignPrescription signs a finalized prescription PDF (request body) with the
+// prescriber's key (?doctorId=) and returns the verification URL for the QR
+// code. Prescriptions are final once issued, and only the doctor who
+// prescribed one can sign it.
+func (h *ESignatureHandler) SignPrescription(w http.ResponseWriter, r *http.Request) {
+	prescriptionID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid prescription ID", http.StatusBadRequest)
+		return
+	}
+
+	doctorID, err := strconv.Atoi(r.URL.Query().Get("doctorId"))
+	if err != nil {
+		http.Error(w, "doctorId is required", http.StatusBadRequest)
+		return
+	}
+
+	prescription, err := h.prescriptions.GetByID(prescriptionID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescription")
+		return
+	}
+	if len(prescription.Items) == 0 {
+		http.Error(w, "Prescription has no items to sign", http.StatusConflict)
+		return
+	}
+	if prescription.DoctorID != doctorID {
+		http.Error(w, "Only the prescribing doctor can sign a prescription", http.StatusForbidden)
+		return
+	}
+
+	document, ok := readPDF(w, r)
+	if !ok {
+		return
+	}
+
+	key, err := h.repo.GetActiveKey(doctorID)
+	if err != nil {
+		http.Error(w, "Doctor has no active signing key", http.StatusUnprocessableEntity)
+		return
+	}
+
+	digest, hash := esign.Digest(document)
+	signature, err := h.signer.Sign(key.SealedKey, digest)
+	if err != nil {
+		writeError(w, err, "Failed to sign prescription")
+		return
+	}
+
+	code, err := esign.NewVerificationCode()
+	if err != nil {
+		writeError(w, err, "Failed to sign prescription")
+		return
+	}
+
+	record := database.PrescriptionSignature{
+		PrescriptionID:   prescription.ID,
+		KeyID:            key.ID,
+		DoctorName:       key.DoctorName,
+		LicenseNumber:    key.LicenseNumber,
+		DocumentHash:     hash,
+		Signature:        signature,
+		VerificationCode: code,
+	}
+	if err := h.repo.CreateSignature(&record); err != nil {
+		writeError(w, err, "Failed to store prescription signature")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"signature":       record,
+		"verificationUrl": h.publicBaseURL + "/public/prescriptions/verify/" + code,
+	})
+}
+
+// VerifyPrescription is the public endpoint behind the printed QR code. GET
+// checks the stored signature; POST with the PDF body also checks that the
+// document is the one that was signed.
+func (h *ESignatureHandler) VerifyPrescription(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(mux.Vars(r)["code"])
+
+	record, err := h.repo.GetSignatureByCode(code)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, VerificationResult{Message: "ไม่พบใบสั่งยานี้ในระบบ (prescription not found)"})
+		return
+	}
+
+	key, err := h.repo.GetKey(record.KeyID)
+	if err != nil {
+		writeError(w, err, "Failed to load signing key")
+		return
+	}
+
+	digest, err := hex.DecodeString(record.DocumentHash)
+	result := VerificationResult{
+		Valid:          err == nil && esign.Verify(key.PublicKey, digest, record.Signature),
+		PrescriptionID: record.PrescriptionID,
+		DoctorName:     record.DoctorName,
+		LicenseNumber:  record.LicenseNumber,
+		SignedAt:       record.SignedAt,
+		DocumentHash:   record.DocumentHash,
+	}
+
+	if r.Method == http.MethodPost {
+		document, ok := readPDF(w, r)
+		if !ok {
+			return
+		}
+		_, hash := esign.Digest(document)
+		match := hash == record.DocumentHash
+		result.DocumentMatch = &match
+		result.Valid = result.Valid && match
+	}
+
+	if result.Valid {
+		result.Message = "ใบสั่งยาถูกต้อง (signature valid)"
+	} else {
+		result.Message = "ใบสั่งยาไม่ถูกต้อง อาจถูกแก้ไข (signature invalid or document altered)"
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// readPDF reads a PDF request body, rejecting anything that is not a PDF
+func readPDF(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	document, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPrescriptionPDFSize))
+	if err != nil {
+		http.Error(w, "Failed to read document", http.StatusBadRequest)
+		return nil, false
+	}
+	if !bytes.HasPrefix(document, []byte("%PDF-")) {
+		http.Error(w, "Request body must be a PDF document", http.StatusBadRequest)
+		return nil, false
+	}
+	return document, true
+}
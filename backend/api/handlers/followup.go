@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultFollowUpDays is how far ahead the upcoming follow-up list looks by default
+const defaultFollowUpDays = 14
+
+// FollowUpRepository interface for follow-up storage
+type FollowUpRepository interface {
+	Create(f *database.FollowUp) error
+	GetByID(id int) (*database.FollowUp, error)
+	List(f database.FollowUpFilter) ([]database.FollowUp, error)
+	Close(id int, status, by string, notes *string) (*database.FollowUp, error)
+}
+
+// FollowUpHandler handles follow-up recall dates and the call-back worklists
+type FollowUpHandler struct {
+	repo     FollowUpRepository
+	patients PatientRepository
+	visits   EncounterRepository
+}
+
+// NewFollowUpHandler creates a new follow-up handler
+func NewFollowUpHandler(repo FollowUpRepository, patients PatientRepository, visits EncounterRepository) *FollowUpHandler {
+	return &FollowUpHandler{repo: repo, patients: patients, visits: visits}
+}
+
+// CreateVisitFollowUp schedules a recall date from a visit, given as dueDate
+// or inDays from today; scheduledBy defaults to the visit's doctor
+func (h *FollowUpHandler) CreateVisitFollowUp(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+
+	var req struct {
+		DueDate     string `json:"dueDate"`
+		InDays      *int   `json:"inDays,omitempty"`
+		Reason      string `json:"reason"`
+		ScheduledBy string `json:"scheduledBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	day := today(r)
+	if req.InDays != nil {
+		if req.DueDate != "" {
+			http.Error(w, "Give dueDate or inDays, not both", http.StatusBadRequest)
+			return
+		}
+		if *req.InDays < 1 {
+			http.Error(w, "inDays must be at least 1", http.StatusBadRequest)
+			return
+		}
+		req.DueDate = localNow(r).AddDate(0, 0, *req.InDays).Format("2006-01-02")
+	}
+	followUp := database.FollowUp{
+		PatientHN:   visit.PatientHN,
+		VisitID:     visit.ID,
+		DueDate:     strings.TrimSpace(req.DueDate),
+		Reason:      strings.TrimSpace(req.Reason),
+		Status:      database.FollowUpPending,
+		ScheduledBy: strings.TrimSpace(req.ScheduledBy),
+	}
+	if followUp.ScheduledBy == "" {
+		followUp.ScheduledBy = visit.DoctorName
+	}
+	if msg := checkFollowUp(&followUp, day); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Create(&followUp); err != nil {
+		writeError(w, err, "Failed to create follow-up")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, followUp)
+}
+
+// GetVisitFollowUps lists the follow-ups scheduled in a visit
+func (h *FollowUpHandler) GetVisitFollowUps(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	h.list(w, database.FollowUpFilter{VisitID: visitID}, false)
+}
+
+// GetPatientFollowUps lists a patient's follow-ups, soonest due first (?status=)
+func (h *FollowUpHandler) GetPatientFollowUps(w http.ResponseWriter, r *http.Request) {
+	h.list(w, database.FollowUpFilter{PatientHN: mux.Vars(r)["hn"], Status: r.URL.Query().Get("status")}, false)
+}
+
+// GetUpcomingFollowUps is the call-back worklist of pending follow-ups due
+// from today to ?days= ahead (default 14), with each patient's name and phone
+func (h *FollowUpHandler) GetUpcomingFollowUps(w http.ResponseWriter, r *http.Request) {
+	days := defaultFollowUpDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > 365 {
+			http.Error(w, "days must be between 0 and 365", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	h.list(w, database.FollowUpFilter{
+		Status:  database.FollowUpPending,
+		DueFrom: today(r),
+		DueTo:   localNow(r).AddDate(0, 0, days).Format("2006-01-02"),
+	}, true)
+}
+
+// GetOverdueFollowUps is the worklist of pending follow-ups whose due date has
+// passed without the patient being reached, longest overdue first
+func (h *FollowUpHandler) GetOverdueFollowUps(w http.ResponseWriter, r *http.Request) {
+	h.list(w, database.FollowUpFilter{
+		Status: database.FollowUpPending,
+		DueTo:  localNow(r).AddDate(0, 0, -1).Format("2006-01-02"),
+	}, true)
+}
+
+// GetFollowUp returns one follow-up
+func (h *FollowUpHandler) GetFollowUp(w http.ResponseWriter, r *http.Request) {
+	followUp, ok := h.loadFollowUp(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, followUp)
+}
+
+// MarkFollowUpContacted records that staff reached the patient, with optional
+// notes on the call; contactedBy defaults to the signed-in user
+func (h *FollowUpHandler) MarkFollowUpContacted(w http.ResponseWriter, r *http.Request) {
+	h.close(w, r, database.FollowUpContacted)
+}
+
+// CancelFollowUp drops a pending follow-up that is no longer needed, with
+// optional notes on why
+func (h *FollowUpHandler) CancelFollowUp(w http.ResponseWriter, r *http.Request) {
+	h.close(w, r, database.FollowUpCancelled)
+}
+
+func (h *FollowUpHandler) close(w http.ResponseWriter, r *http.Request, status string) {
+	followUp, ok := h.loadFollowUp(w, r)
+	if !ok {
+		return
+	}
+	if followUp.Status != database.FollowUpPending {
+		http.Error(w, "Follow-up is already "+followUp.Status, http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		Notes       string `json:"notes"`
+		ContactedBy string `json:"contactedBy"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	by := strings.TrimSpace(req.ContactedBy)
+	if by == "" {
+		by = reqctx.UserName(r.Context())
+	}
+	if by == "" {
+		http.Error(w, "contactedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.repo.Close(followUp.ID, status, by, optionalText(req.Notes))
+	if err != nil {
+		writeError(w, err, "Failed to update follow-up")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// list writes the follow-ups matching f; contacts adds each patient's name and
+// phone for the worklists staff call from
+func (h *FollowUpHandler) list(w http.ResponseWriter, f database.FollowUpFilter, contacts bool) {
+	followUps, err := h.repo.List(f)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve follow-ups")
+		return
+	}
+
+	if contacts {
+		patients := make(map[string]*database.Patient)
+		for i := range followUps {
+			hn := followUps[i].PatientHN
+			patient, seen := patients[hn]
+			if !seen {
+				if id, err := parseHN(hn); err == nil {
+					patient, _ = h.patients.GetByID(id)
+				}
+				patients[hn] = patient
+			}
+			if patient != nil {
+				followUps[i].PatientName, followUps[i].Phone = patient.FullName, patient.Phone
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, followUps)
+}
+
+func (h *FollowUpHandler) loadFollowUp(w http.ResponseWriter, r *http.Request) (*database.FollowUp, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid follow-up ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	followUp, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve follow-up")
+		return nil, false
+	}
+	return followUp, true
+}
+
+// checkFollowUp validates a follow-up scheduled on day, returning what is wrong with it
+func checkFollowUp(f *database.FollowUp, day string) string {
+	if f.DueDate == "" {
+		return "dueDate or inDays is required"
+	}
+	if _, err := time.Parse("2006-01-02", f.DueDate); err != nil {
+		return "Invalid dueDate, expected YYYY-MM-DD"
+	}
+	if f.DueDate <= day {
+		return "dueDate must be after today"
+	}
+	if f.Reason == "" {
+		return "reason is required"
+	}
+	if f.ScheduledBy == "" {
+		return "scheduledBy is required"
+	}
+	return ""
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/forms"
+)
+
+// FormRepository interface for structured form storage
+type FormRepository interface {
+	CreateDefinition(d *database.FormDefinition) error
+	GetDefinition(id int) (*database.FormDefinition, error)
+	GetDefinitions(clinicID int, specialty string) ([]database.FormDefinition, error)
+	UpdateDefinition(d *database.FormDefinition) error
+	CreateSubmission(s *database.FormSubmission, answers []database.FormAnswer) error
+	GetSubmissionsByVisit(visitID int) ([]database.FormSubmission, error)
+	GetLatestSubmission(visitID, formID int) (*database.FormSubmission, error)
+	QueryAnswers(q database.AnswerQuery) ([]database.FormAnswer, error)
+}
+
+// FormHandler handles structured clinical form requests
+type FormHandler struct {
+	repo FormRepository
+}
+
+// NewFormHandler creates a new form handler
+func NewFormHandler(repo FormRepository) *FormHandler {
+	return &FormHandler{repo: repo}
+}
+
+// RenderedForm is a form definition with the values of a visit's latest submission
+type RenderedForm struct {
+	FormID       int                   `json:"formId"`
+	Name         string                `json:"name"`
+	Version      int                   `json:"version"`
+	VisitID      int                   `json:"visitId"`
+	SubmissionID *int                  `json:"submissionId,omitempty"`
+	Fields       []forms.RenderedField `json:"fields"`
+}
+
+// formClinicID reads ?clinicId=, defaulting to clinic 1 for single-clinic installs
+func formClinicID(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("clinicId")
+	if s == "" {
+		return 1, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// CreateForm creates a form definition for a clinic
+func (h *FormHandler) CreateForm(w http.ResponseWriter, r *http.Request) {
+	var form database.FormDefinition
+	if err := json.NewDecoder(r.Body).Decode(&form); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if form.Code == "" || form.Name == "" {
+		http.Error(w, "code and name are required", http.StatusBadRequest)
+		return
+	}
+	if err := forms.ValidateDefinition(form.Fields); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if form.ClinicID == 0 {
+		form.ClinicID = 1
+	}
+	form.Version = 1
+	form.Active = true
+	if err := h.repo.CreateDefinition(&form); err != nil {
+		writeError(w, err, "Failed to create form")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, form)
+}
+
+// GetForms returns a clinic's form definitions (?clinicId=&specialty=)
+func (h *FormHandler) GetForms(w http.ResponseWriter, r *http.Request) {
+	clinicID, err := formClinicID(r)
+	if err != nil {
+		http.Error(w, "Invalid clinic ID", http.StatusBadRequest)
+		return
+	}
+
+	definitions, err := h.repo.GetDefinitions(clinicID, r.URL.Query().Get("specialty"))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve forms")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, definitions)
+}
+
+// GetForm returns a form definition
+func (h *FormHandler) GetForm(w http.ResponseWriter, r *http.Request) {
+	form, ok := h.loadForm(w, r, "id")
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, form)
+}
+
+// UpdateForm replaces a form's name, specialty, fields or active flag.
+// Changing the fields bumps the version; past submissions keep theirs.
+func (h *FormHandler) UpdateForm(w http.ResponseWriter, r *http.Request) {
+	form, ok := h.loadForm(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name      *string              `json:"name"`
+		Specialty *string              `json:"specialty"`
+		Fields    []database.FormField `json:"fields"`
+		Active    *bool                `json:"active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name != nil {
+		form.Name = *req.Name
+	}
+	if req.Specialty != nil {
+		form.Specialty = *req.Specialty
+	}
+	if req.Active != nil {
+		form.Active = *req.Active
+	}
+	if req.Fields != nil {
+		if err := forms.ValidateDefinition(req.Fields); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		form.Fields = req.Fields
+		form.Version++
+	}
+
+	if err := h.repo.UpdateDefinition(form); err != nil {
+		writeError(w, err, "Failed to update form")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, form)
+}
+
+// SubmitForm validates and stores a filled-in form for a visit
+func (h *FormHandler) SubmitForm(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	form, ok := h.loadForm(w, r, "formId")
+	if !ok {
+		return
+	}
+	if !form.Active {
+		http.Error(w, "Form is no longer active", http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		PatientHN   string                 `json:"patientHn"`
+		SubmittedBy string                 `json:"submittedBy"`
+		Answers     map[string]interface{} `json:"answers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.PatientHN == "" || req.SubmittedBy == "" {
+		http.Error(w, "patientHn and submittedBy are required", http.StatusBadRequest)
+		return
+	}
+
+	answers, fieldErrors := forms.Validate(form.Fields, req.Answers)
+	if len(fieldErrors) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"errors": fieldErrors})
+		return
+	}
+
+	submission := database.FormSubmission{
+		FormID:      form.ID,
+		FormVersion: form.Version,
+		VisitID:     visitID,
+		PatientHN:   req.PatientHN,
+		Answers:     answers,
+		SubmittedBy: req.SubmittedBy,
+	}
+	if err := h.repo.CreateSubmission(&submission, forms.Flatten(form.Fields, answers)); err != nil {
+		writeError(w, err, "Failed to store form submission")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, submission)
+}
+
+// GetVisitForms returns all form submissions of a visit
+func (h *FormHandler) GetVisitForms(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	submissions, err := h.repo.GetSubmissionsByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve form submissions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, submissions)
+}
+
+// RenderVisitForm returns the form's fields in order, filled with the visit's
+// latest answers (empty values when the form has not been submitted yet)
+func (h *FormHandler) RenderVisitForm(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	form, ok := h.loadForm(w, r, "formId")
+	if !ok {
+		return
+	}
+
+	rendered := RenderedForm{FormID: form.ID, Name: form.Name, Version: form.Version, VisitID: visitID}
+	var answers map[string]interface{}
+	if submission, err := h.repo.GetLatestSubmission(visitID, form.ID); err == nil {
+		rendered.SubmissionID = &submission.ID
+		answers = submission.Answers
+	}
+	rendered.Fields = forms.Render(form.Fields, answers)
+
+	writeJSON(w, http.StatusOK, rendered)
+}
+
+// QueryAnswers searches one field's answers across visits:
+// ?field=site&value=face or ?field=size_mm&min=10&max=20
+func (h *FormHandler) QueryAnswers(w http.ResponseWriter, r *http.Request) {
+	formID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid form ID", http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	q := database.AnswerQuery{FormID: formID, FieldKey: params.Get("field")}
+	if q.FieldKey == "" {
+		http.Error(w, "field is required", http.StatusBadRequest)
+		return
+	}
+	if v := params.Get("value"); v != "" {
+		q.Value = &v
+	}
+	for name, bound := range map[string]**float64{"min": &q.Min, "max": &q.Max} {
+		if s := params.Get(name); s != "" {
+			n, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				http.Error(w, "Invalid "+name+" value", http.StatusBadRequest)
+				return
+			}
+			*bound = &n
+		}
+	}
+
+	answers, err := h.repo.QueryAnswers(q)
+	if err != nil {
+		writeError(w, err, "Failed to query form answers")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, answers)
+}
+
+func (h *FormHandler) loadForm(w http.ResponseWriter, r *http.Request, name string) (*database.FormDefinition, bool) {
+	id, err := pathID(r, name)
+	if err != nil {
+		http.Error(w, "Invalid form ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	form, err := h.repo.GetDefinition(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve form")
+		return nil, false
+	}
+
+	return form, true
+}
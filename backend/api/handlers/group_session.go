@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// GroupSessionRepository interface for group session storage
+type GroupSessionRepository interface {
+	CreateSession(s *database.GroupSession) error
+	GetSession(id int) (*database.GroupSession, error)
+	GetSessions(from, to time.Time) ([]database.GroupSession, error)
+	UpdateSessionStatus(id int, status string) error
+	Book(b *database.GroupBooking) error
+	GetBookings(sessionID int) ([]database.GroupBooking, error)
+	UpdateBooking(b *database.GroupBooking) error
+}
+
+// SessionVisitCreator opens a visit record for a patient checking in to a group session
+type SessionVisitCreator interface {
+	CreateSessionVisit(hn string, session *database.GroupSession, at time.Time) (int, error)
+}
+
+// GroupSessionHandler handles group appointment session requests
+type GroupSessionHandler struct {
+	repo     GroupSessionRepository
+	patients PatientRepository
+	visits   SessionVisitCreator
+}
+
+// NewGroupSessionHandler creates a new group session handler.
+// With a nil visit creator, check-ins are recorded without a visit.
+func NewGroupSessionHandler(repo GroupSessionRepository, patients PatientRepository, visits SessionVisitCreator) *GroupSessionHandler {
+	return &GroupSessionHandler{repo: repo, patients: patients, visits: visits}
+}
+
+// SessionWithBookings is a session together with its bookings
+type SessionWithBookings struct {
+	database.GroupSession
+	Bookings []database.GroupBooking `json:"bookings"`
+}
+
+// CreateSession schedules a new group session
+func (h *GroupSessionHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	var session database.GroupSession
+	if err := json.NewDecoder(r.Body).Decode(&session); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if session.Title == "" || session.SessionType == "" {
+		http.Error(w, "title and sessionType are required", http.StatusBadRequest)
+		return
+	}
+	if session.StartsAt.IsZero() || !session.EndsAt.After(session.StartsAt) {
+		http.Error(w, "startsAt and a later endsAt are required", http.StatusBadRequest)
+		return
+	}
+	if session.Capacity < 1 {
+		http.Error(w, "capacity must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	session.Status = database.SessionStatusScheduled
+	session.BookedCount = 0
+	if err := h.repo.CreateSession(&session); err != nil {
+		writeError(w, err, "Failed to create group session")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, session)
+}
+
+// GetSessions returns sessions starting within ?from=&to= (defaults to this month)
+func (h *GroupSessionHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := h.repo.GetSessions(from, to)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve group sessions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// GetSession returns a session with its bookings
+func (h *GroupSessionHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.loadSession(w, r)
+	if !ok {
+		return
+	}
+
+	bookings, err := h.repo.GetBookings(session.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve bookings")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SessionWithBookings{GroupSession: *session, Bookings: bookings})
+}
+
+// CancelSession cancels a scheduled session and all of its open bookings
+func (h *GroupSessionHandler) CancelSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.loadSession(w, r)
+	if !ok {
+		return
+	}
+	if session.Status != database.SessionStatusScheduled {
+		http.Error(w, "Only scheduled sessions can be cancelled", http.StatusConflict)
+		return
+	}
+
+	bookings, err := h.repo.GetBookings(session.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve bookings")
+		return
+	}
+	for _, b := range bookings {
+		if b.Status == database.BookingStatusCheckedIn {
+			http.Error(w, "Patients have already checked in to this session", http.StatusConflict)
+			return
+		}
+	}
+
+	if err := h.repo.UpdateSessionStatus(session.ID, database.SessionStatusCancelled); err != nil {
+		writeError(w, err, "Failed to cancel group session")
+		return
+	}
+	for i := range bookings {
+		if bookings[i].Status == database.BookingStatusBooked {
+			bookings[i].Status = database.BookingStatusCancelled
+			if err := h.repo.UpdateBooking(&bookings[i]); err != nil {
+				writeError(w, err, "Failed to cancel bookings")
+				return
+			}
+		}
+	}
+
+	session.Status = database.SessionStatusCancelled
+	session.BookedCount = 0
+	writeJSON(w, http.StatusOK, SessionWithBookings{GroupSession: *session, Bookings: bookings})
+}
+
+// BookPatient reserves a seat in a session for a patient
+func (h *GroupSessionHandler) BookPatient(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.loadSession(w, r)
+	if !ok {
+		return
+	}
+	if session.Status != database.SessionStatusScheduled {
+		http.Error(w, "Session is not open for booking", http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		PatientHN string `json:"patientHn"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PatientHN == "" {
+		http.Error(w, "patientHn is required", http.StatusBadRequest)
+		return
+	}
+	id, err := parseHN(req.PatientHN)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	bookings, err := h.repo.GetBookings(session.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve bookings")
+		return
+	}
+	for _, b := range bookings {
+		if b.PatientHN == req.PatientHN && b.Status != database.BookingStatusCancelled {
+			http.Error(w, "Patient is already booked into this session", http.StatusConflict)
+			return
+		}
+	}
+
+	booking := database.GroupBooking{
+		SessionID: session.ID,
+		PatientHN: req.PatientHN,
+		Status:    database.BookingStatusBooked,
+	}
+	if err := h.repo.Book(&booking); err != nil {
+		writeError(w, err, "Failed to book session")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, booking)
+}
+
+// CancelBooking frees a patient's seat
+func (h *GroupSessionHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	booking, ok := h.loadBooking(w, r)
+	if !ok {
+		return
+	}
+	if booking.Status != database.BookingStatusBooked {
+		http.Error(w, "Only open bookings can be cancelled", http.StatusConflict)
+		return
+	}
+
+	booking.Status = database.BookingStatusCancelled
+	if err := h.repo.UpdateBooking(booking); err != nil {
+		writeError(w, err, "Failed to cancel booking")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, booking)
+}
+
+// CheckIn marks a booked patient as arrived and opens their own visit record
+func (h *GroupSessionHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.loadSession(w, r)
+	if !ok {
+		return
+	}
+	if session.Status == database.SessionStatusCancelled {
+		http.Error(w, "Session has been cancelled", http.StatusConflict)
+		return
+	}
+	booking, ok := h.loadBooking(w, r)
+	if !ok {
+		return
+	}
+	if booking.Status != database.BookingStatusBooked {
+		http.Error(w, "Booking is not awaiting check-in", http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	if h.visits != nil {
+		visitID, err := h.visits.CreateSessionVisit(booking.PatientHN, session, now)
+		if err != nil {
+			writeError(w, err, "Failed to open visit")
+			return
+		}
+		booking.VisitID = &visitID
+	}
+
+	booking.Status = database.BookingStatusCheckedIn
+	booking.CheckedInAt = &now
+	if err := h.repo.UpdateBooking(booking); err != nil {
+		writeError(w, err, "Failed to check in")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, booking)
+}
+
+func (h *GroupSessionHandler) loadSession(w http.ResponseWriter, r *http.Request) (*database.GroupSession, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	session, err := h.repo.GetSession(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve group session")
+		return nil, false
+	}
+
+	return session, true
+}
+
+func (h *GroupSessionHandler) loadBooking(w http.ResponseWriter, r *http.Request) (*database.GroupBooking, bool) {
+	sessionID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return nil, false
+	}
+	bookingID, err := pathID(r, "bookingId")
+	if err != nil {
+		http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	bookings, err := h.repo.GetBookings(sessionID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve bookings")
+		return nil, false
+	}
+	for i := range bookings {
+		if bookings[i].ID == bookingID {
+			return &bookings[i], true
+		}
+	}
+
+	http.Error(w, "Booking not found", http.StatusNotFound)
+	return nil, false
+}
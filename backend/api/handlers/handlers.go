@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"clinic/backend/internal/database"
 
@@ -12,12 +13,16 @@ import (
 
 // PatientHandler handles patient-related HTTP requests
 type PatientHandler struct {
-	repo PatientRepository
+	repo      PatientRepository
+	allergies AllergyRepository
+	problems  ProblemRepository
+	rules     PatientRuleRepository
 }
 
 // PatientRepository interface for database operations
 type PatientRepository interface {
 	GetAll() ([]database.Patient, error)
+	GetAllFields(fields []string) ([]map[string]interface{}, error)
 	GetByID(id int) (*database.Patient, error)
 	Create(p *database.Patient) error
 	Update(p *database.Patient) error
@@ -25,26 +30,31 @@ type PatientRepository interface {
 }
 
 // NewPatientHandler creates a new patient handler
-func NewPatientHandler(repo PatientRepository) *PatientHandler {
-	return &PatientHandler{repo: repo}
+func NewPatientHandler(repo PatientRepository, allergies AllergyRepository, problems ProblemRepository, rules PatientRuleRepository) *PatientHandler {
+	return &PatientHandler{repo: repo, allergies: allergies, problems: problems, rules: rules}
 }
 
-// HealthCheck handles the health check endpoint
-func HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response := map[string]string{
-		"status":  "healthy",
-		"message": "API is running",
+// GetPatients returns a list of all patients; ?fields=hn,fullName,phone
+// returns only those fields, leaving photos out of autocomplete lists
+func (h *PatientHandler) GetPatients(w http.ResponseWriter, r *http.Request) {
+	fields, err := database.PatientFields.Fields(r.URL.Query().Get("fields"))
+	if err != nil {
+		writeError(w, err, "Invalid fields")
+		return
+	}
+	if fields != nil {
+		patients, err := h.repo.GetAllFields(fields)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve patients")
+			return
+		}
+		writeJSON(w, http.StatusOK, patients)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
-}
-
-// GetPatients returns a list of all patients
-func (h *PatientHandler) GetPatients(w http.ResponseWriter, r *http.Request) {
 	patients, err := h.repo.GetAll()
 	if err != nil {
-		http.Error(w, "Failed to retrieve patients", http.StatusInternalServerError)
+		writeError(w, err, "Failed to retrieve patients")
 		return
 	}
 
@@ -52,7 +62,7 @@ func (h *PatientHandler) GetPatients(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(patients)
 }
 
-// GetPatient returns a single patient by HN
+// GetPatient returns a single patient by HN, with their active allergies and problems
 func (h *PatientHandler) GetPatient(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	hnString := vars["hn"]
@@ -66,7 +76,17 @@ func (h *PatientHandler) GetPatient(w http.ResponseWriter, r *http.Request) {
 
 	patient, err := h.repo.GetByID(id)
 	if err != nil {
-		http.Error(w, "Patient not found", http.StatusNotFound)
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+	patient.Allergies, err = h.allergies.GetByPatient(patient.HN, true)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve allergies")
+		return
+	}
+	patient.Problems, err = h.problems.GetByPatient(patient.HN, true)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve problems")
 		return
 	}
 
@@ -74,16 +94,21 @@ func (h *PatientHandler) GetPatient(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(patient)
 }
 
-// CreatePatient creates a new patient
+// CreatePatient creates a new patient; the fields the clinic requires must be filled in
 func (h *PatientHandler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 	var patient database.Patient
 	if err := json.NewDecoder(r.Body).Decode(&patient); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	patient.Allergies = nil // managed through /api/patients/{hn}/allergies
+	patient.Problems = nil  // and /api/patients/{hn}/problems
+	if ok := checkPatient(w, h.rules, &patient); !ok {
+		return
+	}
 
 	if err := h.repo.Create(&patient); err != nil {
-		http.Error(w, "Failed to create patient", http.StatusInternalServerError)
+		writeError(w, err, "Failed to create patient")
 		return
 	}
 
@@ -92,7 +117,7 @@ func (h *PatientHandler) CreatePatient(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(patient)
 }
 
-// UpdatePatient updates an existing patient
+// UpdatePatient updates an existing patient, checked against the clinic's field rules
 func (h *PatientHandler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	hnString := vars["hn"]
@@ -104,8 +129,13 @@ func (h *PatientHandler) UpdatePatient(w http.ResponseWriter, r *http.Request) {
 	}
 
 	patient.HN = hnString
+	patient.Allergies = nil
+	patient.Problems = nil
+	if ok := checkPatient(w, h.rules, &patient); !ok {
+		return
+	}
 	if err := h.repo.Update(&patient); err != nil {
-		http.Error(w, "Failed to update patient", http.StatusInternalServerError)
+		writeError(w, err, "Failed to update patient")
 		return
 	}
 
@@ -126,9 +156,67 @@ func (h *PatientHandler) DeletePatient(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.repo.Delete(id); err != nil {
-		http.Error(w, "Failed to delete patient", http.StatusInternalServerError)
+		writeError(w, err, "Failed to delete patient")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// PatientRuleSource provides the clinic's configured patient field rules
+type PatientRuleSource interface {
+	GetAll() ([]database.PatientFieldRule, error)
+}
+
+// checkPatient validates a patient against the clinic's field rules, writing
+// the response when it fails
+func checkPatient(w http.ResponseWriter, rules PatientRuleSource, p *database.Patient) bool {
+	p.FullName = strings.TrimSpace(p.FullName)
+	if p.FullName == "" {
+		http.Error(w, "fullName is required", http.StatusBadRequest)
+		return false
+	}
+	if p.CitizenID != nil {
+		id := strings.ReplaceAll(strings.TrimSpace(*p.CitizenID), "-", "")
+		if id != "" && !validCitizenID(id) {
+			http.Error(w, "citizenId must be a valid 13-digit Thai citizen ID", http.StatusBadRequest)
+			return false
+		}
+		p.CitizenID = &id
+	}
+	if p.Email != nil {
+		p.Email = optionalText(*p.Email)
+	}
+	if p.Email != nil && !strings.Contains(*p.Email, "@") {
+		http.Error(w, "Invalid email", http.StatusBadRequest)
+		return false
+	}
+
+	configured, err := rules.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient field rules")
+		return false
+	}
+	if missing := database.NewPatientFieldRules(configured).Missing(p); len(missing) > 0 {
+		http.Error(w, strings.Join(missing, ", ")+" required by this clinic", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// validCitizenID checks a Thai citizen ID's length and check digit
+func validCitizenID(id string) bool {
+	if len(id) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(id[i]-'0') * (13 - i)
+		}
+	}
+	return (11-sum%11)%10 == int(id[12]-'0')
+}
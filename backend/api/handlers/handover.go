@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// departmentPattern matches department keys such as "opd" or "er"
+var departmentPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,29}$`)
+
+// HandoverRepository interface for handover note storage
+type HandoverRepository interface {
+	Create(n *database.HandoverNote) error
+	List(f database.HandoverFilter) ([]database.HandoverNote, error)
+	Resolve(id int, resolvedBy string) (*database.HandoverNote, error)
+}
+
+// HandoverHandler handles the notes shifts leave each other at handover
+type HandoverHandler struct {
+	repo HandoverRepository
+}
+
+// NewHandoverHandler creates a new handover handler
+func NewHandoverHandler(repo HandoverRepository) *HandoverHandler {
+	return &HandoverHandler{repo: repo}
+}
+
+// CreateHandoverNote adds a pending issue to the department's thread for today
+func (h *HandoverHandler) CreateHandoverNote(w http.ResponseWriter, r *http.Request) {
+	department, ok := handoverDepartment(w, r)
+	if !ok {
+		return
+	}
+
+	var note database.HandoverNote
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	note.Department = department
+	note.ShiftDate = time.Now().Format("2006-01-02")
+	note.Body = strings.TrimSpace(note.Body)
+	if note.Body == "" {
+		http.Error(w, "body is required", http.StatusBadRequest)
+		return
+	}
+	switch note.Category {
+	case "":
+		note.Category = database.HandoverOther
+	case database.HandoverAwaitingResult, database.HandoverCallBack, database.HandoverFollowUp, database.HandoverOther:
+	default:
+		http.Error(w, "category must be awaiting_result, call_back, follow_up or other", http.StatusBadRequest)
+		return
+	}
+	if note.PatientHN != nil {
+		if _, err := parseHN(*note.PatientHN); err != nil {
+			http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+			return
+		}
+	}
+	if note.Author == "" {
+		note.Author = reqctx.UserName(r.Context())
+	}
+	if note.Author == "" {
+		http.Error(w, "author is required", http.StatusBadRequest)
+		return
+	}
+	note.ResolvedBy, note.ResolvedAt, note.ArchivedAt = nil, nil, nil
+
+	if err := h.repo.Create(&note); err != nil {
+		writeError(w, err, "Failed to create handover note")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, note)
+}
+
+// GetHandover returns the department's live thread, every note not yet
+// archived, for the incoming shift; ?date=YYYY-MM-DD returns that day's
+// thread instead, archived notes included
+func (h *HandoverHandler) GetHandover(w http.ResponseWriter, r *http.Request) {
+	department, ok := handoverDepartment(w, r)
+	if !ok {
+		return
+	}
+	filter := database.HandoverFilter{Department: department, Date: r.URL.Query().Get("date")}
+	if filter.Date != "" {
+		if _, err := time.Parse("2006-01-02", filter.Date); err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	notes, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve handover notes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// ResolveHandoverNote marks a pending issue dealt with
+func (h *HandoverHandler) ResolveHandoverNote(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid handover note ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ResolvedBy string `json:"resolvedBy"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.ResolvedBy == "" {
+		req.ResolvedBy = reqctx.UserName(r.Context())
+	}
+	if req.ResolvedBy == "" {
+		http.Error(w, "resolvedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.repo.Resolve(id, req.ResolvedBy)
+	if err != nil {
+		writeError(w, err, "Failed to resolve handover note")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, note)
+}
+
+func handoverDepartment(w http.ResponseWriter, r *http.Request) (string, bool) {
+	department := mux.Vars(r)["department"]
+	if !departmentPattern.MatchString(department) {
+		http.Error(w, "Invalid department", http.StatusBadRequest)
+		return "", false
+	}
+	return department, true
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"clinic/backend/internal/health"
+	"clinic/backend/internal/reqctx"
+)
+
+// HealthHandler reports API and dependency health
+type HealthHandler struct {
+	checks *health.Registry
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(checks *health.Registry) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Health checks every dependency and answers 503 when one is down, so load
+// balancers stop routing here. Only administrators see per-dependency detail,
+// since error messages can reveal hosts and credentials in use.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	statuses := h.checks.Check(r.Context())
+
+	code := http.StatusOK
+	response := map[string]interface{}{
+		"status":  "healthy",
+		"message": "API is running",
+	}
+	if !health.Healthy(statuses) {
+		code = http.StatusServiceUnavailable
+		response["status"] = "degraded"
+		response["message"] = "One or more dependencies are unavailable"
+	}
+	if reqctx.From(r.Context()).HasRole(reqctx.RoleAdmin) {
+		response["dependencies"] = statuses
+	}
+
+	writeJSON(w, code, response)
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// InsuranceRepository interface for insurance policy and claim storage
+type InsuranceRepository interface {
+	CreatePolicy(p *database.InsurancePolicy) error
+	GetPolicy(id int) (*database.InsurancePolicy, error)
+	GetPoliciesByPatient(hn string) ([]database.InsurancePolicy, error)
+	UpdatePolicy(p *database.InsurancePolicy) error
+	DeletePolicy(id int) error
+	CreateClaim(c *database.InsuranceClaim) error
+	GetClaim(id int) (*database.InsuranceClaim, error)
+	ListClaims(f database.ClaimFilter) ([]database.InsuranceClaim, error)
+	UpdateClaimStatus(id int, from string, d database.ClaimDecision) (*database.InsuranceClaim, error)
+}
+
+// InsuranceHandler handles patient insurance policies and the claims made under them
+type InsuranceHandler struct {
+	repo     InsuranceRepository
+	patients PatientRepository
+	invoices InvoiceRepository
+}
+
+// NewInsuranceHandler creates a new insurance handler
+func NewInsuranceHandler(repo InsuranceRepository, patients PatientRepository, invoices InvoiceRepository) *InsuranceHandler {
+	return &InsuranceHandler{repo: repo, patients: patients, invoices: invoices}
+}
+
+// CreatePolicy puts a patient's insurance policy on file
+func (h *InsuranceHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var policy database.InsurancePolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	policy.PatientHN = hn
+	if msg := checkPolicy(&policy); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.CreatePolicy(&policy); err != nil {
+		writeError(w, err, "Failed to create insurance policy")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, policy)
+}
+
+// GetPatientPolicies lists a patient's policies, most recently started first
+func (h *InsuranceHandler) GetPatientPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.repo.GetPoliciesByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve insurance policies")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policies)
+}
+
+// UpdatePolicy replaces a policy's details; set validTo to end a policy
+func (h *InsuranceHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid policy ID", http.StatusBadRequest)
+		return
+	}
+	existing, err := h.repo.GetPolicy(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve insurance policy")
+		return
+	}
+
+	var policy database.InsurancePolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	policy.ID = existing.ID
+	policy.PatientHN = existing.PatientHN
+	if msg := checkPolicy(&policy); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.UpdatePolicy(&policy); err != nil {
+		writeError(w, err, "Failed to update insurance policy")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// DeletePolicy removes a policy entered by mistake
+func (h *InsuranceHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid policy ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeletePolicy(id); err != nil {
+		writeError(w, err, "Failed to delete insurance policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateClaim submits a claim for an issued or paid invoice under one of the
+// patient's policies in force on the invoice date. The amount defaults to the
+// invoice total, capped at the policy's coverage limit.
+func (h *InsuranceHandler) CreateClaim(w http.ResponseWriter, r *http.Request) {
+	invoiceID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		PolicyID    int      `json:"policyId"`
+		Amount      *float64 `json:"amount"`
+		Notes       *string  `json:"notes"`
+		SubmittedBy string   `json:"submittedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.PolicyID == 0 {
+		http.Error(w, "policyId is required", http.StatusBadRequest)
+		return
+	}
+	if req.SubmittedBy == "" {
+		req.SubmittedBy = reqctx.UserName(r.Context())
+	}
+	if req.SubmittedBy == "" {
+		http.Error(w, "submittedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	invoice, err := h.invoices.GetByID(invoiceID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve invoice")
+		return
+	}
+	if invoice.Status != database.InvoiceIssued && invoice.Status != database.InvoicePaid {
+		http.Error(w, "Only issued or paid invoices can be claimed", http.StatusConflict)
+		return
+	}
+	policy, err := h.repo.GetPolicy(req.PolicyID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve insurance policy")
+		return
+	}
+	if policy.PatientHN != invoice.PatientHN {
+		http.Error(w, "Policy does not belong to the invoiced patient", http.StatusBadRequest)
+		return
+	}
+	invoiceDate := invoice.CreatedAt
+	if invoice.IssuedAt != nil {
+		invoiceDate = *invoice.IssuedAt
+	}
+	if !policy.CoversDate(invoiceDate.In(reqctx.Location(r.Context())).Format("2006-01-02")) {
+		http.Error(w, "Policy was not in force on the invoice date", http.StatusConflict)
+		return
+	}
+
+	amount := invoice.Total
+	if req.Amount != nil {
+		amount = *req.Amount
+	} else if policy.CoverageLimit != nil && *policy.CoverageLimit < amount {
+		amount = *policy.CoverageLimit
+	}
+	if amount <= 0 || amount > invoice.Total {
+		http.Error(w, "amount must be positive and no more than the invoice total", http.StatusBadRequest)
+		return
+	}
+	if policy.CoverageLimit != nil && amount > *policy.CoverageLimit {
+		http.Error(w, "amount is more than the policy's coverage limit", http.StatusBadRequest)
+		return
+	}
+
+	claim := database.InsuranceClaim{
+		InvoiceID:     invoice.ID,
+		PolicyID:      policy.ID,
+		PatientHN:     invoice.PatientHN,
+		Insurer:       policy.Insurer,
+		Status:        database.ClaimSubmitted,
+		ClaimedAmount: amount,
+		Notes:         req.Notes,
+		SubmittedBy:   req.SubmittedBy,
+	}
+	if err := h.repo.CreateClaim(&claim); err != nil {
+		writeError(w, err, "Failed to create insurance claim")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, claim)
+}
+
+// GetClaims lists claims (?status=, ?insurer=, ?patientHn=, ?invoiceId=),
+// oldest submission first, for the back office to work through
+func (h *InsuranceHandler) GetClaims(w http.ResponseWriter, r *http.Request) {
+	filter, ok := claimFilter(w, r)
+	if !ok {
+		return
+	}
+
+	claims, err := h.repo.ListClaims(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve insurance claims")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, claims)
+}
+
+// ClaimTotals is the count and amounts of the claims in one status
+type ClaimTotals struct {
+	Count          int     `json:"count"`
+	ClaimedAmount  float64 `json:"claimedAmount"`
+	ApprovedAmount float64 `json:"approvedAmount"`
+	PaidAmount     float64 `json:"paidAmount"`
+}
+
+// GetClaimSummary totals claims by status, with the same filters as GetClaims
+func (h *InsuranceHandler) GetClaimSummary(w http.ResponseWriter, r *http.Request) {
+	filter, ok := claimFilter(w, r)
+	if !ok {
+		return
+	}
+
+	claims, err := h.repo.ListClaims(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve insurance claims")
+		return
+	}
+
+	summary := map[string]ClaimTotals{}
+	for _, status := range []string{database.ClaimSubmitted, database.ClaimApproved, database.ClaimRejected, database.ClaimPaid} {
+		summary[status] = ClaimTotals{}
+	}
+	for _, c := range claims {
+		totals := summary[c.Status]
+		totals.Count++
+		totals.ClaimedAmount += c.ClaimedAmount
+		if c.ApprovedAmount != nil {
+			totals.ApprovedAmount += *c.ApprovedAmount
+		}
+		if c.PaidAmount != nil {
+			totals.PaidAmount += *c.PaidAmount
+		}
+		summary[c.Status] = totals
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// GetClaim returns one claim
+func (h *InsuranceHandler) GetClaim(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid claim ID", http.StatusBadRequest)
+		return
+	}
+
+	claim, err := h.repo.GetClaim(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve insurance claim")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, claim)
+}
+
+// UpdateClaimStatus records the insurer's answer: approved with the amount it
+// will pay (default the claimed amount), rejected with a reason, or paid
+// (default the approved amount). A payer reference may accompany any of them.
+func (h *InsuranceHandler) UpdateClaimStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid claim ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Status    string   `json:"status"`
+		Amount    *float64 `json:"amount"`
+		Reason    *string  `json:"reason"`
+		Reference *string  `json:"reference"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Status {
+	case database.ClaimApproved, database.ClaimPaid:
+		req.Reason = nil
+	case database.ClaimRejected:
+		if req.Reason == nil || strings.TrimSpace(*req.Reason) == "" {
+			http.Error(w, "reason is required to reject a claim", http.StatusBadRequest)
+			return
+		}
+		req.Amount = nil
+	default:
+		http.Error(w, "status must be approved, rejected or paid", http.StatusBadRequest)
+		return
+	}
+
+	claim, err := h.repo.GetClaim(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve insurance claim")
+		return
+	}
+	if !claim.CanMoveTo(req.Status) {
+		http.Error(w, "Cannot change a "+claim.Status+" claim to "+req.Status, http.StatusConflict)
+		return
+	}
+
+	switch req.Status {
+	case database.ClaimApproved:
+		if req.Amount == nil {
+			req.Amount = &claim.ClaimedAmount
+		}
+		if *req.Amount <= 0 || *req.Amount > claim.ClaimedAmount {
+			http.Error(w, "amount must be positive and no more than the claimed amount", http.StatusBadRequest)
+			return
+		}
+	case database.ClaimPaid:
+		if req.Amount == nil {
+			req.Amount = claim.ApprovedAmount
+		}
+		if *req.Amount <= 0 || *req.Amount > *claim.ApprovedAmount {
+			http.Error(w, "amount must be positive and no more than the approved amount", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Reference != nil {
+		trimmed := strings.TrimSpace(*req.Reference)
+		req.Reference = &trimmed
+	}
+
+	updated, err := h.repo.UpdateClaimStatus(claim.ID, claim.Status, database.ClaimDecision{
+		Status:    req.Status,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+		Reference: req.Reference,
+	})
+	if err != nil {
+		writeError(w, err, "Failed to update insurance claim status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// checkPolicy trims and validates a policy, returning what is wrong with it
+func checkPolicy(p *database.InsurancePolicy) string {
+	p.Insurer = strings.TrimSpace(p.Insurer)
+	p.PolicyNumber = strings.TrimSpace(p.PolicyNumber)
+	if p.Insurer == "" || p.PolicyNumber == "" {
+		return "insurer and policyNumber are required"
+	}
+	if _, err := time.Parse("2006-01-02", p.ValidFrom); err != nil {
+		return "Invalid validFrom, expected YYYY-MM-DD"
+	}
+	if p.ValidTo != nil {
+		if _, err := time.Parse("2006-01-02", *p.ValidTo); err != nil {
+			return "Invalid validTo, expected YYYY-MM-DD"
+		}
+		if *p.ValidTo < p.ValidFrom {
+			return "validTo cannot be before validFrom"
+		}
+	}
+	if p.CoverageLimit != nil && *p.CoverageLimit <= 0 {
+		return "coverageLimit must be positive"
+	}
+	return ""
+}
+
+func claimFilter(w http.ResponseWriter, r *http.Request) (database.ClaimFilter, bool) {
+	q := r.URL.Query()
+	filter := database.ClaimFilter{Status: q.Get("status"), Insurer: q.Get("insurer"), PatientHN: q.Get("patientHn")}
+	if s := q.Get("invoiceId"); s != "" {
+		invoiceID, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.InvoiceID = invoiceID
+	}
+	return filter, true
+}
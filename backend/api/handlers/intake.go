@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/prom"
+
+	"github.com/gorilla/mux"
+)
+
+// IntakeRepository interface for pre-visit intake form storage
+type IntakeRepository interface {
+	Create(in *database.Intake) error
+	GetByAppointment(appointmentID int) (*database.Intake, error)
+	GetByToken(token string) (*database.Intake, error)
+	Complete(in *database.Intake) error
+}
+
+// IntakeLookup provides an appointment's intake form, for opening its visit
+type IntakeLookup interface {
+	GetByAppointment(appointmentID int) (*database.Intake, error)
+}
+
+// IntakeHandler handles the triage forms patients fill in before their appointment
+type IntakeHandler struct {
+	repo          IntakeRepository
+	appointments  AppointmentRepository
+	visits        EncounterRepository
+	publicBaseURL string
+}
+
+// NewIntakeHandler creates a new intake handler
+func NewIntakeHandler(repo IntakeRepository, appointments AppointmentRepository, visits EncounterRepository, publicBaseURL string) *IntakeHandler {
+	return &IntakeHandler{
+		repo:          repo,
+		appointments:  appointments,
+		visits:        visits,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+	}
+}
+
+// CreateIntake returns the intake form link of a scheduled appointment, for
+// staff to share with a patient the reminders have not reached, starting
+// the form if the reminders have not yet
+func (h *IntakeHandler) CreateIntake(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid appointment ID", http.StatusBadRequest)
+		return
+	}
+	appointment, err := h.appointments.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment")
+		return
+	}
+
+	intake, err := h.repo.GetByAppointment(id)
+	switch {
+	case err == nil:
+		if intake.Status != database.IntakePending {
+			http.Error(w, "The patient has already filled in the intake form", http.StatusConflict)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"link": h.link(intake), "intake": intake})
+		return
+	case !apperr.Is(err, apperr.KindNotFound):
+		writeError(w, err, "Failed to retrieve intake form")
+		return
+	}
+
+	if appointment.Status != database.AppointmentScheduled || !time.Now().Before(appointment.EndsAt) {
+		http.Error(w, "Intake forms can only be sent for upcoming scheduled appointments", http.StatusConflict)
+		return
+	}
+	token, err := prom.NewLinkToken()
+	if err != nil {
+		writeError(w, err, "Failed to create intake link")
+		return
+	}
+	intake = database.NewIntake(appointment, token)
+	if err := h.repo.Create(intake); err != nil {
+		writeError(w, err, "Failed to create intake form")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"link": h.link(intake), "intake": intake})
+}
+
+// GetAppointmentIntake returns an appointment's intake form and, once the
+// patient has filled it in, their answers
+func (h *IntakeHandler) GetAppointmentIntake(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid appointment ID", http.StatusBadRequest)
+		return
+	}
+
+	intake, err := h.repo.GetByAppointment(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve intake form")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, intake)
+}
+
+// GetVisitIntake returns the intake form of the appointment a visit was opened for
+func (h *IntakeHandler) GetVisitIntake(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+	if visit.AppointmentID == nil {
+		http.Error(w, "Visit was not opened for an appointment", http.StatusNotFound)
+		return
+	}
+
+	intake, err := h.repo.GetByAppointment(*visit.AppointmentID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve intake form")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, intake)
+}
+
+// GetPublicIntake returns the appointment an intake link is for, so the form
+// can greet the patient with it
+func (h *IntakeHandler) GetPublicIntake(w http.ResponseWriter, r *http.Request) {
+	intake, ok := h.loadOpenIntake(w, r)
+	if !ok {
+		return
+	}
+	appointment, err := h.appointments.GetByID(intake.AppointmentID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"doctorName": appointment.DoctorName,
+		"startsAt":   appointment.StartsAt,
+		"expiresAt":  intake.ExpiresAt,
+	})
+}
+
+// SubmitPublicIntake records a patient's answers: what brings them in, their
+// symptoms and for how long, and the medications they take
+func (h *IntakeHandler) SubmitPublicIntake(w http.ResponseWriter, r *http.Request) {
+	intake, ok := h.loadOpenIntake(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ChiefComplaint string   `json:"chiefComplaint"`
+		Symptoms       []string `json:"symptoms"`
+		Duration       string   `json:"duration"`
+		Medications    string   `json:"medications"`
+		Notes          string   `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	complaint := strings.TrimSpace(req.ChiefComplaint)
+	if complaint == "" {
+		http.Error(w, "chiefComplaint is required", http.StatusBadRequest)
+		return
+	}
+
+	intake.ChiefComplaint = &complaint
+	intake.Symptoms = []string{}
+	for _, s := range req.Symptoms {
+		if s = strings.Join(strings.Fields(s), " "); s != "" {
+			intake.Symptoms = append(intake.Symptoms, s)
+		}
+	}
+	intake.Duration = optionalText(req.Duration)
+	intake.Medications = optionalText(req.Medications)
+	intake.Notes = optionalText(req.Notes)
+	if err := h.repo.Complete(intake); err != nil {
+		writeError(w, err, "Failed to save intake form")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": intake.Status})
+}
+
+// loadOpenIntake resolves a patient link token, rejecting completed and expired forms
+func (h *IntakeHandler) loadOpenIntake(w http.ResponseWriter, r *http.Request) (*database.Intake, bool) {
+	intake, err := h.repo.GetByToken(mux.Vars(r)["token"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve intake form")
+		return nil, false
+	}
+	if intake.Status != database.IntakePending {
+		http.Error(w, "This intake form has already been filled in", http.StatusConflict)
+		return nil, false
+	}
+	if time.Now().After(intake.ExpiresAt) {
+		http.Error(w, "This intake link has expired", http.StatusGone)
+		return nil, false
+	}
+	return intake, true
+}
+
+func (h *IntakeHandler) link(intake *database.Intake) string {
+	return h.publicBaseURL + "/public/intake/" + intake.Token
+}
+
+// intakeComplaint is the chief complaint a filled-in intake form gives a visit,
+// e.g. "ไข้ ไอ (3 วัน)", or "" when there is no completed form
+func intakeComplaint(intakes IntakeLookup, appointmentID int) string {
+	intake, err := intakes.GetByAppointment(appointmentID)
+	if err != nil || intake.Status != database.IntakeCompleted || intake.ChiefComplaint == nil {
+		return ""
+	}
+	complaint := *intake.ChiefComplaint
+	if intake.Duration != nil {
+		complaint += " (" + *intake.Duration + ")"
+	}
+	return complaint
+}
+
+// optionalText trims a free-text field, leaving an empty one nil
+func optionalText(s string) *string {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return &s
+}
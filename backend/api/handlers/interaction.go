@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// currentMedicationDays is how long a prescription line written without a
+// duration counts as something the patient is taking
+const currentMedicationDays = 30
+
+// InteractionRuleRepository interface for drug interaction rule storage
+type InteractionRuleRepository interface {
+	Create(ir *database.InteractionRule) error
+	GetByID(id int) (*database.InteractionRule, error)
+	GetAll(drug string) ([]database.InteractionRule, error)
+	Update(ir *database.InteractionRule) error
+	Delete(id int) error
+}
+
+// PatientAllergies provides a patient's allergies
+type PatientAllergies interface {
+	GetByPatient(hn string, activeOnly bool) ([]database.Allergy, error)
+}
+
+// PatientPrescriptions provides what a patient has been prescribed
+type PatientPrescriptions interface {
+	GetByPatient(hn string) ([]database.Prescription, error)
+}
+
+// InteractionHandler handles drug interaction rules and checking drugs
+// against them before prescribing
+type InteractionHandler struct {
+	repo          InteractionRuleRepository
+	drugs         DrugLookup
+	patients      PatientRepository
+	allergies     PatientAllergies
+	prescriptions PatientPrescriptions
+}
+
+// NewInteractionHandler creates a new interaction handler
+func NewInteractionHandler(repo InteractionRuleRepository, drugs DrugLookup, patients PatientRepository, allergies PatientAllergies, prescriptions PatientPrescriptions) *InteractionHandler {
+	return &InteractionHandler{repo: repo, drugs: drugs, patients: patients, allergies: allergies, prescriptions: prescriptions}
+}
+
+// GetInteractionRules lists the interaction rules, or those naming ?drug= (a generic name)
+func (h *InteractionHandler) GetInteractionRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.repo.GetAll(strings.TrimSpace(r.URL.Query().Get("drug")))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve interaction rules")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// CreateInteractionRule adds an interaction rule
+func (h *InteractionHandler) CreateInteractionRule(w http.ResponseWriter, r *http.Request) {
+	var rule database.InteractionRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if msg := checkInteractionRule(&rule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Create(&rule); err != nil {
+		writeError(w, err, "Failed to create interaction rule")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+// UpdateInteractionRule replaces an interaction rule's details
+func (h *InteractionHandler) UpdateInteractionRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid interaction rule ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.repo.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve interaction rule")
+		return
+	}
+
+	var rule database.InteractionRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if msg := checkInteractionRule(&rule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	rule.ID = id
+	if err := h.repo.Update(&rule); err != nil {
+		writeError(w, err, "Failed to update interaction rule")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// DeleteInteractionRule removes an interaction rule
+func (h *InteractionHandler) DeleteInteractionRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid interaction rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		writeError(w, err, "Failed to delete interaction rule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InteractionCheckRequest lists the catalog drugs about to be prescribed
+type InteractionCheckRequest struct {
+	DrugIDs []int `json:"drugIds"`
+}
+
+// CurrentMedication is a drug the patient is taking, from a recent prescription
+type CurrentMedication struct {
+	DrugID         *int      `json:"drugId,omitempty"`
+	DrugName       string    `json:"drugName"`
+	PrescriptionID int       `json:"prescriptionId"`
+	PrescribedAt   time.Time `json:"prescribedAt"`
+}
+
+// InteractionFinding is one known problem with a drug about to be prescribed
+type InteractionFinding struct {
+	Kind           string  `json:"kind"` // drug or allergy
+	Severity       string  `json:"severity"`
+	Drug           string  `json:"drug"`                     // the drug about to be prescribed
+	With           string  `json:"with"`                     // the other drug, or the allergen
+	Against        string  `json:"against"`                  // proposed, current or allergy: where with comes from
+	PrescriptionID *int    `json:"prescriptionId,omitempty"` // for current medications
+	Reaction       *string `json:"reaction,omitempty"`       // the patient's recorded reaction, for allergies
+	Effect         string  `json:"effect"`
+	Management     *string `json:"management,omitempty"`
+	RuleID         *int    `json:"ruleId,omitempty"` // unset when the drug is itself a recorded allergen
+}
+
+// InteractionCheck is what is known to go wrong with prescribing drugs to a patient
+type InteractionCheck struct {
+	PatientHN          string               `json:"patientHn"`
+	CurrentMedications []CurrentMedication  `json:"currentMedications"`
+	Findings           []InteractionFinding `json:"findings"`        // most severe first
+	Contraindicated    bool                 `json:"contraindicated"` // some finding is contraindicated
+}
+
+// medication is a drug on either side of a check, known by its generic name
+// when it is in the catalog and by its prescribed name otherwise
+type medication struct {
+	generic        string
+	name           string
+	prescriptionID *int
+}
+
+// is reports whether the medication is the generic name, or written as it
+// (e.g. "Warfarin 3 mg" for Warfarin) when it is not in the catalog
+func (m medication) is(generic string) bool {
+	if m.generic != "" {
+		return strings.EqualFold(m.generic, generic)
+	}
+	name, generic := strings.ToLower(m.name), strings.ToLower(strings.TrimSpace(generic))
+	return generic != "" && (name == generic || strings.HasPrefix(name, generic+" "))
+}
+
+// CheckInteractions checks the catalog drugs in drugIds against each other,
+// the patient's current medications (prescription lines still within their
+// duration, or from the last 30 days when written without one) and active
+// allergies. A drug that is itself a recorded allergen is contraindicated.
+func (h *InteractionHandler) CheckInteractions(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var req InteractionCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(req.DrugIDs) == 0 {
+		http.Error(w, "drugIds is required", http.StatusBadRequest)
+		return
+	}
+
+	catalog := map[int]*database.Drug{}
+	lookup := func(id int) (*database.Drug, error) {
+		if d, ok := catalog[id]; ok {
+			return d, nil
+		}
+		d, err := h.drugs.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		catalog[id] = d
+		return d, nil
+	}
+	proposed := []medication{}
+	for _, drugID := range req.DrugIDs {
+		if _, seen := catalog[drugID]; seen {
+			continue
+		}
+		d, err := lookup(drugID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve drug")
+			return
+		}
+		proposed = append(proposed, medication{generic: d.GenericName, name: d.DisplayName()})
+	}
+
+	rules, err := h.repo.GetAll("")
+	if err != nil {
+		writeError(w, err, "Failed to retrieve interaction rules")
+		return
+	}
+	allergies, err := h.allergies.GetByPatient(hn, true)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve allergies")
+		return
+	}
+	prescriptions, err := h.prescriptions.GetByPatient(hn)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescriptions")
+		return
+	}
+
+	check := InteractionCheck{PatientHN: hn, CurrentMedications: []CurrentMedication{}, Findings: []InteractionFinding{}}
+	current := []medication{}
+	now := time.Now()
+	for _, p := range prescriptions {
+		for _, item := range p.Items {
+			days := currentMedicationDays
+			if item.DurationDays != nil {
+				days = *item.DurationDays
+			}
+			if !p.CreatedAt.AddDate(0, 0, days).After(now) {
+				continue
+			}
+			prescriptionID := p.ID
+			m := medication{name: item.DrugName, prescriptionID: &prescriptionID}
+			if item.DrugID != nil {
+				if d, err := lookup(*item.DrugID); err == nil {
+					m.generic = d.GenericName
+				}
+			}
+			current = append(current, m)
+			check.CurrentMedications = append(check.CurrentMedications, CurrentMedication{
+				DrugID: item.DrugID, DrugName: item.DrugName, PrescriptionID: p.ID, PrescribedAt: p.CreatedAt,
+			})
+		}
+	}
+
+	interact := func(a, b medication, against string) {
+		for i := range rules {
+			rule := &rules[i]
+			if rule.Kind != database.InteractionDrug {
+				continue
+			}
+			if (a.is(rule.Drug) && b.is(rule.With)) || (a.is(rule.With) && b.is(rule.Drug)) {
+				check.Findings = append(check.Findings, InteractionFinding{
+					Kind: rule.Kind, Severity: rule.Severity, Drug: a.name, With: b.name, Against: against,
+					PrescriptionID: b.prescriptionID, Effect: rule.Effect, Management: rule.Management, RuleID: &rule.ID,
+				})
+			}
+		}
+	}
+	for i, a := range proposed {
+		for _, b := range proposed[i+1:] {
+			interact(a, b, "proposed")
+		}
+		for _, b := range current {
+			interact(a, b, "current")
+		}
+		for _, allergy := range allergies {
+			if a.is(allergy.Allergen) {
+				check.Findings = append(check.Findings, InteractionFinding{
+					Kind: database.InteractionAllergy, Severity: database.InteractionContraindicated,
+					Drug: a.name, With: allergy.Allergen, Against: "allergy", Reaction: allergy.Reaction,
+					Effect: fmt.Sprintf("patient has a recorded %s allergy to %s", allergy.Severity, allergy.Allergen),
+				})
+				continue
+			}
+			for j := range rules {
+				rule := &rules[j]
+				if rule.Kind == database.InteractionAllergy && a.is(rule.Drug) && strings.EqualFold(rule.With, strings.TrimSpace(allergy.Allergen)) {
+					check.Findings = append(check.Findings, InteractionFinding{
+						Kind: rule.Kind, Severity: rule.Severity, Drug: a.name, With: allergy.Allergen, Against: "allergy",
+						Reaction: allergy.Reaction, Effect: rule.Effect, Management: rule.Management, RuleID: &rule.ID,
+					})
+				}
+			}
+		}
+	}
+
+	rank := map[string]int{}
+	for i, s := range database.InteractionSeverities {
+		rank[s] = i
+	}
+	sort.SliceStable(check.Findings, func(i, j int) bool {
+		return rank[check.Findings[i].Severity] > rank[check.Findings[j].Severity]
+	})
+	for _, f := range check.Findings {
+		if f.Severity == database.InteractionContraindicated {
+			check.Contraindicated = true
+		}
+	}
+
+	writeJSON(w, http.StatusOK, check)
+}
+
+// checkInteractionRule validates a rule and trims its text; kind defaults to
+// drug. The two drugs of a drug rule are put in name order, so a pair has one
+// rule whichever way round it was entered.
+func checkInteractionRule(ir *database.InteractionRule) string {
+	ir.Kind = strings.TrimSpace(ir.Kind)
+	if ir.Kind == "" {
+		ir.Kind = database.InteractionDrug
+	}
+	ir.Drug = strings.TrimSpace(ir.Drug)
+	ir.With = strings.TrimSpace(ir.With)
+	ir.Effect = strings.TrimSpace(ir.Effect)
+	if ir.Management != nil {
+		ir.Management = optionalText(*ir.Management)
+	}
+	if ir.Source != nil {
+		ir.Source = optionalText(*ir.Source)
+	}
+
+	switch {
+	case !oneOf(ir.Kind, database.InteractionKinds):
+		return "kind must be one of " + strings.Join(database.InteractionKinds, ", ")
+	case ir.Drug == "" || ir.With == "":
+		return "drug and with are required"
+	case ir.Kind == database.InteractionDrug && strings.EqualFold(ir.Drug, ir.With):
+		return "a drug rule needs two different drugs"
+	case !oneOf(ir.Severity, database.InteractionSeverities):
+		return "severity must be one of " + strings.Join(database.InteractionSeverities, ", ")
+	case ir.Effect == "":
+		return "effect is required"
+	}
+
+	if ir.Kind == database.InteractionDrug && strings.ToLower(ir.With) < strings.ToLower(ir.Drug) {
+		ir.Drug, ir.With = ir.With, ir.Drug
+	}
+	return ""
+}
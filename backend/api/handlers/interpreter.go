@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// InterpreterRepository interface for language needs and interpreter scheduling storage
+type InterpreterRepository interface {
+	UpsertPatientLanguage(l *database.PatientLanguage) error
+	GetPatientLanguage(hn string) (*database.PatientLanguage, error)
+	CreateInterpreter(i *database.Interpreter) error
+	GetInterpreter(id int) (*database.Interpreter, error)
+	GetInterpreters(language string) ([]database.Interpreter, error)
+	CreateBooking(b *database.InterpreterBooking) error
+	GetBookings(from, to time.Time) ([]database.InterpreterBooking, error)
+	UpdateBookingStatus(id int, status string) error
+}
+
+// InterpreterHandler handles patient language and interpreter scheduling requests
+type InterpreterHandler struct {
+	repo     InterpreterRepository
+	patients PatientRepository
+}
+
+// NewInterpreterHandler creates a new interpreter handler
+func NewInterpreterHandler(repo InterpreterRepository, patients PatientRepository) *InterpreterHandler {
+	return &InterpreterHandler{repo: repo, patients: patients}
+}
+
+// InterpreterWorklistEntry flags an interpreted consultation for the doctor
+type InterpreterWorklistEntry struct {
+	database.InterpreterBooking
+	PatientName     string  `json:"patientName"`
+	InterpreterName string  `json:"interpreterName"`
+	Remote          bool    `json:"remote"`
+	LanguageNotes   *string `json:"languageNotes,omitempty"`
+}
+
+// GetPatientLanguage returns a patient's preferred language and interpreter needs
+func (h *InterpreterHandler) GetPatientLanguage(w http.ResponseWriter, r *http.Request) {
+	language, err := h.repo.GetPatientLanguage(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve language record")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, language)
+}
+
+// UpdatePatientLanguage records a patient's preferred language and interpreter needs
+func (h *InterpreterHandler) UpdatePatientLanguage(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var language database.PatientLanguage
+	if err := json.NewDecoder(r.Body).Decode(&language); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	language.PreferredLanguage = strings.ToLower(strings.TrimSpace(language.PreferredLanguage))
+	if language.PreferredLanguage == "" {
+		http.Error(w, "preferredLanguage is required", http.StatusBadRequest)
+		return
+	}
+
+	language.PatientHN = hn
+	if err := h.repo.UpsertPatientLanguage(&language); err != nil {
+		writeError(w, err, "Failed to save language record")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, language)
+}
+
+// CreateInterpreter registers an interpreter resource
+func (h *InterpreterHandler) CreateInterpreter(w http.ResponseWriter, r *http.Request) {
+	var interpreter database.Interpreter
+	if err := json.NewDecoder(r.Body).Decode(&interpreter); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if interpreter.Name == "" || len(interpreter.Languages) == 0 {
+		http.Error(w, "name and languages are required", http.StatusBadRequest)
+		return
+	}
+	for i, l := range interpreter.Languages {
+		interpreter.Languages[i] = strings.ToLower(strings.TrimSpace(l))
+	}
+
+	interpreter.Active = true
+	if err := h.repo.CreateInterpreter(&interpreter); err != nil {
+		writeError(w, err, "Failed to create interpreter")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, interpreter)
+}
+
+// GetInterpreters returns active interpreters, optionally ?language=
+func (h *InterpreterHandler) GetInterpreters(w http.ResponseWriter, r *http.Request) {
+	interpreters, err := h.repo.GetInterpreters(r.URL.Query().Get("language"))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve interpreters")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, interpreters)
+}
+
+// BookInterpreter attaches an interpreter to a patient's appointment time.
+// The language defaults to the patient's preferred language; without an
+// interpreterId the first free interpreter covering it is chosen.
+func (h *InterpreterHandler) BookInterpreter(w http.ResponseWriter, r *http.Request) {
+	var booking database.InterpreterBooking
+	if err := json.NewDecoder(r.Body).Decode(&booking); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if booking.PatientHN == "" || booking.StartsAt.IsZero() || !booking.EndsAt.After(booking.StartsAt) {
+		http.Error(w, "patientHn, startsAt and a later endsAt are required", http.StatusBadRequest)
+		return
+	}
+
+	booking.Language = strings.ToLower(strings.TrimSpace(booking.Language))
+	if booking.Language == "" {
+		language, err := h.repo.GetPatientLanguage(booking.PatientHN)
+		if err != nil {
+			http.Error(w, "language is required when the patient has no language record", http.StatusBadRequest)
+			return
+		}
+		booking.Language = language.PreferredLanguage
+	}
+	booking.Status = database.InterpreterBookingBooked
+
+	if booking.InterpreterID != 0 {
+		interpreter, err := h.repo.GetInterpreter(booking.InterpreterID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve interpreter")
+			return
+		}
+		if !interpreter.Speaks(booking.Language) {
+			http.Error(w, "Interpreter does not cover "+booking.Language, http.StatusBadRequest)
+			return
+		}
+		if err := h.repo.CreateBooking(&booking); err != nil {
+			writeError(w, err, "Failed to book interpreter")
+			return
+		}
+		writeJSON(w, http.StatusCreated, booking)
+		return
+	}
+
+	candidates, err := h.repo.GetInterpreters(booking.Language)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve interpreters")
+		return
+	}
+	for _, interpreter := range candidates {
+		booking.InterpreterID = interpreter.ID
+		if err := h.repo.CreateBooking(&booking); err == nil {
+			writeJSON(w, http.StatusCreated, booking)
+			return
+		}
+	}
+
+	http.Error(w, "No interpreter for "+booking.Language+" is available at that time", http.StatusConflict)
+}
+
+// CancelInterpreterBooking releases an interpreter booking
+func (h *InterpreterHandler) CancelInterpreterBooking(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.UpdateBookingStatus(id, database.InterpreterBookingCancelled); err != nil {
+		writeError(w, err, "Failed to cancel interpreter booking")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": database.InterpreterBookingCancelled})
+}
+
+// GetWorklist returns the day's interpreted consultations (?date=YYYY-MM-DD, default today)
+func (h *InterpreterHandler) GetWorklist(w http.ResponseWriter, r *http.Request) {
+	day := localNow(r)
+	if s := r.URL.Query().Get("date"); s != "" {
+		d, err := time.ParseInLocation("2006-01-02", s, day.Location())
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		day = d
+	}
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+
+	bookings, err := h.repo.GetBookings(from, from.AddDate(0, 0, 1))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve interpreter bookings")
+		return
+	}
+
+	worklist := []InterpreterWorklistEntry{}
+	for _, b := range bookings {
+		if b.Status == database.InterpreterBookingCancelled {
+			continue
+		}
+		entry := InterpreterWorklistEntry{InterpreterBooking: b}
+		if interpreter, err := h.repo.GetInterpreter(b.InterpreterID); err == nil {
+			entry.InterpreterName = interpreter.Name
+			entry.Remote = interpreter.Remote
+		}
+		if id, err := parseHN(b.PatientHN); err == nil {
+			if patient, err := h.patients.GetByID(id); err == nil {
+				entry.PatientName = patient.FullName
+			}
+		}
+		if language, err := h.repo.GetPatientLanguage(b.PatientHN); err == nil {
+			entry.LanguageNotes = language.Notes
+		}
+		worklist = append(worklist, entry)
+	}
+
+	writeJSON(w, http.StatusOK, worklist)
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// InventoryRepository interface for stock item, level and movement storage
+type InventoryRepository interface {
+	CreateItem(i *database.StockItem) error
+	GetItem(id int) (*database.StockItem, error)
+	GetItems(f database.StockItemFilter) ([]database.StockItem, error)
+	UpdateItem(i *database.StockItem) error
+	Record(m *database.StockMovement) error
+	Dispense(m database.StockMovement, exclude []string) ([]database.StockMovement, error)
+	GetLevels(f database.StockItemFilter) ([]database.StockLevel, error)
+	GetLevel(itemID int) (*database.StockLevel, error)
+	GetMovements(f database.MovementFilter) ([]database.StockMovement, error)
+}
+
+// LotRecallChecker reports recalled lots, which must not be dispensed
+type LotRecallChecker interface {
+	IsLotRecalled(itemID int, lotNumber string) (bool, error)
+}
+
+// LotHoldChecker reports lots held for cold-chain review, which must not be dispensed
+type LotHoldChecker interface {
+	IsLotOnHold(itemID int, lotNumber string) (bool, error)
+}
+
+// InventoryHandler handles stock item, level and movement requests
+type InventoryHandler struct {
+	repo     InventoryRepository
+	drugs    DrugLookup
+	patients PatientRepository
+	recalls  LotRecallChecker
+	holds    LotHoldChecker
+}
+
+// NewInventoryHandler creates a new inventory handler.
+// Nil recall or hold checkers let every lot be dispensed.
+func NewInventoryHandler(repo InventoryRepository, drugs DrugLookup, patients PatientRepository, recalls LotRecallChecker, holds LotHoldChecker) *InventoryHandler {
+	return &InventoryHandler{repo: repo, drugs: drugs, patients: patients, recalls: recalls, holds: holds}
+}
+
+// GetItems lists stock items (?kind=drug|supply|vaccine, ?q= part of the name)
+func (h *InventoryHandler) GetItems(w http.ResponseWriter, r *http.Request) {
+	items, err := h.repo.GetItems(itemFilter(r))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock items")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, items)
+}
+
+// GetItem returns one stock item
+func (h *InventoryHandler) GetItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.repo.GetItem(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock item")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+// CreateItem starts tracking a drug, supply or vaccine. Drug items link a
+// catalog drug and take its name and unit unless given.
+func (h *InventoryHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
+	var item database.StockItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	item.Name = strings.TrimSpace(item.Name)
+	item.Unit = strings.TrimSpace(item.Unit)
+
+	switch item.Kind {
+	case database.StockItemDrug:
+		if item.DrugID == nil {
+			http.Error(w, "drugId is required for drug items", http.StatusBadRequest)
+			return
+		}
+		drug, ok := lookupDrug(w, h.drugs, *item.DrugID)
+		if !ok {
+			return
+		}
+		if item.Name == "" {
+			item.Name = drug.DisplayName()
+		}
+		if item.Unit == "" {
+			item.Unit = drug.Unit
+		}
+	case database.StockItemSupply, database.StockItemVaccine:
+		if item.DrugID != nil {
+			http.Error(w, "Only drug items can link a drug; use kind drug", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "kind must be drug, supply or vaccine", http.StatusBadRequest)
+		return
+	}
+	if item.Name == "" || item.Unit == "" {
+		http.Error(w, "name and unit are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.CreateItem(&item); err != nil {
+		writeError(w, err, "Failed to create stock item")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// UpdateItem renames an item or changes its unit
+func (h *InventoryHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	var item database.StockItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	item.Name = strings.TrimSpace(item.Name)
+	item.Unit = strings.TrimSpace(item.Unit)
+	if item.Name == "" || item.Unit == "" {
+		http.Error(w, "name and unit are required", http.StatusBadRequest)
+		return
+	}
+
+	item.ID = id
+	if err := h.repo.UpdateItem(&item); err != nil {
+		writeError(w, err, "Failed to update stock item")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+// GetStock lists quantities on hand (?kind=, ?q=)
+func (h *InventoryHandler) GetStock(w http.ResponseWriter, r *http.Request) {
+	levels, err := h.repo.GetLevels(itemFilter(r))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock levels")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, levels)
+}
+
+// GetItemStock returns an item's quantity on hand by lot
+func (h *InventoryHandler) GetItemStock(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	level, err := h.repo.GetLevel(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock level")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, level)
+}
+
+// StockIn records received stock. Drug and vaccine deliveries need a lot
+// number and expiry date so recalls and expiry checks can find them.
+func (h *InventoryHandler) StockIn(w http.ResponseWriter, r *http.Request) {
+	item, movement, ok := h.readMovement(w, r)
+	if !ok {
+		return
+	}
+	if movement.Quantity <= 0 {
+		http.Error(w, "quantity must be positive", http.StatusBadRequest)
+		return
+	}
+	if item.Kind != database.StockItemSupply && (movement.LotNumber == "" || movement.ExpiryDate == nil) {
+		http.Error(w, "lotNumber and expiryDate are required for drug and vaccine items", http.StatusBadRequest)
+		return
+	}
+
+	movement.Type = database.MovementStockIn
+	if err := h.repo.Record(movement); err != nil {
+		writeError(w, err, "Failed to record stock-in")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, movement)
+}
+
+// Dispense issues stock, optionally to a patient. Without a lot number the
+// stock comes from unexpired lots, earliest expiry first, passing over lots
+// that are recalled or held for cold-chain review.
+func (h *InventoryHandler) Dispense(w http.ResponseWriter, r *http.Request) {
+	item, movement, ok := h.readMovement(w, r)
+	if !ok {
+		return
+	}
+	if movement.Quantity <= 0 {
+		http.Error(w, "quantity must be positive", http.StatusBadRequest)
+		return
+	}
+	if movement.PatientHN != nil {
+		id, err := parseHN(*movement.PatientHN)
+		if err != nil {
+			http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+			return
+		}
+		if _, err := h.patients.GetByID(id); err != nil {
+			writeError(w, err, "Failed to retrieve patient")
+			return
+		}
+	}
+
+	movement.Type = database.MovementDispense
+	movement.Quantity = -movement.Quantity
+	movement.ExpiryDate = nil
+
+	if movement.LotNumber != "" {
+		blocked, reason, err := lotBlocked(h.recalls, h.holds, item.ID, movement.LotNumber)
+		if err != nil {
+			writeError(w, err, "Failed to check lot status")
+			return
+		}
+		if blocked {
+			http.Error(w, fmt.Sprintf("Lot %s %s", movement.LotNumber, reason), http.StatusConflict)
+			return
+		}
+		if err := h.repo.Record(movement); err != nil {
+			writeError(w, err, "Failed to record dispense")
+			return
+		}
+		writeJSON(w, http.StatusCreated, []database.StockMovement{*movement})
+		return
+	}
+
+	level, err := h.repo.GetLevel(item.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock level")
+		return
+	}
+	exclude, err := blockedLots(h.recalls, h.holds, level)
+	if err != nil {
+		writeError(w, err, "Failed to check lot status")
+		return
+	}
+
+	movements, err := h.repo.Dispense(*movement, exclude)
+	if err != nil {
+		writeError(w, err, "Failed to record dispense")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, movements)
+}
+
+// Adjust corrects stock after a count, breakage or expiry write-off; the
+// quantity is signed and a note is required
+func (h *InventoryHandler) Adjust(w http.ResponseWriter, r *http.Request) {
+	_, movement, ok := h.readMovement(w, r)
+	if !ok {
+		return
+	}
+	if movement.Quantity == 0 {
+		http.Error(w, "quantity must not be zero", http.StatusBadRequest)
+		return
+	}
+	if movement.Note == nil || strings.TrimSpace(*movement.Note) == "" {
+		http.Error(w, "note is required for adjustments", http.StatusBadRequest)
+		return
+	}
+
+	movement.Type = database.MovementAdjustment
+	if err := h.repo.Record(movement); err != nil {
+		writeError(w, err, "Failed to record adjustment")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, movement)
+}
+
+// GetMovements returns stock movement history, newest first
+// (?itemId=, ?type=stock_in|dispense|adjustment, ?from=&to=YYYY-MM-DD)
+func (h *InventoryHandler) GetMovements(w http.ResponseWriter, r *http.Request) {
+	var filter database.MovementFilter
+	if s := r.URL.Query().Get("itemId"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid item ID", http.StatusBadRequest)
+			return
+		}
+		filter.ItemID = id
+	}
+	h.movements(w, r, filter)
+}
+
+// GetItemMovements returns an item's stock movement history, newest first
+func (h *InventoryHandler) GetItemMovements(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+	h.movements(w, r, database.MovementFilter{ItemID: id})
+}
+
+func (h *InventoryHandler) movements(w http.ResponseWriter, r *http.Request, filter database.MovementFilter) {
+	filter.Type = r.URL.Query().Get("type")
+	switch filter.Type {
+	case "", database.MovementStockIn, database.MovementDispense, database.MovementAdjustment:
+	default:
+		http.Error(w, "type must be stock_in, dispense or adjustment", http.StatusBadRequest)
+		return
+	}
+	// Without a date range the whole history is returned
+	if r.URL.Query().Get("from") != "" || r.URL.Query().Get("to") != "" {
+		from, to, err := dateRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.From, filter.To = from, to
+	}
+
+	movements, err := h.repo.GetMovements(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock movements")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, movements)
+}
+
+// readMovement loads the item in the path and decodes a movement for it
+func (h *InventoryHandler) readMovement(w http.ResponseWriter, r *http.Request) (*database.StockItem, *database.StockMovement, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	item, err := h.repo.GetItem(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock item")
+		return nil, nil, false
+	}
+
+	var movement database.StockMovement
+	if err := json.NewDecoder(r.Body).Decode(&movement); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	movement.ItemID = item.ID
+	movement.LotNumber = strings.TrimSpace(movement.LotNumber)
+	if movement.ExpiryDate != nil {
+		if _, err := time.Parse("2006-01-02", *movement.ExpiryDate); err != nil {
+			http.Error(w, "Invalid expiryDate, expected YYYY-MM-DD", http.StatusBadRequest)
+			return nil, nil, false
+		}
+	}
+	if movement.RecordedBy == "" {
+		movement.RecordedBy = reqctx.UserName(r.Context())
+	}
+	if movement.RecordedBy == "" {
+		http.Error(w, "recordedBy is required", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return item, &movement, true
+}
+
+// lotBlocked reports whether a lot is recalled or on cold-chain hold, and why
+func lotBlocked(recalls LotRecallChecker, holds LotHoldChecker, itemID int, lotNumber string) (bool, string, error) {
+	if recalls != nil {
+		recalled, err := recalls.IsLotRecalled(itemID, lotNumber)
+		if err != nil || recalled {
+			return recalled, "has been recalled", err
+		}
+	}
+	if holds != nil {
+		held, err := holds.IsLotOnHold(itemID, lotNumber)
+		if err != nil || held {
+			return held, "is on hold pending cold-chain review", err
+		}
+	}
+	return false, "", nil
+}
+
+// blockedLots lists the lots of an item's stock that must not be dispensed
+func blockedLots(recalls LotRecallChecker, holds LotHoldChecker, level *database.StockLevel) ([]string, error) {
+	exclude := []string{}
+	for _, lot := range level.Lots {
+		if lot.LotNumber == "" {
+			continue
+		}
+		blocked, _, err := lotBlocked(recalls, holds, level.ItemID, lot.LotNumber)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			exclude = append(exclude, lot.LotNumber)
+		}
+	}
+	return exclude, nil
+}
+
+func itemFilter(r *http.Request) database.StockItemFilter {
+	return database.StockItemFilter{
+		Kind:   r.URL.Query().Get("kind"),
+		Search: strings.TrimSpace(r.URL.Query().Get("q")),
+	}
+}
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+
+	"github.com/gorilla/mux"
+)
+
+// InvoiceRepository interface for invoice storage
+type InvoiceRepository interface {
+	Create(inv *database.Invoice) error
+	GetByID(id int) (*database.Invoice, error)
+	List(f database.InvoiceFilter) ([]database.Invoice, error)
+	Update(inv *database.Invoice) error
+	Delete(id int) error
+	UpdateStatus(id int, from, to string, voidReason *string) (*database.Invoice, error)
+}
+
+// VisitPrescriptions provides the drugs prescribed during a visit, for billing
+type VisitPrescriptions interface {
+	GetByVisit(visitID int) ([]database.Prescription, error)
+}
+
+// VisitServices provides the services recorded during a visit, for billing
+type VisitServices interface {
+	GetByVisit(visitID int) ([]database.VisitService, error)
+}
+
+// CosignStatus reports whether a visit's notes still await counter-signature
+type CosignStatus interface {
+	HasPendingCosign(visitID int) (bool, error)
+}
+
+// InvoiceHandler handles billing requests
+type InvoiceHandler struct {
+	repo          InvoiceRepository
+	visits        EncounterRepository
+	prescriptions VisitPrescriptions
+	drugs         DrugLookup
+	visitServices VisitServices
+	services      ServiceLookup
+	history       ServiceHistory
+	patients      PatientRepository
+	cosign        CosignStatus
+}
+
+// NewInvoiceHandler creates a new invoice handler
+func NewInvoiceHandler(repo InvoiceRepository, visits EncounterRepository, prescriptions VisitPrescriptions, drugs DrugLookup,
+	visitServices VisitServices, services ServiceLookup, history ServiceHistory, patients PatientRepository, cosign CosignStatus) *InvoiceHandler {
+	return &InvoiceHandler{repo: repo, visits: visits, prescriptions: prescriptions, drugs: drugs,
+		visitServices: visitServices, services: services, history: history, patients: patients, cosign: cosign}
+}
+
+// invoiceRequest is the editable part of an invoice
+type invoiceRequest struct {
+	VisitID  int                    `json:"visitId"`
+	Items    []database.InvoiceItem `json:"items"`
+	Discount float64                `json:"discount"`
+	Notes    *string                `json:"notes"`
+}
+
+// CreateInvoice starts a draft invoice for a visit from the given lines
+func (h *InvoiceHandler) CreateInvoice(w http.ResponseWriter, r *http.Request) {
+	var req invoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.VisitID == 0 {
+		http.Error(w, "visitId is required", http.StatusBadRequest)
+		return
+	}
+
+	h.create(w, req.VisitID, req, nil)
+}
+
+// GenerateInvoice starts a draft invoice for a visit with a line for every
+// service recorded during it, at the price it was recorded at, and every
+// prescribed drug, priced from the catalog, followed by the given lines
+func (h *InvoiceHandler) GenerateInvoice(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	var req invoiceRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	given, err := h.visitServices.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit services")
+		return
+	}
+	var lines []database.InvoiceItem
+	for _, s := range given {
+		serviceID := s.ServiceID
+		lines = append(lines, database.InvoiceItem{
+			Kind:        database.InvoiceItemService,
+			ServiceID:   &serviceID,
+			Description: s.Name,
+			Quantity:    s.Quantity,
+			UnitPrice:   s.UnitPrice,
+		})
+	}
+
+	prescriptions, err := h.prescriptions.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescriptions")
+		return
+	}
+	for _, p := range prescriptions {
+		for _, item := range p.Items {
+			line := database.InvoiceItem{
+				Kind:        database.InvoiceItemDrug,
+				DrugID:      item.DrugID,
+				Description: item.DrugName,
+				Quantity:    1,
+			}
+			if item.Quantity != nil {
+				line.Quantity = *item.Quantity
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	h.create(w, visitID, req, lines)
+}
+
+func (h *InvoiceHandler) create(w http.ResponseWriter, visitID int, req invoiceRequest, lines []database.InvoiceItem) {
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+
+	invoice := database.Invoice{
+		VisitID:   visit.ID,
+		PatientHN: visit.PatientHN,
+		Status:    database.InvoiceDraft,
+		Items:     append(lines, req.Items...),
+		Discount:  req.Discount,
+		Notes:     req.Notes,
+	}
+	if !h.priceInvoice(w, &invoice) {
+		return
+	}
+
+	if err := h.repo.Create(&invoice); err != nil {
+		writeError(w, err, "Failed to create invoice")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, invoice)
+}
+
+// GetInvoices lists invoices (?patientHn=, ?visitId=, ?status=), most recent first
+func (h *InvoiceHandler) GetInvoices(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := database.InvoiceFilter{PatientHN: q.Get("patientHn"), Status: q.Get("status")}
+	if s := q.Get("visitId"); s != "" {
+		visitID, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+			return
+		}
+		filter.VisitID = visitID
+	}
+
+	h.list(w, filter)
+}
+
+// GetPatientInvoices lists a patient's invoices, most recent first
+func (h *InvoiceHandler) GetPatientInvoices(w http.ResponseWriter, r *http.Request) {
+	h.list(w, database.InvoiceFilter{PatientHN: mux.Vars(r)["hn"], Status: r.URL.Query().Get("status")})
+}
+
+func (h *InvoiceHandler) list(w http.ResponseWriter, filter database.InvoiceFilter) {
+	invoices, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve invoices")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, invoices)
+}
+
+// GetInvoice returns one invoice
+func (h *InvoiceHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, invoice)
+}
+
+// UpdateInvoice replaces a draft invoice's lines, discount and notes
+func (h *InvoiceHandler) UpdateInvoice(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	if invoice.Status != database.InvoiceDraft {
+		http.Error(w, "Only draft invoices can be edited", http.StatusConflict)
+		return
+	}
+
+	var req invoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	invoice.Items = req.Items
+	invoice.Discount = req.Discount
+	invoice.Notes = req.Notes
+	if !h.priceInvoice(w, invoice) {
+		return
+	}
+
+	if err := h.repo.Update(invoice); err != nil {
+		writeError(w, err, "Failed to update invoice")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, invoice)
+}
+
+// DeleteInvoice discards a draft invoice
+func (h *InvoiceHandler) DeleteInvoice(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	if invoice.Status != database.InvoiceDraft {
+		http.Error(w, "Issued invoices cannot be deleted; void them instead", http.StatusConflict)
+		return
+	}
+
+	if err := h.repo.Delete(invoice.ID); err != nil {
+		writeError(w, err, "Failed to delete invoice")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateInvoiceStatus issues, marks paid or voids an invoice. A visit whose
+// notes still await counter-signature cannot be invoiced, and voiding needs a
+// reason. Invoices are settled by recording payments; only one with nothing
+// outstanding, such as a fully discounted visit, can be marked paid directly.
+func (h *InvoiceHandler) UpdateInvoiceStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status string  `json:"status"`
+		Reason *string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Status {
+	case database.InvoiceIssued, database.InvoicePaid:
+		req.Reason = nil
+	case database.InvoiceVoid:
+		if req.Reason == nil || strings.TrimSpace(*req.Reason) == "" {
+			http.Error(w, "reason is required to void an invoice", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "status must be issued, paid or void", http.StatusBadRequest)
+		return
+	}
+
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	if !invoice.CanMoveTo(req.Status) {
+		http.Error(w, "Cannot change a "+invoice.Status+" invoice to "+req.Status, http.StatusConflict)
+		return
+	}
+	if req.Status == database.InvoicePaid && invoice.Outstanding > 0 {
+		http.Error(w, "Invoice has an outstanding balance; record a payment to settle it", http.StatusConflict)
+		return
+	}
+	if req.Status == database.InvoiceVoid && invoice.AmountPaid > 0 {
+		http.Error(w, "Invoice has payments recorded against it and cannot be voided", http.StatusConflict)
+		return
+	}
+	if req.Status == database.InvoiceIssued && h.cosign != nil {
+		pending, err := h.cosign.HasPendingCosign(invoice.VisitID)
+		if err != nil {
+			writeError(w, err, "Failed to check counter-signatures")
+			return
+		}
+		if pending {
+			http.Error(w, "Visit notes are awaiting counter-signature", http.StatusConflict)
+			return
+		}
+	}
+
+	updated, err := h.repo.UpdateStatus(invoice.ID, invoice.Status, req.Status, req.Reason)
+	if err != nil {
+		writeError(w, err, "Failed to update invoice status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// priceInvoice validates the lines, names and prices catalog service and
+// drug lines from the catalogs where they give no price, and works out the
+// totals. The patient must meet the eligibility rules of every catalog service
+// billed, as of the start of the visit.
+func (h *InvoiceHandler) priceInvoice(w http.ResponseWriter, inv *database.Invoice) bool {
+	if len(inv.Items) == 0 {
+		http.Error(w, "At least one item is required", http.StatusBadRequest)
+		return false
+	}
+
+	var patient *database.Patient
+	var visitStart time.Time
+
+	for i := range inv.Items {
+		item := &inv.Items[i]
+		item.Description = strings.TrimSpace(item.Description)
+		switch item.Kind {
+		case database.InvoiceItemService:
+			item.DrugID = nil
+			if item.ServiceID != nil && patient == nil {
+				var ok bool
+				if patient, visitStart, ok = h.billedPatient(w, inv); !ok {
+					return false
+				}
+			}
+			if item.ServiceID != nil && !h.priceService(w, item, patient, visitStart) {
+				return false
+			}
+		case database.InvoiceItemDrug:
+			item.ServiceID = nil
+			if item.DrugID != nil && !h.priceDrug(w, item) {
+				return false
+			}
+		default:
+			http.Error(w, fmt.Sprintf("Item %d: kind must be service or drug", i+1), http.StatusBadRequest)
+			return false
+		}
+		if msg := checkInvoiceItem(item); msg != "" {
+			http.Error(w, fmt.Sprintf("Item %d: %s", i+1, msg), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	inv.CalculateTotals()
+	if inv.Discount < 0 || inv.Total < 0 {
+		http.Error(w, "discount must be between 0 and the subtotal", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// priceDrug names a drug line after its catalog drug and charges the catalog
+// price unless the line sets its own. Withdrawn drugs are still billable,
+// since they may have been prescribed before the withdrawal.
+func (h *InvoiceHandler) priceDrug(w http.ResponseWriter, item *database.InvoiceItem) bool {
+	drug, err := h.drugs.GetByID(*item.DrugID)
+	if err != nil {
+		if apperr.Is(err, apperr.KindNotFound) {
+			http.Error(w, fmt.Sprintf("Drug %d is not in the catalog", *item.DrugID), http.StatusBadRequest)
+			return false
+		}
+		writeError(w, err, "Failed to retrieve drug")
+		return false
+	}
+
+	item.Description = drug.DisplayName()
+	if item.UnitPrice == 0 {
+		item.UnitPrice = drug.Price
+	}
+	return true
+}
+
+// priceService names a service line after its catalog service and charges the
+// catalog price unless the line sets its own. Withdrawn services are still
+// billable, since they may have been given before the withdrawal, but services
+// the patient is not eligible for are not.
+func (h *InvoiceHandler) priceService(w http.ResponseWriter, item *database.InvoiceItem, patient *database.Patient, on time.Time) bool {
+	service, err := h.services.GetByID(*item.ServiceID)
+	if err != nil {
+		if apperr.Is(err, apperr.KindNotFound) {
+			http.Error(w, fmt.Sprintf("Service %d is not in the catalog", *item.ServiceID), http.StatusBadRequest)
+			return false
+		}
+		writeError(w, err, "Failed to retrieve service")
+		return false
+	}
+
+	if !checkEligible(w, service, patient, on, h.services, h.history) {
+		return false
+	}
+
+	item.Description = service.Name
+	if item.UnitPrice == 0 {
+		item.UnitPrice = service.Price
+	}
+	return true
+}
+
+// billedPatient loads the patient an invoice bills and when their visit
+// started, for checking service eligibility
+func (h *InvoiceHandler) billedPatient(w http.ResponseWriter, inv *database.Invoice) (*database.Patient, time.Time, bool) {
+	visit, err := h.visits.GetByID(inv.VisitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return nil, time.Time{}, false
+	}
+	patient, ok := findPatient(w, h.patients, inv.PatientHN)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return patient, visit.StartedAt, true
+}
+
+// checkInvoiceItem validates one line's description, quantity and prices
+func checkInvoiceItem(item *database.InvoiceItem) string {
+	if item.Description == "" {
+		return "description is required"
+	}
+	if item.Quantity <= 0 {
+		return "quantity must be greater than 0"
+	}
+	if item.UnitPrice < 0 {
+		return "unitPrice cannot be negative"
+	}
+	if item.Discount < 0 || item.Discount > item.Quantity*item.UnitPrice {
+		return "discount must be between 0 and the line price"
+	}
+	return ""
+}
+
+func (h *InvoiceHandler) loadInvoice(w http.ResponseWriter, r *http.Request) (*database.Invoice, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	invoice, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve invoice")
+		return nil, false
+	}
+	return invoice, true
+}
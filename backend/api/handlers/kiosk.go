@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+)
+
+// PatientCitizenLookup finds a patient by citizen ID
+type PatientCitizenLookup interface {
+	GetByCitizenID(citizenID string) (*database.Patient, error)
+}
+
+// KioskHandler serves the waiting room's self check-in kiosks, which call it
+// with an API key of the kiosk role. Patients identify themselves by citizen
+// ID, or by HN and date of birth, and a kiosk only ever sees the patient in
+// front of it, with their name shortened: a patient it cannot identify gets
+// the same answer as one who does not exist.
+type KioskHandler struct {
+	patients     PatientRepository
+	citizens     PatientCitizenLookup
+	appointments AppointmentRepository
+	queue        *QueueHandler
+
+	servicePoint string // where patients checking in at a kiosk queue
+}
+
+// NewKioskHandler creates a new kiosk handler queueing arrivals at servicePoint
+func NewKioskHandler(patients PatientRepository, citizens PatientCitizenLookup, appointments AppointmentRepository, queue *QueueHandler, servicePoint string) *KioskHandler {
+	return &KioskHandler{patients: patients, citizens: citizens, appointments: appointments, queue: queue, servicePoint: servicePoint}
+}
+
+// KioskIdentity is how a patient identifies themselves at a kiosk: a citizen
+// ID, e.g. read from their ID card, or their HN with their date of birth
+type KioskIdentity struct {
+	CitizenID   string `json:"citizenId,omitempty"`
+	HN          string `json:"hn,omitempty"`
+	DateOfBirth string `json:"dateOfBirth,omitempty"` // YYYY-MM-DD, with hn
+}
+
+// KioskAppointment is one of the patient's appointments today as a kiosk shows it
+type KioskAppointment struct {
+	ID         int       `json:"id"`
+	DoctorName string    `json:"doctorName"`
+	StartsAt   time.Time `json:"startsAt"`
+	Type       string    `json:"type"`
+	Status     string    `json:"status"` // scheduled, or checked_in once they have arrived
+}
+
+// KioskPatient is what a kiosk shows the patient it identified
+type KioskPatient struct {
+	Name         string             `json:"name"` // first name, with initials for the rest
+	Appointments []KioskAppointment `json:"appointments"`
+}
+
+// KioskTicket is a kiosk check-in: the patient's place in the queue and the
+// ticket to print
+type KioskTicket struct {
+	ServicePoint string    `json:"servicePoint"`
+	Number       int       `json:"number"`
+	Ahead        int       `json:"ahead"`
+	EstimatedAt  time.Time `json:"estimatedAt"` // roughly when they will be called
+	StatusURL    string    `json:"statusUrl"`   // the status page the ticket's QR code opens
+	Ticket       []byte    `json:"ticket"`      // ESC/POS commands, base64 encoded, for the kiosk's printer
+
+	// Accommodation is the patient's recorded accessibility needs, without
+	// staff notes, so the kiosk can tell them help is on its way
+	Accommodation *database.AccommodationAlert `json:"accommodation,omitempty"`
+}
+
+// LookupKioskPatient identifies the patient at a kiosk and lists their
+// appointments today that they can check in for, or have. 404 when no
+// patient matches.
+func (h *KioskHandler) LookupKioskPatient(w http.ResponseWriter, r *http.Request) {
+	var req KioskIdentity
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	patient, ok := h.identify(w, req)
+	if !ok {
+		return
+	}
+	appointments, err := h.today(r, patient.HN)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+
+	found := KioskPatient{Name: maskName(patient.FullName), Appointments: []KioskAppointment{}}
+	for _, a := range appointments {
+		found.Appointments = append(found.Appointments, KioskAppointment{
+			ID: a.ID, DoctorName: a.DoctorName, StartsAt: a.StartsAt, Type: a.Type, Status: a.Status,
+		})
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, found)
+}
+
+// CheckInAtKiosk confirms the identified patient's arrival for one of their
+// appointments today (appointmentId, along with the identity), marking it
+// checked in and queueing them at the kiosk service point. It returns their
+// queue number and the ticket to print.
+func (h *KioskHandler) CheckInAtKiosk(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		KioskIdentity
+		AppointmentID int `json:"appointmentId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.AppointmentID == 0 {
+		http.Error(w, "appointmentId is required", http.StatusBadRequest)
+		return
+	}
+	patient, ok := h.identify(w, req.KioskIdentity)
+	if !ok {
+		return
+	}
+	appointments, err := h.today(r, patient.HN)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+
+	var appointment *database.Appointment
+	for i := range appointments {
+		if appointments[i].ID == req.AppointmentID {
+			appointment = &appointments[i]
+		}
+	}
+	if appointment == nil {
+		http.Error(w, "No such appointment today; please see reception", http.StatusNotFound)
+		return
+	}
+	if appointment.Status != database.AppointmentScheduled {
+		http.Error(w, "You have already checked in for this appointment", http.StatusConflict)
+		return
+	}
+
+	entry, ok := h.queue.checkIn(w, r, patient, h.servicePoint, nil, appointment, nil)
+	if !ok {
+		return
+	}
+	ticket, err := h.queue.printTicket(r, entry)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve queue")
+		return
+	}
+
+	var accommodation *database.AccommodationAlert
+	if entry.Accommodation != nil {
+		alert := *entry.Accommodation
+		alert.Notes = nil
+		accommodation = &alert
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, KioskTicket{
+		ServicePoint:  entry.ServicePoint,
+		Number:        entry.Number,
+		Ahead:         ticket.Ahead,
+		EstimatedAt:   ticket.EstimatedAt,
+		StatusURL:     h.queue.statusURL(*entry.StatusToken),
+		Ticket:        ticket.Bytes,
+		Accommodation: accommodation,
+	})
+}
+
+// identify finds the patient a kiosk identity names, writing 404 when it
+// names nobody, including an HN whose date of birth does not match
+func (h *KioskHandler) identify(w http.ResponseWriter, id KioskIdentity) (*database.Patient, bool) {
+	var patient *database.Patient
+	var err error
+	switch citizenID := strings.ReplaceAll(strings.TrimSpace(id.CitizenID), "-", ""); {
+	case citizenID != "":
+		if !validCitizenID(citizenID) {
+			http.Error(w, "Invalid citizen ID", http.StatusBadRequest)
+			return nil, false
+		}
+		patient, err = h.citizens.GetByCitizenID(citizenID)
+	case id.HN != "" && id.DateOfBirth != "":
+		n, perr := parseHN(strings.ToUpper(strings.TrimSpace(id.HN)))
+		if perr != nil {
+			http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+			return nil, false
+		}
+		patient, err = h.patients.GetByID(n)
+		if err == nil && (patient.DateOfBirth == nil || *patient.DateOfBirth != strings.TrimSpace(id.DateOfBirth)) {
+			patient, err = nil, apperr.NotFound("patient not found")
+		}
+	default:
+		http.Error(w, "citizenId, or hn and dateOfBirth, are required", http.StatusBadRequest)
+		return nil, false
+	}
+	if err != nil {
+		if apperr.Is(err, apperr.KindNotFound) {
+			http.Error(w, "We could not find you; please see reception", http.StatusNotFound)
+			return nil, false
+		}
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	return patient, true
+}
+
+// today lists the patient's appointments today, in the kiosk's branch
+// timezone, that are scheduled or checked in
+func (h *KioskHandler) today(r *http.Request, hn string) ([]database.Appointment, error) {
+	day := midnight(localNow(r))
+	all, err := h.appointments.List(database.AppointmentFilter{From: day, To: day.AddDate(0, 0, 1), PatientHN: hn})
+	if err != nil {
+		return nil, err
+	}
+	appointments := []database.Appointment{}
+	for _, a := range all {
+		if a.Active() && !a.Sandbox {
+			appointments = append(appointments, a)
+		}
+	}
+	return appointments, nil
+}
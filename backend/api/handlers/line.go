@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// lineUserIDPattern matches the user IDs LINE gives a channel, e.g. "U4af4980629..."
+var lineUserIDPattern = regexp.MustCompile(`^U[0-9a-f]{32}$`)
+
+// LINEOutbox queues LINE messages for patients who have linked a LINE account
+type LINEOutbox interface {
+	GetAccount(hn string) (*database.PatientLINE, error)
+	Enqueue(m *database.LINEMessage) error
+}
+
+// LINERepository interface for patients' LINE accounts and the LINE message queue
+type LINERepository interface {
+	LINEOutbox
+	Link(a *database.PatientLINE) error
+	Unlink(hn string) error
+	ListMessages(f database.LINEMessageFilter) ([]database.LINEMessage, error)
+	Requeue(id int) (*database.LINEMessage, error)
+}
+
+// LINEHandler links patients' LINE accounts and shows the LINE message queue
+type LINEHandler struct {
+	repo     LINERepository
+	patients PatientRepository
+}
+
+// NewLINEHandler creates a new LINE handler
+func NewLINEHandler(repo LINERepository, patients PatientRepository) *LINEHandler {
+	return &LINEHandler{repo: repo, patients: patients}
+}
+
+// LinkLINE links a patient to their LINE account: userId is the ID LINE gave
+// the clinic's channel for them, e.g. from the webhook when they added the
+// clinic as a friend. Booking confirmations and queue calls are then pushed
+// to it, and so are LINE reminders.
+func (h *LINEHandler) LinkLINE(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID      string  `json:"userId"`
+		DisplayName *string `json:"displayName,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.UserID = strings.TrimSpace(req.UserID)
+	if !lineUserIDPattern.MatchString(req.UserID) {
+		http.Error(w, "userId must be a LINE user ID: U followed by 32 hex digits", http.StatusBadRequest)
+		return
+	}
+
+	hn, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+	account := database.PatientLINE{PatientHN: hn, UserID: req.UserID, LinkedBy: reqctx.UserName(r.Context())}
+	if req.DisplayName != nil {
+		account.DisplayName = optionalText(*req.DisplayName)
+	}
+
+	if err := h.repo.Link(&account); err != nil {
+		writeError(w, err, "Failed to link LINE account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// GetPatientLINE returns the LINE account a patient has linked
+func (h *LINEHandler) GetPatientLINE(w http.ResponseWriter, r *http.Request) {
+	hn, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.repo.GetAccount(hn)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve LINE account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// UnlinkLINE stops pushing LINE messages to a patient
+func (h *LINEHandler) UnlinkLINE(w http.ResponseWriter, r *http.Request) {
+	hn, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Unlink(hn); err != nil {
+		writeError(w, err, "Failed to unlink LINE account")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLINEMessages lists queued LINE messages, oldest first, filtered by
+// ?patientHn= and ?status=pending|sent|failed
+func (h *LINEHandler) GetLINEMessages(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := database.LINEMessageFilter{PatientHN: q.Get("patientHn"), Status: q.Get("status")}
+	if filter.Status != "" && !oneOf(filter.Status, []string{database.NotificationPending, database.NotificationSent, database.NotificationFailed}) {
+		http.Error(w, "status must be pending, sent or failed", http.StatusBadRequest)
+		return
+	}
+
+	messages, err := h.repo.ListMessages(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve LINE messages")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, messages)
+}
+
+// RequeueLINEMessage puts a message that ran out of retries back in the queue
+func (h *LINEHandler) RequeueLINEMessage(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid LINE message ID", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.repo.Requeue(id)
+	if err != nil {
+		writeError(w, err, "Failed to requeue LINE message")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, message)
+}
+
+func (h *LINEHandler) loadPatient(w http.ResponseWriter, r *http.Request) (string, bool) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return "", false
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return "", false
+	}
+	return hn, true
+}
+
+// queueLINE queues a message to the patient's LINE account if they have
+// linked one. It is best effort: the action it tells the patient about has
+// already happened, so failures are only logged.
+func queueLINE(outbox LINEOutbox, m *database.LINEMessage) {
+	account, err := outbox.GetAccount(m.PatientHN)
+	if err != nil {
+		if !apperr.Is(err, apperr.KindNotFound) {
+			log.Printf("Failed to look up the LINE account of %s: %v", m.PatientHN, err)
+		}
+		return
+	}
+	m.UserID = account.UserID
+	if err := outbox.Enqueue(m); err != nil {
+		log.Printf("Failed to queue %s LINE message to %s: %v", m.Kind, m.PatientHN, err)
+	}
+}
+
+// bookingConfirmation is the LINE message confirming a new appointment
+func bookingConfirmation(a *database.Appointment, loc *time.Location) *database.LINEMessage {
+	starts := a.StartsAt.In(loc)
+	id := a.ID
+	return &database.LINEMessage{
+		PatientHN:     a.PatientHN,
+		Kind:          database.LINEAppointmentConfirmation,
+		AppointmentID: &id,
+		Text: fmt.Sprintf("ยืนยันการนัดหมาย: ท่านมีนัดพบ %s วันที่ %s เวลา %s น. หากไม่สะดวกกรุณาแจ้งคลินิกล่วงหน้า",
+			a.DoctorName, starts.Format("02/01/2006"), starts.Format("15:04")),
+	}
+}
+
+// queueReady is the LINE message calling a patient from the queue
+func queueReady(e *database.QueueEntry) *database.LINEMessage {
+	id := e.ID
+	where := e.ServicePoint
+	if e.Counter != nil && *e.Counter != "" {
+		where = *e.Counter
+	}
+	return &database.LINEMessage{
+		PatientHN:    e.PatientHN,
+		Kind:         database.LINEQueueReady,
+		QueueEntryID: &id,
+		Text:         fmt.Sprintf("ถึงคิวของท่านแล้ว: หมายเลข %d กรุณาไปที่ %s", e.Number, where),
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/reqctx"
+)
+
+// Default maintenance messages, by language
+const (
+	maintenanceMessageTH = "ระบบอยู่ระหว่างปรับปรุง ขณะนี้ดูข้อมูลได้อย่างเดียว กรุณาบันทึกข้อมูลอีกครั้งภายหลัง"
+	maintenanceMessageEN = "The system is under maintenance. Data can be viewed but not changed; please try saving again later."
+)
+
+// MaintenanceState describes whether the API is read-only for maintenance
+type MaintenanceState struct {
+	Enabled   bool       `json:"enabled"`
+	Reason    string     `json:"reason,omitempty"` // e.g. "database migration"
+	MessageTH string     `json:"messageTh,omitempty"`
+	MessageEN string     `json:"messageEn,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`
+	EnabledBy string     `json:"enabledBy,omitempty"`
+}
+
+// MaintenanceHandler toggles maintenance mode and enforces it on incoming requests
+type MaintenanceHandler struct {
+	state MaintenanceState
+	mutex sync.RWMutex
+}
+
+// NewMaintenanceHandler creates a maintenance handler with maintenance off
+func NewMaintenanceHandler() *MaintenanceHandler {
+	return &MaintenanceHandler{}
+}
+
+// GetMaintenance returns the current maintenance state
+func (h *MaintenanceHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	h.mutex.RLock()
+	state := h.state
+	h.mutex.RUnlock()
+
+	writeJSON(w, http.StatusOK, state)
+}
+
+// SetMaintenance switches maintenance mode on or off
+func (h *MaintenanceHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceState
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	h.mutex.Lock()
+	if req.Enabled {
+		now := time.Now()
+		if h.state.Enabled && h.state.Since != nil {
+			now = *h.state.Since
+		}
+		req.Since = &now
+		req.EnabledBy = reqctx.UserName(r.Context())
+		h.state = req
+	} else {
+		h.state = MaintenanceState{}
+	}
+	state := h.state
+	h.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, state)
+}
+
+// Middleware rejects writes with 503 while maintenance mode is on. Reads,
+// CORS preflight, health checks and the maintenance toggle itself stay available.
+func (h *MaintenanceHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mutex.RLock()
+		state := h.state
+		h.mutex.RUnlock()
+
+		if !state.Enabled || !isWrite(r.Method) || maintenanceExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", "300")
+		http.Error(w, maintenanceMessage(state, r.Header.Get("Accept-Language")), http.StatusServiceUnavailable)
+	})
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func maintenanceExempt(path string) bool {
+	return path == "/health" || path == "/api/admin/maintenance"
+}
+
+// maintenanceMessage picks the Thai or English message; Thai unless the client prefers English
+func maintenanceMessage(state MaintenanceState, acceptLanguage string) string {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(acceptLanguage)), "en") {
+		if state.MessageEN != "" {
+			return state.MessageEN
+		}
+		return maintenanceMessageEN
+	}
+	if state.MessageTH != "" {
+		return state.MessageTH
+	}
+	return maintenanceMessageTH
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"clinic/backend/internal/database"
+)
+
+// MockFaultRegistry injects failures into the mock repositories
+type MockFaultRegistry interface {
+	Set(fault database.MockFault) error
+	Clear(operation string)
+	List() []database.MockFault
+}
+
+// MockFaultHandler lets developers make mock repository operations fail on demand
+type MockFaultHandler struct {
+	faults MockFaultRegistry
+}
+
+// NewMockFaultHandler creates a mock fault handler; faults is nil unless the
+// server runs with full-fidelity mocks
+func NewMockFaultHandler(faults MockFaultRegistry) *MockFaultHandler {
+	return &MockFaultHandler{faults: faults}
+}
+
+func (h *MockFaultHandler) enabled(w http.ResponseWriter) bool {
+	if h.faults == nil {
+		http.Error(w, "Fault injection needs MOCK_FIDELITY=full", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// GetFaults lists the active faults
+func (h *MockFaultHandler) GetFaults(w http.ResponseWriter, r *http.Request) {
+	if !h.enabled(w) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.faults.List())
+}
+
+// SetFault adds or replaces the fault for an operation such as "Appointment.Create"
+func (h *MockFaultHandler) SetFault(w http.ResponseWriter, r *http.Request) {
+	if !h.enabled(w) {
+		return
+	}
+
+	var fault database.MockFault
+	if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := h.faults.Set(fault); err != nil {
+		writeError(w, err, "Failed to set fault")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.faults.List())
+}
+
+// ClearFaults removes one operation's fault (?operation=) or every fault
+func (h *MockFaultHandler) ClearFaults(w http.ResponseWriter, r *http.Request) {
+	if !h.enabled(w) {
+		return
+	}
+
+	h.faults.Clear(r.URL.Query().Get("operation"))
+	w.WriteHeader(http.StatusNoContent)
+}
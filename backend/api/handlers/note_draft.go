@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+)
+
+// NoteDraftRepository interface for SOAP note draft storage
+type NoteDraftRepository interface {
+	SaveDraft(d *database.NoteDraft) error
+	GetDraft(id int) (*database.NoteDraft, error)
+	GetVisitDraft(visitID int, author string) (*database.NoteDraft, error)
+	GetDraftsByAuthor(author string) ([]database.NoteDraft, error)
+	DeleteDraft(id int) error
+}
+
+// NoteDraftHandler handles SOAP note autosave and finalization requests
+type NoteDraftHandler struct {
+	drafts NoteDraftRepository
+	notes  ClinicalNoteRepository
+}
+
+// NewNoteDraftHandler creates a new note draft handler
+func NewNoteDraftHandler(drafts NoteDraftRepository, notes ClinicalNoteRepository) *NoteDraftHandler {
+	return &NoteDraftHandler{drafts: drafts, notes: notes}
+}
+
+// SaveDraft autosaves the author's draft for a visit. The body carries the
+// revision the editor last loaded (0 for a new draft); if the draft was saved
+// elsewhere since, 409 is returned with the stored draft so nothing is lost.
+func (h *NoteDraftHandler) SaveDraft(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	var draft database.NoteDraft
+	if err := json.NewDecoder(r.Body).Decode(&draft); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !signedInAuthor(w, r, &draft.AuthorName, &draft.AuthorRole) {
+		return
+	}
+	if draft.PatientHN == "" || draft.AuthorName == "" {
+		http.Error(w, "patientHn and authorName are required", http.StatusBadRequest)
+		return
+	}
+	if msg := checkAuthorRole(draft.AuthorRole, draft.SupervisorName); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	draft.VisitID = visitID
+	if err := h.drafts.SaveDraft(&draft); err != nil {
+		if !apperr.Is(err, apperr.KindConflict) {
+			writeError(w, err, "Failed to save draft")
+			return
+		}
+		current, err := h.drafts.GetVisitDraft(visitID, draft.AuthorName)
+		if err != nil {
+			writeError(w, err, "Failed to save draft")
+			return
+		}
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":   "Draft was saved from another window; reload it before saving",
+			"current": current,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, draft)
+}
+
+// GetVisitDraft returns an author's draft for a visit (?author=)
+func (h *NoteDraftHandler) GetVisitDraft(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	author := r.URL.Query().Get("author")
+	if author == "" {
+		http.Error(w, "author is required", http.StatusBadRequest)
+		return
+	}
+
+	draft, err := h.drafts.GetVisitDraft(visitID, author)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve draft")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, draft)
+}
+
+// GetDrafts lists an author's unfinished drafts (?author=) so work can be
+// recovered after the browser closed before the note was finalized
+func (h *NoteDraftHandler) GetDrafts(w http.ResponseWriter, r *http.Request) {
+	author := r.URL.Query().Get("author")
+	if author == "" {
+		http.Error(w, "author is required", http.StatusBadRequest)
+		return
+	}
+
+	drafts, err := h.drafts.GetDraftsByAuthor(author)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve drafts")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, drafts)
+}
+
+// GetDraft returns a single draft
+func (h *NoteDraftHandler) GetDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := h.loadDraft(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, draft)
+}
+
+// DiscardDraft deletes a draft without creating a note
+func (h *NoteDraftHandler) DiscardDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := h.loadDraft(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.drafts.DeleteDraft(draft.ID); err != nil {
+		writeError(w, err, "Failed to discard draft")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FinalizeDraft turns a draft into an immutable clinical note and removes the draft.
+// Co-signing rules are the same as for notes created directly.
+func (h *NoteDraftHandler) FinalizeDraft(w http.ResponseWriter, r *http.Request) {
+	draft, ok := h.loadDraft(w, r)
+	if !ok {
+		return
+	}
+
+	content := draft.Content()
+	if content == "" {
+		http.Error(w, "Draft is empty", http.StatusBadRequest)
+		return
+	}
+
+	note := database.ClinicalNote{
+		VisitID:        draft.VisitID,
+		PatientHN:      draft.PatientHN,
+		AuthorName:     draft.AuthorName,
+		AuthorRole:     draft.AuthorRole,
+		Content:        content,
+		Status:         noteStatusFor(draft.AuthorRole),
+		SupervisorName: draft.SupervisorName,
+	}
+	if err := h.notes.Create(&note); err != nil {
+		writeError(w, err, "Failed to create clinical note")
+		return
+	}
+	if err := h.drafts.DeleteDraft(draft.ID); err != nil {
+		writeError(w, err, "Note was saved but the draft could not be removed")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, note)
+}
+
+func (h *NoteDraftHandler) loadDraft(w http.ResponseWriter, r *http.Request) (*database.NoteDraft, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid draft ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	draft, err := h.drafts.GetDraft(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve draft")
+		return nil, false
+	}
+
+	return draft, true
+}
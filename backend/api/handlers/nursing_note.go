@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// NursingNoteRepository interface for nursing note storage; notes are
+// append-only, so there is no update or delete
+type NursingNoteRepository interface {
+	Create(n *database.NursingNote) error
+	GetByID(id int) (*database.NursingNote, error)
+	GetByVisit(visitID int) ([]database.NursingNote, error)
+}
+
+// NursingNoteHandler handles the nursing notes of visits
+type NursingNoteHandler struct {
+	repo   NursingNoteRepository
+	visits EncounterRepository
+}
+
+// NewNursingNoteHandler creates a new nursing note handler
+func NewNursingNoteHandler(repo NursingNoteRepository, visits EncounterRepository) *NursingNoteHandler {
+	return &NursingNoteHandler{repo: repo, visits: visits}
+}
+
+// CreateVisitNote appends a nursing note to a visit. observedAt, when the care
+// happened, defaults to now and may be earlier for a late entry, but not
+// before the visit started. A note that fixes an earlier one names it in
+// correctsId and defaults to its observedAt. authorName defaults to the
+// signed-in user.
+func (h *NursingNoteHandler) CreateVisitNote(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+
+	var note database.NursingNote
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	note.VisitID = visit.ID
+	note.PatientHN = visit.PatientHN
+	note.Kind = strings.ToLower(strings.TrimSpace(note.Kind))
+	note.Content = strings.TrimSpace(note.Content)
+	note.AuthorName = strings.TrimSpace(note.AuthorName)
+	if note.AuthorName == "" {
+		note.AuthorName = reqctx.UserName(r.Context())
+	}
+	if note.CorrectsID != nil {
+		corrected, err := h.repo.GetByID(*note.CorrectsID)
+		if err != nil && !apperr.Is(err, apperr.KindNotFound) {
+			writeError(w, err, "Failed to retrieve nursing note")
+			return
+		}
+		if err != nil || corrected.VisitID != visit.ID {
+			http.Error(w, fmt.Sprintf("Nursing note %d is not a note of this visit", *note.CorrectsID), http.StatusBadRequest)
+			return
+		}
+		if note.ObservedAt.IsZero() {
+			note.ObservedAt = corrected.ObservedAt
+		}
+	}
+	if note.ObservedAt.IsZero() {
+		note.ObservedAt = time.Now()
+	}
+	if msg := checkNursingNote(&note, visit); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Create(&note); err != nil {
+		writeError(w, err, "Failed to create nursing note")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, note)
+}
+
+// GetVisitNotes lists a visit's nursing notes in the order the care happened,
+// corrected notes included and marked with the note that corrects them
+func (h *NursingNoteHandler) GetVisitNotes(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.visits.GetByID(visitID); err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+
+	notes, err := h.repo.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve nursing notes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notes)
+}
+
+// GetNursingNote returns one nursing note
+func (h *NursingNoteHandler) GetNursingNote(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid nursing note ID", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve nursing note")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, note)
+}
+
+// checkNursingNote validates a note against the visit it is written in
+func checkNursingNote(n *database.NursingNote, visit *database.Encounter) string {
+	known := false
+	for _, k := range database.NursingNoteKinds {
+		known = known || n.Kind == k
+	}
+	if !known {
+		return "kind must be one of " + strings.Join(database.NursingNoteKinds, ", ")
+	}
+	if n.Content == "" {
+		return "content is required"
+	}
+	if n.AuthorName == "" {
+		return "authorName is required"
+	}
+	if n.ObservedAt.After(time.Now()) {
+		return "observedAt cannot be in the future"
+	}
+	if n.ObservedAt.Before(visit.StartedAt) {
+		return "observedAt cannot be before the visit started"
+	}
+	return ""
+}
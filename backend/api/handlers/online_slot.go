@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+const (
+	// maxReleasedSlots caps the slots one release may cut a time range into
+	maxReleasedSlots = 200
+	// onlineSlotDays is how far ahead slot listings look by default
+	onlineSlotDays = 14
+)
+
+// OnlineSlotRepository interface for the time released for portal booking
+type OnlineSlotRepository interface {
+	Create(s *database.OnlineSlot) error
+	GetByID(id int) (*database.OnlineSlot, error)
+	List(f database.OnlineSlotFilter) ([]database.OnlineSlot, error)
+	Claim(id int, patientHN string) (*database.OnlineSlot, error)
+	SetAppointment(id, appointmentID int) error
+	Unclaim(id int) error
+	Delete(id int) error
+}
+
+// OnlineSlotHandler lets staff release doctors' time for patients to book
+// themselves in the patient portal, and withdraw it again
+type OnlineSlotHandler struct {
+	repo     OnlineSlotRepository
+	bookings *AppointmentHandler
+}
+
+// NewOnlineSlotHandler creates a new online slot handler. Releases are
+// checked the way bookings are, against the doctor's roster and the branch's
+// opening hours.
+func NewOnlineSlotHandler(repo OnlineSlotRepository, bookings *AppointmentHandler) *OnlineSlotHandler {
+	return &OnlineSlotHandler{repo: repo, bookings: bookings}
+}
+
+// ReleaseResult is the slots a release made, and the start of those it left
+// out because the doctor was already booked or had time released then
+type ReleaseResult struct {
+	Released []database.OnlineSlot `json:"released"`
+	Skipped  []time.Time           `json:"skipped"`
+}
+
+// ReleaseOnlineSlots cuts a doctor's time from startsAt to endsAt into slots
+// of slotMinutes (default 15) and releases them for portal booking as
+// appointments of type (default consultation). The whole range must be on
+// the doctor's roster and in the branch's opening hours; slots overlapping
+// the doctor's appointments or open released slots are skipped.
+func (h *OnlineSlotHandler) ReleaseOnlineSlots(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DoctorID    int       `json:"doctorId"`
+		StartsAt    time.Time `json:"startsAt"`
+		EndsAt      time.Time `json:"endsAt"`
+		SlotMinutes int       `json:"slotMinutes"`
+		Type        string    `json:"type"`
+		ReleasedBy  string    `json:"releasedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.DoctorID == 0 || req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		http.Error(w, "doctorId, startsAt and endsAt are required", http.StatusBadRequest)
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		http.Error(w, "endsAt must be after startsAt", http.StatusBadRequest)
+		return
+	}
+	if !req.StartsAt.After(time.Now()) {
+		http.Error(w, "startsAt must be in the future", http.StatusBadRequest)
+		return
+	}
+	if req.SlotMinutes == 0 {
+		req.SlotMinutes = int(defaultAppointmentLength / time.Minute)
+	}
+	length := time.Duration(req.SlotMinutes) * time.Minute
+	if req.SlotMinutes < 5 || req.SlotMinutes > 240 {
+		http.Error(w, "slotMinutes must be from 5 to 240", http.StatusBadRequest)
+		return
+	}
+	if req.EndsAt.Sub(req.StartsAt) < length {
+		http.Error(w, "The range is shorter than one slot", http.StatusBadRequest)
+		return
+	}
+	if req.EndsAt.Sub(req.StartsAt)/length > maxReleasedSlots {
+		http.Error(w, "A release may make at most "+strconv.Itoa(maxReleasedSlots)+" slots", http.StatusBadRequest)
+		return
+	}
+	req.Type = strings.TrimSpace(req.Type)
+	if req.Type == "" {
+		req.Type = database.DefaultAppointmentType
+	}
+	if !appointmentTypePattern.MatchString(req.Type) {
+		http.Error(w, "type must be lowercase letters, digits and underscores, e.g. follow_up", http.StatusBadRequest)
+		return
+	}
+	if req.ReleasedBy == "" {
+		req.ReleasedBy = reqctx.UserName(r.Context())
+	}
+	if req.ReleasedBy == "" {
+		http.Error(w, "releasedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	span := database.Appointment{DoctorID: &req.DoctorID, StartsAt: req.StartsAt, EndsAt: req.EndsAt}
+	if !h.bookings.checkOpen(w, r, &span) || !h.bookings.resolveDoctor(w, r, &span) {
+		return
+	}
+	booked, err := h.bookings.repo.List(database.AppointmentFilter{DoctorID: req.DoctorID, From: req.StartsAt.Add(-24 * time.Hour), To: req.EndsAt})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+	released, err := h.repo.List(database.OnlineSlotFilter{DoctorID: req.DoctorID, From: req.StartsAt.Add(-24 * time.Hour), To: req.EndsAt, OpenOnly: true})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve online slots")
+		return
+	}
+
+	result := ReleaseResult{Released: []database.OnlineSlot{}, Skipped: []time.Time{}}
+	for start := req.StartsAt; !start.Add(length).After(req.EndsAt); start = start.Add(length) {
+		end := start.Add(length)
+		taken := doctorBusy(booked, req.DoctorID, start, end)
+		for _, s := range released {
+			taken = taken || (s.StartsAt.Before(end) && start.Before(s.EndsAt))
+		}
+		if taken {
+			result.Skipped = append(result.Skipped, start)
+			continue
+		}
+		slot := database.OnlineSlot{
+			DoctorID:   req.DoctorID,
+			DoctorName: span.DoctorName,
+			StartsAt:   start,
+			EndsAt:     end,
+			Type:       req.Type,
+			ReleasedBy: req.ReleasedBy,
+		}
+		if err := h.repo.Create(&slot); err != nil {
+			writeError(w, err, "Failed to release online slot")
+			return
+		}
+		result.Released = append(result.Released, slot)
+	}
+
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// GetOnlineSlots lists released slots starting ?from=&to= (default the next
+// two weeks), optionally for one ?doctorId=, booked or not
+func (h *OnlineSlotHandler) GetOnlineSlots(w http.ResponseWriter, r *http.Request) {
+	from, to, err := slotRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f := database.OnlineSlotFilter{From: from, To: to}
+	if s := r.URL.Query().Get("doctorId"); s != "" {
+		if f.DoctorID, err = strconv.Atoi(s); err != nil {
+			http.Error(w, "Invalid doctorId", http.StatusBadRequest)
+			return
+		}
+	}
+
+	slots, err := h.repo.List(f)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve online slots")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, slots)
+}
+
+// WithdrawOnlineSlot takes an open slot off the portal; 409 once a patient has booked it
+func (h *OnlineSlotHandler) WithdrawOnlineSlot(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid online slot ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		writeError(w, err, "Failed to withdraw online slot")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// slotRange reads ?from=&to= like dateRange, defaulting to the next
+// onlineSlotDays days from today
+func slotRange(r *http.Request) (time.Time, time.Time, error) {
+	q := r.URL.Query()
+	if q.Get("from") == "" && q.Get("to") == "" {
+		from := midnight(localNow(r))
+		return from, from.AddDate(0, 0, onlineSlotDays), nil
+	}
+	return dateRange(r)
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// OpenAPIHandler serves the API description client SDKs are generated from
+type OpenAPIHandler struct {
+	spec []byte
+}
+
+// NewOpenAPIHandler creates a handler serving spec, the generated api/openapi.json
+func NewOpenAPIHandler(spec []byte) *OpenAPIHandler {
+	return &OpenAPIHandler{spec: spec}
+}
+
+// GetOpenAPI returns the OpenAPI 3 description of the API
+func (h *OpenAPIHandler) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Write(h.spec)
+}
@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// TreatmentPackageRepository interface for treatment package catalog storage
+type TreatmentPackageRepository interface {
+	Create(p *database.TreatmentPackage) error
+	GetByID(id int) (*database.TreatmentPackage, error)
+	GetAll(activeOnly bool) ([]database.TreatmentPackage, error)
+	Update(p *database.TreatmentPackage) error
+	Deactivate(id int) error
+}
+
+// PatientPackageRepository interface for the packages patients bought and the sessions used from them
+type PatientPackageRepository interface {
+	Create(p *database.PatientPackage) error
+	GetByID(id int) (*database.PatientPackage, error)
+	GetByPatient(hn, status string) ([]database.PatientPackage, error)
+	Cancel(id int) (*database.PatientPackage, error)
+	UseSession(s *database.PackageSession) (*database.PatientPackage, error)
+	UndoSession(visitID, id int) (*database.PatientPackage, error)
+	GetSessions(patientPackageID int) ([]database.PackageSession, error)
+	GetVisitSessions(visitID int) ([]database.PackageSession, error)
+}
+
+// PackageHandler handles treatment packages, their purchase and the sessions
+// deducted from them on each visit
+type PackageHandler struct {
+	repo            TreatmentPackageRepository
+	patientPackages PatientPackageRepository
+	services        ServiceLookup
+	patients        PatientRepository
+	visits          EncounterRepository
+}
+
+// NewPackageHandler creates a new package handler
+func NewPackageHandler(repo TreatmentPackageRepository, patientPackages PatientPackageRepository, services ServiceLookup,
+	patients PatientRepository, visits EncounterRepository) *PackageHandler {
+	return &PackageHandler{repo: repo, patientPackages: patientPackages, services: services, patients: patients, visits: visits}
+}
+
+// GetPackages lists catalog packages (?active=true for those on sale)
+func (h *PackageHandler) GetPackages(w http.ResponseWriter, r *http.Request) {
+	packages, err := h.repo.GetAll(r.URL.Query().Get("active") == "true")
+	if err != nil {
+		writeError(w, err, "Failed to retrieve packages")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, packages)
+}
+
+// GetPackage returns one catalog package
+func (h *PackageHandler) GetPackage(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+
+	pkg, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve package")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pkg)
+}
+
+// CreatePackage adds a package to the catalog: a name, the number of
+// sessions, the price of the course and optionally the serviceId each session
+// covers and validDays from purchase
+func (h *PackageHandler) CreatePackage(w http.ResponseWriter, r *http.Request) {
+	var pkg database.TreatmentPackage
+	if err := json.NewDecoder(r.Body).Decode(&pkg); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !h.checkPackage(w, &pkg) {
+		return
+	}
+
+	pkg.Active = true
+	if err := h.repo.Create(&pkg); err != nil {
+		writeError(w, err, "Failed to create package")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, pkg)
+}
+
+// UpdatePackage replaces a catalog package's details; active can put a
+// withdrawn package back on sale. Packages already bought keep their terms.
+func (h *PackageHandler) UpdatePackage(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.repo.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve package")
+		return
+	}
+
+	var pkg database.TreatmentPackage
+	if err := json.NewDecoder(r.Body).Decode(&pkg); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !h.checkPackage(w, &pkg) {
+		return
+	}
+
+	pkg.ID = id
+	if err := h.repo.Update(&pkg); err != nil {
+		writeError(w, err, "Failed to update package")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pkg)
+}
+
+// DeletePackage takes a package off sale; packages patients bought are unaffected
+func (h *PackageHandler) DeletePackage(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Deactivate(id); err != nil {
+		writeError(w, err, "Failed to deactivate package")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PurchasePackage sells a catalog package to a patient, at the catalog price
+// unless price overrides it; purchasedBy defaults to the signed-in user. The
+// sessions can be used until validDays after today when the package sets it.
+func (h *PackageHandler) PurchasePackage(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PackageID   int      `json:"packageId"`
+		Price       *float64 `json:"price"`
+		PurchasedBy string   `json:"purchasedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.PackageID == 0 {
+		http.Error(w, "packageId is required", http.StatusBadRequest)
+		return
+	}
+	if req.Price != nil && *req.Price < 0 {
+		http.Error(w, "price cannot be negative", http.StatusBadRequest)
+		return
+	}
+	req.PurchasedBy = strings.TrimSpace(req.PurchasedBy)
+	if req.PurchasedBy == "" {
+		req.PurchasedBy = reqctx.UserName(r.Context())
+	}
+	if req.PurchasedBy == "" {
+		http.Error(w, "purchasedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	pkg, err := h.repo.GetByID(req.PackageID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve package")
+		return
+	}
+	if !pkg.Active {
+		http.Error(w, pkg.Name+" is no longer on sale", http.StatusConflict)
+		return
+	}
+
+	bought := database.PatientPackage{
+		PatientHN:     patient.HN,
+		PackageID:     pkg.ID,
+		ServiceID:     pkg.ServiceID,
+		Name:          pkg.Name,
+		TotalSessions: pkg.Sessions,
+		Price:         pkg.Price,
+		Status:        database.PatientPackageActive,
+		PurchasedBy:   req.PurchasedBy,
+	}
+	if req.Price != nil {
+		bought.Price = *req.Price
+	}
+	if pkg.ValidDays != nil {
+		expires := localNow(r).AddDate(0, 0, *pkg.ValidDays).Format("2006-01-02")
+		bought.ExpiresOn = &expires
+	}
+	if err := h.patientPackages.Create(&bought); err != nil {
+		writeError(w, err, "Failed to record package purchase")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, bought)
+}
+
+// GetPatientPackages lists a patient's packages with their remaining session
+// balance, newest first (?status=active|completed|cancelled)
+func (h *PackageHandler) GetPatientPackages(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	packages, err := h.patientPackages.GetByPatient(patient.HN, r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient packages")
+		return
+	}
+	day := today(r)
+	for i := range packages {
+		markExpired(&packages[i], day)
+	}
+
+	writeJSON(w, http.StatusOK, packages)
+}
+
+// GetPatientPackage returns a patient package with the sessions used from it
+func (h *PackageHandler) GetPatientPackage(w http.ResponseWriter, r *http.Request) {
+	pkg, ok := h.loadPatientPackage(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.patientPackages.GetSessions(pkg.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve package sessions")
+		return
+	}
+	pkg.Sessions = sessions
+	markExpired(pkg, today(r))
+
+	writeJSON(w, http.StatusOK, pkg)
+}
+
+// CancelPatientPackage stops an active package, e.g. when it is refunded; its
+// unused sessions can no longer be used
+func (h *PackageHandler) CancelPatientPackage(w http.ResponseWriter, r *http.Request) {
+	pkg, ok := h.loadPatientPackage(w, r)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.patientPackages.Cancel(pkg.ID)
+	if err != nil {
+		writeError(w, err, "Failed to cancel patient package")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cancelled)
+}
+
+// UsePackageSession deducts a session for an open visit from one of the
+// patient's packages. Without patientPackageId, the active package that
+// expires soonest is used, then the oldest. recordedBy defaults to the
+// signed-in user. It answers with the package and its new balance.
+func (h *PackageHandler) UsePackageSession(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.openVisit(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PatientPackageID int    `json:"patientPackageId"`
+		RecordedBy       string `json:"recordedBy"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	req.RecordedBy = strings.TrimSpace(req.RecordedBy)
+	if req.RecordedBy == "" {
+		req.RecordedBy = reqctx.UserName(r.Context())
+	}
+	if req.RecordedBy == "" {
+		http.Error(w, "recordedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	day := today(r)
+	var pkg *database.PatientPackage
+	if req.PatientPackageID != 0 {
+		p, err := h.patientPackages.GetByID(req.PatientPackageID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve patient package")
+			return
+		}
+		if p.PatientHN != visit.PatientHN {
+			http.Error(w, "Package belongs to another patient", http.StatusBadRequest)
+			return
+		}
+		pkg = p
+	} else {
+		active, err := h.patientPackages.GetByPatient(visit.PatientHN, database.PatientPackageActive)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve patient packages")
+			return
+		}
+		pkg = nextPackage(active, day)
+		if pkg == nil {
+			http.Error(w, "Patient has no active package with sessions left", http.StatusConflict)
+			return
+		}
+	}
+	if pkg.Status != database.PatientPackageActive {
+		http.Error(w, "Package is "+pkg.Status, http.StatusConflict)
+		return
+	}
+	if markExpired(pkg, day); pkg.Expired {
+		http.Error(w, "Package expired on "+*pkg.ExpiresOn, http.StatusConflict)
+		return
+	}
+
+	session := database.PackageSession{
+		PatientPackageID: pkg.ID,
+		PatientHN:        visit.PatientHN,
+		VisitID:          visit.ID,
+		RecordedBy:       req.RecordedBy,
+	}
+	updated, err := h.patientPackages.UseSession(&session)
+	if err != nil {
+		writeError(w, err, "Failed to use package session")
+		return
+	}
+	updated.Sessions = []database.PackageSession{session}
+
+	writeJSON(w, http.StatusCreated, updated)
+}
+
+// GetVisitPackageSessions lists the package sessions used during a visit
+func (h *PackageHandler) GetVisitPackageSessions(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.visits.GetByID(visitID); err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+
+	sessions, err := h.patientPackages.GetVisitSessions(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve package sessions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// DeleteVisitPackageSession gives back a session deducted in error while the
+// visit is still open, answering with the package's restored balance
+func (h *PackageHandler) DeleteVisitPackageSession(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.openVisit(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid package session ID", http.StatusBadRequest)
+		return
+	}
+
+	pkg, err := h.patientPackages.UndoSession(visit.ID, id)
+	if err != nil {
+		writeError(w, err, "Failed to undo package session")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pkg)
+}
+
+func (h *PackageHandler) loadPatient(w http.ResponseWriter, r *http.Request) (*database.Patient, bool) {
+	id, err := parseHN(mux.Vars(r)["hn"])
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	return patient, true
+}
+
+func (h *PackageHandler) loadPatientPackage(w http.ResponseWriter, r *http.Request) (*database.PatientPackage, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid patient package ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	pkg, err := h.patientPackages.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient package")
+		return nil, false
+	}
+	return pkg, true
+}
+
+// openVisit loads the visit a request names; sessions can only be deducted or
+// given back while it is open
+func (h *PackageHandler) openVisit(w http.ResponseWriter, r *http.Request) (*database.Encounter, bool) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return nil, false
+	}
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return nil, false
+	}
+	if visit.Status != database.EncounterOpen {
+		http.Error(w, "Package sessions can only be used during an open visit", http.StatusConflict)
+		return nil, false
+	}
+	return visit, true
+}
+
+// checkPackage validates a catalog package and the service it covers
+func (h *PackageHandler) checkPackage(w http.ResponseWriter, p *database.TreatmentPackage) bool {
+	p.Name = strings.TrimSpace(p.Name)
+	msg := ""
+	switch {
+	case p.Name == "":
+		msg = "name is required"
+	case p.Sessions < 1:
+		msg = "sessions must be at least 1"
+	case p.Price < 0:
+		msg = "price cannot be negative"
+	case p.ValidDays != nil && *p.ValidDays < 1:
+		msg = "validDays must be at least 1"
+	}
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return false
+	}
+	if p.ServiceID != nil {
+		if _, ok := lookupService(w, h.services, *p.ServiceID); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// markExpired flags a package whose last usable day is before day
+func markExpired(p *database.PatientPackage, day string) {
+	p.Expired = p.ExpiresOn != nil && *p.ExpiresOn < day
+}
+
+// nextPackage picks the active package a session is taken from: unexpired
+// and with sessions left, the one expiring soonest, then the oldest
+func nextPackage(packages []database.PatientPackage, day string) *database.PatientPackage {
+	var next *database.PatientPackage
+	for i := range packages {
+		p := &packages[i]
+		if markExpired(p, day); p.Expired || p.RemainingSessions <= 0 {
+			continue
+		}
+		if next == nil || expiresBefore(p, next) || (sameExpiry(p, next) && p.ID < next.ID) {
+			next = p
+		}
+	}
+	return next
+}
+
+func expiresBefore(a, b *database.PatientPackage) bool {
+	return a.ExpiresOn != nil && (b.ExpiresOn == nil || *a.ExpiresOn < *b.ExpiresOn)
+}
+
+func sameExpiry(a, b *database.PatientPackage) bool {
+	return (a.ExpiresOn == nil && b.ExpiresOn == nil) || (a.ExpiresOn != nil && b.ExpiresOn != nil && *a.ExpiresOn == *b.ExpiresOn)
+}
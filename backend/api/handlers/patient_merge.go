@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// PatientMergeRepository interface for patient merge storage
+type PatientMergeRepository interface {
+	Merge(m *database.PatientMerge) error
+	Unmerge(id int, undoneBy string) (*database.PatientMerge, error)
+	GetByID(id int) (*database.PatientMerge, error)
+	List(hn string) ([]database.PatientMerge, error)
+}
+
+// PatientMergeHandler handles merging duplicate patient records and undoing merges
+type PatientMergeHandler struct {
+	repo       PatientMergeRepository
+	undoWindow time.Duration
+}
+
+// NewPatientMergeHandler creates a new patient merge handler; merges can be
+// undone for undoWindow after they are made
+func NewPatientMergeHandler(repo PatientMergeRepository, undoWindow time.Duration) *PatientMergeHandler {
+	return &PatientMergeHandler{repo: repo, undoWindow: undoWindow}
+}
+
+// patientMergeView is a merge with whether it can still be undone
+type patientMergeView struct {
+	database.PatientMerge
+	Undoable bool `json:"undoable"`
+}
+
+func newPatientMergeView(m *database.PatientMerge) patientMergeView {
+	return patientMergeView{PatientMerge: *m, Undoable: m.Undoable(time.Now())}
+}
+
+// MergePatients folds the source patient into the target: the source's
+// visits, appointments, invoices and other records move to the target and the
+// source record is removed. The target's own details are not changed.
+func (h *PatientMergeHandler) MergePatients(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SourceHN string  `json:"sourceHn"`
+		TargetHN string  `json:"targetHn"`
+		Reason   *string `json:"reason"`
+		MergedBy string  `json:"mergedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.SourceHN = strings.TrimSpace(req.SourceHN)
+	req.TargetHN = strings.TrimSpace(req.TargetHN)
+	if req.SourceHN == "" || req.TargetHN == "" {
+		http.Error(w, "sourceHn and targetHn are required", http.StatusBadRequest)
+		return
+	}
+	if req.SourceHN == req.TargetHN {
+		http.Error(w, "A patient cannot be merged into itself", http.StatusBadRequest)
+		return
+	}
+	if _, err := parseHN(req.SourceHN); err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := parseHN(req.TargetHN); err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if req.MergedBy == "" {
+		req.MergedBy = reqctx.UserName(r.Context())
+	}
+
+	merge := database.PatientMerge{
+		SourceHN:  req.SourceHN,
+		TargetHN:  req.TargetHN,
+		Reason:    req.Reason,
+		MergedBy:  req.MergedBy,
+		UndoUntil: time.Now().Add(h.undoWindow),
+	}
+	if err := h.repo.Merge(&merge); err != nil {
+		writeError(w, err, "Failed to merge patients")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newPatientMergeView(&merge))
+}
+
+// GetPatientMerges lists merges, most recent first (?hn= merges involving a patient)
+func (h *PatientMergeHandler) GetPatientMerges(w http.ResponseWriter, r *http.Request) {
+	merges, err := h.repo.List(r.URL.Query().Get("hn"))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient merges")
+		return
+	}
+
+	views := make([]patientMergeView, 0, len(merges))
+	for i := range merges {
+		views = append(views, newPatientMergeView(&merges[i]))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// GetPatientMerge returns one merge with its snapshots while they are kept
+func (h *PatientMergeHandler) GetPatientMerge(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid merge ID", http.StatusBadRequest)
+		return
+	}
+
+	merge, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient merge")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newPatientMergeView(merge))
+}
+
+// UndoPatientMerge restores the source patient and moves its records back,
+// while the merge's undo window is open
+func (h *PatientMergeHandler) UndoPatientMerge(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid merge ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		UndoneBy string `json:"undoneBy"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.UndoneBy == "" {
+		req.UndoneBy = reqctx.UserName(r.Context())
+	}
+
+	merge, err := h.repo.Unmerge(id, req.UndoneBy)
+	if err != nil {
+		writeError(w, err, "Failed to undo patient merge")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newPatientMergeView(merge))
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/email"
+)
+
+// Channels OutboxMessenger reports a message went out on
+const (
+	messageChannelLINE  = "line"
+	messageChannelSMS   = "sms"
+	messageChannelEmail = "email"
+)
+
+// OutboxMessenger is the PatientMessenger over the clinic's own channels: a
+// message is queued to the patient's linked LINE account, otherwise texted to
+// their phone, otherwise queued as an email. LINE and email go out with the
+// dispatchers; SMS goes straight through the gateway, as portal codes do.
+type OutboxMessenger struct {
+	patients PatientRepository
+	line     LINEOutbox
+	sms      PortalSMS
+	emails   EmailOutbox
+}
+
+// NewOutboxMessenger creates a patient messenger. A nil sms leaves patients
+// without LINE to email.
+func NewOutboxMessenger(patients PatientRepository, line LINEOutbox, sms PortalSMS, emails EmailOutbox) *OutboxMessenger {
+	return &OutboxMessenger{patients: patients, line: line, sms: sms, emails: emails}
+}
+
+// SendToPatient sends a message to the patient on the first channel they can
+// be reached on and returns it; a patient with none is a validation error
+func (m *OutboxMessenger) SendToPatient(ctx context.Context, hn, message string) (string, error) {
+	id, err := parseHN(hn)
+	if err != nil {
+		return "", apperr.Validation("invalid patient HN %s", hn)
+	}
+	patient, err := m.patients.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+
+	account, err := m.line.GetAccount(patient.HN)
+	switch {
+	case err == nil:
+		if err := m.line.Enqueue(&database.LINEMessage{
+			PatientHN: patient.HN,
+			UserID:    account.UserID,
+			Kind:      database.LINEPatientMessage,
+			Text:      message,
+		}); err != nil {
+			return "", fmt.Errorf("failed to queue LINE message: %w", err)
+		}
+		return messageChannelLINE, nil
+	case !apperr.Is(err, apperr.KindNotFound):
+		return "", err
+	}
+
+	if m.sms != nil && patient.Phone != nil && *patient.Phone != "" {
+		if err := m.sms.Send(ctx, *patient.Phone, message); err != nil {
+			return "", fmt.Errorf("failed to send SMS: %w", err)
+		}
+		return messageChannelSMS, nil
+	}
+
+	if patient.Email != nil && *patient.Email != "" {
+		subject, body, err := email.Render(database.EmailPatientMessage, email.PatientMessage{PatientName: patient.FullName, Message: message})
+		if err != nil {
+			return "", err
+		}
+		hn := patient.HN
+		if err := m.emails.Enqueue(&database.EmailMessage{
+			Template:  database.EmailPatientMessage,
+			To:        *patient.Email,
+			Subject:   subject,
+			Body:      body,
+			PatientHN: &hn,
+		}); err != nil {
+			return "", fmt.Errorf("failed to queue email: %w", err)
+		}
+		return messageChannelEmail, nil
+	}
+
+	return "", apperr.Validation("patient %s has no LINE account, phone or email to send to", patient.HN)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// PatientRuleRepository interface for the clinic's patient field rules
+type PatientRuleRepository interface {
+	GetAll() ([]database.PatientFieldRule, error)
+	Set(rule *database.PatientFieldRule) error
+	Delete(field string) error
+}
+
+// PatientRuleHandler handles which patient fields the clinic requires
+type PatientRuleHandler struct {
+	repo PatientRuleRepository
+}
+
+// NewPatientRuleHandler creates a new patient rule handler
+func NewPatientRuleHandler(repo PatientRuleRepository) *PatientRuleHandler {
+	return &PatientRuleHandler{repo: repo}
+}
+
+// GetPatientRules lists the effective rule of every configurable field, so
+// registration forms can mark required ones; built-in defaults have no updatedAt
+func (h *PatientRuleHandler) GetPatientRules(w http.ResponseWriter, r *http.Request) {
+	configured, err := h.repo.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient field rules")
+		return
+	}
+
+	effective := database.NewPatientFieldRules(configured)
+	rules := make([]database.PatientFieldRule, 0, len(database.PatientRuleFields))
+	for _, field := range database.PatientRuleFields {
+		rules = append(rules, effective[field])
+	}
+
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// SetPatientRule makes a patient field required or optional; patients
+// already registered are only checked against it when next updated
+func (h *PatientRuleHandler) SetPatientRule(w http.ResponseWriter, r *http.Request) {
+	field, ok := ruleField(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Required *bool `json:"required"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Required == nil {
+		http.Error(w, "required must be true or false", http.StatusBadRequest)
+		return
+	}
+
+	rule := database.PatientFieldRule{Field: field, Required: *req.Required}
+	if by := reqctx.UserName(r.Context()); by != "" {
+		rule.UpdatedBy = &by
+	}
+	if err := h.repo.Set(&rule); err != nil {
+		writeError(w, err, "Failed to update patient field rule")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// ResetPatientRule returns a patient field to its default rule
+func (h *PatientRuleHandler) ResetPatientRule(w http.ResponseWriter, r *http.Request) {
+	field, ok := ruleField(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(field); err != nil {
+		writeError(w, err, "Failed to reset patient field rule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ruleField reads a configurable patient field from the path
+func ruleField(w http.ResponseWriter, r *http.Request) (string, bool) {
+	field := mux.Vars(r)["field"]
+	for _, f := range database.PatientRuleFields {
+		if f == field {
+			return field, true
+		}
+	}
+	http.Error(w, "field must be one of "+strings.Join(database.PatientRuleFields, ", "), http.StatusBadRequest)
+	return "", false
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// PaymentRepository interface for payment storage
+type PaymentRepository interface {
+	Record(p *database.Payment) (*database.Invoice, error)
+	GetByInvoice(invoiceID int) ([]database.Payment, error)
+}
+
+// PaymentHandler handles payments taken against invoices
+type PaymentHandler struct {
+	repo     PaymentRepository
+	invoices InvoiceRepository
+}
+
+// NewPaymentHandler creates a new payment handler
+func NewPaymentHandler(repo PaymentRepository, invoices InvoiceRepository) *PaymentHandler {
+	return &PaymentHandler{repo: repo, invoices: invoices}
+}
+
+// paymentResult is a recorded payment with the invoice it was applied to
+type paymentResult struct {
+	Payment database.Payment  `json:"payment"`
+	Invoice *database.Invoice `json:"invoice"`
+	Sandbox bool              `json:"sandbox,omitempty"` // simulated for a sandbox API key; nothing was recorded
+}
+
+// RecordPayment takes a full or partial payment against an issued invoice.
+// paidAt defaults to now; a card approval code or transfer reference may be given.
+// Sandbox API keys get the same checks and the invoice as the payment would
+// leave it, but nothing is recorded.
+func (h *PaymentHandler) RecordPayment(w http.ResponseWriter, r *http.Request) {
+	invoiceID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+		return
+	}
+
+	var payment database.Payment
+	if err := json.NewDecoder(r.Body).Decode(&payment); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	payment.InvoiceID = invoiceID
+	switch payment.Method {
+	case database.PaymentCash, database.PaymentCreditCard, database.PaymentBankTransfer:
+	default:
+		http.Error(w, "method must be cash, credit_card or bank_transfer", http.StatusBadRequest)
+		return
+	}
+	if payment.Amount <= 0 {
+		http.Error(w, "amount must be positive", http.StatusBadRequest)
+		return
+	}
+	if payment.Reference != nil {
+		trimmed := strings.TrimSpace(*payment.Reference)
+		payment.Reference = &trimmed
+	}
+	if payment.PaidAt.IsZero() {
+		payment.PaidAt = time.Now()
+	} else if payment.PaidAt.After(time.Now()) {
+		http.Error(w, "paidAt cannot be in the future", http.StatusBadRequest)
+		return
+	}
+	if payment.ReceivedBy == "" {
+		payment.ReceivedBy = reqctx.UserName(r.Context())
+	}
+	if payment.ReceivedBy == "" {
+		http.Error(w, "receivedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	if reqctx.Sandbox(r.Context()) {
+		invoice, err := h.invoices.GetByID(invoiceID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve invoice")
+			return
+		}
+		simulated, err := database.SimulatePayment(invoice, &payment)
+		if err != nil {
+			writeError(w, err, "Failed to record payment")
+			return
+		}
+		writeJSON(w, http.StatusCreated, paymentResult{Payment: payment, Invoice: simulated, Sandbox: true})
+		return
+	}
+
+	invoice, err := h.repo.Record(&payment)
+	if err != nil {
+		writeError(w, err, "Failed to record payment")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, paymentResult{Payment: payment, Invoice: invoice})
+}
+
+// GetPayments lists an invoice's payments, oldest first
+func (h *PaymentHandler) GetPayments(w http.ResponseWriter, r *http.Request) {
+	invoiceID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.invoices.GetByID(invoiceID); err != nil {
+		writeError(w, err, "Failed to retrieve invoice")
+		return
+	}
+
+	payments, err := h.repo.GetByInvoice(invoiceID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve payments")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, payments)
+}
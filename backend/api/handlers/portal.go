@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+const (
+	// portalCodeTTL is how long a sign-in code texted to a patient works
+	portalCodeTTL = 5 * time.Minute
+	// portalCodeAttempts is how many codes may be tried against one challenge
+	portalCodeAttempts = 5
+	// portalCodesPerHour limits the codes asked for one HN, so the sign-in
+	// cannot be used to flood a patient's phone
+	portalCodesPerHour = 5
+	// portalClientCodesPerHour limits the codes one client may ask for, so
+	// the sign-in cannot be used to try HN and phone pairs at scale
+	portalClientCodesPerHour = 20
+	// portalSessionTTL is how long a patient stays signed in
+	portalSessionTTL = 12 * time.Hour
+)
+
+// PortalRepository interface for patient portal sign-ins
+type PortalRepository interface {
+	CreateChallenge(c *database.PortalChallenge) error
+	CountChallenges(requestedHN, clientIP string, since time.Time) (int, int, error)
+	UseChallenge(id, codeHash string, maxAttempts int) (*database.PortalChallenge, error)
+	CreateSession(s *database.PortalSession) error
+	GetSession(tokenHash string) (*database.PortalSession, error)
+	RevokeSession(id int) error
+}
+
+// PortalSMS texts sign-in codes to patients straight away
+type PortalSMS interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// PortalHandler serves the patient portal: patients sign in with a code
+// texted to the phone number on their record and then see only their own
+// upcoming appointments, visit summaries and invoices, and book themselves
+// into the time released for online booking
+type PortalHandler struct {
+	repo         PortalRepository
+	patients     PatientRepository
+	appointments AppointmentRepository
+	visits       EncounterRepository
+	summaries    *VisitSummaryHandler
+	invoices     InvoiceRepository
+	sms          PortalSMS
+	slots        OnlineSlotRepository
+	bookings     *AppointmentHandler
+	policy       PortalBookingPolicy
+}
+
+// NewPortalHandler creates a new portal handler. With no sms, nobody can sign
+// in. Patients book online slots under policy, with the confirmations
+// bookings sends.
+func NewPortalHandler(repo PortalRepository, patients PatientRepository, appointments AppointmentRepository, visits EncounterRepository, summaries *VisitSummaryHandler, invoices InvoiceRepository, sms PortalSMS, slots OnlineSlotRepository, bookings *AppointmentHandler, policy PortalBookingPolicy) *PortalHandler {
+	return &PortalHandler{repo: repo, patients: patients, appointments: appointments, visits: visits, summaries: summaries, invoices: invoices, sms: sms,
+		slots: slots, bookings: bookings, policy: policy}
+}
+
+// PortalAppointment is what the portal shows of an appointment
+type PortalAppointment struct {
+	ID           int       `json:"id"`
+	DoctorName   string    `json:"doctorName"`
+	StartsAt     time.Time `json:"startsAt"`
+	EndsAt       time.Time `json:"endsAt"`
+	Type         string    `json:"type"`
+	Status       string    `json:"status"`
+	Confirmation string    `json:"confirmation"`
+}
+
+// PortalVisit is a closed visit listed in the portal; its summary has the details
+type PortalVisit struct {
+	ID         int       `json:"id"`
+	StartedAt  time.Time `json:"startedAt"`
+	DoctorName string    `json:"doctorName"`
+}
+
+// RequestPortalCode starts a sign-in: given the patient's hn and the phone
+// number on their record, it texts them a 6-digit code. The answer is the
+// same whether or not they match, so the portal does not reveal who is a
+// patient; only a matching sign-in is texted a code that works. Requests are
+// limited per HN asked for and per client before the patient is looked up,
+// so the limit gives nothing away either.
+func (h *PortalHandler) RequestPortalCode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		HN    string `json:"hn"`
+		Phone string `json:"phone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.HN = strings.ToUpper(strings.TrimSpace(req.HN))
+	phone := normalizePhone(req.Phone)
+	if req.HN == "" || phone == "" {
+		http.Error(w, "hn and phone are required", http.StatusBadRequest)
+		return
+	}
+	if h.sms == nil {
+		http.Error(w, "Portal sign-in is not available: no SMS gateway is configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	// HN1 and HN000001 are the same patient, so both count towards one limit
+	id, hnErr := parseHN(req.HN)
+	requestedHN := req.HN
+	if hnErr == nil {
+		requestedHN = fmt.Sprintf("HN%06d", id)
+	} else if len(requestedHN) > 10 {
+		requestedHN = requestedHN[:10]
+	}
+	clientIP := remoteIP(r)
+	forHN, byClient, err := h.repo.CountChallenges(requestedHN, clientIP, time.Now().Add(-time.Hour))
+	if err != nil {
+		writeError(w, err, "Failed to start sign-in")
+		return
+	}
+	if forHN >= portalCodesPerHour || byClient >= portalClientCodesPerHour {
+		http.Error(w, "Too many codes requested; try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	var patient *database.Patient
+	if hnErr == nil {
+		p, err := h.patients.GetByID(id)
+		switch {
+		case err == nil:
+			if p.Phone != nil && normalizePhone(*p.Phone) == phone {
+				patient = p
+			}
+		case !apperr.Is(err, apperr.KindNotFound):
+			writeError(w, err, "Failed to retrieve patient")
+			return
+		}
+	}
+
+	code, err := portalCode()
+	if err != nil {
+		writeError(w, err, "Failed to start sign-in")
+		return
+	}
+	challengeID, err := portalToken()
+	if err != nil {
+		writeError(w, err, "Failed to start sign-in")
+		return
+	}
+	challenge := database.PortalChallenge{
+		ID:          challengeID,
+		RequestedHN: requestedHN,
+		ClientIP:    clientIP,
+		CodeHash:    portalCodeHash(challengeID, code),
+		ExpiresAt:   time.Now().Add(portalCodeTTL),
+	}
+	if patient != nil {
+		challenge.PatientHN = &patient.HN
+	}
+	if err := h.repo.CreateChallenge(&challenge); err != nil {
+		writeError(w, err, "Failed to start sign-in")
+		return
+	}
+	if patient != nil {
+		message := fmt.Sprintf("รหัสเข้าสู่ระบบของคุณคือ %s (ใช้ได้ %d นาที) ห้ามบอกรหัสนี้แก่ผู้อื่น", code, int(portalCodeTTL/time.Minute))
+		if err := h.sms.Send(r.Context(), *patient.Phone, message); err != nil {
+			log.Printf("Failed to text a portal sign-in code to %s: %v", patient.HN, err)
+			http.Error(w, "Failed to send the code; try again later", http.StatusBadGateway)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusAccepted, challenge)
+}
+
+// VerifyPortalCode finishes a sign-in with the challengeId and the code
+// texted for it, returning a bearer token for the portal. A challenge allows
+// a few tries before a new code has to be requested.
+func (h *PortalHandler) VerifyPortalCode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeID string `json:"challengeId"`
+		Code        string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Code = strings.TrimSpace(req.Code)
+	if req.ChallengeID == "" || req.Code == "" {
+		http.Error(w, "challengeId and code are required", http.StatusBadRequest)
+		return
+	}
+
+	challenge, err := h.repo.UseChallenge(req.ChallengeID, portalCodeHash(req.ChallengeID, req.Code), portalCodeAttempts)
+	if err != nil {
+		switch {
+		case apperr.Is(err, apperr.KindValidation):
+			http.Error(w, "Incorrect code", http.StatusUnauthorized)
+		case apperr.Is(err, apperr.KindNotFound), apperr.Is(err, apperr.KindConflict):
+			http.Error(w, "The code has expired; request a new one", http.StatusUnauthorized)
+		default:
+			writeError(w, err, "Failed to sign in")
+		}
+		return
+	}
+
+	token, err := portalToken()
+	if err != nil {
+		writeError(w, err, "Failed to sign in")
+		return
+	}
+	session := database.PortalSession{
+		PatientHN: *challenge.PatientHN,
+		TokenHash: hashAPIKey(token),
+		ExpiresAt: time.Now().Add(portalSessionTTL),
+	}
+	if err := h.repo.CreateSession(&session); err != nil {
+		writeError(w, err, "Failed to sign in")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Token string `json:"token"` // shown only now
+		database.PortalSession
+	}{token, session})
+}
+
+// RequirePatient wraps a portal handler so only a signed-in patient can call
+// it, with their HN in the request context (reqctx.Patient). Portal requests
+// carry the token from VerifyPortalCode as "Authorization: Bearer <token>".
+func (h *PortalHandler) RequirePatient(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			http.Error(w, "Sign in to the portal first", http.StatusUnauthorized)
+			return
+		}
+		session, err := h.repo.GetSession(hashAPIKey(token))
+		if err != nil {
+			if apperr.Is(err, apperr.KindNotFound) {
+				http.Error(w, "Sign in to the portal first", http.StatusUnauthorized)
+				return
+			}
+			writeError(w, err, "Failed to check portal session")
+			return
+		}
+		if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+			http.Error(w, "Your session has ended; sign in again", http.StatusUnauthorized)
+			return
+		}
+
+		info := reqctx.From(r.Context())
+		info.UserID = "patient:" + session.PatientHN
+		info.UserName = session.PatientHN
+		info.Role = ""
+		info.PatientHN = session.PatientHN
+		ctx := context.WithValue(reqctx.With(r.Context(), info), portalSessionKey{}, session)
+		w.Header().Set("Cache-Control", "no-store")
+		next(w, r.WithContext(ctx))
+	}
+}
+
+type portalSessionKey struct{}
+
+// SignOutPortal ends the patient's portal session
+func (h *PortalHandler) SignOutPortal(w http.ResponseWriter, r *http.Request) {
+	session := r.Context().Value(portalSessionKey{}).(*database.PortalSession)
+	if err := h.repo.RevokeSession(session.ID); err != nil {
+		writeError(w, err, "Failed to sign out")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPortalProfile returns the signed-in patient's record
+func (h *PortalHandler) GetPortalProfile(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, patient)
+}
+
+// GetPortalAppointments lists the patient's appointments from now on that are
+// still going ahead, soonest first
+func (h *PortalHandler) GetPortalAppointments(w http.ResponseWriter, r *http.Request) {
+	appointments, err := h.appointments.List(database.AppointmentFilter{From: time.Now(), PatientHN: reqctx.Patient(r.Context())})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+
+	upcoming := []PortalAppointment{}
+	for _, a := range appointments {
+		if a.Sandbox || (a.Status != database.AppointmentScheduled && a.Status != database.AppointmentCheckedIn) {
+			continue
+		}
+		upcoming = append(upcoming, PortalAppointment{
+			ID:           a.ID,
+			DoctorName:   a.DoctorName,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			Type:         a.Type,
+			Status:       a.Status,
+			Confirmation: a.Confirmation,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, upcoming)
+}
+
+// GetPortalVisits lists the patient's closed visits, most recent first
+func (h *PortalHandler) GetPortalVisits(w http.ResponseWriter, r *http.Request) {
+	encounters, err := h.visits.GetByPatient(reqctx.Patient(r.Context()))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visits")
+		return
+	}
+
+	visits := []PortalVisit{}
+	for _, e := range encounters {
+		if e.Status == database.EncounterClosed {
+			visits = append(visits, PortalVisit{ID: e.ID, StartedAt: e.StartedAt, DoctorName: e.DoctorName})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, visits)
+}
+
+// GetPortalVisitSummary returns the summary of one of the patient's closed
+// visits: diagnoses, instructions and the drugs prescribed. Other patients'
+// visits are not found.
+func (h *PortalHandler) GetPortalVisitSummary(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil && !apperr.Is(err, apperr.KindNotFound) {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+	if visit == nil || visit.PatientHN != reqctx.Patient(r.Context()) || visit.Status != database.EncounterClosed {
+		http.Error(w, "Visit not found", http.StatusNotFound)
+		return
+	}
+
+	summary, ok := h.summaries.summarize(w, r, visit, nil)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// GetPortalInvoices lists the patient's issued and paid invoices; drafts and
+// voided invoices are left out
+func (h *PortalHandler) GetPortalInvoices(w http.ResponseWriter, r *http.Request) {
+	all, err := h.invoices.List(database.InvoiceFilter{PatientHN: reqctx.Patient(r.Context())})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve invoices")
+		return
+	}
+
+	invoices := []database.Invoice{}
+	for _, inv := range all {
+		if inv.Status == database.InvoiceIssued || inv.Status == database.InvoicePaid {
+			invoices = append(invoices, inv)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, invoices)
+}
+
+func (h *PortalHandler) loadPatient(w http.ResponseWriter, r *http.Request) (*database.Patient, bool) {
+	id, err := parseHN(reqctx.Patient(r.Context()))
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return nil, false
+	}
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	return patient, true
+}
+
+// portalCode returns a random 6-digit sign-in code
+func portalCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate sign-in code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// portalToken returns an unguessable challenge ID or session token
+func portalToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate portal token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// portalCodeHash hashes a sign-in code with its challenge, so equal codes
+// hash differently
+func portalCodeHash(challengeID, code string) string {
+	sum := sha256.Sum256([]byte(challengeID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// PortalBookingPolicy limits how patients book themselves in the portal;
+// zero limits are no limit
+type PortalBookingPolicy struct {
+	MaxUpcoming  int           // upcoming appointments a patient may hold, however booked
+	NoShowLimit  int           // missed appointments within NoShowWindow that stop a patient booking online
+	NoShowWindow time.Duration // how far back no-shows count
+}
+
+// PortalSlot is an open online slot as the portal shows it
+type PortalSlot struct {
+	ID         int       `json:"id"`
+	DoctorID   int       `json:"doctorId"`
+	DoctorName string    `json:"doctorName"`
+	StartsAt   time.Time `json:"startsAt"`
+	EndsAt     time.Time `json:"endsAt"`
+	Type       string    `json:"type"`
+}
+
+// GetPortalSlots lists the open slots released for online booking from now
+// until ?to= (default two weeks ahead), optionally from ?from= and for one
+// ?doctorId=. Slots the doctor has since been booked for another way are
+// left out.
+func (h *PortalHandler) GetPortalSlots(w http.ResponseWriter, r *http.Request) {
+	from, to, err := slotRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	f := database.OnlineSlotFilter{From: from, To: to, OpenOnly: true}
+	if s := r.URL.Query().Get("doctorId"); s != "" {
+		if f.DoctorID, err = strconv.Atoi(s); err != nil {
+			http.Error(w, "Invalid doctorId", http.StatusBadRequest)
+			return
+		}
+	}
+
+	slots, err := h.slots.List(f)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve online slots")
+		return
+	}
+	booked, err := h.appointments.List(database.AppointmentFilter{From: from.Add(-24 * time.Hour), To: to, DoctorID: f.DoctorID})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+
+	open := []PortalSlot{}
+	for _, s := range slots {
+		if doctorBusy(booked, s.DoctorID, s.StartsAt, s.EndsAt) {
+			continue
+		}
+		open = append(open, PortalSlot{ID: s.ID, DoctorID: s.DoctorID, DoctorName: s.DoctorName, StartsAt: s.StartsAt, EndsAt: s.EndsAt, Type: s.Type})
+	}
+
+	writeJSON(w, http.StatusOK, open)
+}
+
+// BookPortalAppointment books the signed-in patient into an open online slot
+// (slotId). Booking needs the citizen ID on their record as well as the
+// sign-in, and patients over the policy's upcoming appointment or no-show
+// limits are asked to call the clinic instead. The confirmation is texted at
+// once, and sent by LINE and email to patients who have them.
+func (h *PortalHandler) BookPortalAppointment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SlotID    int    `json:"slotId"`
+		CitizenID string `json:"citizenId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.SlotID == 0 || strings.TrimSpace(req.CitizenID) == "" {
+		http.Error(w, "slotId and citizenId are required", http.StatusBadRequest)
+		return
+	}
+
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+	if patient.CitizenID == nil {
+		http.Error(w, "Online booking needs your citizen ID on record; please ask the clinic to add it", http.StatusForbidden)
+		return
+	}
+	if digitsOnly(req.CitizenID) != digitsOnly(*patient.CitizenID) {
+		log.Printf("Portal booking by %s refused: citizen ID does not match", patient.HN)
+		http.Error(w, "The citizen ID does not match our records", http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	upcoming, err := h.appointments.List(database.AppointmentFilter{From: now, PatientHN: patient.HN})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointments")
+		return
+	}
+	if !h.checkBookingPolicy(w, patient.HN, upcoming) {
+		return
+	}
+
+	slot, err := h.slots.GetByID(req.SlotID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve online slot")
+		return
+	}
+	for _, a := range upcoming {
+		if a.Active() && a.StartsAt.Before(slot.EndsAt) && slot.StartsAt.Before(a.EndsAt) {
+			http.Error(w, "You already have an appointment at that time", http.StatusConflict)
+			return
+		}
+	}
+	if _, err := h.slots.Claim(slot.ID, patient.HN); err != nil {
+		writeError(w, err, "Failed to book online slot")
+		return
+	}
+
+	channel := "online"
+	doctorID := slot.DoctorID
+	appointment := database.Appointment{
+		PatientHN:    patient.HN,
+		DoctorID:     &doctorID,
+		DoctorName:   slot.DoctorName,
+		StartsAt:     slot.StartsAt,
+		EndsAt:       slot.EndsAt,
+		Type:         slot.Type,
+		Channel:      &channel,
+		Status:       database.AppointmentScheduled,
+		Confirmation: database.AppointmentUnconfirmed,
+	}
+	if h.bookings.languages != nil {
+		if language, err := h.bookings.languages.GetPatientLanguage(patient.HN); err == nil && language.InterpreterRequired {
+			appointment.InterpreterRequired = true
+		}
+	}
+	if err := h.appointments.Create(&appointment); err != nil {
+		if err := h.slots.Unclaim(slot.ID); err != nil {
+			log.Printf("Failed to reopen online slot %d: %v", slot.ID, err)
+		}
+		writeError(w, err, "Failed to create appointment")
+		return
+	}
+	if err := h.slots.SetAppointment(slot.ID, appointment.ID); err != nil {
+		log.Printf("Failed to record appointment %d on online slot %d: %v", appointment.ID, slot.ID, err)
+	}
+
+	loc := reqctx.Location(r.Context())
+	confirmation := bookingConfirmation(&appointment, loc)
+	if h.sms != nil && patient.Phone != nil {
+		if err := h.sms.Send(r.Context(), *patient.Phone, confirmation.Text); err != nil {
+			log.Printf("Failed to text the confirmation of appointment %d: %v", appointment.ID, err)
+		}
+	}
+	queueLINE(h.bookings.line, confirmation)
+	confirmationEmail(h.bookings.emails, patient, &appointment, loc)
+
+	writeJSON(w, http.StatusCreated, PortalAppointment{
+		ID:           appointment.ID,
+		DoctorName:   appointment.DoctorName,
+		StartsAt:     appointment.StartsAt,
+		EndsAt:       appointment.EndsAt,
+		Type:         appointment.Type,
+		Status:       appointment.Status,
+		Confirmation: appointment.Confirmation,
+	})
+}
+
+// checkBookingPolicy refuses an online booking by a patient who already holds
+// the most upcoming appointments allowed or has missed too many recently
+func (h *PortalHandler) checkBookingPolicy(w http.ResponseWriter, hn string, upcoming []database.Appointment) bool {
+	if h.policy.MaxUpcoming > 0 {
+		n := 0
+		for _, a := range upcoming {
+			if a.Active() && !a.Sandbox {
+				n++
+			}
+		}
+		if n >= h.policy.MaxUpcoming {
+			http.Error(w, fmt.Sprintf("You already have %d upcoming appointments; please call the clinic to book more", n), http.StatusConflict)
+			return false
+		}
+	}
+	if h.policy.NoShowLimit > 0 {
+		now := time.Now()
+		missed, err := h.appointments.List(database.AppointmentFilter{
+			From: now.Add(-h.policy.NoShowWindow), To: now, PatientHN: hn, Status: database.AppointmentNoShow,
+		})
+		if err != nil {
+			writeError(w, err, "Failed to retrieve appointments")
+			return false
+		}
+		if len(missed) >= h.policy.NoShowLimit {
+			http.Error(w, "Online booking is not available after missed appointments; please call the clinic to book", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// doctorBusy reports whether any of the appointments has the doctor busy
+// between start and end
+func doctorBusy(appointments []database.Appointment, doctorID int, start, end time.Time) bool {
+	for _, a := range appointments {
+		if a.Active() && a.DoctorID != nil && *a.DoctorID == doctorID && a.StartsAt.Before(end) && start.Before(a.EndsAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// digitsOnly drops everything but digits, so "1-2345-67890-12-3" matches "1234567890123"
+func digitsOnly(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c >= '0' && c <= '9' {
+			return c
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// PrescriptionRepository interface for prescription storage
+type PrescriptionRepository interface {
+	Create(p *database.Prescription) error
+	GetByID(id int) (*database.Prescription, error)
+	GetByVisit(visitID int) ([]database.Prescription, error)
+	GetByPatient(hn string) ([]database.Prescription, error)
+}
+
+// PrescriptionHandler handles prescription requests
+type PrescriptionHandler struct {
+	repo     PrescriptionRepository
+	visits   EncounterRepository
+	patients PatientRepository
+	doctors  DoctorRepository
+	drugs    DrugLookup
+}
+
+// NewPrescriptionHandler creates a new prescription handler
+func NewPrescriptionHandler(repo PrescriptionRepository, visits EncounterRepository, patients PatientRepository, doctors DoctorRepository, drugs DrugLookup) *PrescriptionHandler {
+	return &PrescriptionHandler{repo: repo, visits: visits, patients: patients, doctors: doctors, drugs: drugs}
+}
+
+// PrintedPrescription is a prescription laid out for printing
+type PrintedPrescription struct {
+	Number        string                    `json:"number"` // e.g. "RX-000012"
+	Date          string                    `json:"date"`   // DD/MM/YYYY in the Buddhist Era, e.g. "16/10/2569"
+	PatientHN     string                    `json:"patientHn"`
+	PatientName   string                    `json:"patientName"`
+	PatientAge    int                       `json:"patientAge"`
+	PatientGender string                    `json:"patientGender"`
+	DoctorName    string                    `json:"doctorName"`
+	LicenseNumber string                    `json:"licenseNumber"`
+	Lines         []PrintedPrescriptionLine `json:"lines"`
+	Notes         *string                   `json:"notes,omitempty"`
+}
+
+// PrintedPrescriptionLine is one numbered drug line, e.g. Paracetamol with
+// directions "500 mg วันละ 3 ครั้ง หลังอาหาร นาน 5 วัน" and quantity "#15".
+// Catalog drugs with a patient information leaflet link to it, for the
+// dispensing label to print as a QR code.
+type PrintedPrescriptionLine struct {
+	No           int     `json:"no"`
+	DrugName     string  `json:"drugName"`
+	Directions   string  `json:"directions"`
+	Quantity     string  `json:"quantity,omitempty"`
+	Instructions *string `json:"instructions,omitempty"`
+	LeafletURL   *string `json:"leafletUrl,omitempty"`
+}
+
+// CreatePrescription writes a prescription for an open visit. The visit's
+// attending doctor prescribes unless doctorId names another. Lines with a
+// drugId take their name, and any missing dose or frequency, from the catalog.
+func (h *PrescriptionHandler) CreatePrescription(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+	if visit.Status != database.EncounterOpen {
+		http.Error(w, "Prescriptions can only be written during an open visit", http.StatusConflict)
+		return
+	}
+
+	var prescription database.Prescription
+	if err := json.NewDecoder(r.Body).Decode(&prescription); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(prescription.Items) == 0 {
+		http.Error(w, "At least one item is required", http.StatusBadRequest)
+		return
+	}
+	for i := range prescription.Items {
+		if !resolveDrug(w, h.drugs, &prescription.Items[i]) {
+			return
+		}
+		if msg := checkDrugTemplate(&prescription.Items[i]); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		if prescription.Items[i].DurationDays == nil {
+			http.Error(w, "durationDays is required on every item", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if prescription.DoctorID == 0 && visit.DoctorID != nil {
+		prescription.DoctorID = *visit.DoctorID
+	}
+	if prescription.DoctorID == 0 {
+		http.Error(w, "doctorId is required when the visit has no attending doctor", http.StatusBadRequest)
+		return
+	}
+	doctor, err := h.doctors.GetByID(prescription.DoctorID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return
+	}
+	if !doctor.Active {
+		http.Error(w, "Doctor is no longer active", http.StatusConflict)
+		return
+	}
+
+	prescription.VisitID = visit.ID
+	prescription.PatientHN = visit.PatientHN
+	prescription.DoctorName = doctor.FullName
+	prescription.LicenseNumber = doctor.LicenseNumber
+	prescription.DispensedBy, prescription.DispensedAt = nil, nil
+	if err := h.repo.Create(&prescription); err != nil {
+		writeError(w, err, "Failed to create prescription")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, prescription)
+}
+
+// GetVisitPrescriptions lists a visit's prescriptions
+func (h *PrescriptionHandler) GetVisitPrescriptions(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	prescriptions, err := h.repo.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescriptions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prescriptions)
+}
+
+// GetPatientPrescriptions lists a patient's prescriptions, most recent first
+func (h *PrescriptionHandler) GetPatientPrescriptions(w http.ResponseWriter, r *http.Request) {
+	prescriptions, err := h.repo.GetByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescriptions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prescriptions)
+}
+
+// GetPrescription returns one prescription
+func (h *PrescriptionHandler) GetPrescription(w http.ResponseWriter, r *http.Request) {
+	prescription, ok := h.loadPrescription(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prescription)
+}
+
+// PrintPrescription returns the prescription with the patient's details and
+// numbered, human-readable drug lines ready for the print template
+func (h *PrescriptionHandler) PrintPrescription(w http.ResponseWriter, r *http.Request) {
+	prescription, ok := h.loadPrescription(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := parseHN(prescription.PatientHN)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	issued := prescription.CreatedAt.In(reqctx.Location(r.Context()))
+	printed := PrintedPrescription{
+		Number:        fmt.Sprintf("RX-%06d", prescription.ID),
+		Date:          fmt.Sprintf("%02d/%02d/%d", issued.Day(), issued.Month(), issued.Year()+543),
+		PatientHN:     patient.HN,
+		PatientName:   patient.FullName,
+		PatientAge:    patient.Age,
+		PatientGender: patient.Gender,
+		DoctorName:    prescription.DoctorName,
+		LicenseNumber: prescription.LicenseNumber,
+		Lines:         printedLines(h.drugs, prescription.Items),
+		Notes:         prescription.Notes,
+	}
+
+	writeJSON(w, http.StatusOK, printed)
+}
+
+// printedLines numbers a prescription's drugs and writes out their directions
+func printedLines(drugs DrugLookup, items []database.DrugTemplate) []PrintedPrescriptionLine {
+	lines := []PrintedPrescriptionLine{}
+	for i, item := range items {
+		line := PrintedPrescriptionLine{
+			No:           i + 1,
+			DrugName:     item.DrugName,
+			Directions:   item.Dose + " " + item.Frequency,
+			Instructions: item.Instructions,
+		}
+		if item.DurationDays != nil {
+			line.Directions += " นาน " + strconv.Itoa(*item.DurationDays) + " วัน"
+		}
+		if item.Quantity != nil {
+			line.Quantity = "#" + strconv.FormatFloat(*item.Quantity, 'f', -1, 64)
+		}
+		line.LeafletURL = drugLeaflet(drugs, item.DrugID)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// drugLeaflet returns the leaflet link of a catalog drug, or nil for free-text
+// lines and drugs without one. A failed lookup only leaves the link out.
+func drugLeaflet(drugs DrugLookup, drugID *int) *string {
+	if drugID == nil || drugs == nil {
+		return nil
+	}
+	drug, err := drugs.GetByID(*drugID)
+	if err != nil {
+		if !apperr.Is(err, apperr.KindNotFound) {
+			log.Printf("Failed to look up leaflet of drug %d: %v", *drugID, err)
+		}
+		return nil
+	}
+	return drug.LeafletURL
+}
+
+func (h *PrescriptionHandler) loadPrescription(w http.ResponseWriter, r *http.Request) (*database.Prescription, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid prescription ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	prescription, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescription")
+		return nil, false
+	}
+	return prescription, true
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"clinic/backend/internal/database"
+)
+
+// PrescriptionFavoriteRepository interface for doctor drug favorites and prescription sets
+type PrescriptionFavoriteRepository interface {
+	CreateFavorite(f *database.DrugFavorite) error
+	GetFavorites(doctorID int) ([]database.DrugFavorite, error)
+	UseFavorite(doctorID, id int) (*database.DrugFavorite, error)
+	DeleteFavorite(doctorID, id int) error
+	CreateSet(s *database.PrescriptionSet) error
+	GetSet(doctorID, id int) (*database.PrescriptionSet, error)
+	GetSets(doctorID int) ([]database.PrescriptionSet, error)
+	UpdateSet(s *database.PrescriptionSet) error
+	UseSet(doctorID, id int) (*database.PrescriptionSet, error)
+	DeleteSet(doctorID, id int) error
+}
+
+// PrescriptionFavoriteHandler handles per-doctor drug favorite and prescription set requests
+type PrescriptionFavoriteHandler struct {
+	repo  PrescriptionFavoriteRepository
+	drugs DrugLookup
+}
+
+// NewPrescriptionFavoriteHandler creates a new prescription favorite handler
+func NewPrescriptionFavoriteHandler(repo PrescriptionFavoriteRepository, drugs DrugLookup) *PrescriptionFavoriteHandler {
+	return &PrescriptionFavoriteHandler{repo: repo, drugs: drugs}
+}
+
+// CreateFavorite saves a drug to the doctor's favorites
+func (h *PrescriptionFavoriteHandler) CreateFavorite(w http.ResponseWriter, r *http.Request) {
+	doctorID, err := pathID(r, "doctorId")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+
+	var favorite database.DrugFavorite
+	if err := json.NewDecoder(r.Body).Decode(&favorite); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !resolveDrug(w, h.drugs, &favorite.DrugTemplate) {
+		return
+	}
+	if msg := checkDrugTemplate(&favorite.DrugTemplate); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	favorite.DoctorID = doctorID
+	favorite.LastUsedAt = nil
+	if err := h.repo.CreateFavorite(&favorite); err != nil {
+		writeError(w, err, "Failed to save drug favorite")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, favorite)
+}
+
+// GetFavorites returns the doctor's favorites, most used first
+func (h *PrescriptionFavoriteHandler) GetFavorites(w http.ResponseWriter, r *http.Request) {
+	doctorID, err := pathID(r, "doctorId")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+
+	favorites, err := h.repo.GetFavorites(doctorID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve drug favorites")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, favorites)
+}
+
+// UseFavorite returns a favorite's prescription line for one-click prescribing and counts the use
+func (h *PrescriptionFavoriteHandler) UseFavorite(w http.ResponseWriter, r *http.Request) {
+	doctorID, id, ok := doctorItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	favorite, err := h.repo.UseFavorite(doctorID, id)
+	if err != nil {
+		writeError(w, err, "Failed to use drug favorite")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"favoriteId": favorite.ID,
+		"items":      []database.DrugTemplate{favorite.DrugTemplate},
+	})
+}
+
+// DeleteFavorite removes a drug from the doctor's favorites
+func (h *PrescriptionFavoriteHandler) DeleteFavorite(w http.ResponseWriter, r *http.Request) {
+	doctorID, id, ok := doctorItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteFavorite(doctorID, id); err != nil {
+		writeError(w, err, "Failed to delete drug favorite")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateSet saves a named multi-drug prescription set for the doctor
+func (h *PrescriptionFavoriteHandler) CreateSet(w http.ResponseWriter, r *http.Request) {
+	doctorID, err := pathID(r, "doctorId")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+
+	var set database.PrescriptionSet
+	if err := json.NewDecoder(r.Body).Decode(&set); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	set.DoctorID = doctorID
+	set.ID = 0
+	if !h.checkSet(w, &set) {
+		return
+	}
+
+	set.LastUsedAt = nil
+	if err := h.repo.CreateSet(&set); err != nil {
+		writeError(w, err, "Failed to save prescription set")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, set)
+}
+
+// GetSets returns the doctor's prescription sets, most used first
+func (h *PrescriptionFavoriteHandler) GetSets(w http.ResponseWriter, r *http.Request) {
+	doctorID, err := pathID(r, "doctorId")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+
+	sets, err := h.repo.GetSets(doctorID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescription sets")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sets)
+}
+
+// GetSet returns one prescription set
+func (h *PrescriptionFavoriteHandler) GetSet(w http.ResponseWriter, r *http.Request) {
+	doctorID, id, ok := doctorItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	set, err := h.repo.GetSet(doctorID, id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescription set")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, set)
+}
+
+// UpdateSet renames a prescription set or replaces its drugs
+func (h *PrescriptionFavoriteHandler) UpdateSet(w http.ResponseWriter, r *http.Request) {
+	doctorID, id, ok := doctorItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var set database.PrescriptionSet
+	if err := json.NewDecoder(r.Body).Decode(&set); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	set.ID = id
+	set.DoctorID = doctorID
+	if !h.checkSet(w, &set) {
+		return
+	}
+
+	if err := h.repo.UpdateSet(&set); err != nil {
+		writeError(w, err, "Failed to update prescription set")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, set)
+}
+
+// UseSet returns a set's prescription lines for one-click prescribing and counts the use
+func (h *PrescriptionFavoriteHandler) UseSet(w http.ResponseWriter, r *http.Request) {
+	doctorID, id, ok := doctorItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	set, err := h.repo.UseSet(doctorID, id)
+	if err != nil {
+		writeError(w, err, "Failed to use prescription set")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"setId": set.ID,
+		"name":  set.Name,
+		"items": set.Items,
+	})
+}
+
+// DeleteSet removes a prescription set
+func (h *PrescriptionFavoriteHandler) DeleteSet(w http.ResponseWriter, r *http.Request) {
+	doctorID, id, ok := doctorItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteSet(doctorID, id); err != nil {
+		writeError(w, err, "Failed to delete prescription set")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkSet validates a set's name and items and rejects a name the doctor already uses
+func (h *PrescriptionFavoriteHandler) checkSet(w http.ResponseWriter, set *database.PrescriptionSet) bool {
+	set.Name = strings.TrimSpace(set.Name)
+	if set.Name == "" || len(set.Items) == 0 {
+		http.Error(w, "name and at least one item are required", http.StatusBadRequest)
+		return false
+	}
+	for i := range set.Items {
+		if !resolveDrug(w, h.drugs, &set.Items[i]) {
+			return false
+		}
+		if msg := checkDrugTemplate(&set.Items[i]); msg != "" {
+			http.Error(w, fmt.Sprintf("item %d: %s", i+1, msg), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	sets, err := h.repo.GetSets(set.DoctorID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescription sets")
+		return false
+	}
+	for _, s := range sets {
+		if s.ID != set.ID && strings.EqualFold(s.Name, set.Name) {
+			http.Error(w, "A prescription set with this name already exists", http.StatusConflict)
+			return false
+		}
+	}
+
+	return true
+}
+
+func checkDrugTemplate(t *database.DrugTemplate) string {
+	t.DrugName = strings.TrimSpace(t.DrugName)
+	if t.DrugName == "" || t.Dose == "" || t.Frequency == "" {
+		return "drugName, dose and frequency are required"
+	}
+	if t.DurationDays != nil && *t.DurationDays <= 0 {
+		return "durationDays must be positive"
+	}
+	if t.Quantity != nil && *t.Quantity <= 0 {
+		return "quantity must be positive"
+	}
+	return ""
+}
+
+func doctorItemIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	doctorID, err := pathID(r, "doctorId")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return doctorID, id, true
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// ProblemRepository interface for patient problem list storage
+type ProblemRepository interface {
+	Create(p *database.Problem) error
+	GetByID(id int) (*database.Problem, error)
+	GetByPatient(hn string, activeOnly bool) ([]database.Problem, error)
+	Update(p *database.Problem) error
+	Delete(id int) error
+}
+
+// ProblemHandler handles the chronic condition problem list on patient records
+type ProblemHandler struct {
+	repo     ProblemRepository
+	patients PatientRepository
+}
+
+// NewProblemHandler creates a new problem handler
+func NewProblemHandler(repo ProblemRepository, patients PatientRepository) *ProblemHandler {
+	return &ProblemHandler{repo: repo, patients: patients}
+}
+
+// CreateProblem adds a condition to a patient's problem list; recordedBy
+// defaults to the signed-in user
+func (h *ProblemHandler) CreateProblem(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var problem database.Problem
+	if err := json.NewDecoder(r.Body).Decode(&problem); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	problem.PatientHN = hn
+	if problem.Status == "" {
+		problem.Status = database.ProblemActive
+	}
+	if problem.RecordedBy == "" {
+		problem.RecordedBy = reqctx.UserName(r.Context())
+	}
+	if problem.RecordedBy == "" {
+		http.Error(w, "recordedBy is required", http.StatusBadRequest)
+		return
+	}
+	if msg := checkProblem(&problem, today(r)); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if ok := h.checkDuplicate(w, &problem); !ok {
+		return
+	}
+
+	if err := h.repo.Create(&problem); err != nil {
+		writeError(w, err, "Failed to create problem")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, problem)
+}
+
+// GetPatientProblems lists a patient's problem list, active conditions first;
+// resolved ones are included after them unless ?active=true
+func (h *ProblemHandler) GetPatientProblems(w http.ResponseWriter, r *http.Request) {
+	problems, err := h.repo.GetByPatient(mux.Vars(r)["hn"], r.URL.Query().Get("active") == "true")
+	if err != nil {
+		writeError(w, err, "Failed to retrieve problems")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, problems)
+}
+
+// GetProblem returns one problem
+func (h *ProblemHandler) GetProblem(w http.ResponseWriter, r *http.Request) {
+	problem, ok := h.loadProblem(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, problem)
+}
+
+// UpdateProblem replaces a problem's details; set status to resolved, with
+// an optional resolvedDate defaulting to today, once a condition clears up
+func (h *ProblemHandler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.loadProblem(w, r)
+	if !ok {
+		return
+	}
+
+	var problem database.Problem
+	if err := json.NewDecoder(r.Body).Decode(&problem); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	problem.ID = existing.ID
+	problem.PatientHN = existing.PatientHN
+	problem.RecordedBy = existing.RecordedBy
+	if problem.Status == "" {
+		problem.Status = existing.Status
+	}
+	if problem.Status == database.ProblemResolved && problem.ResolvedDate == nil {
+		problem.ResolvedDate = existing.ResolvedDate
+	}
+	if msg := checkProblem(&problem, today(r)); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if ok := h.checkDuplicate(w, &problem); !ok {
+		return
+	}
+
+	if err := h.repo.Update(&problem); err != nil {
+		writeError(w, err, "Failed to update problem")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, problem)
+}
+
+// DeleteProblem removes a problem entered by mistake
+func (h *ProblemHandler) DeleteProblem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid problem ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		writeError(w, err, "Failed to delete problem")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkDuplicate rejects a second active entry of the same condition for a
+// patient, writing the response when it does
+func (h *ProblemHandler) checkDuplicate(w http.ResponseWriter, p *database.Problem) bool {
+	if p.Status != database.ProblemActive {
+		return true
+	}
+	active, err := h.repo.GetByPatient(p.PatientHN, true)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve problems")
+		return false
+	}
+	for _, other := range active {
+		if other.ID != p.ID && strings.EqualFold(other.Condition, p.Condition) {
+			http.Error(w, p.Condition+" is already on the problem list", http.StatusConflict)
+			return false
+		}
+	}
+	return true
+}
+
+// checkProblem trims and validates a problem as of day, returning what is
+// wrong with it; a resolved problem's resolvedDate defaults to day and an
+// active one's is cleared
+func checkProblem(p *database.Problem, day string) string {
+	p.Condition = strings.TrimSpace(p.Condition)
+	if p.Condition == "" {
+		return "condition is required"
+	}
+	if p.Status != database.ProblemActive && p.Status != database.ProblemResolved {
+		return "status must be active or resolved"
+	}
+	if p.OnsetDate != nil && *p.OnsetDate == "" {
+		p.OnsetDate = nil
+	}
+	if p.OnsetDate != nil {
+		if _, err := time.Parse("2006-01-02", *p.OnsetDate); err != nil {
+			return "Invalid onsetDate, expected YYYY-MM-DD"
+		}
+		if *p.OnsetDate > day {
+			return "onsetDate cannot be in the future"
+		}
+	}
+	if p.Status == database.ProblemActive {
+		p.ResolvedDate = nil
+		return ""
+	}
+	if p.ResolvedDate == nil || *p.ResolvedDate == "" {
+		p.ResolvedDate = &day
+	}
+	if _, err := time.Parse("2006-01-02", *p.ResolvedDate); err != nil {
+		return "Invalid resolvedDate, expected YYYY-MM-DD"
+	}
+	if *p.ResolvedDate > day {
+		return "resolvedDate cannot be in the future"
+	}
+	if p.OnsetDate != nil && *p.ResolvedDate < *p.OnsetDate {
+		return "resolvedDate cannot be before onsetDate"
+	}
+	return ""
+}
+
+func (h *ProblemHandler) loadProblem(w http.ResponseWriter, r *http.Request) (*database.Problem, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid problem ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	problem, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve problem")
+		return nil, false
+	}
+	return problem, true
+}
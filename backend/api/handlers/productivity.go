@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// ClosedVisits provides the visits that ended in a period, for reports
+type ClosedVisits interface {
+	GetClosedBetween(from, to time.Time) ([]database.Encounter, error)
+}
+
+// WrittenPrescriptions provides the prescriptions written in a period, for reports
+type WrittenPrescriptions interface {
+	GetWrittenBetween(from, to time.Time) ([]database.Prescription, error)
+}
+
+// VisitInvoices provides the invoices of visits, for reports
+type VisitInvoices interface {
+	List(f database.InvoiceFilter) ([]database.Invoice, error)
+}
+
+// ProductivityHandler reports what each doctor did in a period
+type ProductivityHandler struct {
+	visits        ClosedVisits
+	invoices      VisitInvoices
+	prescriptions WrittenPrescriptions
+}
+
+// NewProductivityHandler creates a new productivity handler
+func NewProductivityHandler(visits ClosedVisits, invoices VisitInvoices, prescriptions WrittenPrescriptions) *ProductivityHandler {
+	return &ProductivityHandler{visits: visits, invoices: invoices, prescriptions: prescriptions}
+}
+
+// ProductivityFigures are one doctor's figures for one period
+type ProductivityFigures struct {
+	VisitsCompleted   int      `json:"visitsCompleted"`
+	AvgConsultMinutes *float64 `json:"avgConsultMinutes"` // from the visits' start and end; null without visits
+	Revenue           float64  `json:"revenue"`           // billed on the visits' issued and paid invoices
+	Collected         float64  `json:"collected"`         // paid so far on those invoices
+	Prescriptions     int      `json:"prescriptions"`     // written by the doctor in the period
+}
+
+// DoctorProductivity is one row of the doctor productivity report. Change is
+// the current period's figures less the previous period's.
+type DoctorProductivity struct {
+	DoctorID   *int                `json:"doctorId,omitempty"`
+	DoctorName string              `json:"doctorName"`
+	Current    ProductivityFigures `json:"current"`
+	Previous   ProductivityFigures `json:"previous"`
+	Change     ProductivityFigures `json:"change"`
+}
+
+// ProductivityReport is the doctor productivity report for a period and the
+// period of the same length just before it; dates are inclusive
+type ProductivityReport struct {
+	From         string               `json:"from"`
+	To           string               `json:"to"`
+	PreviousFrom string               `json:"previousFrom"`
+	PreviousTo   string               `json:"previousTo"`
+	Doctors      []DoctorProductivity `json:"doctors"`
+}
+
+// productivityTally accumulates one doctor's figures for one period
+type productivityTally struct {
+	figures        ProductivityFigures
+	consultMinutes float64
+}
+
+// doctorKey identifies a doctor in the report: by record when there is one,
+// otherwise by the free-text name visits were recorded with
+type doctorKey struct {
+	id   int
+	name string
+}
+
+func keyFor(doctorID *int, doctorName string) doctorKey {
+	if doctorID != nil {
+		return doctorKey{id: *doctorID}
+	}
+	return doctorKey{name: doctorName}
+}
+
+// GetDoctorProductivity reports, per doctor, the visits completed, their
+// average length, the revenue billed for them and the prescriptions written
+// in ?from=&to= (default this month), next to the same figures for the period
+// of the same length just before it. Visits and their revenue count for the
+// attending doctor in the period the visit ended; ?doctorId= narrows the
+// report to one doctor.
+func (h *ProductivityHandler) GetDoctorProductivity(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	days := int(to.Sub(from).Round(24*time.Hour) / (24 * time.Hour))
+	if days <= 0 {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+	var only *doctorKey
+	if s := r.URL.Query().Get("doctorId"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "Invalid doctorId", http.StatusBadRequest)
+			return
+		}
+		only = &doctorKey{id: id}
+	}
+	previousFrom := from.AddDate(0, 0, -days)
+
+	visits, err := h.visits.GetClosedBetween(previousFrom, to)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visits")
+		return
+	}
+	prescriptions, err := h.prescriptions.GetWrittenBetween(previousFrom, to)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescriptions")
+		return
+	}
+	invoices := []database.Invoice{}
+	if len(visits) > 0 {
+		visitIDs := make([]int, len(visits))
+		for i, v := range visits {
+			visitIDs[i] = v.ID
+		}
+		invoices, err = h.invoices.List(database.InvoiceFilter{VisitIDs: visitIDs})
+		if err != nil {
+			writeError(w, err, "Failed to retrieve invoices")
+			return
+		}
+	}
+
+	current := map[doctorKey]*productivityTally{}
+	previous := map[doctorKey]*productivityTally{}
+	names := map[doctorKey]string{}
+	ids := map[doctorKey]*int{}
+	tally := func(key doctorKey, name string, id *int, at time.Time) *productivityTally {
+		if only != nil && key != *only {
+			return nil
+		}
+		names[key] = name
+		ids[key] = id
+		period := current
+		if at.Before(from) {
+			period = previous
+		}
+		if period[key] == nil {
+			period[key] = &productivityTally{}
+		}
+		return period[key]
+	}
+
+	visitTallies := map[int]*productivityTally{}
+	for _, v := range visits {
+		t := tally(keyFor(v.DoctorID, v.DoctorName), v.DoctorName, v.DoctorID, *v.EndedAt)
+		if t == nil {
+			continue
+		}
+		t.figures.VisitsCompleted++
+		if minutes := v.EndedAt.Sub(v.StartedAt).Minutes(); minutes > 0 {
+			t.consultMinutes += minutes
+		}
+		visitTallies[v.ID] = t
+	}
+	for _, inv := range invoices {
+		t := visitTallies[inv.VisitID]
+		if t == nil || (inv.Status != database.InvoiceIssued && inv.Status != database.InvoicePaid) {
+			continue
+		}
+		t.figures.Revenue += inv.Total
+		t.figures.Collected += inv.AmountPaid
+	}
+	for _, p := range prescriptions {
+		doctorID := p.DoctorID
+		if t := tally(doctorKey{id: p.DoctorID}, p.DoctorName, &doctorID, p.CreatedAt); t != nil {
+			t.figures.Prescriptions++
+		}
+	}
+
+	report := ProductivityReport{
+		From:         from.Format("2006-01-02"),
+		To:           to.AddDate(0, 0, -1).Format("2006-01-02"),
+		PreviousFrom: previousFrom.Format("2006-01-02"),
+		PreviousTo:   from.AddDate(0, 0, -1).Format("2006-01-02"),
+		Doctors:      []DoctorProductivity{},
+	}
+	for key, name := range names {
+		row := DoctorProductivity{
+			DoctorID:   ids[key],
+			DoctorName: name,
+			Current:    current[key].result(),
+			Previous:   previous[key].result(),
+		}
+		row.Change = ProductivityFigures{
+			VisitsCompleted: row.Current.VisitsCompleted - row.Previous.VisitsCompleted,
+			Revenue:         roundBaht(row.Current.Revenue - row.Previous.Revenue),
+			Collected:       roundBaht(row.Current.Collected - row.Previous.Collected),
+			Prescriptions:   row.Current.Prescriptions - row.Previous.Prescriptions,
+		}
+		if row.Current.AvgConsultMinutes != nil && row.Previous.AvgConsultMinutes != nil {
+			change := math.Round((*row.Current.AvgConsultMinutes-*row.Previous.AvgConsultMinutes)*10) / 10
+			row.Change.AvgConsultMinutes = &change
+		}
+		report.Doctors = append(report.Doctors, row)
+	}
+	sort.Slice(report.Doctors, func(i, j int) bool {
+		a, b := report.Doctors[i], report.Doctors[j]
+		if a.Current.VisitsCompleted != b.Current.VisitsCompleted {
+			return a.Current.VisitsCompleted > b.Current.VisitsCompleted
+		}
+		return a.DoctorName < b.DoctorName
+	})
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// result rounds the tally into figures; a doctor with nothing in the period
+// has a nil tally and zero figures
+func (t *productivityTally) result() ProductivityFigures {
+	if t == nil {
+		return ProductivityFigures{}
+	}
+	figures := t.figures
+	figures.Revenue = roundBaht(figures.Revenue)
+	figures.Collected = roundBaht(figures.Collected)
+	if figures.VisitsCompleted > 0 {
+		avg := math.Round(t.consultMinutes/float64(figures.VisitsCompleted)*10) / 10
+		figures.AvgConsultMinutes = &avg
+	}
+	return figures
+}
+
+// roundBaht rounds an amount to the satang
+func roundBaht(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// HandlerSampling switches profiling on for one route
+type HandlerSampling struct {
+	Route  string  `json:"route"`  // method and path template as registered, e.g. "GET /api/patients"
+	CPU    bool    `json:"cpu"`    // label sampled requests handler=<route> in CPU and goroutine profiles
+	Allocs bool    `json:"allocs"` // measure heap allocations during sampled requests
+	Rate   float64 `json:"rate"`   // share of requests sampled, 0 < rate <= 1; 0 means every request
+}
+
+// HandlerProfile is a route's sampling toggle and what it has recorded since it was set
+type HandlerProfile struct {
+	HandlerSampling
+	Requests    int64     `json:"requests"`
+	Sampled     int64     `json:"sampled"`
+	MeanMs      float64   `json:"meanMs"`
+	MaxMs       float64   `json:"maxMs"`
+	AllocBytes  int64     `json:"allocBytesPerRequest,omitempty"` // process-wide, so concurrent requests inflate it
+	AllocsCount int64     `json:"allocsPerRequest,omitempty"`
+	Since       time.Time `json:"since"`
+}
+
+// RuntimeProfiling holds the runtime's block and mutex profile rates; both are off at 0
+type RuntimeProfiling struct {
+	BlockProfileRate     int `json:"blockProfileRate"`     // record one blocking event per this many nanoseconds blocked
+	MutexProfileFraction int `json:"mutexProfileFraction"` // record one in this many mutex contention events
+}
+
+// ProfilingState is everything the profiling endpoints report
+type ProfilingState struct {
+	Runtime  RuntimeProfiling `json:"runtime"`
+	Handlers []HandlerProfile `json:"handlers"`
+}
+
+type handlerSamples struct {
+	HandlerProfile
+	totalMs     float64
+	allocBytes  uint64
+	allocsCount uint64
+	measured    int64
+}
+
+// ProfilingHandler serves net/http/pprof and per-route sampling toggles for
+// diagnosing slow endpoints
+type ProfilingHandler struct {
+	routes   map[string]bool
+	handlers map[string]*handlerSamples
+	runtime  RuntimeProfiling
+	mutex    sync.RWMutex
+}
+
+// NewProfilingHandler creates a profiling handler with all sampling off
+func NewProfilingHandler() *ProfilingHandler {
+	return &ProfilingHandler{routes: map[string]bool{}, handlers: map[string]*handlerSamples{}}
+}
+
+// LearnRoutes records the router's routes so toggles naming an unknown route
+// are rejected; call it after every route is registered
+func (h *ProfilingHandler) LearnRoutes(router *mux.Router) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			h.routes[method+" "+template] = true
+		}
+		return nil
+	})
+}
+
+// Pprof serves the net/http/pprof endpoints under /api/admin/debug/pprof/
+func (h *ProfilingHandler) Pprof(w http.ResponseWriter, r *http.Request) {
+	r.URL.Path = strings.TrimPrefix(r.URL.Path, "/api/admin")
+	switch strings.TrimPrefix(r.URL.Path, "/debug/pprof/") {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Index(w, r)
+	}
+}
+
+// GetProfiling returns the runtime profile rates and every route's sampling results
+func (h *ProfilingHandler) GetProfiling(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.state())
+}
+
+// SetHandlerSampling switches sampling on for a route, or changes it; results restart
+func (h *ProfilingHandler) SetHandlerSampling(w http.ResponseWriter, r *http.Request) {
+	var req HandlerSampling
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if method, path, ok := strings.Cut(strings.TrimSpace(req.Route), " "); ok {
+		req.Route = strings.ToUpper(method) + " " + strings.TrimSpace(path)
+	}
+	if !req.CPU && !req.Allocs {
+		http.Error(w, "cpu or allocs must be enabled; DELETE the route to stop sampling", http.StatusBadRequest)
+		return
+	}
+	if req.Rate < 0 || req.Rate > 1 {
+		http.Error(w, "rate must be between 0 and 1", http.StatusBadRequest)
+		return
+	}
+	if req.Rate == 0 {
+		req.Rate = 1
+	}
+
+	h.mutex.Lock()
+	if !h.routes[req.Route] {
+		h.mutex.Unlock()
+		http.Error(w, "Unknown route; expected a method and path template such as \"GET /api/patients\"", http.StatusBadRequest)
+		return
+	}
+	h.handlers[req.Route] = &handlerSamples{HandlerProfile: HandlerProfile{HandlerSampling: req, Since: time.Now()}}
+	h.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, h.state())
+}
+
+// ClearHandlerSampling stops sampling one route (?route=) or every route
+func (h *ProfilingHandler) ClearHandlerSampling(w http.ResponseWriter, r *http.Request) {
+	route := r.URL.Query().Get("route")
+
+	h.mutex.Lock()
+	if route == "" {
+		h.handlers = map[string]*handlerSamples{}
+	} else {
+		delete(h.handlers, route)
+	}
+	h.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, h.state())
+}
+
+// SetRuntimeProfiling sets the block and mutex profile rates served by pprof
+func (h *ProfilingHandler) SetRuntimeProfiling(w http.ResponseWriter, r *http.Request) {
+	var req RuntimeProfiling
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.BlockProfileRate < 0 || req.MutexProfileFraction < 0 {
+		http.Error(w, "blockProfileRate and mutexProfileFraction cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	h.mutex.Lock()
+	runtime.SetBlockProfileRate(req.BlockProfileRate)
+	runtime.SetMutexProfileFraction(req.MutexProfileFraction)
+	h.runtime = req
+	h.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, h.state())
+}
+
+// Middleware samples requests to routes with profiling switched on. CPU
+// sampling labels the request's goroutine so a CPU profile taken meanwhile can
+// be narrowed with `go tool pprof -tagfocus handler=...`. Allocation sampling
+// reads runtime memory stats around the request, which briefly stops the
+// world, so keep the rate low on busy routes.
+func (h *ProfilingHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		samples := h.lookup(r)
+		if samples == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h.mutex.Lock()
+		samples.Requests++
+		sampling := samples.HandlerSampling
+		h.mutex.Unlock()
+		if rand.Float64() >= sampling.Rate {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var before, after runtime.MemStats
+		if sampling.Allocs {
+			runtime.ReadMemStats(&before)
+		}
+		start := time.Now()
+		if sampling.CPU {
+			rpprof.Do(r.Context(), rpprof.Labels("handler", sampling.Route), func(ctx context.Context) {
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		} else {
+			next.ServeHTTP(w, r)
+		}
+		elapsed := float64(time.Since(start).Microseconds()) / 1000
+		if sampling.Allocs {
+			runtime.ReadMemStats(&after)
+		}
+
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		// The route may have been cleared or reset while the request ran
+		if h.handlers[sampling.Route] != samples {
+			return
+		}
+		samples.Sampled++
+		samples.totalMs += elapsed
+		if elapsed > samples.MaxMs {
+			samples.MaxMs = elapsed
+		}
+		if sampling.Allocs {
+			samples.measured++
+			samples.allocBytes += after.TotalAlloc - before.TotalAlloc
+			samples.allocsCount += after.Mallocs - before.Mallocs
+		}
+	})
+}
+
+func (h *ProfilingHandler) lookup(r *http.Request) *handlerSamples {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.handlers[r.Method+" "+template]
+}
+
+func (h *ProfilingHandler) state() ProfilingState {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	state := ProfilingState{Runtime: h.runtime, Handlers: []HandlerProfile{}}
+	for _, samples := range h.handlers {
+		profile := samples.HandlerProfile
+		if samples.Sampled > 0 {
+			profile.MeanMs = samples.totalMs / float64(samples.Sampled)
+		}
+		if samples.measured > 0 {
+			profile.AllocBytes = int64(samples.allocBytes) / samples.measured
+			profile.AllocsCount = int64(samples.allocsCount) / samples.measured
+		}
+		state.Handlers = append(state.Handlers, profile)
+	}
+	sort.Slice(state.Handlers, func(i, j int) bool { return state.Handlers[i].Route < state.Handlers[j].Route })
+	return state
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/prom"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultQuestionnaireExpiry is how long a questionnaire link stays open when no expiry is given
+const defaultQuestionnaireExpiry = 7 * 24 * time.Hour
+
+// QuestionnaireRepository interface for patient-reported outcome storage
+type QuestionnaireRepository interface {
+	CreateRequest(q *database.QuestionnaireRequest) error
+	GetRequest(id int) (*database.QuestionnaireRequest, error)
+	GetRequestByToken(token string) (*database.QuestionnaireRequest, error)
+	GetRequestsByPatient(hn string) ([]database.QuestionnaireRequest, error)
+	GetRequestsByVisit(visitID int) ([]database.QuestionnaireRequest, error)
+	GetOpenAlerts() ([]database.QuestionnaireRequest, error)
+	MarkSent(id int, channel string) error
+	Complete(id int, result database.QuestionnaireResult) error
+	Acknowledge(id int, by string) error
+}
+
+// PatientMessenger delivers a message to a patient over their messaging channel
+// and reports which channel was used
+type PatientMessenger interface {
+	SendToPatient(ctx context.Context, hn, message string) (string, error)
+}
+
+// QuestionnaireHandler handles patient-reported outcome questionnaire requests
+type QuestionnaireHandler struct {
+	repo          QuestionnaireRepository
+	patients      PatientRepository
+	messenger     PatientMessenger
+	publicBaseURL string
+}
+
+// NewQuestionnaireHandler creates a new questionnaire handler. With a nil
+// messenger, questionnaires stay pending and staff share the returned link.
+func NewQuestionnaireHandler(repo QuestionnaireRepository, patients PatientRepository, messenger PatientMessenger, publicBaseURL string) *QuestionnaireHandler {
+	return &QuestionnaireHandler{
+		repo:          repo,
+		patients:      patients,
+		messenger:     messenger,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+	}
+}
+
+// SendQuestionnaireRequest is the body for sending a questionnaire to a patient
+type SendQuestionnaireRequest struct {
+	Instrument    string `json:"instrument"`
+	VisitID       *int   `json:"visitId,omitempty"`
+	Timing        string `json:"timing"` // pre_visit/post_visit
+	ExpiresInDays int    `json:"expiresInDays,omitempty"`
+	CreatedBy     string `json:"createdBy"`
+}
+
+// InstrumentSummary describes an available questionnaire
+type InstrumentSummary struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Items    int    `json:"items"`
+	MaxScore int    `json:"maxScore"`
+}
+
+// crisisMessage is shown to a patient whose answers indicate risk of harm
+const crisisMessage = "หากคุณรู้สึกไม่ปลอดภัยหรือคิดทำร้ายตนเอง โทรสายด่วนสุขภาพจิต 1323 หรือ 1669 ได้ตลอด 24 ชั่วโมง ทางคลินิกจะติดต่อกลับโดยเร็ว"
+
+// GetInstruments lists the questionnaires that can be sent
+func (h *QuestionnaireHandler) GetInstruments(w http.ResponseWriter, r *http.Request) {
+	summaries := []InstrumentSummary{}
+	for _, code := range prom.Codes() {
+		instrument, _ := prom.Lookup(code)
+		summaries = append(summaries, InstrumentSummary{
+			Code:     instrument.Code,
+			Name:     instrument.Name,
+			Items:    len(instrument.Items),
+			MaxScore: instrument.MaxScore,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// SendQuestionnaire creates a questionnaire link for a patient and delivers it
+// through the messaging channel when one is configured
+func (h *QuestionnaireHandler) SendQuestionnaire(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var req SendQuestionnaireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	instrument, ok := prom.Lookup(req.Instrument)
+	if !ok {
+		http.Error(w, "Unknown instrument; expected one of "+strings.Join(prom.Codes(), ", "), http.StatusBadRequest)
+		return
+	}
+	if req.Timing != database.QuestionnairePreVisit && req.Timing != database.QuestionnairePostVisit {
+		http.Error(w, "timing must be pre_visit or post_visit", http.StatusBadRequest)
+		return
+	}
+	if req.CreatedBy == "" {
+		http.Error(w, "createdBy is required", http.StatusBadRequest)
+		return
+	}
+
+	token, err := prom.NewLinkToken()
+	if err != nil {
+		writeError(w, err, "Failed to create questionnaire link")
+		return
+	}
+	expiry := defaultQuestionnaireExpiry
+	if req.ExpiresInDays > 0 {
+		expiry = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+
+	questionnaire := database.QuestionnaireRequest{
+		PatientHN:  hn,
+		VisitID:    req.VisitID,
+		Instrument: instrument.Code,
+		Timing:     req.Timing,
+		Token:      token,
+		Status:     database.QuestionnairePending,
+		ExpiresAt:  time.Now().Add(expiry),
+		CreatedBy:  req.CreatedBy,
+	}
+	if err := h.repo.CreateRequest(&questionnaire); err != nil {
+		writeError(w, err, "Failed to create questionnaire")
+		return
+	}
+
+	link := h.publicBaseURL + "/public/questionnaires/" + token
+	response := map[string]interface{}{"link": link}
+	if h.messenger != nil {
+		message := "กรุณาตอบแบบสอบถาม " + instrument.Name + " ก่อนวันที่ " + questionnaire.ExpiresAt.Format("02/01/2006") + ": " + link
+		if channel, err := h.messenger.SendToPatient(r.Context(), hn, message); err != nil {
+			response["deliveryError"] = err.Error()
+		} else if err := h.repo.MarkSent(questionnaire.ID, channel); err != nil {
+			writeError(w, err, "Failed to record questionnaire delivery")
+			return
+		}
+	}
+
+	sent, err := h.repo.GetRequest(questionnaire.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve questionnaire")
+		return
+	}
+	response["questionnaire"] = sent
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// GetPatientQuestionnaires returns a patient's questionnaires and results, newest first
+func (h *QuestionnaireHandler) GetPatientQuestionnaires(w http.ResponseWriter, r *http.Request) {
+	questionnaires, err := h.repo.GetRequestsByPatient(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve questionnaires")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, questionnaires)
+}
+
+// GetVisitQuestionnaires returns the questionnaires attached to a visit
+func (h *QuestionnaireHandler) GetVisitQuestionnaires(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	questionnaires, err := h.repo.GetRequestsByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve questionnaires")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, questionnaires)
+}
+
+// GetAlerts returns high-risk results awaiting clinician follow-up
+func (h *QuestionnaireHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.repo.GetOpenAlerts()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve questionnaire alerts")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+// AcknowledgeAlert records the clinician who followed up a high-risk result
+func (h *QuestionnaireHandler) AcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid questionnaire ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		AcknowledgedBy string `json:"acknowledgedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.AcknowledgedBy == "" {
+		http.Error(w, "acknowledgedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Acknowledge(id, req.AcknowledgedBy); err != nil {
+		writeError(w, err, "Failed to acknowledge alert")
+		return
+	}
+
+	questionnaire, err := h.repo.GetRequest(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve questionnaire")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, questionnaire)
+}
+
+// GetPublicQuestionnaire returns the questionnaire a patient's link points to
+func (h *QuestionnaireHandler) GetPublicQuestionnaire(w http.ResponseWriter, r *http.Request) {
+	questionnaire, instrument, ok := h.loadOpenQuestionnaire(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"instrument": instrument,
+		"expiresAt":  questionnaire.ExpiresAt,
+	})
+}
+
+// SubmitPublicQuestionnaire scores a patient's answers and attaches the result to their record.
+// The patient only sees a confirmation, plus crisis contacts when the answers are high risk.
+func (h *QuestionnaireHandler) SubmitPublicQuestionnaire(w http.ResponseWriter, r *http.Request) {
+	questionnaire, instrument, ok := h.loadOpenQuestionnaire(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Answers []int `json:"answers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	result, err := instrument.Score(req.Answers)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.repo.Complete(questionnaire.ID, database.QuestionnaireResult{
+		Answers:     req.Answers,
+		Score:       result.Score,
+		Severity:    result.Severity,
+		HighRisk:    result.HighRisk,
+		RiskReasons: strings.Join(result.RiskReasons, "; "),
+	})
+	if err != nil {
+		http.Error(w, "This questionnaire has already been completed", http.StatusConflict)
+		return
+	}
+
+	response := map[string]interface{}{"status": database.QuestionnaireCompleted}
+	if result.HighRisk {
+		response["message"] = crisisMessage
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// loadOpenQuestionnaire resolves a patient link token, rejecting completed and expired links
+func (h *QuestionnaireHandler) loadOpenQuestionnaire(w http.ResponseWriter, r *http.Request) (*database.QuestionnaireRequest, *prom.Instrument, bool) {
+	questionnaire, err := h.repo.GetRequestByToken(mux.Vars(r)["token"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve questionnaire")
+		return nil, nil, false
+	}
+	if questionnaire.Status == database.QuestionnaireCompleted {
+		http.Error(w, "This questionnaire has already been completed", http.StatusConflict)
+		return nil, nil, false
+	}
+	if time.Now().After(questionnaire.ExpiresAt) {
+		http.Error(w, "This questionnaire link has expired", http.StatusGone)
+		return nil, nil, false
+	}
+
+	instrument, ok := prom.Lookup(questionnaire.Instrument)
+	if !ok {
+		http.Error(w, "Questionnaire instrument is no longer available", http.StatusGone)
+		return nil, nil, false
+	}
+
+	return questionnaire, instrument, true
+}
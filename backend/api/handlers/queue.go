@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/prom"
+	"clinic/backend/internal/reqctx"
+)
+
+// servicePointPattern keeps service point codes short and usable in URLs, e.g. "exam-1", "pharmacy"
+var servicePointPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// QueueRepository interface for queue storage
+type QueueRepository interface {
+	CheckIn(e *database.QueueEntry) error
+	GetByID(id int) (*database.QueueEntry, error)
+	GetByStatusToken(token string) (*database.QueueEntry, error)
+	List(f database.QueueFilter) ([]database.QueueEntry, error)
+	CallNext(branch, servicePoint, date, calledBy string, counter *string, doctorID int) (*database.QueueEntry, error)
+	UpdateStatus(id int, from, to, by string, counter *string) (*database.QueueEntry, error)
+}
+
+// QueueHandler handles the waiting room: check-in, calling patients and the queue board
+type QueueHandler struct {
+	repo         QueueRepository
+	patients     PatientRepository
+	visits       EncounterRepository
+	appointments AppointmentRepository
+	doctors      DoctorRepository
+	walkIns      *WalkInAssigner
+	line         LINEOutbox
+	// accommodations flags patients' accessibility needs on entries staff see
+	accommodations AccommodationLookup
+
+	publicBaseURL string // the externally reachable address printed tickets link to
+}
+
+// NewQueueHandler creates a new queue handler; walkIns, if not nil, assigns
+// walk-ins a doctor as they check in
+func NewQueueHandler(repo QueueRepository, patients PatientRepository, visits EncounterRepository, appointments AppointmentRepository, doctors DoctorRepository,
+	walkIns *WalkInAssigner, line LINEOutbox, accommodations AccommodationLookup, publicBaseURL string) *QueueHandler {
+	return &QueueHandler{repo: repo, patients: patients, visits: visits, appointments: appointments, doctors: doctors, walkIns: walkIns, line: line,
+		accommodations: accommodations, publicBaseURL: strings.TrimRight(publicBaseURL, "/")}
+}
+
+// queueBoard is what the waiting room screen and the service desks show
+type queueBoard struct {
+	Date       string                `json:"date"`
+	Waiting    []database.QueueEntry `json:"waiting"`    // by service point and number, each with how many are ahead
+	InProgress []database.QueueEntry `json:"inProgress"` // called and being seen
+}
+
+// CheckIn gives a patient the next queue number at a service point for the
+// request's branch today. Checking in for a scheduled appointment also marks
+// the appointment checked in, and the patient waits for its doctor; a walk-in
+// waits for the doctorId reception names or, by the branch's policy, one
+// assigned to them. The entry carries the patient's accessibility needs, if
+// any, and its ticket can then be printed (see GetQueueTicket).
+func (h *QueueHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PatientHN     string `json:"patientHn"`
+		ServicePoint  string `json:"servicePoint"`
+		VisitID       *int   `json:"visitId,omitempty"`
+		AppointmentID *int   `json:"appointmentId,omitempty"`
+		DoctorID      *int   `json:"doctorId,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.ServicePoint = strings.ToLower(strings.TrimSpace(req.ServicePoint))
+	if !servicePointPattern.MatchString(req.ServicePoint) {
+		http.Error(w, "servicePoint must be a short code such as registration, exam-1 or pharmacy", http.StatusBadRequest)
+		return
+	}
+
+	id, err := parseHN(req.PatientHN)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+	if req.VisitID != nil {
+		visit, err := h.visits.GetByID(*req.VisitID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve visit")
+			return
+		}
+		if visit.PatientHN != patient.HN {
+			http.Error(w, "Visit belongs to another patient", http.StatusBadRequest)
+			return
+		}
+	}
+	var appointment *database.Appointment
+	if req.AppointmentID != nil {
+		appointment, err = h.appointments.GetByID(*req.AppointmentID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve appointment")
+			return
+		}
+		if appointment.PatientHN != patient.HN {
+			http.Error(w, "Appointment belongs to another patient", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var doctor *database.Doctor
+	if req.DoctorID != nil {
+		doctor, err = h.doctors.GetByID(*req.DoctorID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve doctor")
+			return
+		}
+		if !doctor.Active {
+			http.Error(w, "Doctor is no longer active", http.StatusConflict)
+			return
+		}
+	}
+
+	entry, ok := h.checkIn(w, r, patient, req.ServicePoint, req.VisitID, appointment, doctor)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// GetQueue returns the request's branch's waiting and in-progress entries
+// today, optionally at one ?servicePoint=, each with the patient's
+// accessibility needs if any
+func (h *QueueHandler) GetQueue(w http.ResponseWriter, r *http.Request) {
+	day := today(r)
+	entries, err := h.repo.List(database.QueueFilter{
+		Branch:       reqctx.Branch(r.Context()),
+		QueueDate:    day,
+		ServicePoint: r.URL.Query().Get("servicePoint"),
+		Statuses:     []string{database.QueueWaiting, database.QueueInProgress},
+	})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve queue")
+		return
+	}
+
+	board := queueBoard{Date: day, Waiting: []database.QueueEntry{}, InProgress: []database.QueueEntry{}}
+	ahead := make(map[string]int) // service point -> waiting entries seen so far
+	for _, e := range entries {
+		e.Accommodation = accommodationFor(h.accommodations, e.PatientHN)
+		if e.Status == database.QueueInProgress {
+			board.InProgress = append(board.InProgress, e)
+			continue
+		}
+		n := ahead[e.ServicePoint]
+		e.Ahead = &n
+		ahead[e.ServicePoint]++
+		board.Waiting = append(board.Waiting, e)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, board)
+}
+
+// GetQueueEntry returns a queue entry with the patient's accessibility needs;
+// while waiting, with how many are ahead of it
+func (h *QueueHandler) GetQueueEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.loadEntry(w, r)
+	if !ok {
+		return
+	}
+
+	if entry.Status == database.QueueWaiting {
+		waiting, err := h.repo.List(database.QueueFilter{
+			Branch: entry.Branch, QueueDate: entry.QueueDate, ServicePoint: entry.ServicePoint,
+			Statuses: []string{database.QueueWaiting},
+		})
+		if err != nil {
+			writeError(w, err, "Failed to retrieve queue")
+			return
+		}
+		n := 0
+		for _, e := range waiting {
+			if e.Before(entry) {
+				n++
+			}
+		}
+		entry.Ahead = &n
+	}
+	entry.Accommodation = accommodationFor(h.accommodations, entry.PatientHN)
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// CallNext calls the most urgent, then lowest-numbered, waiting patient at a
+// service point to the caller's counter; for a doctorId, only patients
+// waiting for that doctor or for any. 404 when no one is waiting. A patient
+// who has linked a LINE account is told there too.
+func (h *QueueHandler) CallNext(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ServicePoint string  `json:"servicePoint"`
+		Counter      *string `json:"counter,omitempty"`
+		CalledBy     string  `json:"calledBy"`
+		DoctorID     int     `json:"doctorId,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.ServicePoint = strings.ToLower(strings.TrimSpace(req.ServicePoint))
+	if !servicePointPattern.MatchString(req.ServicePoint) {
+		http.Error(w, "servicePoint is required", http.StatusBadRequest)
+		return
+	}
+	if req.CalledBy == "" {
+		req.CalledBy = reqctx.UserName(r.Context())
+	}
+	if req.CalledBy == "" {
+		http.Error(w, "calledBy is required", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.repo.CallNext(reqctx.Branch(r.Context()), req.ServicePoint, today(r), req.CalledBy, req.Counter, req.DoctorID)
+	if err != nil {
+		writeError(w, err, "Failed to call next patient")
+		return
+	}
+	queueLINE(h.line, queueReady(entry))
+	entry.Accommodation = accommodationFor(h.accommodations, entry.PatientHN)
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// UpdateQueueStatus calls a particular patient (in_progress), finishes them
+// (done), marks them as not having come (skipped), sends them back to wait
+// with their number (waiting) or takes them out of the queue (cancelled).
+// Calling a patient tells them by LINE as CallNext does.
+func (h *QueueHandler) UpdateQueueStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status  string  `json:"status"`
+		Counter *string `json:"counter,omitempty"`
+		By      string  `json:"by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Status {
+	case database.QueueWaiting, database.QueueInProgress, database.QueueDone, database.QueueSkipped, database.QueueCancelled:
+	default:
+		http.Error(w, "status must be waiting, in_progress, done, skipped or cancelled", http.StatusBadRequest)
+		return
+	}
+	if req.By == "" {
+		req.By = reqctx.UserName(r.Context())
+	}
+	if req.Status == database.QueueInProgress && req.By == "" {
+		http.Error(w, "by is required to call a patient", http.StatusBadRequest)
+		return
+	}
+
+	entry, ok := h.loadEntry(w, r)
+	if !ok {
+		return
+	}
+	if !entry.CanMoveTo(req.Status) {
+		http.Error(w, "Cannot change a "+entry.Status+" queue entry to "+req.Status, http.StatusConflict)
+		return
+	}
+
+	updated, err := h.repo.UpdateStatus(entry.ID, entry.Status, req.Status, req.By, req.Counter)
+	if err != nil {
+		writeError(w, err, "Failed to update queue entry")
+		return
+	}
+	if updated.Status == database.QueueInProgress {
+		queueLINE(h.line, queueReady(updated))
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// checkIn queues a patient at a service point for the request's branch today,
+// optionally for a visit and an appointment, which is marked checked in. The
+// patient waits for the appointment's doctor, else the doctor given, else
+// the one walkIns assigns. The entry returned carries the patient's
+// accessibility needs. A patient already waiting or being seen there is a
+// conflict.
+func (h *QueueHandler) checkIn(w http.ResponseWriter, r *http.Request, patient *database.Patient, servicePoint string, visitID *int, appointment *database.Appointment, doctor *database.Doctor) (*database.QueueEntry, bool) {
+	token, err := prom.NewLinkToken()
+	if err != nil {
+		writeError(w, err, "Failed to check in")
+		return nil, false
+	}
+	entry := database.QueueEntry{
+		Branch:       reqctx.Branch(r.Context()),
+		ServicePoint: servicePoint,
+		QueueDate:    today(r),
+		PatientHN:    patient.HN,
+		PatientName:  patient.FullName,
+		VisitID:      visitID,
+		Status:       database.QueueWaiting,
+		StatusToken:  &token,
+	}
+	if appointment != nil {
+		entry.AppointmentID = &appointment.ID
+	}
+	queued, err := h.repo.List(database.QueueFilter{
+		Branch: entry.Branch, QueueDate: entry.QueueDate, ServicePoint: entry.ServicePoint, PatientHN: patient.HN,
+		Statuses: []string{database.QueueWaiting, database.QueueInProgress},
+	})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve queue")
+		return nil, false
+	}
+	if len(queued) > 0 {
+		http.Error(w, patient.HN+" is already in the "+entry.ServicePoint+" queue", http.StatusConflict)
+		return nil, false
+	}
+	switch {
+	case appointment != nil && appointment.DoctorID != nil:
+		entry.DoctorID, entry.DoctorName = appointment.DoctorID, appointment.DoctorName
+	case doctor != nil:
+		entry.DoctorID, entry.DoctorName = &doctor.ID, doctor.FullName
+	case appointment == nil && h.walkIns != nil:
+		assigned, err := h.walkIns.assign(r, entry.ServicePoint)
+		if err != nil {
+			writeError(w, err, "Failed to assign a doctor")
+			return nil, false
+		}
+		if assigned != nil {
+			entry.DoctorID, entry.DoctorName = &assigned.ID, assigned.FullName
+		}
+	}
+
+	if err := h.repo.CheckIn(&entry); err != nil {
+		writeError(w, err, "Failed to check in")
+		return nil, false
+	}
+	if appointment != nil && appointment.Status == database.AppointmentScheduled {
+		if _, err := h.appointments.UpdateStatus(appointment.ID, appointment.Status, database.AppointmentCheckedIn, nil); err != nil {
+			writeError(w, err, "Failed to check in appointment")
+			return nil, false
+		}
+	}
+	entry.Accommodation = accommodationFor(h.accommodations, patient.HN)
+	return &entry, true
+}
+
+func (h *QueueHandler) loadEntry(w http.ResponseWriter, r *http.Request) (*database.QueueEntry, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid queue entry ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	entry, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve queue entry")
+		return nil, false
+	}
+	return entry, true
+}
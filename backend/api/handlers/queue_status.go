@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"clinic/backend/internal/database"
+)
+
+const (
+	queueStatusPoll      = 5 * time.Second  // how often a status stream looks for changes
+	queueStatusKeepalive = 30 * time.Second // idle streams get a comment so proxies keep them open
+)
+
+// queueStatus is what a waiting patient sees on the status page their ticket
+// links to. It names no patients, the patient's own included.
+type queueStatus struct {
+	ServicePoint string  `json:"servicePoint"`
+	QueueDate    string  `json:"queueDate"`
+	Number       int     `json:"number"`
+	Status       string  `json:"status"`
+	Ahead        *int    `json:"ahead,omitempty"`      // while waiting
+	NowServing   *int    `json:"nowServing,omitempty"` // the number most recently called at the service point
+	Counter      *string `json:"counter,omitempty"`    // where to go, once called
+}
+
+// GetPublicQueueStatus shows the queue entry a printed ticket's QR code links
+// to: its number and status, how many are waiting ahead of it and the number
+// being served at its service point. Tickets only work on the day they were
+// printed. A client that accepts text/event-stream gets the status as
+// server-sent "status" events instead, each time it changes, until the
+// patient is done or leaves the queue.
+func (h *QueueHandler) GetPublicQueueStatus(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+	status, ok := h.loadQueueStatus(w, r, token)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	poll := time.NewTicker(queueStatusPoll)
+	defer poll.Stop()
+	var last []byte
+	idle := time.Now()
+	for {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return
+		}
+		if string(data) != string(last) {
+			fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+			last, idle = data, time.Now()
+		} else if time.Since(idle) >= queueStatusKeepalive {
+			fmt.Fprint(w, ": keepalive\n\n")
+			idle = time.Now()
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		if status.Status == database.QueueDone || status.Status == database.QueueCancelled {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		}
+		entry, err := h.repo.GetByStatusToken(token)
+		if err != nil {
+			return
+		}
+		if status, err = h.statusOf(entry); err != nil {
+			return
+		}
+	}
+}
+
+// loadQueueStatus loads the status of the entry a token opens, writing the error
+// response when it cannot
+func (h *QueueHandler) loadQueueStatus(w http.ResponseWriter, r *http.Request, token string) (*queueStatus, bool) {
+	entry, err := h.repo.GetByStatusToken(token)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve queue entry")
+		return nil, false
+	}
+	if entry.QueueDate < today(r) {
+		http.Error(w, "This ticket has expired; please ask at the front desk", http.StatusGone)
+		return nil, false
+	}
+
+	status, err := h.statusOf(entry)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve queue")
+		return nil, false
+	}
+	return status, true
+}
+
+func (h *QueueHandler) statusOf(entry *database.QueueEntry) (*queueStatus, error) {
+	status := &queueStatus{
+		ServicePoint: entry.ServicePoint,
+		QueueDate:    entry.QueueDate,
+		Number:       entry.Number,
+		Status:       entry.Status,
+	}
+	if entry.Status == database.QueueInProgress {
+		status.Counter = entry.Counter
+	}
+	if entry.Status != database.QueueWaiting && entry.Status != database.QueueInProgress {
+		return status, nil
+	}
+
+	queue, err := h.repo.List(database.QueueFilter{
+		Branch: entry.Branch, QueueDate: entry.QueueDate, ServicePoint: entry.ServicePoint,
+		Statuses: []string{database.QueueWaiting, database.QueueInProgress},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ahead := 0
+	var serving *database.QueueEntry
+	for i := range queue {
+		e := &queue[i]
+		switch {
+		case e.Status == database.QueueWaiting && e.Before(entry):
+			ahead++
+		case e.Status == database.QueueInProgress && e.CalledAt != nil && (serving == nil || e.CalledAt.After(*serving.CalledAt)):
+			serving = e
+		}
+	}
+	if entry.Status == database.QueueWaiting {
+		status.Ahead = &ahead
+	}
+	if serving != nil {
+		status.NowServing = &serving.Number
+	}
+	return status, nil
+}
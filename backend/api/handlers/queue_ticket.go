@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/escpos"
+	"clinic/backend/internal/reqctx"
+)
+
+// defaultServiceTime is how long each patient is expected to take at a
+// service point until someone has been seen there today
+const defaultServiceTime = 10 * time.Minute
+
+// GetQueueTicket returns the queue entry's ticket as ESC/POS commands, for a
+// kiosk to write straight to its thermal printer: the queue number, how many
+// are waiting ahead and roughly when the patient will be called, and a QR
+// code of the entry's status page. The estimate is the number ahead times the
+// average time patients have taken at the service point today.
+func (h *QueueHandler) GetQueueTicket(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.loadEntry(w, r)
+	if !ok {
+		return
+	}
+	if entry.Status != database.QueueWaiting {
+		http.Error(w, "Tickets are only printed for waiting queue entries", http.StatusConflict)
+		return
+	}
+
+	ticket, err := h.printTicket(r, entry)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve queue")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="queue-%d.bin"`, entry.Number))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(ticket.Bytes)
+}
+
+// queueTicket is a waiting entry's printed ticket and what it says
+type queueTicket struct {
+	Ahead       int
+	EstimatedAt time.Time // roughly when the patient will be called
+	Bytes       []byte    // ESC/POS commands
+}
+
+// printTicket lays out a waiting entry's ticket
+func (h *QueueHandler) printTicket(r *http.Request, entry *database.QueueEntry) (*queueTicket, error) {
+	today, err := h.repo.List(database.QueueFilter{
+		Branch: entry.Branch, QueueDate: entry.QueueDate, ServicePoint: entry.ServicePoint,
+		Statuses: []string{database.QueueWaiting, database.QueueDone},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ahead, seen := 0, 0
+	var total time.Duration
+	for i := range today {
+		e := &today[i]
+		switch {
+		case e.Status == database.QueueWaiting && e.Before(entry):
+			ahead++
+		case e.Status == database.QueueDone && e.CalledAt != nil && e.FinishedAt != nil:
+			total += e.FinishedAt.Sub(*e.CalledAt)
+			seen++
+		}
+	}
+	each := defaultServiceTime
+	if seen > 0 {
+		each = total / time.Duration(seen)
+	}
+	now := localNow(r)
+	estimate := now.Add(time.Duration(ahead) * each)
+
+	ticket := escpos.New().Align(escpos.AlignCenter).
+		Line(strings.ToUpper(entry.ServicePoint)).
+		Line("Queue number").
+		Bold(true).Size(4, 4).Line(fmt.Sprint(entry.Number)).Size(1, 1).Bold(false).
+		Line(entry.CheckedInAt.In(reqctx.Location(r.Context())).Format("02/01/2006 15:04")).
+		Feed(1).
+		Line(fmt.Sprintf("Waiting ahead: %d", ahead)).
+		Line("Estimated call: about " + estimate.Format("15:04"))
+	if entry.StatusToken != nil {
+		ticket.Feed(1).QR(h.statusURL(*entry.StatusToken), 6).Line("Scan to follow your queue")
+	}
+	ticket.Feed(3).Cut()
+
+	return &queueTicket{Ahead: ahead, EstimatedAt: estimate, Bytes: ticket.Bytes()}, nil
+}
+
+// statusURL is the address of a queue entry's public status page
+func (h *QueueHandler) statusURL(token string) string {
+	return h.publicBaseURL + "/public/queue/" + token
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"clinic/backend/internal/database"
+)
+
+// RecallRepository interface for lot recall storage
+type RecallRepository interface {
+	Create(recall *database.LotRecall) error
+	GetAll() ([]database.LotRecall, error)
+	GetByID(id int) (*database.LotRecall, error)
+	IsLotRecalled(itemID int, lotNumber string) (bool, error)
+	CreateNotifications(notifications []database.RecallNotification) error
+	GetNotifications(recallID int) ([]database.RecallNotification, error)
+}
+
+// LotDispenseLookup finds who received stock from a given lot
+type LotDispenseLookup interface {
+	DispensedFromLot(itemID int, lotNumber string) ([]database.LotDispense, error)
+}
+
+// RecallHandler handles manufacturer lot recall requests
+type RecallHandler struct {
+	repo      RecallRepository
+	patients  PatientRepository
+	dispenses LotDispenseLookup
+}
+
+// NewRecallHandler creates a new recall handler.
+// A nil dispense lookup means no affected patients can be listed.
+func NewRecallHandler(repo RecallRepository, patients PatientRepository, dispenses LotDispenseLookup) *RecallHandler {
+	return &RecallHandler{repo: repo, patients: patients, dispenses: dispenses}
+}
+
+// AffectedPatient is a patient who was dispensed stock from a recalled lot
+type AffectedPatient struct {
+	HN          string                 `json:"hn"`
+	FullName    string                 `json:"fullName"`
+	Phone       *string                `json:"phone,omitempty"`
+	Dispenses   []database.LotDispense `json:"dispenses"`
+	TotalIssued float64                `json:"totalIssued"`
+}
+
+// CreateRecall flags a lot as recalled, which blocks further dispensing from it
+func (h *RecallHandler) CreateRecall(w http.ResponseWriter, r *http.Request) {
+	var recall database.LotRecall
+	if err := json.NewDecoder(r.Body).Decode(&recall); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if recall.ItemID == 0 || recall.LotNumber == "" {
+		http.Error(w, "itemId and lotNumber are required", http.StatusBadRequest)
+		return
+	}
+
+	recalled, err := h.repo.IsLotRecalled(recall.ItemID, recall.LotNumber)
+	if err != nil {
+		writeError(w, err, "Failed to check lot recall")
+		return
+	}
+	if recalled {
+		http.Error(w, "Lot is already recalled", http.StatusConflict)
+		return
+	}
+
+	if err := h.repo.Create(&recall); err != nil {
+		writeError(w, err, "Failed to create lot recall")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, recall)
+}
+
+// GetRecalls returns all lot recalls
+func (h *RecallHandler) GetRecalls(w http.ResponseWriter, r *http.Request) {
+	recalls, err := h.repo.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve lot recalls")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, recalls)
+}
+
+// GetRecall returns a single lot recall
+func (h *RecallHandler) GetRecall(w http.ResponseWriter, r *http.Request) {
+	recall, ok := h.loadRecall(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, recall)
+}
+
+// CheckLot reports whether ?itemId=&lotNumber= is blocked from dispensing
+func (h *RecallHandler) CheckLot(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.Atoi(r.URL.Query().Get("itemId"))
+	lotNumber := r.URL.Query().Get("lotNumber")
+	if err != nil || lotNumber == "" {
+		http.Error(w, "itemId and lotNumber are required", http.StatusBadRequest)
+		return
+	}
+
+	recalled, err := h.repo.IsLotRecalled(itemID, lotNumber)
+	if err != nil {
+		writeError(w, err, "Failed to check lot recall")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"itemId":    itemID,
+		"lotNumber": lotNumber,
+		"recalled":  recalled,
+	})
+}
+
+// GetAffectedPatients lists every patient dispensed from the recalled lot with contact details
+func (h *RecallHandler) GetAffectedPatients(w http.ResponseWriter, r *http.Request) {
+	recall, ok := h.loadRecall(w, r)
+	if !ok {
+		return
+	}
+
+	affected, err := h.affectedPatients(recall)
+	if err != nil {
+		writeError(w, err, "Failed to list affected patients")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, affected)
+}
+
+// CreateNotifications generates the recall notification batch for all affected patients
+func (h *RecallHandler) CreateNotifications(w http.ResponseWriter, r *http.Request) {
+	recall, ok := h.loadRecall(w, r)
+	if !ok {
+		return
+	}
+
+	existing, err := h.repo.GetNotifications(recall.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve recall notifications")
+		return
+	}
+	if len(existing) > 0 {
+		http.Error(w, "Notification batch already generated for this recall", http.StatusConflict)
+		return
+	}
+
+	affected, err := h.affectedPatients(recall)
+	if err != nil {
+		writeError(w, err, "Failed to list affected patients")
+		return
+	}
+
+	message := fmt.Sprintf("แจ้งเตือนจากคลินิก: ยา %s ล็อต %s ที่ท่านได้รับถูกเรียกคืนโดยผู้ผลิต กรุณาหยุดใช้และติดต่อคลินิก",
+		recall.ItemName, recall.LotNumber)
+
+	notifications := make([]database.RecallNotification, 0, len(affected))
+	for _, p := range affected {
+		notifications = append(notifications, database.RecallNotification{
+			RecallID:    recall.ID,
+			PatientHN:   p.HN,
+			PatientName: p.FullName,
+			Phone:       p.Phone,
+			Message:     message,
+			Status:      database.NotificationPending,
+		})
+	}
+
+	if err := h.repo.CreateNotifications(notifications); err != nil {
+		writeError(w, err, "Failed to create recall notifications")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, notifications)
+}
+
+// GetNotifications returns the notification batch of a recall
+func (h *RecallHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	recall, ok := h.loadRecall(w, r)
+	if !ok {
+		return
+	}
+
+	notifications, err := h.repo.GetNotifications(recall.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve recall notifications")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notifications)
+}
+
+func (h *RecallHandler) loadRecall(w http.ResponseWriter, r *http.Request) (*database.LotRecall, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid recall ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	recall, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve lot recall")
+		return nil, false
+	}
+
+	return recall, true
+}
+
+// affectedPatients groups the lot's dispenses by patient and attaches contact details
+func (h *RecallHandler) affectedPatients(recall *database.LotRecall) ([]AffectedPatient, error) {
+	affected := []AffectedPatient{}
+	if h.dispenses == nil {
+		return affected, nil
+	}
+
+	dispenses, err := h.dispenses.DispensedFromLot(recall.ItemID, recall.LotNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	byHN := make(map[string]*AffectedPatient)
+	for _, d := range dispenses {
+		p, ok := byHN[d.PatientHN]
+		if !ok {
+			p = &AffectedPatient{HN: d.PatientHN}
+			if id, err := parseHN(d.PatientHN); err == nil {
+				if patient, err := h.patients.GetByID(id); err == nil {
+					p.FullName = patient.FullName
+					p.Phone = patient.Phone
+				}
+			}
+			byHN[d.PatientHN] = p
+		}
+		p.Dispenses = append(p.Dispenses, d)
+		p.TotalIssued += d.Quantity
+	}
+
+	for _, p := range byHN {
+		affected = append(affected, *p)
+	}
+	sort.Slice(affected, func(i, j int) bool {
+		return affected[i].HN < affected[j].HN
+	})
+
+	return affected, nil
+}
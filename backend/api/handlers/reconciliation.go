@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reconciliation"
+	"clinic/backend/internal/reqctx"
+)
+
+// maxStatementSize caps uploaded bank statement files (10 MB)
+const maxStatementSize = 10 << 20
+
+// ReconciliationRepository interface for bank reconciliation storage
+type ReconciliationRepository interface {
+	CreateImport(imp *database.BankStatementImport, txns []database.BankTransaction) error
+	ListImports() ([]database.BankStatementImport, error)
+	ListTransactions(status string) ([]database.BankTransaction, error)
+	GetTransaction(id int) (*database.BankTransaction, error)
+	UpdateMatch(t *database.BankTransaction) error
+	UpdateImportMatched(imp *database.BankStatementImport) error
+}
+
+// ReconciliationHandler handles bank statement import and matching requests
+type ReconciliationHandler struct {
+	repo   ReconciliationRepository
+	ledger reconciliation.InvoiceLedger
+}
+
+// NewReconciliationHandler creates a new reconciliation handler.
+// A nil ledger disables auto-matching and manual matching.
+func NewReconciliationHandler(repo ReconciliationRepository, ledger reconciliation.InvoiceLedger) *ReconciliationHandler {
+	return &ReconciliationHandler{repo: repo, ledger: ledger}
+}
+
+// ImportStatement parses an uploaded statement CSV and auto-matches its lines to open invoices.
+// Accepts a multipart "file" field or a raw text/csv body. Sandbox API keys
+// cannot import, since matched lines are posted as payments.
+func (h *ReconciliationHandler) ImportStatement(w http.ResponseWriter, r *http.Request) {
+	if refuseSandboxPayments(w, r) {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxStatementSize)
+
+	var src io.Reader = r.Body
+	fileName := r.URL.Query().Get("fileName")
+	if file, header, err := r.FormFile("file"); err == nil {
+		defer file.Close()
+		src = file
+		fileName = header.Filename
+	}
+	if fileName == "" {
+		fileName = "statement.csv"
+	}
+
+	txns, err := reconciliation.ParseStatementCSV(src)
+	if err != nil {
+		http.Error(w, "Invalid statement: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imp := database.BankStatementImport{FileName: fileName, RowCount: len(txns)}
+	if h.ledger != nil {
+		invoices, err := h.ledger.OpenInvoices()
+		if err != nil {
+			writeError(w, err, "Failed to load open invoices")
+			return
+		}
+		imp.MatchedCount = autoMatch(txns, invoices)
+	}
+
+	if err := h.repo.CreateImport(&imp, txns); err != nil {
+		writeError(w, err, "Failed to store statement")
+		return
+	}
+
+	// Post payments for auto-matched lines; revert the match if posting fails
+	matched := imp.MatchedCount
+	for i := range txns {
+		t := &txns[i]
+		if t.MatchedInvoiceID == nil {
+			continue
+		}
+		if err := h.ledger.RecordBankPayment(*t.MatchedInvoiceID, t.Amount, t.Reference, t.TransactionDate); err != nil {
+			reverted := *t
+			reverted.MatchStatus = database.MatchStatusUnmatched
+			reverted.MatchedInvoiceID = nil
+			reverted.MatchedAt = nil
+			if err := h.repo.UpdateMatch(&reverted); err != nil {
+				log.Printf("Failed to unmatch bank transaction %d after its payment failed: %v", t.ID, err)
+				continue
+			}
+			*t = reverted
+			imp.MatchedCount--
+		}
+	}
+	if imp.MatchedCount != matched {
+		if err := h.repo.UpdateImportMatched(&imp); err != nil {
+			writeError(w, err, "Failed to update statement import")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"import":       imp,
+		"transactions": txns,
+	})
+}
+
+// refuseSandboxPayments answers 403 to a sandbox API key, reporting whether it
+// did; bank lines are real money and cannot be simulated against an invoice
+func refuseSandboxPayments(w http.ResponseWriter, r *http.Request) bool {
+	if !reqctx.Sandbox(r.Context()) {
+		return false
+	}
+	http.Error(w, "Sandbox API keys cannot post bank payments", http.StatusForbidden)
+	return true
+}
+
+// autoMatch marks unambiguous transactions as matched, using each invoice at most once
+func autoMatch(txns []database.BankTransaction, invoices []reconciliation.OpenInvoice) int {
+	matched := 0
+	now := time.Now()
+	for i := range txns {
+		inv, ok := reconciliation.AutoMatch(txns[i], invoices)
+		if !ok {
+			continue
+		}
+
+		invoiceID := inv.ID
+		txns[i].MatchStatus = database.MatchStatusAuto
+		txns[i].MatchedInvoiceID = &invoiceID
+		txns[i].MatchedAt = &now
+		matched++
+
+		for j := range invoices {
+			if invoices[j].ID == invoiceID {
+				invoices = append(invoices[:j], invoices[j+1:]...)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// GetImports returns all statement imports
+func (h *ReconciliationHandler) GetImports(w http.ResponseWriter, r *http.Request) {
+	imports, err := h.repo.ListImports()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve statement imports")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, imports)
+}
+
+// GetTransactions returns bank transactions, filtered by ?status=unmatched|auto|manual
+func (h *ReconciliationHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	txns, err := h.repo.ListTransactions(r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve bank transactions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, txns)
+}
+
+// GetCandidates returns open invoices that could match an unmatched transaction
+func (h *ReconciliationHandler) GetCandidates(w http.ResponseWriter, r *http.Request) {
+	if h.ledger == nil {
+		http.Error(w, "Invoice ledger not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	txn, err := h.repo.GetTransaction(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve bank transaction")
+		return
+	}
+
+	invoices, err := h.ledger.OpenInvoices()
+	if err != nil {
+		writeError(w, err, "Failed to load open invoices")
+		return
+	}
+
+	candidates := reconciliation.Candidates(*txn, invoices)
+	if candidates == nil {
+		candidates = []reconciliation.OpenInvoice{}
+	}
+	writeJSON(w, http.StatusOK, candidates)
+}
+
+// MatchTransaction manually matches a leftover transaction to an invoice,
+// posting it as a payment; sandbox API keys cannot match
+func (h *ReconciliationHandler) MatchTransaction(w http.ResponseWriter, r *http.Request) {
+	if refuseSandboxPayments(w, r) {
+		return
+	}
+	if h.ledger == nil {
+		http.Error(w, "Invoice ledger not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		InvoiceID int `json:"invoiceId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.InvoiceID == 0 {
+		http.Error(w, "invoiceId is required", http.StatusBadRequest)
+		return
+	}
+
+	txn, err := h.repo.GetTransaction(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve bank transaction")
+		return
+	}
+	if txn.MatchStatus != database.MatchStatusUnmatched {
+		http.Error(w, "Bank transaction is already matched", http.StatusConflict)
+		return
+	}
+
+	// Record the match before posting, as imports do, so a line whose match
+	// could not be stored is never paid; revert it if posting fails
+	unmatched := *txn
+	now := time.Now()
+	txn.MatchStatus = database.MatchStatusManual
+	txn.MatchedInvoiceID = &req.InvoiceID
+	txn.MatchedAt = &now
+	if err := h.repo.UpdateMatch(txn); err != nil {
+		writeError(w, err, "Failed to update bank transaction")
+		return
+	}
+
+	if err := h.ledger.RecordBankPayment(req.InvoiceID, txn.Amount, txn.Reference, txn.TransactionDate); err != nil {
+		if err := h.repo.UpdateMatch(&unmatched); err != nil {
+			log.Printf("Failed to unmatch bank transaction %d after its payment failed: %v", txn.ID, err)
+		}
+		writeError(w, err, "Failed to record payment")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, txn)
+}
+
+// GetReport returns the reconciliation report for ?from=YYYY-MM-DD&to=YYYY-MM-DD (inclusive)
+func (h *ReconciliationHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := dateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	txns, err := h.repo.ListTransactions("")
+	if err != nil {
+		writeError(w, err, "Failed to retrieve bank transactions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reconciliation.BuildReport(txns, from, to))
+}
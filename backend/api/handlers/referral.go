@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// ReferralRepository interface for referral storage
+type ReferralRepository interface {
+	Create(ref *database.Referral) error
+	GetByID(id int) (*database.Referral, error)
+	List(f database.ReferralFilter) ([]database.Referral, error)
+	UpdateStatus(id int, from, to string, outcome *string) (*database.Referral, error)
+	AddDocument(d *database.ReferralDocument) error
+	RemoveDocument(referralID, id int) error
+}
+
+// ReferralHandler handles referrals to and from other facilities
+type ReferralHandler struct {
+	repo     ReferralRepository
+	patients PatientRepository
+	visits   EncounterRepository
+}
+
+// NewReferralHandler creates a new referral handler
+func NewReferralHandler(repo ReferralRepository, patients PatientRepository, visits EncounterRepository) *ReferralHandler {
+	return &ReferralHandler{repo: repo, patients: patients, visits: visits}
+}
+
+// CreateVisitReferral refers a patient out from a visit. referredBy defaults
+// to the visit's doctor and reason to its diagnosis.
+func (h *ReferralHandler) CreateVisitReferral(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+
+	var referral database.Referral
+	if err := json.NewDecoder(r.Body).Decode(&referral); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	referral.PatientHN = visit.PatientHN
+	referral.VisitID = &visit.ID
+	if referral.Direction == "" {
+		referral.Direction = database.ReferralOutbound
+	}
+	if referral.ReferredBy == "" {
+		referral.ReferredBy = visit.DoctorName
+	}
+	if strings.TrimSpace(referral.Reason) == "" && visit.Diagnosis != nil {
+		referral.Reason = *visit.Diagnosis
+	}
+
+	h.create(w, &referral)
+}
+
+// CreatePatientReferral records a referral that is not tied to a visit,
+// typically an inbound one received before the patient is seen
+func (h *ReferralHandler) CreatePatientReferral(w http.ResponseWriter, r *http.Request) {
+	id, err := parseHN(mux.Vars(r)["hn"])
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var referral database.Referral
+	if err := json.NewDecoder(r.Body).Decode(&referral); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	referral.PatientHN = patient.HN
+	referral.VisitID = nil
+
+	h.create(w, &referral)
+}
+
+// GetPatientReferrals lists a patient's referrals, newest first
+func (h *ReferralHandler) GetPatientReferrals(w http.ResponseWriter, r *http.Request) {
+	h.list(w, database.ReferralFilter{PatientHN: mux.Vars(r)["hn"]})
+}
+
+// GetVisitReferrals lists the referrals written in a visit
+func (h *ReferralHandler) GetVisitReferrals(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	h.list(w, database.ReferralFilter{VisitID: visitID})
+}
+
+// GetReferrals lists referrals for tracking (?direction=, ?status=, ?facility=), newest first
+func (h *ReferralHandler) GetReferrals(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	h.list(w, database.ReferralFilter{Direction: q.Get("direction"), Status: q.Get("status"), Facility: q.Get("facility")})
+}
+
+// GetReferral returns a referral with its documents
+func (h *ReferralHandler) GetReferral(w http.ResponseWriter, r *http.Request) {
+	referral, ok := h.loadReferral(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, referral)
+}
+
+// UpdateReferralStatus accepts, declines, completes or cancels a referral;
+// outcome records what came of it
+func (h *ReferralHandler) UpdateReferralStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status  string  `json:"status"`
+		Outcome *string `json:"outcome,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Status {
+	case database.ReferralAccepted, database.ReferralDeclined, database.ReferralCompleted, database.ReferralCancelled:
+	default:
+		http.Error(w, "status must be accepted, declined, completed or cancelled", http.StatusBadRequest)
+		return
+	}
+	if req.Outcome != nil {
+		*req.Outcome = strings.TrimSpace(*req.Outcome)
+		if *req.Outcome == "" {
+			req.Outcome = nil
+		}
+	}
+	if req.Status == database.ReferralCompleted && req.Outcome == nil {
+		http.Error(w, "outcome is required to complete a referral", http.StatusBadRequest)
+		return
+	}
+
+	referral, ok := h.loadReferral(w, r)
+	if !ok {
+		return
+	}
+	if !referral.CanMoveTo(req.Status) {
+		http.Error(w, "Cannot change a "+referral.Status+" referral to "+req.Status, http.StatusConflict)
+		return
+	}
+
+	updated, err := h.repo.UpdateStatus(referral.ID, referral.Status, req.Status, req.Outcome)
+	if err != nil {
+		writeError(w, err, "Failed to update referral status")
+		return
+	}
+	updated.Documents = referral.Documents
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// AddReferralDocument attaches a document, such as the referral letter or the
+// reply, by name and URL; addedBy defaults to the signed-in user
+func (h *ReferralHandler) AddReferralDocument(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid referral ID", http.StatusBadRequest)
+		return
+	}
+
+	var document database.ReferralDocument
+	if err := json.NewDecoder(r.Body).Decode(&document); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	document.ReferralID = id
+	document.Name = strings.TrimSpace(document.Name)
+	document.URL = strings.TrimSpace(document.URL)
+	if document.AddedBy == "" {
+		document.AddedBy = reqctx.UserName(r.Context())
+	}
+	if document.Name == "" || document.URL == "" || document.AddedBy == "" {
+		http.Error(w, "name, url and addedBy are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.AddDocument(&document); err != nil {
+		writeError(w, err, "Failed to add referral document")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, document)
+}
+
+// RemoveReferralDocument detaches a document added by mistake
+func (h *ReferralHandler) RemoveReferralDocument(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid referral ID", http.StatusBadRequest)
+		return
+	}
+	documentID, err := pathID(r, "documentId")
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.RemoveDocument(id, documentID); err != nil {
+		writeError(w, err, "Failed to remove referral document")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ReferralHandler) create(w http.ResponseWriter, referral *database.Referral) {
+	if msg := checkReferral(referral); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	referral.Status = database.ReferralPending
+	referral.Outcome = nil
+
+	if err := h.repo.Create(referral); err != nil {
+		writeError(w, err, "Failed to create referral")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, referral)
+}
+
+func (h *ReferralHandler) list(w http.ResponseWriter, filter database.ReferralFilter) {
+	referrals, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve referrals")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, referrals)
+}
+
+func (h *ReferralHandler) loadReferral(w http.ResponseWriter, r *http.Request) (*database.Referral, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid referral ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	referral, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve referral")
+		return nil, false
+	}
+	return referral, true
+}
+
+// checkReferral trims and validates a new referral, returning what is wrong with it
+func checkReferral(ref *database.Referral) string {
+	ref.Facility = strings.TrimSpace(ref.Facility)
+	ref.Reason = strings.TrimSpace(ref.Reason)
+	ref.ReferredBy = strings.TrimSpace(ref.ReferredBy)
+	if ref.Facility == "" || ref.Reason == "" || ref.ReferredBy == "" {
+		return "facility, reason and referredBy are required"
+	}
+	if ref.Direction != database.ReferralOutbound && ref.Direction != database.ReferralInbound {
+		return "direction must be outbound or inbound"
+	}
+	if ref.Urgency == "" {
+		ref.Urgency = database.UrgencyRoutine
+	}
+	valid := false
+	for _, u := range database.ReferralUrgencies {
+		valid = valid || ref.Urgency == u
+	}
+	if !valid {
+		return "urgency must be one of " + strings.Join(database.ReferralUrgencies, ", ")
+	}
+	if ref.Department != nil {
+		if d := strings.TrimSpace(*ref.Department); d != "" {
+			ref.Department = &d
+		} else {
+			ref.Department = nil
+		}
+	}
+	return ""
+}
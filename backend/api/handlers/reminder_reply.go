@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reminder"
+	"clinic/backend/internal/reqctx"
+)
+
+// ReminderReplyRepository interface for inbound reminder reply storage
+type ReminderReplyRepository interface {
+	Create(m *database.ReminderReply) error
+	GetByID(id int) (*database.ReminderReply, error)
+	List(status string) ([]database.ReminderReply, error)
+	Resolve(id int, status string, appointmentID *int, by string, note *string) (*database.ReminderReply, error)
+}
+
+// ReminderReplyHandler confirms or cancels appointments from patients' SMS
+// and LINE replies to reminders, and keeps the replies it cannot act on for
+// staff to review
+type ReminderReplyHandler struct {
+	repo         ReminderReplyRepository
+	reminders    AppointmentReminderRepository
+	appointments AppointmentRepository
+}
+
+// NewReminderReplyHandler creates a new reminder reply handler
+func NewReminderReplyHandler(repo ReminderReplyRepository, reminders AppointmentReminderRepository, appointments AppointmentRepository) *ReminderReplyHandler {
+	return &ReminderReplyHandler{repo: repo, reminders: reminders, appointments: appointments}
+}
+
+// ReceiveReply takes an inbound message from a messaging gateway. A confirm
+// or cancel keyword answering the sender's latest reminder for an upcoming
+// appointment is applied at once; anything else waits for staff review.
+func (h *ReminderReplyHandler) ReceiveReply(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Channel string `json:"channel"`
+		From    string `json:"from"`
+		Text    string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Channel != database.ReminderLINE && req.Channel != database.ReminderSMS {
+		http.Error(w, "channel must be line or sms", http.StatusBadRequest)
+		return
+	}
+	phone := localPhone(req.From)
+	if phone == "" {
+		http.Error(w, "from must be the sender's phone number", http.StatusBadRequest)
+		return
+	}
+
+	reply := database.ReminderReply{
+		Channel: req.Channel,
+		Phone:   phone,
+		Text:    req.Text,
+		Intent:  reminder.ParseReply(req.Text),
+		Status:  database.ReplyReview,
+	}
+	appointment, err := h.remindedAppointment(req.Channel, phone)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment")
+		return
+	}
+	if appointment != nil {
+		reply.AppointmentID = &appointment.ID
+		reply.PatientHN = &appointment.PatientHN
+	}
+
+	var note string
+	switch {
+	case appointment == nil:
+		note = "No upcoming reminded appointment for this number"
+	case reply.Intent == database.ReplyUnknown:
+		note = "Reply is not confirm or cancel"
+	default:
+		if err := h.apply(appointment, reply.Intent, "Cancelled by patient reply via "+req.Channel); err != nil {
+			note = err.Error()
+		} else {
+			reply.Status = database.ReplyApplied
+		}
+	}
+	if note != "" {
+		reply.Note = &note
+	}
+
+	if err := h.repo.Create(&reply); err != nil {
+		writeError(w, err, "Failed to record reminder reply")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reply)
+}
+
+// GetReplies lists replies by ?status=, by default those waiting for review
+func (h *ReminderReplyHandler) GetReplies(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = database.ReplyReview
+	}
+	if status == "all" {
+		status = ""
+	}
+
+	replies, err := h.repo.List(status)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve reminder replies")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, replies)
+}
+
+// ResolveReply closes a reply waiting for review: confirm or cancel its
+// appointment (or the appointmentId staff matched it to), or dismiss it
+func (h *ReminderReplyHandler) ResolveReply(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid reply ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Action        string  `json:"action"`
+		AppointmentID *int    `json:"appointmentId,omitempty"`
+		ResolvedBy    string  `json:"resolvedBy"`
+		Note          *string `json:"note,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.ResolvedBy == "" {
+		req.ResolvedBy = reqctx.UserName(r.Context())
+	}
+	if req.ResolvedBy == "" {
+		http.Error(w, "resolvedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	reply, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve reminder reply")
+		return
+	}
+	if reply.Status != database.ReplyReview {
+		http.Error(w, "Reply is not waiting for review", http.StatusConflict)
+		return
+	}
+
+	status := database.ReplyResolved
+	switch req.Action {
+	case database.ReplyConfirm, database.ReplyCancel:
+		if req.AppointmentID == nil {
+			req.AppointmentID = reply.AppointmentID
+		}
+		if req.AppointmentID == nil {
+			http.Error(w, "appointmentId is required; the reply matched no appointment", http.StatusBadRequest)
+			return
+		}
+		appointment, err := h.appointments.GetByID(*req.AppointmentID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve appointment")
+			return
+		}
+		if err := h.apply(appointment, req.Action, "Cancelled by patient reply, reviewed by "+req.ResolvedBy); err != nil {
+			writeError(w, err, "Failed to update appointment")
+			return
+		}
+	case "dismiss":
+		status = database.ReplyDismissed
+	default:
+		http.Error(w, "action must be confirm, cancel or dismiss", http.StatusBadRequest)
+		return
+	}
+
+	resolved, err := h.repo.Resolve(reply.ID, status, req.AppointmentID, req.ResolvedBy, req.Note)
+	if err != nil {
+		writeError(w, err, "Failed to resolve reminder reply")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resolved)
+}
+
+// remindedAppointment finds the appointment the sender's latest reminder on
+// the channel was about, if it is still scheduled and upcoming
+func (h *ReminderReplyHandler) remindedAppointment(channel, phone string) (*database.Appointment, error) {
+	reminders, err := h.reminders.List(database.AppointmentReminderFilter{Channel: channel, Phone: phone})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := len(reminders) - 1; i >= 0; i-- {
+		a, err := h.appointments.GetByID(reminders[i].AppointmentID)
+		if err != nil {
+			return nil, err
+		}
+		if a.Status == database.AppointmentScheduled && a.StartsAt.After(now) {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+// apply confirms or cancels a scheduled appointment
+func (h *ReminderReplyHandler) apply(a *database.Appointment, intent, cancelReason string) error {
+	if intent == database.ReplyConfirm {
+		_, err := h.appointments.SetConfirmation(a.ID, database.AppointmentConfirmed)
+		return err
+	}
+	if _, err := h.appointments.SetConfirmation(a.ID, database.AppointmentDeclined); err != nil {
+		return err
+	}
+	_, err := h.appointments.UpdateStatus(a.ID, database.AppointmentScheduled, database.AppointmentCancelled,
+		&database.Cancellation{ReasonCode: database.CancelReasonPatientRequest, Note: &cancelReason})
+	return err
+}
+
+// localPhone turns a gateway's sender number into the local form patients'
+// numbers are kept in, e.g. "+66812345678" to "0812345678"
+func localPhone(phone string) string {
+	phone = normalizePhone(phone)
+	if strings.HasPrefix(phone, "66") && len(phone) == 11 {
+		phone = "0" + phone[2:]
+	}
+	return phone
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/forecast"
+)
+
+// forecastMonths is the default amount of dispensing history used for forecasts
+const forecastMonths = 6
+
+// ReorderRepository interface for reorder policy and suggestion storage
+type ReorderRepository interface {
+	ListPolicies() ([]database.ReorderPolicy, error)
+	UpsertPolicy(p *database.ReorderPolicy) error
+	CreateSuggestions(suggestions []database.PurchaseSuggestion) error
+	ListSuggestions(status string) ([]database.PurchaseSuggestion, error)
+	GetSuggestion(id int) (*database.PurchaseSuggestion, error)
+	UpdateSuggestion(s *database.PurchaseSuggestion) error
+}
+
+// ReorderHandler handles consumption forecast and purchase suggestion requests
+type ReorderHandler struct {
+	repo  ReorderRepository
+	usage forecast.UsageSource
+}
+
+// NewReorderHandler creates a new reorder handler.
+// A nil usage source disables forecasting and suggestion generation.
+func NewReorderHandler(repo ReorderRepository, usage forecast.UsageSource) *ReorderHandler {
+	return &ReorderHandler{repo: repo, usage: usage}
+}
+
+// GetPolicies returns all reorder policies
+func (h *ReorderHandler) GetPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.repo.ListPolicies()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve reorder policies")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policies)
+}
+
+// UpdatePolicy creates or replaces the reorder policy of an item
+func (h *ReorderHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	itemID, err := pathID(r, "itemId")
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	var policy database.ReorderPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if policy.PackSize < 1 || policy.LeadTimeDays < 0 || policy.SafetyStockDays < 0 {
+		http.Error(w, "packSize must be at least 1 and day counts cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	policy.ItemID = itemID
+	if err := h.repo.UpsertPolicy(&policy); err != nil {
+		writeError(w, err, "Failed to save reorder policy")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// GetForecast returns next month's forecast consumption per item (?months= history window)
+func (h *ReorderHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		http.Error(w, "Dispensing history not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	months := forecastMonths
+	if s := r.URL.Query().Get("months"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 24 {
+			http.Error(w, "months must be between 1 and 24", http.StatusBadRequest)
+			return
+		}
+		months = n
+	}
+
+	totals, err := h.monthlyTotals(months)
+	if err != nil {
+		writeError(w, err, "Failed to load dispensing history")
+		return
+	}
+
+	type itemForecast struct {
+		ItemID   int       `json:"itemId"`
+		History  []float64 `json:"history"`
+		Forecast float64   `json:"forecast"`
+	}
+	forecasts := make([]itemForecast, 0, len(totals))
+	for itemID, history := range totals {
+		forecasts = append(forecasts, itemForecast{
+			ItemID:   itemID,
+			History:  history,
+			Forecast: forecast.Monthly(history),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, forecasts)
+}
+
+// GenerateSuggestions creates pending purchase suggestions for every item
+// whose stock will not last through its supplier lead time
+func (h *ReorderHandler) GenerateSuggestions(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		http.Error(w, "Dispensing history not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	policies, err := h.repo.ListPolicies()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve reorder policies")
+		return
+	}
+
+	totals, err := h.monthlyTotals(forecastMonths)
+	if err != nil {
+		writeError(w, err, "Failed to load dispensing history")
+		return
+	}
+
+	stock, err := h.usage.StockLevels()
+	if err != nil {
+		writeError(w, err, "Failed to load stock levels")
+		return
+	}
+
+	// Items that already have a suggestion awaiting approval are skipped
+	pending, err := h.repo.ListSuggestions(database.SuggestionPending)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve purchase suggestions")
+		return
+	}
+	hasPending := make(map[int]bool)
+	for _, s := range pending {
+		hasPending[s.ItemID] = true
+	}
+
+	suggestions := []database.PurchaseSuggestion{}
+	for _, policy := range policies {
+		if hasPending[policy.ItemID] {
+			continue
+		}
+		monthly := forecast.Monthly(totals[policy.ItemID])
+		if s, ok := forecast.Suggest(policy, monthly, stock[policy.ItemID]); ok {
+			suggestions = append(suggestions, s)
+		}
+	}
+
+	if err := h.repo.CreateSuggestions(suggestions); err != nil {
+		writeError(w, err, "Failed to save purchase suggestions")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, suggestions)
+}
+
+func (h *ReorderHandler) monthlyTotals(months int) (map[int][]float64, error) {
+	now := time.Now()
+	usage, err := h.usage.UsageSince(now.AddDate(0, -months-1, 0))
+	if err != nil {
+		return nil, err
+	}
+	return forecast.MonthlyTotals(usage, months, now), nil
+}
+
+// GetSuggestions returns purchase suggestions, filtered by ?status=pending|approved|rejected
+func (h *ReorderHandler) GetSuggestions(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := h.repo.ListSuggestions(r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, err, "Failed to retrieve purchase suggestions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, suggestions)
+}
+
+// ApproveSuggestion approves a pending suggestion, optionally adjusting the pack count
+func (h *ReorderHandler) ApproveSuggestion(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, database.SuggestionApproved)
+}
+
+// RejectSuggestion rejects a pending suggestion
+func (h *ReorderHandler) RejectSuggestion(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, database.SuggestionRejected)
+}
+
+func (h *ReorderHandler) decide(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid suggestion ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		DecidedBy string `json:"decidedBy"`
+		Packs     *int   `json:"packs,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DecidedBy == "" {
+		http.Error(w, "decidedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	suggestion, err := h.repo.GetSuggestion(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve purchase suggestion")
+		return
+	}
+	if suggestion.Status != database.SuggestionPending {
+		http.Error(w, "Purchase suggestion has already been decided", http.StatusConflict)
+		return
+	}
+
+	if req.Packs != nil && status == database.SuggestionApproved {
+		if *req.Packs < 1 {
+			http.Error(w, "packs must be at least 1", http.StatusBadRequest)
+			return
+		}
+		packSize := suggestion.SuggestedQuantity / suggestion.Packs
+		suggestion.Packs = *req.Packs
+		suggestion.SuggestedQuantity = *req.Packs * packSize
+	}
+
+	now := time.Now()
+	suggestion.Status = status
+	suggestion.DecidedBy = &req.DecidedBy
+	suggestion.DecidedAt = &now
+	if err := h.repo.UpdateSuggestion(suggestion); err != nil {
+		writeError(w, err, "Failed to update purchase suggestion")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, suggestion)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// Headers naming the tenant and clinic branch a request acts on
+const (
+	TenantHeader = "X-Tenant-ID"
+	BranchHeader = "X-Branch-ID"
+)
+
+// APIKeyHeader carries an integration's API key
+const APIKeyHeader = "X-API-Key"
+
+// SandboxHeader is set on every response to a sandbox API key, so
+// integrators can see nothing real was charged or sent
+const SandboxHeader = "X-Sandbox"
+
+// apiKeyUseInterval is how stale an API key's last use may get before it is
+// updated, so busy integrations do not write on every request
+const apiKeyUseInterval = time.Minute
+
+var scopeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// BranchLookup returns a branch's settings
+type BranchLookup interface {
+	GetByID(id string) (*database.Branch, error)
+}
+
+// APIKeyLookup finds the API key a request was made with
+type APIKeyLookup interface {
+	GetByHash(hash string) (*database.APIKey, error)
+	MarkUsed(id int) error
+}
+
+// RequestContext builds the request's reqctx.Info so handlers, services and
+// repositories can read who is acting and where from r.Context() instead of
+// taking extra parameters. Until staff accounts can sign in the identified
+// users are the administrator holding the admin token and integrations with
+// an API key, which act with the key's role and, for sandbox keys, in the
+// sandbox; kiosk keys only reach the kiosk API. Requests for a branch with
+// settings carry its timezone; the rest use the clinic's.
+func RequestContext(admin *AdminGate, branches BranchLookup, keys APIKeyLookup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := reqctx.Info{
+				Tenant: r.Header.Get(TenantHeader),
+				Branch: r.Header.Get(BranchHeader),
+			}
+			if (info.Tenant != "" && !scopeIDPattern.MatchString(info.Tenant)) ||
+				(info.Branch != "" && !scopeIDPattern.MatchString(info.Branch)) {
+				http.Error(w, "Invalid "+TenantHeader+" or "+BranchHeader+" header", http.StatusBadRequest)
+				return
+			}
+			if presented := r.Header.Get(APIKeyHeader); presented != "" {
+				key, err := keys.GetByHash(hashAPIKey(presented))
+				if err != nil {
+					if apperr.Is(err, apperr.KindNotFound) {
+						http.Error(w, "Invalid or revoked API key", http.StatusUnauthorized)
+						return
+					}
+					writeError(w, err, "Failed to check API key")
+					return
+				}
+				if key.Role == reqctx.RoleKiosk && !strings.HasPrefix(r.URL.Path, "/kiosk/") {
+					// Kiosks stand in the waiting room; their keys open nothing but the kiosk API
+					http.Error(w, "Kiosk API keys can only call /kiosk/ routes", http.StatusForbidden)
+					return
+				}
+				info.UserID = apiKeyUserID(key)
+				info.UserName = key.Name
+				info.Role = key.Role
+				info.Sandbox = key.Sandbox
+				if key.Sandbox {
+					w.Header().Set(SandboxHeader, "true")
+				}
+				if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyUseInterval {
+					if err := keys.MarkUsed(key.ID); err != nil {
+						log.Printf("Failed to record use of API key %d: %v", key.ID, err)
+					}
+				}
+			} else if admin.IsAdmin(r) {
+				info.UserID = "admin"
+				info.UserName = "admin"
+				info.Role = reqctx.RoleAdmin
+			}
+			if info.Branch != "" {
+				info.Location = branchLocation(branches, info.Branch)
+			}
+
+			next.ServeHTTP(w, r.WithContext(reqctx.With(r.Context(), info)))
+		})
+	}
+}
+
+// RequireRole wraps a handler so only users with one of the roles can call it
+func RequireRole(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		info := reqctx.From(r.Context())
+		if !info.HasRole(roles...) {
+			if !info.Authenticated() {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, "Insufficient role", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// branchLocation loads a branch's timezone, or nil for the clinic's when the
+// branch has no settings or they cannot be read
+func branchLocation(branches BranchLookup, id string) *time.Location {
+	branch, err := branches.GetByID(id)
+	if err != nil {
+		if !apperr.Is(err, apperr.KindNotFound) {
+			log.Printf("Failed to load settings of branch %s: %v", id, err)
+		}
+		return nil
+	}
+	loc, err := branch.Location()
+	if err != nil {
+		log.Printf("Branch %s has an invalid timezone %q: %v", id, branch.Timezone, err)
+		return nil
+	}
+	return loc
+}
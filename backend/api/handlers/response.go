@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// writeJSON encodes v as the response body with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// pathID parses a numeric route variable such as {id}
+func pathID(r *http.Request, name string) (int, error) {
+	return strconv.Atoi(mux.Vars(r)[name])
+}
+
+// localNow is the current time in the request's branch timezone
+func localNow(r *http.Request) time.Time {
+	return time.Now().In(reqctx.Location(r.Context()))
+}
+
+// dateRange reads ?from=YYYY-MM-DD&to=YYYY-MM-DD (both inclusive) and returns
+// the half-open interval [from, to+1 day), with days starting at midnight in
+// the request's branch timezone. Defaults to the current month so far.
+func dateRange(r *http.Request) (time.Time, time.Time, error) {
+	loc := reqctx.Location(r.Context())
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	to := now
+
+	if s := r.URL.Query().Get("from"); s != "" {
+		d, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid from date, expected YYYY-MM-DD")
+		}
+		from = d
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		d, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid to date, expected YYYY-MM-DD")
+		}
+		to = d
+	}
+
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	return from, to, nil
+}
+
+// parseHN extracts the numeric part from an HN string (e.g., "HN000001" -> 1)
+func parseHN(hn string) (int, error) {
+	var id int
+	if _, err := fmt.Sscanf(hn, "HN%d", &id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
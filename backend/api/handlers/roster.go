@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// RosterLookup provides doctors' shifts and days off for booking checks
+type RosterLookup interface {
+	GetRoster(doctorID int) (*database.Roster, error)
+	ListDaysOff(doctorID int, from, to string) ([]database.DayOff, error)
+}
+
+// RosterRepository interface for doctor roster storage
+type RosterRepository interface {
+	RosterLookup
+	SaveRoster(roster *database.Roster) error
+	DeleteRoster(doctorID int) error
+	AddDayOff(d *database.DayOff) error
+	DeleteDayOff(doctorID, id int) error
+}
+
+// RosterHandler handles doctors' weekly shifts, days off and availability
+type RosterHandler struct {
+	repo         RosterRepository
+	doctors      DoctorRepository
+	appointments AppointmentRepository
+}
+
+// NewRosterHandler creates a new roster handler
+func NewRosterHandler(repo RosterRepository, doctors DoctorRepository, appointments AppointmentRepository) *RosterHandler {
+	return &RosterHandler{repo: repo, doctors: doctors, appointments: appointments}
+}
+
+// timeSpan is a stretch of a doctor's day
+type timeSpan struct {
+	StartsAt      time.Time `json:"startsAt"`
+	EndsAt        time.Time `json:"endsAt"`
+	AppointmentID *int      `json:"appointmentId,omitempty"` // for booked spans
+}
+
+// availability is a doctor's day: when they work, what is booked and what is free
+type availability struct {
+	DoctorID   int              `json:"doctorId"`
+	DoctorName string           `json:"doctorName"`
+	Date       string           `json:"date"`
+	Rostered   bool             `json:"rostered"` // shifts come from a roster rather than working days
+	DayOff     *database.DayOff `json:"dayOff,omitempty"`
+	Shifts     []timeSpan       `json:"shifts"`
+	Booked     []timeSpan       `json:"booked"`
+	Free       []timeSpan       `json:"free"`
+}
+
+// GetRoster returns a doctor's weekly shifts
+func (h *RosterHandler) GetRoster(w http.ResponseWriter, r *http.Request) {
+	doctor, ok := h.loadDoctor(w, r)
+	if !ok {
+		return
+	}
+
+	roster, err := h.repo.GetRoster(doctor.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve roster")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, roster)
+}
+
+// SaveRoster replaces a doctor's weekly shifts, e.g.
+// [{"day":"mon","opens":"08:00","closes":"12:00"}]
+func (h *RosterHandler) SaveRoster(w http.ResponseWriter, r *http.Request) {
+	var roster database.Roster
+	if err := json.NewDecoder(r.Body).Decode(&roster); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	shifts, msg := checkHours(roster.Shifts)
+	if msg != "" {
+		http.Error(w, strings.Replace(msg, "hours", "shifts", 1), http.StatusBadRequest)
+		return
+	}
+
+	doctor, ok := h.loadDoctor(w, r)
+	if !ok {
+		return
+	}
+	roster.DoctorID = doctor.ID
+	roster.Shifts = shifts
+	roster.UpdatedBy = reqctx.UserName(r.Context())
+
+	if err := h.repo.SaveRoster(&roster); err != nil {
+		writeError(w, err, "Failed to save roster")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, roster)
+}
+
+// DeleteRoster removes a doctor's shifts; they are bookable all day on their working days again
+func (h *RosterHandler) DeleteRoster(w http.ResponseWriter, r *http.Request) {
+	doctor, ok := h.loadDoctor(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteRoster(doctor.ID); err != nil {
+		writeError(w, err, "Failed to delete roster")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDaysOff lists a doctor's days off in ?from=&to= (YYYY-MM-DD), by default from today on
+func (h *RosterHandler) GetDaysOff(w http.ResponseWriter, r *http.Request) {
+	doctor, ok := h.loadDoctor(w, r)
+	if !ok {
+		return
+	}
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" {
+		from = today(r)
+	}
+	for _, d := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", d); d != "" && err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	days, err := h.repo.ListDaysOff(doctor.ID, from, to)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve days off")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, days)
+}
+
+// AddDayOff records a date a doctor does not work. Appointments already
+// booked that day are left for staff to move.
+func (h *RosterHandler) AddDayOff(w http.ResponseWriter, r *http.Request) {
+	var day database.DayOff
+	if err := json.NewDecoder(r.Body).Decode(&day); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if _, err := time.Parse("2006-01-02", day.Date); err != nil {
+		http.Error(w, "date is required as YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	doctor, ok := h.loadDoctor(w, r)
+	if !ok {
+		return
+	}
+	day.DoctorID = doctor.ID
+	day.CreatedBy = reqctx.UserName(r.Context())
+
+	if err := h.repo.AddDayOff(&day); err != nil {
+		writeError(w, err, "Failed to add day off")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, day)
+}
+
+// DeleteDayOff removes one of a doctor's days off
+func (h *RosterHandler) DeleteDayOff(w http.ResponseWriter, r *http.Request) {
+	doctorID, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return
+	}
+	id, err := pathID(r, "dayOffId")
+	if err != nil {
+		http.Error(w, "Invalid day off ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteDayOff(doctorID, id); err != nil {
+		writeError(w, err, "Failed to delete day off")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetAvailability returns a doctor's shifts, bookings and free time on
+// ?date= (YYYY-MM-DD, default today) in the request's branch timezone
+func (h *RosterHandler) GetAvailability(w http.ResponseWriter, r *http.Request) {
+	loc := reqctx.Location(r.Context())
+	date := midnight(localNow(r))
+	if s := r.URL.Query().Get("date"); s != "" {
+		d, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = d
+	}
+
+	doctor, ok := h.loadDoctor(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := doctorDay(h.repo, h.appointments, doctor, date, loc)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve availability")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *RosterHandler) loadDoctor(w http.ResponseWriter, r *http.Request) (*database.Doctor, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid doctor ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	doctor, err := h.doctors.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve doctor")
+		return nil, false
+	}
+	return doctor, true
+}
+
+// doctorDay works out a doctor's shifts, bookings and free time on date
+// (local midnight in loc)
+func doctorDay(roster RosterLookup, appointments AppointmentRepository, doctor *database.Doctor, date time.Time, loc *time.Location) (*availability, error) {
+	next := date.AddDate(0, 0, 1)
+	day := date.Format("2006-01-02")
+	result := &availability{DoctorID: doctor.ID, DoctorName: doctor.FullName, Date: day,
+		Shifts: []timeSpan{}, Booked: []timeSpan{}, Free: []timeSpan{}}
+
+	off, err := roster.ListDaysOff(doctor.ID, day, day)
+	if err != nil {
+		return nil, err
+	}
+	shifts, err := roster.GetRoster(doctor.ID)
+	if err != nil && !apperr.Is(err, apperr.KindNotFound) {
+		return nil, err
+	}
+	result.Rostered = shifts != nil
+
+	switch {
+	case !doctor.Active:
+	case len(off) > 0:
+		result.DayOff = &off[0]
+	case shifts != nil:
+		for _, s := range shifts.Shifts {
+			if s.Day == database.Weekdays[date.Weekday()] {
+				result.Shifts = append(result.Shifts, timeSpan{StartsAt: clockOn(date, s.Opens), EndsAt: clockOn(date, s.Closes)})
+			}
+		}
+	case doctor.WorksOn(date.Weekday()):
+		result.Shifts = append(result.Shifts, timeSpan{StartsAt: date, EndsAt: next})
+	}
+
+	booked, err := appointments.List(database.AppointmentFilter{From: date, To: next, DoctorName: doctor.FullName})
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range booked {
+		if a.Active() {
+			id := a.ID
+			result.Booked = append(result.Booked, timeSpan{StartsAt: a.StartsAt.In(loc), EndsAt: a.EndsAt.In(loc), AppointmentID: &id})
+		}
+	}
+	sort.Slice(result.Booked, func(i, j int) bool { return result.Booked[i].StartsAt.Before(result.Booked[j].StartsAt) })
+
+	for _, shift := range result.Shifts {
+		start := shift.StartsAt
+		for _, b := range result.Booked {
+			if !b.EndsAt.After(start) || !b.StartsAt.Before(shift.EndsAt) {
+				continue
+			}
+			if b.StartsAt.After(start) {
+				result.Free = append(result.Free, timeSpan{StartsAt: start, EndsAt: b.StartsAt})
+			}
+			start = b.EndsAt
+		}
+		if start.Before(shift.EndsAt) {
+			result.Free = append(result.Free, timeSpan{StartsAt: start, EndsAt: shift.EndsAt})
+		}
+	}
+	return result, nil
+}
+
+// clockOn returns the HH:MM time of day on date; 24:00 is the next midnight
+func clockOn(date time.Time, clock string) time.Time {
+	var hour, minute int
+	fmt.Sscanf(clock, "%d:%d", &hour, &minute)
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, date.Location())
+}
+
+// rosterConflict says why a doctor cannot be booked for [from, to) in loc: a
+// day off, outside their rostered shifts or, without a roster, not a working day.
+// It returns "" when they can.
+func rosterConflict(roster RosterLookup, doctor *database.Doctor, from, to time.Time, loc *time.Location) (string, error) {
+	local := from.In(loc)
+	day := local.Format("2006-01-02")
+
+	off, err := roster.ListDaysOff(doctor.ID, day, day)
+	if err != nil {
+		return "", err
+	}
+	if len(off) > 0 {
+		msg := doctor.FullName + " is off on " + day
+		if off[0].Reason != nil && *off[0].Reason != "" {
+			msg += " (" + *off[0].Reason + ")"
+		}
+		return msg, nil
+	}
+
+	shifts, err := roster.GetRoster(doctor.ID)
+	if apperr.Is(err, apperr.KindNotFound) {
+		if !doctor.WorksOn(local.Weekday()) {
+			return doctor.FullName + " does not work on " + local.Weekday().String(), nil
+		}
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !shifts.OnShift(from, to, loc) {
+		return doctor.FullName + " is not rostered from " + local.Format("Mon 15:04") + " to " + to.In(loc).Format("15:04"), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/prom"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// selfRegistrationValidity is how long a new patient has to fill in the form
+const selfRegistrationValidity = 7 * 24 * time.Hour
+
+// SelfRegistrationRepository interface for self-registration form storage
+type SelfRegistrationRepository interface {
+	Create(s *database.SelfRegistration) error
+	GetByID(id int) (*database.SelfRegistration, error)
+	GetByToken(token string) (*database.SelfRegistration, error)
+	List(status string) ([]database.SelfRegistration, error)
+	Submit(s *database.SelfRegistration) error
+	Review(id int, status string, patientHN, reason *string, by string) (*database.SelfRegistration, error)
+}
+
+// SelfRegistrationHandler handles the demographics forms new patients fill in
+// before arriving, and reception's verification of them
+type SelfRegistrationHandler struct {
+	repo          SelfRegistrationRepository
+	patients      PatientRepository
+	rules         PatientRuleSource
+	publicBaseURL string
+}
+
+// NewSelfRegistrationHandler creates a new self-registration handler
+func NewSelfRegistrationHandler(repo SelfRegistrationRepository, patients PatientRepository, rules PatientRuleSource, publicBaseURL string) *SelfRegistrationHandler {
+	return &SelfRegistrationHandler{
+		repo:          repo,
+		patients:      patients,
+		rules:         rules,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+	}
+}
+
+// CreateSelfRegistration issues a registration link for staff to send a new
+// patient, e.g. when they book by phone; label notes who it was sent to
+func (h *SelfRegistrationHandler) CreateSelfRegistration(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Label    string `json:"label"`
+		IssuedBy string `json:"issuedBy"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	issuedBy := strings.TrimSpace(req.IssuedBy)
+	if issuedBy == "" {
+		issuedBy = reqctx.UserName(r.Context())
+	}
+	if issuedBy == "" {
+		http.Error(w, "issuedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	token, err := prom.NewLinkToken()
+	if err != nil {
+		writeError(w, err, "Failed to create registration link")
+		return
+	}
+	registration := database.SelfRegistration{
+		Token:     token,
+		Status:    database.SelfRegistrationOpen,
+		Label:     optionalText(req.Label),
+		IssuedBy:  issuedBy,
+		ExpiresAt: time.Now().Add(selfRegistrationValidity),
+	}
+	if err := h.repo.Create(&registration); err != nil {
+		writeError(w, err, "Failed to create self-registration")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"link": h.link(&registration), "registration": registration})
+}
+
+// GetSelfRegistrations lists self-registrations (?status=, e.g. pending for
+// the forms waiting at reception), most recently submitted first
+func (h *SelfRegistrationHandler) GetSelfRegistrations(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && !oneOf(status, database.SelfRegistrationStatuses) {
+		http.Error(w, "status must be one of "+strings.Join(database.SelfRegistrationStatuses, ", "), http.StatusBadRequest)
+		return
+	}
+
+	registrations, err := h.repo.List(status)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve self-registrations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, registrations)
+}
+
+// GetSelfRegistration returns one self-registration
+func (h *SelfRegistrationHandler) GetSelfRegistration(w http.ResponseWriter, r *http.Request) {
+	registration, ok := h.loadRegistration(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, registration)
+}
+
+// VerifySelfRegistration converts a pending form into a patient record under
+// the given hn, once reception has checked it against the patient's ID card.
+// Any other patient field in the body corrects what the patient filled in,
+// and the record must meet the clinic's field rules.
+func (h *SelfRegistrationHandler) VerifySelfRegistration(w http.ResponseWriter, r *http.Request) {
+	registration, ok := h.loadRegistration(w, r)
+	if !ok {
+		return
+	}
+	if registration.Status != database.SelfRegistrationPending {
+		http.Error(w, "Only forms pending verification can be verified", http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		database.Patient
+		ReviewedBy string `json:"reviewedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	id, err := parseHN(req.HN)
+	if err != nil || id <= 0 {
+		http.Error(w, "hn is required, e.g. HN000123", http.StatusBadRequest)
+		return
+	}
+	reviewedBy, ok := reviewer(w, r, req.ReviewedBy)
+	if !ok {
+		return
+	}
+
+	patient := registration.Patient(fmt.Sprintf("HN%06d", id))
+	correctPatient(patient, &req.Patient)
+	patient.Age = ageOn(patient, localNow(r))
+	if !checkPatient(w, h.rules, patient) {
+		return
+	}
+	if err := h.patients.Create(patient); err != nil {
+		writeError(w, err, "Failed to create patient")
+		return
+	}
+
+	verified, err := h.repo.Review(registration.ID, database.SelfRegistrationVerified, &patient.HN, nil, reviewedBy)
+	if err != nil {
+		writeError(w, err, "Failed to verify self-registration")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"patient": patient, "registration": verified})
+}
+
+// RejectSelfRegistration closes a pending form that will not become a
+// patient record, e.g. one the patient filled in twice or for an existing patient
+func (h *SelfRegistrationHandler) RejectSelfRegistration(w http.ResponseWriter, r *http.Request) {
+	registration, ok := h.loadRegistration(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason     string `json:"reason"`
+		ReviewedBy string `json:"reviewedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	reason := optionalText(req.Reason)
+	if reason == nil {
+		http.Error(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	reviewedBy, ok := reviewer(w, r, req.ReviewedBy)
+	if !ok {
+		return
+	}
+
+	rejected, err := h.repo.Review(registration.ID, database.SelfRegistrationRejected, nil, reason, reviewedBy)
+	if err != nil {
+		writeError(w, err, "Failed to reject self-registration")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rejected)
+}
+
+// GetPublicSelfRegistration tells the form behind a registration link which
+// fields the clinic requires and until when it can be filled in
+func (h *SelfRegistrationHandler) GetPublicSelfRegistration(w http.ResponseWriter, r *http.Request) {
+	registration, ok := h.loadOpenRegistration(w, r)
+	if !ok {
+		return
+	}
+	rules, ok := h.formRules(w)
+	if !ok {
+		return
+	}
+
+	required := []string{"fullName"}
+	for _, field := range database.PatientRuleFields {
+		if rules[field].Required && field != "photo" {
+			required = append(required, field)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"requiredFields": required,
+		"expiresAt":      registration.ExpiresAt,
+	})
+}
+
+// SubmitPublicSelfRegistration records the details a new patient fills in,
+// leaving the form pending until reception verifies it. The clinic's required
+// fields apply, except the photo, which is taken at the front desk.
+func (h *SelfRegistrationHandler) SubmitPublicSelfRegistration(w http.ResponseWriter, r *http.Request) {
+	registration, ok := h.loadOpenRegistration(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		FullName    string `json:"fullName"`
+		Gender      string `json:"gender"`
+		Nickname    string `json:"nickname"`
+		Phone       string `json:"phone"`
+		DateOfBirth string `json:"dateOfBirth"`
+		CitizenID   string `json:"citizenId"`
+		Notes       string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	registration.FullName = strings.Join(strings.Fields(req.FullName), " ")
+	registration.Gender = strings.TrimSpace(req.Gender)
+	registration.Nickname = optionalText(req.Nickname)
+	registration.Phone = optionalText(req.Phone)
+	registration.DateOfBirth = optionalText(req.DateOfBirth)
+	registration.CitizenID = optionalText(strings.ReplaceAll(req.CitizenID, "-", ""))
+	registration.Notes = optionalText(req.Notes)
+	if msg := checkSelfRegistration(registration, today(r)); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	rules, ok := h.formRules(w)
+	if !ok {
+		return
+	}
+	missing := []string{}
+	for _, field := range rules.Missing(registration.Patient("")) {
+		if field != "photo" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		http.Error(w, strings.Join(missing, ", ")+" required by this clinic", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Submit(registration); err != nil {
+		writeError(w, err, "Failed to save registration form")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": registration.Status})
+}
+
+// checkSelfRegistration validates the details a patient filled in, returning what is wrong with them
+func checkSelfRegistration(s *database.SelfRegistration, day string) string {
+	if s.FullName == "" {
+		return "fullName is required"
+	}
+	if s.CitizenID != nil && !validCitizenID(*s.CitizenID) {
+		return "citizenId must be a valid 13-digit Thai citizen ID"
+	}
+	if s.DateOfBirth != nil {
+		if _, err := time.Parse("2006-01-02", *s.DateOfBirth); err != nil {
+			return "Invalid dateOfBirth, expected YYYY-MM-DD"
+		}
+		if *s.DateOfBirth > day {
+			return "dateOfBirth cannot be in the future"
+		}
+	}
+	return ""
+}
+
+// correctPatient applies reception's corrections: every field set in c
+// replaces the one the patient filled in
+func correctPatient(p, c *database.Patient) {
+	if name := strings.TrimSpace(c.FullName); name != "" {
+		p.FullName = name
+	}
+	if gender := strings.TrimSpace(c.Gender); gender != "" {
+		p.Gender = gender
+	}
+	for _, f := range []struct{ to, from **string }{
+		{&p.Nickname, &c.Nickname},
+		{&p.Phone, &c.Phone},
+		{&p.DateOfBirth, &c.DateOfBirth},
+		{&p.CitizenID, &c.CitizenID},
+		{&p.Photo, &c.Photo},
+	} {
+		if *f.from != nil {
+			*f.to = optionalText(**f.from)
+		}
+	}
+}
+
+// reviewer is who verifies or rejects a form: the one named, else the signed-in user
+func reviewer(w http.ResponseWriter, r *http.Request, named string) (string, bool) {
+	by := strings.TrimSpace(named)
+	if by == "" {
+		by = reqctx.UserName(r.Context())
+	}
+	if by == "" {
+		http.Error(w, "reviewedBy is required", http.StatusBadRequest)
+		return "", false
+	}
+	return by, true
+}
+
+func (h *SelfRegistrationHandler) formRules(w http.ResponseWriter) (database.PatientFieldRules, bool) {
+	configured, err := h.rules.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient field rules")
+		return nil, false
+	}
+	return database.NewPatientFieldRules(configured), true
+}
+
+func (h *SelfRegistrationHandler) loadRegistration(w http.ResponseWriter, r *http.Request) (*database.SelfRegistration, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid self-registration ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	registration, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve self-registration")
+		return nil, false
+	}
+	return registration, true
+}
+
+// loadOpenRegistration resolves a registration link token, rejecting forms
+// already filled in and expired links
+func (h *SelfRegistrationHandler) loadOpenRegistration(w http.ResponseWriter, r *http.Request) (*database.SelfRegistration, bool) {
+	registration, err := h.repo.GetByToken(mux.Vars(r)["token"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve registration form")
+		return nil, false
+	}
+	if registration.Status != database.SelfRegistrationOpen {
+		http.Error(w, "This registration form has already been filled in", http.StatusConflict)
+		return nil, false
+	}
+	if time.Now().After(registration.ExpiresAt) {
+		http.Error(w, "This registration link has expired", http.StatusGone)
+		return nil, false
+	}
+	return registration, true
+}
+
+func (h *SelfRegistrationHandler) link(s *database.SelfRegistration) string {
+	return h.publicBaseURL + "/public/self-registration/" + s.Token
+}
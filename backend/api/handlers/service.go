@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// ServiceRepository interface for service catalog storage
+type ServiceRepository interface {
+	Create(s *database.Service) error
+	GetByID(id int) (*database.Service, error)
+	GetAll(f database.ServiceFilter) ([]database.Service, error)
+	Update(s *database.Service) error
+	Deactivate(id int) error
+}
+
+// ServiceLookup resolves the catalog services that visits and invoice lines refer to
+type ServiceLookup interface {
+	GetByID(id int) (*database.Service, error)
+}
+
+// VisitServiceRepository interface for the services recorded against visits
+type VisitServiceRepository interface {
+	Create(s *database.VisitService) error
+	GetByVisit(visitID int) ([]database.VisitService, error)
+	Delete(visitID, id int) error
+	LastGiven(hn string, serviceID int) (*time.Time, error)
+}
+
+// ServiceHandler handles service catalog requests and the services given during visits
+type ServiceHandler struct {
+	repo          ServiceRepository
+	visitServices VisitServiceRepository
+	visits        EncounterRepository
+	patients      PatientRepository
+}
+
+// NewServiceHandler creates a new service handler
+func NewServiceHandler(repo ServiceRepository, visitServices VisitServiceRepository, visits EncounterRepository, patients PatientRepository) *ServiceHandler {
+	return &ServiceHandler{repo: repo, visitServices: visitServices, visits: visits, patients: patients}
+}
+
+// GetServices lists catalog services (?q= part of the code or name, ?category=, ?active=true)
+func (h *ServiceHandler) GetServices(w http.ResponseWriter, r *http.Request) {
+	filter := database.ServiceFilter{
+		Search:     strings.TrimSpace(r.URL.Query().Get("q")),
+		Category:   r.URL.Query().Get("category"),
+		ActiveOnly: r.URL.Query().Get("active") == "true",
+	}
+
+	services, err := h.repo.GetAll(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve services")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, services)
+}
+
+// GetService returns one catalog service
+func (h *ServiceHandler) GetService(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+
+	service, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve service")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, service)
+}
+
+// CreateService adds a service to the catalog
+func (h *ServiceHandler) CreateService(w http.ResponseWriter, r *http.Request) {
+	var service database.Service
+	if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if msg := checkService(&service); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if !h.checkPrerequisite(w, &service) {
+		return
+	}
+
+	service.Active = true
+	if err := h.repo.Create(&service); err != nil {
+		writeError(w, err, "Failed to create service")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, service)
+}
+
+// UpdateService replaces a service's details; active can return a withdrawn
+// service to the catalog. Visits already recorded keep the price they were given.
+func (h *ServiceHandler) UpdateService(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.repo.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve service")
+		return
+	}
+
+	var service database.Service
+	if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	service.ID = id
+	if msg := checkService(&service); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if !h.checkPrerequisite(w, &service) {
+		return
+	}
+
+	if err := h.repo.Update(&service); err != nil {
+		writeError(w, err, "Failed to update service")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, service)
+}
+
+// DeleteService withdraws a service from the catalog; visits and invoices keep their reference
+func (h *ServiceHandler) DeleteService(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Deactivate(id); err != nil {
+		writeError(w, err, "Failed to deactivate service")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetServiceEligibility reports whether a patient (?hn=) may have a service on
+// a day (?date=YYYY-MM-DD, default today) and, if not, every reason why
+func (h *ServiceHandler) GetServiceEligibility(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+	day := localNow(r)
+	if s := r.URL.Query().Get("date"); s != "" {
+		d, err := time.ParseInLocation("2006-01-02", s, day.Location())
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		day = d
+	}
+
+	service, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve service")
+		return
+	}
+	patient, ok := findPatient(w, h.patients, r.URL.Query().Get("hn"))
+	if !ok {
+		return
+	}
+
+	reasons, err := ineligibility(service, patient, day, h.repo, h.visitServices)
+	if err != nil {
+		writeError(w, err, "Failed to check service eligibility")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ServiceEligibility{
+		ServiceID: service.ID,
+		PatientHN: patient.HN,
+		Eligible:  len(reasons) == 0,
+		Reasons:   reasons,
+	})
+}
+
+// AddVisitService records a catalog service given during an open visit, at the
+// catalog price unless unitPrice overrides it; quantity defaults to 1. The
+// patient must meet the service's eligibility rules.
+func (h *ServiceHandler) AddVisitService(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.openVisit(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ServiceID  int      `json:"serviceId"`
+		Quantity   float64  `json:"quantity"`
+		UnitPrice  *float64 `json:"unitPrice"`
+		Notes      *string  `json:"notes"`
+		RecordedBy string   `json:"recordedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.ServiceID == 0 {
+		http.Error(w, "serviceId is required", http.StatusBadRequest)
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 {
+		http.Error(w, "quantity must be greater than 0", http.StatusBadRequest)
+		return
+	}
+	if req.UnitPrice != nil && *req.UnitPrice < 0 {
+		http.Error(w, "unitPrice cannot be negative", http.StatusBadRequest)
+		return
+	}
+	req.RecordedBy = strings.TrimSpace(req.RecordedBy)
+	if req.RecordedBy == "" {
+		req.RecordedBy = reqctx.UserName(r.Context())
+	}
+	if req.RecordedBy == "" {
+		http.Error(w, "recordedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	service, ok := lookupService(w, h.repo, req.ServiceID)
+	if !ok {
+		return
+	}
+	patient, ok := findPatient(w, h.patients, visit.PatientHN)
+	if !ok || !checkEligible(w, service, patient, time.Now(), h.repo, h.visitServices) {
+		return
+	}
+
+	given := database.VisitService{
+		VisitID:    visit.ID,
+		PatientHN:  visit.PatientHN,
+		ServiceID:  service.ID,
+		Name:       service.Name,
+		Quantity:   req.Quantity,
+		UnitPrice:  service.Price,
+		Notes:      req.Notes,
+		RecordedBy: req.RecordedBy,
+	}
+	if req.UnitPrice != nil {
+		given.UnitPrice = *req.UnitPrice
+	}
+	if err := h.visitServices.Create(&given); err != nil {
+		writeError(w, err, "Failed to record visit service")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, given)
+}
+
+// GetVisitServices lists the services recorded during a visit
+func (h *ServiceHandler) GetVisitServices(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.visits.GetByID(visitID); err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+
+	services, err := h.visitServices.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit services")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, services)
+}
+
+// DeleteVisitService removes a service recorded in error while the visit is still open
+func (h *ServiceHandler) DeleteVisitService(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.openVisit(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid visit service ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.visitServices.Delete(visit.ID, id); err != nil {
+		writeError(w, err, "Failed to delete visit service")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// openVisit loads the visit a request names; services can only be changed while it is open
+func (h *ServiceHandler) openVisit(w http.ResponseWriter, r *http.Request) (*database.Encounter, bool) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return nil, false
+	}
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return nil, false
+	}
+	if visit.Status != database.EncounterOpen {
+		http.Error(w, "Services can only be recorded during an open visit", http.StatusConflict)
+		return nil, false
+	}
+	return visit, true
+}
+
+// checkPrerequisite refuses a prerequisite that is not an active catalog
+// service, writing the response when it does
+func (h *ServiceHandler) checkPrerequisite(w http.ResponseWriter, s *database.Service) bool {
+	if s.PrerequisiteServiceID == nil {
+		return true
+	}
+	_, ok := lookupService(w, h.repo, *s.PrerequisiteServiceID)
+	return ok
+}
+
+// checkService validates required fields, upper-cases the code and checks the
+// category and eligibility rules
+func checkService(s *database.Service) string {
+	s.Code = strings.ToUpper(strings.TrimSpace(s.Code))
+	s.Name = strings.TrimSpace(s.Name)
+	s.Category = strings.ToLower(strings.TrimSpace(s.Category))
+	if s.Code == "" || s.Name == "" {
+		return "code and name are required"
+	}
+	known := false
+	for _, c := range database.ServiceCategories {
+		known = known || s.Category == c
+	}
+	if !known {
+		return "category must be one of " + strings.Join(database.ServiceCategories, ", ")
+	}
+	if s.Price < 0 {
+		return "price cannot be negative"
+	}
+	return checkEligibilityRules(s)
+}
+
+// lookupService loads a service that a request refers to; unknown services are
+// a bad request and withdrawn ones a conflict
+func lookupService(w http.ResponseWriter, services ServiceLookup, id int) (*database.Service, bool) {
+	service, err := services.GetByID(id)
+	if err != nil {
+		if apperr.Is(err, apperr.KindNotFound) {
+			http.Error(w, fmt.Sprintf("Service %d is not in the catalog", id), http.StatusBadRequest)
+			return nil, false
+		}
+		writeError(w, err, "Failed to retrieve service")
+		return nil, false
+	}
+	if !service.Active {
+		http.Error(w, service.Name+" has been withdrawn from the catalog", http.StatusConflict)
+		return nil, false
+	}
+	return service, true
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// TaskRepository interface for task storage
+type TaskRepository interface {
+	Create(t *database.Task) error
+	GetByID(id int) (*database.Task, error)
+	List(f database.TaskFilter) ([]database.Task, error)
+	Update(t *database.Task) error
+	UpdateStatus(id int, from, to, by string) (*database.Task, error)
+}
+
+// TaskHandler handles staff to-dos
+type TaskHandler struct {
+	repo TaskRepository
+}
+
+// NewTaskHandler creates a new task handler
+func NewTaskHandler(repo TaskRepository) *TaskHandler {
+	return &TaskHandler{repo: repo}
+}
+
+// CreateTask assigns a to-do; assignedTo and createdBy default to the signed-in user
+func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	var task database.Task
+	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if task.CreatedBy == "" {
+		task.CreatedBy = reqctx.UserName(r.Context())
+	}
+	if task.AssignedTo == "" {
+		task.AssignedTo = task.CreatedBy
+	}
+	if task.CreatedBy == "" {
+		http.Error(w, "createdBy is required", http.StatusBadRequest)
+		return
+	}
+	if msg := checkTask(&task); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	task.Status = database.TaskOpen
+	task.CompletedBy, task.CompletedAt = nil, nil
+
+	if err := h.repo.Create(&task); err != nil {
+		writeError(w, err, "Failed to create task")
+		return
+	}
+
+	task.MarkOverdue(today(r))
+	writeJSON(w, http.StatusCreated, task)
+}
+
+// GetTasks lists tasks (?assignedTo=, ?status=, ?patientHn=), soonest due first
+func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	h.list(w, r, database.TaskFilter{AssignedTo: q.Get("assignedTo"), Status: q.Get("status"), PatientHN: q.Get("patientHn")})
+}
+
+// GetMyTasks lists the signed-in user's tasks, open ones unless ?status= says
+// otherwise. Until everyone signs in, ?assignedTo= names whose tasks to show.
+func (h *TaskHandler) GetMyTasks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	assignee := reqctx.UserName(r.Context())
+	if assignee == "" {
+		assignee = q.Get("assignedTo")
+	}
+	if assignee == "" {
+		http.Error(w, "Sign in or give ?assignedTo= to see your tasks", http.StatusBadRequest)
+		return
+	}
+	status := q.Get("status")
+	if status == "" {
+		status = database.TaskOpen
+	}
+
+	h.list(w, r, database.TaskFilter{AssignedTo: assignee, Status: status})
+}
+
+// GetOverdueTasks lists open tasks past their due date, most overdue first,
+// for overdue alerts; ?assignedTo= narrows them to one member of staff
+func (h *TaskHandler) GetOverdueTasks(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, database.TaskFilter{AssignedTo: r.URL.Query().Get("assignedTo"), Status: database.TaskOpen, DueBefore: today(r)})
+}
+
+// GetPatientTasks lists the tasks about a patient
+func (h *TaskHandler) GetPatientTasks(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, database.TaskFilter{PatientHN: mux.Vars(r)["hn"], Status: r.URL.Query().Get("status")})
+}
+
+func (h *TaskHandler) list(w http.ResponseWriter, r *http.Request, filter database.TaskFilter) {
+	tasks, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve tasks")
+		return
+	}
+
+	day := today(r)
+	for i := range tasks {
+		tasks[i].MarkOverdue(day)
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// GetTask returns one task
+func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadTask(w, r)
+	if !ok {
+		return
+	}
+
+	task.MarkOverdue(today(r))
+	writeJSON(w, http.StatusOK, task)
+}
+
+// UpdateTask changes an open task's title, details, assignee, patient or due date
+func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.loadTask(w, r)
+	if !ok {
+		return
+	}
+
+	var task database.Task
+	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	task.ID = existing.ID
+	if task.AssignedTo == "" {
+		task.AssignedTo = existing.AssignedTo
+	}
+	if msg := checkTask(&task); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Update(&task); err != nil {
+		writeError(w, err, "Failed to update task")
+		return
+	}
+
+	task.MarkOverdue(today(r))
+	writeJSON(w, http.StatusOK, task)
+}
+
+// UpdateTaskStatus completes, cancels or reopens a task
+func (h *TaskHandler) UpdateTaskStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status string `json:"status"`
+		By     string `json:"by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Status {
+	case database.TaskOpen, database.TaskDone, database.TaskCancelled:
+	default:
+		http.Error(w, "status must be open, done or cancelled", http.StatusBadRequest)
+		return
+	}
+	if req.By == "" {
+		req.By = reqctx.UserName(r.Context())
+	}
+	if req.Status == database.TaskDone && req.By == "" {
+		http.Error(w, "by is required to complete a task", http.StatusBadRequest)
+		return
+	}
+
+	task, ok := h.loadTask(w, r)
+	if !ok {
+		return
+	}
+	if !task.CanMoveTo(req.Status) {
+		http.Error(w, "Cannot change a "+task.Status+" task to "+req.Status, http.StatusConflict)
+		return
+	}
+
+	updated, err := h.repo.UpdateStatus(task.ID, task.Status, req.Status, req.By)
+	if err != nil {
+		writeError(w, err, "Failed to update task status")
+		return
+	}
+
+	updated.MarkOverdue(today(r))
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// checkTask trims and validates the editable fields of a task, returning what is wrong with it
+func checkTask(t *database.Task) string {
+	t.Title = strings.TrimSpace(t.Title)
+	t.AssignedTo = strings.TrimSpace(t.AssignedTo)
+	if t.Title == "" || t.AssignedTo == "" {
+		return "title and assignedTo are required"
+	}
+	if t.PatientHN != nil {
+		if _, err := parseHN(*t.PatientHN); err != nil {
+			return "Invalid patient HN format"
+		}
+	}
+	if t.DueDate != nil {
+		if _, err := time.Parse("2006-01-02", *t.DueDate); err != nil {
+			return "Invalid dueDate, expected YYYY-MM-DD"
+		}
+	}
+	return ""
+}
+
+func (h *TaskHandler) loadTask(w http.ResponseWriter, r *http.Request) (*database.Task, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid task ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	task, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve task")
+		return nil, false
+	}
+	return task, true
+}
+
+// today is the current date at the request's branch, YYYY-MM-DD
+func today(r *http.Request) string {
+	return localNow(r).Format("2006-01-02")
+}
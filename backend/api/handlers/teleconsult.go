@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/prom"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// teleconsultOpensBefore is how early before its time a video visit can be joined
+	teleconsultOpensBefore = 15 * time.Minute
+	// teleconsultLapsesAfter is how long after its time a video visit the
+	// doctor has not started can still be joined
+	teleconsultLapsesAfter = 2 * time.Hour
+)
+
+// TeleconsultRepository interface for video visit session storage
+type TeleconsultRepository interface {
+	Create(t *database.Teleconsult) error
+	GetByID(id int) (*database.Teleconsult, error)
+	GetByToken(token string) (*database.Teleconsult, error)
+	List(f database.TeleconsultFilter) ([]database.Teleconsult, error)
+	MarkPatientJoined(id int) (*database.Teleconsult, error)
+	Start(id, visitID int) (*database.Teleconsult, error)
+	End(id int) (*database.Teleconsult, error)
+	Cancel(id int) (*database.Teleconsult, error)
+}
+
+// TeleconsultHandler handles video visits: sessions held for appointments,
+// joined by the patient and the doctor through their own links and recorded
+// as the appointment's visit
+type TeleconsultHandler struct {
+	repo          TeleconsultRepository
+	appointments  AppointmentRepository
+	patients      PatientRepository
+	visits        *EncounterHandler
+	publicBaseURL string
+	videoURL      string // the video service rooms are opened at, if any
+}
+
+// NewTeleconsultHandler creates a new teleconsult handler. Join links are
+// under publicBaseURL; with a videoURL, joining also answers the room's
+// address there.
+func NewTeleconsultHandler(repo TeleconsultRepository, appointments AppointmentRepository, patients PatientRepository, visits *EncounterHandler, publicBaseURL, videoURL string) *TeleconsultHandler {
+	return &TeleconsultHandler{
+		repo:          repo,
+		appointments:  appointments,
+		patients:      patients,
+		visits:        visits,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+		videoURL:      strings.TrimRight(videoURL, "/"),
+	}
+}
+
+// TeleconsultSession is a video visit as staff see it, with both join links
+type TeleconsultSession struct {
+	database.Teleconsult
+	PatientLink string `json:"patientLink"`
+	DoctorLink  string `json:"doctorLink"`
+}
+
+// CreatedTeleconsult is a new video visit with a message ready to send to the patient
+type CreatedTeleconsult struct {
+	TeleconsultSession
+	Message string `json:"message"` // SMS or LINE text with the patient's link
+}
+
+// TeleconsultJoin is a session as a join link shows it and, once joined, the
+// room to connect to
+type TeleconsultJoin struct {
+	Room        string    `json:"room,omitempty"`
+	VideoURL    string    `json:"videoUrl,omitempty"` // the room at the clinic's video service
+	Role        string    `json:"role"`               // patient or doctor
+	Status      string    `json:"status"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+	DoctorName  string    `json:"doctorName"`
+	PatientName string    `json:"patientName"`       // shortened on the patient's link
+	VisitID     *int      `json:"visitId,omitempty"` // on the doctor's link, the visit to record the consult in
+}
+
+// CreateTeleconsult holds an appointment as a video visit at the appointment's
+// time, or scheduledAt, and returns the patient's and doctor's join links. An
+// appointment has at most one session that is not cancelled.
+func (h *TeleconsultHandler) CreateTeleconsult(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid appointment ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		ScheduledAt *time.Time `json:"scheduledAt"`
+		CreatedBy   string     `json:"createdBy"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	createdBy := strings.TrimSpace(req.CreatedBy)
+	if createdBy == "" {
+		createdBy = reqctx.UserName(r.Context())
+	}
+	if createdBy == "" {
+		http.Error(w, "createdBy is required", http.StatusBadRequest)
+		return
+	}
+
+	appointment, err := h.appointments.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve appointment")
+		return
+	}
+	if !appointment.Active() {
+		http.Error(w, "Cannot hold a "+appointment.Status+" appointment as a video visit", http.StatusConflict)
+		return
+	}
+	scheduledAt := appointment.StartsAt
+	if req.ScheduledAt != nil {
+		scheduledAt = *req.ScheduledAt
+	}
+	if scheduledAt.Add(teleconsultLapsesAfter).Before(time.Now()) {
+		http.Error(w, "scheduledAt has passed", http.StatusBadRequest)
+		return
+	}
+
+	session := database.Teleconsult{
+		AppointmentID: appointment.ID,
+		PatientHN:     appointment.PatientHN,
+		DoctorID:      appointment.DoctorID,
+		DoctorName:    appointment.DoctorName,
+		ScheduledAt:   scheduledAt,
+		Status:        database.TeleconsultScheduled,
+		CreatedBy:     createdBy,
+	}
+	for _, token := range []*string{&session.RoomToken, &session.PatientToken, &session.DoctorToken} {
+		if *token, err = prom.NewLinkToken(); err != nil {
+			writeError(w, err, "Failed to create teleconsult")
+			return
+		}
+	}
+	if err := h.repo.Create(&session); err != nil {
+		writeError(w, err, "Failed to create teleconsult")
+		return
+	}
+
+	created := h.session(&session)
+	at := session.ScheduledAt.In(reqctx.Location(r.Context()))
+	writeJSON(w, http.StatusCreated, CreatedTeleconsult{
+		TeleconsultSession: created,
+		Message: fmt.Sprintf("นัดพบแพทย์ออนไลน์ของคุณ %02d/%02d/%d %02d:%02d น. เข้าร่วมได้ที่ %s",
+			at.Day(), at.Month(), at.Year()+543, at.Hour(), at.Minute(), created.PatientLink),
+	})
+}
+
+// GetTeleconsults lists video visits scheduled ?from=&to= (default today),
+// optionally for one ?doctorId= or in one ?status=
+func (h *TeleconsultHandler) GetTeleconsults(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var f database.TeleconsultFilter
+	if q.Get("from") == "" && q.Get("to") == "" {
+		f.From = midnight(localNow(r))
+		f.To = f.From.AddDate(0, 0, 1)
+	} else {
+		from, to, err := dateRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.From, f.To = from, to
+	}
+	if s := q.Get("doctorId"); s != "" {
+		var err error
+		if f.DoctorID, err = strconv.Atoi(s); err != nil {
+			http.Error(w, "Invalid doctorId", http.StatusBadRequest)
+			return
+		}
+	}
+	if f.Status = q.Get("status"); f.Status != "" && !oneOf(f.Status, database.TeleconsultStatuses) {
+		http.Error(w, "status must be one of "+strings.Join(database.TeleconsultStatuses, ", "), http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := h.repo.List(f)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve teleconsults")
+		return
+	}
+
+	found := []TeleconsultSession{}
+	for i := range sessions {
+		found = append(found, h.session(&sessions[i]))
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+// GetTeleconsult returns one video visit
+func (h *TeleconsultHandler) GetTeleconsult(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.loadSession(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.session(session))
+}
+
+// CancelTeleconsult calls off a video visit the doctor has not started; the
+// appointment itself is left as it is
+func (h *TeleconsultHandler) CancelTeleconsult(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.loadSession(w, r)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.repo.Cancel(session.ID)
+	if err != nil {
+		writeError(w, err, "Failed to cancel teleconsult")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.session(cancelled))
+}
+
+// EndTeleconsult ends a video visit in progress, e.g. when the doctor's
+// connection dropped before they hung up. The visit stays open for the
+// doctor's notes until it is closed.
+func (h *TeleconsultHandler) EndTeleconsult(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.loadSession(w, r)
+	if !ok {
+		return
+	}
+
+	ended, err := h.repo.End(session.ID)
+	if err != nil {
+		writeError(w, err, "Failed to end teleconsult")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.session(ended))
+}
+
+// GetPublicTeleconsult is the page a join link opens; no login. It shows the
+// session without the room, which JoinTeleconsult gives.
+func (h *TeleconsultHandler) GetPublicTeleconsult(w http.ResponseWriter, r *http.Request) {
+	session, asDoctor, ok := h.loadByToken(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, h.view(session, asDoctor))
+}
+
+// JoinTeleconsult joins the session behind a join link; no login. It answers
+// the room from 15 minutes before the session's time. The patient's first
+// join is recorded; the doctor's starts the session, opening the
+// appointment's visit to record the consult in. Ended and cancelled sessions,
+// and those the doctor never started, answer 410.
+func (h *TeleconsultHandler) JoinTeleconsult(w http.ResponseWriter, r *http.Request) {
+	session, asDoctor, ok := h.loadByToken(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	switch {
+	case session.Status == database.TeleconsultEnded || session.Status == database.TeleconsultCancelled:
+		http.Error(w, "This video visit has "+session.Status, http.StatusGone)
+		return
+	case session.Status == database.TeleconsultScheduled && now.After(session.ScheduledAt.Add(teleconsultLapsesAfter)):
+		http.Error(w, "This video visit has lapsed; please call the clinic", http.StatusGone)
+		return
+	case session.Status == database.TeleconsultScheduled && now.Before(session.ScheduledAt.Add(-teleconsultOpensBefore)):
+		at := session.ScheduledAt.In(reqctx.Location(r.Context()))
+		http.Error(w, fmt.Sprintf("This video visit opens at %02d:%02d", at.Add(-teleconsultOpensBefore).Hour(),
+			at.Add(-teleconsultOpensBefore).Minute()), http.StatusConflict)
+		return
+	}
+
+	var err error
+	if !asDoctor {
+		session, err = h.repo.MarkPatientJoined(session.ID)
+	} else if session.Status == database.TeleconsultScheduled {
+		session, err = h.start(session, now)
+	}
+	if err != nil {
+		writeError(w, err, "Failed to join teleconsult")
+		return
+	}
+
+	join := h.view(session, asDoctor)
+	join.Room = session.RoomToken
+	if h.videoURL != "" {
+		join.VideoURL = h.videoURL + "/" + session.RoomToken
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, join)
+}
+
+// LeaveTeleconsult is called when the doctor hangs up, ending the session;
+// the patient leaving does not end it, so their link answers 403
+func (h *TeleconsultHandler) LeaveTeleconsult(w http.ResponseWriter, r *http.Request) {
+	session, asDoctor, ok := h.loadByToken(w, r)
+	if !ok {
+		return
+	}
+	if !asDoctor {
+		http.Error(w, "Only the doctor can end a video visit", http.StatusForbidden)
+		return
+	}
+
+	ended, err := h.repo.End(session.ID)
+	if err != nil {
+		writeError(w, err, "Failed to end teleconsult")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.view(ended, true))
+}
+
+// start opens the visit a session is recorded as and moves it in progress.
+// When the doctor joins twice at once, the second finds it already started.
+func (h *TeleconsultHandler) start(session *database.Teleconsult, now time.Time) (*database.Teleconsult, error) {
+	appointment, err := h.appointments.GetByID(session.AppointmentID)
+	if err != nil {
+		return nil, err
+	}
+	visitID, err := h.visits.CreateTeleconsultVisit(appointment, now)
+	if err != nil {
+		return nil, err
+	}
+	started, err := h.repo.Start(session.ID, visitID)
+	if apperr.Is(err, apperr.KindConflict) {
+		if current, err := h.repo.GetByID(session.ID); err == nil && current.Status == database.TeleconsultInProgress {
+			return current, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Teleconsult %d for appointment %d started, recorded as visit %d", started.ID, started.AppointmentID, visitID)
+	return started, nil
+}
+
+// view is a session as its patient's or doctor's join link shows it, without the room
+func (h *TeleconsultHandler) view(t *database.Teleconsult, asDoctor bool) TeleconsultJoin {
+	view := TeleconsultJoin{
+		Role:        "patient",
+		Status:      t.Status,
+		ScheduledAt: t.ScheduledAt,
+		DoctorName:  t.DoctorName,
+	}
+	if n, err := parseHN(t.PatientHN); err == nil {
+		if patient, err := h.patients.GetByID(n); err == nil {
+			view.PatientName = maskName(patient.FullName)
+			if asDoctor {
+				view.PatientName = patient.FullName
+			}
+		}
+	}
+	if asDoctor {
+		view.Role = "doctor"
+		view.VisitID = t.VisitID
+	}
+	return view
+}
+
+// session adds the join links to a video visit
+func (h *TeleconsultHandler) session(t *database.Teleconsult) TeleconsultSession {
+	return TeleconsultSession{
+		Teleconsult: *t,
+		PatientLink: h.publicBaseURL + "/public/teleconsult/" + t.PatientToken,
+		DoctorLink:  h.publicBaseURL + "/public/teleconsult/" + t.DoctorToken,
+	}
+}
+
+func (h *TeleconsultHandler) loadSession(w http.ResponseWriter, r *http.Request) (*database.Teleconsult, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid teleconsult ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	session, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve teleconsult")
+		return nil, false
+	}
+	return session, true
+}
+
+// loadByToken finds the session a join link belongs to and whether it is the doctor's
+func (h *TeleconsultHandler) loadByToken(w http.ResponseWriter, r *http.Request) (*database.Teleconsult, bool, bool) {
+	token := mux.Vars(r)["token"]
+	session, err := h.repo.GetByToken(token)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve teleconsult")
+		return nil, false, false
+	}
+	return session, token == session.DoctorToken, true
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// TriageRepository interface for triage storage
+type TriageRepository interface {
+	Create(t *database.Triage) error
+	GetByQueueEntry(queueEntryID int) ([]database.Triage, error)
+}
+
+// TriageQueue is the part of the queue triage reorders
+type TriageQueue interface {
+	GetByID(id int) (*database.QueueEntry, error)
+	SetUrgency(id int, urgency string) (*database.QueueEntry, error)
+}
+
+// TriageVitals records and looks up the vital signs taken at triage
+type TriageVitals interface {
+	Create(v *database.VitalSigns) error
+	GetByID(id int) (*database.VitalSigns, error)
+}
+
+// triageResult is a recorded triage with the queue entry it moved
+type triageResult struct {
+	database.Triage
+	QueueEntry *database.QueueEntry `json:"queueEntry"`
+}
+
+// TriageHandler handles triage of waiting patients
+type TriageHandler struct {
+	repo   TriageRepository
+	queue  TriageQueue
+	vitals TriageVitals
+}
+
+// NewTriageHandler creates a new triage handler
+func NewTriageHandler(repo TriageRepository, queue TriageQueue, vitals TriageVitals) *TriageHandler {
+	return &TriageHandler{repo: repo, queue: queue, vitals: vitals}
+}
+
+// TriageQueueEntry records a waiting patient's presenting complaint, urgency
+// and optional pain score and initial vitals, and moves them up or down the
+// queue by urgency; triagedBy defaults to the signed-in user
+func (h *TriageHandler) TriageQueueEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.loadEntry(w, r)
+	if !ok {
+		return
+	}
+	if entry.Status != database.QueueWaiting {
+		http.Error(w, "Only waiting patients can be triaged; this one is "+entry.Status, http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		PresentingComplaint string               `json:"presentingComplaint"`
+		Urgency             string               `json:"urgency"`
+		PainScore           *int                 `json:"painScore,omitempty"`
+		Vitals              *database.VitalSigns `json:"vitals,omitempty"`
+		Notes               string               `json:"notes"`
+		TriagedBy           string               `json:"triagedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	triage := database.Triage{
+		QueueEntryID:        entry.ID,
+		PatientHN:           entry.PatientHN,
+		VisitID:             entry.VisitID,
+		PresentingComplaint: strings.TrimSpace(req.PresentingComplaint),
+		Urgency:             strings.ToLower(strings.TrimSpace(req.Urgency)),
+		PainScore:           req.PainScore,
+		Notes:               optionalText(req.Notes),
+		TriagedBy:           strings.TrimSpace(req.TriagedBy),
+	}
+	if triage.TriagedBy == "" {
+		triage.TriagedBy = reqctx.UserName(r.Context())
+	}
+	if msg := checkTriage(&triage); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if req.Vitals != nil {
+		vitals := *req.Vitals
+		vitals.PatientHN, vitals.VisitID = entry.PatientHN, entry.VisitID
+		vitals.MeasuredAt, vitals.RecordedBy = time.Now(), triage.TriagedBy
+		if msg := checkVitals(&vitals); msg != "" {
+			http.Error(w, "vitals: "+msg, http.StatusBadRequest)
+			return
+		}
+		if err := h.vitals.Create(&vitals); err != nil {
+			writeError(w, err, "Failed to record vital signs")
+			return
+		}
+		triage.VitalsID, triage.Vitals = &vitals.ID, &vitals
+	}
+
+	if err := h.repo.Create(&triage); err != nil {
+		writeError(w, err, "Failed to record triage")
+		return
+	}
+	updated, err := h.queue.SetUrgency(entry.ID, triage.Urgency)
+	if err != nil {
+		writeError(w, err, "Failed to update queue entry")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, triageResult{Triage: triage, QueueEntry: updated})
+}
+
+// GetQueueEntryTriage lists a queue entry's triage assessments with their
+// vital signs, latest (the one that counts) first
+func (h *TriageHandler) GetQueueEntryTriage(w http.ResponseWriter, r *http.Request) {
+	entry, ok := h.loadEntry(w, r)
+	if !ok {
+		return
+	}
+
+	triages, err := h.repo.GetByQueueEntry(entry.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve triage")
+		return
+	}
+	for i := range triages {
+		if triages[i].VitalsID == nil {
+			continue
+		}
+		vitals, err := h.vitals.GetByID(*triages[i].VitalsID)
+		if err != nil {
+			if !apperr.Is(err, apperr.KindNotFound) {
+				writeError(w, err, "Failed to retrieve vital signs")
+				return
+			}
+			log.Printf("Vital signs %d of triage %d are missing", *triages[i].VitalsID, triages[i].ID)
+			continue
+		}
+		triages[i].Vitals = vitals
+	}
+
+	writeJSON(w, http.StatusOK, triages)
+}
+
+func (h *TriageHandler) loadEntry(w http.ResponseWriter, r *http.Request) (*database.QueueEntry, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid queue entry ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	entry, err := h.queue.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve queue entry")
+		return nil, false
+	}
+	return entry, true
+}
+
+// checkTriage validates a triage assessment, returning what is wrong with it
+func checkTriage(t *database.Triage) string {
+	if t.PresentingComplaint == "" {
+		return "presentingComplaint is required"
+	}
+	known := false
+	for _, level := range database.TriageLevels {
+		known = known || t.Urgency == level
+	}
+	if !known {
+		return "urgency must be one of " + strings.Join(database.TriageLevels, ", ")
+	}
+	if t.PainScore != nil && (*t.PainScore < 0 || *t.PainScore > 10) {
+		return "painScore must be between 0 and 10"
+	}
+	if t.TriagedBy == "" {
+		return "triagedBy is required"
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// upload is a file received in a multipart "file" field
+type upload struct {
+	data        []byte
+	contentType string // sniffed from the data, not taken from the client
+	ext         string // file extension for contentType, e.g. ".pdf"
+	header      *multipart.FileHeader
+}
+
+// readUpload reads the multipart "file" field of a request, accepting up to
+// maxSize bytes of the content types in allowed (content type to extension).
+// kinds names the accepted types in the error, e.g. "PDF, JPEG or PNG".
+func readUpload(w http.ResponseWriter, r *http.Request, maxSize int64, allowed map[string]string, kinds string) (*upload, bool) {
+	tooLarge := fmt.Sprintf("File is larger than %d MB", maxSize>>20)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			http.Error(w, tooLarge, http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		http.Error(w, "A multipart file field is required", http.StatusBadRequest)
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		http.Error(w, "Failed to read uploaded file", http.StatusBadRequest)
+		return nil, false
+	}
+	if int64(len(data)) > maxSize {
+		http.Error(w, tooLarge, http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	if len(data) == 0 {
+		http.Error(w, "Uploaded file is empty", http.StatusBadRequest)
+		return nil, false
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := allowed[contentType]
+	if !ok {
+		http.Error(w, "File must be "+kinds, http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+	return &upload{data: data, contentType: contentType, ext: ext, header: header}, true
+}
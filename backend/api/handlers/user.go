@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/password"
+	"clinic/backend/internal/reqctx"
+)
+
+// usernamePattern is what a username may look like once lower-cased
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{2,31}$`)
+
+// UserRepository interface for staff account storage
+type UserRepository interface {
+	Create(u *database.User) error
+	GetByID(id int) (*database.User, error)
+	GetAll(f database.UserFilter) ([]database.User, error)
+	Update(u *database.User) error
+	SetActive(id int, active bool, by string) (*database.User, error)
+	SetPassword(id int, hash string, mustChange bool) (*database.User, error)
+}
+
+// UserHandler handles staff accounts: creating them, assigning roles,
+// deactivating them and resetting their passwords. Accounts are the basis
+// for signing in; until then requests are still identified by the admin token.
+type UserHandler struct {
+	repo    UserRepository
+	doctors DoctorRepository
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(repo UserRepository, doctors DoctorRepository) *UserHandler {
+	return &UserHandler{repo: repo, doctors: doctors}
+}
+
+// userRequest creates or updates an account. Password is only read on
+// creation; leave it out to have a temporary one generated.
+type userRequest struct {
+	Username string  `json:"username"`
+	FullName string  `json:"fullName"`
+	Role     string  `json:"role"`
+	DoctorID *int    `json:"doctorId"`
+	Email    *string `json:"email"`
+	Password string  `json:"password"`
+}
+
+// passwordResetRequest sets an account's password; leave it out to have a
+// temporary one generated
+type passwordResetRequest struct {
+	Password string `json:"password"`
+}
+
+// userPassword is an account with the temporary password generated for it,
+// which is shown only in this response
+type userPassword struct {
+	User              *database.User `json:"user"`
+	TemporaryPassword string         `json:"temporaryPassword,omitempty"`
+}
+
+// GetUsers lists staff accounts (?role=, ?active=true)
+func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	filter := database.UserFilter{
+		Role:       r.URL.Query().Get("role"),
+		ActiveOnly: r.URL.Query().Get("active") == "true",
+	}
+	if filter.Role != "" && !knownRole(filter.Role) {
+		http.Error(w, "role must be one of "+strings.Join(database.UserRoles, ", "), http.StatusBadRequest)
+		return
+	}
+
+	users, err := h.repo.GetAll(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve users")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, users)
+}
+
+// GetUser returns one staff account
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve user")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// CreateUser creates a staff account with a role. Doctors' accounts name
+// their doctor record in doctorId. Whether the password is given or
+// generated, the user must change it when they first sign in.
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	user := database.User{Username: strings.ToLower(strings.TrimSpace(req.Username))}
+	if !usernamePattern.MatchString(user.Username) {
+		http.Error(w, "username must be 3 to 32 letters, digits, dots, dashes or underscores, starting with a letter or digit", http.StatusBadRequest)
+		return
+	}
+	if !h.applyDetails(w, &user, req) {
+		return
+	}
+
+	secret, temporary, ok := choosePassword(w, req.Password)
+	if !ok {
+		return
+	}
+	hash, err := password.Hash(secret)
+	if err != nil {
+		writeError(w, err, "Failed to create user")
+		return
+	}
+
+	user.PasswordHash = hash
+	user.MustChangePassword = true
+	user.Active = true
+	user.CreatedBy = reqctx.UserName(r.Context())
+	if err := h.repo.Create(&user); err != nil {
+		writeError(w, err, "Failed to create user")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, userPassword{User: &user, TemporaryPassword: temporary})
+}
+
+// UpdateUser changes an account's name, role, doctor record and email; the
+// username cannot be changed and the password has its own reset
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	user, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve user")
+		return
+	}
+
+	var req userRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Password != "" {
+		http.Error(w, "Passwords are changed with POST /api/admin/users/{id}/password-reset", http.StatusBadRequest)
+		return
+	}
+	if !h.applyDetails(w, user, req) {
+		return
+	}
+
+	if err := h.repo.Update(user); err != nil {
+		writeError(w, err, "Failed to update user")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// DeactivateUser stops an account from being used; what the user recorded
+// keeps their name
+func (h *UserHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, false)
+}
+
+// ReactivateUser lets a deactivated account be used again
+func (h *UserHandler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, true)
+}
+
+func (h *UserHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	user, err := h.repo.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve user")
+		return
+	}
+	if user.Active == active {
+		state := "active"
+		if !active {
+			state = "deactivated"
+		}
+		http.Error(w, fmt.Sprintf("User %s is already %s", user.Username, state), http.StatusConflict)
+		return
+	}
+
+	user, err = h.repo.SetActive(id, active, reqctx.UserName(r.Context()))
+	if err != nil {
+		writeError(w, err, "Failed to update user")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// ResetUserPassword replaces an account's password with the one given or a
+// generated temporary one, which the user must change when they next sign in
+func (h *UserHandler) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var req passwordResetRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if _, err := h.repo.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve user")
+		return
+	}
+
+	secret, temporary, ok := choosePassword(w, req.Password)
+	if !ok {
+		return
+	}
+	hash, err := password.Hash(secret)
+	if err != nil {
+		writeError(w, err, "Failed to reset password")
+		return
+	}
+
+	user, err := h.repo.SetPassword(id, hash, true)
+	if err != nil {
+		writeError(w, err, "Failed to reset password")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, userPassword{User: user, TemporaryPassword: temporary})
+}
+
+// applyDetails validates and copies the editable fields of req onto u,
+// writing the response when they are invalid
+func (h *UserHandler) applyDetails(w http.ResponseWriter, u *database.User, req userRequest) bool {
+	u.FullName = strings.TrimSpace(req.FullName)
+	u.Role = strings.ToLower(strings.TrimSpace(req.Role))
+	u.Email = nil
+	if req.Email != nil {
+		u.Email = optionalText(*req.Email)
+	}
+	if u.FullName == "" {
+		http.Error(w, "fullName is required", http.StatusBadRequest)
+		return false
+	}
+	if !knownRole(u.Role) {
+		http.Error(w, "role must be one of "+strings.Join(database.UserRoles, ", "), http.StatusBadRequest)
+		return false
+	}
+	if u.Email != nil && !strings.Contains(*u.Email, "@") {
+		http.Error(w, "Invalid email", http.StatusBadRequest)
+		return false
+	}
+
+	u.DoctorID = req.DoctorID
+	switch {
+	case u.Role == reqctx.RoleDoctor && u.DoctorID == nil:
+		http.Error(w, "doctorId is required for a doctor's account", http.StatusBadRequest)
+		return false
+	case u.Role != reqctx.RoleDoctor && u.DoctorID != nil:
+		http.Error(w, "doctorId is only for a doctor's account", http.StatusBadRequest)
+		return false
+	case u.DoctorID != nil:
+		if _, err := h.doctors.GetByID(*u.DoctorID); err != nil {
+			if apperr.Is(err, apperr.KindNotFound) {
+				http.Error(w, fmt.Sprintf("Doctor %d does not exist", *u.DoctorID), http.StatusBadRequest)
+				return false
+			}
+			writeError(w, err, "Failed to retrieve doctor")
+			return false
+		}
+	}
+	return true
+}
+
+// choosePassword returns the password to set: the one given, once it meets
+// the policy, or a generated one that is also returned as temporary
+func choosePassword(w http.ResponseWriter, given string) (secret, temporary string, ok bool) {
+	if given != "" {
+		if msg := password.Check(given); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return "", "", false
+		}
+		return given, "", true
+	}
+	temporary, err := password.Temporary()
+	if err != nil {
+		writeError(w, err, "Failed to generate password")
+		return "", "", false
+	}
+	return temporary, temporary, true
+}
+
+func knownRole(role string) bool {
+	for _, known := range database.UserRoles {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
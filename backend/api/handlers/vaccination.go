@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/immunization"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultDueWithin is how far ahead due-dose listings look unless ?within= says otherwise, in days
+const defaultDueWithin = 30
+
+// VaccinationRepository interface for vaccination record storage
+type VaccinationRepository interface {
+	Create(v *database.Vaccination) error
+	List(hn string) ([]database.Vaccination, error)
+	Delete(id int) error
+}
+
+// VaccineStock is the inventory doses are taken from
+type VaccineStock interface {
+	GetItem(id int) (*database.StockItem, error)
+	GetLevel(itemID int) (*database.StockLevel, error)
+	GetLevels(f database.StockItemFilter) ([]database.StockLevel, error)
+	Record(m *database.StockMovement) error
+}
+
+// VaccineFridges tells which fridge each vaccine lot is stored in
+type VaccineFridges interface {
+	GetFridges() ([]database.Fridge, error)
+	GetFridgeLots(fridgeID int) ([]database.FridgeLot, error)
+}
+
+// VaccinationHandler handles patients' immunization records, due doses and
+// the vaccine lots doses are given from
+type VaccinationHandler struct {
+	repo     VaccinationRepository
+	patients PatientRepository
+	stock    VaccineStock
+	recalls  LotRecallChecker
+	holds    LotHoldChecker
+	fridges  VaccineFridges
+}
+
+// NewVaccinationHandler creates a new vaccination handler.
+// Nil recall or hold checkers let every lot be given.
+func NewVaccinationHandler(repo VaccinationRepository, patients PatientRepository, stock VaccineStock, recalls LotRecallChecker, holds LotHoldChecker, fridges VaccineFridges) *VaccinationHandler {
+	return &VaccinationHandler{repo: repo, patients: patients, stock: stock, recalls: recalls, holds: holds, fridges: fridges}
+}
+
+// patientDueDoses is a patient's doses that are due or overdue
+type patientDueDoses struct {
+	PatientHN string                 `json:"patientHn"`
+	FullName  string                 `json:"fullName"`
+	Phone     *string                `json:"phone,omitempty"`
+	Doses     []immunization.DueDose `json:"doses"`
+}
+
+// RecordVaccination records a dose given to a patient; administeredDate
+// defaults to today and administeredBy to the signed-in user. The dose must
+// come from a vaccine lot in stock (stockItemId and lotNumber) that had not
+// expired on the day and is not recalled or held for cold-chain review; one
+// unit of the lot is taken out of stock against the patient.
+func (h *VaccinationHandler) RecordVaccination(w http.ResponseWriter, r *http.Request) {
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+
+	var vaccination database.Vaccination
+	if err := json.NewDecoder(r.Body).Decode(&vaccination); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	vaccination.PatientHN = patient.HN
+	if vaccination.AdministeredBy == "" {
+		vaccination.AdministeredBy = reqctx.UserName(r.Context())
+	}
+	if vaccination.AdministeredDate == "" {
+		vaccination.AdministeredDate = today(r)
+	}
+	if msg := checkVaccination(&vaccination, patient, today(r)); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	given, err := h.repo.List(patient.HN)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve vaccinations")
+		return
+	}
+	for _, v := range given {
+		if strings.EqualFold(v.Vaccine, vaccination.Vaccine) && v.DoseNumber == vaccination.DoseNumber {
+			http.Error(w, vaccination.Vaccine+" dose "+strconv.Itoa(v.DoseNumber)+" is already recorded", http.StatusConflict)
+			return
+		}
+	}
+	if !h.checkVaccineLot(w, &vaccination) {
+		return
+	}
+
+	if err := h.repo.Create(&vaccination); err != nil {
+		writeError(w, err, "Failed to record vaccination")
+		return
+	}
+
+	// Recording the dose first leaves nothing to undo in the stock history when it fails
+	patientHN := vaccination.PatientHN
+	reference := fmt.Sprintf("VAC-%06d", vaccination.ID)
+	recordedBy := reqctx.UserName(r.Context())
+	if recordedBy == "" {
+		recordedBy = vaccination.AdministeredBy
+	}
+	movement := database.StockMovement{
+		ItemID:     *vaccination.StockItemID,
+		Type:       database.MovementDispense,
+		Quantity:   -1,
+		LotNumber:  vaccination.LotNumber,
+		PatientHN:  &patientHN,
+		Reference:  &reference,
+		RecordedBy: recordedBy,
+	}
+	if err := h.stock.Record(&movement); err != nil {
+		if undoErr := h.repo.Delete(vaccination.ID); undoErr != nil {
+			log.Printf("Failed to remove vaccination %d after its stock could not be taken: %v", vaccination.ID, undoErr)
+		}
+		writeError(w, err, "Failed to take the dose from stock")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, vaccination)
+}
+
+// checkVaccineLot makes sure a dose's lot is a vaccine lot in stock that may
+// be given on the dose's date, writing the error when it is not
+func (h *VaccinationHandler) checkVaccineLot(w http.ResponseWriter, v *database.Vaccination) bool {
+	if v.StockItemID == nil {
+		http.Error(w, "stockItemId is required; doses are given from a vaccine lot in stock", http.StatusBadRequest)
+		return false
+	}
+	item, err := h.stock.GetItem(*v.StockItemID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock item")
+		return false
+	}
+	if item.Kind != database.StockItemVaccine {
+		http.Error(w, fmt.Sprintf("Stock item %d (%s) is not a vaccine", item.ID, item.Name), http.StatusBadRequest)
+		return false
+	}
+
+	level, err := h.stock.GetLevel(item.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock level")
+		return false
+	}
+	var lot *database.StockLot
+	for i := range level.Lots {
+		if level.Lots[i].LotNumber == v.LotNumber {
+			lot = &level.Lots[i]
+		}
+	}
+	if lot == nil {
+		http.Error(w, fmt.Sprintf("Lot %s of %s is not in stock", v.LotNumber, item.Name), http.StatusConflict)
+		return false
+	}
+	if lot.ExpiryDate != nil && *lot.ExpiryDate < v.AdministeredDate {
+		http.Error(w, fmt.Sprintf("Lot %s of %s expired on %s", v.LotNumber, item.Name, *lot.ExpiryDate), http.StatusConflict)
+		return false
+	}
+
+	blocked, reason, err := lotBlocked(h.recalls, h.holds, item.ID, v.LotNumber)
+	if err != nil {
+		writeError(w, err, "Failed to check lot status")
+		return false
+	}
+	if blocked {
+		http.Error(w, fmt.Sprintf("Lot %s %s", v.LotNumber, reason), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// GetPatientVaccinations lists the doses a patient has had, in the order they were given
+func (h *VaccinationHandler) GetPatientVaccinations(w http.ResponseWriter, r *http.Request) {
+	vaccinations, err := h.repo.List(mux.Vars(r)["hn"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve vaccinations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, vaccinations)
+}
+
+// DeleteVaccination removes a dose recorded by mistake. The dose taken from
+// stock stays taken; correct the lot with an inventory adjustment if the vial
+// was not used.
+func (h *VaccinationHandler) DeleteVaccination(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid vaccination ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		writeError(w, err, "Failed to delete vaccination")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPatientDueDoses lists the scheduled doses a patient has not had that are
+// overdue, due, or due within ?within= days (default 30), earliest first
+func (h *VaccinationHandler) GetPatientDueDoses(w http.ResponseWriter, r *http.Request) {
+	within, ok := dueWithin(r)
+	if !ok {
+		http.Error(w, "within must be a number of days from 0 to 365", http.StatusBadRequest)
+		return
+	}
+	patient, ok := h.loadPatient(w, r)
+	if !ok {
+		return
+	}
+	day := midnight(localNow(r))
+	dob, ok := birthDate(patient, day.Location())
+	if !ok {
+		http.Error(w, "Patient's date of birth is not on file", http.StatusConflict)
+		return
+	}
+
+	given, err := h.repo.List(patient.HN)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve vaccinations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, immunization.Due(dob, given, day, within))
+}
+
+// GetOverdueDoses lists the patients with overdue doses, for recall lists;
+// ?status=due also includes doses that have only just fallen due. Patients
+// without a date of birth on file are left out.
+func (h *VaccinationHandler) GetOverdueDoses(w http.ResponseWriter, r *http.Request) {
+	includeDue := false
+	switch r.URL.Query().Get("status") {
+	case "", immunization.StatusOverdue:
+	case immunization.StatusDue:
+		includeDue = true
+	default:
+		http.Error(w, "status must be overdue or due", http.StatusBadRequest)
+		return
+	}
+
+	patients, err := h.patients.GetAll()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patients")
+		return
+	}
+	all, err := h.repo.List("")
+	if err != nil {
+		writeError(w, err, "Failed to retrieve vaccinations")
+		return
+	}
+	given := make(map[string][]database.Vaccination)
+	for _, v := range all {
+		given[v.PatientHN] = append(given[v.PatientHN], v)
+	}
+
+	day := midnight(localNow(r))
+	result := []patientDueDoses{}
+	for i := range patients {
+		p := &patients[i]
+		dob, ok := birthDate(p, day.Location())
+		if !ok {
+			continue
+		}
+		var doses []immunization.DueDose
+		for _, d := range immunization.Due(dob, given[p.HN], day, 0) {
+			if d.Status == immunization.StatusOverdue || (includeDue && d.Status == immunization.StatusDue) {
+				doses = append(doses, d)
+			}
+		}
+		if len(doses) > 0 {
+			result = append(result, patientDueDoses{PatientHN: p.HN, FullName: p.FullName, Phone: p.Phone, Doses: doses})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// GetSchedule returns the standard immunization schedule due doses are worked out from
+func (h *VaccinationHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, immunization.Schedule)
+}
+
+func (h *VaccinationHandler) loadPatient(w http.ResponseWriter, r *http.Request) (*database.Patient, bool) {
+	id, err := parseHN(mux.Vars(r)["hn"])
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return nil, false
+	}
+
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	return patient, true
+}
+
+// checkVaccination trims and validates a dose, returning what is wrong with it.
+// Vaccines on the standard schedule take the schedule's spelling.
+func checkVaccination(v *database.Vaccination, patient *database.Patient, day string) string {
+	v.Vaccine = strings.TrimSpace(v.Vaccine)
+	v.LotNumber = strings.TrimSpace(v.LotNumber)
+	if v.Vaccine == "" || v.LotNumber == "" {
+		return "vaccine and lotNumber are required"
+	}
+	if name, ok := immunization.Known(v.Vaccine); ok {
+		v.Vaccine = name
+	}
+	if v.DoseNumber < 1 {
+		return "doseNumber must be 1 or more"
+	}
+	if v.AdministeredBy == "" {
+		return "administeredBy is required"
+	}
+	if _, err := time.Parse("2006-01-02", v.AdministeredDate); err != nil {
+		return "Invalid administeredDate, expected YYYY-MM-DD"
+	}
+	if v.AdministeredDate > day {
+		return "administeredDate cannot be in the future"
+	}
+	if patient.DateOfBirth != nil && v.AdministeredDate < *patient.DateOfBirth {
+		return "administeredDate cannot be before the patient's date of birth"
+	}
+	return ""
+}
+
+// birthDate parses a patient's date of birth, at midnight in loc
+func birthDate(p *database.Patient, loc *time.Location) (time.Time, bool) {
+	if p.DateOfBirth == nil {
+		return time.Time{}, false
+	}
+	dob, err := time.ParseInLocation("2006-01-02", *p.DateOfBirth, loc)
+	return dob, err == nil
+}
+
+// midnight is the start of t's day in t's location
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// dueWithin reads ?within=, the days ahead a due-dose listing looks
+func dueWithin(r *http.Request) (time.Duration, bool) {
+	days := defaultDueWithin
+	if s := r.URL.Query().Get("within"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > 365 {
+			return 0, false
+		}
+		days = n
+	}
+	return time.Duration(days) * 24 * time.Hour, true
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// Vaccine lot expiry states
+const (
+	LotExpired  = "expired"
+	LotExpiring = "expiring" // expires within the listing's window
+	LotInDate   = "in_date"
+	LotNoExpiry = "no_expiry" // stock without an expiry date on file, which should not happen for vaccines
+)
+
+// VaccineLotPlace is a fridge a vaccine lot is stored in
+type VaccineLotPlace struct {
+	FridgeID int    `json:"fridgeId"`
+	Fridge   string `json:"fridge"`
+	Location string `json:"location"`
+}
+
+// VaccineLot is one vaccine lot in stock, with where it is kept and how close
+// it is to expiry
+type VaccineLot struct {
+	ItemID       int               `json:"itemId"`
+	ItemName     string            `json:"itemName"`
+	Unit         string            `json:"unit"`
+	LotNumber    string            `json:"lotNumber"`
+	ExpiryDate   *string           `json:"expiryDate,omitempty"` // YYYY-MM-DD
+	DaysToExpiry *int              `json:"daysToExpiry,omitempty"`
+	Expiry       string            `json:"expiry"` // expired, expiring, in_date or no_expiry
+	Quantity     float64           `json:"quantity"`
+	Blocked      string            `json:"blocked,omitempty"` // why the lot must not be given, when recalled or held
+	Fridges      []VaccineLotPlace `json:"fridges"`           // empty when the lot is not in a monitored fridge
+}
+
+// GetVaccineLots lists the vaccine lots in stock, earliest expiry first, each
+// flagged expired, expiring within ?within= days (default 30) or in date,
+// with the fridges they are kept in
+func (h *VaccinationHandler) GetVaccineLots(w http.ResponseWriter, r *http.Request) {
+	h.vaccineLots(w, r, false)
+}
+
+// GetExpiringVaccineLots lists only the vaccine lots in stock that have
+// expired or expire within ?within= days (default 30), earliest first, for
+// using up or writing off
+func (h *VaccinationHandler) GetExpiringVaccineLots(w http.ResponseWriter, r *http.Request) {
+	h.vaccineLots(w, r, true)
+}
+
+func (h *VaccinationHandler) vaccineLots(w http.ResponseWriter, r *http.Request, expiringOnly bool) {
+	within, ok := dueWithin(r)
+	if !ok {
+		http.Error(w, "within must be a number of days from 0 to 365", http.StatusBadRequest)
+		return
+	}
+
+	levels, err := h.stock.GetLevels(database.StockItemFilter{Kind: database.StockItemVaccine})
+	if err != nil {
+		writeError(w, err, "Failed to retrieve stock levels")
+		return
+	}
+	places, err := h.lotPlaces()
+	if err != nil {
+		writeError(w, err, "Failed to retrieve fridge lots")
+		return
+	}
+
+	day := midnight(localNow(r))
+	lots := []VaccineLot{}
+	for _, level := range levels {
+		for _, l := range level.Lots {
+			lot := VaccineLot{
+				ItemID:     level.ItemID,
+				ItemName:   level.Name,
+				Unit:       level.Unit,
+				LotNumber:  l.LotNumber,
+				ExpiryDate: l.ExpiryDate,
+				Expiry:     LotNoExpiry,
+				Quantity:   l.Quantity,
+				Fridges:    places[fridgeLotKey{level.ItemID, l.LotNumber}],
+			}
+			if lot.Fridges == nil {
+				lot.Fridges = []VaccineLotPlace{}
+			}
+			if l.ExpiryDate != nil {
+				if expiry, err := time.ParseInLocation("2006-01-02", *l.ExpiryDate, day.Location()); err == nil {
+					days := int(expiry.Sub(day).Hours() / 24)
+					lot.DaysToExpiry = &days
+					switch {
+					case expiry.Before(day):
+						lot.Expiry = LotExpired
+					case !expiry.After(day.Add(within)):
+						lot.Expiry = LotExpiring
+					default:
+						lot.Expiry = LotInDate
+					}
+				}
+			}
+			if expiringOnly && lot.Expiry != LotExpired && lot.Expiry != LotExpiring {
+				continue
+			}
+			if l.LotNumber != "" {
+				blocked, reason, err := lotBlocked(h.recalls, h.holds, level.ItemID, l.LotNumber)
+				if err != nil {
+					writeError(w, err, "Failed to check lot status")
+					return
+				}
+				if blocked {
+					lot.Blocked = reason
+				}
+			}
+			lots = append(lots, lot)
+		}
+	}
+	sort.Slice(lots, func(i, j int) bool {
+		a, b := lots[i], lots[j]
+		if (a.ExpiryDate == nil) != (b.ExpiryDate == nil) {
+			return b.ExpiryDate == nil
+		}
+		if a.ExpiryDate != nil && *a.ExpiryDate != *b.ExpiryDate {
+			return *a.ExpiryDate < *b.ExpiryDate
+		}
+		if a.ItemName != b.ItemName {
+			return a.ItemName < b.ItemName
+		}
+		return a.LotNumber < b.LotNumber
+	})
+
+	writeJSON(w, http.StatusOK, lots)
+}
+
+type fridgeLotKey struct {
+	itemID    int
+	lotNumber string
+}
+
+// lotPlaces maps each lot kept in a fridge to the fridges it is in
+func (h *VaccinationHandler) lotPlaces() (map[fridgeLotKey][]VaccineLotPlace, error) {
+	places := map[fridgeLotKey][]VaccineLotPlace{}
+	fridges, err := h.fridges.GetFridges()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fridges {
+		lots, err := h.fridges.GetFridgeLots(f.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, lot := range lots {
+			key := fridgeLotKey{lot.ItemID, lot.LotNumber}
+			places[key] = append(places[key], VaccineLotPlace{FridgeID: f.ID, Fridge: f.Name, Location: f.Location})
+		}
+	}
+	return places, nil
+}
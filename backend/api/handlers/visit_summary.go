@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/prom"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultSummaryLinkHours is how long a summary link works unless set
+	defaultSummaryLinkHours = 72
+	// maxSummaryLinkHours keeps medical details from staying reachable for long
+	maxSummaryLinkHours = 30 * 24
+)
+
+// VisitSummaryRepository interface for visit summary link storage
+type VisitSummaryRepository interface {
+	Create(l *database.VisitSummaryLink) error
+	GetByID(id int) (*database.VisitSummaryLink, error)
+	GetByToken(token string) (*database.VisitSummaryLink, error)
+	GetByVisit(visitID int) ([]database.VisitSummaryLink, error)
+	Revoke(id int, by string) (*database.VisitSummaryLink, error)
+	LogAccess(a *database.SummaryLinkAccess) error
+	GetAccesses(linkID int) ([]database.SummaryLinkAccess, error)
+}
+
+// VisitDiagnoses provides the diagnosis codes recorded for a visit
+type VisitDiagnoses interface {
+	GetByVisit(visitID int) ([]database.VisitDiagnosis, error)
+}
+
+// VisitSummaryHandler handles visit summaries and the secure links that share them with patients
+type VisitSummaryHandler struct {
+	repo          VisitSummaryRepository
+	visits        EncounterRepository
+	patients      PatientRepository
+	diagnoses     VisitDiagnoses
+	prescriptions VisitPrescriptions
+	drugs         DrugLookup
+	publicBaseURL string
+}
+
+// NewVisitSummaryHandler creates a new visit summary handler
+func NewVisitSummaryHandler(repo VisitSummaryRepository, visits EncounterRepository, patients PatientRepository, diagnoses VisitDiagnoses, prescriptions VisitPrescriptions, drugs DrugLookup, publicBaseURL string) *VisitSummaryHandler {
+	return &VisitSummaryHandler{
+		repo:          repo,
+		visits:        visits,
+		patients:      patients,
+		diagnoses:     diagnoses,
+		prescriptions: prescriptions,
+		drugs:         drugs,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+	}
+}
+
+// VisitSummary is what a patient is told about a visit: what was found, what
+// to do and what drugs were prescribed
+type VisitSummary struct {
+	PatientHN     string                    `json:"patientHn"`
+	FullName      string                    `json:"fullName"`
+	VisitDate     string                    `json:"visitDate"` // DD/MM/YYYY in the Buddhist Era
+	DoctorName    string                    `json:"doctorName"`
+	Diagnoses     []string                  `json:"diagnoses"` // e.g. "J06.9 Acute upper respiratory infection", primary first
+	Instructions  *string                   `json:"instructions,omitempty"`
+	Prescriptions []PrintedPrescriptionLine `json:"prescriptions"`
+	ExpiresAt     *time.Time                `json:"expiresAt,omitempty"` // when the link showing it stops working
+}
+
+// CreatedSummaryLink is a new summary link with a message ready to send to the patient
+type CreatedSummaryLink struct {
+	Link        string                    `json:"link"`
+	Message     string                    `json:"message"` // SMS or LINE text with the link
+	SummaryLink database.VisitSummaryLink `json:"summaryLink"`
+}
+
+// GetVisitSummary shows staff the summary a link would give the patient
+func (h *VisitSummaryHandler) GetVisitSummary(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+
+	summary, ok := h.summarize(w, r, visit, nil)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// CreateSummaryLink issues a link to a closed visit's summary, valid for
+// validHours (default 72, at most 30 days). Instructions default to the visit's plan.
+func (h *VisitSummaryHandler) CreateSummaryLink(w http.ResponseWriter, r *http.Request) {
+	visit, ok := h.loadVisit(w, r)
+	if !ok {
+		return
+	}
+	if visit.Status != database.EncounterClosed {
+		http.Error(w, "Summaries can only be shared after checkout, once the visit is closed", http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		ValidHours   int     `json:"validHours"`
+		Instructions *string `json:"instructions"`
+		IssuedBy     string  `json:"issuedBy"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.ValidHours == 0 {
+		req.ValidHours = defaultSummaryLinkHours
+	}
+	if req.ValidHours < 1 || req.ValidHours > maxSummaryLinkHours {
+		http.Error(w, fmt.Sprintf("validHours must be from 1 to %d", maxSummaryLinkHours), http.StatusBadRequest)
+		return
+	}
+	issuedBy := strings.TrimSpace(req.IssuedBy)
+	if issuedBy == "" {
+		issuedBy = reqctx.UserName(r.Context())
+	}
+	if issuedBy == "" {
+		http.Error(w, "issuedBy is required", http.StatusBadRequest)
+		return
+	}
+	instructions := optionalText(visit.Plan)
+	if req.Instructions != nil {
+		instructions = optionalText(*req.Instructions)
+	}
+
+	token, err := prom.NewLinkToken()
+	if err != nil {
+		writeError(w, err, "Failed to create summary link")
+		return
+	}
+	link := database.VisitSummaryLink{
+		Token:        token,
+		VisitID:      visit.ID,
+		PatientHN:    visit.PatientHN,
+		Instructions: instructions,
+		IssuedBy:     issuedBy,
+		ExpiresAt:    time.Now().Add(time.Duration(req.ValidHours) * time.Hour),
+	}
+	if err := h.repo.Create(&link); err != nil {
+		writeError(w, err, "Failed to create summary link")
+		return
+	}
+
+	expires := link.ExpiresAt.In(reqctx.Location(r.Context()))
+	url := h.link(&link)
+	writeJSON(w, http.StatusCreated, CreatedSummaryLink{
+		Link: url,
+		Message: fmt.Sprintf("สรุปผลการตรวจและรายการยาของคุณ: %s (ใช้ได้ถึง %02d/%02d/%d %02d:%02d น.)",
+			url, expires.Day(), expires.Month(), expires.Year()+543, expires.Hour(), expires.Minute()),
+		SummaryLink: link,
+	})
+}
+
+// GetSummaryLinks lists the links issued for a visit, newest first, with how often each was opened
+func (h *VisitSummaryHandler) GetSummaryLinks(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	links, err := h.repo.GetByVisit(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve summary links")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, links)
+}
+
+// GetSummaryLinkAccesses returns a link's access log, most recent first,
+// including attempts after it expired or was revoked
+func (h *VisitSummaryHandler) GetSummaryLinkAccesses(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid summary link ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.repo.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve summary link")
+		return
+	}
+
+	accesses, err := h.repo.GetAccesses(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve summary link accesses")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, accesses)
+}
+
+// RevokeSummaryLink stops a link working, e.g. when it was sent to the wrong number
+func (h *VisitSummaryHandler) RevokeSummaryLink(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		http.Error(w, "Invalid summary link ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		RevokedBy string `json:"revokedBy"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	by := strings.TrimSpace(req.RevokedBy)
+	if by == "" {
+		by = reqctx.UserName(r.Context())
+	}
+	if by == "" {
+		http.Error(w, "revokedBy is required", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.repo.Revoke(id, by)
+	if err != nil {
+		writeError(w, err, "Failed to revoke summary link")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, link)
+}
+
+// GetPublicVisitSummary is the page behind a summary link. Every opening is
+// logged, including those refused because the link expired or was revoked.
+func (h *VisitSummaryHandler) GetPublicVisitSummary(w http.ResponseWriter, r *http.Request) {
+	link, err := h.repo.GetByToken(mux.Vars(r)["token"])
+	if err != nil {
+		writeError(w, err, "Failed to retrieve summary")
+		return
+	}
+
+	access := database.SummaryLinkAccess{
+		LinkID:    link.ID,
+		Outcome:   database.SummaryAccessViewed,
+		IPAddress: remoteIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	switch {
+	case link.RevokedAt != nil:
+		access.Outcome = database.SummaryAccessRevoked
+	case time.Now().After(link.ExpiresAt):
+		access.Outcome = database.SummaryAccessExpired
+	}
+	if err := h.repo.LogAccess(&access); err != nil {
+		// An opening that cannot be logged is not shown
+		writeError(w, err, "Failed to retrieve summary")
+		return
+	}
+	if access.Outcome != database.SummaryAccessViewed {
+		http.Error(w, "This link is no longer available; please contact the clinic", http.StatusGone)
+		return
+	}
+
+	visit, err := h.visits.GetByID(link.VisitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return
+	}
+	summary, ok := h.summarize(w, r, visit, link)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// summarize puts a visit's summary together; a link's own instructions and
+// expiry apply when the summary is shown through one
+func (h *VisitSummaryHandler) summarize(w http.ResponseWriter, r *http.Request, visit *database.Encounter, link *database.VisitSummaryLink) (*VisitSummary, bool) {
+	id, err := parseHN(visit.PatientHN)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return nil, false
+	}
+	patient, err := h.patients.GetByID(id)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return nil, false
+	}
+	diagnoses, err := h.diagnoses.GetByVisit(visit.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve diagnoses")
+		return nil, false
+	}
+	prescriptions, err := h.prescriptions.GetByVisit(visit.ID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve prescriptions")
+		return nil, false
+	}
+
+	started := visit.StartedAt.In(reqctx.Location(r.Context()))
+	summary := &VisitSummary{
+		PatientHN:     patient.HN,
+		FullName:      patient.FullName,
+		VisitDate:     fmt.Sprintf("%02d/%02d/%d", started.Day(), started.Month(), started.Year()+543),
+		DoctorName:    visit.DoctorName,
+		Diagnoses:     []string{},
+		Instructions:  optionalText(visit.Plan),
+		Prescriptions: []PrintedPrescriptionLine{},
+	}
+	// Coded diagnoses come primary first; an uncoded visit shows the doctor's wording
+	for _, d := range diagnoses {
+		summary.Diagnoses = append(summary.Diagnoses, d.Code+" "+d.Description)
+	}
+	if len(summary.Diagnoses) == 0 && visit.Diagnosis != nil {
+		summary.Diagnoses = append(summary.Diagnoses, *visit.Diagnosis)
+	}
+	items := []database.DrugTemplate{}
+	for _, p := range prescriptions {
+		items = append(items, p.Items...)
+	}
+	summary.Prescriptions = printedLines(h.drugs, items)
+	if link != nil {
+		summary.Instructions = link.Instructions
+		summary.ExpiresAt = &link.ExpiresAt
+	}
+	return summary, true
+}
+
+func (h *VisitSummaryHandler) loadVisit(w http.ResponseWriter, r *http.Request) (*database.Encounter, bool) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	visit, err := h.visits.GetByID(visitID)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve visit")
+		return nil, false
+	}
+	return visit, true
+}
+
+func (h *VisitSummaryHandler) link(l *database.VisitSummaryLink) string {
+	return h.publicBaseURL + "/public/visit-summary/" + l.Token
+}
+
+// remoteIP is the address a request came from, without the port
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
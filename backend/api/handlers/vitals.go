@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+
+	"github.com/gorilla/mux"
+)
+
+// VitalsRepository interface for vital signs storage
+type VitalsRepository interface {
+	Create(v *database.VitalSigns) error
+	List(f database.VitalsFilter) ([]database.VitalSigns, error)
+}
+
+// VitalsHandler handles vital signs recording
+type VitalsHandler struct {
+	repo     VitalsRepository
+	patients PatientRepository
+	visits   EncounterRepository
+}
+
+// NewVitalsHandler creates a new vital signs handler
+func NewVitalsHandler(repo VitalsRepository, patients PatientRepository, visits EncounterRepository) *VitalsHandler {
+	return &VitalsHandler{repo: repo, patients: patients, visits: visits}
+}
+
+// RecordVitals stores a set of a patient's vital signs, optionally taken
+// during one of their visits; measuredAt defaults to now
+func (h *VitalsHandler) RecordVitals(w http.ResponseWriter, r *http.Request) {
+	hn := mux.Vars(r)["hn"]
+	id, err := parseHN(hn)
+	if err != nil {
+		http.Error(w, "Invalid patient HN format", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.patients.GetByID(id); err != nil {
+		writeError(w, err, "Failed to retrieve patient")
+		return
+	}
+
+	var vitals database.VitalSigns
+	if err := json.NewDecoder(r.Body).Decode(&vitals); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	vitals.PatientHN = hn
+	if msg := checkVitals(&vitals); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if vitals.MeasuredAt.IsZero() {
+		vitals.MeasuredAt = time.Now()
+	} else if vitals.MeasuredAt.After(time.Now().Add(5 * time.Minute)) {
+		http.Error(w, "measuredAt cannot be in the future", http.StatusBadRequest)
+		return
+	}
+	if vitals.RecordedBy == "" {
+		vitals.RecordedBy = reqctx.UserName(r.Context())
+	}
+	if vitals.RecordedBy == "" {
+		http.Error(w, "recordedBy is required", http.StatusBadRequest)
+		return
+	}
+	if vitals.VisitID != nil {
+		visit, err := h.visits.GetByID(*vitals.VisitID)
+		if err != nil {
+			writeError(w, err, "Failed to retrieve visit")
+			return
+		}
+		if visit.PatientHN != hn {
+			http.Error(w, "Visit belongs to another patient", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.repo.Create(&vitals); err != nil {
+		writeError(w, err, "Failed to record vital signs")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, vitals)
+}
+
+// GetPatientVitals lists a patient's vital signs in the order they were
+// measured, for trend charts; ?from= and ?to= (YYYY-MM-DD) narrow the range
+func (h *VitalsHandler) GetPatientVitals(w http.ResponseWriter, r *http.Request) {
+	filter := database.VitalsFilter{PatientHN: mux.Vars(r)["hn"]}
+	q := r.URL.Query()
+	if q.Get("from") != "" || q.Get("to") != "" {
+		from, to, err := dateRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.From, filter.To = from, to
+	}
+
+	h.list(w, filter)
+}
+
+// GetVisitVitals lists the vital signs taken during a visit
+func (h *VitalsHandler) GetVisitVitals(w http.ResponseWriter, r *http.Request) {
+	visitID, err := pathID(r, "visitId")
+	if err != nil {
+		http.Error(w, "Invalid visit ID", http.StatusBadRequest)
+		return
+	}
+
+	h.list(w, database.VitalsFilter{VisitID: visitID})
+}
+
+func (h *VitalsHandler) list(w http.ResponseWriter, filter database.VitalsFilter) {
+	vitals, err := h.repo.List(filter)
+	if err != nil {
+		writeError(w, err, "Failed to retrieve vital signs")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, vitals)
+}
+
+// vitalsRanges are the plausible bounds of each measurement, to catch typing mistakes
+var vitalsRanges = []struct {
+	name     string
+	min, max float64
+	value    func(v *database.VitalSigns) *float64
+}{
+	{"systolicBp", 50, 300, func(v *database.VitalSigns) *float64 { return intValue(v.SystolicBP) }},
+	{"diastolicBp", 20, 200, func(v *database.VitalSigns) *float64 { return intValue(v.DiastolicBP) }},
+	{"pulse", 20, 300, func(v *database.VitalSigns) *float64 { return intValue(v.Pulse) }},
+	{"temperature", 30, 45, func(v *database.VitalSigns) *float64 { return v.Temperature }},
+	{"weight", 0.5, 500, func(v *database.VitalSigns) *float64 { return v.Weight }},
+	{"height", 20, 280, func(v *database.VitalSigns) *float64 { return v.Height }},
+	{"spo2", 50, 100, func(v *database.VitalSigns) *float64 { return intValue(v.SpO2) }},
+}
+
+func intValue(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
+
+// checkVitals validates a set of vital signs, returning what is wrong with it
+func checkVitals(v *database.VitalSigns) string {
+	measured := false
+	for _, rng := range vitalsRanges {
+		value := rng.value(v)
+		if value == nil {
+			continue
+		}
+		measured = true
+		if *value < rng.min || *value > rng.max {
+			return fmt.Sprintf("%s must be between %g and %g", rng.name, rng.min, rng.max)
+		}
+	}
+	if !measured {
+		return "At least one measurement is required"
+	}
+	if (v.SystolicBP == nil) != (v.DiastolicBP == nil) {
+		return "systolicBp and diastolicBp must be given together"
+	}
+	if v.SystolicBP != nil && *v.DiastolicBP >= *v.SystolicBP {
+		return "diastolicBp must be lower than systolicBp"
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reqctx"
+)
+
+// WalkInAssigner picks the doctor a walk-in waits for as they check in at the
+// doctors' service point, by their branch's policy: doctors on shift take
+// walk-ins in turn (round_robin), or the one with the fewest patients waiting
+// or being seen gets them (shortest_queue). Under the manual policy reception
+// names the doctor, if any.
+type WalkInAssigner struct {
+	doctors  DoctorRepository
+	roster   RosterLookup
+	branches BranchLookup
+	queue    QueueRepository
+
+	servicePoint string // where walk-ins queue to see a doctor
+	policy       string // for branches without one of their own
+}
+
+// NewWalkInAssigner creates a walk-in assigner for check-ins at servicePoint,
+// using policy where the branch does not set one
+func NewWalkInAssigner(doctors DoctorRepository, roster RosterLookup, branches BranchLookup, queue QueueRepository, servicePoint, policy string) *WalkInAssigner {
+	return &WalkInAssigner{doctors: doctors, roster: roster, branches: branches, queue: queue, servicePoint: servicePoint, policy: policy}
+}
+
+// assign picks the doctor for a walk-in checking in at servicePoint, or nil
+// when the policy is manual, the service point is not the doctors' or no
+// doctor is on shift
+func (a *WalkInAssigner) assign(r *http.Request, servicePoint string) (*database.Doctor, error) {
+	if servicePoint != a.servicePoint {
+		return nil, nil
+	}
+	policy, err := a.branchPolicy(r)
+	if err != nil || policy == database.WalkInManual {
+		return nil, err
+	}
+
+	onShift, err := a.onShift(r)
+	if err != nil || len(onShift) == 0 {
+		return nil, err
+	}
+	entries, err := a.queue.List(database.QueueFilter{Branch: reqctx.Branch(r.Context()), QueueDate: today(r), ServicePoint: servicePoint})
+	if err != nil {
+		return nil, err
+	}
+
+	// For each doctor, the patients waiting for or with them and their latest
+	// walk-in today, by entry ID
+	waiting := make(map[int]int)
+	lastWalkIn := make(map[int]int)
+	for _, e := range entries {
+		if e.DoctorID == nil {
+			continue
+		}
+		if e.Status == database.QueueWaiting || e.Status == database.QueueInProgress {
+			waiting[*e.DoctorID]++
+		}
+		if e.AppointmentID == nil && e.ID > lastWalkIn[*e.DoctorID] {
+			lastWalkIn[*e.DoctorID] = e.ID
+		}
+	}
+
+	// Round robin gives the walk-in to the doctor whose turn came longest ago,
+	// so doctors coming on shift join the rotation at once; shortest queue
+	// breaks ties the same way
+	var picked *database.Doctor
+	for i := range onShift {
+		d := &onShift[i]
+		switch {
+		case picked == nil:
+		case policy == database.WalkInShortestQueue && waiting[d.ID] != waiting[picked.ID]:
+			if waiting[d.ID] > waiting[picked.ID] {
+				continue
+			}
+		case lastWalkIn[d.ID] >= lastWalkIn[picked.ID]:
+			continue
+		}
+		picked = d
+	}
+	return picked, nil
+}
+
+// branchPolicy is the request's branch's walk-in policy, or the clinic's
+func (a *WalkInAssigner) branchPolicy(r *http.Request) (string, error) {
+	if id := reqctx.Branch(r.Context()); id != "" {
+		branch, err := a.branches.GetByID(id)
+		if err != nil && !apperr.Is(err, apperr.KindNotFound) {
+			return "", err
+		}
+		if err == nil && branch.WalkInAssignment != "" {
+			return branch.WalkInAssignment, nil
+		}
+	}
+	return a.policy, nil
+}
+
+// onShift lists the active doctors rostered, or working, now and not on a day
+// off, by ID
+func (a *WalkInAssigner) onShift(r *http.Request) ([]database.Doctor, error) {
+	doctors, err := a.doctors.GetAll(database.DoctorFilter{ActiveOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	loc := reqctx.Location(r.Context())
+
+	onShift := []database.Doctor{}
+	for _, d := range doctors {
+		msg, err := rosterConflict(a.roster, &d, now, now.Add(time.Minute), loc)
+		if err != nil {
+			return nil, err
+		}
+		if msg == "" {
+			onShift = append(onShift, d)
+		}
+	}
+	sort.Slice(onShift, func(i, j int) bool { return onShift[i].ID < onShift[j].ID })
+	return onShift, nil
+}
@@ -0,0 +1,11 @@
+// Package api holds the OpenAPI description of the backend, generated by
+// cmd/openapi from the routes in main.go and the handlers behind them.
+package api
+
+import _ "embed"
+
+// OpenAPI is api/openapi.json; regenerate it with make openapi after changing
+// routes or the types handlers read and write
+//
+//go:embed openapi.json
+var OpenAPI []byte
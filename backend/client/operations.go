@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"clinic/backend/internal/database"
+)
+
+// The methods below are named after the operation IDs in api/openapi.json.
+
+// Health reports whether the API and its dependencies are up; a degraded
+// instance answers with an *Error carrying 503
+func (c *Client) Health(ctx context.Context) (map[string]interface{}, error) {
+	var health map[string]interface{}
+	_, err := c.Do(ctx, http.MethodGet, "/health", nil, &health)
+	return health, err
+}
+
+// GetPatients lists all patients
+func (c *Client) GetPatients(ctx context.Context) ([]database.Patient, error) {
+	var patients []database.Patient
+	_, err := c.Do(ctx, http.MethodGet, "/api/patients", nil, &patients)
+	return patients, err
+}
+
+// GetPatient gets a patient by HN
+func (c *Client) GetPatient(ctx context.Context, hn string) (*database.Patient, error) {
+	var patient database.Patient
+	if _, err := c.Do(ctx, http.MethodGet, "/api/patients/"+url.PathEscape(hn), nil, &patient); err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// CreatePatient registers a patient; a taken HN answers with an *Error carrying 409
+func (c *Client) CreatePatient(ctx context.Context, patient database.Patient) (*database.Patient, error) {
+	var created database.Patient
+	if _, err := c.Do(ctx, http.MethodPost, "/api/patients", patient, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdatePatient replaces the patient with patient.HN
+func (c *Client) UpdatePatient(ctx context.Context, patient database.Patient) (*database.Patient, error) {
+	var updated database.Patient
+	if _, err := c.Do(ctx, http.MethodPut, "/api/patients/"+url.PathEscape(patient.HN), patient, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeletePatient deletes a patient by HN
+func (c *Client) DeletePatient(ctx context.Context, hn string) error {
+	_, err := c.Do(ctx, http.MethodDelete, "/api/patients/"+url.PathEscape(hn), nil, nil)
+	return err
+}
+
+// GetPatientVisits lists a patient's visits
+func (c *Client) GetPatientVisits(ctx context.Context, hn string) ([]database.Encounter, error) {
+	var visits []database.Encounter
+	_, err := c.Do(ctx, http.MethodGet, "/api/patients/"+url.PathEscape(hn)+"/visits", nil, &visits)
+	return visits, err
+}
+
+// CreateVisit opens a visit for the patient with hn
+func (c *Client) CreateVisit(ctx context.Context, hn string, visit database.Encounter) (*database.Encounter, error) {
+	var created database.Encounter
+	if _, err := c.Do(ctx, http.MethodPost, "/api/patients/"+url.PathEscape(hn)+"/visits", visit, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetVisit gets a visit by ID
+func (c *Client) GetVisit(ctx context.Context, id int) (*database.Encounter, error) {
+	var visit database.Encounter
+	if _, err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/visits/%d", id), nil, &visit); err != nil {
+		return nil, err
+	}
+	return &visit, nil
+}
+
+// UpdateVisit records findings on the visit with visit.ID
+func (c *Client) UpdateVisit(ctx context.Context, visit database.Encounter) (*database.Encounter, error) {
+	var updated database.Encounter
+	if _, err := c.Do(ctx, http.MethodPut, fmt.Sprintf("/api/visits/%d", visit.ID), visit, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// CloseVisit closes a visit
+func (c *Client) CloseVisit(ctx context.Context, id int) (*database.Encounter, error) {
+	var visit database.Encounter
+	if _, err := c.Do(ctx, http.MethodPost, fmt.Sprintf("/api/visits/%d/close", id), nil, &visit); err != nil {
+		return nil, err
+	}
+	return &visit, nil
+}
+
+// GetAppointments lists appointments; query takes the endpoint's filters,
+// e.g. date, from and to, doctorId, hn and status
+func (c *Client) GetAppointments(ctx context.Context, query url.Values) ([]database.Appointment, error) {
+	path := "/api/appointments"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var appointments []database.Appointment
+	_, err := c.Do(ctx, http.MethodGet, path, nil, &appointments)
+	return appointments, err
+}
+
+// CreateAppointment books an appointment; a duplicate booking answers with
+// an *Error carrying 409
+func (c *Client) CreateAppointment(ctx context.Context, appointment database.Appointment) (*database.Appointment, error) {
+	var created database.Appointment
+	if _, err := c.Do(ctx, http.MethodPost, "/api/appointments", appointment, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetDoctors lists doctors
+func (c *Client) GetDoctors(ctx context.Context) ([]database.Doctor, error) {
+	var doctors []database.Doctor
+	_, err := c.Do(ctx, http.MethodGet, "/api/doctors", nil, &doctors)
+	return doctors, err
+}
+
+// CreateDoctor registers a doctor
+func (c *Client) CreateDoctor(ctx context.Context, doctor database.Doctor) (*database.Doctor, error) {
+	var created database.Doctor
+	if _, err := c.Do(ctx, http.MethodPost, "/api/doctors", doctor, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetAppointment gets an appointment by ID
+func (c *Client) GetAppointment(ctx context.Context, id int) (*database.Appointment, error) {
+	var appointment database.Appointment
+	if _, err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/appointments/%d", id), nil, &appointment); err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+// CheckIn gives a patient the next queue number at entry.ServicePoint; an
+// entry.AppointmentID checks the appointment in too
+func (c *Client) CheckIn(ctx context.Context, entry database.QueueEntry) (*database.QueueEntry, error) {
+	var created database.QueueEntry
+	if _, err := c.Do(ctx, http.MethodPost, "/api/queue/check-in", entry, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreateDrug adds a drug to the catalog
+func (c *Client) CreateDrug(ctx context.Context, drug database.Drug) (*database.Drug, error) {
+	var created database.Drug
+	if _, err := c.Do(ctx, http.MethodPost, "/api/drugs", drug, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreateService adds a billable service to the catalog
+func (c *Client) CreateService(ctx context.Context, service database.Service) (*database.Service, error) {
+	var created database.Service
+	if _, err := c.Do(ctx, http.MethodPost, "/api/services", service, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// AddVisitService records quantity of a catalog service given during an open visit
+func (c *Client) AddVisitService(ctx context.Context, visitID, serviceID int, quantity float64) (*database.VisitService, error) {
+	req := map[string]interface{}{"serviceId": serviceID, "quantity": quantity}
+	var given database.VisitService
+	if _, err := c.Do(ctx, http.MethodPost, fmt.Sprintf("/api/visits/%d/services", visitID), req, &given); err != nil {
+		return nil, err
+	}
+	return &given, nil
+}
+
+// CreatePrescription writes a prescription for an open visit
+func (c *Client) CreatePrescription(ctx context.Context, visitID int, prescription database.Prescription) (*database.Prescription, error) {
+	var created database.Prescription
+	if _, err := c.Do(ctx, http.MethodPost, fmt.Sprintf("/api/visits/%d/prescriptions", visitID), prescription, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GenerateInvoice drafts a visit's invoice from its recorded services and prescriptions
+func (c *Client) GenerateInvoice(ctx context.Context, visitID int) (*database.Invoice, error) {
+	var invoice database.Invoice
+	if _, err := c.Do(ctx, http.MethodPost, fmt.Sprintf("/api/visits/%d/invoice", visitID), nil, &invoice); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetInvoice gets an invoice by ID
+func (c *Client) GetInvoice(ctx context.Context, id int) (*database.Invoice, error) {
+	var invoice database.Invoice
+	if _, err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api/invoices/%d", id), nil, &invoice); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// UpdateInvoiceStatus issues, marks paid or voids (with reason) an invoice
+func (c *Client) UpdateInvoiceStatus(ctx context.Context, id int, status, reason string) (*database.Invoice, error) {
+	req := map[string]string{"status": status, "reason": reason}
+	var invoice database.Invoice
+	if _, err := c.Do(ctx, http.MethodPut, fmt.Sprintf("/api/invoices/%d/status", id), req, &invoice); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// PaymentResult is a recorded payment with the invoice it was taken against
+type PaymentResult struct {
+	Payment database.Payment `json:"payment"`
+	Invoice database.Invoice `json:"invoice"`
+}
+
+// RecordPayment takes a full or partial payment against an issued invoice
+func (c *Client) RecordPayment(ctx context.Context, invoiceID int, payment database.Payment) (*PaymentResult, error) {
+	var result PaymentResult
+	if _, err := c.Do(ctx, http.MethodPost, fmt.Sprintf("/api/invoices/%d/payments", invoiceID), payment, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,139 @@
+// Command archive moves closed visits, with their invoices, payments, claims,
+// prescriptions, diagnoses, services, vital signs, queue entries and their
+// LINE messages, triage assessments, follow-ups, package sessions, video visit
+// sessions, nursing notes and SOAP note versions, and audit logs older than
+// -years into the archive schema, keeping the hot tables small. Archived rows
+// are still read through the API by ID and in patient histories. Each batch is
+// one transaction; stop it at any time and rerun.
+//
+//	go run ./cmd/archive -years 5 -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"clinic/backend/internal/database"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+func main() {
+	dbHost := flag.String("db-host", getEnv("DB_HOST", "localhost"), "database host")
+	dbPort := flag.String("db-port", getEnv("DB_PORT", "5432"), "database port")
+	dbUser := flag.String("db-user", getEnv("DB_USER", "clinic"), "database user")
+	dbName := flag.String("db-name", getEnv("DB_NAME", "clinic"), "database name")
+	years := flag.Int("years", 5, "archive visits and audit logs older than this many years")
+	batch := flag.Int("batch", 200, "visits per batch, and audit log rows per table per batch")
+	pause := flag.Duration("pause", 200*time.Millisecond, "wait between batches")
+	dryRun := flag.Bool("dry-run", false, "count the visits that would be archived without moving them")
+	flag.Parse()
+
+	if *years < 1 {
+		log.Fatal("-years must be at least 1")
+	}
+	if *batch < 1 {
+		log.Fatal("-batch must be positive")
+	}
+	cutoff := time.Now().AddDate(-*years, 0, 0)
+
+	db, err := database.NewConnection(*dbHost, *dbPort, *dbUser, os.Getenv("DB_PASSWORD"), *dbName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	archive := database.NewArchiveRepository(db)
+
+	if *dryRun {
+		count, err := archive.CountVisits(cutoff)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d visits ended before %s and can be archived\n", count, cutoff.Format("2006-01-02"))
+		return
+	}
+
+	if err := db.CreateArchiveTables(); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	moved := map[string]int64{}
+	visits := 0
+	report := func() {
+		tables := make([]string, 0, len(moved))
+		for table := range moved {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		fmt.Printf("%d visits archived\n", visits)
+		for _, table := range tables {
+			fmt.Printf("  %-22s %d rows\n", table, moved[table])
+		}
+	}
+
+	for ctx.Err() == nil {
+		count, rows, err := archive.ArchiveVisits(cutoff, *batch)
+		if err != nil {
+			report()
+			log.Fatalf("stopped: %v; rerun to continue", err)
+		}
+		visits += count
+		for _, r := range rows {
+			moved[r.Table] += r.Rows
+		}
+		if count == 0 {
+			break
+		}
+		log.Printf("Archived %d visits (%d so far)", count, visits)
+		sleep(ctx, *pause)
+	}
+
+	for ctx.Err() == nil {
+		rows, err := archive.ArchiveAuditLogs(cutoff, *batch)
+		if err != nil {
+			report()
+			log.Fatalf("stopped: %v; rerun to continue", err)
+		}
+		var count int64
+		for _, r := range rows {
+			moved[r.Table] += r.Rows
+			count += r.Rows
+		}
+		if count == 0 {
+			break
+		}
+		log.Printf("Archived %d audit log rows", count)
+		sleep(ctx, *pause)
+	}
+
+	report()
+	if ctx.Err() != nil {
+		fmt.Println("Interrupted; rerun to continue")
+		os.Exit(1)
+	}
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// getEnv returns the environment variable or a fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,83 @@
+// Command loadtest replays clinic traffic patterns against a running instance
+// and reports latency percentiles per request type.
+//
+//	go run ./cmd/loadtest -target http://staging:8080 -scenario day -rate 50 -duration 10m
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/loadtest"
+)
+
+func main() {
+	target := flag.String("target", "http://localhost:8080", "base URL of the instance under test")
+	scenarioName := flag.String("scenario", "day", "traffic pattern: "+strings.Join(loadtest.ScenarioNames(), ", "))
+	rate := flag.Float64("rate", 20, "peak requests per second")
+	duration := flag.Duration("duration", 2*time.Minute, "length of the run")
+	concurrency := flag.Int("concurrency", 200, "most requests in flight")
+	patients := flag.Int("patients", 200, "load-test patients to seed (HN900001 onwards)")
+	visits := flag.Int("visits", 50, "visits to open before the run for end-of-day steps")
+	timeout := flag.Duration("timeout", 10*time.Second, "per-request timeout")
+	reportEvery := flag.Duration("report-every", 0, "print interval summaries this often, e.g. 1m for soak runs")
+	maxP95 := flag.Duration("max-p95", 0, "fail when overall p95 latency is above this; 0 to skip")
+	maxErrorRate := flag.Float64("max-error-rate", 0.01, "fail when the share of errored or dropped requests is above this")
+	list := flag.Bool("list", false, "list scenarios and exit")
+	flag.Parse()
+
+	if *list {
+		for _, name := range loadtest.ScenarioNames() {
+			fmt.Printf("%-8s %s\n", name, loadtest.Scenarios[name].Description)
+		}
+		return
+	}
+
+	scenario, ok := loadtest.Scenarios[*scenarioName]
+	if !ok {
+		log.Fatalf("unknown scenario %q; use -list", *scenarioName)
+	}
+	if *rate <= 0 || *duration <= 0 || *concurrency < 1 {
+		log.Fatal("-rate, -duration and -concurrency must be positive")
+	}
+
+	client := loadtest.NewClient(*target, os.Getenv("ADMIN_TOKEN"), *timeout)
+	log.Printf("Seeding %d patients and %d visits on %s", *patients, *visits, *target)
+	clinic, err := loadtest.Seed(client, *patients, *visits)
+	if err != nil {
+		log.Fatalf("seed failed: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("Running %s scenario at up to %.1f req/s for %s", scenario.Name, *rate, *duration)
+	summary := loadtest.Run(ctx, client, clinic, scenario, loadtest.Config{
+		PeakRate:    *rate,
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		ReportEvery: *reportEvery,
+	}, os.Stdout)
+
+	fmt.Println()
+	loadtest.Print(os.Stdout, scenario.Name, summary)
+
+	failed := false
+	if *maxP95 > 0 && summary.Total.P95 > *maxP95 {
+		fmt.Printf("FAIL: p95 %s is above %s\n", summary.Total.P95, *maxP95)
+		failed = true
+	}
+	if summary.ErrorRate > *maxErrorRate {
+		fmt.Printf("FAIL: error rate %.2f%% is above %.2f%%\n", summary.ErrorRate*100, *maxErrorRate*100)
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
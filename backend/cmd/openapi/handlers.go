@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// handlerPackage is the type-checked api/handlers package
+type handlerPackage struct {
+	pkg   *types.Package
+	info  *types.Info
+	decls map[*types.Func]*ast.FuncDecl
+}
+
+// loadHandlers parses and type-checks the handlers package in dir
+func loadHandlers(dir string) (*handlerPackage, error) {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("clinic/backend/api/handlers", fset, files, info)
+	if err != nil {
+		return nil, fmt.Errorf("type-check handlers: %w", err)
+	}
+
+	h := &handlerPackage{pkg: pkg, info: info, decls: map[*types.Func]*ast.FuncDecl{}}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				if obj, ok := info.Defs[fn.Name].(*types.Func); ok {
+					h.decls[obj] = fn
+				}
+			}
+		}
+	}
+	return h, nil
+}
+
+// method finds the handler method name on the type constructor returns
+func (h *handlerPackage) method(constructor, name string) (*types.Func, *types.Named, error) {
+	fn, ok := h.pkg.Scope().Lookup(constructor).(*types.Func)
+	if !ok {
+		return nil, nil, fmt.Errorf("handlers.%s not found", constructor)
+	}
+	results := fn.Type().(*types.Signature).Results()
+	if results.Len() == 0 {
+		return nil, nil, fmt.Errorf("handlers.%s returns nothing", constructor)
+	}
+	t := results.At(0).Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil, nil, fmt.Errorf("handlers.%s does not return a named type", constructor)
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), true, h.pkg, name)
+	method, ok := obj.(*types.Func)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s has no method %s", named.Obj().Name(), name)
+	}
+	return method, named, nil
+}
+
+// usage is what a handler, and the helpers it calls, read from and write to a request
+type usage struct {
+	request     types.Type // JSON body decoded
+	optional    bool       // the body may be left out
+	multipart   bool       // reads an uploaded file
+	formFields  []string   // multipart fields besides the file
+	query       []string
+	intParams   map[string]bool // path parameters read with pathID
+	responses   map[int]types.Type
+	errors      map[int]bool // plain-text error statuses
+	encodes     types.Type   // written with json.NewEncoder(w).Encode
+	writeHeader int
+}
+
+// analyze walks fn and every handlers function it calls
+func (h *handlerPackage) analyze(fn *types.Func) *usage {
+	u := &usage{intParams: map[string]bool{}, responses: map[int]types.Type{}, errors: map[int]bool{}}
+	h.walk(fn, u, map[*types.Func]bool{})
+	if u.encodes != nil {
+		status := u.writeHeader
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if _, ok := u.responses[status]; !ok {
+			u.responses[status] = u.encodes
+		}
+	}
+	sort.Strings(u.query)
+	sort.Strings(u.formFields)
+	return u
+}
+
+func (h *handlerPackage) walk(fn *types.Func, u *usage, seen map[*types.Func]bool) {
+	decl, ok := h.decls[fn]
+	if !ok || seen[fn] {
+		return
+	}
+	seen[fn] = true
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "ContentLength" {
+			u.optional = true
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		callee := h.callee(call)
+		if callee == nil {
+			return true
+		}
+
+		switch qualifiedName(callee) {
+		case "clinic/backend/api/handlers.writeJSON":
+			if status, ok := h.intConst(call.Args[1]); ok {
+				u.responses[status] = h.info.TypeOf(call.Args[2])
+			}
+		case "net/http.Error":
+			if status, ok := h.intConst(call.Args[2]); ok {
+				u.errors[status] = true
+			}
+		case "(*encoding/json.Decoder).Decode":
+			t := h.info.TypeOf(call.Args[0])
+			if p, ok := t.(*types.Pointer); ok && u.request == nil {
+				u.request = p.Elem()
+			}
+		case "encoding/json.Unmarshal":
+			t := h.info.TypeOf(call.Args[1])
+			if p, ok := t.(*types.Pointer); ok && u.request == nil {
+				u.request = p.Elem()
+			}
+		case "(*encoding/json.Encoder).Encode":
+			u.encodes = h.info.TypeOf(call.Args[0])
+		case "(net/http.ResponseWriter).WriteHeader":
+			if status, ok := h.intConst(call.Args[0]); ok && status >= 200 && status < 300 {
+				u.writeHeader = status
+			}
+		case "(net/url.Values).Get", "(net/url.Values).Has":
+			if name, ok := h.stringConst(call.Args[0]); ok {
+				u.query = appendNew(u.query, name)
+			}
+		case "(*net/http.Request).FormValue":
+			if name, ok := h.stringConst(call.Args[0]); ok {
+				u.formFields = appendNew(u.formFields, name)
+			}
+		case "clinic/backend/api/handlers.readUpload":
+			u.multipart = true
+		case "clinic/backend/api/handlers.pathID":
+			if name, ok := h.stringConst(call.Args[1]); ok {
+				u.intParams[name] = true
+			}
+		}
+
+		if callee.Pkg() == h.pkg {
+			h.walk(callee, u, seen)
+		}
+		return true
+	})
+}
+
+// callee is the function or method a call statically calls, if any
+func (h *handlerPackage) callee(call *ast.CallExpr) *types.Func {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		fn, _ := h.info.Uses[fun].(*types.Func)
+		return fn
+	case *ast.SelectorExpr:
+		if sel, ok := h.info.Selections[fun]; ok {
+			fn, _ := sel.Obj().(*types.Func)
+			return fn
+		}
+		fn, _ := h.info.Uses[fun.Sel].(*types.Func)
+		return fn
+	}
+	return nil
+}
+
+func qualifiedName(fn *types.Func) string {
+	if fn.Pkg() == nil {
+		return fn.Name()
+	}
+	sig := fn.Type().(*types.Signature)
+	if recv := sig.Recv(); recv != nil {
+		t := recv.Type()
+		pointer := ""
+		if p, ok := t.(*types.Pointer); ok {
+			t, pointer = p.Elem(), "*"
+		}
+		if named, ok := t.(*types.Named); ok {
+			return "(" + pointer + named.Obj().Pkg().Path() + "." + named.Obj().Name() + ")." + fn.Name()
+		}
+	}
+	return fn.Pkg().Path() + "." + fn.Name()
+}
+
+func (h *handlerPackage) intConst(expr ast.Expr) (int, bool) {
+	tv, ok := h.info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	n, err := strconv.Atoi(tv.Value.ExactString())
+	return n, err == nil
+}
+
+func (h *handlerPackage) stringConst(expr ast.Expr) (string, bool) {
+	tv, ok := h.info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// doc is a function's doc comment as one paragraph
+func (h *handlerPackage) doc(fn *types.Func) string {
+	decl, ok := h.decls[fn]
+	if !ok || decl.Doc == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(decl.Doc.Text()), " ")
+}
+
+func appendNew(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,230 @@
+// Command openapi generates the OpenAPI 3 description of the API from the
+// routes main.go registers and the handlers behind them: operation IDs come
+// from handler method names, descriptions from their doc comments, and
+// request and response schemas from the types they decode and write with
+// writeJSON. The result is deterministic, so it is committed as
+// api/openapi.json, served at /api/openapi.json and used to generate client
+// SDKs (see the Makefile).
+//
+//	go run ./cmd/openapi -o api/openapi.json
+//	go run ./cmd/openapi -o api/openapi.json -check
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/types"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// apiVersion is the version of the API description; bump it when operations
+// or schemas change incompatibly
+const apiVersion = "1.0.0"
+
+// pathParam matches the {name} and {name:pattern} variables of a mux template
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+func main() {
+	dir := flag.String("dir", ".", "backend module directory")
+	out := flag.String("o", "api/openapi.json", "file to write")
+	check := flag.Bool("check", false, "fail when the file is not up to date instead of writing it")
+	flag.Parse()
+
+	spec, err := generate(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *check {
+		current, err := os.ReadFile(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !bytes.Equal(current, spec) {
+			log.Fatalf("%s is out of date; run go run ./cmd/openapi -o %s", *out, *out)
+		}
+		return
+	}
+	if err := os.WriteFile(*out, spec, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate builds the API description of the module in dir
+func generate(dir string) ([]byte, error) {
+	routes, err := readRoutes(filepath.Join(dir, "main.go"))
+	if err != nil {
+		return nil, err
+	}
+	handlers, err := loadHandlers(filepath.Join(dir, "api", "handlers"))
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := newSchemas()
+	doc := document{
+		OpenAPI: "3.0.3",
+		Info: info{
+			Title:   "Clinic Management System API",
+			Version: apiVersion,
+			Description: "Generated from the backend's routes and handlers by cmd/openapi. " +
+				"Requests may carry X-Tenant-ID and X-Branch-ID headers to act on a tenant and branch. " +
+				"Errors are plain text.",
+		},
+		Paths: map[string]map[string]*operation{},
+		Components: components{
+			Schemas: schemas.components,
+			SecuritySchemes: map[string]*securityScheme{
+				"adminToken":  {Type: "http", Scheme: "bearer", Description: "The ADMIN_TOKEN the server was started with"},
+				"portalToken": {Type: "http", Scheme: "bearer", Description: "The token a patient gets from /portal/sign-in/verify"},
+				"apiKey":      {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "An API key; kiosk routes need one with the kiosk role"},
+			},
+		},
+	}
+
+	operationIDs := map[string]string{}
+	for _, rt := range routes {
+		method, receiver, err := handlers.method(rt.Constructor, rt.Handler)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(rt.Method), rt.Path, err)
+		}
+		u := handlers.analyze(method)
+
+		path := pathParam.ReplaceAllString(rt.Path, "{$1}")
+		op := &operation{
+			OperationID: operationID(rt.Handler),
+			Description: handlers.doc(method),
+			Tags:        []string{strings.TrimSuffix(receiver.Obj().Name(), "Handler")},
+			Responses:   map[string]*response{},
+		}
+		if _, ok := operationIDs[op.OperationID]; ok {
+			// the same handler under another method, e.g. GET and POST
+			op.OperationID += strings.ToUpper(rt.Method[:1]) + rt.Method[1:]
+		}
+		if previous, ok := operationIDs[op.OperationID]; ok {
+			return nil, fmt.Errorf("operation %s is registered twice: %s and %s %s", op.OperationID, previous, strings.ToUpper(rt.Method), rt.Path)
+		}
+		operationIDs[op.OperationID] = strings.ToUpper(rt.Method) + " " + rt.Path
+
+		for _, m := range pathParam.FindAllStringSubmatch(rt.Path, -1) {
+			param := &parameter{Name: m[1], In: "path", Required: true, Schema: &schema{Type: "string"}}
+			if u.intParams[m[1]] {
+				param.Schema = &schema{Type: "integer"}
+			}
+			op.Parameters = append(op.Parameters, param)
+		}
+		for _, name := range u.query {
+			if !u.multipart || !contains(u.formFields, name) {
+				op.Parameters = append(op.Parameters, &parameter{Name: name, In: "query", Schema: &schema{Type: "string"}})
+			}
+		}
+
+		switch {
+		case u.multipart:
+			form := &schema{Type: "object", Properties: map[string]*schema{
+				"file": {Type: "string", Format: "binary"},
+			}, Required: []string{"file"}}
+			for _, name := range u.formFields {
+				form.Properties[name] = &schema{Type: "string"}
+			}
+			op.RequestBody = &requestBody{Required: true, Content: map[string]*mediaType{
+				"multipart/form-data": {Schema: form},
+			}}
+		case u.request != nil:
+			body := schemas.of(u.request)
+			if _, inline := u.request.(*types.Struct); inline {
+				// request-only fields the handler defaults when left out
+				body.Required = nil
+			}
+			op.RequestBody = &requestBody{Required: !u.optional, Content: map[string]*mediaType{
+				"application/json": {Schema: body},
+			}}
+		}
+
+		for status, t := range u.responses {
+			op.Responses[strconv.Itoa(status)] = &response{
+				Description: http.StatusText(status),
+				Content:     map[string]*mediaType{"application/json": {Schema: schemas.of(t)}},
+			}
+		}
+		success := false
+		for status := range op.Responses {
+			success = success || strings.HasPrefix(status, "2")
+		}
+		if !success {
+			op.Responses["200"] = &response{Description: http.StatusText(http.StatusOK)}
+		}
+		for status := range u.errors {
+			if _, ok := op.Responses[strconv.Itoa(status)]; !ok {
+				op.Responses[strconv.Itoa(status)] = &response{
+					Description: http.StatusText(status),
+					Content:     map[string]*mediaType{"text/plain": {Schema: &schema{Type: "string"}}},
+				}
+			}
+		}
+		op.Responses["default"] = &response{
+			Description: "Error",
+			Content:     map[string]*mediaType{"text/plain": {Schema: &schema{Type: "string"}}},
+		}
+		if rt.AdminOnly {
+			op.Security = []map[string][]string{{"adminToken": {}}}
+		}
+		if rt.PatientOnly {
+			op.Security = []map[string][]string{{"portalToken": {}}}
+		}
+		if rt.Kiosk {
+			op.Security = []map[string][]string{{"apiKey": {}}, {"adminToken": {}}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*operation{}
+		}
+		doc.Paths[path][rt.Method] = op
+	}
+
+	var unused []string
+	for field := range enums {
+		if !schemas.used[field] {
+			unused = append(unused, field)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return nil, fmt.Errorf("enum fields not found in any schema: %s", strings.Join(unused, ", "))
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// operationID is a handler method name in lower camel case, e.g. getPatients
+func operationID(handler string) string {
+	r := []rune(handler)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
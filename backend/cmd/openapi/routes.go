@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// route is one r.HandleFunc(path, handler).Methods(method) registration
+type route struct {
+	Method      string
+	Path        string // mux template, e.g. /api/patients/{hn}
+	Constructor string // handlers function that built the handler value, e.g. NewPatientHandler
+	Handler     string // method name, e.g. GetPatients
+	AdminOnly   bool   // wrapped in handlers.RequireRole(..., reqctx.RoleAdmin)
+	PatientOnly bool   // wrapped in portalHandler.RequirePatient(...)
+	Kiosk       bool   // wrapped in handlers.RequireRole(..., reqctx.RoleKiosk, ...)
+}
+
+// readRoutes finds the routes main.go registers, in order. Each handler value
+// must come from a handlers.NewXHandler(...) assignment in the same file.
+func readRoutes(filename string) ([]route, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	constructors := map[string]string{} // variable -> handlers constructor
+	ast.Inspect(file, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != len(assign.Rhs) {
+			return true
+		}
+		for i, rhs := range assign.Rhs {
+			ident, ok := assign.Lhs[i].(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if pkg, name, ok := calledFunc(rhs); ok && pkg == "handlers" && strings.HasPrefix(name, "New") {
+				constructors[ident.Name] = name
+			}
+		}
+		return true
+	})
+
+	var routes []route
+	var failed error
+	ast.Inspect(file, func(n ast.Node) bool {
+		methods, ok := n.(*ast.CallExpr)
+		if !ok || failed != nil {
+			return failed == nil
+		}
+		sel, ok := methods.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Methods" {
+			return true
+		}
+		handleFunc, ok := sel.X.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if hf, ok := handleFunc.Fun.(*ast.SelectorExpr); !ok || hf.Sel.Name != "HandleFunc" || len(handleFunc.Args) != 2 {
+			return true
+		}
+
+		path, err := stringLit(handleFunc.Args[0])
+		if err != nil {
+			failed = fmt.Errorf("%s: %w", fset.Position(handleFunc.Pos()), err)
+			return false
+		}
+		handler := handleFunc.Args[1]
+		adminOnly, patientOnly, kiosk := false, false, false
+		if call, ok := handler.(*ast.CallExpr); ok {
+			pkg, name, _ := calledFunc(call)
+			switch {
+			case pkg == "handlers" && name == "RequireRole" && len(call.Args) >= 2:
+				handler = call.Args[0]
+				for _, arg := range call.Args[1:] {
+					if role, ok := arg.(*ast.SelectorExpr); ok && role.Sel.Name == "RoleKiosk" {
+						kiosk = true
+					}
+				}
+				if role, ok := call.Args[1].(*ast.SelectorExpr); ok && role.Sel.Name == "RoleAdmin" && len(call.Args) == 2 {
+					adminOnly = true
+				}
+			case name == "RequirePatient" && len(call.Args) == 1:
+				handler = call.Args[0]
+				patientOnly = true
+			}
+		}
+		value, ok := handler.(*ast.SelectorExpr)
+		if !ok {
+			failed = fmt.Errorf("%s: handler of %s is not a handler method", fset.Position(handleFunc.Pos()), path)
+			return false
+		}
+		variable, ok := value.X.(*ast.Ident)
+		if !ok || constructors[variable.Name] == "" {
+			failed = fmt.Errorf("%s: cannot tell which handler %s is", fset.Position(value.Pos()), path)
+			return false
+		}
+
+		for _, arg := range methods.Args {
+			method, err := stringLit(arg)
+			if err != nil {
+				failed = fmt.Errorf("%s: %w", fset.Position(arg.Pos()), err)
+				return false
+			}
+			routes = append(routes, route{
+				Method:      strings.ToLower(method),
+				Path:        path,
+				Constructor: constructors[variable.Name],
+				Handler:     value.Sel.Name,
+				AdminOnly:   adminOnly,
+				PatientOnly: patientOnly,
+				Kiosk:       kiosk,
+			})
+		}
+		return false
+	})
+	if failed != nil {
+		return nil, failed
+	}
+	return routes, nil
+}
+
+// calledFunc returns the package and function name of a pkg.Func(...) call
+func calledFunc(expr ast.Expr) (string, string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", "", false
+	}
+	return pkg.Name, sel.Sel.Name, true
+}
+
+func stringLit(expr ast.Expr) (string, error) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", fmt.Errorf("expected a string literal")
+	}
+	return strconv.Unquote(lit.Value)
+}
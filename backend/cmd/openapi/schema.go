@@ -0,0 +1,218 @@
+package main
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+
+	"clinic/backend/internal/database"
+)
+
+// enums names the fields, as Type.jsonName, that only take the values of one
+// of the database package's value lists
+var enums = map[string][]string{
+	"APIKey.role":                   database.APIKeyRoles,
+	"Address.kind":                  database.AddressKinds,
+	"Allergy.severity":              database.AllergySeverities,
+	"Announcement.priority":         database.AnnouncementPriorities,
+	"Appointment.channel":           database.BookingChannels,
+	"Branch.walkInAssignment":       database.WalkInAssignments,
+	"CancellationCount.initiator":   database.CancellationInitiators,
+	"CancellationReason.appliesTo":  database.CancellationReasonScopes,
+	"CancellationReason.initiator":  database.CancellationInitiators,
+	"Consent.type":                  database.ConsentTypes,
+	"Consent.signerRelation":        database.ConsentSignerRelations,
+	"ConsentVerification.type":      database.ConsentTypes,
+	"DentalTreatment.procedure":     database.DentalProcedures,
+	"DentalTreatment.surfaces":      database.ToothSurfaces,
+	"Doctor.workingDays":            database.Weekdays,
+	"Document.category":             database.DocumentCategories,
+	"EmailMessage.template":         database.EmailTemplates,
+	"EmergencyContact.relationship": database.EmergencyContactRelationships,
+	"InteractionFinding.kind":       database.InteractionKinds,
+	"InteractionFinding.severity":   database.InteractionSeverities,
+	"InteractionRule.kind":          database.InteractionKinds,
+	"InteractionRule.severity":      database.InteractionSeverities,
+	"LINEMessage.kind":              database.LINEMessageKinds,
+	"NursingNote.kind":              database.NursingNoteKinds,
+	"PatientFieldRule.field":        database.PatientRuleFields,
+	"Prescription.dispenseStatus":   database.DispenseStatuses,
+	"QueueEntry.urgency":            database.TriageLevels,
+	"Referral.urgency":              database.ReferralUrgencies,
+	"SelfRegistration.status":       database.SelfRegistrationStatuses,
+	"Service.category":              database.ServiceCategories,
+	"StockItem.kind":                database.StockItemKinds,
+	"StockLevel.kind":               database.StockItemKinds,
+	"SummaryLinkAccess.outcome":     database.SummaryAccessOutcomes,
+	"Teleconsult.status":            database.TeleconsultStatuses,
+	"ToothFinding.status":           database.ToothStatuses,
+	"ToothFinding.surfaces":         database.ToothSurfaces,
+	"Triage.urgency":                database.TriageLevels,
+	"User.role":                     database.UserRoles,
+	"userRequest.role":              database.UserRoles,
+}
+
+// schemas turns Go types into schemas, collecting named structs as components
+type schemas struct {
+	components map[string]*schema
+	names      map[*types.TypeName]string
+	taken      map[string]*types.TypeName
+	used       map[string]bool // enums entries that matched a field
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: map[string]*schema{},
+		names:      map[*types.TypeName]string{},
+		taken:      map[string]*types.TypeName{},
+		used:       map[string]bool{},
+	}
+}
+
+// of returns the schema of t as encoding/json writes it
+func (s *schemas) of(t types.Type) *schema {
+	switch t := t.(type) {
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() != nil {
+			switch obj.Pkg().Path() + "." + obj.Name() {
+			case "time.Time":
+				return &schema{Type: "string", Format: "date-time"}
+			case "time.Duration":
+				return &schema{Type: "integer", Format: "int64"}
+			case "encoding/json.RawMessage":
+				return &schema{}
+			}
+		}
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			return s.of(t.Underlying())
+		}
+		return &schema{Ref: "#/components/schemas/" + s.component(obj, t.Underlying().(*types.Struct))}
+	case *types.Alias:
+		return s.of(types.Unalias(t))
+	case *types.Pointer:
+		elem := s.of(t.Elem())
+		if elem.Ref != "" {
+			return elem
+		}
+		nullable := *elem
+		nullable.Nullable = true
+		return &nullable
+	case *types.Slice:
+		if b, ok := t.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: s.of(t.Elem())}
+	case *types.Array:
+		return &schema{Type: "array", Items: s.of(t.Elem())}
+	case *types.Map:
+		return &schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case *types.Struct:
+		return s.object("", t)
+	case *types.Basic:
+		return basic(t)
+	}
+	return &schema{} // interface{}: any JSON value
+}
+
+func basic(t *types.Basic) *schema {
+	switch {
+	case t.Info()&types.IsBoolean != 0:
+		return &schema{Type: "boolean"}
+	case t.Info()&types.IsInteger != 0:
+		if t.Kind() == types.Int64 || t.Kind() == types.Uint64 {
+			return &schema{Type: "integer", Format: "int64"}
+		}
+		return &schema{Type: "integer"}
+	case t.Info()&types.IsFloat != 0:
+		return &schema{Type: "number", Format: "double"}
+	case t.Info()&types.IsString != 0:
+		return &schema{Type: "string"}
+	}
+	return &schema{}
+}
+
+// component registers a named struct under a unique, capitalized component
+// name, its package's name in front when another package has a struct of the
+// same name
+func (s *schemas) component(obj *types.TypeName, st *types.Struct) string {
+	if name, ok := s.names[obj]; ok {
+		return name
+	}
+	name := capitalize(obj.Name())
+	if other, ok := s.taken[name]; ok && other != obj {
+		name = capitalize(obj.Pkg().Name()) + name
+	}
+	s.names[obj] = name
+	s.taken[name] = obj
+	s.components[name] = &schema{} // placeholder while the fields refer back to it
+	s.components[name] = s.object(obj.Name(), st)
+	return name
+}
+
+// object is the schema of a struct's JSON fields; typeName, when the struct
+// is named, looks up enum fields
+func (s *schemas) object(typeName string, st *types.Struct) *schema {
+	obj := &schema{Type: "object", Properties: map[string]*schema{}}
+	s.addFields(obj, typeName, st)
+	return obj
+}
+
+func (s *schemas) addFields(obj *schema, typeName string, st *types.Struct) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Embedded() && name == "" {
+			embedded := field.Type()
+			if p, ok := embedded.(*types.Pointer); ok {
+				embedded = p.Elem()
+			}
+			if inner, ok := embedded.Underlying().(*types.Struct); ok {
+				innerName := typeName
+				if named, ok := embedded.(*types.Named); ok {
+					innerName = named.Obj().Name()
+				}
+				s.addFields(obj, innerName, inner)
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+
+		var prop *schema
+		if strings.Contains(options, "string") {
+			prop = &schema{Type: "string"}
+		} else {
+			prop = s.of(field.Type())
+		}
+		if values, ok := enums[typeName+"."+name]; ok {
+			s.used[typeName+"."+name] = true
+			withEnum := *prop
+			if withEnum.Type == "array" && withEnum.Items != nil {
+				items := *withEnum.Items
+				items.Enum = values
+				withEnum.Items = &items
+			} else {
+				withEnum.Enum = values
+			}
+			prop = &withEnum
+		}
+		obj.Properties[name] = prop
+		if !strings.Contains(options, "omitempty") {
+			obj.Required = append(obj.Required, name)
+		}
+	}
+}
+
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
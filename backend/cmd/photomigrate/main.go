@@ -0,0 +1,106 @@
+// Command photomigrate moves base64 patient photos out of the patients table
+// into the storage directory the API serves at /files, rewriting each photo
+// to its URL. Progress is checkpointed after every batch; rerun the same
+// command to resume, or pass -restart to retry photos that failed.
+//
+//	go run ./cmd/photomigrate -storage-dir /var/lib/clinic/files -batch 200
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/photomigrate"
+	"clinic/backend/internal/storage"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+func main() {
+	dbHost := flag.String("db-host", getEnv("DB_HOST", "localhost"), "database host")
+	dbPort := flag.String("db-port", getEnv("DB_PORT", "5432"), "database port")
+	dbUser := flag.String("db-user", getEnv("DB_USER", "clinic"), "database user")
+	dbName := flag.String("db-name", getEnv("DB_NAME", "clinic"), "database name")
+	storageDir := flag.String("storage-dir", getEnv("STORAGE_DIR", "storage"), "directory the API serves at /files")
+	baseURL := flag.String("base-url", getEnv("PUBLIC_BASE_URL", "http://localhost:8080")+"/files", "URL the storage directory is served at")
+	batch := flag.Int("batch", 100, "photos per batch")
+	pause := flag.Duration("pause", 200*time.Millisecond, "wait between batches")
+	state := flag.String("state", "photomigrate.json", "checkpoint file")
+	restart := flag.Bool("restart", false, "ignore the checkpoint and start from the first patient")
+	dryRun := flag.Bool("dry-run", false, "decode and count photos without storing or rewriting them")
+	flag.Parse()
+
+	if *batch < 1 {
+		log.Fatal("-batch must be positive")
+	}
+
+	checkpoint, err := photomigrate.LoadCheckpoint(*state)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *restart || *dryRun {
+		checkpoint = &photomigrate.Checkpoint{Failed: []photomigrate.Failure{}, StartedAt: time.Now()}
+	}
+	if checkpoint.Done {
+		fmt.Printf("Migration already finished at %s (%d migrated); use -restart to run again\n",
+			checkpoint.UpdatedAt.Format(time.RFC3339), checkpoint.Migrated)
+		return
+	}
+
+	db, err := database.NewConnection(*dbHost, *dbPort, *dbUser, os.Getenv("DB_PASSWORD"), *dbName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	store, err := storage.NewDiskStore(*storageDir, *baseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	migrator := &photomigrate.Migrator{
+		Source:    database.NewPatientRepository(db),
+		Store:     store,
+		BatchSize: *batch,
+		Pause:     *pause,
+		DryRun:    *dryRun,
+		Log:       os.Stdout,
+	}
+	save := func(c *photomigrate.Checkpoint) error {
+		if *dryRun {
+			return nil
+		}
+		return c.Save(*state)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if checkpoint.LastHN != "" {
+		log.Printf("Resuming after %s", checkpoint.LastHN)
+	}
+	err = migrator.Run(ctx, checkpoint, save)
+	fmt.Printf("%d migrated (%.1f MB), %d failed, %d edited during the run\n",
+		checkpoint.Migrated, float64(checkpoint.Bytes)/(1<<20), len(checkpoint.Failed), checkpoint.Changed)
+	if err != nil {
+		log.Fatalf("stopped after %s: %v; rerun to resume", checkpoint.LastHN, err)
+	}
+	if len(checkpoint.Failed) > 0 {
+		fmt.Printf("Failed photos are listed in %s and stay in the table; fix them and rerun with -restart\n", *state)
+		os.Exit(1)
+	}
+}
+
+// getEnv returns the environment variable or a fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
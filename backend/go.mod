@@ -2,4 +2,7 @@ module clinic/backend
 
 go 1.24.5
 
-require github.com/gorilla/mux v1.8.1 // indirect
+require (
+	github.com/gorilla/mux v1.8.1
+	github.com/lib/pq v1.10.9
+)
@@ -0,0 +1,89 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies a domain error so callers can react without parsing messages
+type Kind string
+
+// Error kinds
+const (
+	KindInternal         Kind = "internal"
+	KindNotFound         Kind = "not_found"
+	KindConflict         Kind = "conflict"
+	KindValidation       Kind = "validation"
+	KindPermissionDenied Kind = "permission_denied"
+)
+
+// Error is a domain error with a kind and a message safe to show to API clients
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error // underlying cause, if any
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind Kind, format string, args []interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// NotFound reports that the requested record does not exist
+func NotFound(format string, args ...interface{}) error {
+	return newError(KindNotFound, format, args)
+}
+
+// Conflict reports that the request clashes with the record's current state,
+// such as a duplicate, a double booking or a stale revision
+func Conflict(format string, args ...interface{}) error {
+	return newError(KindConflict, format, args)
+}
+
+// Validation reports that the input is invalid
+func Validation(format string, args ...interface{}) error {
+	return newError(KindValidation, format, args)
+}
+
+// PermissionDenied reports that the caller may not perform the operation
+func PermissionDenied(format string, args ...interface{}) error {
+	return newError(KindPermissionDenied, format, args)
+}
+
+// Wrap attaches a kind and client-safe message to an underlying error
+func Wrap(kind Kind, err error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// KindOf returns the kind of the first domain error in err's chain, or KindInternal
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// Is reports whether err is a domain error of the given kind
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
+
+// Message returns the client-safe message of a domain error, or "" for other errors
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return ""
+}
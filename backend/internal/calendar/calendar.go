@@ -0,0 +1,226 @@
+// Package calendar lays a range of appointments out by doctor and day for the
+// week and month calendar views, with how full each day is.
+package calendar
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// Views
+const (
+	ViewWeek  = "week"
+	ViewMonth = "month"
+)
+
+// DayCapacity is the bookable time in a doctor's clinic day that load is measured against
+const DayCapacity = 8 * time.Hour
+
+// Density levels, by the share of DayCapacity booked
+const (
+	DensityFree  = "free"  // nothing booked
+	DensityLight = "light" // under half booked
+	DensityBusy  = "busy"  // under 85% booked
+	DensityFull  = "full"
+)
+
+// Range returns the days a view shows around day: the Monday-to-Sunday week,
+// or the calendar month. The result is the half-open interval [from, to), in
+// day's location.
+func Range(view string, day time.Time) (time.Time, time.Time, bool) {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	switch view {
+	case ViewWeek:
+		from := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return from, from.AddDate(0, 0, 7), true
+	case ViewMonth:
+		from := day.AddDate(0, 0, 1-day.Day())
+		return from, from.AddDate(0, 1, 0), true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+// Summary counts the appointments in a cell, row or column of the calendar.
+// Cancelled and no-show appointments are counted but take up no time.
+type Summary struct {
+	Count         int     `json:"count"` // booked: scheduled, checked in or completed
+	Cancelled     int     `json:"cancelled"`
+	NoShow        int     `json:"noShow"`
+	BookedMinutes int     `json:"bookedMinutes"`
+	Load          float64 `json:"load"`    // booked time as a share of capacity, may exceed 1
+	Density       string  `json:"density"` // free, light, busy, full
+}
+
+func (s *Summary) add(a *database.Appointment) {
+	switch a.Status {
+	case database.AppointmentCancelled:
+		s.Cancelled++
+	case database.AppointmentNoShow:
+		s.NoShow++
+	default:
+		s.Count++
+		s.BookedMinutes += int(a.EndsAt.Sub(a.StartsAt) / time.Minute)
+	}
+}
+
+// rate works out load and density against capacity
+func (s *Summary) rate(capacity time.Duration) {
+	if capacity > 0 {
+		s.Load = float64(s.BookedMinutes) / capacity.Minutes()
+		s.Load = float64(int(s.Load*100+0.5)) / 100
+	}
+	switch {
+	case s.Count == 0:
+		s.Density = DensityFree
+	case s.Load < 0.5:
+		s.Density = DensityLight
+	case s.Load < 0.85:
+		s.Density = DensityBusy
+	default:
+		s.Density = DensityFull
+	}
+}
+
+// Cell is one doctor's day
+type Cell struct {
+	Date         string                 `json:"date"`    // YYYY-MM-DD
+	Working      *bool                  `json:"working"` // whether it is one of the doctor's working days; nil for doctors known only by name
+	Summary      Summary                `json:"summary"`
+	Appointments []database.Appointment `json:"appointments,omitempty"`
+}
+
+// Row is one doctor's days across the view
+type Row struct {
+	DoctorID   *int    `json:"doctorId,omitempty"`
+	DoctorName string  `json:"doctorName"`
+	Days       []Cell  `json:"days"`
+	Summary    Summary `json:"summary"`
+}
+
+// Day totals one day across all doctors
+type Day struct {
+	Date    string  `json:"date"`
+	Summary Summary `json:"summary"`
+}
+
+// View is a calendar of doctors by day
+type View struct {
+	View    string `json:"view"`
+	From    string `json:"from"` // first day shown, YYYY-MM-DD
+	To      string `json:"to"`   // last day shown, inclusive
+	Doctors []Row  `json:"doctors"`
+	Days    []Day  `json:"days"`
+}
+
+// Build lays out appointments starting in [from, to) by doctor and day.
+// Every doctor in doctors gets a row, booked or not; appointments with a
+// doctor known only by name get a row of their own. Appointments are left out
+// of the cells when summaryOnly is set.
+func Build(view string, from, to time.Time, appointments []database.Appointment, doctors []database.Doctor, summaryOnly bool) View {
+	var dates []string
+	index := map[string]int{}
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		index[d.Format("2006-01-02")] = len(dates)
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+
+	rows := map[string]*Row{}
+	newRow := func(key string, id *int, name string) *Row {
+		row := &Row{DoctorID: id, DoctorName: name, Days: make([]Cell, len(dates))}
+		for i, date := range dates {
+			row.Days[i].Date = date
+		}
+		rows[key] = row
+		return row
+	}
+	for i := range doctors {
+		d := &doctors[i]
+		row := newRow(doctorKey(&d.ID, d.FullName), &d.ID, d.FullName)
+		for j := range row.Days {
+			day, _ := time.ParseInLocation("2006-01-02", dates[j], from.Location())
+			working := d.WorksOn(day.Weekday())
+			row.Days[j].Working = &working
+		}
+	}
+
+	days := make([]Day, len(dates))
+	for i, date := range dates {
+		days[i].Date = date
+	}
+	for i := range appointments {
+		a := &appointments[i]
+		j, ok := index[a.StartsAt.In(from.Location()).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		key := doctorKey(a.DoctorID, a.DoctorName)
+		row, ok := rows[key]
+		if !ok {
+			row = newRow(key, a.DoctorID, a.DoctorName)
+		}
+		row.Days[j].Summary.add(a)
+		row.Summary.add(a)
+		days[j].Summary.add(a)
+		if !summaryOnly {
+			row.Days[j].Appointments = append(row.Days[j].Appointments, *a)
+		}
+	}
+
+	result := View{View: view, From: dates[0], To: dates[len(dates)-1], Doctors: []Row{}, Days: days}
+	for _, row := range rows {
+		for j := range row.Days {
+			row.Days[j].Summary.rate(DayCapacity)
+		}
+		row.Summary.rate(DayCapacity * time.Duration(workingDays(row)))
+		result.Doctors = append(result.Doctors, *row)
+	}
+	sort.Slice(result.Doctors, func(i, j int) bool {
+		return strings.ToLower(result.Doctors[i].DoctorName) < strings.ToLower(result.Doctors[j].DoctorName)
+	})
+	for j := range result.Days {
+		result.Days[j].Summary.rate(DayCapacity * time.Duration(workingDoctors(result.Doctors, j)))
+	}
+
+	return result
+}
+
+// doctorKey identifies a doctor by ID, or by name for free-text bookings
+func doctorKey(id *int, name string) string {
+	if id != nil {
+		return strconv.Itoa(*id)
+	}
+	return "name:" + strings.ToLower(name)
+}
+
+// staffed reports whether a doctor is in on a day: it is one of their working
+// days, or they have bookings anyway
+func staffed(cell Cell) bool {
+	return cell.Working != nil && *cell.Working || cell.Summary.Count > 0
+}
+
+// workingDays counts the days in a row the doctor is in
+func workingDays(row *Row) int {
+	n := 0
+	for _, cell := range row.Days {
+		if staffed(cell) {
+			n++
+		}
+	}
+	return n
+}
+
+// workingDoctors counts the doctors in on day j
+func workingDoctors(rows []Row, j int) int {
+	n := 0
+	for i := range rows {
+		if staffed(rows[i].Days[j]) {
+			n++
+		}
+	}
+	return n
+}
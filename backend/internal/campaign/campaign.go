@@ -0,0 +1,261 @@
+package campaign
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// Header aliases accepted in pre-registration list imports (English and Thai)
+var columnAliases = map[string][]string{
+	"hn":        {"hn", "patient hn", "เลขที่ผู้ป่วย", "เลข hn"},
+	"name":      {"name", "full name", "fullname", "ชื่อ", "ชื่อ-นามสกุล", "ชื่อ-สกุล"},
+	"phone":     {"phone", "mobile", "tel", "เบอร์โทร", "เบอร์โทรศัพท์", "โทรศัพท์"},
+	"preferred": {"preferred date", "date", "วันที่สะดวก", "วันที่"},
+}
+
+// DayStats is one campaign day's capacity and throughput
+type DayStats struct {
+	Date      string `json:"date"`
+	Slots     int    `json:"slots"`
+	Capacity  int    `json:"capacity"`
+	Booked    int    `json:"booked"`
+	CheckedIn int    `json:"checkedIn"`
+}
+
+// HourStats counts check-ins within one clock hour
+type HourStats struct {
+	Hour      string `json:"hour"` // YYYY-MM-DD HH:00
+	CheckedIn int    `json:"checkedIn"`
+}
+
+// Dashboard summarizes throughput during a campaign
+type Dashboard struct {
+	CampaignID       int            `json:"campaignId"`
+	Status           string         `json:"status"`
+	TotalCapacity    int            `json:"totalCapacity"`
+	Booked           int            `json:"booked"`
+	CheckedIn        int            `json:"checkedIn"`
+	Utilization      float64        `json:"utilization"`       // booked / capacity, percent
+	AttendanceRate   float64        `json:"attendanceRate"`    // checked-in / booked, percent
+	CheckedInLastHr  int            `json:"checkedInLastHour"` // current throughput
+	Registrations    map[string]int `json:"registrations"`     // by status
+	Days             []DayStats     `json:"days"`
+	HourlyCheckIns   []HourStats    `json:"hourlyCheckIns"`
+	NextOpenSlotTime *time.Time     `json:"nextOpenSlotTime,omitempty"`
+}
+
+// Slots generates the group-session slot templates for every campaign day
+func Slots(c *database.Campaign, loc *time.Location) ([]database.GroupSession, error) {
+	startDate, err := time.ParseInLocation("2006-01-02", c.StartDate, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid startDate %q", c.StartDate)
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", c.EndDate, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endDate %q", c.EndDate)
+	}
+	dailyStart, err := time.Parse("15:04", c.DailyStart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dailyStart %q", c.DailyStart)
+	}
+	dailyEnd, err := time.Parse("15:04", c.DailyEnd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dailyEnd %q", c.DailyEnd)
+	}
+	if endDate.Before(startDate) || !dailyEnd.After(dailyStart) || c.SlotMinutes <= 0 {
+		return nil, fmt.Errorf("campaign dates, hours or slot length are out of order")
+	}
+
+	slot := time.Duration(c.SlotMinutes) * time.Minute
+	campaignID := c.ID
+
+	var slots []database.GroupSession
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		if c.SkipWeekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+		open := time.Date(day.Year(), day.Month(), day.Day(), dailyStart.Hour(), dailyStart.Minute(), 0, 0, loc)
+		closing := time.Date(day.Year(), day.Month(), day.Day(), dailyEnd.Hour(), dailyEnd.Minute(), 0, 0, loc)
+		for at := open; !at.Add(slot).After(closing); at = at.Add(slot) {
+			slots = append(slots, database.GroupSession{
+				Title:       fmt.Sprintf("%s %s", c.Name, at.Format("15:04")),
+				SessionType: c.SessionType,
+				StartsAt:    at,
+				EndsAt:      at.Add(slot),
+				Location:    c.Location,
+				Capacity:    c.SlotCapacity,
+				Status:      database.SessionStatusScheduled,
+				CampaignID:  &campaignID,
+			})
+		}
+	}
+
+	return slots, nil
+}
+
+// ParseRegistrationCSV reads a pre-registration list. Each row needs a name or an HN.
+func ParseRegistrationCSV(src io.Reader, campaignID int) ([]database.CampaignRegistration, error) {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registration list header: %w", err)
+	}
+
+	columns := mapColumns(header)
+	_, hasHN := columns["hn"]
+	_, hasName := columns["name"]
+	if !hasHN && !hasName {
+		return nil, fmt.Errorf("registration list needs an hn or name column")
+	}
+
+	var regs []database.CampaignRegistration
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		reg := database.CampaignRegistration{
+			CampaignID: campaignID,
+			FullName:   field(record, columns, "name"),
+			Status:     database.RegistrationStatusRegistered,
+		}
+		if hn := strings.ToUpper(field(record, columns, "hn")); hn != "" {
+			reg.PatientHN = &hn
+		}
+		if phone := field(record, columns, "phone"); phone != "" {
+			reg.Phone = &phone
+		}
+		if s := field(record, columns, "preferred"); s != "" {
+			d, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: preferred date must be YYYY-MM-DD", line)
+			}
+			preferred := d.Format("2006-01-02")
+			reg.PreferredDate = &preferred
+		}
+		if reg.PatientHN == nil && reg.FullName == "" {
+			continue
+		}
+
+		regs = append(regs, reg)
+	}
+
+	return regs, nil
+}
+
+// BuildDashboard aggregates slot bookings and check-ins for a campaign.
+// bookings is keyed by session ID.
+func BuildDashboard(c *database.Campaign, sessions []database.GroupSession, bookings map[int][]database.GroupBooking,
+	regs []database.CampaignRegistration, now time.Time) Dashboard {
+	d := Dashboard{
+		CampaignID:     c.ID,
+		Status:         c.Status,
+		Registrations:  make(map[string]int),
+		Days:           []DayStats{},
+		HourlyCheckIns: []HourStats{},
+	}
+
+	days := make(map[string]*DayStats)
+	hours := make(map[string]int)
+	for _, s := range sessions {
+		if s.Status == database.SessionStatusCancelled {
+			continue
+		}
+		date := s.StartsAt.Format("2006-01-02")
+		day, ok := days[date]
+		if !ok {
+			day = &DayStats{Date: date}
+			days[date] = day
+		}
+		day.Slots++
+		day.Capacity += s.Capacity
+		d.TotalCapacity += s.Capacity
+
+		booked := 0
+		for _, b := range bookings[s.ID] {
+			if b.Status == database.BookingStatusCancelled {
+				continue
+			}
+			booked++
+			if b.Status == database.BookingStatusCheckedIn && b.CheckedInAt != nil {
+				day.CheckedIn++
+				d.CheckedIn++
+				hours[b.CheckedInAt.Format("2006-01-02 15:00")]++
+				if now.Sub(*b.CheckedInAt) <= time.Hour {
+					d.CheckedInLastHr++
+				}
+			}
+		}
+		day.Booked += booked
+		d.Booked += booked
+
+		if booked < s.Capacity && s.StartsAt.After(now) && d.NextOpenSlotTime == nil {
+			start := s.StartsAt
+			d.NextOpenSlotTime = &start
+		}
+	}
+
+	for _, day := range days {
+		d.Days = append(d.Days, *day)
+	}
+	sort.Slice(d.Days, func(i, j int) bool { return d.Days[i].Date < d.Days[j].Date })
+
+	for hour, n := range hours {
+		d.HourlyCheckIns = append(d.HourlyCheckIns, HourStats{Hour: hour, CheckedIn: n})
+	}
+	sort.Slice(d.HourlyCheckIns, func(i, j int) bool { return d.HourlyCheckIns[i].Hour < d.HourlyCheckIns[j].Hour })
+
+	for _, reg := range regs {
+		d.Registrations[reg.Status]++
+	}
+
+	if d.TotalCapacity > 0 {
+		d.Utilization = float64(d.Booked) / float64(d.TotalCapacity) * 100
+	}
+	if d.Booked > 0 {
+		d.AttendanceRate = float64(d.CheckedIn) / float64(d.Booked) * 100
+	}
+
+	return d
+}
+
+func mapColumns(header []string) map[string]int {
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, aliases := range columnAliases {
+			if _, seen := columns[column]; seen {
+				continue
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	return columns
+}
+
+func field(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,198 @@
+package careplan
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// offTrackMargin is how many percentage points progress may lag the
+// straight-line schedule before a goal is flagged off-track
+const offTrackMargin = 20.0
+
+// Progress flags
+const (
+	FlagOffTrack          = "off_track"
+	FlagWrongDirection    = "wrong_direction"
+	FlagCheckpointOverdue = "checkpoint_overdue"
+	FlagTargetDatePassed  = "target_date_passed"
+	FlagAchieved          = "achieved"
+)
+
+// Measurement is a value of a metric taken from vitals or lab results
+type Measurement struct {
+	Value      float64
+	Source     string // vitals/lab
+	MeasuredAt time.Time
+}
+
+// MeasurementSource supplies a patient's recorded values of a metric
+// (e.g. weight from vital signs, HbA1c from lab results)
+type MeasurementSource interface {
+	Measurements(hn, metric string, since time.Time) ([]Measurement, error)
+}
+
+// Progress summarizes how a goal is going
+type Progress struct {
+	GoalID            int      `json:"goalId"`
+	Latest            *float64 `json:"latest"`
+	PercentComplete   float64  `json:"percentComplete"` // 0 at baseline, 100 at target; negative when moving away
+	ExpectedPercent   float64  `json:"expectedPercent"` // where a straight line from start to target date would be today
+	OnTrack           bool     `json:"onTrack"`
+	Achieved          bool     `json:"achieved"`
+	Flags             []string `json:"flags"`
+	NextCheckpointDue *string  `json:"nextCheckpointDue,omitempty"` // YYYY-MM-DD
+}
+
+// TimelineEvent is one entry on a patient's care plan timeline
+type TimelineEvent struct {
+	At      time.Time `json:"at"`
+	Type    string    `json:"type"` // goal_set/checkpoint/off_track/achieved
+	GoalID  int       `json:"goalId"`
+	Metric  string    `json:"metric"`
+	Value   *float64  `json:"value,omitempty"`
+	Summary string    `json:"summary"`
+}
+
+// Evaluate computes progress of a goal from its checkpoints (in measurement order) as of now
+func Evaluate(goal database.CarePlanGoal, checkpoints []database.GoalCheckpoint, now time.Time) Progress {
+	p := Progress{GoalID: goal.ID, Flags: []string{}}
+
+	start, _ := time.ParseInLocation("2006-01-02", goal.StartDate, now.Location())
+	end, _ := time.ParseInLocation("2006-01-02", goal.TargetDate, now.Location())
+	if span := end.Sub(start); span > 0 {
+		p.ExpectedPercent = clamp(float64(now.Sub(start))/float64(span)*100, 0, 100)
+	}
+
+	lastMeasured := start
+	if len(checkpoints) > 0 {
+		last := checkpoints[len(checkpoints)-1]
+		p.Latest = &last.Value
+		lastMeasured = last.MeasuredAt
+		p.PercentComplete = percentComplete(goal, last.Value)
+	}
+
+	p.Achieved = goal.Status == database.GoalStatusAchieved || (p.Latest != nil && reached(goal, *p.Latest))
+	switch {
+	case p.Achieved:
+		p.Flags = append(p.Flags, FlagAchieved)
+	case goal.Status == database.GoalStatusActive:
+		if p.Latest != nil && p.PercentComplete < 0 {
+			p.Flags = append(p.Flags, FlagWrongDirection)
+		}
+		if p.PercentComplete < p.ExpectedPercent-offTrackMargin {
+			p.Flags = append(p.Flags, FlagOffTrack)
+		}
+		if !end.IsZero() && now.After(end.AddDate(0, 0, 1)) {
+			p.Flags = append(p.Flags, FlagTargetDatePassed)
+		}
+		if goal.CheckEveryDays > 0 {
+			due := lastMeasured.AddDate(0, 0, goal.CheckEveryDays)
+			dueDate := due.Format("2006-01-02")
+			p.NextCheckpointDue = &dueDate
+			if now.After(due) {
+				p.Flags = append(p.Flags, FlagCheckpointOverdue)
+			}
+		}
+	}
+
+	p.OnTrack = !hasFlag(p.Flags, FlagOffTrack) && !hasFlag(p.Flags, FlagWrongDirection)
+	return p
+}
+
+// Timeline lists goal creation, checkpoints and the points where a goal fell
+// off-track or was achieved, newest first. checkpoints is keyed by goal ID.
+func Timeline(goals []database.CarePlanGoal, checkpoints map[int][]database.GoalCheckpoint) []TimelineEvent {
+	events := []TimelineEvent{}
+
+	for _, g := range goals {
+		setAt := g.CreatedAt
+		if start, err := time.ParseInLocation("2006-01-02", g.StartDate, setAt.Location()); err == nil && start.Before(setAt) {
+			setAt = start
+		}
+		events = append(events, TimelineEvent{
+			At:      setAt,
+			Type:    "goal_set",
+			GoalID:  g.ID,
+			Metric:  g.Metric,
+			Summary: fmt.Sprintf("Goal set: %s from %g to %g %s by %s", g.Metric, g.Baseline, g.Target, g.Unit, g.TargetDate),
+		})
+
+		// Replay history as an active goal so the current status doesn't leak backwards
+		replay := g
+		replay.Status = database.GoalStatusActive
+
+		wasOffTrack := false
+		for i, c := range checkpoints[g.ID] {
+			value := c.Value
+			events = append(events, TimelineEvent{
+				At:      c.MeasuredAt,
+				Type:    "checkpoint",
+				GoalID:  g.ID,
+				Metric:  g.Metric,
+				Value:   &value,
+				Summary: fmt.Sprintf("%s %g %s (%s)", g.Metric, c.Value, g.Unit, c.Source),
+			})
+
+			// Evaluate as of the checkpoint so flags appear where they happened
+			p := Evaluate(replay, checkpoints[g.ID][:i+1], c.MeasuredAt)
+			if p.Achieved {
+				events = append(events, TimelineEvent{
+					At: c.MeasuredAt, Type: "achieved", GoalID: g.ID, Metric: g.Metric, Value: &value,
+					Summary: fmt.Sprintf("Target %g %s reached", g.Target, g.Unit),
+				})
+				break
+			}
+			if !p.OnTrack && !wasOffTrack {
+				events = append(events, TimelineEvent{
+					At: c.MeasuredAt, Type: "off_track", GoalID: g.ID, Metric: g.Metric, Value: &value,
+					Summary: fmt.Sprintf("Off track: %.0f%% done, %.0f%% expected", p.PercentComplete, p.ExpectedPercent),
+				})
+			}
+			wasOffTrack = !p.OnTrack
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.After(events[j].At)
+	})
+
+	return events
+}
+
+func percentComplete(goal database.CarePlanGoal, value float64) float64 {
+	span := goal.Target - goal.Baseline
+	if span == 0 {
+		return 100
+	}
+	return (value - goal.Baseline) / span * 100
+}
+
+// reached reports whether value is at or beyond the target in the goal's direction
+func reached(goal database.CarePlanGoal, value float64) bool {
+	if goal.Target < goal.Baseline {
+		return value <= goal.Target
+	}
+	return value >= goal.Target
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
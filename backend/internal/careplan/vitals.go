@@ -0,0 +1,61 @@
+package careplan
+
+import (
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// Vitals is the record of measurements goals can be tracked against
+type Vitals interface {
+	List(f database.VitalsFilter) ([]database.VitalSigns, error)
+}
+
+// vitalsMetrics reads each goal metric that vital signs record
+var vitalsMetrics = map[string]func(v *database.VitalSigns) *float64{
+	"weight":       func(v *database.VitalSigns) *float64 { return v.Weight },
+	"bmi":          func(v *database.VitalSigns) *float64 { return v.BMI },
+	"temperature":  func(v *database.VitalSigns) *float64 { return v.Temperature },
+	"systolic_bp":  func(v *database.VitalSigns) *float64 { return intMetric(v.SystolicBP) },
+	"diastolic_bp": func(v *database.VitalSigns) *float64 { return intMetric(v.DiastolicBP) },
+	"pulse":        func(v *database.VitalSigns) *float64 { return intMetric(v.Pulse) },
+	"spo2":         func(v *database.VitalSigns) *float64 { return intMetric(v.SpO2) },
+}
+
+func intMetric(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return &f
+}
+
+// FromVitals measures goals from recorded vital signs. Metrics vitals do not
+// record, such as lab values, have no measurements.
+func FromVitals(vitals Vitals) MeasurementSource {
+	return vitalsMeasurements{vitals: vitals}
+}
+
+type vitalsMeasurements struct {
+	vitals Vitals
+}
+
+func (m vitalsMeasurements) Measurements(hn, metric string, since time.Time) ([]Measurement, error) {
+	value, ok := vitalsMetrics[metric]
+	if !ok {
+		return nil, nil
+	}
+
+	vitals, err := m.vitals.List(database.VitalsFilter{PatientHN: hn, From: since})
+	if err != nil {
+		return nil, err
+	}
+
+	var measurements []Measurement
+	for i := range vitals {
+		if v := value(&vitals[i]); v != nil {
+			measurements = append(measurements, Measurement{Value: *v, Source: database.CheckpointSourceVitals, MeasuredAt: vitals[i].MeasuredAt})
+		}
+	}
+	return measurements, nil
+}
@@ -0,0 +1,178 @@
+package coding
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Entry is an ICD-10 code with the Thai and English terms doctors use for it
+type Entry struct {
+	Code        string   `json:"code"`
+	Description string   `json:"description"`
+	Terms       []string `json:"-"`
+}
+
+// Suggestion is a ranked candidate code for a diagnosis text
+type Suggestion struct {
+	Code        string   `json:"code"`
+	Description string   `json:"description"`
+	Score       float64  `json:"score"`
+	Matched     []string `json:"matched"`
+}
+
+var codePattern = regexp.MustCompile(`^[A-Z][0-9]{2}(\.[0-9A-Z]{1,2})?$`)
+
+// ValidCode reports whether s looks like an ICD-10 code, e.g. "J06.9" or "I10"
+func ValidCode(s string) bool {
+	return codePattern.MatchString(s)
+}
+
+// Index is a keyword/synonym index over ICD-10 entries
+type Index struct {
+	entries []Entry
+	byCode  map[string]*Entry
+}
+
+// NewIndex builds an index over the given entries
+func NewIndex(entries []Entry) *Index {
+	idx := &Index{entries: entries, byCode: make(map[string]*Entry)}
+	for i := range idx.entries {
+		idx.byCode[idx.entries[i].Code] = &idx.entries[i]
+	}
+	return idx
+}
+
+// Lookup returns the entry for a code
+func (idx *Index) Lookup(code string) (*Entry, bool) {
+	e, ok := idx.byCode[strings.ToUpper(code)]
+	return e, ok
+}
+
+// Suggest ranks codes for a free-text diagnosis. Each matched term scores its
+// length and longer terms claim their text first, so "ไข้หวัดใหญ่" counts for
+// influenza rather than also for "หวัด"; a code typed in the text scores highest.
+// popularity (confirmed uses per code, may be nil) breaks ties.
+func (idx *Index) Suggest(text string, limit int, popularity map[string]int) []Suggestion {
+	normalized := strings.ToLower(text)
+	upper := strings.ToUpper(text)
+
+	type match struct {
+		entry      int
+		term       string
+		start, end int
+	}
+	var matches []match
+	scores := make([]Suggestion, len(idx.entries))
+	for i, e := range idx.entries {
+		scores[i] = Suggestion{Code: e.Code, Description: e.Description, Matched: []string{}}
+		if len(findTerm(upper, e.Code)) > 0 {
+			scores[i].Score += 100
+			scores[i].Matched = append(scores[i].Matched, e.Code)
+		}
+		for _, term := range e.Terms {
+			term = strings.ToLower(term)
+			for _, start := range findTerm(normalized, term) {
+				matches = append(matches, match{entry: i, term: term, start: start, end: start + len(term)})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].end-matches[i].start > matches[j].end-matches[j].start
+	})
+	claimed := make([]bool, len(normalized))
+	for _, m := range matches {
+		free := true
+		for b := m.start; b < m.end; b++ {
+			if claimed[b] {
+				free = false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+		for b := m.start; b < m.end; b++ {
+			claimed[b] = true
+		}
+		s := &scores[m.entry]
+		if !contains(s.Matched, m.term) {
+			s.Score += float64(utf8.RuneCountInString(m.term))
+			s.Matched = append(s.Matched, m.term)
+		}
+	}
+
+	suggestions := []Suggestion{}
+	for _, s := range scores {
+		if s.Score > 0 {
+			suggestions = append(suggestions, s)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if popularity[suggestions[i].Code] != popularity[suggestions[j].Code] {
+			return popularity[suggestions[i].Code] > popularity[suggestions[j].Code]
+		}
+		return suggestions[i].Code < suggestions[j].Code
+	})
+
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// findTerm returns the byte offsets where term occurs in text. Latin terms
+// must stand alone ("uri" does not match "during"); Thai is written without
+// spaces between words, so Thai terms match anywhere.
+func findTerm(text, term string) []int {
+	var offsets []int
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], term)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		if boundary(text, term, start, start+len(term)) {
+			offsets = append(offsets, start)
+		}
+		offset = start + 1
+	}
+	return offsets
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func boundary(text, term string, start, end int) bool {
+	first, _ := utf8.DecodeRuneInString(term)
+	if start > 0 && isLatinWordRune(first) {
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isLatinWordRune(before) {
+			return false
+		}
+	}
+	last, _ := utf8.DecodeLastRuneInString(term)
+	if end < len(text) && isLatinWordRune(last) {
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isLatinWordRune(after) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLatinWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
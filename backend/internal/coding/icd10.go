@@ -0,0 +1,50 @@
+package coding
+
+// CommonOutpatient covers the diagnoses most often coded at the clinic
+var CommonOutpatient = []Entry{
+	{"J00", "Acute nasopharyngitis [common cold]", []string{"common cold", "coryza", "หวัด", "ไข้หวัด", "คัดจมูก", "น้ำมูกไหล"}},
+	{"J01.9", "Acute sinusitis, unspecified", []string{"sinusitis", "ไซนัสอักเสบ", "ไซนัส"}},
+	{"J02.9", "Acute pharyngitis, unspecified", []string{"pharyngitis", "sore throat", "คออักเสบ", "เจ็บคอ"}},
+	{"J03.9", "Acute tonsillitis, unspecified", []string{"tonsillitis", "ทอนซิลอักเสบ", "ต่อมทอนซิลอักเสบ"}},
+	{"J06.9", "Acute upper respiratory infection, unspecified", []string{"uri", "upper respiratory infection", "upper respiratory tract infection", "ติดเชื้อทางเดินหายใจส่วนบน"}},
+	{"J11.1", "Influenza with other respiratory manifestations, virus not identified", []string{"influenza", "flu", "ไข้หวัดใหญ่"}},
+	{"J18.9", "Pneumonia, unspecified", []string{"pneumonia", "ปอดอักเสบ", "ปอดบวม"}},
+	{"J20.9", "Acute bronchitis, unspecified", []string{"bronchitis", "acute bronchitis", "หลอดลมอักเสบ"}},
+	{"J30.4", "Allergic rhinitis, unspecified", []string{"allergic rhinitis", "ภูมิแพ้จมูก", "แพ้อากาศ", "จมูกอักเสบจากภูมิแพ้"}},
+	{"J45.9", "Asthma, unspecified", []string{"asthma", "หอบหืด", "โรคหืด"}},
+	{"A09", "Other gastroenteritis and colitis of infectious and unspecified origin", []string{"gastroenteritis", "acute gastroenteritis", "diarrhea", "diarrhoea", "ท้องเสีย", "ท้องร่วง", "ลำไส้อักเสบ"}},
+	{"A90", "Dengue fever [classical dengue]", []string{"dengue", "dengue fever", "ไข้เลือดออก", "ไข้เดงกี"}},
+	{"K02.9", "Dental caries, unspecified", []string{"dental caries", "caries", "ฟันผุ"}},
+	{"K21.9", "Gastro-oesophageal reflux disease without oesophagitis", []string{"gerd", "reflux", "กรดไหลย้อน"}},
+	{"K29.7", "Gastritis, unspecified", []string{"gastritis", "กระเพาะอาหารอักเสบ", "โรคกระเพาะ"}},
+	{"K30", "Functional dyspepsia", []string{"dyspepsia", "อาหารไม่ย่อย", "ท้องอืด"}},
+	{"K59.0", "Constipation", []string{"constipation", "ท้องผูก"}},
+	{"I10", "Essential (primary) hypertension", []string{"hypertension", "ht", "htn", "ความดันโลหิตสูง", "ความดันสูง"}},
+	{"E11.9", "Type 2 diabetes mellitus without complications", []string{"diabetes", "dm", "t2dm", "type 2 diabetes", "เบาหวาน"}},
+	{"E03.9", "Hypothyroidism, unspecified", []string{"hypothyroidism", "ไทรอยด์ต่ำ", "ไทรอยด์ทำงานต่ำ"}},
+	{"E78.5", "Hyperlipidaemia, unspecified", []string{"dyslipidemia", "hyperlipidemia", "dlp", "ไขมันในเลือดสูง", "ไขมันสูง"}},
+	{"D50.9", "Iron deficiency anaemia, unspecified", []string{"iron deficiency anemia", "ida", "anemia", "โลหิตจาง", "ภาวะซีด"}},
+	{"M10.9", "Gout, unspecified", []string{"gout", "เกาต์", "เก๊าท์"}},
+	{"M17.9", "Gonarthrosis, unspecified", []string{"knee oa", "knee osteoarthritis", "osteoarthritis of knee", "ข้อเข่าเสื่อม"}},
+	{"M54.5", "Low back pain", []string{"low back pain", "lbp", "back pain", "ปวดหลัง", "ปวดหลังส่วนล่าง", "ปวดเอว"}},
+	{"M79.1", "Myalgia", []string{"myalgia", "muscle pain", "ปวดกล้ามเนื้อ", "ปวดเมื่อย"}},
+	{"G43.9", "Migraine, unspecified", []string{"migraine", "ไมเกรน"}},
+	{"G47.0", "Disorders of initiating and maintaining sleep", []string{"insomnia", "นอนไม่หลับ"}},
+	{"F32.9", "Depressive episode, unspecified", []string{"depression", "depressive", "ซึมเศร้า"}},
+	{"F41.9", "Anxiety disorder, unspecified", []string{"anxiety", "วิตกกังวล"}},
+	{"H10.9", "Conjunctivitis, unspecified", []string{"conjunctivitis", "pink eye", "ตาแดง", "เยื่อบุตาอักเสบ"}},
+	{"H66.9", "Otitis media, unspecified", []string{"otitis media", "หูชั้นกลางอักเสบ", "หูน้ำหนวก"}},
+	{"L30.9", "Dermatitis, unspecified", []string{"dermatitis", "eczema", "ผิวหนังอักเสบ", "ผื่นแพ้"}},
+	{"L50.9", "Urticaria, unspecified", []string{"urticaria", "hives", "ลมพิษ"}},
+	{"B35.4", "Tinea corporis", []string{"tinea corporis", "ringworm", "กลาก"}},
+	{"N30.9", "Cystitis, unspecified", []string{"cystitis", "กระเพาะปัสสาวะอักเสบ"}},
+	{"N39.0", "Urinary tract infection, site not specified", []string{"uti", "urinary tract infection", "ติดเชื้อทางเดินปัสสาวะ"}},
+	{"R05", "Cough", []string{"cough", "ไอ"}},
+	{"R21", "Rash and other nonspecific skin eruption", []string{"rash", "ผื่น", "ผื่นคัน"}},
+	{"R42", "Dizziness and giddiness", []string{"dizziness", "dizzy", "เวียนหัว", "เวียนศีรษะ", "มึนงง"}},
+	{"R50.9", "Fever, unspecified", []string{"fever", "ไข้", "มีไข้"}},
+	{"R51", "Headache", []string{"headache", "ปวดหัว", "ปวดศีรษะ"}},
+	{"S93.4", "Sprain and strain of ankle", []string{"ankle sprain", "sprained ankle", "ข้อเท้าพลิก", "ข้อเท้าแพลง"}},
+	{"T14.1", "Open wound of unspecified body region", []string{"laceration", "open wound", "cut wound", "แผลฉีกขาด", "แผลเปิด"}},
+	{"Z00.0", "General medical examination", []string{"check up", "checkup", "health check", "ตรวจสุขภาพ"}},
+}
@@ -0,0 +1,148 @@
+package coding
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+//go:embed icd10.tsv
+var bundledTable string
+
+// Table is the ICD-10 code list diagnoses are validated and searched against
+type Table struct {
+	entries []Entry
+	byCode  map[string]int
+}
+
+// Bundled returns the code list shipped with the server
+func Bundled() *Table {
+	t, err := ReadTable(strings.NewReader(bundledTable))
+	if err != nil {
+		panic("coding: bundled ICD-10 table: " + err.Error())
+	}
+	return t
+}
+
+// ReadTable reads a code list of "code<TAB>description" lines, skipping blank
+// lines and # comments. Codes may be written without the dot, as in ICD-10-TM
+// files ("J069" for J06.9); a code listed twice keeps its last description.
+func ReadTable(r io.Reader) (*Table, error) {
+	t := &Table{byCode: make(map[string]int)}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		code, description, ok := strings.Cut(text, "\t")
+		code, description = NormalizeCode(code), strings.TrimSpace(description)
+		if !ok || description == "" {
+			return nil, fmt.Errorf("line %d: expected code<TAB>description", line)
+		}
+		if !ValidCode(code) {
+			return nil, fmt.Errorf("line %d: %q is not an ICD-10 code", line, code)
+		}
+		if i, exists := t.byCode[code]; exists {
+			t.entries[i].Description = description
+			continue
+		}
+		t.byCode[code] = len(t.entries)
+		t.entries = append(t.entries, Entry{Code: code, Description: description})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(t.entries) == 0 {
+		return nil, fmt.Errorf("no codes found")
+	}
+	return t, nil
+}
+
+// NormalizeCode upper-cases a code and puts in the dot ICD-10-TM files leave
+// out, e.g. "j069" to "J06.9"
+func NormalizeCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) > 3 && !strings.Contains(code, ".") {
+		code = code[:3] + "." + code[3:]
+	}
+	return code
+}
+
+// WithTerms adds the Thai and English terms of entries to the matching codes
+// in the table, so searching "เบาหวาน" finds E11.9
+func (t *Table) WithTerms(entries []Entry) *Table {
+	for _, e := range entries {
+		if i, ok := t.byCode[e.Code]; ok {
+			t.entries[i].Terms = append(t.entries[i].Terms, e.Terms...)
+		}
+	}
+	return t
+}
+
+// Len returns the number of codes in the table
+func (t *Table) Len() int {
+	return len(t.entries)
+}
+
+// Lookup returns the entry for a code, with or without its dot
+func (t *Table) Lookup(code string) (*Entry, bool) {
+	i, ok := t.byCode[NormalizeCode(code)]
+	if !ok {
+		return nil, false
+	}
+	e := t.entries[i]
+	return &e, true
+}
+
+// Search finds codes for q: codes starting with q (so "J06" lists J06.x)
+// come first, then codes whose description or terms contain every word of q
+func (t *Table) Search(q string, limit int) []Entry {
+	q = strings.ToLower(strings.TrimSpace(q))
+	prefix := strings.ReplaceAll(strings.ToUpper(q), ".", "")
+	words := strings.Fields(q)
+
+	type hit struct {
+		entry Entry
+		rank  int
+	}
+	hits := []hit{}
+	for _, e := range t.entries {
+		switch {
+		case prefix != "" && strings.HasPrefix(strings.ReplaceAll(e.Code, ".", ""), prefix):
+			hits = append(hits, hit{e, 0})
+		case len(words) > 0 && matchesAll(e, words):
+			hits = append(hits, hit{e, 1})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].rank != hits[j].rank {
+			return hits[i].rank < hits[j].rank
+		}
+		return hits[i].entry.Code < hits[j].entry.Code
+	})
+
+	entries := []Entry{}
+	for _, h := range hits {
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+		entries = append(entries, h.entry)
+	}
+	return entries
+}
+
+// matchesAll reports whether every word occurs in the entry's description or
+// one of its terms
+func matchesAll(e Entry, words []string) bool {
+	text := strings.ToLower(e.Description + "\n" + strings.Join(e.Terms, "\n"))
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
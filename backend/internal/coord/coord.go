@@ -0,0 +1,181 @@
+package coord
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// LeaseStore grants named, time-limited leases shared by every API instance
+type LeaseStore interface {
+	Acquire(name, holder string, ttl time.Duration) (*database.Lease, error)
+	Renew(l *database.Lease, ttl time.Duration) error
+	Release(l *database.Lease) error
+}
+
+// InstanceID names this process uniquely among the instances behind the load balancer
+func InstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Elector keeps one instance elected as leader by holding a renewed lease.
+// A leader that cannot renew in time steps down before its lease expires.
+type Elector struct {
+	store    LeaseStore
+	name     string
+	instance string
+	ttl      time.Duration
+	lease    *database.Lease
+	mutex    sync.RWMutex
+}
+
+// NewElector creates an elector competing for the named leadership lease
+func NewElector(store LeaseStore, name, instance string, ttl time.Duration) *Elector {
+	return &Elector{store: store, name: name, instance: instance, ttl: ttl}
+}
+
+// Run campaigns for leadership until ctx is cancelled, then releases the lease
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.step()
+		select {
+		case <-ctx.Done():
+			e.mutex.Lock()
+			if e.lease != nil {
+				e.store.Release(e.lease)
+				e.lease = nil
+			}
+			e.mutex.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) step() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.lease != nil {
+		if err := e.store.Renew(e.lease, e.ttl); err != nil {
+			log.Printf("Instance %s lost leadership of %s: %v", e.instance, e.name, err)
+			e.lease = nil
+		}
+		return
+	}
+
+	lease, err := e.store.Acquire(e.name, e.instance, e.ttl)
+	if err != nil {
+		log.Printf("Leader election for %s failed: %v", e.name, err)
+		return
+	}
+	if lease != nil {
+		log.Printf("Instance %s is now leader of %s (token %d)", e.instance, e.name, lease.Token)
+		e.lease = lease
+	}
+}
+
+// IsLeader reports whether this instance currently holds an unexpired leadership lease
+func (e *Elector) IsLeader() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.lease != nil && time.Now().Before(e.lease.ExpiresAt)
+}
+
+// Token returns the fencing token of the current leadership, or 0 when not leader.
+// Writes made on behalf of the leader can carry it so stale leaders are rejected.
+func (e *Elector) Token() int64 {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.lease == nil {
+		return 0
+	}
+	return e.lease.Token
+}
+
+// Job is a background task run by the scheduler
+type Job func(ctx context.Context) error
+
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler runs each registered job at most once per interval across all
+// instances. Every instance ticks, but only the one that takes the job's lease
+// runs it; the lease is held for the whole interval so no other instance repeats it.
+type Scheduler struct {
+	store    LeaseStore
+	instance string
+	jobs     []scheduledJob
+}
+
+// NewScheduler creates a scheduler for this instance
+func NewScheduler(store LeaseStore, instance string) *Scheduler {
+	return &Scheduler{store: store, instance: instance}
+}
+
+// Every registers a job to run once per interval. Call it before Run.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, interval: interval, run: job})
+}
+
+// Run ticks every registered job until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j scheduledJob) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j scheduledJob) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		lease, err := s.store.Acquire("job:"+j.name, s.instance, j.interval)
+		if err != nil {
+			log.Printf("Job %s: failed to acquire lease: %v", j.name, err)
+			continue
+		}
+		if lease == nil {
+			continue // another instance runs it this interval
+		}
+
+		jobCtx, cancel := context.WithTimeout(ctx, j.interval)
+		if err := j.run(jobCtx); err != nil {
+			log.Printf("Job %s failed: %v", j.name, err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Accessibility accommodations
+const (
+	NeedWheelchair      = "wheelchair"
+	NeedWalkingAid      = "walking_aid"
+	NeedStretcher       = "stretcher"
+	NeedPrioritySeating = "priority_seating"
+	NeedLowVision       = "low_vision"
+	NeedBlind           = "blind"
+	NeedHardOfHearing   = "hard_of_hearing"
+	NeedDeaf            = "deaf"
+	NeedSignLanguage    = "sign_language"
+	NeedCognitive       = "cognitive_support"
+)
+
+// AccessibilityNeeds lists every accommodation staff can record
+var AccessibilityNeeds = []string{
+	NeedWheelchair, NeedWalkingAid, NeedStretcher, NeedPrioritySeating,
+	NeedLowVision, NeedBlind, NeedHardOfHearing, NeedDeaf, NeedSignLanguage, NeedCognitive,
+}
+
+// PatientAccessibility records the accommodations a patient needs at the clinic
+type PatientAccessibility struct {
+	PatientHN string    `json:"patientHn" db:"patient_hn"`
+	Needs     []string  `json:"needs" db:"needs"`           // stored comma-separated
+	Notes     *string   `json:"notes,omitempty" db:"notes"` // e.g. "ต้องมีผู้ช่วยพยุงขึ้นเตียง"
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// AccommodationAlert is what check-in and queue screens show staff ahead of arrival
+type AccommodationAlert struct {
+	PatientHN string   `json:"patientHn"`
+	Needs     []string `json:"needs"`
+	Priority  bool     `json:"priority"` // seat/call ahead (wheelchair, stretcher, priority seating)
+	Notes     *string  `json:"notes,omitempty"`
+}
+
+// Alert is the staff-facing alert for the patient's needs, or nil if none are recorded
+func (a *PatientAccessibility) Alert() *AccommodationAlert {
+	if len(a.Needs) == 0 {
+		return nil
+	}
+	return &AccommodationAlert{PatientHN: a.PatientHN, Needs: a.Needs, Priority: a.NeedsPriority(), Notes: a.Notes}
+}
+
+// NeedsPriority reports whether staff should seat or call the patient ahead of others
+func (a *PatientAccessibility) NeedsPriority() bool {
+	for _, n := range a.Needs {
+		switch n {
+		case NeedWheelchair, NeedStretcher, NeedPrioritySeating, NeedBlind:
+			return true
+		}
+	}
+	return false
+}
+
+// AccessibilityRepository handles patient accessibility database operations
+type AccessibilityRepository struct {
+	db *DB
+}
+
+// NewAccessibilityRepository creates a new accessibility repository
+func NewAccessibilityRepository(db *DB) *AccessibilityRepository {
+	return &AccessibilityRepository{db: db}
+}
+
+// Upsert saves a patient's accessibility record
+func (r *AccessibilityRepository) Upsert(a *PatientAccessibility) error {
+	query := `
+		INSERT INTO patient_accessibility (patient_hn, needs, notes)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (patient_hn) DO UPDATE SET needs = $2, notes = $3, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, a.PatientHN, strings.Join(a.Needs, ","), a.Notes).Scan(&a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save patient accessibility: %w", err)
+	}
+
+	return nil
+}
+
+// GetByPatient retrieves a patient's accessibility record
+func (r *AccessibilityRepository) GetByPatient(hn string) (*PatientAccessibility, error) {
+	query := "SELECT patient_hn, needs, notes, updated_at FROM patient_accessibility WHERE patient_hn = $1"
+
+	var a PatientAccessibility
+	var needs string
+	err := r.db.conn.QueryRow(query, hn).Scan(&a.PatientHN, &needs, &a.Notes, &a.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("accessibility record for patient %s not found", hn)
+		}
+		return nil, fmt.Errorf("failed to get patient accessibility: %w", err)
+	}
+	a.Needs = splitNeeds(needs)
+
+	return &a, nil
+}
+
+// CountByNeed counts patients recorded with each accommodation
+func (r *AccessibilityRepository) CountByNeed() (map[string]int, error) {
+	query := `
+		SELECT need, COUNT(*)
+		FROM patient_accessibility, unnest(string_to_array(needs, ',')) AS need
+		WHERE needs <> ''
+		GROUP BY need
+	`
+
+	rows, err := r.db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count accessibility needs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var need string
+		var n int
+		if err := rows.Scan(&need, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan accessibility count: %w", err)
+		}
+		counts[need] = n
+	}
+
+	return counts, nil
+}
+
+func splitNeeds(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// AddressKinds are what an address is to the patient
+var AddressKinds = []string{
+	"home",       // where the patient lives now
+	"registered", // ที่อยู่ตามทะเบียนบ้าน, as on the house registration
+	"work",
+	"billing",
+	"other",
+}
+
+// bangkok is the province whose tambon and amphoe are called khwaeng and khet
+const bangkok = "กรุงเทพมหานคร"
+
+// Address is a patient's address in the Thai structure: house number, moo
+// (village number), tambon, amphoe and province, with a postal code. A patient
+// may have several; the primary one is used on letters, certificates and claims.
+type Address struct {
+	ID         int       `json:"id" db:"id"`
+	PatientHN  string    `json:"patientHn" db:"patient_hn"`
+	Kind       string    `json:"kind" db:"kind"`                 // home, registered, work, billing, other
+	HouseNo    string    `json:"houseNo" db:"house_no"`          // บ้านเลขที่, e.g. "99/12"
+	Moo        *string   `json:"moo,omitempty" db:"moo"`         // หมู่ที่, e.g. "4"
+	Village    *string   `json:"village,omitempty" db:"village"` // หมู่บ้าน or building name
+	Soi        *string   `json:"soi,omitempty" db:"soi"`         // ซอย
+	Road       *string   `json:"road,omitempty" db:"road"`       // ถนน
+	Tambon     string    `json:"tambon" db:"tambon"`             // ตำบล/แขวง
+	Amphoe     string    `json:"amphoe" db:"amphoe"`             // อำเภอ/เขต
+	Province   string    `json:"province" db:"province"`         // จังหวัด
+	PostalCode string    `json:"postalCode" db:"postal_code"`    // รหัสไปรษณีย์, 5 digits
+	Primary    bool      `json:"primary" db:"is_primary"`        // the address used when only one is printed
+	Notes      *string   `json:"notes,omitempty" db:"notes"`     // e.g. directions for home visits
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+
+	Line string `json:"line" db:"-"` // the whole address on one line, filled in by the API
+}
+
+// FormatLine writes the address on one line the way Thai post expects, e.g.
+// "99/12 หมู่ 4 ต.บางพลีใหญ่ อ.บางพลี จ.สมุทรปราการ 10540"; Bangkok addresses
+// name their แขวง and เขต instead
+func (a *Address) FormatLine() string {
+	parts := []string{a.HouseNo}
+	if a.Moo != nil {
+		parts = append(parts, "หมู่ "+*a.Moo)
+	}
+	if a.Village != nil {
+		parts = append(parts, *a.Village)
+	}
+	if a.Soi != nil {
+		parts = append(parts, "ซ."+*a.Soi)
+	}
+	if a.Road != nil {
+		parts = append(parts, "ถ."+*a.Road)
+	}
+	if a.Province == bangkok {
+		parts = append(parts, "แขวง"+a.Tambon, "เขต"+a.Amphoe, a.Province)
+	} else {
+		parts = append(parts, "ต."+a.Tambon, "อ."+a.Amphoe, "จ."+a.Province)
+	}
+	return strings.Join(append(parts, a.PostalCode), " ")
+}
+
+// AddressRepository handles patient address database operations
+type AddressRepository struct {
+	db *DB
+}
+
+// NewAddressRepository creates a new address repository
+func NewAddressRepository(db *DB) *AddressRepository {
+	return &AddressRepository{db: db}
+}
+
+const addressColumns = `id, patient_hn, kind, house_no, moo, village, soi, road, tambon, amphoe, province, postal_code,
+	is_primary, notes, created_at, updated_at`
+
+func scanAddress(row interface{ Scan(...interface{}) error }) (*Address, error) {
+	var a Address
+	err := row.Scan(&a.ID, &a.PatientHN, &a.Kind, &a.HouseNo, &a.Moo, &a.Village, &a.Soi, &a.Road, &a.Tambon, &a.Amphoe,
+		&a.Province, &a.PostalCode, &a.Primary, &a.Notes, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Create adds an address to a patient. The patient's first address is their
+// primary one, and a new primary address demotes the previous one.
+func (r *AddressRepository) Create(a *Address) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin address: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('addresses:' || $1))", a.PatientHN); err != nil {
+		return fmt.Errorf("failed to lock patient addresses: %w", err)
+	}
+	var others bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM patient_addresses WHERE patient_hn = $1)", a.PatientHN).Scan(&others); err != nil {
+		return fmt.Errorf("failed to check patient addresses: %w", err)
+	}
+	a.Primary = a.Primary || !others
+	if a.Primary {
+		if _, err := tx.Exec("UPDATE patient_addresses SET is_primary = FALSE WHERE patient_hn = $1", a.PatientHN); err != nil {
+			return fmt.Errorf("failed to demote primary address: %w", err)
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO patient_addresses (patient_hn, kind, house_no, moo, village, soi, road, tambon, amphoe, province,
+			postal_code, is_primary, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at
+	`, a.PatientHN, a.Kind, a.HouseNo, a.Moo, a.Village, a.Soi, a.Road, a.Tambon, a.Amphoe, a.Province,
+		a.PostalCode, a.Primary, a.Notes).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create address: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an address by ID
+func (r *AddressRepository) GetByID(id int) (*Address, error) {
+	a, err := scanAddress(r.db.conn.QueryRow("SELECT "+addressColumns+" FROM patient_addresses WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("address %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get address: %w", err)
+	}
+	return a, nil
+}
+
+// GetByPatient retrieves a patient's addresses, the primary one first
+func (r *AddressRepository) GetByPatient(hn string) ([]Address, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+addressColumns+` FROM patient_addresses
+		WHERE patient_hn = $1
+		ORDER BY is_primary DESC, id
+	`, hn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []Address{}
+	for rows.Next() {
+		a, err := scanAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		addresses = append(addresses, *a)
+	}
+
+	return addresses, rows.Err()
+}
+
+// Update replaces an address's details; making it primary demotes the
+// patient's previous primary address
+func (r *AddressRepository) Update(a *Address) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin address: %w", err)
+	}
+	defer tx.Rollback()
+
+	if a.Primary {
+		if _, err := tx.Exec("UPDATE patient_addresses SET is_primary = FALSE WHERE patient_hn = $1 AND id <> $2", a.PatientHN, a.ID); err != nil {
+			return fmt.Errorf("failed to demote primary address: %w", err)
+		}
+	}
+
+	updated, err := scanAddress(tx.QueryRow(`
+		UPDATE patient_addresses SET kind = $2, house_no = $3, moo = $4, village = $5, soi = $6, road = $7, tambon = $8,
+			amphoe = $9, province = $10, postal_code = $11, is_primary = $12, notes = $13, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+addressColumns, a.ID, a.Kind, a.HouseNo, a.Moo, a.Village, a.Soi, a.Road, a.Tambon, a.Amphoe,
+		a.Province, a.PostalCode, a.Primary, a.Notes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("address %d not found", a.ID)
+		}
+		return fmt.Errorf("failed to update address: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address: %w", err)
+	}
+	*a = *updated
+
+	return nil
+}
+
+// Delete removes an address. When it was the primary one, the patient's
+// oldest remaining address becomes primary.
+func (r *AddressRepository) Delete(id int) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin address: %w", err)
+	}
+	defer tx.Rollback()
+
+	var hn string
+	var primary bool
+	err = tx.QueryRow("DELETE FROM patient_addresses WHERE id = $1 RETURNING patient_hn, is_primary", id).Scan(&hn, &primary)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("address %d not found", id)
+		}
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	if primary {
+		_, err := tx.Exec(`
+			UPDATE patient_addresses SET is_primary = TRUE, updated_at = CURRENT_TIMESTAMP
+			WHERE id = (SELECT MIN(id) FROM patient_addresses WHERE patient_hn = $1)
+		`, hn)
+		if err != nil {
+			return fmt.Errorf("failed to promote primary address: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit address: %w", err)
+	}
+
+	return nil
+}
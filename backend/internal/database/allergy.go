@@ -0,0 +1,157 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Allergy severities, mildest first
+const (
+	SeverityMild            = "mild"
+	SeverityModerate        = "moderate"
+	SeveritySevere          = "severe"
+	SeverityLifeThreatening = "life_threatening"
+)
+
+// AllergySeverities lists every severity, mildest first
+var AllergySeverities = []string{SeverityMild, SeverityModerate, SeveritySevere, SeverityLifeThreatening}
+
+// Allergy statuses; an allergy that no longer applies is kept on record as inactive
+const (
+	AllergyActive   = "active"
+	AllergyInactive = "inactive"
+)
+
+// Allergy is something a patient is allergic or intolerant to
+type Allergy struct {
+	ID         int       `json:"id" db:"id"`
+	PatientHN  string    `json:"patientHn" db:"patient_hn"`
+	Allergen   string    `json:"allergen" db:"allergen"`           // e.g. "Penicillin", "กุ้ง"
+	Reaction   *string   `json:"reaction,omitempty" db:"reaction"` // e.g. "ผื่นลมพิษ", "anaphylaxis"
+	Severity   string    `json:"severity" db:"severity"`           // one of AllergySeverities
+	NotedDate  string    `json:"notedDate" db:"noted_date"`        // YYYY-MM-DD
+	Status     string    `json:"status" db:"status"`               // active or inactive
+	Notes      *string   `json:"notes,omitempty" db:"notes"`       // e.g. how it was confirmed
+	RecordedBy string    `json:"recordedBy" db:"recorded_by"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// AllergyRepository handles patient allergy database operations
+type AllergyRepository struct {
+	db *DB
+}
+
+// NewAllergyRepository creates a new allergy repository
+func NewAllergyRepository(db *DB) *AllergyRepository {
+	return &AllergyRepository{db: db}
+}
+
+const allergyColumns = `id, patient_hn, allergen, reaction, severity, to_char(noted_date, 'YYYY-MM-DD'), status, notes,
+	recorded_by, created_at, updated_at`
+
+func scanAllergy(row interface{ Scan(...interface{}) error }) (*Allergy, error) {
+	var a Allergy
+	err := row.Scan(&a.ID, &a.PatientHN, &a.Allergen, &a.Reaction, &a.Severity, &a.NotedDate, &a.Status, &a.Notes,
+		&a.RecordedBy, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Create stores a patient's allergy
+func (r *AllergyRepository) Create(a *Allergy) error {
+	query := `
+		INSERT INTO patient_allergies (patient_hn, allergen, reaction, severity, noted_date, status, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, a.PatientHN, a.Allergen, a.Reaction, a.Severity, a.NotedDate, a.Status, a.Notes, a.RecordedBy).
+		Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create allergy: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an allergy by ID
+func (r *AllergyRepository) GetByID(id int) (*Allergy, error) {
+	a, err := scanAllergy(r.db.conn.QueryRow("SELECT "+allergyColumns+" FROM patient_allergies WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("allergy %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get allergy: %w", err)
+	}
+	return a, nil
+}
+
+// GetByPatient retrieves a patient's allergies, most severe first; activeOnly
+// leaves out those marked inactive
+func (r *AllergyRepository) GetByPatient(hn string, activeOnly bool) ([]Allergy, error) {
+	query := `
+		SELECT ` + allergyColumns + ` FROM patient_allergies
+		WHERE patient_hn = $1 AND (NOT $2 OR status = 'active')
+		ORDER BY status, array_position(ARRAY['life_threatening', 'severe', 'moderate', 'mild'], severity::text), allergen
+	`
+
+	rows, err := r.db.conn.Query(query, hn, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allergies: %w", err)
+	}
+	defer rows.Close()
+
+	allergies := []Allergy{}
+	for rows.Next() {
+		a, err := scanAllergy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan allergy: %w", err)
+		}
+		allergies = append(allergies, *a)
+	}
+
+	return allergies, rows.Err()
+}
+
+// Update replaces an allergy's details and status; the patient it belongs to does not change
+func (r *AllergyRepository) Update(a *Allergy) error {
+	updated, err := scanAllergy(r.db.conn.QueryRow(`
+		UPDATE patient_allergies SET allergen = $2, reaction = $3, severity = $4, noted_date = $5, status = $6, notes = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+allergyColumns, a.ID, a.Allergen, a.Reaction, a.Severity, a.NotedDate, a.Status, a.Notes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("allergy %d not found", a.ID)
+		}
+		return fmt.Errorf("failed to update allergy: %w", err)
+	}
+	*a = *updated
+
+	return nil
+}
+
+// Delete removes an allergy entered by mistake; allergies that no longer
+// apply should be marked inactive instead
+func (r *AllergyRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM patient_allergies WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete allergy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("allergy %d not found", id)
+	}
+
+	return nil
+}
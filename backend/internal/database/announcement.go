@@ -0,0 +1,224 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Announcement priorities, least pressing first
+const (
+	PriorityInfo      = "info"
+	PriorityImportant = "important"
+	PriorityUrgent    = "urgent"
+)
+
+// AnnouncementPriorities lists every priority, least pressing first
+var AnnouncementPriorities = []string{PriorityInfo, PriorityImportant, PriorityUrgent}
+
+// Announcement is a clinic-wide notice to staff, such as a schedule change or
+// a new protocol, shown from PublishAt until ExpiresAt
+type Announcement struct {
+	ID           int        `json:"id" db:"id"`
+	Title        string     `json:"title" db:"title"` // e.g. "ปิดคลินิกวันหยุดชดเชย 23 ต.ค."
+	Body         string     `json:"body" db:"body"`
+	Priority     string     `json:"priority" db:"priority"`
+	RequiresAck  bool       `json:"requiresAck" db:"requires_ack"` // staff must confirm they have read it
+	PublishAt    time.Time  `json:"publishAt" db:"publish_at"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty" db:"expires_at"` // nil keeps it up until taken down
+	CreatedBy    string     `json:"createdBy" db:"created_by"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updatedAt" db:"updated_at"`
+	AckCount     int        `json:"ackCount" db:"-"`               // staff who have acknowledged it
+	Acknowledged *bool      `json:"acknowledged,omitempty" db:"-"` // whether the staff member listing it has
+}
+
+// ActiveAt reports whether the announcement is shown at t
+func (a *Announcement) ActiveAt(t time.Time) bool {
+	return !a.PublishAt.After(t) && (a.ExpiresAt == nil || a.ExpiresAt.After(t))
+}
+
+// Acknowledgment records that a member of staff has read an announcement
+type Acknowledgment struct {
+	AnnouncementID int       `json:"announcementId" db:"announcement_id"`
+	StaffName      string    `json:"staffName" db:"staff_name"`
+	AcknowledgedAt time.Time `json:"acknowledgedAt" db:"acknowledged_at"`
+}
+
+// AnnouncementFilter narrows an announcement listing. A zero ActiveAt lists
+// every announcement, scheduled and expired included. Staff, when set, is
+// whose acknowledgments are reported; Unacknowledged leaves out theirs.
+type AnnouncementFilter struct {
+	ActiveAt       time.Time
+	Staff          string
+	Unacknowledged bool
+}
+
+// AnnouncementRepository handles announcement database operations
+type AnnouncementRepository struct {
+	db *DB
+}
+
+// NewAnnouncementRepository creates a new announcement repository
+func NewAnnouncementRepository(db *DB) *AnnouncementRepository {
+	return &AnnouncementRepository{db: db}
+}
+
+const announcementColumns = `id, title, body, priority, requires_ack, publish_at, expires_at, created_by, created_at, updated_at,
+	(SELECT COUNT(*) FROM announcement_acks WHERE announcement_id = announcements.id)`
+
+func scanAnnouncement(row interface{ Scan(...interface{}) error }) (*Announcement, error) {
+	var a Announcement
+	err := row.Scan(&a.ID, &a.Title, &a.Body, &a.Priority, &a.RequiresAck, &a.PublishAt, &a.ExpiresAt, &a.CreatedBy, &a.CreatedAt, &a.UpdatedAt,
+		&a.AckCount)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Create stores an announcement
+func (r *AnnouncementRepository) Create(a *Announcement) error {
+	query := `
+		INSERT INTO announcements (title, body, priority, requires_ack, publish_at, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, a.Title, a.Body, a.Priority, a.RequiresAck, a.PublishAt, a.ExpiresAt, a.CreatedBy).
+		Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an announcement by ID
+func (r *AnnouncementRepository) GetByID(id int) (*Announcement, error) {
+	a, err := scanAnnouncement(r.db.conn.QueryRow("SELECT "+announcementColumns+" FROM announcements WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("announcement %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get announcement: %w", err)
+	}
+	return a, nil
+}
+
+// List retrieves announcements matching the filter, most pressing and then newest first
+func (r *AnnouncementRepository) List(f AnnouncementFilter) ([]Announcement, error) {
+	query := `
+		SELECT ` + announcementColumns + `,
+			EXISTS (SELECT 1 FROM announcement_acks WHERE announcement_id = announcements.id AND LOWER(staff_name) = LOWER($2))
+		FROM announcements
+		WHERE ($1::timestamp IS NULL OR (publish_at <= $1 AND (expires_at IS NULL OR expires_at > $1)))
+		ORDER BY array_position(ARRAY['urgent', 'important', 'info'], priority::text), publish_at DESC, id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, nullTime(f.ActiveAt), f.Staff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcements: %w", err)
+	}
+	defer rows.Close()
+
+	announcements := []Announcement{}
+	for rows.Next() {
+		var a Announcement
+		var acknowledged bool
+		err := rows.Scan(&a.ID, &a.Title, &a.Body, &a.Priority, &a.RequiresAck, &a.PublishAt, &a.ExpiresAt, &a.CreatedBy, &a.CreatedAt, &a.UpdatedAt,
+			&a.AckCount, &acknowledged)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan announcement: %w", err)
+		}
+		if f.Staff != "" {
+			if f.Unacknowledged && acknowledged {
+				continue
+			}
+			a.Acknowledged = &acknowledged
+		}
+		announcements = append(announcements, a)
+	}
+
+	return announcements, rows.Err()
+}
+
+// Update replaces an announcement's content and window; acknowledgments are kept
+func (r *AnnouncementRepository) Update(a *Announcement) error {
+	updated, err := scanAnnouncement(r.db.conn.QueryRow(`
+		UPDATE announcements SET title = $2, body = $3, priority = $4, requires_ack = $5, publish_at = $6, expires_at = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+announcementColumns, a.ID, a.Title, a.Body, a.Priority, a.RequiresAck, a.PublishAt, a.ExpiresAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("announcement %d not found", a.ID)
+		}
+		return fmt.Errorf("failed to update announcement: %w", err)
+	}
+	*a = *updated
+
+	return nil
+}
+
+// Delete removes an announcement and its acknowledgments
+func (r *AnnouncementRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM announcements WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete announcement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("announcement %d not found", id)
+	}
+
+	return nil
+}
+
+// Acknowledge records that a member of staff has read an announcement; acknowledging
+// again keeps the first time
+func (r *AnnouncementRepository) Acknowledge(id int, staff string) (*Acknowledgment, error) {
+	query := `
+		INSERT INTO announcement_acks (announcement_id, staff_name) VALUES ($1, $2)
+		ON CONFLICT (announcement_id, staff_name) DO UPDATE SET staff_name = EXCLUDED.staff_name
+		RETURNING announcement_id, staff_name, acknowledged_at
+	`
+
+	var ack Acknowledgment
+	err := r.db.conn.QueryRow(query, id, staff).Scan(&ack.AnnouncementID, &ack.StaffName, &ack.AcknowledgedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return nil, apperr.NotFound("announcement %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to acknowledge announcement: %w", err)
+	}
+	return &ack, nil
+}
+
+// GetAcknowledgments retrieves who has acknowledged an announcement, in the order they did
+func (r *AnnouncementRepository) GetAcknowledgments(id int) ([]Acknowledgment, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT announcement_id, staff_name, acknowledged_at FROM announcement_acks
+		WHERE announcement_id = $1 ORDER BY acknowledged_at, staff_name`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query acknowledgments: %w", err)
+	}
+	defer rows.Close()
+
+	acks := []Acknowledgment{}
+	for rows.Next() {
+		var ack Acknowledgment
+		if err := rows.Scan(&ack.AnnouncementID, &ack.StaffName, &ack.AcknowledgedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan acknowledgment: %w", err)
+		}
+		acks = append(acks, ack)
+	}
+
+	return acks, rows.Err()
+}
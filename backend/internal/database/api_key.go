@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/reqctx"
+)
+
+// APIKey lets an integration call the API as a role without a staff account.
+// Only a hash of the key is stored; the key itself is shown once, when it is
+// created. Keys are revoked rather than deleted so the names recorded on past
+// work keep pointing at something.
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`     // the integration, e.g. "LINE booking bot"
+	Prefix     string     `json:"prefix" db:"prefix"` // start of the key, to tell keys apart
+	KeyHash    string     `json:"-" db:"key_hash"`    // hex SHA-256 of the key
+	Role       string     `json:"role" db:"role"`     // one of APIKeyRoles
+	Sandbox    bool       `json:"sandbox" db:"sandbox"`
+	CreatedBy  string     `json:"createdBy" db:"created_by"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	RevokedBy  *string    `json:"revokedBy,omitempty" db:"revoked_by"`
+}
+
+// APIKeyRoles are the roles an API key can act as: the staff roles, and kiosk
+// for the waiting room's self check-in kiosks
+var APIKeyRoles = append(UserRoles[:len(UserRoles):len(UserRoles)], reqctx.RoleKiosk)
+
+// APIKeyRepository handles API key database operations
+type APIKeyRepository struct {
+	db *DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, role, sandbox, created_by, created_at, last_used_at, revoked_at, revoked_by`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*APIKey, error) {
+	var k APIKey
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, &k.Role, &k.Sandbox, &k.CreatedBy, &k.CreatedAt, &k.LastUsedAt,
+		&k.RevokedAt, &k.RevokedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Create stores a new API key
+func (r *APIKeyRepository) Create(k *APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, role, sandbox, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, k.Name, k.Prefix, k.KeyHash, k.Role, k.Sandbox, k.CreatedBy).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAll retrieves every API key, revoked ones included, newest first
+func (r *APIKeyRepository) GetAll() ([]APIKey, error) {
+	rows, err := r.db.conn.Query("SELECT " + apiKeyColumns + " FROM api_keys ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+
+	return keys, rows.Err()
+}
+
+// GetByHash retrieves the unrevoked key with the given hash
+func (r *APIKeyRepository) GetByHash(hash string) (*APIKey, error) {
+	k, err := scanAPIKey(r.db.conn.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL", hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("API key not found")
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return k, nil
+}
+
+// MarkUsed records that a key was just used
+func (r *APIKeyRepository) MarkUsed(id int) error {
+	if _, err := r.db.conn.Exec("UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to mark API key used: %w", err)
+	}
+	return nil
+}
+
+// Revoke stops a key from working
+func (r *APIKeyRepository) Revoke(id int, by string) (*APIKey, error) {
+	k, err := scanAPIKey(r.db.conn.QueryRow(`
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP, revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING `+apiKeyColumns, id, by))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			var exists bool
+			if err := r.db.conn.QueryRow("SELECT EXISTS (SELECT 1 FROM api_keys WHERE id = $1)", id).Scan(&exists); err != nil {
+				return nil, fmt.Errorf("failed to get API key: %w", err)
+			}
+			if !exists {
+				return nil, apperr.NotFound("API key %d not found", id)
+			}
+			return nil, apperr.Conflict("API key %d is already revoked", id)
+		}
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return k, nil
+}
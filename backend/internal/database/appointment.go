@@ -0,0 +1,336 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Appointment statuses
+const (
+	AppointmentScheduled = "scheduled"
+	AppointmentCheckedIn = "checked_in"
+	AppointmentCompleted = "completed"
+	AppointmentCancelled = "cancelled"
+	AppointmentNoShow    = "no_show"
+)
+
+// Appointment confirmation states; reminders stop once the patient confirms or declines
+const (
+	AppointmentUnconfirmed = "unconfirmed"
+	AppointmentConfirmed   = "confirmed"
+	AppointmentDeclined    = "declined"
+)
+
+// DefaultAppointmentType is the type of a booking that names none
+const DefaultAppointmentType = "consultation"
+
+// BookingChannels are where a booking can come from, for marketing attribution
+var BookingChannels = []string{"phone", "walk_in", "online", "line", "referral", "other"}
+
+// appointmentTransitions lists the statuses each status may move to
+var appointmentTransitions = map[string][]string{
+	AppointmentScheduled: {AppointmentCheckedIn, AppointmentCancelled, AppointmentNoShow},
+	AppointmentCheckedIn: {AppointmentCompleted, AppointmentCancelled},
+}
+
+// Appointment is a patient's booked time with a doctor
+type Appointment struct {
+	ID                  int        `json:"id" db:"id"`
+	PatientHN           string     `json:"patientHn" db:"patient_hn"`
+	DoctorID            *int       `json:"doctorId,omitempty" db:"doctor_id"`
+	DoctorName          string     `json:"doctorName" db:"doctor_name"` // copied from the doctor record when doctorId is set
+	StartsAt            time.Time  `json:"startsAt" db:"starts_at"`
+	EndsAt              time.Time  `json:"endsAt" db:"ends_at"`
+	Type                string     `json:"type" db:"type"`                         // e.g. consultation, follow_up, procedure, vaccination
+	ServiceID           *int       `json:"serviceId,omitempty" db:"service_id"`    // catalog service booked, if any; its eligibility rules apply
+	Channel             *string    `json:"channel,omitempty" db:"booking_channel"` // where the booking came from, e.g. phone, line, referral
+	Sandbox             bool       `json:"sandbox,omitempty" db:"sandbox"`         // booked with a sandbox API key; its reminders are simulated
+	Status              string     `json:"status" db:"status"`
+	Reason              *string    `json:"reason,omitempty" db:"reason"` // เหตุผลที่นัด
+	Notes               *string    `json:"notes,omitempty" db:"notes"`
+	InterpreterRequired bool       `json:"interpreterRequired" db:"interpreter_required"`
+	RescheduleCount     int        `json:"rescheduleCount" db:"reschedule_count"`
+	CancelReasonCode    *string    `json:"cancelReasonCode,omitempty" db:"cancel_reason_code"` // from the cancellation taxonomy
+	CancelReason        *string    `json:"cancelReason,omitempty" db:"cancel_reason"`
+	CancelledAt         *time.Time `json:"cancelledAt,omitempty" db:"cancelled_at"`
+	Confirmation        string     `json:"confirmation" db:"confirmation"`          // unconfirmed, confirmed or declined
+	ConfirmedAt         *time.Time `json:"confirmedAt,omitempty" db:"confirmed_at"` // when the patient confirmed or declined
+	RemindersSent       int        `json:"remindersSent" db:"reminders_sent"`       // reminder policy steps fired for the current time
+	CreatedAt           time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updatedAt" db:"updated_at"`
+
+	Display *AppointmentStyle `json:"display,omitempty" db:"-"` // how calendars draw it, filled in by the API
+}
+
+// CanMoveTo reports whether the appointment may change to status
+func (a *Appointment) CanMoveTo(status string) bool {
+	for _, s := range appointmentTransitions[a.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the appointment still occupies its time slot
+func (a *Appointment) Active() bool {
+	return a.Status == AppointmentScheduled || a.Status == AppointmentCheckedIn
+}
+
+// AppointmentFilter narrows an appointment listing; zero values match everything
+type AppointmentFilter struct {
+	From          time.Time
+	To            time.Time
+	CancelledFrom time.Time // cancelled from this time on
+	CancelledTo   time.Time // cancelled before this time
+	PatientHN     string
+	DoctorID      int
+	DoctorName    string
+	Type          string
+	Status        string
+	Channel       string
+}
+
+// AppointmentRepository handles appointment database operations
+type AppointmentRepository struct {
+	db *DB
+}
+
+// NewAppointmentRepository creates a new appointment repository
+func NewAppointmentRepository(db *DB) *AppointmentRepository {
+	return &AppointmentRepository{db: db}
+}
+
+const appointmentColumns = `id, patient_hn, doctor_id, doctor_name, starts_at, ends_at, type, service_id, booking_channel, sandbox, status, reason, notes,
+	interpreter_required, reschedule_count, cancel_reason_code, cancel_reason, cancelled_at, confirmation, confirmed_at, reminders_sent,
+	created_at, updated_at`
+
+func scanAppointment(row interface{ Scan(...interface{}) error }) (*Appointment, error) {
+	var a Appointment
+	err := row.Scan(&a.ID, &a.PatientHN, &a.DoctorID, &a.DoctorName, &a.StartsAt, &a.EndsAt, &a.Type, &a.ServiceID, &a.Channel, &a.Sandbox, &a.Status, &a.Reason, &a.Notes,
+		&a.InterpreterRequired, &a.RescheduleCount, &a.CancelReasonCode, &a.CancelReason, &a.CancelledAt, &a.Confirmation, &a.ConfirmedAt, &a.RemindersSent,
+		&a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// checkDoctorFree locks the doctor's schedule for the transaction and fails
+// when another active appointment overlaps [startsAt, endsAt)
+func checkDoctorFree(tx *sql.Tx, a *Appointment) error {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('appointments:' || lower($1)))", a.DoctorName); err != nil {
+		return fmt.Errorf("failed to lock doctor schedule: %w", err)
+	}
+
+	var clash bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM appointments
+			WHERE lower(doctor_name) = lower($1) AND id <> $2 AND status IN ('scheduled', 'checked_in')
+			AND starts_at < $4 AND ends_at > $3)
+	`, a.DoctorName, a.ID, a.StartsAt, a.EndsAt).Scan(&clash)
+	if err != nil {
+		return fmt.Errorf("failed to check doctor availability: %w", err)
+	}
+	if clash {
+		return apperr.Conflict("%s already has an appointment at that time", a.DoctorName)
+	}
+	return nil
+}
+
+// Create books an appointment if the doctor is free at that time
+func (r *AppointmentRepository) Create(a *Appointment) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin appointment booking: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkDoctorFree(tx, a); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO appointments (patient_hn, doctor_id, doctor_name, starts_at, ends_at, type, service_id, booking_channel, sandbox, status,
+			reason, notes, interpreter_required, confirmation)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at
+	`, a.PatientHN, a.DoctorID, a.DoctorName, a.StartsAt, a.EndsAt, a.Type, a.ServiceID, a.Channel, a.Sandbox, a.Status,
+		a.Reason, a.Notes, a.InterpreterRequired, a.Confirmation).Scan(
+		&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) && a.ServiceID != nil {
+			return apperr.Validation("the appointment's doctor or service %d does not exist", *a.ServiceID)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("doctor %d does not exist", *a.DoctorID)
+		}
+		return fmt.Errorf("failed to create appointment: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetByID retrieves an appointment by ID
+func (r *AppointmentRepository) GetByID(id int) (*Appointment, error) {
+	a, err := scanAppointment(r.db.conn.QueryRow("SELECT "+appointmentColumns+" FROM appointments WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("appointment %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get appointment: %w", err)
+	}
+	return a, nil
+}
+
+// List retrieves appointments matching the filter, earliest first
+func (r *AppointmentRepository) List(f AppointmentFilter) ([]Appointment, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if !f.From.IsZero() {
+		add("starts_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("starts_at < $%d", f.To)
+	}
+	if !f.CancelledFrom.IsZero() {
+		add("cancelled_at >= $%d", f.CancelledFrom)
+	}
+	if !f.CancelledTo.IsZero() {
+		add("cancelled_at < $%d", f.CancelledTo)
+	}
+	if f.PatientHN != "" {
+		add("patient_hn = $%d", f.PatientHN)
+	}
+	if f.DoctorID != 0 {
+		add("doctor_id = $%d", f.DoctorID)
+	}
+	if f.DoctorName != "" {
+		add("lower(doctor_name) = lower($%d)", f.DoctorName)
+	}
+	if f.Type != "" {
+		add("type = $%d", f.Type)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.Channel != "" {
+		add("booking_channel = $%d", f.Channel)
+	}
+
+	query := "SELECT " + appointmentColumns + " FROM appointments WHERE " + strings.Join(conditions, " AND ") + " ORDER BY starts_at"
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appointments: %w", err)
+	}
+	defer rows.Close()
+
+	appointments := []Appointment{}
+	for rows.Next() {
+		a, err := scanAppointment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan appointment: %w", err)
+		}
+		appointments = append(appointments, *a)
+	}
+
+	return appointments, rows.Err()
+}
+
+// Reschedule moves a scheduled appointment to a.StartsAt-a.EndsAt with a.DoctorID/a.DoctorName
+// if the doctor is free then, and counts the change. The new time needs confirming
+// again, so confirmation and reminders start over.
+func (r *AppointmentRepository) Reschedule(a *Appointment) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin appointment reschedule: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkDoctorFree(tx, a); err != nil {
+		return err
+	}
+
+	updated, err := scanAppointment(tx.QueryRow(`
+		UPDATE appointments SET doctor_id = $2, doctor_name = $3, starts_at = $4, ends_at = $5,
+			reschedule_count = reschedule_count + 1, confirmation = 'unconfirmed', confirmed_at = NULL, reminders_sent = 0,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'scheduled'
+		RETURNING `+appointmentColumns, a.ID, a.DoctorID, a.DoctorName, a.StartsAt, a.EndsAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("appointment %d is not scheduled", a.ID)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("doctor %d does not exist", *a.DoctorID)
+		}
+		return fmt.Errorf("failed to reschedule appointment: %w", err)
+	}
+	*a = *updated
+
+	return tx.Commit()
+}
+
+// UpdateStatus moves an appointment from one status to another; cancel is
+// kept for cancellations
+func (r *AppointmentRepository) UpdateStatus(id int, from, to string, cancel *Cancellation) (*Appointment, error) {
+	var code, note *string
+	if cancel != nil {
+		code, note = &cancel.ReasonCode, cancel.Note
+	}
+	a, err := scanAppointment(r.db.conn.QueryRow(`
+		UPDATE appointments SET status = $3, updated_at = CURRENT_TIMESTAMP,
+			cancel_reason_code = CASE WHEN $3 = 'cancelled' THEN $4 ELSE cancel_reason_code END,
+			cancel_reason = CASE WHEN $3 = 'cancelled' THEN $5 ELSE cancel_reason END,
+			cancelled_at = CASE WHEN $3 = 'cancelled' THEN CURRENT_TIMESTAMP ELSE cancelled_at END
+		WHERE id = $1 AND status = $2
+		RETURNING `+appointmentColumns, id, from, to, code, note))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("appointment %d is no longer %s", id, from)
+		}
+		return nil, fmt.Errorf("failed to update appointment status: %w", err)
+	}
+	return a, nil
+}
+
+// SetConfirmation records whether the patient confirmed or declined a scheduled
+// appointment; unconfirmed clears the answer
+func (r *AppointmentRepository) SetConfirmation(id int, confirmation string) (*Appointment, error) {
+	a, err := scanAppointment(r.db.conn.QueryRow(`
+		UPDATE appointments SET confirmation = $2, updated_at = CURRENT_TIMESTAMP,
+			confirmed_at = CASE WHEN $2 = 'unconfirmed' THEN NULL ELSE CURRENT_TIMESTAMP END
+		WHERE id = $1 AND status = 'scheduled'
+		RETURNING `+appointmentColumns, id, confirmation))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("appointment %d is not scheduled", id)
+		}
+		return nil, fmt.Errorf("failed to update appointment confirmation: %w", err)
+	}
+	return a, nil
+}
+
+// AdvanceReminders records that reminder policy steps up to to have fired for a
+// scheduled, unconfirmed appointment that had from steps fired
+func (r *AppointmentRepository) AdvanceReminders(id, from, to int) error {
+	result, err := r.db.conn.Exec(`
+		UPDATE appointments SET reminders_sent = $3
+		WHERE id = $1 AND reminders_sent = $2 AND status = 'scheduled' AND confirmation = 'unconfirmed'
+	`, id, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to advance appointment reminders: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return apperr.Conflict("appointment %d no longer needs reminder %d", id, to)
+	}
+	return nil
+}
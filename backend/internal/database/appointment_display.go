@@ -0,0 +1,148 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Appointment display scopes: a style applies to an appointment type or to a status
+const (
+	DisplayScopeType   = "type"
+	DisplayScopeStatus = "status"
+)
+
+// DisplayStyle is how calendars draw one appointment type or status
+type DisplayStyle struct {
+	Color string `json:"color" db:"color"` // #RRGGBB
+	Icon  string `json:"icon" db:"icon"`   // name from the shared icon set, e.g. "stethoscope"
+	Label string `json:"label" db:"label"` // short label for tight calendar cells
+}
+
+// AppointmentStyle is the resolved display of one appointment: calendars fill
+// the slot with the type's color and badge it with the status
+type AppointmentStyle struct {
+	Type   DisplayStyle `json:"type"`
+	Status DisplayStyle `json:"status"`
+}
+
+// AppointmentDisplay is the style configured for one appointment type or status
+type AppointmentDisplay struct {
+	Scope string `json:"scope" db:"scope"`
+	Key   string `json:"key" db:"key"` // the type or status, e.g. "follow_up", "no_show"
+	DisplayStyle
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" db:"updated_at"` // nil for built-in defaults
+}
+
+// DefaultAppointmentDisplays are used for any type or status the clinic has not restyled
+var DefaultAppointmentDisplays = []AppointmentDisplay{
+	{Scope: DisplayScopeType, Key: "consultation", DisplayStyle: DisplayStyle{Color: "#2563EB", Icon: "stethoscope", Label: "ตรวจ"}},
+	{Scope: DisplayScopeType, Key: "follow_up", DisplayStyle: DisplayStyle{Color: "#059669", Icon: "repeat", Label: "ติดตาม"}},
+	{Scope: DisplayScopeType, Key: "procedure", DisplayStyle: DisplayStyle{Color: "#D97706", Icon: "scissors", Label: "หัตถการ"}},
+	{Scope: DisplayScopeType, Key: "vaccination", DisplayStyle: DisplayStyle{Color: "#7C3AED", Icon: "syringe", Label: "วัคซีน"}},
+	{Scope: DisplayScopeStatus, Key: AppointmentScheduled, DisplayStyle: DisplayStyle{Color: "#64748B", Icon: "calendar", Label: "นัดแล้ว"}},
+	{Scope: DisplayScopeStatus, Key: AppointmentCheckedIn, DisplayStyle: DisplayStyle{Color: "#0EA5E9", Icon: "log-in", Label: "มาถึงแล้ว"}},
+	{Scope: DisplayScopeStatus, Key: AppointmentCompleted, DisplayStyle: DisplayStyle{Color: "#16A34A", Icon: "check", Label: "เสร็จสิ้น"}},
+	{Scope: DisplayScopeStatus, Key: AppointmentCancelled, DisplayStyle: DisplayStyle{Color: "#DC2626", Icon: "x", Label: "ยกเลิก"}},
+	{Scope: DisplayScopeStatus, Key: AppointmentNoShow, DisplayStyle: DisplayStyle{Color: "#9CA3AF", Icon: "user-x", Label: "ไม่มา"}},
+}
+
+// fallbackDisplayColor and fallbackDisplayIcon draw types nobody has styled yet
+const (
+	fallbackDisplayColor = "#6B7280"
+	fallbackDisplayIcon  = "calendar"
+)
+
+// AppointmentDisplays is the effective style of every styled type and status
+type AppointmentDisplays map[string]AppointmentDisplay
+
+// NewAppointmentDisplays overlays the clinic's configured styles on the defaults
+func NewAppointmentDisplays(configured []AppointmentDisplay) AppointmentDisplays {
+	displays := AppointmentDisplays{}
+	for _, d := range DefaultAppointmentDisplays {
+		displays[d.Scope+":"+d.Key] = d
+	}
+	for _, d := range configured {
+		displays[d.Scope+":"+d.Key] = d
+	}
+	return displays
+}
+
+// Style returns the display of a type or status; unstyled keys get a grey
+// calendar labelled with the key itself
+func (d AppointmentDisplays) Style(scope, key string) DisplayStyle {
+	if display, ok := d[scope+":"+key]; ok {
+		return display.DisplayStyle
+	}
+	return DisplayStyle{Color: fallbackDisplayColor, Icon: fallbackDisplayIcon, Label: key}
+}
+
+// StyleOf resolves an appointment's type and status styles
+func (d AppointmentDisplays) StyleOf(a *Appointment) *AppointmentStyle {
+	return &AppointmentStyle{Type: d.Style(DisplayScopeType, a.Type), Status: d.Style(DisplayScopeStatus, a.Status)}
+}
+
+// AppointmentDisplayRepository handles the clinic's appointment display settings
+type AppointmentDisplayRepository struct {
+	db *DB
+}
+
+// NewAppointmentDisplayRepository creates a new appointment display repository
+func NewAppointmentDisplayRepository(db *DB) *AppointmentDisplayRepository {
+	return &AppointmentDisplayRepository{db: db}
+}
+
+// GetAll retrieves the configured styles; defaults are not included
+func (r *AppointmentDisplayRepository) GetAll() ([]AppointmentDisplay, error) {
+	rows, err := r.db.conn.Query("SELECT scope, key, color, icon, label, updated_at FROM appointment_display ORDER BY scope, key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appointment display: %w", err)
+	}
+	defer rows.Close()
+
+	displays := []AppointmentDisplay{}
+	for rows.Next() {
+		var d AppointmentDisplay
+		if err := rows.Scan(&d.Scope, &d.Key, &d.Color, &d.Icon, &d.Label, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan appointment display: %w", err)
+		}
+		displays = append(displays, d)
+	}
+
+	return displays, rows.Err()
+}
+
+// Set configures the style of a type or status, replacing any earlier one
+func (r *AppointmentDisplayRepository) Set(d *AppointmentDisplay) error {
+	query := `
+		INSERT INTO appointment_display (scope, key, color, icon, label)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET color = EXCLUDED.color, icon = EXCLUDED.icon, label = EXCLUDED.label, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	if err := r.db.conn.QueryRow(query, d.Scope, d.Key, d.Color, d.Icon, d.Label).Scan(&d.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to set appointment display: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a configured style, returning the type or status to its default
+func (r *AppointmentDisplayRepository) Delete(scope, key string) error {
+	result, err := r.db.conn.Exec("DELETE FROM appointment_display WHERE scope = $1 AND key = $2", scope, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete appointment display: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("no display configured for %s %s", scope, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AppointmentOverride records a booking made with force despite the patient
+// already having an overlapping or same-type appointment that day
+type AppointmentOverride struct {
+	ID            int       `json:"id" db:"id"`
+	AppointmentID int       `json:"appointmentId" db:"appointment_id"`
+	PatientHN     string    `json:"patientHn" db:"patient_hn"`
+	ConflictsWith []int     `json:"conflictsWith" db:"conflicts_with"` // stored comma-separated
+	Detail        string    `json:"detail" db:"detail"`                // what the guard objected to
+	Reason        *string   `json:"reason,omitempty" db:"reason"`      // why staff booked anyway
+	OverriddenBy  string    `json:"overriddenBy" db:"overridden_by"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+}
+
+// AppointmentOverrideFilter narrows an override listing; zero values match everything
+type AppointmentOverrideFilter struct {
+	From      time.Time
+	To        time.Time
+	PatientHN string
+}
+
+// AppointmentOverrideRepository handles appointment override database operations
+type AppointmentOverrideRepository struct {
+	db *DB
+}
+
+// NewAppointmentOverrideRepository creates a new appointment override repository
+func NewAppointmentOverrideRepository(db *DB) *AppointmentOverrideRepository {
+	return &AppointmentOverrideRepository{db: db}
+}
+
+// Create records an override
+func (r *AppointmentOverrideRepository) Create(o *AppointmentOverride) error {
+	ids := make([]string, len(o.ConflictsWith))
+	for i, id := range o.ConflictsWith {
+		ids[i] = strconv.Itoa(id)
+	}
+
+	err := r.db.conn.QueryRow(`
+		INSERT INTO appointment_overrides (appointment_id, patient_hn, conflicts_with, detail, reason, overridden_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, o.AppointmentID, o.PatientHN, strings.Join(ids, ","), o.Detail, o.Reason, o.OverriddenBy).Scan(&o.ID, &o.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create appointment override: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves overrides matching the filter, newest first
+func (r *AppointmentOverrideRepository) List(f AppointmentOverrideFilter) ([]AppointmentOverride, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT id, appointment_id, patient_hn, conflicts_with, detail, reason, overridden_by, created_at
+		FROM appointment_overrides
+		WHERE ($1::timestamp IS NULL OR created_at >= $1) AND ($2::timestamp IS NULL OR created_at < $2)
+			AND ($3 = '' OR patient_hn = $3)
+		ORDER BY created_at DESC, id DESC
+	`, nullTime(f.From), nullTime(f.To), f.PatientHN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appointment overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []AppointmentOverride{}
+	for rows.Next() {
+		var o AppointmentOverride
+		var ids string
+		if err := rows.Scan(&o.ID, &o.AppointmentID, &o.PatientHN, &ids, &o.Detail, &o.Reason, &o.OverriddenBy, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan appointment override: %w", err)
+		}
+		o.ConflictsWith = []int{}
+		for _, id := range strings.Split(ids, ",") {
+			if n, err := strconv.Atoi(id); err == nil {
+				o.ConflictsWith = append(o.ConflictsWith, n)
+			}
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, rows.Err()
+}
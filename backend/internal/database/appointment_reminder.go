@@ -0,0 +1,303 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Appointment reminder channels
+const (
+	ReminderLINE = "line"
+	ReminderSMS  = "sms"
+	ReminderCall = "call" // a task for staff to phone the patient
+)
+
+// AppointmentReminder is one reminder policy step fired for an appointment.
+// LINE and SMS reminders wait as pending until a messaging gateway sends
+// them; call reminders are handed to staff as a task. A reminder the gateway
+// fails to deliver goes back to pending for a retry until the retry policy
+// runs out, then stays failed: the dead-letter queue staff can requeue from.
+type AppointmentReminder struct {
+	ID            int        `json:"id" db:"id"`
+	AppointmentID int        `json:"appointmentId" db:"appointment_id"`
+	PatientHN     string     `json:"patientHn" db:"patient_hn"`
+	Step          int        `json:"step" db:"step"` // 1-based position in the reminder policy; change notices count down from -1
+	Channel       string     `json:"channel" db:"channel"`
+	Phone         *string    `json:"phone,omitempty" db:"phone"`
+	Message       string     `json:"message" db:"message"`
+	TaskID        *int       `json:"taskId,omitempty" db:"task_id"`
+	Status        string     `json:"status" db:"status"` // pending, sent or failed
+	SentAt        *time.Time `json:"sentAt,omitempty" db:"sent_at"`
+	Attempts      int        `json:"attempts" db:"attempts"`              // failed delivery attempts
+	LastError     *string    `json:"lastError,omitempty" db:"last_error"` // why the last attempt failed
+	RetryAt       *time.Time `json:"retryAt,omitempty" db:"retry_at"`     // a failed reminder is not handed out again before this
+	Sandbox       bool       `json:"sandbox,omitempty" db:"sandbox"`      // for a sandbox appointment: marked sent without being sent
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// NoticeStep returns the step for a new notice of a change to an appointment
+// that has the existing reminders. Notices count down from -1 so they never
+// take the place of a reminder policy step.
+func NoticeStep(existing []AppointmentReminder) int {
+	step := 0
+	for _, m := range existing {
+		if m.Step < step {
+			step = m.Step
+		}
+	}
+	return step - 1
+}
+
+// NotificationFailure is one failed delivery attempt of a reminder, as the
+// messaging gateway reported it
+type NotificationFailure struct {
+	ID         int       `json:"id" db:"id"`
+	ReminderID int       `json:"reminderId" db:"reminder_id"`
+	Attempt    int       `json:"attempt" db:"attempt"`                // 1 for the first failure
+	Provider   *string   `json:"provider,omitempty" db:"provider"`    // e.g. the SMS provider's name
+	ErrorCode  *string   `json:"errorCode,omitempty" db:"error_code"` // the provider's own code
+	Error      string    `json:"error" db:"error_message"`
+	FailedAt   time.Time `json:"failedAt" db:"failed_at"`
+}
+
+// NotificationStats counts gateway deliveries over a period
+type NotificationStats struct {
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"` // failed attempts, each retry counted
+}
+
+// AppointmentReminderFilter narrows a reminder listing; zero values match everything.
+// Phone matches on digits only, e.g. "0812345678" finds "081-234-5678".
+type AppointmentReminderFilter struct {
+	AppointmentID int
+	Channel       string
+	Status        string
+	Phone         string
+	Due           bool // leaves out reminders waiting to be retried
+}
+
+// AppointmentReminderRepository handles appointment reminder database operations
+type AppointmentReminderRepository struct {
+	db *DB
+}
+
+// NewAppointmentReminderRepository creates a new appointment reminder repository
+func NewAppointmentReminderRepository(db *DB) *AppointmentReminderRepository {
+	return &AppointmentReminderRepository{db: db}
+}
+
+const appointmentReminderColumns = `id, appointment_id, patient_hn, step, channel, phone, message, task_id, status, sent_at,
+	attempts, last_error, retry_at, sandbox, created_at`
+
+func scanAppointmentReminder(row interface{ Scan(...interface{}) error }) (*AppointmentReminder, error) {
+	var m AppointmentReminder
+	err := row.Scan(&m.ID, &m.AppointmentID, &m.PatientHN, &m.Step, &m.Channel, &m.Phone, &m.Message, &m.TaskID,
+		&m.Status, &m.SentAt, &m.Attempts, &m.LastError, &m.RetryAt, &m.Sandbox, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Create records a fired reminder step; each step fires once per appointment
+func (r *AppointmentReminderRepository) Create(m *AppointmentReminder) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO appointment_reminders (appointment_id, patient_hn, step, channel, phone, message, task_id, status, sent_at, last_error,
+			sandbox)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`, m.AppointmentID, m.PatientHN, m.Step, m.Channel, m.Phone, m.Message, m.TaskID, m.Status, m.SentAt, m.LastError,
+		m.Sandbox).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("reminder %d of appointment %d was already fired", m.Step, m.AppointmentID)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("appointment %d does not exist", m.AppointmentID)
+		}
+		return fmt.Errorf("failed to create appointment reminder: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves reminders matching the filter, oldest first
+func (r *AppointmentReminderRepository) List(f AppointmentReminderFilter) ([]AppointmentReminder, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+appointmentReminderColumns+` FROM appointment_reminders
+		WHERE ($1 = 0 OR appointment_id = $1) AND ($2 = '' OR channel = $2) AND ($3 = '' OR status = $3)
+			AND ($4 = '' OR regexp_replace(phone, '[^0-9]', '', 'g') = $4)
+			AND (NOT $5 OR retry_at IS NULL OR retry_at <= CURRENT_TIMESTAMP)
+		ORDER BY created_at, id
+	`, f.AppointmentID, f.Channel, f.Status, phoneDigits(f.Phone), f.Due)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appointment reminders: %w", err)
+	}
+	defer rows.Close()
+
+	reminders := []AppointmentReminder{}
+	for rows.Next() {
+		m, err := scanAppointmentReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan appointment reminder: %w", err)
+		}
+		reminders = append(reminders, *m)
+	}
+
+	return reminders, rows.Err()
+}
+
+// UpdateStatus records whether a pending reminder was sent
+func (r *AppointmentReminderRepository) UpdateStatus(id int, from, to string) (*AppointmentReminder, error) {
+	m, err := scanAppointmentReminder(r.db.conn.QueryRow(`
+		UPDATE appointment_reminders SET status = $3,
+			sent_at = CASE WHEN $3 = 'sent' THEN CURRENT_TIMESTAMP ELSE sent_at END,
+			last_error = CASE WHEN $3 = 'sent' THEN NULL ELSE last_error END,
+			retry_at = CASE WHEN $3 = 'sent' THEN NULL ELSE retry_at END
+		WHERE id = $1 AND status = $2
+		RETURNING `+appointmentReminderColumns, id, from, to))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("appointment reminder %d is no longer %s", id, from)
+		}
+		return nil, fmt.Errorf("failed to update appointment reminder: %w", err)
+	}
+	return m, nil
+}
+
+// GetByID retrieves a reminder by ID
+func (r *AppointmentReminderRepository) GetByID(id int) (*AppointmentReminder, error) {
+	m, err := scanAppointmentReminder(r.db.conn.QueryRow("SELECT "+appointmentReminderColumns+" FROM appointment_reminders WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("appointment reminder %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get appointment reminder: %w", err)
+	}
+	return m, nil
+}
+
+// RecordFailure logs a failed delivery attempt of a pending reminder. retryAt
+// gives when the reminder may be tried again after so many failed attempts,
+// or nil when the retry policy has run out and the reminder is dead-lettered
+// as failed.
+func (r *AppointmentReminderRepository) RecordFailure(id int, f *NotificationFailure, retryAt func(attempts int) *time.Time) (*AppointmentReminder, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin reminder failure: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	var attempts int
+	err = tx.QueryRow("SELECT status, attempts FROM appointment_reminders WHERE id = $1 FOR UPDATE", id).Scan(&status, &attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("appointment reminder %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get appointment reminder: %w", err)
+	}
+	if status != NotificationPending {
+		return nil, apperr.Conflict("appointment reminder %d is no longer pending", id)
+	}
+
+	attempts++
+	next := retryAt(attempts)
+	status = NotificationPending
+	if next == nil {
+		status = NotificationFailed
+	}
+	m, err := scanAppointmentReminder(tx.QueryRow(`
+		UPDATE appointment_reminders SET status = $2, attempts = $3, last_error = $4, retry_at = $5
+		WHERE id = $1
+		RETURNING `+appointmentReminderColumns, id, status, attempts, f.Error, next))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update appointment reminder: %w", err)
+	}
+
+	f.ReminderID, f.Attempt = id, attempts
+	err = tx.QueryRow(`
+		INSERT INTO notification_failures (reminder_id, attempt, provider, error_code, error_message)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, failed_at
+	`, f.ReminderID, f.Attempt, f.Provider, f.ErrorCode, f.Error).Scan(&f.ID, &f.FailedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record notification failure: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reminder failure: %w", err)
+	}
+	return m, nil
+}
+
+// GetFailures retrieves a reminder's failed delivery attempts, most recent first
+func (r *AppointmentReminderRepository) GetFailures(reminderID int) ([]NotificationFailure, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT id, reminder_id, attempt, provider, error_code, error_message, failed_at FROM notification_failures
+		WHERE reminder_id = $1
+		ORDER BY failed_at DESC, id DESC
+	`, reminderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification failures: %w", err)
+	}
+	defer rows.Close()
+
+	failures := []NotificationFailure{}
+	for rows.Next() {
+		var f NotificationFailure
+		if err := rows.Scan(&f.ID, &f.ReminderID, &f.Attempt, &f.Provider, &f.ErrorCode, &f.Error, &f.FailedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification failure: %w", err)
+		}
+		failures = append(failures, f)
+	}
+
+	return failures, rows.Err()
+}
+
+// Requeue puts a dead-lettered reminder back in the pending queue with a
+// fresh set of attempts, sending it to phone
+func (r *AppointmentReminderRepository) Requeue(id int, phone *string) (*AppointmentReminder, error) {
+	m, err := scanAppointmentReminder(r.db.conn.QueryRow(`
+		UPDATE appointment_reminders SET status = 'pending', attempts = 0, retry_at = NULL, phone = $2
+		WHERE id = $1 AND status = 'failed'
+		RETURNING `+appointmentReminderColumns, id, phone))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, err := r.GetByID(id); err != nil {
+				return nil, err
+			}
+			return nil, apperr.Conflict("appointment reminder %d is not failed", id)
+		}
+		return nil, fmt.Errorf("failed to requeue appointment reminder: %w", err)
+	}
+	return m, nil
+}
+
+// DeliveryStats counts the reminders the gateway sent, and its failed
+// attempts, from since on; simulated sandbox reminders are left out
+func (r *AppointmentReminderRepository) DeliveryStats(since time.Time) (NotificationStats, error) {
+	var s NotificationStats
+	err := r.db.conn.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM appointment_reminders WHERE sent_at >= $1 AND channel <> 'call' AND NOT sandbox),
+			(SELECT COUNT(*) FROM notification_failures WHERE failed_at >= $1)
+	`, since).Scan(&s.Sent, &s.Failed)
+	if err != nil {
+		return s, fmt.Errorf("failed to count notification deliveries: %w", err)
+	}
+	return s, nil
+}
+
+// phoneDigits strips formatting from a phone number
+func phoneDigits(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+}
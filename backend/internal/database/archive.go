@@ -0,0 +1,238 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// archiveSchema holds the archive copy of each archived table, under the same
+// name and without foreign keys, so old rows can leave the hot tables whole
+const archiveSchema = "archive"
+
+// archivedTable is a table with an archive copy. where picks the rows that go
+// with a batch of visits ($1, comma-separated ids) or, for audit logs, the rows
+// older than the cutoff ($1) up to a limit ($2).
+type archivedTable struct {
+	name  string
+	where string
+}
+
+// visitArchiveTables move together with their visit, children before parents
+// so no foreign key in the hot tables is left pointing at a moved row
+var visitArchiveTables = []archivedTable{
+	{"line_messages", "queue_entry_id IN (SELECT id FROM queue_entries WHERE visit_id = ANY(string_to_array($1, ',')::int[]))"},
+	{"triages", "queue_entry_id IN (SELECT id FROM queue_entries WHERE visit_id = ANY(string_to_array($1, ',')::int[]))"},
+	{"payments", "invoice_id IN (SELECT id FROM invoices WHERE visit_id = ANY(string_to_array($1, ',')::int[]))"},
+	{"insurance_claims", "invoice_id IN (SELECT id FROM invoices WHERE visit_id = ANY(string_to_array($1, ',')::int[]))"},
+	{"summary_link_accesses", "link_id IN (SELECT id FROM visit_summary_links WHERE visit_id = ANY(string_to_array($1, ',')::int[]))"},
+	{"visit_summary_links", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"follow_ups", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"package_sessions", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"nursing_notes", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"encounter_soap_versions", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"invoices", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"prescriptions", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"visit_diagnoses", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"visit_services", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"vital_signs", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"queue_entries", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"teleconsults", "visit_id = ANY(string_to_array($1, ',')::int[])"},
+	{"encounters", "id = ANY(string_to_array($1, ',')::int[])"},
+}
+
+// auditArchiveTables are audit logs, archived on their own by age. A patient
+// merge is only archived once it can no longer be undone.
+var auditArchiveTables = []archivedTable{
+	{"appointment_overrides", "id IN (SELECT id FROM appointment_overrides WHERE created_at < $1 ORDER BY id LIMIT $2)"},
+	{"patient_merges", "id IN (SELECT id FROM patient_merges WHERE merged_at < $1 AND undo_until < CURRENT_TIMESTAMP ORDER BY id LIMIT $2)"},
+}
+
+// archivable visits are closed, settled and no longer discussed: visits with a
+// draft or issued invoice, an open insurance claim, a chat thread, a referral,
+// a pending follow-up, a LINE queue call still to send or a video visit still
+// in progress stay in the hot tables
+const archivableVisits = `
+	SELECT e.id FROM encounters e
+	WHERE e.status = 'closed' AND COALESCE(e.ended_at, e.started_at) < $1
+		AND NOT EXISTS (SELECT 1 FROM invoices i WHERE i.visit_id = e.id AND i.status IN ('draft', 'issued'))
+		AND NOT EXISTS (
+			SELECT 1 FROM insurance_claims c JOIN invoices i ON i.id = c.invoice_id
+			WHERE i.visit_id = e.id AND c.status IN ('submitted', 'approved')
+		)
+		AND NOT EXISTS (SELECT 1 FROM chat_threads t WHERE t.visit_id = e.id)
+		AND NOT EXISTS (SELECT 1 FROM referrals f WHERE f.visit_id = e.id)
+		AND NOT EXISTS (SELECT 1 FROM follow_ups u WHERE u.visit_id = e.id AND u.status = 'pending')
+		AND NOT EXISTS (
+			SELECT 1 FROM line_messages m JOIN queue_entries q ON q.id = m.queue_entry_id
+			WHERE q.visit_id = e.id AND m.status = 'pending'
+		)
+		AND NOT EXISTS (SELECT 1 FROM teleconsults v WHERE v.visit_id = e.id AND v.status = 'in_progress')
+`
+
+// archived names the archive copy of a table
+func archived(table string) string {
+	return archiveSchema + "." + table
+}
+
+// notArchived treats a missing archive table, before CreateArchiveTables has
+// run, as an archive with nothing in it
+func notArchived(err error) error {
+	if undefinedTable(err) {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// ArchivedRows counts the rows of one table moved to the archive
+type ArchivedRows struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// ArchiveRepository moves old visits and audit logs out of the hot tables
+type ArchiveRepository struct {
+	db *DB
+}
+
+// NewArchiveRepository creates a new archive repository; run CreateArchiveTables first
+func NewArchiveRepository(db *DB) *ArchiveRepository {
+	return &ArchiveRepository{db: db}
+}
+
+// CountVisits counts the visits ArchiveVisits would move for cutoff
+func (r *ArchiveRepository) CountVisits(cutoff time.Time) (int, error) {
+	var count int
+	if err := r.db.conn.QueryRow("SELECT COUNT(*) FROM ("+archivableVisits+") v", cutoff).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count archivable visits: %w", err)
+	}
+	return count, nil
+}
+
+// ArchiveVisits moves up to limit visits that ended before cutoff, with their
+// invoices, payments, claims, prescriptions, diagnoses, services, vital signs,
+// queue entries and their LINE messages, triage assessments, follow-ups,
+// package sessions and video visit sessions, to the archive in one
+// transaction. It
+// returns the number of visits moved; zero means none are left to archive.
+// Visits being changed at the same time are skipped and picked up by a later batch.
+func (r *ArchiveRepository) ArchiveVisits(cutoff time.Time, limit int) (int, []ArchivedRows, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin visit archive: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(archivableVisits+" ORDER BY e.id LIMIT $2 FOR UPDATE OF e SKIP LOCKED", cutoff, limit)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to select visits to archive: %w", err)
+	}
+	ids := []string{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, nil, fmt.Errorf("failed to scan visit to archive: %w", err)
+		}
+		ids = append(ids, strconv.Itoa(id))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("failed to select visits to archive: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+
+	moved, err := moveToArchive(tx, visitArchiveTables, strings.Join(ids, ","))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit visit archive: %w", err)
+	}
+
+	return len(ids), moved, nil
+}
+
+// ArchiveAuditLogs moves up to limit rows of each audit log older than cutoff
+// to the archive. Rerun until it moves nothing.
+func (r *ArchiveRepository) ArchiveAuditLogs(cutoff time.Time, limit int) ([]ArchivedRows, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin audit log archive: %w", err)
+	}
+	defer tx.Rollback()
+
+	moved, err := moveToArchive(tx, auditArchiveTables, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit audit log archive: %w", err)
+	}
+
+	return moved, nil
+}
+
+// moveToArchive deletes each table's matching rows and inserts them into its
+// archive copy, naming the columns since the copy may have them in another order
+func moveToArchive(tx *sql.Tx, tables []archivedTable, args ...interface{}) ([]ArchivedRows, error) {
+	moved := []ArchivedRows{}
+	for _, table := range tables {
+		columns, err := tableColumns(tx, "public", table.name)
+		if err != nil {
+			return nil, err
+		}
+		list := strings.Join(columns, ", ")
+
+		result, err := tx.Exec(`
+			WITH moved AS (DELETE FROM `+table.name+` WHERE `+table.where+` RETURNING *)
+			INSERT INTO `+archived(table.name)+` (`+list+`) SELECT `+list+` FROM moved
+		`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to archive %s: %w", table.name, err)
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		moved = append(moved, ArchivedRows{Table: table.name, Rows: count})
+	}
+	return moved, nil
+}
+
+// tableColumns lists a table's column names, quoted, in order
+func tableColumns(q interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}, schema, table string) ([]string, error) {
+	rows, err := q.Query(`
+		SELECT quote_ident(column_name) FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2
+		ORDER BY ordinal_position
+	`, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := []string{}
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list columns of %s: %w", table, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s.%s does not exist", schema, table)
+	}
+	return columns, nil
+}
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// OpeningHours is one span a branch is open on a weekday; a day with a lunch
+// break has two
+type OpeningHours struct {
+	Day    string `json:"day"`    // "mon".."sun"
+	Opens  string `json:"opens"`  // HH:MM, branch local time
+	Closes string `json:"closes"` // HH:MM, exclusive
+}
+
+// Walk-in doctor assignment policies
+const (
+	WalkInManual        = "manual"         // reception picks the doctor, if any
+	WalkInRoundRobin    = "round_robin"    // doctors on shift take walk-ins in turn
+	WalkInShortestQueue = "shortest_queue" // the doctor on shift with the fewest patients waiting
+)
+
+// WalkInAssignments are the ways walk-ins can be assigned to doctors
+var WalkInAssignments = []string{WalkInManual, WalkInRoundRobin, WalkInShortestQueue}
+
+// Branch is a clinic branch's settings, keyed by the ID requests send in X-Branch-ID
+type Branch struct {
+	ID        string         `json:"id" db:"id"`
+	Name      string         `json:"name" db:"name"`
+	Timezone  string         `json:"timezone" db:"timezone"` // IANA name, e.g. "Asia/Bangkok"
+	Hours     []OpeningHours `json:"hours" db:"hours"`       // stored as JSONB; none means no restriction
+	UpdatedAt time.Time      `json:"updatedAt" db:"updated_at"`
+
+	WalkInAssignment string `json:"walkInAssignment,omitempty" db:"walk_in_assignment"` // one of WalkInAssignments; "" for the clinic's default
+}
+
+// Location loads the branch's timezone
+func (b *Branch) Location() (*time.Location, error) {
+	return time.LoadLocation(b.Timezone)
+}
+
+// OpenBetween reports whether the branch is open for the whole of [from, to),
+// which must fall within one opening span. A branch without hours is always open.
+func (b *Branch) OpenBetween(from, to time.Time) bool {
+	if len(b.Hours) == 0 {
+		return true
+	}
+	loc, err := b.Location()
+	if err != nil {
+		return false
+	}
+	return coversSpan(b.Hours, from, to, loc)
+}
+
+// coversSpan reports whether [from, to), read in loc, falls within one of the
+// weekly spans
+func coversSpan(hours []OpeningHours, from, to time.Time, loc *time.Location) bool {
+	from, to = from.In(loc), to.In(loc)
+
+	day := Weekdays[from.Weekday()]
+	start, end := from.Format("15:04"), to.Format("15:04")
+	if to.Format("2006-01-02") != from.Format("2006-01-02") {
+		// Only a span closing at midnight can take something ending then
+		if !to.Equal(time.Date(from.Year(), from.Month(), from.Day()+1, 0, 0, 0, 0, loc)) {
+			return false
+		}
+		end = "24:00"
+	}
+	for _, h := range hours {
+		if h.Day == day && h.Opens <= start && end <= h.Closes {
+			return true
+		}
+	}
+	return false
+}
+
+// BranchRepository handles branch settings database operations
+type BranchRepository struct {
+	db *DB
+}
+
+// NewBranchRepository creates a new branch repository
+func NewBranchRepository(db *DB) *BranchRepository {
+	return &BranchRepository{db: db}
+}
+
+const branchColumns = "id, name, timezone, hours, updated_at, walk_in_assignment"
+
+func scanBranch(row interface{ Scan(...interface{}) error }) (*Branch, error) {
+	var b Branch
+	var hours []byte
+	if err := row.Scan(&b.ID, &b.Name, &b.Timezone, &hours, &b.UpdatedAt, &b.WalkInAssignment); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(hours, &b.Hours); err != nil {
+		return nil, fmt.Errorf("invalid hours for branch %s: %w", b.ID, err)
+	}
+	return &b, nil
+}
+
+// GetAll retrieves every configured branch, by ID
+func (r *BranchRepository) GetAll() ([]Branch, error) {
+	rows, err := r.db.conn.Query("SELECT " + branchColumns + " FROM branches ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query branches: %w", err)
+	}
+	defer rows.Close()
+
+	branches := []Branch{}
+	for rows.Next() {
+		b, err := scanBranch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan branch: %w", err)
+		}
+		branches = append(branches, *b)
+	}
+
+	return branches, rows.Err()
+}
+
+// GetByID retrieves a branch's settings
+func (r *BranchRepository) GetByID(id string) (*Branch, error) {
+	b, err := scanBranch(r.db.conn.QueryRow("SELECT "+branchColumns+" FROM branches WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("branch %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to get branch: %w", err)
+	}
+	return b, nil
+}
+
+// Save creates or replaces a branch's settings
+func (r *BranchRepository) Save(b *Branch) error {
+	hours, err := json.Marshal(b.Hours)
+	if err != nil {
+		return fmt.Errorf("failed to encode branch hours: %w", err)
+	}
+
+	query := `
+		INSERT INTO branches (id, name, timezone, hours, walk_in_assignment)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET name = $2, timezone = $3, hours = $4, walk_in_assignment = $5, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	if err := r.db.conn.QueryRow(query, b.ID, b.Name, b.Timezone, hours, b.WalkInAssignment).Scan(&b.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save branch: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a branch's settings; its requests fall back to the clinic's timezone
+func (r *BranchRepository) Delete(id string) error {
+	result, err := r.db.conn.Exec("DELETE FROM branches WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("branch %s not found", id)
+	}
+
+	return nil
+}
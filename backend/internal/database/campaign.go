@@ -0,0 +1,227 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Campaign states
+const (
+	CampaignStatusDraft  = "draft"
+	CampaignStatusOpen   = "open"
+	CampaignStatusClosed = "closed"
+)
+
+// Pre-registration states
+const (
+	RegistrationStatusRegistered = "registered"
+	RegistrationStatusBooked     = "booked"
+	RegistrationStatusUnmatched  = "unmatched" // no patient record found for the imported row
+)
+
+// Campaign is a surge event (e.g. a flu vaccination campaign) that opens
+// extra group-session slots every day over a date range
+type Campaign struct {
+	ID           int       `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	SessionType  string    `json:"sessionType" db:"session_type"`
+	Location     string    `json:"location" db:"location"`
+	StartDate    string    `json:"startDate" db:"start_date"`   // YYYY-MM-DD
+	EndDate      string    `json:"endDate" db:"end_date"`       // YYYY-MM-DD
+	DailyStart   string    `json:"dailyStart" db:"daily_start"` // HH:MM
+	DailyEnd     string    `json:"dailyEnd" db:"daily_end"`     // HH:MM
+	SlotMinutes  int       `json:"slotMinutes" db:"slot_minutes"`
+	SlotCapacity int       `json:"slotCapacity" db:"slot_capacity"`
+	SkipWeekends bool      `json:"skipWeekends" db:"skip_weekends"`
+	Status       string    `json:"status" db:"status"` // draft/open/closed
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+// CampaignRegistration is one row of an imported pre-registration list
+type CampaignRegistration struct {
+	ID            int       `json:"id" db:"id"`
+	CampaignID    int       `json:"campaignId" db:"campaign_id"`
+	PatientHN     *string   `json:"patientHn,omitempty" db:"patient_hn"`
+	FullName      string    `json:"fullName" db:"full_name"`
+	Phone         *string   `json:"phone,omitempty" db:"phone"`
+	PreferredDate *string   `json:"preferredDate,omitempty" db:"preferred_date"` // YYYY-MM-DD
+	Status        string    `json:"status" db:"status"`                          // registered/booked/unmatched
+	SessionID     *int      `json:"sessionId,omitempty" db:"session_id"`
+	BookingID     *int      `json:"bookingId,omitempty" db:"booking_id"`
+	ImportedAt    time.Time `json:"importedAt" db:"imported_at"`
+}
+
+// CampaignRepository handles campaign database operations
+type CampaignRepository struct {
+	db *DB
+}
+
+// NewCampaignRepository creates a new campaign repository
+func NewCampaignRepository(db *DB) *CampaignRepository {
+	return &CampaignRepository{db: db}
+}
+
+const campaignColumns = `id, name, session_type, location, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'),
+	daily_start, daily_end, slot_minutes, slot_capacity, skip_weekends, status, created_at`
+
+func scanCampaign(row interface{ Scan(...interface{}) error }) (*Campaign, error) {
+	var c Campaign
+	err := row.Scan(&c.ID, &c.Name, &c.SessionType, &c.Location, &c.StartDate, &c.EndDate,
+		&c.DailyStart, &c.DailyEnd, &c.SlotMinutes, &c.SlotCapacity, &c.SkipWeekends, &c.Status, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateCampaign stores a new campaign
+func (r *CampaignRepository) CreateCampaign(c *Campaign) error {
+	query := `
+		INSERT INTO campaigns (name, session_type, location, start_date, end_date, daily_start, daily_end,
+			slot_minutes, slot_capacity, skip_weekends, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, c.Name, c.SessionType, c.Location, c.StartDate, c.EndDate, c.DailyStart,
+		c.DailyEnd, c.SlotMinutes, c.SlotCapacity, c.SkipWeekends, c.Status).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	return nil
+}
+
+// GetCampaign retrieves a campaign by ID
+func (r *CampaignRepository) GetCampaign(id int) (*Campaign, error) {
+	c, err := scanCampaign(r.db.conn.QueryRow("SELECT "+campaignColumns+" FROM campaigns WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("campaign %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get campaign: %w", err)
+	}
+
+	return c, nil
+}
+
+// GetCampaigns retrieves all campaigns, most recent start first
+func (r *CampaignRepository) GetCampaigns() ([]Campaign, error) {
+	rows, err := r.db.conn.Query("SELECT " + campaignColumns + " FROM campaigns ORDER BY start_date DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	var campaigns []Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan campaign: %w", err)
+		}
+		campaigns = append(campaigns, *c)
+	}
+
+	return campaigns, nil
+}
+
+// UpdateCampaignStatus changes a campaign's status
+func (r *CampaignRepository) UpdateCampaignStatus(id int, status string) error {
+	result, err := r.db.conn.Exec("UPDATE campaigns SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update campaign: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("campaign %d not found", id)
+	}
+
+	return nil
+}
+
+// AddRegistrations stores imported pre-registrations in a single transaction
+func (r *CampaignRepository) AddRegistrations(regs []CampaignRegistration) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin registration import: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range regs {
+		reg := &regs[i]
+		err := tx.QueryRow(`
+			INSERT INTO campaign_registrations (campaign_id, patient_hn, full_name, phone, preferred_date, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, imported_at
+		`, reg.CampaignID, reg.PatientHN, reg.FullName, reg.Phone, reg.PreferredDate, reg.Status).Scan(&reg.ID, &reg.ImportedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create campaign registration: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit registration import: %w", err)
+	}
+
+	return nil
+}
+
+// GetRegistrations retrieves a campaign's pre-registrations in import order
+func (r *CampaignRepository) GetRegistrations(campaignID int) ([]CampaignRegistration, error) {
+	query := `
+		SELECT id, campaign_id, patient_hn, full_name, phone, to_char(preferred_date, 'YYYY-MM-DD'),
+			status, session_id, booking_id, imported_at
+		FROM campaign_registrations WHERE campaign_id = $1 ORDER BY id
+	`
+
+	rows, err := r.db.conn.Query(query, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query campaign registrations: %w", err)
+	}
+	defer rows.Close()
+
+	var regs []CampaignRegistration
+	for rows.Next() {
+		var reg CampaignRegistration
+		err := rows.Scan(&reg.ID, &reg.CampaignID, &reg.PatientHN, &reg.FullName, &reg.Phone, &reg.PreferredDate,
+			&reg.Status, &reg.SessionID, &reg.BookingID, &reg.ImportedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan campaign registration: %w", err)
+		}
+		regs = append(regs, reg)
+	}
+
+	return regs, nil
+}
+
+// UpdateRegistration saves a registration's status and booked slot
+func (r *CampaignRepository) UpdateRegistration(reg *CampaignRegistration) error {
+	query := `
+		UPDATE campaign_registrations SET status = $1, session_id = $2, booking_id = $3
+		WHERE id = $4
+	`
+
+	result, err := r.db.conn.Exec(query, reg.Status, reg.SessionID, reg.BookingID, reg.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update campaign registration: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("campaign registration %d not found", reg.ID)
+	}
+
+	return nil
+}
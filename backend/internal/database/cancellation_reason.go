@@ -0,0 +1,153 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// What a cancellation reason may be given for
+const (
+	CancelForAppointment = "appointment"
+	CancelForVisit       = "visit"
+	CancelForBoth        = "both"
+)
+
+// CancellationReasonScopes are the values AppliesTo takes
+var CancellationReasonScopes = []string{CancelForAppointment, CancelForVisit, CancelForBoth}
+
+// CancellationInitiators are who a cancellation comes from
+var CancellationInitiators = []string{"patient", "clinic", "other"}
+
+// CancelReasonPatientRequest is recorded when a patient cancels by replying to a reminder
+const CancelReasonPatientRequest = "patient_request"
+
+// CancellationReason is one entry of the taxonomy appointments and visits are
+// cancelled with. Avoidable reasons are ones the clinic could have prevented,
+// which the cancellation report singles out. Reasons are retired by making
+// them inactive, since cancellations keep their code.
+type CancellationReason struct {
+	Code         string     `json:"code" db:"code"` // e.g. "doctor_unavailable"
+	Label        string     `json:"label" db:"label"`
+	AppliesTo    string     `json:"appliesTo" db:"applies_to"` // appointment, visit or both
+	Initiator    string     `json:"initiator" db:"initiator"`  // patient, clinic or other
+	Avoidable    bool       `json:"avoidable" db:"avoidable"`
+	NoteRequired bool       `json:"noteRequired" db:"note_required"` // a cancellation with it must say more
+	Active       bool       `json:"active" db:"active"`
+	SortOrder    int        `json:"sortOrder" db:"sort_order"`
+	UpdatedBy    *string    `json:"updatedBy,omitempty" db:"updated_by"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty" db:"updated_at"` // nil for built-in defaults
+}
+
+// AppliesToKind reports whether the reason may be given for an appointment or a visit
+func (c *CancellationReason) AppliesToKind(kind string) bool {
+	return c.AppliesTo == CancelForBoth || c.AppliesTo == kind
+}
+
+// DefaultCancellationReasons are the taxonomy until the clinic changes it
+var DefaultCancellationReasons = []CancellationReason{
+	{Code: CancelReasonPatientRequest, Label: "ผู้ป่วยขอยกเลิก", AppliesTo: CancelForBoth, Initiator: "patient", Active: true, SortOrder: 10},
+	{Code: "patient_sick", Label: "ผู้ป่วยไม่สบาย", AppliesTo: CancelForAppointment, Initiator: "patient", Active: true, SortOrder: 20},
+	{Code: "patient_busy", Label: "ผู้ป่วยติดธุระ", AppliesTo: CancelForAppointment, Initiator: "patient", Active: true, SortOrder: 30},
+	{Code: "recovered", Label: "อาการดีขึ้นแล้ว", AppliesTo: CancelForAppointment, Initiator: "patient", Active: true, SortOrder: 40},
+	{Code: "transport", Label: "ไม่สะดวกเดินทาง", AppliesTo: CancelForAppointment, Initiator: "patient", Avoidable: true, Active: true, SortOrder: 50},
+	{Code: "cost", Label: "ค่าใช้จ่าย", AppliesTo: CancelForBoth, Initiator: "patient", Avoidable: true, Active: true, SortOrder: 60},
+	{Code: "went_elsewhere", Label: "ไปรักษาที่อื่น", AppliesTo: CancelForAppointment, Initiator: "patient", Avoidable: true, Active: true, SortOrder: 70},
+	{Code: "wait_too_long", Label: "รอนานเกินไป", AppliesTo: CancelForVisit, Initiator: "patient", Avoidable: true, Active: true, SortOrder: 80},
+	{Code: "doctor_unavailable", Label: "แพทย์ไม่สามารถตรวจได้", AppliesTo: CancelForBoth, Initiator: "clinic", Avoidable: true, Active: true, SortOrder: 90},
+	{Code: "booking_error", Label: "นัดผิดหรือนัดซ้ำ", AppliesTo: CancelForAppointment, Initiator: "clinic", Avoidable: true, Active: true, SortOrder: 100},
+	{Code: "registered_in_error", Label: "เปิดรายการตรวจผิด", AppliesTo: CancelForVisit, Initiator: "clinic", Avoidable: true, Active: true, SortOrder: 110},
+	{Code: "other", Label: "อื่น ๆ", AppliesTo: CancelForBoth, Initiator: "other", NoteRequired: true, Active: true, SortOrder: 1000},
+}
+
+// CancellationReasons is the effective taxonomy by code
+type CancellationReasons map[string]CancellationReason
+
+// NewCancellationReasons overlays the clinic's configured reasons on the defaults
+func NewCancellationReasons(configured []CancellationReason) CancellationReasons {
+	reasons := CancellationReasons{}
+	for _, c := range DefaultCancellationReasons {
+		reasons[c.Code] = c
+	}
+	for _, c := range configured {
+		reasons[c.Code] = c
+	}
+	return reasons
+}
+
+// Sorted lists the reasons in the order forms offer them
+func (r CancellationReasons) Sorted() []CancellationReason {
+	list := make([]CancellationReason, 0, len(r))
+	for _, c := range r {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].SortOrder != list[j].SortOrder {
+			return list[i].SortOrder < list[j].SortOrder
+		}
+		return list[i].Code < list[j].Code
+	})
+	return list
+}
+
+// Cancellation is why an appointment or visit was cancelled: a code from the
+// taxonomy and, optionally, what happened in the words of whoever cancelled
+type Cancellation struct {
+	ReasonCode string
+	Note       *string
+}
+
+// CancellationReasonRepository handles the clinic's cancellation reasons
+type CancellationReasonRepository struct {
+	db *DB
+}
+
+// NewCancellationReasonRepository creates a new cancellation reason repository
+func NewCancellationReasonRepository(db *DB) *CancellationReasonRepository {
+	return &CancellationReasonRepository{db: db}
+}
+
+// GetAll retrieves the configured reasons; defaults are not included
+func (r *CancellationReasonRepository) GetAll() ([]CancellationReason, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT code, label, applies_to, initiator, avoidable, note_required, active, sort_order, updated_by, updated_at
+		FROM cancellation_reasons ORDER BY sort_order, code
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cancellation reasons: %w", err)
+	}
+	defer rows.Close()
+
+	reasons := []CancellationReason{}
+	for rows.Next() {
+		var c CancellationReason
+		err := rows.Scan(&c.Code, &c.Label, &c.AppliesTo, &c.Initiator, &c.Avoidable, &c.NoteRequired, &c.Active,
+			&c.SortOrder, &c.UpdatedBy, &c.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cancellation reason: %w", err)
+		}
+		reasons = append(reasons, c)
+	}
+
+	return reasons, rows.Err()
+}
+
+// Set adds a reason or replaces a default or earlier configured one
+func (r *CancellationReasonRepository) Set(c *CancellationReason) error {
+	query := `
+		INSERT INTO cancellation_reasons (code, label, applies_to, initiator, avoidable, note_required, active, sort_order, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (code) DO UPDATE
+		SET label = EXCLUDED.label, applies_to = EXCLUDED.applies_to, initiator = EXCLUDED.initiator,
+			avoidable = EXCLUDED.avoidable, note_required = EXCLUDED.note_required, active = EXCLUDED.active,
+			sort_order = EXCLUDED.sort_order, updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, c.Code, c.Label, c.AppliesTo, c.Initiator, c.Avoidable, c.NoteRequired, c.Active,
+		c.SortOrder, c.UpdatedBy).Scan(&c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set cancellation reason: %w", err)
+	}
+	return nil
+}
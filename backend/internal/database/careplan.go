@@ -0,0 +1,188 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Care plan goal states
+const (
+	GoalStatusActive    = "active"
+	GoalStatusAchieved  = "achieved"
+	GoalStatusCancelled = "cancelled"
+)
+
+// Checkpoint sources
+const (
+	CheckpointSourceManual = "manual"
+	CheckpointSourceVitals = "vitals"
+	CheckpointSourceLab    = "lab"
+)
+
+// CarePlanGoal is a measurable patient target, e.g. weight 70 kg or systolic BP 130
+type CarePlanGoal struct {
+	ID             int       `json:"id" db:"id"`
+	PatientHN      string    `json:"patientHn" db:"patient_hn"`
+	Metric         string    `json:"metric" db:"metric"` // weight, systolic_bp, diastolic_bp, hba1c, ldl, ...
+	Description    string    `json:"description" db:"description"`
+	Unit           string    `json:"unit" db:"unit"`
+	Baseline       float64   `json:"baseline" db:"baseline"`
+	Target         float64   `json:"target" db:"target"`
+	StartDate      string    `json:"startDate" db:"start_date"`   // YYYY-MM-DD
+	TargetDate     string    `json:"targetDate" db:"target_date"` // YYYY-MM-DD
+	CheckEveryDays int       `json:"checkEveryDays" db:"check_every_days"`
+	Status         string    `json:"status" db:"status"` // active/achieved/cancelled
+	CreatedBy      string    `json:"createdBy" db:"created_by"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
+
+// GoalCheckpoint is one measurement recorded against a goal
+type GoalCheckpoint struct {
+	ID         int       `json:"id" db:"id"`
+	GoalID     int       `json:"goalId" db:"goal_id"`
+	Value      float64   `json:"value" db:"value"`
+	Source     string    `json:"source" db:"source"` // manual/vitals/lab
+	MeasuredAt time.Time `json:"measuredAt" db:"measured_at"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// CarePlanRepository handles care plan goal database operations
+type CarePlanRepository struct {
+	db *DB
+}
+
+// NewCarePlanRepository creates a new care plan repository
+func NewCarePlanRepository(db *DB) *CarePlanRepository {
+	return &CarePlanRepository{db: db}
+}
+
+const carePlanGoalColumns = `id, patient_hn, metric, description, unit, baseline, target,
+	to_char(start_date, 'YYYY-MM-DD'), to_char(target_date, 'YYYY-MM-DD'), check_every_days, status, created_by, created_at`
+
+func scanCarePlanGoal(row interface{ Scan(...interface{}) error }) (*CarePlanGoal, error) {
+	var g CarePlanGoal
+	err := row.Scan(&g.ID, &g.PatientHN, &g.Metric, &g.Description, &g.Unit, &g.Baseline, &g.Target,
+		&g.StartDate, &g.TargetDate, &g.CheckEveryDays, &g.Status, &g.CreatedBy, &g.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// CreateGoal stores a new care plan goal
+func (r *CarePlanRepository) CreateGoal(g *CarePlanGoal) error {
+	query := `
+		INSERT INTO care_plan_goals (patient_hn, metric, description, unit, baseline, target,
+			start_date, target_date, check_every_days, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, g.PatientHN, g.Metric, g.Description, g.Unit, g.Baseline, g.Target,
+		g.StartDate, g.TargetDate, g.CheckEveryDays, g.Status, g.CreatedBy).Scan(&g.ID, &g.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create care plan goal: %w", err)
+	}
+
+	return nil
+}
+
+// GetGoal retrieves a care plan goal by ID
+func (r *CarePlanRepository) GetGoal(id int) (*CarePlanGoal, error) {
+	query := "SELECT " + carePlanGoalColumns + " FROM care_plan_goals WHERE id = $1"
+
+	g, err := scanCarePlanGoal(r.db.conn.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("care plan goal %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get care plan goal: %w", err)
+	}
+
+	return g, nil
+}
+
+// GetGoalsByPatient retrieves a patient's goals, oldest first
+func (r *CarePlanRepository) GetGoalsByPatient(hn string) ([]CarePlanGoal, error) {
+	query := "SELECT " + carePlanGoalColumns + " FROM care_plan_goals WHERE patient_hn = $1 ORDER BY created_at"
+
+	rows, err := r.db.conn.Query(query, hn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query care plan goals: %w", err)
+	}
+	defer rows.Close()
+
+	var goals []CarePlanGoal
+	for rows.Next() {
+		g, err := scanCarePlanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan care plan goal: %w", err)
+		}
+		goals = append(goals, *g)
+	}
+
+	return goals, nil
+}
+
+// UpdateGoalStatus changes a goal's status
+func (r *CarePlanRepository) UpdateGoalStatus(id int, status string) error {
+	result, err := r.db.conn.Exec("UPDATE care_plan_goals SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update care plan goal: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("care plan goal %d not found", id)
+	}
+
+	return nil
+}
+
+// AddCheckpoint records a measurement against a goal
+func (r *CarePlanRepository) AddCheckpoint(c *GoalCheckpoint) error {
+	query := `
+		INSERT INTO goal_checkpoints (goal_id, value, source, measured_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, c.GoalID, c.Value, c.Source, c.MeasuredAt).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create goal checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// GetCheckpoints retrieves a goal's checkpoints in measurement order
+func (r *CarePlanRepository) GetCheckpoints(goalID int) ([]GoalCheckpoint, error) {
+	query := `
+		SELECT id, goal_id, value, source, measured_at, created_at
+		FROM goal_checkpoints WHERE goal_id = $1 ORDER BY measured_at
+	`
+
+	rows, err := r.db.conn.Query(query, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goal checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []GoalCheckpoint
+	for rows.Next() {
+		var c GoalCheckpoint
+		if err := rows.Scan(&c.ID, &c.GoalID, &c.Value, &c.Source, &c.MeasuredAt, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan goal checkpoint: %w", err)
+		}
+		checkpoints = append(checkpoints, c)
+	}
+
+	return checkpoints, nil
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Medical certificate states
+const (
+	CertificateValid   = "valid"
+	CertificateRevoked = "revoked"
+)
+
+// MedicalCertificate is an issued medical certificate (ใบรับรองแพทย์)
+type MedicalCertificate struct {
+	ID                int        `json:"id" db:"id"`
+	CertificateNumber string     `json:"certificateNumber" db:"certificate_number"` // MCYYYY-NNNNNN, sequential per year
+	PatientHN         string     `json:"patientHn" db:"patient_hn"`
+	DoctorName        string     `json:"doctorName" db:"doctor_name"`
+	LicenseNumber     string     `json:"licenseNumber" db:"license_number"`
+	Diagnosis         string     `json:"diagnosis" db:"diagnosis"`                // ผลการตรวจ
+	Recommendation    string     `json:"recommendation" db:"recommendation"`      // ความเห็นแพทย์
+	RestFrom          *string    `json:"restFrom,omitempty" db:"rest_from"`       // YYYY-MM-DD
+	RestTo            *string    `json:"restTo,omitempty" db:"rest_to"`           // YYYY-MM-DD
+	ContentHash       string     `json:"contentHash" db:"content_hash"`           // SHA-256 of the issued content
+	VerificationCode  string     `json:"verificationCode" db:"verification_code"` // printed on the certificate
+	Status            string     `json:"status" db:"status"`                      // valid/revoked
+	RevokeReason      *string    `json:"revokeReason,omitempty" db:"revoke_reason"`
+	RevokedAt         *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	IssuedAt          time.Time  `json:"issuedAt" db:"issued_at"`
+}
+
+// CertificateRepository handles medical certificate database operations
+type CertificateRepository struct {
+	db *DB
+}
+
+// NewCertificateRepository creates a new certificate repository
+func NewCertificateRepository(db *DB) *CertificateRepository {
+	return &CertificateRepository{db: db}
+}
+
+const certificateColumns = `id, certificate_number, patient_hn, doctor_name, license_number, diagnosis,
+	recommendation, rest_from, rest_to, content_hash, verification_code, status, revoke_reason, revoked_at, issued_at`
+
+func scanCertificate(row interface{ Scan(...interface{}) error }) (*MedicalCertificate, error) {
+	var c MedicalCertificate
+	err := row.Scan(&c.ID, &c.CertificateNumber, &c.PatientHN, &c.DoctorName, &c.LicenseNumber, &c.Diagnosis,
+		&c.Recommendation, &c.RestFrom, &c.RestTo, &c.ContentHash, &c.VerificationCode, &c.Status,
+		&c.RevokeReason, &c.RevokedAt, &c.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// NextNumber reserves the next sequential certificate number for a year
+func (r *CertificateRepository) NextNumber(year int) (string, error) {
+	query := `
+		INSERT INTO certificate_sequences (year, last_number)
+		VALUES ($1, 1)
+		ON CONFLICT (year) DO UPDATE SET last_number = certificate_sequences.last_number + 1
+		RETURNING last_number
+	`
+
+	var n int
+	if err := r.db.conn.QueryRow(query, year).Scan(&n); err != nil {
+		return "", fmt.Errorf("failed to reserve certificate number: %w", err)
+	}
+
+	return fmt.Sprintf("MC%d-%06d", year, n), nil
+}
+
+// Create stores an issued certificate
+func (r *CertificateRepository) Create(c *MedicalCertificate) error {
+	query := `
+		INSERT INTO medical_certificates (certificate_number, patient_hn, doctor_name, license_number,
+			diagnosis, recommendation, rest_from, rest_to, content_hash, verification_code, status, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+
+	err := r.db.conn.QueryRow(query, c.CertificateNumber, c.PatientHN, c.DoctorName, c.LicenseNumber,
+		c.Diagnosis, c.Recommendation, c.RestFrom, c.RestTo, c.ContentHash, c.VerificationCode,
+		c.Status, c.IssuedAt).Scan(&c.ID)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("certificate %s already exists", c.CertificateNumber)
+		}
+		return fmt.Errorf("failed to create medical certificate: %w", err)
+	}
+
+	return nil
+}
+
+// GetByNumber retrieves a certificate by its certificate number
+func (r *CertificateRepository) GetByNumber(number string) (*MedicalCertificate, error) {
+	query := "SELECT " + certificateColumns + " FROM medical_certificates WHERE certificate_number = $1"
+
+	c, err := scanCertificate(r.db.conn.QueryRow(query, number))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("certificate %s not found", number)
+		}
+		return nil, fmt.Errorf("failed to get medical certificate: %w", err)
+	}
+
+	return c, nil
+}
+
+// GetByPatient retrieves all certificates issued to a patient, newest first
+func (r *CertificateRepository) GetByPatient(hn string) ([]MedicalCertificate, error) {
+	query := "SELECT " + certificateColumns + " FROM medical_certificates WHERE patient_hn = $1 ORDER BY issued_at DESC"
+
+	rows, err := r.db.conn.Query(query, hn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query medical certificates: %w", err)
+	}
+	defer rows.Close()
+
+	var certificates []MedicalCertificate
+	for rows.Next() {
+		c, err := scanCertificate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan medical certificate: %w", err)
+		}
+		certificates = append(certificates, *c)
+	}
+
+	return certificates, nil
+}
+
+// Revoke marks a certificate as revoked
+func (r *CertificateRepository) Revoke(number, reason string) error {
+	query := `
+		UPDATE medical_certificates
+		SET status = 'revoked', revoke_reason = $1, revoked_at = CURRENT_TIMESTAMP
+		WHERE certificate_number = $2 AND status = 'valid'
+	`
+
+	result, err := r.db.conn.Exec(query, reason, number)
+	if err != nil {
+		return fmt.Errorf("failed to revoke medical certificate: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Conflict("certificate %s is not valid", number)
+	}
+
+	return nil
+}
@@ -0,0 +1,291 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// ChatThread is an internal staff conversation about a patient, optionally
+// about one of their visits, kept with the record instead of in outside chat apps
+type ChatThread struct {
+	ID            int       `json:"id" db:"id"`
+	PatientHN     string    `json:"patientHn" db:"patient_hn"`
+	VisitID       *int      `json:"visitId,omitempty" db:"visit_id"`
+	Subject       string    `json:"subject" db:"subject"` // e.g. "ผลแล็บ HbA1c รอแพทย์ดู"
+	CreatedBy     string    `json:"createdBy" db:"created_by"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	LastMessageAt time.Time `json:"lastMessageAt" db:"last_message_at"`
+	Unread        int       `json:"unread" db:"-"` // messages the reader listing the threads has not read
+}
+
+// ChatMessage is one message in a thread
+type ChatMessage struct {
+	ID        int       `json:"id" db:"id"`
+	ThreadID  int       `json:"threadId" db:"thread_id"`
+	Author    string    `json:"author" db:"author"`
+	Body      string    `json:"body" db:"body"`
+	Mentions  []string  `json:"mentions" db:"-"`         // staff the message asks for, stored in chat_mentions
+	ReadBy    []string  `json:"readBy,omitempty" db:"-"` // staff other than the author who have read it
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// ChatReceipt records how far one member of staff has read a thread
+type ChatReceipt struct {
+	ThreadID          int       `json:"threadId" db:"thread_id"`
+	Reader            string    `json:"reader" db:"reader"`
+	LastReadMessageID int       `json:"lastReadMessageId" db:"last_read_message_id"`
+	ReadAt            time.Time `json:"readAt" db:"read_at"`
+}
+
+// ChatThreadFilter selects a patient's or a visit's threads; Reader, when
+// set, is whose unread messages are counted
+type ChatThreadFilter struct {
+	PatientHN string
+	VisitID   int
+	Reader    string
+}
+
+// ChatRepository handles staff chat database operations
+type ChatRepository struct {
+	db *DB
+}
+
+// NewChatRepository creates a new chat repository
+func NewChatRepository(db *DB) *ChatRepository {
+	return &ChatRepository{db: db}
+}
+
+const chatThreadColumns = "id, patient_hn, visit_id, subject, created_by, created_at, last_message_at"
+
+func scanChatThread(row interface{ Scan(...interface{}) error }) (*ChatThread, error) {
+	var t ChatThread
+	if err := row.Scan(&t.ID, &t.PatientHN, &t.VisitID, &t.Subject, &t.CreatedBy, &t.CreatedAt, &t.LastMessageAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CreateThread starts a thread
+func (r *ChatRepository) CreateThread(t *ChatThread) error {
+	query := `
+		INSERT INTO chat_threads (patient_hn, visit_id, subject, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, last_message_at
+	`
+
+	err := r.db.conn.QueryRow(query, t.PatientHN, t.VisitID, t.Subject, t.CreatedBy).Scan(&t.ID, &t.CreatedAt, &t.LastMessageAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("visit %d does not exist", *t.VisitID)
+		}
+		return fmt.Errorf("failed to create chat thread: %w", err)
+	}
+
+	return nil
+}
+
+// GetThread retrieves a thread by ID
+func (r *ChatRepository) GetThread(id int) (*ChatThread, error) {
+	t, err := scanChatThread(r.db.conn.QueryRow("SELECT "+chatThreadColumns+" FROM chat_threads WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("chat thread %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get chat thread: %w", err)
+	}
+	return t, nil
+}
+
+// ListThreads retrieves threads matching the filter, most recently active first
+func (r *ChatRepository) ListThreads(f ChatThreadFilter) ([]ChatThread, error) {
+	query := `
+		SELECT ` + chatThreadColumns + `,
+			(SELECT COUNT(*) FROM chat_messages m
+			 WHERE $3 <> '' AND m.thread_id = t.id AND m.author <> $3
+				AND m.id > COALESCE((SELECT last_read_message_id FROM chat_reads WHERE thread_id = t.id AND reader = $3), 0))
+		FROM chat_threads t
+		WHERE ($1 = '' OR patient_hn = $1) AND ($2 = 0 OR visit_id = $2)
+		ORDER BY last_message_at DESC, id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, f.PatientHN, f.VisitID, f.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat threads: %w", err)
+	}
+	defer rows.Close()
+
+	threads := []ChatThread{}
+	for rows.Next() {
+		var t ChatThread
+		if err := rows.Scan(&t.ID, &t.PatientHN, &t.VisitID, &t.Subject, &t.CreatedBy, &t.CreatedAt, &t.LastMessageAt, &t.Unread); err != nil {
+			return nil, fmt.Errorf("failed to scan chat thread: %w", err)
+		}
+		threads = append(threads, t)
+	}
+
+	return threads, rows.Err()
+}
+
+// PostMessage adds a message and its mentions to a thread. The author has
+// read everything up to their own message.
+func (r *ChatRepository) PostMessage(m *ChatMessage) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin chat message: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("INSERT INTO chat_messages (thread_id, author, body) VALUES ($1, $2, $3) RETURNING id, created_at",
+		m.ThreadID, m.Author, m.Body).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.NotFound("chat thread %d not found", m.ThreadID)
+		}
+		return fmt.Errorf("failed to post chat message: %w", err)
+	}
+	for _, name := range m.Mentions {
+		if _, err := tx.Exec("INSERT INTO chat_mentions (message_id, staff_name) VALUES ($1, $2) ON CONFLICT DO NOTHING", m.ID, name); err != nil {
+			return fmt.Errorf("failed to record mention: %w", err)
+		}
+	}
+	if _, err := tx.Exec("UPDATE chat_threads SET last_message_at = $2 WHERE id = $1", m.ThreadID, m.CreatedAt); err != nil {
+		return fmt.Errorf("failed to update chat thread: %w", err)
+	}
+	if err := markRead(tx, m.ThreadID, m.Author, m.ID, &ChatReceipt{}); err != nil {
+		return err
+	}
+	m.ReadBy = []string{}
+
+	return tx.Commit()
+}
+
+// GetMessages retrieves a thread's messages oldest first, with their mentions and readers
+func (r *ChatRepository) GetMessages(threadID int) ([]ChatMessage, error) {
+	rows, err := r.db.conn.Query("SELECT id, thread_id, author, body, created_at FROM chat_messages WHERE thread_id = $1 ORDER BY id", threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []ChatMessage{}
+	index := make(map[int]int)
+	for rows.Next() {
+		m := ChatMessage{Mentions: []string{}, ReadBy: []string{}}
+		if err := rows.Scan(&m.ID, &m.ThreadID, &m.Author, &m.Body, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat message: %w", err)
+		}
+		index[m.ID] = len(messages)
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	mentions, err := r.db.conn.Query(`
+		SELECT c.message_id, c.staff_name FROM chat_mentions c JOIN chat_messages m ON m.id = c.message_id
+		WHERE m.thread_id = $1 ORDER BY c.message_id, c.staff_name`, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat mentions: %w", err)
+	}
+	defer mentions.Close()
+	for mentions.Next() {
+		var id int
+		var name string
+		if err := mentions.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan chat mention: %w", err)
+		}
+		m := &messages[index[id]]
+		m.Mentions = append(m.Mentions, name)
+	}
+	if err := mentions.Err(); err != nil {
+		return nil, err
+	}
+
+	receipts, err := r.db.conn.Query("SELECT reader, last_read_message_id FROM chat_reads WHERE thread_id = $1 ORDER BY reader", threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat read receipts: %w", err)
+	}
+	defer receipts.Close()
+	for receipts.Next() {
+		var reader string
+		var upTo int
+		if err := receipts.Scan(&reader, &upTo); err != nil {
+			return nil, fmt.Errorf("failed to scan chat read receipt: %w", err)
+		}
+		for i := range messages {
+			if messages[i].ID <= upTo && messages[i].Author != reader {
+				messages[i].ReadBy = append(messages[i].ReadBy, reader)
+			}
+		}
+	}
+
+	return messages, receipts.Err()
+}
+
+// MarkRead records that reader has read a thread up to message upTo; a
+// receipt never moves backwards
+func (r *ChatRepository) MarkRead(threadID int, reader string, upTo int) (*ChatReceipt, error) {
+	var receipt ChatReceipt
+	if err := markRead(r.db.conn, threadID, reader, upTo, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+func markRead(q interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, threadID int, reader string, upTo int, receipt *ChatReceipt) error {
+	query := `
+		INSERT INTO chat_reads (thread_id, reader, last_read_message_id, read_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (thread_id, reader) DO UPDATE SET
+			last_read_message_id = GREATEST(chat_reads.last_read_message_id, EXCLUDED.last_read_message_id),
+			read_at = EXCLUDED.read_at
+		RETURNING thread_id, reader, last_read_message_id, read_at
+	`
+
+	err := q.QueryRow(query, threadID, reader, upTo).Scan(&receipt.ThreadID, &receipt.Reader, &receipt.LastReadMessageID, &receipt.ReadAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.NotFound("chat thread %d not found", threadID)
+		}
+		return fmt.Errorf("failed to mark chat thread read: %w", err)
+	}
+	return nil
+}
+
+// GetMentions retrieves the messages that mention a member of staff, newest
+// first; unreadOnly leaves out those in threads they have read past the message
+func (r *ChatRepository) GetMentions(staff string, unreadOnly bool) ([]ChatMessage, error) {
+	query := `
+		SELECT m.id, m.thread_id, m.author, m.body, m.created_at,
+			(SELECT string_agg(staff_name, E'\n' ORDER BY staff_name) FROM chat_mentions WHERE message_id = m.id)
+		FROM chat_messages m
+		JOIN chat_mentions c ON c.message_id = m.id AND LOWER(c.staff_name) = LOWER($1)
+		WHERE NOT $2 OR m.id > COALESCE((SELECT last_read_message_id FROM chat_reads WHERE thread_id = m.thread_id AND LOWER(reader) = LOWER($1)), 0)
+		ORDER BY m.id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, staff, unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat mentions: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []ChatMessage{}
+	for rows.Next() {
+		var m ChatMessage
+		var mentioned string
+		if err := rows.Scan(&m.ID, &m.ThreadID, &m.Author, &m.Body, &m.CreatedAt, &mentioned); err != nil {
+			return nil, fmt.Errorf("failed to scan chat message: %w", err)
+		}
+		m.Mentions = strings.Split(mentioned, "\n")
+		messages = append(messages, m)
+	}
+
+	return messages, rows.Err()
+}
@@ -0,0 +1,259 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Clinical note author roles
+const (
+	AuthorRoleDoctor    = "doctor"
+	AuthorRoleTrainee   = "trainee"
+	AuthorRoleAssistant = "assistant"
+)
+
+// Clinical note states
+const (
+	NoteStatusPendingCosign = "pending_cosign"
+	NoteStatusFinal         = "final"
+)
+
+// ClinicalNote is a visit note. Notes by trainees or assistants stay
+// pending until a licensed doctor counter-signs them.
+type ClinicalNote struct {
+	ID              int        `json:"id" db:"id"`
+	VisitID         int        `json:"visitId" db:"visit_id"`
+	PatientHN       string     `json:"patientHn" db:"patient_hn"`
+	AuthorName      string     `json:"authorName" db:"author_name"`
+	AuthorRole      string     `json:"authorRole" db:"author_role"` // doctor/trainee/assistant
+	Content         string     `json:"content" db:"content"`
+	Status          string     `json:"status" db:"status"` // pending_cosign/final
+	SupervisorName  *string    `json:"supervisorName,omitempty" db:"supervisor_name"`
+	CosignedBy      *string    `json:"cosignedBy,omitempty" db:"cosigned_by"`
+	CosignerLicense *string    `json:"cosignerLicense,omitempty" db:"cosigner_license"`
+	CosignedAt      *time.Time `json:"cosignedAt,omitempty" db:"cosigned_at"`
+	Version         int        `json:"version" db:"version"` // 1 until amended
+	AmendedAt       *time.Time `json:"amendedAt,omitempty" db:"amended_at"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// ClinicalNoteVersion is one version of a note's content. Version 1 is the
+// original text; each amendment adds the next version with its reason.
+type ClinicalNoteVersion struct {
+	NoteID     int       `json:"noteId" db:"note_id"`
+	Version    int       `json:"version" db:"version"`
+	Content    string    `json:"content" db:"content"`
+	AuthorName string    `json:"authorName" db:"author_name"`
+	Reason     *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// ClinicalNoteRepository handles clinical note database operations
+type ClinicalNoteRepository struct {
+	db *DB
+}
+
+// NewClinicalNoteRepository creates a new clinical note repository
+func NewClinicalNoteRepository(db *DB) *ClinicalNoteRepository {
+	return &ClinicalNoteRepository{db: db}
+}
+
+const clinicalNoteColumns = `id, visit_id, patient_hn, author_name, author_role, content, status,
+	supervisor_name, cosigned_by, cosigner_license, cosigned_at, version, amended_at, created_at`
+
+func scanClinicalNote(row interface{ Scan(...interface{}) error }) (*ClinicalNote, error) {
+	var n ClinicalNote
+	err := row.Scan(&n.ID, &n.VisitID, &n.PatientHN, &n.AuthorName, &n.AuthorRole, &n.Content, &n.Status,
+		&n.SupervisorName, &n.CosignedBy, &n.CosignerLicense, &n.CosignedAt, &n.Version, &n.AmendedAt, &n.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *ClinicalNoteRepository) queryNotes(query string, args ...interface{}) ([]ClinicalNote, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clinical notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []ClinicalNote
+	for rows.Next() {
+		n, err := scanClinicalNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan clinical note: %w", err)
+		}
+		notes = append(notes, *n)
+	}
+
+	return notes, nil
+}
+
+// Create stores a new clinical note
+func (r *ClinicalNoteRepository) Create(n *ClinicalNote) error {
+	query := `
+		INSERT INTO clinical_notes (visit_id, patient_hn, author_name, author_role, content, status,
+			supervisor_name, cosigned_by, cosigner_license, cosigned_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, version, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, n.VisitID, n.PatientHN, n.AuthorName, n.AuthorRole, n.Content, n.Status,
+		n.SupervisorName, n.CosignedBy, n.CosignerLicense, n.CosignedAt).Scan(&n.ID, &n.Version, &n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create clinical note: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a clinical note by ID
+func (r *ClinicalNoteRepository) GetByID(id int) (*ClinicalNote, error) {
+	query := "SELECT " + clinicalNoteColumns + " FROM clinical_notes WHERE id = $1"
+
+	n, err := scanClinicalNote(r.db.conn.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("clinical note %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get clinical note: %w", err)
+	}
+
+	return n, nil
+}
+
+// GetByVisit retrieves the notes of a visit, oldest first
+func (r *ClinicalNoteRepository) GetByVisit(visitID int) ([]ClinicalNote, error) {
+	return r.queryNotes("SELECT "+clinicalNoteColumns+" FROM clinical_notes WHERE visit_id = $1 ORDER BY created_at", visitID)
+}
+
+// GetPendingCosign retrieves notes awaiting counter-signature, optionally for one supervisor
+func (r *ClinicalNoteRepository) GetPendingCosign(supervisor string) ([]ClinicalNote, error) {
+	query := "SELECT " + clinicalNoteColumns + ` FROM clinical_notes
+		WHERE status = 'pending_cosign' AND ($1 = '' OR supervisor_name = $1)
+		ORDER BY created_at`
+	return r.queryNotes(query, supervisor)
+}
+
+// Cosign finalizes a pending note with the counter-signing doctor's details
+func (r *ClinicalNoteRepository) Cosign(n *ClinicalNote) error {
+	query := `
+		UPDATE clinical_notes
+		SET status = 'final', cosigned_by = $1, cosigner_license = $2, cosigned_at = $3
+		WHERE id = $4 AND status = 'pending_cosign'
+	`
+
+	result, err := r.db.conn.Exec(query, n.CosignedBy, n.CosignerLicense, n.CosignedAt, n.ID)
+	if err != nil {
+		return fmt.Errorf("failed to cosign clinical note: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Conflict("clinical note %d is not awaiting co-signature", n.ID)
+	}
+
+	n.Status = NoteStatusFinal
+	return nil
+}
+
+// HasPendingCosign reports whether a visit still has notes awaiting counter-signature
+func (r *ClinicalNoteRepository) HasPendingCosign(visitID int) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM clinical_notes WHERE visit_id = $1 AND status = 'pending_cosign')"
+
+	var pending bool
+	if err := r.db.conn.QueryRow(query, visitID).Scan(&pending); err != nil {
+		return false, fmt.Errorf("failed to check pending cosign: %w", err)
+	}
+
+	return pending, nil
+}
+
+// Amend replaces a final note's content with a new version, keeping every
+// earlier version (including the original) in clinical_note_versions
+func (r *ClinicalNoteRepository) Amend(noteID int, content, amendedBy, reason string) (*ClinicalNote, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin note amendment: %w", err)
+	}
+	defer tx.Rollback()
+
+	n, err := scanClinicalNote(tx.QueryRow("SELECT "+clinicalNoteColumns+" FROM clinical_notes WHERE id = $1 FOR UPDATE", noteID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("clinical note %d not found", noteID)
+		}
+		return nil, fmt.Errorf("failed to lock clinical note: %w", err)
+	}
+	if n.Status != NoteStatusFinal {
+		return nil, apperr.Conflict("clinical note %d is not final", noteID)
+	}
+
+	if n.Version == 1 {
+		_, err = tx.Exec(`
+			INSERT INTO clinical_note_versions (note_id, version, content, author_name, created_at)
+			VALUES ($1, 1, $2, $3, $4)
+		`, n.ID, n.Content, n.AuthorName, n.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store original note version: %w", err)
+		}
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO clinical_note_versions (note_id, version, content, author_name, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, n.ID, n.Version+1, content, amendedBy, reason, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store note amendment: %w", err)
+	}
+
+	_, err = tx.Exec("UPDATE clinical_notes SET content = $1, version = $2, amended_at = $3 WHERE id = $4",
+		content, n.Version+1, now, n.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to amend clinical note: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit note amendment: %w", err)
+	}
+
+	n.Content = content
+	n.Version++
+	n.AmendedAt = &now
+	return n, nil
+}
+
+// GetVersions retrieves the stored versions of a note, oldest first.
+// Notes that were never amended have no stored versions.
+func (r *ClinicalNoteRepository) GetVersions(noteID int) ([]ClinicalNoteVersion, error) {
+	query := `
+		SELECT note_id, version, content, author_name, reason, created_at
+		FROM clinical_note_versions WHERE note_id = $1 ORDER BY version
+	`
+
+	rows, err := r.db.conn.Query(query, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query note versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []ClinicalNoteVersion
+	for rows.Next() {
+		var v ClinicalNoteVersion
+		if err := rows.Scan(&v.NoteID, &v.Version, &v.Content, &v.AuthorName, &v.Reason, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan note version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
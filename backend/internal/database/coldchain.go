@@ -0,0 +1,431 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Temperature reading sources
+const (
+	ReadingSourceSensor = "sensor"
+	ReadingSourceManual = "manual"
+)
+
+// Lot review states after a cold-chain excursion
+const (
+	LotReviewPending   = "pending"
+	LotReviewReleased  = "released"
+	LotReviewDiscarded = "discarded"
+)
+
+// Fridge is a monitored vaccine/drug storage unit
+type Fridge struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Location  string    `json:"location" db:"location"`
+	MinTemp   float64   `json:"minTemp" db:"min_temp"` // °C, typically 2
+	MaxTemp   float64   `json:"maxTemp" db:"max_temp"` // °C, typically 8
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// FridgeLot is a stock lot currently stored in a fridge
+type FridgeLot struct {
+	FridgeID  int    `json:"fridgeId" db:"fridge_id"`
+	ItemID    int    `json:"itemId" db:"item_id"`
+	ItemName  string `json:"itemName" db:"item_name"`
+	LotNumber string `json:"lotNumber" db:"lot_number"`
+}
+
+// TemperatureReading is one logged fridge temperature
+type TemperatureReading struct {
+	ID         int       `json:"id" db:"id"`
+	FridgeID   int       `json:"fridgeId" db:"fridge_id"`
+	Celsius    float64   `json:"celsius" db:"celsius"`
+	Source     string    `json:"source" db:"source"`
+	RecordedBy *string   `json:"recordedBy,omitempty" db:"recorded_by"`
+	OutOfRange bool      `json:"outOfRange" db:"out_of_range"`
+	RecordedAt time.Time `json:"recordedAt" db:"recorded_at"`
+}
+
+// TemperatureExcursion is a continuous out-of-range period of a fridge
+type TemperatureExcursion struct {
+	ID           int        `json:"id" db:"id"`
+	FridgeID     int        `json:"fridgeId" db:"fridge_id"`
+	StartedAt    time.Time  `json:"startedAt" db:"started_at"`
+	EndedAt      *time.Time `json:"endedAt,omitempty" db:"ended_at"` // nil while still out of range
+	MinCelsius   float64    `json:"minCelsius" db:"min_celsius"`
+	MaxCelsius   float64    `json:"maxCelsius" db:"max_celsius"`
+	ReadingCount int        `json:"readingCount" db:"reading_count"`
+}
+
+// LotReview marks a lot exposed to an excursion as needing review before use
+type LotReview struct {
+	ID          int        `json:"id" db:"id"`
+	ExcursionID int        `json:"excursionId" db:"excursion_id"`
+	FridgeID    int        `json:"fridgeId" db:"fridge_id"`
+	ItemID      int        `json:"itemId" db:"item_id"`
+	ItemName    string     `json:"itemName" db:"item_name"`
+	LotNumber   string     `json:"lotNumber" db:"lot_number"`
+	Status      string     `json:"status" db:"status"`
+	ReviewedBy  *string    `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	Notes       *string    `json:"notes,omitempty" db:"notes"`
+	ReviewedAt  *time.Time `json:"reviewedAt,omitempty" db:"reviewed_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// ColdChainRepository handles fridge, temperature and lot review database operations
+type ColdChainRepository struct {
+	db *DB
+}
+
+// NewColdChainRepository creates a new cold-chain repository
+func NewColdChainRepository(db *DB) *ColdChainRepository {
+	return &ColdChainRepository{db: db}
+}
+
+// CreateFridge adds a monitored fridge
+func (r *ColdChainRepository) CreateFridge(f *Fridge) error {
+	query := `
+		INSERT INTO fridges (name, location, min_temp, max_temp)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, f.Name, f.Location, f.MinTemp, f.MaxTemp).Scan(&f.ID, &f.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create fridge: %w", err)
+	}
+
+	return nil
+}
+
+// GetFridges retrieves all fridges
+func (r *ColdChainRepository) GetFridges() ([]Fridge, error) {
+	rows, err := r.db.conn.Query("SELECT id, name, location, min_temp, max_temp, created_at FROM fridges ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fridges: %w", err)
+	}
+	defer rows.Close()
+
+	var fridges []Fridge
+	for rows.Next() {
+		var f Fridge
+		if err := rows.Scan(&f.ID, &f.Name, &f.Location, &f.MinTemp, &f.MaxTemp, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan fridge: %w", err)
+		}
+		fridges = append(fridges, f)
+	}
+
+	return fridges, nil
+}
+
+// GetFridge retrieves a fridge by ID
+func (r *ColdChainRepository) GetFridge(id int) (*Fridge, error) {
+	query := "SELECT id, name, location, min_temp, max_temp, created_at FROM fridges WHERE id = $1"
+
+	var f Fridge
+	err := r.db.conn.QueryRow(query, id).Scan(&f.ID, &f.Name, &f.Location, &f.MinTemp, &f.MaxTemp, &f.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("fridge %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get fridge: %w", err)
+	}
+
+	return &f, nil
+}
+
+// SetFridgeLots replaces the list of lots stored in a fridge
+func (r *ColdChainRepository) SetFridgeLots(fridgeID int, lots []FridgeLot) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin fridge lot update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM fridge_lots WHERE fridge_id = $1", fridgeID); err != nil {
+		return fmt.Errorf("failed to clear fridge lots: %w", err)
+	}
+
+	for _, lot := range lots {
+		_, err := tx.Exec(`
+			INSERT INTO fridge_lots (fridge_id, item_id, item_name, lot_number)
+			VALUES ($1, $2, $3, $4)
+		`, fridgeID, lot.ItemID, lot.ItemName, lot.LotNumber)
+		if err != nil {
+			return fmt.Errorf("failed to add fridge lot: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit fridge lot update: %w", err)
+	}
+
+	return nil
+}
+
+// GetFridgeLots retrieves the lots stored in a fridge
+func (r *ColdChainRepository) GetFridgeLots(fridgeID int) ([]FridgeLot, error) {
+	query := `
+		SELECT fridge_id, item_id, item_name, lot_number
+		FROM fridge_lots
+		WHERE fridge_id = $1
+		ORDER BY item_name, lot_number
+	`
+
+	rows, err := r.db.conn.Query(query, fridgeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fridge lots: %w", err)
+	}
+	defer rows.Close()
+
+	var lots []FridgeLot
+	for rows.Next() {
+		var lot FridgeLot
+		if err := rows.Scan(&lot.FridgeID, &lot.ItemID, &lot.ItemName, &lot.LotNumber); err != nil {
+			return nil, fmt.Errorf("failed to scan fridge lot: %w", err)
+		}
+		lots = append(lots, lot)
+	}
+
+	return lots, nil
+}
+
+// CreateReading stores a temperature reading
+func (r *ColdChainRepository) CreateReading(reading *TemperatureReading) error {
+	query := `
+		INSERT INTO temperature_readings (fridge_id, celsius, source, recorded_by, out_of_range, recorded_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := r.db.conn.QueryRow(query, reading.FridgeID, reading.Celsius, reading.Source,
+		reading.RecordedBy, reading.OutOfRange, reading.RecordedAt).Scan(&reading.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create temperature reading: %w", err)
+	}
+
+	return nil
+}
+
+// GetReadings retrieves a fridge's readings within [from, to), oldest first
+func (r *ColdChainRepository) GetReadings(fridgeID int, from, to time.Time) ([]TemperatureReading, error) {
+	query := `
+		SELECT id, fridge_id, celsius, source, recorded_by, out_of_range, recorded_at
+		FROM temperature_readings
+		WHERE fridge_id = $1 AND recorded_at >= $2 AND recorded_at < $3
+		ORDER BY recorded_at
+	`
+
+	rows, err := r.db.conn.Query(query, fridgeID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query temperature readings: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []TemperatureReading
+	for rows.Next() {
+		var t TemperatureReading
+		err := rows.Scan(&t.ID, &t.FridgeID, &t.Celsius, &t.Source, &t.RecordedBy, &t.OutOfRange, &t.RecordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan temperature reading: %w", err)
+		}
+		readings = append(readings, t)
+	}
+
+	return readings, nil
+}
+
+// GetOpenExcursion retrieves the ongoing excursion of a fridge, or nil if it is in range
+func (r *ColdChainRepository) GetOpenExcursion(fridgeID int) (*TemperatureExcursion, error) {
+	query := `
+		SELECT id, fridge_id, started_at, ended_at, min_celsius, max_celsius, reading_count
+		FROM temperature_excursions
+		WHERE fridge_id = $1 AND ended_at IS NULL
+	`
+
+	var e TemperatureExcursion
+	err := r.db.conn.QueryRow(query, fridgeID).Scan(&e.ID, &e.FridgeID, &e.StartedAt, &e.EndedAt,
+		&e.MinCelsius, &e.MaxCelsius, &e.ReadingCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get open excursion: %w", err)
+	}
+
+	return &e, nil
+}
+
+// SaveExcursion creates or updates a temperature excursion
+func (r *ColdChainRepository) SaveExcursion(e *TemperatureExcursion) error {
+	if e.ID == 0 {
+		query := `
+			INSERT INTO temperature_excursions (fridge_id, started_at, ended_at, min_celsius, max_celsius, reading_count)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`
+		err := r.db.conn.QueryRow(query, e.FridgeID, e.StartedAt, e.EndedAt,
+			e.MinCelsius, e.MaxCelsius, e.ReadingCount).Scan(&e.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create excursion: %w", err)
+		}
+		return nil
+	}
+
+	query := `
+		UPDATE temperature_excursions
+		SET ended_at = $1, min_celsius = $2, max_celsius = $3, reading_count = $4
+		WHERE id = $5
+	`
+	if _, err := r.db.conn.Exec(query, e.EndedAt, e.MinCelsius, e.MaxCelsius, e.ReadingCount, e.ID); err != nil {
+		return fmt.Errorf("failed to update excursion: %w", err)
+	}
+
+	return nil
+}
+
+// GetExcursions retrieves excursions, optionally only the ones still open
+func (r *ColdChainRepository) GetExcursions(openOnly bool) ([]TemperatureExcursion, error) {
+	query := `
+		SELECT id, fridge_id, started_at, ended_at, min_celsius, max_celsius, reading_count
+		FROM temperature_excursions
+		WHERE (NOT $1 OR ended_at IS NULL)
+		ORDER BY started_at DESC
+	`
+
+	rows, err := r.db.conn.Query(query, openOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query excursions: %w", err)
+	}
+	defer rows.Close()
+
+	var excursions []TemperatureExcursion
+	for rows.Next() {
+		var e TemperatureExcursion
+		err := rows.Scan(&e.ID, &e.FridgeID, &e.StartedAt, &e.EndedAt, &e.MinCelsius, &e.MaxCelsius, &e.ReadingCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan excursion: %w", err)
+		}
+		excursions = append(excursions, e)
+	}
+
+	return excursions, nil
+}
+
+// CreateLotReviews stores pending reviews for lots exposed to an excursion
+func (r *ColdChainRepository) CreateLotReviews(reviews []LotReview) error {
+	query := `
+		INSERT INTO lot_reviews (excursion_id, fridge_id, item_id, item_name, lot_number, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	for i := range reviews {
+		lr := &reviews[i]
+		err := r.db.conn.QueryRow(query, lr.ExcursionID, lr.FridgeID, lr.ItemID, lr.ItemName,
+			lr.LotNumber, lr.Status).Scan(&lr.ID, &lr.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create lot review: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetLotReviews retrieves lot reviews, optionally filtered by status
+func (r *ColdChainRepository) GetLotReviews(status string) ([]LotReview, error) {
+	query := `
+		SELECT id, excursion_id, fridge_id, item_id, item_name, lot_number, status,
+		       reviewed_by, notes, reviewed_at, created_at
+		FROM lot_reviews
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.conn.Query(query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lot reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []LotReview
+	for rows.Next() {
+		var lr LotReview
+		err := rows.Scan(&lr.ID, &lr.ExcursionID, &lr.FridgeID, &lr.ItemID, &lr.ItemName, &lr.LotNumber,
+			&lr.Status, &lr.ReviewedBy, &lr.Notes, &lr.ReviewedAt, &lr.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lot review: %w", err)
+		}
+		reviews = append(reviews, lr)
+	}
+
+	return reviews, nil
+}
+
+// GetLotReview retrieves a lot review by ID
+func (r *ColdChainRepository) GetLotReview(id int) (*LotReview, error) {
+	query := `
+		SELECT id, excursion_id, fridge_id, item_id, item_name, lot_number, status,
+		       reviewed_by, notes, reviewed_at, created_at
+		FROM lot_reviews
+		WHERE id = $1
+	`
+
+	var lr LotReview
+	err := r.db.conn.QueryRow(query, id).Scan(&lr.ID, &lr.ExcursionID, &lr.FridgeID, &lr.ItemID, &lr.ItemName,
+		&lr.LotNumber, &lr.Status, &lr.ReviewedBy, &lr.Notes, &lr.ReviewedAt, &lr.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("lot review %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get lot review: %w", err)
+	}
+
+	return &lr, nil
+}
+
+// UpdateLotReview stores the outcome of a lot review
+func (r *ColdChainRepository) UpdateLotReview(lr *LotReview) error {
+	query := `
+		UPDATE lot_reviews
+		SET status = $1, reviewed_by = $2, notes = $3, reviewed_at = $4
+		WHERE id = $5
+	`
+
+	result, err := r.db.conn.Exec(query, lr.Status, lr.ReviewedBy, lr.Notes, lr.ReviewedAt, lr.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update lot review: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("lot review %d not found", lr.ID)
+	}
+
+	return nil
+}
+
+// IsLotOnHold reports whether a lot has an unresolved cold-chain review
+func (r *ColdChainRepository) IsLotOnHold(itemID int, lotNumber string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM lot_reviews
+			WHERE item_id = $1 AND lot_number = $2 AND status = 'pending'
+		)
+	`
+
+	var onHold bool
+	if err := r.db.conn.QueryRow(query, itemID, lotNumber).Scan(&onHold); err != nil {
+		return false, fmt.Errorf("failed to check lot review: %w", err)
+	}
+
+	return onHold, nil
+}
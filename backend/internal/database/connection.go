@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	// _ "github.com/lib/pq" // PostgreSQL driver (uncomment when using real database)
 )
 
@@ -29,6 +31,11 @@ func NewConnection(host, port, user, password, dbname string) (*DB, error) {
 	return &DB{conn: conn}, nil
 }
 
+// Ping checks that the database is reachable
+func (db *DB) Ping(ctx context.Context) error {
+	return db.conn.PingContext(ctx)
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
@@ -45,9 +52,13 @@ func (db *DB) CreatePatientsTable() error {
 		phone VARCHAR(20),
 		date_of_birth DATE,
 		address TEXT,
+		citizen_id VARCHAR(13),
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
+	);
+
+	ALTER TABLE patients ADD COLUMN IF NOT EXISTS citizen_id VARCHAR(13);
+	ALTER TABLE patients ALTER COLUMN email DROP NOT NULL`
 
 	_, err := db.conn.Exec(query)
 	if err != nil {
@@ -57,3 +68,2456 @@ func (db *DB) CreatePatientsTable() error {
 	log.Println("Patients table created successfully")
 	return nil
 }
+
+// CreateReconciliationTables creates the bank statement import and transaction tables
+func (db *DB) CreateReconciliationTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS bank_statement_imports (
+		id SERIAL PRIMARY KEY,
+		file_name VARCHAR(255) NOT NULL,
+		row_count INTEGER NOT NULL DEFAULT 0,
+		matched_count INTEGER NOT NULL DEFAULT 0,
+		imported_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS bank_transactions (
+		id SERIAL PRIMARY KEY,
+		import_id INTEGER NOT NULL REFERENCES bank_statement_imports(id),
+		transaction_date TIMESTAMP NOT NULL,
+		amount NUMERIC(12, 2) NOT NULL,
+		reference VARCHAR(100),
+		description TEXT,
+		match_status VARCHAR(20) NOT NULL DEFAULT 'unmatched',
+		matched_invoice_id INTEGER,
+		matched_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation tables: %w", err)
+	}
+
+	log.Println("Reconciliation tables created successfully")
+	return nil
+}
+
+// CreateReorderTables creates the reorder policy and purchase suggestion tables
+func (db *DB) CreateReorderTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS reorder_policies (
+		item_id INTEGER PRIMARY KEY,
+		item_name VARCHAR(255) NOT NULL,
+		supplier VARCHAR(255),
+		pack_size INTEGER NOT NULL DEFAULT 1,
+		lead_time_days INTEGER NOT NULL DEFAULT 7,
+		safety_stock_days INTEGER NOT NULL DEFAULT 7,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS purchase_suggestions (
+		id SERIAL PRIMARY KEY,
+		item_id INTEGER NOT NULL,
+		item_name VARCHAR(255) NOT NULL,
+		supplier VARCHAR(255),
+		forecast_monthly NUMERIC(12, 2) NOT NULL,
+		current_stock NUMERIC(12, 2) NOT NULL,
+		suggested_quantity INTEGER NOT NULL,
+		packs INTEGER NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		decided_by VARCHAR(100),
+		decided_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create reorder tables: %w", err)
+	}
+
+	log.Println("Reorder tables created successfully")
+	return nil
+}
+
+// CreateRecallTables creates the lot recall and recall notification tables
+func (db *DB) CreateRecallTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS lot_recalls (
+		id SERIAL PRIMARY KEY,
+		item_id INTEGER NOT NULL,
+		item_name VARCHAR(255) NOT NULL,
+		lot_number VARCHAR(50) NOT NULL,
+		manufacturer VARCHAR(255),
+		reason TEXT,
+		recalled_by VARCHAR(100),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (item_id, lot_number)
+	);
+
+	CREATE TABLE IF NOT EXISTS recall_notifications (
+		id SERIAL PRIMARY KEY,
+		recall_id INTEGER NOT NULL REFERENCES lot_recalls(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		patient_name VARCHAR(255) NOT NULL,
+		phone VARCHAR(20),
+		message TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		sent_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create recall tables: %w", err)
+	}
+
+	log.Println("Recall tables created successfully")
+	return nil
+}
+
+// CreateColdChainTables creates the fridge, temperature log and lot review tables
+func (db *DB) CreateColdChainTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS fridges (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		location VARCHAR(255),
+		min_temp NUMERIC(4, 1) NOT NULL DEFAULT 2.0,
+		max_temp NUMERIC(4, 1) NOT NULL DEFAULT 8.0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS fridge_lots (
+		fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+		item_id INTEGER NOT NULL,
+		item_name VARCHAR(255) NOT NULL,
+		lot_number VARCHAR(50) NOT NULL,
+		PRIMARY KEY (fridge_id, item_id, lot_number)
+	);
+
+	CREATE TABLE IF NOT EXISTS temperature_readings (
+		id SERIAL PRIMARY KEY,
+		fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+		celsius NUMERIC(4, 1) NOT NULL,
+		source VARCHAR(20) NOT NULL,
+		recorded_by VARCHAR(100),
+		out_of_range BOOLEAN NOT NULL DEFAULT FALSE,
+		recorded_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS temperature_excursions (
+		id SERIAL PRIMARY KEY,
+		fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+		started_at TIMESTAMP NOT NULL,
+		ended_at TIMESTAMP,
+		min_celsius NUMERIC(4, 1) NOT NULL,
+		max_celsius NUMERIC(4, 1) NOT NULL,
+		reading_count INTEGER NOT NULL DEFAULT 1
+	);
+
+	CREATE TABLE IF NOT EXISTS lot_reviews (
+		id SERIAL PRIMARY KEY,
+		excursion_id INTEGER NOT NULL REFERENCES temperature_excursions(id),
+		fridge_id INTEGER NOT NULL REFERENCES fridges(id),
+		item_id INTEGER NOT NULL,
+		item_name VARCHAR(255) NOT NULL,
+		lot_number VARCHAR(50) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		reviewed_by VARCHAR(100),
+		notes TEXT,
+		reviewed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create cold-chain tables: %w", err)
+	}
+
+	log.Println("Cold-chain tables created successfully")
+	return nil
+}
+
+// CreateSignatureTables creates the signing key and prescription signature tables
+func (db *DB) CreateSignatureTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS signing_keys (
+		id SERIAL PRIMARY KEY,
+		doctor_id INTEGER NOT NULL,
+		doctor_name VARCHAR(255) NOT NULL,
+		license_number VARCHAR(50) NOT NULL,
+		public_key BYTEA NOT NULL,
+		sealed_key BYTEA NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		revoked_at TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS prescription_signatures (
+		id SERIAL PRIMARY KEY,
+		prescription_id INTEGER NOT NULL,
+		key_id INTEGER NOT NULL REFERENCES signing_keys(id),
+		doctor_name VARCHAR(255) NOT NULL,
+		license_number VARCHAR(50) NOT NULL,
+		document_hash CHAR(64) NOT NULL,
+		signature BYTEA NOT NULL,
+		verification_code VARCHAR(20) UNIQUE NOT NULL,
+		signed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create signature tables: %w", err)
+	}
+
+	log.Println("Signature tables created successfully")
+	return nil
+}
+
+// CreateCertificateTables creates the medical certificate registry tables
+func (db *DB) CreateCertificateTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS certificate_sequences (
+		year INTEGER PRIMARY KEY,
+		last_number INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS medical_certificates (
+		id SERIAL PRIMARY KEY,
+		certificate_number VARCHAR(20) UNIQUE NOT NULL,
+		patient_hn VARCHAR(10) NOT NULL,
+		doctor_name VARCHAR(255) NOT NULL,
+		license_number VARCHAR(50) NOT NULL,
+		diagnosis TEXT,
+		recommendation TEXT,
+		rest_from DATE,
+		rest_to DATE,
+		content_hash CHAR(64) NOT NULL,
+		verification_code VARCHAR(20) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'valid',
+		revoke_reason TEXT,
+		revoked_at TIMESTAMP,
+		issued_at TIMESTAMP NOT NULL
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate tables: %w", err)
+	}
+
+	log.Println("Certificate tables created successfully")
+	return nil
+}
+
+// CreateClinicalNotesTable creates the clinical notes table
+func (db *DB) CreateClinicalNotesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS clinical_notes (
+		id SERIAL PRIMARY KEY,
+		visit_id INTEGER NOT NULL,
+		patient_hn VARCHAR(10) NOT NULL,
+		author_name VARCHAR(255) NOT NULL,
+		author_role VARCHAR(20) NOT NULL,
+		content TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		supervisor_name VARCHAR(255),
+		cosigned_by VARCHAR(255),
+		cosigner_license VARCHAR(50),
+		cosigned_at TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1,
+		amended_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_clinical_notes_pending ON clinical_notes (status) WHERE status = 'pending_cosign';
+
+	CREATE TABLE IF NOT EXISTS clinical_note_versions (
+		note_id INTEGER NOT NULL REFERENCES clinical_notes(id),
+		version INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		author_name VARCHAR(255) NOT NULL,
+		reason TEXT,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (note_id, version)
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create clinical notes table: %w", err)
+	}
+
+	log.Println("Clinical notes table created successfully")
+	return nil
+}
+
+// CreateFormTables creates the structured form definition, submission and answer tables
+func (db *DB) CreateFormTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS form_definitions (
+		id SERIAL PRIMARY KEY,
+		clinic_id INTEGER NOT NULL DEFAULT 1,
+		code VARCHAR(50) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		specialty VARCHAR(50) NOT NULL DEFAULT '',
+		version INTEGER NOT NULL DEFAULT 1,
+		fields JSONB NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (clinic_id, code)
+	);
+
+	CREATE TABLE IF NOT EXISTS form_submissions (
+		id SERIAL PRIMARY KEY,
+		form_id INTEGER NOT NULL REFERENCES form_definitions(id),
+		form_version INTEGER NOT NULL,
+		visit_id INTEGER NOT NULL,
+		patient_hn VARCHAR(10) NOT NULL,
+		answers JSONB NOT NULL,
+		submitted_by VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS form_answers (
+		submission_id INTEGER NOT NULL REFERENCES form_submissions(id) ON DELETE CASCADE,
+		form_id INTEGER NOT NULL,
+		visit_id INTEGER NOT NULL,
+		patient_hn VARCHAR(10) NOT NULL,
+		field_key VARCHAR(50) NOT NULL,
+		value_text TEXT,
+		value_number NUMERIC,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_form_submissions_visit ON form_submissions (visit_id);
+	CREATE INDEX IF NOT EXISTS idx_form_answers_field ON form_answers (form_id, field_key, value_text);
+	CREATE INDEX IF NOT EXISTS idx_form_answers_number ON form_answers (form_id, field_key, value_number)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create form tables: %w", err)
+	}
+
+	log.Println("Form tables created successfully")
+	return nil
+}
+
+// CreateCarePlanTables creates the care plan goal and checkpoint tables
+func (db *DB) CreateCarePlanTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS care_plan_goals (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		metric VARCHAR(50) NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		unit VARCHAR(20) NOT NULL DEFAULT '',
+		baseline NUMERIC NOT NULL,
+		target NUMERIC NOT NULL,
+		start_date DATE NOT NULL,
+		target_date DATE NOT NULL,
+		check_every_days INTEGER NOT NULL DEFAULT 0,
+		status VARCHAR(20) NOT NULL DEFAULT 'active',
+		created_by VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS goal_checkpoints (
+		id SERIAL PRIMARY KEY,
+		goal_id INTEGER NOT NULL REFERENCES care_plan_goals(id) ON DELETE CASCADE,
+		value NUMERIC NOT NULL,
+		source VARCHAR(20) NOT NULL,
+		measured_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_care_plan_goals_patient ON care_plan_goals (patient_hn);
+	CREATE INDEX IF NOT EXISTS idx_goal_checkpoints_goal ON goal_checkpoints (goal_id, measured_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create care plan tables: %w", err)
+	}
+
+	log.Println("Care plan tables created successfully")
+	return nil
+}
+
+// CreateGroupSessionTables creates the group session and booking tables
+func (db *DB) CreateGroupSessionTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS group_sessions (
+		id SERIAL PRIMARY KEY,
+		title VARCHAR(255) NOT NULL,
+		session_type VARCHAR(50) NOT NULL,
+		starts_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP NOT NULL,
+		location VARCHAR(255) NOT NULL DEFAULT '',
+		facilitator VARCHAR(255) NOT NULL DEFAULT '',
+		capacity INTEGER NOT NULL CHECK (capacity > 0),
+		status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+		campaign_id INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS group_bookings (
+		id SERIAL PRIMARY KEY,
+		session_id INTEGER NOT NULL REFERENCES group_sessions(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'booked',
+		visit_id INTEGER,
+		booked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		checked_in_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_group_sessions_starts_at ON group_sessions (starts_at);
+	CREATE INDEX IF NOT EXISTS idx_group_sessions_campaign ON group_sessions (campaign_id);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_group_bookings_active ON group_bookings (session_id, patient_hn) WHERE status <> 'cancelled'`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create group session tables: %w", err)
+	}
+
+	log.Println("Group session tables created successfully")
+	return nil
+}
+
+// CreateCampaignTables creates the campaign and pre-registration tables
+func (db *DB) CreateCampaignTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS campaigns (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		session_type VARCHAR(50) NOT NULL,
+		location VARCHAR(255) NOT NULL DEFAULT '',
+		start_date DATE NOT NULL,
+		end_date DATE NOT NULL,
+		daily_start VARCHAR(5) NOT NULL,
+		daily_end VARCHAR(5) NOT NULL,
+		slot_minutes INTEGER NOT NULL,
+		slot_capacity INTEGER NOT NULL,
+		skip_weekends BOOLEAN NOT NULL DEFAULT FALSE,
+		status VARCHAR(20) NOT NULL DEFAULT 'draft',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS campaign_registrations (
+		id SERIAL PRIMARY KEY,
+		campaign_id INTEGER NOT NULL REFERENCES campaigns(id),
+		patient_hn VARCHAR(10),
+		full_name VARCHAR(255) NOT NULL DEFAULT '',
+		phone VARCHAR(20),
+		preferred_date DATE,
+		status VARCHAR(20) NOT NULL,
+		session_id INTEGER,
+		booking_id INTEGER,
+		imported_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_campaign_registrations_campaign ON campaign_registrations (campaign_id)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create campaign tables: %w", err)
+	}
+
+	log.Println("Campaign tables created successfully")
+	return nil
+}
+
+// CreateInterpreterTables creates the patient language, interpreter and interpreter booking tables
+func (db *DB) CreateInterpreterTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_languages (
+		patient_hn VARCHAR(10) PRIMARY KEY,
+		preferred_language VARCHAR(10) NOT NULL,
+		interpreter_required BOOLEAN NOT NULL DEFAULT FALSE,
+		notes TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS interpreters (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		languages VARCHAR(255) NOT NULL,
+		phone VARCHAR(20),
+		remote BOOLEAN NOT NULL DEFAULT FALSE,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS interpreter_bookings (
+		id SERIAL PRIMARY KEY,
+		interpreter_id INTEGER NOT NULL REFERENCES interpreters(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		appointment_id INTEGER,
+		language VARCHAR(10) NOT NULL,
+		starts_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'booked',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_interpreter_bookings_starts_at ON interpreter_bookings (starts_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create interpreter tables: %w", err)
+	}
+
+	log.Println("Interpreter tables created successfully")
+	return nil
+}
+
+// CreateAccessibilityTable creates the patient accessibility table
+func (db *DB) CreateAccessibilityTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_accessibility (
+		patient_hn VARCHAR(10) PRIMARY KEY,
+		needs VARCHAR(255) NOT NULL DEFAULT '',
+		notes TEXT,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create patient accessibility table: %w", err)
+	}
+
+	log.Println("Patient accessibility table created successfully")
+	return nil
+}
+
+// CreateQuestionnaireTable creates the patient-reported outcome questionnaire table
+func (db *DB) CreateQuestionnaireTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS questionnaire_requests (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		visit_id INTEGER,
+		instrument VARCHAR(20) NOT NULL,
+		timing VARCHAR(20) NOT NULL,
+		token VARCHAR(64) NOT NULL UNIQUE,
+		channel VARCHAR(20),
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		expires_at TIMESTAMP NOT NULL,
+		sent_at TIMESTAMP,
+		completed_at TIMESTAMP,
+		answers VARCHAR(255) NOT NULL DEFAULT '',
+		score INTEGER,
+		severity VARCHAR(30),
+		high_risk BOOLEAN NOT NULL DEFAULT FALSE,
+		risk_reasons TEXT,
+		acknowledged_by VARCHAR(100),
+		acknowledged_at TIMESTAMP,
+		created_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_questionnaire_requests_patient ON questionnaire_requests (patient_hn);
+	CREATE INDEX IF NOT EXISTS idx_questionnaire_requests_visit ON questionnaire_requests (visit_id);
+	CREATE INDEX IF NOT EXISTS idx_questionnaire_requests_open_alerts ON questionnaire_requests (completed_at)
+		WHERE high_risk AND acknowledged_at IS NULL`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create questionnaire table: %w", err)
+	}
+
+	log.Println("Questionnaire table created successfully")
+	return nil
+}
+
+// CreateNoteDraftsTable creates the SOAP note draft table
+func (db *DB) CreateNoteDraftsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS note_drafts (
+		id SERIAL PRIMARY KEY,
+		visit_id INTEGER NOT NULL,
+		patient_hn VARCHAR(10) NOT NULL,
+		author_name VARCHAR(100) NOT NULL,
+		author_role VARCHAR(20) NOT NULL,
+		supervisor_name VARCHAR(100),
+		subjective TEXT NOT NULL DEFAULT '',
+		objective TEXT NOT NULL DEFAULT '',
+		assessment TEXT NOT NULL DEFAULT '',
+		plan TEXT NOT NULL DEFAULT '',
+		revision INTEGER NOT NULL DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (visit_id, author_name)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_note_drafts_author ON note_drafts (author_name, updated_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create note drafts table: %w", err)
+	}
+
+	log.Println("Note drafts table created successfully")
+	return nil
+}
+
+// CreateDiagnosisCodesTable creates the visit diagnosis code table
+func (db *DB) CreateDiagnosisCodesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS visit_diagnoses (
+		id SERIAL PRIMARY KEY,
+		visit_id INTEGER NOT NULL,
+		patient_hn VARCHAR(10) NOT NULL,
+		code VARCHAR(10) NOT NULL,
+		description VARCHAR(255) NOT NULL,
+		diagnosis_text TEXT,
+		is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+		suggested BOOLEAN NOT NULL DEFAULT FALSE,
+		confirmed_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (visit_id, code)
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_visit_diagnoses_primary ON visit_diagnoses (visit_id) WHERE is_primary`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create visit diagnoses table: %w", err)
+	}
+
+	log.Println("Visit diagnoses table created successfully")
+	return nil
+}
+
+// CreatePrescriptionFavoriteTables creates the doctor drug favorite and prescription set tables
+func (db *DB) CreatePrescriptionFavoriteTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS drug_favorites (
+		id SERIAL PRIMARY KEY,
+		doctor_id INTEGER NOT NULL,
+		drug_id INTEGER,
+		drug_name VARCHAR(255) NOT NULL,
+		dose VARCHAR(100) NOT NULL,
+		frequency VARCHAR(255) NOT NULL,
+		duration_days INTEGER,
+		quantity NUMERIC(10,2),
+		instructions TEXT,
+		usage_count INTEGER NOT NULL DEFAULT 0,
+		last_used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_drug_favorites_doctor ON drug_favorites (doctor_id);
+
+	CREATE TABLE IF NOT EXISTS prescription_sets (
+		id SERIAL PRIMARY KEY,
+		doctor_id INTEGER NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		items JSONB NOT NULL,
+		usage_count INTEGER NOT NULL DEFAULT 0,
+		last_used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (doctor_id, name)
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create prescription favorite tables: %w", err)
+	}
+
+	log.Println("Prescription favorite tables created successfully")
+	return nil
+}
+
+// CreateCoordinationTables creates the lease and sequence tables shared by all API instances
+func (db *DB) CreateCoordinationTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS coordination_leases (
+		name VARCHAR(100) PRIMARY KEY,
+		holder VARCHAR(255) NOT NULL,
+		token BIGINT NOT NULL,
+		acquired_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS coordination_sequences (
+		name VARCHAR(100) PRIMARY KEY,
+		last_value BIGINT NOT NULL
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create coordination tables: %w", err)
+	}
+
+	log.Println("Coordination tables created successfully")
+	return nil
+}
+
+// CreateDoctorsTable creates the doctors table
+func (db *DB) CreateDoctorsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS doctors (
+		id SERIAL PRIMARY KEY,
+		full_name VARCHAR(255) NOT NULL,
+		specialty VARCHAR(100) NOT NULL,
+		license_number VARCHAR(50) NOT NULL UNIQUE,
+		working_days VARCHAR(50) NOT NULL DEFAULT '',
+		phone VARCHAR(20),
+		email VARCHAR(255),
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		license_expires_on DATE,
+		license_reminded_for DATE
+	);
+
+	ALTER TABLE doctors ADD COLUMN IF NOT EXISTS license_expires_on DATE;
+	ALTER TABLE doctors ADD COLUMN IF NOT EXISTS license_reminded_for DATE;
+	CREATE INDEX IF NOT EXISTS idx_doctors_license_expires_on ON doctors (license_expires_on) WHERE active`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create doctors table: %w", err)
+	}
+
+	log.Println("Doctors table created successfully")
+	return nil
+}
+
+// CreateAppointmentsTable creates the appointments table; run CreateDoctorsTable first
+func (db *DB) CreateAppointmentsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS appointments (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		doctor_id INTEGER REFERENCES doctors(id),
+		doctor_name VARCHAR(255) NOT NULL,
+		starts_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP NOT NULL,
+		type VARCHAR(30) NOT NULL DEFAULT 'consultation',
+		booking_channel VARCHAR(20),
+		sandbox BOOLEAN NOT NULL DEFAULT FALSE,
+		status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+		reason TEXT,
+		notes TEXT,
+		interpreter_required BOOLEAN NOT NULL DEFAULT FALSE,
+		reschedule_count INTEGER NOT NULL DEFAULT 0,
+		cancel_reason_code VARCHAR(50),
+		cancel_reason TEXT,
+		cancelled_at TIMESTAMP,
+		confirmation VARCHAR(20) NOT NULL DEFAULT 'unconfirmed',
+		confirmed_at TIMESTAMP,
+		reminders_sent INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		CHECK (ends_at > starts_at)
+	);
+
+	ALTER TABLE appointments ADD COLUMN IF NOT EXISTS confirmation VARCHAR(20) NOT NULL DEFAULT 'unconfirmed';
+	ALTER TABLE appointments ADD COLUMN IF NOT EXISTS confirmed_at TIMESTAMP;
+	ALTER TABLE appointments ADD COLUMN IF NOT EXISTS reminders_sent INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE appointments ADD COLUMN IF NOT EXISTS cancel_reason_code VARCHAR(50);
+	ALTER TABLE appointments ADD COLUMN IF NOT EXISTS booking_channel VARCHAR(20);
+	ALTER TABLE appointments ADD COLUMN IF NOT EXISTS sandbox BOOLEAN NOT NULL DEFAULT FALSE;
+
+	CREATE INDEX IF NOT EXISTS idx_appointments_starts_at ON appointments (starts_at);
+	CREATE INDEX IF NOT EXISTS idx_appointments_cancelled ON appointments (cancelled_at) WHERE cancelled_at IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_appointments_patient ON appointments (patient_hn)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create appointments table: %w", err)
+	}
+
+	log.Println("Appointments table created successfully")
+	return nil
+}
+
+// CreateEncountersTable creates the visit table; run CreateAppointmentsTable and CreateGroupSessionTables first
+func (db *DB) CreateEncountersTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS encounters (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		appointment_id INTEGER UNIQUE REFERENCES appointments(id),
+		group_session_id INTEGER REFERENCES group_sessions(id),
+		doctor_id INTEGER REFERENCES doctors(id),
+		doctor_name VARCHAR(255) NOT NULL DEFAULT '',
+		chief_complaint TEXT NOT NULL,
+		diagnosis TEXT,
+		diagnosis_code VARCHAR(10),
+		treatment TEXT,
+		subjective TEXT NOT NULL DEFAULT '',
+		objective TEXT NOT NULL DEFAULT '',
+		assessment TEXT NOT NULL DEFAULT '',
+		plan TEXT NOT NULL DEFAULT '',
+		soap_version INTEGER NOT NULL DEFAULT 0,
+		cancel_reason_code VARCHAR(50),
+		cancel_reason TEXT,
+		status VARCHAR(10) NOT NULL DEFAULT 'open',
+		started_at TIMESTAMP NOT NULL,
+		ended_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE encounters ADD COLUMN IF NOT EXISTS diagnosis_code VARCHAR(10);
+	ALTER TABLE encounters ADD COLUMN IF NOT EXISTS subjective TEXT NOT NULL DEFAULT '';
+	ALTER TABLE encounters ADD COLUMN IF NOT EXISTS objective TEXT NOT NULL DEFAULT '';
+	ALTER TABLE encounters ADD COLUMN IF NOT EXISTS assessment TEXT NOT NULL DEFAULT '';
+	ALTER TABLE encounters ADD COLUMN IF NOT EXISTS plan TEXT NOT NULL DEFAULT '';
+	ALTER TABLE encounters ADD COLUMN IF NOT EXISTS soap_version INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE encounters ADD COLUMN IF NOT EXISTS cancel_reason_code VARCHAR(50);
+	ALTER TABLE encounters ADD COLUMN IF NOT EXISTS cancel_reason TEXT;
+
+	CREATE INDEX IF NOT EXISTS idx_encounters_patient ON encounters (patient_hn, started_at DESC);
+
+	CREATE TABLE IF NOT EXISTS encounter_soap_versions (
+		visit_id INTEGER NOT NULL REFERENCES encounters(id),
+		version INTEGER NOT NULL,
+		subjective TEXT NOT NULL,
+		objective TEXT NOT NULL,
+		assessment TEXT NOT NULL,
+		plan TEXT NOT NULL,
+		author_name VARCHAR(255) NOT NULL,
+		reason TEXT,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (visit_id, version)
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create encounters table: %w", err)
+	}
+
+	log.Println("Encounters table created successfully")
+	return nil
+}
+
+// CreatePrescriptionsTable creates the prescriptions table; run CreateEncountersTable first.
+// Prescriptions written before dispensing was tracked count as dispensed.
+func (db *DB) CreatePrescriptionsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS prescriptions (
+		id SERIAL PRIMARY KEY,
+		visit_id INTEGER NOT NULL REFERENCES encounters(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		doctor_id INTEGER NOT NULL REFERENCES doctors(id),
+		doctor_name VARCHAR(255) NOT NULL,
+		license_number VARCHAR(50) NOT NULL,
+		items JSONB NOT NULL,
+		notes TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_prescriptions_visit ON prescriptions (visit_id);
+	CREATE INDEX IF NOT EXISTS idx_prescriptions_patient ON prescriptions (patient_hn, created_at DESC);
+
+	ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS dispense_status VARCHAR(20) NOT NULL DEFAULT 'dispensed';
+	ALTER TABLE prescriptions ALTER COLUMN dispense_status SET DEFAULT 'pending';
+	ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS dispensed_by VARCHAR(100);
+	ALTER TABLE prescriptions ADD COLUMN IF NOT EXISTS dispensed_at TIMESTAMP;
+	CREATE INDEX IF NOT EXISTS idx_prescriptions_pending ON prescriptions (created_at) WHERE dispense_status = 'pending'`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create prescriptions table: %w", err)
+	}
+
+	log.Println("Prescriptions table created successfully")
+	return nil
+}
+
+// CreateDrugsTable creates the drug catalog table
+func (db *DB) CreateDrugsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS drugs (
+		id SERIAL PRIMARY KEY,
+		generic_name VARCHAR(255) NOT NULL,
+		brand_name VARCHAR(255),
+		strength VARCHAR(50) NOT NULL DEFAULT '',
+		unit VARCHAR(30) NOT NULL,
+		default_dose VARCHAR(100),
+		default_frequency VARCHAR(255),
+		price NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+		image_url TEXT,
+		leaflet_url TEXT,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE drugs ADD COLUMN IF NOT EXISTS image_url TEXT;
+	ALTER TABLE drugs ADD COLUMN IF NOT EXISTS leaflet_url TEXT;
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_drugs_name
+		ON drugs (lower(generic_name), lower(strength), lower(COALESCE(brand_name, '')))`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create drugs table: %w", err)
+	}
+
+	log.Println("Drugs table created successfully")
+	return nil
+}
+
+// CreateInventoryTables creates the stock item, lot and movement tables; run CreateDrugsTable first
+func (db *DB) CreateInventoryTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS stock_items (
+		id SERIAL PRIMARY KEY,
+		kind VARCHAR(20) NOT NULL,
+		drug_id INTEGER UNIQUE REFERENCES drugs(id),
+		name VARCHAR(255) NOT NULL,
+		unit VARCHAR(30) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_items_name ON stock_items (lower(name));
+
+	CREATE TABLE IF NOT EXISTS stock_lots (
+		item_id INTEGER NOT NULL REFERENCES stock_items(id),
+		lot_number VARCHAR(50) NOT NULL DEFAULT '',
+		expiry_date DATE,
+		quantity NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+		PRIMARY KEY (item_id, lot_number)
+	);
+
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id SERIAL PRIMARY KEY,
+		item_id INTEGER NOT NULL REFERENCES stock_items(id),
+		type VARCHAR(20) NOT NULL,
+		quantity NUMERIC(12, 2) NOT NULL CHECK (quantity <> 0),
+		lot_number VARCHAR(50) NOT NULL DEFAULT '',
+		expiry_date DATE,
+		patient_hn VARCHAR(10),
+		reference VARCHAR(100),
+		note TEXT,
+		recorded_by VARCHAR(255) NOT NULL,
+		balance_after NUMERIC(12, 2) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_stock_movements_item ON stock_movements (item_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_stock_movements_lot ON stock_movements (item_id, lot_number) WHERE type = 'dispense'`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create inventory tables: %w", err)
+	}
+
+	log.Println("Inventory tables created successfully")
+	return nil
+}
+
+// CreateInvoicesTable creates the invoices table; run CreateEncountersTable first
+func (db *DB) CreateInvoicesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS invoices (
+		id SERIAL PRIMARY KEY,
+		visit_id INTEGER NOT NULL REFERENCES encounters(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'draft',
+		items JSONB NOT NULL,
+		subtotal NUMERIC(12, 2) NOT NULL,
+		discount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (discount >= 0),
+		total NUMERIC(12, 2) NOT NULL CHECK (total >= 0),
+		amount_paid NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (amount_paid <= total),
+		notes TEXT,
+		void_reason TEXT,
+		issued_at TIMESTAMP,
+		paid_at TIMESTAMP,
+		voided_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_visit ON invoices (visit_id) WHERE status <> 'void';
+	CREATE INDEX IF NOT EXISTS idx_invoices_patient ON invoices (patient_hn, created_at DESC)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create invoices table: %w", err)
+	}
+
+	log.Println("Invoices table created successfully")
+	return nil
+}
+
+// CreatePatientMergesTable creates the patient merge log with its undo snapshots
+func (db *DB) CreatePatientMergesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_merges (
+		id SERIAL PRIMARY KEY,
+		source_hn VARCHAR(10) NOT NULL,
+		target_hn VARCHAR(10) NOT NULL,
+		source_snapshot JSONB,
+		target_snapshot JSONB,
+		relinked JSONB NOT NULL DEFAULT '[]',
+		reason TEXT,
+		merged_by VARCHAR(255) NOT NULL,
+		merged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		undo_until TIMESTAMP NOT NULL,
+		undone_by VARCHAR(255),
+		undone_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_patient_merges_source ON patient_merges (source_hn);
+	CREATE INDEX IF NOT EXISTS idx_patient_merges_target ON patient_merges (target_hn)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create patient merges table: %w", err)
+	}
+
+	log.Println("Patient merges table created successfully")
+	return nil
+}
+
+// CreateAppointmentDisplayTable creates the per-clinic appointment type and status styles
+func (db *DB) CreateAppointmentDisplayTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS appointment_display (
+		scope VARCHAR(10) NOT NULL,
+		key VARCHAR(30) NOT NULL,
+		color CHAR(7) NOT NULL,
+		icon VARCHAR(50) NOT NULL,
+		label VARCHAR(50) NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (scope, key)
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create appointment display table: %w", err)
+	}
+
+	log.Println("Appointment display table created successfully")
+	return nil
+}
+
+// CreatePaymentsTable creates the payments table; run CreateInvoicesTable first
+func (db *DB) CreatePaymentsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS payments (
+		id SERIAL PRIMARY KEY,
+		invoice_id INTEGER NOT NULL REFERENCES invoices(id),
+		method VARCHAR(20) NOT NULL,
+		amount NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+		reference VARCHAR(100),
+		note TEXT,
+		received_by VARCHAR(100) NOT NULL,
+		paid_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_payments_invoice ON payments (invoice_id, paid_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create payments table: %w", err)
+	}
+
+	log.Println("Payments table created successfully")
+	return nil
+}
+
+// CreateInsuranceTables creates the insurance policy and claim tables; run CreateInvoicesTable first
+func (db *DB) CreateInsuranceTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS insurance_policies (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		insurer VARCHAR(100) NOT NULL,
+		policy_number VARCHAR(50) NOT NULL,
+		plan_name VARCHAR(100),
+		coverage_limit NUMERIC(12, 2) CHECK (coverage_limit > 0),
+		valid_from DATE NOT NULL,
+		valid_to DATE CHECK (valid_to >= valid_from),
+		notes TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (insurer, policy_number)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_insurance_policies_patient ON insurance_policies (patient_hn);
+
+	CREATE TABLE IF NOT EXISTS insurance_claims (
+		id SERIAL PRIMARY KEY,
+		invoice_id INTEGER NOT NULL REFERENCES invoices(id),
+		policy_id INTEGER NOT NULL REFERENCES insurance_policies(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		insurer VARCHAR(100) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'submitted',
+		claimed_amount NUMERIC(12, 2) NOT NULL CHECK (claimed_amount > 0),
+		approved_amount NUMERIC(12, 2) CHECK (approved_amount <= claimed_amount),
+		paid_amount NUMERIC(12, 2) CHECK (paid_amount <= approved_amount),
+		rejection_reason TEXT,
+		payer_reference VARCHAR(100),
+		notes TEXT,
+		submitted_by VARCHAR(100) NOT NULL,
+		submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		decided_at TIMESTAMP,
+		paid_at TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_insurance_claims_invoice ON insurance_claims (invoice_id) WHERE status <> 'rejected';
+	CREATE INDEX IF NOT EXISTS idx_insurance_claims_status ON insurance_claims (status, submitted_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create insurance tables: %w", err)
+	}
+
+	log.Println("Insurance tables created successfully")
+	return nil
+}
+
+// CreateHandoverNotesTable creates the department shift handover notes table
+func (db *DB) CreateHandoverNotesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS handover_notes (
+		id SERIAL PRIMARY KEY,
+		department VARCHAR(30) NOT NULL,
+		shift_date DATE NOT NULL,
+		category VARCHAR(20) NOT NULL DEFAULT 'other',
+		patient_hn VARCHAR(10),
+		body TEXT NOT NULL,
+		author VARCHAR(100) NOT NULL,
+		resolved_by VARCHAR(100),
+		resolved_at TIMESTAMP,
+		archived_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_handover_notes_live ON handover_notes (department, created_at) WHERE archived_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_handover_notes_date ON handover_notes (department, shift_date)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create handover notes table: %w", err)
+	}
+
+	log.Println("Handover notes table created successfully")
+	return nil
+}
+
+// CreateTasksTable creates the staff tasks table
+func (db *DB) CreateTasksTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS tasks (
+		id SERIAL PRIMARY KEY,
+		title VARCHAR(200) NOT NULL,
+		details TEXT,
+		assigned_to VARCHAR(100) NOT NULL,
+		patient_hn VARCHAR(10),
+		due_date DATE,
+		status VARCHAR(20) NOT NULL DEFAULT 'open',
+		created_by VARCHAR(100) NOT NULL,
+		completed_by VARCHAR(100),
+		completed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tasks_assignee ON tasks (lower(assigned_to), status, due_date);
+	CREATE INDEX IF NOT EXISTS idx_tasks_patient ON tasks (patient_hn) WHERE patient_hn IS NOT NULL`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create tasks table: %w", err)
+	}
+
+	log.Println("Tasks table created successfully")
+	return nil
+}
+
+// CreateVitalSignsTable creates the vital signs table; run CreateEncountersTable first
+func (db *DB) CreateVitalSignsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS vital_signs (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		visit_id INTEGER REFERENCES encounters(id),
+		systolic_bp INTEGER,
+		diastolic_bp INTEGER,
+		pulse INTEGER,
+		temperature NUMERIC(4, 1),
+		weight NUMERIC(5, 1),
+		height NUMERIC(4, 1),
+		spo2 INTEGER CHECK (spo2 <= 100),
+		notes TEXT,
+		measured_at TIMESTAMP NOT NULL,
+		recorded_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_vital_signs_patient ON vital_signs (patient_hn, measured_at);
+	CREATE INDEX IF NOT EXISTS idx_vital_signs_visit ON vital_signs (visit_id) WHERE visit_id IS NOT NULL`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create vital signs table: %w", err)
+	}
+
+	log.Println("Vital signs table created successfully")
+	return nil
+}
+
+// CreatePatientAllergiesTable creates the patient allergies table
+func (db *DB) CreatePatientAllergiesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_allergies (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		allergen VARCHAR(200) NOT NULL,
+		reaction TEXT,
+		severity VARCHAR(20) NOT NULL CHECK (severity IN ('mild', 'moderate', 'severe', 'life_threatening')),
+		noted_date DATE NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'inactive')),
+		notes TEXT,
+		recorded_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_patient_allergies_patient ON patient_allergies (patient_hn, status)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create patient allergies table: %w", err)
+	}
+
+	log.Println("Patient allergies table created successfully")
+	return nil
+}
+
+// CreateChatTables creates the staff chat thread, message, mention and read receipt tables; run CreateEncountersTable first
+func (db *DB) CreateChatTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS chat_threads (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		visit_id INTEGER REFERENCES encounters(id),
+		subject VARCHAR(200) NOT NULL,
+		created_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_message_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_chat_threads_patient ON chat_threads (patient_hn, last_message_at);
+	CREATE INDEX IF NOT EXISTS idx_chat_threads_visit ON chat_threads (visit_id) WHERE visit_id IS NOT NULL;
+
+	CREATE TABLE IF NOT EXISTS chat_messages (
+		id SERIAL PRIMARY KEY,
+		thread_id INTEGER NOT NULL REFERENCES chat_threads(id) ON DELETE CASCADE,
+		author VARCHAR(100) NOT NULL,
+		body TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_chat_messages_thread ON chat_messages (thread_id, id);
+
+	CREATE TABLE IF NOT EXISTS chat_mentions (
+		message_id INTEGER NOT NULL REFERENCES chat_messages(id) ON DELETE CASCADE,
+		staff_name VARCHAR(100) NOT NULL,
+		PRIMARY KEY (message_id, staff_name)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_chat_mentions_staff ON chat_mentions (LOWER(staff_name));
+
+	CREATE TABLE IF NOT EXISTS chat_reads (
+		thread_id INTEGER NOT NULL REFERENCES chat_threads(id) ON DELETE CASCADE,
+		reader VARCHAR(100) NOT NULL,
+		last_read_message_id INTEGER NOT NULL,
+		read_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (thread_id, reader)
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create chat tables: %w", err)
+	}
+
+	log.Println("Chat tables created successfully")
+	return nil
+}
+
+// CreateAnnouncementsTables creates the announcements and acknowledgments tables
+func (db *DB) CreateAnnouncementsTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS announcements (
+		id SERIAL PRIMARY KEY,
+		title VARCHAR(200) NOT NULL,
+		body TEXT NOT NULL,
+		priority VARCHAR(20) NOT NULL DEFAULT 'info' CHECK (priority IN ('info', 'important', 'urgent')),
+		requires_ack BOOLEAN NOT NULL DEFAULT FALSE,
+		publish_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP CHECK (expires_at > publish_at),
+		created_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_announcements_window ON announcements (publish_at, expires_at);
+
+	CREATE TABLE IF NOT EXISTS announcement_acks (
+		announcement_id INTEGER NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+		staff_name VARCHAR(100) NOT NULL,
+		acknowledged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (announcement_id, staff_name)
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create announcements tables: %w", err)
+	}
+
+	log.Println("Announcements tables created successfully")
+	return nil
+}
+
+// CreateVaccinationsTable creates the vaccinations table; run CreateInventoryTables first
+func (db *DB) CreateVaccinationsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS vaccinations (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		vaccine VARCHAR(50) NOT NULL,
+		dose_number INTEGER NOT NULL CHECK (dose_number > 0),
+		lot_number VARCHAR(50) NOT NULL,
+		stock_item_id INTEGER REFERENCES stock_items(id),
+		administered_date DATE NOT NULL,
+		administered_by VARCHAR(100) NOT NULL,
+		site VARCHAR(50),
+		notes TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE vaccinations ADD COLUMN IF NOT EXISTS stock_item_id INTEGER REFERENCES stock_items(id);
+
+	CREATE INDEX IF NOT EXISTS idx_vaccinations_patient ON vaccinations (patient_hn, administered_date);
+	CREATE INDEX IF NOT EXISTS idx_vaccinations_lot ON vaccinations (vaccine, lot_number)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create vaccinations table: %w", err)
+	}
+
+	log.Println("Vaccinations table created successfully")
+	return nil
+}
+
+// CreateReferralsTables creates the referrals and referral documents tables; run CreateEncountersTable first
+func (db *DB) CreateReferralsTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS referrals (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		visit_id INTEGER REFERENCES encounters(id),
+		direction VARCHAR(10) NOT NULL CHECK (direction IN ('outbound', 'inbound')),
+		facility VARCHAR(255) NOT NULL,
+		department VARCHAR(100),
+		reason TEXT NOT NULL,
+		urgency VARCHAR(10) NOT NULL DEFAULT 'routine',
+		referred_by VARCHAR(255) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		outcome TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		closed_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_referrals_patient ON referrals (patient_hn, created_at);
+	CREATE INDEX IF NOT EXISTS idx_referrals_open ON referrals (direction, status) WHERE closed_at IS NULL;
+
+	CREATE TABLE IF NOT EXISTS referral_documents (
+		id SERIAL PRIMARY KEY,
+		referral_id INTEGER NOT NULL REFERENCES referrals(id) ON DELETE CASCADE,
+		name VARCHAR(255) NOT NULL,
+		url TEXT NOT NULL,
+		added_by VARCHAR(100) NOT NULL,
+		added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create referral tables: %w", err)
+	}
+
+	log.Println("Referral tables created successfully")
+	return nil
+}
+
+// CreateBranchesTable creates the branch settings table
+func (db *DB) CreateBranchesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS branches (
+		id VARCHAR(64) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		timezone VARCHAR(64) NOT NULL,
+		hours JSONB NOT NULL DEFAULT '[]',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE branches ADD COLUMN IF NOT EXISTS walk_in_assignment VARCHAR(20) NOT NULL DEFAULT ''`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create branches table: %w", err)
+	}
+
+	log.Println("Branches table created successfully")
+	return nil
+}
+
+// CreateQueueTables creates the queue entry and queue number tables; run CreateEncountersTable, CreateAppointmentsTable and CreateDoctorsTable first
+func (db *DB) CreateQueueTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS queue_sequences (
+		branch VARCHAR(64) NOT NULL,
+		service_point VARCHAR(50) NOT NULL,
+		queue_date DATE NOT NULL,
+		last_number INTEGER NOT NULL,
+		PRIMARY KEY (branch, service_point, queue_date)
+	);
+
+	CREATE TABLE IF NOT EXISTS queue_entries (
+		id SERIAL PRIMARY KEY,
+		branch VARCHAR(64) NOT NULL DEFAULT '',
+		service_point VARCHAR(50) NOT NULL,
+		queue_date DATE NOT NULL,
+		number INTEGER NOT NULL,
+		patient_hn VARCHAR(10) NOT NULL,
+		patient_name VARCHAR(255) NOT NULL,
+		visit_id INTEGER REFERENCES encounters(id),
+		appointment_id INTEGER REFERENCES appointments(id),
+		status VARCHAR(20) NOT NULL DEFAULT 'waiting',
+		counter VARCHAR(50),
+		called_by VARCHAR(100),
+		checked_in_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		called_at TIMESTAMP,
+		finished_at TIMESTAMP,
+		UNIQUE (branch, service_point, queue_date, number)
+	);
+
+	ALTER TABLE queue_entries ADD COLUMN IF NOT EXISTS urgency VARCHAR(20);
+	ALTER TABLE queue_entries ADD COLUMN IF NOT EXISTS status_token VARCHAR(64) UNIQUE;
+	ALTER TABLE queue_entries ADD COLUMN IF NOT EXISTS doctor_id INTEGER REFERENCES doctors(id);
+	ALTER TABLE queue_entries ADD COLUMN IF NOT EXISTS doctor_name VARCHAR(255) NOT NULL DEFAULT '';
+
+	CREATE INDEX IF NOT EXISTS idx_queue_entries_active ON queue_entries (branch, queue_date, service_point, number)
+		WHERE status IN ('waiting', 'in_progress')`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create queue tables: %w", err)
+	}
+
+	log.Println("Queue tables created successfully")
+	return nil
+}
+
+// CreateAppointmentRemindersTable creates the appointment reminder and delivery failure tables; run CreateAppointmentsTable and CreateTasksTable first
+func (db *DB) CreateAppointmentRemindersTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS appointment_reminders (
+		id SERIAL PRIMARY KEY,
+		appointment_id INTEGER NOT NULL REFERENCES appointments(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		step INTEGER NOT NULL,
+		channel VARCHAR(10) NOT NULL,
+		phone VARCHAR(20),
+		message TEXT NOT NULL,
+		task_id INTEGER REFERENCES tasks(id),
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		sent_at TIMESTAMP,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		retry_at TIMESTAMP,
+		sandbox BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (appointment_id, step)
+	);
+
+	ALTER TABLE appointment_reminders ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE appointment_reminders ADD COLUMN IF NOT EXISTS last_error TEXT;
+	ALTER TABLE appointment_reminders ADD COLUMN IF NOT EXISTS retry_at TIMESTAMP;
+	ALTER TABLE appointment_reminders ADD COLUMN IF NOT EXISTS sandbox BOOLEAN NOT NULL DEFAULT FALSE;
+
+	CREATE INDEX IF NOT EXISTS idx_appointment_reminders_pending ON appointment_reminders (channel, created_at)
+		WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS idx_appointment_reminders_failed ON appointment_reminders (created_at)
+		WHERE status = 'failed';
+
+	CREATE TABLE IF NOT EXISTS notification_failures (
+		id SERIAL PRIMARY KEY,
+		reminder_id INTEGER NOT NULL REFERENCES appointment_reminders(id),
+		attempt INTEGER NOT NULL,
+		provider VARCHAR(50),
+		error_code VARCHAR(100),
+		error_message TEXT NOT NULL,
+		failed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_notification_failures_reminder ON notification_failures (reminder_id);
+	CREATE INDEX IF NOT EXISTS idx_notification_failures_failed_at ON notification_failures (failed_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create appointment reminders table: %w", err)
+	}
+
+	log.Println("Appointment reminders table created successfully")
+	return nil
+}
+
+// CreateRosterTables creates the doctor roster and day-off tables; run CreateDoctorsTable first
+func (db *DB) CreateRosterTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS doctor_rosters (
+		doctor_id INTEGER PRIMARY KEY REFERENCES doctors(id),
+		shifts JSONB NOT NULL DEFAULT '[]',
+		updated_by VARCHAR(100) NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS doctor_days_off (
+		id SERIAL PRIMARY KEY,
+		doctor_id INTEGER NOT NULL REFERENCES doctors(id),
+		day_off DATE NOT NULL,
+		reason TEXT,
+		created_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (doctor_id, day_off)
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create roster tables: %w", err)
+	}
+
+	log.Println("Roster tables created successfully")
+	return nil
+}
+
+// CreateReminderRepliesTable creates the inbound reminder reply table; run CreateAppointmentsTable first
+func (db *DB) CreateReminderRepliesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS reminder_replies (
+		id SERIAL PRIMARY KEY,
+		channel VARCHAR(10) NOT NULL,
+		phone VARCHAR(20) NOT NULL,
+		text TEXT NOT NULL,
+		intent VARCHAR(10) NOT NULL,
+		appointment_id INTEGER REFERENCES appointments(id),
+		patient_hn VARCHAR(10),
+		status VARCHAR(20) NOT NULL,
+		note TEXT,
+		resolved_by VARCHAR(100),
+		resolved_at TIMESTAMP,
+		received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_reminder_replies_review ON reminder_replies (received_at) WHERE status = 'review'`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create reminder replies table: %w", err)
+	}
+
+	log.Println("Reminder replies table created successfully")
+	return nil
+}
+
+// CreatePatientProblemsTable creates the patient problem list table
+func (db *DB) CreatePatientProblemsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_problems (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		condition VARCHAR(200) NOT NULL,
+		onset_date DATE,
+		status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'resolved')),
+		resolved_date DATE,
+		notes TEXT,
+		recorded_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_patient_problems_patient ON patient_problems (patient_hn, status)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create patient problems table: %w", err)
+	}
+
+	log.Println("Patient problems table created successfully")
+	return nil
+}
+
+// CreatePatientFieldRulesTable creates the table of patient fields the clinic requires
+func (db *DB) CreatePatientFieldRulesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_field_rules (
+		field VARCHAR(30) PRIMARY KEY,
+		required BOOLEAN NOT NULL,
+		updated_by VARCHAR(100),
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create patient field rules table: %w", err)
+	}
+
+	log.Println("Patient field rules table created successfully")
+	return nil
+}
+
+// CreateAppointmentOverridesTable creates the audit table of bookings forced past the duplicate guard; run CreateAppointmentsTable first
+func (db *DB) CreateAppointmentOverridesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS appointment_overrides (
+		id SERIAL PRIMARY KEY,
+		appointment_id INTEGER NOT NULL REFERENCES appointments(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		conflicts_with TEXT NOT NULL,
+		detail TEXT NOT NULL,
+		reason TEXT,
+		overridden_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_appointment_overrides_created ON appointment_overrides (created_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create appointment overrides table: %w", err)
+	}
+
+	log.Println("Appointment overrides table created successfully")
+	return nil
+}
+
+// CreateServicesTable creates the billable service catalog and the services
+// recorded against visits, and links appointments to the service booked; run
+// CreateEncountersTable and CreateAppointmentsTable first
+func (db *DB) CreateServicesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS services (
+		id SERIAL PRIMARY KEY,
+		code VARCHAR(30) NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		category VARCHAR(20) NOT NULL,
+		price NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_services_code ON services (lower(code));
+
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS min_age INTEGER;
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS max_age INTEGER;
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS genders TEXT NOT NULL DEFAULT '';
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS prerequisite_service_id INTEGER REFERENCES services(id);
+	ALTER TABLE services ADD COLUMN IF NOT EXISTS prerequisite_within_days INTEGER;
+
+	CREATE TABLE IF NOT EXISTS visit_services (
+		id SERIAL PRIMARY KEY,
+		visit_id INTEGER NOT NULL REFERENCES encounters(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		service_id INTEGER NOT NULL REFERENCES services(id),
+		name VARCHAR(255) NOT NULL,
+		quantity NUMERIC(10, 2) NOT NULL CHECK (quantity > 0),
+		unit_price NUMERIC(10, 2) NOT NULL CHECK (unit_price >= 0),
+		notes TEXT,
+		recorded_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_visit_services_visit ON visit_services (visit_id);
+	CREATE INDEX IF NOT EXISTS idx_visit_services_patient ON visit_services (patient_hn, service_id);
+
+	ALTER TABLE appointments ADD COLUMN IF NOT EXISTS service_id INTEGER REFERENCES services(id)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create services tables: %w", err)
+	}
+
+	log.Println("Services tables created successfully")
+	return nil
+}
+
+// CreateIntakesTable creates the pre-visit intake form table; run CreateAppointmentsTable first
+func (db *DB) CreateIntakesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS intakes (
+		id SERIAL PRIMARY KEY,
+		appointment_id INTEGER NOT NULL UNIQUE REFERENCES appointments(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		token VARCHAR(64) NOT NULL UNIQUE,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		chief_complaint TEXT,
+		symptoms TEXT NOT NULL DEFAULT '',
+		duration VARCHAR(100),
+		medications TEXT,
+		notes TEXT,
+		expires_at TIMESTAMP NOT NULL,
+		completed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create intakes table: %w", err)
+	}
+
+	log.Println("Intakes table created successfully")
+	return nil
+}
+
+// CreatePatientDocumentsTable creates the table of files uploaded to patient records
+func (db *DB) CreatePatientDocumentsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_documents (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		category VARCHAR(30) NOT NULL,
+		title VARCHAR(255) NOT NULL,
+		file_name VARCHAR(255) NOT NULL,
+		content_type VARCHAR(100) NOT NULL,
+		size BIGINT NOT NULL,
+		storage_key VARCHAR(255) NOT NULL UNIQUE,
+		notes TEXT,
+		uploaded_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_patient_documents_patient ON patient_documents (patient_hn, created_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create patient documents table: %w", err)
+	}
+
+	log.Println("Patient documents table created successfully")
+	return nil
+}
+
+// CreateConsentsTable creates the table of consent forms signed by patients
+func (db *DB) CreateConsentsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS consents (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		type VARCHAR(30) NOT NULL,
+		version VARCHAR(30) NOT NULL,
+		procedure VARCHAR(255),
+		signed_date DATE NOT NULL,
+		signer_name VARCHAR(255) NOT NULL,
+		signer_relation VARCHAR(20) NOT NULL,
+		witness VARCHAR(255),
+		signature_key VARCHAR(255) UNIQUE,
+		signature_type VARCHAR(100),
+		status VARCHAR(20) NOT NULL DEFAULT 'active',
+		withdrawn_at TIMESTAMP,
+		withdrawal_reason TEXT,
+		notes TEXT,
+		recorded_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_consents_patient ON consents (patient_hn, type, signed_date)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create consents table: %w", err)
+	}
+
+	log.Println("Consents table created successfully")
+	return nil
+}
+
+// CreateArchiveTables creates the archive schema with a copy of each archived
+// table, and adds columns the hot tables gained since. Run it after the tables
+// it copies, and again after migrations that add columns to them.
+func (db *DB) CreateArchiveTables() error {
+	if _, err := db.conn.Exec("CREATE SCHEMA IF NOT EXISTS " + archiveSchema); err != nil {
+		return fmt.Errorf("failed to create archive schema: %w", err)
+	}
+
+	for _, tables := range [][]archivedTable{visitArchiveTables, auditArchiveTables} {
+		for _, table := range tables {
+			_, err := db.conn.Exec("CREATE TABLE IF NOT EXISTS " + archived(table.name) + " (LIKE " + table.name + " INCLUDING INDEXES)")
+			if err != nil {
+				return fmt.Errorf("failed to create archive table %s: %w", table.name, err)
+			}
+
+			rows, err := db.conn.Query(`
+				SELECT quote_ident(c.column_name), format_type(a.atttypid, a.atttypmod)
+				FROM information_schema.columns c
+				JOIN pg_attribute a ON a.attrelid = to_regclass('public.' || quote_ident(c.table_name)) AND a.attname = c.column_name
+				WHERE c.table_schema = 'public' AND c.table_name = $1
+					AND NOT EXISTS (
+						SELECT 1 FROM information_schema.columns x
+						WHERE x.table_schema = $2 AND x.table_name = $1 AND x.column_name = c.column_name
+					)
+				ORDER BY c.ordinal_position
+			`, table.name, archiveSchema)
+			if err != nil {
+				return fmt.Errorf("failed to compare archive table %s: %w", table.name, err)
+			}
+			added := []string{}
+			for rows.Next() {
+				var column, columnType string
+				if err := rows.Scan(&column, &columnType); err != nil {
+					rows.Close()
+					return fmt.Errorf("failed to compare archive table %s: %w", table.name, err)
+				}
+				added = append(added, "ADD COLUMN IF NOT EXISTS "+column+" "+columnType)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("failed to compare archive table %s: %w", table.name, err)
+			}
+			if len(added) == 0 {
+				continue
+			}
+			if _, err := db.conn.Exec("ALTER TABLE " + archived(table.name) + " " + strings.Join(added, ", ")); err != nil {
+				return fmt.Errorf("failed to add columns to archive table %s: %w", table.name, err)
+			}
+		}
+	}
+
+	log.Println("Archive tables created successfully")
+	return nil
+}
+
+// CreateTriagesTable creates the table of triage assessments; run CreateQueueTables and CreateVitalSignsTable first
+func (db *DB) CreateTriagesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS triages (
+		id SERIAL PRIMARY KEY,
+		queue_entry_id INTEGER NOT NULL REFERENCES queue_entries(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		visit_id INTEGER,
+		presenting_complaint TEXT NOT NULL,
+		urgency VARCHAR(20) NOT NULL,
+		pain_score SMALLINT CHECK (pain_score BETWEEN 0 AND 10),
+		vitals_id INTEGER REFERENCES vital_signs(id),
+		notes TEXT,
+		triaged_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_triages_queue_entry ON triages (queue_entry_id, created_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create triages table: %w", err)
+	}
+
+	log.Println("Triages table created successfully")
+	return nil
+}
+
+// CreateFollowUpsTable creates the table of follow-up recall dates; run CreateEncountersTable first
+func (db *DB) CreateFollowUpsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS follow_ups (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		visit_id INTEGER NOT NULL REFERENCES encounters(id),
+		due_date DATE NOT NULL,
+		reason TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		scheduled_by VARCHAR(100) NOT NULL,
+		contacted_at TIMESTAMP,
+		contacted_by VARCHAR(100),
+		contact_notes TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_follow_ups_patient ON follow_ups (patient_hn, due_date);
+	CREATE INDEX IF NOT EXISTS idx_follow_ups_visit ON follow_ups (visit_id);
+	CREATE INDEX IF NOT EXISTS idx_follow_ups_pending ON follow_ups (due_date) WHERE status = 'pending'`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create follow_ups table: %w", err)
+	}
+
+	log.Println("Follow-ups table created successfully")
+	return nil
+}
+
+// CreatePackagesTable creates the treatment package catalog, the packages
+// patients bought and the sessions used from them; run CreateServicesTable first
+func (db *DB) CreatePackagesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS treatment_packages (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		service_id INTEGER REFERENCES services(id),
+		sessions INTEGER NOT NULL CHECK (sessions > 0),
+		price NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+		valid_days INTEGER CHECK (valid_days > 0),
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS patient_packages (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		package_id INTEGER NOT NULL REFERENCES treatment_packages(id),
+		service_id INTEGER REFERENCES services(id),
+		name VARCHAR(255) NOT NULL,
+		total_sessions INTEGER NOT NULL CHECK (total_sessions > 0),
+		used_sessions INTEGER NOT NULL DEFAULT 0 CHECK (used_sessions BETWEEN 0 AND total_sessions),
+		price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
+		status VARCHAR(20) NOT NULL DEFAULT 'active',
+		expires_on DATE,
+		purchased_by VARCHAR(100) NOT NULL,
+		purchased_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_patient_packages_patient ON patient_packages (patient_hn, status);
+
+	CREATE TABLE IF NOT EXISTS package_sessions (
+		id SERIAL PRIMARY KEY,
+		patient_package_id INTEGER NOT NULL REFERENCES patient_packages(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		visit_id INTEGER NOT NULL REFERENCES encounters(id),
+		recorded_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (patient_package_id, visit_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_package_sessions_visit ON package_sessions (visit_id)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create packages tables: %w", err)
+	}
+
+	log.Println("Packages tables created successfully")
+	return nil
+}
+
+// CreateUsersTable creates the table of staff accounts; run CreateDoctorsTable first
+func (db *DB) CreateUsersTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS users (
+		id SERIAL PRIMARY KEY,
+		username VARCHAR(32) NOT NULL UNIQUE,
+		full_name VARCHAR(255) NOT NULL,
+		role VARCHAR(20) NOT NULL,
+		doctor_id INTEGER REFERENCES doctors(id),
+		email VARCHAR(255),
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		password_hash TEXT NOT NULL,
+		must_change_password BOOLEAN NOT NULL DEFAULT FALSE,
+		password_changed_at TIMESTAMP,
+		deactivated_at TIMESTAMP,
+		deactivated_by VARCHAR(100),
+		created_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_users_role ON users (role) WHERE active`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create users table: %w", err)
+	}
+
+	log.Println("Users table created successfully")
+	return nil
+}
+
+// CreateNursingNotesTable creates the append-only table of nursing notes;
+// run CreateEncountersTable first. A trigger refuses updates to what a note
+// says, so it only changes through a later note that corrects it; patient
+// merges can still relink patient_hn.
+func (db *DB) CreateNursingNotesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS nursing_notes (
+		id SERIAL PRIMARY KEY,
+		visit_id INTEGER NOT NULL REFERENCES encounters(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		kind VARCHAR(20) NOT NULL,
+		content TEXT NOT NULL,
+		author_name VARCHAR(100) NOT NULL,
+		observed_at TIMESTAMP NOT NULL,
+		corrects_id INTEGER UNIQUE REFERENCES nursing_notes(id),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_nursing_notes_visit ON nursing_notes (visit_id, observed_at);
+
+	CREATE OR REPLACE FUNCTION nursing_notes_append_only() RETURNS trigger AS $$
+	BEGIN
+		IF (NEW.visit_id, NEW.kind, NEW.content, NEW.author_name, NEW.observed_at, NEW.corrects_id, NEW.created_at)
+			IS DISTINCT FROM (OLD.visit_id, OLD.kind, OLD.content, OLD.author_name, OLD.observed_at, OLD.corrects_id, OLD.created_at) THEN
+			RAISE EXCEPTION 'nursing note % cannot be changed; add a correcting note', OLD.id;
+		END IF;
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS nursing_notes_append_only ON nursing_notes;
+	CREATE TRIGGER nursing_notes_append_only BEFORE UPDATE ON nursing_notes
+		FOR EACH ROW EXECUTE FUNCTION nursing_notes_append_only()`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create nursing_notes table: %w", err)
+	}
+
+	log.Println("Nursing notes table created successfully")
+	return nil
+}
+
+// CreateCancellationReasonsTable creates the table of the clinic's cancellation reasons
+func (db *DB) CreateCancellationReasonsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS cancellation_reasons (
+		code VARCHAR(50) PRIMARY KEY,
+		label VARCHAR(100) NOT NULL,
+		applies_to VARCHAR(20) NOT NULL CHECK (applies_to IN ('appointment', 'visit', 'both')),
+		initiator VARCHAR(20) NOT NULL CHECK (initiator IN ('patient', 'clinic', 'other')),
+		avoidable BOOLEAN NOT NULL DEFAULT FALSE,
+		note_required BOOLEAN NOT NULL DEFAULT FALSE,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		sort_order INTEGER NOT NULL DEFAULT 0,
+		updated_by VARCHAR(100),
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create cancellation reasons table: %w", err)
+	}
+
+	log.Println("Cancellation reasons table created successfully")
+	return nil
+}
+
+// CreateDentalTables creates the tooth finding and dental treatment tables
+func (db *DB) CreateDentalTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS dental_treatments (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		tooth SMALLINT,
+		surfaces VARCHAR(20) NOT NULL DEFAULT '',
+		procedure VARCHAR(30) NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'planned' CHECK (status IN ('planned', 'completed', 'cancelled')),
+		note TEXT,
+		visit_id INTEGER,
+		recorded_by VARCHAR(100) NOT NULL,
+		performed_by VARCHAR(100),
+		completed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_dental_treatments_patient ON dental_treatments (patient_hn, tooth);
+
+	CREATE TABLE IF NOT EXISTS tooth_findings (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		tooth SMALLINT NOT NULL,
+		status VARCHAR(30) NOT NULL,
+		surfaces VARCHAR(20) NOT NULL DEFAULT '',
+		note TEXT,
+		visit_id INTEGER,
+		treatment_id INTEGER REFERENCES dental_treatments(id),
+		recorded_by VARCHAR(100) NOT NULL,
+		recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tooth_findings_patient ON tooth_findings (patient_hn, tooth, recorded_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create dental tables: %w", err)
+	}
+
+	log.Println("Dental tables created successfully")
+	return nil
+}
+
+// CreateEmergencyContactsTable creates the table of patients' emergency contacts
+func (db *DB) CreateEmergencyContactsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS emergency_contacts (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		name VARCHAR(200) NOT NULL,
+		relationship VARCHAR(20) NOT NULL,
+		phone VARCHAR(20) NOT NULL,
+		priority INTEGER NOT NULL DEFAULT 1 CHECK (priority >= 1),
+		notes TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_emergency_contacts_patient ON emergency_contacts (patient_hn, priority)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create emergency contacts table: %w", err)
+	}
+
+	log.Println("Emergency contacts table created successfully")
+	return nil
+}
+
+// CreateSelfRegistrationsTable creates the table of the forms new patients
+// fill in before arriving
+func (db *DB) CreateSelfRegistrationsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS self_registrations (
+		id SERIAL PRIMARY KEY,
+		token VARCHAR(64) NOT NULL UNIQUE,
+		status VARCHAR(20) NOT NULL DEFAULT 'open',
+		label VARCHAR(200),
+		full_name VARCHAR(200) NOT NULL DEFAULT '',
+		gender VARCHAR(20) NOT NULL DEFAULT '',
+		nickname VARCHAR(100),
+		phone VARCHAR(20),
+		date_of_birth DATE,
+		citizen_id VARCHAR(13),
+		notes TEXT,
+		issued_by VARCHAR(100) NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		submitted_at TIMESTAMP,
+		patient_hn VARCHAR(10),
+		reviewed_by VARCHAR(100),
+		reviewed_at TIMESTAMP,
+		reject_reason TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_self_registrations_status ON self_registrations (status)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create self-registrations table: %w", err)
+	}
+
+	log.Println("Self-registrations table created successfully")
+	return nil
+}
+
+// CreatePatientAddressesTable creates the table of patients' structured
+// addresses; the partial unique index keeps one primary address per patient
+func (db *DB) CreatePatientAddressesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_addresses (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		kind VARCHAR(20) NOT NULL DEFAULT 'home',
+		house_no VARCHAR(50) NOT NULL,
+		moo VARCHAR(10),
+		village VARCHAR(200),
+		soi VARCHAR(100),
+		road VARCHAR(100),
+		tambon VARCHAR(100) NOT NULL,
+		amphoe VARCHAR(100) NOT NULL,
+		province VARCHAR(100) NOT NULL,
+		postal_code CHAR(5) NOT NULL,
+		is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+		notes TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_patient_addresses_patient ON patient_addresses (patient_hn);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_patient_addresses_primary ON patient_addresses (patient_hn) WHERE is_primary`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create patient addresses table: %w", err)
+	}
+
+	log.Println("Patient addresses table created successfully")
+	return nil
+}
+
+// CreateVisitSummaryLinksTable creates the tables of visit summary links and
+// their access log; run CreateEncountersTable first
+func (db *DB) CreateVisitSummaryLinksTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS visit_summary_links (
+		id SERIAL PRIMARY KEY,
+		token VARCHAR(64) NOT NULL UNIQUE,
+		visit_id INTEGER NOT NULL REFERENCES encounters(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		instructions TEXT,
+		issued_by VARCHAR(100) NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP,
+		revoked_by VARCHAR(100),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_visit_summary_links_visit ON visit_summary_links (visit_id);
+
+	CREATE TABLE IF NOT EXISTS summary_link_accesses (
+		id SERIAL PRIMARY KEY,
+		link_id INTEGER NOT NULL REFERENCES visit_summary_links(id),
+		outcome VARCHAR(20) NOT NULL,
+		ip_address VARCHAR(64) NOT NULL,
+		user_agent TEXT NOT NULL,
+		accessed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_summary_link_accesses_link ON summary_link_accesses (link_id, accessed_at DESC)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create visit summary links table: %w", err)
+	}
+
+	log.Println("Visit summary links table created successfully")
+	return nil
+}
+
+// CreateDrugInteractionRulesTable creates the drug interaction rules table
+func (db *DB) CreateDrugInteractionRulesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS drug_interaction_rules (
+		id SERIAL PRIMARY KEY,
+		kind VARCHAR(20) NOT NULL,
+		drug VARCHAR(255) NOT NULL,
+		with_name VARCHAR(255) NOT NULL,
+		severity VARCHAR(20) NOT NULL,
+		effect TEXT NOT NULL,
+		management TEXT,
+		source TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_drug_interaction_rules_pair
+		ON drug_interaction_rules (kind, lower(drug), lower(with_name))`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create drug interaction rules table: %w", err)
+	}
+
+	log.Println("Drug interaction rules table created successfully")
+	return nil
+}
+
+// CreateAPIKeysTable creates the API keys table
+func (db *DB) CreateAPIKeysTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		prefix VARCHAR(20) NOT NULL,
+		key_hash CHAR(64) NOT NULL UNIQUE,
+		role VARCHAR(20) NOT NULL,
+		sandbox BOOLEAN NOT NULL DEFAULT FALSE,
+		created_by VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_used_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		revoked_by VARCHAR(255)
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create API keys table: %w", err)
+	}
+
+	log.Println("API keys table created successfully")
+	return nil
+}
+
+// CreateLINETables creates the patient LINE account and LINE message tables
+func (db *DB) CreateLINETables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS patient_line_accounts (
+		patient_hn VARCHAR(10) PRIMARY KEY,
+		line_user_id VARCHAR(64) NOT NULL UNIQUE,
+		display_name VARCHAR(255),
+		linked_by VARCHAR(255) NOT NULL,
+		linked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS line_messages (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		line_user_id VARCHAR(64) NOT NULL,
+		kind VARCHAR(30) NOT NULL,
+		appointment_id INTEGER REFERENCES appointments(id),
+		queue_entry_id INTEGER REFERENCES queue_entries(id),
+		text TEXT NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		retry_at TIMESTAMP,
+		sent_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_line_messages_pending ON line_messages (created_at)
+		WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS idx_line_messages_patient ON line_messages (patient_hn, created_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create LINE tables: %w", err)
+	}
+
+	log.Println("LINE tables created successfully")
+	return nil
+}
+
+// CreateEmailOutboxTable creates the email outbox table
+func (db *DB) CreateEmailOutboxTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS email_outbox (
+		id SERIAL PRIMARY KEY,
+		template VARCHAR(50) NOT NULL,
+		recipient VARCHAR(255) NOT NULL,
+		subject TEXT NOT NULL,
+		body TEXT NOT NULL,
+		patient_hn VARCHAR(10),
+		appointment_id INTEGER REFERENCES appointments(id),
+		document_id INTEGER REFERENCES patient_documents(id),
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		retry_at TIMESTAMP,
+		sent_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_email_outbox_pending ON email_outbox (created_at)
+		WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS idx_email_outbox_patient ON email_outbox (patient_hn, created_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create email outbox table: %w", err)
+	}
+
+	log.Println("Email outbox table created successfully")
+	return nil
+}
+
+// CreatePortalTables creates the patient portal sign-in challenge and session tables
+func (db *DB) CreatePortalTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS portal_challenges (
+		id VARCHAR(64) PRIMARY KEY,
+		patient_hn VARCHAR(10),
+		code_hash VARCHAR(64) NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE portal_challenges ADD COLUMN IF NOT EXISTS requested_hn VARCHAR(10) NOT NULL DEFAULT '';
+	ALTER TABLE portal_challenges ADD COLUMN IF NOT EXISTS client_ip VARCHAR(45) NOT NULL DEFAULT '';
+
+	CREATE INDEX IF NOT EXISTS idx_portal_challenges_patient ON portal_challenges (patient_hn, created_at);
+	CREATE INDEX IF NOT EXISTS idx_portal_challenges_requested ON portal_challenges (requested_hn, created_at);
+	CREATE INDEX IF NOT EXISTS idx_portal_challenges_client ON portal_challenges (client_ip, created_at);
+
+	CREATE TABLE IF NOT EXISTS portal_sessions (
+		id SERIAL PRIMARY KEY,
+		patient_hn VARCHAR(10) NOT NULL,
+		token_hash VARCHAR(64) NOT NULL UNIQUE,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		revoked_at TIMESTAMP
+	)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create portal tables: %w", err)
+	}
+
+	log.Println("Portal tables created successfully")
+	return nil
+}
+
+// CreateOnlineSlotsTable creates the doctors' time released for booking in the patient portal
+func (db *DB) CreateOnlineSlotsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS online_slots (
+		id SERIAL PRIMARY KEY,
+		doctor_id INTEGER NOT NULL REFERENCES doctors(id),
+		doctor_name VARCHAR(255) NOT NULL,
+		starts_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP NOT NULL,
+		type VARCHAR(30) NOT NULL,
+		released_by VARCHAR(100) NOT NULL,
+		booked_by VARCHAR(10),
+		booked_at TIMESTAMP,
+		appointment_id INTEGER REFERENCES appointments(id),
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_online_slots_starts_at ON online_slots (starts_at) WHERE booked_by IS NULL`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create online slots table: %w", err)
+	}
+
+	log.Println("Online slots table created successfully")
+	return nil
+}
+
+// CreateTeleconsultsTable creates the video visit sessions held for appointments
+func (db *DB) CreateTeleconsultsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS teleconsults (
+		id SERIAL PRIMARY KEY,
+		appointment_id INTEGER NOT NULL REFERENCES appointments(id),
+		patient_hn VARCHAR(10) NOT NULL,
+		doctor_id INTEGER REFERENCES doctors(id),
+		doctor_name VARCHAR(255) NOT NULL,
+		room_token VARCHAR(64) NOT NULL UNIQUE,
+		patient_token VARCHAR(64) NOT NULL UNIQUE,
+		doctor_token VARCHAR(64) NOT NULL UNIQUE,
+		scheduled_at TIMESTAMP NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+		patient_joined_at TIMESTAMP,
+		started_at TIMESTAMP,
+		ended_at TIMESTAMP,
+		visit_id INTEGER REFERENCES encounters(id),
+		created_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_teleconsults_appointment ON teleconsults (appointment_id) WHERE status <> 'cancelled';
+	CREATE INDEX IF NOT EXISTS idx_teleconsults_scheduled_at ON teleconsults (scheduled_at)`
+
+	_, err := db.conn.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create teleconsults table: %w", err)
+	}
+
+	log.Println("Teleconsults table created successfully")
+	return nil
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// ConsentTypes are the kinds of consent a patient gives: data_processing is the
+// PDPA consent to collect and use personal data, the others cover care
+var ConsentTypes = []string{"data_processing", "treatment", "procedure", "anesthesia", "photography", "research", "marketing"}
+
+// ConsentSignerRelations are who may sign for a patient: the patient, or for
+// minors and patients who cannot sign, their representative
+var ConsentSignerRelations = []string{"self", "parent", "guardian", "spouse", "relative", "other"}
+
+// Consent statuses; a withdrawn consent is kept on record
+const (
+	ConsentActive    = "active"
+	ConsentWithdrawn = "withdrawn"
+)
+
+// Consent records a consent form a patient (or their representative) signed.
+// The scanned signature, if any, lives in document storage under SignatureKey.
+type Consent struct {
+	ID               int        `json:"id" db:"id"`
+	PatientHN        string     `json:"patientHn" db:"patient_hn"`
+	Type             string     `json:"type" db:"type"`                     // one of ConsentTypes
+	Version          string     `json:"version" db:"version"`               // form version, e.g. "2026-01"
+	Procedure        *string    `json:"procedure,omitempty" db:"procedure"` // what a procedure consent covers, e.g. "ผ่าฝี"
+	SignedDate       string     `json:"signedDate" db:"signed_date"`        // YYYY-MM-DD
+	SignerName       string     `json:"signerName" db:"signer_name"`
+	SignerRelation   string     `json:"signerRelation" db:"signer_relation"` // one of ConsentSignerRelations
+	Witness          *string    `json:"witness,omitempty" db:"witness"`
+	HasSignature     bool       `json:"hasSignature" db:"-"`
+	SignatureKey     *string    `json:"-" db:"signature_key"`
+	SignatureType    *string    `json:"-" db:"signature_type"`
+	Status           string     `json:"status" db:"status"` // active or withdrawn
+	WithdrawnAt      *time.Time `json:"withdrawnAt,omitempty" db:"withdrawn_at"`
+	WithdrawalReason *string    `json:"withdrawalReason,omitempty" db:"withdrawal_reason"`
+	Notes            *string    `json:"notes,omitempty" db:"notes"`
+	RecordedBy       string     `json:"recordedBy" db:"recorded_by"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// ConsentFilter narrows a patient's consent listing; zero values match everything
+type ConsentFilter struct {
+	Type       string
+	ActiveOnly bool
+}
+
+// ConsentRepository handles patient consent database operations
+type ConsentRepository struct {
+	db *DB
+}
+
+// NewConsentRepository creates a new consent repository
+func NewConsentRepository(db *DB) *ConsentRepository {
+	return &ConsentRepository{db: db}
+}
+
+const consentColumns = `id, patient_hn, type, version, procedure, to_char(signed_date, 'YYYY-MM-DD'), signer_name,
+	signer_relation, witness, signature_key, signature_type, status, withdrawn_at, withdrawal_reason, notes, recorded_by, created_at`
+
+func scanConsent(row interface{ Scan(...interface{}) error }) (*Consent, error) {
+	var c Consent
+	err := row.Scan(&c.ID, &c.PatientHN, &c.Type, &c.Version, &c.Procedure, &c.SignedDate, &c.SignerName,
+		&c.SignerRelation, &c.Witness, &c.SignatureKey, &c.SignatureType, &c.Status, &c.WithdrawnAt,
+		&c.WithdrawalReason, &c.Notes, &c.RecordedBy, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.HasSignature = c.SignatureKey != nil
+	return &c, nil
+}
+
+// Create records a signed consent
+func (r *ConsentRepository) Create(c *Consent) error {
+	query := `
+		INSERT INTO consents (patient_hn, type, version, procedure, signed_date, signer_name, signer_relation, witness,
+			status, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, c.PatientHN, c.Type, c.Version, c.Procedure, c.SignedDate, c.SignerName,
+		c.SignerRelation, c.Witness, c.Status, c.Notes, c.RecordedBy).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create consent: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a consent by ID
+func (r *ConsentRepository) GetByID(id int) (*Consent, error) {
+	c, err := scanConsent(r.db.conn.QueryRow("SELECT "+consentColumns+" FROM consents WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("consent %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get consent: %w", err)
+	}
+	return c, nil
+}
+
+// GetByPatient retrieves a patient's consents matching the filter, most recently signed first
+func (r *ConsentRepository) GetByPatient(hn string, f ConsentFilter) ([]Consent, error) {
+	query := `
+		SELECT ` + consentColumns + ` FROM consents
+		WHERE patient_hn = $1 AND ($2 = '' OR type = $2) AND (NOT $3 OR status = 'active')
+		ORDER BY signed_date DESC, id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, hn, f.Type, f.ActiveOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consents: %w", err)
+	}
+	defer rows.Close()
+
+	consents := []Consent{}
+	for rows.Next() {
+		c, err := scanConsent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan consent: %w", err)
+		}
+		consents = append(consents, *c)
+	}
+
+	return consents, rows.Err()
+}
+
+// SetSignature links a consent to its stored signature image
+func (r *ConsentRepository) SetSignature(c *Consent) error {
+	result, err := r.db.conn.Exec(`UPDATE consents SET signature_key = $2, signature_type = $3 WHERE id = $1`,
+		c.ID, c.SignatureKey, c.SignatureType)
+	if err != nil {
+		return fmt.Errorf("failed to set consent signature: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("consent %d not found", c.ID)
+	}
+
+	c.HasSignature = c.SignatureKey != nil
+	return nil
+}
+
+// Withdraw marks an active consent withdrawn, as the PDPA lets a patient do at any time
+func (r *ConsentRepository) Withdraw(c *Consent) error {
+	err := r.db.conn.QueryRow(`
+		UPDATE consents SET status = 'withdrawn', withdrawn_at = CURRENT_TIMESTAMP, withdrawal_reason = $2
+		WHERE id = $1 AND status = 'active'
+		RETURNING status, withdrawn_at
+	`, c.ID, c.WithdrawalReason).Scan(&c.Status, &c.WithdrawnAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("consent %d has already been withdrawn", c.ID)
+		}
+		return fmt.Errorf("failed to withdraw consent: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Lease is a named, time-limited claim held by one API instance. Leases back
+// leader election and keep scheduled jobs from running on several instances.
+type Lease struct {
+	Name       string    `json:"name" db:"name"`
+	Holder     string    `json:"holder" db:"holder"`
+	Token      int64     `json:"token" db:"token"` // fencing token, increases every time the lease changes hands
+	AcquiredAt time.Time `json:"acquiredAt" db:"acquired_at"`
+	ExpiresAt  time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+// CoordinationRepository handles lease and sequence operations shared by all API instances
+type CoordinationRepository struct {
+	db *DB
+}
+
+// NewCoordinationRepository creates a new coordination repository
+func NewCoordinationRepository(db *DB) *CoordinationRepository {
+	return &CoordinationRepository{db: db}
+}
+
+const leaseColumns = "name, holder, token, acquired_at, expires_at"
+
+func scanLease(row interface{ Scan(...interface{}) error }) (*Lease, error) {
+	var l Lease
+	if err := row.Scan(&l.Name, &l.Holder, &l.Token, &l.AcquiredAt, &l.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Acquire claims a lease for holder when it is free or expired. It returns nil
+// when another holder's lease is still valid.
+func (r *CoordinationRepository) Acquire(name, holder string, ttl time.Duration) (*Lease, error) {
+	query := `
+		INSERT INTO coordination_leases (name, holder, token, acquired_at, expires_at)
+		VALUES ($1, $2, 1, NOW(), NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (name) DO UPDATE SET
+			holder = EXCLUDED.holder,
+			token = coordination_leases.token + 1,
+			acquired_at = EXCLUDED.acquired_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE coordination_leases.expires_at <= NOW()
+		RETURNING ` + leaseColumns
+
+	l, err := scanLease(r.db.conn.QueryRow(query, name, holder, ttl.Milliseconds()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return l, nil
+}
+
+// Renew extends a lease the holder still owns; it fails once the lease expired or changed hands
+func (r *CoordinationRepository) Renew(l *Lease, ttl time.Duration) error {
+	query := `
+		UPDATE coordination_leases SET expires_at = NOW() + $4 * INTERVAL '1 millisecond'
+		WHERE name = $1 AND holder = $2 AND token = $3 AND expires_at > NOW()
+		RETURNING expires_at`
+
+	err := r.db.conn.QueryRow(query, l.Name, l.Holder, l.Token, ttl.Milliseconds()).Scan(&l.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return apperr.Conflict("lease %s lost", l.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to renew lease %s: %w", l.Name, err)
+	}
+	return nil
+}
+
+// Release gives up a lease early. The row is kept so the fencing token keeps increasing.
+func (r *CoordinationRepository) Release(l *Lease) error {
+	query := `
+		UPDATE coordination_leases SET expires_at = NOW()
+		WHERE name = $1 AND holder = $2 AND token = $3`
+
+	if _, err := r.db.conn.Exec(query, l.Name, l.Holder, l.Token); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", l.Name, err)
+	}
+	return nil
+}
+
+// GetLeases returns every lease, including expired ones
+func (r *CoordinationRepository) GetLeases() ([]Lease, error) {
+	rows, err := r.db.conn.Query("SELECT " + leaseColumns + " FROM coordination_leases ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leases: %w", err)
+	}
+	defer rows.Close()
+
+	leases := []Lease{}
+	for rows.Next() {
+		l, err := scanLease(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lease: %w", err)
+		}
+		leases = append(leases, *l)
+	}
+	return leases, rows.Err()
+}
+
+// NextValue reserves the next number of a named sequence. The upsert is atomic,
+// so concurrent instances never hand out the same number.
+func (r *CoordinationRepository) NextValue(name string) (int64, error) {
+	query := `
+		INSERT INTO coordination_sequences (name, last_value)
+		VALUES ($1, 1)
+		ON CONFLICT (name) DO UPDATE SET last_value = coordination_sequences.last_value + 1
+		RETURNING last_value`
+
+	var n int64
+	if err := r.db.conn.QueryRow(query, name).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to reserve %s number: %w", name, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,263 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// ToothStatuses are the conditions a tooth is charted in
+var ToothStatuses = []string{
+	"sound",
+	"caries",
+	"filled",
+	"crowned",
+	"root_canal_treated",
+	"fractured",
+	"missing",
+	"implant",
+	"unerupted",
+	"impacted",
+}
+
+// ToothSurfaces are the surfaces findings and treatments name, in charting order
+var ToothSurfaces = []string{
+	"M", // mesial
+	"O", // occlusal, or incisal on front teeth
+	"D", // distal
+	"B", // buccal or labial
+	"L", // lingual or palatal
+}
+
+// DentalProcedures are the treatments recorded on the chart
+var DentalProcedures = []string{
+	"filling",
+	"extraction",
+	"root_canal",
+	"crown",
+	"implant",
+	"bridge",
+	"sealant",
+	"scaling",
+	"fluoride",
+	"other",
+}
+
+// ProcedureToothStatus is what completing a procedure leaves a tooth in;
+// procedures not listed do not change how the tooth is charted
+var ProcedureToothStatus = map[string]string{
+	"filling":    "filled",
+	"extraction": "missing",
+	"root_canal": "root_canal_treated",
+	"crown":      "crowned",
+	"implant":    "implant",
+}
+
+// Dental treatment statuses
+const (
+	DentalTreatmentPlanned   = "planned"
+	DentalTreatmentCompleted = "completed"
+	DentalTreatmentCancelled = "cancelled"
+)
+
+// ValidTooth reports whether tooth is an FDI tooth number: quadrants 1-4
+// hold permanent teeth 1-8, quadrants 5-8 primary teeth 1-5, e.g. 36 is the
+// lower left first molar and 85 the lower right second primary molar
+func ValidTooth(tooth int) bool {
+	quadrant, position := tooth/10, tooth%10
+	switch {
+	case quadrant >= 1 && quadrant <= 4:
+		return position >= 1 && position <= 8
+	case quadrant >= 5 && quadrant <= 8:
+		return position >= 1 && position <= 5
+	}
+	return false
+}
+
+// ToothFinding is the condition a tooth was charted in. Findings are kept as
+// a history; a tooth's current condition is its latest finding, and teeth
+// never charted are taken to be sound.
+type ToothFinding struct {
+	ID          int       `json:"id" db:"id"`
+	PatientHN   string    `json:"patientHn" db:"patient_hn"`
+	Tooth       int       `json:"tooth" db:"tooth"` // FDI number, e.g. 36
+	Status      string    `json:"status" db:"status"`
+	Surfaces    []string  `json:"surfaces" db:"surfaces"` // e.g. ["M", "O"], stored comma-separated
+	Note        *string   `json:"note,omitempty" db:"note"`
+	VisitID     *int      `json:"visitId,omitempty" db:"visit_id"`
+	TreatmentID *int      `json:"treatmentId,omitempty" db:"treatment_id"` // the completed treatment that left the tooth so
+	RecordedBy  string    `json:"recordedBy" db:"recorded_by"`
+	RecordedAt  time.Time `json:"recordedAt" db:"recorded_at"`
+}
+
+// DentalTreatment is a procedure on a tooth, planned for later or done.
+// Treatments on the whole mouth, such as scaling, have no tooth.
+type DentalTreatment struct {
+	ID          int        `json:"id" db:"id"`
+	PatientHN   string     `json:"patientHn" db:"patient_hn"`
+	Tooth       *int       `json:"tooth,omitempty" db:"tooth"`
+	Surfaces    []string   `json:"surfaces" db:"surfaces"`
+	Procedure   string     `json:"procedure" db:"procedure"`
+	Status      string     `json:"status" db:"status"` // planned, completed or cancelled
+	Note        *string    `json:"note,omitempty" db:"note"`
+	VisitID     *int       `json:"visitId,omitempty" db:"visit_id"` // the visit it was done in
+	RecordedBy  string     `json:"recordedBy" db:"recorded_by"`
+	PerformedBy *string    `json:"performedBy,omitempty" db:"performed_by"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// DentalRepository handles dental chart database operations
+type DentalRepository struct {
+	db *DB
+}
+
+// NewDentalRepository creates a new dental repository
+func NewDentalRepository(db *DB) *DentalRepository {
+	return &DentalRepository{db: db}
+}
+
+func splitSurfaces(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// AddFinding charts the condition of a tooth
+func (r *DentalRepository) AddFinding(f *ToothFinding) error {
+	query := `
+		INSERT INTO tooth_findings (patient_hn, tooth, status, surfaces, note, visit_id, treatment_id, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, recorded_at
+	`
+
+	err := r.db.conn.QueryRow(query, f.PatientHN, f.Tooth, f.Status, strings.Join(f.Surfaces, ","), f.Note, f.VisitID,
+		f.TreatmentID, f.RecordedBy).Scan(&f.ID, &f.RecordedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add tooth finding: %w", err)
+	}
+
+	return nil
+}
+
+// GetFindings retrieves a patient's tooth findings, oldest first; a tooth
+// other than 0 keeps that tooth's
+func (r *DentalRepository) GetFindings(hn string, tooth int) ([]ToothFinding, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT id, patient_hn, tooth, status, surfaces, note, visit_id, treatment_id, recorded_by, recorded_at
+		FROM tooth_findings
+		WHERE patient_hn = $1 AND ($2 = 0 OR tooth = $2)
+		ORDER BY recorded_at, id
+	`, hn, tooth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tooth findings: %w", err)
+	}
+	defer rows.Close()
+
+	findings := []ToothFinding{}
+	for rows.Next() {
+		var f ToothFinding
+		var surfaces string
+		err := rows.Scan(&f.ID, &f.PatientHN, &f.Tooth, &f.Status, &surfaces, &f.Note, &f.VisitID, &f.TreatmentID,
+			&f.RecordedBy, &f.RecordedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tooth finding: %w", err)
+		}
+		f.Surfaces = splitSurfaces(surfaces)
+		findings = append(findings, f)
+	}
+
+	return findings, rows.Err()
+}
+
+const dentalTreatmentColumns = `id, patient_hn, tooth, surfaces, procedure, status, note, visit_id, recorded_by,
+	performed_by, completed_at, created_at, updated_at`
+
+func scanDentalTreatment(row interface{ Scan(...interface{}) error }) (*DentalTreatment, error) {
+	var t DentalTreatment
+	var surfaces string
+	err := row.Scan(&t.ID, &t.PatientHN, &t.Tooth, &surfaces, &t.Procedure, &t.Status, &t.Note, &t.VisitID,
+		&t.RecordedBy, &t.PerformedBy, &t.CompletedAt, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.Surfaces = splitSurfaces(surfaces)
+	return &t, nil
+}
+
+// CreateTreatment records a planned or completed treatment
+func (r *DentalRepository) CreateTreatment(t *DentalTreatment) error {
+	query := `
+		INSERT INTO dental_treatments (patient_hn, tooth, surfaces, procedure, status, note, visit_id, recorded_by,
+			performed_by, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, t.PatientHN, t.Tooth, strings.Join(t.Surfaces, ","), t.Procedure, t.Status, t.Note,
+		t.VisitID, t.RecordedBy, t.PerformedBy, t.CompletedAt).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create dental treatment: %w", err)
+	}
+
+	return nil
+}
+
+// GetTreatmentByID retrieves a dental treatment by ID
+func (r *DentalRepository) GetTreatmentByID(id int) (*DentalTreatment, error) {
+	t, err := scanDentalTreatment(r.db.conn.QueryRow("SELECT "+dentalTreatmentColumns+" FROM dental_treatments WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("dental treatment %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get dental treatment: %w", err)
+	}
+	return t, nil
+}
+
+// GetTreatments retrieves a patient's dental treatments, oldest first; a
+// tooth other than 0 keeps that tooth's
+func (r *DentalRepository) GetTreatments(hn string, tooth int) ([]DentalTreatment, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+dentalTreatmentColumns+` FROM dental_treatments
+		WHERE patient_hn = $1 AND ($2 = 0 OR tooth = $2)
+		ORDER BY created_at, id
+	`, hn, tooth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dental treatments: %w", err)
+	}
+	defer rows.Close()
+
+	treatments := []DentalTreatment{}
+	for rows.Next() {
+		t, err := scanDentalTreatment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dental treatment: %w", err)
+		}
+		treatments = append(treatments, *t)
+	}
+
+	return treatments, rows.Err()
+}
+
+// FinishTreatment completes or cancels a planned treatment. Completing one
+// records who performed it and in which visit.
+func (r *DentalRepository) FinishTreatment(id int, status string, performedBy *string, visitID *int) (*DentalTreatment, error) {
+	t, err := scanDentalTreatment(r.db.conn.QueryRow(`
+		UPDATE dental_treatments SET status = $2, performed_by = $3, visit_id = COALESCE($4, visit_id),
+			completed_at = CASE WHEN $2 = 'completed' THEN CURRENT_TIMESTAMP END, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'planned'
+		RETURNING `+dentalTreatmentColumns, id, status, performedBy, visitID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("dental treatment %d is not planned", id)
+		}
+		return nil, fmt.Errorf("failed to update dental treatment: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// VisitDiagnosis is an ICD-10 code a doctor confirmed for a visit
+type VisitDiagnosis struct {
+	ID            int       `json:"id" db:"id"`
+	VisitID       int       `json:"visitId" db:"visit_id"`
+	PatientHN     string    `json:"patientHn" db:"patient_hn"`
+	Code          string    `json:"code" db:"code"` // ICD-10, e.g. "J06.9"
+	Description   string    `json:"description" db:"description"`
+	DiagnosisText *string   `json:"diagnosisText,omitempty" db:"diagnosis_text"` // the free text the code was chosen for
+	Primary       bool      `json:"primary" db:"is_primary"`
+	Suggested     bool      `json:"suggested" db:"suggested"` // picked from the coding assistant's suggestions
+	ConfirmedBy   string    `json:"confirmedBy" db:"confirmed_by"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+}
+
+// DiagnosisCodeRepository handles visit diagnosis code database operations
+type DiagnosisCodeRepository struct {
+	db *DB
+}
+
+// NewDiagnosisCodeRepository creates a new diagnosis code repository
+func NewDiagnosisCodeRepository(db *DB) *DiagnosisCodeRepository {
+	return &DiagnosisCodeRepository{db: db}
+}
+
+// Create stores a confirmed code. A visit has at most one primary diagnosis,
+// so confirming a new primary demotes the previous one.
+func (r *DiagnosisCodeRepository) Create(d *VisitDiagnosis) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin diagnosis code: %w", err)
+	}
+	defer tx.Rollback()
+
+	if d.Primary {
+		if _, err := tx.Exec("UPDATE visit_diagnoses SET is_primary = FALSE WHERE visit_id = $1", d.VisitID); err != nil {
+			return fmt.Errorf("failed to demote primary diagnosis: %w", err)
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO visit_diagnoses (visit_id, patient_hn, code, description, diagnosis_text, is_primary, suggested, confirmed_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, d.VisitID, d.PatientHN, d.Code, d.Description, d.DiagnosisText, d.Primary, d.Suggested, d.ConfirmedBy).
+		Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("code %s is already recorded for visit %d", d.Code, d.VisitID)
+		}
+		return fmt.Errorf("failed to create diagnosis code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit diagnosis code: %w", err)
+	}
+
+	return nil
+}
+
+// GetByVisit retrieves the codes of a visit, primary first
+func (r *DiagnosisCodeRepository) GetByVisit(visitID int) ([]VisitDiagnosis, error) {
+	query := `
+		SELECT id, visit_id, patient_hn, code, description, diagnosis_text, is_primary, suggested, confirmed_by, created_at
+		FROM visit_diagnoses WHERE visit_id = $1
+		ORDER BY is_primary DESC, id
+	`
+
+	rows, err := r.db.conn.Query(query, visitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query diagnosis codes: %w", err)
+	}
+	defer rows.Close()
+
+	var codes []VisitDiagnosis
+	for rows.Next() {
+		var d VisitDiagnosis
+		err := rows.Scan(&d.ID, &d.VisitID, &d.PatientHN, &d.Code, &d.Description, &d.DiagnosisText,
+			&d.Primary, &d.Suggested, &d.ConfirmedBy, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan diagnosis code: %w", err)
+		}
+		codes = append(codes, d)
+	}
+
+	return codes, nil
+}
+
+// Delete removes a code from a visit
+func (r *DiagnosisCodeRepository) Delete(visitID, id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM visit_diagnoses WHERE id = $1 AND visit_id = $2", id, visitID)
+	if err != nil {
+		return fmt.Errorf("failed to delete diagnosis code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("diagnosis code %d not found on visit %d", id, visitID)
+	}
+
+	return nil
+}
+
+// CountByCode counts confirmations per code, used to rank equally matching suggestions
+func (r *DiagnosisCodeRepository) CountByCode() (map[string]int, error) {
+	rows, err := r.db.conn.Query("SELECT code, COUNT(*) FROM visit_diagnoses GROUP BY code")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count diagnosis codes: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var code string
+		var n int
+		if err := rows.Scan(&code, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan diagnosis code count: %w", err)
+		}
+		counts[code] = n
+	}
+
+	return counts, nil
+}
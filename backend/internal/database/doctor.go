@@ -0,0 +1,231 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Weekdays are the working-day codes, indexed by time.Weekday
+var Weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Doctor is a provider that appointments and encounters refer to
+type Doctor struct {
+	ID            int       `json:"id" db:"id"`
+	FullName      string    `json:"fullName" db:"full_name"`           // ชื่อ-นามสกุล
+	Specialty     string    `json:"specialty" db:"specialty"`          // สาขา, e.g. "อายุรกรรม", "general practice"
+	LicenseNumber string    `json:"licenseNumber" db:"license_number"` // เลขที่ใบอนุญาตประกอบวิชาชีพเวชกรรม
+	WorkingDays   []string  `json:"workingDays" db:"working_days"`     // "mon".."sun", stored comma-separated
+	Phone         *string   `json:"phone,omitempty" db:"phone"`
+	Email         *string   `json:"email,omitempty" db:"email"`
+	Active        bool      `json:"active" db:"active"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+
+	LicenseExpiresOn   *string `json:"licenseExpiresOn,omitempty" db:"license_expires_on"` // YYYY-MM-DD, last day the license is valid
+	LicenseRemindedFor *string `json:"-" db:"license_reminded_for"`                        // the expiry date a renewal reminder was last sent for
+}
+
+// LicenseLapsedOn reports whether the doctor's license has expired by day (YYYY-MM-DD).
+// Doctors with no expiry on file are taken to be licensed.
+func (d *Doctor) LicenseLapsedOn(day string) bool {
+	return d.LicenseExpiresOn != nil && *d.LicenseExpiresOn < day
+}
+
+// WorksOn reports whether the doctor works on the given weekday
+func (d *Doctor) WorksOn(day time.Weekday) bool {
+	for _, w := range d.WorkingDays {
+		if w == Weekdays[day] {
+			return true
+		}
+	}
+	return false
+}
+
+// DoctorFilter narrows a doctor listing; zero values match everything
+type DoctorFilter struct {
+	Specialty  string
+	ActiveOnly bool
+
+	LicenseExpiresBy string // YYYY-MM-DD; only doctors whose license expires on or before it
+}
+
+// DoctorRepository handles doctor database operations
+type DoctorRepository struct {
+	db *DB
+}
+
+// NewDoctorRepository creates a new doctor repository
+func NewDoctorRepository(db *DB) *DoctorRepository {
+	return &DoctorRepository{db: db}
+}
+
+const doctorColumns = `id, full_name, specialty, license_number, working_days, phone, email, active, created_at, updated_at,
+	to_char(license_expires_on, 'YYYY-MM-DD'), to_char(license_reminded_for, 'YYYY-MM-DD')`
+
+func scanDoctor(row interface{ Scan(...interface{}) error }) (*Doctor, error) {
+	var d Doctor
+	var days string
+	err := row.Scan(&d.ID, &d.FullName, &d.Specialty, &d.LicenseNumber, &days, &d.Phone, &d.Email,
+		&d.Active, &d.CreatedAt, &d.UpdatedAt, &d.LicenseExpiresOn, &d.LicenseRemindedFor)
+	if err != nil {
+		return nil, err
+	}
+	d.WorkingDays = []string{}
+	if days != "" {
+		d.WorkingDays = strings.Split(days, ",")
+	}
+	return &d, nil
+}
+
+// Create inserts a new doctor; the license number must be unique
+func (r *DoctorRepository) Create(d *Doctor) error {
+	query := `
+		INSERT INTO doctors (full_name, specialty, license_number, working_days, phone, email, active, license_expires_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, d.FullName, d.Specialty, d.LicenseNumber, strings.Join(d.WorkingDays, ","),
+		d.Phone, d.Email, d.Active, d.LicenseExpiresOn).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("license number %s already registered", d.LicenseNumber)
+		}
+		return fmt.Errorf("failed to create doctor: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a doctor by ID
+func (r *DoctorRepository) GetByID(id int) (*Doctor, error) {
+	d, err := scanDoctor(r.db.conn.QueryRow("SELECT "+doctorColumns+" FROM doctors WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("doctor %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get doctor: %w", err)
+	}
+	return d, nil
+}
+
+// GetAll retrieves doctors matching the filter, by name
+func (r *DoctorRepository) GetAll(f DoctorFilter) ([]Doctor, error) {
+	query := `
+		SELECT ` + doctorColumns + ` FROM doctors
+		WHERE ($1 = '' OR lower(specialty) = lower($1)) AND (NOT $2 OR active)
+			AND ($3 = '' OR license_expires_on <= $3::date)
+		ORDER BY full_name
+	`
+
+	rows, err := r.db.conn.Query(query, f.Specialty, f.ActiveOnly, f.LicenseExpiresBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query doctors: %w", err)
+	}
+	defer rows.Close()
+
+	doctors := []Doctor{}
+	for rows.Next() {
+		d, err := scanDoctor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan doctor: %w", err)
+		}
+		doctors = append(doctors, *d)
+	}
+
+	return doctors, rows.Err()
+}
+
+// Update saves a doctor's details. A new license expiry date gets its own renewal reminder.
+func (r *DoctorRepository) Update(d *Doctor) error {
+	query := `
+		UPDATE doctors SET full_name = $2, specialty = $3, license_number = $4, working_days = $5,
+			phone = $6, email = $7, active = $8, license_expires_on = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at, to_char(license_reminded_for, 'YYYY-MM-DD')
+	`
+
+	err := r.db.conn.QueryRow(query, d.ID, d.FullName, d.Specialty, d.LicenseNumber, strings.Join(d.WorkingDays, ","),
+		d.Phone, d.Email, d.Active, d.LicenseExpiresOn).Scan(&d.CreatedAt, &d.UpdatedAt, &d.LicenseRemindedFor)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("doctor %d not found", d.ID)
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("license number %s already registered", d.LicenseNumber)
+		}
+		return fmt.Errorf("failed to update doctor: %w", err)
+	}
+
+	return nil
+}
+
+// Deactivate marks a doctor inactive. Doctors are never deleted because
+// appointments, notes and signatures keep referring to them.
+func (r *DoctorRepository) Deactivate(id int) error {
+	result, err := r.db.conn.Exec("UPDATE doctors SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate doctor: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("doctor %d not found", id)
+	}
+
+	return nil
+}
+
+// LicensesToRemind retrieves the active doctors whose license expires on or
+// before by and who have not been reminded about that expiry date yet
+func (r *DoctorRepository) LicensesToRemind(by string) ([]Doctor, error) {
+	query := `
+		SELECT ` + doctorColumns + ` FROM doctors
+		WHERE active AND license_expires_on <= $1::date
+			AND license_reminded_for IS DISTINCT FROM license_expires_on
+		ORDER BY license_expires_on, full_name
+	`
+
+	rows, err := r.db.conn.Query(query, by)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expiring licenses: %w", err)
+	}
+	defer rows.Close()
+
+	doctors := []Doctor{}
+	for rows.Next() {
+		d, err := scanDoctor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan doctor: %w", err)
+		}
+		doctors = append(doctors, *d)
+	}
+
+	return doctors, rows.Err()
+}
+
+// MarkLicenseReminded records that a renewal reminder went out for the
+// doctor's license expiring on expiresOn
+func (r *DoctorRepository) MarkLicenseReminded(id int, expiresOn string) error {
+	result, err := r.db.conn.Exec("UPDATE doctors SET license_reminded_for = $2 WHERE id = $1", id, expiresOn)
+	if err != nil {
+		return fmt.Errorf("failed to mark license reminded: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("doctor %d not found", id)
+	}
+
+	return nil
+}
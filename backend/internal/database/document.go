@@ -0,0 +1,130 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// DocumentCategories are the kinds of file kept in a patient's record
+var DocumentCategories = []string{"referral_letter", "old_record", "consent", "lab_result", "imaging", "other"}
+
+// Document is a file uploaded to a patient's record, such as a scanned
+// referral letter or consent form. The file itself lives in document storage
+// under StorageKey and is only served through the patient's documents API.
+type Document struct {
+	ID          int       `json:"id" db:"id"`
+	PatientHN   string    `json:"patientHn" db:"patient_hn"`
+	Category    string    `json:"category" db:"category"`
+	Title       string    `json:"title" db:"title"`
+	FileName    string    `json:"fileName" db:"file_name"` // as uploaded
+	ContentType string    `json:"contentType" db:"content_type"`
+	Size        int64     `json:"size" db:"size"` // bytes
+	StorageKey  string    `json:"-" db:"storage_key"`
+	Notes       *string   `json:"notes,omitempty" db:"notes"`
+	UploadedBy  string    `json:"uploadedBy" db:"uploaded_by"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+// DocumentFilter narrows a patient's document listing; zero values match everything
+type DocumentFilter struct {
+	PatientHN string
+	Category  string
+}
+
+// DocumentRepository handles patient document database operations
+type DocumentRepository struct {
+	db *DB
+}
+
+// NewDocumentRepository creates a new document repository
+func NewDocumentRepository(db *DB) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+const documentColumns = `id, patient_hn, category, title, file_name, content_type, size, storage_key, notes, uploaded_by, created_at`
+
+func scanDocument(row interface{ Scan(...interface{}) error }) (*Document, error) {
+	var d Document
+	err := row.Scan(&d.ID, &d.PatientHN, &d.Category, &d.Title, &d.FileName, &d.ContentType, &d.Size, &d.StorageKey,
+		&d.Notes, &d.UploadedBy, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Create records an uploaded document
+func (r *DocumentRepository) Create(d *Document) error {
+	query := `
+		INSERT INTO patient_documents (patient_hn, category, title, file_name, content_type, size, storage_key, notes, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, d.PatientHN, d.Category, d.Title, d.FileName, d.ContentType, d.Size, d.StorageKey,
+		d.Notes, d.UploadedBy).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create document: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a document by ID
+func (r *DocumentRepository) GetByID(id int) (*Document, error) {
+	d, err := scanDocument(r.db.conn.QueryRow("SELECT "+documentColumns+" FROM patient_documents WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("document %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+	return d, nil
+}
+
+// List retrieves documents matching the filter, newest first
+func (r *DocumentRepository) List(f DocumentFilter) ([]Document, error) {
+	query := `
+		SELECT ` + documentColumns + ` FROM patient_documents
+		WHERE ($1 = '' OR patient_hn = $1) AND ($2 = '' OR category = $2)
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, f.PatientHN, f.Category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := []Document{}
+	for rows.Next() {
+		d, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		documents = append(documents, *d)
+	}
+
+	return documents, rows.Err()
+}
+
+// Delete removes a document's record; the caller removes the stored file
+func (r *DocumentRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM patient_documents WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("document %d not found", id)
+	}
+
+	return nil
+}
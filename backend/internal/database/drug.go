@@ -0,0 +1,169 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Drug is a catalog entry that prescriptions, favorites and billing refer to by ID
+type Drug struct {
+	ID               int       `json:"id" db:"id"`
+	GenericName      string    `json:"genericName" db:"generic_name"`       // ชื่อสามัญ, e.g. "Paracetamol"
+	BrandName        *string   `json:"brandName,omitempty" db:"brand_name"` // ชื่อการค้า, e.g. "Tylenol"
+	Strength         string    `json:"strength" db:"strength"`              // e.g. "500 mg", "120 mg/5 ml"
+	Unit             string    `json:"unit" db:"unit"`                      // dispensing unit, e.g. "tablet", "bottle"
+	DefaultDose      *string   `json:"defaultDose,omitempty" db:"default_dose"`
+	DefaultFrequency *string   `json:"defaultFrequency,omitempty" db:"default_frequency"`
+	Price            float64   `json:"price" db:"price"`                      // baht per unit
+	ImageURL         *string   `json:"imageUrl,omitempty" db:"image_url"`     // photo of the pack or tablet, in file storage
+	LeafletURL       *string   `json:"leafletUrl,omitempty" db:"leaflet_url"` // patient information leaflet, in file storage
+	Active           bool      `json:"active" db:"active"`
+	CreatedAt        time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// DisplayName is the name printed on prescriptions, e.g. "Paracetamol 500 mg (Tylenol)"
+func (d *Drug) DisplayName() string {
+	name := d.GenericName
+	if d.Strength != "" {
+		name += " " + d.Strength
+	}
+	if d.BrandName != nil && *d.BrandName != "" {
+		name += " (" + *d.BrandName + ")"
+	}
+	return name
+}
+
+// DrugFilter narrows a catalog listing; zero values match everything
+type DrugFilter struct {
+	Search     string // part of the generic or brand name
+	ActiveOnly bool
+}
+
+// DrugRepository handles drug catalog database operations
+type DrugRepository struct {
+	db *DB
+}
+
+// NewDrugRepository creates a new drug repository
+func NewDrugRepository(db *DB) *DrugRepository {
+	return &DrugRepository{db: db}
+}
+
+const drugColumns = `id, generic_name, brand_name, strength, unit, default_dose, default_frequency, price, image_url,
+	leaflet_url, active, created_at, updated_at`
+
+func scanDrug(row interface{ Scan(...interface{}) error }) (*Drug, error) {
+	var d Drug
+	err := row.Scan(&d.ID, &d.GenericName, &d.BrandName, &d.Strength, &d.Unit, &d.DefaultDose, &d.DefaultFrequency,
+		&d.Price, &d.ImageURL, &d.LeafletURL, &d.Active, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Create adds a drug to the catalog; generic name, brand and strength must be unique together
+func (r *DrugRepository) Create(d *Drug) error {
+	query := `
+		INSERT INTO drugs (generic_name, brand_name, strength, unit, default_dose, default_frequency, price, image_url,
+			leaflet_url, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, d.GenericName, d.BrandName, d.Strength, d.Unit, d.DefaultDose, d.DefaultFrequency,
+		d.Price, d.ImageURL, d.LeafletURL, d.Active).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("%s is already in the catalog", d.DisplayName())
+		}
+		return fmt.Errorf("failed to create drug: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a drug by ID
+func (r *DrugRepository) GetByID(id int) (*Drug, error) {
+	d, err := scanDrug(r.db.conn.QueryRow("SELECT "+drugColumns+" FROM drugs WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("drug %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get drug: %w", err)
+	}
+	return d, nil
+}
+
+// GetAll retrieves drugs matching the filter, by generic name
+func (r *DrugRepository) GetAll(f DrugFilter) ([]Drug, error) {
+	query := `
+		SELECT ` + drugColumns + ` FROM drugs
+		WHERE ($1 = '' OR generic_name ILIKE '%' || $1 || '%' OR brand_name ILIKE '%' || $1 || '%') AND (NOT $2 OR active)
+		ORDER BY generic_name, strength
+	`
+
+	rows, err := r.db.conn.Query(query, f.Search, f.ActiveOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query drugs: %w", err)
+	}
+	defer rows.Close()
+
+	drugs := []Drug{}
+	for rows.Next() {
+		d, err := scanDrug(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan drug: %w", err)
+		}
+		drugs = append(drugs, *d)
+	}
+
+	return drugs, rows.Err()
+}
+
+// Update saves a drug's details
+func (r *DrugRepository) Update(d *Drug) error {
+	query := `
+		UPDATE drugs SET generic_name = $2, brand_name = $3, strength = $4, unit = $5, default_dose = $6,
+			default_frequency = $7, price = $8, image_url = $9, leaflet_url = $10, active = $11, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, d.ID, d.GenericName, d.BrandName, d.Strength, d.Unit, d.DefaultDose,
+		d.DefaultFrequency, d.Price, d.ImageURL, d.LeafletURL, d.Active).Scan(&d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("drug %d not found", d.ID)
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("%s is already in the catalog", d.DisplayName())
+		}
+		return fmt.Errorf("failed to update drug: %w", err)
+	}
+
+	return nil
+}
+
+// Deactivate withdraws a drug from the catalog. Drugs are never deleted because
+// prescriptions and invoices keep referring to them.
+func (r *DrugRepository) Deactivate(id int) error {
+	result, err := r.db.conn.Exec("UPDATE drugs SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate drug: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("drug %d not found", id)
+	}
+
+	return nil
+}
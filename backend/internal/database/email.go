@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Email templates
+const (
+	EmailAppointmentConfirmation = "appointment_confirmation" // sent when an appointment is booked
+	EmailLabResultReady          = "lab_result_ready"         // sent when a lab result is filed in the patient's record
+	EmailPatientMessage          = "patient_message"          // a message staff send the patient, e.g. a questionnaire link
+)
+
+// EmailTemplates lists every email template
+var EmailTemplates = []string{EmailAppointmentConfirmation, EmailLabResultReady, EmailPatientMessage}
+
+// EmailMessage is an email in the outbox, rendered from a template when it
+// was queued. It waits as pending until the email sender delivers it; a
+// failed delivery goes back to pending for a retry until the retry policy
+// runs out, then stays failed.
+type EmailMessage struct {
+	ID            int        `json:"id" db:"id"`
+	Template      string     `json:"template" db:"template"` // one of EmailTemplates
+	To            string     `json:"to" db:"recipient"`
+	Subject       string     `json:"subject" db:"subject"`
+	Body          string     `json:"body" db:"body"` // plain text
+	PatientHN     *string    `json:"patientHn,omitempty" db:"patient_hn"`
+	AppointmentID *int       `json:"appointmentId,omitempty" db:"appointment_id"`
+	DocumentID    *int       `json:"documentId,omitempty" db:"document_id"`
+	Status        string     `json:"status" db:"status"` // pending, sent or failed
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     *string    `json:"lastError,omitempty" db:"last_error"`
+	RetryAt       *time.Time `json:"retryAt,omitempty" db:"retry_at"`
+	SentAt        *time.Time `json:"sentAt,omitempty" db:"sent_at"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// EmailFilter narrows an outbox listing; zero values match everything
+type EmailFilter struct {
+	PatientHN string
+	Template  string
+	Status    string
+	Due       bool // leaves out emails waiting to be retried
+}
+
+// EmailRepository handles the email outbox
+type EmailRepository struct {
+	db *DB
+}
+
+// NewEmailRepository creates a new email repository
+func NewEmailRepository(db *DB) *EmailRepository {
+	return &EmailRepository{db: db}
+}
+
+const emailColumns = `id, template, recipient, subject, body, patient_hn, appointment_id, document_id, status, attempts, last_error,
+	retry_at, sent_at, created_at`
+
+func scanEmail(row interface{ Scan(...interface{}) error }) (*EmailMessage, error) {
+	var m EmailMessage
+	err := row.Scan(&m.ID, &m.Template, &m.To, &m.Subject, &m.Body, &m.PatientHN, &m.AppointmentID, &m.DocumentID, &m.Status,
+		&m.Attempts, &m.LastError, &m.RetryAt, &m.SentAt, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Enqueue adds an email to the outbox
+func (r *EmailRepository) Enqueue(m *EmailMessage) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO email_outbox (template, recipient, subject, body, patient_hn, appointment_id, document_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, status, created_at
+	`, m.Template, m.To, m.Subject, m.Body, m.PatientHN, m.AppointmentID, m.DocumentID).Scan(&m.ID, &m.Status, &m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an email from the outbox
+func (r *EmailRepository) GetByID(id int) (*EmailMessage, error) {
+	m, err := scanEmail(r.db.conn.QueryRow("SELECT "+emailColumns+" FROM email_outbox WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("email %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+	return m, nil
+}
+
+// List retrieves the emails matching the filter, oldest first
+func (r *EmailRepository) List(f EmailFilter) ([]EmailMessage, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+emailColumns+` FROM email_outbox
+		WHERE ($1 = '' OR patient_hn = $1) AND ($2 = '' OR template = $2) AND ($3 = '' OR status = $3)
+			AND (NOT $4 OR retry_at IS NULL OR retry_at <= CURRENT_TIMESTAMP)
+		ORDER BY created_at, id
+	`, f.PatientHN, f.Template, f.Status, f.Due)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []EmailMessage{}
+	for rows.Next() {
+		m, err := scanEmail(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		emails = append(emails, *m)
+	}
+
+	return emails, rows.Err()
+}
+
+// MarkSent records that a pending email was delivered
+func (r *EmailRepository) MarkSent(id int) (*EmailMessage, error) {
+	m, err := scanEmail(r.db.conn.QueryRow(`
+		UPDATE email_outbox SET status = 'sent', sent_at = CURRENT_TIMESTAMP, retry_at = NULL
+		WHERE id = $1 AND status = 'pending'
+		RETURNING `+emailColumns, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, err := r.GetByID(id); err != nil {
+				return nil, err
+			}
+			return nil, apperr.Conflict("email %d is no longer pending", id)
+		}
+		return nil, fmt.Errorf("failed to mark email sent: %w", err)
+	}
+	return m, nil
+}
+
+// RecordFailure records a failed delivery of a pending email. retryAt gives
+// when it may be tried again after so many failed attempts, or nil when the
+// retry policy has run out and the email stays failed.
+func (r *EmailRepository) RecordFailure(id int, reason string, retryAt func(attempts int) *time.Time) (*EmailMessage, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin email failure: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	var attempts int
+	err = tx.QueryRow("SELECT status, attempts FROM email_outbox WHERE id = $1 FOR UPDATE", id).Scan(&status, &attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("email %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
+	if status != NotificationPending {
+		return nil, apperr.Conflict("email %d is no longer pending", id)
+	}
+
+	attempts++
+	next := retryAt(attempts)
+	status = NotificationPending
+	if next == nil {
+		status = NotificationFailed
+	}
+	m, err := scanEmail(tx.QueryRow(`
+		UPDATE email_outbox SET status = $2, attempts = $3, last_error = $4, retry_at = $5
+		WHERE id = $1
+		RETURNING `+emailColumns, id, status, attempts, reason, next))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit email failure: %w", err)
+	}
+	return m, nil
+}
+
+// Requeue puts a failed email back in the outbox with a fresh set of attempts
+func (r *EmailRepository) Requeue(id int) (*EmailMessage, error) {
+	m, err := scanEmail(r.db.conn.QueryRow(`
+		UPDATE email_outbox SET status = 'pending', attempts = 0, retry_at = NULL
+		WHERE id = $1 AND status = 'failed'
+		RETURNING `+emailColumns, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, err := r.GetByID(id); err != nil {
+				return nil, err
+			}
+			return nil, apperr.Conflict("email %d is not failed", id)
+		}
+		return nil, fmt.Errorf("failed to requeue email: %w", err)
+	}
+	return m, nil
+}
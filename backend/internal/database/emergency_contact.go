@@ -0,0 +1,146 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// EmergencyContactRelationships are how a contact is related to the patient
+var EmergencyContactRelationships = []string{
+	"spouse",
+	"parent",
+	"child",
+	"sibling",
+	"relative",
+	"friend",
+	"caregiver",
+	"other",
+}
+
+// EmergencyContact is someone to call about a patient in an emergency. A
+// patient may have several; they are called in priority order.
+type EmergencyContact struct {
+	ID           int       `json:"id" db:"id"`
+	PatientHN    string    `json:"patientHn" db:"patient_hn"`
+	Name         string    `json:"name" db:"name"`
+	Relationship string    `json:"relationship" db:"relationship"`
+	Phone        string    `json:"phone" db:"phone"`           // digits only, e.g. "0812345678"
+	Priority     int       `json:"priority" db:"priority"`     // 1 is called first
+	Notes        *string   `json:"notes,omitempty" db:"notes"` // e.g. "ติดต่อได้หลัง 18:00"
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// EmergencyContactRepository handles emergency contact database operations
+type EmergencyContactRepository struct {
+	db *DB
+}
+
+// NewEmergencyContactRepository creates a new emergency contact repository
+func NewEmergencyContactRepository(db *DB) *EmergencyContactRepository {
+	return &EmergencyContactRepository{db: db}
+}
+
+const emergencyContactColumns = `id, patient_hn, name, relationship, phone, priority, notes, created_at, updated_at`
+
+func scanEmergencyContact(row interface{ Scan(...interface{}) error }) (*EmergencyContact, error) {
+	var c EmergencyContact
+	err := row.Scan(&c.ID, &c.PatientHN, &c.Name, &c.Relationship, &c.Phone, &c.Priority, &c.Notes,
+		&c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Create adds an emergency contact to a patient
+func (r *EmergencyContactRepository) Create(c *EmergencyContact) error {
+	query := `
+		INSERT INTO emergency_contacts (patient_hn, name, relationship, phone, priority, notes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, c.PatientHN, c.Name, c.Relationship, c.Phone, c.Priority, c.Notes).
+		Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create emergency contact: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an emergency contact by ID
+func (r *EmergencyContactRepository) GetByID(id int) (*EmergencyContact, error) {
+	c, err := scanEmergencyContact(r.db.conn.QueryRow("SELECT "+emergencyContactColumns+" FROM emergency_contacts WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("emergency contact %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get emergency contact: %w", err)
+	}
+	return c, nil
+}
+
+// GetByPatient retrieves a patient's emergency contacts in priority order
+func (r *EmergencyContactRepository) GetByPatient(hn string) ([]EmergencyContact, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+emergencyContactColumns+` FROM emergency_contacts
+		WHERE patient_hn = $1
+		ORDER BY priority, id
+	`, hn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emergency contacts: %w", err)
+	}
+	defer rows.Close()
+
+	contacts := []EmergencyContact{}
+	for rows.Next() {
+		c, err := scanEmergencyContact(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan emergency contact: %w", err)
+		}
+		contacts = append(contacts, *c)
+	}
+
+	return contacts, rows.Err()
+}
+
+// Update replaces a contact's details; the patient it belongs to does not change
+func (r *EmergencyContactRepository) Update(c *EmergencyContact) error {
+	updated, err := scanEmergencyContact(r.db.conn.QueryRow(`
+		UPDATE emergency_contacts SET name = $2, relationship = $3, phone = $4, priority = $5, notes = $6,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+emergencyContactColumns, c.ID, c.Name, c.Relationship, c.Phone, c.Priority, c.Notes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("emergency contact %d not found", c.ID)
+		}
+		return fmt.Errorf("failed to update emergency contact: %w", err)
+	}
+	*c = *updated
+
+	return nil
+}
+
+// Delete removes an emergency contact
+func (r *EmergencyContactRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM emergency_contacts WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete emergency contact: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("emergency contact %d not found", id)
+	}
+
+	return nil
+}
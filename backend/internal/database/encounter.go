@@ -0,0 +1,232 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Encounter states
+const (
+	EncounterOpen      = "open"
+	EncounterClosed    = "closed"
+	EncounterCancelled = "cancelled" // the patient left or the visit was opened in error; ended without being seen
+)
+
+// Encounter is one patient visit: why the patient came, what the attending doctor
+// found and what was done. Clinical notes, forms, diagnosis codes and questionnaires
+// refer to it as visitId.
+type Encounter struct {
+	ID               int        `json:"id" db:"id"`
+	PatientHN        string     `json:"patientHn" db:"patient_hn"`
+	AppointmentID    *int       `json:"appointmentId,omitempty" db:"appointment_id"`
+	GroupSessionID   *int       `json:"groupSessionId,omitempty" db:"group_session_id"`
+	DoctorID         *int       `json:"doctorId,omitempty" db:"doctor_id"`
+	DoctorName       string     `json:"doctorName" db:"doctor_name"`         // attending doctor
+	ChiefComplaint   string     `json:"chiefComplaint" db:"chief_complaint"` // อาการสำคัญ
+	Diagnosis        *string    `json:"diagnosis,omitempty" db:"diagnosis"`
+	DiagnosisCode    *string    `json:"diagnosisCode,omitempty" db:"diagnosis_code"` // ICD-10, validated against the code table
+	Treatment        *string    `json:"treatment,omitempty" db:"treatment"`
+	Subjective       string     `json:"subjective" db:"subjective"`                         // S: the patient's account, history
+	Objective        string     `json:"objective" db:"objective"`                           // O: examination findings and results
+	Assessment       string     `json:"assessment" db:"assessment"`                         // A: impression, differential diagnosis
+	Plan             string     `json:"plan" db:"plan"`                                     // P: investigations, treatment, advice, follow-up
+	SOAPVersion      int        `json:"soapVersion" db:"soap_version"`                      // 0 until the SOAP fields are first saved
+	Status           string     `json:"status" db:"status"`                                 // open/closed/cancelled
+	CancelReasonCode *string    `json:"cancelReasonCode,omitempty" db:"cancel_reason_code"` // from the cancellation taxonomy
+	CancelReason     *string    `json:"cancelReason,omitempty" db:"cancel_reason"`
+	StartedAt        time.Time  `json:"startedAt" db:"started_at"`
+	EndedAt          *time.Time `json:"endedAt,omitempty" db:"ended_at"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// EncounterRepository handles visit database operations
+type EncounterRepository struct {
+	db *DB
+}
+
+// NewEncounterRepository creates a new encounter repository
+func NewEncounterRepository(db *DB) *EncounterRepository {
+	return &EncounterRepository{db: db}
+}
+
+const encounterColumns = `id, patient_hn, appointment_id, group_session_id, doctor_id, doctor_name, chief_complaint,
+	diagnosis, diagnosis_code, treatment, subjective, objective, assessment, plan, soap_version,
+	status, cancel_reason_code, cancel_reason, started_at, ended_at, created_at, updated_at`
+
+func scanEncounter(row interface{ Scan(...interface{}) error }) (*Encounter, error) {
+	var e Encounter
+	err := row.Scan(&e.ID, &e.PatientHN, &e.AppointmentID, &e.GroupSessionID, &e.DoctorID, &e.DoctorName,
+		&e.ChiefComplaint, &e.Diagnosis, &e.DiagnosisCode, &e.Treatment, &e.Subjective, &e.Objective, &e.Assessment, &e.Plan,
+		&e.SOAPVersion, &e.Status, &e.CancelReasonCode, &e.CancelReason, &e.StartedAt, &e.EndedAt, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Create opens a visit
+func (r *EncounterRepository) Create(e *Encounter) error {
+	query := `
+		INSERT INTO encounters (patient_hn, appointment_id, group_session_id, doctor_id, doctor_name,
+			chief_complaint, diagnosis, diagnosis_code, treatment, status, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, e.PatientHN, e.AppointmentID, e.GroupSessionID, e.DoctorID, e.DoctorName,
+		e.ChiefComplaint, e.Diagnosis, e.DiagnosisCode, e.Treatment, e.Status, e.StartedAt).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("visit refers to an appointment, group session or doctor that does not exist")
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("appointment %d already has a visit", *e.AppointmentID)
+		}
+		return fmt.Errorf("failed to create encounter: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a visit by ID, from the archive if it has been archived
+func (r *EncounterRepository) GetByID(id int) (*Encounter, error) {
+	e, err := scanEncounter(r.db.conn.QueryRow("SELECT "+encounterColumns+" FROM encounters WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		e, err = scanEncounter(r.db.conn.QueryRow("SELECT "+encounterColumns+" FROM "+archived("encounters")+" WHERE id = $1", id))
+		err = notArchived(err)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("visit %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get encounter: %w", err)
+	}
+	return e, nil
+}
+
+// GetByPatient retrieves a patient's visits, most recent first; archived
+// visits, being older, follow the rest
+func (r *EncounterRepository) GetByPatient(hn string) ([]Encounter, error) {
+	encounters, err := r.getByPatient("encounters", hn)
+	if err != nil {
+		return nil, err
+	}
+	older, err := r.getByPatient(archived("encounters"), hn)
+	if err != nil {
+		if undefinedTable(err) {
+			return encounters, nil
+		}
+		return nil, err
+	}
+	return append(encounters, older...), nil
+}
+
+func (r *EncounterRepository) getByPatient(table, hn string) ([]Encounter, error) {
+	query := "SELECT " + encounterColumns + " FROM " + table + " WHERE patient_hn = $1 ORDER BY started_at DESC"
+
+	rows, err := r.db.conn.Query(query, hn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query encounters: %w", err)
+	}
+	defer rows.Close()
+
+	encounters := []Encounter{}
+	for rows.Next() {
+		e, err := scanEncounter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan encounter: %w", err)
+		}
+		encounters = append(encounters, *e)
+	}
+
+	return encounters, rows.Err()
+}
+
+// GetClosedBetween retrieves the visits that ended from from up to to, in the
+// order they ended; periods reported on are recent, so the archive is not read
+func (r *EncounterRepository) GetClosedBetween(from, to time.Time) ([]Encounter, error) {
+	return r.endedBetween(EncounterClosed, from, to)
+}
+
+// GetCancelledBetween retrieves the visits cancelled from from up to to, in
+// the order they were cancelled
+func (r *EncounterRepository) GetCancelledBetween(from, to time.Time) ([]Encounter, error) {
+	return r.endedBetween(EncounterCancelled, from, to)
+}
+
+func (r *EncounterRepository) endedBetween(status string, from, to time.Time) ([]Encounter, error) {
+	query := "SELECT " + encounterColumns + " FROM encounters WHERE status = $1 AND ended_at >= $2 AND ended_at < $3 ORDER BY ended_at, id"
+
+	rows, err := r.db.conn.Query(query, status, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query encounters: %w", err)
+	}
+	defer rows.Close()
+
+	encounters := []Encounter{}
+	for rows.Next() {
+		e, err := scanEncounter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan encounter: %w", err)
+		}
+		encounters = append(encounters, *e)
+	}
+
+	return encounters, rows.Err()
+}
+
+// Update saves the complaint, findings and attending doctor of an open visit
+func (r *EncounterRepository) Update(e *Encounter) error {
+	updated, err := scanEncounter(r.db.conn.QueryRow(`
+		UPDATE encounters SET doctor_id = $2, doctor_name = $3, chief_complaint = $4, diagnosis = $5,
+			diagnosis_code = $6, treatment = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'open'
+		RETURNING `+encounterColumns, e.ID, e.DoctorID, e.DoctorName, e.ChiefComplaint, e.Diagnosis, e.DiagnosisCode, e.Treatment))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("visit %d is not open", e.ID)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("doctor %d does not exist", *e.DoctorID)
+		}
+		return fmt.Errorf("failed to update encounter: %w", err)
+	}
+	*e = *updated
+
+	return nil
+}
+
+// Close ends an open visit
+func (r *EncounterRepository) Close(id int) (*Encounter, error) {
+	e, err := scanEncounter(r.db.conn.QueryRow(`
+		UPDATE encounters SET status = 'closed', ended_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'open'
+		RETURNING `+encounterColumns, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("visit %d is not open", id)
+		}
+		return nil, fmt.Errorf("failed to close encounter: %w", err)
+	}
+	return e, nil
+}
+
+// Cancel ends an open visit that the patient was not seen in
+func (r *EncounterRepository) Cancel(id int, c Cancellation) (*Encounter, error) {
+	e, err := scanEncounter(r.db.conn.QueryRow(`
+		UPDATE encounters SET status = 'cancelled', cancel_reason_code = $2, cancel_reason = $3,
+			ended_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'open'
+		RETURNING `+encounterColumns, id, c.ReasonCode, c.Note))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("visit %d is not open", id)
+		}
+		return nil, fmt.Errorf("failed to cancel encounter: %w", err)
+	}
+	return e, nil
+}
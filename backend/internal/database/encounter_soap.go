@@ -0,0 +1,109 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// EncounterSOAPVersion is one saved version of a visit's SOAP fields. Every
+// save adds the next version, so the history shows who documented what and
+// when; edits after the visit closed carry a reason.
+type EncounterSOAPVersion struct {
+	VisitID    int       `json:"visitId" db:"visit_id"`
+	Version    int       `json:"version" db:"version"`
+	Subjective string    `json:"subjective" db:"subjective"`
+	Objective  string    `json:"objective" db:"objective"`
+	Assessment string    `json:"assessment" db:"assessment"`
+	Plan       string    `json:"plan" db:"plan"`
+	AuthorName string    `json:"authorName" db:"author_name"`
+	Reason     *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// SaveSOAP stores v as the visit's SOAP fields and adds it to the history.
+// v.Version must be the version after the one stored, so an editor working
+// from an older version cannot overwrite a newer one.
+func (r *EncounterRepository) SaveSOAP(v *EncounterSOAPVersion) (*Encounter, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin SOAP save: %w", err)
+	}
+	defer tx.Rollback()
+
+	var stored int
+	err = tx.QueryRow("SELECT soap_version FROM encounters WHERE id = $1 FOR UPDATE", v.VisitID).Scan(&stored)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("visit %d not found", v.VisitID)
+		}
+		return nil, fmt.Errorf("failed to lock encounter: %w", err)
+	}
+	if stored != v.Version-1 {
+		return nil, apperr.Conflict("visit %d's SOAP note is at version %d", v.VisitID, stored)
+	}
+
+	v.CreatedAt = time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO encounter_soap_versions (visit_id, version, subjective, objective, assessment, plan, author_name, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, v.VisitID, v.Version, v.Subjective, v.Objective, v.Assessment, v.Plan, v.AuthorName, v.Reason, v.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store SOAP version: %w", err)
+	}
+
+	e, err := scanEncounter(tx.QueryRow(`
+		UPDATE encounters SET subjective = $2, objective = $3, assessment = $4, plan = $5, soap_version = $6,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+encounterColumns, v.VisitID, v.Subjective, v.Objective, v.Assessment, v.Plan, v.Version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save SOAP note: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit SOAP save: %w", err)
+	}
+	return e, nil
+}
+
+// GetSOAPVersions retrieves the saved versions of a visit's SOAP fields,
+// oldest first. A visit is archived whole, so an archived visit's versions
+// are all in the archive.
+func (r *EncounterRepository) GetSOAPVersions(visitID int) ([]EncounterSOAPVersion, error) {
+	const where = " WHERE visit_id = $1 ORDER BY version"
+	versions, err := r.querySOAPVersions("encounter_soap_versions"+where, visitID)
+	if err != nil || len(versions) > 0 {
+		return versions, err
+	}
+	versions, err = r.querySOAPVersions(archived("encounter_soap_versions")+where, visitID)
+	if undefinedTable(err) {
+		return []EncounterSOAPVersion{}, nil
+	}
+	return versions, err
+}
+
+func (r *EncounterRepository) querySOAPVersions(from string, visitID int) ([]EncounterSOAPVersion, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT visit_id, version, subjective, objective, assessment, plan, author_name, reason, created_at
+		FROM `+from, visitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SOAP versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []EncounterSOAPVersion{}
+	for rows.Next() {
+		var v EncounterSOAPVersion
+		err := rows.Scan(&v.VisitID, &v.Version, &v.Subjective, &v.Objective, &v.Assessment, &v.Plan,
+			&v.AuthorName, &v.Reason, &v.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan SOAP version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
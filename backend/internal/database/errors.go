@@ -0,0 +1,22 @@
+package database
+
+import "strings"
+
+// uniqueViolation reports whether err is a Postgres unique constraint violation (SQLSTATE 23505)
+func uniqueViolation(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "duplicate key value") || strings.Contains(err.Error(), "23505"))
+}
+
+// foreignKeyViolation reports whether err is a Postgres foreign key violation (SQLSTATE 23503)
+func foreignKeyViolation(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "violates foreign key constraint") || strings.Contains(err.Error(), "23503"))
+}
+
+// undefinedTable reports whether err is a Postgres reference to a table that does not exist (SQLSTATE 42P01)
+func undefinedTable(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := err.Error()
+	return strings.Contains(message, "42P01") || (strings.Contains(message, "relation") && strings.Contains(message, "does not exist"))
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// SigningKey is a prescriber's e-signature key pair
+type SigningKey struct {
+	ID            int        `json:"id" db:"id"`
+	DoctorID      int        `json:"doctorId" db:"doctor_id"`
+	DoctorName    string     `json:"doctorName" db:"doctor_name"`
+	LicenseNumber string     `json:"licenseNumber" db:"license_number"` // เลขที่ใบอนุญาตประกอบวิชาชีพ
+	PublicKey     []byte     `json:"publicKey" db:"public_key"`
+	SealedKey     []byte     `json:"-" db:"sealed_key"` // AES-GCM sealed private key
+	Active        bool       `json:"active" db:"active"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	RevokedAt     *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+}
+
+// PrescriptionSignature records a signed prescription document
+type PrescriptionSignature struct {
+	ID               int       `json:"id" db:"id"`
+	PrescriptionID   int       `json:"prescriptionId" db:"prescription_id"`
+	KeyID            int       `json:"keyId" db:"key_id"`
+	DoctorName       string    `json:"doctorName" db:"doctor_name"`
+	LicenseNumber    string    `json:"licenseNumber" db:"license_number"`
+	DocumentHash     string    `json:"documentHash" db:"document_hash"` // SHA-256 hex of the signed PDF
+	Signature        []byte    `json:"signature" db:"signature"`
+	VerificationCode string    `json:"verificationCode" db:"verification_code"`
+	SignedAt         time.Time `json:"signedAt" db:"signed_at"`
+}
+
+// SignatureRepository handles e-signature key and signature database operations
+type SignatureRepository struct {
+	db *DB
+}
+
+// NewSignatureRepository creates a new signature repository
+func NewSignatureRepository(db *DB) *SignatureRepository {
+	return &SignatureRepository{db: db}
+}
+
+// CreateKey stores a new key and revokes the doctor's previous active key
+func (r *SignatureRepository) CreateKey(k *SigningKey) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin key rotation: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE signing_keys SET active = FALSE, revoked_at = CURRENT_TIMESTAMP
+		WHERE doctor_id = $1 AND active
+	`, k.DoctorID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke previous key: %w", err)
+	}
+
+	k.Active = true
+	err = tx.QueryRow(`
+		INSERT INTO signing_keys (doctor_id, doctor_name, license_number, public_key, sealed_key, active)
+		VALUES ($1, $2, $3, $4, $5, TRUE)
+		RETURNING id, created_at
+	`, k.DoctorID, k.DoctorName, k.LicenseNumber, k.PublicKey, k.SealedKey).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create signing key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit key rotation: %w", err)
+	}
+
+	return nil
+}
+
+// GetActiveKey retrieves the doctor's current signing key
+func (r *SignatureRepository) GetActiveKey(doctorID int) (*SigningKey, error) {
+	query := `
+		SELECT id, doctor_id, doctor_name, license_number, public_key, sealed_key, active, created_at, revoked_at
+		FROM signing_keys
+		WHERE doctor_id = $1 AND active
+	`
+	return r.scanKey(r.db.conn.QueryRow(query, doctorID), fmt.Sprintf("active signing key for doctor %d", doctorID))
+}
+
+// GetKey retrieves a signing key by ID, including revoked keys
+func (r *SignatureRepository) GetKey(id int) (*SigningKey, error) {
+	query := `
+		SELECT id, doctor_id, doctor_name, license_number, public_key, sealed_key, active, created_at, revoked_at
+		FROM signing_keys
+		WHERE id = $1
+	`
+	return r.scanKey(r.db.conn.QueryRow(query, id), fmt.Sprintf("signing key %d", id))
+}
+
+func (r *SignatureRepository) scanKey(row *sql.Row, what string) (*SigningKey, error) {
+	var k SigningKey
+	err := row.Scan(&k.ID, &k.DoctorID, &k.DoctorName, &k.LicenseNumber, &k.PublicKey,
+		&k.SealedKey, &k.Active, &k.CreatedAt, &k.RevokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("%s not found", what)
+		}
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	return &k, nil
+}
+
+// CreateSignature stores a prescription signature
+func (r *SignatureRepository) CreateSignature(s *PrescriptionSignature) error {
+	query := `
+		INSERT INTO prescription_signatures (prescription_id, key_id, doctor_name, license_number,
+			document_hash, signature, verification_code)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, signed_at
+	`
+
+	err := r.db.conn.QueryRow(query, s.PrescriptionID, s.KeyID, s.DoctorName, s.LicenseNumber,
+		s.DocumentHash, s.Signature, s.VerificationCode).Scan(&s.ID, &s.SignedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("verification code %s already exists", s.VerificationCode)
+		}
+		return fmt.Errorf("failed to create prescription signature: %w", err)
+	}
+
+	return nil
+}
+
+// GetSignatureByCode retrieves a prescription signature by its public verification code
+func (r *SignatureRepository) GetSignatureByCode(code string) (*PrescriptionSignature, error) {
+	query := `
+		SELECT id, prescription_id, key_id, doctor_name, license_number, document_hash,
+		       signature, verification_code, signed_at
+		FROM prescription_signatures
+		WHERE verification_code = $1
+	`
+
+	var s PrescriptionSignature
+	err := r.db.conn.QueryRow(query, code).Scan(&s.ID, &s.PrescriptionID, &s.KeyID, &s.DoctorName,
+		&s.LicenseNumber, &s.DocumentHash, &s.Signature, &s.VerificationCode, &s.SignedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("signature with code %s not found", code)
+		}
+		return nil, fmt.Errorf("failed to get prescription signature: %w", err)
+	}
+
+	return &s, nil
+}
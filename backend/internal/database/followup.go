@@ -0,0 +1,142 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Follow-up statuses
+const (
+	FollowUpPending   = "pending"   // the patient is still to be called back
+	FollowUpContacted = "contacted" // staff reached the patient
+	FollowUpCancelled = "cancelled" // no longer needed, e.g. the patient came in on their own
+)
+
+// FollowUp is a recall date set in a visit (นัดติดตามอาการ) so staff call the
+// patient back when it comes round
+type FollowUp struct {
+	ID           int        `json:"id" db:"id"`
+	PatientHN    string     `json:"patientHn" db:"patient_hn"`
+	PatientName  string     `json:"patientName,omitempty" db:"-"` // filled in by the follow-up lists, for calling
+	Phone        *string    `json:"phone,omitempty" db:"-"`
+	VisitID      int        `json:"visitId" db:"visit_id"`
+	DueDate      string     `json:"dueDate" db:"due_date"` // YYYY-MM-DD
+	Reason       string     `json:"reason" db:"reason"`    // e.g. "ดูผลน้ำตาลหลังปรับยา"
+	Status       string     `json:"status" db:"status"`
+	ScheduledBy  string     `json:"scheduledBy" db:"scheduled_by"`
+	ContactedAt  *time.Time `json:"contactedAt,omitempty" db:"contacted_at"`
+	ContactedBy  *string    `json:"contactedBy,omitempty" db:"contacted_by"`
+	ContactNotes *string    `json:"contactNotes,omitempty" db:"contact_notes"` // what came of the call, or why it was cancelled
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// FollowUpFilter narrows a follow-up listing; zero values match everything.
+// DueFrom and DueTo (YYYY-MM-DD) bound the due date inclusively.
+type FollowUpFilter struct {
+	PatientHN string
+	VisitID   int
+	Status    string
+	DueFrom   string
+	DueTo     string
+}
+
+// FollowUpRepository handles follow-up database operations
+type FollowUpRepository struct {
+	db *DB
+}
+
+// NewFollowUpRepository creates a new follow-up repository
+func NewFollowUpRepository(db *DB) *FollowUpRepository {
+	return &FollowUpRepository{db: db}
+}
+
+const followUpColumns = `id, patient_hn, visit_id, to_char(due_date, 'YYYY-MM-DD'), reason, status, scheduled_by,
+	contacted_at, contacted_by, contact_notes, created_at`
+
+func scanFollowUp(row interface{ Scan(...interface{}) error }) (*FollowUp, error) {
+	var f FollowUp
+	err := row.Scan(&f.ID, &f.PatientHN, &f.VisitID, &f.DueDate, &f.Reason, &f.Status, &f.ScheduledBy,
+		&f.ContactedAt, &f.ContactedBy, &f.ContactNotes, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Create schedules a follow-up
+func (r *FollowUpRepository) Create(f *FollowUp) error {
+	query := `
+		INSERT INTO follow_ups (patient_hn, visit_id, due_date, reason, status, scheduled_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, f.PatientHN, f.VisitID, f.DueDate, f.Reason, f.Status, f.ScheduledBy).
+		Scan(&f.ID, &f.CreatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("visit %d does not exist", f.VisitID)
+		}
+		return fmt.Errorf("failed to create follow-up: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a follow-up
+func (r *FollowUpRepository) GetByID(id int) (*FollowUp, error) {
+	f, err := scanFollowUp(r.db.conn.QueryRow("SELECT "+followUpColumns+" FROM follow_ups WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("follow-up %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get follow-up: %w", err)
+	}
+	return f, nil
+}
+
+// List retrieves follow-ups matching the filter, soonest due first
+func (r *FollowUpRepository) List(f FollowUpFilter) ([]FollowUp, error) {
+	query := `
+		SELECT ` + followUpColumns + ` FROM follow_ups
+		WHERE ($1 = '' OR patient_hn = $1) AND ($2 = 0 OR visit_id = $2) AND ($3 = '' OR status = $3)
+			AND ($4 = '' OR due_date >= $4::date) AND ($5 = '' OR due_date <= $5::date)
+		ORDER BY due_date, id
+	`
+
+	rows, err := r.db.conn.Query(query, f.PatientHN, f.VisitID, f.Status, f.DueFrom, f.DueTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query follow-ups: %w", err)
+	}
+	defer rows.Close()
+
+	followUps := []FollowUp{}
+	for rows.Next() {
+		fu, err := scanFollowUp(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan follow-up: %w", err)
+		}
+		followUps = append(followUps, *fu)
+	}
+
+	return followUps, rows.Err()
+}
+
+// Close moves a pending follow-up to contacted or cancelled, recording who
+// closed it and any notes
+func (r *FollowUpRepository) Close(id int, status, by string, notes *string) (*FollowUp, error) {
+	f, err := scanFollowUp(r.db.conn.QueryRow(`
+		UPDATE follow_ups SET status = $2, contacted_at = CURRENT_TIMESTAMP, contacted_by = $3, contact_notes = $4
+		WHERE id = $1 AND status = 'pending'
+		RETURNING `+followUpColumns, id, status, by, notes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("follow-up %d is no longer pending", id)
+		}
+		return nil, fmt.Errorf("failed to update follow-up: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,319 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Form field types
+const (
+	FieldTypeText        = "text"
+	FieldTypeTextarea    = "textarea"
+	FieldTypeNumber      = "number"
+	FieldTypeBoolean     = "boolean"
+	FieldTypeDate        = "date"
+	FieldTypeSelect      = "select"
+	FieldTypeMultiSelect = "multiselect"
+	FieldTypePhoto       = "photo"
+)
+
+// FormField describes one input of a structured clinical form
+type FormField struct {
+	Key       string   `json:"key"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required,omitempty"`
+	Options   []string `json:"options,omitempty"` // select/multiselect choices
+	Min       *float64 `json:"min,omitempty"`     // number bounds
+	Max       *float64 `json:"max,omitempty"`
+	Unit      string   `json:"unit,omitempty"`      // e.g. "องศา", "cm"
+	MaxPhotos int      `json:"maxPhotos,omitempty"` // photo fields, 0 = no limit
+	Help      string   `json:"help,omitempty"`
+}
+
+// FormDefinition is a clinic's structured form for a specialty
+// (e.g. dermatology lesion record, physiotherapy assessment)
+type FormDefinition struct {
+	ID        int         `json:"id" db:"id"`
+	ClinicID  int         `json:"clinicId" db:"clinic_id"`
+	Code      string      `json:"code" db:"code"` // unique per clinic, e.g. "derm-lesion"
+	Name      string      `json:"name" db:"name"`
+	Specialty string      `json:"specialty" db:"specialty"`
+	Version   int         `json:"version" db:"version"` // bumped whenever fields change
+	Fields    []FormField `json:"fields" db:"fields"`
+	Active    bool        `json:"active" db:"active"`
+	CreatedAt time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
+// FormSubmission is a filled-in form attached to a visit
+type FormSubmission struct {
+	ID          int                    `json:"id" db:"id"`
+	FormID      int                    `json:"formId" db:"form_id"`
+	FormVersion int                    `json:"formVersion" db:"form_version"`
+	VisitID     int                    `json:"visitId" db:"visit_id"`
+	PatientHN   string                 `json:"patientHn" db:"patient_hn"`
+	Answers     map[string]interface{} `json:"answers" db:"answers"`
+	SubmittedBy string                 `json:"submittedBy" db:"submitted_by"`
+	CreatedAt   time.Time              `json:"createdAt" db:"created_at"`
+}
+
+// FormAnswer is one answer value flattened for querying across submissions.
+// Multi-value answers (multiselect, photos) produce one row per value.
+type FormAnswer struct {
+	SubmissionID int       `json:"submissionId" db:"submission_id"`
+	FormID       int       `json:"formId" db:"form_id"`
+	VisitID      int       `json:"visitId" db:"visit_id"`
+	PatientHN    string    `json:"patientHn" db:"patient_hn"`
+	FieldKey     string    `json:"fieldKey" db:"field_key"`
+	ValueText    *string   `json:"valueText,omitempty" db:"value_text"`
+	ValueNumber  *float64  `json:"valueNumber,omitempty" db:"value_number"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+// AnswerQuery filters flattened answers of one field
+type AnswerQuery struct {
+	FormID   int
+	FieldKey string
+	Value    *string  // exact text match
+	Min      *float64 // numeric lower bound (inclusive)
+	Max      *float64 // numeric upper bound (inclusive)
+}
+
+// FormRepository handles structured form database operations
+type FormRepository struct {
+	db *DB
+}
+
+// NewFormRepository creates a new form repository
+func NewFormRepository(db *DB) *FormRepository {
+	return &FormRepository{db: db}
+}
+
+const formDefinitionColumns = "id, clinic_id, code, name, specialty, version, fields, active, created_at, updated_at"
+
+func scanFormDefinition(row interface{ Scan(...interface{}) error }) (*FormDefinition, error) {
+	var d FormDefinition
+	var fields []byte
+	err := row.Scan(&d.ID, &d.ClinicID, &d.Code, &d.Name, &d.Specialty, &d.Version, &fields,
+		&d.Active, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fields, &d.Fields); err != nil {
+		return nil, fmt.Errorf("invalid fields for form %d: %w", d.ID, err)
+	}
+	return &d, nil
+}
+
+// CreateDefinition stores a new form definition
+func (r *FormRepository) CreateDefinition(d *FormDefinition) error {
+	fields, err := json.Marshal(d.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode form fields: %w", err)
+	}
+
+	query := `
+		INSERT INTO form_definitions (clinic_id, code, name, specialty, version, fields, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = r.db.conn.QueryRow(query, d.ClinicID, d.Code, d.Name, d.Specialty, d.Version, fields, d.Active).
+		Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("form %s already exists for clinic %d", d.Code, d.ClinicID)
+		}
+		return fmt.Errorf("failed to create form definition: %w", err)
+	}
+
+	return nil
+}
+
+// GetDefinition retrieves a form definition by ID
+func (r *FormRepository) GetDefinition(id int) (*FormDefinition, error) {
+	query := "SELECT " + formDefinitionColumns + " FROM form_definitions WHERE id = $1"
+
+	d, err := scanFormDefinition(r.db.conn.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("form definition %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get form definition: %w", err)
+	}
+
+	return d, nil
+}
+
+// GetDefinitions retrieves the form definitions of a clinic, optionally for one specialty
+func (r *FormRepository) GetDefinitions(clinicID int, specialty string) ([]FormDefinition, error) {
+	query := "SELECT " + formDefinitionColumns + ` FROM form_definitions
+		WHERE clinic_id = $1 AND ($2 = '' OR specialty = $2)
+		ORDER BY specialty, name`
+
+	rows, err := r.db.conn.Query(query, clinicID, specialty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query form definitions: %w", err)
+	}
+	defer rows.Close()
+
+	var definitions []FormDefinition
+	for rows.Next() {
+		d, err := scanFormDefinition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan form definition: %w", err)
+		}
+		definitions = append(definitions, *d)
+	}
+
+	return definitions, nil
+}
+
+// UpdateDefinition updates a form definition
+func (r *FormRepository) UpdateDefinition(d *FormDefinition) error {
+	fields, err := json.Marshal(d.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode form fields: %w", err)
+	}
+
+	query := `
+		UPDATE form_definitions
+		SET name = $1, specialty = $2, version = $3, fields = $4, active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+		RETURNING updated_at
+	`
+
+	err = r.db.conn.QueryRow(query, d.Name, d.Specialty, d.Version, fields, d.Active, d.ID).Scan(&d.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("form definition %d not found", d.ID)
+		}
+		return fmt.Errorf("failed to update form definition: %w", err)
+	}
+
+	return nil
+}
+
+// CreateSubmission stores a submission and its flattened answers in a single transaction
+func (r *FormRepository) CreateSubmission(s *FormSubmission, answers []FormAnswer) error {
+	raw, err := json.Marshal(s.Answers)
+	if err != nil {
+		return fmt.Errorf("failed to encode form answers: %w", err)
+	}
+
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin form submission: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO form_submissions (form_id, form_version, visit_id, patient_hn, answers, submitted_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, s.FormID, s.FormVersion, s.VisitID, s.PatientHN, raw, s.SubmittedBy).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create form submission: %w", err)
+	}
+
+	for _, a := range answers {
+		_, err := tx.Exec(`
+			INSERT INTO form_answers (submission_id, form_id, visit_id, patient_hn, field_key, value_text, value_number, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, s.ID, s.FormID, s.VisitID, s.PatientHN, a.FieldKey, a.ValueText, a.ValueNumber, s.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create form answer: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit form submission: %w", err)
+	}
+
+	return nil
+}
+
+func (r *FormRepository) querySubmissions(query string, args ...interface{}) ([]FormSubmission, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query form submissions: %w", err)
+	}
+	defer rows.Close()
+
+	var submissions []FormSubmission
+	for rows.Next() {
+		var s FormSubmission
+		var raw []byte
+		err := rows.Scan(&s.ID, &s.FormID, &s.FormVersion, &s.VisitID, &s.PatientHN, &raw, &s.SubmittedBy, &s.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan form submission: %w", err)
+		}
+		if err := json.Unmarshal(raw, &s.Answers); err != nil {
+			return nil, fmt.Errorf("invalid answers for submission %d: %w", s.ID, err)
+		}
+		submissions = append(submissions, s)
+	}
+
+	return submissions, nil
+}
+
+// GetSubmissionsByVisit retrieves all form submissions of a visit, oldest first
+func (r *FormRepository) GetSubmissionsByVisit(visitID int) ([]FormSubmission, error) {
+	return r.querySubmissions(`
+		SELECT id, form_id, form_version, visit_id, patient_hn, answers, submitted_by, created_at
+		FROM form_submissions WHERE visit_id = $1 ORDER BY created_at
+	`, visitID)
+}
+
+// GetLatestSubmission retrieves the most recent submission of a form for a visit
+func (r *FormRepository) GetLatestSubmission(visitID, formID int) (*FormSubmission, error) {
+	submissions, err := r.querySubmissions(`
+		SELECT id, form_id, form_version, visit_id, patient_hn, answers, submitted_by, created_at
+		FROM form_submissions WHERE visit_id = $1 AND form_id = $2 ORDER BY created_at DESC LIMIT 1
+	`, visitID, formID)
+	if err != nil {
+		return nil, err
+	}
+	if len(submissions) == 0 {
+		return nil, apperr.NotFound("no submission of form %d for visit %d", formID, visitID)
+	}
+
+	return &submissions[0], nil
+}
+
+// QueryAnswers retrieves flattened answers of one form field matching the query
+func (r *FormRepository) QueryAnswers(q AnswerQuery) ([]FormAnswer, error) {
+	query := `
+		SELECT submission_id, form_id, visit_id, patient_hn, field_key, value_text, value_number, created_at
+		FROM form_answers
+		WHERE form_id = $1 AND field_key = $2
+			AND ($3::text IS NULL OR value_text = $3)
+			AND ($4::numeric IS NULL OR value_number >= $4)
+			AND ($5::numeric IS NULL OR value_number <= $5)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.conn.Query(query, q.FormID, q.FieldKey, q.Value, q.Min, q.Max)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query form answers: %w", err)
+	}
+	defer rows.Close()
+
+	var answers []FormAnswer
+	for rows.Next() {
+		var a FormAnswer
+		err := rows.Scan(&a.SubmissionID, &a.FormID, &a.VisitID, &a.PatientHN, &a.FieldKey,
+			&a.ValueText, &a.ValueNumber, &a.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan form answer: %w", err)
+		}
+		answers = append(answers, a)
+	}
+
+	return answers, nil
+}
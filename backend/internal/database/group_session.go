@@ -0,0 +1,249 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Group session states
+const (
+	SessionStatusScheduled = "scheduled"
+	SessionStatusCancelled = "cancelled"
+	SessionStatusCompleted = "completed"
+)
+
+// Group booking states
+const (
+	BookingStatusBooked    = "booked"
+	BookingStatusCancelled = "cancelled"
+	BookingStatusCheckedIn = "checked_in"
+)
+
+// GroupSession is one appointment slot shared by many patients,
+// e.g. a vaccination drive or a diabetes education class
+type GroupSession struct {
+	ID          int       `json:"id" db:"id"`
+	Title       string    `json:"title" db:"title"`
+	SessionType string    `json:"sessionType" db:"session_type"` // vaccination_drive, education_class, ...
+	StartsAt    time.Time `json:"startsAt" db:"starts_at"`
+	EndsAt      time.Time `json:"endsAt" db:"ends_at"`
+	Location    string    `json:"location" db:"location"`
+	Facilitator string    `json:"facilitator" db:"facilitator"`
+	Capacity    int       `json:"capacity" db:"capacity"`
+	BookedCount int       `json:"bookedCount" db:"booked_count"` // active (booked or checked-in) seats
+	Status      string    `json:"status" db:"status"`            // scheduled/cancelled/completed
+	CampaignID  *int      `json:"campaignId,omitempty" db:"campaign_id"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+// GroupBooking is one patient's seat in a group session
+type GroupBooking struct {
+	ID          int        `json:"id" db:"id"`
+	SessionID   int        `json:"sessionId" db:"session_id"`
+	PatientHN   string     `json:"patientHn" db:"patient_hn"`
+	Status      string     `json:"status" db:"status"` // booked/cancelled/checked_in
+	VisitID     *int       `json:"visitId,omitempty" db:"visit_id"`
+	BookedAt    time.Time  `json:"bookedAt" db:"booked_at"`
+	CheckedInAt *time.Time `json:"checkedInAt,omitempty" db:"checked_in_at"`
+}
+
+// GroupSessionRepository handles group session database operations
+type GroupSessionRepository struct {
+	db *DB
+}
+
+// NewGroupSessionRepository creates a new group session repository
+func NewGroupSessionRepository(db *DB) *GroupSessionRepository {
+	return &GroupSessionRepository{db: db}
+}
+
+const groupSessionSelect = `
+	SELECT s.id, s.title, s.session_type, s.starts_at, s.ends_at, s.location, s.facilitator, s.capacity,
+		(SELECT COUNT(*) FROM group_bookings b WHERE b.session_id = s.id AND b.status <> 'cancelled'),
+		s.status, s.campaign_id, s.created_at
+	FROM group_sessions s`
+
+func scanGroupSession(row interface{ Scan(...interface{}) error }) (*GroupSession, error) {
+	var s GroupSession
+	err := row.Scan(&s.ID, &s.Title, &s.SessionType, &s.StartsAt, &s.EndsAt, &s.Location, &s.Facilitator,
+		&s.Capacity, &s.BookedCount, &s.Status, &s.CampaignID, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateSession stores a new group session
+func (r *GroupSessionRepository) CreateSession(s *GroupSession) error {
+	query := `
+		INSERT INTO group_sessions (title, session_type, starts_at, ends_at, location, facilitator, capacity, status, campaign_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, s.Title, s.SessionType, s.StartsAt, s.EndsAt, s.Location, s.Facilitator,
+		s.Capacity, s.Status, s.CampaignID).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create group session: %w", err)
+	}
+
+	return nil
+}
+
+// GetSession retrieves a group session by ID
+func (r *GroupSessionRepository) GetSession(id int) (*GroupSession, error) {
+	s, err := scanGroupSession(r.db.conn.QueryRow(groupSessionSelect+" WHERE s.id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("group session %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get group session: %w", err)
+	}
+
+	return s, nil
+}
+
+// GetSessions retrieves sessions starting in [from, to), earliest first
+func (r *GroupSessionRepository) GetSessions(from, to time.Time) ([]GroupSession, error) {
+	return r.querySessions(groupSessionSelect+" WHERE s.starts_at >= $1 AND s.starts_at < $2 ORDER BY s.starts_at", from, to)
+}
+
+// GetSessionsByCampaign retrieves the slots generated for a campaign, earliest first
+func (r *GroupSessionRepository) GetSessionsByCampaign(campaignID int) ([]GroupSession, error) {
+	return r.querySessions(groupSessionSelect+" WHERE s.campaign_id = $1 ORDER BY s.starts_at", campaignID)
+}
+
+func (r *GroupSessionRepository) querySessions(query string, args ...interface{}) ([]GroupSession, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []GroupSession
+	for rows.Next() {
+		s, err := scanGroupSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group session: %w", err)
+		}
+		sessions = append(sessions, *s)
+	}
+
+	return sessions, nil
+}
+
+// UpdateSessionStatus changes a session's status
+func (r *GroupSessionRepository) UpdateSessionStatus(id int, status string) error {
+	result, err := r.db.conn.Exec("UPDATE group_sessions SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update group session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("group session %d not found", id)
+	}
+
+	return nil
+}
+
+// Book reserves a seat for a patient. The session row is locked so
+// concurrent bookings cannot exceed capacity.
+func (r *GroupSessionRepository) Book(b *GroupBooking) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin booking: %w", err)
+	}
+	defer tx.Rollback()
+
+	var capacity, booked int
+	err = tx.QueryRow("SELECT capacity FROM group_sessions WHERE id = $1 AND status = 'scheduled' FOR UPDATE", b.SessionID).Scan(&capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("group session %d is not open for booking", b.SessionID)
+		}
+		return fmt.Errorf("failed to lock group session: %w", err)
+	}
+
+	err = tx.QueryRow("SELECT COUNT(*) FROM group_bookings WHERE session_id = $1 AND status <> 'cancelled'", b.SessionID).Scan(&booked)
+	if err != nil {
+		return fmt.Errorf("failed to count bookings: %w", err)
+	}
+	if booked >= capacity {
+		return apperr.Conflict("group session %d is full", b.SessionID)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO group_bookings (session_id, patient_hn, status)
+		VALUES ($1, $2, $3)
+		RETURNING id, booked_at
+	`, b.SessionID, b.PatientHN, b.Status).Scan(&b.ID, &b.BookedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("patient %s is already booked into group session %d", b.PatientHN, b.SessionID)
+		}
+		return fmt.Errorf("failed to create booking: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit booking: %w", err)
+	}
+
+	return nil
+}
+
+// GetBookings retrieves a session's bookings in booking order
+func (r *GroupSessionRepository) GetBookings(sessionID int) ([]GroupBooking, error) {
+	query := `
+		SELECT id, session_id, patient_hn, status, visit_id, booked_at, checked_in_at
+		FROM group_bookings WHERE session_id = $1 ORDER BY booked_at
+	`
+
+	rows, err := r.db.conn.Query(query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var bookings []GroupBooking
+	for rows.Next() {
+		var b GroupBooking
+		if err := rows.Scan(&b.ID, &b.SessionID, &b.PatientHN, &b.Status, &b.VisitID, &b.BookedAt, &b.CheckedInAt); err != nil {
+			return nil, fmt.Errorf("failed to scan booking: %w", err)
+		}
+		bookings = append(bookings, b)
+	}
+
+	return bookings, nil
+}
+
+// UpdateBooking saves a booking's status, visit and check-in time
+func (r *GroupSessionRepository) UpdateBooking(b *GroupBooking) error {
+	query := `
+		UPDATE group_bookings SET status = $1, visit_id = $2, checked_in_at = $3
+		WHERE id = $4 AND session_id = $5
+	`
+
+	result, err := r.db.conn.Exec(query, b.Status, b.VisitID, b.CheckedInAt, b.ID, b.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to update booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("booking %d not found", b.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Handover note categories
+const (
+	HandoverAwaitingResult = "awaiting_result" // lab or imaging results still to come
+	HandoverCallBack       = "call_back"       // a patient to call back
+	HandoverFollowUp       = "follow_up"       // something to check on a patient
+	HandoverOther          = "other"
+)
+
+// HandoverNote is one pending issue a shift leaves for the next in a
+// department's daily handover thread. Notes are archived once they are older
+// than the handover window, so the live thread only holds recent shifts.
+type HandoverNote struct {
+	ID         int        `json:"id" db:"id"`
+	Department string     `json:"department" db:"department"` // e.g. "opd", "er", "pharmacy"
+	ShiftDate  string     `json:"shiftDate" db:"shift_date"`  // YYYY-MM-DD, the day of the shift that wrote it
+	Category   string     `json:"category" db:"category"`     // awaiting_result, call_back, follow_up, other
+	PatientHN  *string    `json:"patientHn,omitempty" db:"patient_hn"`
+	Body       string     `json:"body" db:"body"`
+	Author     string     `json:"author" db:"author"`
+	ResolvedBy *string    `json:"resolvedBy,omitempty" db:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty" db:"archived_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// HandoverFilter selects a department's notes: those of one shift date, or
+// with Date empty, the live thread of notes not yet archived
+type HandoverFilter struct {
+	Department string
+	Date       string // YYYY-MM-DD
+}
+
+// HandoverRepository handles handover note database operations
+type HandoverRepository struct {
+	db *DB
+}
+
+// NewHandoverRepository creates a new handover repository
+func NewHandoverRepository(db *DB) *HandoverRepository {
+	return &HandoverRepository{db: db}
+}
+
+const handoverNoteColumns = `id, department, to_char(shift_date, 'YYYY-MM-DD'), category, patient_hn, body, author,
+	resolved_by, resolved_at, archived_at, created_at`
+
+func scanHandoverNote(row interface{ Scan(...interface{}) error }) (*HandoverNote, error) {
+	var n HandoverNote
+	err := row.Scan(&n.ID, &n.Department, &n.ShiftDate, &n.Category, &n.PatientHN, &n.Body, &n.Author,
+		&n.ResolvedBy, &n.ResolvedAt, &n.ArchivedAt, &n.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Create adds a note to its department's thread
+func (r *HandoverRepository) Create(n *HandoverNote) error {
+	query := `
+		INSERT INTO handover_notes (department, shift_date, category, patient_hn, body, author)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, n.Department, n.ShiftDate, n.Category, n.PatientHN, n.Body, n.Author).Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create handover note: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves a department's notes, oldest first
+func (r *HandoverRepository) List(f HandoverFilter) ([]HandoverNote, error) {
+	query := `
+		SELECT ` + handoverNoteColumns + ` FROM handover_notes
+		WHERE department = $1 AND (($2 = '' AND archived_at IS NULL) OR to_char(shift_date, 'YYYY-MM-DD') = $2)
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.conn.Query(query, f.Department, f.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query handover notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []HandoverNote{}
+	for rows.Next() {
+		n, err := scanHandoverNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan handover note: %w", err)
+		}
+		notes = append(notes, *n)
+	}
+
+	return notes, rows.Err()
+}
+
+// Resolve marks a pending issue dealt with
+func (r *HandoverRepository) Resolve(id int, resolvedBy string) (*HandoverNote, error) {
+	n, err := scanHandoverNote(r.db.conn.QueryRow(`
+		UPDATE handover_notes SET resolved_by = $2, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND resolved_at IS NULL
+		RETURNING `+handoverNoteColumns, id, resolvedBy))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("handover note %d does not exist or is already resolved", id)
+		}
+		return nil, fmt.Errorf("failed to resolve handover note: %w", err)
+	}
+	return n, nil
+}
+
+// ArchiveBefore archives the notes written before cutoff, returning how many
+func (r *HandoverRepository) ArchiveBefore(cutoff time.Time) (int, error) {
+	result, err := r.db.conn.Exec(`
+		UPDATE handover_notes SET archived_at = CURRENT_TIMESTAMP
+		WHERE created_at < $1 AND archived_at IS NULL
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive handover notes: %w", err)
+	}
+
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(archived), nil
+}
@@ -0,0 +1,315 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Insurance claim statuses
+const (
+	ClaimSubmitted = "submitted"
+	ClaimApproved  = "approved"
+	ClaimRejected  = "rejected"
+	ClaimPaid      = "paid"
+)
+
+// claimTransitions lists the statuses each claim status may move to
+var claimTransitions = map[string][]string{
+	ClaimSubmitted: {ClaimApproved, ClaimRejected},
+	ClaimApproved:  {ClaimPaid},
+}
+
+// InsurancePolicy is a patient's cover with one insurer
+type InsurancePolicy struct {
+	ID            int       `json:"id" db:"id"`
+	PatientHN     string    `json:"patientHn" db:"patient_hn"`
+	Insurer       string    `json:"insurer" db:"insurer"` // e.g. "AIA", "เมืองไทยประกันชีวิต"
+	PolicyNumber  string    `json:"policyNumber" db:"policy_number"`
+	PlanName      *string   `json:"planName,omitempty" db:"plan_name"`
+	CoverageLimit *float64  `json:"coverageLimit,omitempty" db:"coverage_limit"` // most the insurer pays per claim, in baht
+	ValidFrom     string    `json:"validFrom" db:"valid_from"`                   // YYYY-MM-DD
+	ValidTo       *string   `json:"validTo,omitempty" db:"valid_to"`             // YYYY-MM-DD, inclusive; nil while the policy runs
+	Notes         *string   `json:"notes,omitempty" db:"notes"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// CoversDate reports whether the policy is in force on day (YYYY-MM-DD)
+func (p *InsurancePolicy) CoversDate(day string) bool {
+	return p.ValidFrom <= day && (p.ValidTo == nil || day <= *p.ValidTo)
+}
+
+// InsuranceClaim asks an insurer to pay for an invoice under a patient's policy.
+// An invoice can have one claim that has not been rejected.
+type InsuranceClaim struct {
+	ID              int        `json:"id" db:"id"`
+	Number          string     `json:"number" db:"-"` // e.g. "CLM-000012", derived from the ID
+	InvoiceID       int        `json:"invoiceId" db:"invoice_id"`
+	PolicyID        int        `json:"policyId" db:"policy_id"`
+	PatientHN       string     `json:"patientHn" db:"patient_hn"`
+	Insurer         string     `json:"insurer" db:"insurer"` // copied from the policy when submitted
+	Status          string     `json:"status" db:"status"`
+	ClaimedAmount   float64    `json:"claimedAmount" db:"claimed_amount"`
+	ApprovedAmount  *float64   `json:"approvedAmount,omitempty" db:"approved_amount"`
+	PaidAmount      *float64   `json:"paidAmount,omitempty" db:"paid_amount"`
+	RejectionReason *string    `json:"rejectionReason,omitempty" db:"rejection_reason"`
+	PayerReference  *string    `json:"payerReference,omitempty" db:"payer_reference"` // the insurer's claim or remittance number
+	Notes           *string    `json:"notes,omitempty" db:"notes"`
+	SubmittedBy     string     `json:"submittedBy" db:"submitted_by"`
+	SubmittedAt     time.Time  `json:"submittedAt" db:"submitted_at"`
+	DecidedAt       *time.Time `json:"decidedAt,omitempty" db:"decided_at"`
+	PaidAt          *time.Time `json:"paidAt,omitempty" db:"paid_at"`
+	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// ClaimNumber formats a claim ID the way it is quoted to insurers
+func ClaimNumber(id int) string {
+	return fmt.Sprintf("CLM-%06d", id)
+}
+
+// CanMoveTo reports whether the claim may change to status
+func (c *InsuranceClaim) CanMoveTo(status string) bool {
+	for _, s := range claimTransitions[c.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ClaimDecision is an insurer's answer to a claim: approval with the amount
+// it will pay, rejection with a reason, or payment of the approved amount
+type ClaimDecision struct {
+	Status    string
+	Amount    *float64 // approved or paid amount
+	Reason    *string  // why the claim was rejected
+	Reference *string  // the insurer's claim or remittance number
+}
+
+// ClaimFilter narrows a claim listing; zero values match everything
+type ClaimFilter struct {
+	Status    string
+	Insurer   string
+	PatientHN string
+	InvoiceID int
+}
+
+// InsuranceRepository handles insurance policy and claim database operations
+type InsuranceRepository struct {
+	db *DB
+}
+
+// NewInsuranceRepository creates a new insurance repository
+func NewInsuranceRepository(db *DB) *InsuranceRepository {
+	return &InsuranceRepository{db: db}
+}
+
+const insurancePolicyColumns = `id, patient_hn, insurer, policy_number, plan_name, coverage_limit,
+	to_char(valid_from, 'YYYY-MM-DD'), to_char(valid_to, 'YYYY-MM-DD'), notes, created_at, updated_at`
+
+func scanInsurancePolicy(row interface{ Scan(...interface{}) error }) (*InsurancePolicy, error) {
+	var p InsurancePolicy
+	err := row.Scan(&p.ID, &p.PatientHN, &p.Insurer, &p.PolicyNumber, &p.PlanName, &p.CoverageLimit,
+		&p.ValidFrom, &p.ValidTo, &p.Notes, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+const insuranceClaimColumns = `id, invoice_id, policy_id, patient_hn, insurer, status, claimed_amount, approved_amount,
+	paid_amount, rejection_reason, payer_reference, notes, submitted_by, submitted_at, decided_at, paid_at, updated_at`
+
+func scanInsuranceClaim(row interface{ Scan(...interface{}) error }) (*InsuranceClaim, error) {
+	var c InsuranceClaim
+	err := row.Scan(&c.ID, &c.InvoiceID, &c.PolicyID, &c.PatientHN, &c.Insurer, &c.Status, &c.ClaimedAmount, &c.ApprovedAmount,
+		&c.PaidAmount, &c.RejectionReason, &c.PayerReference, &c.Notes, &c.SubmittedBy, &c.SubmittedAt, &c.DecidedAt, &c.PaidAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.Number = ClaimNumber(c.ID)
+	return &c, nil
+}
+
+// CreatePolicy stores a patient's insurance policy; a policy number can be on file once per insurer
+func (r *InsuranceRepository) CreatePolicy(p *InsurancePolicy) error {
+	query := `
+		INSERT INTO insurance_policies (patient_hn, insurer, policy_number, plan_name, coverage_limit, valid_from, valid_to, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, p.PatientHN, p.Insurer, p.PolicyNumber, p.PlanName, p.CoverageLimit, p.ValidFrom, p.ValidTo, p.Notes).
+		Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("%s policy %s is already on file", p.Insurer, p.PolicyNumber)
+		}
+		return fmt.Errorf("failed to create insurance policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetPolicy retrieves an insurance policy by ID
+func (r *InsuranceRepository) GetPolicy(id int) (*InsurancePolicy, error) {
+	p, err := scanInsurancePolicy(r.db.conn.QueryRow("SELECT "+insurancePolicyColumns+" FROM insurance_policies WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("insurance policy %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get insurance policy: %w", err)
+	}
+	return p, nil
+}
+
+// GetPoliciesByPatient retrieves a patient's policies, most recently started first
+func (r *InsuranceRepository) GetPoliciesByPatient(hn string) ([]InsurancePolicy, error) {
+	rows, err := r.db.conn.Query("SELECT "+insurancePolicyColumns+" FROM insurance_policies WHERE patient_hn = $1 ORDER BY valid_from DESC, id DESC", hn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query insurance policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []InsurancePolicy{}
+	for rows.Next() {
+		p, err := scanInsurancePolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan insurance policy: %w", err)
+		}
+		policies = append(policies, *p)
+	}
+
+	return policies, rows.Err()
+}
+
+// UpdatePolicy replaces a policy's details; the patient it belongs to does not change
+func (r *InsuranceRepository) UpdatePolicy(p *InsurancePolicy) error {
+	updated, err := scanInsurancePolicy(r.db.conn.QueryRow(`
+		UPDATE insurance_policies SET insurer = $2, policy_number = $3, plan_name = $4, coverage_limit = $5,
+			valid_from = $6, valid_to = $7, notes = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+insurancePolicyColumns, p.ID, p.Insurer, p.PolicyNumber, p.PlanName, p.CoverageLimit, p.ValidFrom, p.ValidTo, p.Notes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("insurance policy %d not found", p.ID)
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("%s policy %s is already on file", p.Insurer, p.PolicyNumber)
+		}
+		return fmt.Errorf("failed to update insurance policy: %w", err)
+	}
+	*p = *updated
+
+	return nil
+}
+
+// DeletePolicy removes a policy entered by mistake; policies that have been
+// claimed against are kept, ended by setting validTo instead
+func (r *InsuranceRepository) DeletePolicy(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM insurance_policies WHERE id = $1", id)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Conflict("insurance policy %d has claims; set validTo to end it instead", id)
+		}
+		return fmt.Errorf("failed to delete insurance policy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("insurance policy %d not found", id)
+	}
+
+	return nil
+}
+
+// CreateClaim submits a claim
+func (r *InsuranceRepository) CreateClaim(c *InsuranceClaim) error {
+	query := `
+		INSERT INTO insurance_claims (invoice_id, policy_id, patient_hn, insurer, status, claimed_amount, notes, submitted_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, submitted_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, c.InvoiceID, c.PolicyID, c.PatientHN, c.Insurer, c.Status, c.ClaimedAmount, c.Notes, c.SubmittedBy).
+		Scan(&c.ID, &c.SubmittedAt, &c.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("invoice %s already has a claim in progress", InvoiceNumber(c.InvoiceID))
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("invoice %d or insurance policy %d does not exist", c.InvoiceID, c.PolicyID)
+		}
+		return fmt.Errorf("failed to create insurance claim: %w", err)
+	}
+	c.Number = ClaimNumber(c.ID)
+
+	return nil
+}
+
+// GetClaim retrieves an insurance claim by ID
+func (r *InsuranceRepository) GetClaim(id int) (*InsuranceClaim, error) {
+	c, err := scanInsuranceClaim(r.db.conn.QueryRow("SELECT "+insuranceClaimColumns+" FROM insurance_claims WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("insurance claim %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get insurance claim: %w", err)
+	}
+	return c, nil
+}
+
+// ListClaims retrieves claims matching the filter, oldest submission first so
+// the back office works through them in order
+func (r *InsuranceRepository) ListClaims(f ClaimFilter) ([]InsuranceClaim, error) {
+	query := `
+		SELECT ` + insuranceClaimColumns + ` FROM insurance_claims
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR insurer = $2) AND ($3 = '' OR patient_hn = $3) AND ($4 = 0 OR invoice_id = $4)
+		ORDER BY submitted_at, id
+	`
+
+	rows, err := r.db.conn.Query(query, f.Status, f.Insurer, f.PatientHN, f.InvoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query insurance claims: %w", err)
+	}
+	defer rows.Close()
+
+	claims := []InsuranceClaim{}
+	for rows.Next() {
+		c, err := scanInsuranceClaim(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan insurance claim: %w", err)
+		}
+		claims = append(claims, *c)
+	}
+
+	return claims, rows.Err()
+}
+
+// UpdateClaimStatus records the insurer's decision on a claim that is still in
+// status from, stamping when it was decided or paid
+func (r *InsuranceRepository) UpdateClaimStatus(id int, from string, d ClaimDecision) (*InsuranceClaim, error) {
+	c, err := scanInsuranceClaim(r.db.conn.QueryRow(`
+		UPDATE insurance_claims SET status = $3, updated_at = CURRENT_TIMESTAMP,
+			approved_amount = CASE WHEN $3 = 'approved' THEN $4 ELSE approved_amount END,
+			paid_amount = CASE WHEN $3 = 'paid' THEN $4 ELSE paid_amount END,
+			rejection_reason = CASE WHEN $3 = 'rejected' THEN $5 ELSE rejection_reason END,
+			payer_reference = COALESCE($6, payer_reference),
+			decided_at = CASE WHEN $3 IN ('approved', 'rejected') THEN CURRENT_TIMESTAMP ELSE decided_at END,
+			paid_at = CASE WHEN $3 = 'paid' THEN CURRENT_TIMESTAMP ELSE paid_at END
+		WHERE id = $1 AND status = $2
+		RETURNING `+insuranceClaimColumns, id, from, d.Status, d.Amount, d.Reason, d.Reference))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("insurance claim %d is no longer %s", id, from)
+		}
+		return nil, fmt.Errorf("failed to update insurance claim status: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Intake form states
+const (
+	IntakePending   = "pending"
+	IntakeCompleted = "completed"
+)
+
+// Intake is the triage form a patient fills in before an appointment, through
+// the link sent with its reminders. The answers are shown to the doctor before
+// the visit and become the visit's chief complaint when it is opened.
+type Intake struct {
+	ID             int        `json:"id" db:"id"`
+	AppointmentID  int        `json:"appointmentId" db:"appointment_id"`
+	PatientHN      string     `json:"patientHn" db:"patient_hn"`
+	Token          string     `json:"-" db:"token"`
+	Status         string     `json:"status" db:"status"`                            // pending/completed
+	ChiefComplaint *string    `json:"chiefComplaint,omitempty" db:"chief_complaint"` // อาการสำคัญ, in the patient's words
+	Symptoms       []string   `json:"symptoms,omitempty" db:"symptoms"`              // stored newline-separated
+	Duration       *string    `json:"duration,omitempty" db:"duration"`              // how long, e.g. "3 วัน"
+	Medications    *string    `json:"medications,omitempty" db:"medications"`        // what the patient is taking now
+	Notes          *string    `json:"notes,omitempty" db:"notes"`
+	ExpiresAt      time.Time  `json:"expiresAt" db:"expires_at"` // when the appointment ends
+	CompletedAt    *time.Time `json:"completedAt,omitempty" db:"completed_at"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// NewIntake starts a pending intake form for an appointment, open until the appointment ends
+func NewIntake(a *Appointment, token string) *Intake {
+	return &Intake{
+		AppointmentID: a.ID,
+		PatientHN:     a.PatientHN,
+		Token:         token,
+		Status:        IntakePending,
+		ExpiresAt:     a.EndsAt,
+	}
+}
+
+// IntakeRepository handles pre-visit intake database operations
+type IntakeRepository struct {
+	db *DB
+}
+
+// NewIntakeRepository creates a new intake repository
+func NewIntakeRepository(db *DB) *IntakeRepository {
+	return &IntakeRepository{db: db}
+}
+
+const intakeColumns = `id, appointment_id, patient_hn, token, status, chief_complaint, symptoms, duration, medications, notes,
+	expires_at, completed_at, created_at`
+
+func scanIntake(row interface{ Scan(...interface{}) error }) (*Intake, error) {
+	var in Intake
+	var symptoms string
+	err := row.Scan(&in.ID, &in.AppointmentID, &in.PatientHN, &in.Token, &in.Status, &in.ChiefComplaint, &symptoms,
+		&in.Duration, &in.Medications, &in.Notes, &in.ExpiresAt, &in.CompletedAt, &in.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if symptoms != "" {
+		in.Symptoms = strings.Split(symptoms, "\n")
+	}
+	return &in, nil
+}
+
+// Create stores a new intake form; each appointment has at most one
+func (r *IntakeRepository) Create(in *Intake) error {
+	query := `
+		INSERT INTO intakes (appointment_id, patient_hn, token, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, in.AppointmentID, in.PatientHN, in.Token, in.Status, in.ExpiresAt).Scan(&in.ID, &in.CreatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("appointment %d already has an intake form", in.AppointmentID)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("appointment %d does not exist", in.AppointmentID)
+		}
+		return fmt.Errorf("failed to create intake: %w", err)
+	}
+
+	return nil
+}
+
+func (r *IntakeRepository) getOne(where string, arg interface{}, notFound error) (*Intake, error) {
+	in, err := scanIntake(r.db.conn.QueryRow("SELECT "+intakeColumns+" FROM intakes WHERE "+where, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound
+		}
+		return nil, fmt.Errorf("failed to get intake: %w", err)
+	}
+	return in, nil
+}
+
+// GetByAppointment retrieves an appointment's intake form
+func (r *IntakeRepository) GetByAppointment(appointmentID int) (*Intake, error) {
+	return r.getOne("appointment_id = $1", appointmentID, apperr.NotFound("appointment %d has no intake form", appointmentID))
+}
+
+// GetByToken retrieves an intake form by its patient link token
+func (r *IntakeRepository) GetByToken(token string) (*Intake, error) {
+	return r.getOne("token = $1", token, apperr.NotFound("intake link not found"))
+}
+
+// Complete records the patient's answers. A form can only be completed once.
+func (r *IntakeRepository) Complete(in *Intake) error {
+	err := r.db.conn.QueryRow(`
+		UPDATE intakes
+		SET status = 'completed', chief_complaint = $2, symptoms = $3, duration = $4, medications = $5, notes = $6,
+			completed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING status, completed_at
+	`, in.ID, in.ChiefComplaint, strings.Join(in.Symptoms, "\n"), in.Duration, in.Medications, in.Notes).Scan(&in.Status, &in.CompletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("intake %d has already been completed", in.ID)
+		}
+		return fmt.Errorf("failed to complete intake: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Interaction rule kinds
+const (
+	InteractionDrug    = "drug"    // between two drugs
+	InteractionAllergy = "allergy" // a drug the patient must not take with an allergy
+)
+
+// InteractionKinds lists every interaction rule kind
+var InteractionKinds = []string{InteractionDrug, InteractionAllergy}
+
+// Interaction severities, mildest first
+const (
+	InteractionMinor           = "minor"
+	InteractionModerate        = "moderate"
+	InteractionMajor           = "major"
+	InteractionContraindicated = "contraindicated"
+)
+
+// InteractionSeverities lists every severity, mildest first
+var InteractionSeverities = []string{InteractionMinor, InteractionModerate, InteractionMajor, InteractionContraindicated}
+
+// InteractionRule is a known interaction of a drug, by generic name, with
+// another drug or with an allergy. Rules name generics rather than catalog
+// entries so they cover every strength and brand.
+type InteractionRule struct {
+	ID         int       `json:"id" db:"id"`
+	Kind       string    `json:"kind" db:"kind"`                       // drug or allergy
+	Drug       string    `json:"drug" db:"drug"`                       // generic name, e.g. "Warfarin"
+	With       string    `json:"with" db:"with_name"`                  // the other generic, or the allergen, e.g. "Penicillin"
+	Severity   string    `json:"severity" db:"severity"`               // one of InteractionSeverities
+	Effect     string    `json:"effect" db:"effect"`                   // what can happen, e.g. "เพิ่มความเสี่ยงเลือดออก"
+	Management *string   `json:"management,omitempty" db:"management"` // what to do about it
+	Source     *string   `json:"source,omitempty" db:"source"`         // reference the rule was taken from
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// InteractionRuleRepository handles interaction rule database operations
+type InteractionRuleRepository struct {
+	db *DB
+}
+
+// NewInteractionRuleRepository creates a new interaction rule repository
+func NewInteractionRuleRepository(db *DB) *InteractionRuleRepository {
+	return &InteractionRuleRepository{db: db}
+}
+
+const interactionRuleColumns = `id, kind, drug, with_name, severity, effect, management, source, created_at, updated_at`
+
+func scanInteractionRule(row interface{ Scan(...interface{}) error }) (*InteractionRule, error) {
+	var ir InteractionRule
+	err := row.Scan(&ir.ID, &ir.Kind, &ir.Drug, &ir.With, &ir.Severity, &ir.Effect, &ir.Management, &ir.Source,
+		&ir.CreatedAt, &ir.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ir, nil
+}
+
+// Create adds an interaction rule; there is one rule per kind and pair of names
+func (r *InteractionRuleRepository) Create(ir *InteractionRule) error {
+	query := `
+		INSERT INTO drug_interaction_rules (kind, drug, with_name, severity, effect, management, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, ir.Kind, ir.Drug, ir.With, ir.Severity, ir.Effect, ir.Management, ir.Source).
+		Scan(&ir.ID, &ir.CreatedAt, &ir.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("there is already a %s rule for %s with %s", ir.Kind, ir.Drug, ir.With)
+		}
+		return fmt.Errorf("failed to create interaction rule: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an interaction rule by ID
+func (r *InteractionRuleRepository) GetByID(id int) (*InteractionRule, error) {
+	ir, err := scanInteractionRule(r.db.conn.QueryRow("SELECT "+interactionRuleColumns+" FROM drug_interaction_rules WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("interaction rule %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get interaction rule: %w", err)
+	}
+	return ir, nil
+}
+
+// GetAll retrieves the interaction rules that name drug on either side, or
+// every rule when drug is empty, by drug name
+func (r *InteractionRuleRepository) GetAll(drug string) ([]InteractionRule, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+interactionRuleColumns+` FROM drug_interaction_rules
+		WHERE $1 = '' OR lower(drug) = lower($1) OR lower(with_name) = lower($1)
+		ORDER BY lower(drug), lower(with_name), kind
+	`, drug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interaction rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []InteractionRule{}
+	for rows.Next() {
+		ir, err := scanInteractionRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan interaction rule: %w", err)
+		}
+		rules = append(rules, *ir)
+	}
+
+	return rules, rows.Err()
+}
+
+// Update saves an interaction rule's details
+func (r *InteractionRuleRepository) Update(ir *InteractionRule) error {
+	query := `
+		UPDATE drug_interaction_rules SET kind = $2, drug = $3, with_name = $4, severity = $5, effect = $6,
+			management = $7, source = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, ir.ID, ir.Kind, ir.Drug, ir.With, ir.Severity, ir.Effect, ir.Management, ir.Source).
+		Scan(&ir.CreatedAt, &ir.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("interaction rule %d not found", ir.ID)
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("there is already a %s rule for %s with %s", ir.Kind, ir.Drug, ir.With)
+		}
+		return fmt.Errorf("failed to update interaction rule: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an interaction rule
+func (r *InteractionRuleRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM drug_interaction_rules WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete interaction rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("interaction rule %d not found", id)
+	}
+
+	return nil
+}
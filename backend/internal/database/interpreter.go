@@ -0,0 +1,269 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Interpreter booking states
+const (
+	InterpreterBookingBooked    = "booked"
+	InterpreterBookingCancelled = "cancelled"
+	InterpreterBookingCompleted = "completed"
+)
+
+// PatientLanguage records a patient's spoken language and interpreter needs
+type PatientLanguage struct {
+	PatientHN           string    `json:"patientHn" db:"patient_hn"`
+	PreferredLanguage   string    `json:"preferredLanguage" db:"preferred_language"` // ISO 639-1, e.g. "th", "my", "km", "en"
+	InterpreterRequired bool      `json:"interpreterRequired" db:"interpreter_required"`
+	Notes               *string   `json:"notes,omitempty" db:"notes"` // e.g. "ญาติแปลได้", "sign language"
+	UpdatedAt           time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Interpreter is a bookable interpreter (staff, agency or remote service)
+type Interpreter struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Languages []string  `json:"languages" db:"languages"` // stored comma-separated
+	Phone     *string   `json:"phone,omitempty" db:"phone"`
+	Remote    bool      `json:"remote" db:"remote"` // phone/video interpretation
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Speaks reports whether the interpreter covers a language
+func (i *Interpreter) Speaks(language string) bool {
+	for _, l := range i.Languages {
+		if strings.EqualFold(l, language) {
+			return true
+		}
+	}
+	return false
+}
+
+// InterpreterBooking reserves an interpreter for a patient's appointment
+type InterpreterBooking struct {
+	ID            int       `json:"id" db:"id"`
+	InterpreterID int       `json:"interpreterId" db:"interpreter_id"`
+	PatientHN     string    `json:"patientHn" db:"patient_hn"`
+	AppointmentID *int      `json:"appointmentId,omitempty" db:"appointment_id"`
+	Language      string    `json:"language" db:"language"`
+	StartsAt      time.Time `json:"startsAt" db:"starts_at"`
+	EndsAt        time.Time `json:"endsAt" db:"ends_at"`
+	Status        string    `json:"status" db:"status"` // booked/cancelled/completed
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+}
+
+// InterpreterRepository handles language needs and interpreter scheduling database operations
+type InterpreterRepository struct {
+	db *DB
+}
+
+// NewInterpreterRepository creates a new interpreter repository
+func NewInterpreterRepository(db *DB) *InterpreterRepository {
+	return &InterpreterRepository{db: db}
+}
+
+// UpsertPatientLanguage saves a patient's language record
+func (r *InterpreterRepository) UpsertPatientLanguage(l *PatientLanguage) error {
+	query := `
+		INSERT INTO patient_languages (patient_hn, preferred_language, interpreter_required, notes)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (patient_hn) DO UPDATE SET preferred_language = $2, interpreter_required = $3,
+			notes = $4, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, l.PatientHN, l.PreferredLanguage, l.InterpreterRequired, l.Notes).Scan(&l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save patient language: %w", err)
+	}
+
+	return nil
+}
+
+// GetPatientLanguage retrieves a patient's language record
+func (r *InterpreterRepository) GetPatientLanguage(hn string) (*PatientLanguage, error) {
+	query := `
+		SELECT patient_hn, preferred_language, interpreter_required, notes, updated_at
+		FROM patient_languages WHERE patient_hn = $1
+	`
+
+	var l PatientLanguage
+	err := r.db.conn.QueryRow(query, hn).Scan(&l.PatientHN, &l.PreferredLanguage, &l.InterpreterRequired, &l.Notes, &l.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("language record for patient %s not found", hn)
+		}
+		return nil, fmt.Errorf("failed to get patient language: %w", err)
+	}
+
+	return &l, nil
+}
+
+// CreateInterpreter stores a new interpreter
+func (r *InterpreterRepository) CreateInterpreter(i *Interpreter) error {
+	query := `
+		INSERT INTO interpreters (name, languages, phone, remote, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, i.Name, strings.Join(i.Languages, ","), i.Phone, i.Remote, i.Active).
+		Scan(&i.ID, &i.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create interpreter: %w", err)
+	}
+
+	return nil
+}
+
+func scanInterpreter(row interface{ Scan(...interface{}) error }) (*Interpreter, error) {
+	var i Interpreter
+	var languages string
+	if err := row.Scan(&i.ID, &i.Name, &languages, &i.Phone, &i.Remote, &i.Active, &i.CreatedAt); err != nil {
+		return nil, err
+	}
+	i.Languages = strings.Split(languages, ",")
+	return &i, nil
+}
+
+// GetInterpreter retrieves an interpreter by ID
+func (r *InterpreterRepository) GetInterpreter(id int) (*Interpreter, error) {
+	query := "SELECT id, name, languages, phone, remote, active, created_at FROM interpreters WHERE id = $1"
+
+	i, err := scanInterpreter(r.db.conn.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("interpreter %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get interpreter: %w", err)
+	}
+
+	return i, nil
+}
+
+// GetInterpreters retrieves active interpreters, optionally only those covering a language
+func (r *InterpreterRepository) GetInterpreters(language string) ([]Interpreter, error) {
+	query := `
+		SELECT id, name, languages, phone, remote, active, created_at
+		FROM interpreters
+		WHERE active AND ($1 = '' OR $1 = ANY(string_to_array(languages, ',')))
+		ORDER BY remote, name
+	`
+
+	rows, err := r.db.conn.Query(query, strings.ToLower(language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interpreters: %w", err)
+	}
+	defer rows.Close()
+
+	var interpreters []Interpreter
+	for rows.Next() {
+		i, err := scanInterpreter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan interpreter: %w", err)
+		}
+		interpreters = append(interpreters, *i)
+	}
+
+	return interpreters, nil
+}
+
+// CreateBooking reserves an interpreter. The interpreter row is locked so
+// concurrent requests cannot double-book overlapping times.
+func (r *InterpreterRepository) CreateBooking(b *InterpreterBooking) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin interpreter booking: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	if err := tx.QueryRow("SELECT id FROM interpreters WHERE id = $1 AND active FOR UPDATE", b.InterpreterID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("interpreter %d is not active", b.InterpreterID)
+		}
+		return fmt.Errorf("failed to lock interpreter: %w", err)
+	}
+
+	var clash bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM interpreter_bookings
+			WHERE interpreter_id = $1 AND status = 'booked' AND starts_at < $3 AND ends_at > $2)
+	`, b.InterpreterID, b.StartsAt, b.EndsAt).Scan(&clash)
+	if err != nil {
+		return fmt.Errorf("failed to check interpreter availability: %w", err)
+	}
+	if clash {
+		return apperr.Conflict("interpreter %d is already booked at that time", b.InterpreterID)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO interpreter_bookings (interpreter_id, patient_hn, appointment_id, language, starts_at, ends_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, b.InterpreterID, b.PatientHN, b.AppointmentID, b.Language, b.StartsAt, b.EndsAt, b.Status).Scan(&b.ID, &b.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create interpreter booking: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit interpreter booking: %w", err)
+	}
+
+	return nil
+}
+
+// GetBookings retrieves interpreter bookings starting in [from, to), earliest first
+func (r *InterpreterRepository) GetBookings(from, to time.Time) ([]InterpreterBooking, error) {
+	query := `
+		SELECT id, interpreter_id, patient_hn, appointment_id, language, starts_at, ends_at, status, created_at
+		FROM interpreter_bookings
+		WHERE starts_at >= $1 AND starts_at < $2
+		ORDER BY starts_at
+	`
+
+	rows, err := r.db.conn.Query(query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interpreter bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var bookings []InterpreterBooking
+	for rows.Next() {
+		var b InterpreterBooking
+		err := rows.Scan(&b.ID, &b.InterpreterID, &b.PatientHN, &b.AppointmentID, &b.Language,
+			&b.StartsAt, &b.EndsAt, &b.Status, &b.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan interpreter booking: %w", err)
+		}
+		bookings = append(bookings, b)
+	}
+
+	return bookings, nil
+}
+
+// UpdateBookingStatus changes an interpreter booking's status
+func (r *InterpreterRepository) UpdateBookingStatus(id int, status string) error {
+	result, err := r.db.conn.Exec("UPDATE interpreter_bookings SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update interpreter booking: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("interpreter booking %d not found", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,547 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Stock item kinds
+const (
+	StockItemDrug    = "drug"
+	StockItemSupply  = "supply"
+	StockItemVaccine = "vaccine" // kept in lots so every dose given can be traced to its lot
+)
+
+// StockItemKinds lists every stock item kind
+var StockItemKinds = []string{StockItemDrug, StockItemSupply, StockItemVaccine}
+
+// Stock movement types
+const (
+	MovementStockIn    = "stock_in"
+	MovementDispense   = "dispense"
+	MovementAdjustment = "adjustment" // stock count corrections, breakage, expiry write-offs
+)
+
+// StockItem is a drug, supply or vaccine whose stock the clinic tracks. Reorder
+// policies, recalls and fridge lots refer to it as itemId.
+type StockItem struct {
+	ID        int       `json:"id" db:"id"`
+	Kind      string    `json:"kind" db:"kind"`                // drug, supply, vaccine
+	DrugID    *int      `json:"drugId,omitempty" db:"drug_id"` // catalog drug, for kind drug
+	Name      string    `json:"name" db:"name"`                // e.g. "Paracetamol 500 mg", "ถุงมือยาง size M"
+	Unit      string    `json:"unit" db:"unit"`                // unit stock is counted in, e.g. "tablet", "box"
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// StockItemFilter narrows an item or stock level listing; zero values match everything
+type StockItemFilter struct {
+	Kind   string
+	Search string // part of the item name
+}
+
+// StockLot is the quantity on hand of one lot of an item
+type StockLot struct {
+	LotNumber  string  `json:"lotNumber"`            // "" for stock received without a lot number
+	ExpiryDate *string `json:"expiryDate,omitempty"` // YYYY-MM-DD
+	Quantity   float64 `json:"quantity"`
+}
+
+// StockLevel is an item's quantity on hand, split by lot
+type StockLevel struct {
+	ItemID         int        `json:"itemId"`
+	Kind           string     `json:"kind"`
+	DrugID         *int       `json:"drugId,omitempty"`
+	Name           string     `json:"name"`
+	Unit           string     `json:"unit"`
+	Quantity       float64    `json:"quantity"`
+	Lots           []StockLot `json:"lots"` // lots with stock, earliest expiry first
+	LastMovementAt *time.Time `json:"lastMovementAt,omitempty"`
+}
+
+// StockMovement is one change to an item's stock. Movements are never edited;
+// mistakes are corrected with an adjustment.
+type StockMovement struct {
+	ID           int       `json:"id" db:"id"`
+	ItemID       int       `json:"itemId" db:"item_id"`
+	Type         string    `json:"type" db:"type"`
+	Quantity     float64   `json:"quantity" db:"quantity"` // positive adds stock, negative removes it
+	LotNumber    string    `json:"lotNumber,omitempty" db:"lot_number"`
+	ExpiryDate   *string   `json:"expiryDate,omitempty" db:"expiry_date"` // YYYY-MM-DD, for stock-in
+	PatientHN    *string   `json:"patientHn,omitempty" db:"patient_hn"`   // who received a dispense
+	Reference    *string   `json:"reference,omitempty" db:"reference"`    // delivery note, prescription or invoice number
+	Note         *string   `json:"note,omitempty" db:"note"`
+	RecordedBy   string    `json:"recordedBy" db:"recorded_by"`
+	BalanceAfter float64   `json:"balanceAfter" db:"balance_after"` // item's quantity on hand after the movement
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+// MovementFilter narrows a movement history; zero values match everything
+type MovementFilter struct {
+	ItemID int
+	Type   string
+	From   time.Time // inclusive
+	To     time.Time // exclusive
+}
+
+// InventoryRepository handles stock item, level and movement database operations
+type InventoryRepository struct {
+	db *DB
+}
+
+// NewInventoryRepository creates a new inventory repository
+func NewInventoryRepository(db *DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+const stockItemColumns = "id, kind, drug_id, name, unit, created_at, updated_at"
+
+func scanStockItem(row interface{ Scan(...interface{}) error }) (*StockItem, error) {
+	var i StockItem
+	if err := row.Scan(&i.ID, &i.Kind, &i.DrugID, &i.Name, &i.Unit, &i.CreatedAt, &i.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+const stockMovementColumns = `id, item_id, type, quantity, lot_number, to_char(expiry_date, 'YYYY-MM-DD'), patient_hn,
+	reference, note, recorded_by, balance_after, created_at`
+
+func scanStockMovement(row interface{ Scan(...interface{}) error }) (*StockMovement, error) {
+	var m StockMovement
+	err := row.Scan(&m.ID, &m.ItemID, &m.Type, &m.Quantity, &m.LotNumber, &m.ExpiryDate, &m.PatientHN,
+		&m.Reference, &m.Note, &m.RecordedBy, &m.BalanceAfter, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateItem adds a stock item; names and linked drugs must be unique
+func (r *InventoryRepository) CreateItem(i *StockItem) error {
+	query := `
+		INSERT INTO stock_items (kind, drug_id, name, unit)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, i.Kind, i.DrugID, i.Name, i.Unit).Scan(&i.ID, &i.CreatedAt, &i.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("a stock item named %s or for the same drug already exists", i.Name)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("drug %d does not exist", *i.DrugID)
+		}
+		return fmt.Errorf("failed to create stock item: %w", err)
+	}
+
+	return nil
+}
+
+// GetItem retrieves a stock item by ID
+func (r *InventoryRepository) GetItem(id int) (*StockItem, error) {
+	i, err := scanStockItem(r.db.conn.QueryRow("SELECT "+stockItemColumns+" FROM stock_items WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("stock item %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get stock item: %w", err)
+	}
+	return i, nil
+}
+
+// GetItems retrieves stock items matching the filter, by name
+func (r *InventoryRepository) GetItems(f StockItemFilter) ([]StockItem, error) {
+	query := `
+		SELECT ` + stockItemColumns + ` FROM stock_items
+		WHERE ($1 = '' OR kind = $1) AND ($2 = '' OR name ILIKE '%' || $2 || '%')
+		ORDER BY name
+	`
+
+	rows, err := r.db.conn.Query(query, f.Kind, f.Search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stock items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []StockItem{}
+	for rows.Next() {
+		i, err := scanStockItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock item: %w", err)
+		}
+		items = append(items, *i)
+	}
+
+	return items, rows.Err()
+}
+
+// UpdateItem renames an item or changes its unit; its kind and drug stay fixed
+func (r *InventoryRepository) UpdateItem(i *StockItem) error {
+	query := `
+		UPDATE stock_items SET name = $2, unit = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING kind, drug_id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, i.ID, i.Name, i.Unit).Scan(&i.Kind, &i.DrugID, &i.CreatedAt, &i.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("stock item %d not found", i.ID)
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("a stock item named %s already exists", i.Name)
+		}
+		return fmt.Errorf("failed to update stock item: %w", err)
+	}
+
+	return nil
+}
+
+// Record applies one movement to one lot of an item. Removing more than the
+// lot holds is a conflict, as is dispensing from an expired lot.
+func (r *InventoryRepository) Record(m *StockMovement) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin stock movement: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockStockItem(tx, m.ItemID); err != nil {
+		return err
+	}
+
+	if m.Quantity < 0 {
+		var onHand float64
+		var expired bool
+		err := tx.QueryRow(`
+			SELECT quantity, COALESCE(expiry_date < CURRENT_DATE, FALSE) FROM stock_lots
+			WHERE item_id = $1 AND lot_number = $2
+		`, m.ItemID, m.LotNumber).Scan(&onHand, &expired)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to read lot stock: %w", err)
+		}
+		if onHand < -m.Quantity {
+			return apperr.Conflict("only %g of %s on hand", onHand, lotLabel(m.LotNumber))
+		}
+		if expired && m.Type == MovementDispense {
+			return apperr.Conflict("%s has expired", lotLabel(m.LotNumber))
+		}
+	}
+
+	if err := applyStockMovement(tx, m); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit stock movement: %w", err)
+	}
+
+	return nil
+}
+
+// Dispense removes stock from unexpired lots, earliest expiry first, skipping
+// the excluded lots (recalled or on hold), and returns one movement per lot used
+func (r *InventoryRepository) Dispense(m StockMovement, exclude []string) ([]StockMovement, error) {
+	return r.DispenseItems([]StockMovement{m}, map[int][]string{m.ItemID: exclude})
+}
+
+// DispenseItems removes stock for several dispenses at once, as Dispense does
+// for one, skipping each item's excluded lots. Either every dispense is
+// recorded or, when any item is short, none is.
+func (r *InventoryRepository) DispenseItems(ms []StockMovement, exclude map[int][]string) ([]StockMovement, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin dispense: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock in item order so two dispenses sharing items cannot deadlock
+	itemIDs := []int{}
+	for _, m := range ms {
+		itemIDs = append(itemIDs, m.ItemID)
+	}
+	sort.Ints(itemIDs)
+	for i, id := range itemIDs {
+		if i > 0 && id == itemIDs[i-1] {
+			continue
+		}
+		if err := lockStockItem(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	movements := []StockMovement{}
+	for _, m := range ms {
+		lots, err := usableLots(tx, m.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		allocated, err := allocateLots(m, lots, exclude[m.ItemID])
+		if err != nil {
+			return nil, err
+		}
+		for i := range allocated {
+			if err := applyStockMovement(tx, &allocated[i]); err != nil {
+				return nil, err
+			}
+		}
+		movements = append(movements, allocated...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit dispense: %w", err)
+	}
+
+	return movements, nil
+}
+
+// usableLots reads an item's unexpired lots with stock, earliest expiry first
+func usableLots(tx *sql.Tx, itemID int) ([]StockLot, error) {
+	rows, err := tx.Query(`
+		SELECT lot_number, quantity FROM stock_lots
+		WHERE item_id = $1 AND quantity > 0 AND (expiry_date IS NULL OR expiry_date >= CURRENT_DATE)
+		ORDER BY expiry_date NULLS LAST, lot_number
+	`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lot stock: %w", err)
+	}
+	defer rows.Close()
+
+	lots := []StockLot{}
+	for rows.Next() {
+		var lot StockLot
+		if err := rows.Scan(&lot.LotNumber, &lot.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan lot stock: %w", err)
+		}
+		lots = append(lots, lot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query lot stock: %w", err)
+	}
+	return lots, nil
+}
+
+// GetLevels retrieves the stock on hand of items matching the filter, by name
+func (r *InventoryRepository) GetLevels(f StockItemFilter) ([]StockLevel, error) {
+	items, err := r.GetItems(f)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := make([]StockLevel, 0, len(items))
+	for i := range items {
+		level, err := r.level(&items[i])
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, *level)
+	}
+
+	return levels, nil
+}
+
+// GetLevel retrieves one item's stock on hand
+func (r *InventoryRepository) GetLevel(itemID int) (*StockLevel, error) {
+	item, err := r.GetItem(itemID)
+	if err != nil {
+		return nil, err
+	}
+	return r.level(item)
+}
+
+func (r *InventoryRepository) level(item *StockItem) (*StockLevel, error) {
+	level := newStockLevel(item)
+
+	rows, err := r.db.conn.Query(`
+		SELECT lot_number, to_char(expiry_date, 'YYYY-MM-DD'), quantity FROM stock_lots
+		WHERE item_id = $1 AND quantity > 0
+		ORDER BY expiry_date NULLS LAST, lot_number
+	`, item.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lot stock: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lot StockLot
+		if err := rows.Scan(&lot.LotNumber, &lot.ExpiryDate, &lot.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan lot stock: %w", err)
+		}
+		level.Lots = append(level.Lots, lot)
+		level.Quantity += lot.Quantity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query lot stock: %w", err)
+	}
+
+	var last sql.NullTime
+	if err := r.db.conn.QueryRow("SELECT MAX(created_at) FROM stock_movements WHERE item_id = $1", item.ID).Scan(&last); err != nil {
+		return nil, fmt.Errorf("failed to get last stock movement: %w", err)
+	}
+	if last.Valid {
+		level.LastMovementAt = &last.Time
+	}
+
+	return level, nil
+}
+
+// GetMovements retrieves stock movements matching the filter, newest first
+func (r *InventoryRepository) GetMovements(f MovementFilter) ([]StockMovement, error) {
+	query := `
+		SELECT ` + stockMovementColumns + ` FROM stock_movements
+		WHERE ($1 = 0 OR item_id = $1) AND ($2 = '' OR type = $2)
+			AND ($3::timestamp IS NULL OR created_at >= $3) AND ($4::timestamp IS NULL OR created_at < $4)
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, f.ItemID, f.Type, nullTime(f.From), nullTime(f.To))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := []StockMovement{}
+	for rows.Next() {
+		m, err := scanStockMovement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		movements = append(movements, *m)
+	}
+
+	return movements, rows.Err()
+}
+
+// DispensedFromLot lists dispenses of a lot to patients, for recalls
+func (r *InventoryRepository) DispensedFromLot(itemID int, lotNumber string) ([]LotDispense, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT patient_hn, -quantity, created_at FROM stock_movements
+		WHERE item_id = $1 AND lot_number = $2 AND type = $3 AND patient_hn IS NOT NULL
+		ORDER BY created_at
+	`, itemID, lotNumber, MovementDispense)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lot dispenses: %w", err)
+	}
+	defer rows.Close()
+
+	dispenses := []LotDispense{}
+	for rows.Next() {
+		var d LotDispense
+		if err := rows.Scan(&d.PatientHN, &d.Quantity, &d.DispensedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lot dispense: %w", err)
+		}
+		dispenses = append(dispenses, d)
+	}
+
+	return dispenses, rows.Err()
+}
+
+// lockStockItem locks the item row so movements on the same item apply one at a time
+func lockStockItem(tx *sql.Tx, itemID int) error {
+	var id int
+	if err := tx.QueryRow("SELECT id FROM stock_items WHERE id = $1 FOR UPDATE", itemID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("stock item %d not found", itemID)
+		}
+		return fmt.Errorf("failed to lock stock item: %w", err)
+	}
+	return nil
+}
+
+// applyStockMovement updates the lot, then stores the movement with the item's new balance
+func applyStockMovement(tx *sql.Tx, m *StockMovement) error {
+	_, err := tx.Exec(`
+		INSERT INTO stock_lots (item_id, lot_number, expiry_date, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (item_id, lot_number) DO UPDATE
+		SET quantity = stock_lots.quantity + EXCLUDED.quantity,
+			expiry_date = COALESCE(stock_lots.expiry_date, EXCLUDED.expiry_date)
+	`, m.ItemID, m.LotNumber, m.ExpiryDate, m.Quantity)
+	if err != nil {
+		return fmt.Errorf("failed to update lot stock: %w", err)
+	}
+
+	if err := tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM stock_lots WHERE item_id = $1", m.ItemID).
+		Scan(&m.BalanceAfter); err != nil {
+		return fmt.Errorf("failed to get stock balance: %w", err)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO stock_movements (item_id, type, quantity, lot_number, expiry_date, patient_hn, reference, note,
+			recorded_by, balance_after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`, m.ItemID, m.Type, m.Quantity, m.LotNumber, m.ExpiryDate, m.PatientHN, m.Reference, m.Note,
+		m.RecordedBy, m.BalanceAfter).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+
+	return nil
+}
+
+// allocateLots splits a dispense across lots in the order given, skipping the
+// excluded ones; lots must already be in earliest-expiry-first order
+func allocateLots(m StockMovement, lots []StockLot, exclude []string) ([]StockMovement, error) {
+	skip := make(map[string]bool, len(exclude))
+	for _, lot := range exclude {
+		skip[lot] = true
+	}
+
+	needed := -m.Quantity
+	available := 0.0
+	movements := []StockMovement{}
+	for _, lot := range lots {
+		if skip[lot.LotNumber] || lot.Quantity <= 0 {
+			continue
+		}
+		available += lot.Quantity
+		if needed <= 0 {
+			continue
+		}
+		take := lot.Quantity
+		if take > needed {
+			take = needed
+		}
+		movement := m
+		movement.Quantity = -take
+		movement.LotNumber = lot.LotNumber
+		movements = append(movements, movement)
+		needed -= take
+	}
+	if needed > 0 {
+		return nil, apperr.Conflict("only %g available to dispense", available)
+	}
+	return movements, nil
+}
+
+func newStockLevel(item *StockItem) *StockLevel {
+	return &StockLevel{
+		ItemID: item.ID,
+		Kind:   item.Kind,
+		DrugID: item.DrugID,
+		Name:   item.Name,
+		Unit:   item.Unit,
+		Lots:   []StockLot{},
+	}
+}
+
+func lotLabel(lotNumber string) string {
+	if lotNumber == "" {
+		return "stock without a lot number"
+	}
+	return "lot " + lotNumber
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,289 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Invoice statuses
+const (
+	InvoiceDraft  = "draft"
+	InvoiceIssued = "issued"
+	InvoicePaid   = "paid"
+	InvoiceVoid   = "void"
+)
+
+// invoiceTransitions lists the statuses each status may move to
+var invoiceTransitions = map[string][]string{
+	InvoiceDraft:  {InvoiceIssued, InvoiceVoid},
+	InvoiceIssued: {InvoicePaid, InvoiceVoid},
+}
+
+// Invoice line kinds
+const (
+	InvoiceItemService = "service"
+	InvoiceItemDrug    = "drug"
+)
+
+// InvoiceItem is one billed line; Amount is quantity × unit price less the line discount
+type InvoiceItem struct {
+	Kind        string  `json:"kind"`                // service, drug
+	ServiceID   *int    `json:"serviceId,omitempty"` // catalog service, for service lines
+	DrugID      *int    `json:"drugId,omitempty"`    // catalog drug, for drug lines
+	Description string  `json:"description"`         // e.g. "ค่าตรวจแพทย์", "Paracetamol 500 mg"
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	Discount    float64 `json:"discount"` // baht off this line
+	Amount      float64 `json:"amount"`
+}
+
+// Invoice bills a patient for one visit. Drafts can be edited freely; once
+// issued, an invoice is settled by payments or voided.
+type Invoice struct {
+	ID          int           `json:"id" db:"id"`
+	Number      string        `json:"number" db:"-"` // e.g. "INV-000012", derived from the ID
+	VisitID     int           `json:"visitId" db:"visit_id"`
+	PatientHN   string        `json:"patientHn" db:"patient_hn"`
+	Status      string        `json:"status" db:"status"`
+	Items       []InvoiceItem `json:"items" db:"items"` // stored as JSONB
+	Subtotal    float64       `json:"subtotal" db:"subtotal"`
+	Discount    float64       `json:"discount" db:"discount"` // baht off the whole invoice
+	Total       float64       `json:"total" db:"total"`
+	AmountPaid  float64       `json:"amountPaid" db:"amount_paid"` // sum of recorded payments
+	Outstanding float64       `json:"outstanding" db:"-"`          // total less payments
+	Notes       *string       `json:"notes,omitempty" db:"notes"`
+	VoidReason  *string       `json:"voidReason,omitempty" db:"void_reason"`
+	IssuedAt    *time.Time    `json:"issuedAt,omitempty" db:"issued_at"`
+	PaidAt      *time.Time    `json:"paidAt,omitempty" db:"paid_at"`
+	VoidedAt    *time.Time    `json:"voidedAt,omitempty" db:"voided_at"`
+	CreatedAt   time.Time     `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updatedAt" db:"updated_at"`
+}
+
+// InvoiceNumber formats an invoice ID the way it is printed and quoted in bank transfers
+func InvoiceNumber(id int) string {
+	return fmt.Sprintf("INV-%06d", id)
+}
+
+// CanMoveTo reports whether the invoice may change to status
+func (inv *Invoice) CanMoveTo(status string) bool {
+	for _, s := range invoiceTransitions[inv.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CalculateTotals works out each line's amount, the subtotal and the total, to the satang
+func (inv *Invoice) CalculateTotals() {
+	inv.Subtotal = 0
+	for i := range inv.Items {
+		item := &inv.Items[i]
+		item.Amount = roundBaht(item.Quantity*item.UnitPrice - item.Discount)
+		inv.Subtotal += item.Amount
+	}
+	inv.Subtotal = roundBaht(inv.Subtotal)
+	inv.Total = roundBaht(inv.Subtotal - inv.Discount)
+	inv.Outstanding = roundBaht(inv.Total - inv.AmountPaid)
+}
+
+func roundBaht(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// InvoiceFilter narrows an invoice listing; zero values match everything
+type InvoiceFilter struct {
+	PatientHN string
+	VisitID   int
+	VisitIDs  []int // any of these visits; an empty list matches every visit
+	Status    string
+}
+
+// InvoiceRepository handles invoice database operations
+type InvoiceRepository struct {
+	db *DB
+}
+
+// NewInvoiceRepository creates a new invoice repository
+func NewInvoiceRepository(db *DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+const invoiceColumns = `id, visit_id, patient_hn, status, items, subtotal, discount, total, amount_paid, notes, void_reason,
+	issued_at, paid_at, voided_at, created_at, updated_at`
+
+func scanInvoice(row interface{ Scan(...interface{}) error }) (*Invoice, error) {
+	var inv Invoice
+	var items []byte
+	err := row.Scan(&inv.ID, &inv.VisitID, &inv.PatientHN, &inv.Status, &items, &inv.Subtotal, &inv.Discount, &inv.Total,
+		&inv.AmountPaid, &inv.Notes, &inv.VoidReason, &inv.IssuedAt, &inv.PaidAt, &inv.VoidedAt, &inv.CreatedAt, &inv.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(items, &inv.Items); err != nil {
+		return nil, fmt.Errorf("invalid items for invoice %d: %w", inv.ID, err)
+	}
+	inv.Number = InvoiceNumber(inv.ID)
+	inv.Outstanding = roundBaht(inv.Total - inv.AmountPaid)
+	return &inv, nil
+}
+
+// Create stores a draft invoice; a visit can have only one invoice that is not void
+func (r *InvoiceRepository) Create(inv *Invoice) error {
+	items, err := json.Marshal(inv.Items)
+	if err != nil {
+		return fmt.Errorf("failed to encode invoice items: %w", err)
+	}
+
+	query := `
+		INSERT INTO invoices (visit_id, patient_hn, status, items, subtotal, discount, total, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = r.db.conn.QueryRow(query, inv.VisitID, inv.PatientHN, inv.Status, items, inv.Subtotal, inv.Discount, inv.Total, inv.Notes).
+		Scan(&inv.ID, &inv.CreatedAt, &inv.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("visit %d has already been invoiced", inv.VisitID)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("visit %d does not exist", inv.VisitID)
+		}
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+	inv.Number = InvoiceNumber(inv.ID)
+
+	return nil
+}
+
+// GetByID retrieves an invoice by ID, from the archive if its visit has been archived
+func (r *InvoiceRepository) GetByID(id int) (*Invoice, error) {
+	inv, err := scanInvoice(r.db.conn.QueryRow("SELECT "+invoiceColumns+" FROM invoices WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		inv, err = scanInvoice(r.db.conn.QueryRow("SELECT "+invoiceColumns+" FROM "+archived("invoices")+" WHERE id = $1", id))
+		err = notArchived(err)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("invoice %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return inv, nil
+}
+
+// List retrieves invoices matching the filter, most recent first. Listing a
+// patient's or a visit's invoices includes archived ones, after the rest.
+func (r *InvoiceRepository) List(f InvoiceFilter) ([]Invoice, error) {
+	invoices, err := r.list("invoices", f)
+	if err != nil || (f.PatientHN == "" && f.VisitID == 0) {
+		return invoices, err
+	}
+	older, err := r.list(archived("invoices"), f)
+	if err != nil {
+		if undefinedTable(err) {
+			return invoices, nil
+		}
+		return nil, err
+	}
+	return append(invoices, older...), nil
+}
+
+func (r *InvoiceRepository) list(table string, f InvoiceFilter) ([]Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + ` FROM ` + table + `
+		WHERE ($1 = '' OR patient_hn = $1) AND ($2 = 0 OR visit_id = $2) AND ($3 = '' OR status = $3)
+			AND ($4 = '' OR visit_id = ANY(string_to_array($4, ',')::int[]))
+		ORDER BY created_at DESC, id DESC
+	`
+
+	visitIDs := make([]string, len(f.VisitIDs))
+	for i, id := range f.VisitIDs {
+		visitIDs[i] = strconv.Itoa(id)
+	}
+	rows, err := r.db.conn.Query(query, f.PatientHN, f.VisitID, f.Status, strings.Join(visitIDs, ","))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invoices: %w", err)
+	}
+	defer rows.Close()
+
+	invoices := []Invoice{}
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		invoices = append(invoices, *inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// Update replaces a draft invoice's lines, discount and notes
+func (r *InvoiceRepository) Update(inv *Invoice) error {
+	items, err := json.Marshal(inv.Items)
+	if err != nil {
+		return fmt.Errorf("failed to encode invoice items: %w", err)
+	}
+
+	updated, err := scanInvoice(r.db.conn.QueryRow(`
+		UPDATE invoices SET items = $2, subtotal = $3, discount = $4, total = $5, notes = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'draft'
+		RETURNING `+invoiceColumns, inv.ID, items, inv.Subtotal, inv.Discount, inv.Total, inv.Notes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("invoice %d is not a draft", inv.ID)
+		}
+		return fmt.Errorf("failed to update invoice: %w", err)
+	}
+	*inv = *updated
+
+	return nil
+}
+
+// Delete removes a draft invoice; issued invoices keep their number and must be voided instead
+func (r *InvoiceRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM invoices WHERE id = $1 AND status = 'draft'", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete invoice: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.Conflict("invoice %d is not a draft", id)
+	}
+
+	return nil
+}
+
+// UpdateStatus moves an invoice from one status to another, stamping when it
+// was issued, paid or voided; voidReason is kept for voids
+func (r *InvoiceRepository) UpdateStatus(id int, from, to string, voidReason *string) (*Invoice, error) {
+	inv, err := scanInvoice(r.db.conn.QueryRow(`
+		UPDATE invoices SET status = $3, updated_at = CURRENT_TIMESTAMP,
+			issued_at = CASE WHEN $3 = 'issued' THEN CURRENT_TIMESTAMP ELSE issued_at END,
+			paid_at = CASE WHEN $3 = 'paid' THEN CURRENT_TIMESTAMP ELSE paid_at END,
+			voided_at = CASE WHEN $3 = 'void' THEN CURRENT_TIMESTAMP ELSE voided_at END,
+			void_reason = CASE WHEN $3 = 'void' THEN $4 ELSE void_reason END
+		WHERE id = $1 AND status = $2
+		RETURNING `+invoiceColumns, id, from, to, voidReason))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("invoice %d is no longer %s", id, from)
+		}
+		return nil, fmt.Errorf("failed to update invoice status: %w", err)
+	}
+	return inv, nil
+}
@@ -0,0 +1,274 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// LINE message kinds
+const (
+	LINEAppointmentConfirmation = "appointment_confirmation" // sent when an appointment is booked
+	LINEQueueReady              = "queue_ready"              // sent when the patient is called from the queue
+	LINEPatientMessage          = "patient_message"          // a message staff send the patient, e.g. a questionnaire link
+)
+
+// LINEMessageKinds lists every LINE message kind
+var LINEMessageKinds = []string{LINEAppointmentConfirmation, LINEQueueReady, LINEPatientMessage}
+
+// PatientLINE links a patient to their LINE account, so the clinic's LINE
+// Official Account can push messages to them. The user ID is the one LINE
+// gives the clinic's channel when the patient adds it as a friend.
+type PatientLINE struct {
+	PatientHN   string    `json:"patientHn" db:"patient_hn"`
+	UserID      string    `json:"userId" db:"line_user_id"` // e.g. "U4af4980629..."
+	DisplayName *string   `json:"displayName,omitempty" db:"display_name"`
+	LinkedBy    string    `json:"linkedBy" db:"linked_by"`
+	LinkedAt    time.Time `json:"linkedAt" db:"linked_at"`
+}
+
+// LINEMessage is a message queued for a patient's LINE account. Messages wait
+// as pending until the LINE sender pushes them; a failed push goes back to
+// pending for a retry until the retry policy runs out, then stays failed.
+type LINEMessage struct {
+	ID            int        `json:"id" db:"id"`
+	PatientHN     string     `json:"patientHn" db:"patient_hn"`
+	UserID        string     `json:"userId" db:"line_user_id"`
+	Kind          string     `json:"kind" db:"kind"` // one of LINEMessageKinds
+	AppointmentID *int       `json:"appointmentId,omitempty" db:"appointment_id"`
+	QueueEntryID  *int       `json:"queueEntryId,omitempty" db:"queue_entry_id"`
+	Text          string     `json:"text" db:"text"`
+	Status        string     `json:"status" db:"status"` // pending, sent or failed
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     *string    `json:"lastError,omitempty" db:"last_error"`
+	RetryAt       *time.Time `json:"retryAt,omitempty" db:"retry_at"`
+	SentAt        *time.Time `json:"sentAt,omitempty" db:"sent_at"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// LINEMessageFilter narrows a LINE message listing; zero values match everything
+type LINEMessageFilter struct {
+	PatientHN string
+	Status    string
+	Due       bool // leaves out messages waiting to be retried
+}
+
+// LINERepository handles patients' LINE accounts and the LINE message queue
+type LINERepository struct {
+	db *DB
+}
+
+// NewLINERepository creates a new LINE repository
+func NewLINERepository(db *DB) *LINERepository {
+	return &LINERepository{db: db}
+}
+
+const lineMessageColumns = `id, patient_hn, line_user_id, kind, appointment_id, queue_entry_id, text, status, attempts, last_error,
+	retry_at, sent_at, created_at`
+
+func scanLINEMessage(row interface{ Scan(...interface{}) error }) (*LINEMessage, error) {
+	var m LINEMessage
+	err := row.Scan(&m.ID, &m.PatientHN, &m.UserID, &m.Kind, &m.AppointmentID, &m.QueueEntryID, &m.Text, &m.Status, &m.Attempts,
+		&m.LastError, &m.RetryAt, &m.SentAt, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Link links a patient to a LINE account, replacing any account they had
+func (r *LINERepository) Link(a *PatientLINE) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO patient_line_accounts (patient_hn, line_user_id, display_name, linked_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (patient_hn) DO UPDATE SET line_user_id = $2, display_name = $3, linked_by = $4, linked_at = CURRENT_TIMESTAMP
+		RETURNING linked_at
+	`, a.PatientHN, a.UserID, a.DisplayName, a.LinkedBy).Scan(&a.LinkedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("LINE account %s is already linked to another patient", a.UserID)
+		}
+		return fmt.Errorf("failed to link LINE account: %w", err)
+	}
+	return nil
+}
+
+// GetAccount retrieves the LINE account a patient has linked
+func (r *LINERepository) GetAccount(hn string) (*PatientLINE, error) {
+	var a PatientLINE
+	err := r.db.conn.QueryRow(`
+		SELECT patient_hn, line_user_id, display_name, linked_by, linked_at FROM patient_line_accounts WHERE patient_hn = $1
+	`, hn).Scan(&a.PatientHN, &a.UserID, &a.DisplayName, &a.LinkedBy, &a.LinkedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("%s has not linked a LINE account", hn)
+		}
+		return nil, fmt.Errorf("failed to get LINE account: %w", err)
+	}
+	return &a, nil
+}
+
+// Unlink removes a patient's LINE account; messages already queued still go to it
+func (r *LINERepository) Unlink(hn string) error {
+	result, err := r.db.conn.Exec("DELETE FROM patient_line_accounts WHERE patient_hn = $1", hn)
+	if err != nil {
+		return fmt.Errorf("failed to unlink LINE account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("%s has not linked a LINE account", hn)
+	}
+
+	return nil
+}
+
+// Enqueue queues a message to be pushed
+func (r *LINERepository) Enqueue(m *LINEMessage) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO line_messages (patient_hn, line_user_id, kind, appointment_id, queue_entry_id, text)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at
+	`, m.PatientHN, m.UserID, m.Kind, m.AppointmentID, m.QueueEntryID, m.Text).Scan(&m.ID, &m.Status, &m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to queue LINE message: %w", err)
+	}
+	return nil
+}
+
+// ListMessages retrieves the messages matching the filter, oldest first. A
+// patient's listing starts with their archived queue calls, which are older.
+func (r *LINERepository) ListMessages(f LINEMessageFilter) ([]LINEMessage, error) {
+	messages, err := r.listMessages("line_messages", f)
+	if err != nil || f.PatientHN == "" || f.Due {
+		return messages, err
+	}
+	older, err := r.listMessages(archived("line_messages"), f)
+	if err != nil {
+		if undefinedTable(err) {
+			return messages, nil
+		}
+		return nil, err
+	}
+	return append(older, messages...), nil
+}
+
+func (r *LINERepository) listMessages(table string, f LINEMessageFilter) ([]LINEMessage, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+lineMessageColumns+` FROM `+table+`
+		WHERE ($1 = '' OR patient_hn = $1) AND ($2 = '' OR status = $2)
+			AND (NOT $3 OR retry_at IS NULL OR retry_at <= CURRENT_TIMESTAMP)
+		ORDER BY created_at, id
+	`, f.PatientHN, f.Status, f.Due)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query LINE messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []LINEMessage{}
+	for rows.Next() {
+		m, err := scanLINEMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan LINE message: %w", err)
+		}
+		messages = append(messages, *m)
+	}
+
+	return messages, rows.Err()
+}
+
+// MarkSent records that a pending message was pushed
+func (r *LINERepository) MarkSent(id int) (*LINEMessage, error) {
+	m, err := scanLINEMessage(r.db.conn.QueryRow(`
+		UPDATE line_messages SET status = 'sent', sent_at = CURRENT_TIMESTAMP, retry_at = NULL
+		WHERE id = $1 AND status = 'pending'
+		RETURNING `+lineMessageColumns, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, r.notPending(id)
+		}
+		return nil, fmt.Errorf("failed to mark LINE message sent: %w", err)
+	}
+	return m, nil
+}
+
+// RecordFailure records a failed push of a pending message. retryAt gives
+// when it may be tried again after so many failed attempts, or nil when the
+// retry policy has run out and the message stays failed.
+func (r *LINERepository) RecordFailure(id int, reason string, retryAt func(attempts int) *time.Time) (*LINEMessage, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin LINE message failure: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	var attempts int
+	err = tx.QueryRow("SELECT status, attempts FROM line_messages WHERE id = $1 FOR UPDATE", id).Scan(&status, &attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("LINE message %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get LINE message: %w", err)
+	}
+	if status != NotificationPending {
+		return nil, apperr.Conflict("LINE message %d is no longer pending", id)
+	}
+
+	attempts++
+	next := retryAt(attempts)
+	status = NotificationPending
+	if next == nil {
+		status = NotificationFailed
+	}
+	m, err := scanLINEMessage(tx.QueryRow(`
+		UPDATE line_messages SET status = $2, attempts = $3, last_error = $4, retry_at = $5
+		WHERE id = $1
+		RETURNING `+lineMessageColumns, id, status, attempts, reason, next))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update LINE message: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit LINE message failure: %w", err)
+	}
+	return m, nil
+}
+
+// Requeue puts a failed message back in the queue with a fresh set of attempts
+func (r *LINERepository) Requeue(id int) (*LINEMessage, error) {
+	m, err := scanLINEMessage(r.db.conn.QueryRow(`
+		UPDATE line_messages SET status = 'pending', attempts = 0, retry_at = NULL
+		WHERE id = $1 AND status = 'failed'
+		RETURNING `+lineMessageColumns, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			var exists bool
+			if err := r.db.conn.QueryRow("SELECT EXISTS (SELECT 1 FROM line_messages WHERE id = $1)", id).Scan(&exists); err != nil {
+				return nil, fmt.Errorf("failed to get LINE message: %w", err)
+			}
+			if !exists {
+				return nil, apperr.NotFound("LINE message %d not found", id)
+			}
+			return nil, apperr.Conflict("LINE message %d is not failed", id)
+		}
+		return nil, fmt.Errorf("failed to requeue LINE message: %w", err)
+	}
+	return m, nil
+}
+
+func (r *LINERepository) notPending(id int) error {
+	var exists bool
+	if err := r.db.conn.QueryRow("SELECT EXISTS (SELECT 1 FROM line_messages WHERE id = $1)", id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to get LINE message: %w", err)
+	}
+	if !exists {
+		return apperr.NotFound("LINE message %d not found", id)
+	}
+	return apperr.Conflict("LINE message %d is no longer pending", id)
+}
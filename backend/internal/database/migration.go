@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+
+	"clinic/backend/internal/migrate"
+)
+
+// migrationLockKey identifies the advisory lock held while migrating, so only one instance migrates at a time
+const migrationLockKey = 0x636c696e // "clin"
+
+// migrationLockTimeout bounds how long a statement waits for a table lock, so a
+// migration queued behind a long query fails instead of stalling live traffic behind it
+const migrationLockTimeout = "5s"
+
+// AppliedMigration is a row of schema_migrations
+type AppliedMigration struct {
+	Version int    `json:"version" db:"version"`
+	Name    string `json:"name" db:"name"`
+	Forced  bool   `json:"forced" db:"forced"` // applied despite pre-flight findings
+}
+
+// Migrate applies pending migrations in version order. Other instances calling
+// Migrate wait on the advisory lock and then find nothing left to apply. Pending
+// migrations with destructive or long-locking operations are refused unless force is set.
+func (db *DB) Migrate(ctx context.Context, migrations []migrate.Migration, force bool) ([]migrate.Finding, error) {
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	_, err = conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		forced BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []migrate.Migration{}
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+
+	findings, err := migrate.Preflight(pending, force)
+	if err != nil {
+		return findings, err
+	}
+
+	forced := map[int]bool{}
+	for _, f := range findings {
+		forced[f.Version] = true
+	}
+	for _, m := range pending {
+		if err := applyMigration(ctx, conn, m, forced[m.Version]); err != nil {
+			return findings, err
+		}
+		log.Printf("Applied migration %d (%s)", m.Version, m.Name)
+	}
+
+	return findings, nil
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs one migration in a transaction, or statement by statement
+// when it builds or drops indexes concurrently, which Postgres forbids in a transaction
+func applyMigration(ctx context.Context, conn *sql.Conn, m migrate.Migration, forced bool) error {
+	record := "INSERT INTO schema_migrations (version, name, forced) VALUES ($1, $2, $3)"
+
+	if migrate.NeedsNoTransaction(m.SQL) {
+		if _, err := conn.ExecContext(ctx, "SET lock_timeout = '"+migrationLockTimeout+"'"); err != nil {
+			return fmt.Errorf("failed to set lock timeout: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "RESET lock_timeout")
+
+		for _, stmt := range migrate.Statements(m.SQL) {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+			}
+		}
+		if _, err := conn.ExecContext(ctx, record, m.Version, m.Name, forced); err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SET LOCAL lock_timeout = '"+migrationLockTimeout+"'"); err != nil {
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, m.Version, m.Name, forced); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+// GetAppliedMigrations returns the migrations recorded in schema_migrations
+func (db *DB) GetAppliedMigrations() ([]AppliedMigration, error) {
+	rows, err := db.conn.Query("SELECT version, name, forced FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	migrations := []AppliedMigration{}
+	for rows.Next() {
+		var m AppliedMigration
+		if err := rows.Scan(&m.Version, &m.Name, &m.Forced); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations, rows.Err()
+}
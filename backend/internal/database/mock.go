@@ -5,10 +5,14 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"clinic/backend/internal/apperr"
 )
 
 // MockPatientRepository is an in-memory implementation for testing
 type MockPatientRepository struct {
+	mockFidelity
+
 	patients map[string]*Patient
 	mutex    sync.RWMutex
 }
@@ -62,6 +66,10 @@ func (r *MockPatientRepository) createSampleData() {
 
 // GetAll retrieves all patients
 func (r *MockPatientRepository) GetAll() ([]Patient, error) {
+	if err := r.fault("Patient.GetAll"); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -78,8 +86,30 @@ func (r *MockPatientRepository) GetAll() ([]Patient, error) {
 	return patients, nil
 }
 
+// GetAllFields retrieves the given fields (see PatientFields) of all patients, newest first
+func (r *MockPatientRepository) GetAllFields(fields []string) ([]map[string]interface{}, error) {
+	if err := r.fault("Patient.GetAllFields"); err != nil {
+		return nil, err
+	}
+
+	all, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	patients := make([]map[string]interface{}, len(all))
+	for i := range all {
+		patients[i] = PatientFields.Project(&all[i], fields)
+	}
+	return patients, nil
+}
+
 // GetByID retrieves a patient by ID
 func (r *MockPatientRepository) GetByID(id int) (*Patient, error) {
+	if err := r.fault("Patient.GetByID"); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -88,7 +118,7 @@ func (r *MockPatientRepository) GetByID(id int) (*Patient, error) {
 
 	patient, exists := r.patients[hnString]
 	if !exists {
-		return nil, fmt.Errorf("patient with hn %s not found", hnString)
+		return nil, apperr.NotFound("patient with hn %s not found", hnString)
 	}
 
 	// Return a copy
@@ -96,14 +126,45 @@ func (r *MockPatientRepository) GetByID(id int) (*Patient, error) {
 	return &patientCopy, nil
 }
 
+// GetByCitizenID retrieves a patient by citizen ID, stored as 13 digits
+func (r *MockPatientRepository) GetByCitizenID(citizenID string) (*Patient, error) {
+	if err := r.fault("Patient.GetByCitizenID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, patient := range r.patients {
+		if patient.CitizenID != nil && *patient.CitizenID == citizenID {
+			patientCopy := *patient
+			return &patientCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("patient with that citizen ID not found")
+}
+
+// exists reports whether a patient is registered under hn, for reference checks
+func (r *MockPatientRepository) exists(hn string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.patients[hn]
+	return exists
+}
+
 // Create adds a new patient
 func (r *MockPatientRepository) Create(p *Patient) error {
+	if err := r.fault("Patient.Create"); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	// Check if HN already exists
 	if _, exists := r.patients[p.HN]; exists {
-		return fmt.Errorf("patient with HN %s already exists", p.HN)
+		return apperr.Conflict("patient with HN %s already exists", p.HN)
 	}
 
 	p.CreatedAt = time.Now()
@@ -118,12 +179,16 @@ func (r *MockPatientRepository) Create(p *Patient) error {
 
 // Update modifies an existing patient
 func (r *MockPatientRepository) Update(p *Patient) error {
+	if err := r.fault("Patient.Update"); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	existing, exists := r.patients[p.HN]
 	if !exists {
-		return fmt.Errorf("patient with HN %s not found", p.HN)
+		return apperr.NotFound("patient with HN %s not found", p.HN)
 	}
 
 	// Update fields
@@ -131,8 +196,10 @@ func (r *MockPatientRepository) Update(p *Patient) error {
 	existing.Gender = p.Gender
 	existing.Nickname = p.Nickname
 	existing.Phone = p.Phone
+	existing.Email = p.Email
 	existing.Age = p.Age
 	existing.DateOfBirth = p.DateOfBirth
+	existing.CitizenID = p.CitizenID
 	existing.Photo = p.Photo
 	existing.UpdatedAt = time.Now()
 
@@ -145,6 +212,10 @@ func (r *MockPatientRepository) Update(p *Patient) error {
 
 // Delete removes a patient
 func (r *MockPatientRepository) Delete(id int) error {
+	if err := r.fault("Patient.Delete"); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -152,7 +223,7 @@ func (r *MockPatientRepository) Delete(id int) error {
 	hnString := fmt.Sprintf("HN%06d", id)
 
 	if _, exists := r.patients[hnString]; !exists {
-		return fmt.Errorf("patient with hn %s not found", hnString)
+		return apperr.NotFound("patient with hn %s not found", hnString)
 	}
 
 	delete(r.patients, hnString)
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockAccessibilityRepository is an in-memory implementation for testing
+type MockAccessibilityRepository struct {
+	mockFidelity
+
+	records map[string]*PatientAccessibility
+	mutex   sync.RWMutex
+}
+
+// NewMockAccessibilityRepository creates a new mock accessibility repository
+func NewMockAccessibilityRepository() *MockAccessibilityRepository {
+	return &MockAccessibilityRepository{
+		records: make(map[string]*PatientAccessibility),
+	}
+}
+
+// Upsert saves a patient's accessibility record
+func (r *MockAccessibilityRepository) Upsert(a *PatientAccessibility) error {
+	if err := r.fault("Accessibility.Upsert"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(a.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a.UpdatedAt = time.Now()
+	recordCopy := *a
+	recordCopy.Needs = append([]string{}, a.Needs...)
+	r.records[a.PatientHN] = &recordCopy
+
+	return nil
+}
+
+// GetByPatient retrieves a patient's accessibility record
+func (r *MockAccessibilityRepository) GetByPatient(hn string) (*PatientAccessibility, error) {
+	if err := r.fault("Accessibility.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, exists := r.records[hn]
+	if !exists {
+		return nil, apperr.NotFound("accessibility record for patient %s not found", hn)
+	}
+
+	recordCopy := *a
+	recordCopy.Needs = append([]string{}, a.Needs...)
+	return &recordCopy, nil
+}
+
+// CountByNeed counts patients recorded with each accommodation
+func (r *MockAccessibilityRepository) CountByNeed() (map[string]int, error) {
+	if err := r.fault("Accessibility.CountByNeed"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	counts := make(map[string]int)
+	for _, a := range r.records {
+		for _, need := range a.Needs {
+			counts[need]++
+		}
+	}
+
+	return counts, nil
+}
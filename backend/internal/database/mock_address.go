@@ -0,0 +1,165 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockAddressRepository is an in-memory implementation for testing
+type MockAddressRepository struct {
+	mockFidelity
+
+	addresses map[int]*Address
+	nextID    int
+	mutex     sync.RWMutex
+}
+
+// NewMockAddressRepository creates a new mock address repository
+func NewMockAddressRepository() *MockAddressRepository {
+	return &MockAddressRepository{
+		addresses: make(map[int]*Address),
+		nextID:    1,
+	}
+}
+
+// demote clears the primary flag of a patient's other addresses
+func (r *MockAddressRepository) demote(hn string, keep int) {
+	for _, existing := range r.addresses {
+		if existing.PatientHN == hn && existing.ID != keep {
+			existing.Primary = false
+		}
+	}
+}
+
+// Create adds an address to a patient, primary if it is their first
+func (r *MockAddressRepository) Create(a *Address) error {
+	if err := r.fault("Address.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(a.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	others := false
+	for _, existing := range r.addresses {
+		others = others || existing.PatientHN == a.PatientHN
+	}
+	a.Primary = a.Primary || !others
+	if a.Primary {
+		r.demote(a.PatientHN, 0)
+	}
+
+	a.ID = r.nextID
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+	r.nextID++
+
+	addressCopy := *a
+	r.addresses[a.ID] = &addressCopy
+
+	return nil
+}
+
+// GetByID retrieves an address by ID
+func (r *MockAddressRepository) GetByID(id int) (*Address, error) {
+	if err := r.fault("Address.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, exists := r.addresses[id]
+	if !exists {
+		return nil, apperr.NotFound("address %d not found", id)
+	}
+	addressCopy := *a
+	return &addressCopy, nil
+}
+
+// GetByPatient retrieves a patient's addresses, the primary one first
+func (r *MockAddressRepository) GetByPatient(hn string) ([]Address, error) {
+	if err := r.fault("Address.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	addresses := []Address{}
+	for _, a := range r.addresses {
+		if a.PatientHN == hn {
+			addresses = append(addresses, *a)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].Primary != addresses[j].Primary {
+			return addresses[i].Primary
+		}
+		return addresses[i].ID < addresses[j].ID
+	})
+	return addresses, nil
+}
+
+// Update replaces an address's details, demoting the previous primary address if it becomes primary
+func (r *MockAddressRepository) Update(a *Address) error {
+	if err := r.fault("Address.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.addresses[a.ID]
+	if !exists {
+		return apperr.NotFound("address %d not found", a.ID)
+	}
+	if a.Primary {
+		r.demote(existing.PatientHN, a.ID)
+	}
+
+	a.PatientHN = existing.PatientHN
+	a.CreatedAt = existing.CreatedAt
+	a.UpdatedAt = time.Now()
+	addressCopy := *a
+	r.addresses[a.ID] = &addressCopy
+
+	return nil
+}
+
+// Delete removes an address, promoting the patient's oldest remaining one if it was primary
+func (r *MockAddressRepository) Delete(id int) error {
+	if err := r.fault("Address.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a, exists := r.addresses[id]
+	if !exists {
+		return apperr.NotFound("address %d not found", id)
+	}
+	delete(r.addresses, id)
+
+	if a.Primary {
+		var oldest *Address
+		for _, other := range r.addresses {
+			if other.PatientHN == a.PatientHN && (oldest == nil || other.ID < oldest.ID) {
+				oldest = other
+			}
+		}
+		if oldest != nil {
+			oldest.Primary = true
+			oldest.UpdatedAt = time.Now()
+		}
+	}
+
+	return nil
+}
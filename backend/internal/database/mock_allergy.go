@@ -0,0 +1,141 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockAllergyRepository is an in-memory implementation for testing
+type MockAllergyRepository struct {
+	mockFidelity
+
+	allergies map[int]*Allergy
+	nextID    int
+	mutex     sync.RWMutex
+}
+
+// NewMockAllergyRepository creates a new mock allergy repository
+func NewMockAllergyRepository() *MockAllergyRepository {
+	return &MockAllergyRepository{
+		allergies: make(map[int]*Allergy),
+		nextID:    1,
+	}
+}
+
+// Create stores a patient's allergy
+func (r *MockAllergyRepository) Create(a *Allergy) error {
+	if err := r.fault("Allergy.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(a.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a.ID = r.nextID
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+	r.nextID++
+
+	allergyCopy := *a
+	r.allergies[a.ID] = &allergyCopy
+
+	return nil
+}
+
+// GetByID retrieves an allergy by ID
+func (r *MockAllergyRepository) GetByID(id int) (*Allergy, error) {
+	if err := r.fault("Allergy.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, exists := r.allergies[id]
+	if !exists {
+		return nil, apperr.NotFound("allergy %d not found", id)
+	}
+	allergyCopy := *a
+	return &allergyCopy, nil
+}
+
+// GetByPatient retrieves a patient's allergies, active ones first and then most severe first
+func (r *MockAllergyRepository) GetByPatient(hn string, activeOnly bool) ([]Allergy, error) {
+	if err := r.fault("Allergy.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	allergies := []Allergy{}
+	for _, a := range r.allergies {
+		if a.PatientHN == hn && (!activeOnly || a.Status == AllergyActive) {
+			allergies = append(allergies, *a)
+		}
+	}
+	rank := make(map[string]int, len(AllergySeverities))
+	for i, s := range AllergySeverities {
+		rank[s] = i
+	}
+	sort.Slice(allergies, func(i, j int) bool {
+		a, b := allergies[i], allergies[j]
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+		if a.Severity != b.Severity {
+			return rank[a.Severity] > rank[b.Severity]
+		}
+		return a.Allergen < b.Allergen
+	})
+	return allergies, nil
+}
+
+// Update replaces an allergy's details and status
+func (r *MockAllergyRepository) Update(a *Allergy) error {
+	if err := r.fault("Allergy.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.allergies[a.ID]
+	if !exists {
+		return apperr.NotFound("allergy %d not found", a.ID)
+	}
+
+	existing.Allergen = a.Allergen
+	existing.Reaction = a.Reaction
+	existing.Severity = a.Severity
+	existing.NotedDate = a.NotedDate
+	existing.Status = a.Status
+	existing.Notes = a.Notes
+	existing.UpdatedAt = time.Now()
+	*a = *existing
+
+	return nil
+}
+
+// Delete removes an allergy
+func (r *MockAllergyRepository) Delete(id int) error {
+	if err := r.fault("Allergy.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.allergies[id]; !exists {
+		return apperr.NotFound("allergy %d not found", id)
+	}
+	delete(r.allergies, id)
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockAnnouncementRepository is an in-memory implementation for testing
+type MockAnnouncementRepository struct {
+	mockFidelity
+
+	announcements map[int]*Announcement
+	acks          map[int][]Acknowledgment // announcement ID -> acknowledgments in the order given
+	nextID        int
+	mutex         sync.RWMutex
+}
+
+// NewMockAnnouncementRepository creates a new mock announcement repository
+func NewMockAnnouncementRepository() *MockAnnouncementRepository {
+	return &MockAnnouncementRepository{
+		announcements: make(map[int]*Announcement),
+		acks:          make(map[int][]Acknowledgment),
+		nextID:        1,
+	}
+}
+
+// Create stores an announcement
+func (r *MockAnnouncementRepository) Create(a *Announcement) error {
+	if err := r.fault("Announcement.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a.ID = r.nextID
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+	a.AckCount = 0
+	a.Acknowledged = nil
+	r.nextID++
+
+	announcementCopy := *a
+	r.announcements[a.ID] = &announcementCopy
+
+	return nil
+}
+
+// GetByID retrieves an announcement by ID
+func (r *MockAnnouncementRepository) GetByID(id int) (*Announcement, error) {
+	if err := r.fault("Announcement.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, exists := r.announcements[id]
+	if !exists {
+		return nil, apperr.NotFound("announcement %d not found", id)
+	}
+	announcementCopy := *a
+	announcementCopy.AckCount = len(r.acks[id])
+	return &announcementCopy, nil
+}
+
+// List retrieves announcements matching the filter, most pressing and then newest first
+func (r *MockAnnouncementRepository) List(f AnnouncementFilter) ([]Announcement, error) {
+	if err := r.fault("Announcement.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	announcements := []Announcement{}
+	for _, a := range r.announcements {
+		if !f.ActiveAt.IsZero() && !a.ActiveAt(f.ActiveAt) {
+			continue
+		}
+		announcement := *a
+		announcement.AckCount = len(r.acks[a.ID])
+		if f.Staff != "" {
+			acknowledged := false
+			for _, ack := range r.acks[a.ID] {
+				acknowledged = acknowledged || strings.EqualFold(ack.StaffName, f.Staff)
+			}
+			if f.Unacknowledged && acknowledged {
+				continue
+			}
+			announcement.Acknowledged = &acknowledged
+		}
+		announcements = append(announcements, announcement)
+	}
+	rank := make(map[string]int, len(AnnouncementPriorities))
+	for i, p := range AnnouncementPriorities {
+		rank[p] = i
+	}
+	sort.Slice(announcements, func(i, j int) bool {
+		a, b := announcements[i], announcements[j]
+		if a.Priority != b.Priority {
+			return rank[a.Priority] > rank[b.Priority]
+		}
+		if !a.PublishAt.Equal(b.PublishAt) {
+			return a.PublishAt.After(b.PublishAt)
+		}
+		return a.ID > b.ID
+	})
+	return announcements, nil
+}
+
+// Update replaces an announcement's content and window
+func (r *MockAnnouncementRepository) Update(a *Announcement) error {
+	if err := r.fault("Announcement.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.announcements[a.ID]
+	if !exists {
+		return apperr.NotFound("announcement %d not found", a.ID)
+	}
+
+	existing.Title = a.Title
+	existing.Body = a.Body
+	existing.Priority = a.Priority
+	existing.RequiresAck = a.RequiresAck
+	existing.PublishAt = a.PublishAt
+	existing.ExpiresAt = a.ExpiresAt
+	existing.UpdatedAt = time.Now()
+	*a = *existing
+	a.AckCount = len(r.acks[a.ID])
+
+	return nil
+}
+
+// Delete removes an announcement and its acknowledgments
+func (r *MockAnnouncementRepository) Delete(id int) error {
+	if err := r.fault("Announcement.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.announcements[id]; !exists {
+		return apperr.NotFound("announcement %d not found", id)
+	}
+	delete(r.announcements, id)
+	delete(r.acks, id)
+
+	return nil
+}
+
+// Acknowledge records that a member of staff has read an announcement, keeping the first time
+func (r *MockAnnouncementRepository) Acknowledge(id int, staff string) (*Acknowledgment, error) {
+	if err := r.fault("Announcement.Acknowledge"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.announcements[id]; !exists {
+		return nil, apperr.NotFound("announcement %d not found", id)
+	}
+	for _, ack := range r.acks[id] {
+		if ack.StaffName == staff {
+			return &ack, nil
+		}
+	}
+
+	ack := Acknowledgment{AnnouncementID: id, StaffName: staff, AcknowledgedAt: time.Now()}
+	r.acks[id] = append(r.acks[id], ack)
+	return &ack, nil
+}
+
+// GetAcknowledgments retrieves who has acknowledged an announcement, in the order they did
+func (r *MockAnnouncementRepository) GetAcknowledgments(id int) ([]Acknowledgment, error) {
+	if err := r.fault("Announcement.GetAcknowledgments"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]Acknowledgment{}, r.acks[id]...), nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockAPIKeyRepository is an in-memory implementation for testing
+type MockAPIKeyRepository struct {
+	mockFidelity
+
+	keys   map[int]*APIKey
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockAPIKeyRepository creates a new mock API key repository
+func NewMockAPIKeyRepository() *MockAPIKeyRepository {
+	return &MockAPIKeyRepository{
+		keys:   make(map[int]*APIKey),
+		nextID: 1,
+	}
+}
+
+// Create stores a new API key
+func (r *MockAPIKeyRepository) Create(k *APIKey) error {
+	if err := r.fault("APIKey.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	k.ID = r.nextID
+	k.CreatedAt = time.Now()
+	r.nextID++
+
+	keyCopy := *k
+	r.keys[k.ID] = &keyCopy
+
+	return nil
+}
+
+// GetAll retrieves every API key, revoked ones included, newest first
+func (r *MockAPIKeyRepository) GetAll() ([]APIKey, error) {
+	if err := r.fault("APIKey.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	keys := []APIKey{}
+	for _, k := range r.keys {
+		keys = append(keys, *k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID > keys[j].ID })
+
+	return keys, nil
+}
+
+// GetByHash retrieves the unrevoked key with the given hash
+func (r *MockAPIKeyRepository) GetByHash(hash string) (*APIKey, error) {
+	if err := r.fault("APIKey.GetByHash"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, k := range r.keys {
+		if k.KeyHash == hash && k.RevokedAt == nil {
+			keyCopy := *k
+			return &keyCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("API key not found")
+}
+
+// MarkUsed records that a key was just used
+func (r *MockAPIKeyRepository) MarkUsed(id int) error {
+	if err := r.fault("APIKey.MarkUsed"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if k, exists := r.keys[id]; exists {
+		now := time.Now()
+		k.LastUsedAt = &now
+	}
+	return nil
+}
+
+// Revoke stops a key from working
+func (r *MockAPIKeyRepository) Revoke(id int, by string) (*APIKey, error) {
+	if err := r.fault("APIKey.Revoke"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	k, exists := r.keys[id]
+	if !exists {
+		return nil, apperr.NotFound("API key %d not found", id)
+	}
+	if k.RevokedAt != nil {
+		return nil, apperr.Conflict("API key %d is already revoked", id)
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	k.RevokedBy = &by
+
+	keyCopy := *k
+	return &keyCopy, nil
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockAppointmentRepository is an in-memory implementation for testing
+type MockAppointmentRepository struct {
+	mockFidelity
+
+	appointments map[int]*Appointment
+	nextID       int
+	mutex        sync.RWMutex
+}
+
+// NewMockAppointmentRepository creates a new mock appointment repository
+func NewMockAppointmentRepository() *MockAppointmentRepository {
+	return &MockAppointmentRepository{
+		appointments: make(map[int]*Appointment),
+		nextID:       1,
+	}
+}
+
+func (r *MockAppointmentRepository) checkDoctorFree(a *Appointment) error {
+	for _, existing := range r.appointments {
+		if existing.ID != a.ID && existing.Active() && strings.EqualFold(existing.DoctorName, a.DoctorName) &&
+			existing.StartsAt.Before(a.EndsAt) && existing.EndsAt.After(a.StartsAt) {
+			return apperr.Conflict("%s already has an appointment at that time", a.DoctorName)
+		}
+	}
+	return nil
+}
+
+func (r *MockAppointmentRepository) checkReferences(a *Appointment) error {
+	if err := r.checkPatient(a.PatientHN); err != nil {
+		return err
+	}
+	if a.DoctorID != nil {
+		return r.checkDoctor(*a.DoctorID)
+	}
+	return nil
+}
+
+// Create books an appointment if the doctor is free at that time
+func (r *MockAppointmentRepository) Create(a *Appointment) error {
+	if err := r.fault("Appointment.Create"); err != nil {
+		return err
+	}
+	if err := r.checkReferences(a); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkDoctorFree(a); err != nil {
+		return err
+	}
+
+	a.ID = r.nextID
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+	r.nextID++
+
+	appointmentCopy := *a
+	r.appointments[a.ID] = &appointmentCopy
+
+	return nil
+}
+
+// GetByID retrieves an appointment by ID
+func (r *MockAppointmentRepository) GetByID(id int) (*Appointment, error) {
+	if err := r.fault("Appointment.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, exists := r.appointments[id]
+	if !exists {
+		return nil, apperr.NotFound("appointment %d not found", id)
+	}
+
+	appointmentCopy := *a
+	return &appointmentCopy, nil
+}
+
+// List retrieves appointments matching the filter, earliest first
+func (r *MockAppointmentRepository) List(f AppointmentFilter) ([]Appointment, error) {
+	if err := r.fault("Appointment.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	appointments := []Appointment{}
+	for _, a := range r.appointments {
+		if (!f.From.IsZero() && a.StartsAt.Before(f.From)) || (!f.To.IsZero() && !a.StartsAt.Before(f.To)) {
+			continue
+		}
+		if (!f.CancelledFrom.IsZero() && (a.CancelledAt == nil || a.CancelledAt.Before(f.CancelledFrom))) ||
+			(!f.CancelledTo.IsZero() && (a.CancelledAt == nil || !a.CancelledAt.Before(f.CancelledTo))) {
+			continue
+		}
+		if (f.PatientHN != "" && a.PatientHN != f.PatientHN) ||
+			(f.DoctorID != 0 && (a.DoctorID == nil || *a.DoctorID != f.DoctorID)) ||
+			(f.DoctorName != "" && !strings.EqualFold(a.DoctorName, f.DoctorName)) ||
+			(f.Type != "" && a.Type != f.Type) || (f.Status != "" && a.Status != f.Status) ||
+			(f.Channel != "" && (a.Channel == nil || *a.Channel != f.Channel)) {
+			continue
+		}
+		appointments = append(appointments, *a)
+	}
+	sort.Slice(appointments, func(i, j int) bool { return appointments[i].StartsAt.Before(appointments[j].StartsAt) })
+
+	return appointments, nil
+}
+
+// Reschedule moves a scheduled appointment if the doctor is free then, and counts the change
+func (r *MockAppointmentRepository) Reschedule(a *Appointment) error {
+	if err := r.fault("Appointment.Reschedule"); err != nil {
+		return err
+	}
+	if err := r.checkReferences(a); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.appointments[a.ID]
+	if !exists || existing.Status != AppointmentScheduled {
+		return apperr.Conflict("appointment %d is not scheduled", a.ID)
+	}
+	if err := r.checkDoctorFree(a); err != nil {
+		return err
+	}
+
+	existing.DoctorID = a.DoctorID
+	existing.DoctorName = a.DoctorName
+	existing.StartsAt = a.StartsAt
+	existing.EndsAt = a.EndsAt
+	existing.RescheduleCount++
+	existing.Confirmation = AppointmentUnconfirmed
+	existing.ConfirmedAt = nil
+	existing.RemindersSent = 0
+	existing.UpdatedAt = time.Now()
+	*a = *existing
+
+	return nil
+}
+
+// UpdateStatus moves an appointment from one status to another
+func (r *MockAppointmentRepository) UpdateStatus(id int, from, to string, cancel *Cancellation) (*Appointment, error) {
+	if err := r.fault("Appointment.UpdateStatus"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a, exists := r.appointments[id]
+	if !exists || a.Status != from {
+		return nil, apperr.Conflict("appointment %d is no longer %s", id, from)
+	}
+
+	now := time.Now()
+	a.Status = to
+	a.UpdatedAt = now
+	if to == AppointmentCancelled {
+		a.CancelReasonCode, a.CancelReason = nil, nil
+		if cancel != nil {
+			code := cancel.ReasonCode
+			a.CancelReasonCode = &code
+			a.CancelReason = cancel.Note
+		}
+		a.CancelledAt = &now
+	}
+
+	appointmentCopy := *a
+	return &appointmentCopy, nil
+}
+
+// SetConfirmation records whether the patient confirmed or declined a scheduled appointment
+func (r *MockAppointmentRepository) SetConfirmation(id int, confirmation string) (*Appointment, error) {
+	if err := r.fault("Appointment.SetConfirmation"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a, exists := r.appointments[id]
+	if !exists || a.Status != AppointmentScheduled {
+		return nil, apperr.Conflict("appointment %d is not scheduled", id)
+	}
+
+	now := time.Now()
+	a.Confirmation = confirmation
+	a.ConfirmedAt = &now
+	if confirmation == AppointmentUnconfirmed {
+		a.ConfirmedAt = nil
+	}
+	a.UpdatedAt = now
+
+	appointmentCopy := *a
+	return &appointmentCopy, nil
+}
+
+// AdvanceReminders records that reminder policy steps up to to have fired for a scheduled, unconfirmed appointment
+func (r *MockAppointmentRepository) AdvanceReminders(id, from, to int) error {
+	if err := r.fault("Appointment.AdvanceReminders"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a, exists := r.appointments[id]
+	if !exists || a.RemindersSent != from || a.Status != AppointmentScheduled || a.Confirmation != AppointmentUnconfirmed {
+		return apperr.Conflict("appointment %d no longer needs reminder %d", id, to)
+	}
+	a.RemindersSent = to
+
+	return nil
+}
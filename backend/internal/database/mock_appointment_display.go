@@ -0,0 +1,80 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockAppointmentDisplayRepository is an in-memory implementation for testing
+type MockAppointmentDisplayRepository struct {
+	mockFidelity
+
+	displays map[string]*AppointmentDisplay
+	mutex    sync.RWMutex
+}
+
+// NewMockAppointmentDisplayRepository creates a new mock appointment display repository
+func NewMockAppointmentDisplayRepository() *MockAppointmentDisplayRepository {
+	return &MockAppointmentDisplayRepository{
+		displays: make(map[string]*AppointmentDisplay),
+	}
+}
+
+// GetAll retrieves the configured styles; defaults are not included
+func (r *MockAppointmentDisplayRepository) GetAll() ([]AppointmentDisplay, error) {
+	if err := r.fault("AppointmentDisplay.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	displays := []AppointmentDisplay{}
+	for _, d := range r.displays {
+		displays = append(displays, *d)
+	}
+	sort.Slice(displays, func(i, j int) bool {
+		if displays[i].Scope != displays[j].Scope {
+			return displays[i].Scope < displays[j].Scope
+		}
+		return displays[i].Key < displays[j].Key
+	})
+	return displays, nil
+}
+
+// Set configures the style of a type or status, replacing any earlier one
+func (r *MockAppointmentDisplayRepository) Set(d *AppointmentDisplay) error {
+	if err := r.fault("AppointmentDisplay.Set"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	d.UpdatedAt = &now
+	displayCopy := *d
+	r.displays[d.Scope+":"+d.Key] = &displayCopy
+
+	return nil
+}
+
+// Delete removes a configured style, returning the type or status to its default
+func (r *MockAppointmentDisplayRepository) Delete(scope, key string) error {
+	if err := r.fault("AppointmentDisplay.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.displays[scope+":"+key]; !exists {
+		return apperr.NotFound("no display configured for %s %s", scope, key)
+	}
+	delete(r.displays, scope+":"+key)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MockAppointmentOverrideRepository is an in-memory implementation for testing
+type MockAppointmentOverrideRepository struct {
+	mockFidelity
+
+	overrides map[int]*AppointmentOverride
+	nextID    int
+	mutex     sync.RWMutex
+}
+
+// NewMockAppointmentOverrideRepository creates a new mock appointment override repository
+func NewMockAppointmentOverrideRepository() *MockAppointmentOverrideRepository {
+	return &MockAppointmentOverrideRepository{
+		overrides: make(map[int]*AppointmentOverride),
+		nextID:    1,
+	}
+}
+
+// Create records an override
+func (r *MockAppointmentOverrideRepository) Create(o *AppointmentOverride) error {
+	if err := r.fault("AppointmentOverride.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(o.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	o.ID = r.nextID
+	o.CreatedAt = time.Now()
+	r.nextID++
+
+	overrideCopy := *o
+	overrideCopy.ConflictsWith = append([]int{}, o.ConflictsWith...)
+	r.overrides[o.ID] = &overrideCopy
+
+	return nil
+}
+
+// List retrieves overrides matching the filter, newest first
+func (r *MockAppointmentOverrideRepository) List(f AppointmentOverrideFilter) ([]AppointmentOverride, error) {
+	if err := r.fault("AppointmentOverride.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	overrides := []AppointmentOverride{}
+	for _, o := range r.overrides {
+		if (!f.From.IsZero() && o.CreatedAt.Before(f.From)) || (!f.To.IsZero() && !o.CreatedAt.Before(f.To)) ||
+			(f.PatientHN != "" && o.PatientHN != f.PatientHN) {
+			continue
+		}
+		overrides = append(overrides, *o)
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].ID > overrides[j].ID })
+
+	return overrides, nil
+}
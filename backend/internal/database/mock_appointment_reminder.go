@@ -0,0 +1,229 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockAppointmentReminderRepository is an in-memory implementation for testing
+type MockAppointmentReminderRepository struct {
+	mockFidelity
+
+	reminders     map[int]*AppointmentReminder
+	failures      []NotificationFailure
+	nextID        int
+	nextFailureID int
+	mutex         sync.RWMutex
+}
+
+// NewMockAppointmentReminderRepository creates a new mock appointment reminder repository
+func NewMockAppointmentReminderRepository() *MockAppointmentReminderRepository {
+	return &MockAppointmentReminderRepository{
+		reminders:     make(map[int]*AppointmentReminder),
+		nextID:        1,
+		nextFailureID: 1,
+	}
+}
+
+// Create records a fired reminder step; each step fires once per appointment
+func (r *MockAppointmentReminderRepository) Create(m *AppointmentReminder) error {
+	if err := r.fault("AppointmentReminder.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.reminders {
+		if existing.AppointmentID == m.AppointmentID && existing.Step == m.Step {
+			return apperr.Conflict("reminder %d of appointment %d was already fired", m.Step, m.AppointmentID)
+		}
+	}
+
+	m.ID = r.nextID
+	m.CreatedAt = time.Now()
+	r.nextID++
+
+	reminderCopy := *m
+	r.reminders[m.ID] = &reminderCopy
+
+	return nil
+}
+
+// List retrieves reminders matching the filter, oldest first
+func (r *MockAppointmentReminderRepository) List(f AppointmentReminderFilter) ([]AppointmentReminder, error) {
+	if err := r.fault("AppointmentReminder.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	now := time.Now()
+	reminders := []AppointmentReminder{}
+	for _, m := range r.reminders {
+		if (f.AppointmentID != 0 && m.AppointmentID != f.AppointmentID) ||
+			(f.Channel != "" && m.Channel != f.Channel) || (f.Status != "" && m.Status != f.Status) ||
+			(f.Phone != "" && (m.Phone == nil || phoneDigits(*m.Phone) != phoneDigits(f.Phone))) ||
+			(f.Due && m.RetryAt != nil && m.RetryAt.After(now)) {
+			continue
+		}
+		reminders = append(reminders, *m)
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].ID < reminders[j].ID })
+
+	return reminders, nil
+}
+
+// UpdateStatus records whether a pending reminder was sent
+func (r *MockAppointmentReminderRepository) UpdateStatus(id int, from, to string) (*AppointmentReminder, error) {
+	if err := r.fault("AppointmentReminder.UpdateStatus"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, exists := r.reminders[id]
+	if !exists || m.Status != from {
+		return nil, apperr.Conflict("appointment reminder %d is no longer %s", id, from)
+	}
+
+	m.Status = to
+	if to == NotificationSent {
+		now := time.Now()
+		m.SentAt = &now
+		m.LastError = nil
+		m.RetryAt = nil
+	}
+
+	reminderCopy := *m
+	return &reminderCopy, nil
+}
+
+// GetByID retrieves a reminder by ID
+func (r *MockAppointmentReminderRepository) GetByID(id int) (*AppointmentReminder, error) {
+	if err := r.fault("AppointmentReminder.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	m, exists := r.reminders[id]
+	if !exists {
+		return nil, apperr.NotFound("appointment reminder %d not found", id)
+	}
+
+	reminderCopy := *m
+	return &reminderCopy, nil
+}
+
+// RecordFailure logs a failed delivery attempt of a pending reminder, which
+// is dead-lettered as failed when retryAt gives no further attempt
+func (r *MockAppointmentReminderRepository) RecordFailure(id int, f *NotificationFailure, retryAt func(attempts int) *time.Time) (*AppointmentReminder, error) {
+	if err := r.fault("AppointmentReminder.RecordFailure"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, exists := r.reminders[id]
+	if !exists {
+		return nil, apperr.NotFound("appointment reminder %d not found", id)
+	}
+	if m.Status != NotificationPending {
+		return nil, apperr.Conflict("appointment reminder %d is no longer pending", id)
+	}
+
+	lastError := f.Error
+	m.Attempts++
+	m.LastError = &lastError
+	m.RetryAt = retryAt(m.Attempts)
+	if m.RetryAt == nil {
+		m.Status = NotificationFailed
+	}
+
+	f.ID = r.nextFailureID
+	f.ReminderID = id
+	f.Attempt = m.Attempts
+	f.FailedAt = time.Now()
+	r.nextFailureID++
+	r.failures = append(r.failures, *f)
+
+	reminderCopy := *m
+	return &reminderCopy, nil
+}
+
+// GetFailures retrieves a reminder's failed delivery attempts, most recent first
+func (r *MockAppointmentReminderRepository) GetFailures(reminderID int) ([]NotificationFailure, error) {
+	if err := r.fault("AppointmentReminder.GetFailures"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	failures := []NotificationFailure{}
+	for i := len(r.failures) - 1; i >= 0; i-- {
+		if r.failures[i].ReminderID == reminderID {
+			failures = append(failures, r.failures[i])
+		}
+	}
+	return failures, nil
+}
+
+// Requeue puts a dead-lettered reminder back in the pending queue with a
+// fresh set of attempts, sending it to phone
+func (r *MockAppointmentReminderRepository) Requeue(id int, phone *string) (*AppointmentReminder, error) {
+	if err := r.fault("AppointmentReminder.Requeue"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, exists := r.reminders[id]
+	if !exists {
+		return nil, apperr.NotFound("appointment reminder %d not found", id)
+	}
+	if m.Status != NotificationFailed {
+		return nil, apperr.Conflict("appointment reminder %d is not failed", id)
+	}
+
+	m.Status = NotificationPending
+	m.Attempts = 0
+	m.RetryAt = nil
+	m.Phone = phone
+
+	reminderCopy := *m
+	return &reminderCopy, nil
+}
+
+// DeliveryStats counts the reminders the gateway sent, and its failed
+// attempts, from since on; simulated sandbox reminders are left out
+func (r *MockAppointmentReminderRepository) DeliveryStats(since time.Time) (NotificationStats, error) {
+	if err := r.fault("AppointmentReminder.DeliveryStats"); err != nil {
+		return NotificationStats{}, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var s NotificationStats
+	for _, m := range r.reminders {
+		if m.SentAt != nil && !m.SentAt.Before(since) && m.Channel != ReminderCall && !m.Sandbox {
+			s.Sent++
+		}
+	}
+	for _, f := range r.failures {
+		if !f.FailedAt.Before(since) {
+			s.Failed++
+		}
+	}
+	return s, nil
+}
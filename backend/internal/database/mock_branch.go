@@ -0,0 +1,96 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockBranchRepository is an in-memory implementation for testing
+type MockBranchRepository struct {
+	mockFidelity
+
+	branches map[string]*Branch
+	mutex    sync.RWMutex
+}
+
+// NewMockBranchRepository creates a new mock branch repository
+func NewMockBranchRepository() *MockBranchRepository {
+	return &MockBranchRepository{
+		branches: make(map[string]*Branch),
+	}
+}
+
+func copyBranch(b *Branch) *Branch {
+	branchCopy := *b
+	branchCopy.Hours = append([]OpeningHours{}, b.Hours...)
+	return &branchCopy
+}
+
+// GetAll retrieves every configured branch, by ID
+func (r *MockBranchRepository) GetAll() ([]Branch, error) {
+	if err := r.fault("Branch.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	branches := []Branch{}
+	for _, b := range r.branches {
+		branches = append(branches, *copyBranch(b))
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].ID < branches[j].ID })
+
+	return branches, nil
+}
+
+// GetByID retrieves a branch's settings
+func (r *MockBranchRepository) GetByID(id string) (*Branch, error) {
+	if err := r.fault("Branch.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	b, exists := r.branches[id]
+	if !exists {
+		return nil, apperr.NotFound("branch %s not found", id)
+	}
+	return copyBranch(b), nil
+}
+
+// Save creates or replaces a branch's settings
+func (r *MockBranchRepository) Save(b *Branch) error {
+	if err := r.fault("Branch.Save"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b.UpdatedAt = time.Now()
+	r.branches[b.ID] = copyBranch(b)
+
+	return nil
+}
+
+// Delete removes a branch's settings
+func (r *MockBranchRepository) Delete(id string) error {
+	if err := r.fault("Branch.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.branches[id]; !exists {
+		return apperr.NotFound("branch %s not found", id)
+	}
+	delete(r.branches, id)
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockCampaignRepository is an in-memory implementation for testing
+type MockCampaignRepository struct {
+	mockFidelity
+
+	campaigns          map[int]*Campaign
+	registrations      map[int]*CampaignRegistration
+	nextCampaignID     int
+	nextRegistrationID int
+	mutex              sync.RWMutex
+}
+
+// NewMockCampaignRepository creates a new mock campaign repository
+func NewMockCampaignRepository() *MockCampaignRepository {
+	return &MockCampaignRepository{
+		campaigns:          make(map[int]*Campaign),
+		registrations:      make(map[int]*CampaignRegistration),
+		nextCampaignID:     1,
+		nextRegistrationID: 1,
+	}
+}
+
+// CreateCampaign stores a new campaign
+func (r *MockCampaignRepository) CreateCampaign(c *Campaign) error {
+	if err := r.fault("Campaign.CreateCampaign"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c.ID = r.nextCampaignID
+	c.CreatedAt = time.Now()
+	r.nextCampaignID++
+
+	campaignCopy := *c
+	r.campaigns[c.ID] = &campaignCopy
+
+	return nil
+}
+
+// GetCampaign retrieves a campaign by ID
+func (r *MockCampaignRepository) GetCampaign(id int) (*Campaign, error) {
+	if err := r.fault("Campaign.GetCampaign"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, exists := r.campaigns[id]
+	if !exists {
+		return nil, apperr.NotFound("campaign %d not found", id)
+	}
+
+	campaignCopy := *c
+	return &campaignCopy, nil
+}
+
+// GetCampaigns retrieves all campaigns, most recent start first
+func (r *MockCampaignRepository) GetCampaigns() ([]Campaign, error) {
+	if err := r.fault("Campaign.GetCampaigns"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	campaigns := []Campaign{}
+	for _, c := range r.campaigns {
+		campaigns = append(campaigns, *c)
+	}
+
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].StartDate > campaigns[j].StartDate
+	})
+
+	return campaigns, nil
+}
+
+// UpdateCampaignStatus changes a campaign's status
+func (r *MockCampaignRepository) UpdateCampaignStatus(id int, status string) error {
+	if err := r.fault("Campaign.UpdateCampaignStatus"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c, exists := r.campaigns[id]
+	if !exists {
+		return apperr.NotFound("campaign %d not found", id)
+	}
+
+	c.Status = status
+	return nil
+}
+
+// AddRegistrations stores imported pre-registrations
+func (r *MockCampaignRepository) AddRegistrations(regs []CampaignRegistration) error {
+	if err := r.fault("Campaign.AddRegistrations"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	for i := range regs {
+		regs[i].ID = r.nextRegistrationID
+		regs[i].ImportedAt = now
+		r.nextRegistrationID++
+
+		regCopy := regs[i]
+		r.registrations[regCopy.ID] = &regCopy
+	}
+
+	return nil
+}
+
+// GetRegistrations retrieves a campaign's pre-registrations in import order
+func (r *MockCampaignRepository) GetRegistrations(campaignID int) ([]CampaignRegistration, error) {
+	if err := r.fault("Campaign.GetRegistrations"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	regs := []CampaignRegistration{}
+	for _, reg := range r.registrations {
+		if reg.CampaignID == campaignID {
+			regs = append(regs, *reg)
+		}
+	}
+
+	sort.Slice(regs, func(i, j int) bool {
+		return regs[i].ID < regs[j].ID
+	})
+
+	return regs, nil
+}
+
+// UpdateRegistration saves a registration's status and booked slot
+func (r *MockCampaignRepository) UpdateRegistration(reg *CampaignRegistration) error {
+	if err := r.fault("Campaign.UpdateRegistration"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.registrations[reg.ID]
+	if !exists {
+		return apperr.NotFound("campaign registration %d not found", reg.ID)
+	}
+
+	existing.Status = reg.Status
+	existing.SessionID = reg.SessionID
+	existing.BookingID = reg.BookingID
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MockCancellationReasonRepository is an in-memory implementation for testing
+type MockCancellationReasonRepository struct {
+	mockFidelity
+
+	reasons map[string]*CancellationReason
+	mutex   sync.RWMutex
+}
+
+// NewMockCancellationReasonRepository creates a new mock cancellation reason repository
+func NewMockCancellationReasonRepository() *MockCancellationReasonRepository {
+	return &MockCancellationReasonRepository{
+		reasons: make(map[string]*CancellationReason),
+	}
+}
+
+// GetAll retrieves the configured reasons; defaults are not included
+func (r *MockCancellationReasonRepository) GetAll() ([]CancellationReason, error) {
+	if err := r.fault("CancellationReason.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	reasons := []CancellationReason{}
+	for _, c := range r.reasons {
+		reasons = append(reasons, *c)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].SortOrder != reasons[j].SortOrder {
+			return reasons[i].SortOrder < reasons[j].SortOrder
+		}
+		return reasons[i].Code < reasons[j].Code
+	})
+	return reasons, nil
+}
+
+// Set adds a reason or replaces a default or earlier configured one
+func (r *MockCancellationReasonRepository) Set(c *CancellationReason) error {
+	if err := r.fault("CancellationReason.Set"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	c.UpdatedAt = &now
+	reasonCopy := *c
+	r.reasons[c.Code] = &reasonCopy
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockCarePlanRepository is an in-memory implementation for testing
+type MockCarePlanRepository struct {
+	mockFidelity
+
+	goals            map[int]*CarePlanGoal
+	checkpoints      map[int]*GoalCheckpoint
+	nextGoalID       int
+	nextCheckpointID int
+	mutex            sync.RWMutex
+}
+
+// NewMockCarePlanRepository creates a new mock care plan repository
+func NewMockCarePlanRepository() *MockCarePlanRepository {
+	return &MockCarePlanRepository{
+		goals:            make(map[int]*CarePlanGoal),
+		checkpoints:      make(map[int]*GoalCheckpoint),
+		nextGoalID:       1,
+		nextCheckpointID: 1,
+	}
+}
+
+// CreateGoal stores a new care plan goal
+func (r *MockCarePlanRepository) CreateGoal(g *CarePlanGoal) error {
+	if err := r.fault("CarePlan.CreateGoal"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(g.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	g.ID = r.nextGoalID
+	g.CreatedAt = time.Now()
+	r.nextGoalID++
+
+	goalCopy := *g
+	r.goals[g.ID] = &goalCopy
+
+	return nil
+}
+
+// GetGoal retrieves a care plan goal by ID
+func (r *MockCarePlanRepository) GetGoal(id int) (*CarePlanGoal, error) {
+	if err := r.fault("CarePlan.GetGoal"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	g, exists := r.goals[id]
+	if !exists {
+		return nil, apperr.NotFound("care plan goal %d not found", id)
+	}
+
+	goalCopy := *g
+	return &goalCopy, nil
+}
+
+// GetGoalsByPatient retrieves a patient's goals, oldest first
+func (r *MockCarePlanRepository) GetGoalsByPatient(hn string) ([]CarePlanGoal, error) {
+	if err := r.fault("CarePlan.GetGoalsByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	goals := []CarePlanGoal{}
+	for _, g := range r.goals {
+		if g.PatientHN == hn {
+			goals = append(goals, *g)
+		}
+	}
+
+	sort.Slice(goals, func(i, j int) bool {
+		return goals[i].ID < goals[j].ID
+	})
+
+	return goals, nil
+}
+
+// UpdateGoalStatus changes a goal's status
+func (r *MockCarePlanRepository) UpdateGoalStatus(id int, status string) error {
+	if err := r.fault("CarePlan.UpdateGoalStatus"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	g, exists := r.goals[id]
+	if !exists {
+		return apperr.NotFound("care plan goal %d not found", id)
+	}
+
+	g.Status = status
+	return nil
+}
+
+// AddCheckpoint records a measurement against a goal
+func (r *MockCarePlanRepository) AddCheckpoint(c *GoalCheckpoint) error {
+	if err := r.fault("CarePlan.AddCheckpoint"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c.ID = r.nextCheckpointID
+	c.CreatedAt = time.Now()
+	r.nextCheckpointID++
+
+	checkpointCopy := *c
+	r.checkpoints[c.ID] = &checkpointCopy
+
+	return nil
+}
+
+// GetCheckpoints retrieves a goal's checkpoints in measurement order
+func (r *MockCarePlanRepository) GetCheckpoints(goalID int) ([]GoalCheckpoint, error) {
+	if err := r.fault("CarePlan.GetCheckpoints"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	checkpoints := []GoalCheckpoint{}
+	for _, c := range r.checkpoints {
+		if c.GoalID == goalID {
+			checkpoints = append(checkpoints, *c)
+		}
+	}
+
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].MeasuredAt.Before(checkpoints[j].MeasuredAt)
+	})
+
+	return checkpoints, nil
+}
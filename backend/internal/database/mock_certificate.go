@@ -0,0 +1,130 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockCertificateRepository is an in-memory implementation for testing
+type MockCertificateRepository struct {
+	mockFidelity
+
+	certificates map[string]*MedicalCertificate
+	sequences    map[int]int
+	nextID       int
+	mutex        sync.RWMutex
+}
+
+// NewMockCertificateRepository creates a new mock certificate repository
+func NewMockCertificateRepository() *MockCertificateRepository {
+	return &MockCertificateRepository{
+		certificates: make(map[string]*MedicalCertificate),
+		sequences:    make(map[int]int),
+		nextID:       1,
+	}
+}
+
+// NextNumber reserves the next sequential certificate number for a year
+func (r *MockCertificateRepository) NextNumber(year int) (string, error) {
+	if err := r.fault("Certificate.NextNumber"); err != nil {
+		return "", err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.sequences[year]++
+	return fmt.Sprintf("MC%d-%06d", year, r.sequences[year]), nil
+}
+
+// Create stores an issued certificate
+func (r *MockCertificateRepository) Create(c *MedicalCertificate) error {
+	if err := r.fault("Certificate.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(c.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.certificates[c.CertificateNumber]; exists {
+		return apperr.Conflict("certificate %s already exists", c.CertificateNumber)
+	}
+
+	c.ID = r.nextID
+	r.nextID++
+
+	certificateCopy := *c
+	r.certificates[c.CertificateNumber] = &certificateCopy
+
+	return nil
+}
+
+// GetByNumber retrieves a certificate by its certificate number
+func (r *MockCertificateRepository) GetByNumber(number string) (*MedicalCertificate, error) {
+	if err := r.fault("Certificate.GetByNumber"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, exists := r.certificates[number]
+	if !exists {
+		return nil, apperr.NotFound("certificate %s not found", number)
+	}
+
+	certificateCopy := *c
+	return &certificateCopy, nil
+}
+
+// GetByPatient retrieves all certificates issued to a patient, newest first
+func (r *MockCertificateRepository) GetByPatient(hn string) ([]MedicalCertificate, error) {
+	if err := r.fault("Certificate.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	certificates := []MedicalCertificate{}
+	for _, c := range r.certificates {
+		if c.PatientHN == hn {
+			certificates = append(certificates, *c)
+		}
+	}
+
+	sort.Slice(certificates, func(i, j int) bool {
+		return certificates[i].IssuedAt.After(certificates[j].IssuedAt)
+	})
+
+	return certificates, nil
+}
+
+// Revoke marks a certificate as revoked
+func (r *MockCertificateRepository) Revoke(number, reason string) error {
+	if err := r.fault("Certificate.Revoke"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c, exists := r.certificates[number]
+	if !exists || c.Status != CertificateValid {
+		return apperr.Conflict("certificate %s is not valid", number)
+	}
+
+	now := time.Now()
+	c.Status = CertificateRevoked
+	c.RevokeReason = &reason
+	c.RevokedAt = &now
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockChatRepository is an in-memory implementation for testing
+type MockChatRepository struct {
+	mockFidelity
+
+	threads       map[int]*ChatThread
+	messages      []ChatMessage                  // in posting order
+	reads         map[int]map[string]ChatReceipt // thread ID -> reader -> receipt
+	nextThreadID  int
+	nextMessageID int
+	mutex         sync.RWMutex
+}
+
+// NewMockChatRepository creates a new mock chat repository
+func NewMockChatRepository() *MockChatRepository {
+	return &MockChatRepository{
+		threads:       make(map[int]*ChatThread),
+		reads:         make(map[int]map[string]ChatReceipt),
+		nextThreadID:  1,
+		nextMessageID: 1,
+	}
+}
+
+// CreateThread starts a thread
+func (r *MockChatRepository) CreateThread(t *ChatThread) error {
+	if err := r.fault("Chat.CreateThread"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(t.PatientHN); err != nil {
+		return err
+	}
+	if t.VisitID != nil {
+		if err := r.checkVisit(*t.VisitID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t.ID = r.nextThreadID
+	t.CreatedAt = time.Now()
+	t.LastMessageAt = t.CreatedAt
+	r.nextThreadID++
+
+	threadCopy := *t
+	r.threads[t.ID] = &threadCopy
+
+	return nil
+}
+
+// GetThread retrieves a thread by ID
+func (r *MockChatRepository) GetThread(id int) (*ChatThread, error) {
+	if err := r.fault("Chat.GetThread"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	t, exists := r.threads[id]
+	if !exists {
+		return nil, apperr.NotFound("chat thread %d not found", id)
+	}
+	threadCopy := *t
+	return &threadCopy, nil
+}
+
+// ListThreads retrieves threads matching the filter, most recently active first
+func (r *MockChatRepository) ListThreads(f ChatThreadFilter) ([]ChatThread, error) {
+	if err := r.fault("Chat.ListThreads"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	threads := []ChatThread{}
+	for _, t := range r.threads {
+		if (f.PatientHN != "" && t.PatientHN != f.PatientHN) || (f.VisitID != 0 && (t.VisitID == nil || *t.VisitID != f.VisitID)) {
+			continue
+		}
+		thread := *t
+		if f.Reader != "" {
+			upTo := r.reads[t.ID][f.Reader].LastReadMessageID
+			for _, m := range r.messages {
+				if m.ThreadID == t.ID && m.Author != f.Reader && m.ID > upTo {
+					thread.Unread++
+				}
+			}
+		}
+		threads = append(threads, thread)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		if !threads[i].LastMessageAt.Equal(threads[j].LastMessageAt) {
+			return threads[i].LastMessageAt.After(threads[j].LastMessageAt)
+		}
+		return threads[i].ID > threads[j].ID
+	})
+	return threads, nil
+}
+
+// PostMessage adds a message and its mentions to a thread; the author has read up to it
+func (r *MockChatRepository) PostMessage(m *ChatMessage) error {
+	if err := r.fault("Chat.PostMessage"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t, exists := r.threads[m.ThreadID]
+	if !exists {
+		return apperr.NotFound("chat thread %d not found", m.ThreadID)
+	}
+
+	m.ID = r.nextMessageID
+	m.CreatedAt = time.Now()
+	m.Mentions = append([]string{}, m.Mentions...)
+	sort.Strings(m.Mentions)
+	m.ReadBy = []string{}
+	r.nextMessageID++
+	t.LastMessageAt = m.CreatedAt
+
+	messageCopy := *m
+	r.messages = append(r.messages, messageCopy)
+	r.markRead(m.ThreadID, m.Author, m.ID)
+
+	return nil
+}
+
+// GetMessages retrieves a thread's messages oldest first, with their mentions and readers
+func (r *MockChatRepository) GetMessages(threadID int) ([]ChatMessage, error) {
+	if err := r.fault("Chat.GetMessages"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	readers := make([]string, 0, len(r.reads[threadID]))
+	for reader := range r.reads[threadID] {
+		readers = append(readers, reader)
+	}
+	sort.Strings(readers)
+
+	messages := []ChatMessage{}
+	for _, m := range r.messages {
+		if m.ThreadID != threadID {
+			continue
+		}
+		m.Mentions = append([]string{}, m.Mentions...)
+		m.ReadBy = []string{}
+		for _, reader := range readers {
+			if reader != m.Author && r.reads[threadID][reader].LastReadMessageID >= m.ID {
+				m.ReadBy = append(m.ReadBy, reader)
+			}
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+// MarkRead records that reader has read a thread up to message upTo
+func (r *MockChatRepository) MarkRead(threadID int, reader string, upTo int) (*ChatReceipt, error) {
+	if err := r.fault("Chat.MarkRead"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.threads[threadID]; !exists {
+		return nil, apperr.NotFound("chat thread %d not found", threadID)
+	}
+	receipt := r.markRead(threadID, reader, upTo)
+	return &receipt, nil
+}
+
+// markRead moves a reader's receipt forward; callers hold the lock
+func (r *MockChatRepository) markRead(threadID int, reader string, upTo int) ChatReceipt {
+	if r.reads[threadID] == nil {
+		r.reads[threadID] = make(map[string]ChatReceipt)
+	}
+	receipt := r.reads[threadID][reader]
+	receipt.ThreadID = threadID
+	receipt.Reader = reader
+	if upTo > receipt.LastReadMessageID {
+		receipt.LastReadMessageID = upTo
+	}
+	receipt.ReadAt = time.Now()
+	r.reads[threadID][reader] = receipt
+	return receipt
+}
+
+// GetMentions retrieves the messages that mention a member of staff, newest first
+func (r *MockChatRepository) GetMentions(staff string, unreadOnly bool) ([]ChatMessage, error) {
+	if err := r.fault("Chat.GetMentions"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	messages := []ChatMessage{}
+	for i := len(r.messages) - 1; i >= 0; i-- {
+		m := r.messages[i]
+		mentioned := false
+		for _, name := range m.Mentions {
+			mentioned = mentioned || strings.EqualFold(name, staff)
+		}
+		if !mentioned {
+			continue
+		}
+		if unreadOnly {
+			read := false
+			for reader, receipt := range r.reads[m.ThreadID] {
+				read = read || (strings.EqualFold(reader, staff) && receipt.LastReadMessageID >= m.ID)
+			}
+			if read {
+				continue
+			}
+		}
+		m.Mentions = append([]string{}, m.Mentions...)
+		m.ReadBy = nil
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockClinicalNoteRepository is an in-memory implementation for testing
+type MockClinicalNoteRepository struct {
+	mockFidelity
+
+	notes    map[int]*ClinicalNote
+	versions map[int][]ClinicalNoteVersion
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockClinicalNoteRepository creates a new mock clinical note repository
+func NewMockClinicalNoteRepository() *MockClinicalNoteRepository {
+	return &MockClinicalNoteRepository{
+		notes:    make(map[int]*ClinicalNote),
+		versions: make(map[int][]ClinicalNoteVersion),
+		nextID:   1,
+	}
+}
+
+// Create stores a new clinical note
+func (r *MockClinicalNoteRepository) Create(n *ClinicalNote) error {
+	if err := r.fault("ClinicalNote.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(n.PatientHN); err != nil {
+		return err
+	}
+	if err := r.checkVisit(n.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	n.ID = r.nextID
+	n.Version = 1
+	n.CreatedAt = time.Now()
+	r.nextID++
+
+	noteCopy := *n
+	r.notes[n.ID] = &noteCopy
+
+	return nil
+}
+
+// GetByID retrieves a clinical note by ID
+func (r *MockClinicalNoteRepository) GetByID(id int) (*ClinicalNote, error) {
+	if err := r.fault("ClinicalNote.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	n, exists := r.notes[id]
+	if !exists {
+		return nil, apperr.NotFound("clinical note %d not found", id)
+	}
+
+	noteCopy := *n
+	return &noteCopy, nil
+}
+
+// GetByVisit retrieves the notes of a visit, oldest first
+func (r *MockClinicalNoteRepository) GetByVisit(visitID int) ([]ClinicalNote, error) {
+	if err := r.fault("ClinicalNote.GetByVisit"); err != nil {
+		return nil, err
+	}
+
+	return r.filter(func(n *ClinicalNote) bool {
+		return n.VisitID == visitID
+	}), nil
+}
+
+// GetPendingCosign retrieves notes awaiting counter-signature, optionally for one supervisor
+func (r *MockClinicalNoteRepository) GetPendingCosign(supervisor string) ([]ClinicalNote, error) {
+	if err := r.fault("ClinicalNote.GetPendingCosign"); err != nil {
+		return nil, err
+	}
+
+	return r.filter(func(n *ClinicalNote) bool {
+		if n.Status != NoteStatusPendingCosign {
+			return false
+		}
+		return supervisor == "" || (n.SupervisorName != nil && *n.SupervisorName == supervisor)
+	}), nil
+}
+
+func (r *MockClinicalNoteRepository) filter(keep func(n *ClinicalNote) bool) []ClinicalNote {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	notes := []ClinicalNote{}
+	for _, n := range r.notes {
+		if keep(n) {
+			notes = append(notes, *n)
+		}
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].ID < notes[j].ID
+	})
+
+	return notes
+}
+
+// Cosign finalizes a pending note with the counter-signing doctor's details
+func (r *MockClinicalNoteRepository) Cosign(n *ClinicalNote) error {
+	if err := r.fault("ClinicalNote.Cosign"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.notes[n.ID]
+	if !exists || existing.Status != NoteStatusPendingCosign {
+		return apperr.Conflict("clinical note %d is not awaiting co-signature", n.ID)
+	}
+
+	existing.Status = NoteStatusFinal
+	existing.CosignedBy = n.CosignedBy
+	existing.CosignerLicense = n.CosignerLicense
+	existing.CosignedAt = n.CosignedAt
+	n.Status = NoteStatusFinal
+
+	return nil
+}
+
+// HasPendingCosign reports whether a visit still has notes awaiting counter-signature
+func (r *MockClinicalNoteRepository) HasPendingCosign(visitID int) (bool, error) {
+	if err := r.fault("ClinicalNote.HasPendingCosign"); err != nil {
+		return false, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, n := range r.notes {
+		if n.VisitID == visitID && n.Status == NoteStatusPendingCosign {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Amend replaces a final note's content with a new version, keeping every earlier version
+func (r *MockClinicalNoteRepository) Amend(noteID int, content, amendedBy, reason string) (*ClinicalNote, error) {
+	if err := r.fault("ClinicalNote.Amend"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	n, exists := r.notes[noteID]
+	if !exists {
+		return nil, apperr.NotFound("clinical note %d not found", noteID)
+	}
+	if n.Status != NoteStatusFinal {
+		return nil, apperr.Conflict("clinical note %d is not final", noteID)
+	}
+
+	if n.Version == 1 {
+		r.versions[noteID] = append(r.versions[noteID], ClinicalNoteVersion{
+			NoteID: noteID, Version: 1, Content: n.Content, AuthorName: n.AuthorName, CreatedAt: n.CreatedAt,
+		})
+	}
+
+	now := time.Now()
+	r.versions[noteID] = append(r.versions[noteID], ClinicalNoteVersion{
+		NoteID: noteID, Version: n.Version + 1, Content: content, AuthorName: amendedBy, Reason: &reason, CreatedAt: now,
+	})
+	n.Content = content
+	n.Version++
+	n.AmendedAt = &now
+
+	noteCopy := *n
+	return &noteCopy, nil
+}
+
+// GetVersions retrieves the stored versions of a note, oldest first
+func (r *MockClinicalNoteRepository) GetVersions(noteID int) ([]ClinicalNoteVersion, error) {
+	if err := r.fault("ClinicalNote.GetVersions"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]ClinicalNoteVersion{}, r.versions[noteID]...), nil
+}
@@ -0,0 +1,341 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockColdChainRepository is an in-memory implementation for testing
+type MockColdChainRepository struct {
+	mockFidelity
+
+	fridges         map[int]*Fridge
+	fridgeLots      map[int][]FridgeLot
+	readings        []TemperatureReading
+	excursions      map[int]*TemperatureExcursion
+	reviews         map[int]*LotReview
+	nextFridgeID    int
+	nextReadingID   int
+	nextExcursionID int
+	nextReviewID    int
+	mutex           sync.RWMutex
+}
+
+// NewMockColdChainRepository creates a new mock cold-chain repository
+func NewMockColdChainRepository() *MockColdChainRepository {
+	repo := &MockColdChainRepository{
+		fridges:         make(map[int]*Fridge),
+		fridgeLots:      make(map[int][]FridgeLot),
+		excursions:      make(map[int]*TemperatureExcursion),
+		reviews:         make(map[int]*LotReview),
+		nextFridgeID:    1,
+		nextReadingID:   1,
+		nextExcursionID: 1,
+		nextReviewID:    1,
+	}
+
+	// Add a sample vaccine fridge
+	repo.CreateFridge(&Fridge{Name: "ตู้เย็นวัคซีน 1", Location: "ห้องฉีดยา", MinTemp: 2, MaxTemp: 8})
+	return repo
+}
+
+// CreateFridge adds a monitored fridge
+func (r *MockColdChainRepository) CreateFridge(f *Fridge) error {
+	if err := r.fault("ColdChain.CreateFridge"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f.ID = r.nextFridgeID
+	f.CreatedAt = time.Now()
+	r.nextFridgeID++
+
+	fridgeCopy := *f
+	r.fridges[f.ID] = &fridgeCopy
+
+	return nil
+}
+
+// GetFridges retrieves all fridges
+func (r *MockColdChainRepository) GetFridges() ([]Fridge, error) {
+	if err := r.fault("ColdChain.GetFridges"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	fridges := make([]Fridge, 0, len(r.fridges))
+	for _, f := range r.fridges {
+		fridges = append(fridges, *f)
+	}
+
+	sort.Slice(fridges, func(i, j int) bool {
+		return fridges[i].Name < fridges[j].Name
+	})
+
+	return fridges, nil
+}
+
+// GetFridge retrieves a fridge by ID
+func (r *MockColdChainRepository) GetFridge(id int) (*Fridge, error) {
+	if err := r.fault("ColdChain.GetFridge"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	f, exists := r.fridges[id]
+	if !exists {
+		return nil, apperr.NotFound("fridge %d not found", id)
+	}
+
+	fridgeCopy := *f
+	return &fridgeCopy, nil
+}
+
+// SetFridgeLots replaces the list of lots stored in a fridge
+func (r *MockColdChainRepository) SetFridgeLots(fridgeID int, lots []FridgeLot) error {
+	if err := r.fault("ColdChain.SetFridgeLots"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := make([]FridgeLot, len(lots))
+	for i, lot := range lots {
+		lot.FridgeID = fridgeID
+		stored[i] = lot
+	}
+	r.fridgeLots[fridgeID] = stored
+
+	return nil
+}
+
+// GetFridgeLots retrieves the lots stored in a fridge
+func (r *MockColdChainRepository) GetFridgeLots(fridgeID int) ([]FridgeLot, error) {
+	if err := r.fault("ColdChain.GetFridgeLots"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	lots := append([]FridgeLot{}, r.fridgeLots[fridgeID]...)
+	return lots, nil
+}
+
+// CreateReading stores a temperature reading
+func (r *MockColdChainRepository) CreateReading(reading *TemperatureReading) error {
+	if err := r.fault("ColdChain.CreateReading"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	reading.ID = r.nextReadingID
+	r.nextReadingID++
+	r.readings = append(r.readings, *reading)
+
+	return nil
+}
+
+// GetReadings retrieves a fridge's readings within [from, to), oldest first
+func (r *MockColdChainRepository) GetReadings(fridgeID int, from, to time.Time) ([]TemperatureReading, error) {
+	if err := r.fault("ColdChain.GetReadings"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	readings := []TemperatureReading{}
+	for _, t := range r.readings {
+		if t.FridgeID == fridgeID && !t.RecordedAt.Before(from) && t.RecordedAt.Before(to) {
+			readings = append(readings, t)
+		}
+	}
+
+	sort.Slice(readings, func(i, j int) bool {
+		return readings[i].RecordedAt.Before(readings[j].RecordedAt)
+	})
+
+	return readings, nil
+}
+
+// GetOpenExcursion retrieves the ongoing excursion of a fridge, or nil if it is in range
+func (r *MockColdChainRepository) GetOpenExcursion(fridgeID int) (*TemperatureExcursion, error) {
+	if err := r.fault("ColdChain.GetOpenExcursion"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, e := range r.excursions {
+		if e.FridgeID == fridgeID && e.EndedAt == nil {
+			excursionCopy := *e
+			return &excursionCopy, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SaveExcursion creates or updates a temperature excursion
+func (r *MockColdChainRepository) SaveExcursion(e *TemperatureExcursion) error {
+	if err := r.fault("ColdChain.SaveExcursion"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if e.ID == 0 {
+		e.ID = r.nextExcursionID
+		r.nextExcursionID++
+	} else if _, exists := r.excursions[e.ID]; !exists {
+		return apperr.NotFound("excursion %d not found", e.ID)
+	}
+
+	excursionCopy := *e
+	r.excursions[e.ID] = &excursionCopy
+
+	return nil
+}
+
+// GetExcursions retrieves excursions, optionally only the ones still open
+func (r *MockColdChainRepository) GetExcursions(openOnly bool) ([]TemperatureExcursion, error) {
+	if err := r.fault("ColdChain.GetExcursions"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	excursions := []TemperatureExcursion{}
+	for _, e := range r.excursions {
+		if openOnly && e.EndedAt != nil {
+			continue
+		}
+		excursions = append(excursions, *e)
+	}
+
+	sort.Slice(excursions, func(i, j int) bool {
+		return excursions[i].StartedAt.After(excursions[j].StartedAt)
+	})
+
+	return excursions, nil
+}
+
+// CreateLotReviews stores pending reviews for lots exposed to an excursion
+func (r *MockColdChainRepository) CreateLotReviews(reviews []LotReview) error {
+	if err := r.fault("ColdChain.CreateLotReviews"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range reviews {
+		lr := &reviews[i]
+		lr.ID = r.nextReviewID
+		lr.CreatedAt = time.Now()
+		r.nextReviewID++
+
+		reviewCopy := *lr
+		r.reviews[lr.ID] = &reviewCopy
+	}
+
+	return nil
+}
+
+// GetLotReviews retrieves lot reviews, optionally filtered by status
+func (r *MockColdChainRepository) GetLotReviews(status string) ([]LotReview, error) {
+	if err := r.fault("ColdChain.GetLotReviews"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	reviews := []LotReview{}
+	for _, lr := range r.reviews {
+		if status != "" && lr.Status != status {
+			continue
+		}
+		reviews = append(reviews, *lr)
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].ID > reviews[j].ID
+	})
+
+	return reviews, nil
+}
+
+// GetLotReview retrieves a lot review by ID
+func (r *MockColdChainRepository) GetLotReview(id int) (*LotReview, error) {
+	if err := r.fault("ColdChain.GetLotReview"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	lr, exists := r.reviews[id]
+	if !exists {
+		return nil, apperr.NotFound("lot review %d not found", id)
+	}
+
+	reviewCopy := *lr
+	return &reviewCopy, nil
+}
+
+// UpdateLotReview stores the outcome of a lot review
+func (r *MockColdChainRepository) UpdateLotReview(lr *LotReview) error {
+	if err := r.fault("ColdChain.UpdateLotReview"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.reviews[lr.ID]
+	if !exists {
+		return apperr.NotFound("lot review %d not found", lr.ID)
+	}
+
+	existing.Status = lr.Status
+	existing.ReviewedBy = lr.ReviewedBy
+	existing.Notes = lr.Notes
+	existing.ReviewedAt = lr.ReviewedAt
+
+	return nil
+}
+
+// IsLotOnHold reports whether a lot has an unresolved cold-chain review
+func (r *MockColdChainRepository) IsLotOnHold(itemID int, lotNumber string) (bool, error) {
+	if err := r.fault("ColdChain.IsLotOnHold"); err != nil {
+		return false, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, lr := range r.reviews {
+		if lr.ItemID == itemID && lr.LotNumber == lotNumber && lr.Status == LotReviewPending {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockConsentRepository is an in-memory implementation for testing
+type MockConsentRepository struct {
+	mockFidelity
+
+	consents map[int]*Consent
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockConsentRepository creates a new mock consent repository
+func NewMockConsentRepository() *MockConsentRepository {
+	return &MockConsentRepository{
+		consents: make(map[int]*Consent),
+		nextID:   1,
+	}
+}
+
+// Create records a signed consent
+func (r *MockConsentRepository) Create(c *Consent) error {
+	if err := r.fault("Consent.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(c.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c.ID = r.nextID
+	c.CreatedAt = time.Now()
+	r.nextID++
+
+	consentCopy := *c
+	r.consents[c.ID] = &consentCopy
+
+	return nil
+}
+
+// GetByID retrieves a consent by ID
+func (r *MockConsentRepository) GetByID(id int) (*Consent, error) {
+	if err := r.fault("Consent.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, exists := r.consents[id]
+	if !exists {
+		return nil, apperr.NotFound("consent %d not found", id)
+	}
+
+	consentCopy := *c
+	return &consentCopy, nil
+}
+
+// GetByPatient retrieves a patient's consents matching the filter, most recently signed first
+func (r *MockConsentRepository) GetByPatient(hn string, f ConsentFilter) ([]Consent, error) {
+	if err := r.fault("Consent.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	consents := []Consent{}
+	for _, c := range r.consents {
+		if c.PatientHN != hn || (f.Type != "" && c.Type != f.Type) || (f.ActiveOnly && c.Status != ConsentActive) {
+			continue
+		}
+		consents = append(consents, *c)
+	}
+	sort.Slice(consents, func(i, j int) bool {
+		if consents[i].SignedDate != consents[j].SignedDate {
+			return consents[i].SignedDate > consents[j].SignedDate
+		}
+		return consents[i].ID > consents[j].ID
+	})
+
+	return consents, nil
+}
+
+// SetSignature links a consent to its stored signature image
+func (r *MockConsentRepository) SetSignature(c *Consent) error {
+	if err := r.fault("Consent.SetSignature"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.consents[c.ID]
+	if !exists {
+		return apperr.NotFound("consent %d not found", c.ID)
+	}
+
+	existing.SignatureKey = c.SignatureKey
+	existing.SignatureType = c.SignatureType
+	existing.HasSignature = c.SignatureKey != nil
+	c.HasSignature = existing.HasSignature
+	return nil
+}
+
+// Withdraw marks an active consent withdrawn
+func (r *MockConsentRepository) Withdraw(c *Consent) error {
+	if err := r.fault("Consent.Withdraw"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.consents[c.ID]
+	if !exists {
+		return apperr.NotFound("consent %d not found", c.ID)
+	}
+	if existing.Status != ConsentActive {
+		return apperr.Conflict("consent %d has already been withdrawn", c.ID)
+	}
+
+	now := time.Now()
+	existing.Status = ConsentWithdrawn
+	existing.WithdrawnAt = &now
+	existing.WithdrawalReason = c.WithdrawalReason
+
+	c.Status = existing.Status
+	c.WithdrawnAt = existing.WithdrawnAt
+	return nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockCoordinationRepository is an in-memory implementation for testing and single-instance use
+type MockCoordinationRepository struct {
+	mockFidelity
+
+	leases    map[string]*Lease
+	sequences map[string]int64
+	mutex     sync.RWMutex
+}
+
+// NewMockCoordinationRepository creates a new mock coordination repository
+func NewMockCoordinationRepository() *MockCoordinationRepository {
+	return &MockCoordinationRepository{
+		leases:    make(map[string]*Lease),
+		sequences: make(map[string]int64),
+	}
+}
+
+// Acquire claims a lease for holder when it is free or expired
+func (r *MockCoordinationRepository) Acquire(name, holder string, ttl time.Duration) (*Lease, error) {
+	if err := r.fault("Coordination.Acquire"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	existing, ok := r.leases[name]
+	if ok && existing.ExpiresAt.After(now) {
+		return nil, nil
+	}
+
+	l := &Lease{Name: name, Holder: holder, Token: 1, AcquiredAt: now, ExpiresAt: now.Add(ttl)}
+	if ok {
+		l.Token = existing.Token + 1
+	}
+	r.leases[name] = l
+
+	copied := *l
+	return &copied, nil
+}
+
+// Renew extends a lease the holder still owns
+func (r *MockCoordinationRepository) Renew(l *Lease, ttl time.Duration) error {
+	if err := r.fault("Coordination.Renew"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	existing, ok := r.leases[l.Name]
+	if !ok || existing.Holder != l.Holder || existing.Token != l.Token || !existing.ExpiresAt.After(now) {
+		return apperr.Conflict("lease %s lost", l.Name)
+	}
+
+	existing.ExpiresAt = now.Add(ttl)
+	l.ExpiresAt = existing.ExpiresAt
+	return nil
+}
+
+// Release gives up a lease early
+func (r *MockCoordinationRepository) Release(l *Lease) error {
+	if err := r.fault("Coordination.Release"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if existing, ok := r.leases[l.Name]; ok && existing.Holder == l.Holder && existing.Token == l.Token {
+		existing.ExpiresAt = time.Now()
+	}
+	return nil
+}
+
+// GetLeases returns every lease, including expired ones
+func (r *MockCoordinationRepository) GetLeases() ([]Lease, error) {
+	if err := r.fault("Coordination.GetLeases"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	leases := []Lease{}
+	for _, l := range r.leases {
+		leases = append(leases, *l)
+	}
+	sort.Slice(leases, func(i, j int) bool { return leases[i].Name < leases[j].Name })
+	return leases, nil
+}
+
+// NextValue reserves the next number of a named sequence
+func (r *MockCoordinationRepository) NextValue(name string) (int64, error) {
+	if err := r.fault("Coordination.NextValue"); err != nil {
+		return 0, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.sequences[name]++
+	return r.sequences[name], nil
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockDentalRepository is an in-memory implementation for testing
+type MockDentalRepository struct {
+	mockFidelity
+
+	findings        map[int]*ToothFinding
+	treatments      map[int]*DentalTreatment
+	nextFindingID   int
+	nextTreatmentID int
+	mutex           sync.RWMutex
+}
+
+// NewMockDentalRepository creates a new mock dental repository
+func NewMockDentalRepository() *MockDentalRepository {
+	return &MockDentalRepository{
+		findings:        make(map[int]*ToothFinding),
+		treatments:      make(map[int]*DentalTreatment),
+		nextFindingID:   1,
+		nextTreatmentID: 1,
+	}
+}
+
+// AddFinding charts the condition of a tooth
+func (r *MockDentalRepository) AddFinding(f *ToothFinding) error {
+	if err := r.fault("Dental.AddFinding"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(f.PatientHN); err != nil {
+		return err
+	}
+	if f.VisitID != nil {
+		if err := r.checkVisit(*f.VisitID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f.ID = r.nextFindingID
+	f.RecordedAt = time.Now()
+	r.nextFindingID++
+
+	findingCopy := *f
+	findingCopy.Surfaces = append([]string{}, f.Surfaces...)
+	r.findings[f.ID] = &findingCopy
+
+	return nil
+}
+
+// GetFindings retrieves a patient's tooth findings, oldest first
+func (r *MockDentalRepository) GetFindings(hn string, tooth int) ([]ToothFinding, error) {
+	if err := r.fault("Dental.GetFindings"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	findings := []ToothFinding{}
+	for _, f := range r.findings {
+		if f.PatientHN == hn && (tooth == 0 || f.Tooth == tooth) {
+			findings = append(findings, *f)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].ID < findings[j].ID })
+	return findings, nil
+}
+
+// CreateTreatment records a planned or completed treatment
+func (r *MockDentalRepository) CreateTreatment(t *DentalTreatment) error {
+	if err := r.fault("Dental.CreateTreatment"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(t.PatientHN); err != nil {
+		return err
+	}
+	if t.VisitID != nil {
+		if err := r.checkVisit(*t.VisitID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t.ID = r.nextTreatmentID
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	r.nextTreatmentID++
+
+	treatmentCopy := *t
+	treatmentCopy.Surfaces = append([]string{}, t.Surfaces...)
+	r.treatments[t.ID] = &treatmentCopy
+
+	return nil
+}
+
+// GetTreatmentByID retrieves a dental treatment by ID
+func (r *MockDentalRepository) GetTreatmentByID(id int) (*DentalTreatment, error) {
+	if err := r.fault("Dental.GetTreatmentByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	t, exists := r.treatments[id]
+	if !exists {
+		return nil, apperr.NotFound("dental treatment %d not found", id)
+	}
+	treatmentCopy := *t
+	return &treatmentCopy, nil
+}
+
+// GetTreatments retrieves a patient's dental treatments, oldest first
+func (r *MockDentalRepository) GetTreatments(hn string, tooth int) ([]DentalTreatment, error) {
+	if err := r.fault("Dental.GetTreatments"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	treatments := []DentalTreatment{}
+	for _, t := range r.treatments {
+		if t.PatientHN == hn && (tooth == 0 || (t.Tooth != nil && *t.Tooth == tooth)) {
+			treatments = append(treatments, *t)
+		}
+	}
+	sort.Slice(treatments, func(i, j int) bool { return treatments[i].ID < treatments[j].ID })
+	return treatments, nil
+}
+
+// FinishTreatment completes or cancels a planned treatment
+func (r *MockDentalRepository) FinishTreatment(id int, status string, performedBy *string, visitID *int) (*DentalTreatment, error) {
+	if err := r.fault("Dental.FinishTreatment"); err != nil {
+		return nil, err
+	}
+	if visitID != nil {
+		if err := r.checkVisit(*visitID); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t, exists := r.treatments[id]
+	if !exists || t.Status != DentalTreatmentPlanned {
+		return nil, apperr.Conflict("dental treatment %d is not planned", id)
+	}
+
+	now := time.Now()
+	t.Status = status
+	t.PerformedBy = performedBy
+	if visitID != nil {
+		t.VisitID = visitID
+	}
+	if status == DentalTreatmentCompleted {
+		t.CompletedAt = &now
+	}
+	t.UpdatedAt = now
+
+	treatmentCopy := *t
+	return &treatmentCopy, nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockDiagnosisCodeRepository is an in-memory implementation for testing
+type MockDiagnosisCodeRepository struct {
+	mockFidelity
+
+	codes  map[int]*VisitDiagnosis
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockDiagnosisCodeRepository creates a new mock diagnosis code repository
+func NewMockDiagnosisCodeRepository() *MockDiagnosisCodeRepository {
+	return &MockDiagnosisCodeRepository{
+		codes:  make(map[int]*VisitDiagnosis),
+		nextID: 1,
+	}
+}
+
+// Create stores a confirmed code, demoting any earlier primary diagnosis of the visit
+func (r *MockDiagnosisCodeRepository) Create(d *VisitDiagnosis) error {
+	if err := r.fault("DiagnosisCode.Create"); err != nil {
+		return err
+	}
+	if err := r.checkVisit(d.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if d.Primary {
+		for _, existing := range r.codes {
+			if existing.VisitID == d.VisitID {
+				existing.Primary = false
+			}
+		}
+	}
+
+	d.ID = r.nextID
+	d.CreatedAt = time.Now()
+	r.nextID++
+
+	codeCopy := *d
+	r.codes[d.ID] = &codeCopy
+
+	return nil
+}
+
+// GetByVisit retrieves the codes of a visit, primary first
+func (r *MockDiagnosisCodeRepository) GetByVisit(visitID int) ([]VisitDiagnosis, error) {
+	if err := r.fault("DiagnosisCode.GetByVisit"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	codes := []VisitDiagnosis{}
+	for _, d := range r.codes {
+		if d.VisitID == visitID {
+			codes = append(codes, *d)
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if codes[i].Primary != codes[j].Primary {
+			return codes[i].Primary
+		}
+		return codes[i].ID < codes[j].ID
+	})
+
+	return codes, nil
+}
+
+// Delete removes a code from a visit
+func (r *MockDiagnosisCodeRepository) Delete(visitID, id int) error {
+	if err := r.fault("DiagnosisCode.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	d, exists := r.codes[id]
+	if !exists || d.VisitID != visitID {
+		return apperr.NotFound("diagnosis code %d not found on visit %d", id, visitID)
+	}
+	delete(r.codes, id)
+
+	return nil
+}
+
+// CountByCode counts confirmations per code
+func (r *MockDiagnosisCodeRepository) CountByCode() (map[string]int, error) {
+	if err := r.fault("DiagnosisCode.CountByCode"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	counts := make(map[string]int)
+	for _, d := range r.codes {
+		counts[d.Code]++
+	}
+
+	return counts, nil
+}
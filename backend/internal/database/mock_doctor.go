@@ -0,0 +1,214 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockDoctorRepository is an in-memory implementation for testing
+type MockDoctorRepository struct {
+	mockFidelity
+
+	doctors map[int]*Doctor
+	nextID  int
+	mutex   sync.RWMutex
+}
+
+// NewMockDoctorRepository creates a new mock doctor repository
+func NewMockDoctorRepository() *MockDoctorRepository {
+	return &MockDoctorRepository{
+		doctors: make(map[int]*Doctor),
+		nextID:  1,
+	}
+}
+
+func (r *MockDoctorRepository) checkLicense(d *Doctor) error {
+	for _, existing := range r.doctors {
+		if existing.ID != d.ID && strings.EqualFold(existing.LicenseNumber, d.LicenseNumber) {
+			return apperr.Conflict("license number %s already registered", d.LicenseNumber)
+		}
+	}
+	return nil
+}
+
+func copyDoctor(d *Doctor) *Doctor {
+	doctorCopy := *d
+	doctorCopy.WorkingDays = append([]string{}, d.WorkingDays...)
+	if d.LicenseExpiresOn != nil {
+		expires := *d.LicenseExpiresOn
+		doctorCopy.LicenseExpiresOn = &expires
+	}
+	if d.LicenseRemindedFor != nil {
+		reminded := *d.LicenseRemindedFor
+		doctorCopy.LicenseRemindedFor = &reminded
+	}
+	return &doctorCopy
+}
+
+// Create inserts a new doctor; the license number must be unique
+func (r *MockDoctorRepository) Create(d *Doctor) error {
+	if err := r.fault("Doctor.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkLicense(d); err != nil {
+		return err
+	}
+
+	d.ID = r.nextID
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+	r.nextID++
+	r.doctors[d.ID] = copyDoctor(d)
+
+	return nil
+}
+
+// GetByID retrieves a doctor by ID
+func (r *MockDoctorRepository) GetByID(id int) (*Doctor, error) {
+	if err := r.fault("Doctor.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	d, exists := r.doctors[id]
+	if !exists {
+		return nil, apperr.NotFound("doctor %d not found", id)
+	}
+	return copyDoctor(d), nil
+}
+
+// exists reports whether a doctor with the ID is registered, for reference checks
+func (r *MockDoctorRepository) exists(id int) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.doctors[id]
+	return exists
+}
+
+// GetAll retrieves doctors matching the filter, by name
+func (r *MockDoctorRepository) GetAll(f DoctorFilter) ([]Doctor, error) {
+	if err := r.fault("Doctor.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	doctors := []Doctor{}
+	for _, d := range r.doctors {
+		if (f.Specialty != "" && !strings.EqualFold(d.Specialty, f.Specialty)) || (f.ActiveOnly && !d.Active) {
+			continue
+		}
+		if f.LicenseExpiresBy != "" && (d.LicenseExpiresOn == nil || *d.LicenseExpiresOn > f.LicenseExpiresBy) {
+			continue
+		}
+		doctors = append(doctors, *copyDoctor(d))
+	}
+	sort.Slice(doctors, func(i, j int) bool { return doctors[i].FullName < doctors[j].FullName })
+
+	return doctors, nil
+}
+
+// Update saves a doctor's details
+func (r *MockDoctorRepository) Update(d *Doctor) error {
+	if err := r.fault("Doctor.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.doctors[d.ID]
+	if !exists {
+		return apperr.NotFound("doctor %d not found", d.ID)
+	}
+	if err := r.checkLicense(d); err != nil {
+		return err
+	}
+
+	d.CreatedAt = existing.CreatedAt
+	d.UpdatedAt = time.Now()
+	d.LicenseRemindedFor = existing.LicenseRemindedFor
+	r.doctors[d.ID] = copyDoctor(d)
+
+	return nil
+}
+
+// Deactivate marks a doctor inactive
+func (r *MockDoctorRepository) Deactivate(id int) error {
+	if err := r.fault("Doctor.Deactivate"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	d, exists := r.doctors[id]
+	if !exists {
+		return apperr.NotFound("doctor %d not found", id)
+	}
+
+	d.Active = false
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+// LicensesToRemind retrieves the active doctors whose license expires on or
+// before by and who have not been reminded about that expiry date yet
+func (r *MockDoctorRepository) LicensesToRemind(by string) ([]Doctor, error) {
+	if err := r.fault("Doctor.LicensesToRemind"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	doctors := []Doctor{}
+	for _, d := range r.doctors {
+		if !d.Active || d.LicenseExpiresOn == nil || *d.LicenseExpiresOn > by {
+			continue
+		}
+		if d.LicenseRemindedFor != nil && *d.LicenseRemindedFor == *d.LicenseExpiresOn {
+			continue
+		}
+		doctors = append(doctors, *copyDoctor(d))
+	}
+	sort.Slice(doctors, func(i, j int) bool {
+		a, b := doctors[i], doctors[j]
+		if *a.LicenseExpiresOn != *b.LicenseExpiresOn {
+			return *a.LicenseExpiresOn < *b.LicenseExpiresOn
+		}
+		return a.FullName < b.FullName
+	})
+
+	return doctors, nil
+}
+
+// MarkLicenseReminded records that a renewal reminder went out for the doctor's license expiring on expiresOn
+func (r *MockDoctorRepository) MarkLicenseReminded(id int, expiresOn string) error {
+	if err := r.fault("Doctor.MarkLicenseReminded"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	d, exists := r.doctors[id]
+	if !exists {
+		return apperr.NotFound("doctor %d not found", id)
+	}
+
+	d.LicenseRemindedFor = &expiresOn
+	return nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockDocumentRepository is an in-memory implementation for testing
+type MockDocumentRepository struct {
+	mockFidelity
+
+	documents map[int]*Document
+	nextID    int
+	mutex     sync.RWMutex
+}
+
+// NewMockDocumentRepository creates a new mock document repository
+func NewMockDocumentRepository() *MockDocumentRepository {
+	return &MockDocumentRepository{
+		documents: make(map[int]*Document),
+		nextID:    1,
+	}
+}
+
+// Create records an uploaded document
+func (r *MockDocumentRepository) Create(d *Document) error {
+	if err := r.fault("Document.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(d.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	d.ID = r.nextID
+	d.CreatedAt = time.Now()
+	r.nextID++
+
+	documentCopy := *d
+	r.documents[d.ID] = &documentCopy
+
+	return nil
+}
+
+// GetByID retrieves a document by ID
+func (r *MockDocumentRepository) GetByID(id int) (*Document, error) {
+	if err := r.fault("Document.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	d, exists := r.documents[id]
+	if !exists {
+		return nil, apperr.NotFound("document %d not found", id)
+	}
+
+	documentCopy := *d
+	return &documentCopy, nil
+}
+
+// List retrieves documents matching the filter, newest first
+func (r *MockDocumentRepository) List(f DocumentFilter) ([]Document, error) {
+	if err := r.fault("Document.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	documents := []Document{}
+	for _, d := range r.documents {
+		if (f.PatientHN != "" && d.PatientHN != f.PatientHN) || (f.Category != "" && d.Category != f.Category) {
+			continue
+		}
+		documents = append(documents, *d)
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		if !documents[i].CreatedAt.Equal(documents[j].CreatedAt) {
+			return documents[i].CreatedAt.After(documents[j].CreatedAt)
+		}
+		return documents[i].ID > documents[j].ID
+	})
+
+	return documents, nil
+}
+
+// Delete removes a document's record
+func (r *MockDocumentRepository) Delete(id int) error {
+	if err := r.fault("Document.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.documents[id]; !exists {
+		return apperr.NotFound("document %d not found", id)
+	}
+
+	delete(r.documents, id)
+	return nil
+}
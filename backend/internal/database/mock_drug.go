@@ -0,0 +1,153 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockDrugRepository is an in-memory implementation for testing
+type MockDrugRepository struct {
+	mockFidelity
+
+	drugs  map[int]*Drug
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockDrugRepository creates a new mock drug repository
+func NewMockDrugRepository() *MockDrugRepository {
+	return &MockDrugRepository{
+		drugs:  make(map[int]*Drug),
+		nextID: 1,
+	}
+}
+
+func (r *MockDrugRepository) checkUnique(d *Drug) error {
+	for _, existing := range r.drugs {
+		if existing.ID != d.ID && strings.EqualFold(existing.DisplayName(), d.DisplayName()) {
+			return apperr.Conflict("%s is already in the catalog", d.DisplayName())
+		}
+	}
+	return nil
+}
+
+// Create adds a drug to the catalog; generic name, brand and strength must be unique together
+func (r *MockDrugRepository) Create(d *Drug) error {
+	if err := r.fault("Drug.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkUnique(d); err != nil {
+		return err
+	}
+
+	d.ID = r.nextID
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+	r.nextID++
+
+	drugCopy := *d
+	r.drugs[d.ID] = &drugCopy
+
+	return nil
+}
+
+// GetByID retrieves a drug by ID
+func (r *MockDrugRepository) GetByID(id int) (*Drug, error) {
+	if err := r.fault("Drug.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	d, exists := r.drugs[id]
+	if !exists {
+		return nil, apperr.NotFound("drug %d not found", id)
+	}
+
+	drugCopy := *d
+	return &drugCopy, nil
+}
+
+// GetAll retrieves drugs matching the filter, by generic name
+func (r *MockDrugRepository) GetAll(f DrugFilter) ([]Drug, error) {
+	if err := r.fault("Drug.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	search := strings.ToLower(f.Search)
+	drugs := []Drug{}
+	for _, d := range r.drugs {
+		if f.ActiveOnly && !d.Active {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(d.GenericName), search) &&
+			(d.BrandName == nil || !strings.Contains(strings.ToLower(*d.BrandName), search)) {
+			continue
+		}
+		drugs = append(drugs, *d)
+	}
+	sort.Slice(drugs, func(i, j int) bool {
+		if drugs[i].GenericName != drugs[j].GenericName {
+			return drugs[i].GenericName < drugs[j].GenericName
+		}
+		return drugs[i].Strength < drugs[j].Strength
+	})
+
+	return drugs, nil
+}
+
+// Update saves a drug's details
+func (r *MockDrugRepository) Update(d *Drug) error {
+	if err := r.fault("Drug.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.drugs[d.ID]
+	if !exists {
+		return apperr.NotFound("drug %d not found", d.ID)
+	}
+	if err := r.checkUnique(d); err != nil {
+		return err
+	}
+
+	d.CreatedAt = existing.CreatedAt
+	d.UpdatedAt = time.Now()
+	drugCopy := *d
+	r.drugs[d.ID] = &drugCopy
+
+	return nil
+}
+
+// Deactivate withdraws a drug from the catalog
+func (r *MockDrugRepository) Deactivate(id int) error {
+	if err := r.fault("Drug.Deactivate"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	d, exists := r.drugs[id]
+	if !exists {
+		return apperr.NotFound("drug %d not found", id)
+	}
+
+	d.Active = false
+	d.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockEmailRepository is an in-memory implementation for testing
+type MockEmailRepository struct {
+	mockFidelity
+
+	emails map[int]*EmailMessage
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockEmailRepository creates a new mock email repository
+func NewMockEmailRepository() *MockEmailRepository {
+	return &MockEmailRepository{
+		emails: make(map[int]*EmailMessage),
+		nextID: 1,
+	}
+}
+
+// Enqueue adds an email to the outbox
+func (r *MockEmailRepository) Enqueue(m *EmailMessage) error {
+	if err := r.fault("Email.Enqueue"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m.ID = r.nextID
+	m.Status = NotificationPending
+	m.CreatedAt = time.Now()
+	r.nextID++
+
+	emailCopy := *m
+	r.emails[m.ID] = &emailCopy
+
+	return nil
+}
+
+// GetByID retrieves an email from the outbox
+func (r *MockEmailRepository) GetByID(id int) (*EmailMessage, error) {
+	if err := r.fault("Email.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	m, exists := r.emails[id]
+	if !exists {
+		return nil, apperr.NotFound("email %d not found", id)
+	}
+	emailCopy := *m
+	return &emailCopy, nil
+}
+
+// List retrieves the emails matching the filter, oldest first
+func (r *MockEmailRepository) List(f EmailFilter) ([]EmailMessage, error) {
+	if err := r.fault("Email.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	now := time.Now()
+	emails := []EmailMessage{}
+	for _, m := range r.emails {
+		if (f.PatientHN != "" && (m.PatientHN == nil || *m.PatientHN != f.PatientHN)) || (f.Template != "" && m.Template != f.Template) ||
+			(f.Status != "" && m.Status != f.Status) || (f.Due && m.RetryAt != nil && m.RetryAt.After(now)) {
+			continue
+		}
+		emails = append(emails, *m)
+	}
+	sort.Slice(emails, func(i, j int) bool { return emails[i].ID < emails[j].ID })
+
+	return emails, nil
+}
+
+// MarkSent records that a pending email was delivered
+func (r *MockEmailRepository) MarkSent(id int) (*EmailMessage, error) {
+	if err := r.fault("Email.MarkSent"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, err := r.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	m.Status = NotificationSent
+	m.SentAt = &now
+	m.RetryAt = nil
+
+	emailCopy := *m
+	return &emailCopy, nil
+}
+
+// RecordFailure records a failed delivery of a pending email
+func (r *MockEmailRepository) RecordFailure(id int, reason string, retryAt func(attempts int) *time.Time) (*EmailMessage, error) {
+	if err := r.fault("Email.RecordFailure"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, err := r.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	m.Attempts++
+	m.LastError = &reason
+	m.RetryAt = retryAt(m.Attempts)
+	if m.RetryAt == nil {
+		m.Status = NotificationFailed
+	}
+
+	emailCopy := *m
+	return &emailCopy, nil
+}
+
+// Requeue puts a failed email back in the outbox with a fresh set of attempts
+func (r *MockEmailRepository) Requeue(id int) (*EmailMessage, error) {
+	if err := r.fault("Email.Requeue"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, exists := r.emails[id]
+	if !exists {
+		return nil, apperr.NotFound("email %d not found", id)
+	}
+	if m.Status != NotificationFailed {
+		return nil, apperr.Conflict("email %d is not failed", id)
+	}
+	m.Status = NotificationPending
+	m.Attempts = 0
+	m.RetryAt = nil
+
+	emailCopy := *m
+	return &emailCopy, nil
+}
+
+func (r *MockEmailRepository) pending(id int) (*EmailMessage, error) {
+	m, exists := r.emails[id]
+	if !exists {
+		return nil, apperr.NotFound("email %d not found", id)
+	}
+	if m.Status != NotificationPending {
+		return nil, apperr.Conflict("email %d is no longer pending", id)
+	}
+	return m, nil
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockEmergencyContactRepository is an in-memory implementation for testing
+type MockEmergencyContactRepository struct {
+	mockFidelity
+
+	contacts map[int]*EmergencyContact
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockEmergencyContactRepository creates a new mock emergency contact repository
+func NewMockEmergencyContactRepository() *MockEmergencyContactRepository {
+	return &MockEmergencyContactRepository{
+		contacts: make(map[int]*EmergencyContact),
+		nextID:   1,
+	}
+}
+
+// Create adds an emergency contact to a patient
+func (r *MockEmergencyContactRepository) Create(c *EmergencyContact) error {
+	if err := r.fault("EmergencyContact.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(c.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c.ID = r.nextID
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+	r.nextID++
+
+	contactCopy := *c
+	r.contacts[c.ID] = &contactCopy
+
+	return nil
+}
+
+// GetByID retrieves an emergency contact by ID
+func (r *MockEmergencyContactRepository) GetByID(id int) (*EmergencyContact, error) {
+	if err := r.fault("EmergencyContact.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, exists := r.contacts[id]
+	if !exists {
+		return nil, apperr.NotFound("emergency contact %d not found", id)
+	}
+	contactCopy := *c
+	return &contactCopy, nil
+}
+
+// GetByPatient retrieves a patient's emergency contacts in priority order
+func (r *MockEmergencyContactRepository) GetByPatient(hn string) ([]EmergencyContact, error) {
+	if err := r.fault("EmergencyContact.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	contacts := []EmergencyContact{}
+	for _, c := range r.contacts {
+		if c.PatientHN == hn {
+			contacts = append(contacts, *c)
+		}
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		if contacts[i].Priority != contacts[j].Priority {
+			return contacts[i].Priority < contacts[j].Priority
+		}
+		return contacts[i].ID < contacts[j].ID
+	})
+	return contacts, nil
+}
+
+// Update replaces a contact's details
+func (r *MockEmergencyContactRepository) Update(c *EmergencyContact) error {
+	if err := r.fault("EmergencyContact.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.contacts[c.ID]
+	if !exists {
+		return apperr.NotFound("emergency contact %d not found", c.ID)
+	}
+
+	existing.Name = c.Name
+	existing.Relationship = c.Relationship
+	existing.Phone = c.Phone
+	existing.Priority = c.Priority
+	existing.Notes = c.Notes
+	existing.UpdatedAt = time.Now()
+	*c = *existing
+
+	return nil
+}
+
+// Delete removes an emergency contact
+func (r *MockEmergencyContactRepository) Delete(id int) error {
+	if err := r.fault("EmergencyContact.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.contacts[id]; !exists {
+		return apperr.NotFound("emergency contact %d not found", id)
+	}
+	delete(r.contacts, id)
+
+	return nil
+}
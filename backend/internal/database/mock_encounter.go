@@ -0,0 +1,271 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockEncounterRepository is an in-memory implementation for testing
+type MockEncounterRepository struct {
+	mockFidelity
+
+	encounters   map[int]*Encounter
+	soapVersions map[int][]EncounterSOAPVersion // by visit, oldest first
+	nextID       int
+	mutex        sync.RWMutex
+}
+
+// NewMockEncounterRepository creates a new mock encounter repository
+func NewMockEncounterRepository() *MockEncounterRepository {
+	return &MockEncounterRepository{
+		encounters:   make(map[int]*Encounter),
+		soapVersions: make(map[int][]EncounterSOAPVersion),
+		nextID:       1,
+	}
+}
+
+// Create opens a visit
+func (r *MockEncounterRepository) Create(e *Encounter) error {
+	if err := r.fault("Encounter.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(e.PatientHN); err != nil {
+		return err
+	}
+	if e.DoctorID != nil {
+		if err := r.checkDoctor(*e.DoctorID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if e.AppointmentID != nil {
+		for _, existing := range r.encounters {
+			if existing.AppointmentID != nil && *existing.AppointmentID == *e.AppointmentID {
+				return apperr.Conflict("appointment %d already has a visit", *e.AppointmentID)
+			}
+		}
+	}
+
+	e.ID = r.nextID
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	r.nextID++
+
+	encounterCopy := *e
+	r.encounters[e.ID] = &encounterCopy
+
+	return nil
+}
+
+// GetByID retrieves a visit by ID
+func (r *MockEncounterRepository) GetByID(id int) (*Encounter, error) {
+	if err := r.fault("Encounter.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	e, exists := r.encounters[id]
+	if !exists {
+		return nil, apperr.NotFound("visit %d not found", id)
+	}
+
+	encounterCopy := *e
+	return &encounterCopy, nil
+}
+
+// GetByPatient retrieves a patient's visits, most recent first
+func (r *MockEncounterRepository) GetByPatient(hn string) ([]Encounter, error) {
+	if err := r.fault("Encounter.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	encounters := []Encounter{}
+	for _, e := range r.encounters {
+		if e.PatientHN == hn {
+			encounters = append(encounters, *e)
+		}
+	}
+	sort.Slice(encounters, func(i, j int) bool { return encounters[i].StartedAt.After(encounters[j].StartedAt) })
+
+	return encounters, nil
+}
+
+// GetClosedBetween retrieves the visits that ended from from up to to, in the order they ended
+func (r *MockEncounterRepository) GetClosedBetween(from, to time.Time) ([]Encounter, error) {
+	if err := r.fault("Encounter.GetClosedBetween"); err != nil {
+		return nil, err
+	}
+	return r.endedBetween(EncounterClosed, from, to), nil
+}
+
+// GetCancelledBetween retrieves the visits cancelled from from up to to, in the order they were cancelled
+func (r *MockEncounterRepository) GetCancelledBetween(from, to time.Time) ([]Encounter, error) {
+	if err := r.fault("Encounter.GetCancelledBetween"); err != nil {
+		return nil, err
+	}
+	return r.endedBetween(EncounterCancelled, from, to), nil
+}
+
+func (r *MockEncounterRepository) endedBetween(status string, from, to time.Time) []Encounter {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	encounters := []Encounter{}
+	for _, e := range r.encounters {
+		if e.Status == status && e.EndedAt != nil && !e.EndedAt.Before(from) && e.EndedAt.Before(to) {
+			encounters = append(encounters, *e)
+		}
+	}
+	sort.Slice(encounters, func(i, j int) bool {
+		if !encounters[i].EndedAt.Equal(*encounters[j].EndedAt) {
+			return encounters[i].EndedAt.Before(*encounters[j].EndedAt)
+		}
+		return encounters[i].ID < encounters[j].ID
+	})
+
+	return encounters
+}
+
+// Update saves the complaint, findings and attending doctor of an open visit
+func (r *MockEncounterRepository) Update(e *Encounter) error {
+	if err := r.fault("Encounter.Update"); err != nil {
+		return err
+	}
+	if e.DoctorID != nil {
+		if err := r.checkDoctor(*e.DoctorID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.encounters[e.ID]
+	if !exists || existing.Status != EncounterOpen {
+		return apperr.Conflict("visit %d is not open", e.ID)
+	}
+
+	existing.DoctorID = e.DoctorID
+	existing.DoctorName = e.DoctorName
+	existing.ChiefComplaint = e.ChiefComplaint
+	existing.Diagnosis = e.Diagnosis
+	existing.DiagnosisCode = e.DiagnosisCode
+	existing.Treatment = e.Treatment
+	existing.UpdatedAt = time.Now()
+	*e = *existing
+
+	return nil
+}
+
+// Close ends an open visit
+func (r *MockEncounterRepository) Close(id int) (*Encounter, error) {
+	if err := r.fault("Encounter.Close"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	e, exists := r.encounters[id]
+	if !exists || e.Status != EncounterOpen {
+		return nil, apperr.Conflict("visit %d is not open", id)
+	}
+
+	now := time.Now()
+	e.Status = EncounterClosed
+	e.EndedAt = &now
+	e.UpdatedAt = now
+
+	encounterCopy := *e
+	return &encounterCopy, nil
+}
+
+// Cancel ends an open visit that the patient was not seen in
+func (r *MockEncounterRepository) Cancel(id int, c Cancellation) (*Encounter, error) {
+	if err := r.fault("Encounter.Cancel"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	e, exists := r.encounters[id]
+	if !exists || e.Status != EncounterOpen {
+		return nil, apperr.Conflict("visit %d is not open", id)
+	}
+
+	now := time.Now()
+	code := c.ReasonCode
+	e.Status = EncounterCancelled
+	e.CancelReasonCode = &code
+	e.CancelReason = c.Note
+	e.EndedAt = &now
+	e.UpdatedAt = now
+
+	encounterCopy := *e
+	return &encounterCopy, nil
+}
+
+// SaveSOAP stores v as the visit's SOAP fields and adds it to the history;
+// v.Version must be the version after the one stored
+func (r *MockEncounterRepository) SaveSOAP(v *EncounterSOAPVersion) (*Encounter, error) {
+	if err := r.fault("Encounter.SaveSOAP"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	e, exists := r.encounters[v.VisitID]
+	if !exists {
+		return nil, apperr.NotFound("visit %d not found", v.VisitID)
+	}
+	if e.SOAPVersion != v.Version-1 {
+		return nil, apperr.Conflict("visit %d's SOAP note is at version %d", v.VisitID, e.SOAPVersion)
+	}
+
+	now := time.Now()
+	v.CreatedAt = now
+	r.soapVersions[v.VisitID] = append(r.soapVersions[v.VisitID], *v)
+	e.Subjective = v.Subjective
+	e.Objective = v.Objective
+	e.Assessment = v.Assessment
+	e.Plan = v.Plan
+	e.SOAPVersion = v.Version
+	e.UpdatedAt = now
+
+	encounterCopy := *e
+	return &encounterCopy, nil
+}
+
+// GetSOAPVersions retrieves the saved versions of a visit's SOAP fields, oldest first
+func (r *MockEncounterRepository) GetSOAPVersions(visitID int) ([]EncounterSOAPVersion, error) {
+	if err := r.fault("Encounter.GetSOAPVersions"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]EncounterSOAPVersion{}, r.soapVersions[visitID]...), nil
+}
+
+// exists reports whether a visit with the ID is recorded, for reference checks
+func (r *MockEncounterRepository) exists(id int) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	_, exists := r.encounters[id]
+	return exists
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockSignatureRepository is an in-memory implementation for testing
+type MockSignatureRepository struct {
+	mockFidelity
+
+	keys            map[int]*SigningKey
+	signatures      map[string]*PrescriptionSignature
+	nextKeyID       int
+	nextSignatureID int
+	mutex           sync.RWMutex
+}
+
+// NewMockSignatureRepository creates a new mock signature repository
+func NewMockSignatureRepository() *MockSignatureRepository {
+	return &MockSignatureRepository{
+		keys:            make(map[int]*SigningKey),
+		signatures:      make(map[string]*PrescriptionSignature),
+		nextKeyID:       1,
+		nextSignatureID: 1,
+	}
+}
+
+// CreateKey stores a new key and revokes the doctor's previous active key
+func (r *MockSignatureRepository) CreateKey(k *SigningKey) error {
+	if err := r.fault("Signature.CreateKey"); err != nil {
+		return err
+	}
+	if err := r.checkDoctor(k.DoctorID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	for _, existing := range r.keys {
+		if existing.DoctorID == k.DoctorID && existing.Active {
+			existing.Active = false
+			existing.RevokedAt = &now
+		}
+	}
+
+	k.ID = r.nextKeyID
+	k.Active = true
+	k.CreatedAt = now
+	r.nextKeyID++
+
+	keyCopy := *k
+	r.keys[k.ID] = &keyCopy
+
+	return nil
+}
+
+// GetActiveKey retrieves the doctor's current signing key
+func (r *MockSignatureRepository) GetActiveKey(doctorID int) (*SigningKey, error) {
+	if err := r.fault("Signature.GetActiveKey"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, k := range r.keys {
+		if k.DoctorID == doctorID && k.Active {
+			keyCopy := *k
+			return &keyCopy, nil
+		}
+	}
+
+	return nil, apperr.NotFound("active signing key for doctor %d not found", doctorID)
+}
+
+// GetKey retrieves a signing key by ID, including revoked keys
+func (r *MockSignatureRepository) GetKey(id int) (*SigningKey, error) {
+	if err := r.fault("Signature.GetKey"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	k, exists := r.keys[id]
+	if !exists {
+		return nil, apperr.NotFound("signing key %d not found", id)
+	}
+
+	keyCopy := *k
+	return &keyCopy, nil
+}
+
+// CreateSignature stores a prescription signature
+func (r *MockSignatureRepository) CreateSignature(s *PrescriptionSignature) error {
+	if err := r.fault("Signature.CreateSignature"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.signatures[s.VerificationCode]; exists {
+		return apperr.Conflict("verification code %s already exists", s.VerificationCode)
+	}
+
+	s.ID = r.nextSignatureID
+	s.SignedAt = time.Now()
+	r.nextSignatureID++
+
+	signatureCopy := *s
+	r.signatures[s.VerificationCode] = &signatureCopy
+
+	return nil
+}
+
+// GetSignatureByCode retrieves a prescription signature by its public verification code
+func (r *MockSignatureRepository) GetSignatureByCode(code string) (*PrescriptionSignature, error) {
+	if err := r.fault("Signature.GetSignatureByCode"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.signatures[code]
+	if !exists {
+		return nil, apperr.NotFound("signature with code %s not found", code)
+	}
+
+	signatureCopy := *s
+	return &signatureCopy, nil
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockFault makes matching mock repository operations fail or slow down
+type MockFault struct {
+	Operation string  `json:"operation"`           // "Appointment.Create", "Appointment.*" or "*"
+	Rate      float64 `json:"rate"`                // chance of failing, 0 (only delay) to 1 (always)
+	DelayMs   int     `json:"delayMs,omitempty"`   // added latency on every matching call
+	Message   string  `json:"message,omitempty"`   // error text; defaults to a connection failure
+	Remaining int     `json:"remaining,omitempty"` // failures left before the fault clears itself; 0 is unlimited
+}
+
+// MockFaults is the failure injection registry shared by the mock repositories
+type MockFaults struct {
+	faults map[string]*MockFault
+	random *rand.Rand
+	mutex  sync.Mutex
+}
+
+// NewMockFaults creates an empty fault registry
+func NewMockFaults() *MockFaults {
+	return &MockFaults{
+		faults: make(map[string]*MockFault),
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Set adds or replaces the fault for an operation
+func (f *MockFaults) Set(fault MockFault) error {
+	fault.Operation = strings.TrimSpace(fault.Operation)
+	if fault.Operation == "" {
+		return apperr.Validation("operation is required")
+	}
+	if fault.Rate < 0 || fault.Rate > 1 {
+		return apperr.Validation("rate must be between 0 and 1")
+	}
+	if fault.DelayMs < 0 || fault.Remaining < 0 {
+		return apperr.Validation("delayMs and remaining cannot be negative")
+	}
+	if fault.Rate == 0 && fault.DelayMs == 0 {
+		return apperr.Validation("a fault needs a rate or a delay")
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.faults[fault.Operation] = &fault
+	return nil
+}
+
+// Clear removes the fault for an operation, or every fault when operation is empty
+func (f *MockFaults) Clear(operation string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if operation == "" {
+		f.faults = make(map[string]*MockFault)
+		return
+	}
+	delete(f.faults, operation)
+}
+
+// List returns the active faults by operation
+func (f *MockFaults) List() []MockFault {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	faults := []MockFault{}
+	for _, fault := range f.faults {
+		faults = append(faults, *fault)
+	}
+	sort.Slice(faults, func(i, j int) bool { return faults[i].Operation < faults[j].Operation })
+	return faults
+}
+
+// check applies the most specific fault matching op: exact, then "Type.*", then "*"
+func (f *MockFaults) check(op string) error {
+	f.mutex.Lock()
+	fault := f.faults[op]
+	if fault == nil {
+		fault = f.faults[op[:strings.Index(op, ".")+1]+"*"]
+	}
+	if fault == nil {
+		fault = f.faults["*"]
+	}
+	if fault == nil {
+		f.mutex.Unlock()
+		return nil
+	}
+
+	delay := time.Duration(fault.DelayMs) * time.Millisecond
+	fail := fault.Rate > 0 && f.random.Float64() < fault.Rate
+	message := fault.Message
+	if fail && fault.Remaining > 0 {
+		fault.Remaining--
+		if fault.Remaining == 0 {
+			delete(f.faults, fault.Operation)
+		}
+	}
+	f.mutex.Unlock()
+
+	time.Sleep(delay)
+	if !fail {
+		return nil
+	}
+	if message == "" {
+		message = "connection refused"
+	}
+	return fmt.Errorf("injected fault on %s: %s", op, message)
+}
+
+// MockFidelity makes the mock repositories behave like the database: operations
+// pass through the fault registry and references are checked the way foreign keys
+// would check them. Repositories without one behave as plain in-memory maps.
+type MockFidelity struct {
+	Faults   *MockFaults
+	Patients *MockPatientRepository
+	Doctors  *MockDoctorRepository
+	Visits   *MockEncounterRepository
+}
+
+// mockFidelity is embedded by every mock repository
+type mockFidelity struct {
+	fidelity *MockFidelity
+}
+
+// UseFidelity switches the repository to full-fidelity mode
+func (m *mockFidelity) UseFidelity(f *MockFidelity) {
+	m.fidelity = f
+}
+
+func (m *mockFidelity) fault(op string) error {
+	if m.fidelity == nil || m.fidelity.Faults == nil {
+		return nil
+	}
+	return m.fidelity.Faults.check(op)
+}
+
+func (m *mockFidelity) checkPatient(hn string) error {
+	if m.fidelity == nil || m.fidelity.Patients == nil {
+		return nil
+	}
+	if !m.fidelity.Patients.exists(hn) {
+		return apperr.Validation("patient %s does not exist", hn)
+	}
+	return nil
+}
+
+func (m *mockFidelity) checkDoctor(id int) error {
+	if m.fidelity == nil || m.fidelity.Doctors == nil {
+		return nil
+	}
+	if !m.fidelity.Doctors.exists(id) {
+		return apperr.Validation("doctor %d does not exist", id)
+	}
+	return nil
+}
+
+func (m *mockFidelity) checkVisit(id int) error {
+	if m.fidelity == nil || m.fidelity.Visits == nil {
+		return nil
+	}
+	if !m.fidelity.Visits.exists(id) {
+		return apperr.Validation("visit %d does not exist", id)
+	}
+	return nil
+}
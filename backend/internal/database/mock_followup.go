@@ -0,0 +1,123 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockFollowUpRepository is an in-memory implementation for testing
+type MockFollowUpRepository struct {
+	mockFidelity
+
+	followUps map[int]*FollowUp
+	nextID    int
+	mutex     sync.RWMutex
+}
+
+// NewMockFollowUpRepository creates a new mock follow-up repository
+func NewMockFollowUpRepository() *MockFollowUpRepository {
+	return &MockFollowUpRepository{
+		followUps: make(map[int]*FollowUp),
+		nextID:    1,
+	}
+}
+
+// Create schedules a follow-up
+func (r *MockFollowUpRepository) Create(f *FollowUp) error {
+	if err := r.fault("FollowUp.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(f.PatientHN); err != nil {
+		return err
+	}
+	if err := r.checkVisit(f.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f.ID = r.nextID
+	f.CreatedAt = time.Now()
+	r.nextID++
+
+	followUpCopy := *f
+	followUpCopy.PatientName, followUpCopy.Phone = "", nil
+	r.followUps[f.ID] = &followUpCopy
+
+	return nil
+}
+
+// GetByID retrieves a follow-up
+func (r *MockFollowUpRepository) GetByID(id int) (*FollowUp, error) {
+	if err := r.fault("FollowUp.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	f, exists := r.followUps[id]
+	if !exists {
+		return nil, apperr.NotFound("follow-up %d not found", id)
+	}
+
+	followUpCopy := *f
+	return &followUpCopy, nil
+}
+
+// List retrieves follow-ups matching the filter, soonest due first
+func (r *MockFollowUpRepository) List(filter FollowUpFilter) ([]FollowUp, error) {
+	if err := r.fault("FollowUp.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	followUps := []FollowUp{}
+	for _, f := range r.followUps {
+		if (filter.PatientHN != "" && f.PatientHN != filter.PatientHN) || (filter.VisitID != 0 && f.VisitID != filter.VisitID) {
+			continue
+		}
+		if filter.Status != "" && f.Status != filter.Status {
+			continue
+		}
+		if (filter.DueFrom != "" && f.DueDate < filter.DueFrom) || (filter.DueTo != "" && f.DueDate > filter.DueTo) {
+			continue
+		}
+		followUps = append(followUps, *f)
+	}
+	sort.Slice(followUps, func(i, j int) bool {
+		if followUps[i].DueDate != followUps[j].DueDate {
+			return followUps[i].DueDate < followUps[j].DueDate
+		}
+		return followUps[i].ID < followUps[j].ID
+	})
+
+	return followUps, nil
+}
+
+// Close moves a pending follow-up to contacted or cancelled
+func (r *MockFollowUpRepository) Close(id int, status, by string, notes *string) (*FollowUp, error) {
+	if err := r.fault("FollowUp.Close"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f, exists := r.followUps[id]
+	if !exists || f.Status != FollowUpPending {
+		return nil, apperr.Conflict("follow-up %d is no longer pending", id)
+	}
+
+	now := time.Now()
+	f.Status, f.ContactedAt, f.ContactedBy, f.ContactNotes = status, &now, &by, notes
+
+	followUpCopy := *f
+	return &followUpCopy, nil
+}
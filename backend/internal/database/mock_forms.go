@@ -0,0 +1,254 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockFormRepository is an in-memory implementation for testing
+type MockFormRepository struct {
+	mockFidelity
+
+	definitions      map[int]*FormDefinition
+	submissions      map[int]*FormSubmission
+	answers          []FormAnswer
+	nextDefinitionID int
+	nextSubmissionID int
+	mutex            sync.RWMutex
+}
+
+// NewMockFormRepository creates a new mock form repository with a sample dermatology form
+func NewMockFormRepository() *MockFormRepository {
+	repo := &MockFormRepository{
+		definitions:      make(map[int]*FormDefinition),
+		submissions:      make(map[int]*FormSubmission),
+		nextDefinitionID: 1,
+		nextSubmissionID: 1,
+	}
+
+	maxSize := 50.0
+	repo.CreateDefinition(&FormDefinition{
+		ClinicID:  1,
+		Code:      "derm-lesion",
+		Name:      "บันทึกรอยโรคผิวหนัง",
+		Specialty: "dermatology",
+		Version:   1,
+		Active:    true,
+		Fields: []FormField{
+			{Key: "site", Label: "ตำแหน่ง", Type: FieldTypeSelect, Required: true, Options: []string{"face", "trunk", "arm", "leg", "scalp"}},
+			{Key: "morphology", Label: "ลักษณะรอยโรค", Type: FieldTypeMultiSelect, Options: []string{"macule", "papule", "plaque", "vesicle", "pustule", "scale"}},
+			{Key: "size_mm", Label: "ขนาด", Type: FieldTypeNumber, Min: new(float64), Max: &maxSize, Unit: "mm"},
+			{Key: "itching", Label: "คัน", Type: FieldTypeBoolean},
+			{Key: "photos", Label: "รูปถ่าย", Type: FieldTypePhoto, MaxPhotos: 4},
+			{Key: "notes", Label: "หมายเหตุ", Type: FieldTypeTextarea},
+		},
+	})
+
+	return repo
+}
+
+// CreateDefinition stores a new form definition
+func (r *MockFormRepository) CreateDefinition(d *FormDefinition) error {
+	if err := r.fault("Form.CreateDefinition"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.definitions {
+		if existing.ClinicID == d.ClinicID && existing.Code == d.Code {
+			return apperr.Conflict("form %s already exists for clinic %d", d.Code, d.ClinicID)
+		}
+	}
+
+	now := time.Now()
+	d.ID = r.nextDefinitionID
+	d.CreatedAt = now
+	d.UpdatedAt = now
+	r.nextDefinitionID++
+
+	definitionCopy := *d
+	r.definitions[d.ID] = &definitionCopy
+
+	return nil
+}
+
+// GetDefinition retrieves a form definition by ID
+func (r *MockFormRepository) GetDefinition(id int) (*FormDefinition, error) {
+	if err := r.fault("Form.GetDefinition"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	d, exists := r.definitions[id]
+	if !exists {
+		return nil, apperr.NotFound("form definition %d not found", id)
+	}
+
+	definitionCopy := *d
+	return &definitionCopy, nil
+}
+
+// GetDefinitions retrieves the form definitions of a clinic, optionally for one specialty
+func (r *MockFormRepository) GetDefinitions(clinicID int, specialty string) ([]FormDefinition, error) {
+	if err := r.fault("Form.GetDefinitions"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	definitions := []FormDefinition{}
+	for _, d := range r.definitions {
+		if d.ClinicID == clinicID && (specialty == "" || d.Specialty == specialty) {
+			definitions = append(definitions, *d)
+		}
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].Specialty != definitions[j].Specialty {
+			return definitions[i].Specialty < definitions[j].Specialty
+		}
+		return definitions[i].Name < definitions[j].Name
+	})
+
+	return definitions, nil
+}
+
+// UpdateDefinition updates a form definition
+func (r *MockFormRepository) UpdateDefinition(d *FormDefinition) error {
+	if err := r.fault("Form.UpdateDefinition"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.definitions[d.ID]
+	if !exists {
+		return apperr.NotFound("form definition %d not found", d.ID)
+	}
+
+	d.ClinicID = existing.ClinicID
+	d.Code = existing.Code
+	d.CreatedAt = existing.CreatedAt
+	d.UpdatedAt = time.Now()
+
+	definitionCopy := *d
+	r.definitions[d.ID] = &definitionCopy
+
+	return nil
+}
+
+// CreateSubmission stores a submission and its flattened answers
+func (r *MockFormRepository) CreateSubmission(s *FormSubmission, answers []FormAnswer) error {
+	if err := r.fault("Form.CreateSubmission"); err != nil {
+		return err
+	}
+	if err := r.checkVisit(s.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s.ID = r.nextSubmissionID
+	s.CreatedAt = time.Now()
+	r.nextSubmissionID++
+
+	submissionCopy := *s
+	r.submissions[s.ID] = &submissionCopy
+
+	for _, a := range answers {
+		a.SubmissionID = s.ID
+		a.FormID = s.FormID
+		a.VisitID = s.VisitID
+		a.PatientHN = s.PatientHN
+		a.CreatedAt = s.CreatedAt
+		r.answers = append(r.answers, a)
+	}
+
+	return nil
+}
+
+// GetSubmissionsByVisit retrieves all form submissions of a visit, oldest first
+func (r *MockFormRepository) GetSubmissionsByVisit(visitID int) ([]FormSubmission, error) {
+	if err := r.fault("Form.GetSubmissionsByVisit"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	submissions := []FormSubmission{}
+	for _, s := range r.submissions {
+		if s.VisitID == visitID {
+			submissions = append(submissions, *s)
+		}
+	}
+
+	sort.Slice(submissions, func(i, j int) bool {
+		return submissions[i].ID < submissions[j].ID
+	})
+
+	return submissions, nil
+}
+
+// GetLatestSubmission retrieves the most recent submission of a form for a visit
+func (r *MockFormRepository) GetLatestSubmission(visitID, formID int) (*FormSubmission, error) {
+	if err := r.fault("Form.GetLatestSubmission"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var latest *FormSubmission
+	for _, s := range r.submissions {
+		if s.VisitID == visitID && s.FormID == formID && (latest == nil || s.ID > latest.ID) {
+			latest = s
+		}
+	}
+	if latest == nil {
+		return nil, apperr.NotFound("no submission of form %d for visit %d", formID, visitID)
+	}
+
+	submissionCopy := *latest
+	return &submissionCopy, nil
+}
+
+// QueryAnswers retrieves flattened answers of one form field matching the query
+func (r *MockFormRepository) QueryAnswers(q AnswerQuery) ([]FormAnswer, error) {
+	if err := r.fault("Form.QueryAnswers"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	answers := []FormAnswer{}
+	for i := len(r.answers) - 1; i >= 0; i-- {
+		a := r.answers[i]
+		if a.FormID != q.FormID || a.FieldKey != q.FieldKey {
+			continue
+		}
+		if q.Value != nil && (a.ValueText == nil || *a.ValueText != *q.Value) {
+			continue
+		}
+		if q.Min != nil && (a.ValueNumber == nil || *a.ValueNumber < *q.Min) {
+			continue
+		}
+		if q.Max != nil && (a.ValueNumber == nil || *a.ValueNumber > *q.Max) {
+			continue
+		}
+		answers = append(answers, a)
+	}
+
+	return answers, nil
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockGroupSessionRepository is an in-memory implementation for testing
+type MockGroupSessionRepository struct {
+	mockFidelity
+
+	sessions      map[int]*GroupSession
+	bookings      map[int]*GroupBooking
+	nextSessionID int
+	nextBookingID int
+	mutex         sync.RWMutex
+}
+
+// NewMockGroupSessionRepository creates a new mock group session repository
+func NewMockGroupSessionRepository() *MockGroupSessionRepository {
+	return &MockGroupSessionRepository{
+		sessions:      make(map[int]*GroupSession),
+		bookings:      make(map[int]*GroupBooking),
+		nextSessionID: 1,
+		nextBookingID: 1,
+	}
+}
+
+// CreateSession stores a new group session
+func (r *MockGroupSessionRepository) CreateSession(s *GroupSession) error {
+	if err := r.fault("GroupSession.CreateSession"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s.ID = r.nextSessionID
+	s.CreatedAt = time.Now()
+	r.nextSessionID++
+
+	sessionCopy := *s
+	r.sessions[s.ID] = &sessionCopy
+
+	return nil
+}
+
+// GetSession retrieves a group session by ID
+func (r *MockGroupSessionRepository) GetSession(id int) (*GroupSession, error) {
+	if err := r.fault("GroupSession.GetSession"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.sessions[id]
+	if !exists {
+		return nil, apperr.NotFound("group session %d not found", id)
+	}
+
+	sessionCopy := *s
+	sessionCopy.BookedCount = r.bookedCount(id)
+	return &sessionCopy, nil
+}
+
+// GetSessions retrieves sessions starting in [from, to), earliest first
+func (r *MockGroupSessionRepository) GetSessions(from, to time.Time) ([]GroupSession, error) {
+	if err := r.fault("GroupSession.GetSessions"); err != nil {
+		return nil, err
+	}
+
+	return r.filterSessions(func(s *GroupSession) bool {
+		return !s.StartsAt.Before(from) && s.StartsAt.Before(to)
+	}), nil
+}
+
+// GetSessionsByCampaign retrieves the slots generated for a campaign, earliest first
+func (r *MockGroupSessionRepository) GetSessionsByCampaign(campaignID int) ([]GroupSession, error) {
+	if err := r.fault("GroupSession.GetSessionsByCampaign"); err != nil {
+		return nil, err
+	}
+
+	return r.filterSessions(func(s *GroupSession) bool {
+		return s.CampaignID != nil && *s.CampaignID == campaignID
+	}), nil
+}
+
+func (r *MockGroupSessionRepository) filterSessions(keep func(s *GroupSession) bool) []GroupSession {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	sessions := []GroupSession{}
+	for _, s := range r.sessions {
+		if keep(s) {
+			sessionCopy := *s
+			sessionCopy.BookedCount = r.bookedCount(s.ID)
+			sessions = append(sessions, sessionCopy)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartsAt.Before(sessions[j].StartsAt)
+	})
+
+	return sessions
+}
+
+// UpdateSessionStatus changes a session's status
+func (r *MockGroupSessionRepository) UpdateSessionStatus(id int, status string) error {
+	if err := r.fault("GroupSession.UpdateSessionStatus"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.sessions[id]
+	if !exists {
+		return apperr.NotFound("group session %d not found", id)
+	}
+
+	s.Status = status
+	return nil
+}
+
+// Book reserves a seat for a patient if the session has room
+func (r *MockGroupSessionRepository) Book(b *GroupBooking) error {
+	if err := r.fault("GroupSession.Book"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(b.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.sessions[b.SessionID]
+	if !exists || s.Status != SessionStatusScheduled {
+		return apperr.Conflict("group session %d is not open for booking", b.SessionID)
+	}
+	if r.bookedCount(b.SessionID) >= s.Capacity {
+		return apperr.Conflict("group session %d is full", b.SessionID)
+	}
+
+	b.ID = r.nextBookingID
+	b.BookedAt = time.Now()
+	r.nextBookingID++
+
+	bookingCopy := *b
+	r.bookings[b.ID] = &bookingCopy
+
+	return nil
+}
+
+// GetBookings retrieves a session's bookings in booking order
+func (r *MockGroupSessionRepository) GetBookings(sessionID int) ([]GroupBooking, error) {
+	if err := r.fault("GroupSession.GetBookings"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	bookings := []GroupBooking{}
+	for _, b := range r.bookings {
+		if b.SessionID == sessionID {
+			bookings = append(bookings, *b)
+		}
+	}
+
+	sort.Slice(bookings, func(i, j int) bool {
+		return bookings[i].ID < bookings[j].ID
+	})
+
+	return bookings, nil
+}
+
+// UpdateBooking saves a booking's status, visit and check-in time
+func (r *MockGroupSessionRepository) UpdateBooking(b *GroupBooking) error {
+	if err := r.fault("GroupSession.UpdateBooking"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.bookings[b.ID]
+	if !exists || existing.SessionID != b.SessionID {
+		return apperr.NotFound("booking %d not found", b.ID)
+	}
+
+	existing.Status = b.Status
+	existing.VisitID = b.VisitID
+	existing.CheckedInAt = b.CheckedInAt
+
+	return nil
+}
+
+// bookedCount counts active seats; callers must hold the mutex
+func (r *MockGroupSessionRepository) bookedCount(sessionID int) int {
+	count := 0
+	for _, b := range r.bookings {
+		if b.SessionID == sessionID && b.Status != BookingStatusCancelled {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockHandoverRepository is an in-memory implementation for testing
+type MockHandoverRepository struct {
+	mockFidelity
+
+	notes  map[int]*HandoverNote
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockHandoverRepository creates a new mock handover repository
+func NewMockHandoverRepository() *MockHandoverRepository {
+	return &MockHandoverRepository{
+		notes:  make(map[int]*HandoverNote),
+		nextID: 1,
+	}
+}
+
+// Create adds a note to its department's thread
+func (r *MockHandoverRepository) Create(n *HandoverNote) error {
+	if err := r.fault("Handover.Create"); err != nil {
+		return err
+	}
+	if n.PatientHN != nil {
+		if err := r.checkPatient(*n.PatientHN); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	n.ID = r.nextID
+	n.CreatedAt = time.Now()
+	r.nextID++
+
+	noteCopy := *n
+	r.notes[n.ID] = &noteCopy
+
+	return nil
+}
+
+// List retrieves a department's notes, oldest first
+func (r *MockHandoverRepository) List(f HandoverFilter) ([]HandoverNote, error) {
+	if err := r.fault("Handover.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	notes := []HandoverNote{}
+	for _, n := range r.notes {
+		if n.Department != f.Department {
+			continue
+		}
+		if (f.Date == "" && n.ArchivedAt == nil) || n.ShiftDate == f.Date {
+			notes = append(notes, *n)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+	return notes, nil
+}
+
+// Resolve marks a pending issue dealt with
+func (r *MockHandoverRepository) Resolve(id int, resolvedBy string) (*HandoverNote, error) {
+	if err := r.fault("Handover.Resolve"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	n, exists := r.notes[id]
+	if !exists || n.ResolvedAt != nil {
+		return nil, apperr.Conflict("handover note %d does not exist or is already resolved", id)
+	}
+
+	now := time.Now()
+	n.ResolvedBy = &resolvedBy
+	n.ResolvedAt = &now
+
+	noteCopy := *n
+	return &noteCopy, nil
+}
+
+// ArchiveBefore archives the notes written before cutoff, returning how many
+func (r *MockHandoverRepository) ArchiveBefore(cutoff time.Time) (int, error) {
+	if err := r.fault("Handover.ArchiveBefore"); err != nil {
+		return 0, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	archived := 0
+	for _, n := range r.notes {
+		if n.ArchivedAt == nil && n.CreatedAt.Before(cutoff) {
+			n.ArchivedAt = &now
+			archived++
+		}
+	}
+	return archived, nil
+}
@@ -0,0 +1,264 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockInsuranceRepository is an in-memory implementation for testing
+type MockInsuranceRepository struct {
+	mockFidelity
+
+	policies     map[int]*InsurancePolicy
+	claims       map[int]*InsuranceClaim
+	nextPolicyID int
+	nextClaimID  int
+	mutex        sync.RWMutex
+}
+
+// NewMockInsuranceRepository creates a new mock insurance repository
+func NewMockInsuranceRepository() *MockInsuranceRepository {
+	return &MockInsuranceRepository{
+		policies:     make(map[int]*InsurancePolicy),
+		claims:       make(map[int]*InsuranceClaim),
+		nextPolicyID: 1,
+		nextClaimID:  1,
+	}
+}
+
+// policyTaken reports whether another policy already has the insurer and number; callers hold the lock
+func (r *MockInsuranceRepository) policyTaken(p *InsurancePolicy) bool {
+	for _, existing := range r.policies {
+		if existing.ID != p.ID && existing.Insurer == p.Insurer && existing.PolicyNumber == p.PolicyNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatePolicy stores a patient's insurance policy
+func (r *MockInsuranceRepository) CreatePolicy(p *InsurancePolicy) error {
+	if err := r.fault("Insurance.CreatePolicy"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(p.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.policyTaken(p) {
+		return apperr.Conflict("%s policy %s is already on file", p.Insurer, p.PolicyNumber)
+	}
+
+	p.ID = r.nextPolicyID
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	r.nextPolicyID++
+
+	policyCopy := *p
+	r.policies[p.ID] = &policyCopy
+
+	return nil
+}
+
+// GetPolicy retrieves an insurance policy by ID
+func (r *MockInsuranceRepository) GetPolicy(id int) (*InsurancePolicy, error) {
+	if err := r.fault("Insurance.GetPolicy"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, exists := r.policies[id]
+	if !exists {
+		return nil, apperr.NotFound("insurance policy %d not found", id)
+	}
+	policyCopy := *p
+	return &policyCopy, nil
+}
+
+// GetPoliciesByPatient retrieves a patient's policies, most recently started first
+func (r *MockInsuranceRepository) GetPoliciesByPatient(hn string) ([]InsurancePolicy, error) {
+	if err := r.fault("Insurance.GetPoliciesByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	policies := []InsurancePolicy{}
+	for _, p := range r.policies {
+		if p.PatientHN == hn {
+			policies = append(policies, *p)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].ValidFrom != policies[j].ValidFrom {
+			return policies[i].ValidFrom > policies[j].ValidFrom
+		}
+		return policies[i].ID > policies[j].ID
+	})
+	return policies, nil
+}
+
+// UpdatePolicy replaces a policy's details
+func (r *MockInsuranceRepository) UpdatePolicy(p *InsurancePolicy) error {
+	if err := r.fault("Insurance.UpdatePolicy"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.policies[p.ID]
+	if !exists {
+		return apperr.NotFound("insurance policy %d not found", p.ID)
+	}
+	if r.policyTaken(p) {
+		return apperr.Conflict("%s policy %s is already on file", p.Insurer, p.PolicyNumber)
+	}
+
+	existing.Insurer = p.Insurer
+	existing.PolicyNumber = p.PolicyNumber
+	existing.PlanName = p.PlanName
+	existing.CoverageLimit = p.CoverageLimit
+	existing.ValidFrom = p.ValidFrom
+	existing.ValidTo = p.ValidTo
+	existing.Notes = p.Notes
+	existing.UpdatedAt = time.Now()
+	*p = *existing
+
+	return nil
+}
+
+// DeletePolicy removes a policy that has not been claimed against
+func (r *MockInsuranceRepository) DeletePolicy(id int) error {
+	if err := r.fault("Insurance.DeletePolicy"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.policies[id]; !exists {
+		return apperr.NotFound("insurance policy %d not found", id)
+	}
+	for _, c := range r.claims {
+		if c.PolicyID == id {
+			return apperr.Conflict("insurance policy %d has claims; set validTo to end it instead", id)
+		}
+	}
+
+	delete(r.policies, id)
+	return nil
+}
+
+// CreateClaim submits a claim; an invoice can have one claim that has not been rejected
+func (r *MockInsuranceRepository) CreateClaim(c *InsuranceClaim) error {
+	if err := r.fault("Insurance.CreateClaim"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.policies[c.PolicyID]; !exists {
+		return apperr.Validation("invoice %d or insurance policy %d does not exist", c.InvoiceID, c.PolicyID)
+	}
+	for _, existing := range r.claims {
+		if existing.InvoiceID == c.InvoiceID && existing.Status != ClaimRejected {
+			return apperr.Conflict("invoice %s already has a claim in progress", InvoiceNumber(c.InvoiceID))
+		}
+	}
+
+	c.ID = r.nextClaimID
+	c.Number = ClaimNumber(c.ID)
+	c.SubmittedAt = time.Now()
+	c.UpdatedAt = c.SubmittedAt
+	r.nextClaimID++
+
+	claimCopy := *c
+	r.claims[c.ID] = &claimCopy
+
+	return nil
+}
+
+// GetClaim retrieves an insurance claim by ID
+func (r *MockInsuranceRepository) GetClaim(id int) (*InsuranceClaim, error) {
+	if err := r.fault("Insurance.GetClaim"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, exists := r.claims[id]
+	if !exists {
+		return nil, apperr.NotFound("insurance claim %d not found", id)
+	}
+	claimCopy := *c
+	return &claimCopy, nil
+}
+
+// ListClaims retrieves claims matching the filter, oldest submission first
+func (r *MockInsuranceRepository) ListClaims(f ClaimFilter) ([]InsuranceClaim, error) {
+	if err := r.fault("Insurance.ListClaims"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	claims := []InsuranceClaim{}
+	for _, c := range r.claims {
+		if (f.Status != "" && c.Status != f.Status) || (f.Insurer != "" && c.Insurer != f.Insurer) ||
+			(f.PatientHN != "" && c.PatientHN != f.PatientHN) || (f.InvoiceID != 0 && c.InvoiceID != f.InvoiceID) {
+			continue
+		}
+		claims = append(claims, *c)
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].ID < claims[j].ID })
+	return claims, nil
+}
+
+// UpdateClaimStatus records the insurer's decision on a claim that is still in status from
+func (r *MockInsuranceRepository) UpdateClaimStatus(id int, from string, d ClaimDecision) (*InsuranceClaim, error) {
+	if err := r.fault("Insurance.UpdateClaimStatus"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c, exists := r.claims[id]
+	if !exists || c.Status != from {
+		return nil, apperr.Conflict("insurance claim %d is no longer %s", id, from)
+	}
+
+	now := time.Now()
+	c.Status = d.Status
+	c.UpdatedAt = now
+	if d.Reference != nil {
+		c.PayerReference = d.Reference
+	}
+	switch d.Status {
+	case ClaimApproved:
+		c.ApprovedAmount = d.Amount
+		c.DecidedAt = &now
+	case ClaimRejected:
+		c.RejectionReason = d.Reason
+		c.DecidedAt = &now
+	case ClaimPaid:
+		c.PaidAmount = d.Amount
+		c.PaidAt = &now
+	}
+
+	claimCopy := *c
+	return &claimCopy, nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockIntakeRepository is an in-memory implementation for testing
+type MockIntakeRepository struct {
+	mockFidelity
+
+	intakes map[int]*Intake
+	nextID  int
+	mutex   sync.RWMutex
+}
+
+// NewMockIntakeRepository creates a new mock intake repository
+func NewMockIntakeRepository() *MockIntakeRepository {
+	return &MockIntakeRepository{
+		intakes: make(map[int]*Intake),
+		nextID:  1,
+	}
+}
+
+func copyIntake(in *Intake) Intake {
+	intakeCopy := *in
+	if in.Symptoms != nil {
+		intakeCopy.Symptoms = append([]string{}, in.Symptoms...)
+	}
+	return intakeCopy
+}
+
+// Create stores a new intake form; each appointment has at most one
+func (r *MockIntakeRepository) Create(in *Intake) error {
+	if err := r.fault("Intake.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(in.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.intakes {
+		if existing.AppointmentID == in.AppointmentID {
+			return apperr.Conflict("appointment %d already has an intake form", in.AppointmentID)
+		}
+	}
+
+	in.ID = r.nextID
+	in.CreatedAt = time.Now()
+	r.nextID++
+
+	intakeCopy := copyIntake(in)
+	r.intakes[in.ID] = &intakeCopy
+
+	return nil
+}
+
+// GetByAppointment retrieves an appointment's intake form
+func (r *MockIntakeRepository) GetByAppointment(appointmentID int) (*Intake, error) {
+	if err := r.fault("Intake.GetByAppointment"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, in := range r.intakes {
+		if in.AppointmentID == appointmentID {
+			intakeCopy := copyIntake(in)
+			return &intakeCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("appointment %d has no intake form", appointmentID)
+}
+
+// GetByToken retrieves an intake form by its patient link token
+func (r *MockIntakeRepository) GetByToken(token string) (*Intake, error) {
+	if err := r.fault("Intake.GetByToken"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, in := range r.intakes {
+		if in.Token == token {
+			intakeCopy := copyIntake(in)
+			return &intakeCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("intake link not found")
+}
+
+// Complete records the patient's answers. A form can only be completed once.
+func (r *MockIntakeRepository) Complete(in *Intake) error {
+	if err := r.fault("Intake.Complete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.intakes[in.ID]
+	if !exists {
+		return apperr.NotFound("intake %d not found", in.ID)
+	}
+	if existing.Status != IntakePending {
+		return apperr.Conflict("intake %d has already been completed", in.ID)
+	}
+
+	now := time.Now()
+	existing.Status = IntakeCompleted
+	existing.ChiefComplaint = in.ChiefComplaint
+	existing.Symptoms = append([]string{}, in.Symptoms...)
+	existing.Duration = in.Duration
+	existing.Medications = in.Medications
+	existing.Notes = in.Notes
+	existing.CompletedAt = &now
+
+	in.Status = existing.Status
+	in.CompletedAt = existing.CompletedAt
+	return nil
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockInteractionRuleRepository is an in-memory implementation for testing
+type MockInteractionRuleRepository struct {
+	mockFidelity
+
+	rules  map[int]*InteractionRule
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockInteractionRuleRepository creates a new mock interaction rule repository
+func NewMockInteractionRuleRepository() *MockInteractionRuleRepository {
+	return &MockInteractionRuleRepository{
+		rules:  make(map[int]*InteractionRule),
+		nextID: 1,
+	}
+}
+
+func (r *MockInteractionRuleRepository) checkUnique(ir *InteractionRule) error {
+	for _, existing := range r.rules {
+		if existing.ID != ir.ID && existing.Kind == ir.Kind &&
+			strings.EqualFold(existing.Drug, ir.Drug) && strings.EqualFold(existing.With, ir.With) {
+			return apperr.Conflict("there is already a %s rule for %s with %s", ir.Kind, ir.Drug, ir.With)
+		}
+	}
+	return nil
+}
+
+// Create adds an interaction rule; there is one rule per kind and pair of names
+func (r *MockInteractionRuleRepository) Create(ir *InteractionRule) error {
+	if err := r.fault("InteractionRule.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkUnique(ir); err != nil {
+		return err
+	}
+
+	ir.ID = r.nextID
+	ir.CreatedAt = time.Now()
+	ir.UpdatedAt = ir.CreatedAt
+	r.nextID++
+
+	ruleCopy := *ir
+	r.rules[ir.ID] = &ruleCopy
+
+	return nil
+}
+
+// GetByID retrieves an interaction rule by ID
+func (r *MockInteractionRuleRepository) GetByID(id int) (*InteractionRule, error) {
+	if err := r.fault("InteractionRule.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	ir, exists := r.rules[id]
+	if !exists {
+		return nil, apperr.NotFound("interaction rule %d not found", id)
+	}
+
+	ruleCopy := *ir
+	return &ruleCopy, nil
+}
+
+// GetAll retrieves the interaction rules that name drug on either side, or
+// every rule when drug is empty, by drug name
+func (r *MockInteractionRuleRepository) GetAll(drug string) ([]InteractionRule, error) {
+	if err := r.fault("InteractionRule.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	rules := []InteractionRule{}
+	for _, ir := range r.rules {
+		if drug == "" || strings.EqualFold(ir.Drug, drug) || strings.EqualFold(ir.With, drug) {
+			rules = append(rules, *ir)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if !strings.EqualFold(a.Drug, b.Drug) {
+			return strings.ToLower(a.Drug) < strings.ToLower(b.Drug)
+		}
+		if !strings.EqualFold(a.With, b.With) {
+			return strings.ToLower(a.With) < strings.ToLower(b.With)
+		}
+		return a.Kind < b.Kind
+	})
+
+	return rules, nil
+}
+
+// Update saves an interaction rule's details
+func (r *MockInteractionRuleRepository) Update(ir *InteractionRule) error {
+	if err := r.fault("InteractionRule.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.rules[ir.ID]
+	if !exists {
+		return apperr.NotFound("interaction rule %d not found", ir.ID)
+	}
+	if err := r.checkUnique(ir); err != nil {
+		return err
+	}
+
+	ir.CreatedAt = existing.CreatedAt
+	ir.UpdatedAt = time.Now()
+	ruleCopy := *ir
+	r.rules[ir.ID] = &ruleCopy
+
+	return nil
+}
+
+// Delete removes an interaction rule
+func (r *MockInteractionRuleRepository) Delete(id int) error {
+	if err := r.fault("InteractionRule.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.rules[id]; !exists {
+		return apperr.NotFound("interaction rule %d not found", id)
+	}
+	delete(r.rules, id)
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockInterpreterRepository is an in-memory implementation for testing
+type MockInterpreterRepository struct {
+	mockFidelity
+
+	languages         map[string]*PatientLanguage
+	interpreters      map[int]*Interpreter
+	bookings          map[int]*InterpreterBooking
+	nextInterpreterID int
+	nextBookingID     int
+	mutex             sync.RWMutex
+}
+
+// NewMockInterpreterRepository creates a new mock interpreter repository with sample interpreters
+func NewMockInterpreterRepository() *MockInterpreterRepository {
+	repo := &MockInterpreterRepository{
+		languages:         make(map[string]*PatientLanguage),
+		interpreters:      make(map[int]*Interpreter),
+		bookings:          make(map[int]*InterpreterBooking),
+		nextInterpreterID: 1,
+		nextBookingID:     1,
+	}
+
+	repo.CreateInterpreter(&Interpreter{Name: "คุณมิ่ง (ล่ามพม่า)", Languages: []string{"my", "th"}, Active: true})
+	repo.CreateInterpreter(&Interpreter{Name: "บริการล่ามทางไกล", Languages: []string{"en", "zh", "ja", "km", "lo"}, Remote: true, Active: true})
+
+	return repo
+}
+
+// UpsertPatientLanguage saves a patient's language record
+func (r *MockInterpreterRepository) UpsertPatientLanguage(l *PatientLanguage) error {
+	if err := r.fault("Interpreter.UpsertPatientLanguage"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(l.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	l.UpdatedAt = time.Now()
+	languageCopy := *l
+	r.languages[l.PatientHN] = &languageCopy
+
+	return nil
+}
+
+// GetPatientLanguage retrieves a patient's language record
+func (r *MockInterpreterRepository) GetPatientLanguage(hn string) (*PatientLanguage, error) {
+	if err := r.fault("Interpreter.GetPatientLanguage"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	l, exists := r.languages[hn]
+	if !exists {
+		return nil, apperr.NotFound("language record for patient %s not found", hn)
+	}
+
+	languageCopy := *l
+	return &languageCopy, nil
+}
+
+// CreateInterpreter stores a new interpreter
+func (r *MockInterpreterRepository) CreateInterpreter(i *Interpreter) error {
+	if err := r.fault("Interpreter.CreateInterpreter"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i.ID = r.nextInterpreterID
+	i.CreatedAt = time.Now()
+	r.nextInterpreterID++
+
+	interpreterCopy := *i
+	r.interpreters[i.ID] = &interpreterCopy
+
+	return nil
+}
+
+// GetInterpreter retrieves an interpreter by ID
+func (r *MockInterpreterRepository) GetInterpreter(id int) (*Interpreter, error) {
+	if err := r.fault("Interpreter.GetInterpreter"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	i, exists := r.interpreters[id]
+	if !exists {
+		return nil, apperr.NotFound("interpreter %d not found", id)
+	}
+
+	interpreterCopy := *i
+	return &interpreterCopy, nil
+}
+
+// GetInterpreters retrieves active interpreters, optionally only those covering a language
+func (r *MockInterpreterRepository) GetInterpreters(language string) ([]Interpreter, error) {
+	if err := r.fault("Interpreter.GetInterpreters"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	interpreters := []Interpreter{}
+	for _, i := range r.interpreters {
+		if i.Active && (language == "" || i.Speaks(language)) {
+			interpreters = append(interpreters, *i)
+		}
+	}
+
+	sort.Slice(interpreters, func(a, b int) bool {
+		if interpreters[a].Remote != interpreters[b].Remote {
+			return !interpreters[a].Remote
+		}
+		return interpreters[a].Name < interpreters[b].Name
+	})
+
+	return interpreters, nil
+}
+
+// CreateBooking reserves an interpreter if they are free at that time
+func (r *MockInterpreterRepository) CreateBooking(b *InterpreterBooking) error {
+	if err := r.fault("Interpreter.CreateBooking"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(b.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i, exists := r.interpreters[b.InterpreterID]
+	if !exists || !i.Active {
+		return apperr.Conflict("interpreter %d is not active", b.InterpreterID)
+	}
+	for _, existing := range r.bookings {
+		if existing.InterpreterID == b.InterpreterID && existing.Status == InterpreterBookingBooked &&
+			existing.StartsAt.Before(b.EndsAt) && existing.EndsAt.After(b.StartsAt) {
+			return apperr.Conflict("interpreter %d is already booked at that time", b.InterpreterID)
+		}
+	}
+
+	b.ID = r.nextBookingID
+	b.CreatedAt = time.Now()
+	r.nextBookingID++
+
+	bookingCopy := *b
+	r.bookings[b.ID] = &bookingCopy
+
+	return nil
+}
+
+// GetBookings retrieves interpreter bookings starting in [from, to), earliest first
+func (r *MockInterpreterRepository) GetBookings(from, to time.Time) ([]InterpreterBooking, error) {
+	if err := r.fault("Interpreter.GetBookings"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	bookings := []InterpreterBooking{}
+	for _, b := range r.bookings {
+		if !b.StartsAt.Before(from) && b.StartsAt.Before(to) {
+			bookings = append(bookings, *b)
+		}
+	}
+
+	sort.Slice(bookings, func(i, j int) bool {
+		return bookings[i].StartsAt.Before(bookings[j].StartsAt)
+	})
+
+	return bookings, nil
+}
+
+// UpdateBookingStatus changes an interpreter booking's status
+func (r *MockInterpreterRepository) UpdateBookingStatus(id int, status string) error {
+	if err := r.fault("Interpreter.UpdateBookingStatus"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b, exists := r.bookings[id]
+	if !exists {
+		return apperr.NotFound("interpreter booking %d not found", id)
+	}
+
+	b.Status = status
+	return nil
+}
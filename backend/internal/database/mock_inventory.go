@@ -0,0 +1,375 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockInventoryRepository is an in-memory implementation for testing
+type MockInventoryRepository struct {
+	mockFidelity
+
+	items      map[int]*StockItem
+	lots       map[int]map[string]*StockLot
+	movements  []StockMovement
+	nextItemID int
+	nextMoveID int
+	mutex      sync.RWMutex
+}
+
+// NewMockInventoryRepository creates a new mock inventory repository
+func NewMockInventoryRepository() *MockInventoryRepository {
+	return &MockInventoryRepository{
+		items:      make(map[int]*StockItem),
+		lots:       make(map[int]map[string]*StockLot),
+		nextItemID: 1,
+		nextMoveID: 1,
+	}
+}
+
+func (r *MockInventoryRepository) checkUnique(i *StockItem) error {
+	for _, existing := range r.items {
+		if existing.ID == i.ID {
+			continue
+		}
+		if strings.EqualFold(existing.Name, i.Name) {
+			return apperr.Conflict("a stock item named %s already exists", i.Name)
+		}
+		if i.DrugID != nil && existing.DrugID != nil && *existing.DrugID == *i.DrugID {
+			return apperr.Conflict("a stock item named %s or for the same drug already exists", i.Name)
+		}
+	}
+	return nil
+}
+
+// CreateItem adds a stock item; names and linked drugs must be unique
+func (r *MockInventoryRepository) CreateItem(i *StockItem) error {
+	if err := r.fault("Inventory.CreateItem"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkUnique(i); err != nil {
+		return err
+	}
+
+	i.ID = r.nextItemID
+	i.CreatedAt = time.Now()
+	i.UpdatedAt = i.CreatedAt
+	r.nextItemID++
+
+	itemCopy := *i
+	r.items[i.ID] = &itemCopy
+	r.lots[i.ID] = make(map[string]*StockLot)
+
+	return nil
+}
+
+// GetItem retrieves a stock item by ID
+func (r *MockInventoryRepository) GetItem(id int) (*StockItem, error) {
+	if err := r.fault("Inventory.GetItem"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	i, exists := r.items[id]
+	if !exists {
+		return nil, apperr.NotFound("stock item %d not found", id)
+	}
+
+	itemCopy := *i
+	return &itemCopy, nil
+}
+
+// GetItems retrieves stock items matching the filter, by name
+func (r *MockInventoryRepository) GetItems(f StockItemFilter) ([]StockItem, error) {
+	if err := r.fault("Inventory.GetItems"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.filterItems(f), nil
+}
+
+// UpdateItem renames an item or changes its unit; its kind and drug stay fixed
+func (r *MockInventoryRepository) UpdateItem(i *StockItem) error {
+	if err := r.fault("Inventory.UpdateItem"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.items[i.ID]
+	if !exists {
+		return apperr.NotFound("stock item %d not found", i.ID)
+	}
+	if err := r.checkUnique(i); err != nil {
+		return err
+	}
+
+	existing.Name = i.Name
+	existing.Unit = i.Unit
+	existing.UpdatedAt = time.Now()
+	*i = *existing
+
+	return nil
+}
+
+// Record applies one movement to one lot of an item
+func (r *MockInventoryRepository) Record(m *StockMovement) error {
+	if err := r.fault("Inventory.Record"); err != nil {
+		return err
+	}
+	if m.PatientHN != nil {
+		if err := r.checkPatient(*m.PatientHN); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lots, exists := r.lots[m.ItemID]
+	if !exists {
+		return apperr.NotFound("stock item %d not found", m.ItemID)
+	}
+
+	if m.Quantity < 0 {
+		onHand := 0.0
+		lot, exists := lots[m.LotNumber]
+		if exists {
+			onHand = lot.Quantity
+		}
+		if onHand < -m.Quantity {
+			return apperr.Conflict("only %g of %s on hand", onHand, lotLabel(m.LotNumber))
+		}
+		if m.Type == MovementDispense && lotExpired(lot) {
+			return apperr.Conflict("%s has expired", lotLabel(m.LotNumber))
+		}
+	}
+
+	r.apply(m)
+	return nil
+}
+
+// Dispense removes stock from unexpired lots, earliest expiry first, skipping the excluded lots
+func (r *MockInventoryRepository) Dispense(m StockMovement, exclude []string) ([]StockMovement, error) {
+	if err := r.fault("Inventory.Dispense"); err != nil {
+		return nil, err
+	}
+	return r.dispenseItems([]StockMovement{m}, map[int][]string{m.ItemID: exclude})
+}
+
+// DispenseItems removes stock for several dispenses at once; when any item is short, none is removed
+func (r *MockInventoryRepository) DispenseItems(ms []StockMovement, exclude map[int][]string) ([]StockMovement, error) {
+	if err := r.fault("Inventory.DispenseItems"); err != nil {
+		return nil, err
+	}
+	return r.dispenseItems(ms, exclude)
+}
+
+func (r *MockInventoryRepository) dispenseItems(ms []StockMovement, exclude map[int][]string) ([]StockMovement, error) {
+	for _, m := range ms {
+		if m.PatientHN != nil {
+			if err := r.checkPatient(*m.PatientHN); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Allocate everything against what is left after the earlier dispenses
+	// before applying any of it
+	taken := map[int]map[string]float64{}
+	movements := []StockMovement{}
+	for _, m := range ms {
+		if _, exists := r.lots[m.ItemID]; !exists {
+			return nil, apperr.NotFound("stock item %d not found", m.ItemID)
+		}
+		if taken[m.ItemID] == nil {
+			taken[m.ItemID] = map[string]float64{}
+		}
+		usable := []StockLot{}
+		for _, lot := range r.sortedLots(m.ItemID) {
+			lot.Quantity -= taken[m.ItemID][lot.LotNumber]
+			if !lotExpired(&lot) {
+				usable = append(usable, lot)
+			}
+		}
+		allocated, err := allocateLots(m, usable, exclude[m.ItemID])
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range allocated {
+			taken[m.ItemID][a.LotNumber] -= a.Quantity
+		}
+		movements = append(movements, allocated...)
+	}
+	for i := range movements {
+		r.apply(&movements[i])
+	}
+
+	return movements, nil
+}
+
+// GetLevels retrieves the stock on hand of items matching the filter, by name
+func (r *MockInventoryRepository) GetLevels(f StockItemFilter) ([]StockLevel, error) {
+	if err := r.fault("Inventory.GetLevels"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	items := r.filterItems(f)
+	levels := make([]StockLevel, 0, len(items))
+	for i := range items {
+		levels = append(levels, *r.level(&items[i]))
+	}
+	return levels, nil
+}
+
+// GetLevel retrieves one item's stock on hand
+func (r *MockInventoryRepository) GetLevel(itemID int) (*StockLevel, error) {
+	if err := r.fault("Inventory.GetLevel"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	item, exists := r.items[itemID]
+	if !exists {
+		return nil, apperr.NotFound("stock item %d not found", itemID)
+	}
+	return r.level(item), nil
+}
+
+// GetMovements retrieves stock movements matching the filter, newest first
+func (r *MockInventoryRepository) GetMovements(f MovementFilter) ([]StockMovement, error) {
+	if err := r.fault("Inventory.GetMovements"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	movements := []StockMovement{}
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		m := r.movements[i]
+		if (f.ItemID != 0 && m.ItemID != f.ItemID) || (f.Type != "" && m.Type != f.Type) ||
+			(!f.From.IsZero() && m.CreatedAt.Before(f.From)) || (!f.To.IsZero() && !m.CreatedAt.Before(f.To)) {
+			continue
+		}
+		movements = append(movements, m)
+	}
+	return movements, nil
+}
+
+// DispensedFromLot lists dispenses of a lot to patients, for recalls
+func (r *MockInventoryRepository) DispensedFromLot(itemID int, lotNumber string) ([]LotDispense, error) {
+	if err := r.fault("Inventory.DispensedFromLot"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	dispenses := []LotDispense{}
+	for _, m := range r.movements {
+		if m.ItemID == itemID && m.LotNumber == lotNumber && m.Type == MovementDispense && m.PatientHN != nil {
+			dispenses = append(dispenses, LotDispense{PatientHN: *m.PatientHN, Quantity: -m.Quantity, DispensedAt: m.CreatedAt})
+		}
+	}
+	return dispenses, nil
+}
+
+func (r *MockInventoryRepository) apply(m *StockMovement) {
+	lots := r.lots[m.ItemID]
+	lot, exists := lots[m.LotNumber]
+	if !exists {
+		lot = &StockLot{LotNumber: m.LotNumber}
+		lots[m.LotNumber] = lot
+	}
+	lot.Quantity += m.Quantity
+	if lot.ExpiryDate == nil && m.ExpiryDate != nil {
+		expiry := *m.ExpiryDate
+		lot.ExpiryDate = &expiry
+	}
+
+	m.BalanceAfter = 0
+	for _, l := range lots {
+		m.BalanceAfter += l.Quantity
+	}
+	m.ID = r.nextMoveID
+	m.CreatedAt = time.Now()
+	r.nextMoveID++
+	r.movements = append(r.movements, *m)
+}
+
+func (r *MockInventoryRepository) filterItems(f StockItemFilter) []StockItem {
+	search := strings.ToLower(f.Search)
+	items := []StockItem{}
+	for _, i := range r.items {
+		if (f.Kind != "" && i.Kind != f.Kind) || (search != "" && !strings.Contains(strings.ToLower(i.Name), search)) {
+			continue
+		}
+		items = append(items, *i)
+	}
+	sort.Slice(items, func(a, b int) bool { return items[a].Name < items[b].Name })
+	return items
+}
+
+// sortedLots returns the item's lots with stock, earliest expiry first and undated lots last
+func (r *MockInventoryRepository) sortedLots(itemID int) []StockLot {
+	lots := []StockLot{}
+	for _, lot := range r.lots[itemID] {
+		if lot.Quantity > 0 {
+			lots = append(lots, *lot)
+		}
+	}
+	sort.Slice(lots, func(i, j int) bool {
+		a, b := lots[i], lots[j]
+		if (a.ExpiryDate == nil) != (b.ExpiryDate == nil) {
+			return b.ExpiryDate == nil
+		}
+		if a.ExpiryDate != nil && *a.ExpiryDate != *b.ExpiryDate {
+			return *a.ExpiryDate < *b.ExpiryDate
+		}
+		return a.LotNumber < b.LotNumber
+	})
+	return lots
+}
+
+func (r *MockInventoryRepository) level(item *StockItem) *StockLevel {
+	level := newStockLevel(item)
+	level.Lots = r.sortedLots(item.ID)
+	for _, lot := range level.Lots {
+		level.Quantity += lot.Quantity
+	}
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		if r.movements[i].ItemID == item.ID {
+			last := r.movements[i].CreatedAt
+			level.LastMovementAt = &last
+			break
+		}
+	}
+	return level
+}
+
+func lotExpired(lot *StockLot) bool {
+	return lot != nil && lot.ExpiryDate != nil && *lot.ExpiryDate < time.Now().Format("2006-01-02")
+}
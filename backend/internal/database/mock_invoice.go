@@ -0,0 +1,185 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockInvoiceRepository is an in-memory implementation for testing
+type MockInvoiceRepository struct {
+	mockFidelity
+
+	invoices map[int]*Invoice
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockInvoiceRepository creates a new mock invoice repository
+func NewMockInvoiceRepository() *MockInvoiceRepository {
+	return &MockInvoiceRepository{
+		invoices: make(map[int]*Invoice),
+		nextID:   1,
+	}
+}
+
+func copyInvoice(inv *Invoice) Invoice {
+	invoiceCopy := *inv
+	invoiceCopy.Items = append([]InvoiceItem{}, inv.Items...)
+	return invoiceCopy
+}
+
+// Create stores a draft invoice; a visit can have only one invoice that is not void
+func (r *MockInvoiceRepository) Create(inv *Invoice) error {
+	if err := r.fault("Invoice.Create"); err != nil {
+		return err
+	}
+	if err := r.checkVisit(inv.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.invoices {
+		if existing.VisitID == inv.VisitID && existing.Status != InvoiceVoid {
+			return apperr.Conflict("visit %d has already been invoiced", inv.VisitID)
+		}
+	}
+
+	inv.ID = r.nextID
+	inv.Number = InvoiceNumber(inv.ID)
+	inv.CreatedAt = time.Now()
+	inv.UpdatedAt = inv.CreatedAt
+	r.nextID++
+
+	invoiceCopy := copyInvoice(inv)
+	r.invoices[inv.ID] = &invoiceCopy
+
+	return nil
+}
+
+// GetByID retrieves an invoice by ID
+func (r *MockInvoiceRepository) GetByID(id int) (*Invoice, error) {
+	if err := r.fault("Invoice.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	inv, exists := r.invoices[id]
+	if !exists {
+		return nil, apperr.NotFound("invoice %d not found", id)
+	}
+
+	invoiceCopy := copyInvoice(inv)
+	return &invoiceCopy, nil
+}
+
+// List retrieves invoices matching the filter, most recent first
+func (r *MockInvoiceRepository) List(f InvoiceFilter) ([]Invoice, error) {
+	if err := r.fault("Invoice.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	invoices := []Invoice{}
+	for _, inv := range r.invoices {
+		if (f.PatientHN != "" && inv.PatientHN != f.PatientHN) || (f.VisitID != 0 && inv.VisitID != f.VisitID) ||
+			(f.Status != "" && inv.Status != f.Status) || (len(f.VisitIDs) > 0 && !containsID(f.VisitIDs, inv.VisitID)) {
+			continue
+		}
+		invoices = append(invoices, copyInvoice(inv))
+	}
+	sort.Slice(invoices, func(i, j int) bool { return invoices[i].ID > invoices[j].ID })
+	return invoices, nil
+}
+
+// Update replaces a draft invoice's lines, discount and notes
+func (r *MockInvoiceRepository) Update(inv *Invoice) error {
+	if err := r.fault("Invoice.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.invoices[inv.ID]
+	if !exists || existing.Status != InvoiceDraft {
+		return apperr.Conflict("invoice %d is not a draft", inv.ID)
+	}
+
+	existing.Items = append([]InvoiceItem{}, inv.Items...)
+	existing.Subtotal = inv.Subtotal
+	existing.Discount = inv.Discount
+	existing.Total = inv.Total
+	existing.Outstanding = roundBaht(inv.Total - existing.AmountPaid)
+	existing.Notes = inv.Notes
+	existing.UpdatedAt = time.Now()
+	*inv = copyInvoice(existing)
+
+	return nil
+}
+
+// Delete removes a draft invoice
+func (r *MockInvoiceRepository) Delete(id int) error {
+	if err := r.fault("Invoice.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	inv, exists := r.invoices[id]
+	if !exists || inv.Status != InvoiceDraft {
+		return apperr.Conflict("invoice %d is not a draft", id)
+	}
+
+	delete(r.invoices, id)
+	return nil
+}
+
+// UpdateStatus moves an invoice from one status to another
+func (r *MockInvoiceRepository) UpdateStatus(id int, from, to string, voidReason *string) (*Invoice, error) {
+	if err := r.fault("Invoice.UpdateStatus"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	inv, exists := r.invoices[id]
+	if !exists || inv.Status != from {
+		return nil, apperr.Conflict("invoice %d is no longer %s", id, from)
+	}
+
+	now := time.Now()
+	inv.Status = to
+	inv.UpdatedAt = now
+	switch to {
+	case InvoiceIssued:
+		inv.IssuedAt = &now
+	case InvoicePaid:
+		inv.PaidAt = &now
+	case InvoiceVoid:
+		inv.VoidedAt = &now
+		inv.VoidReason = voidReason
+	}
+
+	invoiceCopy := copyInvoice(inv)
+	return &invoiceCopy, nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
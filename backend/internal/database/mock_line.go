@@ -0,0 +1,208 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockLINERepository is an in-memory implementation for testing
+type MockLINERepository struct {
+	mockFidelity
+
+	accounts map[string]*PatientLINE
+	messages map[int]*LINEMessage
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockLINERepository creates a new mock LINE repository
+func NewMockLINERepository() *MockLINERepository {
+	return &MockLINERepository{
+		accounts: make(map[string]*PatientLINE),
+		messages: make(map[int]*LINEMessage),
+		nextID:   1,
+	}
+}
+
+// Link links a patient to a LINE account, replacing any account they had
+func (r *MockLINERepository) Link(a *PatientLINE) error {
+	if err := r.fault("LINE.Link"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.accounts {
+		if existing.UserID == a.UserID && existing.PatientHN != a.PatientHN {
+			return apperr.Conflict("LINE account %s is already linked to another patient", a.UserID)
+		}
+	}
+	a.LinkedAt = time.Now()
+
+	accountCopy := *a
+	r.accounts[a.PatientHN] = &accountCopy
+
+	return nil
+}
+
+// GetAccount retrieves the LINE account a patient has linked
+func (r *MockLINERepository) GetAccount(hn string) (*PatientLINE, error) {
+	if err := r.fault("LINE.GetAccount"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, exists := r.accounts[hn]
+	if !exists {
+		return nil, apperr.NotFound("%s has not linked a LINE account", hn)
+	}
+	accountCopy := *a
+	return &accountCopy, nil
+}
+
+// Unlink removes a patient's LINE account; messages already queued still go to it
+func (r *MockLINERepository) Unlink(hn string) error {
+	if err := r.fault("LINE.Unlink"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.accounts[hn]; !exists {
+		return apperr.NotFound("%s has not linked a LINE account", hn)
+	}
+	delete(r.accounts, hn)
+
+	return nil
+}
+
+// Enqueue queues a message to be pushed
+func (r *MockLINERepository) Enqueue(m *LINEMessage) error {
+	if err := r.fault("LINE.Enqueue"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m.ID = r.nextID
+	m.Status = NotificationPending
+	m.CreatedAt = time.Now()
+	r.nextID++
+
+	messageCopy := *m
+	r.messages[m.ID] = &messageCopy
+
+	return nil
+}
+
+// ListMessages retrieves the messages matching the filter, oldest first
+func (r *MockLINERepository) ListMessages(f LINEMessageFilter) ([]LINEMessage, error) {
+	if err := r.fault("LINE.ListMessages"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	now := time.Now()
+	messages := []LINEMessage{}
+	for _, m := range r.messages {
+		if (f.PatientHN != "" && m.PatientHN != f.PatientHN) || (f.Status != "" && m.Status != f.Status) ||
+			(f.Due && m.RetryAt != nil && m.RetryAt.After(now)) {
+			continue
+		}
+		messages = append(messages, *m)
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+
+	return messages, nil
+}
+
+// MarkSent records that a pending message was pushed
+func (r *MockLINERepository) MarkSent(id int) (*LINEMessage, error) {
+	if err := r.fault("LINE.MarkSent"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, err := r.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	m.Status = NotificationSent
+	m.SentAt = &now
+	m.RetryAt = nil
+
+	messageCopy := *m
+	return &messageCopy, nil
+}
+
+// RecordFailure records a failed push of a pending message
+func (r *MockLINERepository) RecordFailure(id int, reason string, retryAt func(attempts int) *time.Time) (*LINEMessage, error) {
+	if err := r.fault("LINE.RecordFailure"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, err := r.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	m.Attempts++
+	m.LastError = &reason
+	m.RetryAt = retryAt(m.Attempts)
+	if m.RetryAt == nil {
+		m.Status = NotificationFailed
+	}
+
+	messageCopy := *m
+	return &messageCopy, nil
+}
+
+// Requeue puts a failed message back in the queue with a fresh set of attempts
+func (r *MockLINERepository) Requeue(id int) (*LINEMessage, error) {
+	if err := r.fault("LINE.Requeue"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, exists := r.messages[id]
+	if !exists {
+		return nil, apperr.NotFound("LINE message %d not found", id)
+	}
+	if m.Status != NotificationFailed {
+		return nil, apperr.Conflict("LINE message %d is not failed", id)
+	}
+	m.Status = NotificationPending
+	m.Attempts = 0
+	m.RetryAt = nil
+
+	messageCopy := *m
+	return &messageCopy, nil
+}
+
+func (r *MockLINERepository) pending(id int) (*LINEMessage, error) {
+	m, exists := r.messages[id]
+	if !exists {
+		return nil, apperr.NotFound("LINE message %d not found", id)
+	}
+	if m.Status != NotificationPending {
+		return nil, apperr.Conflict("LINE message %d is no longer pending", id)
+	}
+	return m, nil
+}
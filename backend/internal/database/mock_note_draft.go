@@ -0,0 +1,149 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockNoteDraftRepository is an in-memory implementation for testing
+type MockNoteDraftRepository struct {
+	mockFidelity
+
+	drafts map[int]*NoteDraft
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockNoteDraftRepository creates a new mock note draft repository
+func NewMockNoteDraftRepository() *MockNoteDraftRepository {
+	return &MockNoteDraftRepository{
+		drafts: make(map[int]*NoteDraft),
+		nextID: 1,
+	}
+}
+
+// SaveDraft creates or overwrites an author's draft for a visit if d.Revision is still current
+func (r *MockNoteDraftRepository) SaveDraft(d *NoteDraft) error {
+	if err := r.fault("NoteDraft.SaveDraft"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(d.PatientHN); err != nil {
+		return err
+	}
+	if err := r.checkVisit(d.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var existing *NoteDraft
+	for _, draft := range r.drafts {
+		if draft.VisitID == d.VisitID && draft.AuthorName == d.AuthorName {
+			existing = draft
+			break
+		}
+	}
+
+	current := 0
+	if existing != nil {
+		current = existing.Revision
+	}
+	if d.Revision != current {
+		return apperr.Conflict("draft for visit %d by %s was saved elsewhere since revision %d", d.VisitID, d.AuthorName, d.Revision)
+	}
+
+	now := time.Now()
+	if existing == nil {
+		d.ID = r.nextID
+		d.CreatedAt = now
+		r.nextID++
+	} else {
+		d.ID = existing.ID
+		d.CreatedAt = existing.CreatedAt
+	}
+	d.Revision++
+	d.UpdatedAt = now
+
+	draftCopy := *d
+	r.drafts[d.ID] = &draftCopy
+
+	return nil
+}
+
+// GetDraft retrieves a note draft by ID
+func (r *MockNoteDraftRepository) GetDraft(id int) (*NoteDraft, error) {
+	if err := r.fault("NoteDraft.GetDraft"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	d, exists := r.drafts[id]
+	if !exists {
+		return nil, apperr.NotFound("note draft %d not found", id)
+	}
+
+	draftCopy := *d
+	return &draftCopy, nil
+}
+
+// GetVisitDraft retrieves an author's draft for a visit
+func (r *MockNoteDraftRepository) GetVisitDraft(visitID int, author string) (*NoteDraft, error) {
+	if err := r.fault("NoteDraft.GetVisitDraft"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, d := range r.drafts {
+		if d.VisitID == visitID && d.AuthorName == author {
+			draftCopy := *d
+			return &draftCopy, nil
+		}
+	}
+
+	return nil, apperr.NotFound("no draft for visit %d by %s", visitID, author)
+}
+
+// GetDraftsByAuthor retrieves an author's unfinished drafts, most recently saved first
+func (r *MockNoteDraftRepository) GetDraftsByAuthor(author string) ([]NoteDraft, error) {
+	if err := r.fault("NoteDraft.GetDraftsByAuthor"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	drafts := []NoteDraft{}
+	for _, d := range r.drafts {
+		if d.AuthorName == author {
+			drafts = append(drafts, *d)
+		}
+	}
+	sort.Slice(drafts, func(i, j int) bool { return drafts[i].UpdatedAt.After(drafts[j].UpdatedAt) })
+
+	return drafts, nil
+}
+
+// DeleteDraft removes a note draft
+func (r *MockNoteDraftRepository) DeleteDraft(id int) error {
+	if err := r.fault("NoteDraft.DeleteDraft"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.drafts[id]; !exists {
+		return apperr.NotFound("note draft %d not found", id)
+	}
+	delete(r.drafts, id)
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockNursingNoteRepository is an in-memory implementation for testing
+type MockNursingNoteRepository struct {
+	mockFidelity
+
+	notes  map[int]*NursingNote
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockNursingNoteRepository creates a new mock nursing note repository
+func NewMockNursingNoteRepository() *MockNursingNoteRepository {
+	return &MockNursingNoteRepository{
+		notes:  make(map[int]*NursingNote),
+		nextID: 1,
+	}
+}
+
+// withCorrection copies a note, filling in the note correcting it; hold the mutex
+func (r *MockNursingNoteRepository) withCorrection(n *NursingNote) *NursingNote {
+	noteCopy := *n
+	if n.CorrectsID != nil {
+		correctsID := *n.CorrectsID
+		noteCopy.CorrectsID = &correctsID
+	}
+	noteCopy.CorrectedBy = nil
+	for _, other := range r.notes {
+		if other.CorrectsID != nil && *other.CorrectsID == n.ID {
+			correctedBy := other.ID
+			noteCopy.CorrectedBy = &correctedBy
+		}
+	}
+	return &noteCopy
+}
+
+// Create appends a note; a correction must name an uncorrected note of the same visit
+func (r *MockNursingNoteRepository) Create(n *NursingNote) error {
+	if err := r.fault("NursingNote.Create"); err != nil {
+		return err
+	}
+	if err := r.checkVisit(n.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if n.CorrectsID != nil {
+		corrected, exists := r.notes[*n.CorrectsID]
+		if !exists || corrected.VisitID != n.VisitID {
+			return apperr.Validation("nursing note %d is not a note of visit %d", *n.CorrectsID, n.VisitID)
+		}
+		if r.withCorrection(corrected).CorrectedBy != nil {
+			return apperr.Conflict("nursing note %d has already been corrected", *n.CorrectsID)
+		}
+	}
+
+	n.ID = r.nextID
+	n.CorrectedBy = nil
+	n.CreatedAt = time.Now()
+	r.nextID++
+	r.notes[n.ID] = r.withCorrection(n)
+
+	return nil
+}
+
+// GetByID retrieves a nursing note
+func (r *MockNursingNoteRepository) GetByID(id int) (*NursingNote, error) {
+	if err := r.fault("NursingNote.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	n, exists := r.notes[id]
+	if !exists {
+		return nil, apperr.NotFound("nursing note %d not found", id)
+	}
+	return r.withCorrection(n), nil
+}
+
+// GetByVisit retrieves a visit's nursing notes in the order the care happened
+func (r *MockNursingNoteRepository) GetByVisit(visitID int) ([]NursingNote, error) {
+	if err := r.fault("NursingNote.GetByVisit"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	notes := []NursingNote{}
+	for _, n := range r.notes {
+		if n.VisitID == visitID {
+			notes = append(notes, *r.withCorrection(n))
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		if !notes[i].ObservedAt.Equal(notes[j].ObservedAt) {
+			return notes[i].ObservedAt.Before(notes[j].ObservedAt)
+		}
+		return notes[i].ID < notes[j].ID
+	})
+
+	return notes, nil
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockOnlineSlotRepository is an in-memory implementation for testing
+type MockOnlineSlotRepository struct {
+	mockFidelity
+
+	slots  map[int]*OnlineSlot
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockOnlineSlotRepository creates a new mock online slot repository
+func NewMockOnlineSlotRepository() *MockOnlineSlotRepository {
+	return &MockOnlineSlotRepository{
+		slots:  make(map[int]*OnlineSlot),
+		nextID: 1,
+	}
+}
+
+// Create releases a slot
+func (r *MockOnlineSlotRepository) Create(s *OnlineSlot) error {
+	if err := r.fault("OnlineSlot.Create"); err != nil {
+		return err
+	}
+	if err := r.checkDoctor(s.DoctorID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s.ID = r.nextID
+	s.CreatedAt = time.Now()
+	r.nextID++
+
+	slotCopy := *s
+	r.slots[s.ID] = &slotCopy
+
+	return nil
+}
+
+// GetByID retrieves a slot
+func (r *MockOnlineSlotRepository) GetByID(id int) (*OnlineSlot, error) {
+	if err := r.fault("OnlineSlot.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.slots[id]
+	if !exists {
+		return nil, apperr.NotFound("online slot %d not found", id)
+	}
+	slotCopy := *s
+	return &slotCopy, nil
+}
+
+// List retrieves slots matching the filter, earliest first
+func (r *MockOnlineSlotRepository) List(f OnlineSlotFilter) ([]OnlineSlot, error) {
+	if err := r.fault("OnlineSlot.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	slots := []OnlineSlot{}
+	for _, s := range r.slots {
+		if (f.DoctorID != 0 && s.DoctorID != f.DoctorID) ||
+			(!f.From.IsZero() && s.StartsAt.Before(f.From)) || (!f.To.IsZero() && !s.StartsAt.Before(f.To)) ||
+			(f.OpenOnly && s.BookedBy != nil) {
+			continue
+		}
+		slots = append(slots, *s)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		if !slots[i].StartsAt.Equal(slots[j].StartsAt) {
+			return slots[i].StartsAt.Before(slots[j].StartsAt)
+		}
+		return slots[i].DoctorName < slots[j].DoctorName
+	})
+
+	return slots, nil
+}
+
+// Claim takes an open slot that has not started for a patient, before their
+// appointment is booked into it; a slot someone else has claimed is a conflict
+func (r *MockOnlineSlotRepository) Claim(id int, patientHN string) (*OnlineSlot, error) {
+	if err := r.fault("OnlineSlot.Claim"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.slots[id]
+	if !exists {
+		return nil, apperr.NotFound("online slot %d not found", id)
+	}
+	now := time.Now()
+	if s.BookedBy != nil || !s.StartsAt.After(now) {
+		return nil, apperr.Conflict("online slot %d is no longer available", id)
+	}
+	hn := patientHN
+	s.BookedBy = &hn
+	s.BookedAt = &now
+
+	slotCopy := *s
+	return &slotCopy, nil
+}
+
+// SetAppointment records the appointment booked into a claimed slot
+func (r *MockOnlineSlotRepository) SetAppointment(id, appointmentID int) error {
+	if err := r.fault("OnlineSlot.SetAppointment"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.slots[id]
+	if !exists || s.BookedBy == nil {
+		return apperr.NotFound("claimed online slot %d not found", id)
+	}
+	s.AppointmentID = &appointmentID
+
+	return nil
+}
+
+// Unclaim opens a claimed slot again, when its booking could not be made
+func (r *MockOnlineSlotRepository) Unclaim(id int) error {
+	if err := r.fault("OnlineSlot.Unclaim"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if s, exists := r.slots[id]; exists {
+		s.BookedBy, s.BookedAt, s.AppointmentID = nil, nil, nil
+	}
+	return nil
+}
+
+// Delete withdraws an open slot; a claimed one is a conflict
+func (r *MockOnlineSlotRepository) Delete(id int) error {
+	if err := r.fault("OnlineSlot.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.slots[id]
+	if !exists {
+		return apperr.NotFound("online slot %d not found", id)
+	}
+	if s.BookedBy != nil {
+		return apperr.Conflict("online slot %d has been booked; cancel the appointment instead", id)
+	}
+	delete(r.slots, id)
+
+	return nil
+}
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockTreatmentPackageRepository is an in-memory implementation for testing
+type MockTreatmentPackageRepository struct {
+	mockFidelity
+
+	packages map[int]*TreatmentPackage
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockTreatmentPackageRepository creates a new mock treatment package repository
+func NewMockTreatmentPackageRepository() *MockTreatmentPackageRepository {
+	return &MockTreatmentPackageRepository{
+		packages: make(map[int]*TreatmentPackage),
+		nextID:   1,
+	}
+}
+
+// Create adds a package to the catalog
+func (r *MockTreatmentPackageRepository) Create(p *TreatmentPackage) error {
+	if err := r.fault("TreatmentPackage.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p.ID = r.nextID
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	r.nextID++
+
+	packageCopy := *p
+	r.packages[p.ID] = &packageCopy
+
+	return nil
+}
+
+// GetByID retrieves a catalog package
+func (r *MockTreatmentPackageRepository) GetByID(id int) (*TreatmentPackage, error) {
+	if err := r.fault("TreatmentPackage.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, exists := r.packages[id]
+	if !exists {
+		return nil, apperr.NotFound("treatment package %d not found", id)
+	}
+
+	packageCopy := *p
+	return &packageCopy, nil
+}
+
+// GetAll retrieves catalog packages by name, only those on sale when activeOnly
+func (r *MockTreatmentPackageRepository) GetAll(activeOnly bool) ([]TreatmentPackage, error) {
+	if err := r.fault("TreatmentPackage.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	packages := []TreatmentPackage{}
+	for _, p := range r.packages {
+		if activeOnly && !p.Active {
+			continue
+		}
+		packages = append(packages, *p)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+		return packages[i].ID < packages[j].ID
+	})
+
+	return packages, nil
+}
+
+// Update saves a catalog package's details
+func (r *MockTreatmentPackageRepository) Update(p *TreatmentPackage) error {
+	if err := r.fault("TreatmentPackage.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.packages[p.ID]
+	if !exists {
+		return apperr.NotFound("treatment package %d not found", p.ID)
+	}
+
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = time.Now()
+	packageCopy := *p
+	r.packages[p.ID] = &packageCopy
+
+	return nil
+}
+
+// Deactivate takes a package off sale
+func (r *MockTreatmentPackageRepository) Deactivate(id int) error {
+	if err := r.fault("TreatmentPackage.Deactivate"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, exists := r.packages[id]
+	if !exists {
+		return apperr.NotFound("treatment package %d not found", id)
+	}
+
+	p.Active = false
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// MockPatientPackageRepository is an in-memory implementation for testing
+type MockPatientPackageRepository struct {
+	mockFidelity
+
+	packages      map[int]*PatientPackage
+	sessions      map[int]*PackageSession
+	nextID        int
+	nextSessionID int
+	mutex         sync.RWMutex
+}
+
+// NewMockPatientPackageRepository creates a new mock patient package repository
+func NewMockPatientPackageRepository() *MockPatientPackageRepository {
+	return &MockPatientPackageRepository{
+		packages:      make(map[int]*PatientPackage),
+		sessions:      make(map[int]*PackageSession),
+		nextID:        1,
+		nextSessionID: 1,
+	}
+}
+
+// patientPackageCopy returns a copy of p with its remaining sessions worked out
+func patientPackageCopy(p *PatientPackage) *PatientPackage {
+	packageCopy := *p
+	packageCopy.RemainingSessions = p.TotalSessions - p.UsedSessions
+	packageCopy.Sessions = nil
+	return &packageCopy
+}
+
+// Create records a package a patient bought
+func (r *MockPatientPackageRepository) Create(p *PatientPackage) error {
+	if err := r.fault("PatientPackage.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(p.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p.ID = r.nextID
+	p.PurchasedAt = time.Now()
+	p.RemainingSessions = p.TotalSessions - p.UsedSessions
+	r.nextID++
+
+	r.packages[p.ID] = patientPackageCopy(p)
+
+	return nil
+}
+
+// GetByID retrieves a patient package
+func (r *MockPatientPackageRepository) GetByID(id int) (*PatientPackage, error) {
+	if err := r.fault("PatientPackage.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, exists := r.packages[id]
+	if !exists {
+		return nil, apperr.NotFound("patient package %d not found", id)
+	}
+
+	return patientPackageCopy(p), nil
+}
+
+// GetByPatient retrieves a patient's packages, newest first, with the given
+// status or all of them
+func (r *MockPatientPackageRepository) GetByPatient(hn, status string) ([]PatientPackage, error) {
+	if err := r.fault("PatientPackage.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	packages := []PatientPackage{}
+	for _, p := range r.packages {
+		if p.PatientHN != hn || (status != "" && p.Status != status) {
+			continue
+		}
+		packages = append(packages, *patientPackageCopy(p))
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].ID > packages[j].ID })
+
+	return packages, nil
+}
+
+// Cancel stops an active package
+func (r *MockPatientPackageRepository) Cancel(id int) (*PatientPackage, error) {
+	if err := r.fault("PatientPackage.Cancel"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, exists := r.packages[id]
+	if !exists || p.Status != PatientPackageActive {
+		return nil, apperr.Conflict("patient package %d is not active", id)
+	}
+
+	p.Status = PatientPackageCancelled
+	return patientPackageCopy(p), nil
+}
+
+// UseSession deducts one session from an active package for a visit, at most once per visit
+func (r *MockPatientPackageRepository) UseSession(s *PackageSession) (*PatientPackage, error) {
+	if err := r.fault("PatientPackage.UseSession"); err != nil {
+		return nil, err
+	}
+	if err := r.checkVisit(s.VisitID); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, exists := r.packages[s.PatientPackageID]
+	if !exists {
+		return nil, apperr.NotFound("patient package %d not found", s.PatientPackageID)
+	}
+	if p.Status != PatientPackageActive || p.UsedSessions >= p.TotalSessions {
+		return nil, apperr.Conflict("patient package %d has no sessions left", s.PatientPackageID)
+	}
+	for _, existing := range r.sessions {
+		if existing.PatientPackageID == s.PatientPackageID && existing.VisitID == s.VisitID {
+			return nil, apperr.Conflict("visit %d already used a session of patient package %d", s.VisitID, s.PatientPackageID)
+		}
+	}
+
+	s.ID = r.nextSessionID
+	s.CreatedAt = time.Now()
+	r.nextSessionID++
+	sessionCopy := *s
+	r.sessions[s.ID] = &sessionCopy
+
+	p.UsedSessions++
+	if p.UsedSessions >= p.TotalSessions {
+		p.Status = PatientPackageCompleted
+	}
+	return patientPackageCopy(p), nil
+}
+
+// UndoSession gives back a session recorded on a visit in error
+func (r *MockPatientPackageRepository) UndoSession(visitID, id int) (*PatientPackage, error) {
+	if err := r.fault("PatientPackage.UndoSession"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.sessions[id]
+	if !exists || s.VisitID != visitID {
+		return nil, apperr.NotFound("package session %d not recorded on visit %d", id, visitID)
+	}
+	delete(r.sessions, id)
+
+	p := r.packages[s.PatientPackageID]
+	p.UsedSessions--
+	if p.Status == PatientPackageCompleted {
+		p.Status = PatientPackageActive
+	}
+	return patientPackageCopy(p), nil
+}
+
+// GetSessions retrieves the sessions used from a package, oldest first
+func (r *MockPatientPackageRepository) GetSessions(patientPackageID int) ([]PackageSession, error) {
+	if err := r.fault("PatientPackage.GetSessions"); err != nil {
+		return nil, err
+	}
+	return r.filterSessions(func(s *PackageSession) bool { return s.PatientPackageID == patientPackageID }), nil
+}
+
+// GetVisitSessions retrieves the package sessions used during a visit
+func (r *MockPatientPackageRepository) GetVisitSessions(visitID int) ([]PackageSession, error) {
+	if err := r.fault("PatientPackage.GetVisitSessions"); err != nil {
+		return nil, err
+	}
+	return r.filterSessions(func(s *PackageSession) bool { return s.VisitID == visitID }), nil
+}
+
+func (r *MockPatientPackageRepository) filterSessions(match func(s *PackageSession) bool) []PackageSession {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	sessions := []PackageSession{}
+	for _, s := range r.sessions {
+		if match(s) {
+			sessions = append(sessions, *s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockPatientMergeRepository is an in-memory implementation for testing. It
+// merges the records in the mock patient repository; the other mock
+// repositories keep their rows under the source HN, so nothing is re-linked.
+type MockPatientMergeRepository struct {
+	mockFidelity
+
+	patients *MockPatientRepository
+	merges   map[int]*PatientMerge
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockPatientMergeRepository creates a new mock patient merge repository over patients
+func NewMockPatientMergeRepository(patients *MockPatientRepository) *MockPatientMergeRepository {
+	return &MockPatientMergeRepository{
+		patients: patients,
+		merges:   make(map[int]*PatientMerge),
+		nextID:   1,
+	}
+}
+
+func copyPatientMerge(m *PatientMerge) PatientMerge {
+	mergeCopy := *m
+	mergeCopy.Relinked = append([]RelinkedRows{}, m.Relinked...)
+	return mergeCopy
+}
+
+// Merge removes the source patient, keeping both records so the merge can be undone
+func (r *MockPatientMergeRepository) Merge(m *PatientMerge) error {
+	if err := r.fault("PatientMerge.Merge"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.patients.mutex.Lock()
+	defer r.patients.mutex.Unlock()
+
+	source, exists := r.patients.patients[m.SourceHN]
+	if !exists {
+		return apperr.NotFound("patient %s not found", m.SourceHN)
+	}
+	target, exists := r.patients.patients[m.TargetHN]
+	if !exists {
+		return apperr.NotFound("patient %s not found", m.TargetHN)
+	}
+
+	sourceCopy, targetCopy := *source, *target
+	m.SourceSnapshot = &sourceCopy
+	m.TargetSnapshot = &targetCopy
+	m.Relinked = []RelinkedRows{}
+	delete(r.patients.patients, m.SourceHN)
+
+	m.ID = r.nextID
+	m.MergedAt = time.Now()
+	r.nextID++
+
+	mergeCopy := copyPatientMerge(m)
+	r.merges[m.ID] = &mergeCopy
+
+	return nil
+}
+
+// Unmerge restores the source patient from its snapshot
+func (r *MockPatientMergeRepository) Unmerge(id int, undoneBy string) (*PatientMerge, error) {
+	if err := r.fault("PatientMerge.Unmerge"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.patients.mutex.Lock()
+	defer r.patients.mutex.Unlock()
+
+	m, exists := r.merges[id]
+	if !exists {
+		return nil, apperr.NotFound("patient merge %d not found", id)
+	}
+	now := time.Now()
+	if err := m.checkUndo(now); err != nil {
+		return nil, err
+	}
+	if _, exists := r.patients.patients[m.TargetHN]; !exists {
+		return nil, apperr.NotFound("patient %s not found", m.TargetHN)
+	}
+	if _, exists := r.patients.patients[m.SourceHN]; exists {
+		return nil, apperr.Conflict("HN %s has been reused since the merge", m.SourceHN)
+	}
+
+	restored := *m.SourceSnapshot
+	r.patients.patients[m.SourceHN] = &restored
+	m.UndoneBy = &undoneBy
+	m.UndoneAt = &now
+
+	mergeCopy := copyPatientMerge(m)
+	return &mergeCopy, nil
+}
+
+// GetByID retrieves a patient merge by ID
+func (r *MockPatientMergeRepository) GetByID(id int) (*PatientMerge, error) {
+	if err := r.fault("PatientMerge.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	m, exists := r.merges[id]
+	if !exists {
+		return nil, apperr.NotFound("patient merge %d not found", id)
+	}
+
+	mergeCopy := copyPatientMerge(m)
+	return &mergeCopy, nil
+}
+
+// List retrieves merges involving hn, or all merges when hn is empty, most recent first
+func (r *MockPatientMergeRepository) List(hn string) ([]PatientMerge, error) {
+	if err := r.fault("PatientMerge.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	merges := []PatientMerge{}
+	for _, m := range r.merges {
+		if hn == "" || m.SourceHN == hn || m.TargetHN == hn {
+			merges = append(merges, copyPatientMerge(m))
+		}
+	}
+	sort.Slice(merges, func(i, j int) bool { return merges[i].ID > merges[j].ID })
+	return merges, nil
+}
+
+// PurgeSnapshots drops the snapshots of merges whose undo window closed before now
+func (r *MockPatientMergeRepository) PurgeSnapshots(now time.Time) (int, error) {
+	if err := r.fault("PatientMerge.PurgeSnapshots"); err != nil {
+		return 0, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged := 0
+	for _, m := range r.merges {
+		if !m.UndoUntil.After(now) && m.SourceSnapshot != nil {
+			m.SourceSnapshot = nil
+			m.TargetSnapshot = nil
+			m.Relinked = []RelinkedRows{}
+			purged++
+		}
+	}
+	return purged, nil
+}
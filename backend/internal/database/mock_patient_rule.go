@@ -0,0 +1,75 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockPatientRuleRepository is an in-memory implementation for testing
+type MockPatientRuleRepository struct {
+	mockFidelity
+
+	rules map[string]*PatientFieldRule
+	mutex sync.RWMutex
+}
+
+// NewMockPatientRuleRepository creates a new mock patient rule repository
+func NewMockPatientRuleRepository() *MockPatientRuleRepository {
+	return &MockPatientRuleRepository{
+		rules: make(map[string]*PatientFieldRule),
+	}
+}
+
+// GetAll retrieves the configured rules; defaults are not included
+func (r *MockPatientRuleRepository) GetAll() ([]PatientFieldRule, error) {
+	if err := r.fault("PatientRule.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	rules := []PatientFieldRule{}
+	for _, rule := range r.rules {
+		rules = append(rules, *rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Field < rules[j].Field })
+	return rules, nil
+}
+
+// Set configures whether a field is required, replacing any earlier rule
+func (r *MockPatientRuleRepository) Set(rule *PatientFieldRule) error {
+	if err := r.fault("PatientRule.Set"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	rule.UpdatedAt = &now
+	ruleCopy := *rule
+	r.rules[rule.Field] = &ruleCopy
+
+	return nil
+}
+
+// Delete removes a configured rule, returning the field to its default
+func (r *MockPatientRuleRepository) Delete(field string) error {
+	if err := r.fault("PatientRule.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.rules[field]; !exists {
+		return apperr.NotFound("no rule configured for %s", field)
+	}
+	delete(r.rules, field)
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"sort"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockPaymentRepository is an in-memory implementation for testing. Payments
+// are applied to the invoices in the mock invoice repository, under its lock.
+type MockPaymentRepository struct {
+	mockFidelity
+
+	invoices *MockInvoiceRepository
+	payments map[int]*Payment
+	nextID   int
+}
+
+// NewMockPaymentRepository creates a new mock payment repository over invoices
+func NewMockPaymentRepository(invoices *MockInvoiceRepository) *MockPaymentRepository {
+	return &MockPaymentRepository{
+		invoices: invoices,
+		payments: make(map[int]*Payment),
+		nextID:   1,
+	}
+}
+
+// Record takes a payment against an issued invoice and marks it paid once nothing is outstanding
+func (r *MockPaymentRepository) Record(p *Payment) (*Invoice, error) {
+	if err := r.fault("Payment.Record"); err != nil {
+		return nil, err
+	}
+
+	r.invoices.mutex.Lock()
+	defer r.invoices.mutex.Unlock()
+
+	inv, exists := r.invoices.invoices[p.InvoiceID]
+	if !exists {
+		return nil, apperr.NotFound("invoice %d not found", p.InvoiceID)
+	}
+	if err := checkPayable(inv, p.Amount); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p.ID = r.nextID
+	p.CreatedAt = now
+	r.nextID++
+	paymentCopy := *p
+	r.payments[p.ID] = &paymentCopy
+
+	inv.AmountPaid = roundBaht(inv.AmountPaid + p.Amount)
+	inv.Outstanding = roundBaht(inv.Total - inv.AmountPaid)
+	inv.UpdatedAt = now
+	if inv.Outstanding <= 0 {
+		inv.Status = InvoicePaid
+		inv.PaidAt = &now
+	}
+
+	invoiceCopy := copyInvoice(inv)
+	return &invoiceCopy, nil
+}
+
+// GetByInvoice retrieves an invoice's payments, oldest first
+func (r *MockPaymentRepository) GetByInvoice(invoiceID int) ([]Payment, error) {
+	if err := r.fault("Payment.GetByInvoice"); err != nil {
+		return nil, err
+	}
+
+	r.invoices.mutex.RLock()
+	defer r.invoices.mutex.RUnlock()
+
+	payments := []Payment{}
+	for _, p := range r.payments {
+		if p.InvoiceID == invoiceID {
+			payments = append(payments, *p)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		if !payments[i].PaidAt.Equal(payments[j].PaidAt) {
+			return payments[i].PaidAt.Before(payments[j].PaidAt)
+		}
+		return payments[i].ID < payments[j].ID
+	})
+	return payments, nil
+}
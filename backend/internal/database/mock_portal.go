@@ -0,0 +1,151 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockPortalRepository is an in-memory implementation for testing
+type MockPortalRepository struct {
+	mockFidelity
+
+	challenges map[string]*PortalChallenge
+	sessions   map[int]*PortalSession
+	nextID     int
+	mutex      sync.RWMutex
+}
+
+// NewMockPortalRepository creates a new mock portal repository
+func NewMockPortalRepository() *MockPortalRepository {
+	return &MockPortalRepository{
+		challenges: make(map[string]*PortalChallenge),
+		sessions:   make(map[int]*PortalSession),
+		nextID:     1,
+	}
+}
+
+// CreateChallenge stores a new sign-in challenge
+func (r *MockPortalRepository) CreateChallenge(c *PortalChallenge) error {
+	if err := r.fault("Portal.CreateChallenge"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c.CreatedAt = time.Now()
+	challengeCopy := *c
+	r.challenges[c.ID] = &challengeCopy
+
+	return nil
+}
+
+// CountChallenges counts the challenges asked for an HN, whether or not it
+// matched a patient, and the challenges asked by a client, since a time
+func (r *MockPortalRepository) CountChallenges(requestedHN, clientIP string, since time.Time) (int, int, error) {
+	if err := r.fault("Portal.CountChallenges"); err != nil {
+		return 0, 0, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	forHN, byClient := 0, 0
+	for _, c := range r.challenges {
+		if c.CreatedAt.Before(since) {
+			continue
+		}
+		if c.RequestedHN == requestedHN {
+			forHN++
+		}
+		if c.ClientIP == clientIP {
+			byClient++
+		}
+	}
+	return forHN, byClient, nil
+}
+
+// UseChallenge checks a code against a challenge, counting the attempt
+func (r *MockPortalRepository) UseChallenge(id, codeHash string, maxAttempts int) (*PortalChallenge, error) {
+	if err := r.fault("Portal.UseChallenge"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c, exists := r.challenges[id]
+	if !exists {
+		return nil, apperr.NotFound("portal challenge not found")
+	}
+	if c.UsedAt != nil || c.Attempts >= maxAttempts || time.Now().After(c.ExpiresAt) {
+		return nil, apperr.Conflict("the code has expired; request a new one")
+	}
+	c.Attempts++
+	if c.PatientHN == nil || c.CodeHash != codeHash {
+		return nil, apperr.Validation("incorrect code")
+	}
+	now := time.Now()
+	c.UsedAt = &now
+
+	challengeCopy := *c
+	return &challengeCopy, nil
+}
+
+// CreateSession stores a new portal session
+func (r *MockPortalRepository) CreateSession(s *PortalSession) error {
+	if err := r.fault("Portal.CreateSession"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s.ID = r.nextID
+	s.CreatedAt = time.Now()
+	r.nextID++
+
+	sessionCopy := *s
+	r.sessions[s.ID] = &sessionCopy
+
+	return nil
+}
+
+// GetSession finds a session by its token hash, including revoked and expired ones
+func (r *MockPortalRepository) GetSession(tokenHash string) (*PortalSession, error) {
+	if err := r.fault("Portal.GetSession"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, s := range r.sessions {
+		if s.TokenHash == tokenHash {
+			sessionCopy := *s
+			return &sessionCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("portal session not found")
+}
+
+// RevokeSession signs a session out
+func (r *MockPortalRepository) RevokeSession(id int) error {
+	if err := r.fault("Portal.RevokeSession"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.sessions[id]
+	if !exists || s.RevokedAt != nil {
+		return apperr.NotFound("portal session %d not found", id)
+	}
+	now := time.Now()
+	s.RevokedAt = &now
+
+	return nil
+}
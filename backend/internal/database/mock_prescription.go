@@ -0,0 +1,193 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockPrescriptionRepository is an in-memory implementation for testing
+type MockPrescriptionRepository struct {
+	mockFidelity
+
+	prescriptions map[int]*Prescription
+	nextID        int
+	mutex         sync.RWMutex
+}
+
+// NewMockPrescriptionRepository creates a new mock prescription repository
+func NewMockPrescriptionRepository() *MockPrescriptionRepository {
+	return &MockPrescriptionRepository{
+		prescriptions: make(map[int]*Prescription),
+		nextID:        1,
+	}
+}
+
+func copyPrescription(p *Prescription) Prescription {
+	prescriptionCopy := *p
+	prescriptionCopy.Items = append([]DrugTemplate{}, p.Items...)
+	return prescriptionCopy
+}
+
+// Create stores a new prescription
+func (r *MockPrescriptionRepository) Create(p *Prescription) error {
+	if err := r.fault("Prescription.Create"); err != nil {
+		return err
+	}
+	if err := r.checkVisit(p.VisitID); err != nil {
+		return err
+	}
+	if err := r.checkDoctor(p.DoctorID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p.ID = r.nextID
+	p.DispenseStatus = PrescriptionPending
+	p.DispensedBy = nil
+	p.DispensedAt = nil
+	p.CreatedAt = time.Now()
+	r.nextID++
+
+	prescriptionCopy := copyPrescription(p)
+	r.prescriptions[p.ID] = &prescriptionCopy
+
+	return nil
+}
+
+// GetByID retrieves a prescription by ID
+func (r *MockPrescriptionRepository) GetByID(id int) (*Prescription, error) {
+	if err := r.fault("Prescription.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, exists := r.prescriptions[id]
+	if !exists {
+		return nil, apperr.NotFound("prescription %d not found", id)
+	}
+
+	prescriptionCopy := copyPrescription(p)
+	return &prescriptionCopy, nil
+}
+
+// GetByVisit retrieves a visit's prescriptions, oldest first
+func (r *MockPrescriptionRepository) GetByVisit(visitID int) ([]Prescription, error) {
+	if err := r.fault("Prescription.GetByVisit"); err != nil {
+		return nil, err
+	}
+
+	prescriptions := r.filter(func(p *Prescription) bool { return p.VisitID == visitID })
+	sort.Slice(prescriptions, func(i, j int) bool { return prescriptions[i].ID < prescriptions[j].ID })
+	return prescriptions, nil
+}
+
+// GetByPatient retrieves a patient's prescriptions, most recent first
+func (r *MockPrescriptionRepository) GetByPatient(hn string) ([]Prescription, error) {
+	if err := r.fault("Prescription.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	prescriptions := r.filter(func(p *Prescription) bool { return p.PatientHN == hn })
+	sort.Slice(prescriptions, func(i, j int) bool { return prescriptions[i].ID > prescriptions[j].ID })
+	return prescriptions, nil
+}
+
+// GetWrittenBetween retrieves the prescriptions written from from up to to, oldest first
+func (r *MockPrescriptionRepository) GetWrittenBetween(from, to time.Time) ([]Prescription, error) {
+	if err := r.fault("Prescription.GetWrittenBetween"); err != nil {
+		return nil, err
+	}
+
+	prescriptions := r.filter(func(p *Prescription) bool { return !p.CreatedAt.Before(from) && p.CreatedAt.Before(to) })
+	sort.Slice(prescriptions, func(i, j int) bool { return prescriptions[i].ID < prescriptions[j].ID })
+	return prescriptions, nil
+}
+
+// GetPending retrieves the prescriptions waiting at the pharmacy, oldest first
+func (r *MockPrescriptionRepository) GetPending() ([]Prescription, error) {
+	if err := r.fault("Prescription.GetPending"); err != nil {
+		return nil, err
+	}
+
+	prescriptions := r.filter(func(p *Prescription) bool { return p.DispenseStatus == PrescriptionPending })
+	sort.Slice(prescriptions, func(i, j int) bool { return prescriptions[i].ID < prescriptions[j].ID })
+	return prescriptions, nil
+}
+
+// GetDispensedBetween retrieves the prescriptions dispensed from from up to to, most recent first
+func (r *MockPrescriptionRepository) GetDispensedBetween(from, to time.Time) ([]Prescription, error) {
+	if err := r.fault("Prescription.GetDispensedBetween"); err != nil {
+		return nil, err
+	}
+
+	prescriptions := r.filter(func(p *Prescription) bool {
+		return p.DispensedAt != nil && !p.DispensedAt.Before(from) && p.DispensedAt.Before(to)
+	})
+	sort.Slice(prescriptions, func(i, j int) bool {
+		if a, b := *prescriptions[i].DispensedAt, *prescriptions[j].DispensedAt; !a.Equal(b) {
+			return a.After(b)
+		}
+		return prescriptions[i].ID > prescriptions[j].ID
+	})
+	return prescriptions, nil
+}
+
+// MarkDispensed records that a pending prescription was handed over, and by whom
+func (r *MockPrescriptionRepository) MarkDispensed(id int, by string) (*Prescription, error) {
+	if err := r.fault("Prescription.MarkDispensed"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p, exists := r.prescriptions[id]
+	if !exists || p.DispenseStatus != PrescriptionPending {
+		return nil, apperr.Conflict("prescription %d is not pending dispensing", id)
+	}
+
+	now := time.Now()
+	p.DispenseStatus = PrescriptionDispensed
+	p.DispensedBy = &by
+	p.DispensedAt = &now
+
+	prescriptionCopy := copyPrescription(p)
+	return &prescriptionCopy, nil
+}
+
+// UndoDispensed puts a prescription back in the pharmacy queue
+func (r *MockPrescriptionRepository) UndoDispensed(id int) error {
+	if err := r.fault("Prescription.UndoDispensed"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if p, exists := r.prescriptions[id]; exists && p.DispenseStatus == PrescriptionDispensed {
+		p.DispenseStatus = PrescriptionPending
+		p.DispensedBy = nil
+		p.DispensedAt = nil
+	}
+	return nil
+}
+
+func (r *MockPrescriptionRepository) filter(keep func(p *Prescription) bool) []Prescription {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	prescriptions := []Prescription{}
+	for _, p := range r.prescriptions {
+		if keep(p) {
+			prescriptions = append(prescriptions, copyPrescription(p))
+		}
+	}
+	return prescriptions
+}
@@ -0,0 +1,256 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockPrescriptionFavoriteRepository is an in-memory implementation for testing
+type MockPrescriptionFavoriteRepository struct {
+	mockFidelity
+
+	favorites      map[int]*DrugFavorite
+	sets           map[int]*PrescriptionSet
+	nextFavoriteID int
+	nextSetID      int
+	mutex          sync.RWMutex
+}
+
+// NewMockPrescriptionFavoriteRepository creates a new mock prescription favorite repository
+func NewMockPrescriptionFavoriteRepository() *MockPrescriptionFavoriteRepository {
+	return &MockPrescriptionFavoriteRepository{
+		favorites:      make(map[int]*DrugFavorite),
+		sets:           make(map[int]*PrescriptionSet),
+		nextFavoriteID: 1,
+		nextSetID:      1,
+	}
+}
+
+func copySet(s *PrescriptionSet) PrescriptionSet {
+	setCopy := *s
+	setCopy.Items = append([]DrugTemplate{}, s.Items...)
+	return setCopy
+}
+
+// CreateFavorite stores a new drug favorite
+func (r *MockPrescriptionFavoriteRepository) CreateFavorite(f *DrugFavorite) error {
+	if err := r.fault("PrescriptionFavorite.CreateFavorite"); err != nil {
+		return err
+	}
+	if err := r.checkDoctor(f.DoctorID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f.ID = r.nextFavoriteID
+	f.UsageCount = 0
+	f.CreatedAt = time.Now()
+	r.nextFavoriteID++
+
+	favoriteCopy := *f
+	r.favorites[f.ID] = &favoriteCopy
+
+	return nil
+}
+
+// GetFavorites retrieves a doctor's favorites, most used first
+func (r *MockPrescriptionFavoriteRepository) GetFavorites(doctorID int) ([]DrugFavorite, error) {
+	if err := r.fault("PrescriptionFavorite.GetFavorites"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	favorites := []DrugFavorite{}
+	for _, f := range r.favorites {
+		if f.DoctorID == doctorID {
+			favorites = append(favorites, *f)
+		}
+	}
+	sort.Slice(favorites, func(i, j int) bool {
+		if favorites[i].UsageCount != favorites[j].UsageCount {
+			return favorites[i].UsageCount > favorites[j].UsageCount
+		}
+		return favorites[i].DrugName < favorites[j].DrugName
+	})
+
+	return favorites, nil
+}
+
+// UseFavorite bumps a favorite's usage count and returns it
+func (r *MockPrescriptionFavoriteRepository) UseFavorite(doctorID, id int) (*DrugFavorite, error) {
+	if err := r.fault("PrescriptionFavorite.UseFavorite"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f, exists := r.favorites[id]
+	if !exists || f.DoctorID != doctorID {
+		return nil, apperr.NotFound("drug favorite %d not found for doctor %d", id, doctorID)
+	}
+
+	now := time.Now()
+	f.UsageCount++
+	f.LastUsedAt = &now
+
+	favoriteCopy := *f
+	return &favoriteCopy, nil
+}
+
+// DeleteFavorite removes a doctor's favorite
+func (r *MockPrescriptionFavoriteRepository) DeleteFavorite(doctorID, id int) error {
+	if err := r.fault("PrescriptionFavorite.DeleteFavorite"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f, exists := r.favorites[id]
+	if !exists || f.DoctorID != doctorID {
+		return apperr.NotFound("drug favorite %d not found for doctor %d", id, doctorID)
+	}
+	delete(r.favorites, id)
+
+	return nil
+}
+
+// CreateSet stores a new prescription set
+func (r *MockPrescriptionFavoriteRepository) CreateSet(s *PrescriptionSet) error {
+	if err := r.fault("PrescriptionFavorite.CreateSet"); err != nil {
+		return err
+	}
+	if err := r.checkDoctor(s.DoctorID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s.ID = r.nextSetID
+	s.UsageCount = 0
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	r.nextSetID++
+
+	setCopy := copySet(s)
+	r.sets[s.ID] = &setCopy
+
+	return nil
+}
+
+// GetSet retrieves one of a doctor's prescription sets
+func (r *MockPrescriptionFavoriteRepository) GetSet(doctorID, id int) (*PrescriptionSet, error) {
+	if err := r.fault("PrescriptionFavorite.GetSet"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.sets[id]
+	if !exists || s.DoctorID != doctorID {
+		return nil, apperr.NotFound("prescription set %d not found for doctor %d", id, doctorID)
+	}
+
+	setCopy := copySet(s)
+	return &setCopy, nil
+}
+
+// GetSets retrieves a doctor's prescription sets, most used first
+func (r *MockPrescriptionFavoriteRepository) GetSets(doctorID int) ([]PrescriptionSet, error) {
+	if err := r.fault("PrescriptionFavorite.GetSets"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	sets := []PrescriptionSet{}
+	for _, s := range r.sets {
+		if s.DoctorID == doctorID {
+			sets = append(sets, copySet(s))
+		}
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].UsageCount != sets[j].UsageCount {
+			return sets[i].UsageCount > sets[j].UsageCount
+		}
+		return sets[i].Name < sets[j].Name
+	})
+
+	return sets, nil
+}
+
+// UpdateSet replaces a prescription set's name and items
+func (r *MockPrescriptionFavoriteRepository) UpdateSet(s *PrescriptionSet) error {
+	if err := r.fault("PrescriptionFavorite.UpdateSet"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.sets[s.ID]
+	if !exists || existing.DoctorID != s.DoctorID {
+		return apperr.NotFound("prescription set %d not found for doctor %d", s.ID, s.DoctorID)
+	}
+
+	existing.Name = s.Name
+	existing.Items = append([]DrugTemplate{}, s.Items...)
+	existing.UpdatedAt = time.Now()
+	s.UsageCount = existing.UsageCount
+	s.LastUsedAt = existing.LastUsedAt
+	s.CreatedAt = existing.CreatedAt
+	s.UpdatedAt = existing.UpdatedAt
+
+	return nil
+}
+
+// UseSet bumps a set's usage count and returns it
+func (r *MockPrescriptionFavoriteRepository) UseSet(doctorID, id int) (*PrescriptionSet, error) {
+	if err := r.fault("PrescriptionFavorite.UseSet"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.sets[id]
+	if !exists || s.DoctorID != doctorID {
+		return nil, apperr.NotFound("prescription set %d not found for doctor %d", id, doctorID)
+	}
+
+	now := time.Now()
+	s.UsageCount++
+	s.LastUsedAt = &now
+
+	setCopy := copySet(s)
+	return &setCopy, nil
+}
+
+// DeleteSet removes a doctor's prescription set
+func (r *MockPrescriptionFavoriteRepository) DeleteSet(doctorID, id int) error {
+	if err := r.fault("PrescriptionFavorite.DeleteSet"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.sets[id]
+	if !exists || s.DoctorID != doctorID {
+		return apperr.NotFound("prescription set %d not found for doctor %d", id, doctorID)
+	}
+	delete(r.sets, id)
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockProblemRepository is an in-memory implementation for testing
+type MockProblemRepository struct {
+	mockFidelity
+
+	problems map[int]*Problem
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockProblemRepository creates a new mock problem repository
+func NewMockProblemRepository() *MockProblemRepository {
+	return &MockProblemRepository{
+		problems: make(map[int]*Problem),
+		nextID:   1,
+	}
+}
+
+// Create adds a condition to a patient's problem list
+func (r *MockProblemRepository) Create(p *Problem) error {
+	if err := r.fault("Problem.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(p.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p.ID = r.nextID
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	r.nextID++
+
+	problemCopy := *p
+	r.problems[p.ID] = &problemCopy
+
+	return nil
+}
+
+// GetByID retrieves a problem by ID
+func (r *MockProblemRepository) GetByID(id int) (*Problem, error) {
+	if err := r.fault("Problem.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, exists := r.problems[id]
+	if !exists {
+		return nil, apperr.NotFound("problem %d not found", id)
+	}
+	problemCopy := *p
+	return &problemCopy, nil
+}
+
+// GetByPatient retrieves a patient's problem list, active conditions first and then by onset
+func (r *MockProblemRepository) GetByPatient(hn string, activeOnly bool) ([]Problem, error) {
+	if err := r.fault("Problem.GetByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	problems := []Problem{}
+	for _, p := range r.problems {
+		if p.PatientHN == hn && (!activeOnly || p.Status == ProblemActive) {
+			problems = append(problems, *p)
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+		if (a.OnsetDate == nil) != (b.OnsetDate == nil) {
+			return b.OnsetDate == nil
+		}
+		if a.OnsetDate != nil && *a.OnsetDate != *b.OnsetDate {
+			return *a.OnsetDate < *b.OnsetDate
+		}
+		return a.Condition < b.Condition
+	})
+	return problems, nil
+}
+
+// Update replaces a problem's details and status
+func (r *MockProblemRepository) Update(p *Problem) error {
+	if err := r.fault("Problem.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.problems[p.ID]
+	if !exists {
+		return apperr.NotFound("problem %d not found", p.ID)
+	}
+
+	existing.Condition = p.Condition
+	existing.OnsetDate = p.OnsetDate
+	existing.Status = p.Status
+	existing.ResolvedDate = p.ResolvedDate
+	existing.Notes = p.Notes
+	existing.UpdatedAt = time.Now()
+	*p = *existing
+
+	return nil
+}
+
+// Delete removes a problem
+func (r *MockProblemRepository) Delete(id int) error {
+	if err := r.fault("Problem.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.problems[id]; !exists {
+		return apperr.NotFound("problem %d not found", id)
+	}
+	delete(r.problems, id)
+
+	return nil
+}
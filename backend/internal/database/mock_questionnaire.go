@@ -0,0 +1,232 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockQuestionnaireRepository is an in-memory implementation for testing
+type MockQuestionnaireRepository struct {
+	mockFidelity
+
+	requests map[int]*QuestionnaireRequest
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockQuestionnaireRepository creates a new mock questionnaire repository
+func NewMockQuestionnaireRepository() *MockQuestionnaireRepository {
+	return &MockQuestionnaireRepository{
+		requests: make(map[int]*QuestionnaireRequest),
+		nextID:   1,
+	}
+}
+
+func copyQuestionnaire(q *QuestionnaireRequest) QuestionnaireRequest {
+	requestCopy := *q
+	if q.Answers != nil {
+		requestCopy.Answers = append([]int{}, q.Answers...)
+	}
+	return requestCopy
+}
+
+// CreateRequest stores a new questionnaire request
+func (r *MockQuestionnaireRepository) CreateRequest(q *QuestionnaireRequest) error {
+	if err := r.fault("Questionnaire.CreateRequest"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(q.PatientHN); err != nil {
+		return err
+	}
+	if q.VisitID != nil {
+		if err := r.checkVisit(*q.VisitID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	q.ID = r.nextID
+	q.CreatedAt = time.Now()
+	r.nextID++
+
+	requestCopy := copyQuestionnaire(q)
+	r.requests[q.ID] = &requestCopy
+
+	return nil
+}
+
+// GetRequest retrieves a questionnaire request by ID
+func (r *MockQuestionnaireRepository) GetRequest(id int) (*QuestionnaireRequest, error) {
+	if err := r.fault("Questionnaire.GetRequest"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	q, exists := r.requests[id]
+	if !exists {
+		return nil, apperr.NotFound("questionnaire request %d not found", id)
+	}
+
+	requestCopy := copyQuestionnaire(q)
+	return &requestCopy, nil
+}
+
+// GetRequestByToken retrieves a questionnaire request by its patient link token
+func (r *MockQuestionnaireRepository) GetRequestByToken(token string) (*QuestionnaireRequest, error) {
+	if err := r.fault("Questionnaire.GetRequestByToken"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, q := range r.requests {
+		if q.Token == token {
+			requestCopy := copyQuestionnaire(q)
+			return &requestCopy, nil
+		}
+	}
+
+	return nil, apperr.NotFound("questionnaire link not found")
+}
+
+func (r *MockQuestionnaireRepository) filter(match func(q *QuestionnaireRequest) bool) []QuestionnaireRequest {
+	requests := []QuestionnaireRequest{}
+	for _, q := range r.requests {
+		if match(q) {
+			requests = append(requests, copyQuestionnaire(q))
+		}
+	}
+	return requests
+}
+
+// GetRequestsByPatient retrieves a patient's questionnaires, newest first
+func (r *MockQuestionnaireRepository) GetRequestsByPatient(hn string) ([]QuestionnaireRequest, error) {
+	if err := r.fault("Questionnaire.GetRequestsByPatient"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	requests := r.filter(func(q *QuestionnaireRequest) bool { return q.PatientHN == hn })
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID > requests[j].ID })
+
+	return requests, nil
+}
+
+// GetRequestsByVisit retrieves the questionnaires attached to a visit
+func (r *MockQuestionnaireRepository) GetRequestsByVisit(visitID int) ([]QuestionnaireRequest, error) {
+	if err := r.fault("Questionnaire.GetRequestsByVisit"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	requests := r.filter(func(q *QuestionnaireRequest) bool { return q.VisitID != nil && *q.VisitID == visitID })
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID < requests[j].ID })
+
+	return requests, nil
+}
+
+// GetOpenAlerts retrieves high-risk results no clinician has acknowledged yet, oldest first
+func (r *MockQuestionnaireRepository) GetOpenAlerts() ([]QuestionnaireRequest, error) {
+	if err := r.fault("Questionnaire.GetOpenAlerts"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	requests := r.filter(func(q *QuestionnaireRequest) bool { return q.HighRisk && q.AcknowledgedAt == nil })
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].CompletedAt.Equal(*requests[j].CompletedAt) {
+			return requests[i].CompletedAt.Before(*requests[j].CompletedAt)
+		}
+		return requests[i].ID < requests[j].ID
+	})
+
+	return requests, nil
+}
+
+// MarkSent records that the questionnaire link was delivered to the patient
+func (r *MockQuestionnaireRepository) MarkSent(id int, channel string) error {
+	if err := r.fault("Questionnaire.MarkSent"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	q, exists := r.requests[id]
+	if !exists || q.Status == QuestionnaireCompleted {
+		return apperr.Conflict("questionnaire request %d is not open", id)
+	}
+
+	now := time.Now()
+	q.Status = QuestionnaireSent
+	q.Channel = &channel
+	q.SentAt = &now
+
+	return nil
+}
+
+// Complete records the patient's answers and score. A questionnaire can only be completed once.
+func (r *MockQuestionnaireRepository) Complete(id int, result QuestionnaireResult) error {
+	if err := r.fault("Questionnaire.Complete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	q, exists := r.requests[id]
+	if !exists || q.Status == QuestionnaireCompleted {
+		return apperr.Conflict("questionnaire request %d is not open", id)
+	}
+
+	now := time.Now()
+	score, severity := result.Score, result.Severity
+	q.Status = QuestionnaireCompleted
+	q.CompletedAt = &now
+	q.Answers = append([]int{}, result.Answers...)
+	q.Score = &score
+	q.Severity = &severity
+	q.HighRisk = result.HighRisk
+	q.RiskReasons = nil
+	if result.RiskReasons != "" {
+		reasons := result.RiskReasons
+		q.RiskReasons = &reasons
+	}
+
+	return nil
+}
+
+// Acknowledge records the clinician who followed up a high-risk result
+func (r *MockQuestionnaireRepository) Acknowledge(id int, by string) error {
+	if err := r.fault("Questionnaire.Acknowledge"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	q, exists := r.requests[id]
+	if !exists || !q.HighRisk || q.AcknowledgedAt != nil {
+		return apperr.Conflict("questionnaire alert %d is not open", id)
+	}
+
+	now := time.Now()
+	q.AcknowledgedBy = &by
+	q.AcknowledgedAt = &now
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockQueueRepository is an in-memory implementation for testing
+type MockQueueRepository struct {
+	mockFidelity
+
+	entries   map[int]*QueueEntry
+	sequences map[string]int // branch/service point/day -> last number given
+	nextID    int
+	mutex     sync.RWMutex
+}
+
+// NewMockQueueRepository creates a new mock queue repository
+func NewMockQueueRepository() *MockQueueRepository {
+	return &MockQueueRepository{
+		entries:   make(map[int]*QueueEntry),
+		sequences: make(map[string]int),
+		nextID:    1,
+	}
+}
+
+// CheckIn adds a waiting entry with the next number for its branch, service point and day
+func (r *MockQueueRepository) CheckIn(e *QueueEntry) error {
+	if err := r.fault("Queue.CheckIn"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(e.PatientHN); err != nil {
+		return err
+	}
+	if e.VisitID != nil {
+		if err := r.checkVisit(*e.VisitID); err != nil {
+			return err
+		}
+	}
+	if e.DoctorID != nil {
+		if err := r.checkDoctor(*e.DoctorID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := strings.Join([]string{e.Branch, e.ServicePoint, e.QueueDate}, "/")
+	r.sequences[key]++
+	e.Number = r.sequences[key]
+	e.ID = r.nextID
+	e.CheckedInAt = time.Now()
+	r.nextID++
+
+	entryCopy := *e
+	r.entries[e.ID] = &entryCopy
+
+	return nil
+}
+
+// GetByID retrieves a queue entry
+func (r *MockQueueRepository) GetByID(id int) (*QueueEntry, error) {
+	if err := r.fault("Queue.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	e, exists := r.entries[id]
+	if !exists {
+		return nil, apperr.NotFound("queue entry %d not found", id)
+	}
+	entryCopy := *e
+	return &entryCopy, nil
+}
+
+// GetByStatusToken retrieves the queue entry a printed ticket's status page link opens
+func (r *MockQueueRepository) GetByStatusToken(token string) (*QueueEntry, error) {
+	if err := r.fault("Queue.GetByStatusToken"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, e := range r.entries {
+		if e.StatusToken != nil && *e.StatusToken == token {
+			entryCopy := *e
+			return &entryCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("queue entry not found")
+}
+
+// List retrieves queue entries matching the filter, by service point and the order they are called in
+func (r *MockQueueRepository) List(f QueueFilter) ([]QueueEntry, error) {
+	if err := r.fault("Queue.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entries := []QueueEntry{}
+	for _, e := range r.entries {
+		if e.Branch != f.Branch || (f.QueueDate != "" && e.QueueDate != f.QueueDate) {
+			continue
+		}
+		if (f.ServicePoint != "" && e.ServicePoint != f.ServicePoint) || (f.PatientHN != "" && e.PatientHN != f.PatientHN) {
+			continue
+		}
+		matches := len(f.Statuses) == 0
+		for _, status := range f.Statuses {
+			matches = matches || e.Status == status
+		}
+		if matches {
+			entries = append(entries, *e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ServicePoint != b.ServicePoint {
+			return a.ServicePoint < b.ServicePoint
+		}
+		if a.QueueDate != b.QueueDate {
+			return a.QueueDate < b.QueueDate
+		}
+		return a.Before(&b)
+	})
+
+	return entries, nil
+}
+
+// CallNext calls the most urgent, then lowest-numbered, waiting patient at a
+// branch's service point on a day; for a doctorID, only those waiting for
+// that doctor or for no one in particular
+func (r *MockQueueRepository) CallNext(branch, servicePoint, date, calledBy string, counter *string, doctorID int) (*QueueEntry, error) {
+	if err := r.fault("Queue.CallNext"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var next *QueueEntry
+	for _, e := range r.entries {
+		if e.Branch != branch || e.ServicePoint != servicePoint || e.QueueDate != date || e.Status != QueueWaiting {
+			continue
+		}
+		if doctorID != 0 && e.DoctorID != nil && *e.DoctorID != doctorID {
+			continue
+		}
+		if next == nil || e.Before(next) {
+			next = e
+		}
+	}
+	if next == nil {
+		return nil, apperr.NotFound("no one is waiting at %s", servicePoint)
+	}
+
+	now := time.Now()
+	next.Status = QueueInProgress
+	next.Counter = counter
+	next.CalledBy = &calledBy
+	next.CalledAt = &now
+
+	entryCopy := *next
+	return &entryCopy, nil
+}
+
+// UpdateStatus moves an entry from one status to another
+func (r *MockQueueRepository) UpdateStatus(id int, from, to, by string, counter *string) (*QueueEntry, error) {
+	if err := r.fault("Queue.UpdateStatus"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	e, exists := r.entries[id]
+	if !exists || e.Status != from {
+		return nil, apperr.Conflict("queue entry %d is no longer %s", id, from)
+	}
+
+	now := time.Now()
+	e.Status = to
+	e.FinishedAt = nil
+	switch to {
+	case QueueInProgress:
+		e.Counter = counter
+		e.CalledBy = &by
+		e.CalledAt = &now
+	case QueueWaiting:
+		e.Counter, e.CalledBy, e.CalledAt = nil, nil, nil
+	default:
+		e.FinishedAt = &now
+	}
+
+	entryCopy := *e
+	return &entryCopy, nil
+}
+
+// SetUrgency records an entry's triage level
+func (r *MockQueueRepository) SetUrgency(id int, urgency string) (*QueueEntry, error) {
+	if err := r.fault("Queue.SetUrgency"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	e, exists := r.entries[id]
+	if !exists {
+		return nil, apperr.NotFound("queue entry %d not found", id)
+	}
+	e.Urgency = &urgency
+
+	entryCopy := *e
+	return &entryCopy, nil
+}
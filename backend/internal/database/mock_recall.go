@@ -0,0 +1,154 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockRecallRepository is an in-memory implementation for testing
+type MockRecallRepository struct {
+	mockFidelity
+
+	recalls            map[int]*LotRecall
+	notifications      []RecallNotification
+	nextID             int
+	nextNotificationID int
+	mutex              sync.RWMutex
+}
+
+// NewMockRecallRepository creates a new mock recall repository
+func NewMockRecallRepository() *MockRecallRepository {
+	return &MockRecallRepository{
+		recalls:            make(map[int]*LotRecall),
+		nextID:             1,
+		nextNotificationID: 1,
+	}
+}
+
+// Create flags a lot as recalled
+func (r *MockRecallRepository) Create(recall *LotRecall) error {
+	if err := r.fault("Recall.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.recalls {
+		if existing.ItemID == recall.ItemID && existing.LotNumber == recall.LotNumber {
+			return apperr.Conflict("lot %s of item %d is already recalled", recall.LotNumber, recall.ItemID)
+		}
+	}
+
+	recall.ID = r.nextID
+	recall.CreatedAt = time.Now()
+	r.nextID++
+
+	recallCopy := *recall
+	r.recalls[recall.ID] = &recallCopy
+
+	return nil
+}
+
+// GetAll retrieves all lot recalls, newest first
+func (r *MockRecallRepository) GetAll() ([]LotRecall, error) {
+	if err := r.fault("Recall.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	recalls := make([]LotRecall, 0, len(r.recalls))
+	for _, rc := range r.recalls {
+		recalls = append(recalls, *rc)
+	}
+
+	sort.Slice(recalls, func(i, j int) bool {
+		return recalls[i].CreatedAt.After(recalls[j].CreatedAt)
+	})
+
+	return recalls, nil
+}
+
+// GetByID retrieves a lot recall by ID
+func (r *MockRecallRepository) GetByID(id int) (*LotRecall, error) {
+	if err := r.fault("Recall.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	rc, exists := r.recalls[id]
+	if !exists {
+		return nil, apperr.NotFound("lot recall %d not found", id)
+	}
+
+	recallCopy := *rc
+	return &recallCopy, nil
+}
+
+// IsLotRecalled reports whether a lot of an item has been recalled
+func (r *MockRecallRepository) IsLotRecalled(itemID int, lotNumber string) (bool, error) {
+	if err := r.fault("Recall.IsLotRecalled"); err != nil {
+		return false, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, rc := range r.recalls {
+		if rc.ItemID == itemID && rc.LotNumber == lotNumber {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CreateNotifications stores a recall notification batch
+func (r *MockRecallRepository) CreateNotifications(notifications []RecallNotification) error {
+	if err := r.fault("Recall.CreateNotifications"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range notifications {
+		n := &notifications[i]
+		n.ID = r.nextNotificationID
+		n.CreatedAt = time.Now()
+		r.nextNotificationID++
+		r.notifications = append(r.notifications, *n)
+	}
+
+	return nil
+}
+
+// GetNotifications retrieves the notification batch of a recall
+func (r *MockRecallRepository) GetNotifications(recallID int) ([]RecallNotification, error) {
+	if err := r.fault("Recall.GetNotifications"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	notifications := []RecallNotification{}
+	for _, n := range r.notifications {
+		if n.RecallID == recallID {
+			notifications = append(notifications, n)
+		}
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].PatientHN < notifications[j].PatientHN
+	})
+
+	return notifications, nil
+}
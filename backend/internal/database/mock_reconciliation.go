@@ -0,0 +1,156 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockReconciliationRepository is an in-memory implementation for testing
+type MockReconciliationRepository struct {
+	mockFidelity
+
+	imports      []BankStatementImport
+	transactions map[int]*BankTransaction
+	nextImportID int
+	nextTxnID    int
+	mutex        sync.RWMutex
+}
+
+// NewMockReconciliationRepository creates a new mock reconciliation repository
+func NewMockReconciliationRepository() *MockReconciliationRepository {
+	return &MockReconciliationRepository{
+		transactions: make(map[int]*BankTransaction),
+		nextImportID: 1,
+		nextTxnID:    1,
+	}
+}
+
+// CreateImport stores an import and its transactions
+func (r *MockReconciliationRepository) CreateImport(imp *BankStatementImport, txns []BankTransaction) error {
+	if err := r.fault("Reconciliation.CreateImport"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	imp.ID = r.nextImportID
+	imp.ImportedAt = time.Now()
+	r.nextImportID++
+	r.imports = append(r.imports, *imp)
+
+	for i := range txns {
+		t := &txns[i]
+		t.ID = r.nextTxnID
+		t.ImportID = imp.ID
+		t.CreatedAt = time.Now()
+		r.nextTxnID++
+
+		txnCopy := *t
+		r.transactions[t.ID] = &txnCopy
+	}
+
+	return nil
+}
+
+// ListTransactions retrieves bank transactions, optionally filtered by match status
+func (r *MockReconciliationRepository) ListTransactions(status string) ([]BankTransaction, error) {
+	if err := r.fault("Reconciliation.ListTransactions"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	txns := make([]BankTransaction, 0, len(r.transactions))
+	for _, t := range r.transactions {
+		if status != "" && t.MatchStatus != status {
+			continue
+		}
+		txns = append(txns, *t)
+	}
+
+	// Sort by TransactionDate (newest first)
+	sort.Slice(txns, func(i, j int) bool {
+		return txns[i].TransactionDate.After(txns[j].TransactionDate)
+	})
+
+	return txns, nil
+}
+
+// GetTransaction retrieves a bank transaction by ID
+func (r *MockReconciliationRepository) GetTransaction(id int) (*BankTransaction, error) {
+	if err := r.fault("Reconciliation.GetTransaction"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	t, exists := r.transactions[id]
+	if !exists {
+		return nil, apperr.NotFound("bank transaction %d not found", id)
+	}
+
+	txnCopy := *t
+	return &txnCopy, nil
+}
+
+// UpdateMatch stores the match state of a bank transaction
+func (r *MockReconciliationRepository) UpdateMatch(t *BankTransaction) error {
+	if err := r.fault("Reconciliation.UpdateMatch"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.transactions[t.ID]
+	if !exists {
+		return apperr.NotFound("bank transaction %d not found", t.ID)
+	}
+
+	existing.MatchStatus = t.MatchStatus
+	existing.MatchedInvoiceID = t.MatchedInvoiceID
+	existing.MatchedAt = t.MatchedAt
+
+	return nil
+}
+
+// UpdateImportMatched stores how many of an import's transactions are auto-matched
+func (r *MockReconciliationRepository) UpdateImportMatched(imp *BankStatementImport) error {
+	if err := r.fault("Reconciliation.UpdateImportMatched"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range r.imports {
+		if r.imports[i].ID == imp.ID {
+			r.imports[i].MatchedCount = imp.MatchedCount
+			return nil
+		}
+	}
+	return apperr.NotFound("statement import %d not found", imp.ID)
+}
+
+// ListImports retrieves all statement imports, newest first
+func (r *MockReconciliationRepository) ListImports() ([]BankStatementImport, error) {
+	if err := r.fault("Reconciliation.ListImports"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	imports := make([]BankStatementImport, len(r.imports))
+	for i, imp := range r.imports {
+		imports[len(r.imports)-1-i] = imp
+	}
+
+	return imports, nil
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockReferralRepository is an in-memory implementation for testing
+type MockReferralRepository struct {
+	mockFidelity
+
+	referrals      map[int]*Referral
+	documents      []ReferralDocument // in the order they were added
+	nextID         int
+	nextDocumentID int
+	mutex          sync.RWMutex
+}
+
+// NewMockReferralRepository creates a new mock referral repository
+func NewMockReferralRepository() *MockReferralRepository {
+	return &MockReferralRepository{
+		referrals:      make(map[int]*Referral),
+		nextID:         1,
+		nextDocumentID: 1,
+	}
+}
+
+// Create stores a new referral
+func (r *MockReferralRepository) Create(ref *Referral) error {
+	if err := r.fault("Referral.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(ref.PatientHN); err != nil {
+		return err
+	}
+	if ref.VisitID != nil {
+		if err := r.checkVisit(*ref.VisitID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ref.ID = r.nextID
+	ref.CreatedAt = time.Now()
+	ref.UpdatedAt = ref.CreatedAt
+	ref.Documents = nil
+	r.nextID++
+
+	referralCopy := *ref
+	r.referrals[ref.ID] = &referralCopy
+
+	return nil
+}
+
+// GetByID retrieves a referral with its documents
+func (r *MockReferralRepository) GetByID(id int) (*Referral, error) {
+	if err := r.fault("Referral.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	ref, exists := r.referrals[id]
+	if !exists {
+		return nil, apperr.NotFound("referral %d not found", id)
+	}
+
+	referralCopy := *ref
+	for _, d := range r.documents {
+		if d.ReferralID == id {
+			referralCopy.Documents = append(referralCopy.Documents, d)
+		}
+	}
+	return &referralCopy, nil
+}
+
+// List retrieves referrals matching the filter, newest first, without their documents
+func (r *MockReferralRepository) List(f ReferralFilter) ([]Referral, error) {
+	if err := r.fault("Referral.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	referrals := []Referral{}
+	for _, ref := range r.referrals {
+		if f.PatientHN != "" && ref.PatientHN != f.PatientHN {
+			continue
+		}
+		if f.VisitID != 0 && (ref.VisitID == nil || *ref.VisitID != f.VisitID) {
+			continue
+		}
+		if (f.Direction != "" && ref.Direction != f.Direction) || (f.Status != "" && ref.Status != f.Status) {
+			continue
+		}
+		if f.Facility != "" && !strings.Contains(strings.ToLower(ref.Facility), strings.ToLower(f.Facility)) {
+			continue
+		}
+		referrals = append(referrals, *ref)
+	}
+	sort.Slice(referrals, func(i, j int) bool {
+		if !referrals[i].CreatedAt.Equal(referrals[j].CreatedAt) {
+			return referrals[i].CreatedAt.After(referrals[j].CreatedAt)
+		}
+		return referrals[i].ID > referrals[j].ID
+	})
+
+	return referrals, nil
+}
+
+// UpdateStatus moves a referral from one status to another, recording the outcome
+func (r *MockReferralRepository) UpdateStatus(id int, from, to string, outcome *string) (*Referral, error) {
+	if err := r.fault("Referral.UpdateStatus"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ref, exists := r.referrals[id]
+	if !exists || ref.Status != from {
+		return nil, apperr.Conflict("referral %d is no longer %s", id, from)
+	}
+
+	ref.Status = to
+	if outcome != nil {
+		ref.Outcome = outcome
+	}
+	ref.UpdatedAt = time.Now()
+	ref.ClosedAt = nil
+	switch to {
+	case ReferralDeclined, ReferralCompleted, ReferralCancelled:
+		closedAt := ref.UpdatedAt
+		ref.ClosedAt = &closedAt
+	}
+
+	referralCopy := *ref
+	return &referralCopy, nil
+}
+
+// AddDocument attaches a document to a referral
+func (r *MockReferralRepository) AddDocument(d *ReferralDocument) error {
+	if err := r.fault("Referral.AddDocument"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.referrals[d.ReferralID]; !exists {
+		return apperr.NotFound("referral %d not found", d.ReferralID)
+	}
+
+	d.ID = r.nextDocumentID
+	d.AddedAt = time.Now()
+	r.nextDocumentID++
+	r.documents = append(r.documents, *d)
+
+	return nil
+}
+
+// RemoveDocument detaches a document added by mistake
+func (r *MockReferralRepository) RemoveDocument(referralID, id int) error {
+	if err := r.fault("Referral.RemoveDocument"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, d := range r.documents {
+		if d.ID == id && d.ReferralID == referralID {
+			r.documents = append(r.documents[:i], r.documents[i+1:]...)
+			return nil
+		}
+	}
+	return apperr.NotFound("document %d not found on referral %d", id, referralID)
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockReminderReplyRepository is an in-memory implementation for testing
+type MockReminderReplyRepository struct {
+	mockFidelity
+
+	replies map[int]*ReminderReply
+	nextID  int
+	mutex   sync.RWMutex
+}
+
+// NewMockReminderReplyRepository creates a new mock reminder reply repository
+func NewMockReminderReplyRepository() *MockReminderReplyRepository {
+	return &MockReminderReplyRepository{
+		replies: make(map[int]*ReminderReply),
+		nextID:  1,
+	}
+}
+
+// Create records an inbound reply
+func (r *MockReminderReplyRepository) Create(m *ReminderReply) error {
+	if err := r.fault("ReminderReply.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m.ID = r.nextID
+	m.ReceivedAt = time.Now()
+	r.nextID++
+
+	replyCopy := *m
+	r.replies[m.ID] = &replyCopy
+
+	return nil
+}
+
+// GetByID retrieves a reply
+func (r *MockReminderReplyRepository) GetByID(id int) (*ReminderReply, error) {
+	if err := r.fault("ReminderReply.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	m, exists := r.replies[id]
+	if !exists {
+		return nil, apperr.NotFound("reminder reply %d not found", id)
+	}
+	replyCopy := *m
+	return &replyCopy, nil
+}
+
+// List retrieves replies in a status ("" for all), oldest first
+func (r *MockReminderReplyRepository) List(status string) ([]ReminderReply, error) {
+	if err := r.fault("ReminderReply.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	replies := []ReminderReply{}
+	for _, m := range r.replies {
+		if status == "" || m.Status == status {
+			replies = append(replies, *m)
+		}
+	}
+	sort.Slice(replies, func(i, j int) bool { return replies[i].ID < replies[j].ID })
+
+	return replies, nil
+}
+
+// Resolve closes a reply waiting for review as resolved or dismissed
+func (r *MockReminderReplyRepository) Resolve(id int, status string, appointmentID *int, by string, note *string) (*ReminderReply, error) {
+	if err := r.fault("ReminderReply.Resolve"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m, exists := r.replies[id]
+	if !exists || m.Status != ReplyReview {
+		return nil, apperr.Conflict("reminder reply %d is not waiting for review", id)
+	}
+
+	now := time.Now()
+	m.Status = status
+	if appointmentID != nil {
+		m.AppointmentID = appointmentID
+	}
+	if note != nil {
+		m.Note = note
+	}
+	m.ResolvedBy = &by
+	m.ResolvedAt = &now
+
+	replyCopy := *m
+	return &replyCopy, nil
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockReorderRepository is an in-memory implementation for testing
+type MockReorderRepository struct {
+	mockFidelity
+
+	policies    map[int]*ReorderPolicy
+	suggestions map[int]*PurchaseSuggestion
+	nextID      int
+	mutex       sync.RWMutex
+}
+
+// NewMockReorderRepository creates a new mock reorder repository
+func NewMockReorderRepository() *MockReorderRepository {
+	return &MockReorderRepository{
+		policies:    make(map[int]*ReorderPolicy),
+		suggestions: make(map[int]*PurchaseSuggestion),
+		nextID:      1,
+	}
+}
+
+// ListPolicies retrieves all reorder policies
+func (r *MockReorderRepository) ListPolicies() ([]ReorderPolicy, error) {
+	if err := r.fault("Reorder.ListPolicies"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	policies := make([]ReorderPolicy, 0, len(r.policies))
+	for _, p := range r.policies {
+		policies = append(policies, *p)
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].ItemName < policies[j].ItemName
+	})
+
+	return policies, nil
+}
+
+// UpsertPolicy creates or replaces the reorder policy of an item
+func (r *MockReorderRepository) UpsertPolicy(p *ReorderPolicy) error {
+	if err := r.fault("Reorder.UpsertPolicy"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p.UpdatedAt = time.Now()
+
+	policyCopy := *p
+	r.policies[p.ItemID] = &policyCopy
+
+	return nil
+}
+
+// CreateSuggestions stores a batch of purchase suggestions
+func (r *MockReorderRepository) CreateSuggestions(suggestions []PurchaseSuggestion) error {
+	if err := r.fault("Reorder.CreateSuggestions"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range suggestions {
+		s := &suggestions[i]
+		s.ID = r.nextID
+		s.CreatedAt = time.Now()
+		r.nextID++
+
+		suggestionCopy := *s
+		r.suggestions[s.ID] = &suggestionCopy
+	}
+
+	return nil
+}
+
+// ListSuggestions retrieves purchase suggestions, optionally filtered by status
+func (r *MockReorderRepository) ListSuggestions(status string) ([]PurchaseSuggestion, error) {
+	if err := r.fault("Reorder.ListSuggestions"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	suggestions := make([]PurchaseSuggestion, 0, len(r.suggestions))
+	for _, s := range r.suggestions {
+		if status != "" && s.Status != status {
+			continue
+		}
+		suggestions = append(suggestions, *s)
+	}
+
+	// Sort by CreatedAt (newest first)
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].CreatedAt.After(suggestions[j].CreatedAt)
+	})
+
+	return suggestions, nil
+}
+
+// GetSuggestion retrieves a purchase suggestion by ID
+func (r *MockReorderRepository) GetSuggestion(id int) (*PurchaseSuggestion, error) {
+	if err := r.fault("Reorder.GetSuggestion"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.suggestions[id]
+	if !exists {
+		return nil, apperr.NotFound("purchase suggestion %d not found", id)
+	}
+
+	suggestionCopy := *s
+	return &suggestionCopy, nil
+}
+
+// UpdateSuggestion stores the decision and (possibly adjusted) quantity of a suggestion
+func (r *MockReorderRepository) UpdateSuggestion(s *PurchaseSuggestion) error {
+	if err := r.fault("Reorder.UpdateSuggestion"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.suggestions[s.ID]
+	if !exists {
+		return apperr.NotFound("purchase suggestion %d not found", s.ID)
+	}
+
+	existing.SuggestedQuantity = s.SuggestedQuantity
+	existing.Packs = s.Packs
+	existing.Status = s.Status
+	existing.DecidedBy = s.DecidedBy
+	existing.DecidedAt = s.DecidedAt
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockRosterRepository is an in-memory implementation for testing
+type MockRosterRepository struct {
+	mockFidelity
+
+	rosters   map[int]*Roster
+	daysOff   map[int]*DayOff
+	nextDayID int
+	mutex     sync.RWMutex
+}
+
+// NewMockRosterRepository creates a new mock roster repository
+func NewMockRosterRepository() *MockRosterRepository {
+	return &MockRosterRepository{
+		rosters:   make(map[int]*Roster),
+		daysOff:   make(map[int]*DayOff),
+		nextDayID: 1,
+	}
+}
+
+func copyRoster(r *Roster) *Roster {
+	rosterCopy := *r
+	rosterCopy.Shifts = append([]OpeningHours{}, r.Shifts...)
+	return &rosterCopy
+}
+
+// GetRoster retrieves a doctor's weekly shifts
+func (r *MockRosterRepository) GetRoster(doctorID int) (*Roster, error) {
+	if err := r.fault("Roster.GetRoster"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	roster, exists := r.rosters[doctorID]
+	if !exists {
+		return nil, apperr.NotFound("doctor %d has no roster", doctorID)
+	}
+	return copyRoster(roster), nil
+}
+
+// SaveRoster creates or replaces a doctor's weekly shifts
+func (r *MockRosterRepository) SaveRoster(roster *Roster) error {
+	if err := r.fault("Roster.SaveRoster"); err != nil {
+		return err
+	}
+	if err := r.checkDoctor(roster.DoctorID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	roster.UpdatedAt = time.Now()
+	r.rosters[roster.DoctorID] = copyRoster(roster)
+
+	return nil
+}
+
+// DeleteRoster removes a doctor's shifts
+func (r *MockRosterRepository) DeleteRoster(doctorID int) error {
+	if err := r.fault("Roster.DeleteRoster"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.rosters[doctorID]; !exists {
+		return apperr.NotFound("doctor %d has no roster", doctorID)
+	}
+	delete(r.rosters, doctorID)
+
+	return nil
+}
+
+// ListDaysOff retrieves a doctor's days off between from and to, earliest first
+func (r *MockRosterRepository) ListDaysOff(doctorID int, from, to string) ([]DayOff, error) {
+	if err := r.fault("Roster.ListDaysOff"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	days := []DayOff{}
+	for _, d := range r.daysOff {
+		if d.DoctorID != doctorID || (from != "" && d.Date < from) || (to != "" && d.Date > to) {
+			continue
+		}
+		days = append(days, *d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+
+	return days, nil
+}
+
+// AddDayOff records a day a doctor does not work
+func (r *MockRosterRepository) AddDayOff(d *DayOff) error {
+	if err := r.fault("Roster.AddDayOff"); err != nil {
+		return err
+	}
+	if err := r.checkDoctor(d.DoctorID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.daysOff {
+		if existing.DoctorID == d.DoctorID && existing.Date == d.Date {
+			return apperr.Conflict("doctor %d is already off on %s", d.DoctorID, d.Date)
+		}
+	}
+
+	d.ID = r.nextDayID
+	d.CreatedAt = time.Now()
+	r.nextDayID++
+
+	dayCopy := *d
+	r.daysOff[d.ID] = &dayCopy
+
+	return nil
+}
+
+// DeleteDayOff removes one of a doctor's days off
+func (r *MockRosterRepository) DeleteDayOff(doctorID, id int) error {
+	if err := r.fault("Roster.DeleteDayOff"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	d, exists := r.daysOff[id]
+	if !exists || d.DoctorID != doctorID {
+		return apperr.NotFound("day off %d not found", id)
+	}
+	delete(r.daysOff, id)
+
+	return nil
+}
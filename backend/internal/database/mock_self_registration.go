@@ -0,0 +1,168 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockSelfRegistrationRepository is an in-memory implementation for testing
+type MockSelfRegistrationRepository struct {
+	mockFidelity
+
+	registrations map[int]*SelfRegistration
+	nextID        int
+	mutex         sync.RWMutex
+}
+
+// NewMockSelfRegistrationRepository creates a new mock self-registration repository
+func NewMockSelfRegistrationRepository() *MockSelfRegistrationRepository {
+	return &MockSelfRegistrationRepository{
+		registrations: make(map[int]*SelfRegistration),
+		nextID:        1,
+	}
+}
+
+// Create issues a new self-registration link
+func (r *MockSelfRegistrationRepository) Create(s *SelfRegistration) error {
+	if err := r.fault("SelfRegistration.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s.ID = r.nextID
+	s.CreatedAt = time.Now()
+	r.nextID++
+
+	registrationCopy := *s
+	r.registrations[s.ID] = &registrationCopy
+
+	return nil
+}
+
+// GetByID retrieves a self-registration by ID
+func (r *MockSelfRegistrationRepository) GetByID(id int) (*SelfRegistration, error) {
+	if err := r.fault("SelfRegistration.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.registrations[id]
+	if !exists {
+		return nil, apperr.NotFound("self-registration %d not found", id)
+	}
+	registrationCopy := *s
+	return &registrationCopy, nil
+}
+
+// GetByToken retrieves a self-registration by its link token
+func (r *MockSelfRegistrationRepository) GetByToken(token string) (*SelfRegistration, error) {
+	if err := r.fault("SelfRegistration.GetByToken"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, s := range r.registrations {
+		if s.Token == token {
+			registrationCopy := *s
+			return &registrationCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("registration link not found")
+}
+
+// List retrieves self-registrations in a status, or all of them, newest first
+func (r *MockSelfRegistrationRepository) List(status string) ([]SelfRegistration, error) {
+	if err := r.fault("SelfRegistration.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	registrations := []SelfRegistration{}
+	for _, s := range r.registrations {
+		if status == "" || s.Status == status {
+			registrations = append(registrations, *s)
+		}
+	}
+	latest := func(s SelfRegistration) time.Time {
+		if s.SubmittedAt != nil {
+			return *s.SubmittedAt
+		}
+		return s.CreatedAt
+	}
+	sort.Slice(registrations, func(i, j int) bool {
+		if a, b := latest(registrations[i]), latest(registrations[j]); !a.Equal(b) {
+			return a.After(b)
+		}
+		return registrations[i].ID > registrations[j].ID
+	})
+	return registrations, nil
+}
+
+// Submit records the patient's details on an open form, leaving it pending verification
+func (r *MockSelfRegistrationRepository) Submit(s *SelfRegistration) error {
+	if err := r.fault("SelfRegistration.Submit"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.registrations[s.ID]
+	if !exists {
+		return apperr.NotFound("self-registration %d not found", s.ID)
+	}
+	if existing.Status != SelfRegistrationOpen {
+		return apperr.Conflict("self-registration %d has already been submitted", s.ID)
+	}
+
+	now := time.Now()
+	existing.Status = SelfRegistrationPending
+	existing.FullName = s.FullName
+	existing.Gender = s.Gender
+	existing.Nickname = s.Nickname
+	existing.Phone = s.Phone
+	existing.DateOfBirth = s.DateOfBirth
+	existing.CitizenID = s.CitizenID
+	existing.Notes = s.Notes
+	existing.SubmittedAt = &now
+
+	s.Status = existing.Status
+	s.SubmittedAt = existing.SubmittedAt
+	return nil
+}
+
+// Review closes a pending form, verified or rejected
+func (r *MockSelfRegistrationRepository) Review(id int, status string, patientHN, reason *string, by string) (*SelfRegistration, error) {
+	if err := r.fault("SelfRegistration.Review"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.registrations[id]
+	if !exists || s.Status != SelfRegistrationPending {
+		return nil, apperr.Conflict("self-registration %d is not pending verification", id)
+	}
+
+	now := time.Now()
+	s.Status = status
+	s.PatientHN = patientHN
+	s.RejectReason = reason
+	s.ReviewedBy = &by
+	s.ReviewedAt = &now
+
+	registrationCopy := *s
+	return &registrationCopy, nil
+}
@@ -0,0 +1,250 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockServiceRepository is an in-memory implementation for testing
+type MockServiceRepository struct {
+	mockFidelity
+
+	services map[int]*Service
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockServiceRepository creates a new mock service repository
+func NewMockServiceRepository() *MockServiceRepository {
+	return &MockServiceRepository{
+		services: make(map[int]*Service),
+		nextID:   1,
+	}
+}
+
+func (r *MockServiceRepository) checkUnique(s *Service) error {
+	for _, existing := range r.services {
+		if existing.ID != s.ID && strings.EqualFold(existing.Code, s.Code) {
+			return apperr.Conflict("service code %s is already in the catalog", s.Code)
+		}
+	}
+	return nil
+}
+
+// Create adds a service to the catalog; codes are unique
+func (r *MockServiceRepository) Create(s *Service) error {
+	if err := r.fault("Service.Create"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkUnique(s); err != nil {
+		return err
+	}
+
+	s.ID = r.nextID
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	r.nextID++
+
+	serviceCopy := *s
+	serviceCopy.Genders = append([]string{}, s.Genders...)
+	r.services[s.ID] = &serviceCopy
+
+	return nil
+}
+
+// GetByID retrieves a service by ID
+func (r *MockServiceRepository) GetByID(id int) (*Service, error) {
+	if err := r.fault("Service.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	s, exists := r.services[id]
+	if !exists {
+		return nil, apperr.NotFound("service %d not found", id)
+	}
+
+	serviceCopy := *s
+	return &serviceCopy, nil
+}
+
+// GetAll retrieves services matching the filter, by category and name
+func (r *MockServiceRepository) GetAll(f ServiceFilter) ([]Service, error) {
+	if err := r.fault("Service.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	search := strings.ToLower(f.Search)
+	services := []Service{}
+	for _, s := range r.services {
+		if (f.ActiveOnly && !s.Active) || (f.Category != "" && s.Category != f.Category) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(s.Code), search) && !strings.Contains(strings.ToLower(s.Name), search) {
+			continue
+		}
+		services = append(services, *s)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Category != services[j].Category {
+			return services[i].Category < services[j].Category
+		}
+		return services[i].Name < services[j].Name
+	})
+
+	return services, nil
+}
+
+// Update saves a service's details
+func (r *MockServiceRepository) Update(s *Service) error {
+	if err := r.fault("Service.Update"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.services[s.ID]
+	if !exists {
+		return apperr.NotFound("service %d not found", s.ID)
+	}
+	if err := r.checkUnique(s); err != nil {
+		return err
+	}
+
+	s.CreatedAt = existing.CreatedAt
+	s.UpdatedAt = time.Now()
+	serviceCopy := *s
+	serviceCopy.Genders = append([]string{}, s.Genders...)
+	r.services[s.ID] = &serviceCopy
+
+	return nil
+}
+
+// Deactivate withdraws a service from the catalog
+func (r *MockServiceRepository) Deactivate(id int) error {
+	if err := r.fault("Service.Deactivate"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.services[id]
+	if !exists {
+		return apperr.NotFound("service %d not found", id)
+	}
+
+	s.Active = false
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// MockVisitServiceRepository is an in-memory implementation for testing
+type MockVisitServiceRepository struct {
+	mockFidelity
+
+	services map[int]*VisitService
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockVisitServiceRepository creates a new mock visit service repository
+func NewMockVisitServiceRepository() *MockVisitServiceRepository {
+	return &MockVisitServiceRepository{
+		services: make(map[int]*VisitService),
+		nextID:   1,
+	}
+}
+
+// Create records a service given during a visit
+func (r *MockVisitServiceRepository) Create(s *VisitService) error {
+	if err := r.fault("VisitService.Create"); err != nil {
+		return err
+	}
+	if err := r.checkVisit(s.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s.ID = r.nextID
+	s.CreatedAt = time.Now()
+	r.nextID++
+
+	serviceCopy := *s
+	r.services[s.ID] = &serviceCopy
+
+	return nil
+}
+
+// GetByVisit retrieves the services recorded during a visit, in the order they were recorded
+func (r *MockVisitServiceRepository) GetByVisit(visitID int) ([]VisitService, error) {
+	if err := r.fault("VisitService.GetByVisit"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	services := []VisitService{}
+	for _, s := range r.services {
+		if s.VisitID == visitID {
+			services = append(services, *s)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].ID < services[j].ID })
+
+	return services, nil
+}
+
+// LastGiven returns when a patient was last given a service, or nil if never
+func (r *MockVisitServiceRepository) LastGiven(hn string, serviceID int) (*time.Time, error) {
+	if err := r.fault("VisitService.LastGiven"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var last *time.Time
+	for _, s := range r.services {
+		if s.PatientHN == hn && s.ServiceID == serviceID && (last == nil || s.CreatedAt.After(*last)) {
+			given := s.CreatedAt
+			last = &given
+		}
+	}
+	return last, nil
+}
+
+// Delete removes a service recorded in error from a visit
+func (r *MockVisitServiceRepository) Delete(visitID, id int) error {
+	if err := r.fault("VisitService.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, exists := r.services[id]
+	if !exists || s.VisitID != visitID {
+		return apperr.NotFound("service %d not recorded on visit %d", id, visitID)
+	}
+
+	delete(r.services, id)
+	return nil
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockTaskRepository is an in-memory implementation for testing
+type MockTaskRepository struct {
+	mockFidelity
+
+	tasks  map[int]*Task
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockTaskRepository creates a new mock task repository
+func NewMockTaskRepository() *MockTaskRepository {
+	return &MockTaskRepository{
+		tasks:  make(map[int]*Task),
+		nextID: 1,
+	}
+}
+
+// Create stores a new task
+func (r *MockTaskRepository) Create(t *Task) error {
+	if err := r.fault("Task.Create"); err != nil {
+		return err
+	}
+	if t.PatientHN != nil {
+		if err := r.checkPatient(*t.PatientHN); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t.ID = r.nextID
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	r.nextID++
+
+	taskCopy := *t
+	r.tasks[t.ID] = &taskCopy
+
+	return nil
+}
+
+// GetByID retrieves a task by ID
+func (r *MockTaskRepository) GetByID(id int) (*Task, error) {
+	if err := r.fault("Task.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	t, exists := r.tasks[id]
+	if !exists {
+		return nil, apperr.NotFound("task %d not found", id)
+	}
+	taskCopy := *t
+	return &taskCopy, nil
+}
+
+// List retrieves tasks matching the filter, soonest due first and undated last
+func (r *MockTaskRepository) List(f TaskFilter) ([]Task, error) {
+	if err := r.fault("Task.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tasks := []Task{}
+	for _, t := range r.tasks {
+		if (f.AssignedTo != "" && !strings.EqualFold(t.AssignedTo, f.AssignedTo)) || (f.Status != "" && t.Status != f.Status) ||
+			(f.PatientHN != "" && (t.PatientHN == nil || *t.PatientHN != f.PatientHN)) ||
+			(f.DueBefore != "" && (t.DueDate == nil || *t.DueDate >= f.DueBefore)) {
+			continue
+		}
+		tasks = append(tasks, *t)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i].DueDate, tasks[j].DueDate
+		if (a == nil) != (b == nil) {
+			return b == nil
+		}
+		if a != nil && *a != *b {
+			return *a < *b
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+// Update replaces an open task's title, details, assignee, patient and due date
+func (r *MockTaskRepository) Update(t *Task) error {
+	if err := r.fault("Task.Update"); err != nil {
+		return err
+	}
+	if t.PatientHN != nil {
+		if err := r.checkPatient(*t.PatientHN); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.tasks[t.ID]
+	if !exists || existing.Status != TaskOpen {
+		return apperr.Conflict("task %d is not open", t.ID)
+	}
+
+	existing.Title = t.Title
+	existing.Details = t.Details
+	existing.AssignedTo = t.AssignedTo
+	existing.PatientHN = t.PatientHN
+	existing.DueDate = t.DueDate
+	existing.UpdatedAt = time.Now()
+	*t = *existing
+
+	return nil
+}
+
+// UpdateStatus moves a task from one status to another
+func (r *MockTaskRepository) UpdateStatus(id int, from, to, by string) (*Task, error) {
+	if err := r.fault("Task.UpdateStatus"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t, exists := r.tasks[id]
+	if !exists || t.Status != from {
+		return nil, apperr.Conflict("task %d is no longer %s", id, from)
+	}
+
+	now := time.Now()
+	t.Status = to
+	t.UpdatedAt = now
+	t.CompletedBy, t.CompletedAt = nil, nil
+	if to == TaskDone {
+		t.CompletedBy = &by
+		t.CompletedAt = &now
+	}
+
+	taskCopy := *t
+	return &taskCopy, nil
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockTeleconsultRepository is an in-memory implementation for testing
+type MockTeleconsultRepository struct {
+	mockFidelity
+
+	sessions map[int]*Teleconsult
+	nextID   int
+	mutex    sync.RWMutex
+}
+
+// NewMockTeleconsultRepository creates a new mock teleconsult repository
+func NewMockTeleconsultRepository() *MockTeleconsultRepository {
+	return &MockTeleconsultRepository{
+		sessions: make(map[int]*Teleconsult),
+		nextID:   1,
+	}
+}
+
+// Create schedules a session; an appointment has at most one that is not cancelled
+func (r *MockTeleconsultRepository) Create(t *Teleconsult) error {
+	if err := r.fault("Teleconsult.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(t.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, s := range r.sessions {
+		if s.AppointmentID == t.AppointmentID && s.Status != TeleconsultCancelled {
+			return apperr.Conflict("appointment %d already has a teleconsult", t.AppointmentID)
+		}
+	}
+
+	t.ID = r.nextID
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	r.nextID++
+
+	sessionCopy := *t
+	r.sessions[t.ID] = &sessionCopy
+
+	return nil
+}
+
+// GetByID retrieves a session
+func (r *MockTeleconsultRepository) GetByID(id int) (*Teleconsult, error) {
+	if err := r.fault("Teleconsult.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	t, exists := r.sessions[id]
+	if !exists {
+		return nil, apperr.NotFound("teleconsult %d not found", id)
+	}
+	sessionCopy := *t
+	return &sessionCopy, nil
+}
+
+// GetByToken retrieves the session a patient or doctor join link belongs to
+func (r *MockTeleconsultRepository) GetByToken(token string) (*Teleconsult, error) {
+	if err := r.fault("Teleconsult.GetByToken"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, t := range r.sessions {
+		if t.PatientToken == token || t.DoctorToken == token {
+			sessionCopy := *t
+			return &sessionCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("teleconsult not found")
+}
+
+// List retrieves sessions matching the filter, earliest first
+func (r *MockTeleconsultRepository) List(f TeleconsultFilter) ([]Teleconsult, error) {
+	if err := r.fault("Teleconsult.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	sessions := []Teleconsult{}
+	for _, t := range r.sessions {
+		if (f.DoctorID != 0 && (t.DoctorID == nil || *t.DoctorID != f.DoctorID)) || (f.Status != "" && t.Status != f.Status) ||
+			(!f.From.IsZero() && t.ScheduledAt.Before(f.From)) || (!f.To.IsZero() && !t.ScheduledAt.Before(f.To)) {
+			continue
+		}
+		sessions = append(sessions, *t)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].ScheduledAt.Equal(sessions[j].ScheduledAt) {
+			return sessions[i].ScheduledAt.Before(sessions[j].ScheduledAt)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+// MarkPatientJoined records when the patient first joined
+func (r *MockTeleconsultRepository) MarkPatientJoined(id int) (*Teleconsult, error) {
+	if err := r.fault("Teleconsult.MarkPatientJoined"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t, exists := r.sessions[id]
+	if !exists {
+		return nil, apperr.NotFound("teleconsult %d not found", id)
+	}
+	if t.PatientJoinedAt == nil {
+		now := time.Now()
+		t.PatientJoinedAt = &now
+		t.UpdatedAt = now
+	}
+
+	sessionCopy := *t
+	return &sessionCopy, nil
+}
+
+// Start moves a scheduled session in progress, recording the visit it opened
+func (r *MockTeleconsultRepository) Start(id, visitID int) (*Teleconsult, error) {
+	return r.transition("Teleconsult.Start", id, TeleconsultScheduled, TeleconsultInProgress, func(t *Teleconsult, now time.Time) {
+		t.StartedAt = &now
+		t.VisitID = &visitID
+	})
+}
+
+// End finishes a session in progress
+func (r *MockTeleconsultRepository) End(id int) (*Teleconsult, error) {
+	return r.transition("Teleconsult.End", id, TeleconsultInProgress, TeleconsultEnded, func(t *Teleconsult, now time.Time) {
+		t.EndedAt = &now
+	})
+}
+
+// Cancel calls off a session that has not started
+func (r *MockTeleconsultRepository) Cancel(id int) (*Teleconsult, error) {
+	return r.transition("Teleconsult.Cancel", id, TeleconsultScheduled, TeleconsultCancelled, func(t *Teleconsult, now time.Time) {
+		t.EndedAt = &now
+	})
+}
+
+// transition moves a session from one status to another, applying set too; a
+// session in any other status is a conflict
+func (r *MockTeleconsultRepository) transition(op string, id int, from, to string, set func(t *Teleconsult, now time.Time)) (*Teleconsult, error) {
+	if err := r.fault(op); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t, exists := r.sessions[id]
+	if !exists {
+		return nil, apperr.NotFound("teleconsult %d not found", id)
+	}
+	if t.Status != from {
+		return nil, apperr.Conflict("teleconsult %d is %s, not %s", id, t.Status, from)
+	}
+	now := time.Now()
+	t.Status = to
+	t.UpdatedAt = now
+	set(t, now)
+
+	sessionCopy := *t
+	return &sessionCopy, nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MockTriageRepository is an in-memory implementation for testing
+type MockTriageRepository struct {
+	mockFidelity
+
+	triages map[int]*Triage
+	nextID  int
+	mutex   sync.RWMutex
+}
+
+// NewMockTriageRepository creates a new mock triage repository
+func NewMockTriageRepository() *MockTriageRepository {
+	return &MockTriageRepository{
+		triages: make(map[int]*Triage),
+		nextID:  1,
+	}
+}
+
+// Create records a triage assessment
+func (r *MockTriageRepository) Create(t *Triage) error {
+	if err := r.fault("Triage.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(t.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	t.ID = r.nextID
+	t.CreatedAt = time.Now()
+	r.nextID++
+
+	triageCopy := *t
+	triageCopy.Vitals = nil
+	r.triages[t.ID] = &triageCopy
+
+	return nil
+}
+
+// GetByQueueEntry retrieves the assessments of a queue entry, latest first
+func (r *MockTriageRepository) GetByQueueEntry(queueEntryID int) ([]Triage, error) {
+	if err := r.fault("Triage.GetByQueueEntry"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	triages := []Triage{}
+	for _, t := range r.triages {
+		if t.QueueEntryID == queueEntryID {
+			triages = append(triages, *t)
+		}
+	}
+	sort.Slice(triages, func(i, j int) bool {
+		if !triages[i].CreatedAt.Equal(triages[j].CreatedAt) {
+			return triages[i].CreatedAt.After(triages[j].CreatedAt)
+		}
+		return triages[i].ID > triages[j].ID
+	})
+
+	return triages, nil
+}
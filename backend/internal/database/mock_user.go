@@ -0,0 +1,216 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockUserRepository is an in-memory implementation for testing
+type MockUserRepository struct {
+	mockFidelity
+
+	users  map[int]*User
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockUserRepository creates a new mock user repository
+func NewMockUserRepository() *MockUserRepository {
+	return &MockUserRepository{
+		users:  make(map[int]*User),
+		nextID: 1,
+	}
+}
+
+func copyUser(u *User) *User {
+	userCopy := *u
+	if u.DoctorID != nil {
+		doctorID := *u.DoctorID
+		userCopy.DoctorID = &doctorID
+	}
+	if u.Email != nil {
+		email := *u.Email
+		userCopy.Email = &email
+	}
+	if u.PasswordChangedAt != nil {
+		changed := *u.PasswordChangedAt
+		userCopy.PasswordChangedAt = &changed
+	}
+	if u.DeactivatedAt != nil {
+		deactivated := *u.DeactivatedAt
+		userCopy.DeactivatedAt = &deactivated
+	}
+	if u.DeactivatedBy != nil {
+		by := *u.DeactivatedBy
+		userCopy.DeactivatedBy = &by
+	}
+	return &userCopy
+}
+
+func (r *MockUserRepository) checkDoctorRef(u *User) error {
+	if u.DoctorID == nil {
+		return nil
+	}
+	return r.checkDoctor(*u.DoctorID)
+}
+
+// Create inserts a new account; the username must be unused
+func (r *MockUserRepository) Create(u *User) error {
+	if err := r.fault("User.Create"); err != nil {
+		return err
+	}
+	if err := r.checkDoctorRef(u); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Username == u.Username {
+			return apperr.Conflict("username %s is already taken", u.Username)
+		}
+	}
+
+	now := time.Now()
+	u.ID = r.nextID
+	u.PasswordChangedAt = &now
+	u.CreatedAt = now
+	u.UpdatedAt = now
+	r.nextID++
+	r.users[u.ID] = copyUser(u)
+
+	return nil
+}
+
+// GetByID retrieves an account by ID
+func (r *MockUserRepository) GetByID(id int) (*User, error) {
+	if err := r.fault("User.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	u, exists := r.users[id]
+	if !exists {
+		return nil, apperr.NotFound("user %d not found", id)
+	}
+	return copyUser(u), nil
+}
+
+// GetByUsername retrieves an account by its username
+func (r *MockUserRepository) GetByUsername(username string) (*User, error) {
+	if err := r.fault("User.GetByUsername"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return copyUser(u), nil
+		}
+	}
+	return nil, apperr.NotFound("user %s not found", username)
+}
+
+// GetAll retrieves accounts matching the filter, by username
+func (r *MockUserRepository) GetAll(f UserFilter) ([]User, error) {
+	if err := r.fault("User.GetAll"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	users := []User{}
+	for _, u := range r.users {
+		if (f.Role != "" && u.Role != f.Role) || (f.ActiveOnly && !u.Active) {
+			continue
+		}
+		users = append(users, *copyUser(u))
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+
+	return users, nil
+}
+
+// Update saves an account's name, role, doctor record and email
+func (r *MockUserRepository) Update(u *User) error {
+	if err := r.fault("User.Update"); err != nil {
+		return err
+	}
+	if err := r.checkDoctorRef(u); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	existing, exists := r.users[u.ID]
+	if !exists {
+		return apperr.NotFound("user %d not found", u.ID)
+	}
+
+	updated := copyUser(existing)
+	updated.FullName = u.FullName
+	updated.Role = u.Role
+	updated.DoctorID = u.DoctorID
+	updated.Email = u.Email
+	updated.UpdatedAt = time.Now()
+	r.users[u.ID] = updated
+	*u = *copyUser(updated)
+
+	return nil
+}
+
+// SetActive deactivates an account, recording who did so, or reactivates it
+func (r *MockUserRepository) SetActive(id int, active bool, by string) (*User, error) {
+	if err := r.fault("User.SetActive"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, exists := r.users[id]
+	if !exists {
+		return nil, apperr.NotFound("user %d not found", id)
+	}
+
+	now := time.Now()
+	u.Active = active
+	u.DeactivatedAt, u.DeactivatedBy = nil, nil
+	if !active {
+		u.DeactivatedAt, u.DeactivatedBy = &now, &by
+	}
+	u.UpdatedAt = now
+	return copyUser(u), nil
+}
+
+// SetPassword replaces an account's password hash
+func (r *MockUserRepository) SetPassword(id int, hash string, mustChange bool) (*User, error) {
+	if err := r.fault("User.SetPassword"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, exists := r.users[id]
+	if !exists {
+		return nil, apperr.NotFound("user %d not found", id)
+	}
+
+	now := time.Now()
+	u.PasswordHash = hash
+	u.MustChangePassword = mustChange
+	u.PasswordChangedAt = &now
+	u.UpdatedAt = now
+	return copyUser(u), nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockVaccinationRepository is an in-memory implementation for testing
+type MockVaccinationRepository struct {
+	mockFidelity
+
+	vaccinations map[int]*Vaccination
+	nextID       int
+	mutex        sync.RWMutex
+}
+
+// NewMockVaccinationRepository creates a new mock vaccination repository
+func NewMockVaccinationRepository() *MockVaccinationRepository {
+	return &MockVaccinationRepository{
+		vaccinations: make(map[int]*Vaccination),
+		nextID:       1,
+	}
+}
+
+// Create records a dose
+func (r *MockVaccinationRepository) Create(v *Vaccination) error {
+	if err := r.fault("Vaccination.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(v.PatientHN); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	v.ID = r.nextID
+	v.CreatedAt = time.Now()
+	r.nextID++
+
+	vaccinationCopy := *v
+	r.vaccinations[v.ID] = &vaccinationCopy
+
+	return nil
+}
+
+// List retrieves a patient's doses, or every patient's when hn is empty, in the order they were given
+func (r *MockVaccinationRepository) List(hn string) ([]Vaccination, error) {
+	if err := r.fault("Vaccination.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	vaccinations := []Vaccination{}
+	for _, v := range r.vaccinations {
+		if hn == "" || v.PatientHN == hn {
+			vaccinations = append(vaccinations, *v)
+		}
+	}
+	sort.Slice(vaccinations, func(i, j int) bool {
+		a, b := vaccinations[i], vaccinations[j]
+		if a.PatientHN != b.PatientHN {
+			return a.PatientHN < b.PatientHN
+		}
+		if a.AdministeredDate != b.AdministeredDate {
+			return a.AdministeredDate < b.AdministeredDate
+		}
+		return a.ID < b.ID
+	})
+	return vaccinations, nil
+}
+
+// Delete removes a dose
+func (r *MockVaccinationRepository) Delete(id int) error {
+	if err := r.fault("Vaccination.Delete"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.vaccinations[id]; !exists {
+		return apperr.NotFound("vaccination %d not found", id)
+	}
+	delete(r.vaccinations, id)
+
+	return nil
+}
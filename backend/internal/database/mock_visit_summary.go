@@ -0,0 +1,181 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockVisitSummaryRepository is an in-memory implementation for testing
+type MockVisitSummaryRepository struct {
+	mockFidelity
+
+	links        map[int]*VisitSummaryLink
+	accesses     []SummaryLinkAccess
+	nextID       int
+	nextAccessID int
+	mutex        sync.RWMutex
+}
+
+// NewMockVisitSummaryRepository creates a new mock visit summary link repository
+func NewMockVisitSummaryRepository() *MockVisitSummaryRepository {
+	return &MockVisitSummaryRepository{
+		links:        make(map[int]*VisitSummaryLink),
+		nextID:       1,
+		nextAccessID: 1,
+	}
+}
+
+// Create issues a new summary link
+func (r *MockVisitSummaryRepository) Create(l *VisitSummaryLink) error {
+	if err := r.fault("VisitSummary.Create"); err != nil {
+		return err
+	}
+	if err := r.checkVisit(l.VisitID); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	l.ID = r.nextID
+	l.CreatedAt = time.Now()
+	r.nextID++
+
+	linkCopy := *l
+	r.links[l.ID] = &linkCopy
+
+	return nil
+}
+
+// withViews returns a copy of the link with its view count filled in
+func (r *MockVisitSummaryRepository) withViews(l *VisitSummaryLink) VisitSummaryLink {
+	linkCopy := *l
+	for i := range r.accesses {
+		a := &r.accesses[i]
+		if a.LinkID == l.ID && a.Outcome == SummaryAccessViewed {
+			linkCopy.Views++
+			if linkCopy.LastViewedAt == nil || a.AccessedAt.After(*linkCopy.LastViewedAt) {
+				viewed := a.AccessedAt
+				linkCopy.LastViewedAt = &viewed
+			}
+		}
+	}
+	return linkCopy
+}
+
+// GetByID retrieves a summary link by ID
+func (r *MockVisitSummaryRepository) GetByID(id int) (*VisitSummaryLink, error) {
+	if err := r.fault("VisitSummary.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	l, exists := r.links[id]
+	if !exists {
+		return nil, apperr.NotFound("summary link %d not found", id)
+	}
+	linkCopy := r.withViews(l)
+	return &linkCopy, nil
+}
+
+// GetByToken retrieves a summary link by its token
+func (r *MockVisitSummaryRepository) GetByToken(token string) (*VisitSummaryLink, error) {
+	if err := r.fault("VisitSummary.GetByToken"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, l := range r.links {
+		if l.Token == token {
+			linkCopy := r.withViews(l)
+			return &linkCopy, nil
+		}
+	}
+	return nil, apperr.NotFound("summary link not found")
+}
+
+// GetByVisit retrieves the links issued for a visit, newest first
+func (r *MockVisitSummaryRepository) GetByVisit(visitID int) ([]VisitSummaryLink, error) {
+	if err := r.fault("VisitSummary.GetByVisit"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	links := []VisitSummaryLink{}
+	for _, l := range r.links {
+		if l.VisitID == visitID {
+			links = append(links, r.withViews(l))
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].ID > links[j].ID })
+	return links, nil
+}
+
+// Revoke stops a link working before it expires
+func (r *MockVisitSummaryRepository) Revoke(id int, by string) (*VisitSummaryLink, error) {
+	if err := r.fault("VisitSummary.Revoke"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	l, exists := r.links[id]
+	if !exists {
+		return nil, apperr.NotFound("summary link %d not found", id)
+	}
+	if l.RevokedAt != nil {
+		return nil, apperr.Conflict("summary link %d has already been revoked", id)
+	}
+
+	now := time.Now()
+	l.RevokedAt = &now
+	l.RevokedBy = &by
+
+	linkCopy := r.withViews(l)
+	return &linkCopy, nil
+}
+
+// LogAccess records one opening of a link
+func (r *MockVisitSummaryRepository) LogAccess(a *SummaryLinkAccess) error {
+	if err := r.fault("VisitSummary.LogAccess"); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	a.ID = r.nextAccessID
+	a.AccessedAt = time.Now()
+	r.nextAccessID++
+	r.accesses = append(r.accesses, *a)
+
+	return nil
+}
+
+// GetAccesses retrieves a link's access log, most recent first
+func (r *MockVisitSummaryRepository) GetAccesses(linkID int) ([]SummaryLinkAccess, error) {
+	if err := r.fault("VisitSummary.GetAccesses"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	accesses := []SummaryLinkAccess{}
+	for i := len(r.accesses) - 1; i >= 0; i-- {
+		if r.accesses[i].LinkID == linkID {
+			accesses = append(accesses, r.accesses[i])
+		}
+	}
+	return accesses, nil
+}
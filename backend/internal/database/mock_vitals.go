@@ -0,0 +1,97 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// MockVitalsRepository is an in-memory implementation for testing
+type MockVitalsRepository struct {
+	mockFidelity
+
+	vitals map[int]*VitalSigns
+	nextID int
+	mutex  sync.RWMutex
+}
+
+// NewMockVitalsRepository creates a new mock vital signs repository
+func NewMockVitalsRepository() *MockVitalsRepository {
+	return &MockVitalsRepository{
+		vitals: make(map[int]*VitalSigns),
+		nextID: 1,
+	}
+}
+
+// Create stores a set of vital signs
+func (r *MockVitalsRepository) Create(v *VitalSigns) error {
+	if err := r.fault("Vitals.Create"); err != nil {
+		return err
+	}
+	if err := r.checkPatient(v.PatientHN); err != nil {
+		return err
+	}
+	if v.VisitID != nil {
+		if err := r.checkVisit(*v.VisitID); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	v.ID = r.nextID
+	v.CreatedAt = time.Now()
+	v.CalculateBMI()
+	r.nextID++
+
+	vitalsCopy := *v
+	r.vitals[v.ID] = &vitalsCopy
+
+	return nil
+}
+
+// GetByID retrieves a set of vital signs
+func (r *MockVitalsRepository) GetByID(id int) (*VitalSigns, error) {
+	if err := r.fault("Vitals.GetByID"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	v, exists := r.vitals[id]
+	if !exists {
+		return nil, apperr.NotFound("vital signs %d not found", id)
+	}
+	vitalsCopy := *v
+	return &vitalsCopy, nil
+}
+
+// List retrieves vital signs matching the filter, in the order they were measured
+func (r *MockVitalsRepository) List(f VitalsFilter) ([]VitalSigns, error) {
+	if err := r.fault("Vitals.List"); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	vitals := []VitalSigns{}
+	for _, v := range r.vitals {
+		if (f.PatientHN != "" && v.PatientHN != f.PatientHN) || (f.VisitID != 0 && (v.VisitID == nil || *v.VisitID != f.VisitID)) ||
+			(!f.From.IsZero() && v.MeasuredAt.Before(f.From)) || (!f.To.IsZero() && !v.MeasuredAt.Before(f.To)) {
+			continue
+		}
+		vitals = append(vitals, *v)
+	}
+	sort.Slice(vitals, func(i, j int) bool {
+		if !vitals[i].MeasuredAt.Equal(vitals[j].MeasuredAt) {
+			return vitals[i].MeasuredAt.Before(vitals[j].MeasuredAt)
+		}
+		return vitals[i].ID < vitals[j].ID
+	})
+	return vitals, nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// NoteDraft is an autosaved, editable SOAP note. Drafts live apart from the
+// immutable clinical_notes store and are removed once finalized.
+type NoteDraft struct {
+	ID             int       `json:"id" db:"id"`
+	VisitID        int       `json:"visitId" db:"visit_id"`
+	PatientHN      string    `json:"patientHn" db:"patient_hn"`
+	AuthorName     string    `json:"authorName" db:"author_name"`
+	AuthorRole     string    `json:"authorRole" db:"author_role"`
+	SupervisorName *string   `json:"supervisorName,omitempty" db:"supervisor_name"`
+	Subjective     string    `json:"subjective" db:"subjective"`
+	Objective      string    `json:"objective" db:"objective"`
+	Assessment     string    `json:"assessment" db:"assessment"`
+	Plan           string    `json:"plan" db:"plan"`
+	Revision       int       `json:"revision" db:"revision"` // bumped on every save
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Content renders the draft's non-empty SOAP sections as note text
+func (d *NoteDraft) Content() string {
+	var sections []string
+	for _, s := range []struct{ label, text string }{
+		{"S", d.Subjective}, {"O", d.Objective}, {"A", d.Assessment}, {"P", d.Plan},
+	} {
+		if text := strings.TrimSpace(s.text); text != "" {
+			sections = append(sections, s.label+": "+text)
+		}
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// NoteDraftRepository handles note draft database operations
+type NoteDraftRepository struct {
+	db *DB
+}
+
+// NewNoteDraftRepository creates a new note draft repository
+func NewNoteDraftRepository(db *DB) *NoteDraftRepository {
+	return &NoteDraftRepository{db: db}
+}
+
+const noteDraftColumns = `id, visit_id, patient_hn, author_name, author_role, supervisor_name,
+	subjective, objective, assessment, plan, revision, created_at, updated_at`
+
+func scanNoteDraft(row interface{ Scan(...interface{}) error }) (*NoteDraft, error) {
+	var d NoteDraft
+	err := row.Scan(&d.ID, &d.VisitID, &d.PatientHN, &d.AuthorName, &d.AuthorRole, &d.SupervisorName,
+		&d.Subjective, &d.Objective, &d.Assessment, &d.Plan, &d.Revision, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SaveDraft creates or overwrites an author's draft for a visit. The save only
+// applies if the stored revision still equals d.Revision (0 for a new draft),
+// so a stale browser tab cannot overwrite newer work.
+func (r *NoteDraftRepository) SaveDraft(d *NoteDraft) error {
+	query := `
+		INSERT INTO note_drafts (visit_id, patient_hn, author_name, author_role, supervisor_name,
+			subjective, objective, assessment, plan, revision)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, 1
+		WHERE $10 = 0
+		ON CONFLICT (visit_id, author_name) DO NOTHING
+		RETURNING id, revision, created_at, updated_at
+	`
+	if d.Revision > 0 {
+		query = `
+			UPDATE note_drafts
+			SET patient_hn = $2, author_role = $4, supervisor_name = $5, subjective = $6, objective = $7,
+				assessment = $8, plan = $9, revision = revision + 1, updated_at = CURRENT_TIMESTAMP
+			WHERE visit_id = $1 AND author_name = $3 AND revision = $10
+			RETURNING id, revision, created_at, updated_at
+		`
+	}
+
+	err := r.db.conn.QueryRow(query, d.VisitID, d.PatientHN, d.AuthorName, d.AuthorRole, d.SupervisorName,
+		d.Subjective, d.Objective, d.Assessment, d.Plan, d.Revision).Scan(&d.ID, &d.Revision, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("draft for visit %d by %s was saved elsewhere since revision %d", d.VisitID, d.AuthorName, d.Revision)
+		}
+		return fmt.Errorf("failed to save note draft: %w", err)
+	}
+
+	return nil
+}
+
+// GetDraft retrieves a note draft by ID
+func (r *NoteDraftRepository) GetDraft(id int) (*NoteDraft, error) {
+	d, err := scanNoteDraft(r.db.conn.QueryRow("SELECT "+noteDraftColumns+" FROM note_drafts WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("note draft %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get note draft: %w", err)
+	}
+
+	return d, nil
+}
+
+// GetVisitDraft retrieves an author's draft for a visit
+func (r *NoteDraftRepository) GetVisitDraft(visitID int, author string) (*NoteDraft, error) {
+	query := "SELECT " + noteDraftColumns + " FROM note_drafts WHERE visit_id = $1 AND author_name = $2"
+
+	d, err := scanNoteDraft(r.db.conn.QueryRow(query, visitID, author))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("no draft for visit %d by %s", visitID, author)
+		}
+		return nil, fmt.Errorf("failed to get note draft: %w", err)
+	}
+
+	return d, nil
+}
+
+// GetDraftsByAuthor retrieves an author's unfinished drafts, most recently saved first
+func (r *NoteDraftRepository) GetDraftsByAuthor(author string) ([]NoteDraft, error) {
+	query := "SELECT " + noteDraftColumns + " FROM note_drafts WHERE author_name = $1 ORDER BY updated_at DESC"
+
+	rows, err := r.db.conn.Query(query, author)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query note drafts: %w", err)
+	}
+	defer rows.Close()
+
+	var drafts []NoteDraft
+	for rows.Next() {
+		d, err := scanNoteDraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note draft: %w", err)
+		}
+		drafts = append(drafts, *d)
+	}
+
+	return drafts, nil
+}
+
+// DeleteDraft removes a note draft
+func (r *NoteDraftRepository) DeleteDraft(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM note_drafts WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete note draft: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("note draft %d not found", id)
+	}
+
+	return nil
+}
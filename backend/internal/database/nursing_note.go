@@ -0,0 +1,126 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// NursingNoteKinds are the kinds of nursing note, in the order forms offer them
+var NursingNoteKinds = []string{
+	"observation",  // what was seen or measured, e.g. "แผลแห้งดี ไม่มีหนอง"
+	"intervention", // care given, e.g. a dressing change or a medicine administered
+	"education",    // advice given to the patient or their family
+	"other",
+}
+
+// NursingNote is a timestamped entry by a nurse in a visit. Notes are
+// append-only: a mistake is fixed by a later note that corrects it, and both
+// stay in the history.
+type NursingNote struct {
+	ID          int       `json:"id" db:"id"`
+	VisitID     int       `json:"visitId" db:"visit_id"`
+	PatientHN   string    `json:"patientHn" db:"patient_hn"`
+	Kind        string    `json:"kind" db:"kind"`
+	Content     string    `json:"content" db:"content"`
+	AuthorName  string    `json:"authorName" db:"author_name"`
+	ObservedAt  time.Time `json:"observedAt" db:"observed_at"`           // when the care or observation happened
+	CorrectsID  *int      `json:"correctsId,omitempty" db:"corrects_id"` // the earlier note this one corrects
+	CorrectedBy *int      `json:"correctedBy,omitempty" db:"-"`          // the later note correcting this one, filled in by listings
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`             // when the note was written
+}
+
+// NursingNoteRepository handles nursing note database operations
+type NursingNoteRepository struct {
+	db *DB
+}
+
+// NewNursingNoteRepository creates a new nursing note repository
+func NewNursingNoteRepository(db *DB) *NursingNoteRepository {
+	return &NursingNoteRepository{db: db}
+}
+
+const nursingNoteColumns = `id, visit_id, patient_hn, kind, content, author_name, observed_at, corrects_id,
+	(SELECT c.id FROM nursing_notes c WHERE c.corrects_id = nursing_notes.id), created_at`
+
+func scanNursingNote(row interface{ Scan(...interface{}) error }) (*NursingNote, error) {
+	var n NursingNote
+	err := row.Scan(&n.ID, &n.VisitID, &n.PatientHN, &n.Kind, &n.Content, &n.AuthorName, &n.ObservedAt,
+		&n.CorrectsID, &n.CorrectedBy, &n.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Create appends a note. A correction must name an uncorrected note of the
+// same visit; the unique corrects_id keeps two corrections of one note out.
+func (r *NursingNoteRepository) Create(n *NursingNote) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if n.CorrectsID != nil {
+		var visitID int
+		err := tx.QueryRow("SELECT visit_id FROM nursing_notes WHERE id = $1 FOR UPDATE", *n.CorrectsID).Scan(&visitID)
+		if err == sql.ErrNoRows || (err == nil && visitID != n.VisitID) {
+			return apperr.Validation("nursing note %d is not a note of visit %d", *n.CorrectsID, n.VisitID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get corrected nursing note: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO nursing_notes (visit_id, patient_hn, kind, content, author_name, observed_at, corrects_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(query, n.VisitID, n.PatientHN, n.Kind, n.Content, n.AuthorName, n.ObservedAt, n.CorrectsID).
+		Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("nursing note %d has already been corrected", *n.CorrectsID)
+		}
+		return fmt.Errorf("failed to create nursing note: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetByID retrieves a nursing note
+func (r *NursingNoteRepository) GetByID(id int) (*NursingNote, error) {
+	n, err := scanNursingNote(r.db.conn.QueryRow("SELECT "+nursingNoteColumns+" FROM nursing_notes WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("nursing note %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get nursing note: %w", err)
+	}
+	return n, nil
+}
+
+// GetByVisit retrieves a visit's nursing notes in the order the care happened
+func (r *NursingNoteRepository) GetByVisit(visitID int) ([]NursingNote, error) {
+	rows, err := r.db.conn.Query("SELECT "+nursingNoteColumns+" FROM nursing_notes WHERE visit_id = $1 ORDER BY observed_at, id", visitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nursing notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []NursingNote{}
+	for rows.Next() {
+		n, err := scanNursingNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan nursing note: %w", err)
+		}
+		notes = append(notes, *n)
+	}
+
+	return notes, rows.Err()
+}
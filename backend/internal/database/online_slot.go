@@ -0,0 +1,178 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// OnlineSlot is a doctor's time released for patients to book themselves in
+// the patient portal. A slot is taken by the first patient to claim it.
+type OnlineSlot struct {
+	ID            int        `json:"id" db:"id"`
+	DoctorID      int        `json:"doctorId" db:"doctor_id"`
+	DoctorName    string     `json:"doctorName" db:"doctor_name"`
+	StartsAt      time.Time  `json:"startsAt" db:"starts_at"`
+	EndsAt        time.Time  `json:"endsAt" db:"ends_at"`
+	Type          string     `json:"type" db:"type"` // the appointment type booked into it
+	ReleasedBy    string     `json:"releasedBy" db:"released_by"`
+	BookedBy      *string    `json:"bookedBy,omitempty" db:"booked_by"` // patient HN, once claimed
+	BookedAt      *time.Time `json:"bookedAt,omitempty" db:"booked_at"`
+	AppointmentID *int       `json:"appointmentId,omitempty" db:"appointment_id"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// OnlineSlotFilter narrows a slot listing by start time; zero values match everything
+type OnlineSlotFilter struct {
+	DoctorID int
+	From     time.Time
+	To       time.Time
+	OpenOnly bool // leave out claimed slots
+}
+
+// OnlineSlotRepository handles online booking slot database operations
+type OnlineSlotRepository struct {
+	db *DB
+}
+
+// NewOnlineSlotRepository creates a new online slot repository
+func NewOnlineSlotRepository(db *DB) *OnlineSlotRepository {
+	return &OnlineSlotRepository{db: db}
+}
+
+const onlineSlotColumns = `id, doctor_id, doctor_name, starts_at, ends_at, type, released_by, booked_by, booked_at, appointment_id, created_at`
+
+func scanOnlineSlot(row interface{ Scan(...interface{}) error }) (*OnlineSlot, error) {
+	var s OnlineSlot
+	err := row.Scan(&s.ID, &s.DoctorID, &s.DoctorName, &s.StartsAt, &s.EndsAt, &s.Type, &s.ReleasedBy, &s.BookedBy, &s.BookedAt,
+		&s.AppointmentID, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Create releases a slot
+func (r *OnlineSlotRepository) Create(s *OnlineSlot) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO online_slots (doctor_id, doctor_name, starts_at, ends_at, type, released_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, s.DoctorID, s.DoctorName, s.StartsAt, s.EndsAt, s.Type, s.ReleasedBy).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("doctor %d does not exist", s.DoctorID)
+		}
+		return fmt.Errorf("failed to release online slot: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a slot
+func (r *OnlineSlotRepository) GetByID(id int) (*OnlineSlot, error) {
+	s, err := scanOnlineSlot(r.db.conn.QueryRow("SELECT "+onlineSlotColumns+" FROM online_slots WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("online slot %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get online slot: %w", err)
+	}
+	return s, nil
+}
+
+// List retrieves slots matching the filter, earliest first
+func (r *OnlineSlotRepository) List(f OnlineSlotFilter) ([]OnlineSlot, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if f.DoctorID != 0 {
+		add("doctor_id = $%d", f.DoctorID)
+	}
+	if !f.From.IsZero() {
+		add("starts_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("starts_at < $%d", f.To)
+	}
+	if f.OpenOnly {
+		conditions = append(conditions, "booked_by IS NULL")
+	}
+
+	rows, err := r.db.conn.Query("SELECT "+onlineSlotColumns+" FROM online_slots WHERE "+strings.Join(conditions, " AND ")+
+		" ORDER BY starts_at, doctor_name", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list online slots: %w", err)
+	}
+	defer rows.Close()
+
+	slots := []OnlineSlot{}
+	for rows.Next() {
+		s, err := scanOnlineSlot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan online slot: %w", err)
+		}
+		slots = append(slots, *s)
+	}
+	return slots, rows.Err()
+}
+
+// Claim takes an open slot that has not started for a patient, before their
+// appointment is booked into it; a slot someone else has claimed is a conflict
+func (r *OnlineSlotRepository) Claim(id int, patientHN string) (*OnlineSlot, error) {
+	s, err := scanOnlineSlot(r.db.conn.QueryRow(`
+		UPDATE online_slots SET booked_by = $2, booked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND booked_by IS NULL AND starts_at > CURRENT_TIMESTAMP
+		RETURNING `+onlineSlotColumns, id, patientHN))
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(id); err != nil {
+			return nil, err
+		}
+		return nil, apperr.Conflict("online slot %d is no longer available", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim online slot: %w", err)
+	}
+	return s, nil
+}
+
+// SetAppointment records the appointment booked into a claimed slot
+func (r *OnlineSlotRepository) SetAppointment(id, appointmentID int) error {
+	result, err := r.db.conn.Exec("UPDATE online_slots SET appointment_id = $2 WHERE id = $1 AND booked_by IS NOT NULL", id, appointmentID)
+	if err != nil {
+		return fmt.Errorf("failed to update online slot: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return apperr.NotFound("claimed online slot %d not found", id)
+	}
+	return nil
+}
+
+// Unclaim opens a claimed slot again, when its booking could not be made
+func (r *OnlineSlotRepository) Unclaim(id int) error {
+	_, err := r.db.conn.Exec("UPDATE online_slots SET booked_by = NULL, booked_at = NULL, appointment_id = NULL WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to reopen online slot: %w", err)
+	}
+	return nil
+}
+
+// Delete withdraws an open slot; a claimed one is a conflict
+func (r *OnlineSlotRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM online_slots WHERE id = $1 AND booked_by IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to withdraw online slot: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if _, err := r.GetByID(id); err != nil {
+			return err
+		}
+		return apperr.Conflict("online slot %d has been booked; cancel the appointment instead", id)
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Patient package statuses
+const (
+	PatientPackageActive    = "active"    // sessions left to use
+	PatientPackageCompleted = "completed" // every session used
+	PatientPackageCancelled = "cancelled" // stopped, e.g. refunded; no more sessions
+)
+
+// TreatmentPackage is a catalog course of sessions sold up front, such as ten
+// physiotherapy sessions (คอร์สกายภาพบำบัด 10 ครั้ง)
+type TreatmentPackage struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`                      // e.g. "กายภาพบำบัด 10 ครั้ง"
+	ServiceID *int      `json:"serviceId,omitempty" db:"service_id"` // the catalog service each session covers
+	Sessions  int       `json:"sessions" db:"sessions"`
+	Price     float64   `json:"price" db:"price"`                    // baht for the whole course
+	ValidDays *int      `json:"validDays,omitempty" db:"valid_days"` // days from purchase the sessions can be used; nil never expires
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// PatientPackage is a package a patient bought. The name, sessions and price
+// are copied from the catalog at purchase, so catalog changes do not alter
+// courses already sold.
+type PatientPackage struct {
+	ID                int              `json:"id" db:"id"`
+	PatientHN         string           `json:"patientHn" db:"patient_hn"`
+	PackageID         int              `json:"packageId" db:"package_id"`
+	ServiceID         *int             `json:"serviceId,omitempty" db:"service_id"`
+	Name              string           `json:"name" db:"name"`
+	TotalSessions     int              `json:"totalSessions" db:"total_sessions"`
+	UsedSessions      int              `json:"usedSessions" db:"used_sessions"`
+	RemainingSessions int              `json:"remainingSessions" db:"-"`
+	Price             float64          `json:"price" db:"price"`
+	Status            string           `json:"status" db:"status"`
+	ExpiresOn         *string          `json:"expiresOn,omitempty" db:"expires_on"` // YYYY-MM-DD, the last day sessions can be used
+	Expired           bool             `json:"expired,omitempty" db:"-"`            // set by the API against the clinic's today
+	PurchasedBy       string           `json:"purchasedBy" db:"purchased_by"`
+	PurchasedAt       time.Time        `json:"purchasedAt" db:"purchased_at"`
+	Sessions          []PackageSession `json:"sessions,omitempty" db:"-"` // filled in when one package is fetched
+}
+
+// PackageSession is one session of a patient package used during a visit
+type PackageSession struct {
+	ID               int       `json:"id" db:"id"`
+	PatientPackageID int       `json:"patientPackageId" db:"patient_package_id"`
+	PatientHN        string    `json:"patientHn" db:"patient_hn"`
+	VisitID          int       `json:"visitId" db:"visit_id"`
+	RecordedBy       string    `json:"recordedBy" db:"recorded_by"`
+	CreatedAt        time.Time `json:"createdAt" db:"created_at"`
+}
+
+// TreatmentPackageRepository handles treatment package catalog database operations
+type TreatmentPackageRepository struct {
+	db *DB
+}
+
+// NewTreatmentPackageRepository creates a new treatment package repository
+func NewTreatmentPackageRepository(db *DB) *TreatmentPackageRepository {
+	return &TreatmentPackageRepository{db: db}
+}
+
+const treatmentPackageColumns = `id, name, service_id, sessions, price, valid_days, active, created_at, updated_at`
+
+func scanTreatmentPackage(row interface{ Scan(...interface{}) error }) (*TreatmentPackage, error) {
+	var p TreatmentPackage
+	err := row.Scan(&p.ID, &p.Name, &p.ServiceID, &p.Sessions, &p.Price, &p.ValidDays, &p.Active, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Create adds a package to the catalog
+func (r *TreatmentPackageRepository) Create(p *TreatmentPackage) error {
+	query := `
+		INSERT INTO treatment_packages (name, service_id, sessions, price, valid_days, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, p.Name, p.ServiceID, p.Sessions, p.Price, p.ValidDays, p.Active).
+		Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("service %d does not exist", *p.ServiceID)
+		}
+		return fmt.Errorf("failed to create treatment package: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a catalog package
+func (r *TreatmentPackageRepository) GetByID(id int) (*TreatmentPackage, error) {
+	p, err := scanTreatmentPackage(r.db.conn.QueryRow("SELECT "+treatmentPackageColumns+" FROM treatment_packages WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("treatment package %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get treatment package: %w", err)
+	}
+	return p, nil
+}
+
+// GetAll retrieves catalog packages by name, only those on sale when activeOnly
+func (r *TreatmentPackageRepository) GetAll(activeOnly bool) ([]TreatmentPackage, error) {
+	rows, err := r.db.conn.Query("SELECT "+treatmentPackageColumns+" FROM treatment_packages WHERE (NOT $1 OR active) ORDER BY name", activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query treatment packages: %w", err)
+	}
+	defer rows.Close()
+
+	packages := []TreatmentPackage{}
+	for rows.Next() {
+		p, err := scanTreatmentPackage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan treatment package: %w", err)
+		}
+		packages = append(packages, *p)
+	}
+
+	return packages, rows.Err()
+}
+
+// Update saves a catalog package's details
+func (r *TreatmentPackageRepository) Update(p *TreatmentPackage) error {
+	query := `
+		UPDATE treatment_packages SET name = $2, service_id = $3, sessions = $4, price = $5, valid_days = $6,
+			active = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, p.ID, p.Name, p.ServiceID, p.Sessions, p.Price, p.ValidDays, p.Active).
+		Scan(&p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("treatment package %d not found", p.ID)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("service %d does not exist", *p.ServiceID)
+		}
+		return fmt.Errorf("failed to update treatment package: %w", err)
+	}
+
+	return nil
+}
+
+// Deactivate takes a package off sale. Packages are never deleted because
+// the courses patients bought keep referring to them.
+func (r *TreatmentPackageRepository) Deactivate(id int) error {
+	result, err := r.db.conn.Exec("UPDATE treatment_packages SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate treatment package: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("treatment package %d not found", id)
+	}
+
+	return nil
+}
+
+// PatientPackageRepository handles the packages patients bought and the
+// sessions used from them
+type PatientPackageRepository struct {
+	db *DB
+}
+
+// NewPatientPackageRepository creates a new patient package repository
+func NewPatientPackageRepository(db *DB) *PatientPackageRepository {
+	return &PatientPackageRepository{db: db}
+}
+
+const patientPackageColumns = `id, patient_hn, package_id, service_id, name, total_sessions, used_sessions, price, status,
+	to_char(expires_on, 'YYYY-MM-DD'), purchased_by, purchased_at`
+
+func scanPatientPackage(row interface{ Scan(...interface{}) error }) (*PatientPackage, error) {
+	var p PatientPackage
+	err := row.Scan(&p.ID, &p.PatientHN, &p.PackageID, &p.ServiceID, &p.Name, &p.TotalSessions, &p.UsedSessions, &p.Price,
+		&p.Status, &p.ExpiresOn, &p.PurchasedBy, &p.PurchasedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.RemainingSessions = p.TotalSessions - p.UsedSessions
+	return &p, nil
+}
+
+const packageSessionColumns = `id, patient_package_id, patient_hn, visit_id, recorded_by, created_at`
+
+func scanPackageSession(row interface{ Scan(...interface{}) error }) (*PackageSession, error) {
+	var s PackageSession
+	if err := row.Scan(&s.ID, &s.PatientPackageID, &s.PatientHN, &s.VisitID, &s.RecordedBy, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Create records a package a patient bought
+func (r *PatientPackageRepository) Create(p *PatientPackage) error {
+	query := `
+		INSERT INTO patient_packages (patient_hn, package_id, service_id, name, total_sessions, used_sessions, price, status,
+			expires_on, purchased_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, purchased_at
+	`
+
+	err := r.db.conn.QueryRow(query, p.PatientHN, p.PackageID, p.ServiceID, p.Name, p.TotalSessions, p.UsedSessions,
+		p.Price, p.Status, p.ExpiresOn, p.PurchasedBy).Scan(&p.ID, &p.PurchasedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("treatment package %d does not exist", p.PackageID)
+		}
+		return fmt.Errorf("failed to create patient package: %w", err)
+	}
+	p.RemainingSessions = p.TotalSessions - p.UsedSessions
+
+	return nil
+}
+
+// GetByID retrieves a patient package
+func (r *PatientPackageRepository) GetByID(id int) (*PatientPackage, error) {
+	p, err := scanPatientPackage(r.db.conn.QueryRow("SELECT "+patientPackageColumns+" FROM patient_packages WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("patient package %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get patient package: %w", err)
+	}
+	return p, nil
+}
+
+// GetByPatient retrieves a patient's packages, newest first, with the given
+// status or all of them
+func (r *PatientPackageRepository) GetByPatient(hn, status string) ([]PatientPackage, error) {
+	query := `
+		SELECT ` + patientPackageColumns + ` FROM patient_packages
+		WHERE patient_hn = $1 AND ($2 = '' OR status = $2)
+		ORDER BY purchased_at DESC, id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, hn, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query patient packages: %w", err)
+	}
+	defer rows.Close()
+
+	packages := []PatientPackage{}
+	for rows.Next() {
+		p, err := scanPatientPackage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan patient package: %w", err)
+		}
+		packages = append(packages, *p)
+	}
+
+	return packages, rows.Err()
+}
+
+// Cancel stops an active package; its unused sessions can no longer be used
+func (r *PatientPackageRepository) Cancel(id int) (*PatientPackage, error) {
+	p, err := scanPatientPackage(r.db.conn.QueryRow(`
+		UPDATE patient_packages SET status = 'cancelled'
+		WHERE id = $1 AND status = 'active'
+		RETURNING `+patientPackageColumns, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("patient package %d is not active", id)
+		}
+		return nil, fmt.Errorf("failed to cancel patient package: %w", err)
+	}
+	return p, nil
+}
+
+// UseSession deducts one session from an active package for a visit, at most
+// once per visit. The package row is locked so concurrent visits cannot use
+// more sessions than were bought; the last session completes the package.
+func (r *PatientPackageRepository) UseSession(s *PackageSession) (*PatientPackage, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin package session: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	var total, used int
+	err = tx.QueryRow("SELECT status, total_sessions, used_sessions FROM patient_packages WHERE id = $1 FOR UPDATE",
+		s.PatientPackageID).Scan(&status, &total, &used)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("patient package %d not found", s.PatientPackageID)
+		}
+		return nil, fmt.Errorf("failed to lock patient package: %w", err)
+	}
+	if status != PatientPackageActive || used >= total {
+		return nil, apperr.Conflict("patient package %d has no sessions left", s.PatientPackageID)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO package_sessions (patient_package_id, patient_hn, visit_id, recorded_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, s.PatientPackageID, s.PatientHN, s.VisitID, s.RecordedBy).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return nil, apperr.Conflict("visit %d already used a session of patient package %d", s.VisitID, s.PatientPackageID)
+		}
+		if foreignKeyViolation(err) {
+			return nil, apperr.Validation("visit %d does not exist", s.VisitID)
+		}
+		return nil, fmt.Errorf("failed to record package session: %w", err)
+	}
+
+	p, err := scanPatientPackage(tx.QueryRow(`
+		UPDATE patient_packages SET used_sessions = used_sessions + 1,
+			status = CASE WHEN used_sessions + 1 >= total_sessions THEN 'completed' ELSE status END
+		WHERE id = $1
+		RETURNING `+patientPackageColumns, s.PatientPackageID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to deduct package session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit package session: %w", err)
+	}
+
+	return p, nil
+}
+
+// UndoSession gives back a session recorded on a visit in error; a package it
+// completed becomes active again
+func (r *PatientPackageRepository) UndoSession(visitID, id int) (*PatientPackage, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin package session undo: %w", err)
+	}
+	defer tx.Rollback()
+
+	var packageID int
+	err = tx.QueryRow("DELETE FROM package_sessions WHERE id = $1 AND visit_id = $2 RETURNING patient_package_id", id, visitID).
+		Scan(&packageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("package session %d not recorded on visit %d", id, visitID)
+		}
+		return nil, fmt.Errorf("failed to delete package session: %w", err)
+	}
+
+	p, err := scanPatientPackage(tx.QueryRow(`
+		UPDATE patient_packages SET used_sessions = used_sessions - 1,
+			status = CASE WHEN status = 'completed' THEN 'active' ELSE status END
+		WHERE id = $1
+		RETURNING `+patientPackageColumns, packageID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore package session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit package session undo: %w", err)
+	}
+
+	return p, nil
+}
+
+// GetSessions retrieves the sessions used from a package, oldest first
+func (r *PatientPackageRepository) GetSessions(patientPackageID int) ([]PackageSession, error) {
+	return r.querySessions("patient_package_id", patientPackageID)
+}
+
+// GetVisitSessions retrieves the package sessions used during a visit
+func (r *PatientPackageRepository) GetVisitSessions(visitID int) ([]PackageSession, error) {
+	return r.querySessions("visit_id", visitID)
+}
+
+func (r *PatientPackageRepository) querySessions(column string, id int) ([]PackageSession, error) {
+	rows, err := r.db.conn.Query("SELECT "+packageSessionColumns+" FROM package_sessions WHERE "+column+" = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query package sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []PackageSession{}
+	for rows.Next() {
+		s, err := scanPackageSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan package session: %w", err)
+		}
+		sessions = append(sessions, *s)
+	}
+
+	return sessions, rows.Err()
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"clinic/backend/internal/apperr"
 )
 
 // Patient represents a patient in the database
@@ -13,11 +15,16 @@ type Patient struct {
 	Gender      string    `json:"gender" db:"gender"`                       // เพศ
 	Nickname    *string   `json:"nickname,omitempty" db:"nickname"`         // ชื่อเล่น
 	Phone       *string   `json:"phone,omitempty" db:"phone"`               // เบอร์โทร
+	Email       *string   `json:"email,omitempty" db:"email"`               // อีเมล, for email notifications
 	Age         int       `json:"age" db:"age"`                             // อายุ
 	DateOfBirth *string   `json:"dateOfBirth,omitempty" db:"date_of_birth"` // วันเกิด
+	CitizenID   *string   `json:"citizenId,omitempty" db:"citizen_id"`      // เลขบัตรประชาชน, 13 digits
 	Photo       *string   `json:"photo,omitempty" db:"photo"`               // Photo URL/Base64
 	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+
+	Allergies []Allergy `json:"allergies,omitempty" db:"-"` // active allergies, filled in by the patient detail API
+	Problems  []Problem `json:"problems,omitempty" db:"-"`  // active problem list, filled in by the patient detail API
 }
 
 // PatientRepository handles patient database operations
@@ -33,7 +40,7 @@ func NewPatientRepository(db *DB) *PatientRepository {
 // GetAll retrieves all patients from the database
 func (r *PatientRepository) GetAll() ([]Patient, error) {
 	query := `
-		SELECT hn, full_name, gender, nickname, phone, age, date_of_birth, photo, created_at, updated_at
+		SELECT hn, full_name, gender, nickname, phone, email, age, date_of_birth, citizen_id, photo, created_at, updated_at
 		FROM patients
 		ORDER BY created_at DESC
 	`
@@ -48,7 +55,7 @@ func (r *PatientRepository) GetAll() ([]Patient, error) {
 	for rows.Next() {
 		var p Patient
 		err := rows.Scan(&p.HN, &p.FullName, &p.Gender, &p.Nickname,
-			&p.Phone, &p.Age, &p.DateOfBirth, &p.Photo, &p.CreatedAt, &p.UpdatedAt)
+			&p.Phone, &p.Email, &p.Age, &p.DateOfBirth, &p.CitizenID, &p.Photo, &p.CreatedAt, &p.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan patient: %w", err)
 		}
@@ -58,10 +65,31 @@ func (r *PatientRepository) GetAll() ([]Patient, error) {
 	return patients, nil
 }
 
+// GetAllFields retrieves the given fields (see PatientFields) of all patients,
+// newest first, selecting only their columns
+func (r *PatientRepository) GetAllFields(fields []string) ([]map[string]interface{}, error) {
+	rows, err := r.db.conn.Query("SELECT " + PatientFields.columns(fields) + " FROM patients ORDER BY created_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query patients: %w", err)
+	}
+	defer rows.Close()
+
+	patients := []map[string]interface{}{}
+	for rows.Next() {
+		var p Patient
+		if err := rows.Scan(PatientFields.targets(&p, fields)...); err != nil {
+			return nil, fmt.Errorf("failed to scan patient: %w", err)
+		}
+		patients = append(patients, PatientFields.Project(&p, fields))
+	}
+
+	return patients, rows.Err()
+}
+
 // GetByID retrieves a patient by ID
 func (r *PatientRepository) GetByID(id int) (*Patient, error) {
 	query := `
-		SELECT hn, full_name, gender, nickname, phone, age, date_of_birth, photo, created_at, updated_at
+		SELECT hn, full_name, gender, nickname, phone, email, age, date_of_birth, citizen_id, photo, created_at, updated_at
 		FROM patients
 		WHERE hn = $1
 	`
@@ -69,11 +97,34 @@ func (r *PatientRepository) GetByID(id int) (*Patient, error) {
 	var p Patient
 	err := r.db.conn.QueryRow(query, id).Scan(
 		&p.HN, &p.FullName, &p.Gender, &p.Nickname,
-		&p.Phone, &p.Age, &p.DateOfBirth, &p.Photo, &p.CreatedAt, &p.UpdatedAt)
+		&p.Phone, &p.Email, &p.Age, &p.DateOfBirth, &p.CitizenID, &p.Photo, &p.CreatedAt, &p.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("patient with hn %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get patient: %w", err)
+	}
+
+	return &p, nil
+}
+
+// GetByCitizenID retrieves a patient by citizen ID, stored as 13 digits
+func (r *PatientRepository) GetByCitizenID(citizenID string) (*Patient, error) {
+	query := `
+		SELECT hn, full_name, gender, nickname, phone, email, age, date_of_birth, citizen_id, photo, created_at, updated_at
+		FROM patients
+		WHERE citizen_id = $1
+	`
+
+	var p Patient
+	err := r.db.conn.QueryRow(query, citizenID).Scan(
+		&p.HN, &p.FullName, &p.Gender, &p.Nickname,
+		&p.Phone, &p.Email, &p.Age, &p.DateOfBirth, &p.CitizenID, &p.Photo, &p.CreatedAt, &p.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("patient with hn %d not found", id)
+			return nil, apperr.NotFound("patient with that citizen ID not found")
 		}
 		return nil, fmt.Errorf("failed to get patient: %w", err)
 	}
@@ -84,13 +135,13 @@ func (r *PatientRepository) GetByID(id int) (*Patient, error) {
 // Create adds a new patient to the database
 func (r *PatientRepository) Create(p *Patient) error {
 	query := `
-		INSERT INTO patients (hn, full_name, gender, nickname, phone, age, date_of_birth, photo)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO patients (hn, full_name, gender, nickname, phone, email, age, date_of_birth, citizen_id, photo)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING created_at, updated_at
 	`
 
 	err := r.db.conn.QueryRow(query, p.HN, p.FullName, p.Gender, p.Nickname,
-		p.Phone, p.Age, p.DateOfBirth, p.Photo).Scan(&p.CreatedAt, &p.UpdatedAt)
+		p.Phone, p.Email, p.Age, p.DateOfBirth, p.CitizenID, p.Photo).Scan(&p.CreatedAt, &p.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create patient: %w", err)
@@ -103,14 +154,14 @@ func (r *PatientRepository) Create(p *Patient) error {
 func (r *PatientRepository) Update(p *Patient) error {
 	query := `
 		UPDATE patients 
-		SET full_name = $1, gender = $2, nickname = $3, phone = $4, 
-		    age = $5, date_of_birth = $6, photo = $7, updated_at = CURRENT_TIMESTAMP
-		WHERE hn = $8
+		SET full_name = $1, gender = $2, nickname = $3, phone = $4, email = $5,
+		    age = $6, date_of_birth = $7, citizen_id = $8, photo = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE hn = $10
 		RETURNING updated_at
 	`
 
 	err := r.db.conn.QueryRow(query, p.FullName, p.Gender, p.Nickname,
-		p.Phone, p.Age, p.DateOfBirth, p.Photo, p.HN).Scan(&p.UpdatedAt)
+		p.Phone, p.Email, p.Age, p.DateOfBirth, p.CitizenID, p.Photo, p.HN).Scan(&p.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to update patient: %w", err)
@@ -134,8 +185,59 @@ func (r *PatientRepository) Delete(id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("patient with hn %d not found", id)
+		return apperr.NotFound("patient with hn %d not found", id)
 	}
 
 	return nil
 }
+
+// InlinePhoto is a patient photo still stored in the patients table as base64
+type InlinePhoto struct {
+	HN    string
+	Photo string
+}
+
+// GetInlinePhotos retrieves up to limit photos that are not yet URLs, for
+// patients after afterHN in HN order, so a migration can page through them
+func (r *PatientRepository) GetInlinePhotos(afterHN string, limit int) ([]InlinePhoto, error) {
+	query := `
+		SELECT hn, photo FROM patients
+		WHERE hn > $1 AND photo IS NOT NULL AND photo <> ''
+			AND photo NOT LIKE 'http://%' AND photo NOT LIKE 'https://%'
+		ORDER BY hn
+		LIMIT $2
+	`
+
+	rows, err := r.db.conn.Query(query, afterHN, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inline photos: %w", err)
+	}
+	defer rows.Close()
+
+	photos := []InlinePhoto{}
+	for rows.Next() {
+		var p InlinePhoto
+		if err := rows.Scan(&p.HN, &p.Photo); err != nil {
+			return nil, fmt.Errorf("failed to scan inline photo: %w", err)
+		}
+		photos = append(photos, p)
+	}
+
+	return photos, rows.Err()
+}
+
+// ReplacePhoto swaps a patient's inline photo for its URL, leaving updated_at
+// alone because the photo itself is unchanged. It reports false, leaving the
+// row alone, when the photo was changed since it was read.
+func (r *PatientRepository) ReplacePhoto(hn, inline, url string) (bool, error) {
+	result, err := r.db.conn.Exec("UPDATE patients SET photo = $3 WHERE hn = $1 AND photo = $2", hn, inline, url)
+	if err != nil {
+		return false, fmt.Errorf("failed to replace photo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
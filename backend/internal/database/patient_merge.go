@@ -0,0 +1,306 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// patientLinkedTables are the tables whose rows belong to a patient by patient_hn
+// and a SERIAL id; a merge moves their rows and records the ids so an undo can move them back
+var patientLinkedTables = []string{
+	"appointments", "encounters", "prescriptions", "invoices", "medical_certificates", "clinical_notes",
+	"note_drafts", "visit_diagnoses", "form_submissions", "care_plan_goals", "group_bookings",
+	"campaign_registrations", "interpreter_bookings", "questionnaire_requests", "recall_notifications",
+	"stock_movements", "insurance_policies", "insurance_claims",
+	"handover_notes", "tasks", "vital_signs", "patient_allergies", "chat_threads", "vaccinations",
+	"referrals", "queue_entries", "appointment_reminders", "reminder_replies", "patient_problems",
+	"appointment_overrides", "visit_services", "intakes", "patient_documents", "consents",
+	"triages", "follow_ups", "patient_packages", "package_sessions", "nursing_notes",
+	"dental_treatments", "tooth_findings", "emergency_contacts", "self_registrations",
+	"patient_addresses", "visit_summary_links",
+}
+
+// patientProfileTables hold at most one row per patient, keyed by patient_hn.
+// A merge moves the source's row only when the target has none.
+var patientProfileTables = []string{"patient_languages", "patient_accessibility"}
+
+// RelinkedRows are the rows of one table a merge moved to the target patient
+type RelinkedRows struct {
+	Table string `json:"table"`
+	IDs   []int  `json:"ids,omitempty"` // nil for a profile table, whose single row moved
+}
+
+// PatientMerge records folding a duplicate (source) patient into the record
+// kept (target). The target's details are left as they were; the source
+// record is removed and its children re-linked. Until UndoUntil the snapshots
+// and the list of moved rows are kept so the merge can be undone.
+type PatientMerge struct {
+	ID             int            `json:"id" db:"id"`
+	SourceHN       string         `json:"sourceHn" db:"source_hn"` // the duplicate, removed by the merge
+	TargetHN       string         `json:"targetHn" db:"target_hn"` // the record kept
+	SourceSnapshot *Patient       `json:"sourceSnapshot,omitempty" db:"source_snapshot"`
+	TargetSnapshot *Patient       `json:"targetSnapshot,omitempty" db:"target_snapshot"`
+	Relinked       []RelinkedRows `json:"relinked" db:"relinked"`
+	Reason         *string        `json:"reason,omitempty" db:"reason"`
+	MergedBy       string         `json:"mergedBy" db:"merged_by"`
+	MergedAt       time.Time      `json:"mergedAt" db:"merged_at"`
+	UndoUntil      time.Time      `json:"undoUntil" db:"undo_until"`
+	UndoneBy       *string        `json:"undoneBy,omitempty" db:"undone_by"`
+	UndoneAt       *time.Time     `json:"undoneAt,omitempty" db:"undone_at"`
+}
+
+// Undoable reports whether the merge can still be undone at now
+func (m *PatientMerge) Undoable(now time.Time) bool {
+	return m.UndoneAt == nil && m.SourceSnapshot != nil && now.Before(m.UndoUntil)
+}
+
+// checkUndo explains why a merge cannot be undone at now
+func (m *PatientMerge) checkUndo(now time.Time) error {
+	if m.UndoneAt != nil {
+		return apperr.Conflict("merge %d has already been undone", m.ID)
+	}
+	if !m.Undoable(now) {
+		return apperr.Conflict("merge %d can no longer be undone; the window closed at %s", m.ID, m.UndoUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// PatientMergeRepository handles patient merge database operations
+type PatientMergeRepository struct {
+	db *DB
+}
+
+// NewPatientMergeRepository creates a new patient merge repository
+func NewPatientMergeRepository(db *DB) *PatientMergeRepository {
+	return &PatientMergeRepository{db: db}
+}
+
+const patientMergeColumns = `id, source_hn, target_hn, source_snapshot, target_snapshot, relinked, reason,
+	merged_by, merged_at, undo_until, undone_by, undone_at`
+
+func scanPatientMerge(row interface{ Scan(...interface{}) error }) (*PatientMerge, error) {
+	var m PatientMerge
+	var source, target, relinked []byte
+	err := row.Scan(&m.ID, &m.SourceHN, &m.TargetHN, &source, &target, &relinked, &m.Reason,
+		&m.MergedBy, &m.MergedAt, &m.UndoUntil, &m.UndoneBy, &m.UndoneAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range []struct {
+		data []byte
+		dest interface{}
+	}{{source, &m.SourceSnapshot}, {target, &m.TargetSnapshot}, {relinked, &m.Relinked}} {
+		if field.data == nil {
+			continue
+		}
+		if err := json.Unmarshal(field.data, field.dest); err != nil {
+			return nil, fmt.Errorf("invalid snapshot for patient merge %d: %w", m.ID, err)
+		}
+	}
+	return &m, nil
+}
+
+const patientRowColumns = "hn, full_name, gender, nickname, phone, email, age, date_of_birth, photo, created_at, updated_at"
+
+func lockPatient(tx *sql.Tx, hn string) (*Patient, error) {
+	var p Patient
+	err := tx.QueryRow("SELECT "+patientRowColumns+" FROM patients WHERE hn = $1 FOR UPDATE", hn).Scan(
+		&p.HN, &p.FullName, &p.Gender, &p.Nickname, &p.Phone, &p.Email, &p.Age, &p.DateOfBirth, &p.Photo, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("patient %s not found", hn)
+		}
+		return nil, fmt.Errorf("failed to lock patient: %w", err)
+	}
+	return &p, nil
+}
+
+// Merge moves every row of the source patient to the target and removes the
+// source, keeping both records as they were so the merge can be undone until
+// m.UndoUntil. m.SourceHN, TargetHN, MergedBy, Reason and UndoUntil must be set.
+func (r *PatientMergeRepository) Merge(m *PatientMerge) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin patient merge: %w", err)
+	}
+	defer tx.Rollback()
+
+	if m.SourceSnapshot, err = lockPatient(tx, m.SourceHN); err != nil {
+		return err
+	}
+	if m.TargetSnapshot, err = lockPatient(tx, m.TargetHN); err != nil {
+		return err
+	}
+
+	m.Relinked = []RelinkedRows{}
+	for _, table := range patientLinkedTables {
+		rows, err := tx.Query("UPDATE "+table+" SET patient_hn = $2 WHERE patient_hn = $1 RETURNING id", m.SourceHN, m.TargetHN)
+		if err != nil {
+			return fmt.Errorf("failed to re-link %s: %w", table, err)
+		}
+		ids := []int{}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan re-linked %s: %w", table, err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to re-link %s: %w", table, err)
+		}
+		if len(ids) > 0 {
+			m.Relinked = append(m.Relinked, RelinkedRows{Table: table, IDs: ids})
+		}
+	}
+	for _, table := range patientProfileTables {
+		result, err := tx.Exec(`UPDATE `+table+` SET patient_hn = $2
+			WHERE patient_hn = $1 AND NOT EXISTS (SELECT 1 FROM `+table+` WHERE patient_hn = $2)`, m.SourceHN, m.TargetHN)
+		if err != nil {
+			return fmt.Errorf("failed to re-link %s: %w", table, err)
+		}
+		if moved, _ := result.RowsAffected(); moved > 0 {
+			m.Relinked = append(m.Relinked, RelinkedRows{Table: table})
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM patients WHERE hn = $1", m.SourceHN); err != nil {
+		return fmt.Errorf("failed to remove merged patient: %w", err)
+	}
+
+	source, _ := json.Marshal(m.SourceSnapshot)
+	target, _ := json.Marshal(m.TargetSnapshot)
+	relinked, _ := json.Marshal(m.Relinked)
+	err = tx.QueryRow(`
+		INSERT INTO patient_merges (source_hn, target_hn, source_snapshot, target_snapshot, relinked, reason, merged_by, undo_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, merged_at
+	`, m.SourceHN, m.TargetHN, source, target, relinked, m.Reason, m.MergedBy, m.UndoUntil).Scan(&m.ID, &m.MergedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record patient merge: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Unmerge restores the source patient from its snapshot and moves the rows the
+// merge re-linked back to it. Rows added to the target since the merge stay there.
+func (r *PatientMergeRepository) Unmerge(id int, undoneBy string) (*PatientMerge, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin patient unmerge: %w", err)
+	}
+	defer tx.Rollback()
+
+	m, err := scanPatientMerge(tx.QueryRow("SELECT "+patientMergeColumns+" FROM patient_merges WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("patient merge %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get patient merge: %w", err)
+	}
+	if err := m.checkUndo(time.Now()); err != nil {
+		return nil, err
+	}
+	if _, err := lockPatient(tx, m.TargetHN); err != nil {
+		return nil, err
+	}
+
+	p := m.SourceSnapshot
+	_, err = tx.Exec(`
+		INSERT INTO patients (`+patientRowColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, p.HN, p.FullName, p.Gender, p.Nickname, p.Phone, p.Email, p.Age, p.DateOfBirth, p.Photo, p.CreatedAt, p.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return nil, apperr.Conflict("HN %s has been reused since the merge", m.SourceHN)
+		}
+		return nil, fmt.Errorf("failed to restore patient: %w", err)
+	}
+
+	for _, moved := range m.Relinked {
+		if moved.IDs == nil {
+			_, err = tx.Exec("UPDATE "+moved.Table+" SET patient_hn = $1 WHERE patient_hn = $2", m.SourceHN, m.TargetHN)
+		} else {
+			ids, _ := json.Marshal(moved.IDs)
+			_, err = tx.Exec(`UPDATE `+moved.Table+` SET patient_hn = $1
+				WHERE id IN (SELECT jsonb_array_elements_text($2::jsonb)::int)`, m.SourceHN, ids)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to move %s back: %w", moved.Table, err)
+		}
+	}
+
+	updated, err := scanPatientMerge(tx.QueryRow(`
+		UPDATE patient_merges SET undone_by = $2, undone_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+patientMergeColumns, id, undoneBy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record patient unmerge: %w", err)
+	}
+
+	return updated, tx.Commit()
+}
+
+// GetByID retrieves a patient merge by ID
+func (r *PatientMergeRepository) GetByID(id int) (*PatientMerge, error) {
+	m, err := scanPatientMerge(r.db.conn.QueryRow("SELECT "+patientMergeColumns+" FROM patient_merges WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("patient merge %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get patient merge: %w", err)
+	}
+	return m, nil
+}
+
+// List retrieves merges involving hn as source or target, or all merges when
+// hn is empty, most recent first
+func (r *PatientMergeRepository) List(hn string) ([]PatientMerge, error) {
+	query := `
+		SELECT ` + patientMergeColumns + ` FROM patient_merges
+		WHERE $1 = '' OR source_hn = $1 OR target_hn = $1
+		ORDER BY merged_at DESC, id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, hn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query patient merges: %w", err)
+	}
+	defer rows.Close()
+
+	merges := []PatientMerge{}
+	for rows.Next() {
+		m, err := scanPatientMerge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan patient merge: %w", err)
+		}
+		merges = append(merges, *m)
+	}
+
+	return merges, rows.Err()
+}
+
+// PurgeSnapshots drops the snapshots and moved-row lists of merges whose undo
+// window closed before now, leaving the merge itself on record
+func (r *PatientMergeRepository) PurgeSnapshots(now time.Time) (int, error) {
+	result, err := r.db.conn.Exec(`
+		UPDATE patient_merges SET source_snapshot = NULL, target_snapshot = NULL, relinked = '[]'
+		WHERE undo_until <= $1 AND source_snapshot IS NOT NULL
+	`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge patient merge snapshots: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(purged), nil
+}
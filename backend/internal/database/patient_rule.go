@@ -0,0 +1,128 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// PatientRuleFields are the patient fields a clinic can make required, by
+// their JSON names; fullName is always required
+var PatientRuleFields = []string{"gender", "nickname", "phone", "dateOfBirth", "citizenId", "photo"}
+
+// PatientFieldRule says whether registration and updates must fill in a patient field
+type PatientFieldRule struct {
+	Field     string     `json:"field" db:"field"` // one of PatientRuleFields
+	Required  bool       `json:"required" db:"required"`
+	UpdatedBy *string    `json:"updatedBy,omitempty" db:"updated_by"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" db:"updated_at"` // nil for built-in defaults
+}
+
+// DefaultPatientFieldRules apply to any field the clinic has not configured
+var DefaultPatientFieldRules = []PatientFieldRule{
+	{Field: "gender", Required: true},
+	{Field: "nickname"},
+	{Field: "phone"},
+	{Field: "dateOfBirth"},
+	{Field: "citizenId"},
+	{Field: "photo"},
+}
+
+// PatientFieldRules is the effective rule of every configurable field
+type PatientFieldRules map[string]PatientFieldRule
+
+// NewPatientFieldRules overlays the clinic's configured rules on the defaults
+func NewPatientFieldRules(configured []PatientFieldRule) PatientFieldRules {
+	rules := PatientFieldRules{}
+	for _, rule := range DefaultPatientFieldRules {
+		rules[rule.Field] = rule
+	}
+	for _, rule := range configured {
+		rules[rule.Field] = rule
+	}
+	return rules
+}
+
+// Missing lists the required fields a patient leaves empty, in PatientRuleFields order
+func (r PatientFieldRules) Missing(p *Patient) []string {
+	filled := map[string]bool{
+		"gender":      p.Gender != "",
+		"nickname":    p.Nickname != nil && *p.Nickname != "",
+		"phone":       p.Phone != nil && *p.Phone != "",
+		"dateOfBirth": p.DateOfBirth != nil && *p.DateOfBirth != "",
+		"citizenId":   p.CitizenID != nil && *p.CitizenID != "",
+		"photo":       p.Photo != nil && *p.Photo != "",
+	}
+	missing := []string{}
+	for _, field := range PatientRuleFields {
+		if r[field].Required && !filled[field] {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// PatientRuleRepository handles the clinic's patient field rules
+type PatientRuleRepository struct {
+	db *DB
+}
+
+// NewPatientRuleRepository creates a new patient rule repository
+func NewPatientRuleRepository(db *DB) *PatientRuleRepository {
+	return &PatientRuleRepository{db: db}
+}
+
+// GetAll retrieves the configured rules; defaults are not included
+func (r *PatientRuleRepository) GetAll() ([]PatientFieldRule, error) {
+	rows, err := r.db.conn.Query("SELECT field, required, updated_by, updated_at FROM patient_field_rules ORDER BY field")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query patient field rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []PatientFieldRule{}
+	for rows.Next() {
+		var rule PatientFieldRule
+		if err := rows.Scan(&rule.Field, &rule.Required, &rule.UpdatedBy, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan patient field rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// Set configures whether a field is required, replacing any earlier rule
+func (r *PatientRuleRepository) Set(rule *PatientFieldRule) error {
+	query := `
+		INSERT INTO patient_field_rules (field, required, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (field) DO UPDATE
+		SET required = EXCLUDED.required, updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	if err := r.db.conn.QueryRow(query, rule.Field, rule.Required, rule.UpdatedBy).Scan(&rule.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to set patient field rule: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a configured rule, returning the field to its default
+func (r *PatientRuleRepository) Delete(field string) error {
+	result, err := r.db.conn.Exec("DELETE FROM patient_field_rules WHERE field = $1", field)
+	if err != nil {
+		return fmt.Errorf("failed to delete patient field rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("no rule configured for %s", field)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Payment methods
+const (
+	PaymentCash         = "cash"
+	PaymentCreditCard   = "credit_card"
+	PaymentBankTransfer = "bank_transfer"
+)
+
+// Payment is money received against an issued invoice. An invoice may be paid
+// in parts; it becomes paid when its payments reach the total.
+type Payment struct {
+	ID         int       `json:"id" db:"id"`
+	InvoiceID  int       `json:"invoiceId" db:"invoice_id"`
+	Method     string    `json:"method" db:"method"` // cash, credit_card, bank_transfer
+	Amount     float64   `json:"amount" db:"amount"`
+	Reference  *string   `json:"reference,omitempty" db:"reference"` // card approval code or transfer reference
+	Note       *string   `json:"note,omitempty" db:"note"`
+	ReceivedBy string    `json:"receivedBy" db:"received_by"`
+	PaidAt     time.Time `json:"paidAt" db:"paid_at"` // when the money was received, e.g. the transfer date
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// checkPayable explains why a payment cannot be taken against an invoice
+func checkPayable(inv *Invoice, amount float64) error {
+	switch inv.Status {
+	case InvoiceIssued:
+	case InvoicePaid:
+		return apperr.Conflict("invoice %s is already paid", inv.Number)
+	case InvoiceVoid:
+		return apperr.Conflict("invoice %s has been voided", inv.Number)
+	default:
+		return apperr.Conflict("invoice %s must be issued before it can be paid", inv.Number)
+	}
+	if roundBaht(amount) > inv.Outstanding {
+		return apperr.Conflict("payment of %.2f is more than the %.2f outstanding on %s", amount, inv.Outstanding, inv.Number)
+	}
+	return nil
+}
+
+// SimulatePayment works out how an invoice would stand after a payment, as
+// Record would leave it, without recording anything; for sandbox API keys.
+// The payment gets no ID.
+func SimulatePayment(inv *Invoice, p *Payment) (*Invoice, error) {
+	if err := checkPayable(inv, p.Amount); err != nil {
+		return nil, err
+	}
+	p.CreatedAt = time.Now()
+
+	after := *inv
+	after.AmountPaid = roundBaht(inv.AmountPaid + p.Amount)
+	after.Outstanding = roundBaht(after.Total - after.AmountPaid)
+	if after.Outstanding <= 0 {
+		after.Status = InvoicePaid
+		after.PaidAt = &p.CreatedAt
+	}
+	return &after, nil
+}
+
+// PaymentRepository handles payment database operations
+type PaymentRepository struct {
+	db *DB
+}
+
+// NewPaymentRepository creates a new payment repository
+func NewPaymentRepository(db *DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+const paymentColumns = "id, invoice_id, method, amount, reference, note, received_by, paid_at, created_at"
+
+func scanPayment(row interface{ Scan(...interface{}) error }) (*Payment, error) {
+	var p Payment
+	err := row.Scan(&p.ID, &p.InvoiceID, &p.Method, &p.Amount, &p.Reference, &p.Note, &p.ReceivedBy, &p.PaidAt, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Record takes a payment against an issued invoice, no more than its
+// outstanding balance, and marks the invoice paid once nothing is outstanding.
+// It returns the invoice as it stands after the payment.
+func (r *PaymentRepository) Record(p *Payment) (*Invoice, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin payment: %w", err)
+	}
+	defer tx.Rollback()
+
+	inv, err := scanInvoice(tx.QueryRow("SELECT "+invoiceColumns+" FROM invoices WHERE id = $1 FOR UPDATE", p.InvoiceID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("invoice %d not found", p.InvoiceID)
+		}
+		return nil, fmt.Errorf("failed to lock invoice: %w", err)
+	}
+	if err := checkPayable(inv, p.Amount); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO payments (invoice_id, method, amount, reference, note, received_by, paid_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, p.InvoiceID, p.Method, p.Amount, p.Reference, p.Note, p.ReceivedBy, p.PaidAt).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record payment: %w", err)
+	}
+
+	updated, err := scanInvoice(tx.QueryRow(`
+		UPDATE invoices SET amount_paid = amount_paid + $2, updated_at = CURRENT_TIMESTAMP,
+			status = CASE WHEN amount_paid + $2 >= total THEN 'paid' ELSE status END,
+			paid_at = CASE WHEN amount_paid + $2 >= total THEN CURRENT_TIMESTAMP ELSE paid_at END
+		WHERE id = $1
+		RETURNING `+invoiceColumns, p.InvoiceID, p.Amount))
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply payment: %w", err)
+	}
+
+	return updated, tx.Commit()
+}
+
+// GetByInvoice retrieves an invoice's payments, oldest first
+func (r *PaymentRepository) GetByInvoice(invoiceID int) ([]Payment, error) {
+	rows, err := r.db.conn.Query("SELECT "+paymentColumns+" FROM payments WHERE invoice_id = $1 ORDER BY paid_at, id", invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []Payment{}
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, *p)
+	}
+
+	return payments, rows.Err()
+}
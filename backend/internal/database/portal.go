@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// PortalChallenge is a one-time code texted to a patient signing in to the
+// patient portal. Only a hash of the code is kept. A sign-in naming no patient
+// with that phone number still gets a challenge, with no patient, so callers
+// cannot tell which patients exist; it never verifies. Every challenge keeps
+// the HN asked for and the client that asked, which sign-ins are limited by.
+type PortalChallenge struct {
+	ID          string     `json:"challengeId" db:"id"` // random, so challenges cannot be guessed
+	PatientHN   *string    `json:"-" db:"patient_hn"`
+	RequestedHN string     `json:"-" db:"requested_hn"`
+	ClientIP    string     `json:"-" db:"client_ip"`
+	CodeHash    string     `json:"-" db:"code_hash"`
+	Attempts    int        `json:"-" db:"attempts"`
+	ExpiresAt   time.Time  `json:"expiresAt" db:"expires_at"`
+	UsedAt      *time.Time `json:"-" db:"used_at"`
+	CreatedAt   time.Time  `json:"-" db:"created_at"`
+}
+
+// PortalSession is a patient signed in to the portal. Only a hash of its
+// bearer token is kept; the token itself is shown once, at sign-in.
+type PortalSession struct {
+	ID        int        `json:"id" db:"id"`
+	PatientHN string     `json:"patientHn" db:"patient_hn"`
+	TokenHash string     `json:"-" db:"token_hash"` // hex SHA-256 of the token
+	ExpiresAt time.Time  `json:"expiresAt" db:"expires_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+}
+
+// PortalRepository handles portal sign-in challenges and sessions
+type PortalRepository struct {
+	db *DB
+}
+
+// NewPortalRepository creates a new portal repository
+func NewPortalRepository(db *DB) *PortalRepository {
+	return &PortalRepository{db: db}
+}
+
+// CreateChallenge stores a new sign-in challenge
+func (r *PortalRepository) CreateChallenge(c *PortalChallenge) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO portal_challenges (id, patient_hn, requested_hn, client_ip, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`, c.ID, c.PatientHN, c.RequestedHN, c.ClientIP, c.CodeHash, c.ExpiresAt).Scan(&c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create portal challenge: %w", err)
+	}
+	return nil
+}
+
+// CountChallenges counts the challenges asked for an HN, whether or not it
+// matched a patient, and the challenges asked by a client, since a time
+func (r *PortalRepository) CountChallenges(requestedHN, clientIP string, since time.Time) (int, int, error) {
+	var forHN, byClient int
+	err := r.db.conn.QueryRow(`
+		SELECT COUNT(*) FILTER (WHERE requested_hn = $1), COUNT(*) FILTER (WHERE client_ip = $2)
+		FROM portal_challenges WHERE (requested_hn = $1 OR client_ip = $2) AND created_at >= $3
+	`, requestedHN, clientIP, since).Scan(&forHN, &byClient)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count portal challenges: %w", err)
+	}
+	return forHN, byClient, nil
+}
+
+// UseChallenge checks a code against a challenge, counting the attempt. A
+// matching code uses the challenge up. Wrong codes are a validation error;
+// a challenge that has expired, been used or had maxAttempts tries is a
+// conflict, as is one with no patient.
+func (r *PortalRepository) UseChallenge(id, codeHash string, maxAttempts int) (*PortalChallenge, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin portal sign-in: %w", err)
+	}
+	defer tx.Rollback()
+
+	var c PortalChallenge
+	err = tx.QueryRow(`
+		SELECT id, patient_hn, code_hash, attempts, expires_at, used_at, created_at
+		FROM portal_challenges WHERE id = $1 FOR UPDATE
+	`, id).Scan(&c.ID, &c.PatientHN, &c.CodeHash, &c.Attempts, &c.ExpiresAt, &c.UsedAt, &c.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("portal challenge not found")
+		}
+		return nil, fmt.Errorf("failed to get portal challenge: %w", err)
+	}
+	if c.UsedAt != nil || c.Attempts >= maxAttempts || time.Now().After(c.ExpiresAt) {
+		return nil, apperr.Conflict("the code has expired; request a new one")
+	}
+
+	c.Attempts++
+	matched := c.PatientHN != nil && c.CodeHash == codeHash
+	if matched {
+		now := time.Now()
+		c.UsedAt = &now
+	}
+	if _, err := tx.Exec("UPDATE portal_challenges SET attempts = $2, used_at = $3 WHERE id = $1", c.ID, c.Attempts, c.UsedAt); err != nil {
+		return nil, fmt.Errorf("failed to update portal challenge: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit portal sign-in: %w", err)
+	}
+	if !matched {
+		return nil, apperr.Validation("incorrect code")
+	}
+	return &c, nil
+}
+
+// CreateSession stores a new portal session
+func (r *PortalRepository) CreateSession(s *PortalSession) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO portal_sessions (patient_hn, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, s.PatientHN, s.TokenHash, s.ExpiresAt).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create portal session: %w", err)
+	}
+	return nil
+}
+
+// GetSession finds a session by its token hash, including revoked and expired ones
+func (r *PortalRepository) GetSession(tokenHash string) (*PortalSession, error) {
+	var s PortalSession
+	err := r.db.conn.QueryRow(`
+		SELECT id, patient_hn, token_hash, expires_at, created_at, revoked_at
+		FROM portal_sessions WHERE token_hash = $1
+	`, tokenHash).Scan(&s.ID, &s.PatientHN, &s.TokenHash, &s.ExpiresAt, &s.CreatedAt, &s.RevokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("portal session not found")
+		}
+		return nil, fmt.Errorf("failed to get portal session: %w", err)
+	}
+	return &s, nil
+}
+
+// RevokeSession signs a session out
+func (r *PortalRepository) RevokeSession(id int) error {
+	result, err := r.db.conn.Exec("UPDATE portal_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke portal session: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return apperr.NotFound("portal session %d not found", id)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Prescription dispensing states
+const (
+	PrescriptionPending   = "pending"   // waiting at the pharmacy
+	PrescriptionDispensed = "dispensed" // handed to the patient
+)
+
+// DispenseStatuses are the states a prescription moves through at the pharmacy
+var DispenseStatuses = []string{PrescriptionPending, PrescriptionDispensed}
+
+// Prescription is the drugs a doctor ordered for a patient during a visit
+type Prescription struct {
+	ID             int            `json:"id" db:"id"`
+	VisitID        int            `json:"visitId" db:"visit_id"`
+	PatientHN      string         `json:"patientHn" db:"patient_hn"`
+	DoctorID       int            `json:"doctorId" db:"doctor_id"`
+	DoctorName     string         `json:"doctorName" db:"doctor_name"`
+	LicenseNumber  string         `json:"licenseNumber" db:"license_number"` // prescriber's license at the time of prescribing
+	Items          []DrugTemplate `json:"items" db:"items"`                  // stored as JSONB
+	Notes          *string        `json:"notes,omitempty" db:"notes"`
+	DispenseStatus string         `json:"dispenseStatus" db:"dispense_status"`     // pending, dispensed
+	DispensedBy    *string        `json:"dispensedBy,omitempty" db:"dispensed_by"` // the pharmacist who handed it over
+	DispensedAt    *time.Time     `json:"dispensedAt,omitempty" db:"dispensed_at"`
+	CreatedAt      time.Time      `json:"createdAt" db:"created_at"`
+}
+
+// PrescriptionRepository handles prescription database operations
+type PrescriptionRepository struct {
+	db *DB
+}
+
+// NewPrescriptionRepository creates a new prescription repository
+func NewPrescriptionRepository(db *DB) *PrescriptionRepository {
+	return &PrescriptionRepository{db: db}
+}
+
+const prescriptionColumns = `id, visit_id, patient_hn, doctor_id, doctor_name, license_number, items, notes,
+	dispense_status, dispensed_by, dispensed_at, created_at`
+
+func scanPrescription(row interface{ Scan(...interface{}) error }) (*Prescription, error) {
+	var p Prescription
+	var items []byte
+	err := row.Scan(&p.ID, &p.VisitID, &p.PatientHN, &p.DoctorID, &p.DoctorName, &p.LicenseNumber, &items, &p.Notes,
+		&p.DispenseStatus, &p.DispensedBy, &p.DispensedAt, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(items, &p.Items); err != nil {
+		return nil, fmt.Errorf("invalid items for prescription %d: %w", p.ID, err)
+	}
+	return &p, nil
+}
+
+// Create stores a new prescription
+func (r *PrescriptionRepository) Create(p *Prescription) error {
+	items, err := json.Marshal(p.Items)
+	if err != nil {
+		return fmt.Errorf("failed to encode prescription items: %w", err)
+	}
+
+	query := `
+		INSERT INTO prescriptions (visit_id, patient_hn, doctor_id, doctor_name, license_number, items, notes, dispense_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'pending')
+		RETURNING id, dispense_status, created_at
+	`
+
+	err = r.db.conn.QueryRow(query, p.VisitID, p.PatientHN, p.DoctorID, p.DoctorName, p.LicenseNumber, items, p.Notes).
+		Scan(&p.ID, &p.DispenseStatus, &p.CreatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("prescription refers to a visit or doctor that does not exist")
+		}
+		return fmt.Errorf("failed to create prescription: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a prescription by ID, from the archive if its visit has been archived
+func (r *PrescriptionRepository) GetByID(id int) (*Prescription, error) {
+	p, err := scanPrescription(r.db.conn.QueryRow("SELECT "+prescriptionColumns+" FROM prescriptions WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		p, err = scanPrescription(r.db.conn.QueryRow("SELECT "+prescriptionColumns+" FROM "+archived("prescriptions")+" WHERE id = $1", id))
+		err = notArchived(err)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("prescription %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get prescription: %w", err)
+	}
+	return p, nil
+}
+
+// GetByVisit retrieves a visit's prescriptions, oldest first. A visit is
+// archived whole, so an archived visit's prescriptions are all in the archive.
+func (r *PrescriptionRepository) GetByVisit(visitID int) ([]Prescription, error) {
+	const where = " WHERE visit_id = $1 ORDER BY created_at"
+	prescriptions, err := r.query("SELECT "+prescriptionColumns+" FROM prescriptions"+where, visitID)
+	if err != nil || len(prescriptions) > 0 {
+		return prescriptions, err
+	}
+	return r.queryArchive("SELECT "+prescriptionColumns+" FROM "+archived("prescriptions")+where, visitID)
+}
+
+// GetByPatient retrieves a patient's prescriptions, most recent first; archived
+// ones, being older, follow the rest
+func (r *PrescriptionRepository) GetByPatient(hn string) ([]Prescription, error) {
+	const where = " WHERE patient_hn = $1 ORDER BY created_at DESC"
+	prescriptions, err := r.query("SELECT "+prescriptionColumns+" FROM prescriptions"+where, hn)
+	if err != nil {
+		return nil, err
+	}
+	older, err := r.queryArchive("SELECT "+prescriptionColumns+" FROM "+archived("prescriptions")+where, hn)
+	if err != nil {
+		return nil, err
+	}
+	return append(prescriptions, older...), nil
+}
+
+// GetWrittenBetween retrieves the prescriptions written from from up to to,
+// oldest first; periods reported on are recent, so the archive is not read
+func (r *PrescriptionRepository) GetWrittenBetween(from, to time.Time) ([]Prescription, error) {
+	return r.query("SELECT "+prescriptionColumns+" FROM prescriptions WHERE created_at >= $1 AND created_at < $2 ORDER BY created_at", from, to)
+}
+
+// GetPending retrieves the prescriptions waiting at the pharmacy, oldest
+// first; archived visits were closed long ago, so the archive is not read
+func (r *PrescriptionRepository) GetPending() ([]Prescription, error) {
+	return r.query("SELECT " + prescriptionColumns + " FROM prescriptions WHERE dispense_status = 'pending' ORDER BY created_at, id")
+}
+
+// GetDispensedBetween retrieves the prescriptions dispensed from from up to to, most recent first
+func (r *PrescriptionRepository) GetDispensedBetween(from, to time.Time) ([]Prescription, error) {
+	return r.query(`
+		SELECT `+prescriptionColumns+` FROM prescriptions
+		WHERE dispense_status = 'dispensed' AND dispensed_at >= $1 AND dispensed_at < $2
+		ORDER BY dispensed_at DESC, id DESC
+	`, from, to)
+}
+
+// MarkDispensed records that a pending prescription was handed over, and by whom
+func (r *PrescriptionRepository) MarkDispensed(id int, by string) (*Prescription, error) {
+	p, err := scanPrescription(r.db.conn.QueryRow(`
+		UPDATE prescriptions SET dispense_status = 'dispensed', dispensed_by = $2, dispensed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND dispense_status = 'pending'
+		RETURNING `+prescriptionColumns, id, by))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("prescription %d is not pending dispensing", id)
+		}
+		return nil, fmt.Errorf("failed to mark prescription dispensed: %w", err)
+	}
+	return p, nil
+}
+
+// UndoDispensed puts a prescription back in the pharmacy queue, for when its
+// stock could not be taken after it was marked dispensed
+func (r *PrescriptionRepository) UndoDispensed(id int) error {
+	_, err := r.db.conn.Exec(`
+		UPDATE prescriptions SET dispense_status = 'pending', dispensed_by = NULL, dispensed_at = NULL
+		WHERE id = $1 AND dispense_status = 'dispensed'
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to undo prescription dispensing: %w", err)
+	}
+	return nil
+}
+
+// queryArchive runs query against the archive, which may not have been created yet
+func (r *PrescriptionRepository) queryArchive(query string, arg interface{}) ([]Prescription, error) {
+	prescriptions, err := r.query(query, arg)
+	if undefinedTable(err) {
+		return []Prescription{}, nil
+	}
+	return prescriptions, err
+}
+
+func (r *PrescriptionRepository) query(query string, args ...interface{}) ([]Prescription, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prescriptions: %w", err)
+	}
+	defer rows.Close()
+
+	prescriptions := []Prescription{}
+	for rows.Next() {
+		p, err := scanPrescription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prescription: %w", err)
+		}
+		prescriptions = append(prescriptions, *p)
+	}
+
+	return prescriptions, rows.Err()
+}
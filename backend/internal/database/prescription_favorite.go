@@ -0,0 +1,277 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// DrugTemplate is a pre-filled prescription line a doctor reuses
+type DrugTemplate struct {
+	DrugID       *int     `json:"drugId,omitempty" db:"drug_id"` // catalog item, when known
+	DrugName     string   `json:"drugName" db:"drug_name"`
+	Dose         string   `json:"dose" db:"dose"`           // e.g. "500 mg"
+	Frequency    string   `json:"frequency" db:"frequency"` // e.g. "วันละ 3 ครั้ง หลังอาหาร"
+	DurationDays *int     `json:"durationDays,omitempty" db:"duration_days"`
+	Quantity     *float64 `json:"quantity,omitempty" db:"quantity"`
+	Instructions *string  `json:"instructions,omitempty" db:"instructions"`
+}
+
+// DrugFavorite is a single drug a doctor prescribes often
+type DrugFavorite struct {
+	ID       int `json:"id" db:"id"`
+	DoctorID int `json:"doctorId" db:"doctor_id"`
+	DrugTemplate
+	UsageCount int        `json:"usageCount" db:"usage_count"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// PrescriptionSet is a named group of drugs prescribed together, e.g. "ชุดไข้หวัด"
+type PrescriptionSet struct {
+	ID         int            `json:"id" db:"id"`
+	DoctorID   int            `json:"doctorId" db:"doctor_id"`
+	Name       string         `json:"name" db:"name"`
+	Items      []DrugTemplate `json:"items" db:"items"` // stored as JSONB
+	UsageCount int            `json:"usageCount" db:"usage_count"`
+	LastUsedAt *time.Time     `json:"lastUsedAt,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+// PrescriptionFavoriteRepository handles doctor drug favorites and prescription set database operations
+type PrescriptionFavoriteRepository struct {
+	db *DB
+}
+
+// NewPrescriptionFavoriteRepository creates a new prescription favorite repository
+func NewPrescriptionFavoriteRepository(db *DB) *PrescriptionFavoriteRepository {
+	return &PrescriptionFavoriteRepository{db: db}
+}
+
+const drugFavoriteColumns = `id, doctor_id, drug_id, drug_name, dose, frequency, duration_days, quantity,
+	instructions, usage_count, last_used_at, created_at`
+
+func scanDrugFavorite(row interface{ Scan(...interface{}) error }) (*DrugFavorite, error) {
+	var f DrugFavorite
+	err := row.Scan(&f.ID, &f.DoctorID, &f.DrugID, &f.DrugName, &f.Dose, &f.Frequency, &f.DurationDays,
+		&f.Quantity, &f.Instructions, &f.UsageCount, &f.LastUsedAt, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// CreateFavorite stores a new drug favorite
+func (r *PrescriptionFavoriteRepository) CreateFavorite(f *DrugFavorite) error {
+	query := `
+		INSERT INTO drug_favorites (doctor_id, drug_id, drug_name, dose, frequency, duration_days, quantity, instructions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, usage_count, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, f.DoctorID, f.DrugID, f.DrugName, f.Dose, f.Frequency, f.DurationDays,
+		f.Quantity, f.Instructions).Scan(&f.ID, &f.UsageCount, &f.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create drug favorite: %w", err)
+	}
+
+	return nil
+}
+
+// GetFavorites retrieves a doctor's favorites, most used first
+func (r *PrescriptionFavoriteRepository) GetFavorites(doctorID int) ([]DrugFavorite, error) {
+	query := "SELECT " + drugFavoriteColumns + `
+		FROM drug_favorites WHERE doctor_id = $1
+		ORDER BY usage_count DESC, drug_name`
+
+	rows, err := r.db.conn.Query(query, doctorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query drug favorites: %w", err)
+	}
+	defer rows.Close()
+
+	var favorites []DrugFavorite
+	for rows.Next() {
+		f, err := scanDrugFavorite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan drug favorite: %w", err)
+		}
+		favorites = append(favorites, *f)
+	}
+
+	return favorites, nil
+}
+
+// UseFavorite bumps a favorite's usage count and returns it
+func (r *PrescriptionFavoriteRepository) UseFavorite(doctorID, id int) (*DrugFavorite, error) {
+	query := `
+		UPDATE drug_favorites SET usage_count = usage_count + 1, last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND doctor_id = $2
+		RETURNING ` + drugFavoriteColumns
+
+	f, err := scanDrugFavorite(r.db.conn.QueryRow(query, id, doctorID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("drug favorite %d not found for doctor %d", id, doctorID)
+		}
+		return nil, fmt.Errorf("failed to use drug favorite: %w", err)
+	}
+
+	return f, nil
+}
+
+// DeleteFavorite removes a doctor's favorite
+func (r *PrescriptionFavoriteRepository) DeleteFavorite(doctorID, id int) error {
+	return r.deleteOwned("drug_favorites", "drug favorite", doctorID, id)
+}
+
+const prescriptionSetColumns = "id, doctor_id, name, items, usage_count, last_used_at, created_at, updated_at"
+
+func scanPrescriptionSet(row interface{ Scan(...interface{}) error }) (*PrescriptionSet, error) {
+	var s PrescriptionSet
+	var items []byte
+	err := row.Scan(&s.ID, &s.DoctorID, &s.Name, &items, &s.UsageCount, &s.LastUsedAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(items, &s.Items); err != nil {
+		return nil, fmt.Errorf("invalid items for prescription set %d: %w", s.ID, err)
+	}
+	return &s, nil
+}
+
+// CreateSet stores a new prescription set
+func (r *PrescriptionFavoriteRepository) CreateSet(s *PrescriptionSet) error {
+	items, err := json.Marshal(s.Items)
+	if err != nil {
+		return fmt.Errorf("failed to encode prescription set items: %w", err)
+	}
+
+	query := `
+		INSERT INTO prescription_sets (doctor_id, name, items)
+		VALUES ($1, $2, $3)
+		RETURNING id, usage_count, created_at, updated_at
+	`
+
+	err = r.db.conn.QueryRow(query, s.DoctorID, s.Name, items).Scan(&s.ID, &s.UsageCount, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("prescription set %s already exists", s.Name)
+		}
+		return fmt.Errorf("failed to create prescription set: %w", err)
+	}
+
+	return nil
+}
+
+// GetSet retrieves one of a doctor's prescription sets
+func (r *PrescriptionFavoriteRepository) GetSet(doctorID, id int) (*PrescriptionSet, error) {
+	query := "SELECT " + prescriptionSetColumns + " FROM prescription_sets WHERE id = $1 AND doctor_id = $2"
+
+	s, err := scanPrescriptionSet(r.db.conn.QueryRow(query, id, doctorID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("prescription set %d not found for doctor %d", id, doctorID)
+		}
+		return nil, fmt.Errorf("failed to get prescription set: %w", err)
+	}
+
+	return s, nil
+}
+
+// GetSets retrieves a doctor's prescription sets, most used first
+func (r *PrescriptionFavoriteRepository) GetSets(doctorID int) ([]PrescriptionSet, error) {
+	query := "SELECT " + prescriptionSetColumns + `
+		FROM prescription_sets WHERE doctor_id = $1
+		ORDER BY usage_count DESC, name`
+
+	rows, err := r.db.conn.Query(query, doctorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prescription sets: %w", err)
+	}
+	defer rows.Close()
+
+	var sets []PrescriptionSet
+	for rows.Next() {
+		s, err := scanPrescriptionSet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prescription set: %w", err)
+		}
+		sets = append(sets, *s)
+	}
+
+	return sets, nil
+}
+
+// UpdateSet replaces a prescription set's name and items
+func (r *PrescriptionFavoriteRepository) UpdateSet(s *PrescriptionSet) error {
+	items, err := json.Marshal(s.Items)
+	if err != nil {
+		return fmt.Errorf("failed to encode prescription set items: %w", err)
+	}
+
+	query := `
+		UPDATE prescription_sets SET name = $1, items = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND doctor_id = $4
+		RETURNING usage_count, last_used_at, created_at, updated_at
+	`
+
+	err = r.db.conn.QueryRow(query, s.Name, items, s.ID, s.DoctorID).
+		Scan(&s.UsageCount, &s.LastUsedAt, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("prescription set %d not found for doctor %d", s.ID, s.DoctorID)
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("prescription set %s already exists", s.Name)
+		}
+		return fmt.Errorf("failed to update prescription set: %w", err)
+	}
+
+	return nil
+}
+
+// UseSet bumps a set's usage count and returns it
+func (r *PrescriptionFavoriteRepository) UseSet(doctorID, id int) (*PrescriptionSet, error) {
+	query := `
+		UPDATE prescription_sets SET usage_count = usage_count + 1, last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND doctor_id = $2
+		RETURNING ` + prescriptionSetColumns
+
+	s, err := scanPrescriptionSet(r.db.conn.QueryRow(query, id, doctorID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("prescription set %d not found for doctor %d", id, doctorID)
+		}
+		return nil, fmt.Errorf("failed to use prescription set: %w", err)
+	}
+
+	return s, nil
+}
+
+// DeleteSet removes a doctor's prescription set
+func (r *PrescriptionFavoriteRepository) DeleteSet(doctorID, id int) error {
+	return r.deleteOwned("prescription_sets", "prescription set", doctorID, id)
+}
+
+func (r *PrescriptionFavoriteRepository) deleteOwned(table, what string, doctorID, id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM "+table+" WHERE id = $1 AND doctor_id = $2", id, doctorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", what, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("%s %d not found for doctor %d", what, id, doctorID)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Problem statuses; a condition that has cleared up stays on the list as resolved
+const (
+	ProblemActive   = "active"
+	ProblemResolved = "resolved"
+)
+
+// Problem is an ongoing or past condition on a patient's problem list, such
+// as diabetes or hypertension, kept so it need not be retyped at every visit
+type Problem struct {
+	ID           int       `json:"id" db:"id"`
+	PatientHN    string    `json:"patientHn" db:"patient_hn"`
+	Condition    string    `json:"condition" db:"condition"`                  // e.g. "Type 2 diabetes", "ความดันโลหิตสูง"
+	OnsetDate    *string   `json:"onsetDate,omitempty" db:"onset_date"`       // YYYY-MM-DD, when known
+	Status       string    `json:"status" db:"status"`                        // active or resolved
+	ResolvedDate *string   `json:"resolvedDate,omitempty" db:"resolved_date"` // YYYY-MM-DD, set when resolved
+	Notes        *string   `json:"notes,omitempty" db:"notes"`                // e.g. current treatment
+	RecordedBy   string    `json:"recordedBy" db:"recorded_by"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// ProblemRepository handles patient problem list database operations
+type ProblemRepository struct {
+	db *DB
+}
+
+// NewProblemRepository creates a new problem repository
+func NewProblemRepository(db *DB) *ProblemRepository {
+	return &ProblemRepository{db: db}
+}
+
+const problemColumns = `id, patient_hn, condition, to_char(onset_date, 'YYYY-MM-DD'), status, to_char(resolved_date, 'YYYY-MM-DD'),
+	notes, recorded_by, created_at, updated_at`
+
+func scanProblem(row interface{ Scan(...interface{}) error }) (*Problem, error) {
+	var p Problem
+	err := row.Scan(&p.ID, &p.PatientHN, &p.Condition, &p.OnsetDate, &p.Status, &p.ResolvedDate,
+		&p.Notes, &p.RecordedBy, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Create adds a condition to a patient's problem list
+func (r *ProblemRepository) Create(p *Problem) error {
+	query := `
+		INSERT INTO patient_problems (patient_hn, condition, onset_date, status, resolved_date, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, p.PatientHN, p.Condition, p.OnsetDate, p.Status, p.ResolvedDate, p.Notes, p.RecordedBy).
+		Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create problem: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a problem by ID
+func (r *ProblemRepository) GetByID(id int) (*Problem, error) {
+	p, err := scanProblem(r.db.conn.QueryRow("SELECT "+problemColumns+" FROM patient_problems WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("problem %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+	return p, nil
+}
+
+// GetByPatient retrieves a patient's problem list, active conditions first
+// and then by onset; activeOnly leaves out resolved ones
+func (r *ProblemRepository) GetByPatient(hn string, activeOnly bool) ([]Problem, error) {
+	query := `
+		SELECT ` + problemColumns + ` FROM patient_problems
+		WHERE patient_hn = $1 AND (NOT $2 OR status = 'active')
+		ORDER BY status, onset_date NULLS LAST, condition
+	`
+
+	rows, err := r.db.conn.Query(query, hn, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query problems: %w", err)
+	}
+	defer rows.Close()
+
+	problems := []Problem{}
+	for rows.Next() {
+		p, err := scanProblem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan problem: %w", err)
+		}
+		problems = append(problems, *p)
+	}
+
+	return problems, rows.Err()
+}
+
+// Update replaces a problem's details and status; the patient it belongs to does not change
+func (r *ProblemRepository) Update(p *Problem) error {
+	updated, err := scanProblem(r.db.conn.QueryRow(`
+		UPDATE patient_problems SET condition = $2, onset_date = $3, status = $4, resolved_date = $5, notes = $6,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+problemColumns, p.ID, p.Condition, p.OnsetDate, p.Status, p.ResolvedDate, p.Notes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("problem %d not found", p.ID)
+		}
+		return fmt.Errorf("failed to update problem: %w", err)
+	}
+	*p = *updated
+
+	return nil
+}
+
+// Delete removes a problem entered by mistake; conditions that have cleared
+// up should be marked resolved instead
+func (r *ProblemRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM patient_problems WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete problem: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("problem %d not found", id)
+	}
+
+	return nil
+}
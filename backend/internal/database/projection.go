@@ -0,0 +1,103 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Projection maps a model's JSON field names to its columns, generated from
+// the struct tags, so list endpoints can select only the fields a client asks
+// for with ?fields=, e.g. the queue display and autocomplete widgets that do
+// not need patient photos
+type Projection struct {
+	key    string // always selected, e.g. hn
+	fields map[string]projectedField
+	names  []string // in struct order
+}
+
+type projectedField struct {
+	column string
+	index  []int
+}
+
+// PatientFields projects patients; hn is always included
+var PatientFields = newProjection(Patient{}, "hn")
+
+// newProjection builds the projection of model's fields that have both a JSON
+// name and a column; fields tagged db:"-" are filled in elsewhere and left out
+func newProjection(model interface{}, key string) *Projection {
+	p := &Projection{key: key, fields: map[string]projectedField{}}
+	t := reflect.TypeOf(model)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		column := f.Tag.Get("db")
+		if name == "" || name == "-" || column == "" || column == "-" {
+			continue
+		}
+		p.fields[name] = projectedField{column: column, index: f.Index}
+		p.names = append(p.names, name)
+	}
+	if _, ok := p.fields[key]; !ok {
+		panic("projection key " + key + " is not a field of " + t.Name())
+	}
+	return p
+}
+
+// Fields parses a comma-separated ?fields= list into the fields to select, key
+// first and without duplicates. An empty list means every field and returns nil.
+func (p *Projection) Fields(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	fields := []string{p.key}
+	seen := map[string]bool{p.key: true}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := p.fields[name]; !ok {
+			return nil, apperr.Validation("unknown field %q; fields are %s", name, strings.Join(p.names, ", "))
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// columns is the SELECT list for fields
+func (p *Projection) columns(fields []string) string {
+	columns := make([]string, len(fields))
+	for i, name := range fields {
+		columns[i] = p.fields[name].column
+	}
+	return strings.Join(columns, ", ")
+}
+
+// targets points Scan at the struct fields of model (a pointer) in fields order
+func (p *Projection) targets(model interface{}, fields []string) []interface{} {
+	v := reflect.ValueOf(model).Elem()
+	targets := make([]interface{}, len(fields))
+	for i, name := range fields {
+		targets[i] = v.FieldByIndex(p.fields[name].index).Addr().Interface()
+	}
+	return targets
+}
+
+// Project returns fields of model (a struct or pointer to one) keyed by their
+// JSON names; unset optional fields are left out, as the full model omits them
+func (p *Projection) Project(model interface{}, fields []string) map[string]interface{} {
+	v := reflect.Indirect(reflect.ValueOf(model))
+	projected := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		field := v.FieldByIndex(p.fields[name].index)
+		if field.Kind() == reflect.Ptr && field.IsNil() {
+			continue
+		}
+		projected[name] = field.Interface()
+	}
+	return projected
+}
@@ -0,0 +1,245 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Questionnaire request states
+const (
+	QuestionnairePending   = "pending" // created, link not yet delivered
+	QuestionnaireSent      = "sent"
+	QuestionnaireCompleted = "completed"
+)
+
+// Questionnaire timing relative to the visit
+const (
+	QuestionnairePreVisit  = "pre_visit"
+	QuestionnairePostVisit = "post_visit"
+)
+
+// QuestionnaireRequest is a patient-reported outcome questionnaire sent to a patient and its scored result
+type QuestionnaireRequest struct {
+	ID             int        `json:"id" db:"id"`
+	PatientHN      string     `json:"patientHn" db:"patient_hn"`
+	VisitID        *int       `json:"visitId,omitempty" db:"visit_id"`
+	Instrument     string     `json:"instrument" db:"instrument"` // phq9, gad7, pain_nrs
+	Timing         string     `json:"timing" db:"timing"`         // pre_visit/post_visit
+	Token          string     `json:"-" db:"token"`
+	Channel        *string    `json:"channel,omitempty" db:"channel"`
+	Status         string     `json:"status" db:"status"` // pending/sent/completed
+	ExpiresAt      time.Time  `json:"expiresAt" db:"expires_at"`
+	SentAt         *time.Time `json:"sentAt,omitempty" db:"sent_at"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty" db:"completed_at"`
+	Answers        []int      `json:"answers,omitempty" db:"answers"` // stored comma-separated
+	Score          *int       `json:"score,omitempty" db:"score"`
+	Severity       *string    `json:"severity,omitempty" db:"severity"`
+	HighRisk       bool       `json:"highRisk" db:"high_risk"`
+	RiskReasons    *string    `json:"riskReasons,omitempty" db:"risk_reasons"`
+	AcknowledgedBy *string    `json:"acknowledgedBy,omitempty" db:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt,omitempty" db:"acknowledged_at"`
+	CreatedBy      string     `json:"createdBy" db:"created_by"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// QuestionnaireResult is the scored outcome recorded when a patient submits
+type QuestionnaireResult struct {
+	Answers     []int
+	Score       int
+	Severity    string
+	HighRisk    bool
+	RiskReasons string
+}
+
+// QuestionnaireRepository handles patient-reported outcome database operations
+type QuestionnaireRepository struct {
+	db *DB
+}
+
+// NewQuestionnaireRepository creates a new questionnaire repository
+func NewQuestionnaireRepository(db *DB) *QuestionnaireRepository {
+	return &QuestionnaireRepository{db: db}
+}
+
+const questionnaireColumns = `id, patient_hn, visit_id, instrument, timing, token, channel, status, expires_at,
+	sent_at, completed_at, answers, score, severity, high_risk, risk_reasons, acknowledged_by, acknowledged_at,
+	created_by, created_at`
+
+func scanQuestionnaire(row interface{ Scan(...interface{}) error }) (*QuestionnaireRequest, error) {
+	var q QuestionnaireRequest
+	var answers string
+	err := row.Scan(&q.ID, &q.PatientHN, &q.VisitID, &q.Instrument, &q.Timing, &q.Token, &q.Channel, &q.Status,
+		&q.ExpiresAt, &q.SentAt, &q.CompletedAt, &answers, &q.Score, &q.Severity, &q.HighRisk, &q.RiskReasons,
+		&q.AcknowledgedBy, &q.AcknowledgedAt, &q.CreatedBy, &q.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if answers != "" {
+		for _, a := range strings.Split(answers, ",") {
+			n, err := strconv.Atoi(a)
+			if err != nil {
+				return nil, fmt.Errorf("invalid stored answer %q: %w", a, err)
+			}
+			q.Answers = append(q.Answers, n)
+		}
+	}
+	return &q, nil
+}
+
+func joinAnswers(answers []int) string {
+	parts := make([]string, len(answers))
+	for i, a := range answers {
+		parts[i] = strconv.Itoa(a)
+	}
+	return strings.Join(parts, ",")
+}
+
+// CreateRequest stores a new questionnaire request
+func (r *QuestionnaireRepository) CreateRequest(q *QuestionnaireRequest) error {
+	query := `
+		INSERT INTO questionnaire_requests (patient_hn, visit_id, instrument, timing, token, status, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, q.PatientHN, q.VisitID, q.Instrument, q.Timing, q.Token, q.Status,
+		q.ExpiresAt, q.CreatedBy).Scan(&q.ID, &q.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create questionnaire request: %w", err)
+	}
+
+	return nil
+}
+
+func (r *QuestionnaireRepository) getOne(where string, arg interface{}, notFound error) (*QuestionnaireRequest, error) {
+	q, err := scanQuestionnaire(r.db.conn.QueryRow("SELECT "+questionnaireColumns+" FROM questionnaire_requests WHERE "+where, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound
+		}
+		return nil, fmt.Errorf("failed to get questionnaire request: %w", err)
+	}
+	return q, nil
+}
+
+// GetRequest retrieves a questionnaire request by ID
+func (r *QuestionnaireRepository) GetRequest(id int) (*QuestionnaireRequest, error) {
+	return r.getOne("id = $1", id, apperr.NotFound("questionnaire request %d not found", id))
+}
+
+// GetRequestByToken retrieves a questionnaire request by its patient link token
+func (r *QuestionnaireRepository) GetRequestByToken(token string) (*QuestionnaireRequest, error) {
+	return r.getOne("token = $1", token, apperr.NotFound("questionnaire link not found"))
+}
+
+func (r *QuestionnaireRepository) list(query string, args ...interface{}) ([]QuestionnaireRequest, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query questionnaire requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []QuestionnaireRequest
+	for rows.Next() {
+		q, err := scanQuestionnaire(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan questionnaire request: %w", err)
+		}
+		requests = append(requests, *q)
+	}
+
+	return requests, nil
+}
+
+// GetRequestsByPatient retrieves a patient's questionnaires, newest first
+func (r *QuestionnaireRepository) GetRequestsByPatient(hn string) ([]QuestionnaireRequest, error) {
+	return r.list("SELECT "+questionnaireColumns+" FROM questionnaire_requests WHERE patient_hn = $1 ORDER BY created_at DESC, id DESC", hn)
+}
+
+// GetRequestsByVisit retrieves the questionnaires attached to a visit
+func (r *QuestionnaireRepository) GetRequestsByVisit(visitID int) ([]QuestionnaireRequest, error) {
+	return r.list("SELECT "+questionnaireColumns+" FROM questionnaire_requests WHERE visit_id = $1 ORDER BY created_at, id", visitID)
+}
+
+// GetOpenAlerts retrieves high-risk results no clinician has acknowledged yet, oldest first
+func (r *QuestionnaireRepository) GetOpenAlerts() ([]QuestionnaireRequest, error) {
+	return r.list("SELECT " + questionnaireColumns + " FROM questionnaire_requests WHERE high_risk AND acknowledged_at IS NULL ORDER BY completed_at, id")
+}
+
+// MarkSent records that the questionnaire link was delivered to the patient
+func (r *QuestionnaireRepository) MarkSent(id int, channel string) error {
+	result, err := r.db.conn.Exec(`
+		UPDATE questionnaire_requests SET status = 'sent', channel = $1, sent_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status <> 'completed'
+	`, channel, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark questionnaire sent: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Conflict("questionnaire request %d is not open", id)
+	}
+
+	return nil
+}
+
+// Complete records the patient's answers and score. A questionnaire can only be completed once.
+func (r *QuestionnaireRepository) Complete(id int, result QuestionnaireResult) error {
+	var riskReasons *string
+	if result.RiskReasons != "" {
+		riskReasons = &result.RiskReasons
+	}
+
+	res, err := r.db.conn.Exec(`
+		UPDATE questionnaire_requests
+		SET status = 'completed', completed_at = CURRENT_TIMESTAMP, answers = $1, score = $2,
+			severity = $3, high_risk = $4, risk_reasons = $5
+		WHERE id = $6 AND status <> 'completed'
+	`, joinAnswers(result.Answers), result.Score, result.Severity, result.HighRisk, riskReasons, id)
+	if err != nil {
+		return fmt.Errorf("failed to complete questionnaire: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Conflict("questionnaire request %d is not open", id)
+	}
+
+	return nil
+}
+
+// Acknowledge records the clinician who followed up a high-risk result
+func (r *QuestionnaireRepository) Acknowledge(id int, by string) error {
+	result, err := r.db.conn.Exec(`
+		UPDATE questionnaire_requests SET acknowledged_by = $1, acknowledged_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND high_risk AND acknowledged_at IS NULL
+	`, by, id)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge questionnaire alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Conflict("questionnaire alert %d is not open", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,263 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Queue entry statuses
+const (
+	QueueWaiting    = "waiting"
+	QueueInProgress = "in_progress" // called and being seen
+	QueueDone       = "done"
+	QueueSkipped    = "skipped" // called but did not come
+	QueueCancelled  = "cancelled"
+)
+
+// queueTransitions lists the statuses each queue status may move to; a called
+// patient can be sent back to wait and a skipped one can rejoin
+var queueTransitions = map[string][]string{
+	QueueWaiting:    {QueueInProgress, QueueCancelled},
+	QueueInProgress: {QueueDone, QueueSkipped, QueueWaiting},
+	QueueSkipped:    {QueueWaiting},
+}
+
+// QueueEntry is a patient's place in the queue for a service point, e.g.
+// registration, an exam room or the pharmacy. Numbers restart every day at
+// each branch's service point.
+type QueueEntry struct {
+	ID            int        `json:"id" db:"id"`
+	Branch        string     `json:"branch,omitempty" db:"branch"` // X-Branch-ID at check-in
+	ServicePoint  string     `json:"servicePoint" db:"service_point"`
+	QueueDate     string     `json:"queueDate" db:"queue_date"` // YYYY-MM-DD, branch local
+	Number        int        `json:"number" db:"number"`
+	PatientHN     string     `json:"patientHn" db:"patient_hn"`
+	PatientName   string     `json:"patientName" db:"patient_name"`
+	VisitID       *int       `json:"visitId,omitempty" db:"visit_id"`
+	AppointmentID *int       `json:"appointmentId,omitempty" db:"appointment_id"`
+	DoctorID      *int       `json:"doctorId,omitempty" db:"doctor_id"` // the appointment's doctor, or the one a walk-in was assigned
+	DoctorName    string     `json:"doctorName,omitempty" db:"doctor_name"`
+	Urgency       *string    `json:"urgency,omitempty" db:"urgency"` // triage level, once triaged
+	Status        string     `json:"status" db:"status"`
+	Counter       *string    `json:"counter,omitempty" db:"counter"` // room or desk the patient was called to
+	CalledBy      *string    `json:"calledBy,omitempty" db:"called_by"`
+	CheckedInAt   time.Time  `json:"checkedInAt" db:"checked_in_at"`
+	CalledAt      *time.Time `json:"calledAt,omitempty" db:"called_at"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty" db:"finished_at"`
+	StatusToken   *string    `json:"-" db:"status_token"`    // opens the entry's status page, linked from the printed ticket
+	Ahead         *int       `json:"ahead,omitempty" db:"-"` // waiting entries before this one
+
+	Accommodation *AccommodationAlert `json:"accommodation,omitempty" db:"-"` // the patient's accessibility needs, on staff screens
+}
+
+// Priority ranks the entry in its queue: patients triaged as resuscitation,
+// emergent or urgent are called first, in that order, and everyone else by number
+func (e *QueueEntry) Priority() int {
+	if e.Urgency != nil {
+		switch *e.Urgency {
+		case TriageResuscitation:
+			return 1
+		case TriageEmergent:
+			return 2
+		case TriageUrgent:
+			return 3
+		}
+	}
+	return 4
+}
+
+// Before reports whether e is called before other in the same queue
+func (e *QueueEntry) Before(other *QueueEntry) bool {
+	if e.Priority() != other.Priority() {
+		return e.Priority() < other.Priority()
+	}
+	return e.Number < other.Number
+}
+
+// queuePriority is QueueEntry.Priority in SQL
+const queuePriority = `CASE urgency WHEN 'resuscitation' THEN 1 WHEN 'emergent' THEN 2 WHEN 'urgent' THEN 3 ELSE 4 END`
+
+// CanMoveTo reports whether the entry may move to the given status
+func (e *QueueEntry) CanMoveTo(status string) bool {
+	for _, s := range queueTransitions[e.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// QueueFilter narrows a queue listing; zero values match everything except
+// Branch, where "" is the requests that name no branch
+type QueueFilter struct {
+	Branch       string
+	QueueDate    string
+	ServicePoint string
+	PatientHN    string
+	Statuses     []string
+}
+
+// QueueRepository handles queue database operations
+type QueueRepository struct {
+	db *DB
+}
+
+// NewQueueRepository creates a new queue repository
+func NewQueueRepository(db *DB) *QueueRepository {
+	return &QueueRepository{db: db}
+}
+
+const queueColumns = `id, branch, service_point, to_char(queue_date, 'YYYY-MM-DD'), number, patient_hn, patient_name,
+	visit_id, appointment_id, urgency, status, counter, called_by, checked_in_at, called_at, finished_at, status_token,
+	doctor_id, doctor_name`
+
+func scanQueueEntry(row interface{ Scan(...interface{}) error }) (*QueueEntry, error) {
+	var e QueueEntry
+	err := row.Scan(&e.ID, &e.Branch, &e.ServicePoint, &e.QueueDate, &e.Number, &e.PatientHN, &e.PatientName,
+		&e.VisitID, &e.AppointmentID, &e.Urgency, &e.Status, &e.Counter, &e.CalledBy, &e.CheckedInAt, &e.CalledAt, &e.FinishedAt,
+		&e.StatusToken, &e.DoctorID, &e.DoctorName)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// CheckIn adds a waiting entry with the next number for its branch, service point and day
+func (r *QueueRepository) CheckIn(e *QueueEntry) error {
+	query := `
+		WITH seq AS (
+			INSERT INTO queue_sequences (branch, service_point, queue_date, last_number)
+			VALUES ($1, $2, $3, 1)
+			ON CONFLICT (branch, service_point, queue_date) DO UPDATE SET last_number = queue_sequences.last_number + 1
+			RETURNING last_number
+		)
+		INSERT INTO queue_entries (branch, service_point, queue_date, number, patient_hn, patient_name, visit_id, appointment_id, status,
+			status_token, doctor_id, doctor_name)
+		SELECT $1, $2, $3, last_number, $4, $5, $6, $7, $8, $9, $10, $11 FROM seq
+		RETURNING id, number, checked_in_at
+	`
+
+	err := r.db.conn.QueryRow(query, e.Branch, e.ServicePoint, e.QueueDate, e.PatientHN, e.PatientName,
+		e.VisitID, e.AppointmentID, e.Status, e.StatusToken, e.DoctorID, e.DoctorName).Scan(&e.ID, &e.Number, &e.CheckedInAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("queue entry refers to a visit, appointment or doctor that does not exist")
+		}
+		return fmt.Errorf("failed to check in: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a queue entry
+func (r *QueueRepository) GetByID(id int) (*QueueEntry, error) {
+	e, err := scanQueueEntry(r.db.conn.QueryRow("SELECT "+queueColumns+" FROM queue_entries WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("queue entry %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get queue entry: %w", err)
+	}
+	return e, nil
+}
+
+// GetByStatusToken retrieves the queue entry a printed ticket's status page link opens
+func (r *QueueRepository) GetByStatusToken(token string) (*QueueEntry, error) {
+	e, err := scanQueueEntry(r.db.conn.QueryRow("SELECT "+queueColumns+" FROM queue_entries WHERE status_token = $1", token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("queue entry not found")
+		}
+		return nil, fmt.Errorf("failed to get queue entry: %w", err)
+	}
+	return e, nil
+}
+
+// List retrieves queue entries matching the filter, by service point and the order they are called in
+func (r *QueueRepository) List(f QueueFilter) ([]QueueEntry, error) {
+	query := `
+		SELECT ` + queueColumns + ` FROM queue_entries
+		WHERE branch = $1 AND ($2 = '' OR queue_date = $2::date) AND ($3 = '' OR service_point = $3)
+			AND ($4 = '' OR patient_hn = $4) AND ($5 = '' OR status = ANY(string_to_array($5, ',')))
+		ORDER BY service_point, queue_date, ` + queuePriority + `, number
+	`
+
+	rows, err := r.db.conn.Query(query, f.Branch, f.QueueDate, f.ServicePoint, f.PatientHN, strings.Join(f.Statuses, ","))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queue: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []QueueEntry{}
+	for rows.Next() {
+		e, err := scanQueueEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan queue entry: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+
+	return entries, rows.Err()
+}
+
+// CallNext calls the most urgent, then lowest-numbered, waiting patient at a
+// branch's service point on a day to the counter; for a doctorID, only
+// patients waiting for that doctor or for no one in particular. Two desks
+// calling at once get different patients.
+func (r *QueueRepository) CallNext(branch, servicePoint, date, calledBy string, counter *string, doctorID int) (*QueueEntry, error) {
+	e, err := scanQueueEntry(r.db.conn.QueryRow(`
+		UPDATE queue_entries SET status = 'in_progress', counter = $5, called_by = $4, called_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM queue_entries
+			WHERE branch = $1 AND service_point = $2 AND queue_date = $3::date AND status = 'waiting'
+				AND ($6 = 0 OR doctor_id = $6 OR doctor_id IS NULL)
+			ORDER BY `+queuePriority+`, number LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+queueColumns, branch, servicePoint, date, calledBy, counter, doctorID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("no one is waiting at %s", servicePoint)
+		}
+		return nil, fmt.Errorf("failed to call next patient: %w", err)
+	}
+	return e, nil
+}
+
+// UpdateStatus moves an entry from one status to another. Calling a patient
+// records who called them and to which counter; sending them back to wait clears it.
+func (r *QueueRepository) UpdateStatus(id int, from, to, by string, counter *string) (*QueueEntry, error) {
+	e, err := scanQueueEntry(r.db.conn.QueryRow(`
+		UPDATE queue_entries SET status = $3,
+			counter = CASE WHEN $3 = 'in_progress' THEN $5 WHEN $3 = 'waiting' THEN NULL ELSE counter END,
+			called_by = CASE WHEN $3 = 'in_progress' THEN $4 WHEN $3 = 'waiting' THEN NULL ELSE called_by END,
+			called_at = CASE WHEN $3 = 'in_progress' THEN CURRENT_TIMESTAMP WHEN $3 = 'waiting' THEN NULL ELSE called_at END,
+			finished_at = CASE WHEN $3 IN ('done', 'skipped', 'cancelled') THEN CURRENT_TIMESTAMP END
+		WHERE id = $1 AND status = $2
+		RETURNING `+queueColumns, id, from, to, by, counter))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("queue entry %d is no longer %s", id, from)
+		}
+		return nil, fmt.Errorf("failed to update queue entry: %w", err)
+	}
+	return e, nil
+}
+
+// SetUrgency records an entry's triage level, moving it up or down the queue
+func (r *QueueRepository) SetUrgency(id int, urgency string) (*QueueEntry, error) {
+	e, err := scanQueueEntry(r.db.conn.QueryRow(`
+		UPDATE queue_entries SET urgency = $2 WHERE id = $1
+		RETURNING `+queueColumns, id, urgency))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("queue entry %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to set queue entry urgency: %w", err)
+	}
+	return e, nil
+}
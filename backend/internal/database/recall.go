@@ -0,0 +1,189 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Recall notification states
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+// LotRecall flags a manufacturer lot as recalled, blocking further dispensing
+type LotRecall struct {
+	ID           int       `json:"id" db:"id"`
+	ItemID       int       `json:"itemId" db:"item_id"`
+	ItemName     string    `json:"itemName" db:"item_name"`
+	LotNumber    string    `json:"lotNumber" db:"lot_number"` // เลขที่ผลิต
+	Manufacturer string    `json:"manufacturer" db:"manufacturer"`
+	Reason       string    `json:"reason" db:"reason"`
+	RecalledBy   string    `json:"recalledBy" db:"recalled_by"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+// RecallNotification is one message in a recall notification batch
+type RecallNotification struct {
+	ID          int        `json:"id" db:"id"`
+	RecallID    int        `json:"recallId" db:"recall_id"`
+	PatientHN   string     `json:"patientHn" db:"patient_hn"`
+	PatientName string     `json:"patientName" db:"patient_name"`
+	Phone       *string    `json:"phone,omitempty" db:"phone"`
+	Message     string     `json:"message" db:"message"`
+	Status      string     `json:"status" db:"status"`
+	SentAt      *time.Time `json:"sentAt,omitempty" db:"sent_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// LotDispense is a dispensing event of a specific lot to a patient
+type LotDispense struct {
+	PatientHN   string    `json:"patientHn"`
+	Quantity    float64   `json:"quantity"`
+	DispensedAt time.Time `json:"dispensedAt"`
+}
+
+// RecallRepository handles lot recall database operations
+type RecallRepository struct {
+	db *DB
+}
+
+// NewRecallRepository creates a new recall repository
+func NewRecallRepository(db *DB) *RecallRepository {
+	return &RecallRepository{db: db}
+}
+
+// Create flags a lot as recalled
+func (r *RecallRepository) Create(recall *LotRecall) error {
+	query := `
+		INSERT INTO lot_recalls (item_id, item_name, lot_number, manufacturer, reason, recalled_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, recall.ItemID, recall.ItemName, recall.LotNumber,
+		recall.Manufacturer, recall.Reason, recall.RecalledBy).Scan(&recall.ID, &recall.CreatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("lot %s of item %d is already recalled", recall.LotNumber, recall.ItemID)
+		}
+		return fmt.Errorf("failed to create lot recall: %w", err)
+	}
+
+	return nil
+}
+
+// GetAll retrieves all lot recalls, newest first
+func (r *RecallRepository) GetAll() ([]LotRecall, error) {
+	query := `
+		SELECT id, item_id, item_name, lot_number, manufacturer, reason, recalled_by, created_at
+		FROM lot_recalls
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lot recalls: %w", err)
+	}
+	defer rows.Close()
+
+	var recalls []LotRecall
+	for rows.Next() {
+		var rc LotRecall
+		err := rows.Scan(&rc.ID, &rc.ItemID, &rc.ItemName, &rc.LotNumber,
+			&rc.Manufacturer, &rc.Reason, &rc.RecalledBy, &rc.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lot recall: %w", err)
+		}
+		recalls = append(recalls, rc)
+	}
+
+	return recalls, nil
+}
+
+// GetByID retrieves a lot recall by ID
+func (r *RecallRepository) GetByID(id int) (*LotRecall, error) {
+	query := `
+		SELECT id, item_id, item_name, lot_number, manufacturer, reason, recalled_by, created_at
+		FROM lot_recalls
+		WHERE id = $1
+	`
+
+	var rc LotRecall
+	err := r.db.conn.QueryRow(query, id).Scan(&rc.ID, &rc.ItemID, &rc.ItemName, &rc.LotNumber,
+		&rc.Manufacturer, &rc.Reason, &rc.RecalledBy, &rc.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("lot recall %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get lot recall: %w", err)
+	}
+
+	return &rc, nil
+}
+
+// IsLotRecalled reports whether a lot of an item has been recalled
+func (r *RecallRepository) IsLotRecalled(itemID int, lotNumber string) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM lot_recalls WHERE item_id = $1 AND lot_number = $2)"
+
+	var recalled bool
+	if err := r.db.conn.QueryRow(query, itemID, lotNumber).Scan(&recalled); err != nil {
+		return false, fmt.Errorf("failed to check lot recall: %w", err)
+	}
+
+	return recalled, nil
+}
+
+// CreateNotifications stores a recall notification batch
+func (r *RecallRepository) CreateNotifications(notifications []RecallNotification) error {
+	query := `
+		INSERT INTO recall_notifications (recall_id, patient_hn, patient_name, phone, message, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	for i := range notifications {
+		n := &notifications[i]
+		err := r.db.conn.QueryRow(query, n.RecallID, n.PatientHN, n.PatientName,
+			n.Phone, n.Message, n.Status).Scan(&n.ID, &n.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create recall notification: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetNotifications retrieves the notification batch of a recall
+func (r *RecallRepository) GetNotifications(recallID int) ([]RecallNotification, error) {
+	query := `
+		SELECT id, recall_id, patient_hn, patient_name, phone, message, status, sent_at, created_at
+		FROM recall_notifications
+		WHERE recall_id = $1
+		ORDER BY patient_hn
+	`
+
+	rows, err := r.db.conn.Query(query, recallID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recall notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []RecallNotification
+	for rows.Next() {
+		var n RecallNotification
+		err := rows.Scan(&n.ID, &n.RecallID, &n.PatientHN, &n.PatientName,
+			&n.Phone, &n.Message, &n.Status, &n.SentAt, &n.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recall notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
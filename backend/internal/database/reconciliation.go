@@ -0,0 +1,211 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Bank transaction match states
+const (
+	MatchStatusUnmatched = "unmatched"
+	MatchStatusAuto      = "auto"
+	MatchStatusManual    = "manual"
+)
+
+// BankStatementImport records one uploaded bank-statement file
+type BankStatementImport struct {
+	ID           int       `json:"id" db:"id"`
+	FileName     string    `json:"fileName" db:"file_name"`
+	RowCount     int       `json:"rowCount" db:"row_count"`
+	MatchedCount int       `json:"matchedCount" db:"matched_count"` // auto-matched at import time
+	ImportedAt   time.Time `json:"importedAt" db:"imported_at"`
+}
+
+// BankTransaction is a single incoming transfer/PromptPay line from a statement
+type BankTransaction struct {
+	ID               int        `json:"id" db:"id"`
+	ImportID         int        `json:"importId" db:"import_id"`
+	TransactionDate  time.Time  `json:"transactionDate" db:"transaction_date"`
+	Amount           float64    `json:"amount" db:"amount"`
+	Reference        string     `json:"reference" db:"reference"`     // เลขที่อ้างอิง
+	Description      string     `json:"description" db:"description"` // รายละเอียด
+	MatchStatus      string     `json:"matchStatus" db:"match_status"`
+	MatchedInvoiceID *int       `json:"matchedInvoiceId,omitempty" db:"matched_invoice_id"`
+	MatchedAt        *time.Time `json:"matchedAt,omitempty" db:"matched_at"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// ReconciliationRepository handles bank reconciliation database operations
+type ReconciliationRepository struct {
+	db *DB
+}
+
+// NewReconciliationRepository creates a new reconciliation repository
+func NewReconciliationRepository(db *DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// CreateImport stores an import and its transactions in a single transaction
+func (r *ReconciliationRepository) CreateImport(imp *BankStatementImport, txns []BankTransaction) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO bank_statement_imports (file_name, row_count, matched_count)
+		VALUES ($1, $2, $3)
+		RETURNING id, imported_at
+	`, imp.FileName, imp.RowCount, imp.MatchedCount).Scan(&imp.ID, &imp.ImportedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create statement import: %w", err)
+	}
+
+	for i := range txns {
+		t := &txns[i]
+		t.ImportID = imp.ID
+		err := tx.QueryRow(`
+			INSERT INTO bank_transactions (import_id, transaction_date, amount, reference, description,
+				match_status, matched_invoice_id, matched_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at
+		`, t.ImportID, t.TransactionDate, t.Amount, t.Reference, t.Description,
+			t.MatchStatus, t.MatchedInvoiceID, t.MatchedAt).Scan(&t.ID, &t.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create bank transaction: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return nil
+}
+
+// ListTransactions retrieves bank transactions, optionally filtered by match status
+func (r *ReconciliationRepository) ListTransactions(status string) ([]BankTransaction, error) {
+	query := `
+		SELECT id, import_id, transaction_date, amount, reference, description,
+		       match_status, matched_invoice_id, matched_at, created_at
+		FROM bank_transactions
+		WHERE ($1 = '' OR match_status = $1)
+		ORDER BY transaction_date DESC
+	`
+
+	rows, err := r.db.conn.Query(query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bank transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var txns []BankTransaction
+	for rows.Next() {
+		var t BankTransaction
+		err := rows.Scan(&t.ID, &t.ImportID, &t.TransactionDate, &t.Amount, &t.Reference,
+			&t.Description, &t.MatchStatus, &t.MatchedInvoiceID, &t.MatchedAt, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bank transaction: %w", err)
+		}
+		txns = append(txns, t)
+	}
+
+	return txns, nil
+}
+
+// GetTransaction retrieves a bank transaction by ID
+func (r *ReconciliationRepository) GetTransaction(id int) (*BankTransaction, error) {
+	query := `
+		SELECT id, import_id, transaction_date, amount, reference, description,
+		       match_status, matched_invoice_id, matched_at, created_at
+		FROM bank_transactions
+		WHERE id = $1
+	`
+
+	var t BankTransaction
+	err := r.db.conn.QueryRow(query, id).Scan(&t.ID, &t.ImportID, &t.TransactionDate, &t.Amount,
+		&t.Reference, &t.Description, &t.MatchStatus, &t.MatchedInvoiceID, &t.MatchedAt, &t.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("bank transaction %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get bank transaction: %w", err)
+	}
+
+	return &t, nil
+}
+
+// UpdateMatch stores the match state of a bank transaction
+func (r *ReconciliationRepository) UpdateMatch(t *BankTransaction) error {
+	query := `
+		UPDATE bank_transactions
+		SET match_status = $1, matched_invoice_id = $2, matched_at = $3
+		WHERE id = $4
+	`
+
+	result, err := r.db.conn.Exec(query, t.MatchStatus, t.MatchedInvoiceID, t.MatchedAt, t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update bank transaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("bank transaction %d not found", t.ID)
+	}
+
+	return nil
+}
+
+// UpdateImportMatched stores how many of an import's transactions are auto-matched
+func (r *ReconciliationRepository) UpdateImportMatched(imp *BankStatementImport) error {
+	result, err := r.db.conn.Exec("UPDATE bank_statement_imports SET matched_count = $1 WHERE id = $2", imp.MatchedCount, imp.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update statement import: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("statement import %d not found", imp.ID)
+	}
+
+	return nil
+}
+
+// ListImports retrieves all statement imports, newest first
+func (r *ReconciliationRepository) ListImports() ([]BankStatementImport, error) {
+	query := `
+		SELECT id, file_name, row_count, matched_count, imported_at
+		FROM bank_statement_imports
+		ORDER BY imported_at DESC
+	`
+
+	rows, err := r.db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statement imports: %w", err)
+	}
+	defer rows.Close()
+
+	var imports []BankStatementImport
+	for rows.Next() {
+		var imp BankStatementImport
+		if err := rows.Scan(&imp.ID, &imp.FileName, &imp.RowCount, &imp.MatchedCount, &imp.ImportedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan statement import: %w", err)
+		}
+		imports = append(imports, imp)
+	}
+
+	return imports, nil
+}
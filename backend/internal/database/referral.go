@@ -0,0 +1,243 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Referral directions
+const (
+	ReferralOutbound = "outbound" // the clinic refers the patient elsewhere
+	ReferralInbound  = "inbound"  // another facility refers the patient to the clinic
+)
+
+// Referral urgencies
+const (
+	UrgencyRoutine   = "routine"
+	UrgencyUrgent    = "urgent"
+	UrgencyEmergency = "emergency"
+)
+
+// ReferralUrgencies lists the valid urgencies, least pressing first
+var ReferralUrgencies = []string{UrgencyRoutine, UrgencyUrgent, UrgencyEmergency}
+
+// Referral statuses
+const (
+	ReferralPending   = "pending"   // waiting for the receiving side to accept
+	ReferralAccepted  = "accepted"  // the receiving side will see the patient
+	ReferralDeclined  = "declined"  // the receiving side will not see the patient
+	ReferralCompleted = "completed" // the patient was seen; the outcome is recorded
+	ReferralCancelled = "cancelled"
+)
+
+// referralTransitions lists the statuses each referral status may move to
+var referralTransitions = map[string][]string{
+	ReferralPending:  {ReferralAccepted, ReferralDeclined, ReferralCancelled},
+	ReferralAccepted: {ReferralCompleted, ReferralCancelled},
+}
+
+// Referral is a patient referred to another facility (ใบส่งตัว) or to the
+// clinic from one
+type Referral struct {
+	ID         int                `json:"id" db:"id"`
+	PatientHN  string             `json:"patientHn" db:"patient_hn"`
+	VisitID    *int               `json:"visitId,omitempty" db:"visit_id"` // the visit the referral was written in
+	Direction  string             `json:"direction" db:"direction"`        // outbound/inbound
+	Facility   string             `json:"facility" db:"facility"`          // the receiving facility for outbound referrals, the referring one for inbound
+	Department *string            `json:"department,omitempty" db:"department"`
+	Reason     string             `json:"reason" db:"reason"`
+	Urgency    string             `json:"urgency" db:"urgency"`
+	ReferredBy string             `json:"referredBy" db:"referred_by"` // referring doctor
+	Status     string             `json:"status" db:"status"`
+	Outcome    *string            `json:"outcome,omitempty" db:"outcome"` // what came of it, or why it was declined or cancelled
+	Documents  []ReferralDocument `json:"documents,omitempty" db:"-"`
+	CreatedAt  time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time          `json:"updatedAt" db:"updated_at"`
+	ClosedAt   *time.Time         `json:"closedAt,omitempty" db:"closed_at"` // when it was completed, declined or cancelled
+}
+
+// CanMoveTo reports whether the referral may move to the given status
+func (r *Referral) CanMoveTo(status string) bool {
+	for _, s := range referralTransitions[r.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ReferralDocument is a document sent with or received for a referral, e.g.
+// the referral letter, lab results or the receiving doctor's reply
+type ReferralDocument struct {
+	ID         int       `json:"id" db:"id"`
+	ReferralID int       `json:"referralId" db:"referral_id"`
+	Name       string    `json:"name" db:"name"`
+	URL        string    `json:"url" db:"url"`
+	AddedBy    string    `json:"addedBy" db:"added_by"`
+	AddedAt    time.Time `json:"addedAt" db:"added_at"`
+}
+
+// ReferralFilter narrows a referral listing; zero values match everything
+type ReferralFilter struct {
+	PatientHN string
+	VisitID   int
+	Direction string
+	Status    string
+	Facility  string // matched case-insensitively as a substring
+}
+
+// ReferralRepository handles referral database operations
+type ReferralRepository struct {
+	db *DB
+}
+
+// NewReferralRepository creates a new referral repository
+func NewReferralRepository(db *DB) *ReferralRepository {
+	return &ReferralRepository{db: db}
+}
+
+const referralColumns = `id, patient_hn, visit_id, direction, facility, department, reason, urgency, referred_by,
+	status, outcome, created_at, updated_at, closed_at`
+
+func scanReferral(row interface{ Scan(...interface{}) error }) (*Referral, error) {
+	var ref Referral
+	err := row.Scan(&ref.ID, &ref.PatientHN, &ref.VisitID, &ref.Direction, &ref.Facility, &ref.Department,
+		&ref.Reason, &ref.Urgency, &ref.ReferredBy, &ref.Status, &ref.Outcome, &ref.CreatedAt, &ref.UpdatedAt, &ref.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// Create stores a new referral
+func (r *ReferralRepository) Create(ref *Referral) error {
+	query := `
+		INSERT INTO referrals (patient_hn, visit_id, direction, facility, department, reason, urgency, referred_by, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, ref.PatientHN, ref.VisitID, ref.Direction, ref.Facility, ref.Department,
+		ref.Reason, ref.Urgency, ref.ReferredBy, ref.Status).Scan(&ref.ID, &ref.CreatedAt, &ref.UpdatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("visit %d does not exist", *ref.VisitID)
+		}
+		return fmt.Errorf("failed to create referral: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a referral with its documents
+func (r *ReferralRepository) GetByID(id int) (*Referral, error) {
+	ref, err := scanReferral(r.db.conn.QueryRow("SELECT "+referralColumns+" FROM referrals WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("referral %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get referral: %w", err)
+	}
+
+	rows, err := r.db.conn.Query(`
+		SELECT id, referral_id, name, url, added_by, added_at FROM referral_documents
+		WHERE referral_id = $1 ORDER BY added_at, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referral documents: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d ReferralDocument
+		if err := rows.Scan(&d.ID, &d.ReferralID, &d.Name, &d.URL, &d.AddedBy, &d.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan referral document: %w", err)
+		}
+		ref.Documents = append(ref.Documents, d)
+	}
+
+	return ref, rows.Err()
+}
+
+// List retrieves referrals matching the filter, newest first, without their documents
+func (r *ReferralRepository) List(f ReferralFilter) ([]Referral, error) {
+	query := `
+		SELECT ` + referralColumns + ` FROM referrals
+		WHERE ($1 = '' OR patient_hn = $1) AND ($2 = 0 OR visit_id = $2) AND ($3 = '' OR direction = $3)
+			AND ($4 = '' OR status = $4) AND ($5 = '' OR facility ILIKE '%' || $5 || '%')
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.conn.Query(query, f.PatientHN, f.VisitID, f.Direction, f.Status, f.Facility)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referrals: %w", err)
+	}
+	defer rows.Close()
+
+	referrals := []Referral{}
+	for rows.Next() {
+		ref, err := scanReferral(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan referral: %w", err)
+		}
+		referrals = append(referrals, *ref)
+	}
+
+	return referrals, rows.Err()
+}
+
+// UpdateStatus moves a referral from one status to another, recording the
+// outcome; declining, completing or cancelling it closes it
+func (r *ReferralRepository) UpdateStatus(id int, from, to string, outcome *string) (*Referral, error) {
+	ref, err := scanReferral(r.db.conn.QueryRow(`
+		UPDATE referrals SET status = $3, outcome = COALESCE($4, outcome), updated_at = CURRENT_TIMESTAMP,
+			closed_at = CASE WHEN $3 IN ('declined', 'completed', 'cancelled') THEN CURRENT_TIMESTAMP END
+		WHERE id = $1 AND status = $2
+		RETURNING `+referralColumns, id, from, to, outcome))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("referral %d is no longer %s", id, from)
+		}
+		return nil, fmt.Errorf("failed to update referral status: %w", err)
+	}
+	return ref, nil
+}
+
+// AddDocument attaches a document to a referral
+func (r *ReferralRepository) AddDocument(d *ReferralDocument) error {
+	query := `
+		INSERT INTO referral_documents (referral_id, name, url, added_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, added_at
+	`
+
+	err := r.db.conn.QueryRow(query, d.ReferralID, d.Name, d.URL, d.AddedBy).Scan(&d.ID, &d.AddedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.NotFound("referral %d not found", d.ReferralID)
+		}
+		return fmt.Errorf("failed to add referral document: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveDocument detaches a document added by mistake
+func (r *ReferralRepository) RemoveDocument(referralID, id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM referral_documents WHERE id = $1 AND referral_id = $2", id, referralID)
+	if err != nil {
+		return fmt.Errorf("failed to remove referral document: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("document %d not found on referral %d", id, referralID)
+	}
+
+	return nil
+}
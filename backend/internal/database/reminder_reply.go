@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// What a reminder reply asks for
+const (
+	ReplyConfirm = "confirm"
+	ReplyCancel  = "cancel"
+	ReplyUnknown = "unknown"
+)
+
+// Reminder reply states
+const (
+	ReplyApplied   = "applied"   // the appointment was confirmed or cancelled automatically
+	ReplyReview    = "review"    // waiting for staff: unparseable, unmatched or could not be applied
+	ReplyResolved  = "resolved"  // staff acted on the appointment
+	ReplyDismissed = "dismissed" // staff decided nothing needed doing
+)
+
+// ReminderReply is an inbound SMS or LINE message answering an appointment reminder
+type ReminderReply struct {
+	ID            int        `json:"id" db:"id"`
+	Channel       string     `json:"channel" db:"channel"` // line or sms
+	Phone         string     `json:"phone" db:"phone"`     // sender
+	Text          string     `json:"text" db:"text"`
+	Intent        string     `json:"intent" db:"intent"`                          // confirm, cancel or unknown
+	AppointmentID *int       `json:"appointmentId,omitempty" db:"appointment_id"` // the reminded appointment it answers, if found
+	PatientHN     *string    `json:"patientHn,omitempty" db:"patient_hn"`
+	Status        string     `json:"status" db:"status"`
+	Note          *string    `json:"note,omitempty" db:"note"` // why it needs review, or what staff did
+	ResolvedBy    *string    `json:"resolvedBy,omitempty" db:"resolved_by"`
+	ResolvedAt    *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+	ReceivedAt    time.Time  `json:"receivedAt" db:"received_at"`
+}
+
+// ReminderReplyRepository handles reminder reply database operations
+type ReminderReplyRepository struct {
+	db *DB
+}
+
+// NewReminderReplyRepository creates a new reminder reply repository
+func NewReminderReplyRepository(db *DB) *ReminderReplyRepository {
+	return &ReminderReplyRepository{db: db}
+}
+
+const reminderReplyColumns = `id, channel, phone, text, intent, appointment_id, patient_hn, status, note, resolved_by, resolved_at, received_at`
+
+func scanReminderReply(row interface{ Scan(...interface{}) error }) (*ReminderReply, error) {
+	var m ReminderReply
+	err := row.Scan(&m.ID, &m.Channel, &m.Phone, &m.Text, &m.Intent, &m.AppointmentID, &m.PatientHN, &m.Status,
+		&m.Note, &m.ResolvedBy, &m.ResolvedAt, &m.ReceivedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Create records an inbound reply
+func (r *ReminderReplyRepository) Create(m *ReminderReply) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO reminder_replies (channel, phone, text, intent, appointment_id, patient_hn, status, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, received_at
+	`, m.Channel, m.Phone, m.Text, m.Intent, m.AppointmentID, m.PatientHN, m.Status, m.Note).Scan(&m.ID, &m.ReceivedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reminder reply: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a reply
+func (r *ReminderReplyRepository) GetByID(id int) (*ReminderReply, error) {
+	m, err := scanReminderReply(r.db.conn.QueryRow("SELECT "+reminderReplyColumns+" FROM reminder_replies WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("reminder reply %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get reminder reply: %w", err)
+	}
+	return m, nil
+}
+
+// List retrieves replies in a status ("" for all), oldest first
+func (r *ReminderReplyRepository) List(status string) ([]ReminderReply, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+reminderReplyColumns+` FROM reminder_replies
+		WHERE $1 = '' OR status = $1
+		ORDER BY received_at, id
+	`, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reminder replies: %w", err)
+	}
+	defer rows.Close()
+
+	replies := []ReminderReply{}
+	for rows.Next() {
+		m, err := scanReminderReply(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder reply: %w", err)
+		}
+		replies = append(replies, *m)
+	}
+
+	return replies, rows.Err()
+}
+
+// Resolve closes a reply waiting for review as resolved or dismissed
+func (r *ReminderReplyRepository) Resolve(id int, status string, appointmentID *int, by string, note *string) (*ReminderReply, error) {
+	m, err := scanReminderReply(r.db.conn.QueryRow(`
+		UPDATE reminder_replies SET status = $2, appointment_id = COALESCE($3, appointment_id),
+			resolved_by = $4, note = COALESCE($5, note), resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'review'
+		RETURNING `+reminderReplyColumns, id, status, appointmentID, by, note))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("reminder reply %d is not waiting for review", id)
+		}
+		return nil, fmt.Errorf("failed to resolve reminder reply: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Purchase suggestion states
+const (
+	SuggestionPending  = "pending"
+	SuggestionApproved = "approved"
+	SuggestionRejected = "rejected"
+)
+
+// ReorderPolicy holds purchasing parameters for one stock item
+type ReorderPolicy struct {
+	ItemID          int       `json:"itemId" db:"item_id"`
+	ItemName        string    `json:"itemName" db:"item_name"`
+	Supplier        string    `json:"supplier" db:"supplier"`                 // ผู้จำหน่าย
+	PackSize        int       `json:"packSize" db:"pack_size"`                // units per purchasable pack
+	LeadTimeDays    int       `json:"leadTimeDays" db:"lead_time_days"`       // supplier delivery time
+	SafetyStockDays int       `json:"safetyStockDays" db:"safety_stock_days"` // buffer held on top of lead time
+	UpdatedAt       time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// PurchaseSuggestion is a generated reorder line awaiting pharmacist approval
+type PurchaseSuggestion struct {
+	ID                int        `json:"id" db:"id"`
+	ItemID            int        `json:"itemId" db:"item_id"`
+	ItemName          string     `json:"itemName" db:"item_name"`
+	Supplier          string     `json:"supplier" db:"supplier"`
+	ForecastMonthly   float64    `json:"forecastMonthly" db:"forecast_monthly"`
+	CurrentStock      float64    `json:"currentStock" db:"current_stock"`
+	SuggestedQuantity int        `json:"suggestedQuantity" db:"suggested_quantity"`
+	Packs             int        `json:"packs" db:"packs"`
+	Status            string     `json:"status" db:"status"`
+	DecidedBy         *string    `json:"decidedBy,omitempty" db:"decided_by"`
+	DecidedAt         *time.Time `json:"decidedAt,omitempty" db:"decided_at"`
+	CreatedAt         time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// ReorderRepository handles reorder policy and suggestion database operations
+type ReorderRepository struct {
+	db *DB
+}
+
+// NewReorderRepository creates a new reorder repository
+func NewReorderRepository(db *DB) *ReorderRepository {
+	return &ReorderRepository{db: db}
+}
+
+// ListPolicies retrieves all reorder policies
+func (r *ReorderRepository) ListPolicies() ([]ReorderPolicy, error) {
+	query := `
+		SELECT item_id, item_name, supplier, pack_size, lead_time_days, safety_stock_days, updated_at
+		FROM reorder_policies
+		ORDER BY item_name
+	`
+
+	rows, err := r.db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reorder policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []ReorderPolicy
+	for rows.Next() {
+		var p ReorderPolicy
+		err := rows.Scan(&p.ItemID, &p.ItemName, &p.Supplier, &p.PackSize,
+			&p.LeadTimeDays, &p.SafetyStockDays, &p.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reorder policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+
+	return policies, nil
+}
+
+// UpsertPolicy creates or replaces the reorder policy of an item
+func (r *ReorderRepository) UpsertPolicy(p *ReorderPolicy) error {
+	query := `
+		INSERT INTO reorder_policies (item_id, item_name, supplier, pack_size, lead_time_days, safety_stock_days)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (item_id) DO UPDATE
+		SET item_name = $2, supplier = $3, pack_size = $4, lead_time_days = $5,
+		    safety_stock_days = $6, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, p.ItemID, p.ItemName, p.Supplier, p.PackSize,
+		p.LeadTimeDays, p.SafetyStockDays).Scan(&p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save reorder policy: %w", err)
+	}
+
+	return nil
+}
+
+// CreateSuggestions stores a batch of purchase suggestions
+func (r *ReorderRepository) CreateSuggestions(suggestions []PurchaseSuggestion) error {
+	query := `
+		INSERT INTO purchase_suggestions (item_id, item_name, supplier, forecast_monthly,
+			current_stock, suggested_quantity, packs, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	for i := range suggestions {
+		s := &suggestions[i]
+		err := r.db.conn.QueryRow(query, s.ItemID, s.ItemName, s.Supplier, s.ForecastMonthly,
+			s.CurrentStock, s.SuggestedQuantity, s.Packs, s.Status).Scan(&s.ID, &s.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create purchase suggestion: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListSuggestions retrieves purchase suggestions, optionally filtered by status
+func (r *ReorderRepository) ListSuggestions(status string) ([]PurchaseSuggestion, error) {
+	query := `
+		SELECT id, item_id, item_name, supplier, forecast_monthly, current_stock,
+		       suggested_quantity, packs, status, decided_by, decided_at, created_at
+		FROM purchase_suggestions
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.conn.Query(query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query purchase suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []PurchaseSuggestion
+	for rows.Next() {
+		var s PurchaseSuggestion
+		err := rows.Scan(&s.ID, &s.ItemID, &s.ItemName, &s.Supplier, &s.ForecastMonthly, &s.CurrentStock,
+			&s.SuggestedQuantity, &s.Packs, &s.Status, &s.DecidedBy, &s.DecidedAt, &s.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan purchase suggestion: %w", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions, nil
+}
+
+// GetSuggestion retrieves a purchase suggestion by ID
+func (r *ReorderRepository) GetSuggestion(id int) (*PurchaseSuggestion, error) {
+	query := `
+		SELECT id, item_id, item_name, supplier, forecast_monthly, current_stock,
+		       suggested_quantity, packs, status, decided_by, decided_at, created_at
+		FROM purchase_suggestions
+		WHERE id = $1
+	`
+
+	var s PurchaseSuggestion
+	err := r.db.conn.QueryRow(query, id).Scan(&s.ID, &s.ItemID, &s.ItemName, &s.Supplier, &s.ForecastMonthly,
+		&s.CurrentStock, &s.SuggestedQuantity, &s.Packs, &s.Status, &s.DecidedBy, &s.DecidedAt, &s.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("purchase suggestion %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get purchase suggestion: %w", err)
+	}
+
+	return &s, nil
+}
+
+// UpdateSuggestion stores the decision and (possibly adjusted) quantity of a suggestion
+func (r *ReorderRepository) UpdateSuggestion(s *PurchaseSuggestion) error {
+	query := `
+		UPDATE purchase_suggestions
+		SET suggested_quantity = $1, packs = $2, status = $3, decided_by = $4, decided_at = $5
+		WHERE id = $6
+	`
+
+	result, err := r.db.conn.Exec(query, s.SuggestedQuantity, s.Packs, s.Status, s.DecidedBy, s.DecidedAt, s.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update purchase suggestion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.NotFound("purchase suggestion %d not found", s.ID)
+	}
+
+	return nil
+}
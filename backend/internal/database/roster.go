@@ -0,0 +1,156 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Roster is a doctor's weekly shifts, in the local time of the branch booking
+// them. Doctors without a roster are bookable all day on their working days.
+type Roster struct {
+	DoctorID  int            `json:"doctorId" db:"doctor_id"`
+	Shifts    []OpeningHours `json:"shifts" db:"shifts"` // stored as JSONB; day, opens (shift start), closes (shift end)
+	UpdatedBy string         `json:"updatedBy" db:"updated_by"`
+	UpdatedAt time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+// OnShift reports whether [from, to), read in loc, falls within one shift
+func (r *Roster) OnShift(from, to time.Time, loc *time.Location) bool {
+	return coversSpan(r.Shifts, from, to, loc)
+}
+
+// DayOff is a date a doctor does not work, e.g. leave or a conference,
+// whatever their roster says
+type DayOff struct {
+	ID        int       `json:"id" db:"id"`
+	DoctorID  int       `json:"doctorId" db:"doctor_id"`
+	Date      string    `json:"date" db:"day_off"` // YYYY-MM-DD
+	Reason    *string   `json:"reason,omitempty" db:"reason"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// RosterRepository handles doctor roster and day-off database operations
+type RosterRepository struct {
+	db *DB
+}
+
+// NewRosterRepository creates a new roster repository
+func NewRosterRepository(db *DB) *RosterRepository {
+	return &RosterRepository{db: db}
+}
+
+// GetRoster retrieves a doctor's weekly shifts
+func (r *RosterRepository) GetRoster(doctorID int) (*Roster, error) {
+	var roster Roster
+	var shifts []byte
+	err := r.db.conn.QueryRow(
+		"SELECT doctor_id, shifts, updated_by, updated_at FROM doctor_rosters WHERE doctor_id = $1", doctorID,
+	).Scan(&roster.DoctorID, &shifts, &roster.UpdatedBy, &roster.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("doctor %d has no roster", doctorID)
+		}
+		return nil, fmt.Errorf("failed to get roster: %w", err)
+	}
+	if err := json.Unmarshal(shifts, &roster.Shifts); err != nil {
+		return nil, fmt.Errorf("invalid shifts for doctor %d: %w", doctorID, err)
+	}
+	return &roster, nil
+}
+
+// SaveRoster creates or replaces a doctor's weekly shifts
+func (r *RosterRepository) SaveRoster(roster *Roster) error {
+	shifts, err := json.Marshal(roster.Shifts)
+	if err != nil {
+		return fmt.Errorf("failed to encode shifts: %w", err)
+	}
+
+	err = r.db.conn.QueryRow(`
+		INSERT INTO doctor_rosters (doctor_id, shifts, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (doctor_id) DO UPDATE SET shifts = $2, updated_by = $3, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`, roster.DoctorID, shifts, roster.UpdatedBy).Scan(&roster.UpdatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("doctor %d does not exist", roster.DoctorID)
+		}
+		return fmt.Errorf("failed to save roster: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRoster removes a doctor's shifts; they are bookable all day on their working days again
+func (r *RosterRepository) DeleteRoster(doctorID int) error {
+	result, err := r.db.conn.Exec("DELETE FROM doctor_rosters WHERE doctor_id = $1", doctorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete roster: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return apperr.NotFound("doctor %d has no roster", doctorID)
+	}
+	return nil
+}
+
+// ListDaysOff retrieves a doctor's days off between from and to (YYYY-MM-DD,
+// inclusive; either may be empty), earliest first
+func (r *RosterRepository) ListDaysOff(doctorID int, from, to string) ([]DayOff, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT id, doctor_id, to_char(day_off, 'YYYY-MM-DD'), reason, created_by, created_at FROM doctor_days_off
+		WHERE doctor_id = $1 AND ($2 = '' OR day_off >= $2::date) AND ($3 = '' OR day_off <= $3::date)
+		ORDER BY day_off
+	`, doctorID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query days off: %w", err)
+	}
+	defer rows.Close()
+
+	days := []DayOff{}
+	for rows.Next() {
+		var d DayOff
+		if err := rows.Scan(&d.ID, &d.DoctorID, &d.Date, &d.Reason, &d.CreatedBy, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan day off: %w", err)
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
+
+// AddDayOff records a day a doctor does not work
+func (r *RosterRepository) AddDayOff(d *DayOff) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO doctor_days_off (doctor_id, day_off, reason, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, d.DoctorID, d.Date, d.Reason, d.CreatedBy).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("doctor %d is already off on %s", d.DoctorID, d.Date)
+		}
+		if foreignKeyViolation(err) {
+			return apperr.Validation("doctor %d does not exist", d.DoctorID)
+		}
+		return fmt.Errorf("failed to add day off: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDayOff removes one of a doctor's days off
+func (r *RosterRepository) DeleteDayOff(doctorID, id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM doctor_days_off WHERE id = $1 AND doctor_id = $2", id, doctorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete day off: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return apperr.NotFound("day off %d not found", id)
+	}
+	return nil
+}
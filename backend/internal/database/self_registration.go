@@ -0,0 +1,181 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Self-registration states
+const (
+	SelfRegistrationOpen     = "open"     // link issued, not yet filled in
+	SelfRegistrationPending  = "pending"  // filled in, waiting for reception to verify it
+	SelfRegistrationVerified = "verified" // converted into a patient record
+	SelfRegistrationRejected = "rejected"
+)
+
+// SelfRegistrationStatuses are the states a self-registration form moves through
+var SelfRegistrationStatuses = []string{SelfRegistrationOpen, SelfRegistrationPending, SelfRegistrationVerified, SelfRegistrationRejected}
+
+// SelfRegistration is the demographics form a new patient fills in through a
+// link before their first visit. Reception checks the details against the
+// patient's ID card on arrival and converts the form into a patient record,
+// so nobody types them in at the front desk.
+type SelfRegistration struct {
+	ID           int        `json:"id" db:"id"`
+	Token        string     `json:"-" db:"token"`
+	Status       string     `json:"status" db:"status"`               // open/pending/verified/rejected
+	Label        *string    `json:"label,omitempty" db:"label"`       // who staff gave the link to, e.g. "คุณสมศรี โทรนัด 20 ต.ค."
+	FullName     string     `json:"fullName" db:"full_name"`          // ชื่อ-นามสกุล
+	Gender       string     `json:"gender" db:"gender"`               // เพศ
+	Nickname     *string    `json:"nickname,omitempty" db:"nickname"` // ชื่อเล่น
+	Phone        *string    `json:"phone,omitempty" db:"phone"`
+	DateOfBirth  *string    `json:"dateOfBirth,omitempty" db:"date_of_birth"` // YYYY-MM-DD
+	CitizenID    *string    `json:"citizenId,omitempty" db:"citizen_id"`
+	Notes        *string    `json:"notes,omitempty" db:"notes"` // anything else the patient wants the clinic to know
+	IssuedBy     string     `json:"issuedBy" db:"issued_by"`
+	ExpiresAt    time.Time  `json:"expiresAt" db:"expires_at"` // the link stops accepting the form after this
+	SubmittedAt  *time.Time `json:"submittedAt,omitempty" db:"submitted_at"`
+	PatientHN    *string    `json:"patientHn" db:"patient_hn"` // the patient record it became, once verified
+	ReviewedBy   *string    `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty" db:"reviewed_at"`
+	RejectReason *string    `json:"rejectReason,omitempty" db:"reject_reason"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// Patient is the patient record a submitted form describes, under the given HN
+func (s *SelfRegistration) Patient(hn string) *Patient {
+	return &Patient{
+		HN:          hn,
+		FullName:    s.FullName,
+		Gender:      s.Gender,
+		Nickname:    s.Nickname,
+		Phone:       s.Phone,
+		DateOfBirth: s.DateOfBirth,
+		CitizenID:   s.CitizenID,
+	}
+}
+
+// SelfRegistrationRepository handles self-registration database operations
+type SelfRegistrationRepository struct {
+	db *DB
+}
+
+// NewSelfRegistrationRepository creates a new self-registration repository
+func NewSelfRegistrationRepository(db *DB) *SelfRegistrationRepository {
+	return &SelfRegistrationRepository{db: db}
+}
+
+const selfRegistrationColumns = `id, token, status, label, full_name, gender, nickname, phone, date_of_birth, citizen_id, notes,
+	issued_by, expires_at, submitted_at, patient_hn, reviewed_by, reviewed_at, reject_reason, created_at`
+
+func scanSelfRegistration(row interface{ Scan(...interface{}) error }) (*SelfRegistration, error) {
+	var s SelfRegistration
+	err := row.Scan(&s.ID, &s.Token, &s.Status, &s.Label, &s.FullName, &s.Gender, &s.Nickname, &s.Phone, &s.DateOfBirth,
+		&s.CitizenID, &s.Notes, &s.IssuedBy, &s.ExpiresAt, &s.SubmittedAt, &s.PatientHN, &s.ReviewedBy, &s.ReviewedAt,
+		&s.RejectReason, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Create issues a new self-registration link
+func (r *SelfRegistrationRepository) Create(s *SelfRegistration) error {
+	query := `
+		INSERT INTO self_registrations (token, status, label, issued_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, s.Token, s.Status, s.Label, s.IssuedBy, s.ExpiresAt).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create self-registration: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SelfRegistrationRepository) getOne(where string, arg interface{}, notFound error) (*SelfRegistration, error) {
+	s, err := scanSelfRegistration(r.db.conn.QueryRow("SELECT "+selfRegistrationColumns+" FROM self_registrations WHERE "+where, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound
+		}
+		return nil, fmt.Errorf("failed to get self-registration: %w", err)
+	}
+	return s, nil
+}
+
+// GetByID retrieves a self-registration by ID
+func (r *SelfRegistrationRepository) GetByID(id int) (*SelfRegistration, error) {
+	return r.getOne("id = $1", id, apperr.NotFound("self-registration %d not found", id))
+}
+
+// GetByToken retrieves a self-registration by its link token
+func (r *SelfRegistrationRepository) GetByToken(token string) (*SelfRegistration, error) {
+	return r.getOne("token = $1", token, apperr.NotFound("registration link not found"))
+}
+
+// List retrieves self-registrations in a status, or all of them, newest first
+func (r *SelfRegistrationRepository) List(status string) ([]SelfRegistration, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+selfRegistrationColumns+` FROM self_registrations
+		WHERE $1 = '' OR status = $1
+		ORDER BY COALESCE(submitted_at, created_at) DESC, id DESC
+	`, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query self-registrations: %w", err)
+	}
+	defer rows.Close()
+
+	registrations := []SelfRegistration{}
+	for rows.Next() {
+		s, err := scanSelfRegistration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan self-registration: %w", err)
+		}
+		registrations = append(registrations, *s)
+	}
+
+	return registrations, rows.Err()
+}
+
+// Submit records the patient's details on an open form, leaving it pending
+// verification. A form can only be submitted once.
+func (r *SelfRegistrationRepository) Submit(s *SelfRegistration) error {
+	err := r.db.conn.QueryRow(`
+		UPDATE self_registrations
+		SET status = 'pending', full_name = $2, gender = $3, nickname = $4, phone = $5, date_of_birth = $6,
+			citizen_id = $7, notes = $8, submitted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'open'
+		RETURNING status, submitted_at
+	`, s.ID, s.FullName, s.Gender, s.Nickname, s.Phone, s.DateOfBirth, s.CitizenID, s.Notes).Scan(&s.Status, &s.SubmittedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("self-registration %d has already been submitted", s.ID)
+		}
+		return fmt.Errorf("failed to submit self-registration: %w", err)
+	}
+
+	return nil
+}
+
+// Review closes a pending form: verified with the patient record it became,
+// or rejected with a reason
+func (r *SelfRegistrationRepository) Review(id int, status string, patientHN, reason *string, by string) (*SelfRegistration, error) {
+	s, err := scanSelfRegistration(r.db.conn.QueryRow(`
+		UPDATE self_registrations
+		SET status = $2, patient_hn = $3, reject_reason = $4, reviewed_by = $5, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'
+		RETURNING `+selfRegistrationColumns, id, status, patientHN, reason, by))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("self-registration %d is not pending verification", id)
+		}
+		return nil, fmt.Errorf("failed to review self-registration: %w", err)
+	}
+	return s, nil
+}
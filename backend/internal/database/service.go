@@ -0,0 +1,283 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Service categories, in the order they are listed on invoices
+var ServiceCategories = []string{"consultation", "procedure", "injection", "dressing", "laboratory", "imaging", "other"}
+
+// Service is a billable catalog entry, such as a consultation, a wound
+// dressing or an injection, that visits record and invoices are built from.
+// The eligibility rules limit who it may be booked, given or billed for.
+type Service struct {
+	ID        int       `json:"id" db:"id"`
+	Code      string    `json:"code" db:"code"`         // short code printed on invoices, e.g. "CONS", "DRESS-S"
+	Name      string    `json:"name" db:"name"`         // e.g. "ค่าตรวจแพทย์", "Wound dressing (small)"
+	Category  string    `json:"category" db:"category"` // consultation, procedure, injection, dressing, laboratory, imaging, other
+	Price     float64   `json:"price" db:"price"`       // baht per unit
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+
+	MinAge                 *int     `json:"minAge,omitempty" db:"min_age"`                                  // years, inclusive
+	MaxAge                 *int     `json:"maxAge,omitempty" db:"max_age"`                                  // years, inclusive
+	Genders                []string `json:"genders,omitempty" db:"genders"`                                 // patient genders it is for, e.g. ["หญิง"]; empty means anyone
+	PrerequisiteServiceID  *int     `json:"prerequisiteServiceId,omitempty" db:"prerequisite_service_id"`   // a service, e.g. a lab test, the patient must have had first
+	PrerequisiteWithinDays *int     `json:"prerequisiteWithinDays,omitempty" db:"prerequisite_within_days"` // how recent the prerequisite must be; unset means any time
+}
+
+// ServiceFilter narrows a catalog listing; zero values match everything
+type ServiceFilter struct {
+	Search     string // part of the code or name
+	Category   string
+	ActiveOnly bool
+}
+
+// ServiceRepository handles service catalog database operations
+type ServiceRepository struct {
+	db *DB
+}
+
+// NewServiceRepository creates a new service repository
+func NewServiceRepository(db *DB) *ServiceRepository {
+	return &ServiceRepository{db: db}
+}
+
+const serviceColumns = `id, code, name, category, price, active, created_at, updated_at,
+	min_age, max_age, genders, prerequisite_service_id, prerequisite_within_days`
+
+func scanService(row interface{ Scan(...interface{}) error }) (*Service, error) {
+	var s Service
+	var genders string
+	err := row.Scan(&s.ID, &s.Code, &s.Name, &s.Category, &s.Price, &s.Active, &s.CreatedAt, &s.UpdatedAt,
+		&s.MinAge, &s.MaxAge, &genders, &s.PrerequisiteServiceID, &s.PrerequisiteWithinDays)
+	if err != nil {
+		return nil, err
+	}
+	s.Genders = splitGenders(genders)
+	return &s, nil
+}
+
+func splitGenders(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// Create adds a service to the catalog; codes are unique
+func (r *ServiceRepository) Create(s *Service) error {
+	query := `
+		INSERT INTO services (code, name, category, price, active, min_age, max_age, genders,
+			prerequisite_service_id, prerequisite_within_days)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, s.Code, s.Name, s.Category, s.Price, s.Active, s.MinAge, s.MaxAge,
+		strings.Join(s.Genders, ","), s.PrerequisiteServiceID, s.PrerequisiteWithinDays).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("service code %s is already in the catalog", s.Code)
+		}
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a service by ID
+func (r *ServiceRepository) GetByID(id int) (*Service, error) {
+	s, err := scanService(r.db.conn.QueryRow("SELECT "+serviceColumns+" FROM services WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("service %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	return s, nil
+}
+
+// GetAll retrieves services matching the filter, by category and name
+func (r *ServiceRepository) GetAll(f ServiceFilter) ([]Service, error) {
+	query := `
+		SELECT ` + serviceColumns + ` FROM services
+		WHERE ($1 = '' OR code ILIKE '%' || $1 || '%' OR name ILIKE '%' || $1 || '%')
+			AND ($2 = '' OR category = $2) AND (NOT $3 OR active)
+		ORDER BY category, name
+	`
+
+	rows, err := r.db.conn.Query(query, f.Search, f.Category, f.ActiveOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query services: %w", err)
+	}
+	defer rows.Close()
+
+	services := []Service{}
+	for rows.Next() {
+		s, err := scanService(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service: %w", err)
+		}
+		services = append(services, *s)
+	}
+
+	return services, rows.Err()
+}
+
+// Update saves a service's details
+func (r *ServiceRepository) Update(s *Service) error {
+	query := `
+		UPDATE services SET code = $2, name = $3, category = $4, price = $5, active = $6,
+			min_age = $7, max_age = $8, genders = $9, prerequisite_service_id = $10, prerequisite_within_days = $11,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, s.ID, s.Code, s.Name, s.Category, s.Price, s.Active, s.MinAge, s.MaxAge,
+		strings.Join(s.Genders, ","), s.PrerequisiteServiceID, s.PrerequisiteWithinDays).Scan(&s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("service %d not found", s.ID)
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("service code %s is already in the catalog", s.Code)
+		}
+		return fmt.Errorf("failed to update service: %w", err)
+	}
+
+	return nil
+}
+
+// Deactivate withdraws a service from the catalog. Services are never deleted
+// because visits and invoices keep referring to them.
+func (r *ServiceRepository) Deactivate(id int) error {
+	result, err := r.db.conn.Exec("UPDATE services SET active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate service: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("service %d not found", id)
+	}
+
+	return nil
+}
+
+// VisitService records a catalog service given during a visit. The name and
+// price are copied from the catalog when it is recorded, so later price
+// changes do not alter what the visit is billed.
+type VisitService struct {
+	ID         int       `json:"id" db:"id"`
+	VisitID    int       `json:"visitId" db:"visit_id"`
+	PatientHN  string    `json:"patientHn" db:"patient_hn"`
+	ServiceID  int       `json:"serviceId" db:"service_id"`
+	Name       string    `json:"name" db:"name"`
+	Quantity   float64   `json:"quantity" db:"quantity"`
+	UnitPrice  float64   `json:"unitPrice" db:"unit_price"`
+	Notes      *string   `json:"notes,omitempty" db:"notes"`
+	RecordedBy string    `json:"recordedBy" db:"recorded_by"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// VisitServiceRepository handles the services recorded against visits
+type VisitServiceRepository struct {
+	db *DB
+}
+
+// NewVisitServiceRepository creates a new visit service repository
+func NewVisitServiceRepository(db *DB) *VisitServiceRepository {
+	return &VisitServiceRepository{db: db}
+}
+
+const visitServiceColumns = `id, visit_id, patient_hn, service_id, name, quantity, unit_price, notes, recorded_by, created_at`
+
+func scanVisitService(row interface{ Scan(...interface{}) error }) (*VisitService, error) {
+	var s VisitService
+	err := row.Scan(&s.ID, &s.VisitID, &s.PatientHN, &s.ServiceID, &s.Name, &s.Quantity, &s.UnitPrice, &s.Notes,
+		&s.RecordedBy, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Create records a service given during a visit
+func (r *VisitServiceRepository) Create(s *VisitService) error {
+	query := `
+		INSERT INTO visit_services (visit_id, patient_hn, service_id, name, quantity, unit_price, notes, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, s.VisitID, s.PatientHN, s.ServiceID, s.Name, s.Quantity, s.UnitPrice, s.Notes,
+		s.RecordedBy).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("visit %d or service %d does not exist", s.VisitID, s.ServiceID)
+		}
+		return fmt.Errorf("failed to record visit service: %w", err)
+	}
+
+	return nil
+}
+
+// GetByVisit retrieves the services recorded during a visit, in the order they were recorded
+func (r *VisitServiceRepository) GetByVisit(visitID int) ([]VisitService, error) {
+	rows, err := r.db.conn.Query("SELECT "+visitServiceColumns+" FROM visit_services WHERE visit_id = $1 ORDER BY id", visitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit services: %w", err)
+	}
+	defer rows.Close()
+
+	services := []VisitService{}
+	for rows.Next() {
+		s, err := scanVisitService(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan visit service: %w", err)
+		}
+		services = append(services, *s)
+	}
+
+	return services, rows.Err()
+}
+
+// LastGiven returns when a patient was last given a service, or nil if never
+func (r *VisitServiceRepository) LastGiven(hn string, serviceID int) (*time.Time, error) {
+	var last *time.Time
+	err := r.db.conn.QueryRow(
+		"SELECT MAX(created_at) FROM visit_services WHERE patient_hn = $1 AND service_id = $2", hn, serviceID).Scan(&last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit services: %w", err)
+	}
+	return last, nil
+}
+
+// Delete removes a service recorded in error from a visit
+func (r *VisitServiceRepository) Delete(visitID, id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM visit_services WHERE id = $1 AND visit_id = $2", id, visitID)
+	if err != nil {
+		return fmt.Errorf("failed to delete visit service: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("service %d not recorded on visit %d", id, visitID)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Task statuses
+const (
+	TaskOpen      = "open"
+	TaskDone      = "done"
+	TaskCancelled = "cancelled"
+)
+
+// taskTransitions lists the statuses each task status may move to; finished tasks can be reopened
+var taskTransitions = map[string][]string{
+	TaskOpen:      {TaskDone, TaskCancelled},
+	TaskDone:      {TaskOpen},
+	TaskCancelled: {TaskOpen},
+}
+
+// Task is a to-do for a member of staff, e.g. calling a patient about a
+// result or chasing an insurer, optionally about one patient
+type Task struct {
+	ID          int        `json:"id" db:"id"`
+	Title       string     `json:"title" db:"title"`
+	Details     *string    `json:"details,omitempty" db:"details"`
+	AssignedTo  string     `json:"assignedTo" db:"assigned_to"` // staff member's name
+	PatientHN   *string    `json:"patientHn,omitempty" db:"patient_hn"`
+	DueDate     *string    `json:"dueDate,omitempty" db:"due_date"` // YYYY-MM-DD
+	Status      string     `json:"status" db:"status"`
+	Overdue     bool       `json:"overdue" db:"-"` // open past its due date
+	CreatedBy   string     `json:"createdBy" db:"created_by"`
+	CompletedBy *string    `json:"completedBy,omitempty" db:"completed_by"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// CanMoveTo reports whether the task may change to status
+func (t *Task) CanMoveTo(status string) bool {
+	for _, s := range taskTransitions[t.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkOverdue sets Overdue for an open task due before today (YYYY-MM-DD)
+func (t *Task) MarkOverdue(today string) {
+	t.Overdue = t.Status == TaskOpen && t.DueDate != nil && *t.DueDate < today
+}
+
+// TaskFilter narrows a task listing; zero values match everything.
+// DueBefore (YYYY-MM-DD) keeps tasks due before that day.
+type TaskFilter struct {
+	AssignedTo string
+	Status     string
+	PatientHN  string
+	DueBefore  string
+}
+
+// TaskRepository handles task database operations
+type TaskRepository struct {
+	db *DB
+}
+
+// NewTaskRepository creates a new task repository
+func NewTaskRepository(db *DB) *TaskRepository {
+	return &TaskRepository{db: db}
+}
+
+const taskColumns = `id, title, details, assigned_to, patient_hn, to_char(due_date, 'YYYY-MM-DD'), status, created_by,
+	completed_by, completed_at, created_at, updated_at`
+
+func scanTask(row interface{ Scan(...interface{}) error }) (*Task, error) {
+	var t Task
+	err := row.Scan(&t.ID, &t.Title, &t.Details, &t.AssignedTo, &t.PatientHN, &t.DueDate, &t.Status, &t.CreatedBy,
+		&t.CompletedBy, &t.CompletedAt, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Create stores a new task
+func (r *TaskRepository) Create(t *Task) error {
+	query := `
+		INSERT INTO tasks (title, details, assigned_to, patient_hn, due_date, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, t.Title, t.Details, t.AssignedTo, t.PatientHN, t.DueDate, t.Status, t.CreatedBy).
+		Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a task by ID
+func (r *TaskRepository) GetByID(id int) (*Task, error) {
+	t, err := scanTask(r.db.conn.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("task %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return t, nil
+}
+
+// List retrieves tasks matching the filter, soonest due first and undated last
+func (r *TaskRepository) List(f TaskFilter) ([]Task, error) {
+	query := `
+		SELECT ` + taskColumns + ` FROM tasks
+		WHERE ($1 = '' OR lower(assigned_to) = lower($1)) AND ($2 = '' OR status = $2) AND ($3 = '' OR patient_hn = $3)
+			AND ($4 = '' OR due_date < $4::date)
+		ORDER BY due_date NULLS LAST, created_at, id
+	`
+
+	rows, err := r.db.conn.Query(query, f.AssignedTo, f.Status, f.PatientHN, f.DueBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, *t)
+	}
+
+	return tasks, rows.Err()
+}
+
+// Update replaces an open task's title, details, assignee, patient and due date
+func (r *TaskRepository) Update(t *Task) error {
+	updated, err := scanTask(r.db.conn.QueryRow(`
+		UPDATE tasks SET title = $2, details = $3, assigned_to = $4, patient_hn = $5, due_date = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'open'
+		RETURNING `+taskColumns, t.ID, t.Title, t.Details, t.AssignedTo, t.PatientHN, t.DueDate))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Conflict("task %d is not open", t.ID)
+		}
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	*t = *updated
+
+	return nil
+}
+
+// UpdateStatus moves a task from one status to another; completing it records
+// who did it and when, reopening it clears that
+func (r *TaskRepository) UpdateStatus(id int, from, to, by string) (*Task, error) {
+	t, err := scanTask(r.db.conn.QueryRow(`
+		UPDATE tasks SET status = $3, updated_at = CURRENT_TIMESTAMP,
+			completed_by = CASE WHEN $3 = 'done' THEN $4 ELSE NULL END,
+			completed_at = CASE WHEN $3 = 'done' THEN CURRENT_TIMESTAMP ELSE NULL END
+		WHERE id = $1 AND status = $2
+		RETURNING `+taskColumns, id, from, to, by))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Conflict("task %d is no longer %s", id, from)
+		}
+		return nil, fmt.Errorf("failed to update task status: %w", err)
+	}
+	return t, nil
+}
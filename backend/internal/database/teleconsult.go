@@ -0,0 +1,214 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Teleconsult states
+const (
+	TeleconsultScheduled  = "scheduled"
+	TeleconsultInProgress = "in_progress" // the doctor has joined
+	TeleconsultEnded      = "ended"
+	TeleconsultCancelled  = "cancelled"
+)
+
+// TeleconsultStatuses are the states a teleconsult session moves through
+var TeleconsultStatuses = []string{TeleconsultScheduled, TeleconsultInProgress, TeleconsultEnded, TeleconsultCancelled}
+
+// Teleconsult is a video visit for an appointment: a room the patient and the
+// doctor join with their own links. The session starts when the doctor joins,
+// which opens the appointment's visit, and ends when the doctor hangs up or
+// staff end it.
+type Teleconsult struct {
+	ID              int        `json:"id" db:"id"`
+	AppointmentID   int        `json:"appointmentId" db:"appointment_id"`
+	PatientHN       string     `json:"patientHn" db:"patient_hn"`
+	DoctorID        *int       `json:"doctorId,omitempty" db:"doctor_id"`
+	DoctorName      string     `json:"doctorName" db:"doctor_name"`
+	RoomToken       string     `json:"-" db:"room_token"`    // names the room at the video service
+	PatientToken    string     `json:"-" db:"patient_token"` // in the patient's join link
+	DoctorToken     string     `json:"-" db:"doctor_token"`  // in the doctor's join link
+	ScheduledAt     time.Time  `json:"scheduledAt" db:"scheduled_at"`
+	Status          string     `json:"status" db:"status"` // scheduled/in_progress/ended/cancelled
+	PatientJoinedAt *time.Time `json:"patientJoinedAt,omitempty" db:"patient_joined_at"`
+	StartedAt       *time.Time `json:"startedAt,omitempty" db:"started_at"`
+	EndedAt         *time.Time `json:"endedAt,omitempty" db:"ended_at"`
+	VisitID         *int       `json:"visitId,omitempty" db:"visit_id"` // the encounter the consult is recorded as
+	CreatedBy       string     `json:"createdBy" db:"created_by"`
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// TeleconsultFilter narrows a teleconsult listing by scheduled time; zero values match everything
+type TeleconsultFilter struct {
+	DoctorID int
+	Status   string
+	From     time.Time
+	To       time.Time
+}
+
+// TeleconsultRepository handles teleconsult session database operations
+type TeleconsultRepository struct {
+	db *DB
+}
+
+// NewTeleconsultRepository creates a new teleconsult repository
+func NewTeleconsultRepository(db *DB) *TeleconsultRepository {
+	return &TeleconsultRepository{db: db}
+}
+
+const teleconsultColumns = `id, appointment_id, patient_hn, doctor_id, doctor_name, room_token, patient_token, doctor_token,
+	scheduled_at, status, patient_joined_at, started_at, ended_at, visit_id, created_by, created_at, updated_at`
+
+func scanTeleconsult(row interface{ Scan(...interface{}) error }) (*Teleconsult, error) {
+	var t Teleconsult
+	err := row.Scan(&t.ID, &t.AppointmentID, &t.PatientHN, &t.DoctorID, &t.DoctorName, &t.RoomToken, &t.PatientToken,
+		&t.DoctorToken, &t.ScheduledAt, &t.Status, &t.PatientJoinedAt, &t.StartedAt, &t.EndedAt, &t.VisitID, &t.CreatedBy,
+		&t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Create schedules a session; an appointment has at most one that is not cancelled
+func (r *TeleconsultRepository) Create(t *Teleconsult) error {
+	err := r.db.conn.QueryRow(`
+		INSERT INTO teleconsults (appointment_id, patient_hn, doctor_id, doctor_name, room_token, patient_token,
+			doctor_token, scheduled_at, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`, t.AppointmentID, t.PatientHN, t.DoctorID, t.DoctorName, t.RoomToken, t.PatientToken, t.DoctorToken, t.ScheduledAt,
+		t.Status, t.CreatedBy).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("appointment %d does not exist", t.AppointmentID)
+		}
+		if uniqueViolation(err) {
+			return apperr.Conflict("appointment %d already has a teleconsult", t.AppointmentID)
+		}
+		return fmt.Errorf("failed to create teleconsult: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a session, from the archive if its visit has been archived
+func (r *TeleconsultRepository) GetByID(id int) (*Teleconsult, error) {
+	t, err := scanTeleconsult(r.db.conn.QueryRow("SELECT "+teleconsultColumns+" FROM teleconsults WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		t, err = scanTeleconsult(r.db.conn.QueryRow("SELECT "+teleconsultColumns+" FROM "+archived("teleconsults")+" WHERE id = $1", id))
+		err = notArchived(err)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("teleconsult %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get teleconsult: %w", err)
+	}
+	return t, nil
+}
+
+// GetByToken retrieves the session a patient or doctor join link belongs to
+func (r *TeleconsultRepository) GetByToken(token string) (*Teleconsult, error) {
+	t, err := scanTeleconsult(r.db.conn.QueryRow("SELECT "+teleconsultColumns+
+		" FROM teleconsults WHERE patient_token = $1 OR doctor_token = $1", token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("teleconsult not found")
+		}
+		return nil, fmt.Errorf("failed to get teleconsult: %w", err)
+	}
+	return t, nil
+}
+
+// List retrieves sessions matching the filter, earliest first
+func (r *TeleconsultRepository) List(f TeleconsultFilter) ([]Teleconsult, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if f.DoctorID != 0 {
+		add("doctor_id = $%d", f.DoctorID)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if !f.From.IsZero() {
+		add("scheduled_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("scheduled_at < $%d", f.To)
+	}
+
+	rows, err := r.db.conn.Query("SELECT "+teleconsultColumns+" FROM teleconsults WHERE "+strings.Join(conditions, " AND ")+
+		" ORDER BY scheduled_at, id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teleconsults: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Teleconsult{}
+	for rows.Next() {
+		t, err := scanTeleconsult(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan teleconsult: %w", err)
+		}
+		sessions = append(sessions, *t)
+	}
+	return sessions, rows.Err()
+}
+
+// MarkPatientJoined records when the patient first joined
+func (r *TeleconsultRepository) MarkPatientJoined(id int) (*Teleconsult, error) {
+	_, err := r.db.conn.Exec(`
+		UPDATE teleconsults SET patient_joined_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND patient_joined_at IS NULL
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update teleconsult: %w", err)
+	}
+	return r.GetByID(id)
+}
+
+// Start moves a scheduled session in progress, recording the visit it opened
+func (r *TeleconsultRepository) Start(id, visitID int) (*Teleconsult, error) {
+	return r.transition(id, TeleconsultScheduled, TeleconsultInProgress,
+		"started_at = CURRENT_TIMESTAMP, visit_id = $4", visitID)
+}
+
+// End finishes a session in progress
+func (r *TeleconsultRepository) End(id int) (*Teleconsult, error) {
+	return r.transition(id, TeleconsultInProgress, TeleconsultEnded, "ended_at = CURRENT_TIMESTAMP")
+}
+
+// Cancel calls off a session that has not started
+func (r *TeleconsultRepository) Cancel(id int) (*Teleconsult, error) {
+	return r.transition(id, TeleconsultScheduled, TeleconsultCancelled, "ended_at = CURRENT_TIMESTAMP")
+}
+
+// transition moves a session from one status to another, setting the given
+// columns too; a session in any other status is a conflict
+func (r *TeleconsultRepository) transition(id int, from, to, set string, args ...interface{}) (*Teleconsult, error) {
+	result, err := r.db.conn.Exec("UPDATE teleconsults SET status = $3, "+set+", updated_at = CURRENT_TIMESTAMP"+
+		" WHERE id = $1 AND status = $2", append([]interface{}{id, from, to}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update teleconsult: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		t, err := r.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, apperr.Conflict("teleconsult %d is %s, not %s", id, t.Status, from)
+	}
+	return r.GetByID(id)
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Triage urgency levels, most urgent first, after the five-level Emergency
+// Severity Index used by Thai emergency departments
+const (
+	TriageResuscitation = "resuscitation" // life-threatening, seen at once
+	TriageEmergent      = "emergent"      // high risk, e.g. chest pain
+	TriageUrgent        = "urgent"        // stable but needs several resources
+	TriageLessUrgent    = "less_urgent"
+	TriageNonUrgent     = "non_urgent"
+)
+
+// TriageLevels lists every urgency level, most urgent first
+var TriageLevels = []string{TriageResuscitation, TriageEmergent, TriageUrgent, TriageLessUrgent, TriageNonUrgent}
+
+// Triage is the nurse's assessment of a walk-in at check-in. Its urgency
+// sets the patient's place in the queue; a patient can be re-triaged while
+// waiting, and the latest assessment counts.
+type Triage struct {
+	ID                  int         `json:"id" db:"id"`
+	QueueEntryID        int         `json:"queueEntryId" db:"queue_entry_id"`
+	PatientHN           string      `json:"patientHn" db:"patient_hn"`
+	VisitID             *int        `json:"visitId,omitempty" db:"visit_id"`
+	PresentingComplaint string      `json:"presentingComplaint" db:"presenting_complaint"` // อาการนำ, e.g. "เจ็บหน้าอก 30 นาที"
+	Urgency             string      `json:"urgency" db:"urgency"`                          // one of TriageLevels
+	PainScore           *int        `json:"painScore,omitempty" db:"pain_score"`           // 0-10
+	VitalsID            *int        `json:"vitalsId,omitempty" db:"vitals_id"`             // initial vital signs, recorded with the triage
+	Vitals              *VitalSigns `json:"vitals,omitempty" db:"-"`
+	Notes               *string     `json:"notes,omitempty" db:"notes"`
+	TriagedBy           string      `json:"triagedBy" db:"triaged_by"`
+	CreatedAt           time.Time   `json:"createdAt" db:"created_at"`
+}
+
+// TriageRepository handles triage database operations
+type TriageRepository struct {
+	db *DB
+}
+
+// NewTriageRepository creates a new triage repository
+func NewTriageRepository(db *DB) *TriageRepository {
+	return &TriageRepository{db: db}
+}
+
+const triageColumns = `id, queue_entry_id, patient_hn, visit_id, presenting_complaint, urgency, pain_score, vitals_id, notes,
+	triaged_by, created_at`
+
+func scanTriage(row interface{ Scan(...interface{}) error }) (*Triage, error) {
+	var t Triage
+	err := row.Scan(&t.ID, &t.QueueEntryID, &t.PatientHN, &t.VisitID, &t.PresentingComplaint, &t.Urgency, &t.PainScore,
+		&t.VitalsID, &t.Notes, &t.TriagedBy, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Create records a triage assessment
+func (r *TriageRepository) Create(t *Triage) error {
+	query := `
+		INSERT INTO triages (queue_entry_id, patient_hn, visit_id, presenting_complaint, urgency, pain_score, vitals_id, notes, triaged_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, t.QueueEntryID, t.PatientHN, t.VisitID, t.PresentingComplaint, t.Urgency, t.PainScore,
+		t.VitalsID, t.Notes, t.TriagedBy).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create triage: %w", err)
+	}
+
+	return nil
+}
+
+// GetByQueueEntry retrieves the assessments of a queue entry, latest first
+func (r *TriageRepository) GetByQueueEntry(queueEntryID int) ([]Triage, error) {
+	rows, err := r.db.conn.Query("SELECT "+triageColumns+" FROM triages WHERE queue_entry_id = $1 ORDER BY created_at DESC, id DESC", queueEntryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query triages: %w", err)
+	}
+	defer rows.Close()
+
+	triages := []Triage{}
+	for rows.Next() {
+		t, err := scanTriage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan triage: %w", err)
+		}
+		triages = append(triages, *t)
+	}
+
+	return triages, rows.Err()
+}
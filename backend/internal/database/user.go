@@ -0,0 +1,194 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/reqctx"
+)
+
+// UserRoles are the roles a staff account can be given
+var UserRoles = []string{
+	reqctx.RoleAdmin, reqctx.RoleDoctor, reqctx.RoleNurse, reqctx.RolePharmacist, reqctx.RoleCashier, reqctx.RoleReception,
+}
+
+// User is a staff account. Accounts are deactivated rather than deleted so
+// the names recorded on past work keep pointing at someone.
+type User struct {
+	ID                 int        `json:"id" db:"id"`
+	Username           string     `json:"username" db:"username"` // lowercase, unique
+	FullName           string     `json:"fullName" db:"full_name"`
+	Role               string     `json:"role" db:"role"`
+	DoctorID           *int       `json:"doctorId,omitempty" db:"doctor_id"` // the doctor record a doctor's account acts as
+	Email              *string    `json:"email,omitempty" db:"email"`
+	Active             bool       `json:"active" db:"active"`
+	PasswordHash       string     `json:"-" db:"password_hash"`
+	MustChangePassword bool       `json:"mustChangePassword" db:"must_change_password"` // set by a reset until the user picks their own
+	PasswordChangedAt  *time.Time `json:"passwordChangedAt,omitempty" db:"password_changed_at"`
+	DeactivatedAt      *time.Time `json:"deactivatedAt,omitempty" db:"deactivated_at"`
+	DeactivatedBy      *string    `json:"deactivatedBy,omitempty" db:"deactivated_by"`
+	CreatedBy          string     `json:"createdBy" db:"created_by"`
+	CreatedAt          time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt          time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// UserFilter narrows a user listing; zero values match everything
+type UserFilter struct {
+	Role       string
+	ActiveOnly bool
+}
+
+// UserRepository handles staff account database operations
+type UserRepository struct {
+	db *DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+const userColumns = `id, username, full_name, role, doctor_id, email, active, password_hash, must_change_password,
+	password_changed_at, deactivated_at, deactivated_by, created_by, created_at, updated_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	var u User
+	err := row.Scan(&u.ID, &u.Username, &u.FullName, &u.Role, &u.DoctorID, &u.Email, &u.Active, &u.PasswordHash,
+		&u.MustChangePassword, &u.PasswordChangedAt, &u.DeactivatedAt, &u.DeactivatedBy, &u.CreatedBy, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Create inserts a new account; the username must be unused
+func (r *UserRepository) Create(u *User) error {
+	query := `
+		INSERT INTO users (username, full_name, role, doctor_id, email, active, password_hash, must_change_password,
+			password_changed_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, $9)
+		RETURNING id, password_changed_at, created_at, updated_at
+	`
+
+	err := r.db.conn.QueryRow(query, u.Username, u.FullName, u.Role, u.DoctorID, u.Email, u.Active, u.PasswordHash,
+		u.MustChangePassword, u.CreatedBy).Scan(&u.ID, &u.PasswordChangedAt, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		if uniqueViolation(err) {
+			return apperr.Conflict("username %s is already taken", u.Username)
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an account by ID
+func (r *UserRepository) GetByID(id int) (*User, error) {
+	u, err := scanUser(r.db.conn.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("user %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return u, nil
+}
+
+// GetByUsername retrieves an account by its username
+func (r *UserRepository) GetByUsername(username string) (*User, error) {
+	u, err := scanUser(r.db.conn.QueryRow("SELECT "+userColumns+" FROM users WHERE username = $1", username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("user %s not found", username)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return u, nil
+}
+
+// GetAll retrieves accounts matching the filter, by username
+func (r *UserRepository) GetAll(f UserFilter) ([]User, error) {
+	query := `
+		SELECT ` + userColumns + ` FROM users
+		WHERE ($1 = '' OR role = $1) AND (NOT $2 OR active)
+		ORDER BY username
+	`
+
+	rows, err := r.db.conn.Query(query, f.Role, f.ActiveOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *u)
+	}
+
+	return users, rows.Err()
+}
+
+// Update saves an account's name, role, doctor record and email; the
+// username, password and active state have their own operations
+func (r *UserRepository) Update(u *User) error {
+	query := `
+		UPDATE users SET full_name = $2, role = $3, doctor_id = $4, email = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + userColumns
+
+	updated, err := scanUser(r.db.conn.QueryRow(query, u.ID, u.FullName, u.Role, u.DoctorID, u.Email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.NotFound("user %d not found", u.ID)
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	*u = *updated
+	return nil
+}
+
+// SetActive deactivates an account, recording who did so, or reactivates it
+func (r *UserRepository) SetActive(id int, active bool, by string) (*User, error) {
+	query := `
+		UPDATE users SET active = $2,
+			deactivated_at = CASE WHEN $2 THEN NULL ELSE CURRENT_TIMESTAMP END,
+			deactivated_by = CASE WHEN $2 THEN NULL ELSE $3 END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + userColumns
+
+	u, err := scanUser(r.db.conn.QueryRow(query, id, active, by))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("user %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return u, nil
+}
+
+// SetPassword replaces an account's password hash; mustChange asks the user
+// to choose a new password when they next sign in
+func (r *UserRepository) SetPassword(id int, hash string, mustChange bool) (*User, error) {
+	query := `
+		UPDATE users SET password_hash = $2, must_change_password = $3,
+			password_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + userColumns
+
+	u, err := scanUser(r.db.conn.QueryRow(query, id, hash, mustChange))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("user %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to set password: %w", err)
+	}
+	return u, nil
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Vaccination is one vaccine dose given to a patient
+type Vaccination struct {
+	ID               int       `json:"id" db:"id"`
+	PatientHN        string    `json:"patientHn" db:"patient_hn"`
+	Vaccine          string    `json:"vaccine" db:"vaccine"`                     // e.g. "MMR", "DTP-HB-Hib", "Influenza"
+	DoseNumber       int       `json:"doseNumber" db:"dose_number"`              // 1 for the first dose, counting boosters on
+	LotNumber        string    `json:"lotNumber" db:"lot_number"`                // เลขที่ผลิต, for recalls
+	StockItemID      *int      `json:"stockItemId,omitempty" db:"stock_item_id"` // vaccine stock item the dose was taken from; nil on doses recorded before lots were tracked
+	AdministeredDate string    `json:"administeredDate" db:"administered_date"`  // YYYY-MM-DD
+	AdministeredBy   string    `json:"administeredBy" db:"administered_by"`
+	Site             *string   `json:"site,omitempty" db:"site"` // e.g. "left deltoid"
+	Notes            *string   `json:"notes,omitempty" db:"notes"`
+	CreatedAt        time.Time `json:"createdAt" db:"created_at"`
+}
+
+// VaccinationRepository handles vaccination record database operations
+type VaccinationRepository struct {
+	db *DB
+}
+
+// NewVaccinationRepository creates a new vaccination repository
+func NewVaccinationRepository(db *DB) *VaccinationRepository {
+	return &VaccinationRepository{db: db}
+}
+
+const vaccinationColumns = `id, patient_hn, vaccine, dose_number, lot_number, stock_item_id, to_char(administered_date, 'YYYY-MM-DD'),
+	administered_by, site, notes, created_at`
+
+func scanVaccination(row interface{ Scan(...interface{}) error }) (*Vaccination, error) {
+	var v Vaccination
+	err := row.Scan(&v.ID, &v.PatientHN, &v.Vaccine, &v.DoseNumber, &v.LotNumber, &v.StockItemID, &v.AdministeredDate,
+		&v.AdministeredBy, &v.Site, &v.Notes, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Create records a dose
+func (r *VaccinationRepository) Create(v *Vaccination) error {
+	query := `
+		INSERT INTO vaccinations (patient_hn, vaccine, dose_number, lot_number, stock_item_id, administered_date, administered_by,
+			site, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, v.PatientHN, v.Vaccine, v.DoseNumber, v.LotNumber, v.StockItemID, v.AdministeredDate, v.AdministeredBy,
+		v.Site, v.Notes).
+		Scan(&v.ID, &v.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record vaccination: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves a patient's doses, or every patient's when hn is empty, in
+// the order they were given
+func (r *VaccinationRepository) List(hn string) ([]Vaccination, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+vaccinationColumns+` FROM vaccinations
+		WHERE $1 = '' OR patient_hn = $1
+		ORDER BY patient_hn, administered_date, id`, hn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vaccinations: %w", err)
+	}
+	defer rows.Close()
+
+	vaccinations := []Vaccination{}
+	for rows.Next() {
+		v, err := scanVaccination(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan vaccination: %w", err)
+		}
+		vaccinations = append(vaccinations, *v)
+	}
+
+	return vaccinations, rows.Err()
+}
+
+// Delete removes a dose recorded by mistake
+func (r *VaccinationRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec("DELETE FROM vaccinations WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete vaccination: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.NotFound("vaccination %d not found", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// Summary link access outcomes
+const (
+	SummaryAccessViewed  = "viewed"
+	SummaryAccessExpired = "expired" // opened after the link stopped working
+	SummaryAccessRevoked = "revoked"
+)
+
+// SummaryAccessOutcomes are what happened when a summary link was opened
+var SummaryAccessOutcomes = []string{SummaryAccessViewed, SummaryAccessExpired, SummaryAccessRevoked}
+
+// VisitSummaryLink is a time-limited link that shows a patient the summary of
+// a closed visit (diagnosis, instructions and prescriptions), sent to them
+// after checkout instead of emailing the summary as an attachment. Every
+// opening of the link is logged.
+type VisitSummaryLink struct {
+	ID           int        `json:"id" db:"id"`
+	Token        string     `json:"-" db:"token"`
+	VisitID      int        `json:"visitId" db:"visit_id"`
+	PatientHN    string     `json:"patientHn" db:"patient_hn"`
+	Instructions *string    `json:"instructions,omitempty" db:"instructions"` // advice shown to the patient, from the visit plan unless written
+	IssuedBy     string     `json:"issuedBy" db:"issued_by"`
+	ExpiresAt    time.Time  `json:"expiresAt" db:"expires_at"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty" db:"revoked_at"`
+	RevokedBy    *string    `json:"revokedBy,omitempty" db:"revoked_by"`
+	Views        int        `json:"views" db:"-"` // successful openings
+	LastViewedAt *time.Time `json:"lastViewedAt,omitempty" db:"-"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// SummaryLinkAccess is one opening of a visit summary link
+type SummaryLinkAccess struct {
+	ID         int       `json:"id" db:"id"`
+	LinkID     int       `json:"linkId" db:"link_id"`
+	Outcome    string    `json:"outcome" db:"outcome"`      // viewed, expired, revoked
+	IPAddress  string    `json:"ipAddress" db:"ip_address"` // as seen by the server, a proxy's address behind one
+	UserAgent  string    `json:"userAgent" db:"user_agent"`
+	AccessedAt time.Time `json:"accessedAt" db:"accessed_at"`
+}
+
+// VisitSummaryRepository handles visit summary link database operations
+type VisitSummaryRepository struct {
+	db *DB
+}
+
+// NewVisitSummaryRepository creates a new visit summary link repository
+func NewVisitSummaryRepository(db *DB) *VisitSummaryRepository {
+	return &VisitSummaryRepository{db: db}
+}
+
+const visitSummaryLinkColumns = `l.id, l.token, l.visit_id, l.patient_hn, l.instructions, l.issued_by, l.expires_at,
+	l.revoked_at, l.revoked_by, l.created_at,
+	(SELECT COUNT(*) FROM summary_link_accesses a WHERE a.link_id = l.id AND a.outcome = 'viewed'),
+	(SELECT MAX(accessed_at) FROM summary_link_accesses a WHERE a.link_id = l.id AND a.outcome = 'viewed')`
+
+func scanVisitSummaryLink(row interface{ Scan(...interface{}) error }) (*VisitSummaryLink, error) {
+	var l VisitSummaryLink
+	err := row.Scan(&l.ID, &l.Token, &l.VisitID, &l.PatientHN, &l.Instructions, &l.IssuedBy, &l.ExpiresAt,
+		&l.RevokedAt, &l.RevokedBy, &l.CreatedAt, &l.Views, &l.LastViewedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Create issues a new summary link
+func (r *VisitSummaryRepository) Create(l *VisitSummaryLink) error {
+	query := `
+		INSERT INTO visit_summary_links (token, visit_id, patient_hn, instructions, issued_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, l.Token, l.VisitID, l.PatientHN, l.Instructions, l.IssuedBy, l.ExpiresAt).
+		Scan(&l.ID, &l.CreatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("visit %d does not exist", l.VisitID)
+		}
+		return fmt.Errorf("failed to create summary link: %w", err)
+	}
+
+	return nil
+}
+
+func (r *VisitSummaryRepository) getOne(where string, arg interface{}, notFound error) (*VisitSummaryLink, error) {
+	l, err := scanVisitSummaryLink(r.db.conn.QueryRow("SELECT "+visitSummaryLinkColumns+" FROM visit_summary_links l WHERE "+where, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound
+		}
+		return nil, fmt.Errorf("failed to get summary link: %w", err)
+	}
+	return l, nil
+}
+
+// GetByID retrieves a summary link by ID
+func (r *VisitSummaryRepository) GetByID(id int) (*VisitSummaryLink, error) {
+	return r.getOne("l.id = $1", id, apperr.NotFound("summary link %d not found", id))
+}
+
+// GetByToken retrieves a summary link by its token
+func (r *VisitSummaryRepository) GetByToken(token string) (*VisitSummaryLink, error) {
+	return r.getOne("l.token = $1", token, apperr.NotFound("summary link not found"))
+}
+
+// GetByVisit retrieves the links issued for a visit, newest first
+func (r *VisitSummaryRepository) GetByVisit(visitID int) ([]VisitSummaryLink, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT `+visitSummaryLinkColumns+` FROM visit_summary_links l
+		WHERE l.visit_id = $1
+		ORDER BY l.created_at DESC, l.id DESC
+	`, visitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query summary links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []VisitSummaryLink{}
+	for rows.Next() {
+		l, err := scanVisitSummaryLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan summary link: %w", err)
+		}
+		links = append(links, *l)
+	}
+
+	return links, rows.Err()
+}
+
+// Revoke stops a link working before it expires
+func (r *VisitSummaryRepository) Revoke(id int, by string) (*VisitSummaryLink, error) {
+	result, err := r.db.conn.Exec(`
+		UPDATE visit_summary_links SET revoked_at = CURRENT_TIMESTAMP, revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id, by)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke summary link: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		if _, err := r.GetByID(id); err != nil {
+			return nil, err
+		}
+		return nil, apperr.Conflict("summary link %d has already been revoked", id)
+	}
+	return r.GetByID(id)
+}
+
+// LogAccess records one opening of a link
+func (r *VisitSummaryRepository) LogAccess(a *SummaryLinkAccess) error {
+	query := `
+		INSERT INTO summary_link_accesses (link_id, outcome, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, accessed_at
+	`
+
+	err := r.db.conn.QueryRow(query, a.LinkID, a.Outcome, a.IPAddress, a.UserAgent).Scan(&a.ID, &a.AccessedAt)
+	if err != nil {
+		return fmt.Errorf("failed to log summary link access: %w", err)
+	}
+
+	return nil
+}
+
+// GetAccesses retrieves a link's access log, most recent first
+func (r *VisitSummaryRepository) GetAccesses(linkID int) ([]SummaryLinkAccess, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT id, link_id, outcome, ip_address, user_agent, accessed_at FROM summary_link_accesses
+		WHERE link_id = $1
+		ORDER BY accessed_at DESC, id DESC
+	`, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query summary link accesses: %w", err)
+	}
+	defer rows.Close()
+
+	accesses := []SummaryLinkAccess{}
+	for rows.Next() {
+		var a SummaryLinkAccess
+		if err := rows.Scan(&a.ID, &a.LinkID, &a.Outcome, &a.IPAddress, &a.UserAgent, &a.AccessedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan summary link access: %w", err)
+		}
+		accesses = append(accesses, a)
+	}
+
+	return accesses, rows.Err()
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"clinic/backend/internal/apperr"
+)
+
+// VitalSigns is one set of measurements taken from a patient, usually at
+// triage during a visit. Any measurement may be left out.
+type VitalSigns struct {
+	ID          int       `json:"id" db:"id"`
+	PatientHN   string    `json:"patientHn" db:"patient_hn"`
+	VisitID     *int      `json:"visitId,omitempty" db:"visit_id"`
+	SystolicBP  *int      `json:"systolicBp,omitempty" db:"systolic_bp"`   // mmHg
+	DiastolicBP *int      `json:"diastolicBp,omitempty" db:"diastolic_bp"` // mmHg
+	Pulse       *int      `json:"pulse,omitempty" db:"pulse"`              // beats per minute
+	Temperature *float64  `json:"temperature,omitempty" db:"temperature"`  // °C
+	Weight      *float64  `json:"weight,omitempty" db:"weight"`            // kg
+	Height      *float64  `json:"height,omitempty" db:"height"`            // cm
+	SpO2        *int      `json:"spo2,omitempty" db:"spo2"`                // %
+	BMI         *float64  `json:"bmi,omitempty" db:"-"`                    // from weight and height, when both were taken
+	Notes       *string   `json:"notes,omitempty" db:"notes"`
+	MeasuredAt  time.Time `json:"measuredAt" db:"measured_at"`
+	RecordedBy  string    `json:"recordedBy" db:"recorded_by"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+// CalculateBMI works out the BMI, to one decimal, when weight and height were both taken
+func (v *VitalSigns) CalculateBMI() {
+	v.BMI = nil
+	if v.Weight == nil || v.Height == nil || *v.Height <= 0 {
+		return
+	}
+	metres := *v.Height / 100
+	bmi := math.Round(*v.Weight/(metres*metres)*10) / 10
+	v.BMI = &bmi
+}
+
+// VitalsFilter selects a patient's or a visit's vital signs; zero values match everything
+type VitalsFilter struct {
+	PatientHN string
+	VisitID   int
+	From      time.Time
+	To        time.Time
+}
+
+// VitalsRepository handles vital signs database operations
+type VitalsRepository struct {
+	db *DB
+}
+
+// NewVitalsRepository creates a new vital signs repository
+func NewVitalsRepository(db *DB) *VitalsRepository {
+	return &VitalsRepository{db: db}
+}
+
+const vitalsColumns = `id, patient_hn, visit_id, systolic_bp, diastolic_bp, pulse, temperature, weight, height, spo2,
+	notes, measured_at, recorded_by, created_at`
+
+func scanVitals(row interface{ Scan(...interface{}) error }) (*VitalSigns, error) {
+	var v VitalSigns
+	err := row.Scan(&v.ID, &v.PatientHN, &v.VisitID, &v.SystolicBP, &v.DiastolicBP, &v.Pulse, &v.Temperature, &v.Weight, &v.Height, &v.SpO2,
+		&v.Notes, &v.MeasuredAt, &v.RecordedBy, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	v.CalculateBMI()
+	return &v, nil
+}
+
+// Create stores a set of vital signs
+func (r *VitalsRepository) Create(v *VitalSigns) error {
+	query := `
+		INSERT INTO vital_signs (patient_hn, visit_id, systolic_bp, diastolic_bp, pulse, temperature, weight, height, spo2,
+			notes, measured_at, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at
+	`
+
+	err := r.db.conn.QueryRow(query, v.PatientHN, v.VisitID, v.SystolicBP, v.DiastolicBP, v.Pulse, v.Temperature, v.Weight, v.Height, v.SpO2,
+		v.Notes, v.MeasuredAt, v.RecordedBy).Scan(&v.ID, &v.CreatedAt)
+	if err != nil {
+		if foreignKeyViolation(err) {
+			return apperr.Validation("visit %d does not exist", *v.VisitID)
+		}
+		return fmt.Errorf("failed to record vital signs: %w", err)
+	}
+	v.CalculateBMI()
+
+	return nil
+}
+
+// GetByID retrieves a set of vital signs
+func (r *VitalsRepository) GetByID(id int) (*VitalSigns, error) {
+	v, err := scanVitals(r.db.conn.QueryRow("SELECT "+vitalsColumns+" FROM vital_signs WHERE id = $1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("vital signs %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get vital signs: %w", err)
+	}
+	return v, nil
+}
+
+// List retrieves vital signs matching the filter, in the order they were measured
+func (r *VitalsRepository) List(f VitalsFilter) ([]VitalSigns, error) {
+	query := `
+		SELECT ` + vitalsColumns + ` FROM vital_signs
+		WHERE ($1 = '' OR patient_hn = $1) AND ($2 = 0 OR visit_id = $2)
+			AND ($3::timestamp IS NULL OR measured_at >= $3) AND ($4::timestamp IS NULL OR measured_at < $4)
+		ORDER BY measured_at, id
+	`
+
+	rows, err := r.db.conn.Query(query, f.PatientHN, f.VisitID, nullTime(f.From), nullTime(f.To))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vital signs: %w", err)
+	}
+	defer rows.Close()
+
+	vitals := []VitalSigns{}
+	for rows.Next() {
+		v, err := scanVitals(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan vital signs: %w", err)
+		}
+		vitals = append(vitals, *v)
+	}
+
+	return vitals, rows.Err()
+}
@@ -0,0 +1,142 @@
+// Package e2e runs end-to-end scenarios against a running API: each walks a
+// realistic flow through several modules with the Go client and checks the
+// invariants that hold across them, such as an invoice adding up to the
+// services and drugs of its visit. Every scenario creates its own patients,
+// doctors and catalog entries, so scenarios can run against any instance and
+// in any order.
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"clinic/backend/client"
+)
+
+// Scenario is one end-to-end flow
+type Scenario struct {
+	Name        string
+	Description string
+	Run         func(t *T)
+}
+
+// Scenarios are the flows cmd/e2e runs, by name
+var Scenarios = map[string]Scenario{}
+
+func register(s Scenario) {
+	Scenarios[s.Name] = s
+}
+
+// ScenarioNames lists the scenarios in alphabetical order
+func ScenarioNames() []string {
+	names := make([]string, 0, len(Scenarios))
+	for name := range Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// T is handed to a scenario to call the API and report failures, in the
+// manner of testing.T
+type T struct {
+	C   *client.Client
+	Ctx context.Context
+
+	name   string
+	out    io.Writer
+	failed bool
+}
+
+// failNow unwinds a scenario stopped by Fatalf
+type failNow struct{}
+
+// Logf prints a progress line under the scenario's name
+func (t *T) Logf(format string, args ...interface{}) {
+	fmt.Fprintf(t.out, "    %s: %s\n", t.name, fmt.Sprintf(format, args...))
+}
+
+// Errorf records a failure and lets the scenario continue
+func (t *T) Errorf(format string, args ...interface{}) {
+	t.failed = true
+	fmt.Fprintf(t.out, "    %s: FAIL: %s\n", t.name, fmt.Sprintf(format, args...))
+}
+
+// Fatalf records a failure and stops the scenario
+func (t *T) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	panic(failNow{})
+}
+
+// Must stops the scenario when a step it depends on failed
+func (t *T) Must(err error, step string) {
+	if err != nil {
+		t.Fatalf("%s: %v", step, err)
+	}
+}
+
+// Refused checks that a step was answered with status, e.g. a conflict
+func (t *T) Refused(err error, status int, step string) {
+	switch {
+	case err == nil:
+		t.Errorf("%s: succeeded, expected status %d", step, status)
+	case !client.IsStatus(err, status):
+		t.Errorf("%s: %v, expected status %d", step, err, status)
+	}
+}
+
+// Result is the outcome of one scenario
+type Result struct {
+	Name     string
+	Passed   bool
+	Duration time.Duration
+}
+
+// Run runs scenarios in order, printing progress and failures to out
+func Run(ctx context.Context, c *client.Client, scenarios []Scenario, out io.Writer) []Result {
+	results := make([]Result, 0, len(scenarios))
+	for _, s := range scenarios {
+		fmt.Fprintf(out, "=== RUN   %s\n", s.Name)
+		t := &T{C: c, Ctx: ctx, name: s.Name, out: out}
+		start := time.Now()
+		run(t, s.Run)
+		result := Result{Name: s.Name, Passed: !t.failed, Duration: time.Since(start)}
+		verdict := "PASS"
+		if !result.Passed {
+			verdict = "FAIL"
+		}
+		fmt.Fprintf(out, "--- %s: %s (%.2fs)\n", verdict, s.Name, result.Duration.Seconds())
+		results = append(results, result)
+	}
+	return results
+}
+
+func run(t *T, scenario func(t *T)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(failNow); !ok {
+				t.Errorf("panic: %v", r)
+			}
+		}
+	}()
+	scenario(t)
+}
+
+// unique returns a suffix that differs between runs, for codes and license
+// numbers that must not collide with earlier runs against the same instance
+func unique() string {
+	return fmt.Sprintf("%d", time.Now().UnixNano())
+}
+
+// hnSeq starts each run at a different point of the HN700000 range, which
+// sits below the load-test patients (HN900001 onwards)
+var hnSeq = time.Now().UnixNano() / 1000 % 150000
+
+// newHN returns a hospital number unlikely to have been used by an earlier run
+func newHN() string {
+	return fmt.Sprintf("HN%06d", 700000+atomic.AddInt64(&hnSeq, 1)%200000)
+}
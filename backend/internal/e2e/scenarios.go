@@ -0,0 +1,277 @@
+package e2e
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+func init() {
+	register(Scenario{
+		Name:        "visit",
+		Description: "register, book, check in, consult, prescribe, bill and pay in two parts",
+		Run:         visitJourney,
+	})
+	register(Scenario{
+		Name:        "booking",
+		Description: "a doctor's slot can be booked once, and again after the booking is cancelled",
+		Run:         bookingConflicts,
+	})
+	register(Scenario{
+		Name:        "package",
+		Description: "sell a course of sessions, deduct one per visit and refuse once it is used up",
+		Run:         packageCourse,
+	})
+	register(Scenario{
+		Name:        "closed-visit",
+		Description: "a closed visit refuses prescriptions and services, and its invoice cannot be paid before issue",
+		Run:         closedVisit,
+	})
+}
+
+// fixtures are the records most scenarios start from
+type fixtures struct {
+	patient *database.Patient
+	doctor  *database.Doctor
+}
+
+func setUp(t *T, name string) fixtures {
+	patient, err := t.C.CreatePatient(t.Ctx, database.Patient{HN: newHN(), FullName: "E2E " + name, Gender: "ไม่ระบุ", Age: 40})
+	t.Must(err, "register patient")
+	doctor, err := t.C.CreateDoctor(t.Ctx, database.Doctor{
+		FullName:      "E2E Doctor " + name,
+		Specialty:     "general practice",
+		LicenseNumber: "E2E" + unique(),
+		WorkingDays:   database.Weekdays,
+	})
+	t.Must(err, "register doctor")
+	return fixtures{patient: patient, doctor: doctor}
+}
+
+// slot is an hour-long appointment time no earlier run used, on a day far
+// enough ahead that it never falls in the past
+func slot() time.Time {
+	base := time.Now().AddDate(1, 0, 0).Truncate(24 * time.Hour)
+	return base.Add(time.Duration(time.Now().UnixNano()%1000) * 24 * time.Hour).Add(9 * time.Hour)
+}
+
+func money(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+func visitJourney(t *T) {
+	f := setUp(t, "visit")
+
+	appointment, err := t.C.CreateAppointment(t.Ctx, database.Appointment{
+		PatientHN: f.patient.HN, DoctorID: &f.doctor.ID, StartsAt: slot(),
+	})
+	t.Must(err, "book appointment")
+	if appointment.Status != database.AppointmentScheduled {
+		t.Errorf("new appointment is %s, expected %s", appointment.Status, database.AppointmentScheduled)
+	}
+
+	entry, err := t.C.CheckIn(t.Ctx, database.QueueEntry{
+		PatientHN: f.patient.HN, ServicePoint: "registration", AppointmentID: &appointment.ID,
+	})
+	t.Must(err, "check in")
+	if entry.Number < 1 || entry.PatientName != f.patient.FullName {
+		t.Errorf("queue entry %+v does not name the patient with a number", entry)
+	}
+	appointment, err = t.C.GetAppointment(t.Ctx, appointment.ID)
+	t.Must(err, "get appointment")
+	if appointment.Status != database.AppointmentCheckedIn {
+		t.Errorf("appointment is %s after queue check-in, expected %s", appointment.Status, database.AppointmentCheckedIn)
+	}
+
+	visit, err := t.C.CreateVisit(t.Ctx, f.patient.HN, database.Encounter{ChiefComplaint: "ไอ มีเสมหะ 3 วัน", AppointmentID: &appointment.ID})
+	t.Must(err, "open visit")
+	if visit.DoctorID == nil || *visit.DoctorID != f.doctor.ID {
+		t.Errorf("visit attended by %v, expected the appointment's doctor %d", visit.DoctorID, f.doctor.ID)
+	}
+
+	diagnosis := "Acute bronchitis"
+	visit.Diagnosis = &diagnosis
+	visit, err = t.C.UpdateVisit(t.Ctx, *visit)
+	t.Must(err, "record findings")
+
+	service, err := t.C.CreateService(t.Ctx, database.Service{Code: "E2E" + unique(), Name: "ค่าตรวจแพทย์", Category: "consultation", Price: 300})
+	t.Must(err, "add service to catalog")
+	_, err = t.C.AddVisitService(t.Ctx, visit.ID, service.ID, 1)
+	t.Must(err, "record service")
+
+	drug, err := t.C.CreateDrug(t.Ctx, database.Drug{GenericName: "Ambroxol", Strength: "30 mg", Unit: "tablet", Price: 2.5})
+	t.Must(err, "add drug to catalog")
+	days, quantity := 5, 15.0
+	_, err = t.C.CreatePrescription(t.Ctx, visit.ID, database.Prescription{Items: []database.DrugTemplate{{
+		DrugID: &drug.ID, Dose: "1 tablet", Frequency: "วันละ 3 ครั้ง หลังอาหาร", DurationDays: &days, Quantity: &quantity,
+	}}})
+	t.Must(err, "prescribe")
+
+	visit, err = t.C.CloseVisit(t.Ctx, visit.ID)
+	t.Must(err, "close visit")
+	if visit.Status != database.EncounterClosed || visit.EndedAt == nil {
+		t.Errorf("closed visit is %s with endedAt %v", visit.Status, visit.EndedAt)
+	}
+
+	invoice, err := t.C.GenerateInvoice(t.Ctx, visit.ID)
+	t.Must(err, "draft invoice")
+	if invoice.PatientHN != f.patient.HN || invoice.Status != database.InvoiceDraft {
+		t.Errorf("invoice for %s is %s, expected a draft for %s", invoice.PatientHN, invoice.Status, f.patient.HN)
+	}
+	if len(invoice.Items) != 2 {
+		t.Fatalf("invoice has %d lines, expected the service and the drug", len(invoice.Items))
+	}
+	sum := 0.0
+	for _, line := range invoice.Items {
+		sum += line.Amount
+	}
+	if !money(sum, 300+15*2.5) || !money(invoice.Subtotal, sum) || !money(invoice.Total, invoice.Subtotal-invoice.Discount) {
+		t.Errorf("invoice lines add up to %.2f, subtotal %.2f, total %.2f; expected 337.50 throughout", sum, invoice.Subtotal, invoice.Total)
+	}
+
+	invoice, err = t.C.UpdateInvoiceStatus(t.Ctx, invoice.ID, database.InvoiceIssued, "")
+	t.Must(err, "issue invoice")
+
+	paid, err := t.C.RecordPayment(t.Ctx, invoice.ID, database.Payment{Method: database.PaymentCash, Amount: 200, ReceivedBy: "cashier"})
+	t.Must(err, "record first payment")
+	if paid.Invoice.Status != database.InvoiceIssued || !money(paid.Invoice.Outstanding, invoice.Total-200) {
+		t.Errorf("after a part payment the invoice is %s with %.2f outstanding, expected issued with %.2f",
+			paid.Invoice.Status, paid.Invoice.Outstanding, invoice.Total-200)
+	}
+
+	_, err = t.C.RecordPayment(t.Ctx, invoice.ID, database.Payment{Method: database.PaymentCash, Amount: paid.Invoice.Outstanding + 100, ReceivedBy: "cashier"})
+	t.Refused(err, http.StatusConflict, "overpay invoice")
+
+	paid, err = t.C.RecordPayment(t.Ctx, invoice.ID, database.Payment{Method: database.PaymentCash, Amount: paid.Invoice.Outstanding, ReceivedBy: "cashier"})
+	t.Must(err, "record final payment")
+	invoice, err = t.C.GetInvoice(t.Ctx, invoice.ID)
+	t.Must(err, "get invoice")
+	if invoice.Status != database.InvoicePaid || !money(invoice.Outstanding, 0) || !money(invoice.AmountPaid, invoice.Total) {
+		t.Errorf("settled invoice is %s, paid %.2f of %.2f with %.2f outstanding", invoice.Status, invoice.AmountPaid, invoice.Total, invoice.Outstanding)
+	}
+	t.Logf("%s billed %s %.2f and paid in full", f.patient.HN, invoice.Number, invoice.Total)
+}
+
+func bookingConflicts(t *T) {
+	f := setUp(t, "booking")
+	other, err := t.C.CreatePatient(t.Ctx, database.Patient{HN: newHN(), FullName: "E2E booking (other)", Gender: "ไม่ระบุ", Age: 30})
+	t.Must(err, "register second patient")
+
+	startsAt := slot()
+	first, err := t.C.CreateAppointment(t.Ctx, database.Appointment{PatientHN: f.patient.HN, DoctorID: &f.doctor.ID, StartsAt: startsAt})
+	t.Must(err, "book slot")
+
+	_, err = t.C.CreateAppointment(t.Ctx, database.Appointment{PatientHN: other.HN, DoctorID: &f.doctor.ID, StartsAt: startsAt.Add(10 * time.Minute)})
+	t.Refused(err, http.StatusConflict, "book overlapping slot with the same doctor")
+
+	_, err = t.C.Do(t.Ctx, http.MethodPost, fmt.Sprintf("/api/appointments/%d/cancel", first.ID), map[string]string{"reasonCode": "patient_request", "reason": "patient called to cancel"}, nil)
+	t.Must(err, "cancel first booking")
+
+	second, err := t.C.CreateAppointment(t.Ctx, database.Appointment{PatientHN: other.HN, DoctorID: &f.doctor.ID, StartsAt: startsAt})
+	t.Must(err, "book the freed slot")
+
+	booked, err := t.C.GetAppointments(t.Ctx, map[string][]string{
+		"doctorId": {fmt.Sprint(f.doctor.ID)}, "date": {startsAt.Format("2006-01-02")},
+	})
+	t.Must(err, "list the doctor's day")
+	live := 0
+	for _, a := range booked {
+		if a.Status != database.AppointmentCancelled {
+			live++
+			if a.ID != second.ID {
+				t.Errorf("appointment %d is still live in the freed slot", a.ID)
+			}
+		}
+	}
+	if live != 1 {
+		t.Errorf("the doctor has %d live appointments that day, expected 1", live)
+	}
+}
+
+func packageCourse(t *T) {
+	f := setUp(t, "package")
+
+	var course database.TreatmentPackage
+	_, err := t.C.Do(t.Ctx, http.MethodPost, "/api/packages",
+		database.TreatmentPackage{Name: "E2E กายภาพบำบัด 2 ครั้ง", Sessions: 2, Price: 1800}, &course)
+	t.Must(err, "add package to catalog")
+
+	var bought database.PatientPackage
+	packagesPath := "/api/patients/" + f.patient.HN + "/packages"
+	_, err = t.C.Do(t.Ctx, http.MethodPost, packagesPath, map[string]interface{}{"packageId": course.ID, "purchasedBy": "front desk"}, &bought)
+	t.Must(err, "sell package")
+	if bought.RemainingSessions != 2 || bought.Status != database.PatientPackageActive {
+		t.Errorf("new package has %d sessions left and is %s", bought.RemainingSessions, bought.Status)
+	}
+
+	use := func(step string) (*database.Encounter, *database.PatientPackage, error) {
+		visit, err := t.C.CreateVisit(t.Ctx, f.patient.HN, database.Encounter{ChiefComplaint: "ปวดหลัง", DoctorID: &f.doctor.ID})
+		t.Must(err, step+": open visit")
+		var pkg database.PatientPackage
+		_, err = t.C.Do(t.Ctx, http.MethodPost, fmt.Sprintf("/api/visits/%d/package-sessions", visit.ID),
+			map[string]string{"recordedBy": "physiotherapist"}, &pkg)
+		return visit, &pkg, err
+	}
+
+	visit, pkg, err := use("first session")
+	t.Must(err, "first session")
+	if pkg.RemainingSessions != 1 {
+		t.Errorf("%d sessions left after the first, expected 1", pkg.RemainingSessions)
+	}
+	_, err = t.C.Do(t.Ctx, http.MethodPost, fmt.Sprintf("/api/visits/%d/package-sessions", visit.ID),
+		map[string]interface{}{"patientPackageId": bought.ID, "recordedBy": "physiotherapist"}, nil)
+	t.Refused(err, http.StatusConflict, "deduct twice in one visit")
+
+	_, pkg, err = use("second session")
+	t.Must(err, "second session")
+	if pkg.RemainingSessions != 0 || pkg.Status != database.PatientPackageCompleted {
+		t.Errorf("after the last session %d are left and the package is %s", pkg.RemainingSessions, pkg.Status)
+	}
+
+	_, _, err = use("third session")
+	t.Refused(err, http.StatusConflict, "deduct from a used-up package")
+
+	var balances []database.PatientPackage
+	_, err = t.C.Do(t.Ctx, http.MethodGet, packagesPath, nil, &balances)
+	t.Must(err, "list patient packages")
+	if len(balances) != 1 || balances[0].UsedSessions != 2 || balances[0].UsedSessions+balances[0].RemainingSessions != balances[0].TotalSessions {
+		t.Errorf("patient package balances %+v do not add up to the 2 sessions used", balances)
+	}
+}
+
+func closedVisit(t *T) {
+	f := setUp(t, "closed-visit")
+
+	visit, err := t.C.CreateVisit(t.Ctx, f.patient.HN, database.Encounter{ChiefComplaint: "แผลถลอก", DoctorID: &f.doctor.ID})
+	t.Must(err, "open visit")
+	service, err := t.C.CreateService(t.Ctx, database.Service{Code: "E2E" + unique(), Name: "Wound dressing", Category: "dressing", Price: 150})
+	t.Must(err, "add service to catalog")
+	_, err = t.C.AddVisitService(t.Ctx, visit.ID, service.ID, 1)
+	t.Must(err, "record service")
+	_, err = t.C.CloseVisit(t.Ctx, visit.ID)
+	t.Must(err, "close visit")
+
+	_, err = t.C.AddVisitService(t.Ctx, visit.ID, service.ID, 1)
+	t.Refused(err, http.StatusConflict, "record a service on a closed visit")
+	days := 3
+	_, err = t.C.CreatePrescription(t.Ctx, visit.ID, database.Prescription{Items: []database.DrugTemplate{{
+		DrugName: "Povidone-iodine", Dose: "ทาแผล", Frequency: "วันละ 2 ครั้ง", DurationDays: &days,
+	}}})
+	t.Refused(err, http.StatusConflict, "prescribe on a closed visit")
+
+	invoice, err := t.C.GenerateInvoice(t.Ctx, visit.ID)
+	t.Must(err, "draft invoice")
+	if !money(invoice.Total, 150) {
+		t.Errorf("invoice total %.2f, expected the 150.00 dressing", invoice.Total)
+	}
+	_, err = t.C.RecordPayment(t.Ctx, invoice.ID, database.Payment{Method: database.PaymentCash, Amount: invoice.Total, ReceivedBy: "cashier"})
+	t.Refused(err, http.StatusConflict, "pay a draft invoice")
+	_, err = t.C.UpdateInvoiceStatus(t.Ctx, invoice.ID, database.InvoiceVoid, "")
+	t.Refused(err, http.StatusBadRequest, "void without a reason")
+
+	_, err = t.C.CreateVisit(t.Ctx, "HN999999", database.Encounter{ChiefComplaint: "ไม่มีผู้ป่วยนี้"})
+	t.Refused(err, http.StatusNotFound, "open a visit for an unknown patient")
+}
@@ -0,0 +1,141 @@
+package e2e
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Server is a clinic API started from source for a run. It uses the
+// full-fidelity mock repositories, which check references like the
+// database's foreign keys, and stores files in a temporary directory.
+type Server struct {
+	URL        string
+	AdminToken string
+	Dir        string // temporary directory holding the binary, files and the server log
+	cmd        *exec.Cmd
+	done       chan struct{} // closed once the process has exited
+	exitErr    error
+}
+
+// StartServer builds the backend module in moduleDir and starts it on a free
+// port, waiting up to timeout for it to answer /health
+func StartServer(moduleDir string, timeout time.Duration) (*Server, error) {
+	dir, err := os.MkdirTemp("", "clinic-e2e-")
+	if err != nil {
+		return nil, err
+	}
+
+	binary := filepath.Join(dir, "clinic-server")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Dir = moduleDir
+	if output, err := build.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("build server: %v\n%s", err, output)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	logFile, err := os.Create(filepath.Join(dir, "server.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		URL:        "http://127.0.0.1:" + strconv.Itoa(port),
+		AdminToken: hex.EncodeToString(token),
+		Dir:        dir,
+		done:       make(chan struct{}),
+	}
+	s.cmd = exec.Command(binary)
+	s.cmd.Dir = dir
+	s.cmd.Env = append(os.Environ(),
+		"PORT="+strconv.Itoa(port),
+		"PUBLIC_BASE_URL="+s.URL,
+		"ADMIN_TOKEN="+s.AdminToken,
+		"MOCK_FIDELITY=full",
+		"STORAGE_DIR="+filepath.Join(dir, "storage"),
+		"DOCUMENT_DIR="+filepath.Join(dir, "documents"),
+	)
+	s.cmd.Stdout, s.cmd.Stderr = logFile, logFile
+	if err := s.cmd.Start(); err != nil {
+		logFile.Close()
+		return nil, fmt.Errorf("start server: %w", err)
+	}
+	go func() {
+		s.exitErr = s.cmd.Wait()
+		logFile.Close()
+		close(s.done)
+	}()
+
+	if err := s.waitHealthy(timeout); err != nil {
+		s.Stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Server) waitHealthy(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"/health", nil)
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-s.done:
+			return fmt.Errorf("server exited during startup: %v; see %s", s.exitErr, filepath.Join(s.Dir, "server.log"))
+		case <-ctx.Done():
+			return fmt.Errorf("server not healthy after %s; see %s", timeout, filepath.Join(s.Dir, "server.log"))
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Stop ends the server; its directory is left for the log until RemoveAll
+func (s *Server) Stop() {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	s.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		s.cmd.Process.Kill()
+		<-s.done
+	}
+}
+
+// RemoveAll deletes the server's temporary directory
+func (s *Server) RemoveAll() error {
+	return os.RemoveAll(s.Dir)
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,194 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/reminder"
+)
+
+// Sender delivers rendered emails
+type Sender interface {
+	Name() string // provider name recorded against failed deliveries
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// LogSender writes emails to the server log instead of sending them, for
+// development and demos
+type LogSender struct{}
+
+// Name returns the sender's provider name
+func (LogSender) Name() string { return "log" }
+
+// Send logs the email
+func (LogSender) Send(ctx context.Context, to, subject, body string) error {
+	log.Printf("Email to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+// SMTPSender sends plain-text UTF-8 emails through an SMTP server, upgrading
+// the connection with STARTTLS whenever the server offers it. Credentials are
+// only sent over TLS.
+type SMTPSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     *mail.Address
+	timeout  time.Duration
+}
+
+// NewSMTPSender creates a sender relaying through host:port as from, e.g.
+// "Clinic <noreply@clinic.example>", logging in with username and password
+// unless username is empty
+func NewSMTPSender(host string, port int, username, password, from string) (*SMTPSender, error) {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", from, err)
+	}
+	return &SMTPSender{
+		addr:     net.JoinHostPort(host, fmt.Sprint(port)),
+		host:     host,
+		username: username,
+		password: password,
+		from:     address,
+		timeout:  30 * time.Second,
+	}, nil
+}
+
+// Name returns the sender's provider name
+func (s *SMTPSender) Name() string { return "smtp" }
+
+// Send delivers one email
+func (s *SMTPSender) Send(ctx context.Context, to, subject, body string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		// PlainAuth itself refuses to send credentials over an unencrypted connection
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	wc, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := wc.Write(message(s.from.String(), to, subject, body)); err != nil {
+		wc.Close()
+		return err
+	}
+	if err := wc.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message builds the RFC 5322 message. Thai text needs the subject encoded
+// and the body base64-encoded to pass through every relay intact.
+func message(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return []byte(b.String())
+}
+
+// Outbox is the queue of emails waiting to be sent
+type Outbox interface {
+	List(f database.EmailFilter) ([]database.EmailMessage, error)
+	MarkSent(id int) (*database.EmailMessage, error)
+	RecordFailure(id int, reason string, retryAt func(attempts int) *time.Time) (*database.EmailMessage, error)
+}
+
+// Dispatcher sends the emails waiting in the outbox, marking each sent or
+// recording the failure for a retry under the retry policy
+type Dispatcher struct {
+	sender Sender
+	outbox Outbox
+	retry  reminder.RetryPolicy
+}
+
+// NewDispatcher creates a dispatcher sending through sender
+func NewDispatcher(sender Sender, outbox Outbox, retry reminder.RetryPolicy) *Dispatcher {
+	return &Dispatcher{sender: sender, outbox: outbox, retry: retry}
+}
+
+// Run sends the pending emails that are not waiting out a retry backoff
+func (d *Dispatcher) Run(ctx context.Context) error {
+	pending, err := d.outbox.List(database.EmailFilter{Status: database.NotificationPending, Due: true})
+	if err != nil {
+		return err
+	}
+
+	sent, failed := 0, 0
+	for i := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		m := &pending[i]
+		sendErr := d.sender.Send(ctx, m.To, m.Subject, m.Body)
+		if sendErr == nil {
+			_, err = d.outbox.MarkSent(m.ID)
+			sent++
+		} else {
+			now := time.Now()
+			_, err = d.outbox.RecordFailure(m.ID, sendErr.Error(), func(attempts int) *time.Time { return d.retry.RetryAt(attempts, now) })
+			failed++
+		}
+		// A conflict means the email was settled elsewhere while it was being sent
+		if err != nil && !apperr.Is(err, apperr.KindConflict) {
+			return fmt.Errorf("failed to settle email %d: %w", m.ID, err)
+		}
+	}
+	if sent+failed > 0 {
+		log.Printf("Emails: %d sent, %d failed through %s", sent, failed, d.sender.Name())
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package email
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"clinic/backend/internal/database"
+)
+
+// AppointmentConfirmation is the data for the appointment_confirmation template
+type AppointmentConfirmation struct {
+	PatientName string
+	DoctorName  string
+	Date        string // dd/mm/yyyy
+	Time        string // HH:MM
+}
+
+// LabResultReady is the data for the lab_result_ready template
+type LabResultReady struct {
+	PatientName string
+	Title       string // the lab result document's title
+}
+
+// PatientMessage is the data for the patient_message template
+type PatientMessage struct {
+	PatientName string
+	Message     string
+}
+
+// templates are the email templates by name: the first line is the subject
+// and the rest, after a blank line, the body
+var templates = map[string]*template.Template{
+	database.EmailAppointmentConfirmation: template.Must(template.New(database.EmailAppointmentConfirmation).Parse(
+		`ยืนยันการนัดหมายวันที่ {{.Date}} เวลา {{.Time}} น.
+
+เรียน คุณ{{.PatientName}}
+
+คลินิกขอยืนยันการนัดหมายของท่านกับ {{.DoctorName}}
+วันที่ {{.Date}} เวลา {{.Time}} น.
+
+กรุณามาถึงก่อนเวลานัด 15 นาที หากไม่สะดวกมาตามนัด กรุณาแจ้งคลินิกล่วงหน้า
+`)),
+	database.EmailLabResultReady: template.Must(template.New(database.EmailLabResultReady).Parse(
+		`ผลตรวจทางห้องปฏิบัติการของท่านพร้อมแล้ว
+
+เรียน คุณ{{.PatientName}}
+
+ผลตรวจ "{{.Title}}" ของท่านพร้อมแล้ว ท่านสามารถติดต่อรับผลหรือสอบถามแพทย์ได้ที่คลินิก
+`)),
+	database.EmailPatientMessage: template.Must(template.New(database.EmailPatientMessage).Parse(
+		`ข้อความจากคลินิก
+
+เรียน คุณ{{.PatientName}}
+
+{{.Message}}
+`)),
+}
+
+// Render fills in a template, returning the email's subject and body
+func Render(name string, data interface{}) (subject, body string, err error) {
+	t, ok := templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown email template %q", name)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	subject, body, _ = strings.Cut(b.String(), "\n\n")
+	return subject, body, nil
+}
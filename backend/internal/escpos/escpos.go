@@ -0,0 +1,117 @@
+// Package escpos builds ESC/POS command streams for receipt and ticket
+// thermal printers. The bytes can be written to the printer as they are, e.g.
+// by a kiosk over USB or to port 9100 of a network printer.
+package escpos
+
+import "bytes"
+
+// Alignments
+const (
+	AlignLeft   = 0
+	AlignCenter = 1
+	AlignRight  = 2
+)
+
+const (
+	esc = 0x1b
+	gs  = 0x1d
+)
+
+// Builder accumulates printer commands. Text is printed in ASCII: what other
+// characters print as depends on the printer's code page, so they print as "?".
+type Builder struct {
+	buf bytes.Buffer
+}
+
+// New starts a command stream, resetting the printer to its defaults
+func New() *Builder {
+	b := &Builder{}
+	b.buf.Write([]byte{esc, '@'})
+	return b
+}
+
+// Align sets the alignment of the following lines
+func (b *Builder) Align(a int) *Builder {
+	b.buf.Write([]byte{esc, 'a', byte(a)})
+	return b
+}
+
+// Bold turns emphasized printing on or off
+func (b *Builder) Bold(on bool) *Builder {
+	var n byte
+	if on {
+		n = 1
+	}
+	b.buf.Write([]byte{esc, 'E', n})
+	return b
+}
+
+// Size scales the following characters width by height times, each 1 to 8
+func (b *Builder) Size(width, height int) *Builder {
+	b.buf.Write([]byte{gs, '!', byte((clamp(width)-1)<<4 | (clamp(height) - 1))})
+	return b
+}
+
+// Line prints text and ends the line
+func (b *Builder) Line(text string) *Builder {
+	for _, c := range text {
+		if c < ' ' || c > '~' {
+			c = '?'
+		}
+		b.buf.WriteByte(byte(c))
+	}
+	b.buf.WriteByte('\n')
+	return b
+}
+
+// Feed advances the paper n lines
+func (b *Builder) Feed(n int) *Builder {
+	b.buf.Write([]byte{esc, 'd', byte(n)})
+	return b
+}
+
+// QR prints data as a QR code (model 2, error correction level M) with
+// modules of size dots, 1 to 16. The printer draws the code itself.
+func (b *Builder) QR(data string, size int) *Builder {
+	if size < 1 {
+		size = 1
+	} else if size > 16 {
+		size = 16
+	}
+	b.qr(0x41, 50, 0)      // model 2
+	b.qr(0x43, byte(size)) // module size
+	b.qr(0x45, 49)         // error correction M
+	n := len(data) + 3     // the store function's parameters count cn, fn and m
+	b.buf.Write([]byte{gs, '(', 'k', byte(n), byte(n >> 8), 49, 0x50, 48})
+	b.buf.WriteString(data)
+	b.qr(0x51, 48) // print the stored symbol
+	return b
+}
+
+// qr writes a GS ( k function of the QR code symbol (cn 49) with its parameters
+func (b *Builder) qr(fn byte, params ...byte) {
+	n := len(params) + 2
+	b.buf.Write([]byte{gs, '(', 'k', byte(n), byte(n >> 8), 49, fn})
+	b.buf.Write(params)
+}
+
+// Cut feeds the paper past the cutter and cuts it, leaving a small hinge
+func (b *Builder) Cut() *Builder {
+	b.buf.Write([]byte{gs, 'V', 66, 0})
+	return b
+}
+
+// Bytes returns the command stream
+func (b *Builder) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+func clamp(n int) int {
+	if n < 1 {
+		return 1
+	}
+	if n > 8 {
+		return 8
+	}
+	return n
+}
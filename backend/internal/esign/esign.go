@@ -0,0 +1,93 @@
+package esign
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Signer creates and uses prescriber signing keys. Private keys never leave
+// the signer unencrypted: they are sealed with AES-GCM under a master key.
+type Signer struct {
+	aead cipher.AEAD
+}
+
+// NewSigner creates a signer from a 32-byte master key
+func NewSigner(masterKey []byte) (*Signer, error) {
+	if len(masterKey) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(masterKey))
+	}
+
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &Signer{aead: aead}, nil
+}
+
+// GenerateKey creates a new Ed25519 key pair and returns the public key and
+// the sealed private key for storage
+func (s *Signer) GenerateKey() (ed25519.PublicKey, []byte, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, private.Seed(), nil)
+	return public, sealed, nil
+}
+
+// Sign signs a document digest with a sealed private key
+func (s *Signer) Sign(sealedKey, digest []byte) ([]byte, error) {
+	nonceSize := s.aead.NonceSize()
+	if len(sealedKey) < nonceSize {
+		return nil, fmt.Errorf("sealed key is too short")
+	}
+
+	seed, err := s.aead.Open(nil, sealedKey[:nonceSize], sealedKey[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unseal key: %w", err)
+	}
+
+	return ed25519.Sign(ed25519.NewKeyFromSeed(seed), digest), nil
+}
+
+// Verify checks a signature over a document digest
+func Verify(publicKey ed25519.PublicKey, digest, signature []byte) bool {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(publicKey, digest, signature)
+}
+
+// Digest returns the SHA-256 digest of a document and its hex form
+func Digest(document []byte) ([]byte, string) {
+	sum := sha256.Sum256(document)
+	return sum[:], hex.EncodeToString(sum[:])
+}
+
+// NewVerificationCode returns a random code short enough to print under a QR
+func NewVerificationCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
@@ -0,0 +1,101 @@
+package forecast
+
+import (
+	"math"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// daysPerMonth is the month length used to turn monthly forecasts into daily rates
+const daysPerMonth = 30.0
+
+// Usage is one consumption event (dispense or stock-out) of a stock item
+type Usage struct {
+	ItemID   int
+	Quantity float64
+	At       time.Time
+}
+
+// UsageSource supplies dispensing history and current stock for forecasting
+type UsageSource interface {
+	UsageSince(since time.Time) ([]Usage, error)
+	StockLevels() (map[int]float64, error)
+}
+
+// MonthlyTotals buckets usage of each item into the last n calendar months
+// before now, oldest first. The current, incomplete month is excluded.
+func MonthlyTotals(usage []Usage, n int, now time.Time) map[int][]float64 {
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	start := currentMonth.AddDate(0, -n, 0)
+
+	totals := make(map[int][]float64)
+	for _, u := range usage {
+		if u.At.Before(start) || !u.At.Before(currentMonth) {
+			continue
+		}
+		// Bucket by the calendar month in now's timezone; the database may
+		// hand back times labelled UTC
+		at := u.At.In(now.Location())
+		index := (at.Year()-start.Year())*12 + int(at.Month()) - int(start.Month())
+		if index < 0 || index >= n {
+			continue
+		}
+		months, ok := totals[u.ItemID]
+		if !ok {
+			months = make([]float64, n)
+			totals[u.ItemID] = months
+		}
+		months[index] += u.Quantity
+	}
+
+	return totals
+}
+
+// Monthly forecasts next month's consumption as a linearly weighted moving
+// average, so recent months count more than older ones
+func Monthly(months []float64) float64 {
+	var weighted, weights float64
+	for i, quantity := range months {
+		weight := float64(i + 1)
+		weighted += quantity * weight
+		weights += weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return weighted / weights
+}
+
+// Suggest returns a purchase suggestion when stock will not cover the lead
+// time plus safety buffer. The order tops stock up to one month of cover
+// beyond that point, rounded up to whole packs.
+func Suggest(policy database.ReorderPolicy, monthly, stock float64) (database.PurchaseSuggestion, bool) {
+	daily := monthly / daysPerMonth
+	reorderPoint := daily * float64(policy.LeadTimeDays+policy.SafetyStockDays)
+	if daily == 0 || stock > reorderPoint {
+		return database.PurchaseSuggestion{}, false
+	}
+
+	packSize := policy.PackSize
+	if packSize < 1 {
+		packSize = 1
+	}
+
+	target := reorderPoint + monthly
+	packs := int(math.Ceil((target - stock) / float64(packSize)))
+	if packs < 1 {
+		packs = 1
+	}
+
+	return database.PurchaseSuggestion{
+		ItemID:            policy.ItemID,
+		ItemName:          policy.ItemName,
+		Supplier:          policy.Supplier,
+		ForecastMonthly:   math.Round(monthly*100) / 100,
+		CurrentStock:      stock,
+		SuggestedQuantity: packs * packSize,
+		Packs:             packs,
+		Status:            database.SuggestionPending,
+	}, true
+}
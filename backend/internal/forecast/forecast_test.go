@@ -0,0 +1,33 @@
+package forecast
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMonthlyTotalsMonthBoundaries(t *testing.T) {
+	bangkok := time.FixedZone("Asia/Bangkok", 7*60*60)
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, bangkok)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want []float64 // February, March, April
+	}{
+		{"start of the first month, stored as UTC", time.Date(2024, 1, 31, 17, 30, 0, 0, time.UTC), []float64{1, 0, 0}},
+		{"end of the last month, stored as UTC", time.Date(2024, 4, 30, 16, 59, 0, 0, time.UTC), []float64{0, 0, 1}},
+		{"middle month boundary, stored as UTC", time.Date(2024, 2, 29, 17, 0, 0, 0, time.UTC), []float64{0, 1, 0}},
+		{"local time", time.Date(2024, 3, 31, 23, 59, 0, 0, bangkok), []float64{0, 1, 0}},
+		{"before the first month", time.Date(2024, 1, 31, 16, 59, 0, 0, time.UTC), nil},
+		{"in the current month", time.Date(2024, 4, 30, 17, 0, 0, 0, time.UTC), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals := MonthlyTotals([]Usage{{ItemID: 1, Quantity: 1, At: tt.at}}, 3, now)
+			if got := totals[1]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MonthlyTotals = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
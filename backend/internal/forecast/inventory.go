@@ -0,0 +1,49 @@
+package forecast
+
+import (
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// Inventory is the stock history that forecasts are built from
+type Inventory interface {
+	GetMovements(f database.MovementFilter) ([]database.StockMovement, error)
+	GetLevels(f database.StockItemFilter) ([]database.StockLevel, error)
+}
+
+// FromInventory reads usage from inventory dispenses. Adjustments such as
+// breakage and expiry write-offs are not demand and are left out.
+func FromInventory(inv Inventory) UsageSource {
+	return inventoryUsage{inv: inv}
+}
+
+type inventoryUsage struct {
+	inv Inventory
+}
+
+func (u inventoryUsage) UsageSince(since time.Time) ([]Usage, error) {
+	movements, err := u.inv.GetMovements(database.MovementFilter{Type: database.MovementDispense, From: since})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]Usage, 0, len(movements))
+	for _, m := range movements {
+		usage = append(usage, Usage{ItemID: m.ItemID, Quantity: -m.Quantity, At: m.CreatedAt})
+	}
+	return usage, nil
+}
+
+func (u inventoryUsage) StockLevels() (map[int]float64, error) {
+	levels, err := u.inv.GetLevels(database.StockItemFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	stock := make(map[int]float64, len(levels))
+	for _, l := range levels {
+		stock[l.ItemID] = l.Quantity
+	}
+	return stock, nil
+}
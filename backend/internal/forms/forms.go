@@ -0,0 +1,244 @@
+package forms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// FieldError describes why one answer was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// RenderedField pairs a field definition with its current value for display
+type RenderedField struct {
+	database.FormField
+	Value interface{} `json:"value"`
+}
+
+var fieldTypes = map[string]bool{
+	database.FieldTypeText:        true,
+	database.FieldTypeTextarea:    true,
+	database.FieldTypeNumber:      true,
+	database.FieldTypeBoolean:     true,
+	database.FieldTypeDate:        true,
+	database.FieldTypeSelect:      true,
+	database.FieldTypeMultiSelect: true,
+	database.FieldTypePhoto:       true,
+}
+
+// ValidateDefinition checks that a form's fields are well-formed
+func ValidateDefinition(fields []database.FormField) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("form must have at least one field")
+	}
+
+	seen := make(map[string]bool)
+	for i, f := range fields {
+		if f.Key == "" || f.Label == "" {
+			return fmt.Errorf("field %d needs a key and label", i+1)
+		}
+		if seen[f.Key] {
+			return fmt.Errorf("duplicate field key %q", f.Key)
+		}
+		seen[f.Key] = true
+
+		if !fieldTypes[f.Type] {
+			return fmt.Errorf("field %q has unknown type %q", f.Key, f.Type)
+		}
+		if (f.Type == database.FieldTypeSelect || f.Type == database.FieldTypeMultiSelect) && len(f.Options) == 0 {
+			return fmt.Errorf("field %q needs options", f.Key)
+		}
+		if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+			return fmt.Errorf("field %q has min above max", f.Key)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks answers against the form's fields. It returns the answers
+// normalized to the field types (unknown keys dropped) and any field errors.
+func Validate(fields []database.FormField, answers map[string]interface{}) (map[string]interface{}, []FieldError) {
+	clean := make(map[string]interface{})
+	var errs []FieldError
+
+	for _, f := range fields {
+		raw, present := answers[f.Key]
+		if !present || isBlank(raw) {
+			if f.Required {
+				errs = append(errs, FieldError{Field: f.Key, Message: f.Label + " is required"})
+			}
+			continue
+		}
+
+		value, err := normalize(f, raw)
+		if err != nil {
+			errs = append(errs, FieldError{Field: f.Key, Message: err.Error()})
+			continue
+		}
+		clean[f.Key] = value
+	}
+
+	return clean, errs
+}
+
+// normalize converts one raw JSON value to the field's type
+func normalize(f database.FormField, raw interface{}) (interface{}, error) {
+	switch f.Type {
+	case database.FieldTypeText, database.FieldTypeTextarea:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be text")
+		}
+		return s, nil
+
+	case database.FieldTypeNumber:
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("must be a number")
+			}
+			n = parsed
+		default:
+			return nil, fmt.Errorf("must be a number")
+		}
+		if f.Min != nil && n < *f.Min {
+			return nil, fmt.Errorf("must be at least %g", *f.Min)
+		}
+		if f.Max != nil && n > *f.Max {
+			return nil, fmt.Errorf("must be at most %g", *f.Max)
+		}
+		return n, nil
+
+	case database.FieldTypeBoolean:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+
+	case database.FieldTypeDate:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a YYYY-MM-DD date")
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return nil, fmt.Errorf("must be a YYYY-MM-DD date")
+		}
+		return s, nil
+
+	case database.FieldTypeSelect:
+		s, ok := raw.(string)
+		if !ok || !contains(f.Options, s) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(f.Options, ", "))
+		}
+		return s, nil
+
+	case database.FieldTypeMultiSelect:
+		values, ok := stringList(raw)
+		if !ok {
+			return nil, fmt.Errorf("must be a list of options")
+		}
+		for _, s := range values {
+			if !contains(f.Options, s) {
+				return nil, fmt.Errorf("%q is not one of %s", s, strings.Join(f.Options, ", "))
+			}
+		}
+		return values, nil
+
+	case database.FieldTypePhoto:
+		photos, ok := stringList(raw)
+		if !ok {
+			return nil, fmt.Errorf("must be a photo or list of photos")
+		}
+		if f.MaxPhotos > 0 && len(photos) > f.MaxPhotos {
+			return nil, fmt.Errorf("at most %d photos allowed", f.MaxPhotos)
+		}
+		return photos, nil
+	}
+
+	return nil, fmt.Errorf("unsupported field type %q", f.Type)
+}
+
+// Flatten turns normalized answers into one queryable row per value
+func Flatten(fields []database.FormField, answers map[string]interface{}) []database.FormAnswer {
+	var rows []database.FormAnswer
+
+	for _, f := range fields {
+		switch v := answers[f.Key].(type) {
+		case string:
+			rows = append(rows, database.FormAnswer{FieldKey: f.Key, ValueText: &v})
+		case float64:
+			rows = append(rows, database.FormAnswer{FieldKey: f.Key, ValueNumber: &v})
+		case bool:
+			s := strconv.FormatBool(v)
+			rows = append(rows, database.FormAnswer{FieldKey: f.Key, ValueText: &s})
+		case []string:
+			for i := range v {
+				rows = append(rows, database.FormAnswer{FieldKey: f.Key, ValueText: &v[i]})
+			}
+		}
+	}
+
+	return rows
+}
+
+// Render lists the form's fields in order with the values from a submission
+// (nil when the form has not been filled in yet)
+func Render(fields []database.FormField, answers map[string]interface{}) []RenderedField {
+	rendered := make([]RenderedField, len(fields))
+	for i, f := range fields {
+		rendered[i] = RenderedField{FormField: f, Value: answers[f.Key]}
+	}
+	return rendered
+}
+
+func isBlank(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(x) == ""
+	case []interface{}:
+		return len(x) == 0
+	}
+	return false
+}
+
+// stringList accepts a single string or a JSON array of strings
+func stringList(raw interface{}) ([]string, bool) {
+	switch v := raw.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok || s == "" {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func contains(options []string, s string) bool {
+	for _, o := range options {
+		if o == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Dependency states
+const (
+	StatusUp            = "up"
+	StatusDown          = "down"
+	StatusNotConfigured = "not_configured"
+)
+
+// CheckFunc probes one dependency; a nil error means it is reachable
+type CheckFunc func(ctx context.Context) error
+
+// DependencyStatus is the latest check result of one dependency
+type DependencyStatus struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	LatencyMs   float64    `json:"latencyMs"`
+	CheckedAt   time.Time  `json:"checkedAt"`
+	LastError   *string    `json:"lastError,omitempty"` // most recent failure, kept after recovery
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+type dependency struct {
+	name        string
+	check       CheckFunc
+	lastError   *string
+	lastErrorAt *time.Time
+}
+
+// Registry runs dependency checks and remembers each dependency's last error
+type Registry struct {
+	timeout      time.Duration
+	dependencies []*dependency
+	mutex        sync.Mutex
+}
+
+// NewRegistry creates a registry whose checks are each bounded by timeout
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register adds a dependency. A nil check reports it as not configured.
+func (r *Registry) Register(name string, check CheckFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.dependencies = append(r.dependencies, &dependency{name: name, check: check})
+}
+
+// Check probes every dependency concurrently and returns their statuses in registration order
+func (r *Registry) Check(ctx context.Context) []DependencyStatus {
+	r.mutex.Lock()
+	dependencies := append([]*dependency{}, r.dependencies...)
+	r.mutex.Unlock()
+
+	statuses := make([]DependencyStatus, len(dependencies))
+	var wg sync.WaitGroup
+	for i, d := range dependencies {
+		wg.Add(1)
+		go func(i int, d *dependency) {
+			defer wg.Done()
+			statuses[i] = r.run(ctx, d)
+		}(i, d)
+	}
+	wg.Wait()
+
+	return statuses
+}
+
+func (r *Registry) run(ctx context.Context, d *dependency) DependencyStatus {
+	status := DependencyStatus{Name: d.name, Status: StatusNotConfigured, CheckedAt: time.Now()}
+	if d.check != nil {
+		checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		err := d.check(checkCtx)
+		cancel()
+
+		status.LatencyMs = float64(time.Since(status.CheckedAt).Microseconds()) / 1000
+		status.Status = StatusUp
+		if err != nil {
+			status.Status = StatusDown
+		}
+
+		r.mutex.Lock()
+		if err != nil {
+			msg := err.Error()
+			d.lastError = &msg
+			d.lastErrorAt = &status.CheckedAt
+		}
+		status.LastError = d.lastError
+		status.LastErrorAt = d.lastErrorAt
+		r.mutex.Unlock()
+	}
+	return status
+}
+
+// Healthy reports whether every configured dependency is up
+func Healthy(statuses []DependencyStatus) bool {
+	for _, s := range statuses {
+		if s.Status == StatusDown {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package immunization
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// Due dose statuses
+const (
+	StatusUpcoming = "upcoming" // due within the look-ahead window
+	StatusDue      = "due"      // due date reached
+	StatusOverdue  = "overdue"  // more than OverdueAfter past the due date
+)
+
+// OverdueAfter is how long past its due date a dose counts as overdue
+const OverdueAfter = 30 * 24 * time.Hour
+
+// minDoseInterval is the shortest gap between doses of the same vaccine
+const minDoseInterval = 28
+
+// Recommendation is one dose of the standard schedule
+type Recommendation struct {
+	Vaccine      string `json:"vaccine"`
+	DoseNumber   int    `json:"doseNumber"`
+	AgeMonths    int    `json:"ageMonths"`              // age the dose is due
+	UntilMonths  int    `json:"untilMonths,omitempty"`  // age after which a missed dose is no longer offered
+	RepeatMonths int    `json:"repeatMonths,omitempty"` // for boosters given for life, the interval between doses
+}
+
+// Schedule is the standard schedule, based on the Thai national immunization
+// programme (EPI) with adult dT boosters and yearly influenza from 65. DTP
+// boosters continue the DTP-HB-Hib series, so they are doses 4 and 5.
+var Schedule = []Recommendation{
+	{Vaccine: "BCG", DoseNumber: 1, AgeMonths: 0, UntilMonths: 12},
+	{Vaccine: "HB", DoseNumber: 1, AgeMonths: 0, UntilMonths: 1},
+	{Vaccine: "DTP-HB-Hib", DoseNumber: 1, AgeMonths: 2, UntilMonths: 84},
+	{Vaccine: "DTP-HB-Hib", DoseNumber: 2, AgeMonths: 4, UntilMonths: 84},
+	{Vaccine: "DTP-HB-Hib", DoseNumber: 3, AgeMonths: 6, UntilMonths: 84},
+	{Vaccine: "OPV", DoseNumber: 1, AgeMonths: 2, UntilMonths: 84},
+	{Vaccine: "OPV", DoseNumber: 2, AgeMonths: 4, UntilMonths: 84},
+	{Vaccine: "OPV", DoseNumber: 3, AgeMonths: 6, UntilMonths: 84},
+	{Vaccine: "OPV", DoseNumber: 4, AgeMonths: 18, UntilMonths: 84},
+	{Vaccine: "OPV", DoseNumber: 5, AgeMonths: 48, UntilMonths: 84},
+	{Vaccine: "IPV", DoseNumber: 1, AgeMonths: 4, UntilMonths: 84},
+	{Vaccine: "Rota", DoseNumber: 1, AgeMonths: 2, UntilMonths: 4},
+	{Vaccine: "Rota", DoseNumber: 2, AgeMonths: 4, UntilMonths: 8},
+	{Vaccine: "MMR", DoseNumber: 1, AgeMonths: 9, UntilMonths: 180},
+	{Vaccine: "MMR", DoseNumber: 2, AgeMonths: 18, UntilMonths: 180},
+	{Vaccine: "JE", DoseNumber: 1, AgeMonths: 12, UntilMonths: 180},
+	{Vaccine: "JE", DoseNumber: 2, AgeMonths: 30, UntilMonths: 180},
+	{Vaccine: "DTP", DoseNumber: 4, AgeMonths: 18, UntilMonths: 84},
+	{Vaccine: "DTP", DoseNumber: 5, AgeMonths: 48, UntilMonths: 84},
+	{Vaccine: "dT", DoseNumber: 1, AgeMonths: 144, RepeatMonths: 120},
+	{Vaccine: "Influenza", DoseNumber: 1, AgeMonths: 780, RepeatMonths: 12},
+}
+
+// Known reports the schedule's spelling of vaccine, matched regardless of case
+func Known(vaccine string) (string, bool) {
+	for _, rec := range Schedule {
+		if strings.EqualFold(rec.Vaccine, vaccine) {
+			return rec.Vaccine, true
+		}
+	}
+	return "", false
+}
+
+// DueDose is a scheduled dose a patient has not had
+type DueDose struct {
+	Vaccine     string `json:"vaccine"`
+	DoseNumber  int    `json:"doseNumber"`
+	DueDate     string `json:"dueDate"` // YYYY-MM-DD
+	Status      string `json:"status"`
+	DaysOverdue int    `json:"daysOverdue,omitempty"` // days past the due date
+}
+
+// Due lists the doses a patient born on dob has not had that are due by
+// today plus within, earliest first. A dose in a series waits at least four
+// weeks after the one before it, and doses a patient has aged out of are left
+// out along with the rest of their series.
+func Due(dob time.Time, given []database.Vaccination, today time.Time, within time.Duration) []DueDose {
+	last := make(map[string]database.Vaccination) // vaccine -> latest dose given
+	had := make(map[doseKey]time.Time)            // when each dose was given
+	for _, v := range given {
+		name, ok := Known(v.Vaccine)
+		if !ok {
+			continue
+		}
+		at, err := time.ParseInLocation("2006-01-02", v.AdministeredDate, today.Location())
+		if err != nil {
+			continue
+		}
+		had[doseKey{name, v.DoseNumber}] = at
+		if prev, ok := last[name]; !ok || v.AdministeredDate > prev.AdministeredDate {
+			last[name] = v
+		}
+	}
+
+	horizon := today.Add(within)
+	previous := make(map[string]*time.Time) // vaccine -> when the previous dose in the series was given or is due; nil once it was missed
+	due := []DueDose{}
+	for _, rec := range Schedule {
+		dose := rec.DoseNumber
+		dueDate := dob.AddDate(0, rec.AgeMonths, 0)
+		if rec.RepeatMonths > 0 {
+			// Lifelong boosters fall due again an interval after the latest one
+			if prev, ok := last[rec.Vaccine]; ok {
+				dose = prev.DoseNumber + 1
+				if next := had[doseKey{rec.Vaccine, prev.DoseNumber}].AddDate(0, rec.RepeatMonths, 0); next.After(dueDate) {
+					dueDate = next
+				}
+			}
+		} else {
+			if at, ok := had[doseKey{rec.Vaccine, dose}]; ok {
+				previous[rec.Vaccine] = &at
+				continue
+			}
+			prev, inSeries := previous[rec.Vaccine]
+			if (inSeries && prev == nil) || (rec.UntilMonths > 0 && !dob.AddDate(0, rec.UntilMonths, 0).After(today)) {
+				previous[rec.Vaccine] = nil
+				continue
+			}
+			if inSeries {
+				if next := prev.AddDate(0, 0, minDoseInterval); next.After(dueDate) {
+					dueDate = next
+				}
+			}
+			// A dose still to be given can be given today at the earliest
+			scheduled := dueDate
+			if scheduled.Before(today) {
+				scheduled = today
+			}
+			previous[rec.Vaccine] = &scheduled
+		}
+		if dueDate.After(horizon) {
+			continue
+		}
+
+		d := DueDose{Vaccine: rec.Vaccine, DoseNumber: dose, DueDate: dueDate.Format("2006-01-02"), Status: StatusUpcoming}
+		if !dueDate.After(today) {
+			d.Status = StatusDue
+			if today.Sub(dueDate) > OverdueAfter {
+				d.Status = StatusOverdue
+				d.DaysOverdue = int(today.Sub(dueDate).Hours() / 24)
+			}
+		}
+		due = append(due, d)
+	}
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].DueDate < due[j].DueDate })
+	return due
+}
+
+// doseKey identifies one dose of a vaccine
+type doseKey struct {
+	vaccine string
+	number  int
+}
@@ -0,0 +1,99 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Config controls a run
+type Config struct {
+	PeakRate    float64       // requests per second in the busiest phase
+	Duration    time.Duration // whole run, split between phases by their share
+	Concurrency int           // most requests in flight; later arrivals are dropped and counted
+	ReportEvery time.Duration // print an interval summary this often; 0 for none
+}
+
+// Run replays a scenario at open-loop arrival rates, so a slow server does not
+// slow the traffic down, and returns the summary of the whole run. Cancelling
+// ctx ends the run early; requests in flight are still counted.
+func Run(ctx context.Context, c *Client, k *Clinic, s Scenario, cfg Config, out io.Writer) Summary {
+	rec := newRecorder()
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	if cfg.ReportEvery > 0 {
+		reportCtx, stopReports := context.WithCancel(ctx)
+		defer stopReports()
+		go func() {
+			ticker := time.NewTicker(cfg.ReportEvery)
+			defer ticker.Stop()
+			last := start
+			for {
+				select {
+				case <-reportCtx.Done():
+					return
+				case now := <-ticker.C:
+					Print(out, fmt.Sprintf("[%s]", now.Sub(start).Round(time.Second)), summarize(rec.takeInterval(), now.Sub(last)))
+					fmt.Fprintln(out)
+					last = now
+				}
+			}
+		}()
+	}
+
+	for _, phase := range s.Phases {
+		fmt.Fprintf(out, "phase %s: %.1f req/s for %s\n", phase.Name, cfg.PeakRate*phase.Rate,
+			time.Duration(float64(cfg.Duration)*phase.Duration).Round(time.Second))
+		if !runPhase(ctx, c, k, phase, cfg, rec, sem, &wg) {
+			break
+		}
+	}
+	wg.Wait()
+
+	return summarize(rec.total(), time.Since(start))
+}
+
+// runPhase sends the phase's arrivals until it ends; false means ctx was cancelled
+func runPhase(ctx context.Context, c *Client, k *Clinic, phase Phase, cfg Config, rec *recorder, sem chan struct{}, wg *sync.WaitGroup) bool {
+	end := time.Now().Add(time.Duration(float64(cfg.Duration) * phase.Duration))
+	rate := cfg.PeakRate * phase.Rate
+	if rate <= 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(time.Until(end)):
+			return true
+		}
+	}
+
+	gap := time.Duration(float64(time.Second) / rate)
+	for next := time.Now(); next.Before(end); next = next.Add(gap) {
+		if wait := time.Until(next); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(wait):
+			}
+		}
+
+		step := phase.pick()
+		select {
+		case sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				sent := time.Now()
+				status, err := step.Do(c, k)
+				rec.record(step.Name, time.Since(sent), status, err)
+			}()
+		default:
+			rec.drop(step.Name)
+		}
+	}
+	return true
+}
@@ -0,0 +1,362 @@
+package loadtest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSkipped is returned by a step that had nothing to work on, e.g. no open visit to close
+var ErrSkipped = errors.New("skipped")
+
+// Step is one kind of request in a traffic mix
+type Step struct {
+	Name   string
+	Weight int
+	Do     func(c *Client, k *Clinic) (int, error)
+}
+
+// Phase is a stretch of a scenario, as a share of the run's duration and of the peak rate
+type Phase struct {
+	Name     string
+	Duration float64
+	Rate     float64
+	Mix      []Step
+}
+
+// pick chooses a step by weight
+func (p Phase) pick() Step {
+	total := 0
+	for _, s := range p.Mix {
+		total += s.Weight
+	}
+	n := rand.Intn(total)
+	for _, s := range p.Mix {
+		if n < s.Weight {
+			return s
+		}
+		n -= s.Weight
+	}
+	return p.Mix[len(p.Mix)-1]
+}
+
+// Scenario is a named traffic pattern
+type Scenario struct {
+	Name        string
+	Description string
+	Phases      []Phase
+}
+
+// Clinic is what a run works against: seeded patients and doctor, the visits
+// opened so far that end-of-day steps can complete, and the closed visits and
+// invoices waiting to be billed and paid
+type Clinic struct {
+	Patients []string
+	DoctorID int
+	slotBase time.Time
+	slot     int64
+	visits   []int
+	closed   []int
+	invoices []bill
+	mutex    sync.Mutex
+}
+
+// bill is an invoice the run generated, with the amount still to pay once issued
+type bill struct {
+	id     int
+	total  float64
+	issued bool
+}
+
+func (k *Clinic) patient() string {
+	return k.Patients[rand.Intn(len(k.Patients))]
+}
+
+// nextSlot hands out consecutive 15-minute slots a year ahead so bookings never clash
+func (k *Clinic) nextSlot() time.Time {
+	return k.slotBase.Add(time.Duration(atomic.AddInt64(&k.slot, 1)) * 15 * time.Minute)
+}
+
+func (k *Clinic) addVisit(id int) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.visits = append(k.visits, id)
+}
+
+// visit returns an open visit, removing it when take is set
+func (k *Clinic) visit(take bool) (int, bool) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if len(k.visits) == 0 {
+		return 0, false
+	}
+	i := rand.Intn(len(k.visits))
+	id := k.visits[i]
+	if take {
+		k.visits[i] = k.visits[len(k.visits)-1]
+		k.visits = k.visits[:len(k.visits)-1]
+	}
+	return id, true
+}
+
+// Seed registers patients load-test patients (HN900001 onwards, reused across runs),
+// a doctor who works every day, and visits open visits for end-of-day steps
+func Seed(c *Client, patients, visits int) (*Clinic, error) {
+	if patients < 1 {
+		return nil, fmt.Errorf("at least one patient is needed")
+	}
+
+	k := &Clinic{slotBase: time.Now().AddDate(1, 0, 0).Truncate(24 * time.Hour)}
+	for i := 1; i <= patients; i++ {
+		hn := fmt.Sprintf("HN%06d", 900000+i)
+		status, err := c.Do(http.MethodPost, "/api/patients", map[string]interface{}{
+			"hn":       hn,
+			"fullName": fmt.Sprintf("ผู้ป่วยทดสอบ %d", i),
+			"gender":   "ไม่ระบุ",
+			"age":      20 + i%60,
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("seed patient %s: %w", hn, err)
+		}
+		if status != http.StatusCreated && status != http.StatusConflict {
+			return nil, fmt.Errorf("seed patient %s: status %d", hn, status)
+		}
+		k.Patients = append(k.Patients, hn)
+	}
+
+	var doctor struct {
+		ID int `json:"id"`
+	}
+	status, err := c.Do(http.MethodPost, "/api/doctors", map[string]interface{}{
+		"fullName":      "Load Test Doctor",
+		"specialty":     "general practice",
+		"licenseNumber": fmt.Sprintf("LOAD%d", time.Now().UnixNano()),
+		"workingDays":   []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"},
+	}, &doctor)
+	if err != nil {
+		return nil, fmt.Errorf("seed doctor: %w", err)
+	}
+	if status != http.StatusCreated {
+		return nil, fmt.Errorf("seed doctor: status %d", status)
+	}
+	k.DoctorID = doctor.ID
+
+	for i := 0; i < visits; i++ {
+		if status, err := openVisit(c, k); err != nil || status != http.StatusCreated {
+			return nil, fmt.Errorf("seed visit: status %d: %v", status, err)
+		}
+	}
+
+	return k, nil
+}
+
+func get(path func(k *Clinic) string) func(c *Client, k *Clinic) (int, error) {
+	return func(c *Client, k *Clinic) (int, error) {
+		return c.Do(http.MethodGet, path(k), nil, nil)
+	}
+}
+
+func bookAppointment(c *Client, k *Clinic) (int, error) {
+	return c.Do(http.MethodPost, "/api/appointments", map[string]interface{}{
+		"patientHn": k.patient(),
+		"doctorId":  k.DoctorID,
+		"startsAt":  k.nextSlot(),
+		"reason":    "ติดตามอาการ",
+	}, nil)
+}
+
+func openVisit(c *Client, k *Clinic) (int, error) {
+	var visit struct {
+		ID int `json:"id"`
+	}
+	status, err := c.Do(http.MethodPost, "/api/patients/"+k.patient()+"/visits", map[string]interface{}{
+		"doctorId":       k.DoctorID,
+		"chiefComplaint": "ไข้ ไอ เจ็บคอ 2 วัน",
+	}, &visit)
+	if err == nil && status == http.StatusCreated {
+		k.addVisit(visit.ID)
+	}
+	return status, err
+}
+
+func recordFindings(c *Client, k *Clinic) (int, error) {
+	id, ok := k.visit(false)
+	if !ok {
+		return 0, ErrSkipped
+	}
+	return c.Do(http.MethodPut, fmt.Sprintf("/api/visits/%d", id), map[string]interface{}{
+		"doctorId":       k.DoctorID,
+		"chiefComplaint": "ไข้ ไอ เจ็บคอ 2 วัน",
+		"diagnosis":      "Acute pharyngitis",
+		"treatment":      "Paracetamol 500 mg prn, warm saline gargle",
+	}, nil)
+}
+
+func closeVisit(c *Client, k *Clinic) (int, error) {
+	id, ok := k.visit(true)
+	if !ok {
+		return 0, ErrSkipped
+	}
+	status, err := c.Do(http.MethodPost, fmt.Sprintf("/api/visits/%d/close", id), nil, nil)
+	if err == nil && status == http.StatusOK {
+		k.mutex.Lock()
+		k.closed = append(k.closed, id)
+		k.mutex.Unlock()
+	}
+	return status, err
+}
+
+// takeClosed removes and returns a closed visit that has not been invoiced
+func (k *Clinic) takeClosed() (int, bool) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if len(k.closed) == 0 {
+		return 0, false
+	}
+	id := k.closed[len(k.closed)-1]
+	k.closed = k.closed[:len(k.closed)-1]
+	return id, true
+}
+
+// takeBill removes and returns a generated invoice, preferring issued ones so payments keep up
+func (k *Clinic) takeBill() (bill, bool) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if len(k.invoices) == 0 {
+		return bill{}, false
+	}
+	i := 0
+	for j, b := range k.invoices {
+		if b.issued {
+			i = j
+			break
+		}
+	}
+	b := k.invoices[i]
+	k.invoices = append(k.invoices[:i], k.invoices[i+1:]...)
+	return b, true
+}
+
+func (k *Clinic) addBill(b bill) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.invoices = append(k.invoices, b)
+}
+
+func generateInvoice(c *Client, k *Clinic) (int, error) {
+	id, ok := k.takeClosed()
+	if !ok {
+		return 0, ErrSkipped
+	}
+	var invoice struct {
+		ID    int     `json:"id"`
+		Total float64 `json:"total"`
+	}
+	status, err := c.Do(http.MethodPost, fmt.Sprintf("/api/visits/%d/invoice", id), map[string]interface{}{
+		"items": []map[string]interface{}{
+			{"kind": "service", "description": "ค่าตรวจแพทย์", "quantity": 1, "unitPrice": 300},
+		},
+	}, &invoice)
+	if err == nil && status == http.StatusCreated {
+		k.addBill(bill{id: invoice.ID, total: invoice.Total})
+	}
+	return status, err
+}
+
+// settleInvoice issues a draft invoice, or pays an issued one in full in cash
+func settleInvoice(c *Client, k *Clinic) (int, error) {
+	b, ok := k.takeBill()
+	if !ok {
+		return 0, ErrSkipped
+	}
+	if !b.issued {
+		status, err := c.Do(http.MethodPut, fmt.Sprintf("/api/invoices/%d/status", b.id), map[string]interface{}{
+			"status": "issued",
+		}, nil)
+		if err == nil && status == http.StatusOK {
+			b.issued = true
+			k.addBill(b)
+		}
+		return status, err
+	}
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/invoices/%d/payments", b.id), map[string]interface{}{
+		"method": "cash",
+		"amount": b.total,
+	}, nil)
+}
+
+// Traffic mixes, weighted by how often each request happens at that time of day
+var (
+	checkInMix = []Step{
+		{Name: "patients.list", Weight: 2, Do: get(func(k *Clinic) string { return "/api/patients" })},
+		{Name: "patient.get", Weight: 4, Do: get(func(k *Clinic) string { return "/api/patients/" + k.patient() })},
+		{Name: "appointments.today", Weight: 3, Do: get(func(k *Clinic) string { return "/api/appointments?date=" + time.Now().Format("2006-01-02") })},
+		{Name: "appointment.book", Weight: 2, Do: bookAppointment},
+		{Name: "visit.open", Weight: 4, Do: openVisit},
+		{Name: "health", Weight: 1, Do: get(func(k *Clinic) string { return "/health" })},
+	}
+	billingMix = []Step{
+		{Name: "visit.update", Weight: 3, Do: recordFindings},
+		{Name: "visit.close", Weight: 3, Do: closeVisit},
+		{Name: "invoice.generate", Weight: 2, Do: generateInvoice},
+		{Name: "invoice.settle", Weight: 3, Do: settleInvoice},
+		{Name: "visits.patient", Weight: 3, Do: get(func(k *Clinic) string { return "/api/patients/" + k.patient() + "/visits" })},
+		{Name: "appointments.today", Weight: 2, Do: get(func(k *Clinic) string { return "/api/appointments?date=" + time.Now().Format("2006-01-02") })},
+		{Name: "reconciliation.transactions", Weight: 1, Do: get(func(k *Clinic) string { return "/api/reconciliation/transactions" })},
+	}
+	steadyMix = append(append([]Step{}, checkInMix...), billingMix...)
+)
+
+// Scenarios are the built-in traffic patterns, by name
+var Scenarios = map[string]Scenario{
+	"morning": {
+		Name:        "morning",
+		Description: "Morning check-in spike: patient lookups, bookings and visits opening",
+		Phases: []Phase{
+			{Name: "warm-up", Duration: 0.2, Rate: 0.3, Mix: checkInMix},
+			{Name: "spike", Duration: 0.5, Rate: 1, Mix: checkInMix},
+			{Name: "taper", Duration: 0.3, Rate: 0.5, Mix: checkInMix},
+		},
+	},
+	"billing": {
+		Name:        "billing",
+		Description: "End-of-day billing: findings recorded and visits closed",
+		Phases: []Phase{
+			{Name: "billing", Duration: 1, Rate: 1, Mix: billingMix},
+		},
+	},
+	"day": {
+		Name:        "day",
+		Description: "A compressed clinic day: morning spike, quiet midday, end-of-day billing",
+		Phases: []Phase{
+			{Name: "morning", Duration: 0.35, Rate: 1, Mix: checkInMix},
+			{Name: "midday", Duration: 0.3, Rate: 0.3, Mix: steadyMix},
+			{Name: "end-of-day", Duration: 0.35, Rate: 0.8, Mix: billingMix},
+		},
+	},
+	"soak": {
+		Name:        "soak",
+		Description: "Steady mixed traffic at half the peak rate for long runs; watch interval reports for drift",
+		Phases: []Phase{
+			{Name: "soak", Duration: 1, Rate: 0.5, Mix: steadyMix},
+		},
+	},
+}
+
+// ScenarioNames lists the built-in scenarios alphabetically
+func ScenarioNames() []string {
+	names := make([]string, 0, len(Scenarios))
+	for name := range Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,179 @@
+package loadtest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// StepStats summarizes one step's requests. Errors are transport failures and 5xx
+// answers; Rejected are 4xx answers such as a double booking; Dropped are arrivals
+// that found every connection busy and were never sent.
+type StepStats struct {
+	Name     string        `json:"name"`
+	Requests int           `json:"requests"`
+	Errors   int           `json:"errors"`
+	Rejected int           `json:"rejected"`
+	Skipped  int           `json:"skipped"`
+	Dropped  int           `json:"dropped"`
+	P50      time.Duration `json:"p50"`
+	P90      time.Duration `json:"p90"`
+	P95      time.Duration `json:"p95"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+}
+
+// Summary is the outcome of a run or of one reporting interval
+type Summary struct {
+	Elapsed    time.Duration `json:"elapsed"`
+	Throughput float64       `json:"throughput"` // requests answered per second
+	ErrorRate  float64       `json:"errorRate"`
+	Total      StepStats     `json:"total"`
+	Steps      []StepStats   `json:"steps"`
+}
+
+type samples struct {
+	latencies []time.Duration
+	errors    int
+	rejected  int
+	skipped   int
+	dropped   int
+}
+
+// recorder collects latencies for the whole run and for the current interval
+type recorder struct {
+	all      map[string]*samples
+	interval map[string]*samples
+	mutex    sync.Mutex
+}
+
+func newRecorder() *recorder {
+	return &recorder{all: make(map[string]*samples), interval: make(map[string]*samples)}
+}
+
+func (r *recorder) add(name string, apply func(s *samples)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, m := range []map[string]*samples{r.all, r.interval} {
+		s := m[name]
+		if s == nil {
+			s = &samples{}
+			m[name] = s
+		}
+		apply(s)
+	}
+}
+
+func (r *recorder) record(name string, latency time.Duration, status int, err error) {
+	r.add(name, func(s *samples) {
+		switch {
+		case err == ErrSkipped:
+			s.skipped++
+			return
+		case err != nil || status >= 500:
+			s.errors++
+		case status >= 400:
+			s.rejected++
+		}
+		s.latencies = append(s.latencies, latency)
+	})
+}
+
+func (r *recorder) drop(name string) {
+	r.add(name, func(s *samples) { s.dropped++ })
+}
+
+// takeInterval returns the samples since the previous call and starts a new interval
+func (r *recorder) takeInterval() map[string]*samples {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	interval := r.interval
+	r.interval = make(map[string]*samples)
+	return interval
+}
+
+func (r *recorder) total() map[string]*samples {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.all
+}
+
+func summarize(m map[string]*samples, elapsed time.Duration) Summary {
+	summary := Summary{Elapsed: elapsed, Steps: []StepStats{}}
+	total := &samples{}
+	for name, s := range m {
+		summary.Steps = append(summary.Steps, stepStats(name, s))
+		total.latencies = append(total.latencies, s.latencies...)
+		total.errors += s.errors
+		total.rejected += s.rejected
+		total.skipped += s.skipped
+		total.dropped += s.dropped
+	}
+	sort.Slice(summary.Steps, func(i, j int) bool { return summary.Steps[i].Name < summary.Steps[j].Name })
+
+	summary.Total = stepStats("total", total)
+	if elapsed > 0 {
+		summary.Throughput = float64(summary.Total.Requests) / elapsed.Seconds()
+	}
+	if sent := summary.Total.Requests + summary.Total.Dropped; sent > 0 {
+		summary.ErrorRate = float64(summary.Total.Errors+summary.Total.Dropped) / float64(sent)
+	}
+	return summary
+}
+
+func stepStats(name string, s *samples) StepStats {
+	latencies := append([]time.Duration{}, s.latencies...)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	stats := StepStats{
+		Name:     name,
+		Requests: len(latencies),
+		Errors:   s.errors,
+		Rejected: s.rejected,
+		Skipped:  s.skipped,
+		Dropped:  s.dropped,
+		P50:      percentile(latencies, 50),
+		P90:      percentile(latencies, 90),
+		P95:      percentile(latencies, 95),
+		P99:      percentile(latencies, 99),
+	}
+	if len(latencies) > 0 {
+		stats.Max = latencies[len(latencies)-1]
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Print writes the summary as a table headed by title
+func Print(w io.Writer, title string, s Summary) {
+	fmt.Fprintf(w, "%s: %d requests in %s (%.1f req/s), error rate %.2f%%\n",
+		title, s.Total.Requests, s.Elapsed.Round(time.Second), s.Throughput, s.ErrorRate*100)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "step\trequests\terrors\trejected\tskipped\tdropped\tp50\tp90\tp95\tp99\tmax\t")
+	for _, step := range append(s.Steps, s.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t\n", step.Name, step.Requests, step.Errors,
+			step.Rejected, step.Skipped, step.Dropped, ms(step.P50), ms(step.P90), ms(step.P95), ms(step.P99), ms(step.Max))
+	}
+	tw.Flush()
+}
+
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
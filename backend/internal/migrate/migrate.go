@@ -0,0 +1,192 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Finding kinds
+const (
+	// KindDestructive loses data or breaks instances still running the old code
+	KindDestructive = "destructive"
+	// KindLocking holds a lock that blocks live reads or writes for the length of a table scan or rewrite
+	KindLocking = "locking"
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Finding is an operation that is unsafe to run against live traffic
+type Finding struct {
+	Version   int    `json:"version"`
+	Statement string `json:"statement"`
+	Kind      string `json:"kind"`
+	Rule      string `json:"rule"`
+	Message   string `json:"message"`
+}
+
+// GuardError is returned when pending migrations contain unsafe operations and force was not given
+type GuardError struct {
+	Findings []Finding
+}
+
+func (e *GuardError) Error() string {
+	lines := make([]string, 0, len(e.Findings)+1)
+	lines = append(lines, fmt.Sprintf("migration pre-flight found %d unsafe operation(s); re-run with force to apply:", len(e.Findings)))
+	for _, f := range e.Findings {
+		lines = append(lines, fmt.Sprintf("  v%d [%s] %s: %s", f.Version, f.Kind, f.Rule, f.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type rule struct {
+	name    string
+	kind    string
+	pattern *regexp.Regexp
+	unless  *regexp.Regexp // statement is safe when this also matches
+	message string
+	onTable bool // only applies to tables that existed before this migration
+}
+
+var rules = []rule{
+	{name: "drop-table", kind: KindDestructive, pattern: regexp.MustCompile(`^DROP TABLE`),
+		message: "drops a table and its data"},
+	{name: "drop-column", kind: KindDestructive, pattern: regexp.MustCompile(`^ALTER TABLE .* DROP (COLUMN )?`),
+		unless:  regexp.MustCompile(`DROP (CONSTRAINT|DEFAULT|NOT NULL)`),
+		message: "drops a column that running instances may still read or write"},
+	{name: "rename", kind: KindDestructive, pattern: regexp.MustCompile(`^ALTER TABLE .* RENAME `),
+		message: "renames a table or column out from under running instances"},
+	{name: "truncate", kind: KindDestructive, pattern: regexp.MustCompile(`^TRUNCATE`),
+		message: "deletes every row"},
+	{name: "delete-all", kind: KindDestructive, pattern: regexp.MustCompile(`^DELETE FROM`),
+		unless:  regexp.MustCompile(` WHERE `),
+		message: "deletes every row"},
+	{name: "add-not-null-column", kind: KindDestructive, pattern: regexp.MustCompile(`^ALTER TABLE .* ADD (COLUMN )?.* NOT NULL`),
+		unless:  regexp.MustCompile(` DEFAULT `),
+		message: "adds a NOT NULL column without a default; fails on existing rows and rejects inserts from old code", onTable: true},
+	{name: "change-column-type", kind: KindLocking, pattern: regexp.MustCompile(`^ALTER TABLE .* ALTER (COLUMN )?\S+ (SET DATA )?TYPE `),
+		message: "rewrites the table under an exclusive lock", onTable: true},
+	{name: "set-not-null", kind: KindLocking, pattern: regexp.MustCompile(`^ALTER TABLE .* SET NOT NULL`),
+		message: "scans the whole table under an exclusive lock; add a NOT VALID check constraint first", onTable: true},
+	{name: "add-constraint", kind: KindLocking, pattern: regexp.MustCompile(`^ALTER TABLE .* ADD (CONSTRAINT \S+ )?(FOREIGN KEY|CHECK)`),
+		unless:  regexp.MustCompile(`NOT VALID`),
+		message: "validates every row while holding a lock; add it NOT VALID and VALIDATE CONSTRAINT separately", onTable: true},
+	{name: "create-index", kind: KindLocking, pattern: regexp.MustCompile(`^CREATE (UNIQUE )?INDEX `),
+		unless:  regexp.MustCompile(`INDEX CONCURRENTLY`),
+		message: "blocks writes while the index builds; use CREATE INDEX CONCURRENTLY", onTable: true},
+	{name: "drop-index", kind: KindLocking, pattern: regexp.MustCompile(`^DROP INDEX `),
+		unless:  regexp.MustCompile(`INDEX CONCURRENTLY`),
+		message: "takes an exclusive lock on the table; use DROP INDEX CONCURRENTLY"},
+	{name: "full-rewrite", kind: KindLocking, pattern: regexp.MustCompile(`^(VACUUM FULL|CLUSTER|LOCK TABLE)`),
+		message: "holds an exclusive lock for the whole operation"},
+}
+
+var (
+	createTablePattern = regexp.MustCompile(`^CREATE TABLE (IF NOT EXISTS )?("?[\w.]+"?)`)
+	targetPatterns     = []*regexp.Regexp{
+		regexp.MustCompile(`^ALTER TABLE (IF EXISTS )?(ONLY )?("?[\w.]+"?)`),
+		regexp.MustCompile(` ON (ONLY )?("?[\w.]+"?)`),
+	}
+	spacePattern        = regexp.MustCompile(`\s+`)
+	concurrentlyPattern = regexp.MustCompile(`\bCONCURRENTLY\b`)
+)
+
+// Analyze lists the unsafe operations in the migrations. Lock rules are skipped
+// for tables created earlier in the same batch, since nothing reads them yet.
+func Analyze(migrations []Migration) []Finding {
+	findings := []Finding{}
+	created := map[string]bool{}
+	for _, m := range migrations {
+		for _, stmt := range Statements(m.SQL) {
+			normalized := strings.ToUpper(spacePattern.ReplaceAllString(stmt, " "))
+			if match := createTablePattern.FindStringSubmatch(normalized); match != nil {
+				created[strings.Trim(match[2], `"`)] = true
+				continue
+			}
+			for _, rl := range rules {
+				if !rl.pattern.MatchString(normalized) || (rl.unless != nil && rl.unless.MatchString(normalized)) {
+					continue
+				}
+				if rl.onTable && created[target(normalized)] {
+					continue
+				}
+				findings = append(findings, Finding{
+					Version:   m.Version,
+					Statement: stmt,
+					Kind:      rl.kind,
+					Rule:      rl.name,
+					Message:   rl.message,
+				})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// Preflight returns a GuardError when the migrations contain unsafe operations, unless force is set
+func Preflight(migrations []Migration, force bool) ([]Finding, error) {
+	findings := Analyze(migrations)
+	if len(findings) > 0 && !force {
+		return findings, &GuardError{Findings: findings}
+	}
+	return findings, nil
+}
+
+// NeedsNoTransaction reports whether the migration contains statements Postgres refuses to run in a transaction
+func NeedsNoTransaction(sql string) bool {
+	return concurrentlyPattern.MatchString(strings.ToUpper(sql))
+}
+
+func target(normalized string) string {
+	for _, p := range targetPatterns {
+		if match := p.FindStringSubmatch(normalized); match != nil {
+			return strings.Trim(match[len(match)-1], `"`)
+		}
+	}
+	return ""
+}
+
+// Statements splits SQL into statements on semicolons outside quotes and comments
+func Statements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	inQuote, inComment := false, false
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			statements = append(statements, s)
+		}
+		current.Reset()
+	}
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case inComment:
+			if c == '\n' {
+				inComment = false
+				current.WriteByte(' ')
+			}
+			continue
+		case inQuote:
+			if c == '\'' {
+				inQuote = false
+			}
+		case c == '\'':
+			inQuote = true
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			inComment = true
+			continue
+		case c == ';':
+			flush()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	flush()
+	return statements
+}
@@ -0,0 +1,103 @@
+// Package password hashes staff account passwords with PBKDF2-SHA256 and
+// generates temporary passwords for resets. Hashes are self-describing,
+// "pbkdf2-sha256$<iterations>$<salt>$<key>" with base64 salt and key, so the
+// iteration count can be raised without invalidating existing hashes.
+package password
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	scheme     = "pbkdf2-sha256"
+	iterations = 600000 // OWASP's recommendation for PBKDF2-HMAC-SHA256
+	saltLength = 16
+	keyLength  = 32
+
+	// MinLength is the fewest characters a password may have
+	MinLength = 8
+	// MaxLength caps the work a single hash can be made to do
+	MaxLength = 128
+)
+
+var encoding = base64.RawStdEncoding
+
+// Check reports why a password cannot be used, or "" when it can
+func Check(password string) string {
+	switch n := utf8.RuneCountInString(password); {
+	case n < MinLength:
+		return fmt.Sprintf("password must be at least %d characters", MinLength)
+	case n > MaxLength:
+		return fmt.Sprintf("password must be at most %d characters", MaxLength)
+	}
+	return ""
+}
+
+// Hash returns the encoded hash of password under a new random salt
+func Hash(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, keyLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return scheme + "$" + strconv.Itoa(iterations) + "$" + encoding.EncodeToString(salt) + "$" + encoding.EncodeToString(key), nil
+}
+
+// Verify reports whether password matches an encoded hash; a malformed hash matches nothing
+func Verify(password, hash string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != scheme {
+		return false
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil || n < 1 {
+		return false
+	}
+	salt, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := encoding.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, n, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// temporaryAlphabet leaves out characters read aloud or copied by hand wrongly: 0/O, 1/l/I
+const temporaryAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Temporary returns a random 12-character password to hand to a user after a reset
+func Temporary() (string, error) {
+	// Bytes at or above the largest multiple of the alphabet's length are
+	// skipped so every character is equally likely
+	limit := 256 - 256%len(temporaryAlphabet)
+	out := make([]byte, 0, 12)
+	b := make([]byte, 16)
+	for len(out) < cap(out) {
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		for _, c := range b {
+			if int(c) < limit && len(out) < cap(out) {
+				out = append(out, temporaryAlphabet[int(c)%len(temporaryAlphabet)])
+			}
+		}
+	}
+	return string(out), nil
+}
@@ -0,0 +1,225 @@
+// Package photomigrate moves base64 patient photos out of the patients table
+// into a storage backend, replacing each with the URL it is served from.
+package photomigrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/storage"
+)
+
+// Source is where inline photos are read from and their URLs written back
+type Source interface {
+	GetInlinePhotos(afterHN string, limit int) ([]database.InlinePhoto, error)
+	ReplacePhoto(hn, inline, url string) (bool, error)
+}
+
+// Failure is a photo that could not be migrated
+type Failure struct {
+	HN     string `json:"hn"`
+	Reason string `json:"reason"`
+}
+
+// Checkpoint is the progress of a migration, saved after every batch so an
+// interrupted run resumes after the last patient it finished
+type Checkpoint struct {
+	LastHN    string    `json:"lastHn"`
+	Migrated  int       `json:"migrated"`
+	Bytes     int64     `json:"bytes"`   // decoded photo bytes moved to storage
+	Changed   int       `json:"changed"` // photos edited during the run, left for the next run
+	Failed    []Failure `json:"failed"`
+	StartedAt time.Time `json:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Done      bool      `json:"done"`
+}
+
+// LoadCheckpoint reads a checkpoint file; a missing file starts from the beginning
+func LoadCheckpoint(file string) (*Checkpoint, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Checkpoint{Failed: []Failure{}, StartedAt: time.Now()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", file, err)
+	}
+	return &c, nil
+}
+
+// Save writes the checkpoint through a temporary file so a crash never leaves it half written
+func (c *Checkpoint) Save(file string) error {
+	c.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file+".tmp", data, 0o600); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return os.Rename(file+".tmp", file)
+}
+
+// Migrator moves photos in batches
+type Migrator struct {
+	Source    Source
+	Store     storage.Store
+	BatchSize int
+	Pause     time.Duration // wait between batches to spare the database
+	DryRun    bool          // decode and count photos without storing or rewriting them
+	Log       io.Writer
+}
+
+// Run migrates photos after the checkpoint's last HN until none are left or
+// ctx is cancelled, calling save after each batch. Failed photos are recorded
+// and skipped; rerunning from the start retries them, since migrated photos
+// are URLs and no longer selected.
+func (m *Migrator) Run(ctx context.Context, c *Checkpoint, save func(*Checkpoint) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		photos, err := m.Source.GetInlinePhotos(c.LastHN, m.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(photos) == 0 {
+			c.Done = true
+			return save(c)
+		}
+
+		for _, p := range photos {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := m.migrate(ctx, c, p); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				c.Failed = append(c.Failed, Failure{HN: p.HN, Reason: err.Error()})
+				fmt.Fprintf(m.Log, "%s: %v\n", p.HN, err)
+			}
+			c.LastHN = p.HN
+		}
+
+		if err := save(c); err != nil {
+			return err
+		}
+		fmt.Fprintf(m.Log, "through %s: %d migrated, %d failed, %.1f MB moved\n",
+			c.LastHN, c.Migrated, len(c.Failed), float64(c.Bytes)/(1<<20))
+
+		if m.Pause > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(m.Pause):
+			}
+		}
+	}
+}
+
+func (m *Migrator) migrate(ctx context.Context, c *Checkpoint, p database.InlinePhoto) error {
+	data, contentType, err := Decode(p.Photo)
+	if err != nil {
+		return err
+	}
+	if m.DryRun {
+		c.Migrated++
+		c.Bytes += int64(len(data))
+		return nil
+	}
+
+	url, err := m.Store.Put(ctx, Key(p.HN, data, contentType), contentType, data)
+	if err != nil {
+		return err
+	}
+	replaced, err := m.Source.ReplacePhoto(p.HN, p.Photo, url)
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		// Edited since it was read; a run from the start picks up the new photo
+		c.Changed++
+		return nil
+	}
+
+	c.Migrated++
+	c.Bytes += int64(len(data))
+	return nil
+}
+
+// Decode reads a "data:image/jpeg;base64,..." URI or bare base64 and returns
+// the image bytes and their content type
+func Decode(photo string) ([]byte, string, error) {
+	encoded := strings.TrimSpace(photo)
+	declared := ""
+	if strings.HasPrefix(encoded, "data:") {
+		header, payload, ok := strings.Cut(encoded, ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return nil, "", errors.New("data URI is not base64")
+		}
+		declared = strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+		encoded = payload
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid base64: %w", err)
+	}
+
+	// Trust the bytes over the declared type, which browsers sometimes get wrong
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		if declared == "" {
+			return nil, "", fmt.Errorf("not an image (%s)", contentType)
+		}
+		contentType = declared
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("not an image (%s)", contentType)
+	}
+	return data, contentType, nil
+}
+
+// Key names a photo by patient and content, so storing the same photo twice
+// after an interrupted batch overwrites the first copy instead of duplicating it
+func Key(hn string, data []byte, contentType string) string {
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("patients/%s/photo-%s%s", hn, hex.EncodeToString(sum[:6]), extension(contentType))
+}
+
+func extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/heic":
+		return ".heic"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,190 @@
+package prom
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sort"
+)
+
+// Instrument codes
+const (
+	PHQ9 = "phq9"
+	GAD7 = "gad7"
+	NRS  = "pain_nrs"
+)
+
+// Option is one answer choice and its score
+type Option struct {
+	Value int    `json:"value"`
+	Label string `json:"label"`
+}
+
+// Item is one question of an instrument
+type Item struct {
+	Text    string   `json:"text"`
+	Options []Option `json:"options"`
+}
+
+// Band maps a score range to a severity label
+type Band struct {
+	Min      int
+	Severity string
+}
+
+// Instrument is a validated questionnaire with its scoring rules
+type Instrument struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	Instructions string `json:"instructions"`
+	Items        []Item `json:"items"`
+	MaxScore     int    `json:"maxScore"`
+	bands        []Band // ascending by Min
+	highRiskAt   int    // total score at or above which the result is high risk
+	riskItems    []int  // zero-based items where any non-zero answer is high risk
+}
+
+// Result is a scored questionnaire
+type Result struct {
+	Score       int      `json:"score"`
+	Severity    string   `json:"severity"`
+	HighRisk    bool     `json:"highRisk"`
+	RiskReasons []string `json:"riskReasons,omitempty"`
+}
+
+var frequencyOptions = []Option{
+	{Value: 0, Label: "Not at all"},
+	{Value: 1, Label: "Several days"},
+	{Value: 2, Label: "More than half the days"},
+	{Value: 3, Label: "Nearly every day"},
+}
+
+func frequencyItems(texts ...string) []Item {
+	items := make([]Item, len(texts))
+	for i, t := range texts {
+		items[i] = Item{Text: t, Options: frequencyOptions}
+	}
+	return items
+}
+
+func scaleOptions(min, max int) []Option {
+	options := make([]Option, 0, max-min+1)
+	for v := min; v <= max; v++ {
+		options = append(options, Option{Value: v, Label: fmt.Sprint(v)})
+	}
+	return options
+}
+
+var instruments = map[string]*Instrument{
+	PHQ9: {
+		Code:         PHQ9,
+		Name:         "Patient Health Questionnaire (PHQ-9)",
+		Instructions: "Over the last 2 weeks, how often have you been bothered by any of the following problems?",
+		Items: frequencyItems(
+			"Little interest or pleasure in doing things",
+			"Feeling down, depressed, or hopeless",
+			"Trouble falling or staying asleep, or sleeping too much",
+			"Feeling tired or having little energy",
+			"Poor appetite or overeating",
+			"Feeling bad about yourself - or that you are a failure or have let yourself or your family down",
+			"Trouble concentrating on things, such as reading the newspaper or watching television",
+			"Moving or speaking so slowly that other people could have noticed? Or the opposite - being so fidgety or restless that you have been moving around a lot more than usual",
+			"Thoughts that you would be better off dead or of hurting yourself in some way",
+		),
+		MaxScore:   27,
+		bands:      []Band{{0, "minimal"}, {5, "mild"}, {10, "moderate"}, {15, "moderately_severe"}, {20, "severe"}},
+		highRiskAt: 20,
+		riskItems:  []int{8},
+	},
+	GAD7: {
+		Code:         GAD7,
+		Name:         "Generalized Anxiety Disorder (GAD-7)",
+		Instructions: "Over the last 2 weeks, how often have you been bothered by the following problems?",
+		Items: frequencyItems(
+			"Feeling nervous, anxious or on edge",
+			"Not being able to stop or control worrying",
+			"Worrying too much about different things",
+			"Trouble relaxing",
+			"Being so restless that it is hard to sit still",
+			"Becoming easily annoyed or irritable",
+			"Feeling afraid as if something awful might happen",
+		),
+		MaxScore:   21,
+		bands:      []Band{{0, "minimal"}, {5, "mild"}, {10, "moderate"}, {15, "severe"}},
+		highRiskAt: 15,
+	},
+	NRS: {
+		Code:         NRS,
+		Name:         "Numeric Pain Rating Scale",
+		Instructions: "Rate your pain right now from 0 (no pain) to 10 (worst pain imaginable).",
+		Items:        []Item{{Text: "Pain right now", Options: scaleOptions(0, 10)}},
+		MaxScore:     10,
+		bands:        []Band{{0, "none"}, {1, "mild"}, {4, "moderate"}, {7, "severe"}},
+		highRiskAt:   7,
+	},
+}
+
+// Lookup returns an instrument by code
+func Lookup(code string) (*Instrument, bool) {
+	i, ok := instruments[code]
+	return i, ok
+}
+
+// Codes lists the available instrument codes
+func Codes() []string {
+	codes := make([]string, 0, len(instruments))
+	for code := range instruments {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Score validates one answer per item and scores the questionnaire
+func (i *Instrument) Score(answers []int) (Result, error) {
+	if len(answers) != len(i.Items) {
+		return Result{}, fmt.Errorf("%s needs %d answers, got %d", i.Code, len(i.Items), len(answers))
+	}
+
+	var r Result
+	for n, a := range answers {
+		valid := false
+		for _, o := range i.Items[n].Options {
+			if o.Value == a {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return Result{}, fmt.Errorf("answer %d is out of range", n+1)
+		}
+		r.Score += a
+	}
+
+	for _, b := range i.bands {
+		if r.Score >= b.Min {
+			r.Severity = b.Severity
+		}
+	}
+	if i.highRiskAt > 0 && r.Score >= i.highRiskAt {
+		r.HighRisk = true
+		r.RiskReasons = append(r.RiskReasons, fmt.Sprintf("score %d is %s", r.Score, r.Severity))
+	}
+	for _, n := range i.riskItems {
+		if answers[n] > 0 {
+			r.HighRisk = true
+			r.RiskReasons = append(r.RiskReasons, fmt.Sprintf("item %d answered positively: %s", n+1, i.Items[n].Text))
+		}
+	}
+
+	return r, nil
+}
+
+// NewLinkToken returns an unguessable token for a patient's questionnaire link
+func NewLinkToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate questionnaire token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
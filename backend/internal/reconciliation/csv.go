@@ -0,0 +1,146 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// Header aliases seen in Thai bank statement exports (English and Thai)
+var columnAliases = map[string][]string{
+	"date":        {"date", "transaction date", "value date", "วันที่", "วันที่ทำรายการ"},
+	"amount":      {"amount", "deposit", "credit", "จำนวนเงิน", "ฝาก", "เงินเข้า"},
+	"reference":   {"reference", "ref", "ref no", "reference no", "เลขที่อ้างอิง", "อ้างอิง"},
+	"description": {"description", "details", "remark", "รายละเอียด", "รายการ"},
+}
+
+// Date layouts accepted in the date column
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"02/01/2006",
+	"02/01/2006 15:04",
+	"02/01/2006 15:04:05",
+	"2/1/2006",
+}
+
+// ParseStatementCSV reads a bank statement CSV export into unmatched transactions.
+// Only credit (incoming) lines are kept; zero and negative amounts are skipped.
+func ParseStatementCSV(src io.Reader) ([]database.BankTransaction, error) {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement header: %w", err)
+	}
+
+	columns := mapColumns(header)
+	for _, required := range []string{"date", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("statement is missing a %s column", required)
+		}
+	}
+
+	var txns []database.BankTransaction
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		amount, err := parseAmount(field(record, columns, "amount"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		date, err := parseDate(field(record, columns, "date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		txns = append(txns, database.BankTransaction{
+			TransactionDate: date,
+			Amount:          amount,
+			Reference:       field(record, columns, "reference"),
+			Description:     field(record, columns, "description"),
+			MatchStatus:     database.MatchStatusUnmatched,
+		})
+	}
+
+	return txns, nil
+}
+
+func mapColumns(header []string) map[string]int {
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, aliases := range columnAliases {
+			if _, seen := columns[column]; seen {
+				continue
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	return columns
+}
+
+func field(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func parseAmount(s string) (float64, error) {
+	s = strings.NewReplacer(",", "", "฿", "", " ", "").Replace(s)
+	if s == "" {
+		return 0, nil
+	}
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return amount, nil
+}
+
+// yearPattern finds the year in a statement date
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+func parseDate(s string) (time.Time, error) {
+	// Thai bank exports often use Buddhist Era years (e.g. 2567). Convert the
+	// year before parsing, since a BE leap day such as 29/02/2567 is not a
+	// valid date in Gregorian year 2567.
+	date := yearPattern.ReplaceAllStringFunc(s, func(year string) string {
+		if y, _ := strconv.Atoi(year); y > 2400 {
+			return strconv.Itoa(y - 543)
+		}
+		return year
+	})
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, date, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
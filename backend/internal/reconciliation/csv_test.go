@@ -0,0 +1,37 @@
+package reconciliation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"29/02/2024", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+		// A Buddhist Era leap day; 2567 is not a leap year in the Gregorian calendar
+		{"29/02/2567", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"2567-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+		{"29/02/2567 14:30", time.Date(2024, 2, 29, 14, 30, 0, 0, time.Local)},
+		{"1/3/2567", time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.in)
+		if err != nil {
+			t.Errorf("parseDate(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"29/02/2023", "30/02/2567", "not a date"} {
+		if _, err := parseDate(in); err == nil {
+			t.Errorf("parseDate(%q): expected an error", in)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package reconciliation
+
+import (
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+)
+
+// bankPaymentReceiver is who matched bank transfers are recorded as received by
+const bankPaymentReceiver = "bank reconciliation"
+
+// InvoiceLister lists invoices
+type InvoiceLister interface {
+	List(f database.InvoiceFilter) ([]database.Invoice, error)
+}
+
+// PaymentRecorder records payments against invoices
+type PaymentRecorder interface {
+	Record(p *database.Payment) (*database.Invoice, error)
+}
+
+// Ledger is the InvoiceLedger backed by the billing invoices and payments
+type Ledger struct {
+	invoices InvoiceLister
+	payments PaymentRecorder
+}
+
+// NewLedger creates an invoice ledger over the billing repositories
+func NewLedger(invoices InvoiceLister, payments PaymentRecorder) *Ledger {
+	return &Ledger{invoices: invoices, payments: payments}
+}
+
+// OpenInvoices returns the issued invoices that still have a balance to pay
+func (l *Ledger) OpenInvoices() ([]OpenInvoice, error) {
+	invoices, err := l.invoices.List(database.InvoiceFilter{Status: database.InvoiceIssued})
+	if err != nil {
+		return nil, err
+	}
+
+	open := []OpenInvoice{}
+	for _, inv := range invoices {
+		if inv.Outstanding <= amountTolerance {
+			continue
+		}
+		open = append(open, OpenInvoice{ID: inv.ID, Number: inv.Number, PatientHN: inv.PatientHN, Outstanding: inv.Outstanding})
+	}
+	return open, nil
+}
+
+// RecordBankPayment records a matched transaction as a bank transfer payment.
+// An invoice that does not exist or a line that is not a credit is a
+// validation error; an invoice that cannot take the payment is a conflict.
+func (l *Ledger) RecordBankPayment(invoiceID int, amount float64, reference string, paidAt time.Time) error {
+	if amount <= 0 {
+		return apperr.Validation("only credit lines can be posted as payments")
+	}
+	payment := database.Payment{
+		InvoiceID:  invoiceID,
+		Method:     database.PaymentBankTransfer,
+		Amount:     amount,
+		ReceivedBy: bankPaymentReceiver,
+		PaidAt:     paidAt,
+	}
+	if reference = strings.TrimSpace(reference); reference != "" {
+		payment.Reference = &reference
+	}
+
+	_, err := l.payments.Record(&payment)
+	if apperr.Is(err, apperr.KindNotFound) {
+		return apperr.Validation("invoice %d does not exist", invoiceID)
+	}
+	return err
+}
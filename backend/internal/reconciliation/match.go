@@ -0,0 +1,123 @@
+package reconciliation
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// amountTolerance absorbs float rounding when comparing baht amounts
+const amountTolerance = 0.005
+
+// OpenInvoice is an invoice that still has an outstanding balance
+type OpenInvoice struct {
+	ID          int     `json:"id"`
+	Number      string  `json:"number"`
+	PatientHN   string  `json:"patientHn"`
+	Outstanding float64 `json:"outstanding"`
+}
+
+// InvoiceLedger provides open invoices and records bank payments against them
+type InvoiceLedger interface {
+	OpenInvoices() ([]OpenInvoice, error)
+	RecordBankPayment(invoiceID int, amount float64, reference string, paidAt time.Time) error
+}
+
+// Candidates returns the open invoices whose outstanding balance equals the
+// transaction amount, with invoices referenced in the transaction text first
+func Candidates(t database.BankTransaction, invoices []OpenInvoice) []OpenInvoice {
+	var referenced, others []OpenInvoice
+	for _, inv := range invoices {
+		if math.Abs(inv.Outstanding-t.Amount) > amountTolerance {
+			continue
+		}
+		if mentions(t, inv) {
+			referenced = append(referenced, inv)
+		} else {
+			others = append(others, inv)
+		}
+	}
+	return append(referenced, others...)
+}
+
+// AutoMatch picks the invoice a transaction unambiguously pays: either the
+// only amount match that is mentioned in the reference/description, or the
+// only amount match overall. Ambiguous transactions are left for manual matching.
+func AutoMatch(t database.BankTransaction, invoices []OpenInvoice) (*OpenInvoice, bool) {
+	candidates := Candidates(t, invoices)
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	var referenced []OpenInvoice
+	for _, inv := range candidates {
+		if mentions(t, inv) {
+			referenced = append(referenced, inv)
+		}
+	}
+
+	switch {
+	case len(referenced) == 1:
+		return &referenced[0], true
+	case len(referenced) == 0 && len(candidates) == 1:
+		return &candidates[0], true
+	default:
+		return nil, false
+	}
+}
+
+func mentions(t database.BankTransaction, inv OpenInvoice) bool {
+	text := strings.ToUpper(t.Reference + " " + t.Description)
+	if inv.Number != "" && strings.Contains(text, strings.ToUpper(inv.Number)) {
+		return true
+	}
+	return inv.PatientHN != "" && strings.Contains(text, strings.ToUpper(inv.PatientHN))
+}
+
+// Report summarizes reconciliation state for a date range
+type Report struct {
+	From           time.Time                  `json:"from"`
+	To             time.Time                  `json:"to"`
+	TotalCount     int                        `json:"totalCount"`
+	TotalAmount    float64                    `json:"totalAmount"`
+	ByStatus       map[string]StatusSummary   `json:"byStatus"`
+	UnmatchedItems []database.BankTransaction `json:"unmatchedItems"`
+}
+
+// StatusSummary holds the count and amount of transactions in one match state
+type StatusSummary struct {
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// BuildReport aggregates the transactions dated within [from, to)
+func BuildReport(txns []database.BankTransaction, from, to time.Time) Report {
+	report := Report{
+		From:           from,
+		To:             to,
+		ByStatus:       make(map[string]StatusSummary),
+		UnmatchedItems: []database.BankTransaction{},
+	}
+
+	for _, t := range txns {
+		if t.TransactionDate.Before(from) || !t.TransactionDate.Before(to) {
+			continue
+		}
+
+		report.TotalCount++
+		report.TotalAmount += t.Amount
+
+		summary := report.ByStatus[t.MatchStatus]
+		summary.Count++
+		summary.Amount += t.Amount
+		report.ByStatus[t.MatchStatus] = summary
+
+		if t.MatchStatus == database.MatchStatusUnmatched {
+			report.UnmatchedItems = append(report.UnmatchedItems, t)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,118 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"clinic/backend/internal/database"
+)
+
+// minAlertAttempts is how many deliveries a window needs before its failure
+// rate can raise an alert, so one failed message on a quiet night does not
+const minAlertAttempts = 5
+
+// RetryPolicy decides when a reminder the messaging gateway failed to deliver
+// is tried again
+type RetryPolicy struct {
+	MaxAttempts int           // failed attempts before the reminder is dead-lettered
+	Backoff     time.Duration // wait after the first failure, doubling after each later one
+}
+
+// DefaultRetryPolicy tries a reminder three times, 5 and then 10 minutes apart
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: 5 * time.Minute}
+
+// RetryAt returns when a reminder that has failed attempts times may be tried
+// again, or nil when it has run out of attempts
+func (p RetryPolicy) RetryAt(attempts int, now time.Time) *time.Time {
+	if attempts >= p.MaxAttempts {
+		return nil
+	}
+	next := now.Add(p.Backoff * time.Duration(1<<(attempts-1)))
+	return &next
+}
+
+// DeliveryStats counts the gateway's deliveries and failures
+type DeliveryStats interface {
+	DeliveryStats(since time.Time) (database.NotificationStats, error)
+}
+
+// DeliveryHealth is how the messaging gateway has been doing lately
+type DeliveryHealth struct {
+	Since       time.Time `json:"since"`
+	Sent        int       `json:"sent"`
+	Failed      int       `json:"failed"`      // failed attempts, each retry counted
+	FailureRate float64   `json:"failureRate"` // failed share of all attempts, 0 to 1
+	AlertRate   float64   `json:"alertRate"`   // failure rate above which staff are alerted
+	Alerting    bool      `json:"alerting"`
+}
+
+// FailureMonitor watches the gateway's failure rate over a sliding window
+// and gives staff a task when it passes the alert rate
+type FailureMonitor struct {
+	stats     DeliveryStats
+	tasks     Tasks
+	assignee  string
+	window    time.Duration
+	rate      float64
+	lastAlert time.Time
+	mutex     sync.Mutex
+}
+
+// NewFailureMonitor creates a monitor alerting assignee when more than rate
+// (0 to 1) of the delivery attempts in the last window failed
+func NewFailureMonitor(stats DeliveryStats, tasks Tasks, assignee string, window time.Duration, rate float64) *FailureMonitor {
+	return &FailureMonitor{stats: stats, tasks: tasks, assignee: assignee, window: window, rate: rate}
+}
+
+// Health reports the delivery figures of the window up to now
+func (m *FailureMonitor) Health(now time.Time) (DeliveryHealth, error) {
+	since := now.Add(-m.window)
+	stats, err := m.stats.DeliveryStats(since)
+	if err != nil {
+		return DeliveryHealth{}, err
+	}
+	h := DeliveryHealth{Since: since, Sent: stats.Sent, Failed: stats.Failed, AlertRate: m.rate}
+	if attempts := stats.Sent + stats.Failed; attempts > 0 {
+		h.FailureRate = math.Round(float64(stats.Failed)/float64(attempts)*1000) / 1000
+		h.Alerting = attempts >= minAlertAttempts && h.FailureRate > m.rate
+	}
+	return h, nil
+}
+
+// Run raises an alert task when the failure rate is over the alert rate, at
+// most once a window while it stays there
+func (m *FailureMonitor) Run(ctx context.Context) error {
+	now := time.Now()
+	h, err := m.Health(now)
+	if err != nil || !h.Alerting {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if now.Sub(m.lastAlert) < m.window {
+		return nil
+	}
+
+	details := fmt.Sprintf("%d of %d reminder deliveries failed since %s. Check the messaging gateway and the dead-letter queue at /api/admin/dead-letters.",
+		h.Failed, h.Sent+h.Failed, h.Since.In(time.Local).Format("15:04"))
+	day := now.In(time.Local).Format("2006-01-02")
+	task := &database.Task{
+		Title:      fmt.Sprintf("Reminder delivery failure rate at %.0f%%", h.FailureRate*100),
+		Details:    &details,
+		AssignedTo: m.assignee,
+		DueDate:    &day,
+		Status:     database.TaskOpen,
+		CreatedBy:  "notification-failure-alerts",
+	}
+	if err := m.tasks.Create(task); err != nil {
+		return err
+	}
+	m.lastAlert = now
+	log.Printf("Reminder delivery failure rate %.1f%% over the last %s; alerted %s", h.FailureRate*100, m.window, m.assignee)
+	return nil
+}
@@ -0,0 +1,204 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+)
+
+// linePushURL is the LINE Messaging API's push message endpoint
+const linePushURL = "https://api.line.me/v2/bot/message/push"
+
+// LINEGateway pushes text messages to LINE users
+type LINEGateway interface {
+	Name() string // provider name recorded against failed deliveries
+	Push(ctx context.Context, userID, text string) error
+}
+
+// LogLINE writes LINE messages to the server log instead of pushing them,
+// for development and demos
+type LogLINE struct{}
+
+// Name returns the gateway's provider name
+func (LogLINE) Name() string { return "log" }
+
+// Push logs the message
+func (LogLINE) Push(ctx context.Context, userID, text string) error {
+	log.Printf("LINE to %s: %s", userID, text)
+	return nil
+}
+
+// LINEMessaging pushes messages through the LINE Messaging API as the
+// clinic's LINE Official Account
+type LINEMessaging struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewLINEMessaging creates a gateway pushing with the channel's access token
+func NewLINEMessaging(token string) *LINEMessaging {
+	return &LINEMessaging{url: linePushURL, token: token, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Name returns the gateway's provider name
+func (g *LINEMessaging) Name() string { return "line" }
+
+// Push sends one text message to a user. LINE answers a refused push with a
+// non-2xx status and a {"message"} body saying why.
+func (g *LINEMessaging) Push(ctx context.Context, userID, text string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"to":       userID,
+		"messages": []map[string]string{{"type": "text", "text": text}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	gerr := &GatewayError{Code: strconv.Itoa(resp.StatusCode), Message: resp.Status}
+	var reason struct {
+		Message string `json:"message"`
+	}
+	if raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096)); json.Unmarshal(raw, &reason) == nil && reason.Message != "" {
+		gerr.Message = reason.Message
+	}
+	return gerr
+}
+
+// LINEMessages is the LINE message queue and the patients' linked accounts
+type LINEMessages interface {
+	GetAccount(hn string) (*database.PatientLINE, error)
+	ListMessages(f database.LINEMessageFilter) ([]database.LINEMessage, error)
+	MarkSent(id int) (*database.LINEMessage, error)
+	RecordFailure(id int, reason string, retryAt func(attempts int) *time.Time) (*database.LINEMessage, error)
+}
+
+// LINESender pushes the queued LINE messages, such as booking confirmations
+// and queue calls, and the pending LINE reminders to patients' linked LINE
+// accounts, retrying failures under the retry policy
+type LINESender struct {
+	gateway   LINEGateway
+	messages  LINEMessages
+	reminders GatewayReminders
+	retry     RetryPolicy
+}
+
+// NewLINESender creates a sender pushing through gateway
+func NewLINESender(gateway LINEGateway, messages LINEMessages, reminders GatewayReminders, retry RetryPolicy) *LINESender {
+	return &LINESender{gateway: gateway, messages: messages, reminders: reminders, retry: retry}
+}
+
+// Run pushes the pending messages and reminders that are not waiting out a retry backoff
+func (s *LINESender) Run(ctx context.Context) error {
+	sent, failed := 0, 0
+	count := func(err error) {
+		if err == nil {
+			sent++
+		} else {
+			failed++
+		}
+	}
+
+	pending, err := s.messages.ListMessages(database.LINEMessageFilter{Status: database.NotificationPending, Due: true})
+	if err != nil {
+		return err
+	}
+	for i := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		m := &pending[i]
+		pushErr := s.gateway.Push(ctx, m.UserID, m.Text)
+		if err := s.settleMessage(m, pushErr); err != nil {
+			return err
+		}
+		count(pushErr)
+	}
+
+	reminders, err := s.reminders.List(database.AppointmentReminderFilter{
+		Channel: database.ReminderLINE,
+		Status:  database.NotificationPending,
+		Due:     true,
+	})
+	if err != nil {
+		return err
+	}
+	for i := range reminders {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		m := &reminders[i]
+		var pushErr error
+		account, err := s.messages.GetAccount(m.PatientHN)
+		switch {
+		case apperr.Is(err, apperr.KindNotFound):
+			pushErr = &GatewayError{Message: "patient has not linked a LINE account"}
+		case err != nil:
+			return err
+		default:
+			pushErr = s.gateway.Push(ctx, account.UserID, m.Message)
+		}
+		if err := s.settleReminder(m, pushErr); err != nil {
+			return err
+		}
+		count(pushErr)
+	}
+
+	if sent+failed > 0 {
+		log.Printf("LINE messages: %d sent, %d failed through %s", sent, failed, s.gateway.Name())
+	}
+	return nil
+}
+
+// settleMessage marks a queued message sent, or records why it failed. A
+// conflict means it was settled elsewhere while it was being pushed.
+func (s *LINESender) settleMessage(m *database.LINEMessage, pushErr error) error {
+	var err error
+	if pushErr == nil {
+		_, err = s.messages.MarkSent(m.ID)
+	} else {
+		now := time.Now()
+		_, err = s.messages.RecordFailure(m.ID, pushErr.Error(), func(attempts int) *time.Time { return s.retry.RetryAt(attempts, now) })
+	}
+	if err != nil && !apperr.Is(err, apperr.KindConflict) {
+		return fmt.Errorf("failed to settle LINE message %d: %w", m.ID, err)
+	}
+	return nil
+}
+
+// settleReminder marks a LINE reminder sent, or logs the failed attempt
+func (s *LINESender) settleReminder(m *database.AppointmentReminder, pushErr error) error {
+	if pushErr == nil {
+		_, err := s.reminders.UpdateStatus(m.ID, database.NotificationPending, database.NotificationSent)
+		if err != nil && !apperr.Is(err, apperr.KindConflict) {
+			return err
+		}
+		return nil
+	}
+
+	return recordReminderFailure(s.reminders, s.gateway.Name(), s.retry, m, pushErr)
+}
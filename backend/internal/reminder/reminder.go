@@ -0,0 +1,290 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+	"clinic/backend/internal/prom"
+)
+
+// DefaultPolicy reminds by LINE two days ahead, by SMS the day before and has
+// staff phone the patient four hours before if they still have not confirmed
+const DefaultPolicy = "line:48h,sms:24h,call:4h"
+
+// Step is one escalation step: remind through Channel Before the appointment starts
+type Step struct {
+	Channel string        `json:"channel"`
+	Before  time.Duration `json:"before"`
+}
+
+// Policy is a sequence of steps, earliest first. Each step fires only while
+// the appointment is scheduled and unconfirmed.
+type Policy []Step
+
+// ParsePolicy reads a policy written as channel:duration pairs, e.g.
+// "line:48h,sms:24h,call:4h". Steps are ordered earliest first.
+func ParsePolicy(s string) (Policy, error) {
+	var p Policy
+	for _, part := range strings.Split(s, ",") {
+		channel, before, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("step %q must be channel:duration", part)
+		}
+		switch channel {
+		case database.ReminderLINE, database.ReminderSMS, database.ReminderCall:
+		default:
+			return nil, fmt.Errorf("step %q: channel must be line, sms or call", part)
+		}
+		d, err := time.ParseDuration(before)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("step %q: duration must be positive, e.g. 24h", part)
+		}
+		p = append(p, Step{Channel: channel, Before: d})
+	}
+	sort.SliceStable(p, func(i, j int) bool { return p[i].Before > p[j].Before })
+	for i := 1; i < len(p); i++ {
+		if p[i].Before == p[i-1].Before {
+			return nil, fmt.Errorf("two steps fire %s before", p[i].Before)
+		}
+	}
+	return p, nil
+}
+
+// Next returns the 1-based step that should fire now for the appointment, if
+// any. When several steps have come due at once, e.g. for a booking made the
+// day before, only the latest fires and the earlier ones are skipped.
+func (p Policy) Next(a *database.Appointment, now time.Time) (int, bool) {
+	if a.Status != database.AppointmentScheduled || a.Confirmation != database.AppointmentUnconfirmed || !now.Before(a.StartsAt) {
+		return 0, false
+	}
+	due := 0
+	for i, s := range p {
+		if !now.Before(a.StartsAt.Add(-s.Before)) {
+			due = i + 1
+		}
+	}
+	return due, due > a.RemindersSent
+}
+
+// Appointments lists and advances appointments' reminder state
+type Appointments interface {
+	List(f database.AppointmentFilter) ([]database.Appointment, error)
+	AdvanceReminders(id, from, to int) error
+}
+
+// Patients looks up who to remind
+type Patients interface {
+	GetByID(id int) (*database.Patient, error)
+}
+
+// Reminders stores fired reminder steps
+type Reminders interface {
+	Create(m *database.AppointmentReminder) error
+}
+
+// Tasks creates the phone-call tasks for staff
+type Tasks interface {
+	Create(t *database.Task) error
+}
+
+// Intakes stores the pre-visit intake forms linked from reminders
+type Intakes interface {
+	Create(in *database.Intake) error
+	GetByAppointment(appointmentID int) (*database.Intake, error)
+}
+
+// Escalator fires reminder policy steps for upcoming appointments
+type Escalator struct {
+	policy       Policy
+	appointments Appointments
+	patients     Patients
+	reminders    Reminders
+	tasks        Tasks
+	caller       string // staff member who gets the phone-call tasks
+	intakes      Intakes
+	intakeURL    string // public base URL of the intake form links
+}
+
+// NewEscalator creates an escalator firing policy's steps; call tasks go to
+// caller. LINE and SMS reminders link to the appointment's intake form until
+// the patient fills it in; with nil intakes they carry no link.
+func NewEscalator(policy Policy, appointments Appointments, patients Patients, reminders Reminders, tasks Tasks, caller string,
+	intakes Intakes, publicBaseURL string) *Escalator {
+	return &Escalator{policy: policy, appointments: appointments, patients: patients, reminders: reminders, tasks: tasks, caller: caller,
+		intakes: intakes, intakeURL: strings.TrimRight(publicBaseURL, "/") + "/public/intake/"}
+}
+
+// Run fires the steps that have come due for scheduled, unconfirmed appointments
+func (e *Escalator) Run(ctx context.Context) error {
+	if len(e.policy) == 0 {
+		return nil
+	}
+	now := time.Now()
+	appointments, err := e.appointments.List(database.AppointmentFilter{
+		From:   now,
+		To:     now.Add(e.policy[0].Before),
+		Status: database.AppointmentScheduled,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range appointments {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		a := &appointments[i]
+		step, ok := e.policy.Next(a, now)
+		if !ok {
+			continue
+		}
+		if err := e.fire(a, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Escalator) fire(a *database.Appointment, step int) error {
+	var id int
+	if _, err := fmt.Sscanf(a.PatientHN, "HN%d", &id); err != nil {
+		return fmt.Errorf("appointment %d has invalid patient HN %q", a.ID, a.PatientHN)
+	}
+	patient, err := e.patients.GetByID(id)
+	if err != nil {
+		return err
+	}
+	channel := e.policy[step-1].Channel
+	reminder := &database.AppointmentReminder{
+		AppointmentID: a.ID,
+		PatientHN:     a.PatientHN,
+		Step:          step,
+		Channel:       channel,
+		Phone:         patient.Phone,
+		Message:       message(a),
+		Status:        database.NotificationPending,
+	}
+	if channel != database.ReminderCall {
+		token, err := e.intakeToken(a)
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			reminder.Message += " กรุณากรอกแบบคัดกรองอาการก่อนพบแพทย์: " + e.intakeURL + token
+		}
+	}
+
+	switch {
+	case a.Sandbox:
+		// Booked with a sandbox API key: nothing is sent and no one is asked
+		// to call, but the step is recorded as if it had been delivered
+		now := time.Now()
+		reminder.Status = database.NotificationSent
+		reminder.SentAt = &now
+		reminder.Sandbox = true
+	case channel == database.ReminderCall:
+		day := a.StartsAt.In(time.Local).Format("2006-01-02")
+		details := reminder.Message
+		task := &database.Task{
+			Title:      fmt.Sprintf("Call %s to confirm appointment at %s", patient.FullName, a.StartsAt.In(time.Local).Format("15:04")),
+			Details:    &details,
+			AssignedTo: e.caller,
+			PatientHN:  &a.PatientHN,
+			DueDate:    &day,
+			Status:     database.TaskOpen,
+			CreatedBy:  "appointment-reminders",
+		}
+		if err := e.tasks.Create(task); err != nil {
+			return err
+		}
+		now := time.Now()
+		reminder.TaskID = &task.ID
+		reminder.Status = database.NotificationSent
+		reminder.SentAt = &now
+	case patient.Phone == nil || *patient.Phone == "":
+		// Nowhere to send it; the next step still fires, and staff can
+		// requeue this one from the dead-letter queue once there is a number
+		lastError := "patient has no phone number"
+		reminder.Status = database.NotificationFailed
+		reminder.LastError = &lastError
+	}
+
+	if err := e.reminders.Create(reminder); err != nil && !apperr.Is(err, apperr.KindConflict) {
+		return err
+	}
+	if err := e.appointments.AdvanceReminders(a.ID, a.RemindersSent, step); err != nil && !apperr.Is(err, apperr.KindConflict) {
+		return err
+	}
+	log.Printf("Appointment %d reminder %d (%s) for %s: %s", a.ID, step, channel, a.PatientHN, reminder.Status)
+	return nil
+}
+
+// intakeToken returns the link token of the appointment's intake form,
+// starting one if there is none, or "" once the patient has filled it in
+func (e *Escalator) intakeToken(a *database.Appointment) (string, error) {
+	if e.intakes == nil {
+		return "", nil
+	}
+	intake, err := e.intakes.GetByAppointment(a.ID)
+	if err == nil {
+		if intake.Status != database.IntakePending {
+			return "", nil
+		}
+		return intake.Token, nil
+	}
+	if !apperr.Is(err, apperr.KindNotFound) {
+		return "", err
+	}
+
+	token, err := prom.NewLinkToken()
+	if err != nil {
+		return "", err
+	}
+	intake = database.NewIntake(a, token)
+	if err := e.intakes.Create(intake); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// message is the reminder text sent to the patient
+func message(a *database.Appointment) string {
+	starts := a.StartsAt.In(time.Local)
+	return fmt.Sprintf("แจ้งเตือนนัดหมาย: ท่านมีนัดพบ %s วันที่ %s เวลา %s น. ตอบ 1 เพื่อยืนยัน หรือ 2 เพื่อยกเลิกนัด",
+		a.DoctorName, starts.Format("02/01/2006"), starts.Format("15:04"))
+}
+
+// replyKeywords map what patients answer reminders with to what they ask for
+var replyKeywords = map[string]string{
+	"1": database.ReplyConfirm, "confirm": database.ReplyConfirm, "confirmed": database.ReplyConfirm,
+	"yes": database.ReplyConfirm, "y": database.ReplyConfirm, "ok": database.ReplyConfirm,
+	"ยืนยัน": database.ReplyConfirm, "ตกลง": database.ReplyConfirm, "ไป": database.ReplyConfirm,
+
+	"2": database.ReplyCancel, "cancel": database.ReplyCancel, "no": database.ReplyCancel, "n": database.ReplyCancel,
+	"ยกเลิก": database.ReplyCancel, "ไม่ไป": database.ReplyCancel, "ไม่สะดวก": database.ReplyCancel,
+}
+
+// politeEndings are the particles Thai replies often end with, e.g. "ยืนยันค่ะ"
+var politeEndings = []string{"นะคะ", "นะครับ", "ค่ะ", "คะ", "ครับ", "คับ", "จ้า", "ค่า"}
+
+// ParseReply reads what a reply to a reminder asks for: confirm, cancel or,
+// when it is anything but a keyword (optionally with a polite ending), unknown
+func ParseReply(text string) string {
+	t := strings.ToLower(strings.TrimSpace(text))
+	t = strings.TrimRight(t, " .!")
+	for _, ending := range politeEndings {
+		if trimmed := strings.TrimSuffix(t, ending); trimmed != "" {
+			t = strings.TrimSpace(trimmed)
+		}
+	}
+	if intent, ok := replyKeywords[t]; ok {
+		return intent
+	}
+	return database.ReplyUnknown
+}
@@ -0,0 +1,194 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"clinic/backend/internal/apperr"
+	"clinic/backend/internal/database"
+)
+
+// SMSGateway sends text messages through an SMS provider. Any provider can
+// be plugged in by implementing it.
+type SMSGateway interface {
+	Name() string // provider name recorded against failed deliveries
+	Send(ctx context.Context, phone, message string) error
+}
+
+// GatewayError is a delivery the provider refused, with its own error code
+type GatewayError struct {
+	Code    string
+	Message string
+}
+
+func (e *GatewayError) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return e.Code + ": " + e.Message
+}
+
+// LogGateway writes messages to the server log instead of sending them, for
+// development and demos
+type LogGateway struct{}
+
+// Name returns the gateway's provider name
+func (LogGateway) Name() string { return "log" }
+
+// Send logs the message
+func (LogGateway) Send(ctx context.Context, phone, message string) error {
+	log.Printf("SMS to %s: %s", phone, message)
+	return nil
+}
+
+// HTTPGateway posts each message as JSON ({"to", "from", "message"}) to an
+// SMS provider's HTTP API. A non-2xx response is a failed delivery; its body
+// may give {"code", "message"} to say why.
+type HTTPGateway struct {
+	url    string
+	token  string
+	sender string
+	client *http.Client
+}
+
+// NewHTTPGateway creates a gateway posting to url with token as a bearer
+// token, sending from sender (the registered sender name)
+func NewHTTPGateway(url, token, sender string) *HTTPGateway {
+	return &HTTPGateway{url: url, token: token, sender: sender, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Name returns the gateway's provider name
+func (g *HTTPGateway) Name() string { return "http" }
+
+// Send posts one message to the provider
+func (g *HTTPGateway) Send(ctx context.Context, phone, message string) error {
+	body, err := json.Marshal(map[string]string{"to": phone, "from": g.sender, "message": message})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	gerr := &GatewayError{Code: strconv.Itoa(resp.StatusCode), Message: resp.Status}
+	var reason struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096)); json.Unmarshal(raw, &reason) == nil {
+		if reason.Code != "" {
+			gerr.Code = reason.Code
+		}
+		if reason.Message != "" {
+			gerr.Message = reason.Message
+		}
+	}
+	return gerr
+}
+
+// GatewayReminders is the reminder queue the SMS and LINE senders work through
+type GatewayReminders interface {
+	List(f database.AppointmentReminderFilter) ([]database.AppointmentReminder, error)
+	UpdateStatus(id int, from, to string) (*database.AppointmentReminder, error)
+	RecordFailure(id int, f *database.NotificationFailure, retryAt func(attempts int) *time.Time) (*database.AppointmentReminder, error)
+}
+
+// SMSSender delivers the pending SMS reminders through a gateway, marking
+// each sent or logging the failure for a retry under the retry policy
+type SMSSender struct {
+	gateway   SMSGateway
+	reminders GatewayReminders
+	retry     RetryPolicy
+}
+
+// NewSMSSender creates a sender delivering through gateway
+func NewSMSSender(gateway SMSGateway, reminders GatewayReminders, retry RetryPolicy) *SMSSender {
+	return &SMSSender{gateway: gateway, reminders: reminders, retry: retry}
+}
+
+// Run sends the SMS reminders that are pending and not waiting out a retry backoff
+func (s *SMSSender) Run(ctx context.Context) error {
+	pending, err := s.reminders.List(database.AppointmentReminderFilter{
+		Channel: database.ReminderSMS,
+		Status:  database.NotificationPending,
+		Due:     true,
+	})
+	if err != nil {
+		return err
+	}
+
+	sent, failed := 0, 0
+	for i := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		m := &pending[i]
+		var sendErr error
+		if m.Phone == nil || *m.Phone == "" {
+			sendErr = &GatewayError{Message: "patient has no phone number"}
+		} else {
+			sendErr = s.gateway.Send(ctx, *m.Phone, m.Message)
+		}
+
+		if sendErr == nil {
+			// A conflict means the reminder was settled elsewhere while it was being sent
+			_, err := s.reminders.UpdateStatus(m.ID, database.NotificationPending, database.NotificationSent)
+			if err != nil && !apperr.Is(err, apperr.KindConflict) {
+				return err
+			}
+			sent++
+			continue
+		}
+		if err := s.recordFailure(m, sendErr); err != nil {
+			return err
+		}
+		failed++
+	}
+	if sent+failed > 0 {
+		log.Printf("SMS reminders: %d sent, %d failed through %s", sent, failed, s.gateway.Name())
+	}
+	return nil
+}
+
+func (s *SMSSender) recordFailure(m *database.AppointmentReminder, sendErr error) error {
+	return recordReminderFailure(s.reminders, s.gateway.Name(), s.retry, m, sendErr)
+}
+
+// recordReminderFailure logs a failed delivery of a reminder through
+// provider, with the provider's own error code when it gave one
+func recordReminderFailure(reminders GatewayReminders, provider string, retry RetryPolicy, m *database.AppointmentReminder, sendErr error) error {
+	failure := &database.NotificationFailure{Provider: &provider, Error: sendErr.Error()}
+	if gerr, ok := sendErr.(*GatewayError); ok {
+		failure.Error = gerr.Message
+		if gerr.Code != "" {
+			failure.ErrorCode = &gerr.Code
+		}
+	}
+	now := time.Now()
+	_, err := reminders.RecordFailure(m.ID, failure, func(attempts int) *time.Time { return retry.RetryAt(attempts, now) })
+	if err != nil && !apperr.Is(err, apperr.KindConflict) {
+		return fmt.Errorf("failed to record delivery failure for reminder %d: %w", m.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package reqctx
+
+import (
+	"context"
+	"time"
+)
+
+// Roles
+const (
+	RoleAdmin      = "admin"
+	RoleDoctor     = "doctor"
+	RoleNurse      = "nurse"
+	RolePharmacist = "pharmacist"
+	RoleCashier    = "cashier"
+	RoleReception  = "reception"
+	RoleKiosk      = "kiosk" // a self check-in kiosk, through an API key
+)
+
+// DefaultTenant is used for requests that do not name a tenant
+const DefaultTenant = "default"
+
+// Info is who is making a request and which tenant and clinic branch it acts on
+type Info struct {
+	UserID   string `json:"userId,omitempty"`
+	UserName string `json:"userName,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	Tenant   string `json:"tenant"`
+	Sandbox  bool   `json:"sandbox,omitempty"` // called with a sandbox API key: payments and messages are simulated
+
+	PatientHN string `json:"patientHn,omitempty"` // the patient signed in to the patient portal, on portal requests
+
+	Location *time.Location `json:"-"` // the branch's timezone; nil means the clinic's, time.Local
+}
+
+// Authenticated reports whether a user has been identified
+func (i Info) Authenticated() bool {
+	return i.UserID != ""
+}
+
+// HasRole reports whether the user has one of the roles
+func (i Info) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if i.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+type key struct{}
+
+// With returns a copy of ctx carrying info
+func With(ctx context.Context, info Info) context.Context {
+	if info.Tenant == "" {
+		info.Tenant = DefaultTenant
+	}
+	return context.WithValue(ctx, key{}, info)
+}
+
+// From returns the request info carried by ctx; an anonymous caller in the default tenant when there is none
+func From(ctx context.Context) Info {
+	if info, ok := ctx.Value(key{}).(Info); ok {
+		return info
+	}
+	return Info{Tenant: DefaultTenant}
+}
+
+// UserName returns the acting user's name, or "" when anonymous
+func UserName(ctx context.Context) string {
+	return From(ctx).UserName
+}
+
+// Role returns the acting user's role, or "" when anonymous
+func Role(ctx context.Context) string {
+	return From(ctx).Role
+}
+
+// Branch returns the clinic branch the request acts on, or "" when not given
+func Branch(ctx context.Context) string {
+	return From(ctx).Branch
+}
+
+// Tenant returns the tenant the request acts on
+func Tenant(ctx context.Context) string {
+	return From(ctx).Tenant
+}
+
+// Sandbox reports whether the request came from a sandbox API key, so its
+// payments and messages must be simulated rather than made for real
+func Sandbox(ctx context.Context) bool {
+	return From(ctx).Sandbox
+}
+
+// Patient returns the HN of the patient signed in to the portal, or "" when
+// the request is not a portal request
+func Patient(ctx context.Context) string {
+	return From(ctx).PatientHN
+}
+
+// Location returns the timezone the request's dates and working hours are in:
+// its branch's when configured, otherwise time.Local, which main sets to the
+// clinic's timezone rather than the server's
+func Location(ctx context.Context) *time.Location {
+	if loc := From(ctx).Location; loc != nil {
+		return loc
+	}
+	return time.Local
+}
@@ -0,0 +1,159 @@
+// Package storage keeps binary objects such as patient photos outside the
+// database and hands back the URLs they are served from.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Store saves objects under slash-separated keys, e.g. "patients/HN000123/photo-3f2a9c.jpg"
+type Store interface {
+	// Put saves data under key, replacing any object already there, and returns its URL
+	Put(ctx context.Context, key, contentType string, data []byte) (string, error)
+	// URL returns where the object under key is served from
+	URL(key string) string
+}
+
+// ErrNotFound is returned for keys with no object stored under them
+var ErrNotFound = errors.New("storage: object not found")
+
+// DiskStore keeps objects as files under a directory that the API serves at baseURL
+type DiskStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewDiskStore creates a store under dir, served at baseURL (e.g. "https://clinic.example/files")
+func NewDiskStore(dir, baseURL string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &DiskStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Dir returns the directory objects are kept in
+func (s *DiskStore) Dir() string {
+	return s.dir
+}
+
+// Put writes the object to a temporary file and renames it into place, so
+// readers never see a partly written object
+func (s *DiskStore) Put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	file, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", key, err)
+	}
+
+	return s.URL(key), nil
+}
+
+// Open returns a reader for the object under key; the caller closes it
+func (s *DiskStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	file, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", key, err)
+	}
+	return f, nil
+}
+
+// Delete removes the object under key; deleting a missing object is not an error
+func (s *DiskStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL returns where the object under key is served from
+func (s *DiskStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// Check reports whether the storage directory is writable, for health checks
+func (s *DiskStore) Check(ctx context.Context) error {
+	tmp, err := os.CreateTemp(s.dir, ".health-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+// path maps a key to a file under the store's directory, rejecting keys that would escape it
+func (s *DiskStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Handler serves stored objects by key, e.g. mounted at /files/ behind
+// http.StripPrefix. Directories are not listed.
+func (s *DiskStore) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, err := s.path(strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		info, err := os.Stat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, file)
+	})
+}
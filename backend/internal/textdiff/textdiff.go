@@ -0,0 +1,88 @@
+package textdiff
+
+import "strings"
+
+// Line operations
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Line is one line of a diff. OldLine/NewLine are 1-based and zero when the
+// line does not exist on that side.
+type Line struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"oldLine,omitempty"`
+	NewLine int    `json:"newLine,omitempty"`
+}
+
+// Summary counts the changed lines of a diff
+type Summary struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// Lines returns a line-by-line diff of two texts using the longest common subsequence,
+// listing deletions before insertions where lines were replaced
+func Lines(oldText, newText string) []Line {
+	a, b := split(oldText), split(newText)
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := []Line{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff = append(diff, Line{Op: OpEqual, Text: a[i], OldLine: i + 1, NewLine: j + 1})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, Line{Op: OpDelete, Text: a[i], OldLine: i + 1})
+			i++
+		default:
+			diff = append(diff, Line{Op: OpInsert, Text: b[j], NewLine: j + 1})
+			j++
+		}
+	}
+
+	return diff
+}
+
+// Summarize counts added and removed lines
+func Summarize(diff []Line) Summary {
+	var s Summary
+	for _, l := range diff {
+		switch l.Op {
+		case OpInsert:
+			s.Added++
+		case OpDelete:
+			s.Removed++
+		}
+	}
+	return s
+}
+
+func split(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
@@ -1,27 +1,569 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"log"
 	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // cloud images often ship without a zoneinfo database
 
+	"clinic/backend/api"
 	"clinic/backend/api/handlers"
+	"clinic/backend/internal/careplan"
+	"clinic/backend/internal/coding"
+	"clinic/backend/internal/coord"
 	"clinic/backend/internal/database"
+	"clinic/backend/internal/email"
+	"clinic/backend/internal/esign"
+	"clinic/backend/internal/forecast"
+	"clinic/backend/internal/health"
+	"clinic/backend/internal/reconciliation"
+	"clinic/backend/internal/reminder"
+	"clinic/backend/internal/reqctx"
+	"clinic/backend/internal/storage"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
+	// Dates, working hours and report boundaries follow the clinic's timezone,
+	// not the server's, which in the cloud is usually UTC. Branches with
+	// settings override it for their own requests.
+	clinicLocation, err := time.LoadLocation(getEnv("CLINIC_TIMEZONE", "Asia/Bangkok"))
+	if err != nil {
+		log.Fatalf("Invalid CLINIC_TIMEZONE: %v", err)
+	}
+	time.Local = clinicLocation
+
 	// Initialize mock database (replace with real database connection later)
 	patientRepo := database.NewMockPatientRepository()
-	patientHandler := handlers.NewPatientHandler(patientRepo)
+	allergyRepo := database.NewMockAllergyRepository()
+	problemRepo := database.NewMockProblemRepository()
+	patientRuleRepo := database.NewMockPatientRuleRepository()
+	patientHandler := handlers.NewPatientHandler(patientRepo, allergyRepo, problemRepo, patientRuleRepo)
+	patientRuleHandler := handlers.NewPatientRuleHandler(patientRuleRepo)
+	// New patients fill in their own details before arriving; reception verifies them
+	selfRegistrationRepo := database.NewMockSelfRegistrationRepository()
+	selfRegistrationHandler := handlers.NewSelfRegistrationHandler(selfRegistrationRepo, patientRepo, patientRuleRepo,
+		getEnv("PUBLIC_BASE_URL", "http://localhost:8080"))
+
+	adminGate := handlers.NewAdminGate(os.Getenv("ADMIN_TOKEN"))
+
+	// Dependencies without a check are reported as not configured
+	healthChecks := health.NewRegistry(2 * time.Second)
+	healthChecks.Register("database", nil) // mock repositories in use
+	healthChecks.Register("cache", nil)
+	// Photos and other files live under STORAGE_DIR and are served at /files/
+	fileStore, err := storage.NewDiskStore(getEnv("STORAGE_DIR", "storage"), getEnv("PUBLIC_BASE_URL", "http://localhost:8080")+"/files")
+	if err != nil {
+		log.Fatal(err)
+	}
+	healthChecks.Register("storage", fileStore.Check)
+	// Patient documents are kept apart from STORAGE_DIR so /files/ never serves them
+	documentStore, err := storage.NewDiskStore(getEnv("DOCUMENT_DIR", "documents"), "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	healthChecks.Register("documents", documentStore.Check)
+	// Booking confirmations and lab results ready are emailed to patients with an email address
+	emailRepo := database.NewMockEmailRepository()
+	documentRepo := database.NewMockDocumentRepository()
+	documentHandler := handlers.NewDocumentHandler(documentRepo, patientRepo, documentStore, emailRepo)
+	consentRepo := database.NewMockConsentRepository()
+	consentHandler := handlers.NewConsentHandler(consentRepo, patientRepo, documentStore)
+	healthChecks.Register("sms", nil)
+	healthChecks.Register("payment_gateway", nil)
+	healthHandler := handlers.NewHealthHandler(healthChecks)
+	maintenanceHandler := handlers.NewMaintenanceHandler()
+	profilingHandler := handlers.NewProfilingHandler()
+
+	// Leases coordinate instances behind the load balancer: one elected leader,
+	// and scheduled jobs that run on a single instance per interval. The mock
+	// store only coordinates within this process.
+	coordinationRepo := database.NewMockCoordinationRepository()
+	instanceID := coord.InstanceID()
+	leader := coord.NewElector(coordinationRepo, "api-leader", instanceID, 15*time.Second)
+	go leader.Run(context.Background())
+	scheduler := coord.NewScheduler(coordinationRepo, instanceID)
+	coordinationHandler := handlers.NewCoordinationHandler(coordinationRepo, leader, instanceID)
+
+	// Merged patients can be unmerged for PATIENT_MERGE_UNDO_WINDOW; after that
+	// the pre-merge snapshots are dropped
+	mergeUndoWindow, err := time.ParseDuration(getEnv("PATIENT_MERGE_UNDO_WINDOW", "72h"))
+	if err != nil {
+		log.Fatalf("Invalid PATIENT_MERGE_UNDO_WINDOW: %v", err)
+	}
+	patientMergeRepo := database.NewMockPatientMergeRepository(patientRepo)
+	patientMergeHandler := handlers.NewPatientMergeHandler(patientMergeRepo, mergeUndoWindow)
+	scheduler.Every("patient-merge-snapshots", time.Hour, func(ctx context.Context) error {
+		purged, err := patientMergeRepo.PurgeSnapshots(time.Now())
+		if purged > 0 {
+			log.Printf("Dropped the undo snapshots of %d patient merges", purged)
+		}
+		return err
+	})
+
+	// Handover notes stay in the live thread for HANDOVER_ARCHIVE_AFTER, long
+	// enough to reach the shifts after the one they were written for
+	handoverArchiveAfter, err := time.ParseDuration(getEnv("HANDOVER_ARCHIVE_AFTER", "36h"))
+	if err != nil {
+		log.Fatalf("Invalid HANDOVER_ARCHIVE_AFTER: %v", err)
+	}
+	handoverRepo := database.NewMockHandoverRepository()
+	handoverHandler := handlers.NewHandoverHandler(handoverRepo)
+	scheduler.Every("handover-archive", 15*time.Minute, func(ctx context.Context) error {
+		archived, err := handoverRepo.ArchiveBefore(time.Now().Add(-handoverArchiveAfter))
+		if archived > 0 {
+			log.Printf("Archived %d handover notes", archived)
+		}
+		return err
+	})
+
+	taskRepo := database.NewMockTaskRepository()
+	taskHandler := handlers.NewTaskHandler(taskRepo)
+
+	reconciliationRepo := database.NewMockReconciliationRepository()
+
+	drugRepo := database.NewMockDrugRepository()
+	drugHandler := handlers.NewDrugHandler(drugRepo, fileStore)
+
+	inventoryRepo := database.NewMockInventoryRepository()
+
+	reorderRepo := database.NewMockReorderRepository()
+	reorderHandler := handlers.NewReorderHandler(reorderRepo, forecast.FromInventory(inventoryRepo))
+
+	recallRepo := database.NewMockRecallRepository()
+	recallHandler := handlers.NewRecallHandler(recallRepo, patientRepo, inventoryRepo)
+
+	coldChainRepo := database.NewMockColdChainRepository()
+	coldChainHandler := handlers.NewColdChainHandler(coldChainRepo)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryRepo, drugRepo, patientRepo, recallRepo, coldChainRepo)
+
+	signatureRepo := database.NewMockSignatureRepository()
+	certificateRepo := database.NewMockCertificateRepository()
+	certificateHandler := handlers.NewCertificateHandler(certificateRepo, patientRepo)
+
+	clinicalNoteRepo := database.NewMockClinicalNoteRepository()
+	clinicalNoteHandler := handlers.NewClinicalNoteHandler(clinicalNoteRepo)
+
+	formRepo := database.NewMockFormRepository()
+	formHandler := handlers.NewFormHandler(formRepo)
+
+	carePlanRepo := database.NewMockCarePlanRepository()
+	vitalsRepo := database.NewMockVitalsRepository()
+	// Care plan goals on weight, blood pressure and the like pick up recorded vital signs
+	carePlanHandler := handlers.NewCarePlanHandler(carePlanRepo, patientRepo, careplan.FromVitals(vitalsRepo))
+
+	chatRepo := database.NewMockChatRepository()
+
+	announcementRepo := database.NewMockAnnouncementRepository()
+	announcementHandler := handlers.NewAnnouncementHandler(announcementRepo)
+
+	vaccinationRepo := database.NewMockVaccinationRepository()
+	vaccinationHandler := handlers.NewVaccinationHandler(vaccinationRepo, patientRepo, inventoryRepo, recallRepo, coldChainRepo, coldChainRepo)
+
+	referralRepo := database.NewMockReferralRepository()
+	followUpRepo := database.NewMockFollowUpRepository()
+	nursingNoteRepo := database.NewMockNursingNoteRepository()
+	dentalRepo := database.NewMockDentalRepository()
+	emergencyContactRepo := database.NewMockEmergencyContactRepository()
+	addressRepo := database.NewMockAddressRepository()
+
+	queueRepo := database.NewMockQueueRepository()
+	triageRepo := database.NewMockTriageRepository()
+	triageHandler := handlers.NewTriageHandler(triageRepo, queueRepo, vitalsRepo)
+
+	groupSessionRepo := database.NewMockGroupSessionRepository()
+
+	campaignRepo := database.NewMockCampaignRepository()
+	campaignHandler := handlers.NewCampaignHandler(campaignRepo, groupSessionRepo, patientRepo)
+
+	interpreterRepo := database.NewMockInterpreterRepository()
+	interpreterHandler := handlers.NewInterpreterHandler(interpreterRepo, patientRepo)
+
+	accessibilityRepo := database.NewMockAccessibilityRepository()
+	accessibilityHandler := handlers.NewAccessibilityHandler(accessibilityRepo, patientRepo)
+
+	questionnaireRepo := database.NewMockQuestionnaireRepository()
+
+	noteDraftRepo := database.NewMockNoteDraftRepository()
+	noteDraftHandler := handlers.NewNoteDraftHandler(noteDraftRepo, clinicalNoteRepo)
+
+	// Diagnoses are coded against the bundled ICD-10 list unless ICD10_TABLE
+	// names a complete one, such as an ICD-10-TM release, in the same format
+	icd10 := coding.Bundled()
+	if path := os.Getenv("ICD10_TABLE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Invalid ICD10_TABLE: %v", err)
+		}
+		icd10, err = coding.ReadTable(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Invalid ICD10_TABLE %s: %v", path, err)
+		}
+		log.Printf("Loaded %d ICD-10 codes from %s", icd10.Len(), path)
+	}
+	icd10.WithTerms(coding.CommonOutpatient)
+
+	diagnosisCodeRepo := database.NewMockDiagnosisCodeRepository()
+	codingHandler := handlers.NewCodingHandler(diagnosisCodeRepo, coding.NewIndex(coding.CommonOutpatient), icd10)
+
+	prescriptionFavoriteRepo := database.NewMockPrescriptionFavoriteRepository()
+	prescriptionFavoriteHandler := handlers.NewPrescriptionFavoriteHandler(prescriptionFavoriteRepo, drugRepo)
+
+	doctorRepo := database.NewMockDoctorRepository()
+	doctorHandler := handlers.NewDoctorHandler(doctorRepo)
+
+	userRepo := database.NewMockUserRepository()
+	userHandler := handlers.NewUserHandler(userRepo, doctorRepo)
+	// Integrations call the API with keys; sandbox keys simulate payments and messages
+	apiKeyRepo := database.NewMockAPIKeyRepository()
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo)
+
+	// Doctors get a renewal task LICENSE_REMINDER_BEFORE their license expires,
+	// once per expiry date
+	licenseReminderBefore, err := time.ParseDuration(getEnv("LICENSE_REMINDER_BEFORE", "1440h"))
+	if err != nil {
+		log.Fatalf("Invalid LICENSE_REMINDER_BEFORE: %v", err)
+	}
+	scheduler.Every("license-expiry-reminders", time.Hour, func(ctx context.Context) error {
+		doctors, err := doctorRepo.LicensesToRemind(time.Now().Add(licenseReminderBefore).Format("2006-01-02"))
+		if err != nil {
+			return err
+		}
+		for _, d := range doctors {
+			task := &database.Task{
+				Title:      "Renew medical license " + d.LicenseNumber,
+				AssignedTo: d.FullName,
+				DueDate:    d.LicenseExpiresOn,
+				Status:     database.TaskOpen,
+				CreatedBy:  "license-expiry-reminders",
+			}
+			if err := taskRepo.Create(task); err != nil {
+				return err
+			}
+			if err := doctorRepo.MarkLicenseReminded(d.ID, *d.LicenseExpiresOn); err != nil {
+				return err
+			}
+			log.Printf("Reminded %s that their license expires on %s", d.FullName, *d.LicenseExpiresOn)
+		}
+		return nil
+	})
+
+	appointmentDisplayRepo := database.NewMockAppointmentDisplayRepository()
+	appointmentDisplayHandler := handlers.NewAppointmentDisplayHandler(appointmentDisplayRepo)
+
+	branchRepo := database.NewMockBranchRepository()
+	branchHandler := handlers.NewBranchHandler(branchRepo)
+
+	rosterRepo := database.NewMockRosterRepository()
+
+	serviceRepo := database.NewMockServiceRepository()
+	visitServiceRepo := database.NewMockVisitServiceRepository()
+	appointmentRepo := database.NewMockAppointmentRepository()
+	appointmentOverrideRepo := database.NewMockAppointmentOverrideRepository()
+	cancellationReasonRepo := database.NewMockCancellationReasonRepository()
+	// Patients who link a LINE account get booking confirmations and queue calls there
+	lineRepo := database.NewMockLINERepository()
+	appointmentHandler := handlers.NewAppointmentHandler(appointmentRepo, patientRepo, doctorRepo, interpreterRepo, appointmentDisplayRepo, branchRepo, rosterRepo, appointmentOverrideRepo, cancellationReasonRepo,
+		serviceRepo, visitServiceRepo, lineRepo, emailRepo, getEnv("BLOCK_LAPSED_LICENSES", "false") == "true")
+
+	// Unconfirmed appointments are reminded step by step, e.g. by LINE, then SMS,
+	// then a task for the front desk to phone the patient
+	reminderPolicy, err := reminder.ParsePolicy(getEnv("APPOINTMENT_REMINDER_POLICY", reminder.DefaultPolicy))
+	if err != nil {
+		log.Fatalf("Invalid APPOINTMENT_REMINDER_POLICY: %v", err)
+	}
+	appointmentReminderRepo := database.NewMockAppointmentReminderRepository()
+	// Reminders the messaging gateway fails to deliver are retried
+	// NOTIFICATION_MAX_ATTEMPTS times, NOTIFICATION_RETRY_BACKOFF apart and
+	// doubling, then dead-lettered. More than NOTIFICATION_FAILURE_ALERT_RATE
+	// of the deliveries failing over NOTIFICATION_ALERT_WINDOW gives
+	// NOTIFICATION_ALERT_ASSIGNEE a task.
+	retryPolicy := reminder.DefaultRetryPolicy
+	if s := os.Getenv("NOTIFICATION_MAX_ATTEMPTS"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid NOTIFICATION_MAX_ATTEMPTS %q: expected a positive number", s)
+		}
+		retryPolicy.MaxAttempts = n
+	}
+	if retryPolicy.Backoff, err = time.ParseDuration(getEnv("NOTIFICATION_RETRY_BACKOFF", retryPolicy.Backoff.String())); err != nil {
+		log.Fatalf("Invalid NOTIFICATION_RETRY_BACKOFF: %v", err)
+	}
+	alertRate, err := strconv.ParseFloat(getEnv("NOTIFICATION_FAILURE_ALERT_RATE", "0.2"), 64)
+	if err != nil || alertRate < 0 || alertRate > 1 {
+		log.Fatalf("Invalid NOTIFICATION_FAILURE_ALERT_RATE: expected a fraction from 0 to 1")
+	}
+	alertWindow, err := time.ParseDuration(getEnv("NOTIFICATION_ALERT_WINDOW", "1h"))
+	if err != nil || alertWindow <= 0 {
+		log.Fatalf("Invalid NOTIFICATION_ALERT_WINDOW: expected a positive duration, e.g. 1h")
+	}
+	failureMonitor := reminder.NewFailureMonitor(appointmentReminderRepo, taskRepo, getEnv("NOTIFICATION_ALERT_ASSIGNEE", "Admin"),
+		alertWindow, alertRate)
+	scheduler.Every("notification-failure-alerts", 10*time.Minute, failureMonitor.Run)
+	appointmentReminderHandler := handlers.NewAppointmentReminderHandler(appointmentReminderRepo, patientRepo, retryPolicy, failureMonitor)
+	reminderReplyRepo := database.NewMockReminderReplyRepository()
+	// Reminders link to a pre-visit intake form the doctor sees before the visit
+	intakeRepo := database.NewMockIntakeRepository()
+	escalator := reminder.NewEscalator(reminderPolicy, appointmentRepo, patientRepo, appointmentReminderRepo, taskRepo,
+		getEnv("APPOINTMENT_REMINDER_CALLER", "Front desk"), intakeRepo, getEnv("PUBLIC_BASE_URL", "http://localhost:8080"))
+	scheduler.Every("appointment-reminders", 10*time.Minute, escalator.Run)
+	// SMS_GATEWAY=log or http sends SMS reminders from here; unset leaves them
+	// pending for an external messaging gateway to take from the API
+	var smsGateway reminder.SMSGateway
+	switch gateway := getEnv("SMS_GATEWAY", ""); gateway {
+	case "":
+	case "log":
+		smsGateway = reminder.LogGateway{}
+	case "http":
+		url := os.Getenv("SMS_GATEWAY_URL")
+		if url == "" {
+			log.Fatalf("SMS_GATEWAY=http needs SMS_GATEWAY_URL")
+		}
+		smsGateway = reminder.NewHTTPGateway(url, os.Getenv("SMS_GATEWAY_TOKEN"), getEnv("SMS_SENDER", "Clinic"))
+	default:
+		log.Fatalf("Invalid SMS_GATEWAY %q: expected log or http", gateway)
+	}
+	if smsGateway != nil {
+		smsSender := reminder.NewSMSSender(smsGateway, appointmentReminderRepo, retryPolicy)
+		scheduler.Every("sms-reminders", time.Minute, smsSender.Run)
+	}
+	// LINE_GATEWAY=api pushes queued LINE messages and LINE reminders through
+	// the LINE Messaging API, or =log writes them to the server log; unset
+	// leaves them pending
+	var lineGateway reminder.LINEGateway
+	switch gateway := getEnv("LINE_GATEWAY", ""); gateway {
+	case "":
+	case "log":
+		lineGateway = reminder.LogLINE{}
+	case "api":
+		token := os.Getenv("LINE_CHANNEL_ACCESS_TOKEN")
+		if token == "" {
+			log.Fatalf("LINE_GATEWAY=api needs LINE_CHANNEL_ACCESS_TOKEN")
+		}
+		lineGateway = reminder.NewLINEMessaging(token)
+	default:
+		log.Fatalf("Invalid LINE_GATEWAY %q: expected log or api", gateway)
+	}
+	if lineGateway != nil {
+		lineSender := reminder.NewLINESender(lineGateway, lineRepo, appointmentReminderRepo, retryPolicy)
+		scheduler.Every("line-messages", time.Minute, lineSender.Run)
+	}
+	lineHandler := handlers.NewLINEHandler(lineRepo, patientRepo)
+	// EMAIL_GATEWAY=smtp sends the email outbox through SMTP_HOST:SMTP_PORT as
+	// EMAIL_FROM, or =log writes it to the server log; unset leaves it pending
+	var emailSender email.Sender
+	switch gateway := getEnv("EMAIL_GATEWAY", ""); gateway {
+	case "":
+	case "log":
+		emailSender = email.LogSender{}
+	case "smtp":
+		host, from := os.Getenv("SMTP_HOST"), os.Getenv("EMAIL_FROM")
+		if host == "" || from == "" {
+			log.Fatalf("EMAIL_GATEWAY=smtp needs SMTP_HOST and EMAIL_FROM")
+		}
+		port, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+		if err != nil || port < 1 || port > 65535 {
+			log.Fatalf("Invalid SMTP_PORT: expected a port number")
+		}
+		if emailSender, err = email.NewSMTPSender(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from); err != nil {
+			log.Fatalf("Invalid EMAIL_FROM: %v", err)
+		}
+	default:
+		log.Fatalf("Invalid EMAIL_GATEWAY %q: expected log or smtp", gateway)
+	}
+	if emailSender != nil {
+		emailDispatcher := email.NewDispatcher(emailSender, emailRepo, retryPolicy)
+		scheduler.Every("emails", time.Minute, emailDispatcher.Run)
+	}
+	emailHandler := handlers.NewEmailHandler(emailRepo)
+
+	// Questionnaire links go to the patient's LINE account, phone or email
+	patientMessenger := handlers.NewOutboxMessenger(patientRepo, lineRepo, smsGateway, emailRepo)
+	questionnaireHandler := handlers.NewQuestionnaireHandler(questionnaireRepo, patientRepo, patientMessenger, getEnv("PUBLIC_BASE_URL", "http://localhost:8080"))
+
+	encounterRepo := database.NewMockEncounterRepository()
+	encounterHandler := handlers.NewEncounterHandler(encounterRepo, patientRepo, doctorRepo, appointmentRepo, intakeRepo, cancellationReasonRepo, icd10)
+	intakeHandler := handlers.NewIntakeHandler(intakeRepo, appointmentRepo, encounterRepo, getEnv("PUBLIC_BASE_URL", "http://localhost:8080"))
+	// Group session check-ins open a visit for each patient
+	groupSessionHandler := handlers.NewGroupSessionHandler(groupSessionRepo, patientRepo, encounterHandler)
+	// Video visits open the appointment's visit when the doctor joins; rooms are
+	// at TELECONSULT_VIDEO_URL when the clinic has a video service
+	teleconsultRepo := database.NewMockTeleconsultRepository()
+	teleconsultHandler := handlers.NewTeleconsultHandler(teleconsultRepo, appointmentRepo, patientRepo, encounterHandler,
+		getEnv("PUBLIC_BASE_URL", "http://localhost:8080"), getEnv("TELECONSULT_VIDEO_URL", ""))
+
+	prescriptionRepo := database.NewMockPrescriptionRepository()
+	prescriptionHandler := handlers.NewPrescriptionHandler(prescriptionRepo, encounterRepo, patientRepo, doctorRepo, drugRepo)
+	signatureHandler := handlers.NewESignatureHandler(signatureRepo, prescriptionRepo, doctorRepo, newSigner(),
+		getEnv("PUBLIC_BASE_URL", "http://localhost:8080"))
+	dispensingHandler := handlers.NewDispensingHandler(prescriptionRepo, inventoryRepo, recallRepo, coldChainRepo)
+	visitSummaryRepo := database.NewMockVisitSummaryRepository()
+	visitSummaryHandler := handlers.NewVisitSummaryHandler(visitSummaryRepo, encounterRepo, patientRepo, diagnosisCodeRepo,
+		prescriptionRepo, drugRepo, getEnv("PUBLIC_BASE_URL", "http://localhost:8080"))
+	interactionRuleRepo := database.NewMockInteractionRuleRepository()
+	interactionHandler := handlers.NewInteractionHandler(interactionRuleRepo, drugRepo, patientRepo, allergyRepo, prescriptionRepo)
+
+	serviceHandler := handlers.NewServiceHandler(serviceRepo, visitServiceRepo, encounterRepo, patientRepo)
+
+	treatmentPackageRepo := database.NewMockTreatmentPackageRepository()
+	patientPackageRepo := database.NewMockPatientPackageRepository()
+	packageHandler := handlers.NewPackageHandler(treatmentPackageRepo, patientPackageRepo, serviceRepo, patientRepo, encounterRepo)
+
+	invoiceRepo := database.NewMockInvoiceRepository()
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceRepo, encounterRepo, prescriptionRepo, drugRepo,
+		visitServiceRepo, serviceRepo, visitServiceRepo, patientRepo, clinicalNoteRepo)
+	paymentRepo := database.NewMockPaymentRepository(invoiceRepo)
+	paymentHandler := handlers.NewPaymentHandler(paymentRepo, invoiceRepo)
+	// Matched bank transfers are recorded as invoice payments
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationRepo, reconciliation.NewLedger(invoiceRepo, paymentRepo))
+	insuranceRepo := database.NewMockInsuranceRepository()
+	insuranceHandler := handlers.NewInsuranceHandler(insuranceRepo, patientRepo, invoiceRepo)
+	productivityHandler := handlers.NewProductivityHandler(encounterRepo, invoiceRepo, prescriptionRepo)
+	cancellationHandler := handlers.NewCancellationHandler(cancellationReasonRepo, appointmentRepo, encounterRepo)
+	// Patients sign in to the portal with a code texted through SMS_GATEWAY
+	portalRepo := database.NewMockPortalRepository()
+	// and book themselves into time staff release for online booking, holding
+	// at most PORTAL_MAX_UPCOMING appointments and with fewer than
+	// PORTAL_NO_SHOW_LIMIT no-shows in the last PORTAL_NO_SHOW_DAYS days
+	onlineSlotRepo := database.NewMockOnlineSlotRepository()
+	onlineSlotHandler := handlers.NewOnlineSlotHandler(onlineSlotRepo, appointmentHandler)
+	var portalPolicy handlers.PortalBookingPolicy
+	if portalPolicy.MaxUpcoming, err = strconv.Atoi(getEnv("PORTAL_MAX_UPCOMING", "2")); err != nil || portalPolicy.MaxUpcoming < 0 {
+		log.Fatalf("Invalid PORTAL_MAX_UPCOMING: expected a number, 0 for no limit")
+	}
+	if portalPolicy.NoShowLimit, err = strconv.Atoi(getEnv("PORTAL_NO_SHOW_LIMIT", "2")); err != nil || portalPolicy.NoShowLimit < 0 {
+		log.Fatalf("Invalid PORTAL_NO_SHOW_LIMIT: expected a number, 0 for no limit")
+	}
+	noShowDays, err := strconv.Atoi(getEnv("PORTAL_NO_SHOW_DAYS", "180"))
+	if err != nil || noShowDays < 1 {
+		log.Fatalf("Invalid PORTAL_NO_SHOW_DAYS: expected a positive number")
+	}
+	portalPolicy.NoShowWindow = time.Duration(noShowDays) * 24 * time.Hour
+	portalHandler := handlers.NewPortalHandler(portalRepo, patientRepo, appointmentRepo, encounterRepo, visitSummaryHandler,
+		invoiceRepo, smsGateway, onlineSlotRepo, appointmentHandler, portalPolicy)
+
+	// MOCK_FIDELITY=full makes the mocks check references like foreign keys and
+	// accept injected failures, for offline frontend work and error-path testing
+	var mockFaults handlers.MockFaultRegistry
+	if getEnv("MOCK_FIDELITY", "basic") == "full" {
+		faults := database.NewMockFaults()
+		fidelity := &database.MockFidelity{Faults: faults, Patients: patientRepo, Doctors: doctorRepo, Visits: encounterRepo}
+		for _, repo := range []interface{ UseFidelity(*database.MockFidelity) }{
+			patientRepo, coordinationRepo, reconciliationRepo, reorderRepo, recallRepo, coldChainRepo,
+			signatureRepo, certificateRepo, clinicalNoteRepo, formRepo, carePlanRepo, groupSessionRepo,
+			campaignRepo, interpreterRepo, accessibilityRepo, questionnaireRepo, noteDraftRepo,
+			diagnosisCodeRepo, prescriptionFavoriteRepo, doctorRepo, appointmentRepo, encounterRepo, prescriptionRepo,
+			drugRepo, inventoryRepo, invoiceRepo, patientMergeRepo, appointmentDisplayRepo, paymentRepo,
+			insuranceRepo, handoverRepo, taskRepo, vitalsRepo, allergyRepo, chatRepo,
+			announcementRepo, vaccinationRepo, referralRepo, branchRepo, queueRepo,
+			appointmentReminderRepo, rosterRepo, reminderReplyRepo, problemRepo, patientRuleRepo,
+			appointmentOverrideRepo, serviceRepo, visitServiceRepo, intakeRepo, documentRepo,
+			consentRepo, triageRepo, followUpRepo, treatmentPackageRepo, patientPackageRepo, userRepo,
+			nursingNoteRepo, cancellationReasonRepo, dentalRepo, emergencyContactRepo, selfRegistrationRepo,
+			addressRepo, visitSummaryRepo, interactionRuleRepo, apiKeyRepo, lineRepo, emailRepo, portalRepo, onlineSlotRepo,
+			teleconsultRepo,
+		} {
+			repo.UseFidelity(fidelity)
+		}
+		mockFaults = faults
+		log.Printf("Mock repositories running in full-fidelity mode")
+	}
+	mockFaultHandler := handlers.NewMockFaultHandler(mockFaults)
+
+	vitalsHandler := handlers.NewVitalsHandler(vitalsRepo, patientRepo, encounterRepo)
+
+	allergyHandler := handlers.NewAllergyHandler(allergyRepo, patientRepo)
+	problemHandler := handlers.NewProblemHandler(problemRepo, patientRepo)
+	dentalHandler := handlers.NewDentalHandler(dentalRepo, patientRepo, encounterRepo)
+	emergencyContactHandler := handlers.NewEmergencyContactHandler(emergencyContactRepo, patientRepo)
+	addressHandler := handlers.NewAddressHandler(addressRepo, patientRepo)
+
+	chatHandler := handlers.NewChatHandler(chatRepo, patientRepo, encounterRepo)
+
+	referralHandler := handlers.NewReferralHandler(referralRepo, patientRepo, encounterRepo)
+	followUpHandler := handlers.NewFollowUpHandler(followUpRepo, patientRepo, encounterRepo)
+	nursingNoteHandler := handlers.NewNursingNoteHandler(nursingNoteRepo, encounterRepo)
+
+	// Walk-ins checking in at WALK_IN_SERVICE_POINT are assigned a doctor on
+	// shift by their branch's policy, or WALK_IN_ASSIGNMENT for branches without one
+	walkInPolicy := getEnv("WALK_IN_ASSIGNMENT", database.WalkInManual)
+	if !slices.Contains(database.WalkInAssignments, walkInPolicy) {
+		log.Fatalf("Invalid WALK_IN_ASSIGNMENT %q: expected one of %s", walkInPolicy, strings.Join(database.WalkInAssignments, ", "))
+	}
+	walkInAssigner := handlers.NewWalkInAssigner(doctorRepo, rosterRepo, branchRepo, queueRepo,
+		getEnv("WALK_IN_SERVICE_POINT", "exam"), walkInPolicy)
+	queueHandler := handlers.NewQueueHandler(queueRepo, patientRepo, encounterRepo, appointmentRepo, doctorRepo, walkInAssigner, lineRepo, accessibilityRepo,
+		getEnv("PUBLIC_BASE_URL", "http://localhost:8080"))
+	// Self check-in kiosks call with an API key of the kiosk role and queue
+	// arriving patients at KIOSK_SERVICE_POINT
+	kioskHandler := handlers.NewKioskHandler(patientRepo, patientRepo, appointmentRepo, queueHandler,
+		getEnv("KIOSK_SERVICE_POINT", "registration"))
+
+	rosterHandler := handlers.NewRosterHandler(rosterRepo, doctorRepo, appointmentRepo)
+	bulkRescheduleHandler := handlers.NewBulkRescheduleHandler(appointmentRepo, doctorRepo, rosterRepo, cancellationReasonRepo, patientRepo, appointmentReminderRepo)
+	reminderReplyHandler := handlers.NewReminderReplyHandler(reminderReplyRepo, appointmentReminderRepo, appointmentRepo)
+
+	// Responses of COMPRESSION_MIN_SIZE bytes or more are compressed for
+	// clients that accept it, except COMPRESSION_EXCLUDE_TYPES; "off" disables it
+	var compression *handlers.Compression
+	if minSize := getEnv("COMPRESSION_MIN_SIZE", "1024"); minSize != "off" {
+		n, err := strconv.Atoi(minSize)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid COMPRESSION_MIN_SIZE %q: expected a number of bytes or off", minSize)
+		}
+		excluded := handlers.DefaultUncompressedTypes
+		if types := os.Getenv("COMPRESSION_EXCLUDE_TYPES"); types != "" {
+			excluded = strings.Split(types, ",")
+		}
+		compression = handlers.NewCompression(n, excluded)
+	}
+
+	// DEMO_MODE=true shows placeholders for patients' names and phone numbers
+	// and makes the API read-only, for demos and screenshots of live data
+	var demoMode *handlers.DemoMode
+	if getEnv("DEMO_MODE", "false") == "true" {
+		var err error
+		if demoMode, err = handlers.NewDemoMode(); err != nil {
+			log.Fatalf("Failed to start demo mode: %v", err)
+		}
+		log.Printf("Demo mode: patient details are anonymized and the API is read-only")
+	}
+
+	openAPIHandler := handlers.NewOpenAPIHandler(api.OpenAPI)
 
 	r := mux.NewRouter()
 
 	// Add CORS middleware
 	r.Use(corsMiddleware)
+	// Compress large responses for slow connections
+	if compression != nil {
+		r.Use(compression.Middleware)
+	}
+	// Anonymize patients in responses for demos, inside compression so the
+	// placeholders are what gets compressed
+	if demoMode != nil {
+		r.Use(demoMode.Middleware)
+	}
+	// Carry the acting user, role, tenant and branch in each request's context
+	r.Use(handlers.RequestContext(adminGate, branchRepo, apiKeyRepo))
+	// Reject writes while an administrator has the API in maintenance mode
+	r.Use(maintenanceHandler.Middleware)
+	// Sample requests to routes an administrator switched profiling on for
+	r.Use(profilingHandler.Middleware)
 
 	// API routes
-	r.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
+	r.HandleFunc("/health", healthHandler.Health).Methods("GET")
+	r.HandleFunc("/api/openapi.json", openAPIHandler.GetOpenAPI).Methods("GET")
+	r.PathPrefix("/files/").Handler(http.StripPrefix("/files", fileStore.Handler())).Methods("GET")
 
 	// Patient routes
 	r.HandleFunc("/api/patients", patientHandler.GetPatients).Methods("GET")
@@ -30,16 +572,1015 @@ func main() {
 	r.HandleFunc("/api/patients/{hn}", patientHandler.UpdatePatient).Methods("PUT")
 	r.HandleFunc("/api/patients/{hn}", patientHandler.DeletePatient).Methods("DELETE")
 
-	log.Printf("Starting server on :8080")
+	// Patient field rule routes
+	r.HandleFunc("/api/patient-rules", patientRuleHandler.GetPatientRules).Methods("GET")
+	r.HandleFunc("/api/admin/patient-rules/{field}", handlers.RequireRole(patientRuleHandler.SetPatientRule, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/patient-rules/{field}", handlers.RequireRole(patientRuleHandler.ResetPatientRule, reqctx.RoleAdmin)).Methods("DELETE")
+
+	// Self-registration routes
+	r.HandleFunc("/api/self-registrations", selfRegistrationHandler.CreateSelfRegistration).Methods("POST")
+	r.HandleFunc("/api/self-registrations", selfRegistrationHandler.GetSelfRegistrations).Methods("GET")
+	r.HandleFunc("/api/self-registrations/{id}", selfRegistrationHandler.GetSelfRegistration).Methods("GET")
+	r.HandleFunc("/api/self-registrations/{id}/verify", selfRegistrationHandler.VerifySelfRegistration).Methods("POST")
+	r.HandleFunc("/api/self-registrations/{id}/reject", selfRegistrationHandler.RejectSelfRegistration).Methods("POST")
+	r.HandleFunc("/public/self-registration/{token}", selfRegistrationHandler.GetPublicSelfRegistration).Methods("GET")
+	r.HandleFunc("/public/self-registration/{token}", selfRegistrationHandler.SubmitPublicSelfRegistration).Methods("POST")
+
+	// Bank reconciliation routes
+	r.HandleFunc("/api/reconciliation/imports", reconciliationHandler.ImportStatement).Methods("POST")
+	r.HandleFunc("/api/reconciliation/imports", reconciliationHandler.GetImports).Methods("GET")
+	r.HandleFunc("/api/reconciliation/transactions", reconciliationHandler.GetTransactions).Methods("GET")
+	r.HandleFunc("/api/reconciliation/transactions/{id}/candidates", reconciliationHandler.GetCandidates).Methods("GET")
+	r.HandleFunc("/api/reconciliation/transactions/{id}/match", reconciliationHandler.MatchTransaction).Methods("POST")
+	r.HandleFunc("/api/reconciliation/report", reconciliationHandler.GetReport).Methods("GET")
+
+	// Inventory reorder routes
+	r.HandleFunc("/api/inventory/reorder-policies", reorderHandler.GetPolicies).Methods("GET")
+	r.HandleFunc("/api/inventory/reorder-policies/{itemId}", reorderHandler.UpdatePolicy).Methods("PUT")
+	r.HandleFunc("/api/inventory/forecast", reorderHandler.GetForecast).Methods("GET")
+	r.HandleFunc("/api/inventory/reorder-suggestions/generate", reorderHandler.GenerateSuggestions).Methods("POST")
+	r.HandleFunc("/api/inventory/reorder-suggestions", reorderHandler.GetSuggestions).Methods("GET")
+	r.HandleFunc("/api/inventory/reorder-suggestions/{id}/approve", reorderHandler.ApproveSuggestion).Methods("POST")
+	r.HandleFunc("/api/inventory/reorder-suggestions/{id}/reject", reorderHandler.RejectSuggestion).Methods("POST")
+
+	// Lot recall routes
+	r.HandleFunc("/api/recalls", recallHandler.CreateRecall).Methods("POST")
+	r.HandleFunc("/api/recalls", recallHandler.GetRecalls).Methods("GET")
+	r.HandleFunc("/api/recalls/check", recallHandler.CheckLot).Methods("GET")
+	r.HandleFunc("/api/recalls/{id}", recallHandler.GetRecall).Methods("GET")
+	r.HandleFunc("/api/recalls/{id}/patients", recallHandler.GetAffectedPatients).Methods("GET")
+	r.HandleFunc("/api/recalls/{id}/notifications", recallHandler.CreateNotifications).Methods("POST")
+	r.HandleFunc("/api/recalls/{id}/notifications", recallHandler.GetNotifications).Methods("GET")
+
+	// Cold-chain monitoring routes
+	r.HandleFunc("/api/cold-chain/fridges", coldChainHandler.CreateFridge).Methods("POST")
+	r.HandleFunc("/api/cold-chain/fridges", coldChainHandler.GetFridges).Methods("GET")
+	r.HandleFunc("/api/cold-chain/fridges/{id}/lots", coldChainHandler.SetFridgeLots).Methods("PUT")
+	r.HandleFunc("/api/cold-chain/fridges/{id}/lots", coldChainHandler.GetFridgeLots).Methods("GET")
+	r.HandleFunc("/api/cold-chain/fridges/{id}/readings", coldChainHandler.GetReadings).Methods("GET")
+	r.HandleFunc("/api/cold-chain/readings", coldChainHandler.RecordReadings).Methods("POST")
+	r.HandleFunc("/api/cold-chain/excursions", coldChainHandler.GetExcursions).Methods("GET")
+	r.HandleFunc("/api/cold-chain/lot-reviews", coldChainHandler.GetLotReviews).Methods("GET")
+	r.HandleFunc("/api/cold-chain/lot-reviews/{id}/decision", coldChainHandler.DecideLotReview).Methods("POST")
+
+	// Prescription e-signature routes
+	r.HandleFunc("/api/doctors/{id}/signing-keys", signatureHandler.CreateSigningKey).Methods("POST")
+	r.HandleFunc("/api/doctors/{id}/signing-keys/active", signatureHandler.GetSigningKey).Methods("GET")
+	r.HandleFunc("/api/prescriptions/{id}/signature", signatureHandler.SignPrescription).Methods("POST")
+	r.HandleFunc("/public/prescriptions/verify/{code}", signatureHandler.VerifyPrescription).Methods("GET")
+	r.HandleFunc("/public/prescriptions/verify/{code}", signatureHandler.VerifyPrescription).Methods("POST")
+
+	// Medical certificate routes
+	r.HandleFunc("/api/patients/{hn}/certificates", certificateHandler.IssueCertificate).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/certificates", certificateHandler.GetPatientCertificates).Methods("GET")
+	r.HandleFunc("/api/certificates/{number}", certificateHandler.GetCertificate).Methods("GET")
+	r.HandleFunc("/api/certificates/{number}/revoke", certificateHandler.RevokeCertificate).Methods("POST")
+	r.HandleFunc("/public/certificates/verify", certificateHandler.VerifyCertificate).Methods("GET")
+
+	// Clinical note co-signing routes
+	r.HandleFunc("/api/visits/{visitId}/clinical-notes", clinicalNoteHandler.CreateNote).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/clinical-notes", clinicalNoteHandler.GetVisitNotes).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/cosign-status", clinicalNoteHandler.GetCosignStatus).Methods("GET")
+	r.HandleFunc("/api/clinical-notes/pending-cosign", clinicalNoteHandler.GetCosignWorklist).Methods("GET")
+	r.HandleFunc("/api/clinical-notes/{id}/cosign", handlers.RequireRole(clinicalNoteHandler.CosignNote, reqctx.RoleDoctor)).Methods("POST")
+
+	// Structured form routes
+	r.HandleFunc("/api/forms", formHandler.CreateForm).Methods("POST")
+	r.HandleFunc("/api/forms", formHandler.GetForms).Methods("GET")
+	r.HandleFunc("/api/forms/{id}", formHandler.GetForm).Methods("GET")
+	r.HandleFunc("/api/forms/{id}", formHandler.UpdateForm).Methods("PUT")
+	r.HandleFunc("/api/forms/{id}/answers", formHandler.QueryAnswers).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/forms/{formId}", formHandler.SubmitForm).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/forms", formHandler.GetVisitForms).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/forms/{formId}", formHandler.RenderVisitForm).Methods("GET")
+
+	// Care plan goal routes
+	r.HandleFunc("/api/patients/{hn}/care-plan/goals", carePlanHandler.CreateGoal).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/care-plan/goals", carePlanHandler.GetPatientGoals).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/care-plan/timeline", carePlanHandler.GetTimeline).Methods("GET")
+	r.HandleFunc("/api/care-plan/goals/{id}", carePlanHandler.GetGoal).Methods("GET")
+	r.HandleFunc("/api/care-plan/goals/{id}/checkpoints", carePlanHandler.AddCheckpoint).Methods("POST")
+	r.HandleFunc("/api/care-plan/goals/{id}/status", carePlanHandler.UpdateGoalStatus).Methods("PUT")
+
+	// Group session routes
+	r.HandleFunc("/api/group-sessions", groupSessionHandler.CreateSession).Methods("POST")
+	r.HandleFunc("/api/group-sessions", groupSessionHandler.GetSessions).Methods("GET")
+	r.HandleFunc("/api/group-sessions/{id}", groupSessionHandler.GetSession).Methods("GET")
+	r.HandleFunc("/api/group-sessions/{id}/cancel", groupSessionHandler.CancelSession).Methods("POST")
+	r.HandleFunc("/api/group-sessions/{id}/bookings", groupSessionHandler.BookPatient).Methods("POST")
+	r.HandleFunc("/api/group-sessions/{id}/bookings/{bookingId}", groupSessionHandler.CancelBooking).Methods("DELETE")
+	r.HandleFunc("/api/group-sessions/{id}/bookings/{bookingId}/check-in", groupSessionHandler.CheckIn).Methods("POST")
+
+	// Campaign routes
+	r.HandleFunc("/api/campaigns", campaignHandler.CreateCampaign).Methods("POST")
+	r.HandleFunc("/api/campaigns", campaignHandler.GetCampaigns).Methods("GET")
+	r.HandleFunc("/api/campaigns/{id}", campaignHandler.GetCampaign).Methods("GET")
+	r.HandleFunc("/api/campaigns/{id}/open", campaignHandler.OpenCampaign).Methods("POST")
+	r.HandleFunc("/api/campaigns/{id}/close", campaignHandler.CloseCampaign).Methods("POST")
+	r.HandleFunc("/api/campaigns/{id}/registrations/import", campaignHandler.ImportRegistrations).Methods("POST")
+	r.HandleFunc("/api/campaigns/{id}/registrations", campaignHandler.GetRegistrations).Methods("GET")
+	r.HandleFunc("/api/campaigns/{id}/registrations/assign", campaignHandler.AssignRegistrations).Methods("POST")
+	r.HandleFunc("/api/campaigns/{id}/dashboard", campaignHandler.GetDashboard).Methods("GET")
+
+	// Interpreter routes
+	r.HandleFunc("/api/patients/{hn}/language", interpreterHandler.GetPatientLanguage).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/language", interpreterHandler.UpdatePatientLanguage).Methods("PUT")
+	r.HandleFunc("/api/interpreters", interpreterHandler.CreateInterpreter).Methods("POST")
+	r.HandleFunc("/api/interpreters", interpreterHandler.GetInterpreters).Methods("GET")
+	r.HandleFunc("/api/interpreter-bookings", interpreterHandler.BookInterpreter).Methods("POST")
+	r.HandleFunc("/api/interpreter-bookings/{id}/cancel", interpreterHandler.CancelInterpreterBooking).Methods("POST")
+	r.HandleFunc("/api/interpreter-worklist", interpreterHandler.GetWorklist).Methods("GET")
+
+	// Accessibility routes
+	r.HandleFunc("/api/patients/{hn}/accessibility", accessibilityHandler.GetAccessibility).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/accessibility", accessibilityHandler.UpdateAccessibility).Methods("PUT")
+	r.HandleFunc("/api/accessibility/alerts", accessibilityHandler.GetAlerts).Methods("GET")
+	r.HandleFunc("/api/reports/accessibility", accessibilityHandler.GetReport).Methods("GET")
+
+	// Patient-reported outcome questionnaire routes
+	r.HandleFunc("/api/questionnaires/instruments", questionnaireHandler.GetInstruments).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/questionnaires", questionnaireHandler.SendQuestionnaire).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/questionnaires", questionnaireHandler.GetPatientQuestionnaires).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/questionnaires", questionnaireHandler.GetVisitQuestionnaires).Methods("GET")
+	r.HandleFunc("/api/questionnaire-alerts", questionnaireHandler.GetAlerts).Methods("GET")
+	r.HandleFunc("/api/questionnaire-alerts/{id}/acknowledge", questionnaireHandler.AcknowledgeAlert).Methods("POST")
+	r.HandleFunc("/public/questionnaires/{token}", questionnaireHandler.GetPublicQuestionnaire).Methods("GET")
+	r.HandleFunc("/public/questionnaires/{token}", questionnaireHandler.SubmitPublicQuestionnaire).Methods("POST")
+
+	// SOAP note draft routes
+	r.HandleFunc("/api/visits/{visitId}/note-draft", noteDraftHandler.SaveDraft).Methods("PUT")
+	r.HandleFunc("/api/visits/{visitId}/note-draft", noteDraftHandler.GetVisitDraft).Methods("GET")
+	r.HandleFunc("/api/note-drafts", noteDraftHandler.GetDrafts).Methods("GET")
+	r.HandleFunc("/api/note-drafts/{id}", noteDraftHandler.GetDraft).Methods("GET")
+	r.HandleFunc("/api/note-drafts/{id}", noteDraftHandler.DiscardDraft).Methods("DELETE")
+	r.HandleFunc("/api/note-drafts/{id}/finalize", noteDraftHandler.FinalizeDraft).Methods("POST")
+
+	// Clinical note amendment routes
+	r.HandleFunc("/api/clinical-notes/{id}/amendments", clinicalNoteHandler.AmendNote).Methods("POST")
+	r.HandleFunc("/api/clinical-notes/{id}/versions", clinicalNoteHandler.GetNoteVersions).Methods("GET")
+	r.HandleFunc("/api/clinical-notes/{id}/diff", clinicalNoteHandler.GetNoteDiff).Methods("GET")
+
+	// ICD-10 coding routes
+	r.HandleFunc("/api/icd10", codingHandler.SearchICD10).Methods("GET")
+	r.HandleFunc("/api/coding/suggestions", codingHandler.SuggestCodes).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/diagnosis-codes", codingHandler.ConfirmCode).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/diagnosis-codes", codingHandler.GetVisitCodes).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/diagnosis-codes/{id}", codingHandler.RemoveCode).Methods("DELETE")
+
+	// Drug favorite and prescription set routes
+	r.HandleFunc("/api/doctors/{doctorId}/drug-favorites", prescriptionFavoriteHandler.CreateFavorite).Methods("POST")
+	r.HandleFunc("/api/doctors/{doctorId}/drug-favorites", prescriptionFavoriteHandler.GetFavorites).Methods("GET")
+	r.HandleFunc("/api/doctors/{doctorId}/drug-favorites/{id}/use", prescriptionFavoriteHandler.UseFavorite).Methods("POST")
+	r.HandleFunc("/api/doctors/{doctorId}/drug-favorites/{id}", prescriptionFavoriteHandler.DeleteFavorite).Methods("DELETE")
+	r.HandleFunc("/api/doctors/{doctorId}/prescription-sets", prescriptionFavoriteHandler.CreateSet).Methods("POST")
+	r.HandleFunc("/api/doctors/{doctorId}/prescription-sets", prescriptionFavoriteHandler.GetSets).Methods("GET")
+	r.HandleFunc("/api/doctors/{doctorId}/prescription-sets/{id}", prescriptionFavoriteHandler.GetSet).Methods("GET")
+	r.HandleFunc("/api/doctors/{doctorId}/prescription-sets/{id}", prescriptionFavoriteHandler.UpdateSet).Methods("PUT")
+	r.HandleFunc("/api/doctors/{doctorId}/prescription-sets/{id}/use", prescriptionFavoriteHandler.UseSet).Methods("POST")
+	r.HandleFunc("/api/doctors/{doctorId}/prescription-sets/{id}", prescriptionFavoriteHandler.DeleteSet).Methods("DELETE")
+
+	// Maintenance mode routes
+	r.HandleFunc("/api/admin/maintenance", handlers.RequireRole(maintenanceHandler.GetMaintenance, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/maintenance", handlers.RequireRole(maintenanceHandler.SetMaintenance, reqctx.RoleAdmin)).Methods("PUT")
+
+	// Coordination routes
+	r.HandleFunc("/api/admin/coordination", handlers.RequireRole(coordinationHandler.GetCoordination, reqctx.RoleAdmin)).Methods("GET")
+
+	// Jobs must be registered with scheduler.Every before this point
+	go scheduler.Run(context.Background())
+
+	// Appointment routes
+	r.HandleFunc("/api/appointments", appointmentHandler.CreateAppointment).Methods("POST")
+	r.HandleFunc("/api/appointments", appointmentHandler.GetAppointments).Methods("GET")
+	r.HandleFunc("/api/appointments/{id}", appointmentHandler.GetAppointment).Methods("GET")
+	r.HandleFunc("/api/appointments/{id}/reschedule", appointmentHandler.RescheduleAppointment).Methods("PUT")
+	r.HandleFunc("/api/appointments/{id}/cancel", appointmentHandler.CancelAppointment).Methods("POST")
+	r.HandleFunc("/api/appointments/{id}/status", appointmentHandler.UpdateAppointmentStatus).Methods("PUT")
+	r.HandleFunc("/api/appointments/{id}/confirmation", appointmentHandler.ConfirmAppointment).Methods("PUT")
+	r.HandleFunc("/api/appointments/{id}/reminders", appointmentReminderHandler.GetAppointmentReminders).Methods("GET")
+	r.HandleFunc("/api/appointments/{id}/delivery-log", appointmentReminderHandler.GetAppointmentDeliveryLog).Methods("GET")
+	r.HandleFunc("/api/appointment-reminders", appointmentReminderHandler.GetReminders).Methods("GET")
+	r.HandleFunc("/api/appointment-reminders/{id}/status", appointmentReminderHandler.UpdateReminderStatus).Methods("PUT")
+	r.HandleFunc("/api/appointment-reminders/{id}/failures", appointmentReminderHandler.GetReminderFailures).Methods("GET")
+	r.HandleFunc("/api/admin/dead-letters", handlers.RequireRole(appointmentReminderHandler.GetDeadLetters, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/dead-letters/{id}/requeue", handlers.RequireRole(appointmentReminderHandler.RequeueReminder, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/notification-health", handlers.RequireRole(appointmentReminderHandler.GetDeliveryHealth, reqctx.RoleAdmin)).Methods("GET")
+
+	// LINE routes
+	r.HandleFunc("/api/patients/{hn}/line", lineHandler.GetPatientLINE).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/line", lineHandler.LinkLINE).Methods("PUT")
+	r.HandleFunc("/api/patients/{hn}/line", lineHandler.UnlinkLINE).Methods("DELETE")
+	r.HandleFunc("/api/line-messages", lineHandler.GetLINEMessages).Methods("GET")
+	r.HandleFunc("/api/admin/line-messages/{id}/requeue", handlers.RequireRole(lineHandler.RequeueLINEMessage, reqctx.RoleAdmin)).Methods("POST")
+
+	// Email routes
+	r.HandleFunc("/api/emails", emailHandler.GetEmails).Methods("GET")
+	r.HandleFunc("/api/admin/emails/{id}/requeue", handlers.RequireRole(emailHandler.RequeueEmail, reqctx.RoleAdmin)).Methods("POST")
+
+	// Pre-visit intake routes
+	r.HandleFunc("/api/appointments/{id}/intake", intakeHandler.CreateIntake).Methods("POST")
+	r.HandleFunc("/api/appointments/{id}/intake", intakeHandler.GetAppointmentIntake).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/intake", intakeHandler.GetVisitIntake).Methods("GET")
+	r.HandleFunc("/public/intake/{token}", intakeHandler.GetPublicIntake).Methods("GET")
+	r.HandleFunc("/public/intake/{token}", intakeHandler.SubmitPublicIntake).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/appointments", appointmentHandler.GetPatientAppointments).Methods("GET")
+	r.HandleFunc("/api/appointment-overrides", appointmentHandler.GetAppointmentOverrides).Methods("GET")
+
+	// Doctor routes
+	r.HandleFunc("/api/doctors", doctorHandler.GetDoctors).Methods("GET")
+	r.HandleFunc("/api/doctors", doctorHandler.CreateDoctor).Methods("POST")
+	r.HandleFunc("/api/doctors/{id}", doctorHandler.GetDoctor).Methods("GET")
+	r.HandleFunc("/api/doctors/{id}", doctorHandler.UpdateDoctor).Methods("PUT")
+	r.HandleFunc("/api/doctors/{id}", doctorHandler.DeleteDoctor).Methods("DELETE")
+
+	// Mock fault injection routes
+	r.HandleFunc("/api/admin/mock/faults", handlers.RequireRole(mockFaultHandler.GetFaults, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/mock/faults", handlers.RequireRole(mockFaultHandler.SetFault, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/mock/faults", handlers.RequireRole(mockFaultHandler.ClearFaults, reqctx.RoleAdmin)).Methods("DELETE")
+
+	// Visit routes
+	r.HandleFunc("/api/patients/{hn}/visits", encounterHandler.CreateVisit).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/visits", encounterHandler.GetPatientVisits).Methods("GET")
+	r.HandleFunc("/api/visits/{id}", encounterHandler.GetVisit).Methods("GET")
+	r.HandleFunc("/api/visits/{id}", encounterHandler.UpdateVisit).Methods("PUT")
+	r.HandleFunc("/api/visits/{id}/close", encounterHandler.CloseVisit).Methods("POST")
+	r.HandleFunc("/api/visits/{id}/cancel", encounterHandler.CancelVisit).Methods("POST")
+	r.HandleFunc("/api/visits/{id}/soap", encounterHandler.SaveVisitSOAP).Methods("PUT")
+	r.HandleFunc("/api/visits/{id}/soap/versions", encounterHandler.GetVisitSOAPVersions).Methods("GET")
+	r.HandleFunc("/api/visits/{id}/soap/diff", encounterHandler.GetVisitSOAPDiff).Methods("GET")
+
+	// Prescription routes
+	r.HandleFunc("/api/visits/{visitId}/prescriptions", prescriptionHandler.CreatePrescription).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/prescriptions", prescriptionHandler.GetVisitPrescriptions).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/prescriptions", prescriptionHandler.GetPatientPrescriptions).Methods("GET")
+	r.HandleFunc("/api/prescriptions/{id}", prescriptionHandler.GetPrescription).Methods("GET")
+	r.HandleFunc("/api/prescriptions/{id}/print", prescriptionHandler.PrintPrescription).Methods("GET")
+
+	// Dispensing routes
+	r.HandleFunc("/api/dispensing", dispensingHandler.GetDispensing).Methods("GET")
+	r.HandleFunc("/api/prescriptions/{id}/dispense", dispensingHandler.DispensePrescription).Methods("POST")
+
+	// Visit summary sharing routes
+	r.HandleFunc("/api/visits/{visitId}/summary", visitSummaryHandler.GetVisitSummary).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/summary-links", visitSummaryHandler.CreateSummaryLink).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/summary-links", visitSummaryHandler.GetSummaryLinks).Methods("GET")
+	r.HandleFunc("/api/summary-links/{id}/accesses", visitSummaryHandler.GetSummaryLinkAccesses).Methods("GET")
+	r.HandleFunc("/api/summary-links/{id}/revoke", visitSummaryHandler.RevokeSummaryLink).Methods("POST")
+	r.HandleFunc("/public/visit-summary/{token}", visitSummaryHandler.GetPublicVisitSummary).Methods("GET")
+
+	// Teleconsult routes
+	r.HandleFunc("/api/appointments/{id}/teleconsult", teleconsultHandler.CreateTeleconsult).Methods("POST")
+	r.HandleFunc("/api/teleconsults", teleconsultHandler.GetTeleconsults).Methods("GET")
+	r.HandleFunc("/api/teleconsults/{id}", teleconsultHandler.GetTeleconsult).Methods("GET")
+	r.HandleFunc("/api/teleconsults/{id}/cancel", teleconsultHandler.CancelTeleconsult).Methods("POST")
+	r.HandleFunc("/api/teleconsults/{id}/end", teleconsultHandler.EndTeleconsult).Methods("POST")
+	r.HandleFunc("/public/teleconsult/{token}", teleconsultHandler.GetPublicTeleconsult).Methods("GET")
+	r.HandleFunc("/public/teleconsult/{token}/join", teleconsultHandler.JoinTeleconsult).Methods("POST")
+	r.HandleFunc("/public/teleconsult/{token}/leave", teleconsultHandler.LeaveTeleconsult).Methods("POST")
+
+	// Online booking slot routes
+	r.HandleFunc("/api/online-slots", onlineSlotHandler.ReleaseOnlineSlots).Methods("POST")
+	r.HandleFunc("/api/online-slots", onlineSlotHandler.GetOnlineSlots).Methods("GET")
+	r.HandleFunc("/api/online-slots/{id}", onlineSlotHandler.WithdrawOnlineSlot).Methods("DELETE")
+
+	// Patient portal routes
+	r.HandleFunc("/portal/sign-in", portalHandler.RequestPortalCode).Methods("POST")
+	r.HandleFunc("/portal/sign-in/verify", portalHandler.VerifyPortalCode).Methods("POST")
+	r.HandleFunc("/portal/sign-out", portalHandler.RequirePatient(portalHandler.SignOutPortal)).Methods("POST")
+	r.HandleFunc("/portal/me", portalHandler.RequirePatient(portalHandler.GetPortalProfile)).Methods("GET")
+	r.HandleFunc("/portal/appointments", portalHandler.RequirePatient(portalHandler.GetPortalAppointments)).Methods("GET")
+	r.HandleFunc("/portal/appointments", portalHandler.RequirePatient(portalHandler.BookPortalAppointment)).Methods("POST")
+	r.HandleFunc("/portal/slots", portalHandler.RequirePatient(portalHandler.GetPortalSlots)).Methods("GET")
+	r.HandleFunc("/portal/visits", portalHandler.RequirePatient(portalHandler.GetPortalVisits)).Methods("GET")
+	r.HandleFunc("/portal/visits/{visitId}/summary", portalHandler.RequirePatient(portalHandler.GetPortalVisitSummary)).Methods("GET")
+	r.HandleFunc("/portal/invoices", portalHandler.RequirePatient(portalHandler.GetPortalInvoices)).Methods("GET")
+
+	// Drug catalog routes
+	r.HandleFunc("/api/drugs", drugHandler.GetDrugs).Methods("GET")
+	r.HandleFunc("/api/drugs", drugHandler.CreateDrug).Methods("POST")
+	r.HandleFunc("/api/drugs/{id}", drugHandler.GetDrug).Methods("GET")
+	r.HandleFunc("/api/drugs/{id}", drugHandler.UpdateDrug).Methods("PUT")
+	r.HandleFunc("/api/drugs/{id}", drugHandler.DeleteDrug).Methods("DELETE")
+	r.HandleFunc("/api/drugs/{id}/image", drugHandler.UploadDrugImage).Methods("PUT")
+	r.HandleFunc("/api/drugs/{id}/image", drugHandler.DeleteDrugImage).Methods("DELETE")
+	r.HandleFunc("/api/drugs/{id}/leaflet", drugHandler.UploadDrugLeaflet).Methods("PUT")
+	r.HandleFunc("/api/drugs/{id}/leaflet", drugHandler.DeleteDrugLeaflet).Methods("DELETE")
+
+	// Drug interaction routes
+	r.HandleFunc("/api/interaction-rules", interactionHandler.GetInteractionRules).Methods("GET")
+	r.HandleFunc("/api/admin/interaction-rules", handlers.RequireRole(interactionHandler.CreateInteractionRule, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/interaction-rules/{id}", handlers.RequireRole(interactionHandler.UpdateInteractionRule, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/interaction-rules/{id}", handlers.RequireRole(interactionHandler.DeleteInteractionRule, reqctx.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/api/patients/{hn}/interaction-check", interactionHandler.CheckInteractions).Methods("POST")
+
+	// Service catalog routes
+	r.HandleFunc("/api/services", serviceHandler.GetServices).Methods("GET")
+	r.HandleFunc("/api/services", serviceHandler.CreateService).Methods("POST")
+	r.HandleFunc("/api/services/{id}", serviceHandler.GetService).Methods("GET")
+	r.HandleFunc("/api/services/{id}", serviceHandler.UpdateService).Methods("PUT")
+	r.HandleFunc("/api/services/{id}", serviceHandler.DeleteService).Methods("DELETE")
+	r.HandleFunc("/api/services/{id}/eligibility", serviceHandler.GetServiceEligibility).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/services", serviceHandler.AddVisitService).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/services", serviceHandler.GetVisitServices).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/services/{id}", serviceHandler.DeleteVisitService).Methods("DELETE")
+
+	// Treatment package routes
+	r.HandleFunc("/api/packages", packageHandler.GetPackages).Methods("GET")
+	r.HandleFunc("/api/packages", packageHandler.CreatePackage).Methods("POST")
+	r.HandleFunc("/api/packages/{id}", packageHandler.GetPackage).Methods("GET")
+	r.HandleFunc("/api/packages/{id}", packageHandler.UpdatePackage).Methods("PUT")
+	r.HandleFunc("/api/packages/{id}", packageHandler.DeletePackage).Methods("DELETE")
+	r.HandleFunc("/api/patients/{hn}/packages", packageHandler.PurchasePackage).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/packages", packageHandler.GetPatientPackages).Methods("GET")
+	r.HandleFunc("/api/patient-packages/{id}", packageHandler.GetPatientPackage).Methods("GET")
+	r.HandleFunc("/api/patient-packages/{id}/cancel", packageHandler.CancelPatientPackage).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/package-sessions", packageHandler.UsePackageSession).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/package-sessions", packageHandler.GetVisitPackageSessions).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/package-sessions/{id}", packageHandler.DeleteVisitPackageSession).Methods("DELETE")
+
+	// Profiling routes
+	r.HandleFunc("/api/admin/profiling", handlers.RequireRole(profilingHandler.GetProfiling, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/profiling/handlers", handlers.RequireRole(profilingHandler.SetHandlerSampling, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/profiling/handlers", handlers.RequireRole(profilingHandler.ClearHandlerSampling, reqctx.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/api/admin/profiling/runtime", handlers.RequireRole(profilingHandler.SetRuntimeProfiling, reqctx.RoleAdmin)).Methods("PUT")
+	r.PathPrefix("/api/admin/debug/pprof/").HandlerFunc(handlers.RequireRole(profilingHandler.Pprof, reqctx.RoleAdmin)).Methods("GET")
+
+	// Inventory routes
+	r.HandleFunc("/api/inventory/items", inventoryHandler.GetItems).Methods("GET")
+	r.HandleFunc("/api/inventory/items", inventoryHandler.CreateItem).Methods("POST")
+	r.HandleFunc("/api/inventory/items/{id}", inventoryHandler.GetItem).Methods("GET")
+	r.HandleFunc("/api/inventory/items/{id}", inventoryHandler.UpdateItem).Methods("PUT")
+	r.HandleFunc("/api/inventory/items/{id}/stock", inventoryHandler.GetItemStock).Methods("GET")
+	r.HandleFunc("/api/inventory/items/{id}/stock-in", inventoryHandler.StockIn).Methods("POST")
+	r.HandleFunc("/api/inventory/items/{id}/dispense", inventoryHandler.Dispense).Methods("POST")
+	r.HandleFunc("/api/inventory/items/{id}/adjustments", inventoryHandler.Adjust).Methods("POST")
+	r.HandleFunc("/api/inventory/items/{id}/movements", inventoryHandler.GetItemMovements).Methods("GET")
+	r.HandleFunc("/api/inventory/stock", inventoryHandler.GetStock).Methods("GET")
+	r.HandleFunc("/api/inventory/movements", inventoryHandler.GetMovements).Methods("GET")
+
+	// Billing routes
+	r.HandleFunc("/api/visits/{visitId}/invoice", invoiceHandler.GenerateInvoice).Methods("POST")
+	r.HandleFunc("/api/invoices", invoiceHandler.GetInvoices).Methods("GET")
+	r.HandleFunc("/api/invoices", invoiceHandler.CreateInvoice).Methods("POST")
+	r.HandleFunc("/api/invoices/{id}", invoiceHandler.GetInvoice).Methods("GET")
+	r.HandleFunc("/api/invoices/{id}", invoiceHandler.UpdateInvoice).Methods("PUT")
+	r.HandleFunc("/api/invoices/{id}", invoiceHandler.DeleteInvoice).Methods("DELETE")
+	r.HandleFunc("/api/invoices/{id}/status", invoiceHandler.UpdateInvoiceStatus).Methods("PUT")
+	r.HandleFunc("/api/invoices/{id}/payments", paymentHandler.RecordPayment).Methods("POST")
+	r.HandleFunc("/api/invoices/{id}/payments", paymentHandler.GetPayments).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/invoices", invoiceHandler.GetPatientInvoices).Methods("GET")
+
+	// Patient merge routes
+	r.HandleFunc("/api/admin/patient-merges", handlers.RequireRole(patientMergeHandler.MergePatients, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/patient-merges", handlers.RequireRole(patientMergeHandler.GetPatientMerges, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/patient-merges/{id}", handlers.RequireRole(patientMergeHandler.GetPatientMerge, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/patient-merges/{id}/undo", handlers.RequireRole(patientMergeHandler.UndoPatientMerge, reqctx.RoleAdmin)).Methods("POST")
+
+	// Appointment display routes
+	r.HandleFunc("/api/appointment-display", appointmentDisplayHandler.GetAppointmentDisplay).Methods("GET")
+	r.HandleFunc("/api/admin/appointment-display/{scope}/{key}", handlers.RequireRole(appointmentDisplayHandler.SetAppointmentDisplay, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/appointment-display/{scope}/{key}", handlers.RequireRole(appointmentDisplayHandler.ResetAppointmentDisplay, reqctx.RoleAdmin)).Methods("DELETE")
+
+	// Cancellation routes
+	r.HandleFunc("/api/cancellation-reasons", cancellationHandler.GetCancellationReasons).Methods("GET")
+	r.HandleFunc("/api/admin/cancellation-reasons/{code}", handlers.RequireRole(cancellationHandler.SetCancellationReason, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/reports/cancellations", cancellationHandler.GetCancellationReport).Methods("GET")
+	r.HandleFunc("/api/reports/booking-channels", appointmentHandler.GetBookingChannelReport).Methods("GET")
+
+	// Insurance routes
+	r.HandleFunc("/api/patients/{hn}/insurance-policies", insuranceHandler.CreatePolicy).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/insurance-policies", insuranceHandler.GetPatientPolicies).Methods("GET")
+	r.HandleFunc("/api/insurance-policies/{id}", insuranceHandler.UpdatePolicy).Methods("PUT")
+	r.HandleFunc("/api/insurance-policies/{id}", insuranceHandler.DeletePolicy).Methods("DELETE")
+	r.HandleFunc("/api/invoices/{id}/claims", insuranceHandler.CreateClaim).Methods("POST")
+	r.HandleFunc("/api/claims", insuranceHandler.GetClaims).Methods("GET")
+	r.HandleFunc("/api/claims/summary", insuranceHandler.GetClaimSummary).Methods("GET")
+	r.HandleFunc("/api/claims/{id}", insuranceHandler.GetClaim).Methods("GET")
+	r.HandleFunc("/api/claims/{id}/status", insuranceHandler.UpdateClaimStatus).Methods("PUT")
+	r.HandleFunc("/api/reports/doctor-productivity", productivityHandler.GetDoctorProductivity).Methods("GET")
+
+	// Calendar routes
+	r.HandleFunc("/api/calendar", appointmentHandler.GetCalendar).Methods("GET")
+
+	// Shift handover routes
+	r.HandleFunc("/api/handover/{department}", handoverHandler.CreateHandoverNote).Methods("POST")
+	r.HandleFunc("/api/handover/{department}", handoverHandler.GetHandover).Methods("GET")
+	r.HandleFunc("/api/handover-notes/{id}/resolve", handoverHandler.ResolveHandoverNote).Methods("PUT")
+
+	// Tasks routes
+	r.HandleFunc("/api/tasks", taskHandler.CreateTask).Methods("POST")
+	r.HandleFunc("/api/tasks", taskHandler.GetTasks).Methods("GET")
+	r.HandleFunc("/api/tasks/mine", taskHandler.GetMyTasks).Methods("GET")
+	r.HandleFunc("/api/tasks/overdue", taskHandler.GetOverdueTasks).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}", taskHandler.GetTask).Methods("GET")
+	r.HandleFunc("/api/tasks/{id}", taskHandler.UpdateTask).Methods("PUT")
+	r.HandleFunc("/api/tasks/{id}/status", taskHandler.UpdateTaskStatus).Methods("PUT")
+	r.HandleFunc("/api/patients/{hn}/tasks", taskHandler.GetPatientTasks).Methods("GET")
+
+	// Vital signs routes
+	r.HandleFunc("/api/patients/{hn}/vitals", vitalsHandler.RecordVitals).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/vitals", vitalsHandler.GetPatientVitals).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/vitals", vitalsHandler.GetVisitVitals).Methods("GET")
+
+	// Allergies routes
+	r.HandleFunc("/api/patients/{hn}/allergies", allergyHandler.CreateAllergy).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/allergies", allergyHandler.GetPatientAllergies).Methods("GET")
+	r.HandleFunc("/api/allergies/{id}", allergyHandler.GetAllergy).Methods("GET")
+	r.HandleFunc("/api/allergies/{id}", allergyHandler.UpdateAllergy).Methods("PUT")
+	r.HandleFunc("/api/allergies/{id}", allergyHandler.DeleteAllergy).Methods("DELETE")
+
+	// Problem list routes
+	r.HandleFunc("/api/patients/{hn}/problems", problemHandler.CreateProblem).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/problems", problemHandler.GetPatientProblems).Methods("GET")
+	r.HandleFunc("/api/problems/{id}", problemHandler.GetProblem).Methods("GET")
+	r.HandleFunc("/api/problems/{id}", problemHandler.UpdateProblem).Methods("PUT")
+	r.HandleFunc("/api/problems/{id}", problemHandler.DeleteProblem).Methods("DELETE")
+
+	// Emergency contact routes
+	r.HandleFunc("/api/patients/{hn}/emergency-contacts", emergencyContactHandler.CreateEmergencyContact).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/emergency-contacts", emergencyContactHandler.GetPatientEmergencyContacts).Methods("GET")
+	r.HandleFunc("/api/emergency-contacts/{id}", emergencyContactHandler.GetEmergencyContact).Methods("GET")
+	r.HandleFunc("/api/emergency-contacts/{id}", emergencyContactHandler.UpdateEmergencyContact).Methods("PUT")
+	r.HandleFunc("/api/emergency-contacts/{id}", emergencyContactHandler.DeleteEmergencyContact).Methods("DELETE")
+
+	// Address routes
+	r.HandleFunc("/api/patients/{hn}/addresses", addressHandler.CreateAddress).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/addresses", addressHandler.GetPatientAddresses).Methods("GET")
+	r.HandleFunc("/api/addresses/{id}", addressHandler.GetAddress).Methods("GET")
+	r.HandleFunc("/api/addresses/{id}", addressHandler.UpdateAddress).Methods("PUT")
+	r.HandleFunc("/api/addresses/{id}", addressHandler.DeleteAddress).Methods("DELETE")
+
+	// Dental chart routes
+	r.HandleFunc("/api/patients/{hn}/dental-chart", dentalHandler.GetDentalChart).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/dental-chart/findings", dentalHandler.ChartTooth).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/dental-chart/teeth/{tooth}", dentalHandler.GetToothHistory).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/dental-treatments", dentalHandler.CreateDentalTreatment).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/dental-treatments", dentalHandler.GetPatientDentalTreatments).Methods("GET")
+	r.HandleFunc("/api/dental-treatments/{id}/status", dentalHandler.UpdateDentalTreatmentStatus).Methods("PUT")
+
+	// Patient document routes
+	r.HandleFunc("/api/patients/{hn}/documents", documentHandler.UploadDocument).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/documents", documentHandler.GetPatientDocuments).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/documents/{id}", documentHandler.GetDocument).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/documents/{id}/download", documentHandler.DownloadDocument).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/documents/{id}", documentHandler.DeleteDocument).Methods("DELETE")
+	r.HandleFunc("/api/patients/{hn}/consents", consentHandler.CreateConsent).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/consents", consentHandler.GetPatientConsents).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/consents/verify", consentHandler.VerifyConsent).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/consents/{id}", consentHandler.GetConsent).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/consents/{id}/withdraw", consentHandler.WithdrawConsent).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/consents/{id}/signature", consentHandler.UploadConsentSignature).Methods("PUT")
+	r.HandleFunc("/api/patients/{hn}/consents/{id}/signature", consentHandler.GetConsentSignature).Methods("GET")
+
+	// Staff chat routes
+	r.HandleFunc("/api/patients/{hn}/chat-threads", chatHandler.CreateThread).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/chat-threads", chatHandler.GetPatientThreads).Methods("GET")
+	r.HandleFunc("/api/visits/{visitId}/chat-threads", chatHandler.GetVisitThreads).Methods("GET")
+	r.HandleFunc("/api/chat-threads/{id}", chatHandler.GetThread).Methods("GET")
+	r.HandleFunc("/api/chat-threads/{id}/messages", chatHandler.PostMessage).Methods("POST")
+	r.HandleFunc("/api/chat-threads/{id}/read", chatHandler.MarkRead).Methods("PUT")
+	r.HandleFunc("/api/chat/mentions", chatHandler.GetMentions).Methods("GET")
+
+	// Announcements routes
+	r.HandleFunc("/api/announcements", announcementHandler.GetAnnouncements).Methods("GET")
+	r.HandleFunc("/api/announcements/banner", announcementHandler.GetBanner).Methods("GET")
+	r.HandleFunc("/api/announcements/{id}/acknowledge", announcementHandler.AcknowledgeAnnouncement).Methods("POST")
+	r.HandleFunc("/api/admin/announcements", handlers.RequireRole(announcementHandler.CreateAnnouncement, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/announcements", handlers.RequireRole(announcementHandler.GetAllAnnouncements, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/announcements/{id}", handlers.RequireRole(announcementHandler.UpdateAnnouncement, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/announcements/{id}", handlers.RequireRole(announcementHandler.DeleteAnnouncement, reqctx.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/api/admin/announcements/{id}/acknowledgments", handlers.RequireRole(announcementHandler.GetAnnouncementAcknowledgments, reqctx.RoleAdmin)).Methods("GET")
+
+	// Vaccinations routes
+	r.HandleFunc("/api/patients/{hn}/vaccinations", vaccinationHandler.RecordVaccination).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/vaccinations", vaccinationHandler.GetPatientVaccinations).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/vaccinations/due", vaccinationHandler.GetPatientDueDoses).Methods("GET")
+	r.HandleFunc("/api/vaccinations/{id}", vaccinationHandler.DeleteVaccination).Methods("DELETE")
+	r.HandleFunc("/api/vaccinations/overdue", vaccinationHandler.GetOverdueDoses).Methods("GET")
+	r.HandleFunc("/api/vaccinations/schedule", vaccinationHandler.GetSchedule).Methods("GET")
+	r.HandleFunc("/api/vaccine-lots", vaccinationHandler.GetVaccineLots).Methods("GET")
+	r.HandleFunc("/api/vaccine-lots/expiring", vaccinationHandler.GetExpiringVaccineLots).Methods("GET")
+
+	// Referral routes
+	r.HandleFunc("/api/visits/{visitId}/referrals", referralHandler.CreateVisitReferral).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/referrals", referralHandler.GetVisitReferrals).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/referrals", referralHandler.CreatePatientReferral).Methods("POST")
+	r.HandleFunc("/api/patients/{hn}/referrals", referralHandler.GetPatientReferrals).Methods("GET")
+	r.HandleFunc("/api/referrals", referralHandler.GetReferrals).Methods("GET")
+	r.HandleFunc("/api/referrals/{id}", referralHandler.GetReferral).Methods("GET")
+	r.HandleFunc("/api/referrals/{id}/status", referralHandler.UpdateReferralStatus).Methods("PUT")
+	r.HandleFunc("/api/referrals/{id}/documents", referralHandler.AddReferralDocument).Methods("POST")
+	r.HandleFunc("/api/referrals/{id}/documents/{documentId}", referralHandler.RemoveReferralDocument).Methods("DELETE")
+
+	// Follow-up routes
+	r.HandleFunc("/api/visits/{visitId}/follow-ups", followUpHandler.CreateVisitFollowUp).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/follow-ups", followUpHandler.GetVisitFollowUps).Methods("GET")
+	r.HandleFunc("/api/patients/{hn}/follow-ups", followUpHandler.GetPatientFollowUps).Methods("GET")
+	r.HandleFunc("/api/follow-ups/upcoming", followUpHandler.GetUpcomingFollowUps).Methods("GET")
+	r.HandleFunc("/api/follow-ups/overdue", followUpHandler.GetOverdueFollowUps).Methods("GET")
+	r.HandleFunc("/api/follow-ups/{id}", followUpHandler.GetFollowUp).Methods("GET")
+	r.HandleFunc("/api/follow-ups/{id}/contacted", followUpHandler.MarkFollowUpContacted).Methods("POST")
+	r.HandleFunc("/api/follow-ups/{id}/cancel", followUpHandler.CancelFollowUp).Methods("POST")
+
+	// Nursing note routes
+	r.HandleFunc("/api/visits/{visitId}/notes", nursingNoteHandler.CreateVisitNote).Methods("POST")
+	r.HandleFunc("/api/visits/{visitId}/notes", nursingNoteHandler.GetVisitNotes).Methods("GET")
+	r.HandleFunc("/api/nursing-notes/{id}", nursingNoteHandler.GetNursingNote).Methods("GET")
+
+	// Branch routes
+	r.HandleFunc("/api/clinic-time", branchHandler.GetClinicTime).Methods("GET")
+	r.HandleFunc("/api/branches", branchHandler.GetBranches).Methods("GET")
+	r.HandleFunc("/api/branches/{id}", branchHandler.GetBranch).Methods("GET")
+	r.HandleFunc("/api/admin/branches/{id}", handlers.RequireRole(branchHandler.SaveBranch, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/branches/{id}", handlers.RequireRole(branchHandler.DeleteBranch, reqctx.RoleAdmin)).Methods("DELETE")
+
+	// Staff account routes
+	r.HandleFunc("/api/admin/users", handlers.RequireRole(userHandler.GetUsers, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/users", handlers.RequireRole(userHandler.CreateUser, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/users/{id}", handlers.RequireRole(userHandler.GetUser, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/users/{id}", handlers.RequireRole(userHandler.UpdateUser, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/users/{id}/deactivate", handlers.RequireRole(userHandler.DeactivateUser, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/users/{id}/reactivate", handlers.RequireRole(userHandler.ReactivateUser, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/users/{id}/password-reset", handlers.RequireRole(userHandler.ResetUserPassword, reqctx.RoleAdmin)).Methods("POST")
+
+	// API key routes
+	r.HandleFunc("/api/admin/api-keys", handlers.RequireRole(apiKeyHandler.GetAPIKeys, reqctx.RoleAdmin)).Methods("GET")
+	r.HandleFunc("/api/admin/api-keys", handlers.RequireRole(apiKeyHandler.CreateAPIKey, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/api-keys/{id}/revoke", handlers.RequireRole(apiKeyHandler.RevokeAPIKey, reqctx.RoleAdmin)).Methods("POST")
+
+	// Queue routes
+	r.HandleFunc("/api/queue/check-in", queueHandler.CheckIn).Methods("POST")
+	r.HandleFunc("/api/queue", queueHandler.GetQueue).Methods("GET")
+	r.HandleFunc("/api/queue/call-next", queueHandler.CallNext).Methods("POST")
+	r.HandleFunc("/api/queue/{id}", queueHandler.GetQueueEntry).Methods("GET")
+	r.HandleFunc("/api/queue/{id}/status", queueHandler.UpdateQueueStatus).Methods("PUT")
+	r.HandleFunc("/api/queue/{id}/ticket", queueHandler.GetQueueTicket).Methods("GET")
+	r.HandleFunc("/public/queue/{token}", queueHandler.GetPublicQueueStatus).Methods("GET")
+	r.HandleFunc("/api/queue/{id}/triage", triageHandler.TriageQueueEntry).Methods("POST")
+	r.HandleFunc("/api/queue/{id}/triage", triageHandler.GetQueueEntryTriage).Methods("GET")
+
+	// Self check-in kiosk routes
+	r.HandleFunc("/kiosk/lookup", handlers.RequireRole(kioskHandler.LookupKioskPatient, reqctx.RoleKiosk, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/kiosk/check-in", handlers.RequireRole(kioskHandler.CheckInAtKiosk, reqctx.RoleKiosk, reqctx.RoleAdmin)).Methods("POST")
+
+	// Roster routes
+	r.HandleFunc("/api/doctors/{id}/roster", rosterHandler.GetRoster).Methods("GET")
+	r.HandleFunc("/api/admin/doctors/{id}/roster", handlers.RequireRole(rosterHandler.SaveRoster, reqctx.RoleAdmin)).Methods("PUT")
+	r.HandleFunc("/api/admin/doctors/{id}/roster", handlers.RequireRole(rosterHandler.DeleteRoster, reqctx.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/api/doctors/{id}/days-off", rosterHandler.GetDaysOff).Methods("GET")
+	r.HandleFunc("/api/admin/doctors/{id}/days-off", handlers.RequireRole(rosterHandler.AddDayOff, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/admin/doctors/{id}/days-off/{dayOffId}", handlers.RequireRole(rosterHandler.DeleteDayOff, reqctx.RoleAdmin)).Methods("DELETE")
+	r.HandleFunc("/api/admin/doctors/{id}/bulk-reschedule", handlers.RequireRole(bulkRescheduleHandler.BulkReschedule, reqctx.RoleAdmin)).Methods("POST")
+	r.HandleFunc("/api/doctors/{id}/availability", rosterHandler.GetAvailability).Methods("GET")
+
+	// Reminder reply routes
+	r.HandleFunc("/api/appointment-reminders/replies", reminderReplyHandler.ReceiveReply).Methods("POST")
+	r.HandleFunc("/api/reminder-replies", reminderReplyHandler.GetReplies).Methods("GET")
+	r.HandleFunc("/api/reminder-replies/{id}/resolve", reminderReplyHandler.ResolveReply).Methods("PUT")
+
+	addr := ":" + getEnv("PORT", "8080")
+	log.Printf("Starting server on %s", addr)
 	log.Printf("Available endpoints:")
 	log.Printf("  GET    /health")
+	log.Printf("  GET    /api/openapi.json")
+	log.Printf("  GET    /files/{key}")
 	log.Printf("  GET    /api/patients")
 	log.Printf("  GET    /api/patients/{hn}")
 	log.Printf("  POST   /api/patients")
 	log.Printf("  PUT    /api/patients/{hn}")
 	log.Printf("  DELETE /api/patients/{hn}")
+	log.Printf("  GET    /api/patient-rules")
+	log.Printf("  PUT    /api/admin/patient-rules/{field}")
+	log.Printf("  DELETE /api/admin/patient-rules/{field}")
+	log.Printf("  POST   /api/self-registrations")
+	log.Printf("  GET    /api/self-registrations")
+	log.Printf("  GET    /api/self-registrations/{id}")
+	log.Printf("  POST   /api/self-registrations/{id}/verify")
+	log.Printf("  POST   /api/self-registrations/{id}/reject")
+	log.Printf("  GET    /public/self-registration/{token}")
+	log.Printf("  POST   /public/self-registration/{token}")
+	log.Printf("  POST   /api/reconciliation/imports")
+	log.Printf("  GET    /api/reconciliation/imports")
+	log.Printf("  GET    /api/reconciliation/transactions")
+	log.Printf("  GET    /api/reconciliation/transactions/{id}/candidates")
+	log.Printf("  POST   /api/reconciliation/transactions/{id}/match")
+	log.Printf("  GET    /api/reconciliation/report")
+	log.Printf("  GET    /api/inventory/reorder-policies")
+	log.Printf("  PUT    /api/inventory/reorder-policies/{itemId}")
+	log.Printf("  GET    /api/inventory/forecast")
+	log.Printf("  POST   /api/inventory/reorder-suggestions/generate")
+	log.Printf("  GET    /api/inventory/reorder-suggestions")
+	log.Printf("  POST   /api/inventory/reorder-suggestions/{id}/approve")
+	log.Printf("  POST   /api/inventory/reorder-suggestions/{id}/reject")
+	log.Printf("  POST   /api/recalls")
+	log.Printf("  GET    /api/recalls")
+	log.Printf("  GET    /api/recalls/check")
+	log.Printf("  GET    /api/recalls/{id}")
+	log.Printf("  GET    /api/recalls/{id}/patients")
+	log.Printf("  POST   /api/recalls/{id}/notifications")
+	log.Printf("  GET    /api/recalls/{id}/notifications")
+	log.Printf("  POST   /api/cold-chain/fridges")
+	log.Printf("  GET    /api/cold-chain/fridges")
+	log.Printf("  PUT    /api/cold-chain/fridges/{id}/lots")
+	log.Printf("  GET    /api/cold-chain/fridges/{id}/lots")
+	log.Printf("  GET    /api/cold-chain/fridges/{id}/readings")
+	log.Printf("  POST   /api/cold-chain/readings")
+	log.Printf("  GET    /api/cold-chain/excursions")
+	log.Printf("  GET    /api/cold-chain/lot-reviews")
+	log.Printf("  POST   /api/cold-chain/lot-reviews/{id}/decision")
+	log.Printf("  POST   /api/doctors/{id}/signing-keys")
+	log.Printf("  GET    /api/doctors/{id}/signing-keys/active")
+	log.Printf("  POST   /api/prescriptions/{id}/signature")
+	log.Printf("  GET    /public/prescriptions/verify/{code}")
+	log.Printf("  POST   /public/prescriptions/verify/{code}")
+	log.Printf("  POST   /api/patients/{hn}/certificates")
+	log.Printf("  GET    /api/patients/{hn}/certificates")
+	log.Printf("  GET    /api/certificates/{number}")
+	log.Printf("  POST   /api/certificates/{number}/revoke")
+	log.Printf("  GET    /public/certificates/verify")
+	log.Printf("  POST   /api/visits/{visitId}/clinical-notes")
+	log.Printf("  GET    /api/visits/{visitId}/clinical-notes")
+	log.Printf("  GET    /api/visits/{visitId}/cosign-status")
+	log.Printf("  GET    /api/clinical-notes/pending-cosign")
+	log.Printf("  POST   /api/clinical-notes/{id}/cosign")
+	log.Printf("  POST   /api/forms")
+	log.Printf("  GET    /api/forms")
+	log.Printf("  GET    /api/forms/{id}")
+	log.Printf("  PUT    /api/forms/{id}")
+	log.Printf("  GET    /api/forms/{id}/answers")
+	log.Printf("  POST   /api/visits/{visitId}/forms/{formId}")
+	log.Printf("  GET    /api/visits/{visitId}/forms")
+	log.Printf("  GET    /api/visits/{visitId}/forms/{formId}")
+	log.Printf("  POST   /api/patients/{hn}/care-plan/goals")
+	log.Printf("  GET    /api/patients/{hn}/care-plan/goals")
+	log.Printf("  GET    /api/patients/{hn}/care-plan/timeline")
+	log.Printf("  GET    /api/care-plan/goals/{id}")
+	log.Printf("  POST   /api/care-plan/goals/{id}/checkpoints")
+	log.Printf("  PUT    /api/care-plan/goals/{id}/status")
+	log.Printf("  POST   /api/group-sessions")
+	log.Printf("  GET    /api/group-sessions")
+	log.Printf("  GET    /api/group-sessions/{id}")
+	log.Printf("  POST   /api/group-sessions/{id}/cancel")
+	log.Printf("  POST   /api/group-sessions/{id}/bookings")
+	log.Printf("  DELETE /api/group-sessions/{id}/bookings/{bookingId}")
+	log.Printf("  POST   /api/group-sessions/{id}/bookings/{bookingId}/check-in")
+	log.Printf("  POST   /api/campaigns")
+	log.Printf("  GET    /api/campaigns")
+	log.Printf("  GET    /api/campaigns/{id}")
+	log.Printf("  POST   /api/campaigns/{id}/open")
+	log.Printf("  POST   /api/campaigns/{id}/close")
+	log.Printf("  POST   /api/campaigns/{id}/registrations/import")
+	log.Printf("  GET    /api/campaigns/{id}/registrations")
+	log.Printf("  POST   /api/campaigns/{id}/registrations/assign")
+	log.Printf("  GET    /api/campaigns/{id}/dashboard")
+	log.Printf("  GET    /api/patients/{hn}/language")
+	log.Printf("  PUT    /api/patients/{hn}/language")
+	log.Printf("  POST   /api/interpreters")
+	log.Printf("  GET    /api/interpreters")
+	log.Printf("  POST   /api/interpreter-bookings")
+	log.Printf("  POST   /api/interpreter-bookings/{id}/cancel")
+	log.Printf("  GET    /api/interpreter-worklist")
+	log.Printf("  GET    /api/patients/{hn}/accessibility")
+	log.Printf("  PUT    /api/patients/{hn}/accessibility")
+	log.Printf("  GET    /api/accessibility/alerts")
+	log.Printf("  GET    /api/reports/accessibility")
+	log.Printf("  GET    /api/questionnaires/instruments")
+	log.Printf("  POST   /api/patients/{hn}/questionnaires")
+	log.Printf("  GET    /api/patients/{hn}/questionnaires")
+	log.Printf("  GET    /api/visits/{visitId}/questionnaires")
+	log.Printf("  GET    /api/questionnaire-alerts")
+	log.Printf("  POST   /api/questionnaire-alerts/{id}/acknowledge")
+	log.Printf("  GET    /public/questionnaires/{token}")
+	log.Printf("  POST   /public/questionnaires/{token}")
+	log.Printf("  PUT    /api/visits/{visitId}/note-draft")
+	log.Printf("  GET    /api/visits/{visitId}/note-draft")
+	log.Printf("  GET    /api/note-drafts")
+	log.Printf("  GET    /api/note-drafts/{id}")
+	log.Printf("  DELETE /api/note-drafts/{id}")
+	log.Printf("  POST   /api/note-drafts/{id}/finalize")
+	log.Printf("  POST   /api/clinical-notes/{id}/amendments")
+	log.Printf("  GET    /api/clinical-notes/{id}/versions")
+	log.Printf("  GET    /api/clinical-notes/{id}/diff")
+	log.Printf("  GET    /api/icd10")
+	log.Printf("  GET    /api/coding/suggestions")
+	log.Printf("  POST   /api/visits/{visitId}/diagnosis-codes")
+	log.Printf("  GET    /api/visits/{visitId}/diagnosis-codes")
+	log.Printf("  DELETE /api/visits/{visitId}/diagnosis-codes/{id}")
+	log.Printf("  POST   /api/doctors/{doctorId}/drug-favorites")
+	log.Printf("  GET    /api/doctors/{doctorId}/drug-favorites")
+	log.Printf("  POST   /api/doctors/{doctorId}/drug-favorites/{id}/use")
+	log.Printf("  DELETE /api/doctors/{doctorId}/drug-favorites/{id}")
+	log.Printf("  POST   /api/doctors/{doctorId}/prescription-sets")
+	log.Printf("  GET    /api/doctors/{doctorId}/prescription-sets")
+	log.Printf("  GET    /api/doctors/{doctorId}/prescription-sets/{id}")
+	log.Printf("  PUT    /api/doctors/{doctorId}/prescription-sets/{id}")
+	log.Printf("  POST   /api/doctors/{doctorId}/prescription-sets/{id}/use")
+	log.Printf("  DELETE /api/doctors/{doctorId}/prescription-sets/{id}")
+	log.Printf("  GET    /api/admin/maintenance")
+	log.Printf("  PUT    /api/admin/maintenance")
+	log.Printf("  GET    /api/admin/coordination")
+	log.Printf("  POST   /api/appointments")
+	log.Printf("  GET    /api/appointments")
+	log.Printf("  GET    /api/appointments/{id}")
+	log.Printf("  PUT    /api/appointments/{id}/reschedule")
+	log.Printf("  POST   /api/appointments/{id}/cancel")
+	log.Printf("  PUT    /api/appointments/{id}/status")
+	log.Printf("  PUT    /api/appointments/{id}/confirmation")
+	log.Printf("  GET    /api/appointments/{id}/reminders")
+	log.Printf("  GET    /api/appointments/{id}/delivery-log")
+	log.Printf("  GET    /api/appointment-reminders")
+	log.Printf("  PUT    /api/appointment-reminders/{id}/status")
+	log.Printf("  GET    /api/appointment-reminders/{id}/failures")
+	log.Printf("  GET    /api/admin/dead-letters")
+	log.Printf("  POST   /api/admin/dead-letters/{id}/requeue")
+	log.Printf("  GET    /api/admin/notification-health")
+	log.Printf("  GET    /api/patients/{hn}/line")
+	log.Printf("  PUT    /api/patients/{hn}/line")
+	log.Printf("  DELETE /api/patients/{hn}/line")
+	log.Printf("  GET    /api/line-messages")
+	log.Printf("  POST   /api/admin/line-messages/{id}/requeue")
+	log.Printf("  GET    /api/emails")
+	log.Printf("  POST   /api/admin/emails/{id}/requeue")
+	log.Printf("  POST   /api/appointments/{id}/intake")
+	log.Printf("  GET    /api/appointments/{id}/intake")
+	log.Printf("  GET    /api/visits/{visitId}/intake")
+	log.Printf("  GET    /public/intake/{token}")
+	log.Printf("  POST   /public/intake/{token}")
+	log.Printf("  GET    /api/patients/{hn}/appointments")
+	log.Printf("  GET    /api/appointment-overrides")
+	log.Printf("  GET    /api/doctors")
+	log.Printf("  POST   /api/doctors")
+	log.Printf("  GET    /api/doctors/{id}")
+	log.Printf("  PUT    /api/doctors/{id}")
+	log.Printf("  DELETE /api/doctors/{id}")
+	log.Printf("  GET    /api/admin/mock/faults")
+	log.Printf("  PUT    /api/admin/mock/faults")
+	log.Printf("  DELETE /api/admin/mock/faults")
+	log.Printf("  POST   /api/patients/{hn}/visits")
+	log.Printf("  GET    /api/patients/{hn}/visits")
+	log.Printf("  GET    /api/visits/{id}")
+	log.Printf("  PUT    /api/visits/{id}")
+	log.Printf("  POST   /api/visits/{id}/close")
+	log.Printf("  POST   /api/visits/{id}/cancel")
+	log.Printf("  PUT    /api/visits/{id}/soap")
+	log.Printf("  GET    /api/visits/{id}/soap/versions")
+	log.Printf("  GET    /api/visits/{id}/soap/diff")
+	log.Printf("  POST   /api/visits/{visitId}/prescriptions")
+	log.Printf("  GET    /api/visits/{visitId}/prescriptions")
+	log.Printf("  GET    /api/patients/{hn}/prescriptions")
+	log.Printf("  GET    /api/prescriptions/{id}")
+	log.Printf("  GET    /api/prescriptions/{id}/print")
+	log.Printf("  GET    /api/dispensing")
+	log.Printf("  POST   /api/prescriptions/{id}/dispense")
+	log.Printf("  GET    /api/visits/{visitId}/summary")
+	log.Printf("  POST   /api/visits/{visitId}/summary-links")
+	log.Printf("  GET    /api/visits/{visitId}/summary-links")
+	log.Printf("  GET    /api/summary-links/{id}/accesses")
+	log.Printf("  POST   /api/summary-links/{id}/revoke")
+	log.Printf("  GET    /public/visit-summary/{token}")
+	log.Printf("  POST   /api/appointments/{id}/teleconsult")
+	log.Printf("  GET    /api/teleconsults")
+	log.Printf("  GET    /api/teleconsults/{id}")
+	log.Printf("  POST   /api/teleconsults/{id}/cancel")
+	log.Printf("  POST   /api/teleconsults/{id}/end")
+	log.Printf("  GET    /public/teleconsult/{token}")
+	log.Printf("  POST   /public/teleconsult/{token}/join")
+	log.Printf("  POST   /public/teleconsult/{token}/leave")
+	log.Printf("  POST   /api/online-slots")
+	log.Printf("  GET    /api/online-slots")
+	log.Printf("  DELETE /api/online-slots/{id}")
+	log.Printf("  POST   /portal/sign-in")
+	log.Printf("  POST   /portal/sign-in/verify")
+	log.Printf("  POST   /portal/sign-out")
+	log.Printf("  GET    /portal/me")
+	log.Printf("  GET    /portal/appointments")
+	log.Printf("  POST   /portal/appointments")
+	log.Printf("  GET    /portal/slots")
+	log.Printf("  GET    /portal/visits")
+	log.Printf("  GET    /portal/visits/{visitId}/summary")
+	log.Printf("  GET    /portal/invoices")
+	log.Printf("  GET    /api/drugs")
+	log.Printf("  POST   /api/drugs")
+	log.Printf("  GET    /api/drugs/{id}")
+	log.Printf("  PUT    /api/drugs/{id}")
+	log.Printf("  DELETE /api/drugs/{id}")
+	log.Printf("  PUT    /api/drugs/{id}/image")
+	log.Printf("  DELETE /api/drugs/{id}/image")
+	log.Printf("  PUT    /api/drugs/{id}/leaflet")
+	log.Printf("  DELETE /api/drugs/{id}/leaflet")
+	log.Printf("  GET    /api/interaction-rules")
+	log.Printf("  POST   /api/admin/interaction-rules")
+	log.Printf("  PUT    /api/admin/interaction-rules/{id}")
+	log.Printf("  DELETE /api/admin/interaction-rules/{id}")
+	log.Printf("  POST   /api/patients/{hn}/interaction-check")
+	log.Printf("  GET    /api/services")
+	log.Printf("  POST   /api/services")
+	log.Printf("  GET    /api/services/{id}")
+	log.Printf("  PUT    /api/services/{id}")
+	log.Printf("  DELETE /api/services/{id}")
+	log.Printf("  GET    /api/services/{id}/eligibility")
+	log.Printf("  POST   /api/visits/{visitId}/services")
+	log.Printf("  GET    /api/visits/{visitId}/services")
+	log.Printf("  DELETE /api/visits/{visitId}/services/{id}")
+	log.Printf("  GET    /api/packages")
+	log.Printf("  POST   /api/packages")
+	log.Printf("  GET    /api/packages/{id}")
+	log.Printf("  PUT    /api/packages/{id}")
+	log.Printf("  DELETE /api/packages/{id}")
+	log.Printf("  POST   /api/patients/{hn}/packages")
+	log.Printf("  GET    /api/patients/{hn}/packages")
+	log.Printf("  GET    /api/patient-packages/{id}")
+	log.Printf("  POST   /api/patient-packages/{id}/cancel")
+	log.Printf("  POST   /api/visits/{visitId}/package-sessions")
+	log.Printf("  GET    /api/visits/{visitId}/package-sessions")
+	log.Printf("  DELETE /api/visits/{visitId}/package-sessions/{id}")
+	log.Printf("  GET    /api/admin/profiling")
+	log.Printf("  PUT    /api/admin/profiling/handlers")
+	log.Printf("  DELETE /api/admin/profiling/handlers")
+	log.Printf("  PUT    /api/admin/profiling/runtime")
+	log.Printf("  GET    /api/admin/debug/pprof/")
+	log.Printf("  GET    /api/inventory/items")
+	log.Printf("  POST   /api/inventory/items")
+	log.Printf("  GET    /api/inventory/items/{id}")
+	log.Printf("  PUT    /api/inventory/items/{id}")
+	log.Printf("  GET    /api/inventory/items/{id}/stock")
+	log.Printf("  POST   /api/inventory/items/{id}/stock-in")
+	log.Printf("  POST   /api/inventory/items/{id}/dispense")
+	log.Printf("  POST   /api/inventory/items/{id}/adjustments")
+	log.Printf("  GET    /api/inventory/items/{id}/movements")
+	log.Printf("  GET    /api/inventory/stock")
+	log.Printf("  GET    /api/inventory/movements")
+	log.Printf("  POST   /api/visits/{visitId}/invoice")
+	log.Printf("  GET    /api/invoices")
+	log.Printf("  POST   /api/invoices")
+	log.Printf("  GET    /api/invoices/{id}")
+	log.Printf("  PUT    /api/invoices/{id}")
+	log.Printf("  DELETE /api/invoices/{id}")
+	log.Printf("  PUT    /api/invoices/{id}/status")
+	log.Printf("  POST   /api/invoices/{id}/payments")
+	log.Printf("  GET    /api/invoices/{id}/payments")
+	log.Printf("  GET    /api/patients/{hn}/invoices")
+	log.Printf("  POST   /api/admin/patient-merges")
+	log.Printf("  GET    /api/admin/patient-merges")
+	log.Printf("  GET    /api/admin/patient-merges/{id}")
+	log.Printf("  POST   /api/admin/patient-merges/{id}/undo")
+	log.Printf("  GET    /api/appointment-display")
+	log.Printf("  PUT    /api/admin/appointment-display/{scope}/{key}")
+	log.Printf("  DELETE /api/admin/appointment-display/{scope}/{key}")
+	log.Printf("  GET    /api/cancellation-reasons")
+	log.Printf("  PUT    /api/admin/cancellation-reasons/{code}")
+	log.Printf("  GET    /api/reports/cancellations")
+	log.Printf("  GET    /api/reports/booking-channels")
+	log.Printf("  POST   /api/patients/{hn}/insurance-policies")
+	log.Printf("  GET    /api/patients/{hn}/insurance-policies")
+	log.Printf("  PUT    /api/insurance-policies/{id}")
+	log.Printf("  DELETE /api/insurance-policies/{id}")
+	log.Printf("  POST   /api/invoices/{id}/claims")
+	log.Printf("  GET    /api/claims")
+	log.Printf("  GET    /api/claims/summary")
+	log.Printf("  GET    /api/claims/{id}")
+	log.Printf("  PUT    /api/claims/{id}/status")
+	log.Printf("  GET    /api/reports/doctor-productivity")
+	log.Printf("  GET    /api/calendar")
+	log.Printf("  POST   /api/handover/{department}")
+	log.Printf("  GET    /api/handover/{department}")
+	log.Printf("  PUT    /api/handover-notes/{id}/resolve")
+	log.Printf("  POST   /api/tasks")
+	log.Printf("  GET    /api/tasks")
+	log.Printf("  GET    /api/tasks/mine")
+	log.Printf("  GET    /api/tasks/overdue")
+	log.Printf("  GET    /api/tasks/{id}")
+	log.Printf("  PUT    /api/tasks/{id}")
+	log.Printf("  PUT    /api/tasks/{id}/status")
+	log.Printf("  GET    /api/patients/{hn}/tasks")
+	log.Printf("  POST   /api/patients/{hn}/vitals")
+	log.Printf("  GET    /api/patients/{hn}/vitals")
+	log.Printf("  GET    /api/visits/{visitId}/vitals")
+	log.Printf("  POST   /api/patients/{hn}/allergies")
+	log.Printf("  GET    /api/patients/{hn}/allergies")
+	log.Printf("  GET    /api/allergies/{id}")
+	log.Printf("  PUT    /api/allergies/{id}")
+	log.Printf("  DELETE /api/allergies/{id}")
+	log.Printf("  POST   /api/patients/{hn}/problems")
+	log.Printf("  GET    /api/patients/{hn}/problems")
+	log.Printf("  GET    /api/problems/{id}")
+	log.Printf("  PUT    /api/problems/{id}")
+	log.Printf("  DELETE /api/problems/{id}")
+	log.Printf("  POST   /api/patients/{hn}/emergency-contacts")
+	log.Printf("  GET    /api/patients/{hn}/emergency-contacts")
+	log.Printf("  GET    /api/emergency-contacts/{id}")
+	log.Printf("  PUT    /api/emergency-contacts/{id}")
+	log.Printf("  DELETE /api/emergency-contacts/{id}")
+	log.Printf("  POST   /api/patients/{hn}/addresses")
+	log.Printf("  GET    /api/patients/{hn}/addresses")
+	log.Printf("  GET    /api/addresses/{id}")
+	log.Printf("  PUT    /api/addresses/{id}")
+	log.Printf("  DELETE /api/addresses/{id}")
+	log.Printf("  GET    /api/patients/{hn}/dental-chart")
+	log.Printf("  POST   /api/patients/{hn}/dental-chart/findings")
+	log.Printf("  GET    /api/patients/{hn}/dental-chart/teeth/{tooth}")
+	log.Printf("  POST   /api/patients/{hn}/dental-treatments")
+	log.Printf("  GET    /api/patients/{hn}/dental-treatments")
+	log.Printf("  PUT    /api/dental-treatments/{id}/status")
+	log.Printf("  POST   /api/patients/{hn}/documents")
+	log.Printf("  GET    /api/patients/{hn}/documents")
+	log.Printf("  GET    /api/patients/{hn}/documents/{id}")
+	log.Printf("  GET    /api/patients/{hn}/documents/{id}/download")
+	log.Printf("  DELETE /api/patients/{hn}/documents/{id}")
+	log.Printf("  POST   /api/patients/{hn}/consents")
+	log.Printf("  GET    /api/patients/{hn}/consents")
+	log.Printf("  GET    /api/patients/{hn}/consents/verify")
+	log.Printf("  GET    /api/patients/{hn}/consents/{id}")
+	log.Printf("  POST   /api/patients/{hn}/consents/{id}/withdraw")
+	log.Printf("  PUT    /api/patients/{hn}/consents/{id}/signature")
+	log.Printf("  GET    /api/patients/{hn}/consents/{id}/signature")
+	log.Printf("  POST   /api/patients/{hn}/chat-threads")
+	log.Printf("  GET    /api/patients/{hn}/chat-threads")
+	log.Printf("  GET    /api/visits/{visitId}/chat-threads")
+	log.Printf("  GET    /api/chat-threads/{id}")
+	log.Printf("  POST   /api/chat-threads/{id}/messages")
+	log.Printf("  PUT    /api/chat-threads/{id}/read")
+	log.Printf("  GET    /api/chat/mentions")
+	log.Printf("  GET    /api/announcements")
+	log.Printf("  GET    /api/announcements/banner")
+	log.Printf("  POST   /api/announcements/{id}/acknowledge")
+	log.Printf("  POST   /api/admin/announcements")
+	log.Printf("  GET    /api/admin/announcements")
+	log.Printf("  PUT    /api/admin/announcements/{id}")
+	log.Printf("  DELETE /api/admin/announcements/{id}")
+	log.Printf("  GET    /api/admin/announcements/{id}/acknowledgments")
+	log.Printf("  POST   /api/patients/{hn}/vaccinations")
+	log.Printf("  GET    /api/patients/{hn}/vaccinations")
+	log.Printf("  GET    /api/patients/{hn}/vaccinations/due")
+	log.Printf("  DELETE /api/vaccinations/{id}")
+	log.Printf("  GET    /api/vaccinations/overdue")
+	log.Printf("  GET    /api/vaccinations/schedule")
+	log.Printf("  GET    /api/vaccine-lots")
+	log.Printf("  GET    /api/vaccine-lots/expiring")
+	log.Printf("  POST   /api/visits/{visitId}/referrals")
+	log.Printf("  GET    /api/visits/{visitId}/referrals")
+	log.Printf("  POST   /api/patients/{hn}/referrals")
+	log.Printf("  GET    /api/patients/{hn}/referrals")
+	log.Printf("  GET    /api/referrals")
+	log.Printf("  GET    /api/referrals/{id}")
+	log.Printf("  PUT    /api/referrals/{id}/status")
+	log.Printf("  POST   /api/referrals/{id}/documents")
+	log.Printf("  DELETE /api/referrals/{id}/documents/{documentId}")
+	log.Printf("  POST   /api/visits/{visitId}/follow-ups")
+	log.Printf("  GET    /api/visits/{visitId}/follow-ups")
+	log.Printf("  GET    /api/patients/{hn}/follow-ups")
+	log.Printf("  GET    /api/follow-ups/upcoming")
+	log.Printf("  GET    /api/follow-ups/overdue")
+	log.Printf("  GET    /api/follow-ups/{id}")
+	log.Printf("  POST   /api/follow-ups/{id}/contacted")
+	log.Printf("  POST   /api/follow-ups/{id}/cancel")
+	log.Printf("  POST   /api/visits/{visitId}/notes")
+	log.Printf("  GET    /api/visits/{visitId}/notes")
+	log.Printf("  GET    /api/nursing-notes/{id}")
+	log.Printf("  GET    /api/clinic-time")
+	log.Printf("  GET    /api/branches")
+	log.Printf("  GET    /api/branches/{id}")
+	log.Printf("  PUT    /api/admin/branches/{id}")
+	log.Printf("  DELETE /api/admin/branches/{id}")
+	log.Printf("  GET    /api/admin/users")
+	log.Printf("  POST   /api/admin/users")
+	log.Printf("  GET    /api/admin/users/{id}")
+	log.Printf("  PUT    /api/admin/users/{id}")
+	log.Printf("  POST   /api/admin/users/{id}/deactivate")
+	log.Printf("  POST   /api/admin/users/{id}/reactivate")
+	log.Printf("  POST   /api/admin/users/{id}/password-reset")
+	log.Printf("  GET    /api/admin/api-keys")
+	log.Printf("  POST   /api/admin/api-keys")
+	log.Printf("  POST   /api/admin/api-keys/{id}/revoke")
+	log.Printf("  POST   /api/queue/check-in")
+	log.Printf("  GET    /api/queue")
+	log.Printf("  POST   /api/queue/call-next")
+	log.Printf("  GET    /api/queue/{id}")
+	log.Printf("  PUT    /api/queue/{id}/status")
+	log.Printf("  GET    /api/queue/{id}/ticket")
+	log.Printf("  GET    /public/queue/{token}")
+	log.Printf("  POST   /api/queue/{id}/triage")
+	log.Printf("  GET    /api/queue/{id}/triage")
+	log.Printf("  POST   /kiosk/lookup")
+	log.Printf("  POST   /kiosk/check-in")
+	log.Printf("  GET    /api/doctors/{id}/roster")
+	log.Printf("  PUT    /api/admin/doctors/{id}/roster")
+	log.Printf("  DELETE /api/admin/doctors/{id}/roster")
+	log.Printf("  GET    /api/doctors/{id}/days-off")
+	log.Printf("  POST   /api/admin/doctors/{id}/days-off")
+	log.Printf("  DELETE /api/admin/doctors/{id}/days-off/{dayOffId}")
+	log.Printf("  POST   /api/admin/doctors/{id}/bulk-reschedule")
+	log.Printf("  GET    /api/doctors/{id}/availability")
+	log.Printf("  POST   /api/appointment-reminders/replies")
+	log.Printf("  GET    /api/reminder-replies")
+	log.Printf("  PUT    /api/reminder-replies/{id}/resolve")
 
-	if err := http.ListenAndServe(":8080", r); err != nil {
+	// Profiling toggles may only name registered routes
+	if err := profilingHandler.LearnRoutes(r); err != nil {
+		log.Fatalf("Failed to index routes for profiling: %v", err)
+	}
+
+	if err := http.ListenAndServe(addr, r); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -48,7 +1589,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Tenant-ID, X-Branch-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -58,3 +1599,35 @@ func corsMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// getEnv returns the environment variable or a fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// newSigner loads the e-signature master key from ESIGN_MASTER_KEY (base64, 32 bytes).
+// Without it a random key is used and sealed signing keys do not survive a restart.
+func newSigner() *esign.Signer {
+	masterKey := make([]byte, 32)
+	if encoded := os.Getenv("ESIGN_MASTER_KEY"); encoded != "" {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			log.Fatalf("invalid ESIGN_MASTER_KEY: %v", err)
+		}
+		masterKey = decoded
+	} else {
+		log.Printf("ESIGN_MASTER_KEY not set, using a temporary signing master key")
+		if _, err := rand.Read(masterKey); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	signer, err := esign.NewSigner(masterKey)
+	if err != nil {
+		log.Fatalf("invalid ESIGN_MASTER_KEY: %v", err)
+	}
+	return signer
+}